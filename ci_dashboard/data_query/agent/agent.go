@@ -0,0 +1,435 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/runner"
+)
+
+// DefaultVersion is the runner version the agent reports. The broker
+// refuses runners it considers too old.
+const DefaultVersion = "2.328.0"
+
+// renewInterval is how often the lock on a running job is renewed; the
+// service releases jobs whose lock lapses for ten minutes.
+const renewInterval = time.Minute
+
+// Agent takes jobs for a registered runner and runs them.
+type Agent struct {
+	Config *Config
+	// Options configure the runner of each job. The job request sets
+	// the event, contexts and secrets. Workspace defaults to
+	// WorkFolder/<repo>/<repo>, as on GitHub's runners.
+	Options runner.Options
+	// Version is the runner version to report. Defaults to
+	// DefaultVersion.
+	Version string
+	// HTTPClient defaults to http.DefaultClient. It must not time out
+	// long polls, which last about a minute.
+	HTTPClient *http.Client
+	// Logf reports what the agent does. Defaults to discarding.
+	Logf func(format string, args ...any)
+
+	drainOnce sync.Once
+	drain     chan struct{}
+	mu        sync.Mutex
+}
+
+// Drain stops the agent taking jobs. Run returns once the job it is
+// running, if any, completes.
+func (a *Agent) Drain() {
+	a.init()
+	a.drainOnce.Do(func() { close(a.drain) })
+}
+
+func (a *Agent) init() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.drain == nil {
+		a.drain = make(chan struct{})
+	}
+}
+
+func (a *Agent) logf(format string, args ...any) {
+	if a.Logf != nil {
+		a.Logf(format, args...)
+	}
+}
+
+// runningJob is the job the agent is running.
+type runningJob struct {
+	id     string
+	cancel context.CancelFunc
+}
+
+// Run opens a session and runs the jobs offered to the runner, one at a
+// time, until Drain is called, an ephemeral runner's job completes or
+// ctx is done. Cancelling ctx cancels the running job, which is reported
+// as cancelled.
+func (a *Agent) Run(ctx context.Context) error {
+	a.init()
+	cfg := a.Config
+	if !cfg.UseV2Flow || cfg.BrokerURL == "" {
+		return fmt.Errorf("runner %s is not registered for the broker protocol; register it again with a current runner or as a just-in-time runner", cfg.AgentName)
+	}
+	version := a.Version
+	if version == "" {
+		version = DefaultVersion
+	}
+	hc := a.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	b := &broker{url: cfg.BrokerURL, tokens: &tokenSource{cfg: cfg, client: hc, now: time.Now}, client: hc, version: version}
+
+	sess, err := a.createSession(ctx, b)
+	if err != nil {
+		return err
+	}
+	a.logf("Listening for jobs as %s", cfg.AgentName)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if err := b.deleteSession(ctx); err != nil {
+			a.logf("Failed to delete the session: %v", err)
+		}
+	}()
+
+	pollCtx, stopPoll := context.WithCancel(ctx)
+	defer stopPoll()
+	var status atomic.Value
+	status.Store(statusOnline)
+	messages := make(chan *message)
+	go a.poll(pollCtx, b, sess.SessionID, &status, messages)
+
+	var current *runningJob
+	done := make(chan error, 1)
+	draining := false
+	drain := a.drain
+	for {
+		select {
+		case <-ctx.Done():
+			if current != nil {
+				// The job sees the cancellation and completes.
+				<-done
+			}
+			return nil
+		case <-drain:
+			drain, draining = nil, true
+			if current == nil {
+				a.logf("Drained")
+				return nil
+			}
+			a.logf("Draining: waiting for job %s to complete", current.id)
+		case err := <-done:
+			current = nil
+			if err != nil {
+				a.logf("%v", err)
+			}
+			if draining {
+				a.logf("Drained")
+				return nil
+			}
+			if cfg.Ephemeral {
+				return nil
+			}
+			status.Store(statusOnline)
+		case m := <-messages:
+			switch m.MessageType {
+			case messageJobRequest:
+				var ref jobRequestRef
+				if err := json.Unmarshal([]byte(m.Body), &ref); err != nil {
+					a.logf("Invalid job request: %v", err)
+					continue
+				}
+				if current != nil || draining {
+					// The broker offers one job at a time; another goes to
+					// another runner once this one does not take it.
+					a.logf("Not taking job request %s while busy", ref.RunnerRequestID)
+					continue
+				}
+				job, err := a.acquire(ctx, b, sess.SessionID, &ref)
+				if err != nil {
+					a.logf("Failed to acquire job request %s: %v", ref.RunnerRequestID, err)
+					continue
+				}
+				jobCtx, cancel := context.WithCancel(ctx)
+				current = &runningJob{id: job.JobID, cancel: cancel}
+				status.Store(statusBusy)
+				go func() {
+					defer cancel()
+					done <- a.runJob(jobCtx, b, &ref, job)
+				}()
+			case messageJobCancellation:
+				var body struct {
+					JobID string `json:"jobId"`
+				}
+				if json.Unmarshal([]byte(m.Body), &body) == nil && current != nil && strings.EqualFold(body.JobID, current.id) {
+					a.logf("Job %s was cancelled", current.id)
+					current.cancel()
+				}
+			default:
+				a.logf("Ignoring %s message", m.MessageType)
+			}
+		}
+	}
+}
+
+// createSession opens the runner's session, waiting out one another
+// process still holds.
+func (a *Agent) createSession(ctx context.Context, b *broker) (*session, error) {
+	host, _ := os.Hostname()
+	s := &session{
+		OwnerName: host,
+		Agent:     agentRef{ID: a.Config.AgentID, Name: a.Config.AgentName, Version: b.version, OSDescription: runnerOS() + " " + runnerArch(), Ephemeral: a.Config.Ephemeral},
+	}
+	for {
+		created, err := b.createSession(ctx, s)
+		var e *Error
+		if err == nil || !errors.As(err, &e) || e.StatusCode != http.StatusConflict {
+			if err != nil {
+				return nil, fmt.Errorf("failed to create a session: %w", err)
+			}
+			return created, nil
+		}
+		a.logf("A session for %s already exists; retrying in 30s", a.Config.AgentName)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(30 * time.Second):
+		}
+	}
+}
+
+// poll long-polls the queue and passes on messages until ctx is done,
+// backing off while the broker fails. Each poll reports the runner's
+// status, so polling is also its heartbeat.
+func (a *Agent) poll(ctx context.Context, b *broker, sessionID string, status *atomic.Value, out chan<- *message) {
+	backoff := time.Duration(0)
+	for ctx.Err() == nil {
+		m, err := b.getMessage(ctx, sessionID, status.Load().(string))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = min(max(2*backoff, 5*time.Second), time.Minute)
+			a.logf("Failed to get messages: %v; retrying in %s", err, backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+		if m == nil {
+			continue
+		}
+		if m.MessageType == messageBrokerMigration {
+			var body struct {
+				BrokerBaseURL string `json:"brokerBaseUrl"`
+			}
+			if json.Unmarshal([]byte(m.Body), &body) == nil && body.BrokerBaseURL != "" {
+				b.setURL(body.BrokerBaseURL)
+			}
+			continue
+		}
+		select {
+		case out <- m:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// acquire acknowledges a job request and fetches its job.
+func (a *Agent) acquire(ctx context.Context, b *broker, sessionID string, ref *jobRequestRef) (*JobRequest, error) {
+	if err := b.acknowledge(ctx, sessionID, ref.RunnerRequestID); err != nil {
+		return nil, err
+	}
+	return b.acquireJob(ctx, ref)
+}
+
+// runJob runs a job while renewing the lock on it, and reports its result.
+// The job is cancelled if the lock cannot be renewed.
+func (a *Agent) runJob(ctx context.Context, b *broker, ref *jobRequestRef, req *JobRequest) error {
+	name := req.JobDisplayName
+	if _, err := b.renewJob(ctx, ref, req.Plan.PlanID, req.JobID); err != nil {
+		return fmt.Errorf("failed to lock job %s: %w", name, err)
+	}
+	a.logf("Running job %s", name)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		tick := time.NewTicker(renewInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			if _, err := b.renewJob(ctx, ref, req.Plan.PlanID, req.JobID); err != nil && ctx.Err() == nil {
+				var e *Error
+				if errors.As(err, &e) && e.StatusCode == http.StatusNotFound {
+					// The service gave up on the job.
+					a.logf("Job %s is no longer assigned to the runner; cancelling it", name)
+					cancel()
+					return
+				}
+				a.logf("Failed to renew the lock on job %s: %v", name, err)
+			}
+		}
+	}()
+
+	c := &completion{PlanID: req.Plan.PlanID, JobID: req.JobID, Conclusion: "failed", Outputs: map[string]variableValue{}, BillingOwnerID: ref.BillingOwnerID}
+	if err := a.execute(ctx, req, c); err != nil {
+		a.logf("Job %s failed: %v", name, err)
+	}
+	if ctx.Err() != nil && c.Conclusion == "failed" {
+		c.Conclusion = "canceled"
+	}
+	a.logf("Job %s completed: %s", name, c.Conclusion)
+
+	// The result is reported even when the agent is stopping.
+	reportCtx, stop := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Minute)
+	defer stop()
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = b.completeJob(reportCtx, ref, c); err == nil {
+			return nil
+		}
+		select {
+		case <-reportCtx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return fmt.Errorf("failed to report the result of job %s: %w", name, err)
+}
+
+// execute runs req with the local runner and records its result in c.
+func (a *Agent) execute(ctx context.Context, req *JobRequest, c *completion) error {
+	wf, err := req.Workflow()
+	if err != nil {
+		return err
+	}
+	opts, err := req.Options(a.Options)
+	if err != nil {
+		return err
+	}
+	if opts.Workspace == "" {
+		repo := "workspace"
+		if github, ok := opts.Contexts["github"].(map[string]any); ok && expr.ToString(github["repository"]) != "" {
+			repo = path.Base(expr.ToString(github["repository"]))
+		}
+		work := a.Config.WorkFolder
+		if work == "" {
+			work = "_work"
+		}
+		opts.Workspace = filepath.Join(work, repo, repo)
+	}
+	if err := os.MkdirAll(opts.Workspace, 0o755); err != nil {
+		return err
+	}
+	times := &stepTimes{started: map[string]time.Time{}, finished: map[string]time.Time{}}
+	progress := opts.Progress
+	opts.Progress = func(e runner.ProgressEvent) {
+		times.record(e)
+		if progress != nil {
+			progress(e)
+		}
+	}
+	result, err := runner.New(opts).Run(ctx, wf)
+	if err != nil {
+		return err
+	}
+	if len(result.Jobs) == 0 {
+		return fmt.Errorf("the job did not run")
+	}
+	jr := result.Jobs[0]
+	c.Conclusion = conclusion(jr.Result)
+	for k, v := range jr.Outputs {
+		c.Outputs[k] = variableValue{Value: v}
+	}
+	if len(jr.Legs) == 0 {
+		return nil
+	}
+	leg := jr.Legs[0]
+	c.EnvironmentURL = leg.EnvironmentURL
+	for i, step := range req.Steps {
+		sr := mainStep(leg.Steps, step.ContextName)
+		if sr == nil {
+			continue
+		}
+		c.StepResults = append(c.StepResults, stepResult{
+			ExternalID:  step.ID,
+			Number:      i + 1,
+			Name:        sr.Name,
+			Status:      "completed",
+			Conclusion:  sr.Conclusion,
+			StartedAt:   times.started[sr.ID],
+			CompletedAt: times.finished[sr.ID],
+		})
+	}
+	return nil
+}
+
+// mainStep returns the result of the main entry point of the step id,
+// which shares its ID with the step's pre and post entry points.
+func mainStep(results []*runner.StepResult, id string) *runner.StepResult {
+	for _, sr := range results {
+		if sr.ID == id && !isHook(sr.Name) {
+			return sr
+		}
+	}
+	return nil
+}
+
+func isHook(name string) bool {
+	return strings.HasPrefix(name, "Pre ") || strings.HasPrefix(name, "Post ")
+}
+
+// stepTimes records when the job's steps started and finished.
+type stepTimes struct {
+	mu                sync.Mutex
+	started, finished map[string]time.Time
+}
+
+func (t *stepTimes) record(e runner.ProgressEvent) {
+	if e.StepID == "" || e.Parent != "" || isHook(e.Step) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e.Result == "" {
+		t.started[e.StepID] = e.Time
+	} else {
+		t.finished[e.StepID] = e.Time
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error is a failed request to the broker or run service.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// broker speaks to the broker, which holds the runner's session and
+// message queue, and to the run services that hand out its jobs.
+type broker struct {
+	tokens  *tokenSource
+	client  *http.Client
+	version string
+
+	mu  sync.Mutex
+	url string
+}
+
+// setURL moves the runner to another broker, as a BrokerMigration message
+// asks.
+func (b *broker) setURL(u string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.url = u
+}
+
+// session is a runner's TaskAgentSession.
+type session struct {
+	SessionID         string   `json:"sessionId,omitempty"`
+	OwnerName         string   `json:"ownerName"`
+	Agent             agentRef `json:"agent"`
+	UseFipsEncryption bool     `json:"useFipsEncryption"`
+}
+
+type agentRef struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	OSDescription string `json:"osDescription"`
+	Ephemeral     bool   `json:"ephemeral,omitempty"`
+	Status        string `json:"status,omitempty"`
+}
+
+// message is a TaskAgentMessage from the queue.
+type message struct {
+	MessageID   int64  `json:"messageId"`
+	MessageType string `json:"messageType"`
+	IV          string `json:"iv"`
+	Body        string `json:"body"`
+}
+
+// Message types the agent handles.
+const (
+	messageJobRequest      = "RunnerJobRequest"
+	messageJobCancellation = "JobCancellation"
+	messageBrokerMigration = "BrokerMigration"
+)
+
+// jobRequestRef is the body of a RunnerJobRequest message.
+type jobRequestRef struct {
+	RunnerRequestID string `json:"runner_request_id"`
+	RunServiceURL   string `json:"run_service_url"`
+	BillingOwnerID  string `json:"billing_owner_id"`
+}
+
+// Status values of a runner polling for messages.
+const (
+	statusOnline = "Online"
+	statusBusy   = "Busy"
+)
+
+// do sends a JSON request and decodes the response into out. It returns
+// false without decoding if the response has no content.
+func (b *broker) do(ctx context.Context, method, rawURL string, query url.Values, in, out any) (bool, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return false, err
+		}
+		body = bytes.NewReader(data)
+	}
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return false, err
+	}
+	token, err := b.tokens.Token(ctx)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "GitHubActionsRunner-Go/"+b.version)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode >= 300 {
+		return false, &Error{StatusCode: resp.StatusCode, Message: errorMessage(data)}
+	}
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(data)) == 0 {
+		return false, nil
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return false, fmt.Errorf("invalid response from %s: %w", req.URL.Host, err)
+		}
+	}
+	return true, nil
+}
+
+// errorMessage extracts the message of an error response.
+func errorMessage(data []byte) string {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &e) == nil && e.Message != "" {
+		return e.Message
+	}
+	msg := strings.TrimSpace(string(data))
+	if len(msg) > 200 {
+		msg = msg[:200] + "..."
+	}
+	return msg
+}
+
+func (b *broker) endpoint(elem string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return serviceURL(b.url, elem)
+}
+
+// runnerQuery identifies the runner to the broker's message endpoints.
+func (b *broker) runnerQuery(sessionID, status string) url.Values {
+	return url.Values{
+		"sessionId":     {sessionID},
+		"status":        {status},
+		"runnerVersion": {b.version},
+		"os":            {runnerOS()},
+		"architecture":  {runnerArch()},
+		"disableUpdate": {"true"},
+	}
+}
+
+func (b *broker) createSession(ctx context.Context, s *session) (*session, error) {
+	var created session
+	if _, err := b.do(ctx, http.MethodPost, b.endpoint("session"), nil, s, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (b *broker) deleteSession(ctx context.Context) error {
+	_, err := b.do(ctx, http.MethodDelete, b.endpoint("session"), nil, nil, nil)
+	return err
+}
+
+// getMessage long-polls for the next message. It returns nil when the
+// poll ends without one.
+func (b *broker) getMessage(ctx context.Context, sessionID, status string) (*message, error) {
+	var m message
+	ok, err := b.do(ctx, http.MethodGet, b.endpoint("message"), b.runnerQuery(sessionID, status), nil, &m)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// acknowledge tells the broker the runner took the job request, so it is
+// not offered to another runner.
+func (b *broker) acknowledge(ctx context.Context, sessionID, requestID string) error {
+	body := map[string]string{"runnerRequestId": requestID}
+	_, err := b.do(ctx, http.MethodPost, b.endpoint("acknowledge"), b.runnerQuery(sessionID, statusBusy), body, nil)
+	return err
+}
+
+// acquireJob fetches the job of a request from its run service.
+func (b *broker) acquireJob(ctx context.Context, ref *jobRequestRef) (*JobRequest, error) {
+	body := map[string]string{"jobMessageId": ref.RunnerRequestID, "runnerOS": runnerOS(), "billingOwnerId": ref.BillingOwnerID}
+	var job JobRequest
+	ok, err := b.do(ctx, http.MethodPost, serviceURL(ref.RunServiceURL, "acquirejob"), nil, body, &job)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("the run service returned no job for request %s", ref.RunnerRequestID)
+	}
+	return &job, nil
+}
+
+// renewJob extends the runner's lock on a job and returns when it expires.
+func (b *broker) renewJob(ctx context.Context, ref *jobRequestRef, planID, jobID string) (time.Time, error) {
+	var resp struct {
+		LockedUntil time.Time `json:"lockedUntil"`
+	}
+	body := map[string]string{"planId": planID, "jobId": jobID}
+	if _, err := b.do(ctx, http.MethodPost, serviceURL(ref.RunServiceURL, "renewjob"), nil, body, &resp); err != nil {
+		return time.Time{}, err
+	}
+	return resp.LockedUntil, nil
+}
+
+// completion is a CompleteJobRequest.
+type completion struct {
+	PlanID         string                   `json:"planId"`
+	JobID          string                   `json:"jobId"`
+	Conclusion     string                   `json:"conclusion"`
+	Outputs        map[string]variableValue `json:"outputs"`
+	StepResults    []stepResult             `json:"stepResults"`
+	Annotations    []any                    `json:"annotations"`
+	EnvironmentURL string                   `json:"environmentUrl,omitempty"`
+	BillingOwnerID string                   `json:"billingOwnerId,omitempty"`
+}
+
+type stepResult struct {
+	ExternalID  string    `json:"external_id"`
+	Number      int       `json:"number"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// completeJob reports a job's result to the run service.
+func (b *broker) completeJob(ctx context.Context, ref *jobRequestRef, c *completion) error {
+	_, err := b.do(ctx, http.MethodPost, serviceURL(ref.RunServiceURL, "completejob"), nil, c, nil)
+	return err
+}
+
+func serviceURL(base, elem string) string {
+	return strings.TrimSuffix(base, "/") + "/" + elem
+}
+
+// runnerOS and runnerArch name the platform as the runner does.
+func runnerOS() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Windows"
+	case "darwin":
+		return "macOS"
+	}
+	return "Linux"
+}
+
+func runnerArch() string {
+	switch runtime.GOARCH {
+	case "arm64":
+		return "ARM64"
+	case "386":
+		return "X86"
+	case "arm":
+		return "ARM"
+	}
+	return "X64"
+}
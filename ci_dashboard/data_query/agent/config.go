@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agent takes jobs from GitHub as a self-hosted runner does and runs
+// them with the local runner. It speaks the runner's broker protocol: a
+// session, a long-polled message queue, and acquiring, renewing and
+// completing each job. It suits simple jobs, such as ones in a container;
+// their logs stay local rather than going to the results service.
+package agent
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+// Config is a registered runner: what config.sh writes to .runner,
+// .credentials and .credentials_rsaparams, or a just-in-time
+// configuration holds.
+type Config struct {
+	AgentID   int64
+	AgentName string
+	PoolID    int64
+	// ServerURL is the pipelines service, and BrokerURL the broker that
+	// serves runners using the v2 flow.
+	ServerURL string
+	BrokerURL string
+	UseV2Flow bool
+	// GitHubURL is the repository or organization the runner serves.
+	GitHubURL  string
+	WorkFolder string
+	// Ephemeral runners take a single job.
+	Ephemeral bool
+	// ClientID and AuthorizationURL identify the runner to the token
+	// service, and Key signs its assertions.
+	ClientID         string
+	AuthorizationURL string
+	Key              *rsa.PrivateKey
+}
+
+// runnerFile is the JSON of .runner.
+type runnerFile struct {
+	AgentID     int64  `json:"AgentId"`
+	AgentName   string `json:"AgentName"`
+	PoolID      int64  `json:"PoolId"`
+	ServerURL   string `json:"ServerUrl"`
+	ServerURLV2 string `json:"ServerUrlV2"`
+	UseV2Flow   bool   `json:"UseV2Flow"`
+	GitHubURL   string `json:"GitHubUrl"`
+	WorkFolder  string `json:"WorkFolder"`
+	Ephemeral   bool   `json:"Ephemeral"`
+}
+
+// credentialsFile is the JSON of .credentials.
+type credentialsFile struct {
+	Scheme string `json:"Scheme"`
+	Data   struct {
+		ClientID         string `json:"ClientId"`
+		AuthorizationURL string `json:"AuthorizationUrl"`
+	} `json:"Data"`
+}
+
+// rsaParams is the JSON of .credentials_rsaparams, each number in
+// big-endian base64.
+type rsaParams struct {
+	D        string `json:"d"`
+	DP       string `json:"dp"`
+	DQ       string `json:"dq"`
+	Exponent string `json:"exponent"`
+	InverseQ string `json:"inverseQ"`
+	Modulus  string `json:"modulus"`
+	P        string `json:"p"`
+	Q        string `json:"q"`
+}
+
+// configFiles are the files of a runner's configuration.
+var configFiles = []string{".runner", ".credentials", ".credentials_rsaparams"}
+
+// ParseJITConfig decodes the encoded_jit_config of a just-in-time runner:
+// base64 JSON mapping each configuration file to its base64 content.
+func ParseJITConfig(encoded string) (*Config, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid just-in-time configuration: %w", err)
+	}
+	var files map[string]string
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("invalid just-in-time configuration: %w", err)
+	}
+	contents := map[string][]byte{}
+	for _, name := range configFiles {
+		content, err := base64.StdEncoding.DecodeString(files[name])
+		if err != nil || len(content) == 0 {
+			return nil, fmt.Errorf("invalid just-in-time configuration: no valid %s", name)
+		}
+		contents[name] = content
+	}
+	return parseConfig(contents)
+}
+
+// LoadConfig reads the configuration config.sh wrote in dir.
+func LoadConfig(dir string) (*Config, error) {
+	contents := map[string][]byte{}
+	for _, name := range configFiles {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		contents[name] = content
+	}
+	return parseConfig(contents)
+}
+
+func parseConfig(files map[string][]byte) (*Config, error) {
+	var r runnerFile
+	if err := json.Unmarshal(trimBOM(files[".runner"]), &r); err != nil {
+		return nil, fmt.Errorf("invalid .runner: %w", err)
+	}
+	var creds credentialsFile
+	if err := json.Unmarshal(trimBOM(files[".credentials"]), &creds); err != nil {
+		return nil, fmt.Errorf("invalid .credentials: %w", err)
+	}
+	if creds.Scheme != "OAuth" {
+		return nil, fmt.Errorf("unsupported credential scheme %q", creds.Scheme)
+	}
+	var params rsaParams
+	if err := json.Unmarshal(trimBOM(files[".credentials_rsaparams"]), &params); err != nil {
+		return nil, fmt.Errorf("invalid .credentials_rsaparams: %w", err)
+	}
+	key, err := params.key()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{
+		AgentID:          r.AgentID,
+		AgentName:        r.AgentName,
+		PoolID:           r.PoolID,
+		ServerURL:        r.ServerURL,
+		BrokerURL:        r.ServerURLV2,
+		UseV2Flow:        r.UseV2Flow,
+		GitHubURL:        r.GitHubURL,
+		WorkFolder:       r.WorkFolder,
+		Ephemeral:        r.Ephemeral,
+		ClientID:         creds.Data.ClientID,
+		AuthorizationURL: creds.Data.AuthorizationURL,
+		Key:              key,
+	}, nil
+}
+
+// trimBOM drops the byte order mark the .NET runner writes.
+func trimBOM(data []byte) []byte {
+	if len(data) >= 3 && data[0] == 0xef && data[1] == 0xbb && data[2] == 0xbf {
+		return data[3:]
+	}
+	return data
+}
+
+func (p rsaParams) key() (*rsa.PrivateKey, error) {
+	var nums [5]*big.Int
+	for i, s := range []string{p.Modulus, p.Exponent, p.D, p.P, p.Q} {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("invalid .credentials_rsaparams")
+		}
+		nums[i] = new(big.Int).SetBytes(b)
+	}
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: nums[0], E: int(nums[1].Int64())},
+		D:         nums[2],
+		Primes:    []*big.Int{nums[3], nums[4]},
+	}
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid .credentials_rsaparams: %w", err)
+	}
+	key.Precompute()
+	return key, nil
+}
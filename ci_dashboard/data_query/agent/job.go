@@ -0,0 +1,264 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/runner"
+	"testingdashboard/m/v2/workflow"
+)
+
+// givenContexts are the contexts of a job request the runner takes as
+// they are.
+var givenContexts = []string{"github", "needs", "matrix", "strategy", "inputs", "vars"}
+
+// Workflow returns the job as a workflow of that one job, with the
+// expressions the service left unevaluated in ${{ }}.
+func (r *JobRequest) Workflow() (*workflow.Workflow, error) {
+	job := &workflow.Job{ID: r.JobName, Name: r.JobDisplayName, Env: map[string]string{}}
+	if job.ID == "" {
+		job.ID = "job"
+	}
+	for _, t := range r.EnvironmentVariables {
+		env, err := t.StringMap()
+		if err != nil {
+			return nil, fmt.Errorf("env: %w", err)
+		}
+		for k, v := range env {
+			job.Env[k] = v
+		}
+	}
+	for _, t := range r.Defaults {
+		run := t.Get("run")
+		if run == nil {
+			continue
+		}
+		defaults, err := run.StringMap()
+		if err != nil {
+			return nil, fmt.Errorf("defaults: %w", err)
+		}
+		job.Defaults = &workflow.Defaults{Run: &workflow.RunDefaults{Shell: defaults["shell"], WorkingDirectory: defaults["working-directory"]}}
+	}
+	var err error
+	if job.Container, err = container(r.JobContainer); err != nil {
+		return nil, fmt.Errorf("container: %w", err)
+	}
+	if t := r.JobServiceContainers; t != nil && t.Kind == tokenMapping {
+		job.Services = map[string]*workflow.Container{}
+		for _, p := range t.Mapping {
+			id, err := p.Key.String()
+			if err != nil {
+				return nil, fmt.Errorf("services: %w", err)
+			}
+			if job.Services[id], err = container(p.Value); err != nil {
+				return nil, fmt.Errorf("services.%s: %w", id, err)
+			}
+		}
+	}
+	if job.Outputs, err = r.JobOutputs.StringMap(); err != nil {
+		return nil, fmt.Errorf("outputs: %w", err)
+	}
+	for i := range r.Steps {
+		step, err := r.Steps[i].workflowStep()
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		job.Steps = append(job.Steps, step)
+	}
+	name := r.JobName
+	if github, ok := r.context("github").(map[string]any); ok {
+		name = expr.ToString(github["workflow"])
+	}
+	return &workflow.Workflow{Name: name, Jobs: map[string]*workflow.Job{job.ID: job}}, nil
+}
+
+func (s *actionStep) workflowStep() (*workflow.Step, error) {
+	step := &workflow.Step{ID: s.ContextName, If: s.Condition, Name: s.DisplayName}
+	var err error
+	if s.DisplayNameToken != nil {
+		if step.Name, err = s.DisplayNameToken.String(); err != nil {
+			return nil, fmt.Errorf("name: %w", err)
+		}
+	}
+	if step.Env, err = s.Environment.StringMap(); err != nil {
+		return nil, fmt.Errorf("env: %w", err)
+	}
+	if t := s.ContinueOnError; t != nil {
+		v, err := t.String()
+		if err != nil {
+			return nil, fmt.Errorf("continue-on-error: %w", err)
+		}
+		step.ContinueOnError = &workflow.BoolExpr{Value: v == "true"}
+		if t.Kind == tokenBasicExpression {
+			step.ContinueOnError = &workflow.BoolExpr{Expression: v}
+		}
+	}
+	if t := s.TimeoutInMinutes; t != nil {
+		switch t.Kind {
+		case tokenNumber:
+			step.TimeoutMinutes = &workflow.NumberExpr{Value: t.Number}
+		case tokenBasicExpression:
+			v, _ := t.String()
+			step.TimeoutMinutes = &workflow.NumberExpr{Expression: v}
+		}
+	}
+	inputs, err := s.Inputs.StringMap()
+	if err != nil {
+		return nil, fmt.Errorf("inputs: %w", err)
+	}
+	ref := s.Reference
+	switch ref.Type {
+	case sourceScript:
+		step.Run, step.Shell, step.WorkingDirectory = inputs["script"], inputs["shell"], inputs["workingDirectory"]
+		return step, nil
+	case sourceRepository:
+		switch {
+		case strings.EqualFold(ref.RepositoryType, "self") || ref.Name == "":
+			// An action in the workflow's repository.
+			step.Uses = "./" + strings.TrimPrefix(ref.Path, "./")
+		case ref.Path != "":
+			step.Uses = ref.Name + "/" + ref.Path + "@" + ref.Ref
+		default:
+			step.Uses = ref.Name + "@" + ref.Ref
+		}
+	case sourceContainerRegistry:
+		step.Uses = "docker://" + ref.Image
+		if v, ok := inputs["entryPoint"]; ok {
+			delete(inputs, "entryPoint")
+			inputs["entrypoint"] = v
+		}
+	default:
+		return nil, fmt.Errorf("unsupported action source %q", ref.Type)
+	}
+	step.With = inputs
+	return step, nil
+}
+
+// container converts a job or service container, given as an image or a
+// mapping.
+func container(t *templateToken) (*workflow.Container, error) {
+	if t == nil || t.Kind == tokenNull {
+		return nil, nil
+	}
+	if t.Kind != tokenMapping {
+		image, err := t.String()
+		if err != nil || image == "" {
+			return nil, err
+		}
+		return &workflow.Container{Image: image}, nil
+	}
+	c := &workflow.Container{}
+	var err error
+	if c.Image, err = t.Get("image").String(); err != nil {
+		return nil, fmt.Errorf("image: %w", err)
+	}
+	if c.Options, err = t.Get("options").String(); err != nil {
+		return nil, fmt.Errorf("options: %w", err)
+	}
+	if c.Env, err = t.Get("env").StringMap(); err != nil {
+		return nil, fmt.Errorf("env: %w", err)
+	}
+	if c.Ports, err = t.Get("ports").Strings(); err != nil {
+		return nil, fmt.Errorf("ports: %w", err)
+	}
+	if c.Volumes, err = t.Get("volumes").Strings(); err != nil {
+		return nil, fmt.Errorf("volumes: %w", err)
+	}
+	if creds := t.Get("credentials"); creds != nil {
+		m, err := creds.StringMap()
+		if err != nil {
+			return nil, fmt.Errorf("credentials: %w", err)
+		}
+		c.Credentials = &workflow.Credentials{Username: m["username"], Password: m["password"]}
+	}
+	if c.Image == "" {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// context returns the decoded context name, or nil.
+func (r *JobRequest) context(name string) any {
+	data, ok := r.ContextData[name]
+	if !ok {
+		return nil
+	}
+	v, err := decodeContext(data)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// Options returns runner options for the job, on top of base: the
+// request's contexts, event and secrets, and the GitHub instance it
+// came from.
+func (r *JobRequest) Options(base runner.Options) (runner.Options, error) {
+	opts := base
+	opts.Contexts = map[string]any{}
+	for _, name := range givenContexts {
+		data, ok := r.ContextData[name]
+		if !ok {
+			continue
+		}
+		v, err := decodeContext(data)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s context: %w", name, err)
+		}
+		opts.Contexts[name] = v
+	}
+	github, _ := opts.Contexts["github"].(map[string]any)
+	if github != nil {
+		opts.EventName = expr.ToString(github["event_name"])
+		opts.Event, _ = github["event"].(map[string]any)
+		if server := expr.ToString(github["server_url"]); server != "" {
+			opts.Endpoints = endpoints.Endpoints{Server: server, API: expr.ToString(github["api_url"]), GraphQL: expr.ToString(github["graphql_url"])}
+		}
+	}
+	if inputs, ok := opts.Contexts["inputs"].(map[string]any); ok {
+		opts.Inputs = inputs
+	}
+	opts.Secrets = map[string]string{}
+	for k, v := range base.Secrets {
+		opts.Secrets[k] = v
+	}
+	for name, v := range r.Variables {
+		switch {
+		case name == "system.github.token":
+			opts.Secrets["GITHUB_TOKEN"] = v.Value
+		case v.IsSecret && !strings.HasPrefix(name, "system."):
+			opts.Secrets[name] = v.Value
+		}
+	}
+	opts.Jobs = nil
+	return opts, nil
+}
+
+// conclusion maps a runner result to the service's TaskResult.
+func conclusion(result string) string {
+	switch result {
+	case runner.ResultSuccess:
+		return "succeeded"
+	case runner.ResultCancelled:
+		return "canceled"
+	case runner.ResultSkipped:
+		return "skipped"
+	}
+	return "failed"
+}
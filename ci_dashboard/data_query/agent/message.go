@@ -0,0 +1,322 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JobRequest is the AgentJobRequestMessage the run service sends for a
+// job: its steps and settings as template tokens, with the contexts they
+// are evaluated in.
+type JobRequest struct {
+	Plan struct {
+		PlanID string `json:"planId"`
+	} `json:"plan"`
+	JobID          string `json:"jobId"`
+	JobDisplayName string `json:"jobDisplayName"`
+	// JobName is the job's ID in the workflow.
+	JobName     string                     `json:"jobName"`
+	ContextData map[string]json.RawMessage `json:"contextData"`
+	Variables   map[string]variableValue   `json:"variables"`
+	Steps       []actionStep               `json:"steps"`
+	Mask        []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"mask"`
+	EnvironmentVariables []*templateToken `json:"environmentVariables"`
+	Defaults             []*templateToken `json:"defaults"`
+	JobContainer         *templateToken   `json:"jobContainer"`
+	JobServiceContainers *templateToken   `json:"jobServiceContainers"`
+	JobOutputs           *templateToken   `json:"jobOutputs"`
+}
+
+// variableValue is a variable of the job or an output of the run.
+type variableValue struct {
+	Value    string `json:"value"`
+	IsSecret bool   `json:"isSecret,omitempty"`
+}
+
+// actionStep is a step of the job.
+type actionStep struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Reference struct {
+		Type sourceType `json:"type"`
+		Name string     `json:"name"`
+		Ref  string     `json:"ref"`
+		Path string     `json:"path"`
+		// RepositoryType is self for actions in the workflow's
+		// repository.
+		RepositoryType string `json:"repositoryType"`
+		Image          string `json:"image"`
+	} `json:"reference"`
+	DisplayName      string         `json:"displayName"`
+	DisplayNameToken *templateToken `json:"displayNameToken"`
+	// ContextName is the step's id, generated for steps without one.
+	ContextName      string         `json:"contextName"`
+	Condition        string         `json:"condition"`
+	ContinueOnError  *templateToken `json:"continueOnError"`
+	TimeoutInMinutes *templateToken `json:"timeoutInMinutes"`
+	Environment      *templateToken `json:"environment"`
+	Inputs           *templateToken `json:"inputs"`
+}
+
+// sourceType is where a step's action comes from.
+type sourceType string
+
+const (
+	sourceRepository        sourceType = "repository"
+	sourceContainerRegistry sourceType = "containerregistry"
+	sourceScript            sourceType = "script"
+)
+
+// UnmarshalJSON accepts the names and the numbers of ActionSourceType.
+func (t *sourceType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = sourceType(strings.ToLower(s))
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid action source type %s", data)
+	}
+	*t = map[int]sourceType{1: sourceRepository, 2: sourceContainerRegistry, 3: sourceScript}[n]
+	return nil
+}
+
+// Kinds of template tokens.
+const (
+	tokenString = iota
+	tokenSequence
+	tokenMapping
+	tokenBasicExpression
+	tokenInsertExpression
+	tokenBoolean
+	tokenNumber
+	tokenNull
+)
+
+// templateToken is a value of the workflow as the service parsed it: a
+// literal, a sequence, a mapping or an expression still to evaluate.
+type templateToken struct {
+	Kind     int
+	Literal  string
+	Bool     bool
+	Number   float64
+	Expr     string
+	Sequence []*templateToken
+	Mapping  []tokenPair
+}
+
+type tokenPair struct {
+	Key   *templateToken `json:"key"`
+	Value *templateToken `json:"value"`
+}
+
+func (t *templateToken) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		t.Kind = tokenNull
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		t.Kind = tokenString
+		return json.Unmarshal(data, &t.Literal)
+	case bytes.Equal(data, []byte("true")), bytes.Equal(data, []byte("false")):
+		t.Kind = tokenBoolean
+		return json.Unmarshal(data, &t.Bool)
+	case len(data) > 0 && data[0] != '{':
+		t.Kind = tokenNumber
+		return json.Unmarshal(data, &t.Number)
+	}
+	var raw struct {
+		Type      int              `json:"type"`
+		Lit       string           `json:"lit"`
+		Bool      bool             `json:"bool"`
+		Num       float64          `json:"num"`
+		Expr      string           `json:"expr"`
+		Directive string           `json:"directive"`
+		Seq       []*templateToken `json:"seq"`
+		Map       []tokenPair      `json:"map"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*t = templateToken{Kind: raw.Type, Literal: raw.Lit, Bool: raw.Bool, Number: raw.Num, Expr: raw.Expr, Sequence: raw.Seq, Mapping: raw.Map}
+	if raw.Type == tokenInsertExpression {
+		t.Expr = raw.Directive
+	}
+	return nil
+}
+
+// String returns a scalar token as the workflow would spell it, with
+// expressions in ${{ }} for the runner to evaluate.
+func (t *templateToken) String() (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	switch t.Kind {
+	case tokenString:
+		return t.Literal, nil
+	case tokenBasicExpression:
+		return "${{ " + t.Expr + " }}", nil
+	case tokenBoolean:
+		return strconv.FormatBool(t.Bool), nil
+	case tokenNumber:
+		return strconv.FormatFloat(t.Number, 'f', -1, 64), nil
+	case tokenNull:
+		return "", nil
+	}
+	return "", fmt.Errorf("expected a string, not %s", t.kindName())
+}
+
+// StringMap returns a mapping of scalars.
+func (t *templateToken) StringMap() (map[string]string, error) {
+	if t == nil || t.Kind == tokenNull {
+		return nil, nil
+	}
+	if t.Kind != tokenMapping {
+		return nil, fmt.Errorf("expected a mapping, not %s", t.kindName())
+	}
+	out := map[string]string{}
+	for _, p := range t.Mapping {
+		k, err := p.Key.String()
+		if err != nil {
+			return nil, err
+		}
+		if out[k], err = p.Value.String(); err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return out, nil
+}
+
+// Strings returns a sequence of scalars.
+func (t *templateToken) Strings() ([]string, error) {
+	if t == nil || t.Kind == tokenNull {
+		return nil, nil
+	}
+	if t.Kind != tokenSequence {
+		return nil, fmt.Errorf("expected a sequence, not %s", t.kindName())
+	}
+	var out []string
+	for _, item := range t.Sequence {
+		s, err := item.String()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// Get returns the value of key in a mapping, or nil.
+func (t *templateToken) Get(key string) *templateToken {
+	if t == nil || t.Kind != tokenMapping {
+		return nil
+	}
+	for _, p := range t.Mapping {
+		if k, err := p.Key.String(); err == nil && strings.EqualFold(k, key) {
+			return p.Value
+		}
+	}
+	return nil
+}
+
+func (t *templateToken) kindName() string {
+	switch t.Kind {
+	case tokenSequence:
+		return "a sequence"
+	case tokenMapping:
+		return "a mapping"
+	case tokenInsertExpression:
+		return "an insert expression"
+	}
+	return "a scalar"
+}
+
+// Kinds of pipeline context data.
+const (
+	contextString = iota
+	contextArray
+	contextDictionary
+	contextBoolean
+	contextNumber
+	contextCaseSensitiveDictionary
+)
+
+// decodeContext decodes PipelineContextData into the values expressions
+// see: strings, booleans, numbers, []any and map[string]any.
+func decodeContext(data json.RawMessage) (any, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		var v any
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+	var raw struct {
+		T int               `json:"t"`
+		S string            `json:"s"`
+		B bool              `json:"b"`
+		N float64           `json:"n"`
+		A []json.RawMessage `json:"a"`
+		D []struct {
+			K string          `json:"k"`
+			V json.RawMessage `json:"v"`
+		} `json:"d"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	switch raw.T {
+	case contextString:
+		return raw.S, nil
+	case contextBoolean:
+		return raw.B, nil
+	case contextNumber:
+		return raw.N, nil
+	case contextArray:
+		out := make([]any, 0, len(raw.A))
+		for _, item := range raw.A {
+			v, err := decodeContext(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case contextDictionary, contextCaseSensitiveDictionary:
+		out := make(map[string]any, len(raw.D))
+		for _, kv := range raw.D {
+			if len(kv.V) == 0 {
+				out[kv.K] = nil
+				continue
+			}
+			v, err := decodeContext(kv.V)
+			if err != nil {
+				return nil, err
+			}
+			out[kv.K] = v
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown context data type %d", raw.T)
+}
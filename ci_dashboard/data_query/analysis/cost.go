@@ -0,0 +1,226 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/client"
+)
+
+// Rates are per-minute prices keyed by runner label, such as
+// "ubuntu-latest-16-cores", or by operating system: "linux", "windows",
+// "macos" and "self-hosted".
+type Rates map[string]float64
+
+// DefaultRates are the list prices of standard GitHub-hosted runners in
+// USD. Self-hosted runners are free.
+var DefaultRates = Rates{
+	"linux":       0.008,
+	"windows":     0.016,
+	"macos":       0.08,
+	"self-hosted": 0,
+}
+
+// RunnerOS guesses the operating system of a runner from its labels.
+func RunnerOS(labels []string) string {
+	for _, l := range labels {
+		if strings.EqualFold(l, "self-hosted") {
+			return "self-hosted"
+		}
+	}
+	for _, l := range labels {
+		l = strings.ToLower(l)
+		switch {
+		case strings.Contains(l, "windows"):
+			return "windows"
+		case strings.Contains(l, "macos"):
+			return "macos"
+		case strings.Contains(l, "ubuntu"), strings.Contains(l, "linux"):
+			return "linux"
+		}
+	}
+	return "unknown"
+}
+
+// Rate returns the rate for a runner with labels: the rate of the first
+// label that has one, else the rate of its operating system. Labels that
+// name an operating system, which self-hosted runners carry too, only
+// count as the latter.
+func (r Rates) Rate(labels []string) float64 {
+	for _, l := range labels {
+		if _, isOS := DefaultRates[strings.ToLower(l)]; isOS {
+			continue
+		}
+		if rate, ok := r[l]; ok {
+			return rate
+		}
+	}
+	return r[RunnerOS(labels)]
+}
+
+// JobUsage is the runner time of one job.
+type JobUsage struct {
+	RunID    int64  `json:"run_id"`
+	Workflow string `json:"workflow"`
+	// Job is the job name without the matrix values, and Leg the full
+	// name, such as "test (ubuntu, 1.22)".
+	Job   string `json:"job"`
+	Leg   string `json:"leg"`
+	OS    string `json:"os"`
+	Label string `json:"label"`
+	// Minutes is the time the job ran; GitHub bills each job rounded up
+	// to a whole minute.
+	Minutes         float64 `json:"minutes"`
+	BillableMinutes int     `json:"billable_minutes"`
+	Cost            float64 `json:"cost"`
+}
+
+// UsageOptions selects the runs CollectUsage looks at.
+type UsageOptions struct {
+	// Workflow limits the runs to one workflow, by ID or file name.
+	Workflow string
+	// Since and Until bound the creation date of the runs. Zero values
+	// are unbounded.
+	Since, Until time.Time
+	// Rates defaults to DefaultRates.
+	Rates Rates
+}
+
+// CollectUsage returns the runner time of every job, including every
+// attempt, of the completed runs matching opts.
+func CollectUsage(ctx context.Context, c *client.Client, owner, repo string, opts UsageOptions) ([]JobUsage, error) {
+	rates := opts.Rates
+	if rates == nil {
+		rates = DefaultRates
+	}
+	ro := client.RunsOptions{Workflow: opts.Workflow, Status: "completed", Created: createdRange(opts.Since, opts.Until)}
+	var out []JobUsage
+	for run, err := range c.ListRuns(ctx, owner, repo, ro) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runs: %w", err)
+		}
+		jobs, err := c.ListJobs(ctx, owner, repo, run.ID, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs of run %d: %w", run.ID, err)
+		}
+		for _, job := range jobs {
+			d := job.Duration()
+			if d == 0 {
+				continue
+			}
+			u := JobUsage{
+				RunID:           run.ID,
+				Workflow:        run.Name,
+				Job:             baseJobName(job.Name),
+				Leg:             job.Name,
+				OS:              RunnerOS(job.Labels),
+				Label:           strings.Join(job.Labels, ","),
+				Minutes:         d.Minutes(),
+				BillableMinutes: int(math.Ceil(d.Minutes())),
+			}
+			u.Cost = float64(u.BillableMinutes) * rates.Rate(job.Labels)
+			out = append(out, u)
+		}
+	}
+	return out, nil
+}
+
+// createdRange formats a created: search qualifier.
+func createdRange(since, until time.Time) string {
+	switch {
+	case since.IsZero() && until.IsZero():
+		return ""
+	case until.IsZero():
+		return ">=" + since.Format(time.DateOnly)
+	case since.IsZero():
+		return "<=" + until.Format(time.DateOnly)
+	}
+	return since.Format(time.DateOnly) + ".." + until.Format(time.DateOnly)
+}
+
+// baseJobName strips the matrix values GitHub appends to the names of
+// matrix jobs.
+func baseJobName(name string) string {
+	if i := strings.LastIndex(name, " ("); i > 0 && strings.HasSuffix(name, ")") {
+		return name[:i]
+	}
+	return name
+}
+
+// Dimensions a cost breakdown can group by.
+var Dimensions = []string{"workflow", "job", "leg", "os", "label"}
+
+// CostLine is the total of one group of jobs.
+type CostLine struct {
+	Key             string  `json:"key"`
+	Jobs            int     `json:"jobs"`
+	Minutes         float64 `json:"minutes"`
+	BillableMinutes int     `json:"billable_minutes"`
+	Cost            float64 `json:"cost"`
+}
+
+// Breakdown totals usage by one of Dimensions, most expensive first. Jobs
+// and legs are qualified with their workflow.
+func Breakdown(usage []JobUsage, dimension string) ([]CostLine, error) {
+	if !slices.Contains(Dimensions, dimension) {
+		return nil, fmt.Errorf("unknown dimension %q; want one of %s", dimension, strings.Join(Dimensions, ", "))
+	}
+	lines := map[string]*CostLine{}
+	for _, u := range usage {
+		var key string
+		switch dimension {
+		case "workflow":
+			key = u.Workflow
+		case "job":
+			key = u.Workflow + " / " + u.Job
+		case "leg":
+			key = u.Workflow + " / " + u.Leg
+		case "os":
+			key = u.OS
+		case "label":
+			key = u.Label
+		}
+		l := lines[key]
+		if l == nil {
+			l = &CostLine{Key: key}
+			lines[key] = l
+		}
+		l.Jobs++
+		l.Minutes += u.Minutes
+		l.BillableMinutes += u.BillableMinutes
+		l.Cost += u.Cost
+	}
+	out := make([]CostLine, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost > out[j].Cost
+		}
+		if out[i].BillableMinutes != out[j].BillableMinutes {
+			return out[i].BillableMinutes > out[j].BillableMinutes
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analysis looks for patterns in the history of a workflow's runs,
+// such as steps that fail intermittently on the same commit.
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"time"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/summary"
+)
+
+// Outcome is the result of one step in one attempt of a run.
+type Outcome struct {
+	RunID   int64  `json:"run_id"`
+	Attempt int    `json:"attempt"`
+	SHA     string `json:"sha"`
+	Job     string `json:"job"`
+	// Step is empty for jobs that failed before running any step.
+	Step       string    `json:"step,omitempty"`
+	Conclusion string    `json:"conclusion"`
+	Time       time.Time `json:"time"`
+	URL        string    `json:"url,omitempty"`
+}
+
+// Failed reports whether the step failed.
+func (o *Outcome) Failed() bool {
+	return o.Conclusion == "failure" || o.Conclusion == "timed_out"
+}
+
+// Collect fetches the outcomes of every step of the last n completed runs
+// of workflow, an ID or file name, including every attempt of each run.
+// Skipped and cancelled steps are left out as they say nothing about the
+// step itself.
+func Collect(ctx context.Context, c *client.Client, owner, repo, workflow string, n int) ([]Outcome, error) {
+	var out []Outcome
+	count := 0
+	for run, err := range c.ListRuns(ctx, owner, repo, client.RunsOptions{Workflow: workflow, Status: "completed"}) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list runs of %s: %w", workflow, err)
+		}
+		if count >= n {
+			break
+		}
+		count++
+		jobs, err := c.ListJobs(ctx, owner, repo, run.ID, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs of run %d: %w", run.ID, err)
+		}
+		for _, job := range jobs {
+			out = append(out, outcomes(run, job)...)
+		}
+	}
+	return out, nil
+}
+
+func outcomes(run *client.Run, job *client.Job) []Outcome {
+	base := Outcome{RunID: run.ID, Attempt: job.RunAttempt, SHA: run.HeadSHA, Job: job.Name, Time: job.StartedAt, URL: job.HTMLURL}
+	var out []Outcome
+	for _, step := range job.Steps {
+		if step.Conclusion != "success" && step.Conclusion != "failure" && step.Conclusion != "timed_out" {
+			continue
+		}
+		o := base
+		o.Step, o.Conclusion = step.Name, step.Conclusion
+		if !step.StartedAt.IsZero() {
+			o.Time = step.StartedAt
+		}
+		out = append(out, o)
+	}
+	if len(out) == 0 && job.Conclusion == "failure" {
+		o := base
+		o.Conclusion = job.Conclusion
+		out = append(out, o)
+	}
+	return out
+}
+
+// Classes of steps in a report.
+const (
+	// ClassFlaky steps both failed and succeeded on the same commit.
+	ClassFlaky = "flaky"
+	// ClassFailing steps failed on every attempt at the newest commit they
+	// ran on.
+	ClassFailing = "failing"
+	// ClassIntermittent steps failed on some commits but not the newest.
+	ClassIntermittent = "intermittent"
+)
+
+// StepReport summarizes the outcomes of one step.
+type StepReport struct {
+	Job         string  `json:"job"`
+	Step        string  `json:"step,omitempty"`
+	Class       string  `json:"class"`
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+	// FlakyCommits are the commits the step both failed and succeeded on.
+	FlakyCommits []string `json:"flaky_commits,omitempty"`
+	// LastFailure links to the job of the most recent failure.
+	LastFailure    time.Time `json:"last_failure"`
+	LastFailureURL string    `json:"last_failure_url,omitempty"`
+}
+
+// Report is the result of Analyze. Steps that never failed are left out.
+type Report struct {
+	Outcomes int          `json:"outcomes"`
+	Runs     int          `json:"runs"`
+	From     time.Time    `json:"from"`
+	To       time.Time    `json:"to"`
+	Steps    []StepReport `json:"steps"`
+}
+
+// Analyze correlates outcomes by job and step. Steps are sorted flaky
+// first, then failing, then by failure count.
+func Analyze(outcomes []Outcome) *Report {
+	type key struct{ job, step string }
+	byStep := map[key][]Outcome{}
+	runs := map[int64]bool{}
+	r := &Report{Outcomes: len(outcomes), Steps: []StepReport{}}
+	for _, o := range outcomes {
+		k := key{o.Job, o.Step}
+		byStep[k] = append(byStep[k], o)
+		runs[o.RunID] = true
+		if r.From.IsZero() || o.Time.Before(r.From) {
+			r.From = o.Time
+		}
+		if o.Time.After(r.To) {
+			r.To = o.Time
+		}
+	}
+	r.Runs = len(runs)
+
+	for k, list := range byStep {
+		sr := StepReport{Job: k.job, Step: k.step, Runs: len(list)}
+		type mix struct{ failed, passed bool }
+		commits := map[string]*mix{}
+		var newest *Outcome
+		for i := range list {
+			o := &list[i]
+			m := commits[o.SHA]
+			if m == nil {
+				m = &mix{}
+				commits[o.SHA] = m
+			}
+			if o.Failed() {
+				sr.Failures++
+				m.failed = true
+				if o.Time.After(sr.LastFailure) || sr.LastFailureURL == "" {
+					sr.LastFailure, sr.LastFailureURL = o.Time, o.URL
+				}
+			} else {
+				m.passed = true
+			}
+			if newest == nil || o.Time.After(newest.Time) {
+				newest = o
+			}
+		}
+		if sr.Failures == 0 {
+			continue
+		}
+		for sha, m := range commits {
+			if m.failed && m.passed {
+				sr.FlakyCommits = append(sr.FlakyCommits, sha)
+			}
+		}
+		sort.Strings(sr.FlakyCommits)
+		sr.FailureRate = float64(sr.Failures) / float64(sr.Runs)
+		switch {
+		case len(sr.FlakyCommits) > 0:
+			sr.Class = ClassFlaky
+		case commits[newest.SHA].failed:
+			sr.Class = ClassFailing
+		default:
+			sr.Class = ClassIntermittent
+		}
+		r.Steps = append(r.Steps, sr)
+	}
+	rank := map[string]int{ClassFlaky: 0, ClassFailing: 1, ClassIntermittent: 2}
+	sort.Slice(r.Steps, func(i, j int) bool {
+		a, b := r.Steps[i], r.Steps[j]
+		if rank[a.Class] != rank[b.Class] {
+			return rank[a.Class] < rank[b.Class]
+		}
+		if a.Failures != b.Failures {
+			return a.Failures > b.Failures
+		}
+		if a.Job != b.Job {
+			return a.Job < b.Job
+		}
+		return a.Step < b.Step
+	})
+	return r
+}
+
+// Summary renders the report for GITHUB_STEP_SUMMARY.
+func (r *Report) Summary(title string) *summary.Builder {
+	b := summary.New().Heading(html.EscapeString(title), 2)
+	b.Paragraph(fmt.Sprintf("%d runs from %s to %s.", r.Runs, r.From.Format(time.DateOnly), r.To.Format(time.DateOnly)))
+	if len(r.Steps) == 0 {
+		return b.Paragraph("No step failed.")
+	}
+	var rows [][]string
+	for _, s := range r.Steps {
+		name := html.EscapeString(s.Job)
+		if s.Step != "" {
+			name += " / " + html.EscapeString(s.Step)
+		}
+		if s.LastFailureURL != "" {
+			name = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(s.LastFailureURL), name)
+		}
+		commits := ""
+		for i, sha := range s.FlakyCommits {
+			if i > 0 {
+				commits += " "
+			}
+			commits += "<code>" + sha[:min(7, len(sha))] + "</code>"
+		}
+		rows = append(rows, []string{
+			name,
+			s.Class,
+			fmt.Sprintf("%d/%d (%.0f%%)", s.Failures, s.Runs, 100*s.FailureRate),
+			commits,
+		})
+	}
+	return b.SimpleTable([]string{"Step", "Class", "Failures", "Flaky on"}, rows)
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifact uploads and downloads workflow artifacts with the v4
+// artifact service, the protocol behind actions/upload-artifact@v4.
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"testingdashboard/m/v2/results"
+)
+
+// Client manages the artifacts of the current workflow run.
+type Client struct {
+	Results *results.Client
+}
+
+// NewFromEnv returns a client configured from the runner environment.
+func NewFromEnv() (*Client, error) {
+	r, err := results.NewFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Results: r}, nil
+}
+
+func (c *Client) call(ctx context.Context, method string, in, out any) error {
+	return c.Results.Call(ctx, "ArtifactService", method, in, out)
+}
+
+// Artifact describes an uploaded artifact.
+type Artifact struct {
+	ID        int64
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+	// Digest is "sha256:<hex>" of the zip, when the service knows it.
+	Digest string
+}
+
+type listRequest struct {
+	RunID      string               `json:"workflow_run_backend_id"`
+	JobID      string               `json:"workflow_job_run_backend_id"`
+	NameFilter *results.StringValue `json:"name_filter,omitempty"`
+	IDFilter   *results.Int64Value  `json:"id_filter,omitempty"`
+}
+
+type listResponse struct {
+	Artifacts []struct {
+		DatabaseID int64                `json:"database_id,string"`
+		Name       string               `json:"name"`
+		Size       int64                `json:"size,string"`
+		CreatedAt  time.Time            `json:"created_at"`
+		Digest     *results.StringValue `json:"digest"`
+	} `json:"artifacts"`
+}
+
+// List returns the artifacts of the current run, newest first.
+func (c *Client) List(ctx context.Context) ([]*Artifact, error) {
+	return c.list(ctx, listRequest{})
+}
+
+// Get returns the artifact with the given name.
+func (c *Client) Get(ctx context.Context, name string) (*Artifact, error) {
+	list, err := c.list(ctx, listRequest{NameFilter: &results.StringValue{Value: name}})
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("artifact %q not found", name)
+	}
+	return list[0], nil
+}
+
+// GetByID returns the artifact with the given ID.
+func (c *Client) GetByID(ctx context.Context, id int64) (*Artifact, error) {
+	list, err := c.list(ctx, listRequest{IDFilter: &results.Int64Value{Value: id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("artifact %d not found", id)
+	}
+	return list[0], nil
+}
+
+func (c *Client) list(ctx context.Context, req listRequest) ([]*Artifact, error) {
+	var err error
+	if req.RunID, req.JobID, err = c.Results.BackendIDs(); err != nil {
+		return nil, err
+	}
+	var resp listResponse
+	if err := c.call(ctx, "ListArtifacts", req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	var out []*Artifact
+	for _, a := range resp.Artifacts {
+		art := &Artifact{ID: a.DatabaseID, Name: a.Name, Size: a.Size, CreatedAt: a.CreatedAt}
+		if a.Digest != nil {
+			art.Digest = a.Digest.Value
+		}
+		out = append(out, art)
+	}
+	// Names are unique within a run, but a re-run can leave older
+	// artifacts of the same name behind.
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Delete deletes the named artifact and returns its ID.
+func (c *Client) Delete(ctx context.Context, name string) (int64, error) {
+	runID, jobID, err := c.Results.BackendIDs()
+	if err != nil {
+		return 0, err
+	}
+	req := map[string]string{"workflow_run_backend_id": runID, "workflow_job_run_backend_id": jobID, "name": name}
+	var resp struct {
+		OK bool  `json:"ok"`
+		ID int64 `json:"artifact_id,string"`
+	}
+	if err := c.call(ctx, "DeleteArtifact", req, &resp); err != nil {
+		return 0, fmt.Errorf("failed to delete artifact %s: %w", name, err)
+	}
+	if !resp.OK {
+		return 0, fmt.Errorf("failed to delete artifact %s", name)
+	}
+	return resp.ID, nil
+}
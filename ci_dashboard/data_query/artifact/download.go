@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"testingdashboard/m/v2/results"
+)
+
+// Download fetches an artifact and extracts it into dir. The zip is checked
+// against the artifact's digest when the service reports one.
+func (c *Client) Download(ctx context.Context, a *Artifact, dir string) error {
+	runID, jobID, err := c.Results.BackendIDs()
+	if err != nil {
+		return err
+	}
+	req := map[string]string{"workflow_run_backend_id": runID, "workflow_job_run_backend_id": jobID, "name": a.Name}
+	var signed struct {
+		URL string `json:"signed_url"`
+	}
+	if err := c.call(ctx, "GetSignedArtifactURL", req, &signed); err != nil {
+		return fmt.Errorf("failed to get download URL of artifact %s: %w", a.Name, err)
+	}
+
+	f, err := os.CreateTemp("", "artifact-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	size, err := c.Results.DownloadBlob(ctx, signed.URL, f, results.BlobOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to download artifact %s: %w", a.Name, err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, size)); err != nil {
+		return err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if want, ok := strings.CutPrefix(a.Digest, "sha256:"); ok && want != digest {
+		return fmt.Errorf("artifact %s is corrupt: sha256 is %s, want %s", a.Name, digest, want)
+	}
+	return Extract(f, size, dir)
+}
+
+// Extract unzips r into dir, refusing entries that would escape it.
+func Extract(r io.ReaderAt, size int64, dir string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to open artifact zip: %w", err)
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for _, zf := range zr.File {
+		target := filepath.Join(dir, filepath.FromSlash(zf.Name))
+		if target != dir && !strings.HasPrefix(target, dir+string(filepath.Separator)) {
+			return fmt.Errorf("zip entry %q escapes the target directory", zf.Name)
+		}
+		if zf.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractFile(zf, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(zf *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	in, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	mode := zf.Mode().Perm()
+	if mode == 0 {
+		mode = 0o644
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifact
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/results"
+)
+
+// UploadOptions controls Upload.
+type UploadOptions struct {
+	// RetentionDays overrides the repository's retention when positive.
+	RetentionDays int
+	// CompressionLevel is the flate level, 0 (store) to 9. Defaults to 6,
+	// like actions/upload-artifact. Use a negative value to store.
+	CompressionLevel int
+	// ChunkSize is the size of each uploaded block. Defaults to 8 MiB.
+	ChunkSize int64
+	// Concurrency is how many blocks upload at once. Defaults to 4.
+	Concurrency int
+}
+
+// invalidNameChars may not appear in artifact names.
+const invalidNameChars = "\":<>|*?\r\n\\/"
+
+// ValidateName checks an artifact name.
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("artifact name is empty")
+	}
+	if i := strings.IndexAny(name, invalidNameChars); i >= 0 {
+		return fmt.Errorf("artifact name %q contains the invalid character %q", name, name[i])
+	}
+	return nil
+}
+
+// Upload zips files, which must be inside root, and uploads them as an
+// artifact. Entries in the zip are named relative to root.
+func (c *Client) Upload(ctx context.Context, name string, files []string, root string, opts UploadOptions) (*Artifact, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	runID, jobID, err := c.Results.BackendIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	zipFile, err := os.CreateTemp("", "artifact-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(zipFile.Name())
+	defer zipFile.Close()
+	size, digest, err := writeZip(zipFile, files, root, opts.CompressionLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	create := map[string]any{
+		"workflow_run_backend_id":     runID,
+		"workflow_job_run_backend_id": jobID,
+		"name":                        name,
+		"version":                     4,
+	}
+	if opts.RetentionDays > 0 {
+		create["expires_at"] = time.Now().AddDate(0, 0, opts.RetentionDays).UTC().Format(time.RFC3339)
+	}
+	var created struct {
+		OK  bool   `json:"ok"`
+		URL string `json:"signed_upload_url"`
+	}
+	if err := c.call(ctx, "CreateArtifact", create, &created); err != nil {
+		return nil, fmt.Errorf("failed to create artifact %s: %w", name, err)
+	}
+	if !created.OK {
+		return nil, fmt.Errorf("failed to create artifact %s", name)
+	}
+
+	blob := results.BlobOptions{ChunkSize: opts.ChunkSize, Concurrency: opts.Concurrency, ContentType: "application/zip"}
+	if err := c.Results.UploadBlob(ctx, created.URL, zipFile, size, blob); err != nil {
+		return nil, fmt.Errorf("failed to upload artifact %s: %w", name, err)
+	}
+
+	finalize := map[string]any{
+		"workflow_run_backend_id":     runID,
+		"workflow_job_run_backend_id": jobID,
+		"name":                        name,
+		"size":                        fmt.Sprint(size),
+		"hash":                        results.StringValue{Value: "sha256:" + digest},
+	}
+	var finalized struct {
+		OK bool  `json:"ok"`
+		ID int64 `json:"artifact_id,string"`
+	}
+	if err := c.call(ctx, "FinalizeArtifact", finalize, &finalized); err != nil {
+		return nil, fmt.Errorf("failed to finalize artifact %s: %w", name, err)
+	}
+	if !finalized.OK {
+		return nil, fmt.Errorf("failed to finalize artifact %s", name)
+	}
+	return &Artifact{ID: finalized.ID, Name: name, Size: size, CreatedAt: time.Now(), Digest: "sha256:" + digest}, nil
+}
+
+// writeZip writes the files to w as a zip, returning its size and SHA-256.
+func writeZip(w io.Writer, files []string, root string, level int) (int64, string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return 0, "", err
+	}
+	if level == 0 {
+		level = 6
+	}
+	h := sha256.New()
+	cw := &countWriter{w: io.MultiWriter(w, h)}
+	zw := zip.NewWriter(cw)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+	seen := map[string]bool{}
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return 0, "", err
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return 0, "", fmt.Errorf("%s is not inside the root directory %s", file, root)
+		}
+		rel = filepath.ToSlash(rel)
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		if err := addFile(zw, abs, rel, level); err != nil {
+			return 0, "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, "", err
+	}
+	return cw.n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func addFile(zw *zip.Writer, path, name string, level int) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+		_, err := zw.CreateHeader(hdr)
+		return err
+	}
+	hdr.Method = zip.Deflate
+	if level < 0 {
+		hdr.Method = zip.Store
+	}
+	w, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
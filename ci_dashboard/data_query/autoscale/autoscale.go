@@ -0,0 +1,421 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package autoscale starts ephemeral self-hosted runners when workflow_job
+// webhooks report queued jobs and stops idle ones, within per-pool limits.
+package autoscale
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/events"
+)
+
+// Pool is a kind of runner, serving the jobs whose labels it has.
+type Pool struct {
+	Name string `yaml:"name"`
+	// Labels are given to the pool's runners. A job is served by the first
+	// pool that has all of its labels; self-hosted is implied.
+	Labels []string `yaml:"labels"`
+	// Min runners are kept idle; Max caps the runners of the pool.
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+	// IdleTimeout is how long a runner above Min may wait for a job before
+	// it is stopped. Defaults to DefaultIdleTimeout.
+	IdleTimeout time.Duration `yaml:"idle-timeout"`
+	// Group is the runner group of organization runners.
+	Group string `yaml:"group"`
+	// Image is the runner image for provisioners that use one.
+	Image string `yaml:"image"`
+}
+
+// DefaultIdleTimeout is the idle timeout of pools that do not set one.
+const DefaultIdleTimeout = 10 * time.Minute
+
+// Serves reports whether the pool's runners have every label of a job.
+func (p *Pool) Serves(labels []string) bool {
+	for _, l := range labels {
+		if !strings.EqualFold(l, "self-hosted") && !slices.ContainsFunc(p.Labels, func(have string) bool { return strings.EqualFold(have, l) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// Config is the autoscaler configuration file.
+type Config struct {
+	// Prefix starts the names of the runners the autoscaler creates.
+	// Defaults to "autoscale-".
+	Prefix string  `yaml:"prefix"`
+	Pools  []*Pool `yaml:"pools"`
+}
+
+// LoadConfig reads a configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func (c *Config) validate() error {
+	names := map[string]bool{}
+	for _, p := range c.Pools {
+		switch {
+		case p.Name == "":
+			return fmt.Errorf("pool without a name")
+		case names[p.Name]:
+			return fmt.Errorf("pool %s is defined twice", p.Name)
+		case len(p.Labels) == 0:
+			return fmt.Errorf("pool %s has no labels", p.Name)
+		case p.Max <= 0 || p.Min < 0 || p.Min > p.Max:
+			return fmt.Errorf("pool %s: want 0 <= min <= max and max > 0", p.Name)
+		}
+		names[p.Name] = true
+	}
+	return nil
+}
+
+// Provisioner creates and destroys the machines or containers runners run
+// on.
+type Provisioner interface {
+	// Launch starts a runner of pool with a just-in-time configuration.
+	// The runner exits after one job.
+	Launch(ctx context.Context, pool *Pool, name, jitConfig string) error
+	// Stop destroys the runner named name. It is called for runners that
+	// exited, too, and must succeed if there is nothing left to destroy.
+	Stop(ctx context.Context, name string) error
+}
+
+// runner is a runner the autoscaler started.
+type runner struct {
+	name string
+	id   int64
+	pool *Pool
+	// idleSince is when the runner started or was last known idle. It is
+	// zero while the runner runs a job.
+	idleSince time.Time
+	job       int64
+}
+
+type queuedJob struct {
+	labels string
+	pool   *Pool
+}
+
+// Scaler tracks demand from workflow_job events and scales pools to it.
+type Scaler struct {
+	Client      *client.Client
+	Scope       client.Scope
+	Provisioner Provisioner
+	Config      *Config
+	// Logf reports each change. Defaults to discarding.
+	Logf func(format string, args ...any)
+
+	mu sync.Mutex
+	// queued holds queued jobs by ID.
+	queued map[int64]*queuedJob
+	// runners are keyed by name.
+	runners map[string]*runner
+	// starting counts launches in flight by pool.
+	starting map[string]int
+}
+
+// Register subscribes the scaler to workflow_job events.
+func (s *Scaler) Register(d *events.Dispatcher) {
+	events.On(d, s.HandleWorkflowJob)
+}
+
+// HandleWorkflowJob updates demand from a workflow_job event and scales
+// the pool serving the job.
+func (s *Scaler) HandleWorkflowJob(ctx context.Context, e *events.WorkflowJobEvent) error {
+	job := e.WorkflowJob
+	pool := s.pool(job.Labels)
+	s.mu.Lock()
+	s.init()
+	var done *runner
+	switch e.Action {
+	case "queued":
+		// Jobs no pool serves still count as demand, so they show up in
+		// Demand and point at a missing pool.
+		s.queued[job.ID] = &queuedJob{labels: LabelSet(job.Labels), pool: pool}
+	case "in_progress":
+		delete(s.queued, job.ID)
+		if r := s.runners[job.RunnerName]; r != nil {
+			r.idleSince, r.job = time.Time{}, job.ID
+		}
+	case "completed":
+		delete(s.queued, job.ID)
+		// Ephemeral runners exit after their job.
+		if r := s.runners[job.RunnerName]; r != nil {
+			done = r
+			delete(s.runners, r.name)
+		}
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	if done != nil {
+		if err := s.Provisioner.Stop(ctx, done.name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop runner %s: %w", done.name, err))
+		}
+		s.logf("runner %s finished job %d", done.name, job.ID)
+		if pool == nil {
+			pool = done.pool
+		}
+	}
+	if pool != nil {
+		errs = append(errs, s.scale(ctx, pool))
+	}
+	return errors.Join(errs...)
+}
+
+// Demand returns the number of queued jobs by LabelSet.
+func (s *Scaler) Demand() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := map[string]int{}
+	for _, j := range s.queued {
+		out[j.labels]++
+	}
+	return out
+}
+
+// LabelSet returns the key Demand uses for a job's labels: lowercased,
+// sorted, deduplicated and joined by commas.
+func LabelSet(labels []string) string {
+	set := make([]string, len(labels))
+	for i, l := range labels {
+		set[i] = strings.ToLower(l)
+	}
+	slices.Sort(set)
+	return strings.Join(slices.Compact(set), ",")
+}
+
+// Tick stops runners that have been idle too long and tops pools up to
+// their minimum. Call it periodically, as Run does.
+func (s *Scaler) Tick(ctx context.Context) error {
+	var errs []error
+	for _, pool := range s.Config.Pools {
+		errs = append(errs, s.scale(ctx, pool))
+	}
+	return errors.Join(errs...)
+}
+
+// Run calls Tick every interval until ctx is done.
+func (s *Scaler) Run(ctx context.Context, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil {
+			s.logf("autoscale: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// scale starts runners until the pool has one per queued job, or Min,
+// capped at Max, and stops runners idle past the timeout beyond that.
+func (s *Scaler) scale(ctx context.Context, pool *Pool) error {
+	s.mu.Lock()
+	s.init()
+	var busy, idle []*runner
+	for _, r := range s.runners {
+		if r.pool != pool {
+			continue
+		}
+		if r.idleSince.IsZero() {
+			busy = append(busy, r)
+		} else {
+			idle = append(idle, r)
+		}
+	}
+	queued := 0
+	for _, j := range s.queued {
+		if j.pool == pool {
+			queued++
+		}
+	}
+	want := min(pool.Max, max(pool.Min, len(busy)+queued))
+	have := len(busy) + len(idle) + s.starting[pool.Name]
+	start := max(0, want-have)
+	s.starting[pool.Name] += start
+
+	// Stop the longest idle runners first.
+	var stop []*runner
+	slices.SortFunc(idle, func(a, b *runner) int { return a.idleSince.Compare(b.idleSince) })
+	timeout := pool.IdleTimeout
+	if timeout <= 0 {
+		timeout = DefaultIdleTimeout
+	}
+	for _, r := range idle {
+		if have-len(stop) <= want || time.Since(r.idleSince) < timeout {
+			break
+		}
+		stop = append(stop, r)
+		delete(s.runners, r.name)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for range start {
+		if err := s.launch(ctx, pool); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, r := range stop {
+		if err := s.stop(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scaler) launch(ctx context.Context, pool *Pool) error {
+	defer func() {
+		s.mu.Lock()
+		s.starting[pool.Name]--
+		s.mu.Unlock()
+	}()
+	name := s.prefix() + pool.Name + "-" + randomSuffix()
+	labels := pool.Labels
+	if !slices.ContainsFunc(labels, func(l string) bool { return strings.EqualFold(l, "self-hosted") }) {
+		labels = append([]string{"self-hosted"}, labels...)
+	}
+	group, err := s.groupID(ctx, pool.Group)
+	if err != nil {
+		return err
+	}
+	cfg, err := s.Client.GenerateJITConfig(ctx, s.Scope, client.JITConfigOptions{Name: name, Labels: labels, GroupID: group})
+	if err != nil {
+		return fmt.Errorf("failed to register runner %s: %w", name, err)
+	}
+	r := &runner{name: name, pool: pool, idleSince: time.Now()}
+	if cfg.Runner != nil {
+		r.id = cfg.Runner.ID
+	}
+	if err := s.Provisioner.Launch(ctx, pool, name, cfg.Encoded); err != nil {
+		if r.id != 0 {
+			s.Client.DeleteRunner(ctx, s.Scope, r.id)
+		}
+		return fmt.Errorf("failed to launch runner %s: %w", name, err)
+	}
+	s.mu.Lock()
+	s.runners[name] = r
+	s.mu.Unlock()
+	s.logf("launched runner %s for pool %s", name, pool.Name)
+	return nil
+}
+
+// stop unregisters an idle runner and destroys it. If the runner picked
+// up a job meanwhile, the API refuses and the runner is kept.
+func (s *Scaler) stop(ctx context.Context, r *runner) error {
+	if r.id != 0 {
+		if err := s.Client.DeleteRunner(ctx, s.Scope, r.id); err != nil && !client.IsNotFound(err) {
+			s.mu.Lock()
+			s.runners[r.name] = r
+			s.mu.Unlock()
+			return fmt.Errorf("failed to remove runner %s: %w", r.name, err)
+		}
+	}
+	if err := s.Provisioner.Stop(ctx, r.name); err != nil {
+		return fmt.Errorf("failed to stop runner %s: %w", r.name, err)
+	}
+	s.logf("stopped idle runner %s", r.name)
+	return nil
+}
+
+// groupID returns the ID of a runner group, or of the default group.
+func (s *Scaler) groupID(ctx context.Context, name string) (int64, error) {
+	if name == "" {
+		return 1, nil
+	}
+	if s.Scope.Repo != "" {
+		return 0, fmt.Errorf("runner groups are only available to organization runners")
+	}
+	groups, err := s.Client.ListRunnerGroups(ctx, s.Scope.Owner)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list runner groups: %w", err)
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			return g.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("organization %s has no runner group %q", s.Scope.Owner, name)
+}
+
+// pool returns the first pool serving labels, or nil.
+func (s *Scaler) pool(labels []string) *Pool {
+	for _, p := range s.Config.Pools {
+		if p.Serves(labels) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Scaler) init() {
+	if s.runners != nil {
+		return
+	}
+	s.runners = map[string]*runner{}
+	s.starting = map[string]int{}
+	s.queued = map[int64]*queuedJob{}
+}
+
+func (s *Scaler) prefix() string {
+	if s.Config.Prefix == "" {
+		return "autoscale-"
+	}
+	return s.Config.Prefix
+}
+
+func (s *Scaler) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
+
+func randomSuffix() string {
+	var b [4]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
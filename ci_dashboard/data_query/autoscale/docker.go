@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package autoscale
+
+import (
+	"context"
+
+	"testingdashboard/m/v2/docker"
+)
+
+// DefaultImage is the runner image of pools that do not set one.
+const DefaultImage = "ghcr.io/actions/actions-runner:latest"
+
+// DockerProvisioner runs each runner in a container that is removed when
+// the runner exits.
+type DockerProvisioner struct {
+	Client *docker.Client
+	// Network is the network mode of the containers, such as host.
+	Network string
+	// Binds are mounted into every container, such as
+	// /var/run/docker.sock:/var/run/docker.sock for jobs that use Docker.
+	Binds []string
+}
+
+// Launch starts a container for the runner, named after it.
+func (p *DockerProvisioner) Launch(ctx context.Context, pool *Pool, name, jitConfig string) error {
+	image := pool.Image
+	if image == "" {
+		image = DefaultImage
+	}
+	id, err := p.Client.ContainerCreate(ctx, name, &docker.ContainerConfig{
+		Image: image,
+		Cmd:   []string{"/home/runner/run.sh"},
+		Env:   []string{"ACTIONS_RUNNER_INPUT_JITCONFIG=" + jitConfig},
+		Labels: map[string]string{
+			"actions.autoscale.pool": pool.Name,
+		},
+		HostConfig: docker.HostConfig{
+			Binds:       p.Binds,
+			NetworkMode: p.Network,
+			AutoRemove:  true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.Client.ContainerStart(ctx, id); err != nil {
+		p.Client.ContainerRemove(ctx, id, true)
+		return err
+	}
+	return nil
+}
+
+// Stop removes the runner's container, if it has not exited already.
+func (p *DockerProvisioner) Stop(ctx context.Context, name string) error {
+	if err := p.Client.ContainerRemove(ctx, name, true); err != nil && !docker.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
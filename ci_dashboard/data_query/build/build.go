@@ -0,0 +1,310 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package build constructs workflows in Go and writes them as canonical
+// YAML:
+//
+//	wf := build.NewWorkflow("CI").
+//		OnPush(build.Branches("main")).
+//		OnPullRequest().
+//		Job("test").
+//		RunsOn("ubuntu-latest").
+//		Step(build.Uses("actions/checkout@v4")).
+//		Step(build.Run("go test ./...").Name("Test"))
+//	data, err := wf.YAML()
+//
+// Mistakes such as a job needing an unknown job are collected as the
+// workflow is built and reported by Build and YAML.
+package build
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"testingdashboard/m/v2/lint"
+	"testingdashboard/m/v2/workflow"
+)
+
+// WorkflowBuilder builds a workflow.
+type WorkflowBuilder struct {
+	wf      *workflow.Workflow
+	jobs    []*JobBuilder
+	comment string
+	errs    []error
+}
+
+// NewWorkflow starts a workflow named name.
+func NewWorkflow(name string) *WorkflowBuilder {
+	return &WorkflowBuilder{wf: &workflow.Workflow{Name: name, Jobs: map[string]*workflow.Job{}}}
+}
+
+func (b *WorkflowBuilder) errorf(format string, args ...any) {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+}
+
+// Comment sets the comment at the top of the file. Lines are prefixed
+// with # as needed.
+func (b *WorkflowBuilder) Comment(text string) *WorkflowBuilder {
+	b.comment = text
+	return b
+}
+
+// RunName sets run-name.
+func (b *WorkflowBuilder) RunName(name string) *WorkflowBuilder {
+	b.wf.RunName = name
+	return b
+}
+
+// EventOption configures a trigger.
+type EventOption func(*workflow.Event)
+
+// Branches limits a push or pull request trigger to branches matching the
+// patterns.
+func Branches(patterns ...string) EventOption {
+	return func(ev *workflow.Event) { ev.Branches = append(ev.Branches, patterns...) }
+}
+
+// BranchesIgnore skips branches matching the patterns.
+func BranchesIgnore(patterns ...string) EventOption {
+	return func(ev *workflow.Event) { ev.BranchesIgnore = append(ev.BranchesIgnore, patterns...) }
+}
+
+// Tags limits a push trigger to tags matching the patterns.
+func Tags(patterns ...string) EventOption {
+	return func(ev *workflow.Event) { ev.Tags = append(ev.Tags, patterns...) }
+}
+
+// TagsIgnore skips tags matching the patterns.
+func TagsIgnore(patterns ...string) EventOption {
+	return func(ev *workflow.Event) { ev.TagsIgnore = append(ev.TagsIgnore, patterns...) }
+}
+
+// Paths limits a trigger to changes of files matching the patterns.
+func Paths(patterns ...string) EventOption {
+	return func(ev *workflow.Event) { ev.Paths = append(ev.Paths, patterns...) }
+}
+
+// PathsIgnore skips changes only to files matching the patterns.
+func PathsIgnore(patterns ...string) EventOption {
+	return func(ev *workflow.Event) { ev.PathsIgnore = append(ev.PathsIgnore, patterns...) }
+}
+
+// Types limits a trigger to activity types such as opened.
+func Types(types ...string) EventOption {
+	return func(ev *workflow.Event) { ev.Types = append(ev.Types, types...) }
+}
+
+// Workflows names the workflows a workflow_run trigger follows.
+func Workflows(names ...string) EventOption {
+	return func(ev *workflow.Event) { ev.Workflows = append(ev.Workflows, names...) }
+}
+
+// Input declares an input of a workflow_dispatch or workflow_call trigger.
+func Input(name string, in workflow.Input) EventOption {
+	return func(ev *workflow.Event) {
+		if ev.Inputs == nil {
+			ev.Inputs = map[string]*workflow.Input{}
+		}
+		ev.Inputs[name] = &in
+	}
+}
+
+// Secret declares a secret of a workflow_call trigger.
+func Secret(name string, s workflow.Secret) EventOption {
+	return func(ev *workflow.Event) {
+		if ev.Secrets == nil {
+			ev.Secrets = map[string]*workflow.Secret{}
+		}
+		ev.Secrets[name] = &s
+	}
+}
+
+// Output declares an output of a workflow_call trigger.
+func Output(name, description, value string) EventOption {
+	return func(ev *workflow.Event) {
+		if ev.Outputs == nil {
+			ev.Outputs = map[string]*workflow.Output{}
+		}
+		ev.Outputs[name] = &workflow.Output{Description: description, Value: value}
+	}
+}
+
+// On adds a trigger on event. Adding an event again applies the options
+// to the existing trigger.
+func (b *WorkflowBuilder) On(event string, opts ...EventOption) *WorkflowBuilder {
+	if !slices.Contains(lint.Events, event) {
+		b.errorf("unknown event %q", event)
+	}
+	ev := b.wf.On.Event(event)
+	if ev == nil {
+		ev = &workflow.Event{Name: event}
+		if b.wf.On.Events == nil {
+			b.wf.On.Events = map[string]*workflow.Event{}
+		}
+		b.wf.On.Names = append(b.wf.On.Names, event)
+		b.wf.On.Events[event] = ev
+	}
+	for _, opt := range opts {
+		opt(ev)
+	}
+	return b
+}
+
+// OnPush adds a push trigger.
+func (b *WorkflowBuilder) OnPush(opts ...EventOption) *WorkflowBuilder {
+	return b.On("push", opts...)
+}
+
+// OnPullRequest adds a pull_request trigger.
+func (b *WorkflowBuilder) OnPullRequest(opts ...EventOption) *WorkflowBuilder {
+	return b.On("pull_request", opts...)
+}
+
+// OnWorkflowDispatch adds a manual trigger.
+func (b *WorkflowBuilder) OnWorkflowDispatch(opts ...EventOption) *WorkflowBuilder {
+	return b.On("workflow_dispatch", opts...)
+}
+
+// OnWorkflowCall makes the workflow reusable.
+func (b *WorkflowBuilder) OnWorkflowCall(opts ...EventOption) *WorkflowBuilder {
+	return b.On("workflow_call", opts...)
+}
+
+// OnSchedule adds a schedule trigger with the cron expressions.
+func (b *WorkflowBuilder) OnSchedule(crons ...string) *WorkflowBuilder {
+	b.On("schedule")
+	ev := b.wf.On.Event("schedule")
+	for _, cron := range crons {
+		ev.Schedules = append(ev.Schedules, workflow.Schedule{Cron: cron})
+	}
+	return b
+}
+
+// Permission grants the GITHUB_TOKEN of every job level on scope.
+func (b *WorkflowBuilder) Permission(scope, level string) *WorkflowBuilder {
+	b.wf.Permissions = grant(b.errorf, b.wf.Permissions, scope, level)
+	return b
+}
+
+// ReadAll grants every job read access to all scopes.
+func (b *WorkflowBuilder) ReadAll() *WorkflowBuilder {
+	b.wf.Permissions = &workflow.Permissions{All: "read-all"}
+	return b
+}
+
+// NoPermissions grants the GITHUB_TOKEN nothing by default.
+func (b *WorkflowBuilder) NoPermissions() *WorkflowBuilder {
+	b.wf.Permissions = &workflow.Permissions{Scopes: map[string]string{}}
+	return b
+}
+
+// Env sets a variable for every job.
+func (b *WorkflowBuilder) Env(name, value string) *WorkflowBuilder {
+	b.wf.Env = set(b.wf.Env, name, value)
+	return b
+}
+
+// Concurrency sets the concurrency group of the workflow.
+func (b *WorkflowBuilder) Concurrency(group string, cancelInProgress bool) *WorkflowBuilder {
+	b.wf.Concurrency = concurrency(group, cancelInProgress)
+	return b
+}
+
+// DefaultShell sets defaults.run.shell.
+func (b *WorkflowBuilder) DefaultShell(shell string) *WorkflowBuilder {
+	b.wf.Defaults = defaultShell(b.wf.Defaults, shell)
+	return b
+}
+
+// Job adds a job, or returns the one already added with id.
+func (b *WorkflowBuilder) Job(id string) *JobBuilder {
+	for _, j := range b.jobs {
+		if j.job.ID == id {
+			return j
+		}
+	}
+	j := &JobBuilder{wb: b, job: &workflow.Job{ID: id}}
+	b.jobs = append(b.jobs, j)
+	b.wf.Jobs[id] = j.job
+	return j
+}
+
+// Build returns the workflow, or the mistakes made building it.
+func (b *WorkflowBuilder) Build() (*workflow.Workflow, error) {
+	errs := slices.Clone(b.errs)
+	if len(b.wf.On.Names) == 0 {
+		errs = append(errs, errors.New("workflow has no triggers"))
+	}
+	if len(b.jobs) == 0 {
+		errs = append(errs, errors.New("workflow has no jobs"))
+	}
+	for _, j := range b.jobs {
+		errs = append(errs, j.check()...)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return b.wf, nil
+}
+
+// WriteFile writes the workflow to path.
+func (b *WorkflowBuilder) WriteFile(path string) error {
+	data, err := b.YAML()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func set(m map[string]string, k, v string) map[string]string {
+	if m == nil {
+		m = map[string]string{}
+	}
+	m[k] = v
+	return m
+}
+
+func grant(errorf func(string, ...any), p *workflow.Permissions, scope, level string) *workflow.Permissions {
+	switch level {
+	case workflow.PermissionRead, workflow.PermissionWrite, workflow.PermissionNone:
+	default:
+		errorf("invalid permission level %q for %s", level, scope)
+	}
+	if p == nil || p.All != "" {
+		p = &workflow.Permissions{}
+	}
+	p.Scopes = set(p.Scopes, scope, level)
+	return p
+}
+
+func concurrency(group string, cancel bool) *workflow.Concurrency {
+	c := &workflow.Concurrency{Group: group}
+	if cancel {
+		c.CancelInProgress = &workflow.BoolExpr{Value: true}
+	}
+	return c
+}
+
+func defaultShell(d *workflow.Defaults, shell string) *workflow.Defaults {
+	if d == nil {
+		d = &workflow.Defaults{}
+	}
+	if d.Run == nil {
+		d.Run = &workflow.RunDefaults{}
+	}
+	d.Run.Shell = shell
+	return d
+}
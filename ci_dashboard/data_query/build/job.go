@@ -0,0 +1,376 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// JobBuilder builds a job of a workflow. Its methods return the builder
+// so calls chain; Job and Workflow continue with the rest of the workflow.
+type JobBuilder struct {
+	wb      *WorkflowBuilder
+	job     *workflow.Job
+	comment string
+	steps   []*StepBuilder
+}
+
+// Job continues with another job of the workflow.
+func (j *JobBuilder) Job(id string) *JobBuilder { return j.wb.Job(id) }
+
+// Workflow returns the builder of the workflow.
+func (j *JobBuilder) Workflow() *WorkflowBuilder { return j.wb }
+
+// YAML is shorthand for j.Workflow().YAML().
+func (j *JobBuilder) YAML() ([]byte, error) { return j.wb.YAML() }
+
+// Build is shorthand for j.Workflow().Build().
+func (j *JobBuilder) Build() (*workflow.Workflow, error) { return j.wb.Build() }
+
+// Comment sets the comment written above the job.
+func (j *JobBuilder) Comment(text string) *JobBuilder {
+	j.comment = text
+	return j
+}
+
+// Name sets the display name of the job.
+func (j *JobBuilder) Name(name string) *JobBuilder {
+	j.job.Name = name
+	return j
+}
+
+// RunsOn sets the runner labels.
+func (j *JobBuilder) RunsOn(labels ...string) *JobBuilder {
+	j.job.RunsOn = &workflow.RunsOn{Labels: labels}
+	return j
+}
+
+// RunsOnGroup runs the job in a runner group, optionally limited to labels.
+func (j *JobBuilder) RunsOnGroup(group string, labels ...string) *JobBuilder {
+	j.job.RunsOn = &workflow.RunsOn{Group: group, Labels: labels}
+	return j
+}
+
+// Needs adds jobs that must finish first.
+func (j *JobBuilder) Needs(ids ...string) *JobBuilder {
+	j.job.Needs = append(j.job.Needs, ids...)
+	return j
+}
+
+// If sets the condition of the job.
+func (j *JobBuilder) If(cond string) *JobBuilder {
+	j.job.If = cond
+	return j
+}
+
+// Permission grants the job's GITHUB_TOKEN level on scope.
+func (j *JobBuilder) Permission(scope, level string) *JobBuilder {
+	j.job.Permissions = grant(j.errorf, j.job.Permissions, scope, level)
+	return j
+}
+
+// NoPermissions grants the job's GITHUB_TOKEN nothing.
+func (j *JobBuilder) NoPermissions() *JobBuilder {
+	j.job.Permissions = &workflow.Permissions{Scopes: map[string]string{}}
+	return j
+}
+
+// Environment deploys to the named environment, with an optional URL.
+func (j *JobBuilder) Environment(name, url string) *JobBuilder {
+	j.job.Environment = &workflow.Environment{Name: name, URL: url}
+	return j
+}
+
+// Concurrency sets the concurrency group of the job.
+func (j *JobBuilder) Concurrency(group string, cancelInProgress bool) *JobBuilder {
+	j.job.Concurrency = concurrency(group, cancelInProgress)
+	return j
+}
+
+// Output declares an output of the job.
+func (j *JobBuilder) Output(name, value string) *JobBuilder {
+	j.job.Outputs = set(j.job.Outputs, name, value)
+	return j
+}
+
+// Env sets a variable for the steps of the job.
+func (j *JobBuilder) Env(name, value string) *JobBuilder {
+	j.job.Env = set(j.job.Env, name, value)
+	return j
+}
+
+// DefaultShell sets defaults.run.shell of the job.
+func (j *JobBuilder) DefaultShell(shell string) *JobBuilder {
+	j.job.Defaults = defaultShell(j.job.Defaults, shell)
+	return j
+}
+
+// TimeoutMinutes limits how long the job runs.
+func (j *JobBuilder) TimeoutMinutes(n float64) *JobBuilder {
+	j.job.TimeoutMinutes = &workflow.NumberExpr{Value: n}
+	return j
+}
+
+// ContinueOnError lets the workflow succeed when the job fails.
+func (j *JobBuilder) ContinueOnError() *JobBuilder {
+	j.job.ContinueOnError = &workflow.BoolExpr{Value: true}
+	return j
+}
+
+func (j *JobBuilder) strategy() *workflow.Strategy {
+	if j.job.Strategy == nil {
+		j.job.Strategy = &workflow.Strategy{}
+	}
+	return j.job.Strategy
+}
+
+func (j *JobBuilder) matrix() *workflow.Matrix {
+	s := j.strategy()
+	if s.Matrix == nil {
+		s.Matrix = &workflow.Matrix{}
+	}
+	return s.Matrix
+}
+
+// Matrix adds a matrix dimension. Dimensions are written in the order
+// they are added.
+func (j *JobBuilder) Matrix(name string, values ...any) *JobBuilder {
+	m := j.matrix()
+	for _, dim := range m.Dimensions {
+		if dim.Name == name {
+			j.errorf("job %s: duplicate matrix dimension %q", j.job.ID, name)
+			return j
+		}
+	}
+	m.Dimensions = append(m.Dimensions, workflow.Dimension{Name: name, Values: values})
+	return j
+}
+
+// MatrixInclude adds a combination to matrix.include.
+func (j *JobBuilder) MatrixInclude(combo map[string]any) *JobBuilder {
+	m := j.matrix()
+	m.Include = append(m.Include, combo)
+	return j
+}
+
+// MatrixExclude adds a combination to matrix.exclude.
+func (j *JobBuilder) MatrixExclude(combo map[string]any) *JobBuilder {
+	m := j.matrix()
+	m.Exclude = append(m.Exclude, combo)
+	return j
+}
+
+// FailFast sets whether one failed combination cancels the others.
+func (j *JobBuilder) FailFast(v bool) *JobBuilder {
+	j.strategy().FailFast = &workflow.BoolExpr{Value: v}
+	return j
+}
+
+// MaxParallel limits how many combinations run at once.
+func (j *JobBuilder) MaxParallel(n float64) *JobBuilder {
+	j.strategy().MaxParallel = &workflow.NumberExpr{Value: n}
+	return j
+}
+
+// Container runs the steps of the job in the container c.
+func (j *JobBuilder) Container(c workflow.Container) *JobBuilder {
+	j.job.Container = &c
+	return j
+}
+
+// Service starts a service container beside the job.
+func (j *JobBuilder) Service(name string, c workflow.Container) *JobBuilder {
+	if j.job.Services == nil {
+		j.job.Services = map[string]*workflow.Container{}
+	}
+	j.job.Services[name] = &c
+	return j
+}
+
+// Uses makes the job call a reusable workflow.
+func (j *JobBuilder) Uses(workflowRef string) *JobBuilder {
+	j.job.Uses = workflowRef
+	return j
+}
+
+// With passes an input to the reusable workflow the job calls.
+func (j *JobBuilder) With(name string, value any) *JobBuilder {
+	if j.job.With == nil {
+		j.job.With = map[string]any{}
+	}
+	j.job.With[name] = value
+	return j
+}
+
+// Secret passes a secret to the reusable workflow the job calls.
+func (j *JobBuilder) Secret(name, value string) *JobBuilder {
+	if j.job.Secrets == nil {
+		j.job.Secrets = &workflow.JobSecrets{}
+	}
+	j.job.Secrets.Values = set(j.job.Secrets.Values, name, value)
+	return j
+}
+
+// SecretsInherit passes all of the caller's secrets to the reusable
+// workflow the job calls.
+func (j *JobBuilder) SecretsInherit() *JobBuilder {
+	j.job.Secrets = &workflow.JobSecrets{Inherit: true}
+	return j
+}
+
+// Step appends steps to the job.
+func (j *JobBuilder) Step(steps ...*StepBuilder) *JobBuilder {
+	for _, s := range steps {
+		j.steps = append(j.steps, s)
+		j.job.Steps = append(j.job.Steps, s.step)
+	}
+	return j
+}
+
+func (j *JobBuilder) errorf(format string, args ...any) { j.wb.errorf(format, args...) }
+
+// check returns the mistakes in the job.
+func (j *JobBuilder) check() []error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("job %s: "+format, append([]any{j.job.ID}, args...)...))
+	}
+	job := j.job
+	if job.Uses != "" {
+		if job.RunsOn != nil || len(job.Steps) > 0 {
+			add("a job calling a reusable workflow cannot have runs-on or steps")
+		}
+	} else {
+		if job.RunsOn == nil {
+			add("runs-on is required")
+		}
+		if len(job.Steps) == 0 {
+			add("no steps")
+		}
+		if job.With != nil || job.Secrets != nil {
+			add("with and secrets require uses")
+		}
+	}
+	for _, need := range job.Needs {
+		switch {
+		case need == job.ID:
+			add("needs itself")
+		case j.wb.wf.Jobs[need] == nil:
+			add("needs unknown job %q", need)
+		}
+	}
+	var ids []string
+	for i, s := range j.steps {
+		name := fmt.Sprintf("step %d", i+1)
+		if s.step.ID != "" {
+			name = "step " + s.step.ID
+			if slices.Contains(ids, s.step.ID) {
+				add("duplicate step id %q", s.step.ID)
+			}
+			ids = append(ids, s.step.ID)
+		}
+		if (s.step.Run == "") == (s.step.Uses == "") {
+			add("%s must set exactly one of run and uses", name)
+		}
+		if s.step.Shell != "" && s.step.Run == "" {
+			add("%s: shell requires run", name)
+		}
+	}
+	return errs
+}
+
+// StepBuilder builds a step. Start one with Run or Uses.
+type StepBuilder struct {
+	step    *workflow.Step
+	comment string
+}
+
+// Run starts a step running a script. Scripts of several lines are ended
+// with a newline so they are written as plain | blocks.
+func Run(script string) *StepBuilder {
+	if strings.Contains(script, "\n") && !strings.HasSuffix(script, "\n") {
+		script += "\n"
+	}
+	return &StepBuilder{step: &workflow.Step{Run: script}}
+}
+
+// Uses starts a step running an action such as actions/checkout@v4.
+func Uses(action string) *StepBuilder {
+	return &StepBuilder{step: &workflow.Step{Uses: action}}
+}
+
+// Comment sets the comment written above the step.
+func (s *StepBuilder) Comment(text string) *StepBuilder {
+	s.comment = text
+	return s
+}
+
+// Name sets the display name of the step.
+func (s *StepBuilder) Name(name string) *StepBuilder {
+	s.step.Name = name
+	return s
+}
+
+// ID sets the id later steps refer to the step by.
+func (s *StepBuilder) ID(id string) *StepBuilder {
+	s.step.ID = id
+	return s
+}
+
+// If sets the condition of the step.
+func (s *StepBuilder) If(cond string) *StepBuilder {
+	s.step.If = cond
+	return s
+}
+
+// With passes an input to the action.
+func (s *StepBuilder) With(name, value string) *StepBuilder {
+	s.step.With = set(s.step.With, name, value)
+	return s
+}
+
+// Env sets a variable for the step.
+func (s *StepBuilder) Env(name, value string) *StepBuilder {
+	s.step.Env = set(s.step.Env, name, value)
+	return s
+}
+
+// Shell sets the shell the script runs in.
+func (s *StepBuilder) Shell(shell string) *StepBuilder {
+	s.step.Shell = shell
+	return s
+}
+
+// WorkingDirectory sets the directory the script runs in.
+func (s *StepBuilder) WorkingDirectory(dir string) *StepBuilder {
+	s.step.WorkingDirectory = dir
+	return s
+}
+
+// ContinueOnError lets the job continue when the step fails.
+func (s *StepBuilder) ContinueOnError() *StepBuilder {
+	s.step.ContinueOnError = &workflow.BoolExpr{Value: true}
+	return s
+}
+
+// TimeoutMinutes limits how long the step runs.
+func (s *StepBuilder) TimeoutMinutes(n float64) *StepBuilder {
+	s.step.TimeoutMinutes = &workflow.NumberExpr{Value: n}
+	return s
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/format"
+	"testingdashboard/m/v2/workflow"
+)
+
+// YAML returns the workflow in the style of format.Source, with jobs in
+// the order they were added and free-form mappings such as env sorted. The
+// same workflow always produces the same bytes.
+func (b *WorkflowBuilder) YAML() ([]byte, error) {
+	wf, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	root, err := b.node(wf)
+	if err != nil {
+		return nil, err
+	}
+	data, err := format.Node(root)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := workflow.Parse(data); err != nil {
+		return nil, fmt.Errorf("generated workflow does not parse: %w", err)
+	}
+	return data, nil
+}
+
+// node returns the document node of wf, with jobs in insertion order.
+func (b *WorkflowBuilder) node(wf *workflow.Workflow) (*yaml.Node, error) {
+	top := *wf
+	top.Jobs = nil
+	root := &yaml.Node{}
+	if err := root.Encode(&top); err != nil {
+		return nil, err
+	}
+	jobs := &yaml.Node{Kind: yaml.MappingNode}
+	for _, j := range b.jobs {
+		value, err := j.node()
+		if err != nil {
+			return nil, err
+		}
+		jobs.Content = append(jobs.Content, key(j.job.ID, j.comment), value)
+	}
+	if v := workflow.MappingValue(root, "jobs"); v != nil {
+		*v = *jobs
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, HeadComment: comment(b.comment), Content: []*yaml.Node{root}}, nil
+}
+
+// node returns the mapping of the job, with comments on its steps.
+func (j *JobBuilder) node() (*yaml.Node, error) {
+	out := &yaml.Node{}
+	if err := out.Encode(j.job); err != nil {
+		return nil, err
+	}
+	steps := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, s := range j.steps {
+		value := &yaml.Node{}
+		if err := value.Encode(s.step); err != nil {
+			return nil, err
+		}
+		value.HeadComment = comment(s.comment)
+		steps.Content = append(steps.Content, value)
+	}
+	if v := workflow.MappingValue(out, "steps"); v != nil {
+		*v = *steps
+	}
+	return out, nil
+}
+
+func key(name, text string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: name, HeadComment: comment(text)}
+}
+
+// comment turns text into comment lines.
+func comment(text string) string {
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "#"):
+		case line == "":
+			lines[i] = "#"
+		default:
+			lines[i] = "# " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"testingdashboard/m/v2/glob"
+)
+
+// ResolvePaths expands the path patterns of a cache, relative to the
+// workspace, into the files and directories to archive.
+func ResolvePaths(workspace string, patterns []string) ([]string, error) {
+	opts := glob.DefaultOptions()
+	opts.Root = workspace
+	opts.ImplicitDescendants = false
+	g, err := glob.New(strings.Join(patterns, "\n"), opts)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := g.Glob()
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, m := range matches {
+		rel, err := filepath.Rel(workspace, m)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	return out, nil
+}
+
+// writeArchive writes a compressed tar of paths, which are relative to the
+// workspace, to w. Directories are archived recursively.
+func writeArchive(w io.Writer, workspace string, paths []string, c Compression) error {
+	cw, wait, err := compressor(w, c)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(cw)
+	seen := map[string]bool{}
+	for _, p := range paths {
+		root := filepath.Join(workspace, filepath.FromSlash(p))
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(workspace, path)
+			if err != nil {
+				return err
+			}
+			name := filepath.ToSlash(rel)
+			if seen[name] {
+				if d.IsDir() && path != root {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			seen[name] = true
+			return addEntry(tw, path, name, d)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", p, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := cw.Close(); err != nil {
+		return err
+	}
+	return wait()
+}
+
+func addEntry(tw *tar.Writer, path, name string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extractArchive unpacks a compressed tar into the workspace. Entry names
+// may climb out of the workspace with .., as actions/cache allows caching
+// paths such as ~/.npm.
+func extractArchive(r io.Reader, workspace string, c Compression) error {
+	dr, wait, err := decompressor(r, c)
+	if err != nil {
+		return err
+	}
+	defer dr.Close()
+	tr := tar.NewReader(dr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read cache archive: %w", err)
+		}
+		target := filepath.Join(workspace, filepath.FromSlash(hdr.Name))
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, mode.Perm()|0o700)
+		case tar.TypeSymlink:
+			os.Remove(target)
+			if err = os.MkdirAll(filepath.Dir(target), 0o755); err == nil {
+				err = os.Symlink(hdr.Linkname, target)
+			}
+		case tar.TypeReg:
+			err = writeFile(target, tr, mode.Perm())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", hdr.Name, err)
+		}
+	}
+	// Drain the padding after the end of the archive so zstd can exit.
+	if _, err := io.Copy(io.Discard, dr); err != nil {
+		return err
+	}
+	return wait()
+}
+
+func writeFile(path string, r io.Reader, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// compressor wraps w. Zstd runs the zstd binary, as actions/cache does;
+// wait reports its exit status after Close.
+func compressor(w io.Writer, c Compression) (io.WriteCloser, func() error, error) {
+	switch c {
+	case Gzip:
+		return gzip.NewWriter(w), func() error { return nil }, nil
+	case Zstd, ZstdWithoutLong:
+		args := []string{"-T0", "-q", "-c"}
+		if c == Zstd {
+			args = append(args, "--long=30")
+		}
+		cmd := exec.Command("zstd", args...)
+		cmd.Stdout, cmd.Stderr = w, os.Stderr
+		in, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, nil, fmt.Errorf("failed to start zstd: %w", err)
+		}
+		return in, cmd.Wait, nil
+	}
+	return nil, nil, fmt.Errorf("unknown compression %q", c)
+}
+
+// decompressor wraps r; see compressor.
+func decompressor(r io.Reader, c Compression) (io.ReadCloser, func() error, error) {
+	switch c {
+	case Gzip:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read cache archive: %w", err)
+		}
+		return gr, func() error { return nil }, nil
+	case Zstd, ZstdWithoutLong:
+		args := []string{"-d", "-q", "-c"}
+		if c == Zstd {
+			args = append(args, "--long=30")
+		}
+		cmd := exec.Command("zstd", args...)
+		cmd.Stdin, cmd.Stderr = r, os.Stderr
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, nil, fmt.Errorf("failed to start zstd: %w", err)
+		}
+		return out, cmd.Wait, nil
+	}
+	return nil, nil, fmt.Errorf("unknown compression %q", c)
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/results"
+)
+
+// AzureBucket stores objects as block blobs in an Azure Storage container,
+// authorized with a SAS token.
+type AzureBucket struct {
+	Account   string
+	Container string
+	// SAS is the shared access signature query string. Defaults to
+	// AZURE_STORAGE_SAS_TOKEN.
+	SAS string
+	// Endpoint defaults to https://<account>.blob.core.windows.net, and
+	// can point at Azurite.
+	Endpoint string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func (a *AzureBucket) client() *http.Client {
+	if a.HTTPClient != nil {
+		return a.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// url returns the URL of the container or of a blob in it, with the SAS
+// and extra query parameters.
+func (a *AzureBucket) url(name string, q url.Values) string {
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = "https://" + a.Account + ".blob.core.windows.net"
+	}
+	u := strings.TrimSuffix(endpoint, "/") + "/" + url.PathEscape(a.Container)
+	if name != "" {
+		u += "/" + strings.ReplaceAll(url.PathEscape(name), "%2F", "/")
+	}
+	sas := a.SAS
+	if sas == "" {
+		sas = os.Getenv("AZURE_STORAGE_SAS_TOKEN")
+	}
+	query := strings.TrimPrefix(sas, "?")
+	if extra := q.Encode(); extra != "" {
+		query = strings.TrimPrefix(query+"&"+extra, "&")
+	}
+	return u + "?" + query
+}
+
+func (a *AzureBucket) do(ctx context.Context, method, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", "2023-11-03")
+	resp, err := a.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<12))
+		return nil, fmt.Errorf("azure %s: %s: %s", method, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// Put implements Bucket, uploading in blocks like the hosted cache.
+func (a *AzureBucket) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return fmt.Errorf("azure uploads need an io.ReaderAt")
+	}
+	rc := &results.Client{HTTPClient: a.HTTPClient}
+	return rc.UploadBlob(ctx, a.url(name, nil), ra, size, results.BlobOptions{})
+}
+
+// Get implements Bucket.
+func (a *AzureBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := a.do(ctx, http.MethodGet, a.url(name, nil))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// List implements Bucket.
+func (a *AzureBucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objs []Object
+	marker := ""
+	for {
+		q := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+		if marker != "" {
+			q.Set("marker", marker)
+		}
+		resp, err := a.do(ctx, http.MethodGet, a.url("", q))
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Blobs []struct {
+				Name       string
+				Properties struct {
+					Size     int64  `xml:"Content-Length"`
+					Modified string `xml:"Last-Modified"`
+				}
+			} `xml:"Blobs>Blob"`
+			NextMarker string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode azure listing: %w", err)
+		}
+		for _, b := range result.Blobs {
+			mod, _ := time.Parse(time.RFC1123, b.Properties.Modified)
+			objs = append(objs, Object{Name: b.Name, Size: b.Properties.Size, Modified: mod})
+		}
+		if result.NextMarker == "" {
+			return objs, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// Delete implements Bucket.
+func (a *AzureBucket) Delete(ctx context.Context, name string) error {
+	resp, err := a.do(ctx, http.MethodDelete, a.url(name, nil))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
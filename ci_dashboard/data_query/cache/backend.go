@@ -0,0 +1,114 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"testingdashboard/m/v2/results"
+)
+
+// Entry is a cache entry found by Lookup.
+type Entry struct {
+	Key     string
+	Version string
+	// Size is zero when the backend does not report it.
+	Size int64
+
+	// ref is how the backend finds the entry again.
+	ref string
+}
+
+// Backend stores cache archives.
+type Backend interface {
+	// Lookup returns the entry saved under key with the given version or,
+	// failing that, the newest entry whose key starts with one of the
+	// restore keys, tried in order. It returns nil on a miss.
+	Lookup(ctx context.Context, version, key string, restoreKeys []string) (*Entry, error)
+	// Download writes the archive of e to w and returns its size.
+	Download(ctx context.Context, e *Entry, w io.WriterAt) (int64, error)
+	// Upload stores an archive. It returns ErrReserved if the entry exists
+	// or is being written.
+	Upload(ctx context.Context, version, key string, r io.ReaderAt, size int64) error
+}
+
+// Service is the Backend of GitHub-hosted runs, the Actions cache service.
+type Service struct {
+	Results *results.Client
+	// Blob controls segment size and concurrency of transfers.
+	Blob results.BlobOptions
+}
+
+func (s *Service) call(ctx context.Context, method string, in, out any) error {
+	return s.Results.Call(ctx, "CacheService", method, in, out)
+}
+
+// Lookup implements Backend.
+func (s *Service) Lookup(ctx context.Context, version, key string, restoreKeys []string) (*Entry, error) {
+	if restoreKeys == nil {
+		restoreKeys = []string{}
+	}
+	req := map[string]any{"key": key, "restore_keys": restoreKeys, "version": version}
+	var resp struct {
+		OK         bool   `json:"ok"`
+		URL        string `json:"signed_download_url"`
+		MatchedKey string `json:"matched_key"`
+	}
+	if err := s.call(ctx, "GetCacheEntryDownloadURL", req, &resp); err != nil {
+		if results.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up cache %s: %w", key, err)
+	}
+	if !resp.OK {
+		return nil, nil
+	}
+	return &Entry{Key: resp.MatchedKey, Version: version, ref: resp.URL}, nil
+}
+
+// Download implements Backend.
+func (s *Service) Download(ctx context.Context, e *Entry, w io.WriterAt) (int64, error) {
+	return s.Results.DownloadBlob(ctx, e.ref, w, s.Blob)
+}
+
+// Upload implements Backend.
+func (s *Service) Upload(ctx context.Context, version, key string, r io.ReaderAt, size int64) error {
+	var created struct {
+		OK  bool   `json:"ok"`
+		URL string `json:"signed_upload_url"`
+	}
+	if err := s.call(ctx, "CreateCacheEntry", map[string]string{"key": key, "version": version}, &created); err != nil {
+		return fmt.Errorf("failed to reserve cache %s: %w", key, err)
+	}
+	if !created.OK {
+		return fmt.Errorf("%w: %s", ErrReserved, key)
+	}
+	if err := s.Results.UploadBlob(ctx, created.URL, r, size, s.Blob); err != nil {
+		return fmt.Errorf("failed to upload cache %s: %w", key, err)
+	}
+	finalize := map[string]string{"key": key, "version": version, "size_bytes": fmt.Sprint(size)}
+	var finalized struct {
+		OK bool `json:"ok"`
+	}
+	if err := s.call(ctx, "FinalizeCacheEntryUpload", finalize, &finalized); err != nil {
+		return fmt.Errorf("failed to finalize cache %s: %w", key, err)
+	}
+	if !finalized.OK {
+		return fmt.Errorf("failed to finalize cache %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by a Bucket for missing objects.
+var ErrNotFound = errors.New("object not found")
+
+// Object is an object in a bucket.
+type Object struct {
+	Name     string
+	Size     int64
+	Modified time.Time
+}
+
+// Bucket is a flat object store such as S3 or a local directory.
+type Bucket interface {
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	// Get returns ErrNotFound if the object does not exist.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the objects whose names start with prefix.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// BucketBackend keeps cache entries in a Bucket, laid out as
+// <prefix>/<scope>/<version>/<key> with each part path-escaped.
+type BucketBackend struct {
+	Bucket Bucket
+	// Prefix is prepended to every object name.
+	Prefix string
+	// Scopes are searched in order on restore, and entries are saved to
+	// the first. Like the hosted service scoping caches to the branch,
+	// then the base and default branches, this keeps branches from seeing
+	// each other's entries. Defaults to a single "default" scope.
+	Scopes []string
+	// MaxSize and MaxAge, when set, evict entries after each save.
+	MaxSize int64
+	MaxAge  time.Duration
+}
+
+func (b *BucketBackend) scopes() []string {
+	if len(b.Scopes) == 0 {
+		return []string{"default"}
+	}
+	return b.Scopes
+}
+
+func (b *BucketBackend) dir(scope, version string) string {
+	return path.Join(b.Prefix, url.PathEscape(scope), version) + "/"
+}
+
+// Lookup implements Backend. An exact key match in any scope wins over a
+// restore-key match; prefix matches pick the newest entry.
+func (b *BucketBackend) Lookup(ctx context.Context, version, key string, restoreKeys []string) (*Entry, error) {
+	for _, scope := range b.scopes() {
+		dir := b.dir(scope, version)
+		name := dir + url.PathEscape(key)
+		objs, err := b.Bucket.List(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objs {
+			if o.Name == name {
+				return &Entry{Key: key, Version: version, Size: o.Size, ref: o.Name}, nil
+			}
+		}
+	}
+	for _, prefix := range restoreKeys {
+		for _, scope := range b.scopes() {
+			dir := b.dir(scope, version)
+			objs, err := b.Bucket.List(ctx, dir+url.PathEscape(prefix))
+			if err != nil {
+				return nil, err
+			}
+			if len(objs) == 0 {
+				continue
+			}
+			sort.Slice(objs, func(i, j int) bool { return objs[i].Modified.After(objs[j].Modified) })
+			key, err := url.PathUnescape(strings.TrimPrefix(objs[0].Name, dir))
+			if err != nil {
+				return nil, err
+			}
+			return &Entry{Key: key, Version: version, Size: objs[0].Size, ref: objs[0].Name}, nil
+		}
+	}
+	return nil, nil
+}
+
+// Download implements Backend.
+func (b *BucketBackend) Download(ctx context.Context, e *Entry, w io.WriterAt) (int64, error) {
+	r, err := b.Bucket.Get(ctx, e.ref)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	return io.Copy(io.NewOffsetWriter(w, 0), r)
+}
+
+// Upload implements Backend. Entries are immutable, as in the hosted
+// service.
+func (b *BucketBackend) Upload(ctx context.Context, version, key string, r io.ReaderAt, size int64) error {
+	name := b.dir(b.scopes()[0], version) + url.PathEscape(key)
+	objs, err := b.Bucket.List(ctx, name)
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		if o.Name == name {
+			return fmt.Errorf("%w: %s", ErrReserved, key)
+		}
+	}
+	if err := b.Bucket.Put(ctx, name, io.NewSectionReader(r, 0, size), size); err != nil {
+		return fmt.Errorf("failed to upload cache %s: %w", key, err)
+	}
+	if b.MaxSize > 0 || b.MaxAge > 0 {
+		if _, err := b.Evict(ctx, b.MaxSize, b.MaxAge); err != nil {
+			return fmt.Errorf("failed to evict caches: %w", err)
+		}
+	}
+	return nil
+}
+
+// Evict deletes entries older than maxAge, then the oldest entries until
+// the total size is at most maxSize. Zero disables either limit. It returns
+// the deleted objects.
+func (b *BucketBackend) Evict(ctx context.Context, maxSize int64, maxAge time.Duration) ([]Object, error) {
+	prefix := b.Prefix
+	if prefix != "" {
+		prefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+	objs, err := b.Bucket.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Modified.Before(objs[j].Modified) })
+	var total int64
+	for _, o := range objs {
+		total += o.Size
+	}
+	var evicted []Object
+	now := time.Now()
+	for _, o := range objs {
+		expired := maxAge > 0 && now.Sub(o.Modified) > maxAge
+		if !expired && (maxSize <= 0 || total <= maxSize) {
+			continue
+		}
+		if err := b.Bucket.Delete(ctx, o.Name); err != nil && !errors.Is(err, ErrNotFound) {
+			return evicted, err
+		}
+		total -= o.Size
+		evicted = append(evicted, o)
+	}
+	return evicted, nil
+}
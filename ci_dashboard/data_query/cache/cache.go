@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache saves and restores dependency caches with the Actions cache
+// service, compatible with entries written by actions/cache.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Compression is how an archive is compressed. The name is part of the
+// cache version, so entries only match when both sides agree.
+type Compression string
+
+const (
+	Gzip Compression = "gzip"
+	// Zstd uses a long matching window, which costs memory to decompress.
+	Zstd Compression = "zstd"
+	// ZstdWithoutLong is what actions/cache picks when zstd is installed.
+	ZstdWithoutLong Compression = "zstd-without-long"
+)
+
+// DetectCompression returns ZstdWithoutLong if the zstd binary is on PATH,
+// and Gzip otherwise, as actions/cache does.
+func DetectCompression() Compression {
+	if _, err := exec.LookPath("zstd"); err == nil {
+		return ZstdWithoutLong
+	}
+	return Gzip
+}
+
+// archiveName returns the file name actions/cache uses for the archive.
+func (c Compression) archiveName() string {
+	if c == Gzip {
+		return "cache.tgz"
+	}
+	return "cache.tzst"
+}
+
+// versionSalt changes whenever actions/cache changes its archive format.
+const versionSalt = "1.0"
+
+// Version returns the version an entry is scoped to: a hash of the path
+// patterns as written, the compression, and the OS family unless the
+// archive is meant to be shared across operating systems.
+func Version(paths []string, compression Compression, crossOS bool) string {
+	components := append([]string(nil), paths...)
+	if compression != "" {
+		components = append(components, string(compression))
+	}
+	if runtime.GOOS == "windows" && !crossOS {
+		components = append(components, "windows-only")
+	}
+	components = append(components, versionSalt)
+	sum := sha256.Sum256([]byte(strings.Join(components, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+// MaxKeyLength is the longest key the service accepts.
+const MaxKeyLength = 512
+
+// ValidateKey checks a primary or restore key.
+func ValidateKey(key string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("cache key is empty")
+	case len(key) > MaxKeyLength:
+		return fmt.Errorf("key %q is longer than %d characters", key, MaxKeyLength)
+	case strings.Contains(key, ","):
+		return fmt.Errorf("key %q must not contain commas", key)
+	}
+	return nil
+}
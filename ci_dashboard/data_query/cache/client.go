@@ -0,0 +1,161 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// MaxSize is the largest entry the service accepts.
+const MaxSize = 10 << 30
+
+// ErrReserved is returned by Save when another job is already saving an
+// entry with the same key and version.
+var ErrReserved = errors.New("cache entry is being created by another job")
+
+// Client saves and restores caches.
+type Client struct {
+	Backend Backend
+	// Workspace is the directory relative paths are resolved against.
+	// Defaults to GITHUB_WORKSPACE, then the current directory.
+	Workspace string
+}
+
+// NewFromEnv returns a client for the backend configured in the
+// environment; see ConfigFromEnv.
+func NewFromEnv() (*Client, error) {
+	b, err := OpenBackend(ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Backend: b}, nil
+}
+
+// Options controls Save and Restore.
+type Options struct {
+	// Compression defaults to DetectCompression.
+	Compression Compression
+	// CrossOS shares the entry between operating systems.
+	CrossOS bool
+	// LookupOnly checks for a hit without downloading.
+	LookupOnly bool
+}
+
+func (o Options) compression() Compression {
+	if o.Compression == "" {
+		return DetectCompression()
+	}
+	return o.Compression
+}
+
+func (c *Client) workspace() (string, error) {
+	if c.Workspace != "" {
+		return c.Workspace, nil
+	}
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" {
+		return ws, nil
+	}
+	return os.Getwd()
+}
+
+// Save archives the paths, which are patterns as in actions/cache, and
+// stores them under key.
+func (c *Client) Save(ctx context.Context, paths []string, key string, opts Options) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	ws, err := c.workspace()
+	if err != nil {
+		return err
+	}
+	files, err := ResolvePaths(ws, paths)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("path validation error: no files match %v", paths)
+	}
+	compression := opts.compression()
+
+	dir, err := os.MkdirTemp("", "cache-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	archive, err := os.Create(dir + "/" + compression.archiveName())
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	if err := writeArchive(archive, ws, files, compression); err != nil {
+		return err
+	}
+	info, err := archive.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > MaxSize {
+		return fmt.Errorf("cache size of %d bytes is over the %d byte limit", info.Size(), int64(MaxSize))
+	}
+	return c.Backend.Upload(ctx, Version(paths, compression, opts.CrossOS), key, archive, info.Size())
+}
+
+// Restore looks up key, then each restore key as a prefix, and extracts the
+// first entry found. It returns the matched key, or "" on a miss.
+func (c *Client) Restore(ctx context.Context, paths []string, key string, restoreKeys []string, opts Options) (string, error) {
+	for _, k := range append([]string{key}, restoreKeys...) {
+		if err := ValidateKey(k); err != nil {
+			return "", err
+		}
+	}
+	compression := opts.compression()
+	entry, err := c.Backend.Lookup(ctx, Version(paths, compression, opts.CrossOS), key, restoreKeys)
+	if err == nil && entry == nil && compression != Gzip {
+		// The entry may have been saved where zstd is not installed.
+		compression = Gzip
+		entry, err = c.Backend.Lookup(ctx, Version(paths, compression, opts.CrossOS), key, restoreKeys)
+	}
+	if err != nil || entry == nil {
+		return "", err
+	}
+	if opts.LookupOnly {
+		return entry.Key, nil
+	}
+
+	ws, err := c.workspace()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "cache-*-"+compression.archiveName())
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := c.Backend.Download(ctx, entry, f); err != nil {
+		return "", fmt.Errorf("failed to download cache %s: %w", entry.Key, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if err := extractArchive(f, ws, compression); err != nil {
+		return "", err
+	}
+	return entry.Key, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/results"
+)
+
+// Config selects and configures a backend.
+type Config struct {
+	// Backend is "github" (the hosted service), "local", "s3", "gcs" or
+	// "azure". Defaults to "github" when the runtime token is set and
+	// "local" otherwise.
+	Backend string `yaml:"backend"`
+	// Bucket is the S3 or GCS bucket, or the Azure container.
+	Bucket string `yaml:"bucket"`
+	// Prefix is prepended to object names.
+	Prefix string `yaml:"prefix"`
+	// Dir is the directory of the local backend.
+	Dir string `yaml:"dir"`
+	// Region and Endpoint configure S3; Endpoint also overrides the GCS
+	// and Azure service URLs.
+	Region   string `yaml:"region"`
+	Endpoint string `yaml:"endpoint"`
+	// Account is the Azure storage account.
+	Account string `yaml:"account"`
+	// Scopes are searched in order on restore; see BucketBackend.
+	Scopes []string `yaml:"scopes"`
+	// MaxSize and MaxAge bound the cache; see BucketBackend.Evict.
+	MaxSize int64         `yaml:"max-size"`
+	MaxAge  time.Duration `yaml:"max-age"`
+}
+
+// DefaultCacheDir returns the directory of the local backend when Dir is
+// unset.
+func DefaultCacheDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "actions", "cache")
+	}
+	return filepath.Join(os.TempDir(), "actions-cache")
+}
+
+// ConfigFromEnv reads ACTIONS_CACHE_BACKEND, ACTIONS_CACHE_BUCKET,
+// ACTIONS_CACHE_PREFIX, ACTIONS_CACHE_DIR, ACTIONS_CACHE_REGION,
+// ACTIONS_CACHE_ENDPOINT, ACTIONS_CACHE_ACCOUNT, ACTIONS_CACHE_MAX_SIZE (in
+// bytes) and ACTIONS_CACHE_MAX_AGE (a duration). Entries are scoped to
+// GITHUB_REF, then to the ref of GITHUB_BASE_REF.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Backend:  os.Getenv("ACTIONS_CACHE_BACKEND"),
+		Bucket:   os.Getenv("ACTIONS_CACHE_BUCKET"),
+		Prefix:   os.Getenv("ACTIONS_CACHE_PREFIX"),
+		Dir:      os.Getenv("ACTIONS_CACHE_DIR"),
+		Region:   os.Getenv("ACTIONS_CACHE_REGION"),
+		Endpoint: os.Getenv("ACTIONS_CACHE_ENDPOINT"),
+		Account:  os.Getenv("ACTIONS_CACHE_ACCOUNT"),
+	}
+	cfg.MaxSize, _ = strconv.ParseInt(os.Getenv("ACTIONS_CACHE_MAX_SIZE"), 10, 64)
+	cfg.MaxAge, _ = time.ParseDuration(os.Getenv("ACTIONS_CACHE_MAX_AGE"))
+	if ref := os.Getenv("GITHUB_REF"); ref != "" {
+		cfg.Scopes = append(cfg.Scopes, ref)
+	}
+	if base := os.Getenv("GITHUB_BASE_REF"); base != "" {
+		cfg.Scopes = append(cfg.Scopes, "refs/heads/"+base)
+	}
+	return cfg
+}
+
+// OpenBackend returns the backend cfg describes.
+func OpenBackend(cfg Config) (Backend, error) {
+	backend := strings.ToLower(cfg.Backend)
+	if backend == "" {
+		backend = "local"
+		if os.Getenv("ACTIONS_RUNTIME_TOKEN") != "" && os.Getenv("ACTIONS_RESULTS_URL") != "" {
+			backend = "github"
+		}
+	}
+	var bucket Bucket
+	switch backend {
+	case "github":
+		r, err := results.NewFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return &Service{Results: r}, nil
+	case "local":
+		dir := cfg.Dir
+		if dir == "" {
+			dir = DefaultCacheDir()
+		}
+		bucket = &LocalBucket{Dir: dir}
+	case "s3":
+		bucket = &S3Bucket{Bucket: cfg.Bucket, Region: cfg.Region, Endpoint: cfg.Endpoint}
+	case "gcs":
+		bucket = &GCSBucket{Bucket: cfg.Bucket, Endpoint: cfg.Endpoint}
+	case "azure":
+		bucket = &AzureBucket{Account: cfg.Account, Container: cfg.Bucket, Endpoint: cfg.Endpoint}
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+	if backend != "local" && cfg.Bucket == "" {
+		return nil, fmt.Errorf("the %s cache backend needs a bucket", backend)
+	}
+	return &BucketBackend{Bucket: bucket, Prefix: cfg.Prefix, Scopes: cfg.Scopes, MaxSize: cfg.MaxSize, MaxAge: cfg.MaxAge}, nil
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metadataTokenURL hands out access tokens on GCE, GKE and Cloud Run.
+const metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCSBucket stores objects in Google Cloud Storage with the JSON API.
+type GCSBucket struct {
+	Bucket string
+	// Token is an OAuth access token. Defaults to
+	// GOOGLE_OAUTH_ACCESS_TOKEN, then the metadata server.
+	Token string
+	// Endpoint defaults to https://storage.googleapis.com.
+	Endpoint string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	cached  string
+	expires time.Time
+}
+
+func (g *GCSBucket) client() *http.Client {
+	if g.HTTPClient != nil {
+		return g.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (g *GCSBucket) token(ctx context.Context) (string, error) {
+	if g.Token != "" {
+		return g.Token, nil
+	}
+	if t := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); t != "" {
+		return t, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cached != "" && time.Until(g.expires) > time.Minute {
+		return g.cached, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := g.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get a token from the metadata server: %w", err)
+	}
+	defer resp.Body.Close()
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get a token from the metadata server: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("failed to decode metadata token: %w", err)
+	}
+	g.cached, g.expires = tok.AccessToken, time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second)
+	return g.cached, nil
+}
+
+func (g *GCSBucket) do(ctx context.Context, method, rawURL string, body io.Reader, size int64) (*http.Response, error) {
+	tok, err := g.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	resp, err := g.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<12))
+		return nil, fmt.Errorf("gcs %s: %s: %s", method, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+func (g *GCSBucket) endpoint() string {
+	if g.Endpoint != "" {
+		return strings.TrimSuffix(g.Endpoint, "/")
+	}
+	return "https://storage.googleapis.com"
+}
+
+func (g *GCSBucket) objectURL(name string) string {
+	return g.endpoint() + "/storage/v1/b/" + url.PathEscape(g.Bucket) + "/o/" + url.PathEscape(name)
+}
+
+// Put implements Bucket.
+func (g *GCSBucket) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	u := g.endpoint() + "/upload/storage/v1/b/" + url.PathEscape(g.Bucket) + "/o?" +
+		url.Values{"uploadType": {"media"}, "name": {name}}.Encode()
+	resp, err := g.do(ctx, http.MethodPost, u, r, size)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Get implements Bucket.
+func (g *GCSBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := g.do(ctx, http.MethodGet, g.objectURL(name)+"?alt=media", nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// List implements Bucket.
+func (g *GCSBucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objs []Object
+	page := ""
+	for {
+		q := url.Values{"prefix": {prefix}, "fields": {"items(name,size,updated),nextPageToken"}}
+		if page != "" {
+			q.Set("pageToken", page)
+		}
+		resp, err := g.do(ctx, http.MethodGet, g.endpoint()+"/storage/v1/b/"+url.PathEscape(g.Bucket)+"/o?"+q.Encode(), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Items []struct {
+				Name    string    `json:"name"`
+				Size    string    `json:"size"`
+				Updated time.Time `json:"updated"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gcs listing: %w", err)
+		}
+		for _, it := range result.Items {
+			size, _ := strconv.ParseInt(it.Size, 10, 64)
+			objs = append(objs, Object{Name: it.Name, Size: size, Modified: it.Updated})
+		}
+		if result.NextPageToken == "" {
+			return objs, nil
+		}
+		page = result.NextPageToken
+	}
+}
+
+// Delete implements Bucket.
+func (g *GCSBucket) Delete(ctx context.Context, name string) error {
+	resp, err := g.do(ctx, http.MethodDelete, g.objectURL(name), nil, 0)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalBucket stores objects as files under a directory.
+type LocalBucket struct {
+	Dir string
+}
+
+func (l *LocalBucket) path(name string) string {
+	return filepath.Join(l.Dir, filepath.FromSlash(name))
+}
+
+// Put implements Bucket. The file appears atomically.
+func (l *LocalBucket) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	p := l.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get implements Bucket. Reading an object refreshes its modification time,
+// so eviction removes the least recently used entries first.
+func (l *LocalBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(l.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	os.Chtimes(f.Name(), now, now)
+	return f, nil
+}
+
+// List implements Bucket.
+func (l *LocalBucket) List(ctx context.Context, prefix string) ([]Object, error) {
+	// Walk the deepest directory the prefix names completely.
+	root := l.Dir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		root = l.path(prefix[:i])
+	}
+	var objs []Object
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.Dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objs = append(objs, Object{Name: name, Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	return objs, err
+}
+
+// Delete implements Bucket.
+func (l *LocalBucket) Delete(ctx context.Context, name string) error {
+	err := os.Remove(l.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changes
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"testingdashboard/m/v2/client"
+)
+
+// FromAPI returns the files changed in r as the API reports them. Pull
+// requests list at most 3000 files and other comparisons 300. The compare
+// API always compares with the merge base, so a forced push lists the
+// changes since the history it replaced forked rather than those from the
+// commit before it.
+func FromAPI(ctx context.Context, c *client.Client, owner, repo string, r Range) ([]File, error) {
+	if r.PullRequest != 0 {
+		return fromCommitFiles(c.ListPullRequestFiles(ctx, owner, repo, r.PullRequest))
+	}
+	if r.Base == "" {
+		t, err := c.GetTree(ctx, owner, repo, r.Head, true)
+		if err != nil {
+			return nil, err
+		}
+		if t.Truncated {
+			return nil, fmt.Errorf("the tree of %s is too large for the API to list", r.Head)
+		}
+		var files []File
+		for _, e := range t.Entries {
+			if e.Type != "tree" {
+				files = append(files, File{Path: e.Path, Status: Added, Submodule: e.Type == "commit"})
+			}
+		}
+		return files, nil
+	}
+	return fromCommitFiles(c.ListComparisonFiles(ctx, owner, repo, r.Base, r.Head))
+}
+
+func fromCommitFiles(files iter.Seq2[*client.CommitFile, error]) ([]File, error) {
+	var out []File
+	for f, err := range files {
+		if err != nil {
+			return nil, err
+		}
+		file := File{Path: f.Filename, Status: f.Status, PreviousPath: f.PreviousFilename}
+		switch f.Status {
+		case "unchanged":
+			continue
+		case "removed":
+			file.Status = Deleted
+		case "changed":
+			file.Status = Modified
+		}
+		out = append(out, file)
+	}
+	return out, nil
+}
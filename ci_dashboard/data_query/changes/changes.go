@@ -0,0 +1,152 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package changes computes the files an event changed, as GitHub does for
+// paths filters: pushes against the commit before them, pull requests
+// against their merge base, with renames and submodule pointers reported
+// as such, from a local clone or the API.
+package changes
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"testingdashboard/m/v2/events"
+)
+
+// Statuses of changed files.
+const (
+	Added    = "added"
+	Copied   = "copied"
+	Deleted  = "deleted"
+	Modified = "modified"
+	Renamed  = "renamed"
+	Unmerged = "unmerged"
+)
+
+// Statuses lists the statuses of changed files.
+var Statuses = []string{Added, Copied, Deleted, Modified, Renamed, Unmerged}
+
+// File is a changed file.
+type File struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	// PreviousPath is the path a renamed or copied file had.
+	PreviousPath string `json:"previous_path,omitempty"`
+	// Submodule is set when the change is to a submodule's commit.
+	Submodule bool `json:"submodule,omitempty"`
+}
+
+// Range is the pair of commits an event's changes lie between.
+type Range struct {
+	// Base is empty when every file of Head is new, as for the first push
+	// to a repository.
+	Base string `json:"base,omitempty"`
+	Head string `json:"head"`
+	// MergeBase compares Head with the merge base of Base and Head, as a
+	// pull request does, rather than with Base itself.
+	MergeBase bool `json:"merge_base,omitempty"`
+	// PullRequest is the number of the pull request the range is for, whose
+	// files the API lists directly.
+	PullRequest int `json:"pull_request,omitempty"`
+}
+
+func (r Range) String() string {
+	switch {
+	case r.Base == "":
+		return r.Head
+	case r.MergeBase:
+		return r.Base + "..." + r.Head
+	}
+	return r.Base + ".." + r.Head
+}
+
+// ErrNoRange is returned for events that change no files, such as
+// schedule, workflow_dispatch and branch deletions.
+var ErrNoRange = errors.New("the event does not change files")
+
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// ForEvent returns the range of commits an event changed.
+//
+// A push compares its commits with the commit before it, even when it was
+// forced. A push that creates a branch compares with the merge base of the
+// default branch, approximating GitHub's diff against the parent of the
+// earliest commit pushed, and the first push of the default branch adds
+// every file. Pull requests compare their head with its merge base with
+// the base branch, and merge groups with the base commit they build on.
+func ForEvent(e events.Event) (Range, error) {
+	switch e := e.(type) {
+	case *events.PushEvent:
+		if e.Deleted || e.After == "" || e.After == zeroSHA {
+			return Range{}, ErrNoRange
+		}
+		if e.Before != "" && e.Before != zeroSHA && !e.Created {
+			return Range{Base: e.Before, Head: e.After}, nil
+		}
+		def := ""
+		if repo := e.Info().Repository; repo != nil {
+			def = repo.DefaultBranch
+		}
+		if def == "" {
+			return Range{}, fmt.Errorf("the push created %s but the payload has no default branch to compare with", e.Ref)
+		}
+		if !strings.HasPrefix(e.Ref, "refs/heads/") || strings.TrimPrefix(e.Ref, "refs/heads/") != def {
+			return Range{Base: def, Head: e.After, MergeBase: true}, nil
+		}
+		return Range{Head: e.After}, nil
+	case *events.PullRequestTargetEvent:
+		return pullRequest(&e.PullRequest)
+	case *events.PullRequestEvent:
+		return pullRequest(&e.PullRequest)
+	case *events.PullRequestReviewEvent:
+		return pullRequest(&e.PullRequest)
+	case *events.PullRequestReviewCommentEvent:
+		return pullRequest(&e.PullRequest)
+	case *events.MergeGroupEvent:
+		if e.MergeGroup.BaseSHA == "" || e.MergeGroup.HeadSHA == "" {
+			return Range{}, fmt.Errorf("the merge group has no base or head commit")
+		}
+		return Range{Base: e.MergeGroup.BaseSHA, Head: e.MergeGroup.HeadSHA}, nil
+	}
+	return Range{}, ErrNoRange
+}
+
+func pullRequest(pr *events.PullRequest) (Range, error) {
+	if pr.Base.SHA == "" || pr.Head.SHA == "" {
+		return Range{}, fmt.Errorf("pull request #%d has no base or head commit", pr.Number)
+	}
+	return Range{Base: pr.Base.SHA, Head: pr.Head.SHA, MergeBase: true, PullRequest: pr.Number}, nil
+}
+
+// MarkSubmodules sets Submodule on the files at the given submodule paths,
+// for sources such as the compare API that do not tell them apart.
+func MarkSubmodules(files []File, paths []string) {
+	for i := range files {
+		if slices.Contains(paths, files[i].Path) {
+			files[i].Submodule = true
+		}
+	}
+}
+
+// Paths returns the paths of the files.
+func Paths(files []File) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return paths
+}
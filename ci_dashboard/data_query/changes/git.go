@@ -0,0 +1,303 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package changes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitOptions configure FromGit.
+type GitOptions struct {
+	// Remote is the remote to fetch missing commits from. Defaults to
+	// origin.
+	Remote string
+	// Fetch fetches commits the clone lacks, and deepens a shallow clone,
+	// such as actions/checkout makes, until the merge base is found.
+	Fetch bool
+	// RecurseSubmodules also lists the files changed inside submodules
+	// that are checked out.
+	RecurseSubmodules bool
+}
+
+// maxDepth is how deep a shallow clone is fetched looking for a merge base
+// before it is unshallowed.
+const maxDepth = 1 << 12
+
+// FromGit returns the files changed in r in the repository at dir.
+func FromGit(ctx context.Context, dir string, r Range, opts GitOptions) ([]File, error) {
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+	g := &git{ctx: ctx, dir: dir, opts: opts}
+	head, err := g.commit(r.Head)
+	if err != nil {
+		return nil, err
+	}
+	if r.Base == "" {
+		return g.tree(head, "")
+	}
+	base, err := g.commit(r.Base)
+	if err != nil {
+		return nil, err
+	}
+	if r.MergeBase {
+		if base, err = g.mergeBase(base, head); err != nil {
+			return nil, err
+		}
+	}
+	return g.diff(base, head, "")
+}
+
+type git struct {
+	ctx  context.Context
+	dir  string
+	opts GitOptions
+}
+
+func (g *git) run(args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(g.ctx, "git", append([]string{"-C", g.dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// commit resolves rev to a commit, fetching it if the clone lacks it. A
+// branch name also resolves to the remote's branch.
+func (g *git) commit(rev string) (string, error) {
+	candidates := []string{rev, "refs/remotes/" + g.opts.Remote + "/" + rev}
+	for _, c := range candidates {
+		if out, err := g.run("rev-parse", "--verify", "--quiet", c+"^{commit}"); err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+	}
+	if !g.opts.Fetch {
+		return "", fmt.Errorf("commit %s is not in the clone", rev)
+	}
+	refspec := rev
+	if !isSHA(rev) {
+		branch := strings.TrimPrefix(rev, "refs/heads/")
+		refspec = "+refs/heads/" + branch + ":refs/remotes/" + g.opts.Remote + "/" + branch
+		candidates[1] = "refs/remotes/" + g.opts.Remote + "/" + branch
+	}
+	args := []string{"fetch", "-q", "--no-tags", "--no-recurse-submodules"}
+	if g.shallow() {
+		args = append(args, "--depth=1")
+	}
+	if _, err := g.run(append(args, g.opts.Remote, refspec)...); err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", rev, err)
+	}
+	for _, c := range candidates {
+		if out, err := g.run("rev-parse", "--verify", "--quiet", c+"^{commit}"); err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+	}
+	return "", fmt.Errorf("commit %s is not in the clone after fetching it", rev)
+}
+
+func (g *git) shallow() bool {
+	out, err := g.run("rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// mergeBase returns the merge base of base and head, deepening a shallow
+// clone until history reaches it.
+func (g *git) mergeBase(base, head string) (string, error) {
+	for depth := 64; ; depth *= 2 {
+		if out, err := g.run("merge-base", base, head); err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+		if !g.opts.Fetch || !g.shallow() {
+			return "", fmt.Errorf("%s and %s have no merge base", base, head)
+		}
+		args := []string{"fetch", "-q", "--no-tags", "--no-recurse-submodules", fmt.Sprintf("--depth=%d", depth), g.opts.Remote, base, head}
+		if depth > maxDepth {
+			args = []string{"fetch", "-q", "--no-tags", "--no-recurse-submodules", "--unshallow", g.opts.Remote}
+		}
+		if _, err := g.run(args...); err != nil {
+			return "", fmt.Errorf("failed to deepen the clone: %w", err)
+		}
+		if depth > maxDepth {
+			// The clone has its full history now, so this is final.
+			out, err := g.run("merge-base", base, head)
+			if err != nil {
+				return "", fmt.Errorf("%s and %s have no merge base", base, head)
+			}
+			return strings.TrimSpace(string(out)), nil
+		}
+	}
+}
+
+const gitlinkMode = "160000"
+
+// diff lists the files changed from base to head, under prefix for a
+// submodule.
+func (g *git) diff(base, head, prefix string) ([]File, error) {
+	out, err := g.run("diff", "--raw", "-z", "--no-abbrev", "--find-renames", "--ignore-submodules=none", base, head, "--")
+	if err != nil {
+		return nil, err
+	}
+	// :oldmode newmode oldsha newsha status NUL path [NUL new path]
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	var files []File
+	for i := 0; i < len(fields) && fields[i] != ""; {
+		meta := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		if len(meta) != 5 {
+			return nil, fmt.Errorf("unexpected git diff output %q", fields[i])
+		}
+		status := meta[4]
+		n := 1
+		if status[0] == 'R' || status[0] == 'C' {
+			n = 2
+		}
+		if i+n >= len(fields) {
+			return nil, fmt.Errorf("truncated git diff output after %q", fields[i])
+		}
+		f := File{Path: path.Join(prefix, fields[i+n]), Status: gitStatus(status[0])}
+		if n == 2 {
+			f.PreviousPath = path.Join(prefix, fields[i+1])
+		}
+		f.Submodule = meta[0] == gitlinkMode || meta[1] == gitlinkMode
+		files = append(files, f)
+		if f.Submodule && g.opts.RecurseSubmodules {
+			inner, err := g.submodule(fields[i+n], meta[2], meta[3], f.Path)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, inner...)
+		}
+		i += n + 1
+	}
+	return files, nil
+}
+
+// tree lists every file of a commit as added.
+func (g *git) tree(commit, prefix string) ([]File, error) {
+	out, err := g.run("ls-tree", "-r", "-z", "--full-tree", commit)
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, entry := range strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+		// mode type sha TAB path
+		meta, name, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		f := File{Path: path.Join(prefix, name), Status: Added, Submodule: len(fields) > 0 && fields[0] == gitlinkMode}
+		files = append(files, f)
+		if f.Submodule && g.opts.RecurseSubmodules && len(fields) == 3 {
+			inner, err := g.submodule(name, zeroSHA, fields[2], f.Path)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, inner...)
+		}
+	}
+	return files, nil
+}
+
+// submodule lists the files changed inside the submodule at rel between
+// two of its commits. Submodules that are not checked out, or lack the
+// commits, contribute only their pointer.
+func (g *git) submodule(rel, from, to, prefix string) ([]File, error) {
+	dir := filepath.Join(g.dir, filepath.FromSlash(rel))
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return nil, nil
+	}
+	sub := &git{ctx: g.ctx, dir: dir, opts: GitOptions{Remote: g.opts.Remote, RecurseSubmodules: true}}
+	switch {
+	case !isSHA(to) || to == zeroSHA:
+		// The submodule was removed; its files went with it.
+		return nil, nil
+	case from == zeroSHA:
+		if _, err := sub.commit(to); err != nil {
+			return nil, nil
+		}
+		return sub.tree(to, prefix)
+	}
+	for _, c := range []string{from, to} {
+		if _, err := sub.commit(c); err != nil {
+			return nil, nil
+		}
+	}
+	return sub.diff(from, to, prefix)
+}
+
+func gitStatus(code byte) string {
+	switch code {
+	case 'A':
+		return Added
+	case 'C':
+		return Copied
+	case 'D':
+		return Deleted
+	case 'R':
+		return Renamed
+	case 'U':
+		return Unmerged
+	}
+	// M, and T for a change of type.
+	return Modified
+}
+
+func isSHA(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Submodules returns the paths of the submodules .gitmodules in dir
+// declares.
+func Submodules(dir string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".gitmodules")); err != nil {
+		return nil, nil
+	}
+	g := &git{ctx: context.Background(), dir: dir}
+	out, err := g.run("config", "-f", ".gitmodules", "--get-regexp", `^submodule\..*\.path$`)
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 {
+		// git config exits 1 when nothing matches.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .gitmodules: %w", err)
+	}
+	var paths []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if _, p, ok := strings.Cut(line, " "); ok {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,426 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package checks publishes check runs: a status and conclusion on a commit
+// with output that can carry annotations, images and buttons.
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/commands"
+)
+
+// MaxAnnotations is how many annotations one request may carry. Create and
+// Update send the rest in further requests.
+const MaxAnnotations = 50
+
+// Annotation levels.
+const (
+	Notice  = "notice"
+	Warning = "warning"
+	Failure = "failure"
+)
+
+// Statuses and conclusions of a check run.
+const (
+	StatusQueued     = "queued"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+
+	ConclusionSuccess        = "success"
+	ConclusionFailure        = "failure"
+	ConclusionNeutral        = "neutral"
+	ConclusionCancelled      = "cancelled"
+	ConclusionSkipped        = "skipped"
+	ConclusionTimedOut       = "timed_out"
+	ConclusionActionRequired = "action_required"
+)
+
+// MaxText is the most characters the summary or text of an output may
+// have.
+const MaxText = 65535
+
+// Limits of the API on titles and actions.
+const (
+	maxTitle       = 255
+	maxActions     = 3
+	maxLabel       = 20
+	maxDescription = 40
+	maxIdentifier  = 20
+)
+
+// Annotation marks a range of lines of a file. Columns are only kept when
+// the range is a single line.
+type Annotation struct {
+	Path        string `json:"path"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	StartColumn int    `json:"start_column,omitempty"`
+	EndColumn   int    `json:"end_column,omitempty"`
+	// Level is Notice, Warning or Failure.
+	Level      string `json:"annotation_level"`
+	Message    string `json:"message"`
+	Title      string `json:"title,omitempty"`
+	RawDetails string `json:"raw_details,omitempty"`
+}
+
+// Image is shown in the output of a check run.
+type Image struct {
+	Alt     string `json:"alt"`
+	URL     string `json:"image_url"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// Action is a button on the check run. Clicking it sends a check_run
+// event with the requested_action activity and the identifier.
+type Action struct {
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	Identifier  string `json:"identifier"`
+}
+
+// Output is what the check run page shows. Summary and Text are Markdown.
+type Output struct {
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Text        string       `json:"text,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Images      []Image      `json:"images,omitempty"`
+}
+
+// Run describes a check run to create or the changes to make to one. Zero
+// fields are left out; Name and HeadSHA are required to create one.
+type Run struct {
+	Name       string
+	HeadSHA    string
+	DetailsURL string
+	ExternalID string
+	// Status defaults to queued. Setting a Conclusion completes the run.
+	Status      string
+	Conclusion  string
+	StartedAt   time.Time
+	CompletedAt time.Time
+	Output      *Output
+	Actions     []Action
+}
+
+// CheckRun is a check run as the API returns it.
+type CheckRun struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	HeadSHA     string     `json:"head_sha"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	HTMLURL     string     `json:"html_url"`
+	DetailsURL  string     `json:"details_url"`
+	ExternalID  string     `json:"external_id"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Output      struct {
+		Title            string `json:"title"`
+		Summary          string `json:"summary"`
+		AnnotationsCount int    `json:"annotations_count"`
+	} `json:"output"`
+	// App is the GitHub App that created the run.
+	App *struct {
+		ID   int64  `json:"id"`
+		Slug string `json:"slug"`
+	} `json:"app,omitempty"`
+}
+
+// Create creates a check run on the commit r.HeadSHA. The job needs the
+// checks: write permission.
+func Create(ctx context.Context, c *client.Client, owner, repo string, r *Run) (*CheckRun, error) {
+	switch {
+	case r.Name == "":
+		return nil, errors.New("a check run needs a name")
+	case r.HeadSHA == "":
+		return nil, errors.New("a check run needs a head SHA")
+	}
+	run, err := send(ctx, c, http.MethodPost, checkRunsPath(owner, repo), owner, repo, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create check run %s: %w", r.Name, err)
+	}
+	return run, nil
+}
+
+// Update changes the check run id. Annotations are added to those the run
+// already has; other output replaces the old.
+func Update(ctx context.Context, c *client.Client, owner, repo string, id int64, r *Run) (*CheckRun, error) {
+	run, err := send(ctx, c, http.MethodPatch, fmt.Sprintf("%s/%d", checkRunsPath(owner, repo), id), owner, repo, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update check run %d: %w", id, err)
+	}
+	return run, nil
+}
+
+// ListForRef returns the latest check runs named name on the commit ref,
+// a SHA, branch or tag.
+func ListForRef(ctx context.Context, c *client.Client, owner, repo, ref, name string) ([]*CheckRun, error) {
+	path := fmt.Sprintf("/repos/%s/%s/commits/%s/check-runs?filter=latest&per_page=100&check_name=%s", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref), url.QueryEscape(name))
+	var page struct {
+		CheckRuns []*CheckRun `json:"check_runs"`
+	}
+	if err := c.Do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to list check runs of %s: %w", ref, err)
+	}
+	return page.CheckRuns, nil
+}
+
+// Status is a commit status, the older kind of check set by integrations
+// that do not create check runs.
+type Status struct {
+	Context string `json:"context"`
+	// State is error, failure, pending or success.
+	State       string `json:"state"`
+	Description string `json:"description"`
+	TargetURL   string `json:"target_url"`
+}
+
+// Statuses returns the latest status of each context on the commit ref.
+func Statuses(ctx context.Context, c *client.Client, owner, repo, ref string) ([]Status, error) {
+	path := fmt.Sprintf("/repos/%s/%s/commits/%s/status?per_page=100", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref))
+	var combined struct {
+		Statuses []Status `json:"statuses"`
+	}
+	if err := c.Do(ctx, http.MethodGet, path, nil, &combined); err != nil {
+		return nil, fmt.Errorf("failed to get the statuses of %s: %w", ref, err)
+	}
+	return combined.Statuses, nil
+}
+
+// send makes the request for r. When the output has more annotations than
+// one request carries, the rest follow in updates, and the run is only
+// completed by the last of them so it never shows as done with half of
+// its annotations.
+func send(ctx context.Context, c *client.Client, method, path, owner, repo string, r *Run) (*CheckRun, error) {
+	body, err := requestBody(r)
+	if err != nil {
+		return nil, err
+	}
+	var annotations []Annotation
+	if r.Output != nil {
+		annotations = normalize(r.Output.Annotations)
+		for _, a := range annotations {
+			if err := validate(a); err != nil {
+				return nil, err
+			}
+		}
+	}
+	batches := batch(annotations)
+	completion := map[string]any{}
+	if len(batches) > 1 {
+		for _, k := range []string{"status", "conclusion", "completed_at"} {
+			if v, ok := body[k]; ok {
+				completion[k] = v
+				delete(body, k)
+			}
+		}
+		if len(completion) > 0 {
+			body["status"] = StatusInProgress
+		}
+	}
+	if len(batches) > 0 {
+		body["output"].(map[string]any)["annotations"] = batches[0]
+	}
+
+	var run CheckRun
+	if err := c.Do(ctx, method, path, body, &run); err != nil {
+		return nil, err
+	}
+	rest := batches[min(1, len(batches)):]
+	for i, annotations := range rest {
+		more := map[string]any{"output": map[string]any{
+			"title":       r.Output.Title,
+			"summary":     r.Output.Summary,
+			"annotations": annotations,
+		}}
+		if i == len(rest)-1 {
+			for k, v := range completion {
+				more[k] = v
+			}
+		}
+		path := fmt.Sprintf("%s/%d", checkRunsPath(owner, repo), run.ID)
+		if err := c.Do(ctx, http.MethodPatch, path, more, &run); err != nil {
+			return nil, fmt.Errorf("failed to add annotations: %w", err)
+		}
+	}
+	return &run, nil
+}
+
+// requestBody is the JSON body for r without annotations, after checking
+// the limits the API enforces.
+func requestBody(r *Run) (map[string]any, error) {
+	body := map[string]any{}
+	for k, v := range map[string]string{
+		"name":        r.Name,
+		"head_sha":    r.HeadSHA,
+		"details_url": r.DetailsURL,
+		"external_id": r.ExternalID,
+		"status":      r.Status,
+		"conclusion":  r.Conclusion,
+	} {
+		if v != "" {
+			body[k] = v
+		}
+	}
+	if !r.StartedAt.IsZero() {
+		body["started_at"] = r.StartedAt.UTC().Format(time.RFC3339)
+	}
+	if !r.CompletedAt.IsZero() {
+		body["completed_at"] = r.CompletedAt.UTC().Format(time.RFC3339)
+	}
+	if r.Conclusion != "" && r.Status == "" {
+		body["status"] = StatusCompleted
+	}
+	if o := r.Output; o != nil {
+		switch {
+		case o.Title == "" || o.Summary == "":
+			return nil, errors.New("output needs a title and a summary")
+		case utf8.RuneCountInString(o.Summary) > MaxText:
+			return nil, fmt.Errorf("output summary is longer than %d characters", MaxText)
+		case utf8.RuneCountInString(o.Text) > MaxText:
+			return nil, fmt.Errorf("output text is longer than %d characters", MaxText)
+		}
+		output := map[string]any{"title": o.Title, "summary": o.Summary}
+		if o.Text != "" {
+			output["text"] = o.Text
+		}
+		for _, img := range o.Images {
+			if img.Alt == "" || img.URL == "" {
+				return nil, errors.New("an image needs alt text and a URL")
+			}
+		}
+		if len(o.Images) > 0 {
+			output["images"] = o.Images
+		}
+		body["output"] = output
+	}
+	if len(r.Actions) > maxActions {
+		return nil, fmt.Errorf("a check run has at most %d actions", maxActions)
+	}
+	for _, a := range r.Actions {
+		switch {
+		case a.Label == "" || a.Description == "" || a.Identifier == "":
+			return nil, errors.New("an action needs a label, description and identifier")
+		case utf8.RuneCountInString(a.Label) > maxLabel:
+			return nil, fmt.Errorf("action label %q is longer than %d characters", a.Label, maxLabel)
+		case utf8.RuneCountInString(a.Description) > maxDescription:
+			return nil, fmt.Errorf("action description %q is longer than %d characters", a.Description, maxDescription)
+		case utf8.RuneCountInString(a.Identifier) > maxIdentifier:
+			return nil, fmt.Errorf("action identifier %q is longer than %d characters", a.Identifier, maxIdentifier)
+		}
+	}
+	if len(r.Actions) > 0 {
+		body["actions"] = r.Actions
+	}
+	return body, nil
+}
+
+// normalize fills in end lines, drops the columns of ranges the API does
+// not accept them on and shortens overlong text. It returns a copy.
+func normalize(annotations []Annotation) []Annotation {
+	out := make([]Annotation, len(annotations))
+	for i, a := range annotations {
+		if a.EndLine < a.StartLine {
+			a.EndLine = a.StartLine
+		}
+		if a.StartLine != a.EndLine {
+			a.StartColumn, a.EndColumn = 0, 0
+		} else if a.EndColumn < a.StartColumn {
+			a.EndColumn = a.StartColumn
+		}
+		a.Title = truncate(a.Title, maxTitle)
+		a.Message = truncate(a.Message, MaxText)
+		a.RawDetails = truncate(a.RawDetails, MaxText)
+		out[i] = a
+	}
+	return out
+}
+
+// truncate cuts s to n characters.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func validate(a Annotation) error {
+	switch {
+	case a.Path == "":
+		return errors.New("an annotation needs a path")
+	case a.StartLine < 1:
+		return fmt.Errorf("annotation of %s: lines start at 1", a.Path)
+	case a.Message == "":
+		return fmt.Errorf("annotation of %s:%d: the message is empty", a.Path, a.StartLine)
+	}
+	switch a.Level {
+	case Notice, Warning, Failure:
+	default:
+		return fmt.Errorf("annotation of %s:%d: unknown level %q", a.Path, a.StartLine, a.Level)
+	}
+	return nil
+}
+
+// batch splits annotations into requests of at most MaxAnnotations.
+func batch(annotations []Annotation) [][]Annotation {
+	var out [][]Annotation
+	for len(annotations) > 0 {
+		n := min(len(annotations), MaxAnnotations)
+		out = append(out, annotations[:n])
+		annotations = annotations[n:]
+	}
+	return out
+}
+
+// FromCommand converts an error, warning or notice a step reported into a
+// check run annotation. It reports false for those without a file, which
+// check runs cannot show.
+func FromCommand(a commands.Annotation) (Annotation, bool) {
+	if a.File == "" {
+		return Annotation{}, false
+	}
+	level := a.Level
+	if level == "error" {
+		level = Failure
+	}
+	line := max(a.Line, 1)
+	out := Annotation{
+		Path:        a.File,
+		StartLine:   line,
+		EndLine:     max(a.EndLine, line),
+		StartColumn: a.Column,
+		EndColumn:   a.EndColumn,
+		Level:       level,
+		Message:     a.Message,
+		Title:       a.Title,
+	}
+	if out.Title == "" {
+		out.Title = a.Code
+	}
+	return out, true
+}
+
+func checkRunsPath(owner, repo string) string {
+	return fmt.Sprintf("/repos/%s/%s/check-runs", url.PathEscape(owner), url.PathEscape(repo))
+}
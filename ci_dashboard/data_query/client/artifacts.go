@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Artifact is an artifact a workflow run uploaded.
+type Artifact struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	SizeInBytes int64     `json:"size_in_bytes"`
+	Digest      string    `json:"digest"`
+	Expired     bool      `json:"expired"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	WorkflowRun struct {
+		ID         int64  `json:"id"`
+		HeadBranch string `json:"head_branch"`
+		HeadSHA    string `json:"head_sha"`
+	} `json:"workflow_run"`
+}
+
+// ListArtifacts returns the artifacts of a repository, newest first. A
+// non-empty name returns only the artifacts with exactly that name.
+func (c *Client) ListArtifacts(ctx context.Context, owner, repo, name string) iter.Seq2[*Artifact, error] {
+	q := url.Values{}
+	if name != "" {
+		q.Set("name", name)
+	}
+	return list[Artifact](ctx, c, repoPath(owner, repo, "actions", "artifacts"), q, "artifacts")
+}
+
+// GetArtifact returns an artifact.
+func (c *Client) GetArtifact(ctx context.Context, owner, repo string, id int64) (*Artifact, error) {
+	var a Artifact
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "actions", "artifacts", id), nil, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteArtifact deletes an artifact, freeing its storage.
+func (c *Client) DeleteArtifact(ctx context.Context, owner, repo string, id int64) error {
+	return c.Do(ctx, http.MethodDelete, repoPath(owner, repo, "actions", "artifacts", id), nil, nil)
+}
+
+// Retention is how many days artifacts and logs are kept.
+type Retention struct {
+	Days int `json:"days"`
+	// MaximumAllowedDays is the most the organization or enterprise allows.
+	MaximumAllowedDays int `json:"maximum_allowed_days,omitempty"`
+}
+
+// ArtifactRetention returns the artifact and log retention of a repository
+// or an organization.
+func (c *Client) ArtifactRetention(ctx context.Context, s Scope) (*Retention, error) {
+	var r Retention
+	if err := c.Do(ctx, http.MethodGet, s.path("permissions", "artifact-and-log-retention"), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SetArtifactRetention sets the artifact and log retention of a repository
+// or an organization. It applies to artifacts uploaded afterwards.
+func (c *Client) SetArtifactRetention(ctx context.Context, s Scope, days int) error {
+	return c.Do(ctx, http.MethodPut, s.path("permissions", "artifact-and-log-retention"), map[string]int{"days": days}, nil)
+}
+
+// ListRunArtifacts returns the artifacts of a run.
+func (c *Client) ListRunArtifacts(ctx context.Context, owner, repo string, runID int64) ([]*Artifact, error) {
+	return collect(list[Artifact](ctx, c, repoPath(owner, repo, "actions", "runs", runID, "artifacts"), nil, "artifacts"))
+}
+
+// DownloadArtifact returns the zip archive of an artifact. The caller must
+// close it.
+func (c *Client) DownloadArtifact(ctx context.Context, owner, repo string, id int64) (*http.Response, error) {
+	return c.download(ctx, repoPath(owner, repo, "actions", "artifacts", id, "zip"))
+}
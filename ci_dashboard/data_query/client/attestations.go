@@ -0,0 +1,46 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+)
+
+// Attestation is a Sigstore bundle stored for a repository.
+type Attestation struct {
+	Bundle       json.RawMessage `json:"bundle"`
+	RepositoryID int64           `json:"repository_id"`
+	BundleURL    string          `json:"bundle_url,omitempty"`
+}
+
+// CreateAttestation stores a Sigstore bundle for the repository and returns
+// the ID of the attestation. The job needs the attestations: write
+// permission.
+func (c *Client) CreateAttestation(ctx context.Context, owner, repo string, bundle any) (int64, error) {
+	var resp struct {
+		ID int64 `json:"id"`
+	}
+	err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "attestations"), map[string]any{"bundle": bundle}, &resp)
+	return resp.ID, err
+}
+
+// ListAttestations lists the attestations of the subject with digest, given
+// as algorithm:hex.
+func (c *Client) ListAttestations(ctx context.Context, owner, repo, digest string) iter.Seq2[*Attestation, error] {
+	return list[Attestation](ctx, c, repoPath(owner, repo, "attestations", digest), nil, "attestations")
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CacheLimit is the default size of a repository's Actions cache, past
+// which the least recently used entries are evicted.
+const CacheLimit = 10 << 30
+
+// Cache is an entry of a repository's Actions cache.
+type Cache struct {
+	ID  int64  `json:"id"`
+	Key string `json:"key"`
+	// Ref is the branch or pull request ref that saved the entry, which
+	// limits the runs that can restore it.
+	Ref            string    `json:"ref"`
+	Version        string    `json:"version"`
+	SizeInBytes    int64     `json:"size_in_bytes"`
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CachesOptions filter and order ListCaches. Zero fields are not sent.
+type CachesOptions struct {
+	// Key matches entries whose key is or starts with it.
+	Key string
+	Ref string
+	// Sort is created_at, last_accessed_at or size_in_bytes; Direction is
+	// asc or desc.
+	Sort      string
+	Direction string
+}
+
+// ListCaches returns the cache entries of a repository.
+func (c *Client) ListCaches(ctx context.Context, owner, repo string, opts CachesOptions) iter.Seq2[*Cache, error] {
+	q := url.Values{}
+	for k, v := range map[string]string{"key": opts.Key, "ref": opts.Ref, "sort": opts.Sort, "direction": opts.Direction} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	return list[Cache](ctx, c, repoPath(owner, repo, "actions", "caches"), q, "actions_caches")
+}
+
+// DeleteCache deletes a cache entry.
+func (c *Client) DeleteCache(ctx context.Context, owner, repo string, id int64) error {
+	return c.Do(ctx, http.MethodDelete, repoPath(owner, repo, "actions", "caches", id), nil, nil)
+}
+
+// DeleteCachesByKey deletes the entries with exactly this key, of ref if
+// it is not empty, and returns them.
+func (c *Client) DeleteCachesByKey(ctx context.Context, owner, repo, key, ref string) ([]*Cache, error) {
+	q := url.Values{"key": {key}}
+	if ref != "" {
+		q.Set("ref", ref)
+	}
+	var out struct {
+		Caches []*Cache `json:"actions_caches"`
+	}
+	if err := c.Do(ctx, http.MethodDelete, repoPath(owner, repo, "actions", "caches")+"?"+q.Encode(), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Caches, nil
+}
+
+// CacheUsage is how much of the Actions cache a repository uses.
+type CacheUsage struct {
+	FullName    string `json:"full_name"`
+	SizeInBytes int64  `json:"active_caches_size_in_bytes"`
+	Count       int    `json:"active_caches_count"`
+}
+
+// GetCacheUsage returns the cache usage of a repository.
+func (c *Client) GetCacheUsage(ctx context.Context, owner, repo string) (*CacheUsage, error) {
+	var u CacheUsage
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "actions", "cache", "usage"), nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ListOrgCacheUsage returns the cache usage of each repository of an
+// organization that has cache entries.
+func (c *Client) ListOrgCacheUsage(ctx context.Context, org string) iter.Seq2[*CacheUsage, error] {
+	return list[CacheUsage](ctx, c, "/orgs/"+url.PathEscape(org)+"/actions/cache/usage-by-repository", nil, "repository_cache_usages")
+}
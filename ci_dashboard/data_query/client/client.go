@@ -0,0 +1,370 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client is a client for the GitHub REST API endpoints that deal
+// with Actions: workflows, runs, jobs, logs, deployments, secrets and
+// variables.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/githubapp"
+	"testingdashboard/m/v2/metrics"
+	"testingdashboard/m/v2/transport"
+)
+
+// DefaultURL is the API used when GITHUB_API_URL is unset.
+const DefaultURL = "https://api.github.com"
+
+// Client talks to the GitHub REST API.
+type Client struct {
+	// URL is the API root. Defaults to DefaultURL.
+	URL string
+	// Token authenticates requests. Anonymous requests are heavily rate
+	// limited.
+	Token string
+	// HTTPClient defaults to http.DefaultClient. Its transport is wrapped
+	// in the retry, rate limit and ETag middleware of package transport.
+	HTTPClient *http.Client
+	// MaxRetries is how often a request is retried after a server error or
+	// rate limit. Defaults to 3.
+	MaxRetries int
+	// MaxWait caps how long a rate-limited request waits for the limit to
+	// reset before failing instead. Defaults to a minute.
+	MaxWait time.Duration
+	// Metrics, if set, records requests, retries and the rate limit.
+	Metrics *metrics.Client
+
+	mu sync.Mutex
+	hc *http.Client
+	// budget tracks the rate limit and etags caches GET responses so
+	// repeated requests can be conditional; 304 responses do not count
+	// against the rate limit.
+	budget transport.Budget
+	etags  transport.ETagCache
+	rate   Rate
+	// app is the token source of NewForApp.
+	app *githubapp.TokenSource
+}
+
+// Rate is the rate limit state reported by the last response.
+type Rate struct {
+	Limit, Remaining int
+	Reset            time.Time
+}
+
+// New returns a client for the API at url with token.
+func New(url, token string) *Client {
+	return &Client{URL: url, Token: token}
+}
+
+// NewForApp returns a client for the API at url that authenticates as the
+// app installation of s. The app's API defaults to url.
+func NewForApp(url string, s *githubapp.TokenSource) *Client {
+	if s.App.APIURL == "" {
+		s.App.APIURL = url
+		if url == "" {
+			s.App.APIURL = DefaultURL
+		}
+	}
+	return &Client{URL: url, HTTPClient: s.Client(), app: s}
+}
+
+// NewFromEnv returns a client for the API of endpoints.FromEnv, configured
+// like NewFromEnvAt.
+func NewFromEnv() *Client {
+	return NewFromEnvAt(endpoints.FromEnv().API)
+}
+
+// NewFromEnvAt returns a client for the API at url. If GITHUB_APP_ID is
+// set it authenticates as the installation of githubapp.FromEnv, and
+// every request fails if the app is misconfigured; otherwise it uses
+// GITHUB_TOKEN or else GH_TOKEN.
+func NewFromEnvAt(url string) *Client {
+	src, err := githubapp.FromEnv()
+	switch {
+	case err != nil:
+		return &Client{URL: url, HTTPClient: &http.Client{Transport: transport.RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return nil, err
+		})}}
+	case src != nil:
+		return NewForApp(url, src)
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	return New(url, token)
+}
+
+// Error is an unsuccessful API response.
+type Error struct {
+	StatusCode       int    `json:"-"`
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+	Errors           []struct {
+		Resource string `json:"resource"`
+		Field    string `json:"field"`
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+	} `json:"errors"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	for _, d := range e.Errors {
+		if d.Message != "" {
+			msg += "; " + d.Message
+		} else if d.Field != "" {
+			msg += fmt.Sprintf("; %s %s", d.Field, d.Code)
+		}
+	}
+	return msg
+}
+
+// IsNotFound reports whether err is a 404 response.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// Rate returns the rate limit state seen on the last response.
+func (c *Client) Rate() Rate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rate
+}
+
+func (c *Client) baseURL() string {
+	if c.URL == "" {
+		return DefaultURL
+	}
+	return strings.TrimSuffix(c.URL, "/")
+}
+
+// httpClient returns HTTPClient wrapped in the middleware, built on first
+// use.
+func (c *Client) httpClient() *http.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hc != nil {
+		return c.hc
+	}
+	hc := &http.Client{}
+	if c.HTTPClient != nil {
+		*hc = *c.HTTPClient
+	}
+	c.budget.Block = true
+	c.budget.MaxWait = c.MaxWait
+	c.budget.OnUpdate = c.updateRate
+	hc.Transport = transport.Chain(hc.Transport,
+		transport.Retry(transport.RetryOptions{
+			MaxRetries: c.MaxRetries,
+			BaseDelay:  2 * time.Second,
+			OnRetry:    func(req *http.Request, reason string) { c.Metrics.ObserveRetry(req.Method, reason) },
+		}),
+		transport.SecondaryRateLimit(transport.RateLimitOptions{
+			MaxRetries: c.MaxRetries,
+			MaxWait:    c.MaxWait,
+			OnRetry: func(req *http.Request, _ time.Duration) {
+				c.Metrics.ObserveRetry(req.Method, transport.ReasonRateLimit)
+			},
+		}),
+		c.budget.Middleware,
+		c.etags.Middleware,
+		c.observe,
+	)
+	c.hc = hc
+	return hc
+}
+
+// observe records every attempt of a request.
+func (c *Client) observe(next http.RoundTripper) http.RoundTripper {
+	return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		code := 0
+		if err == nil {
+			code = resp.StatusCode
+		}
+		c.Metrics.ObserveRequest(req.Method, code, time.Since(start))
+		return resp, err
+	})
+}
+
+// response is a successful response.
+type response struct {
+	header http.Header
+	body   []byte
+	// next is the URL of the next page from the Link header.
+	next string
+}
+
+// Do sends a request to path, which is relative to the API root unless it
+// is an absolute URL, encoding in as the JSON body and decoding the
+// response into out. Either may be nil.
+func (c *Client) Do(ctx context.Context, method, path string, in, out any) error {
+	resp, err := c.do(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(resp.body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in any) (*response, error) {
+	u := path
+	if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+		u = c.baseURL() + "/" + strings.TrimPrefix(path, "/")
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return &response{header: resp.Header, body: data, next: nextLink(resp.Header)}, nil
+	}
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	json.Unmarshal(data, apiErr)
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return nil, apiErr
+}
+
+func (c *Client) updateRate(_ string, r transport.Rate) {
+	c.mu.Lock()
+	c.rate = Rate{Limit: r.Limit, Remaining: r.Remaining, Reset: r.Reset}
+	c.mu.Unlock()
+	c.Metrics.SetRateLimit(r.Limit, r.Remaining)
+}
+
+var linkNextRE = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+func nextLink(h http.Header) string {
+	if m := linkNextRE.FindStringSubmatch(h.Get("Link")); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// list pages through a list endpoint. Actions endpoints wrap their items
+// in an object such as {"total_count": 1, "workflow_runs": [...]}; key
+// names the array, or is empty for endpoints that return a bare array.
+func list[T any](ctx context.Context, c *Client, path string, query url.Values, key string) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		if query == nil {
+			query = url.Values{}
+		}
+		if query.Get("per_page") == "" {
+			query.Set("per_page", "100")
+		}
+		next := path + "?" + query.Encode()
+		for next != "" {
+			resp, err := c.do(ctx, http.MethodGet, next, nil)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			var items []*T
+			if key == "" {
+				err = json.Unmarshal(resp.body, &items)
+			} else {
+				var page map[string]json.RawMessage
+				if err = json.Unmarshal(resp.body, &page); err == nil && page[key] != nil {
+					err = json.Unmarshal(page[key], &items)
+				}
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to decode response: %w", err))
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			next = resp.next
+		}
+	}
+}
+
+// collect gathers a listing into a slice.
+func collect[T any](seq iter.Seq2[*T, error]) ([]*T, error) {
+	var out []*T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// repoPath returns /repos/owner/repo followed by the escaped elements.
+func repoPath(owner, repo string, elems ...any) string {
+	parts := []string{"repos", url.PathEscape(owner), url.PathEscape(repo)}
+	for _, e := range elems {
+		parts = append(parts, url.PathEscape(fmt.Sprint(e)))
+	}
+	return "/" + strings.Join(parts, "/")
+}
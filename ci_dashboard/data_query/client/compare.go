@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CommitFile is a file a comparison or pull request changes.
+type CommitFile struct {
+	Filename string `json:"filename"`
+	// Status is added, removed, modified, renamed, copied, changed or
+	// unchanged.
+	Status           string `json:"status"`
+	PreviousFilename string `json:"previous_filename"`
+	Additions        int    `json:"additions"`
+	Deletions        int    `json:"deletions"`
+	Changes          int    `json:"changes"`
+}
+
+// ListComparisonFiles returns the files changed between the merge base of
+// base and head and head. The API lists at most 300 files; larger
+// comparisons are truncated.
+func (c *Client) ListComparisonFiles(ctx context.Context, owner, repo, base, head string) iter.Seq2[*CommitFile, error] {
+	// Branch names keep their slashes; the API splits on the three dots.
+	basehead := strings.ReplaceAll(url.PathEscape(base)+"..."+url.PathEscape(head), "%2F", "/")
+	return list[CommitFile](ctx, c, repoPath(owner, repo, "compare")+"/"+basehead, nil, "files")
+}
+
+// ListPullRequestFiles returns the files a pull request changes, at most
+// 3000.
+func (c *Client) ListPullRequestFiles(ctx context.Context, owner, repo string, number int) iter.Seq2[*CommitFile, error] {
+	return list[CommitFile](ctx, c, repoPath(owner, repo, "pulls", number, "files"), nil, "")
+}
+
+// Tree is a git tree.
+type Tree struct {
+	SHA string `json:"sha"`
+	// Truncated is set when a recursive listing exceeded the API's limit.
+	Truncated bool        `json:"truncated"`
+	Entries   []TreeEntry `json:"tree"`
+}
+
+// TreeEntry is a file, directory or submodule in a tree.
+type TreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	// Type is blob, tree or commit, for a submodule.
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+// GetTree returns the tree of a commit or tree, with every entry below it
+// if recursive is set.
+func (c *Client) GetTree(ctx context.Context, owner, repo, sha string, recursive bool) (*Tree, error) {
+	path := repoPath(owner, repo, "git", "trees", sha)
+	if recursive {
+		path += "?recursive=1"
+	}
+	var t Tree
+	if err := c.Do(ctx, http.MethodGet, path, nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Environment is a deployment environment of a repository.
+type Environment struct {
+	ID              int64            `json:"id"`
+	Name            string           `json:"name"`
+	HTMLURL         string           `json:"html_url"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	ProtectionRules []ProtectionRule `json:"protection_rules"`
+	// DeploymentBranchPolicy is nil when any branch may deploy.
+	DeploymentBranchPolicy *struct {
+		ProtectedBranches    bool `json:"protected_branches"`
+		CustomBranchPolicies bool `json:"custom_branch_policies"`
+	} `json:"deployment_branch_policy"`
+}
+
+// ProtectionRule is a built-in protection rule of an environment: a
+// required_reviewers, wait_timer or branch_policy rule.
+type ProtectionRule struct {
+	ID                int64  `json:"id"`
+	Type              string `json:"type"`
+	WaitTimer         int    `json:"wait_timer,omitempty"`
+	PreventSelfReview bool   `json:"prevent_self_review,omitempty"`
+	Reviewers         []struct {
+		Type     string `json:"type"`
+		Reviewer struct {
+			Login string `json:"login"`
+			Slug  string `json:"slug"`
+		} `json:"reviewer"`
+	} `json:"reviewers,omitempty"`
+}
+
+// CustomProtectionRule is a deployment protection rule enforced by a
+// GitHub App.
+type CustomProtectionRule struct {
+	ID      int64 `json:"id"`
+	Enabled bool  `json:"enabled"`
+	App     struct {
+		ID   int64  `json:"id"`
+		Slug string `json:"slug"`
+	} `json:"app"`
+}
+
+// ListEnvironments returns the environments of a repository.
+func (c *Client) ListEnvironments(ctx context.Context, owner, repo string) ([]*Environment, error) {
+	return collect(list[Environment](ctx, c, repoPath(owner, repo, "environments"), nil, "environments"))
+}
+
+// GetEnvironment returns the environment name, including its protection
+// rules.
+func (c *Client) GetEnvironment(ctx context.Context, owner, repo, name string) (*Environment, error) {
+	var e Environment
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "environments", name), nil, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListCustomProtectionRules returns the app-enforced protection rules of
+// the environment name.
+func (c *Client) ListCustomProtectionRules(ctx context.Context, owner, repo, name string) ([]*CustomProtectionRule, error) {
+	return collect(list[CustomProtectionRule](ctx, c, repoPath(owner, repo, "environments", name, "deployment_protection_rules"), nil, "custom_deployment_protection_rules"))
+}
+
+// ReviewCustomProtectionRule approves or rejects a deployment of a run
+// that waits on an app's protection rule, on behalf of the app.
+func (c *Client) ReviewCustomProtectionRule(ctx context.Context, owner, repo string, runID int64, environment string, approve bool, comment string) error {
+	state := "rejected"
+	if approve {
+		state = "approved"
+	}
+	body := map[string]any{"environment_name": environment, "state": state, "comment": comment}
+	return c.Do(ctx, http.MethodPost, repoPath(owner, repo, "actions", "runs", runID, "deployment_protection_rule"), body, nil)
+}
@@ -0,0 +1,193 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graphql is a client for the GitHub GraphQL API, for the Actions
+// data the REST API serves poorly: runs across many commits with their
+// check suites, required status checks, and the workflows rulesets
+// require.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/endpoints"
+)
+
+// Client sends GraphQL queries through a REST client, sharing its token,
+// retries, rate limit tracking and metrics.
+type Client struct {
+	REST *client.Client
+	// URL is the GraphQL endpoint. Defaults to the one of the instance
+	// REST.URL belongs to.
+	URL string
+}
+
+// New returns a client for the GraphQL API of the instance rest talks to.
+func New(rest *client.Client) *Client {
+	return &Client{REST: rest}
+}
+
+// NewFromEnv returns a client configured like client.NewFromEnv, with the
+// endpoint of GITHUB_GRAPHQL_URL if set.
+func NewFromEnv() *Client {
+	return &Client{REST: client.NewFromEnv(), URL: endpoints.FromEnv().GraphQL}
+}
+
+func (c *Client) endpoint() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	if c.REST.URL == "" {
+		return endpoints.FromEnv().GraphQL
+	}
+	return endpoints.ForAPI(c.REST.URL).GraphQL
+}
+
+// Error is an error GraphQL reports alongside or instead of data.
+type Error struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Path    []any  `json:"path"`
+}
+
+// Errors are the errors of a response.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Message
+		if len(err.Path) > 0 {
+			var path []string
+			for _, p := range err.Path {
+				path = append(path, fmt.Sprint(p))
+			}
+			msgs[i] = strings.Join(path, ".") + ": " + msgs[i]
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Query runs query with vars and decodes its data into out. Errors in the
+// response are returned as Errors, after out is filled with whatever data
+// came with them.
+func (c *Client) Query(ctx context.Context, query string, vars map[string]any, out any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors Errors          `json:"errors"`
+	}
+	in := map[string]any{"query": query, "variables": vars}
+	if err := c.REST.Do(ctx, http.MethodPost, c.endpoint(), in, &resp); err != nil {
+		return err
+	}
+	if out != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
+// PageInfo is the pageInfo of a connection.
+type PageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// Connection is a page of a connection queried with
+// nodes { ... } pageInfo { hasNextPage endCursor }.
+type Connection[T any] struct {
+	TotalCount int      `json:"totalCount"`
+	Nodes      []*T     `json:"nodes"`
+	PageInfo   PageInfo `json:"pageInfo"`
+}
+
+// Paginate runs query once per page of the connection at path in its data,
+// such as "organization", "repositories", and yields its nodes. The query
+// takes the page's cursor as $cursor: String, which is null for the first
+// page. A missing object along the path ends the listing.
+func Paginate[T any](ctx context.Context, c *Client, query string, vars map[string]any, path ...string) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		page := map[string]any{}
+		for k, v := range vars {
+			page[k] = v
+		}
+		page["cursor"] = nil
+		for {
+			var data json.RawMessage
+			if err := c.Query(ctx, query, page, &data); err != nil {
+				yield(nil, err)
+				return
+			}
+			conn, err := connectionAt[T](data, path)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if conn == nil {
+				return
+			}
+			for _, node := range conn.Nodes {
+				if !yield(node, nil) {
+					return
+				}
+			}
+			if !conn.PageInfo.HasNextPage || conn.PageInfo.EndCursor == "" {
+				return
+			}
+			page["cursor"] = conn.PageInfo.EndCursor
+		}
+	}
+}
+
+// Collect gathers a listing into a slice.
+func Collect[T any](seq iter.Seq2[*T, error]) ([]*T, error) {
+	var out []*T
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// connectionAt decodes the connection at path of data, or returns nil if
+// an object along the path is null.
+func connectionAt[T any](data json.RawMessage, path []string) (*Connection[T], error) {
+	for _, key := range path {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		if obj == nil || obj[key] == nil || string(obj[key]) == "null" {
+			return nil, nil
+		}
+		data = obj[key]
+	}
+	var conn Connection[T]
+	if err := json.Unmarshal(data, &conn); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &conn, nil
+}
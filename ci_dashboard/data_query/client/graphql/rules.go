@@ -0,0 +1,206 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"iter"
+)
+
+// BranchProtectionRule is a classic branch protection rule.
+type BranchProtectionRule struct {
+	Pattern                    string                `json:"pattern"`
+	RequiresStatusChecks       bool                  `json:"requiresStatusChecks"`
+	RequiresStrictStatusChecks bool                  `json:"requiresStrictStatusChecks"`
+	RequiredStatusChecks       []RequiredStatusCheck `json:"requiredStatusChecks"`
+}
+
+// RequiredStatusCheck is a check a branch protection rule requires. App is
+// nil when any app may report it.
+type RequiredStatusCheck struct {
+	Context string `json:"context"`
+	App     *App   `json:"app"`
+}
+
+const branchProtectionRulesQuery = `query($owner: String!, $name: String!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    branchProtectionRules(first: 100, after: $cursor) {
+      nodes {
+        pattern requiresStatusChecks requiresStrictStatusChecks
+        requiredStatusChecks { context app { slug name } }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+// BranchProtectionRules lists the branch protection rules of a repository.
+func (c *Client) BranchProtectionRules(ctx context.Context, owner, repo string) iter.Seq2[*BranchProtectionRule, error] {
+	return Paginate[BranchProtectionRule](ctx, c, branchProtectionRulesQuery, map[string]any{"owner": owner, "name": repo}, "repository", "branchProtectionRules")
+}
+
+// Ruleset is a repository or organization ruleset.
+type Ruleset struct {
+	DatabaseID int64  `json:"databaseId"`
+	Name       string `json:"name"`
+	// Enforcement is ACTIVE, EVALUATE or DISABLED.
+	Enforcement string `json:"enforcement"`
+	// Target is BRANCH, TAG or PUSH.
+	Target string `json:"target"`
+	Source struct {
+		// Typename is Repository or Organization.
+		Typename      string `json:"__typename"`
+		NameWithOwner string `json:"nameWithOwner,omitempty"`
+		Login         string `json:"login,omitempty"`
+	} `json:"source"`
+	Conditions struct {
+		RefName *struct {
+			Include []string `json:"include"`
+			Exclude []string `json:"exclude"`
+		} `json:"refName"`
+	} `json:"conditions"`
+	Rules Nodes[Rule] `json:"rules"`
+}
+
+// Rule is a rule of a ruleset. Parameters are decoded for the
+// REQUIRED_STATUS_CHECKS and WORKFLOWS types.
+type Rule struct {
+	Type       string `json:"type"`
+	Parameters *struct {
+		RequiredStatusChecks             []StatusCheckConfiguration `json:"requiredStatusChecks,omitempty"`
+		StrictRequiredStatusChecksPolicy bool                       `json:"strictRequiredStatusChecksPolicy,omitempty"`
+		Workflows                        []WorkflowFileReference    `json:"workflows,omitempty"`
+	} `json:"parameters"`
+}
+
+// StatusCheckConfiguration is a check a ruleset requires. IntegrationID
+// is the app that must report it, or 0 for any.
+type StatusCheckConfiguration struct {
+	Context       string `json:"context"`
+	IntegrationID int64  `json:"integrationId"`
+}
+
+// WorkflowFileReference is a workflow a ruleset requires to pass.
+type WorkflowFileReference struct {
+	Path         string `json:"path"`
+	Ref          string `json:"ref"`
+	RepositoryID int64  `json:"repositoryId"`
+	SHA          string `json:"sha"`
+}
+
+const rulesetFields = `
+      nodes {
+        databaseId name enforcement target
+        source {
+          __typename
+          ... on Repository { nameWithOwner }
+          ... on Organization { login }
+        }
+        conditions { refName { include exclude } }
+        rules(first: 50) {
+          nodes {
+            type
+            parameters {
+              ... on RequiredStatusChecksParameters {
+                requiredStatusChecks { context integrationId }
+                strictRequiredStatusChecksPolicy
+              }
+              ... on WorkflowsParameters {
+                workflows { path ref repositoryId sha }
+              }
+            }
+          }
+        }
+      }
+      pageInfo { hasNextPage endCursor }`
+
+const rulesetsQuery = `query($owner: String!, $name: String!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    rulesets(first: 50, after: $cursor, includeParents: true) {` + rulesetFields + `
+    }
+  }
+}`
+
+const orgRulesetsQuery = `query($login: String!, $cursor: String) {
+  organization(login: $login) {
+    rulesets(first: 50, after: $cursor) {` + rulesetFields + `
+    }
+  }
+}`
+
+// Rulesets lists the rulesets that apply to a repository, including those
+// of its organization.
+func (c *Client) Rulesets(ctx context.Context, owner, repo string) iter.Seq2[*Ruleset, error] {
+	return Paginate[Ruleset](ctx, c, rulesetsQuery, map[string]any{"owner": owner, "name": repo}, "repository", "rulesets")
+}
+
+// OrgRulesets lists the rulesets of an organization.
+func (c *Client) OrgRulesets(ctx context.Context, org string) iter.Seq2[*Ruleset, error] {
+	return Paginate[Ruleset](ctx, c, orgRulesetsQuery, map[string]any{"login": org}, "organization", "rulesets")
+}
+
+// RequiredWorkflows returns the workflows the rules of r require.
+func (r *Ruleset) RequiredWorkflows() []WorkflowFileReference {
+	var out []WorkflowFileReference
+	for _, rule := range r.Rules {
+		if rule.Type == "WORKFLOWS" && rule.Parameters != nil {
+			out = append(out, rule.Parameters.Workflows...)
+		}
+	}
+	return out
+}
+
+// RequiredStatusChecks returns the checks the rules of r require.
+func (r *Ruleset) RequiredStatusChecks() []StatusCheckConfiguration {
+	var out []StatusCheckConfiguration
+	for _, rule := range r.Rules {
+		if rule.Type == "REQUIRED_STATUS_CHECKS" && rule.Parameters != nil {
+			out = append(out, rule.Parameters.RequiredStatusChecks...)
+		}
+	}
+	return out
+}
+
+// Repository is a repository listed for an audit.
+type Repository struct {
+	DatabaseID       int64  `json:"databaseId"`
+	Name             string `json:"name"`
+	NameWithOwner    string `json:"nameWithOwner"`
+	URL              string `json:"url"`
+	IsArchived       bool   `json:"isArchived"`
+	IsFork           bool   `json:"isFork"`
+	IsPrivate        bool   `json:"isPrivate"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+}
+
+const orgRepositoriesQuery = `query($login: String!, $cursor: String) {
+  organization(login: $login) {
+    repositories(first: 100, after: $cursor, orderBy: {field: NAME, direction: ASC}) {
+      nodes {
+        databaseId name nameWithOwner url isArchived isFork isPrivate
+        defaultBranchRef { name }
+      }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+// OrgRepositories lists the repositories of an organization, sorted by
+// name.
+func (c *Client) OrgRepositories(ctx context.Context, org string) iter.Seq2[*Repository, error] {
+	return Paginate[Repository](ctx, c, orgRepositoriesQuery, map[string]any{"login": org}, "organization", "repositories")
+}
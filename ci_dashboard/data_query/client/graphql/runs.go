@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Nodes is a list queried as a connection's nodes { ... }, without
+// pagination. JSON decodes it from {"nodes": [...]}.
+type Nodes[T any] []*T
+
+func (n *Nodes[T]) UnmarshalJSON(data []byte) error {
+	var conn struct {
+		Nodes []*T `json:"nodes"`
+	}
+	if err := json.Unmarshal(data, &conn); err != nil {
+		return err
+	}
+	*n = conn.Nodes
+	return nil
+}
+
+// Commit is a commit with the check suites that ran on it.
+type Commit struct {
+	OID             string            `json:"oid"`
+	MessageHeadline string            `json:"messageHeadline"`
+	CommittedDate   time.Time         `json:"committedDate"`
+	URL             string            `json:"url"`
+	CheckSuites     Nodes[CheckSuite] `json:"checkSuites"`
+}
+
+// CheckSuite is the checks one app ran on a commit. Suites of GitHub
+// Actions have a WorkflowRun.
+type CheckSuite struct {
+	DatabaseID  int64        `json:"databaseId"`
+	Status      string       `json:"status"`
+	Conclusion  string       `json:"conclusion"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	App         *App         `json:"app"`
+	WorkflowRun *WorkflowRun `json:"workflowRun"`
+	// CheckRuns are only queried with CommitRunsOptions.CheckRuns.
+	CheckRuns Nodes[CheckRun] `json:"checkRuns"`
+}
+
+// App is the GitHub App behind a check suite or required check.
+type App struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// WorkflowRun is the run behind a check suite. DatabaseID is the run ID of
+// the REST API.
+type WorkflowRun struct {
+	DatabaseID int64     `json:"databaseId"`
+	RunNumber  int       `json:"runNumber"`
+	Event      string    `json:"event"`
+	URL        string    `json:"url"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Workflow   struct {
+		DatabaseID   int64  `json:"databaseId"`
+		Name         string `json:"name"`
+		ResourcePath string `json:"resourcePath"`
+	} `json:"workflow"`
+}
+
+// CheckRun is one check of a suite; for Actions, a job.
+type CheckRun struct {
+	DatabaseID  int64      `json:"databaseId"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	StartedAt   *time.Time `json:"startedAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+}
+
+// CommitRunsOptions select the commits and how much of their checks
+// CommitRuns fetches.
+type CommitRunsOptions struct {
+	// Branch defaults to the default branch.
+	Branch string
+	// Since skips commits committed before it.
+	Since time.Time
+	// PerPage is how many commits each request fetches. Defaults to 50;
+	// smaller pages keep deep queries under the API's node limit.
+	PerPage int
+	// Suites caps the check suites fetched per commit. Defaults to 25.
+	Suites int
+	// ActionsOnly drops the suites without a workflow run, which other
+	// apps created. They still count against Suites.
+	ActionsOnly bool
+	// CheckRuns also fetches up to this many check runs per suite.
+	CheckRuns int
+}
+
+// Query returns the query CommitRuns sends.
+func (o CommitRunsOptions) Query() string {
+	ref := "defaultBranchRef"
+	params := "$owner: String!, $name: String!, $cursor: String, $first: Int!, $suites: Int!"
+	if o.Branch != "" {
+		ref = "ref(qualifiedName: $branch)"
+		params += ", $branch: String!"
+	}
+	history := "history(first: $first, after: $cursor)"
+	if !o.Since.IsZero() {
+		history = "history(first: $first, after: $cursor, since: $since)"
+		params += ", $since: GitTimestamp"
+	}
+	runs := ""
+	if o.CheckRuns > 0 {
+		params += ", $checkRuns: Int!"
+		runs = "checkRuns(first: $checkRuns) { nodes { databaseId name status conclusion startedAt completedAt } }"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "query(%s) {\n", params)
+	fmt.Fprintf(&b, "  repository(owner: $owner, name: $name) {\n    %s {\n      target {\n        ... on Commit {\n", ref)
+	fmt.Fprintf(&b, "          %s {\n            nodes {\n              oid messageHeadline committedDate url\n", history)
+	b.WriteString("              checkSuites(first: $suites) {\n                nodes {\n")
+	b.WriteString("                  databaseId status conclusion createdAt\n")
+	b.WriteString("                  app { slug name }\n")
+	b.WriteString("                  workflowRun { databaseId runNumber event url createdAt updatedAt workflow { databaseId name resourcePath } }\n")
+	if runs != "" {
+		fmt.Fprintf(&b, "                  %s\n", runs)
+	}
+	b.WriteString("                }\n              }\n            }\n            pageInfo { hasNextPage endCursor }\n")
+	b.WriteString("          }\n        }\n      }\n    }\n  }\n}\n")
+	return b.String()
+}
+
+func (o CommitRunsOptions) vars(owner, repo string) map[string]any {
+	vars := map[string]any{"owner": owner, "name": repo, "first": o.PerPage, "suites": o.Suites}
+	if o.PerPage <= 0 {
+		vars["first"] = 50
+	}
+	if o.Suites <= 0 {
+		vars["suites"] = 25
+	}
+	if o.Branch != "" {
+		vars["branch"] = "refs/heads/" + strings.TrimPrefix(o.Branch, "refs/heads/")
+	}
+	if !o.Since.IsZero() {
+		vars["since"] = o.Since.UTC().Format(time.RFC3339)
+	}
+	if o.CheckRuns > 0 {
+		vars["checkRuns"] = o.CheckRuns
+	}
+	return vars
+}
+
+// CommitRuns lists the commits of a branch, newest first, with their check
+// suites and the workflow runs behind them: the runs of hundreds of
+// commits in a few requests.
+func (c *Client) CommitRuns(ctx context.Context, owner, repo string, opts CommitRunsOptions) iter.Seq2[*Commit, error] {
+	ref := "defaultBranchRef"
+	if opts.Branch != "" {
+		ref = "ref"
+	}
+	commits := Paginate[Commit](ctx, c, opts.Query(), opts.vars(owner, repo), "repository", ref, "target", "history")
+	if !opts.ActionsOnly {
+		return commits
+	}
+	return func(yield func(*Commit, error) bool) {
+		for commit, err := range commits {
+			if commit != nil {
+				commit.CheckSuites = slices.DeleteFunc(commit.CheckSuites, func(s *CheckSuite) bool { return s.WorkflowRun == nil })
+			}
+			if !yield(commit, err) {
+				return
+			}
+		}
+	}
+}
+
+// Runs returns the workflow runs of the suites of commits, skipping suites
+// of other apps.
+func Runs(commits []*Commit) []*WorkflowRun {
+	var out []*WorkflowRun
+	for _, commit := range commits {
+		for _, s := range commit.CheckSuites {
+			if s.WorkflowRun != nil {
+				out = append(out, s.WorkflowRun)
+			}
+		}
+	}
+	return out
+}
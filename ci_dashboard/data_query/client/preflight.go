@@ -0,0 +1,346 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/permissions"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Token kinds Preflight recognizes.
+const (
+	TokenClassic      = "classic"
+	TokenFineGrained  = "fine-grained"
+	TokenOAuth        = "oauth"
+	TokenInstallation = "installation"
+	TokenGitHubToken  = "github-token"
+	TokenUnknown      = "unknown"
+)
+
+// PreflightReport is what Preflight found out about the token.
+type PreflightReport struct {
+	Repository string `json:"repository"`
+	Kind       string `json:"kind"`
+	// Scopes are the OAuth scopes of a classic or OAuth token.
+	Scopes []string        `json:"scopes,omitempty"`
+	Need   permissions.Set `json:"need"`
+	// Granted are the needs the token is known to meet and Missing those
+	// it is known not to. Unverified are needs that cannot be checked
+	// without side effects, such as writes a fine-grained token may or
+	// may not be allowed.
+	Granted    permissions.Set `json:"granted"`
+	Missing    permissions.Set `json:"missing"`
+	Unverified permissions.Set `json:"unverified"`
+	// Reasons explain, per missing or unverified scope, how it was
+	// decided.
+	Reasons map[string]string `json:"reasons,omitempty"`
+}
+
+// OK reports whether no need is known to be missing.
+func (r *PreflightReport) OK() bool {
+	return len(r.Missing) == 0
+}
+
+// Err describes the missing permissions, or returns nil if there are none.
+func (r *PreflightReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	var parts []string
+	for _, scope := range sortedScopes(r.Missing) {
+		part := scope + ": " + r.Missing[scope]
+		if reason := r.Reasons[scope]; reason != "" {
+			part += " (" + reason + ")"
+		}
+		parts = append(parts, part)
+	}
+	return fmt.Errorf("the token (%s) lacks permissions on %s: %s", r.Kind, r.Repository, strings.Join(parts, ", "))
+}
+
+func (r *PreflightReport) grant(scope, level string) {
+	r.Granted[scope] = level
+}
+
+func (r *PreflightReport) miss(scope, level, reason string) {
+	r.Missing[scope] = level
+	r.Reasons[scope] = reason
+}
+
+func (r *PreflightReport) unverified(scope, level, reason string) {
+	r.Unverified[scope] = level
+	r.Reasons[scope] = reason
+}
+
+// Preflight checks that the client's token has the permissions need on
+// owner/repo, so an operation can fail up front instead of with "Resource
+// not accessible by integration" halfway through. How it finds out
+// depends on the token:
+//
+//   - classic and OAuth tokens report their scopes, which are mapped to
+//     permissions and capped by the user's role on the repository;
+//   - app installations report the permissions of their tokens;
+//   - GITHUB_TOKEN has the permissions its job declares, per
+//     permissions.FromContext;
+//   - otherwise, as for fine-grained tokens, reads are probed with
+//     harmless requests and writes are unverified unless the read fails.
+//
+// It fails only if the repository cannot be read at all.
+func (c *Client) Preflight(ctx context.Context, owner, repo string, need permissions.Set) (*PreflightReport, error) {
+	r := &PreflightReport{
+		Repository: owner + "/" + repo,
+		Kind:       tokenKind(c.Token),
+		Need:       need,
+		Granted:    permissions.Set{},
+		Missing:    permissions.Set{},
+		Unverified: permissions.Set{},
+		Reasons:    map[string]string{},
+	}
+	resp, err := c.do(ctx, http.MethodGet, repoPath(owner, repo), nil)
+	if err != nil {
+		var e *Error
+		if errors.As(err, &e) && (e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusForbidden) {
+			return nil, fmt.Errorf("the token cannot read %s: %w", r.Repository, err)
+		}
+		return nil, err
+	}
+	var info struct {
+		Private       bool            `json:"private"`
+		DefaultBranch string          `json:"default_branch"`
+		Permissions   map[string]bool `json:"permissions"`
+	}
+	if err := json.Unmarshal(resp.body, &info); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	scopes, classic := resp.header["X-Oauth-Scopes"]
+	if classic {
+		r.Scopes = splitScopes(strings.Join(scopes, ","))
+		if r.Kind == TokenUnknown {
+			r.Kind = TokenClassic
+		}
+	}
+
+	var granted permissions.Set
+	switch {
+	case c.app != nil:
+		r.Kind = TokenInstallation
+		tok, err := c.app.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		granted = permissions.Set{}
+		for name, level := range tok.Permissions {
+			granted[strings.ReplaceAll(name, "_", "-")] = level
+		}
+	case r.Kind == TokenInstallation && c.Token == os.Getenv("GITHUB_TOKEN"):
+		if s, ok := permissions.FromContext(); ok {
+			r.Kind, granted = TokenGitHubToken, s
+		}
+	}
+
+	for _, scope := range sortedScopes(need) {
+		level := need[scope]
+		switch {
+		case scope == "id-token" && userToken(r.Kind):
+			r.miss(scope, level, "only GITHUB_TOKEN can request OIDC tokens")
+		case granted != nil:
+			if permissions.Covers(granted.Level(scope), level) {
+				r.grant(scope, level)
+			} else {
+				r.miss(scope, level, "granted "+granted.Level(scope))
+			}
+		case classic:
+			r.checkScopes(scope, level, info.Private)
+		default:
+			r.probe(ctx, c, owner, repo, info.DefaultBranch, scope, level)
+		}
+	}
+	if userToken(r.Kind) {
+		r.capByRole(info.Permissions)
+	}
+	if len(r.Reasons) == 0 {
+		r.Reasons = nil
+	}
+	return r, nil
+}
+
+// tokenKind tells tokens apart by their prefix.
+func tokenKind(token string) string {
+	switch {
+	case strings.HasPrefix(token, "ghp_"):
+		return TokenClassic
+	case strings.HasPrefix(token, "github_pat_"):
+		return TokenFineGrained
+	case strings.HasPrefix(token, "gho_"), strings.HasPrefix(token, "ghu_"):
+		return TokenOAuth
+	case strings.HasPrefix(token, "ghs_"):
+		return TokenInstallation
+	}
+	return TokenUnknown
+}
+
+// userToken reports whether tokens of kind act as a user.
+func userToken(kind string) bool {
+	return kind == TokenClassic || kind == TokenFineGrained || kind == TokenOAuth
+}
+
+func splitScopes(s string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// oauthScopes maps permission scopes to the OAuth scopes granting them,
+// besides repo, which grants them all.
+var oauthScopes = map[string][]string{
+	"statuses":        {"repo:status"},
+	"deployments":     {"repo_deployment"},
+	"security-events": {"security_events"},
+}
+
+// checkScopes decides a need from the OAuth scopes of a classic token.
+func (r *PreflightReport) checkScopes(scope, level string, private bool) {
+	has := func(names ...string) bool {
+		for _, name := range names {
+			for _, s := range r.Scopes {
+				if s == name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	switch scope {
+	case "checks":
+		if level == workflow.PermissionWrite {
+			r.miss(scope, level, "only apps can create check runs")
+			return
+		}
+	case "packages":
+		if has("write:packages") || (level == workflow.PermissionRead && has("read:packages")) {
+			r.grant(scope, level)
+		} else {
+			r.miss(scope, level, "needs the "+level+":packages scope")
+		}
+		return
+	case "discussions":
+		if has("write:discussion") || (level == workflow.PermissionRead && (has("read:discussion") || !private)) {
+			r.grant(scope, level)
+		} else {
+			r.miss(scope, level, "needs the "+level+":discussion scope")
+		}
+		return
+	case "attestations", "models":
+		r.unverified(scope, level, "no OAuth scope maps to it")
+		return
+	}
+	switch {
+	case has("repo"), !private && has("public_repo"), has(oauthScopes[scope]...):
+		r.grant(scope, level)
+	case !private && level == workflow.PermissionRead:
+		// Anyone can read a public repository.
+		r.grant(scope, level)
+	case private:
+		r.miss(scope, level, "needs the repo scope")
+	default:
+		r.miss(scope, level, "needs the public_repo scope")
+	}
+}
+
+// probes are harmless requests that need read access to a scope, relative
+// to the repository; {branch} is its default branch.
+var probes = map[string]string{
+	"actions":       "actions/runs?per_page=1",
+	"checks":        "commits/{branch}/check-runs?per_page=1",
+	"contents":      "commits?per_page=1",
+	"deployments":   "deployments?per_page=1",
+	"issues":        "issues?per_page=1",
+	"pull-requests": "pulls?per_page=1",
+	"statuses":      "commits/{branch}/status",
+}
+
+// probe decides a need by trying to read the scope.
+func (r *PreflightReport) probe(ctx context.Context, c *Client, owner, repo, branch, scope, level string) {
+	p, ok := probes[scope]
+	if !ok {
+		r.unverified(scope, level, "cannot be probed")
+		return
+	}
+	p = strings.ReplaceAll(p, "{branch}", branch)
+	_, err := c.do(ctx, http.MethodGet, repoPath(owner, repo)+"/"+p, nil)
+	var e *Error
+	switch {
+	case err == nil, errors.As(err, &e) && e.StatusCode == http.StatusConflict:
+		// An empty repository has no commits to list.
+		if level == workflow.PermissionRead {
+			r.grant(scope, level)
+		} else {
+			r.unverified(scope, level, "can read; writing cannot be probed")
+		}
+	case errors.As(err, &e) && e.StatusCode == http.StatusForbidden:
+		r.miss(scope, level, "cannot read: "+e.Message)
+	default:
+		r.unverified(scope, level, "probe failed: "+err.Error())
+	}
+}
+
+// capByRole moves needs the user's role on the repository cannot meet to
+// Missing, since user tokens never exceed it. Packages have roles of their
+// own.
+func (r *PreflightReport) capByRole(role map[string]bool) {
+	if role == nil {
+		return
+	}
+	for _, set := range []permissions.Set{r.Granted, r.Unverified} {
+		for scope, level := range set {
+			if scope == "packages" {
+				continue
+			}
+			ok := role["pull"]
+			if level == workflow.PermissionWrite {
+				ok = role["push"] || role["maintain"] || role["admin"] ||
+					(role["triage"] && (scope == "issues" || scope == "pull-requests"))
+			}
+			if !ok {
+				delete(set, scope)
+				r.miss(scope, level, "the user's role on the repository does not allow it")
+			}
+		}
+	}
+}
+
+func sortedScopes(s permissions.Set) []string {
+	scopes := make([]string, 0, len(s))
+	for scope, level := range s {
+		if level != workflow.PermissionNone {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Release is a GitHub release.
+type Release struct {
+	ID              int64           `json:"id"`
+	TagName         string          `json:"tag_name"`
+	TargetCommitish string          `json:"target_commitish"`
+	Name            string          `json:"name"`
+	Body            string          `json:"body"`
+	Draft           bool            `json:"draft"`
+	Prerelease      bool            `json:"prerelease"`
+	HTMLURL         string          `json:"html_url"`
+	UploadURL       string          `json:"upload_url"`
+	CreatedAt       time.Time       `json:"created_at"`
+	PublishedAt     *time.Time      `json:"published_at"`
+	Assets          []*ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a release.
+type ReleaseAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Label              string `json:"label"`
+	ContentType        string `json:"content_type"`
+	Size               int64  `json:"size"`
+	State              string `json:"state"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// ReleaseRequest creates or updates a release. Nil fields are left as they
+// are on update.
+type ReleaseRequest struct {
+	TagName         string `json:"tag_name,omitempty"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Body            string `json:"body,omitempty"`
+	Draft           *bool  `json:"draft,omitempty"`
+	Prerelease      *bool  `json:"prerelease,omitempty"`
+	// MakeLatest is true, false or legacy, which marks the newest semver
+	// release latest.
+	MakeLatest string `json:"make_latest,omitempty"`
+}
+
+// GetReleaseByTag returns the published release of a tag. Drafts have no
+// tag yet and are only found by ListReleases.
+func (c *Client) GetReleaseByTag(ctx context.Context, owner, repo, tag string) (*Release, error) {
+	var r Release
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "releases", "tags", tag), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListReleases returns the releases of a repository, newest first,
+// including drafts when the token can push.
+func (c *Client) ListReleases(ctx context.Context, owner, repo string) ([]*Release, error) {
+	return collect(list[Release](ctx, c, repoPath(owner, repo, "releases"), nil, ""))
+}
+
+// CreateRelease creates a release, and the tag if it does not exist.
+func (c *Client) CreateRelease(ctx context.Context, owner, repo string, req *ReleaseRequest) (*Release, error) {
+	var r Release
+	if err := c.Do(ctx, http.MethodPost, repoPath(owner, repo, "releases"), req, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UpdateRelease updates a release.
+func (c *Client) UpdateRelease(ctx context.Context, owner, repo string, id int64, req *ReleaseRequest) (*Release, error) {
+	var r Release
+	if err := c.Do(ctx, http.MethodPatch, repoPath(owner, repo, "releases", id), req, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// DeleteReleaseAsset deletes an asset.
+func (c *Client) DeleteReleaseAsset(ctx context.Context, owner, repo string, id int64) error {
+	return c.Do(ctx, http.MethodDelete, repoPath(owner, repo, "releases", "assets", id), nil, nil)
+}
+
+// UploadReleaseAsset uploads size bytes of r as the asset name of the
+// release whose upload URL is uploadURL.
+func (c *Client) UploadReleaseAsset(ctx context.Context, uploadURL, name, label, contentType string, r io.ReaderAt, size int64) (*ReleaseAsset, error) {
+	// The upload URL is a template such as .../assets{?name,label}.
+	base, _, _ := strings.Cut(uploadURL, "{")
+	query := url.Values{"name": {name}}
+	if label != "" {
+		query.Set("label", label)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"?"+query.Encode(), io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	// Retries send the body again.
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(r, 0, size)), nil
+	}
+	req.ContentLength = size
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Content-Type", contentType)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		json.Unmarshal(data, apiErr)
+		return nil, fmt.Errorf("failed to upload %s: %w", name, apiErr)
+	}
+	var a ReleaseAsset
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &a, nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Repository is a GitHub repository.
+type Repository struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+	Archived      bool   `json:"archived"`
+	Disabled      bool   `json:"disabled"`
+	Fork          bool   `json:"fork"`
+	HTMLURL       string `json:"html_url"`
+	// PushedAt is when a commit was last pushed to any branch.
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// GetRepository returns a repository.
+func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*Repository, error) {
+	var r Repository
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetRepositoryByID returns the repository with the ID, which rules refer
+// to repositories by.
+func (c *Client) GetRepositoryByID(ctx context.Context, id int64) (*Repository, error) {
+	var r Repository
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/repositories/%d", id), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListOrgRepos returns the repositories of an organization.
+func (c *Client) ListOrgRepos(ctx context.Context, org string) iter.Seq2[*Repository, error] {
+	return list[Repository](ctx, c, "/orgs/"+url.PathEscape(org)+"/repos", nil, "")
+}
+
+// ContentEntry is an entry of a directory listing.
+type ContentEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// SHA is the blob SHA of a file.
+	SHA string `json:"sha"`
+	// Type is file, dir, symlink or submodule.
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+}
+
+// ListContents returns the entries of the directory at path in a
+// repository at ref, which is the default branch if empty.
+func (c *Client) ListContents(ctx context.Context, owner, repo, path, ref string) ([]*ContentEntry, error) {
+	var entries []*ContentEntry
+	if err := c.Do(ctx, http.MethodGet, contentsPath(owner, repo, path, ref), nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetBlob returns the content of the blob sha.
+func (c *Client) GetBlob(ctx context.Context, owner, repo, sha string) ([]byte, error) {
+	var blob struct {
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+	}
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "git", "blobs", sha), nil, &blob); err != nil {
+		return nil, err
+	}
+	return decodeContent(blob.Encoding, blob.Content)
+}
+
+func contentsPath(owner, repo, path, ref string) string {
+	p := repoPath(owner, repo, "contents") + "/" + strings.ReplaceAll(url.PathEscape(path), "%2F", "/")
+	if ref != "" {
+		p += "?ref=" + url.QueryEscape(ref)
+	}
+	return p
+}
+
+func decodeContent(encoding, content string) ([]byte, error) {
+	if encoding != "base64" {
+		return []byte(content), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(content, "\n", ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode content: %w", err)
+	}
+	return data, nil
+}
+
+// GetContents returns the content of the file at path in a repository at
+// ref, which is the default branch if empty.
+func (c *Client) GetContents(ctx context.Context, owner, repo, path, ref string) ([]byte, error) {
+	var file struct {
+		Type     string `json:"type"`
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+	}
+	if err := c.Do(ctx, http.MethodGet, contentsPath(owner, repo, path, ref), nil, &file); err != nil {
+		return nil, err
+	}
+	if file.Type != "file" {
+		return nil, fmt.Errorf("%s is not a file", path)
+	}
+	return decodeContent(file.Encoding, file.Content)
+}
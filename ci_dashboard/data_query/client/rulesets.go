@@ -0,0 +1,178 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Ruleset types of rule that involve workflows.
+const (
+	RuleWorkflows            = "workflows"
+	RuleRequiredStatusChecks = "required_status_checks"
+	// RuleMergeQueue sends pull requests through a merge queue, which
+	// needs the required checks to run for merge groups.
+	RuleMergeQueue = "merge_queue"
+)
+
+// Ruleset is a repository or organization ruleset. Listings leave out
+// Conditions and Rules; GetOrgRuleset and GetRuleset return them.
+type Ruleset struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Target is branch, tag or push.
+	Target string `json:"target"`
+	// SourceType is Repository, Organization or Enterprise, and Source
+	// its name.
+	SourceType string `json:"source_type"`
+	Source     string `json:"source"`
+	// Enforcement is active, evaluate or disabled.
+	Enforcement string             `json:"enforcement"`
+	Conditions  *RulesetConditions `json:"conditions,omitempty"`
+	Rules       []Rule             `json:"rules,omitempty"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// RulesetConditions select the refs and, for organization rulesets, the
+// repositories a ruleset applies to.
+type RulesetConditions struct {
+	RefName        *NameCondition `json:"ref_name,omitempty"`
+	RepositoryName *NameCondition `json:"repository_name,omitempty"`
+	RepositoryID   *struct {
+		RepositoryIDs []int64 `json:"repository_ids"`
+	} `json:"repository_id,omitempty"`
+	// RepositoryProperty selects repositories by custom property, which
+	// is kept undecoded.
+	RepositoryProperty json.RawMessage `json:"repository_property,omitempty"`
+}
+
+// NameCondition includes and excludes names by fnmatch pattern. Ref
+// patterns may also be ~DEFAULT_BRANCH or ~ALL, and repository patterns
+// ~ALL.
+type NameCondition struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// Rule is a rule of a ruleset. Parameters depend on Type.
+type Rule struct {
+	Type       string          `json:"type"`
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// BranchRule is a rule that applies to a branch, with the ruleset it comes
+// from.
+type BranchRule struct {
+	Rule
+	RulesetSourceType string `json:"ruleset_source_type"`
+	RulesetSource     string `json:"ruleset_source"`
+	RulesetID         int64  `json:"ruleset_id"`
+}
+
+// WorkflowsParameters are the parameters of a workflows rule.
+type WorkflowsParameters struct {
+	DoNotEnforceOnCreate bool           `json:"do_not_enforce_on_create"`
+	Workflows            []RuleWorkflow `json:"workflows"`
+}
+
+// RuleWorkflow is a workflow a workflows rule requires to pass. Ref or SHA
+// picks its version.
+type RuleWorkflow struct {
+	Path         string `json:"path"`
+	RepositoryID int64  `json:"repository_id"`
+	Ref          string `json:"ref,omitempty"`
+	SHA          string `json:"sha,omitempty"`
+}
+
+// StatusChecksParameters are the parameters of a required_status_checks
+// rule.
+type StatusChecksParameters struct {
+	RequiredStatusChecks []struct {
+		Context string `json:"context"`
+		// IntegrationID is the app that must set the check, if any.
+		IntegrationID int64 `json:"integration_id,omitempty"`
+	} `json:"required_status_checks"`
+	StrictPolicy bool `json:"strict_required_status_checks_policy"`
+}
+
+// Workflows returns the parameters of a workflows rule.
+func (r *Rule) Workflows() (*WorkflowsParameters, error) {
+	var p WorkflowsParameters
+	if err := r.decode(RuleWorkflows, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// StatusChecks returns the parameters of a required_status_checks rule.
+func (r *Rule) StatusChecks() (*StatusChecksParameters, error) {
+	var p StatusChecksParameters
+	if err := r.decode(RuleRequiredStatusChecks, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *Rule) decode(typ string, v any) error {
+	if r.Type != typ {
+		return fmt.Errorf("rule is %s, not %s", r.Type, typ)
+	}
+	if err := json.Unmarshal(r.Parameters, v); err != nil {
+		return fmt.Errorf("failed to decode the parameters of a %s rule: %w", typ, err)
+	}
+	return nil
+}
+
+// ListBranchRules returns the active rules that apply to branch, from the
+// rulesets of the repository and those above it.
+func (c *Client) ListBranchRules(ctx context.Context, owner, repo, branch string) ([]*BranchRule, error) {
+	return collect(list[BranchRule](ctx, c, repoPath(owner, repo, "rules", "branches", branch), nil, ""))
+}
+
+// ListRulesets returns the rulesets of a repository, including those of
+// its organization.
+func (c *Client) ListRulesets(ctx context.Context, owner, repo string) ([]*Ruleset, error) {
+	return collect(list[Ruleset](ctx, c, repoPath(owner, repo, "rulesets"), url.Values{"includes_parents": {"true"}}, ""))
+}
+
+// GetRuleset returns a ruleset of a repository with its conditions and
+// rules.
+func (c *Client) GetRuleset(ctx context.Context, owner, repo string, id int64) (*Ruleset, error) {
+	var rs Ruleset
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "rulesets", id), nil, &rs); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// ListOrgRulesets returns the rulesets of an organization.
+func (c *Client) ListOrgRulesets(ctx context.Context, org string) ([]*Ruleset, error) {
+	return collect(list[Ruleset](ctx, c, "/orgs/"+url.PathEscape(org)+"/rulesets", nil, ""))
+}
+
+// GetOrgRuleset returns a ruleset of an organization with its conditions
+// and rules.
+func (c *Client) GetOrgRuleset(ctx context.Context, org string, id int64) (*Ruleset, error) {
+	var rs Ruleset
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/orgs/%s/rulesets/%d", url.PathEscape(org), id), nil, &rs); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
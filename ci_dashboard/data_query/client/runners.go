@@ -0,0 +1,259 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Runner is a registered self-hosted runner.
+type Runner struct {
+	ID     int64         `json:"id"`
+	Name   string        `json:"name"`
+	OS     string        `json:"os"`
+	Status string        `json:"status"`
+	Busy   bool          `json:"busy"`
+	Labels []RunnerLabel `json:"labels"`
+	// Ephemeral runners unregister after one job.
+	Ephemeral     bool  `json:"ephemeral,omitempty"`
+	RunnerGroupID int64 `json:"runner_group_id,omitempty"`
+}
+
+// Online reports whether the runner is connected.
+func (r *Runner) Online() bool { return r.Status == "online" }
+
+// LabelNames returns the names of the runner's labels.
+func (r *Runner) LabelNames() []string {
+	names := make([]string, len(r.Labels))
+	for i, l := range r.Labels {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// RunnerLabel is a label of a runner. Type is read-only for labels the
+// runner reports itself, such as self-hosted and its OS, and custom for
+// labels that were assigned.
+type RunnerLabel struct {
+	ID   int64  `json:"id,omitempty"`
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// RunnerToken is a short-lived token for configuring or removing a runner.
+type RunnerToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ListRunners returns the self-hosted runners of the scope.
+func (c *Client) ListRunners(ctx context.Context, s Scope) ([]*Runner, error) {
+	return collect(list[Runner](ctx, c, s.path("runners"), nil, "runners"))
+}
+
+// GetRunner returns a runner.
+func (c *Client) GetRunner(ctx context.Context, s Scope, id int64) (*Runner, error) {
+	var r Runner
+	if err := c.Do(ctx, http.MethodGet, s.path("runners", strconv.FormatInt(id, 10)), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// DeleteRunner unregisters a runner. A busy runner finishes its job first.
+func (c *Client) DeleteRunner(ctx context.Context, s Scope, id int64) error {
+	return c.Do(ctx, http.MethodDelete, s.path("runners", strconv.FormatInt(id, 10)), nil, nil)
+}
+
+// RegistrationToken returns a token for config.sh to register a runner.
+func (c *Client) RegistrationToken(ctx context.Context, s Scope) (*RunnerToken, error) {
+	var t RunnerToken
+	if err := c.Do(ctx, http.MethodPost, s.path("runners", "registration-token"), nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RemoveToken returns a token for config.sh remove.
+func (c *Client) RemoveToken(ctx context.Context, s Scope) (*RunnerToken, error) {
+	var t RunnerToken
+	if err := c.Do(ctx, http.MethodPost, s.path("runners", "remove-token"), nil, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// JITConfigOptions describe a just-in-time runner.
+type JITConfigOptions struct {
+	Name   string
+	Labels []string
+	// GroupID defaults to 1, the default runner group.
+	GroupID int64
+	// WorkFolder defaults to _work.
+	WorkFolder string
+}
+
+// JITConfig is the configuration of a just-in-time runner, which is
+// registered by the API and runs a single job.
+type JITConfig struct {
+	Runner *Runner `json:"runner"`
+	// Encoded is passed to run.sh --jitconfig.
+	Encoded string `json:"encoded_jit_config"`
+}
+
+// GenerateJITConfig registers an ephemeral runner and returns its
+// configuration, so no registration token or config.sh step is needed.
+func (c *Client) GenerateJITConfig(ctx context.Context, s Scope, opts JITConfigOptions) (*JITConfig, error) {
+	if len(opts.Labels) == 0 {
+		return nil, fmt.Errorf("a just-in-time runner needs at least one label")
+	}
+	body := map[string]any{
+		"name":            opts.Name,
+		"labels":          opts.Labels,
+		"runner_group_id": opts.GroupID,
+		"work_folder":     opts.WorkFolder,
+	}
+	if opts.GroupID == 0 {
+		body["runner_group_id"] = 1
+	}
+	if opts.WorkFolder == "" {
+		body["work_folder"] = "_work"
+	}
+	var cfg JITConfig
+	if err := c.Do(ctx, http.MethodPost, s.path("runners", "generate-jitconfig"), body, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// runnerLabels sends a labels request and returns the runner's labels.
+func (c *Client) runnerLabels(ctx context.Context, method string, s Scope, id int64, body any, elems ...string) ([]RunnerLabel, error) {
+	var resp struct {
+		Labels []RunnerLabel `json:"labels"`
+	}
+	path := s.path("runners", append([]string{strconv.FormatInt(id, 10), "labels"}, elems...)...)
+	if err := c.Do(ctx, method, path, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Labels, nil
+}
+
+// ListRunnerLabels returns the labels of a runner.
+func (c *Client) ListRunnerLabels(ctx context.Context, s Scope, id int64) ([]RunnerLabel, error) {
+	return c.runnerLabels(ctx, http.MethodGet, s, id, nil)
+}
+
+// AddRunnerLabels adds custom labels to a runner.
+func (c *Client) AddRunnerLabels(ctx context.Context, s Scope, id int64, labels []string) ([]RunnerLabel, error) {
+	return c.runnerLabels(ctx, http.MethodPost, s, id, map[string][]string{"labels": labels})
+}
+
+// SetRunnerLabels replaces the custom labels of a runner.
+func (c *Client) SetRunnerLabels(ctx context.Context, s Scope, id int64, labels []string) ([]RunnerLabel, error) {
+	if labels == nil {
+		labels = []string{}
+	}
+	return c.runnerLabels(ctx, http.MethodPut, s, id, map[string][]string{"labels": labels})
+}
+
+// RemoveRunnerLabel removes a custom label from a runner.
+func (c *Client) RemoveRunnerLabel(ctx context.Context, s Scope, id int64, label string) ([]RunnerLabel, error) {
+	return c.runnerLabels(ctx, http.MethodDelete, s, id, nil, label)
+}
+
+// RunnerGroup is an organization's group of runners and the repositories
+// and workflows that may use it.
+type RunnerGroup struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Visibility is all, private or selected.
+	Visibility               string   `json:"visibility"`
+	Default                  bool     `json:"default"`
+	AllowsPublicRepositories bool     `json:"allows_public_repositories"`
+	RestrictedToWorkflows    bool     `json:"restricted_to_workflows"`
+	SelectedWorkflows        []string `json:"selected_workflows,omitempty"`
+}
+
+// RunnerGroupOptions are the settings of a runner group.
+type RunnerGroupOptions struct {
+	Name string `json:"name,omitempty"`
+	// Visibility defaults to all.
+	Visibility string `json:"visibility,omitempty"`
+	// Repositories are the IDs of the repositories that may use the group
+	// when Visibility is selected.
+	Repositories             []int64 `json:"selected_repository_ids,omitempty"`
+	AllowsPublicRepositories bool    `json:"allows_public_repositories"`
+	// Workflows restricts the group to these workflows, given as
+	// owner/repo/.github/workflows/file.yml@ref.
+	RestrictedToWorkflows bool     `json:"restricted_to_workflows"`
+	Workflows             []string `json:"selected_workflows,omitempty"`
+}
+
+func groupPath(org string, elems ...any) string {
+	p := "/orgs/" + url.PathEscape(org) + "/actions/runner-groups"
+	for _, e := range elems {
+		p += "/" + url.PathEscape(fmt.Sprint(e))
+	}
+	return p
+}
+
+// ListRunnerGroups returns the runner groups of an organization.
+func (c *Client) ListRunnerGroups(ctx context.Context, org string) ([]*RunnerGroup, error) {
+	return collect(list[RunnerGroup](ctx, c, groupPath(org), nil, "runner_groups"))
+}
+
+// CreateRunnerGroup creates a runner group.
+func (c *Client) CreateRunnerGroup(ctx context.Context, org string, opts RunnerGroupOptions) (*RunnerGroup, error) {
+	var g RunnerGroup
+	if err := c.Do(ctx, http.MethodPost, groupPath(org), opts, &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// UpdateRunnerGroup changes the settings of a runner group.
+func (c *Client) UpdateRunnerGroup(ctx context.Context, org string, id int64, opts RunnerGroupOptions) (*RunnerGroup, error) {
+	var g RunnerGroup
+	if err := c.Do(ctx, http.MethodPatch, groupPath(org, id), opts, &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// DeleteRunnerGroup deletes a runner group. Its runners move to the
+// default group.
+func (c *Client) DeleteRunnerGroup(ctx context.Context, org string, id int64) error {
+	return c.Do(ctx, http.MethodDelete, groupPath(org, id), nil, nil)
+}
+
+// ListGroupRunners returns the runners in a group.
+func (c *Client) ListGroupRunners(ctx context.Context, org string, id int64) ([]*Runner, error) {
+	return collect(list[Runner](ctx, c, groupPath(org, id, "runners"), nil, "runners"))
+}
+
+// AddGroupRunner moves a runner into a group.
+func (c *Client) AddGroupRunner(ctx context.Context, org string, groupID, runnerID int64) error {
+	return c.Do(ctx, http.MethodPut, groupPath(org, groupID, "runners", runnerID), nil, nil)
+}
+
+// RemoveGroupRunner moves a runner out of a group, into the default group.
+func (c *Client) RemoveGroupRunner(ctx context.Context, org string, groupID, runnerID int64) error {
+	return c.Do(ctx, http.MethodDelete, groupPath(org, groupID, "runners", runnerID), nil, nil)
+}
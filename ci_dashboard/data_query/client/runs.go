@@ -0,0 +1,391 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Workflow is a workflow file registered with a repository.
+type Workflow struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	State     string    `json:"state"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListWorkflows returns the workflows of a repository.
+func (c *Client) ListWorkflows(ctx context.Context, owner, repo string) ([]*Workflow, error) {
+	return collect(list[Workflow](ctx, c, repoPath(owner, repo, "actions", "workflows"), nil, "workflows"))
+}
+
+// GetWorkflow returns a workflow by ID or file name, such as "ci.yml".
+func (c *Client) GetWorkflow(ctx context.Context, owner, repo, workflow string) (*Workflow, error) {
+	var w Workflow
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "actions", "workflows", workflow), nil, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// DispatchWorkflow triggers a workflow_dispatch event for workflow, an ID or
+// file name, on ref.
+func (c *Client) DispatchWorkflow(ctx context.Context, owner, repo, workflow, ref string, inputs map[string]any) error {
+	body := map[string]any{"ref": ref}
+	if len(inputs) > 0 {
+		body["inputs"] = inputs
+	}
+	return c.Do(ctx, http.MethodPost, repoPath(owner, repo, "actions", "workflows", workflow, "dispatches"), body, nil)
+}
+
+// Run is a workflow run.
+type Run struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	DisplayTitle string    `json:"display_title"`
+	RunNumber    int       `json:"run_number"`
+	RunAttempt   int       `json:"run_attempt"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	WorkflowID   int64     `json:"workflow_id"`
+	Path         string    `json:"path"`
+	HeadBranch   string    `json:"head_branch"`
+	HeadSHA      string    `json:"head_sha"`
+	HTMLURL      string    `json:"html_url"`
+	Actor        *User     `json:"actor"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	RunStartedAt time.Time `json:"run_started_at"`
+}
+
+// Completed reports whether the run has finished.
+func (r *Run) Completed() bool {
+	return r.Status == "completed"
+}
+
+// Duration returns how long the run took, or has taken so far.
+func (r *Run) Duration() time.Duration {
+	end := r.UpdatedAt
+	if !r.Completed() {
+		end = time.Now()
+	}
+	if r.RunStartedAt.IsZero() {
+		return 0
+	}
+	return end.Sub(r.RunStartedAt)
+}
+
+// User is the account that triggered a run.
+type User struct {
+	Login string `json:"login"`
+	ID    int64  `json:"id"`
+}
+
+// RunsOptions filters ListRuns. Zero fields are not filtered on.
+type RunsOptions struct {
+	// Workflow limits the listing to one workflow, by ID or file name.
+	Workflow string
+	Actor    string
+	Branch   string
+	Event    string
+	// Status is a status such as "in_progress" or a conclusion such as
+	// "failure".
+	Status  string
+	HeadSHA string
+	// Created is a date range such as ">=2025-01-01".
+	Created string
+}
+
+// ListRuns returns the workflow runs of a repository, newest first.
+func (c *Client) ListRuns(ctx context.Context, owner, repo string, opts RunsOptions) iter.Seq2[*Run, error] {
+	path := repoPath(owner, repo, "actions", "runs")
+	if opts.Workflow != "" {
+		path = repoPath(owner, repo, "actions", "workflows", opts.Workflow, "runs")
+	}
+	q := url.Values{}
+	for k, v := range map[string]string{
+		"actor":    opts.Actor,
+		"branch":   opts.Branch,
+		"event":    opts.Event,
+		"status":   opts.Status,
+		"head_sha": opts.HeadSHA,
+		"created":  opts.Created,
+	} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	return list[Run](ctx, c, path, q, "workflow_runs")
+}
+
+// GetRun returns a workflow run.
+func (c *Client) GetRun(ctx context.Context, owner, repo string, id int64) (*Run, error) {
+	var r Run
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "actions", "runs", id), nil, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CancelRun cancels a workflow run.
+func (c *Client) CancelRun(ctx context.Context, owner, repo string, id int64) error {
+	return c.Do(ctx, http.MethodPost, repoPath(owner, repo, "actions", "runs", id, "cancel"), nil, nil)
+}
+
+// ForceCancelRun cancels a workflow run, bypassing always() conditions that
+// would otherwise keep jobs running.
+func (c *Client) ForceCancelRun(ctx context.Context, owner, repo string, id int64) error {
+	return c.Do(ctx, http.MethodPost, repoPath(owner, repo, "actions", "runs", id, "force-cancel"), nil, nil)
+}
+
+// RerunRun re-runs every job of a workflow run.
+func (c *Client) RerunRun(ctx context.Context, owner, repo string, id int64, debug bool) error {
+	return c.Do(ctx, http.MethodPost, repoPath(owner, repo, "actions", "runs", id, "rerun"), rerunBody(debug), nil)
+}
+
+// RerunFailedJobs re-runs the failed jobs of a workflow run and the jobs
+// that depend on them.
+func (c *Client) RerunFailedJobs(ctx context.Context, owner, repo string, id int64, debug bool) error {
+	return c.Do(ctx, http.MethodPost, repoPath(owner, repo, "actions", "runs", id, "rerun-failed-jobs"), rerunBody(debug), nil)
+}
+
+// RerunJob re-runs one job and the jobs that depend on it.
+func (c *Client) RerunJob(ctx context.Context, owner, repo string, jobID int64, debug bool) error {
+	return c.Do(ctx, http.MethodPost, repoPath(owner, repo, "actions", "jobs", jobID, "rerun"), rerunBody(debug), nil)
+}
+
+func rerunBody(debug bool) map[string]bool {
+	return map[string]bool{"enable_debug_logging": debug}
+}
+
+// RerunOptions select what Rerun runs again. The zero value re-runs every
+// job.
+type RerunOptions struct {
+	// FailedOnly re-runs the failed jobs and the jobs that depend on them.
+	FailedOnly bool
+	// Job re-runs only the job with this ID and the jobs that depend on it.
+	Job int64
+	// Debug enables runner and step debug logging in the new attempt.
+	Debug bool
+	// Wait is how long to wait for the new attempt to show up, which lags
+	// the request. Defaults to 30 seconds.
+	Wait time.Duration
+}
+
+// Rerun starts a new attempt of a completed workflow run and returns the
+// run once the API reports the new attempt.
+func (c *Client) Rerun(ctx context.Context, owner, repo string, id int64, opts RerunOptions) (*Run, error) {
+	run, err := c.GetRun(ctx, owner, repo, id)
+	if err != nil {
+		return nil, err
+	}
+	if !run.Completed() {
+		return nil, fmt.Errorf("run %d is still %s", id, run.Status)
+	}
+	switch {
+	case opts.Job != 0:
+		err = c.RerunJob(ctx, owner, repo, opts.Job, opts.Debug)
+	case opts.FailedOnly:
+		err = c.RerunFailedJobs(ctx, owner, repo, id, opts.Debug)
+	default:
+		err = c.RerunRun(ctx, owner, repo, id, opts.Debug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Wait <= 0 {
+		opts.Wait = 30 * time.Second
+	}
+	deadline := time.Now().Add(opts.Wait)
+	for {
+		next, err := c.GetRun(ctx, owner, repo, id)
+		if err != nil {
+			return nil, err
+		}
+		if next.RunAttempt > run.RunAttempt {
+			return next, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("run %d has no new attempt after %v", id, opts.Wait)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// DeleteRun deletes a completed workflow run.
+func (c *Client) DeleteRun(ctx context.Context, owner, repo string, id int64) error {
+	return c.Do(ctx, http.MethodDelete, repoPath(owner, repo, "actions", "runs", id), nil, nil)
+}
+
+// Job is a job of a workflow run.
+type Job struct {
+	ID          int64     `json:"id"`
+	RunID       int64     `json:"run_id"`
+	RunAttempt  int       `json:"run_attempt"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	HTMLURL     string    `json:"html_url"`
+	Labels      []string  `json:"labels"`
+	RunnerName  string    `json:"runner_name"`
+	CreatedAt   time.Time `json:"created_at"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Steps       []*Step   `json:"steps"`
+}
+
+// QueueTime returns how long the job waited for a runner.
+func (j *Job) QueueTime() time.Duration {
+	return span(j.CreatedAt, j.StartedAt)
+}
+
+// Duration returns how long the job ran.
+func (j *Job) Duration() time.Duration {
+	return span(j.StartedAt, j.CompletedAt)
+}
+
+// Step is a step of a job.
+type Step struct {
+	Number      int       `json:"number"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Duration returns how long the step ran.
+func (s *Step) Duration() time.Duration {
+	return span(s.StartedAt, s.CompletedAt)
+}
+
+// span returns the time from start to end, or 0 if either is unknown.
+func span(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// ListJobs returns the jobs of the latest attempt of a run, or of every
+// attempt if all is set.
+func (c *Client) ListJobs(ctx context.Context, owner, repo string, runID int64, all bool) ([]*Job, error) {
+	q := url.Values{"filter": {"latest"}}
+	if all {
+		q.Set("filter", "all")
+	}
+	return collect(list[Job](ctx, c, repoPath(owner, repo, "actions", "runs", runID, "jobs"), q, "jobs"))
+}
+
+// ListAttemptJobs returns the jobs of one attempt of a run.
+func (c *Client) ListAttemptJobs(ctx context.Context, owner, repo string, runID int64, attempt int) ([]*Job, error) {
+	path := repoPath(owner, repo, "actions", "runs", runID, "attempts", strconv.Itoa(attempt), "jobs")
+	return collect(list[Job](ctx, c, path, nil, "jobs"))
+}
+
+// GetJob returns a job.
+func (c *Client) GetJob(ctx context.Context, owner, repo string, id int64) (*Job, error) {
+	var j Job
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "actions", "jobs", id), nil, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// DownloadJobLogs returns the plain text log of a job. The caller must
+// close it.
+func (c *Client) DownloadJobLogs(ctx context.Context, owner, repo string, jobID int64) (*http.Response, error) {
+	return c.download(ctx, repoPath(owner, repo, "actions", "jobs", jobID, "logs"))
+}
+
+// DownloadRunLogs returns a zip archive of the logs of every job of a run,
+// with one file per step. The caller must close it.
+func (c *Client) DownloadRunLogs(ctx context.Context, owner, repo string, runID int64) (*http.Response, error) {
+	return c.download(ctx, repoPath(owner, repo, "actions", "runs", runID, "logs"))
+}
+
+// download follows the redirect to the storage URL of a log or artifact.
+// Bodies are streamed rather than buffered.
+func (c *Client) download(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL()+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %w", path, &Error{StatusCode: resp.StatusCode, Message: resp.Status})
+	}
+	return resp, nil
+}
+
+// PendingDeployment is an environment a run is waiting for approval to
+// deploy to.
+type PendingDeployment struct {
+	Environment struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"environment"`
+	WaitTimer             int  `json:"wait_timer"`
+	CurrentUserCanApprove bool `json:"current_user_can_approve"`
+	Reviewers             []struct {
+		Type     string `json:"type"`
+		Reviewer struct {
+			Login string `json:"login"`
+			Slug  string `json:"slug"`
+		} `json:"reviewer"`
+	} `json:"reviewers"`
+}
+
+// PendingDeployments returns the environments a run is waiting on.
+func (c *Client) PendingDeployments(ctx context.Context, owner, repo string, runID int64) ([]*PendingDeployment, error) {
+	var out []*PendingDeployment
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo, "actions", "runs", runID, "pending_deployments"), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReviewDeployments approves or rejects the deployments of a run to the
+// environments with the given IDs.
+func (c *Client) ReviewDeployments(ctx context.Context, owner, repo string, runID int64, environments []int64, approve bool, comment string) error {
+	state := "rejected"
+	if approve {
+		state = "approved"
+	}
+	body := map[string]any{"environment_ids": environments, "state": state, "comment": comment}
+	return c.Do(ctx, http.MethodPost, repoPath(owner, repo, "actions", "runs", runID, "pending_deployments"), body, nil)
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Scope is where secrets and variables are stored: a repository, one of
+// its deployment environments or an organization.
+type Scope struct {
+	Owner string
+	// Repo is empty for organization secrets and variables.
+	Repo string
+	// Environment is set for environment secrets and variables.
+	Environment string
+}
+
+// Repo returns the scope of a repository.
+func Repo(owner, repo string) Scope { return Scope{Owner: owner, Repo: repo} }
+
+// Env returns the scope of a deployment environment of a repository.
+func Env(owner, repo, environment string) Scope {
+	return Scope{Owner: owner, Repo: repo, Environment: environment}
+}
+
+// Org returns the scope of an organization.
+func Org(org string) Scope { return Scope{Owner: org} }
+
+func (s Scope) String() string {
+	switch {
+	case s.Repo == "":
+		return s.Owner
+	case s.Environment != "":
+		return s.Owner + "/" + s.Repo + " environment " + s.Environment
+	}
+	return s.Owner + "/" + s.Repo
+}
+
+func (s Scope) path(kind string, elems ...string) string {
+	var p string
+	switch {
+	case s.Repo == "":
+		p = "/orgs/" + url.PathEscape(s.Owner) + "/actions/" + kind
+	case s.Environment != "":
+		p = repoPath(s.Owner, s.Repo, "environments", s.Environment, kind)
+	default:
+		p = repoPath(s.Owner, s.Repo, "actions", kind)
+	}
+	for _, e := range elems {
+		p += "/" + url.PathEscape(e)
+	}
+	return p
+}
+
+// Secret is the metadata of a secret; values cannot be read back.
+type Secret struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Visibility is set for organization secrets: all, private or selected.
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// PublicKey is the key secret values are encrypted with before upload.
+type PublicKey struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"`
+}
+
+// Seal encrypts value for the key as a libsodium sealed box, as the API
+// expects, and returns it base64-encoded.
+func (k *PublicKey) Seal(value []byte) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(k.Key)
+	if err != nil || len(raw) != 32 {
+		return "", fmt.Errorf("invalid public key %q", k.Key)
+	}
+	var pub [32]byte
+	copy(pub[:], raw)
+	sealed, err := box.SealAnonymous(nil, value, &pub, rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// PublicKey returns the key to encrypt secrets of the scope with.
+func (c *Client) PublicKey(ctx context.Context, s Scope) (*PublicKey, error) {
+	var k PublicKey
+	if err := c.Do(ctx, http.MethodGet, s.path("secrets", "public-key"), nil, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// ListSecrets returns the secrets of the scope.
+func (c *Client) ListSecrets(ctx context.Context, s Scope) ([]*Secret, error) {
+	return collect(list[Secret](ctx, c, s.path("secrets"), nil, "secrets"))
+}
+
+// SecretOptions controls who can use an organization secret or variable.
+// It is ignored for repositories.
+type SecretOptions struct {
+	// Visibility is all, private or selected. Defaults to private.
+	Visibility string
+	// Repositories are the IDs of the repositories that can use it when
+	// Visibility is selected.
+	Repositories []int64
+}
+
+// RepositoryID returns the ID of a repository, as SecretOptions.Repositories
+// lists them.
+func (c *Client) RepositoryID(ctx context.Context, owner, repo string) (int64, error) {
+	var r struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.Do(ctx, http.MethodGet, repoPath(owner, repo), nil, &r); err != nil {
+		return 0, err
+	}
+	return r.ID, nil
+}
+
+func (o SecretOptions) apply(s Scope, body map[string]any) {
+	if s.Repo != "" {
+		return
+	}
+	body["visibility"] = o.Visibility
+	if o.Visibility == "" {
+		body["visibility"] = "private"
+	}
+	if o.Visibility == "selected" {
+		body["selected_repository_ids"] = o.Repositories
+	}
+}
+
+// SetSecret creates or updates a secret, encrypting the value with the
+// scope's public key.
+func (c *Client) SetSecret(ctx context.Context, s Scope, name string, value []byte, opts SecretOptions) error {
+	key, err := c.PublicKey(ctx, s)
+	if err != nil {
+		return fmt.Errorf("failed to get public key: %w", err)
+	}
+	sealed, err := key.Seal(value)
+	if err != nil {
+		return err
+	}
+	body := map[string]any{"encrypted_value": sealed, "key_id": key.KeyID}
+	opts.apply(s, body)
+	return c.Do(ctx, http.MethodPut, s.path("secrets", name), body, nil)
+}
+
+// DeleteSecret deletes a secret.
+func (c *Client) DeleteSecret(ctx context.Context, s Scope, name string) error {
+	return c.Do(ctx, http.MethodDelete, s.path("secrets", name), nil, nil)
+}
+
+// Variable is a configuration variable.
+type Variable struct {
+	Name       string    `json:"name"`
+	Value      string    `json:"value"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Visibility string    `json:"visibility,omitempty"`
+}
+
+// ListVariables returns the variables of the scope.
+func (c *Client) ListVariables(ctx context.Context, s Scope) ([]*Variable, error) {
+	return collect(list[Variable](ctx, c, s.path("variables"), nil, "variables"))
+}
+
+// ListRepoOrganizationVariables returns the variables of the repository's
+// organization that the repository can use.
+func (c *Client) ListRepoOrganizationVariables(ctx context.Context, owner, repo string) ([]*Variable, error) {
+	return collect(list[Variable](ctx, c, repoPath(owner, repo, "actions", "organization-variables"), nil, "variables"))
+}
+
+// GetVariable returns a variable.
+func (c *Client) GetVariable(ctx context.Context, s Scope, name string) (*Variable, error) {
+	var v Variable
+	if err := c.Do(ctx, http.MethodGet, s.path("variables", name), nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// SetVariable creates a variable or updates it if it exists.
+func (c *Client) SetVariable(ctx context.Context, s Scope, name, value string, opts SecretOptions) error {
+	body := map[string]any{"name": name, "value": value}
+	opts.apply(s, body)
+	err := c.Do(ctx, http.MethodPatch, s.path("variables", name), body, nil)
+	if IsNotFound(err) {
+		err = c.Do(ctx, http.MethodPost, s.path("variables"), body, nil)
+	}
+	return err
+}
+
+// DeleteVariable deletes a variable.
+func (c *Client) DeleteVariable(ctx context.Context, s Scope, name string) error {
+	return c.Do(ctx, http.MethodDelete, s.path("variables", name), nil, nil)
+}
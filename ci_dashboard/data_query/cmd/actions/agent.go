@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"testingdashboard/m/v2/agent"
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/runner"
+)
+
+func init() {
+	register("agent", "Take jobs from GitHub as a self-hosted runner and run them locally", agentCommand)
+}
+
+func agentCommand(args []string) int {
+	fs := flag.NewFlagSet("agent", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions agent [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Runs as the runner of a just-in-time configuration, of the directory\n")
+		fmt.Fprintf(fs.Output(), "config.sh configured, or of one -register registers. The first SIGTERM\n")
+		fmt.Fprintf(fs.Output(), "or interrupt drains the agent: it takes no more jobs and exits once the\n")
+		fmt.Fprintf(fs.Output(), "running one completes. A second cancels the running job.\n\n")
+		fs.PrintDefaults()
+	}
+	jitConfig := fs.String("jitconfig", os.Getenv("ACTIONS_RUNNER_INPUT_JITCONFIG"), "encoded just-in-time `config`uration (default $ACTIONS_RUNNER_INPUT_JITCONFIG)")
+	configDir := fs.String("config", "", "`directory` holding the .runner and .credentials files config.sh wrote")
+	doRegister := fs.Bool("register", false, "register an ephemeral just-in-time runner and run as it")
+	name := fs.String("name", "", "`name` of the runner -register registers (default the host name)")
+	var labels listFlag
+	fs.Var(&labels, "label", "`label` of the runner -register registers (repeatable)")
+	group := fs.Int64("group", 0, "runner group `id` of the organization runner -register registers (default the default group)")
+	work := fs.String("work", "", "`directory` jobs run in (default the runner's work folder)")
+	version := fs.String("runner-version", agent.DefaultVersion, "runner `version` to report to GitHub")
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` to register with (default $GITHUB_REPOSITORY or the origin remote)")
+	org := fs.String("org", "", "`organization` to register with instead of a repository")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || (*doRegister && len(labels) == 0) {
+		fs.Usage()
+		return 2
+	}
+
+	var cfg *agent.Config
+	var err error
+	switch {
+	case *doRegister:
+		scope := client.Org(*org)
+		if *org == "" {
+			owner, repo, err := currentRepository(*workspace, *repoFlag)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			scope = client.Repo(owner, repo)
+		}
+		runnerName := *name
+		if runnerName == "" {
+			runnerName, _ = os.Hostname()
+		}
+		jit, err := newClient(*workspace, *apiURL, *token).GenerateJITConfig(context.Background(), scope, client.JITConfigOptions{Name: runnerName, Labels: labels, GroupID: *group})
+		if err != nil {
+			return fatalf("failed to register runner %s: %v", runnerName, err)
+		}
+		log.Printf("registered runner %s with %s", runnerName, scope)
+		cfg, err = agent.ParseJITConfig(jit.Encoded)
+	case *configDir != "":
+		cfg, err = agent.LoadConfig(*configDir)
+	case *jitConfig != "":
+		cfg, err = agent.ParseJITConfig(*jitConfig)
+	default:
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *work != "" {
+		cfg.WorkFolder = *work
+	}
+
+	a := &agent.Agent{Config: cfg, Version: *version, Logf: log.Printf, Options: runner.Options{Stdout: os.Stdout}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		log.Printf("draining; signal again to cancel the running job")
+		a.Drain()
+		<-signals
+		cancel()
+	}()
+	if err := a.Run(ctx); err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
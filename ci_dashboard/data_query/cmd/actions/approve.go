@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/client"
+)
+
+func init() {
+	register("approve-deployment", "Approve or reject the deployments a run is waiting on", approveCommand)
+}
+
+func approveCommand(args []string) int {
+	fs := flag.NewFlagSet("approve-deployment", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions approve-deployment [flags] <run-id>\n\n")
+		fmt.Fprintf(fs.Output(), "Without -env, reviews every environment the run waits on that you can approve.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` of the run (default $GITHUB_REPOSITORY or the origin remote)")
+	var envs listFlag
+	fs.Var(&envs, "env", "`environment` to review (repeatable)")
+	reject := fs.Bool("reject", false, "reject the deployments instead of approving them")
+	comment := fs.String("comment", "", "`comment` recorded with the review")
+	listOnly := fs.Bool("list", false, "only list the pending deployments")
+	asJSON := fs.Bool("json", false, "print the pending deployments as JSON (implies -list)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(fs.Arg(0), "#"), 10, 64)
+	if err != nil {
+		return fatalf("invalid run id %q", fs.Arg(0))
+	}
+	owner, repo, err := currentRepository(*workspace, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	ctx := context.Background()
+	c := newClient(*workspace, *apiURL, *token)
+	pending, err := c.PendingDeployments(ctx, owner, repo, id)
+	if err != nil {
+		return fatalf("failed to list pending deployments: %v", err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(pending); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	if len(pending) == 0 {
+		return fatalf("run %d is not waiting for any deployment", id)
+	}
+	printPendingDeployments(pending)
+	if *listOnly {
+		return 0
+	}
+
+	for _, name := range envs {
+		if !slices.ContainsFunc(pending, func(p *client.PendingDeployment) bool { return p.Environment.Name == name }) {
+			return fatalf("run %d is not waiting for environment %q", id, name)
+		}
+	}
+	var ids []int64
+	var names []string
+	for _, p := range pending {
+		if len(envs) > 0 && !slices.Contains(envs, p.Environment.Name) {
+			continue
+		}
+		if !p.CurrentUserCanApprove {
+			if len(envs) > 0 {
+				return fatalf("you are not a required reviewer of environment %q", p.Environment.Name)
+			}
+			continue
+		}
+		ids = append(ids, p.Environment.ID)
+		names = append(names, p.Environment.Name)
+	}
+	if len(ids) == 0 {
+		return fatalf("you are not a required reviewer of any environment run %d is waiting on", id)
+	}
+	if err := c.ReviewDeployments(ctx, owner, repo, id, ids, !*reject, *comment); err != nil {
+		return fatalf("failed to review deployments: %v", err)
+	}
+	verb := "Approved"
+	if *reject {
+		verb = "Rejected"
+	}
+	fmt.Printf("%s deployment of run %d to %s\n", verb, id, strings.Join(names, ", "))
+	return 0
+}
+
+func printPendingDeployments(pending []*client.PendingDeployment) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENVIRONMENT\tWAIT TIMER\tREVIEWERS\tCAN APPROVE")
+	for _, p := range pending {
+		var reviewers []string
+		for _, r := range p.Reviewers {
+			name := r.Reviewer.Login
+			if r.Type == "Team" {
+				name = r.Reviewer.Slug
+			}
+			reviewers = append(reviewers, name)
+		}
+		wait := "-"
+		if p.WaitTimer > 0 {
+			wait = fmt.Sprintf("%dm", p.WaitTimer)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\n", p.Environment.Name, wait, strings.Join(reviewers, ","), p.CurrentUserCanApprove)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"testingdashboard/m/v2/githubapp"
+)
+
+func init() {
+	register("app-token", "Mint a GitHub App installation token", appTokenCommand)
+}
+
+func appTokenCommand(args []string) int {
+	fs := flag.NewFlagSet("app-token", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions app-token [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Prints an installation token of the app for the repository, or for -owner.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	appID := fs.String("app-id", os.Getenv("GITHUB_APP_ID"), "app ID or client `id` (default $GITHUB_APP_ID)")
+	keyFile := fs.String("key", os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"), "private key `file` of the app (default $GITHUB_APP_PRIVATE_KEY_PATH, or the key in $GITHUB_APP_PRIVATE_KEY)")
+	installation := fs.Int64("installation", 0, "installation `id` (default the installation covering the repository or -owner)")
+	repoFlag := fs.String("repo", "", "`owner/repo` whose installation is used (default $GITHUB_REPOSITORY or the origin remote)")
+	owner := fs.String("owner", "", "use the installation on organization or user `login` instead of a repository's")
+	repos := fs.String("repos", "", "comma-separated `names` of repositories of the installation's account to limit the token to")
+	perms := keyValueFlag{}
+	fs.Var(perms, "p", "limit the token to `permission=level`, such as contents=read (repeatable)")
+	asJSON := fs.Bool("json", false, "print the token with its expiry and permissions as JSON")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var app *githubapp.App
+	switch {
+	case *appID == "":
+		return fatalf("-app-id or $GITHUB_APP_ID is required")
+	case *keyFile != "":
+		var err error
+		if app, err = githubapp.Load(*appID, *keyFile); err != nil {
+			return fatalf("%v", err)
+		}
+	case os.Getenv("GITHUB_APP_PRIVATE_KEY") != "":
+		key, err := githubapp.ParsePrivateKey([]byte(os.Getenv("GITHUB_APP_PRIVATE_KEY")))
+		if err != nil {
+			return fatalf("GITHUB_APP_PRIVATE_KEY: %v", err)
+		}
+		app = &githubapp.App{ID: *appID, Key: key}
+	default:
+		return fatalf("-key, $GITHUB_APP_PRIVATE_KEY_PATH or $GITHUB_APP_PRIVATE_KEY is required")
+	}
+	app.APIURL = *apiURL
+	if app.APIURL == "" {
+		app.APIURL = instance(*workspace).API
+	}
+
+	src := &githubapp.TokenSource{App: app, InstallationID: *installation, Owner: *owner}
+	if *installation == 0 && *owner == "" {
+		var err error
+		if src.Owner, src.Repo, err = currentRepository(*workspace, *repoFlag); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	if *repos != "" {
+		for _, name := range strings.Split(*repos, ",") {
+			src.Options.Repositories = append(src.Options.Repositories, strings.TrimSpace(name))
+		}
+	}
+	if len(perms) > 0 {
+		src.Options.Permissions = perms
+	}
+	tok, err := src.Token(context.Background())
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *asJSON {
+		if err := printJSON(tok); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	fmt.Println(tok.Token)
+	return 0
+}
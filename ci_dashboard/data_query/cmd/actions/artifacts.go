@@ -0,0 +1,349 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"testingdashboard/m/v2/artifact"
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/runner"
+)
+
+func init() {
+	register("artifacts", "List, download and clean up the artifacts of a repository or an organization", artifactsCommand)
+}
+
+// repoArtifact is an artifact and the repository it belongs to.
+type repoArtifact struct {
+	Repo string `json:"repository"`
+	*client.Artifact
+}
+
+// artifactFilter selects artifacts. Zero fields match everything.
+type artifactFilter struct {
+	// name is a path.Match pattern.
+	name      string
+	run       int64
+	olderThan time.Duration
+	expired   bool
+}
+
+func (f artifactFilter) match(a *client.Artifact) bool {
+	if a.Expired && !f.expired {
+		return false
+	}
+	if f.name != "" {
+		if ok, _ := path.Match(f.name, a.Name); !ok {
+			return false
+		}
+	}
+	if f.run != 0 && a.WorkflowRun.ID != f.run {
+		return false
+	}
+	return f.olderThan == 0 || time.Since(a.CreatedAt) >= f.olderThan
+}
+
+func artifactsCommand(args []string) int {
+	subs := []string{"list", "download", "delete", "retention"}
+	if len(args) == 0 || !slices.Contains(subs, args[0]) {
+		fmt.Fprintf(os.Stderr, "Usage: actions artifacts <list|download|delete|retention> [flags]\n")
+		return 2
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("artifacts "+sub, flag.ContinueOnError)
+	fs.Usage = func() {
+		switch sub {
+		case "list":
+			fmt.Fprintf(fs.Output(), "Usage: actions artifacts list [flags]\n\n")
+			fmt.Fprintf(fs.Output(), "Lists the artifacts of the repository, or of every repository of -org,\n")
+			fmt.Fprintf(fs.Output(), "newest first, with their size and expiry.\n\n")
+		case "download":
+			fmt.Fprintf(fs.Output(), "Usage: actions artifacts download [flags] <pattern>\n\n")
+			fmt.Fprintf(fs.Output(), "Downloads the newest artifact of each name matching the pattern, or\n")
+			fmt.Fprintf(fs.Output(), "those of -run. A single artifact is extracted into -dir, several into\n")
+			fmt.Fprintf(fs.Output(), "a directory of -dir named after each.\n\n")
+		case "delete":
+			fmt.Fprintf(fs.Output(), "Usage: actions artifacts delete [flags]\n\n")
+			fmt.Fprintf(fs.Output(), "Deletes the artifacts matching -name, -older-than and -run, of the\n")
+			fmt.Fprintf(fs.Output(), "repository or of every repository of -org, to free storage.\n\n")
+		case "retention":
+			fmt.Fprintf(fs.Output(), "Usage: actions artifacts retention [flags] [days]\n\n")
+			fmt.Fprintf(fs.Output(), "Shows, or sets to days, how long the artifacts and logs of the\n")
+			fmt.Fprintf(fs.Output(), "repository or of -org are kept. A new retention applies to artifacts\n")
+			fmt.Fprintf(fs.Output(), "uploaded afterwards.\n\n")
+		}
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	var org, dir *string
+	var asJSON, expired, dryRun *bool
+	filter := artifactFilter{}
+	if sub != "retention" {
+		fs.Int64Var(&filter.run, "run", 0, "only artifacts of the run with this `id`")
+	} else {
+		org = fs.String("org", "", "the retention of this `organization` instead of the repository")
+	}
+	switch sub {
+	case "list", "delete":
+		org = fs.String("org", "", "the artifacts of every repository of this `organization`")
+		fs.StringVar(&filter.name, "name", "", "only artifacts whose name matches this `pattern`")
+		fs.DurationVar(&filter.olderThan, "older-than", 0, "only artifacts created at least this `duration` ago")
+	}
+	switch sub {
+	case "list":
+		asJSON = fs.Bool("json", false, "print the artifacts as JSON")
+		expired = fs.Bool("expired", false, "include expired artifacts")
+	case "download":
+		dir = fs.String("dir", ".", "`directory` to extract into")
+	case "delete":
+		dryRun = fs.Bool("dry-run", false, "print what would be deleted without deleting it")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	switch {
+	case sub == "download" && fs.NArg() != 1,
+		sub == "retention" && fs.NArg() > 1,
+		(sub == "list" || sub == "delete") && fs.NArg() != 0,
+		sub == "delete" && filter.name == "" && filter.olderThan == 0 && filter.run == 0:
+		fs.Usage()
+		return 2
+	}
+	if expired != nil {
+		filter.expired = *expired
+	}
+	ctx := context.Background()
+	c := newClient(*workspace, *apiURL, *token)
+
+	if sub == "retention" {
+		return artifactRetention(ctx, c, *workspace, *repoFlag, *org, fs.Arg(0))
+	}
+	if sub == "download" {
+		filter.name = fs.Arg(0)
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		return downloadArtifacts(ctx, c, owner, repo, filter, *dir)
+	}
+
+	var repos []string
+	if *org != "" {
+		for r, err := range c.ListOrgRepos(ctx, *org) {
+			if err != nil {
+				return fatalf("failed to list repositories of %s: %v", *org, err)
+			}
+			repos = append(repos, r.FullName)
+		}
+	} else {
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		repos = []string{owner + "/" + repo}
+	}
+	artifacts := []repoArtifact{}
+	var total int64
+	for _, full := range repos {
+		owner, repo, _ := strings.Cut(full, "/")
+		found, err := listArtifacts(ctx, c, owner, repo, filter)
+		if err != nil {
+			return fatalf("failed to list artifacts of %s: %v", full, err)
+		}
+		for _, a := range found {
+			artifacts = append(artifacts, repoArtifact{Repo: full, Artifact: a})
+			total += a.SizeInBytes
+		}
+	}
+
+	if sub == "delete" {
+		verb := "Deleted"
+		if *dryRun {
+			verb = "Would delete"
+		}
+		failed := false
+		var freed int64
+		for _, a := range artifacts {
+			if !*dryRun {
+				owner, repo, _ := strings.Cut(a.Repo, "/")
+				if err := c.DeleteArtifact(ctx, owner, repo, a.ID); err != nil {
+					fmt.Fprintf(os.Stderr, "actions: failed to delete artifact %s of %s: %v\n", a.Name, a.Repo, err)
+					failed = true
+					continue
+				}
+			}
+			freed += a.SizeInBytes
+			fmt.Printf("%s %s %s of run %d (%s)\n", verb, a.Repo, a.Name, a.WorkflowRun.ID, runner.FormatMemory(a.SizeInBytes))
+		}
+		fmt.Fprintf(os.Stderr, "%s %d artifacts, %s\n", verb, len(artifacts), runner.FormatMemory(freed))
+		if failed {
+			return 1
+		}
+		return 0
+	}
+
+	if *asJSON {
+		return encodeJSON(artifacts)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tNAME\tRUN\tSIZE\tCREATED\tEXPIRES")
+	for _, a := range artifacts {
+		expires := a.ExpiresAt.Local().Format(time.DateTime)
+		if a.Expired {
+			expires = "expired"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n", a.Repo, a.Name, a.WorkflowRun.ID, runner.FormatMemory(a.SizeInBytes), a.CreatedAt.Local().Format(time.DateTime), expires)
+	}
+	tw.Flush()
+	fmt.Fprintf(os.Stderr, "%d artifacts, %s\n", len(artifacts), runner.FormatMemory(total))
+	return 0
+}
+
+// listArtifacts returns the artifacts of a repository that match f. A
+// name without wildcards is left to the API to filter.
+func listArtifacts(ctx context.Context, c *client.Client, owner, repo string, f artifactFilter) ([]*client.Artifact, error) {
+	var name string
+	if !strings.ContainsAny(f.name, `*?[\`) {
+		name = f.name
+	}
+	var artifacts []*client.Artifact
+	if f.run != 0 {
+		all, err := c.ListRunArtifacts(ctx, owner, repo, f.run)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range all {
+			if f.match(a) {
+				artifacts = append(artifacts, a)
+			}
+		}
+		return artifacts, nil
+	}
+	for a, err := range c.ListArtifacts(ctx, owner, repo, name) {
+		if err != nil {
+			return nil, err
+		}
+		if f.match(a) {
+			artifacts = append(artifacts, a)
+		}
+	}
+	return artifacts, nil
+}
+
+// downloadArtifacts extracts the newest artifact of each name that f
+// matches.
+func downloadArtifacts(ctx context.Context, c *client.Client, owner, repo string, f artifactFilter, dir string) int {
+	found, err := listArtifacts(ctx, c, owner, repo, f)
+	if err != nil {
+		return fatalf("failed to list artifacts: %v", err)
+	}
+	var newest []*client.Artifact
+	seen := map[string]bool{}
+	for _, a := range found {
+		if !seen[a.Name] {
+			seen[a.Name] = true
+			newest = append(newest, a)
+		}
+	}
+	if len(newest) == 0 {
+		return fatalf("no artifact matches %q", f.name)
+	}
+	for _, a := range newest {
+		target := dir
+		if len(newest) > 1 {
+			target = filepath.Join(dir, a.Name)
+		}
+		if err := downloadArtifact(ctx, c, owner, repo, a, target); err != nil {
+			return fatalf("failed to download artifact %s: %v", a.Name, err)
+		}
+		fmt.Fprintf(os.Stderr, "Downloaded %s of run %d to %s\n", a.Name, a.WorkflowRun.ID, target)
+	}
+	return 0
+}
+
+// downloadArtifact extracts an artifact into dir, checking the zip against
+// the artifact's digest when it has one.
+func downloadArtifact(ctx context.Context, c *client.Client, owner, repo string, a *client.Artifact, dir string) error {
+	resp, err := c.DownloadArtifact(ctx, owner, repo, a.ID)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	f, err := os.CreateTemp("", "artifact-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), resp.Body)
+	if err != nil {
+		return err
+	}
+	if want, ok := strings.CutPrefix(a.Digest, "sha256:"); ok && want != hex.EncodeToString(h.Sum(nil)) {
+		return fmt.Errorf("the download is corrupt: sha256 is %x, want %s", h.Sum(nil), want)
+	}
+	return artifact.Extract(f, size, dir)
+}
+
+// artifactRetention prints the retention of the repository or org, or sets
+// it to days.
+func artifactRetention(ctx context.Context, c *client.Client, workspace, repoFlag, org, days string) int {
+	scope := client.Org(org)
+	name := org
+	if org == "" {
+		owner, repo, err := currentRepository(workspace, repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		scope, name = client.Repo(owner, repo), owner+"/"+repo
+	}
+	if days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return fatalf("invalid number of days %q", days)
+		}
+		if err := c.SetArtifactRetention(ctx, scope, n); err != nil {
+			return fatalf("failed to set the retention of %s: %v", name, err)
+		}
+	}
+	r, err := c.ArtifactRetention(ctx, scope)
+	if err != nil {
+		return fatalf("failed to get the retention of %s: %v", name, err)
+	}
+	fmt.Printf("Artifacts and logs of %s are kept %d days", name, r.Days)
+	if r.MaximumAllowedDays > 0 {
+		fmt.Printf(" (at most %d allowed)", r.MaximumAllowedDays)
+	}
+	fmt.Println()
+	return 0
+}
@@ -0,0 +1,206 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/inventory"
+)
+
+func init() {
+	register("audit", "Crawl an organization's workflows into an inventory of the actions, triggers, permissions and runners they use", auditCommand)
+}
+
+func auditCommand(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions audit [flags] -org <org>\n")
+		fmt.Fprintf(fs.Output(), "       actions audit [flags] -db <file>\n\n")
+		fmt.Fprintf(fs.Output(), "Downloads the workflows of every repository of the organization into the\n")
+		fmt.Fprintf(fs.Output(), "inventory -db and reports on it. Repositories not pushed to since the last\n")
+		fmt.Fprintf(fs.Output(), "crawl are taken from the existing inventory. Without -org the existing\n")
+		fmt.Fprintf(fs.Output(), "inventory is reported on. policy check and sbom read it with -inventory.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose origin remote picks the GitHub instance")
+	org := fs.String("org", "", "`organization` to crawl")
+	db := fs.String("db", "inventory.json", "inventory `file`")
+	full := fs.Bool("full", false, "crawl every repository again, ignoring the existing inventory")
+	repos := fs.String("repos", "", "comma-separated `patterns` of the repository names to crawl")
+	archived := fs.Bool("archived", false, "include archived repositories")
+	parallel := fs.Int("parallel", 4, "how `many` repositories to crawl at once")
+	noCache := fs.Bool("no-cache", false, "do not keep downloaded workflow files in the user cache directory")
+	report := fs.String("report", "actions", "what to report: actions, triggers, runners, permissions, errors or none")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	quiet := fs.Bool("q", false, "do not print progress")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var inv *inventory.Inventory
+	if *org == "" {
+		var err error
+		if inv, err = inventory.Load(*db); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		opts := inventory.CrawlOptions{Server: instance(*workspace).Server, Archived: *archived, Parallel: *parallel}
+		if *repos != "" {
+			opts.Repos = strings.Split(*repos, ",")
+		}
+		if !*full {
+			prev, err := inventory.Load(*db)
+			switch {
+			case err == nil && strings.EqualFold(prev.Org, *org):
+				opts.Previous = prev
+			case err != nil && !errors.Is(err, os.ErrNotExist):
+				return fatalf("%v", err)
+			}
+		}
+		if !*noCache {
+			dir, err := inventory.DefaultCacheDir()
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			opts.CacheDir = dir
+		}
+		done := 0
+		if !*quiet {
+			opts.Progress = func(r *inventory.Repository, cached bool) {
+				done++
+				status := fmt.Sprintf("%d workflows", len(r.Workflows))
+				if len(r.Workflows) == 1 {
+					status = "1 workflow"
+				}
+				switch {
+				case r.Error != "":
+					status = "error: " + r.Error
+				case cached:
+					status += ", unchanged"
+				}
+				fmt.Fprintf(os.Stderr, "[%d] %s: %s\n", done, r.Name, status)
+			}
+		}
+		c := newClient(*workspace, *apiURL, *token)
+		var err error
+		if inv, err = inventory.Crawl(context.Background(), c, *org, opts); err != nil {
+			return fatalf("%v", err)
+		}
+		if err := inv.Save(*db); err != nil {
+			return fatalf("failed to write the inventory: %v", err)
+		}
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "Wrote %s: %d repositories", *db, len(inv.Repos))
+			if rate := c.Rate(); rate.Limit > 0 {
+				fmt.Fprintf(os.Stderr, "; %d of %d API requests left", rate.Remaining, rate.Limit)
+			}
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+	return printInventoryReport(inv, *report, *asJSON)
+}
+
+func printInventoryReport(inv *inventory.Inventory, report string, asJSON bool) int {
+	var usages []*inventory.Usage
+	switch report {
+	case "none":
+		return 0
+	case "actions":
+		usages = inv.Actions()
+	case "triggers":
+		usages = inv.Triggers()
+	case "runners":
+		usages = inv.Runners()
+	case "permissions":
+		usages = inv.Permissions()
+	case "errors":
+		type repoError struct {
+			Repository string `json:"repository"`
+			Workflow   string `json:"workflow,omitempty"`
+			Error      string `json:"error"`
+		}
+		errs := []repoError{}
+		for _, r := range inv.Repos {
+			if r.Error != "" {
+				errs = append(errs, repoError{Repository: r.Name, Error: r.Error})
+			}
+			for _, w := range r.Workflows {
+				if w.Error != "" {
+					errs = append(errs, repoError{Repository: r.Name, Workflow: w.Path, Error: w.Error})
+				}
+			}
+		}
+		if asJSON {
+			return encodeJSON(errs)
+		}
+		for _, e := range errs {
+			if e.Workflow != "" {
+				fmt.Printf("%s/%s: %s\n", e.Repository, e.Workflow, e.Error)
+			} else {
+				fmt.Printf("%s: %s\n", e.Repository, e.Error)
+			}
+		}
+		return 0
+	default:
+		return fatalf("unknown report %q; want actions, triggers, runners, permissions, errors or none", report)
+	}
+	if asJSON {
+		return encodeJSON(usages)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if report != "actions" {
+		fmt.Fprintf(tw, "%s\tREPOSITORIES\tWORKFLOWS\n", strings.ToUpper(strings.TrimSuffix(report, "s")))
+		for _, u := range usages {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", u.Value, len(u.Repositories), u.Workflows)
+		}
+		tw.Flush()
+		return 0
+	}
+	fmt.Fprintln(tw, "ACTION\tREPOSITORIES\tWORKFLOWS\tVERSIONS")
+	for _, u := range usages {
+		var versions []string
+		for v, repos := range u.Versions {
+			versions = append(versions, fmt.Sprintf("%s (%d)", v, len(repos)))
+		}
+		sort.Strings(versions)
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", u.Value, len(u.Repositories), u.Workflows, strings.Join(versions, ", "))
+	}
+	tw.Flush()
+	return 0
+}
+
+func encodeJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
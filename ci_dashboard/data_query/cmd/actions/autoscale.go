@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"testingdashboard/m/v2/autoscale"
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/docker"
+	"testingdashboard/m/v2/events"
+	"testingdashboard/m/v2/metrics"
+)
+
+func init() {
+	register("autoscale", "Serve workflow_job webhooks and scale ephemeral runners in Docker", autoscaleCommand)
+}
+
+func autoscaleCommand(args []string) int {
+	fs := flag.NewFlagSet("autoscale", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions autoscale [flags] -config pools.yml\n\n")
+		fmt.Fprintf(fs.Output(), "Point a webhook for workflow_job events at the listen address.\n\n")
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", "", "pool configuration `file`")
+	listen := fs.String("listen", ":8080", "`address` to serve webhooks on")
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` to register runners with (default $GITHUB_REPOSITORY or the origin remote)")
+	org := fs.String("org", "", "`organization` to register runners with instead of a repository")
+	secret := fs.String("secret", os.Getenv("GITHUB_WEBHOOK_SECRET"), "webhook `secret` (default $GITHUB_WEBHOOK_SECRET)")
+	interval := fs.Duration("interval", 30*time.Second, "how often to stop idle runners and top pools up")
+	metricsAddr := fs.String("metrics", "", "serve Prometheus metrics on `address`/metrics; may be the listen address")
+	network := fs.String("network", "", "Docker network `mode` of the runner containers")
+	var binds listFlag
+	fs.Var(&binds, "bind", "`host:container` path to mount into every runner (repeatable)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *configPath == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := autoscale.LoadConfig(*configPath)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	scope := client.Org(*org)
+	if *org == "" {
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		scope = client.Repo(owner, repo)
+	}
+	dc, err := docker.NewClient()
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *secret == "" {
+		fmt.Fprintln(os.Stderr, "warning: no webhook secret; deliveries are not authenticated")
+	}
+
+	c := newClient(*workspace, *apiURL, *token)
+	reg := &metrics.Registry{}
+	c.Metrics = metrics.NewClient(reg)
+	s := &autoscale.Scaler{
+		Client:      c,
+		Scope:       scope,
+		Provisioner: &autoscale.DockerProvisioner{Client: dc, Network: *network, Binds: binds},
+		Config:      cfg,
+		Logf:        log.Printf,
+	}
+	d := &events.Dispatcher{}
+	s.Register(d)
+	mux := http.NewServeMux()
+	mux.Handle("/", &events.WebhookHandler{Dispatcher: d, Secret: *secret})
+	switch *metricsAddr {
+	case "":
+	case *listen:
+		mux.Handle("/metrics", reg)
+	default:
+		if err := serveMetrics(*metricsAddr, reg); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	srv := &http.Server{Addr: *listen, Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go s.Run(ctx, *interval)
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+	log.Printf("serving webhooks on %s", *listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fatalf("%v", err)
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"testingdashboard/m/v2/docker"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/runner"
+	"testingdashboard/m/v2/transport"
+)
+
+func init() {
+	register("cache", "List and prune the local cache of actions and their images, and the Actions cache of repositories", cacheCommand)
+}
+
+func cacheCommand(args []string) int {
+	if len(args) > 0 && (args[0] == "ls" || args[0] == "rm" || args[0] == "usage") {
+		return remoteCacheCommand(args[0], args[1:])
+	}
+	if len(args) == 0 || (args[0] != "list" && args[0] != "prune") {
+		fmt.Fprintf(os.Stderr, "Usage: actions cache <list|prune> [flags]\n")
+		fmt.Fprintf(os.Stderr, "       actions cache <ls|rm|usage> [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "list and prune manage the local cache of actions; ls, rm and usage the\n")
+		fmt.Fprintf(os.Stderr, "Actions cache of repositories on GitHub.\n")
+		return 2
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("cache "+sub, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions cache %s [flags]\n\n", sub)
+		if sub == "prune" {
+			fmt.Fprintf(fs.Output(), "Removes the actions and images not used within -max-age, then the least\n")
+			fmt.Fprintf(fs.Output(), "recently used ones until the rest fit in -max-size. Responses in the HTTP\n")
+			fmt.Fprintf(fs.Output(), "cache of $ACTIONS_HTTP_CACHE older than -max-age go too.\n\n")
+		}
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", "", "cache `directory` (default $ACTIONS_RUNNER_ACTION_CACHE or the user cache directory)")
+	asJSON := fs.Bool("json", false, "print the entries as JSON")
+	var maxAge *time.Duration
+	var maxSize *string
+	var all, dryRun *bool
+	if sub == "prune" {
+		maxAge = fs.Duration("max-age", resolve.DefaultMaxAge, "remove entries not used for this `duration`; 0 keeps them")
+		maxSize = fs.String("max-size", runner.FormatMemory(resolve.DefaultMaxSize), "`size` the cache is pruned to; empty for no limit")
+		all = fs.Bool("all", false, "remove every entry")
+		dryRun = fs.Bool("dry-run", false, "print what would be removed without removing it")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	store := &resolve.Store{Dir: *dir}
+	if store.Dir == "" {
+		var err error
+		if store, err = resolve.DefaultStore(); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+
+	if sub == "list" {
+		entries, err := store.Entries()
+		if err != nil {
+			return fatalf("failed to list the cache: %v", err)
+		}
+		return printCacheEntries(entries, *asJSON)
+	}
+
+	opts := resolve.PruneOptions{DryRun: *dryRun}
+	if !*all {
+		opts.MaxAge = *maxAge
+		if *maxSize != "" {
+			size, err := runner.ParseMemory(*maxSize)
+			if err != nil {
+				return fatalf("invalid -max-size: %v", err)
+			}
+			opts.MaxSize = size
+		}
+		if opts.MaxAge <= 0 && opts.MaxSize <= 0 {
+			return fatalf("-max-age 0 and no -max-size would remove everything; pass -all")
+		}
+	}
+	var client *docker.Client
+	opts.RemoveImage = func(ctx context.Context, ref string) error {
+		if client == nil {
+			c, err := docker.NewClient()
+			if err != nil {
+				return err
+			}
+			client = c
+		}
+		if err := client.ImageRemove(ctx, ref, false); err != nil && !docker.IsNotFound(err) {
+			return fmt.Errorf("failed to remove image %s: %w", ref, err)
+		}
+		return nil
+	}
+	removed, pruneErr := store.Prune(context.Background(), opts)
+	// -max-size bounds only the store; the HTTP cache holds small files.
+	responses := 0
+	if hc, err := transport.DiskCacheFromEnv(); err == nil && hc != nil && !*dryRun && (*all || opts.MaxAge > 0) {
+		responses, err = hc.Prune(opts.MaxAge)
+		pruneErr = errors.Join(pruneErr, err)
+	}
+	code := printCacheEntries(removed, *asJSON)
+	if !*asJSON {
+		var size int64
+		for _, e := range removed {
+			size += e.Size
+		}
+		verb := "Removed"
+		if *dryRun {
+			verb = "Would remove"
+		}
+		fmt.Fprintf(os.Stderr, "%s %d entries, %s\n", verb, len(removed), runner.FormatMemory(size))
+		if responses > 0 {
+			fmt.Fprintf(os.Stderr, "Removed %d cached HTTP responses\n", responses)
+		}
+	}
+	if pruneErr != nil {
+		return fatalf("%v", pruneErr)
+	}
+	return code
+}
+
+func printCacheEntries(entries []resolve.Entry, asJSON bool) int {
+	if asJSON {
+		if entries == nil {
+			entries = []resolve.Entry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	if len(entries) == 0 {
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAME\tSIZE\tLAST USED")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Kind, e.Name, runner.FormatMemory(e.Size), e.Used.Format(time.DateTime))
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/runner"
+)
+
+// cacheSorts maps the -sort values of cache ls to the API's.
+var cacheSorts = map[string]string{"last-used": "last_accessed_at", "size": "size_in_bytes", "created": "created_at"}
+
+// remoteCacheCommand implements cache ls, rm and usage, which manage the
+// Actions cache through the API.
+func remoteCacheCommand(sub string, args []string) int {
+	fs := flag.NewFlagSet("cache "+sub, flag.ContinueOnError)
+	fs.Usage = func() {
+		switch sub {
+		case "ls":
+			fmt.Fprintf(fs.Output(), "Usage: actions cache ls [flags]\n\n")
+			fmt.Fprintf(fs.Output(), "Lists the Actions cache entries of the repository and how much of its\n")
+			fmt.Fprintf(fs.Output(), "cache they use.\n\n")
+		case "rm":
+			fmt.Fprintf(fs.Output(), "Usage: actions cache rm [flags] [id ...]\n\n")
+			fmt.Fprintf(fs.Output(), "Deletes the Actions cache entries with the IDs, -key or -prefix, of\n")
+			fmt.Fprintf(fs.Output(), "-ref if given.\n\n")
+		case "usage":
+			fmt.Fprintf(fs.Output(), "Usage: actions cache usage [flags]\n\n")
+			fmt.Fprintf(fs.Output(), "Reports how much of its Actions cache the repository, or each repository\n")
+			fmt.Fprintf(fs.Output(), "of -org, uses. Past the limit GitHub evicts the least recently used\n")
+			fmt.Fprintf(fs.Output(), "entries, so a repository near it restores few of its caches.\n\n")
+		}
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	var key, prefix, ref, sortFlag, org, limitFlag *string
+	var dryRun *bool
+	if sub != "rm" {
+		limitFlag = fs.String("limit", runner.FormatMemory(client.CacheLimit), "`size` of a repository's cache")
+	}
+	switch sub {
+	case "ls":
+		prefix = fs.String("prefix", "", "only entries whose key starts with this `prefix`")
+		ref = fs.String("ref", "", "only entries saved by this `ref`, such as refs/heads/main or refs/pull/1/merge")
+		sortFlag = fs.String("sort", "last-used", "order of the entries, newest or largest first: last-used, size or created")
+	case "rm":
+		key = fs.String("key", "", "delete the entries with exactly this `key`")
+		prefix = fs.String("prefix", "", "delete the entries whose key starts with this `prefix`")
+		ref = fs.String("ref", "", "only delete entries saved by this `ref`")
+		dryRun = fs.Bool("dry-run", false, "print what would be deleted without deleting it")
+	case "usage":
+		org = fs.String("org", "", "report every repository of this `organization`")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	switch {
+	case sub != "rm" && fs.NArg() != 0,
+		sub == "rm" && fs.NArg() == 0 && *key == "" && *prefix == "",
+		sub == "rm" && *key != "" && *prefix != "",
+		sub == "ls" && cacheSorts[*sortFlag] == "":
+		fs.Usage()
+		return 2
+	}
+	var limit int64
+	if limitFlag != nil {
+		var err error
+		if limit, err = runner.ParseMemory(*limitFlag); err != nil || limit <= 0 {
+			return fatalf("invalid -limit %q", *limitFlag)
+		}
+	}
+	ctx := context.Background()
+	c := newClient(*workspace, *apiURL, *token)
+
+	if sub == "usage" && *org != "" {
+		var usage []*client.CacheUsage
+		for u, err := range c.ListOrgCacheUsage(ctx, *org) {
+			if err != nil {
+				return fatalf("failed to get the cache usage of %s: %v", *org, err)
+			}
+			usage = append(usage, u)
+		}
+		sort.SliceStable(usage, func(i, j int) bool { return usage[i].SizeInBytes > usage[j].SizeInBytes })
+		return printCacheUsage(usage, limit, *asJSON)
+	}
+	owner, repo, err := currentRepository(*workspace, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	switch sub {
+	case "usage":
+		u, err := c.GetCacheUsage(ctx, owner, repo)
+		if err != nil {
+			return fatalf("failed to get the cache usage of %s/%s: %v", owner, repo, err)
+		}
+		return printCacheUsage([]*client.CacheUsage{u}, limit, *asJSON)
+	case "rm":
+		return removeCaches(ctx, c, owner, repo, fs.Args(), *key, *prefix, *ref, *dryRun, *asJSON)
+	}
+
+	caches, err := listCaches(ctx, c, owner, repo, client.CachesOptions{Key: *prefix, Ref: *ref, Sort: cacheSorts[*sortFlag], Direction: "desc"}, *prefix)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *asJSON {
+		return encodeJSON(caches)
+	}
+	var total int64
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tKEY\tREF\tSIZE\tLAST USED\tCREATED")
+	for _, e := range caches {
+		total += e.SizeInBytes
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.Key, e.Ref, runner.FormatMemory(e.SizeInBytes), e.LastAccessedAt.Local().Format(time.DateTime), e.CreatedAt.Local().Format(time.DateTime))
+	}
+	tw.Flush()
+	fmt.Fprintf(os.Stderr, "%d entries, %s, %s of the %s limit\n", len(caches), runner.FormatMemory(total), usedPercent(total, limit), runner.FormatMemory(limit))
+	return 0
+}
+
+// listCaches returns the entries matching opts whose key starts with
+// prefix. The API matches keys by prefix too, but also exactly.
+func listCaches(ctx context.Context, c *client.Client, owner, repo string, opts client.CachesOptions, prefix string) ([]*client.Cache, error) {
+	caches := []*client.Cache{}
+	for e, err := range c.ListCaches(ctx, owner, repo, opts) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the caches of %s/%s: %w", owner, repo, err)
+		}
+		if strings.HasPrefix(e.Key, prefix) {
+			caches = append(caches, e)
+		}
+	}
+	return caches, nil
+}
+
+func removeCaches(ctx context.Context, c *client.Client, owner, repo string, ids []string, key, prefix, ref string, dryRun, asJSON bool) int {
+	var targets []*client.Cache
+	for _, arg := range ids {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fatalf("invalid cache id %q", arg)
+		}
+		targets = append(targets, &client.Cache{ID: id})
+	}
+	if key != "" || prefix != "" {
+		found, err := listCaches(ctx, c, owner, repo, client.CachesOptions{Key: key + prefix, Ref: ref}, prefix)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		for _, e := range found {
+			if key == "" || e.Key == key {
+				targets = append(targets, e)
+			}
+		}
+	}
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	deleted := []*client.Cache{}
+	failed := false
+	var freed int64
+	for _, e := range targets {
+		if !dryRun {
+			if err := c.DeleteCache(ctx, owner, repo, e.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "actions: failed to delete cache %d: %v\n", e.ID, err)
+				failed = true
+				continue
+			}
+		}
+		deleted = append(deleted, e)
+		freed += e.SizeInBytes
+		if !asJSON {
+			if e.Key == "" {
+				fmt.Printf("%s cache %d\n", verb, e.ID)
+			} else {
+				fmt.Printf("%s %s of %s (%s)\n", verb, e.Key, e.Ref, runner.FormatMemory(e.SizeInBytes))
+			}
+		}
+	}
+	if asJSON {
+		if code := encodeJSON(deleted); code != 0 {
+			return code
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "%s %d entries, %s\n", verb, len(deleted), runner.FormatMemory(freed))
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func printCacheUsage(usage []*client.CacheUsage, limit int64, asJSON bool) int {
+	if asJSON {
+		if usage == nil {
+			usage = []*client.CacheUsage{}
+		}
+		return encodeJSON(usage)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPOSITORY\tENTRIES\tSIZE\tUSED")
+	for _, u := range usage {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", u.FullName, u.Count, runner.FormatMemory(u.SizeInBytes), usedPercent(u.SizeInBytes, limit))
+	}
+	tw.Flush()
+	return 0
+}
+
+// usedPercent is size as a share of limit.
+func usedPercent(size, limit int64) string {
+	return fmt.Sprintf("%.0f%%", 100*float64(size)/float64(limit))
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/changes"
+	"testingdashboard/m/v2/events"
+)
+
+func init() {
+	register("changes", "List the files the triggering event changed", changesCommand)
+}
+
+// changeFlags are the flags of commands that look at changed files.
+type changeFlags struct {
+	eventName, eventFile *string
+	base, head           *string
+	pr                   *int
+	useAPI, fetch        *bool
+	recurse              *bool
+	repo, apiURL, token  *string
+	files                listFlag
+}
+
+func addChangeFlags(fs *flag.FlagSet) *changeFlags {
+	f := &changeFlags{
+		eventName: fs.String("e", "", "`name` of the event (default $GITHUB_EVENT_NAME)"),
+		eventFile: fs.String("event-file", "", "JSON `file` with the event payload (default $GITHUB_EVENT_PATH)"),
+		base:      fs.String("base", "", "compare from the merge base with this `rev` instead of the event's range"),
+		head:      fs.String("head", "HEAD", "compare -base up to this `rev`"),
+		pr:        fs.Int("pr", 0, "use the files pull request `number` changes, from the API"),
+		useAPI:    fs.Bool("api", false, "ask the GitHub API instead of git"),
+		fetch:     fs.Bool("fetch", os.Getenv("GITHUB_ACTIONS") == "true", "fetch missing commits and deepen shallow clones"),
+		recurse:   fs.Bool("recurse-submodules", false, "also list the files changed inside checked-out submodules"),
+		repo:      fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)"),
+		apiURL:    fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)"),
+		token:     fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)"),
+	}
+	fs.Var(&f.files, "file", "changed `path` (repeatable)")
+	return f
+}
+
+// changed returns the files changed in the repository at dir.
+func (f *changeFlags) changed(ctx context.Context, dir string) ([]changes.File, error) {
+	if len(f.files) > 0 {
+		var files []changes.File
+		for _, p := range f.files {
+			files = append(files, changes.File{Path: p, Status: changes.Modified})
+		}
+		return files, nil
+	}
+	eventName, eventFile := *f.eventName, *f.eventFile
+	if eventName == "" {
+		eventName = os.Getenv("GITHUB_EVENT_NAME")
+	}
+	if eventFile == "" {
+		eventFile = os.Getenv("GITHUB_EVENT_PATH")
+	}
+	var r changes.Range
+	switch {
+	case *f.pr != 0:
+		r = changes.Range{PullRequest: *f.pr}
+		*f.useAPI = true
+	case *f.base != "":
+		r = changes.Range{Base: *f.base, Head: *f.head, MergeBase: true}
+	case eventFile != "":
+		data, err := os.ReadFile(eventFile)
+		if err != nil {
+			return nil, err
+		}
+		ev, err := events.ParseEvent(eventName, data)
+		if err != nil {
+			return nil, err
+		}
+		if r, err = changes.ForEvent(ev); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("no event to compute changes for; pass -event-file, -base, -pr or -file")
+	}
+	if !*f.useAPI {
+		return changes.FromGit(ctx, dir, r, changes.GitOptions{Fetch: *f.fetch, RecurseSubmodules: *f.recurse})
+	}
+	owner, repo, err := currentRepository(dir, *f.repo)
+	if err != nil {
+		return nil, err
+	}
+	files, err := changes.FromAPI(ctx, newClient(dir, *f.apiURL, *f.token), owner, repo, r)
+	if err != nil {
+		return nil, err
+	}
+	submodules, err := changes.Submodules(dir)
+	if err != nil {
+		return nil, err
+	}
+	changes.MarkSubmodules(files, submodules)
+	return files, nil
+}
+
+func changesCommand(args []string) int {
+	fs := flag.NewFlagSet("changes", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions changes [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Pushes are compared with the commit before them and pull requests with\n")
+		fmt.Fprintf(fs.Output(), "their merge base, as GitHub does for paths filters.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	cf := addChangeFlags(fs)
+	asJSON := fs.Bool("json", false, "print the files as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	files, err := cf.changed(context.Background(), *workspace)
+	if errors.Is(err, changes.ErrNoRange) {
+		files, err = []changes.File{}, nil
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(files); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, f := range files {
+		path := f.Path
+		if f.PreviousPath != "" {
+			path = f.PreviousPath + " -> " + f.Path
+		}
+		if f.Submodule {
+			path += " (submodule)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", f.Status, path)
+	}
+	tw.Flush()
+	return 0
+}
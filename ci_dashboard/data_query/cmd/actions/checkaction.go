@@ -0,0 +1,63 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"testingdashboard/m/v2/metadata"
+)
+
+func init() {
+	register("check-action", "Validate action.yml files before publishing", checkActionCommand)
+}
+
+func checkActionCommand(args []string) int {
+	fs := flag.NewFlagSet("check-action", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions check-action [dir|action.yml ...]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	status := 0
+	for _, path := range paths {
+		var action *metadata.Action
+		var err error
+		if info, statErr := os.Stat(path); statErr == nil && info.IsDir() {
+			action, err = metadata.Load(path)
+		} else {
+			action, err = metadata.ParseFile(path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			status = 1
+			continue
+		}
+		for _, p := range action.Validate() {
+			fmt.Printf("%s: %s\n", action.Path, p)
+			status = 1
+		}
+	}
+	return status
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"testingdashboard/m/v2/compliance"
+	"testingdashboard/m/v2/provenance"
+	"testingdashboard/m/v2/sign"
+)
+
+func init() {
+	register("compliance", "Verify and show the audit logs and bundles of local runs", complianceCommand)
+}
+
+func complianceCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "verify" && args[0] != "show") {
+		fmt.Fprintln(os.Stderr, "Usage: actions compliance <verify|show> [flags] <bundle.json|log.jsonl>")
+		return 2
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("compliance "+sub, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions compliance %s [flags] <bundle.json|log.jsonl>\n\n", sub)
+		switch sub {
+		case "verify":
+			fmt.Fprintf(fs.Output(), "Checks that the audit log written by actions run -audit-log is unbroken, or\n")
+			fmt.Fprintf(fs.Output(), "that the bundle written by -audit-bundle is and was signed by -key or, for\n")
+			fmt.Fprintf(fs.Output(), "keyless bundles, by a Fulcio certificate matching the identity flags.\n\n")
+		case "show":
+			fmt.Fprintf(fs.Output(), "Lists the steps of an audit log or bundle without checking it.\n\n")
+		}
+		fs.PrintDefaults()
+	}
+	var key, rootsFile, fulcioURL *string
+	var identity sign.IdentityPolicy
+	if sub == "verify" {
+		key = fs.String("key", "", "PEM public key, certificate or private key `file` the bundle must be signed by")
+		rootsFile = fs.String("roots", "", "PEM `file` of the Fulcio certificates keyless signatures must chain to (default fetched from -fulcio-url)")
+		fulcioURL = fs.String("fulcio-url", sign.DefaultFulcioURL, "Fulcio `url` to fetch the trust roots from")
+		fs.StringVar(&identity.Repository, "repository", "", "`owner/repo` the signing run must be for")
+		fs.StringVar(&identity.Workflow, "signer-workflow", "", "`owner/repo/path` of the workflow the signing job must be defined in")
+		fs.StringVar(&identity.Ref, "ref", "", "`ref` the signing run must be for")
+	}
+	asJSON := fs.Bool("json", false, "print the entries as JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	var b provenance.Bundle
+	isBundle := json.Unmarshal(data, &b) == nil && b.DSSEEnvelope != nil
+	var entries []compliance.Entry
+	if isBundle {
+		r, err := compliance.Open(&b)
+		if err != nil && sub == "verify" {
+			return fatalf("%v", err)
+		}
+		if r != nil {
+			entries = r.Entries
+		}
+	} else {
+		if entries, err = compliance.Read(bytes.NewReader(data)); err != nil {
+			return fatalf("%v", err)
+		}
+		if sub == "verify" {
+			if *key != "" {
+				fmt.Fprintln(os.Stderr, "actions: -key needs a bundle; an audit log is not signed")
+				return 2
+			}
+			if err := compliance.Verify(entries); err != nil {
+				return fatalf("%v", err)
+			}
+		}
+	}
+
+	signer := ""
+	if sub == "verify" && isBundle {
+		switch m := b.VerificationMaterial; {
+		case *key != "":
+			pub, err := compliance.LoadPublicKey(*key)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			if err := compliance.VerifyKey(&b, pub); err != nil {
+				return fatalf("%v", err)
+			}
+			if signer, err = compliance.KeyHint(pub); err != nil {
+				return fatalf("%v", err)
+			}
+		case m != nil && m.Certificate != nil:
+			var roots *sign.Roots
+			if *rootsFile != "" {
+				roots, err = sign.LoadRoots(*rootsFile)
+			} else {
+				roots, err = sign.FetchRoots(context.Background(), *fulcioURL, nil)
+			}
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			id, err := sign.Verify(&b, sign.VerifyOptions{Roots: roots, Identity: identity})
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			signer = id.SubjectAlternativeName
+		default:
+			fmt.Fprintln(os.Stderr, "actions: the bundle is signed with a key; pass -key")
+			return 2
+		}
+	}
+
+	if *asJSON {
+		return encodeJSON(entries)
+	}
+	if sub == "verify" {
+		head := ""
+		if n := len(entries); n > 0 {
+			head = entries[n-1].Hash
+		}
+		fmt.Printf("Verified %d steps, head %s\n", len(entries), head)
+		if signer != "" {
+			fmt.Printf("Signed by %s\n", signer)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEQ\tJOB\tSTEP\tUSES\tSHA\tEXIT\tCONCLUSION\tDURATION")
+	for _, e := range entries {
+		job := e.Job
+		if e.Leg != "" {
+			job = e.Leg
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", e.Seq, job, e.Step, orDash(e.Uses), orDash(e.SHA[:min(7, len(e.SHA))]), e.ExitCode, e.Conclusion, time.Duration(e.DurationMS)*time.Millisecond)
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/concurrency"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("concurrency", "Report which runs a sequence of events would cancel", concurrencyCommand)
+}
+
+func concurrencyCommand(args []string) int {
+	fs := flag.NewFlagSet("concurrency", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions concurrency [flags] -events events.yml [workflow.yml ...]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are checked when no files are given")
+	eventsFile := fs.String("events", "", "YAML `file` listing the events in the order they arrive")
+	repoFlag := fs.String("repo", "", "`owner/repo` of github.repository (default from the origin remote)")
+	asJSON := fs.Bool("json", false, "print the outcomes as JSON")
+	vars := keyValueFlag{}
+	fs.Var(vars, "var", "`KEY=VALUE` of the vars context (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *eventsFile == "" {
+		fs.Usage()
+		return 2
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	var wfs []*workflow.Workflow
+	for _, path := range paths {
+		wf, err := workflow.ParseFile(path)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		wfs = append(wfs, wf)
+	}
+	evs, err := concurrency.LoadEvents(*eventsFile)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	opts := concurrency.SimulateOptions{Vars: vars}
+	if owner, repo, err := currentRepository(*workspace, *repoFlag); err == nil {
+		opts.Repository = owner + "/" + repo
+	}
+	outcomes, err := concurrency.Simulate(wfs, evs, opts)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(outcomes); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "EVENT\tWORKFLOW\tJOB\tGROUP\tSTATE\tCANCELLED BY\tREASON")
+	for _, o := range outcomes {
+		job, by := o.Job, ""
+		if job == "" {
+			job = "-"
+		}
+		if o.CancelledBy != 0 {
+			by = fmt.Sprint(o.CancelledBy)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", o.Event, o.Workflow, job, o.Group, o.State, by, o.Reason)
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"testingdashboard/m/v2/contracts"
+	"testingdashboard/m/v2/inventory"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("contracts", "Check the callers of reusable workflows against the inputs and secrets the workflows declare", contractsCommand)
+}
+
+func contractsCommand(args []string) int {
+	fs := flag.NewFlagSet("contracts", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions contracts [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Checks each job that calls a reusable workflow against the workflow_call\n")
+		fmt.Fprintf(fs.Output(), "trigger of the called workflow: unknown and missing required inputs and\n")
+		fmt.Fprintf(fs.Output(), "secrets, inputs of the wrong type, and secrets: inherit to another\n")
+		fmt.Fprintf(fs.Output(), "organization. The workflows are those of the repository, or of every\n")
+		fmt.Fprintf(fs.Output(), "repository of an inventory written by actions audit. Calls of workflows\n")
+		fmt.Fprintf(fs.Output(), "outside them are checked only with -fetch. Exits 1 on any violation.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` of the repository (default $GITHUB_REPOSITORY or the origin remote)")
+	inventoryFile := fs.String("inventory", "", "check the workflows of an inventory `file` instead of the repository")
+	fetch := fs.Bool("fetch", false, "fetch called workflows outside the checked repositories")
+	fetcherMode := fs.String("fetcher", "", fetcherUsage)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var workflows []*contracts.Workflow
+	if *inventoryFile != "" {
+		inv, err := inventory.Load(*inventoryFile)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		for _, r := range inv.Repos {
+			for _, w := range r.Workflows {
+				wf, err := workflow.Parse([]byte(w.Content))
+				if err != nil {
+					continue
+				}
+				wf.Path = r.Name + "/" + w.Path
+				workflows = append(workflows, &contracts.Workflow{Repo: r.Name, Path: w.Path, Workflow: wf})
+			}
+		}
+	} else {
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		files, err := workflowFiles(*workspace)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		for _, file := range files {
+			wf, err := workflow.ParseFile(file)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			rel, err := filepath.Rel(*workspace, file)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			workflows = append(workflows, &contracts.Workflow{Repo: owner + "/" + repo, Path: filepath.ToSlash(rel), Workflow: wf})
+		}
+	}
+
+	var fetcher resolve.Fetcher
+	if *fetch {
+		f, err := metadataFetcher(*workspace, *fetcherMode, "", "")
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		fetcher = f
+	}
+	report := contracts.Check(context.Background(), workflows, fetcher)
+	if *asJSON {
+		if code := encodeJSON(report); code != 0 {
+			return code
+		}
+	} else {
+		for _, v := range report.Violations {
+			if *inventoryFile != "" {
+				fmt.Printf("%s/", v.Repo)
+			}
+			fmt.Println(v)
+		}
+		fmt.Fprintf(os.Stderr, "Checked %d calls: %d violations\n", report.Calls, len(report.Violations))
+		if len(report.External) > 0 {
+			fmt.Fprintf(os.Stderr, "Not checked, pass -fetch to check them:\n")
+			for _, ref := range report.External {
+				fmt.Fprintf(os.Stderr, "  %s\n", ref)
+			}
+		}
+	}
+	if len(report.Violations) > 0 {
+		return 1
+	}
+	return 0
+}
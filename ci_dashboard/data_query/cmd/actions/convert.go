@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"testingdashboard/m/v2/convert"
+)
+
+func init() {
+	register("convert", "Translate GitLab CI, CircleCI or Travis CI configuration into workflows", convertCommand)
+}
+
+func convertCommand(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions convert [flags] <config.yml>\n\n")
+		fmt.Fprintf(fs.Output(), "Constructs that cannot be converted are listed on standard error and\n")
+		fmt.Fprintf(fs.Output(), "left as TODO comments in the workflows.\n\n")
+		fs.PrintDefaults()
+	}
+	from := fs.String("from", "", "`format` of the configuration: gitlab, circleci or travis (default from the file name)")
+	out := fs.String("o", "", "`directory` to write the workflows to, such as .github/workflows (default standard output)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+	format := *from
+	if format == "" {
+		var err error
+		if format, err = convert.Detect(path); err != nil {
+			return fatalf("%v; use -from", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	res, err := convert.Convert(format, data)
+	if err != nil {
+		return fatalf("%s: %v", path, err)
+	}
+
+	if *out != "" {
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	for i, f := range res.Files {
+		data, err := f.Workflow.YAML()
+		if err != nil {
+			return fatalf("%s: %v", f.Name, err)
+		}
+		if *out == "" {
+			if i > 0 {
+				fmt.Println("---")
+			}
+			os.Stdout.Write(data)
+			continue
+		}
+		dest := filepath.Join(*out, f.Name)
+		if err := os.WriteFile(dest, data, 0o644); err != nil {
+			return fatalf("%v", err)
+		}
+		fmt.Println(dest)
+	}
+	for _, n := range res.Notes {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, n)
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/analysis"
+)
+
+func init() {
+	register("cost", "Report runner minutes and cost by workflow, job, matrix leg or runner", costCommand)
+}
+
+type costReport struct {
+	Since     string                         `json:"since"`
+	Until     string                         `json:"until"`
+	Rates     analysis.Rates                 `json:"rates"`
+	Total     analysis.CostLine              `json:"total"`
+	Breakdown map[string][]analysis.CostLine `json:"breakdown"`
+}
+
+func costCommand(args []string) int {
+	fs := flag.NewFlagSet("cost", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions cost [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Rates are per minute, keyed by runner label or by linux, windows, macos or\nself-hosted, and default to the list prices of standard hosted runners.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	wf := fs.String("workflow", "", "only count runs of this workflow `file` name or ID")
+	since := fs.String("since", time.Now().AddDate(0, 0, -30).Format(time.DateOnly), "first `date` of runs to count")
+	until := fs.String("until", "", "last `date` of runs to count (default today)")
+	by := fs.String("by", "workflow", "comma-separated `dimensions` to break the cost down by: "+strings.Join(analysis.Dimensions, ", "))
+	ratesFile := fs.String("rates", "", "YAML or JSON `file` mapping labels or operating systems to per-minute rates")
+	rateFlags := keyValueFlag{}
+	fs.Var(rateFlags, "rate", "per-minute rate `KEY=PRICE` (repeatable)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	opts := analysis.UsageOptions{Workflow: *wf, Rates: maps.Clone(analysis.DefaultRates)}
+	var err error
+	if opts.Since, err = time.Parse(time.DateOnly, *since); err != nil {
+		return fatalf("invalid -since date %q", *since)
+	}
+	if *until != "" {
+		if opts.Until, err = time.Parse(time.DateOnly, *until); err != nil {
+			return fatalf("invalid -until date %q", *until)
+		}
+	}
+	if *ratesFile != "" {
+		data, err := os.ReadFile(*ratesFile)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		var rates analysis.Rates
+		if err := yaml.Unmarshal(data, &rates); err != nil {
+			return fatalf("failed to parse %s: %v", *ratesFile, err)
+		}
+		maps.Copy(opts.Rates, rates)
+	}
+	for k, v := range rateFlags {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fatalf("invalid rate %s=%s", k, v)
+		}
+		opts.Rates[k] = rate
+	}
+	dims := strings.Split(*by, ",")
+
+	owner, repo, err := currentRepository(*workspace, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	usage, err := analysis.CollectUsage(context.Background(), newClient(*workspace, *apiURL, *token), owner, repo, opts)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	report := costReport{Since: *since, Until: *until, Rates: opts.Rates, Total: analysis.CostLine{Key: "total"}, Breakdown: map[string][]analysis.CostLine{}}
+	for _, u := range usage {
+		report.Total.Jobs++
+		report.Total.Minutes += u.Minutes
+		report.Total.BillableMinutes += u.BillableMinutes
+		report.Total.Cost += u.Cost
+	}
+	for _, dim := range dims {
+		lines, err := analysis.Breakdown(usage, strings.TrimSpace(dim))
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		report.Breakdown[strings.TrimSpace(dim)] = lines
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for i, dim := range dims {
+		dim = strings.TrimSpace(dim)
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "%s\tJOBS\tMINUTES\tBILLABLE\tCOST\n", strings.ToUpper(dim))
+		for _, l := range report.Breakdown[dim] {
+			printCostLine(tw, l)
+		}
+	}
+	fmt.Fprintln(tw)
+	printCostLine(tw, report.Total)
+	tw.Flush()
+	return 0
+}
+
+func printCostLine(tw *tabwriter.Writer, l analysis.CostLine) {
+	fmt.Fprintf(tw, "%s\t%d\t%.1f\t%d\t%.2f\n", l.Key, l.Jobs, l.Minutes, l.BillableMinutes, l.Cost)
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/coverage"
+	"testingdashboard/m/v2/summary"
+)
+
+func init() {
+	register("coverage", "Compare a coverage report with its baseline and gate the change", coverageCommand)
+}
+
+func coverageCommand(args []string) int {
+	fs := flag.NewFlagSet("coverage", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions coverage [flags] <report>\n\n")
+		fmt.Fprintf(fs.Output(), "The report is a go test -coverprofile, lcov or Cobertura file. The baseline\n")
+		fmt.Fprintf(fs.Output(), "is another report, or the artifact -baseline-artifact of the newest\n")
+		fmt.Fprintf(fs.Output(), "successful run of the workflow on the target branch. The exit status is 1\n")
+		fmt.Fprintf(fs.Output(), "if the change misses -min, -max-drop or -min-file.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` report paths are relative to")
+	baselineFile := fs.String("baseline", "", "baseline report `file`")
+	artifact := fs.String("baseline-artifact", "", "`name` of the artifact holding the baseline report")
+	artifactFile := fs.String("baseline-path", "", "`name` of the baseline report in the artifact (default the first report)")
+	workflowFlag := fs.String("workflow", "", "`workflow` that uploads the baseline (default the running workflow)")
+	branch := fs.String("branch", "", "target `branch` of the baseline (default $GITHUB_BASE_REF or main)")
+	gate := coverage.Gate{}
+	fs.Float64Var(&gate.Min, "min", 0, "lowest overall coverage allowed, in `percent`")
+	fs.Float64Var(&gate.MaxDrop, "max-drop", 0, "most overall coverage may fall below the baseline, in percentage `points`")
+	fs.Float64Var(&gate.MinFile, "min-file", 0, "lowest coverage allowed for added files and files whose coverage fell, in `percent`")
+	title := fs.String("title", "Coverage", "`title` of the summary")
+	asJSON := fs.Bool("json", false, "print the comparison as JSON")
+	writeSummary := fs.Bool("summary", false, "append the comparison to $GITHUB_STEP_SUMMARY")
+	check := fs.String("check", "", "publish a check run `name`d so with the comparison")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	sha := fs.String("sha", "", "`commit` of the check run (default $GITHUB_SHA or HEAD)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || (*baselineFile != "" && *artifact != "") {
+		fs.Usage()
+		return 2
+	}
+	head, err := coverage.ParseFile(fs.Arg(0), *workspace)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	var base *coverage.Profile
+	switch {
+	case *baselineFile != "":
+		if base, err = coverage.ParseFile(*baselineFile, *workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	case *artifact != "":
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		opts := coverage.BaselineOptions{Workflow: *workflowFlag, Branch: *branch, Artifact: *artifact, File: *artifactFile}
+		if opts.Workflow == "" {
+			// GITHUB_WORKFLOW_REF is owner/repo/.github/workflows/ci.yml@ref.
+			ref, _, _ := strings.Cut(os.Getenv("GITHUB_WORKFLOW_REF"), "@")
+			if opts.Workflow = path.Base(ref); ref == "" {
+				return fatalf("cannot determine the workflow of the baseline; pass -workflow")
+			}
+		}
+		if opts.Branch == "" {
+			opts.Branch = os.Getenv("GITHUB_BASE_REF")
+		}
+		if opts.Branch == "" {
+			opts.Branch = "main"
+		}
+		p, run, err := coverage.Baseline(context.Background(), newClient(*workspace, *apiURL, *token), owner, repo, *workspace, opts)
+		if err != nil {
+			// A first run on a branch has nothing to compare with.
+			fmt.Fprintf(os.Stderr, "actions: no baseline: %v\n", err)
+		} else {
+			base = p
+			fmt.Fprintf(os.Stderr, "Baseline from run %d on %s (%s)\n", run.ID, opts.Branch, run.HeadSHA[:min(7, len(run.HeadSHA))])
+		}
+	}
+
+	diff := coverage.Compare(base, head)
+	passed := gate.Check(diff)
+	if *writeSummary {
+		if err := diff.Summary(*title).Write(summary.WriteOptions{}); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	if *check != "" {
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		commit := *sha
+		if commit == "" {
+			commit = os.Getenv("GITHUB_SHA")
+		}
+		if commit == "" {
+			head, err := gitLines(*workspace, "rev-parse", "HEAD")
+			if err != nil || len(head) == 0 {
+				return fatalf("cannot determine the commit; pass -sha")
+			}
+			commit = head[0]
+		}
+		run, err := diff.Publish(context.Background(), newClient(*workspace, *apiURL, *token), owner, repo, commit, *check)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Published check run %s\n", run.HTMLURL)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		fmt.Printf("Coverage %.2f%% (%d of %d)", diff.Head.Percent(), diff.Head.Covered, diff.Head.Total)
+		if diff.Base != nil {
+			fmt.Printf(", %+.2f points from %.2f%%", diff.Delta(), diff.Base.Percent())
+		}
+		fmt.Println()
+		if len(diff.Files) > 0 {
+			fmt.Println()
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "FILE\tBASE\tHEAD\tCHANGE")
+			for _, f := range diff.Files {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Path, percentOf(f.Base), percentOf(f.Head), f.Change())
+			}
+			tw.Flush()
+		}
+	}
+	for _, v := range diff.Violations {
+		fmt.Fprintf(os.Stderr, "actions: %s\n", v)
+	}
+	if !passed {
+		return 1
+	}
+	return 0
+}
+
+func percentOf(t *coverage.Totals) string {
+	if t == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", t.Percent())
+}
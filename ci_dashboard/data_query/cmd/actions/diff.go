@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/diff"
+	"testingdashboard/m/v2/summary"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("diff", "Summarize what changed between two versions of a workflow", diffCommand)
+}
+
+func diffCommand(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions diff [flags] <old.yml> <new.yml>\n\n")
+		fmt.Fprintf(fs.Output(), "Either version may be a file, rev:path to read it from git, such as\n")
+		fmt.Fprintf(fs.Output(), "origin/main:.github/workflows/ci.yml, or /dev/null for a workflow that\n")
+		fmt.Fprintf(fs.Output(), "was added or removed.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` rev:path versions are read from")
+	asJSON := fs.Bool("json", false, "print the changes as JSON")
+	markdown := fs.Bool("markdown", false, "print the changes as a job summary or PR comment")
+	writeSummary := fs.Bool("summary", false, "append the changes to $GITHUB_STEP_SUMMARY")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	old, err := loadVersion(*workspace, fs.Arg(0))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	new, err := loadVersion(*workspace, fs.Arg(1))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	changes := diff.Compare(old, new)
+	if changes == nil {
+		changes = []diff.Change{}
+	}
+
+	title := "Changes to " + fs.Arg(1)
+	if new == nil {
+		title = "Changes to " + fs.Arg(0)
+	}
+	if *writeSummary {
+		if err := diff.Summary(title, changes).Write(summary.WriteOptions{}); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	switch {
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			return fatalf("%v", err)
+		}
+	case *markdown:
+		fmt.Print(diff.Summary(title, changes).String())
+	default:
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, c := range changes {
+			fmt.Fprintf(tw, "%s\t%s\n", c.Kind, c.Message)
+		}
+		tw.Flush()
+	}
+	return 0
+}
+
+// loadVersion parses a version of a workflow named as on the command line,
+// returning nil for /dev/null.
+func loadVersion(dir, arg string) (*workflow.Workflow, error) {
+	if arg == os.DevNull {
+		return nil, nil
+	}
+	if _, err := os.Stat(arg); err == nil || !strings.Contains(arg, ":") {
+		return workflow.ParseFile(arg)
+	}
+	data, err := exec.Command("git", "-C", dir, "show", arg).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git show %s: %s", arg, strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, err
+	}
+	wf, err := workflow.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", arg, err)
+	}
+	wf.Path = arg
+	return wf, nil
+}
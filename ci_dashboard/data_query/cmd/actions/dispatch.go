@@ -0,0 +1,191 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("dispatch", "Trigger a workflow_dispatch event after validating its inputs", dispatchCommand)
+}
+
+func dispatchCommand(args []string) int {
+	fs := flag.NewFlagSet("dispatch", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions dispatch [flags] <workflow>\n\n")
+		fmt.Fprintf(fs.Output(), "The workflow is a path or the file name of a workflow in .github/workflows.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` to dispatch in (default $GITHUB_REPOSITORY or the origin remote)")
+	ref := fs.String("ref", "", "branch or tag `ref` to run the workflow on (default the current branch)")
+	dryRun := fs.Bool("n", false, "validate the inputs and print them without dispatching")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	inputs := keyValueFlag{}
+	fs.Var(inputs, "input", "workflow input `KEY=VALUE` (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	path, err := findWorkflow(*workspace, fs.Arg(0))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	wf, err := workflow.ParseFile(path)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	ev := wf.On.Event("workflow_dispatch")
+	if ev == nil {
+		return fatalf("%s is not triggered by workflow_dispatch", path)
+	}
+	resolved, err := workflow.ValidateInputs(ev.Inputs, inputs)
+	if err != nil {
+		return fatalf("%s: invalid inputs:\n%v", path, err)
+	}
+	// Only send what was given so the server applies the defaults itself,
+	// but send it converted so nothing is left for it to coerce.
+	send := map[string]any{}
+	for name := range inputs {
+		for declared, in := range ev.Inputs {
+			if !strings.EqualFold(declared, name) {
+				continue
+			}
+			send[declared] = resolved[declared]
+			if in.DeprecationMessage != "" {
+				fmt.Fprintf(os.Stderr, "warning: input %s is deprecated: %s\n", declared, in.DeprecationMessage)
+			}
+		}
+	}
+
+	if *ref == "" {
+		branch, err := gitLines(*workspace, "symbolic-ref", "-q", "--short", "HEAD")
+		if err != nil || len(branch) == 0 {
+			return fatalf("cannot determine the current branch; pass -ref")
+		}
+		*ref = branch[0]
+	}
+
+	if *dryRun {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "INPUT\tVALUE\tSOURCE")
+		names := make([]string, 0, len(resolved))
+		for name := range resolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			source := "default"
+			if _, ok := send[name]; ok {
+				source = "flag"
+			}
+			fmt.Fprintf(tw, "%s\t%v\t%s\n", name, resolved[name], source)
+		}
+		tw.Flush()
+		return 0
+	}
+
+	owner, repo, err := currentRepository(*workspace, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	c := newClient(*workspace, *apiURL, *token)
+	id := filepath.Base(path)
+	if err := c.DispatchWorkflow(context.Background(), owner, repo, id, *ref, send); err != nil {
+		return fatalf("failed to dispatch %s: %v", id, err)
+	}
+	fmt.Printf("Dispatched %s on %s in %s/%s\n", id, *ref, owner, repo)
+	return 0
+}
+
+// findWorkflow resolves a workflow argument, which is either a path or the
+// name of a file in dir/.github/workflows, with or without its extension.
+func findWorkflow(dir, name string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	base := filepath.Join(dir, ".github", "workflows", name)
+	for _, candidate := range []string{base, base + ".yml", base + ".yaml"} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("workflow %s not found", name)
+}
+
+// currentRepository returns the repository commands act on: flag if set,
+// else $GITHUB_REPOSITORY, else the GitHub repository of the origin remote.
+func currentRepository(dir, flag string) (owner, repo string, err error) {
+	full := flag
+	if full == "" {
+		full = os.Getenv("GITHUB_REPOSITORY")
+	}
+	if full == "" {
+		if remote, err := gitLines(dir, "remote", "get-url", "origin"); err == nil && len(remote) > 0 {
+			_, full = endpoints.ParseRemote(remote[0])
+		}
+	}
+	if full == "" {
+		return "", "", fmt.Errorf("cannot determine the repository; pass -repo owner/repo")
+	}
+	owner, repo, ok := strings.Cut(full, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", fmt.Errorf("invalid repository %q; want owner/repo", full)
+	}
+	return owner, repo, nil
+}
+
+// instance returns the GitHub instance commands talk to: the one named by
+// GITHUB_SERVER_URL or GITHUB_API_URL, else the one hosting the origin
+// remote of dir, else github.com.
+func instance(dir string) endpoints.Endpoints {
+	if os.Getenv("GITHUB_SERVER_URL") != "" || os.Getenv("GITHUB_API_URL") != "" {
+		return endpoints.FromEnv()
+	}
+	var server string
+	if remote, err := gitLines(dir, "remote", "get-url", "origin"); err == nil && len(remote) > 0 {
+		server, _ = endpoints.ParseRemote(remote[0])
+	}
+	return endpoints.ForServer(server)
+}
+
+// newClient returns an API client for the -api-url and -token flags,
+// falling back to the environment and the instance of dir.
+func newClient(dir, apiURL, token string) *client.Client {
+	if apiURL == "" {
+		apiURL = instance(dir).API
+	}
+	if token != "" {
+		return client.New(apiURL, token)
+	}
+	return client.NewFromEnvAt(apiURL)
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"testingdashboard/m/v2/export"
+)
+
+func init() {
+	register("export", "Export run and job timing to CSV, SQLite or BigQuery", exportCommand)
+}
+
+func exportCommand(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions export [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Writes one record per job attempt of the completed runs created in the\n")
+		fmt.Fprintf(fs.Output(), "window, with its queue delay, duration, labels and matrix leg, then prints\n")
+		fmt.Fprintf(fs.Output(), "the queue delay per runner label to stderr. The format defaults to sqlite\n")
+		fmt.Fprintf(fs.Output(), "for -out files ending in .db, .sqlite or .sqlite3, and to csv otherwise.\n")
+		fmt.Fprintf(fs.Output(), "SQLite databases are written with the sqlite3 command.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	org := fs.String("org", "", "export every repository of the `organization` instead")
+	wf := fs.String("workflow", "", "only export runs of this workflow `file` name or ID")
+	since := fs.String("since", time.Now().AddDate(0, 0, -30).Format(time.DateOnly), "first `date` of runs to export")
+	until := fs.String("until", "", "last `date` of runs to export (default today)")
+	format := fs.String("format", "", "output `format`: csv, sqlite or bigquery")
+	out := fs.String("out", "-", "output `file`; - is stdout for csv")
+	table := fs.String("table", "", "SQLite `table` (default "+export.DefaultTable+"), or BigQuery project.dataset.table")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	if *format == "" {
+		*format = "csv"
+		switch filepath.Ext(*out) {
+		case ".db", ".sqlite", ".sqlite3":
+			*format = "sqlite"
+		}
+	}
+	switch {
+	case *format == "sqlite" && *out == "-":
+		return fatalf("-format sqlite needs an -out file")
+	case *format == "bigquery" && *table == "":
+		return fatalf("-format bigquery needs a -table")
+	case *format != "csv" && *format != "sqlite" && *format != "bigquery":
+		return fatalf("unknown format %q; want csv, sqlite or bigquery", *format)
+	}
+
+	opts := export.Options{Workflow: *wf}
+	var err error
+	if opts.Since, err = time.Parse(time.DateOnly, *since); err != nil {
+		return fatalf("invalid -since date %q", *since)
+	}
+	if *until != "" {
+		if opts.Until, err = time.Parse(time.DateOnly, *until); err != nil {
+			return fatalf("invalid -until date %q", *until)
+		}
+	}
+	ctx := context.Background()
+	c := newClient(*workspace, *apiURL, *token)
+	var records iter.Seq2[*export.Record, error]
+	if *org != "" {
+		records = export.CollectOrg(ctx, c, *org, opts)
+	} else {
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		records = export.Collect(ctx, c, owner, repo, opts)
+	}
+	var all []*export.Record
+	runs := map[int64]bool{}
+	for r, err := range records {
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		all = append(all, r)
+		runs[r.RunID] = true
+	}
+
+	dest := *out
+	switch *format {
+	case "csv":
+		w := os.Stdout
+		if *out != "-" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			defer f.Close()
+			w = f
+		} else {
+			dest = "stdout"
+		}
+		if err := export.WriteCSV(w, all); err != nil {
+			return fatalf("failed to write %s: %v", dest, err)
+		}
+	case "sqlite":
+		if err := (&export.SQLite{Path: *out, Table: *table}).Write(ctx, all); err != nil {
+			return fatalf("%v", err)
+		}
+	case "bigquery":
+		bq, err := export.ParseBigQueryTable(*table)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		if err := bq.Write(ctx, all); err != nil {
+			return fatalf("%v", err)
+		}
+		dest = bq.String()
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d jobs of %d runs to %s\n", len(all), len(runs), dest)
+	if stats := export.ByLabel(all); len(stats) > 0 {
+		tw := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "LABEL\tJOBS\tQUEUE P50\tQUEUE P90\tQUEUE P99\tQUEUE MAX\tMINUTES")
+		for _, s := range stats {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%.1f\n", s.Label, s.Jobs, seconds(s.QueueP50), seconds(s.QueueP90), seconds(s.QueueP99), seconds(s.QueueMax), s.Minutes)
+		}
+		tw.Flush()
+	}
+	return 0
+}
+
+func seconds(s float64) string {
+	return time.Duration(s * float64(time.Second)).Round(time.Second).String()
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/transport"
+)
+
+// metadataClient returns the client for looking up action metadata and
+// refs, which keeps responses in the cache of transport.DiskCacheFromEnv.
+func metadataClient() (*http.Client, error) {
+	cache, err := transport.DiskCacheFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return cache.Client(), nil
+}
+
+// fetcherUsage is the usage of the -fetcher flag of metadataFetcher.
+const fetcherUsage = "how to get actions and reusable workflows of other repositories, by `mode`: api downloads just their metadata through the HTTP cache, git clones them (default api with a token, else git)"
+
+// metadataFetcher returns the Fetcher of the -fetcher mode for code that
+// reads the metadata of actions but runs nothing.
+func metadataFetcher(dir, mode, apiURL, token string) (resolve.Fetcher, error) {
+	inst := instance(dir)
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if mode == "" {
+		if mode = "git"; token != "" {
+			mode = "api"
+		}
+	}
+	switch mode {
+	case "git":
+		return &resolve.GitFetcher{Workspace: dir, ServerURL: inst.Server}, nil
+	case "api":
+		hc, err := metadataClient()
+		if err != nil {
+			return nil, err
+		}
+		if apiURL == "" {
+			apiURL = inst.API
+		}
+		return &resolve.APIFetcher{Workspace: dir, APIURL: apiURL, Token: token, Client: hc}, nil
+	}
+	return nil, fmt.Errorf("invalid -fetcher %q; want api or git", mode)
+}
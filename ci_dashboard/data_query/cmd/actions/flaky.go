@@ -0,0 +1,96 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/analysis"
+	"testingdashboard/m/v2/summary"
+)
+
+func init() {
+	register("flaky", "Find flaky and failing steps in the recent runs of a workflow", flakyCommand)
+}
+
+func flakyCommand(args []string) int {
+	fs := flag.NewFlagSet("flaky", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions flaky [flags] <workflow>\n\n")
+		fmt.Fprintf(fs.Output(), "The workflow is a file name such as ci.yml or a workflow ID.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	n := fs.Int("n", 50, "number of recent runs to analyze")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	markdown := fs.Bool("markdown", false, "print the report as a job summary")
+	writeSummary := fs.Bool("summary", false, "append the report to $GITHUB_STEP_SUMMARY")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || *n <= 0 {
+		fs.Usage()
+		return 2
+	}
+	owner, repo, err := currentRepository(*workspace, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	wf := filepath.Base(fs.Arg(0))
+
+	outcomes, err := analysis.Collect(context.Background(), newClient(*workspace, *apiURL, *token), owner, repo, wf, *n)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	report := analysis.Analyze(outcomes)
+	title := "Step failures in " + wf
+	if *writeSummary {
+		if err := report.Summary(title).Write(summary.WriteOptions{}); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	switch {
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fatalf("%v", err)
+		}
+	case *markdown:
+		fmt.Print(report.Summary(title).String())
+	default:
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "CLASS\tJOB\tSTEP\tFAILURES\tFLAKY ON")
+		for _, s := range report.Steps {
+			short := make([]string, len(s.FlakyCommits))
+			for i, sha := range s.FlakyCommits {
+				short[i] = sha[:min(7, len(sha))]
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d/%d\t%s\n", s.Class, s.Job, s.Step, s.Failures, s.Runs, strings.Join(short, " "))
+		}
+		tw.Flush()
+	}
+	return 0
+}
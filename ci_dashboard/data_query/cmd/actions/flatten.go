@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("flatten", "List the steps of each job with composite actions inlined", flattenCommand)
+}
+
+func flattenCommand(args []string) int {
+	fs := flag.NewFlagSet("flatten", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions flatten [flags] [workflow.yml]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "workspace `directory` local actions are relative to")
+	asJSON := fs.Bool("json", false, "print the steps as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		var err error
+		if path, err = defaultWorkflowFile(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	wf, err := workflow.ParseFile(path)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	jobs, err := resolve.Workflow(context.Background(), &resolve.GitFetcher{Workspace: *workspace, ServerURL: instance(*workspace).Server}, wf)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	var rows []flatStep
+	for _, id := range wf.JobIDs() {
+		for _, s := range jobs[id] {
+			name := s.Step.Name
+			if name == "" {
+				name = s.Step.Uses
+			}
+			if name == "" {
+				name, _, _ = strings.Cut(strings.TrimSpace(s.Step.Run), "\n")
+			}
+			rows = append(rows, flatStep{Job: id, Name: name, Uses: s.Step.Uses, Using: s.Using, Via: s.Via})
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSTEP\tUSING\tVIA")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s%s\t%s\t%s\n", row.Job, strings.Repeat("  ", len(row.Via)), row.Name, row.Using, strings.Join(row.Via, " > "))
+	}
+	tw.Flush()
+	return 0
+}
+
+// flatStep is one line of flatten output.
+type flatStep struct {
+	Job   string   `json:"job"`
+	Name  string   `json:"name"`
+	Uses  string   `json:"uses,omitempty"`
+	Using string   `json:"using"`
+	Via   []string `json:"via,omitempty"`
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"testingdashboard/m/v2/format"
+)
+
+func init() {
+	register("fmt", "Rewrite workflow files in the canonical style", fmtCommand)
+}
+
+func fmtCommand(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions fmt [flags] [file.yml ...]\n\n")
+		fmt.Fprintf(fs.Output(), "A file of - is read from standard input and written to standard output.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are formatted when no files are given")
+	check := fs.Bool("check", false, "list files that are not formatted, without writing them, and exit 1 if there are any")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	unformatted := false
+	for _, path := range paths {
+		var data []byte
+		var err error
+		if path == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		out, err := format.Source(data)
+		if err != nil {
+			return fatalf("%s: %v", path, err)
+		}
+		switch {
+		case *check:
+			if !bytes.Equal(out, data) {
+				fmt.Println(path)
+				unformatted = true
+			}
+		case path == "-":
+			os.Stdout.Write(out)
+		case !bytes.Equal(out, data):
+			info, err := os.Stat(path)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+				return fatalf("%v", err)
+			}
+		}
+	}
+	if unformatted {
+		return 1
+	}
+	return 0
+}
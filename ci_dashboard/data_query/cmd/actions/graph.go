@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"testingdashboard/m/v2/graph"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("graph", "Render the job graph of a workflow as DOT or Mermaid", graphCommand)
+}
+
+func graphCommand(args []string) int {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions graph [flags] [workflow.yml]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "workspace `directory` local workflows are relative to")
+	format := fs.String("f", "dot", "output `format`: dot or mermaid")
+	legs := fs.Bool("legs", false, "draw one node per matrix combination")
+	expand := fs.Bool("expand", false, "expand reusable workflow calls into their jobs")
+	fails := fs.String("fails", "", "instead of a graph, list what still runs if `job` fails")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		var err error
+		if path, err = defaultWorkflowFile(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	wf, err := workflow.ParseFile(path)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	var g *graph.Graph
+	if *expand {
+		plan, err := resolve.Expand(context.Background(), &resolve.GitFetcher{Workspace: *workspace, ServerURL: instance(*workspace).Server}, wf)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		g, err = graph.FromPlan(plan)
+	} else {
+		g, err = graph.New(wf)
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	if *fails != "" {
+		impact, err := g.IfFails(*fails)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		fmt.Printf("skipped:     %s\n", strings.Join(impact.Skipped, ", "))
+		fmt.Printf("conditional: %s\n", strings.Join(impact.Conditional, ", "))
+		fmt.Printf("unaffected:  %s\n", strings.Join(impact.Unaffected, ", "))
+		return 0
+	}
+
+	title := wf.Name
+	if title == "" {
+		title = filepath.Base(path)
+	}
+	opts := graph.ExportOptions{Title: title, Legs: *legs}
+	switch *format {
+	case "dot":
+		err = graph.ExportDOT(os.Stdout, g, opts)
+	case "mermaid":
+		err = graph.ExportMermaid(os.Stdout, g, opts)
+	default:
+		return fatalf("unknown format %q, expected dot or mermaid", *format)
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
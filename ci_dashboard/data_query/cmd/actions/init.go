@@ -0,0 +1,185 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/scaffold"
+)
+
+func init() {
+	register("init", "Generate workflows for a repository from a template", initCommand)
+}
+
+func initCommand(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions init [flags] [template]\n\n")
+		fmt.Fprintf(fs.Output(), "Writes the files of a template into the repository, asking for the\n")
+		fmt.Fprintf(fs.Output(), "parameters not given with -set when run in a terminal. The templates are\n")
+		fmt.Fprintf(fs.Output(), "the built-in ones and those of each -templates directory, one YAML file\n")
+		fmt.Fprintf(fs.Output(), "per template, which replace built-in ones of the same name. -list shows\n")
+		fmt.Fprintf(fs.Output(), "them and their parameters.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` to write to")
+	var dirs, sets listFlag
+	fs.Var(&dirs, "templates", "`directory` of more templates, repeatable (default $ACTIONS_TEMPLATES)")
+	fs.Var(&sets, "set", "set a parameter, as `name=value`; repeatable")
+	list := fs.Bool("list", false, "list the templates")
+	force := fs.Bool("force", false, "overwrite existing files")
+	dryRun := fs.Bool("dry-run", false, "print the files instead of writing them")
+	noPrompt := fs.Bool("no-prompt", false, "do not ask for parameters; use their defaults")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	if len(dirs) == 0 && os.Getenv("ACTIONS_TEMPLATES") != "" {
+		dirs = strings.Split(os.Getenv("ACTIONS_TEMPLATES"), string(os.PathListSeparator))
+	}
+	registry := scaffold.Chain{scaffold.Builtin}
+	for _, dir := range dirs {
+		registry = append(registry, scaffold.Dir(dir))
+	}
+	templates, err := registry.Templates()
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *list {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "TEMPLATE\tPARAMETER\tDEFAULT\tDESCRIPTION")
+		for _, t := range templates {
+			fmt.Fprintf(tw, "%s\t\t\t%s\n", t.Name, t.Description)
+			for _, p := range t.Params {
+				def := p.Default
+				if p.Required() {
+					def = "(required)"
+				}
+				fmt.Fprintf(tw, "\t%s\t%s\t%s\n", p.Name, def, p.Description)
+			}
+		}
+		tw.Flush()
+		return 0
+	}
+
+	values := map[string]string{}
+	for _, s := range sets {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok || name == "" {
+			return fatalf("invalid -set %q; want name=value", s)
+		}
+		values[name] = value
+	}
+	interactive := !*noPrompt && isTerminal(os.Stdin)
+	in := bufio.NewReader(os.Stdin)
+	name := fs.Arg(0)
+	if name == "" {
+		if !interactive {
+			fs.Usage()
+			return 2
+		}
+		for _, t := range templates {
+			fmt.Fprintf(os.Stderr, "  %-10s %s\n", t.Name, t.Description)
+		}
+		if name, err = prompt(in, "Template", ""); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	t, err := scaffold.Lookup(registry, name)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if interactive {
+		for _, p := range t.Params {
+			if _, ok := values[p.Name]; ok {
+				continue
+			}
+			label := p.Description
+			if len(p.Options) > 0 {
+				label += " (" + strings.Join(p.Options, ", ") + ")"
+			}
+			for {
+				v, err := prompt(in, label, p.Default)
+				if err != nil {
+					return fatalf("%v", err)
+				}
+				if err := p.Check(v); err != nil || v == "" {
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "%v\n", err)
+					}
+					continue
+				}
+				values[p.Name] = v
+				break
+			}
+		}
+	}
+
+	files, err := t.Render(values)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *dryRun {
+		for _, f := range files {
+			fmt.Printf("# %s\n%s", f.Path, f.Content)
+		}
+		return 0
+	}
+	if err := scaffold.Write(*workspace, files, *force); err != nil {
+		return fatalf("%v; pass -force to overwrite", err)
+	}
+	for _, f := range files {
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", f.Path)
+	}
+	return 0
+}
+
+// prompt asks for a value on stderr, returning def for an empty answer or
+// the end of the input.
+func prompt(in *bufio.Reader, label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", label)
+	}
+	line, err := in.ReadString('\n')
+	if err == io.EOF && line == "" && def != "" {
+		fmt.Fprintln(os.Stderr)
+		return def, nil
+	}
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("failed to read %s: %w", label, err)
+	}
+	if line = strings.TrimSpace(line); line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
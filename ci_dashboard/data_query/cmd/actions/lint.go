@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/lint"
+	"testingdashboard/m/v2/rulesets"
+)
+
+func init() {
+	register("lint", "Check workflow files for mistakes", lintCommand)
+}
+
+func lintCommand(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions lint [flags] [workflow.yml ...]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are linted when no files are given")
+	asJSON := fs.Bool("json", false, "print findings as JSON")
+	list := fs.Bool("rules", false, "list the available rules and exit")
+	var disable, labels listFlag
+	fs.Var(&disable, "disable", "skip the named `rule` (repeatable)")
+	fs.Var(&labels, "label", "accept this self-hosted runner `label` (repeatable)")
+	runnersFile := fs.String("runners", "", "runner catalog `file` runs-on must resolve against (default "+defaultRunnersFile+" if it exists)")
+	var requiredChecks listFlag
+	fs.Var(&requiredChecks, "required-check", "`name` of a status check the merge queue requires (repeatable)")
+	queueBranch := fs.String("queue-branch", "", "`branch` of the merge queue (default the default branch with -rulesets, else main)")
+	fromRulesets := fs.Bool("rulesets", false, "read the merge queue and its required checks from the repository's rulesets")
+	varsFrom := fs.String("vars-from", "", "report references to variables not defined in `source`: a file written by actions vars resolve -save, or github for the repository's variables")
+	repoFlag := fs.String("repo", "", "`owner/repo` for -rulesets and -vars-from github (default $GITHUB_REPOSITORY or the origin remote)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *list {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, r := range lint.Rules() {
+			fmt.Fprintf(tw, "%s\t%s\n", r.Name(), r.Description())
+		}
+		tw.Flush()
+		return 0
+	}
+
+	catalog, err := loadCatalog(*workspace, *runnersFile)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *fromRulesets {
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		checks, branch, err := mergeQueueChecks(newClient(*workspace, *apiURL, *token), owner, repo, *queueBranch)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		requiredChecks = append(requiredChecks, checks...)
+		*queueBranch = branch
+	}
+	defined, err := loadVariables(*workspace, *repoFlag, *varsFrom, *apiURL, *token)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	var rules []lint.Rule
+	for _, r := range lint.Rules() {
+		if slices.Contains(disable, r.Name()) {
+			continue
+		}
+		if rl, ok := r.(*lint.RunnerLabels); ok {
+			rl.Extra = append(rl.Extra, labels...)
+			rl.Catalog = catalog
+		}
+		if mq, ok := r.(*lint.MergeQueue); ok {
+			mq.RequiredChecks, mq.Branch = requiredChecks, *queueBranch
+		}
+		if v, ok := r.(*lint.Variables); ok {
+			v.Defined = defined
+		}
+		rules = append(rules, r)
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	findings := []lint.Finding{}
+	for _, path := range paths {
+		f, err := lint.LintFile(path, rules)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		findings = append(findings, f...)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		for _, f := range findings {
+			fmt.Println(f)
+		}
+	}
+	for _, f := range findings {
+		if f.Severity == lint.SeverityError {
+			return 1
+		}
+	}
+	return 0
+}
+
+// mergeQueueChecks returns the status checks the rulesets of branch, or
+// else the default branch, require if they also require a merge queue.
+func mergeQueueChecks(c *client.Client, owner, repo, branch string) ([]string, string, error) {
+	ctx := context.Background()
+	if branch == "" {
+		r, err := c.GetRepository(ctx, owner, repo)
+		if err != nil {
+			return nil, "", err
+		}
+		branch = r.DefaultBranch
+	}
+	queued, err := rulesets.MergeQueue(ctx, c, owner, repo, branch)
+	if err != nil || !queued {
+		return nil, branch, err
+	}
+	reqs, err := rulesets.Required(ctx, c, owner, repo, branch)
+	if err != nil {
+		return nil, branch, err
+	}
+	var checks []string
+	for _, req := range reqs {
+		if req.Kind == rulesets.KindStatusCheck {
+			checks = append(checks, req.Check)
+		}
+	}
+	return checks, branch, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"testingdashboard/m/v2/lint"
+	"testingdashboard/m/v2/lsp"
+)
+
+func init() {
+	register("lsp", "Serve the Language Server Protocol for workflow files on standard input and output", lspCommand)
+}
+
+func lspCommand(args []string) int {
+	fs := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions lsp [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Editors start the server and talk to it on standard input and output. It\n")
+		fmt.Fprintf(fs.Output(), "completes keys, action inputs and expression contexts, documents them on\n")
+		fmt.Fprintf(fs.Output(), "hover, goes to the definition of actions, reusable workflows, jobs and\n")
+		fmt.Fprintf(fs.Output(), "steps, and reports what actions lint finds.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", "", "repository `directory` local actions of files outside .github/workflows are relative to (default the editor's root)")
+	offline := fs.Bool("offline", false, "do not fetch actions and reusable workflows of other repositories")
+	fetcherMode := fs.String("fetcher", "", fetcherUsage)
+	var disable, labels listFlag
+	fs.Var(&disable, "disable", "skip the named lint `rule` (repeatable)")
+	fs.Var(&labels, "label", "accept this self-hosted runner `label` (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var rules []lint.Rule
+	for _, r := range lint.Rules() {
+		if slices.Contains(disable, r.Name()) {
+			continue
+		}
+		if rl, ok := r.(*lint.RunnerLabels); ok {
+			rl.Extra = append(rl.Extra, labels...)
+		}
+		rules = append(rules, r)
+	}
+	s := &lsp.Server{Rules: rules, Workspace: *workspace}
+	if !*offline {
+		dir := *workspace
+		if dir == "" {
+			dir = "."
+		}
+		fetcher, err := metadataFetcher(dir, *fetcherMode, "", "")
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		s.Fetcher = fetcher
+	}
+	if err := s.Serve(context.Background(), os.Stdin, os.Stdout); err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
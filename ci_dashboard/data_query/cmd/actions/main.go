@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command actions is a toolbox for working with GitHub Actions workflows.
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/transport"
+)
+
+// command is a single actions subcommand.
+type command struct {
+	summary string
+	// run executes the command and returns the process exit code.
+	run func(args []string) int
+}
+
+var commands = map[string]command{}
+
+func register(name, summary string, run func(args []string) int) {
+	commands[name] = command{summary: summary, run: run}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: actions <command> [flags]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'actions <command> -h' for help on a command.\n")
+	fmt.Fprintf(os.Stderr, "Set ACTIONS_CASSETTE to a file to record HTTP requests to it and replay\n")
+	fmt.Fprintf(os.Stderr, "them, with ACTIONS_CASSETTE_MODE once (default), record or replay.\n")
+	fmt.Fprintf(os.Stderr, "Lookups of action metadata and refs are cached in ACTIONS_HTTP_CACHE (off\n")
+	fmt.Fprintf(os.Stderr, "to disable) for ACTIONS_HTTP_CACHE_TTL (default 1h) before they are\n")
+	fmt.Fprintf(os.Stderr, "revalidated; with ACTIONS_OFFLINE=true only the cache answers them.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "-h" || name == "--help" || name == "help" {
+		usage()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "actions: unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+	cassette, err := transport.CassetteFromEnv()
+	if err != nil {
+		os.Exit(fatalf("%v", err))
+	}
+	if cassette != nil {
+		// Every client sends its requests through the default transport.
+		http.DefaultTransport = cassette.Middleware(http.DefaultTransport)
+	}
+	os.Exit(cmd.run(os.Args[2:]))
+}
+
+// keyValueFlag collects repeated KEY=VALUE flags into a map.
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	parts := make([]string, 0, len(f))
+	for k, v := range f {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (f keyValueFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", s)
+	}
+	f[k] = v
+	return nil
+}
+
+// listFlag collects repeated string flags.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// defaultWorkflowFile picks the only workflow in .github/workflows when the
+// user did not name one.
+func defaultWorkflowFile(dir string) (string, error) {
+	files, err := workflowFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	switch len(files) {
+	case 0:
+		return "", fmt.Errorf("no workflow files found in %s", filepath.Join(dir, ".github", "workflows"))
+	case 1:
+		return files[0], nil
+	}
+	return "", fmt.Errorf("multiple workflow files found; specify one of %s", strings.Join(files, ", "))
+}
+
+// workflowFiles lists the workflow files under dir/.github/workflows.
+func workflowFiles(dir string) ([]string, error) {
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, ".github", "workflows", pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// fatalf prints an error and returns the failure exit code.
+func fatalf(format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "actions: "+format+"\n", args...)
+	return 1
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"testingdashboard/m/v2/scaffold"
+)
+
+func init() {
+	register("new-action", "Generate the skeleton of an action written in Go", newActionCommand)
+}
+
+func newActionCommand(args []string) int {
+	fs := flag.NewFlagSet("new-action", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions new-action [flags] <name>\n\n")
+		fmt.Fprintf(fs.Output(), "Writes action.yml, a main package reading the inputs and setting the\n")
+		fmt.Fprintf(fs.Output(), "outputs with the core package, its packaging and a release workflow into\n")
+		fmt.Fprintf(fs.Output(), "the directory -dir. A docker action builds its image from source on\n")
+		fmt.Fprintf(fs.Output(), "every run; a binary action runs the binaries the release workflow\n")
+		fmt.Fprintf(fs.Output(), "attaches to each release, so it must be used at a release tag.\n\n")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", "", "`directory` to write to (default the name)")
+	description := fs.String("description", "", "what the action does")
+	module := fs.String("module", "", "Go module `path` (default github.com/$GITHUB_REPOSITORY_OWNER/<name>, or the name)")
+	var inputs, outputs listFlag
+	fs.Var(&inputs, "input", "input `name`; repeatable")
+	fs.Var(&outputs, "output", "output `name`; repeatable")
+	packaging := fs.String("packaging", "docker", "how the action runs: docker or binary")
+	goVersion := fs.String("go", "", "Go `version` (default 1.24)")
+	force := fs.Bool("force", false, "overwrite existing files")
+	dryRun := fs.Bool("dry-run", false, "print the files instead of writing them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	name := fs.Arg(0)
+	if *dir == "" {
+		*dir = name
+	}
+	if *module == "" {
+		*module = name
+		if owner := os.Getenv("GITHUB_REPOSITORY_OWNER"); owner != "" {
+			*module = path.Join("github.com", owner, name)
+		}
+	}
+	for _, n := range append(inputs[:len(inputs):len(inputs)], outputs...) {
+		if n == "" || strings.ContainsAny(n, ", ") {
+			return fatalf("invalid input or output name %q", n)
+		}
+	}
+	values := map[string]string{
+		"Name":      name,
+		"Module":    *module,
+		"Inputs":    strings.Join(inputs, ","),
+		"Outputs":   strings.Join(outputs, ","),
+		"Packaging": *packaging,
+	}
+	if *description != "" {
+		values["Description"] = *description
+	}
+	if *goVersion != "" {
+		values["GoVersion"] = *goVersion
+	}
+	files, err := scaffold.GoAction().Render(values)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *dryRun {
+		for _, f := range files {
+			fmt.Printf("# %s\n%s", f.Path, f.Content)
+		}
+		return 0
+	}
+	if err := scaffold.Write(*dir, files, *force); err != nil {
+		return fatalf("%v; pass -force to overwrite", err)
+	}
+	for _, f := range files {
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", path.Join(*dir, f.Path))
+	}
+	fmt.Fprintf(os.Stderr, "Run go get %s@latest && go mod tidy in %s to add the core package.\n", scaffold.CoreModule, *dir)
+	return 0
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/packaging"
+	"testingdashboard/m/v2/release"
+)
+
+func init() {
+	register("package", "Build a Go action for every runner platform and publish it as a precompiled-binary action", packageCommand)
+}
+
+func packageCommand(args []string) int {
+	fs := flag.NewFlagSet("package", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions package [flags] [dir]\n\n")
+		fmt.Fprintf(fs.Output(), "Builds the main package in dir, default the current directory, for each\n")
+		fmt.Fprintf(fs.Output(), "platform and rewrites the runs of its action.yml into a composite action\n")
+		fmt.Fprintf(fs.Output(), "that runs the binary of the runner's platform, downloaded from the\n")
+		fmt.Fprintf(fs.Output(), "release the action is used at and checked against its checksums.\n")
+		fmt.Fprintf(fs.Output(), "-publish attaches the binaries and checksums to the release of -tag; the\n")
+		fmt.Fprintf(fs.Output(), "action.yml committed at the tag must be the generated one, which -check\n")
+		fmt.Fprintf(fs.Output(), "verifies instead of rewriting it.\n\n")
+		fs.PrintDefaults()
+	}
+	name := fs.String("name", "", "base `name` of the binaries (default the directory's name)")
+	platformsFlag := fs.String("platforms", "", "comma-separated os/arch `platforms` (default those of the hosted runners)")
+	out := fs.String("out", "", "`directory` of the binaries (default dir/dist)")
+	ldflags := fs.String("ldflags", "", "more `flags` for the linker, such as -X main.version=v1.2.3")
+	check := fs.Bool("check", false, "exit 1 if action.yml is not the generated one instead of writing it")
+	noBuild := fs.Bool("no-build", false, "only generate action.yml")
+	publish := fs.Bool("publish", false, "attach the binaries and checksums to the release of -tag, creating it if needed")
+	tag := fs.String("tag", "", "`tag` of the release (default $GITHUB_REF_NAME of a tag push)")
+	draft := fs.Bool("draft", false, "create the release as a draft")
+	overwrite := fs.Bool("overwrite", false, "replace assets the release already has")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 || (*publish && *noBuild) {
+		fs.Usage()
+		return 2
+	}
+	dir := "."
+	if fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *name == "" {
+		*name = filepath.Base(abs)
+	}
+	if *out == "" {
+		*out = filepath.Join(dir, "dist")
+	}
+	platforms := packaging.DefaultPlatforms
+	if *platformsFlag != "" {
+		platforms = nil
+		for _, s := range strings.Split(*platformsFlag, ",") {
+			p, err := packaging.ParsePlatform(strings.TrimSpace(s))
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			platforms = append(platforms, p)
+		}
+	}
+	if *publish && *tag == "" && os.Getenv("GITHUB_REF_TYPE") == "tag" {
+		*tag = os.Getenv("GITHUB_REF_NAME")
+	}
+	if *publish && *tag == "" {
+		return fatalf("-publish needs -tag")
+	}
+
+	meta, err := metadata.Load(dir)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	data, err := packaging.MarshalWrapper(packaging.Wrapper(meta, *name, platforms))
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	old, err := os.ReadFile(meta.Path)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	switch {
+	case bytes.Equal(old, data):
+	case *check:
+		return fatalf("%s is not the generated action; run actions package and commit it", meta.Path)
+	default:
+		if err := os.WriteFile(meta.Path, data, 0o644); err != nil {
+			return fatalf("%v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", meta.Path)
+	}
+	if *noBuild {
+		return 0
+	}
+
+	ctx := context.Background()
+	binaries, err := packaging.Build(ctx, packaging.BuildOptions{Dir: dir, Name: *name, OutDir: *out, Platforms: platforms, LDFlags: *ldflags})
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	files := make([]string, len(binaries))
+	for i, b := range binaries {
+		files[i] = b.Path
+		fmt.Printf("%s  %s\n", b.SHA256, filepath.Base(b.Path))
+	}
+	if !*publish {
+		return 0
+	}
+	owner, repo, err := currentRepository(dir, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	c := newClient(dir, *apiURL, *token)
+	rel, err := release.Publish(ctx, c, owner, repo, &client.ReleaseRequest{TagName: *tag, Name: *tag, Draft: draft})
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if _, err := release.UploadAssets(ctx, c, owner, repo, rel, files, release.AssetOptions{Checksums: packaging.ChecksumsAsset, Overwrite: *overwrite}); err != nil {
+		return fatalf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Published %s\n", rel.HTMLURL)
+	return 0
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/changes"
+	"testingdashboard/m/v2/core"
+	"testingdashboard/m/v2/pathfilter"
+)
+
+func init() {
+	register("paths", "Report which named path filters the changed files match", pathsCommand)
+}
+
+func pathsCommand(args []string) int {
+	fs := flag.NewFlagSet("paths", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions paths [flags] <filters.yml|->\n\n")
+		fmt.Fprintf(fs.Output(), "The filters map names to path patterns, as for dorny/paths-filter. The\n")
+		fmt.Fprintf(fs.Output(), "changed files are those of the triggering event unless -base, -pr or\n")
+		fmt.Fprintf(fs.Output(), "-file give them.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	listFiles := fs.String("list-files", pathfilter.ListNone, "`format` of the <filter>_files outputs: none, csv, json, shell or escape")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	setOutputs := fs.Bool("output", false, "write the results to $GITHUB_OUTPUT")
+	cf := addChangeFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	var data []byte
+	var err error
+	if fs.Arg(0) == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(fs.Arg(0))
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	filters, err := pathfilter.Parse(data)
+	if err != nil {
+		return fatalf("%s: %v", fs.Arg(0), err)
+	}
+
+	changed, err := cf.changed(context.Background(), *workspace)
+	if errors.Is(err, changes.ErrNoRange) {
+		changed, err = nil, nil
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	res, err := pathfilter.Match(filters, changed)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *setOutputs {
+		outs, err := res.Outputs(*listFiles)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		for _, o := range outs {
+			if err := core.SetOutput(o.Name, o.Value); err != nil {
+				return fatalf("%v", err)
+			}
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILTER\tMATCHED\tFILES")
+	for _, f := range res.Filters {
+		fmt.Fprintf(tw, "%s\t%t\t%d\n", f.Name, f.Matched(), len(f.Files))
+	}
+	tw.Flush()
+	return 0
+}
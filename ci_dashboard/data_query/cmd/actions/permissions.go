@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"testingdashboard/m/v2/permissions"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("permissions", "Suggest least-privilege permissions for workflow jobs", permissionsCommand)
+}
+
+func permissionsCommand(args []string) int {
+	fs := flag.NewFlagSet("permissions", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions permissions [flags] [workflow.yml ...]\n\n")
+		fmt.Fprintf(fs.Output(), "Exits 1 if any job has default, write-all, excessive or missing permissions.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are checked when no files are given")
+	asJSON := fs.Bool("json", false, "print the reports as JSON")
+	verbose := fs.Bool("v", false, "also print steps whose needs cannot be inferred")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	reports := []*permissions.Report{}
+	for _, path := range paths {
+		wf, err := workflow.ParseFile(path)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		reports = append(reports, permissions.Analyze(wf))
+	}
+	failed := false
+	for _, r := range reports {
+		for _, f := range r.Findings {
+			failed = failed || f.Severity == "warning"
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		for i, r := range reports {
+			if i > 0 {
+				fmt.Println()
+			}
+			for _, f := range r.Findings {
+				if f.Severity == "warning" || *verbose {
+					fmt.Println(f)
+				}
+			}
+			fmt.Printf("# Suggested permissions for %s\n", r.Path)
+			fmt.Print(r.Workflow.YAML(""))
+			if len(r.Suggested) > 0 {
+				fmt.Println("jobs:")
+				for _, jr := range r.Jobs {
+					if s, ok := r.Suggested[jr.ID]; ok {
+						fmt.Printf("  %s:\n", jr.ID)
+						fmt.Print(s.YAML("    "))
+					}
+				}
+			}
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
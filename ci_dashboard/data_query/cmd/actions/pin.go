@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"testingdashboard/m/v2/pin"
+)
+
+func init() {
+	register("pin", "Pin action references to commit SHAs", pinCommand)
+}
+
+func pinCommand(args []string) int {
+	fs := flag.NewFlagSet("pin", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions pin [flags] [file.yml ...]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are pinned when no files are given")
+	dryRun := fs.Bool("n", false, "print the changes without writing them")
+	repin := fs.Bool("repin", false, "re-resolve pinned references from their trailing comment")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	if *apiURL == "" {
+		*apiURL = instance(*workspace).API
+	}
+	hc, err := metadataClient()
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	resolver := &pin.GitHubResolver{APIURL: *apiURL, Token: *token, Client: hc}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		out, changes, err := pin.Pin(context.Background(), data, resolver, pin.Options{Repin: *repin})
+		if err != nil {
+			return fatalf("%s: %v", path, err)
+		}
+		for _, c := range changes {
+			from := *c.Ref.Uses
+			from.Ref = c.From
+			fmt.Printf("%s:%d: %s -> %s\n", path, c.Ref.Line, from.String(), c.SHA)
+		}
+		if *dryRun || bytes.Equal(out, data) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,283 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/dryrun"
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/pin"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("plan", "Show what a run of a workflow would do without running it", planCommand)
+}
+
+func planCommand(args []string) int {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions plan [flags] [workflow.yml]\n\n")
+		fmt.Fprintf(fs.Output(), "Without -e or -event-file, lists the jobs with reusable workflow calls\n")
+		fmt.Fprintf(fs.Output(), "expanded. With them, shows whether the event triggers the workflow, the\n")
+		fmt.Fprintf(fs.Output(), "legs of every matrix, which jobs and steps their conditions select, the\n")
+		fmt.Fprintf(fs.Output(), "commits actions resolve to and the environment of each step.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "workspace `directory` local workflows are relative to")
+	asJSON := fs.Bool("json", false, "print the plan as JSON")
+	eventName := fs.String("e", "", "`name` of the simulated event")
+	eventFile := fs.String("event-file", "", "JSON `file` with the event payload")
+	ref := fs.String("ref", "", "`ref` the event is for, or the base branch of a pull request or merge group (default the current branch, or main)")
+	actor := fs.String("actor", "", "`login` of the user who triggered the run (default the git user name)")
+	activity := fs.String("type", "", "activity `type` of the event, such as opened")
+	base := fs.String("base", "", "compute changed files as the diff from this `rev` to HEAD")
+	showEnv := fs.Bool("env", false, "show the environment of each step")
+	offline := fs.Bool("offline", false, "do not resolve action refs to commits")
+	fetcherMode := fs.String("fetcher", "", fetcherUsage)
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	var files, secretNames listFlag
+	fs.Var(&files, "file", "changed `path` (repeatable)")
+	fs.Var(&secretNames, "secret", "`name` of a secret that is set (repeatable); once one is given the others count as unset, before that conditions on secrets are unknown")
+	vars, inputs := keyValueFlag{}, keyValueFlag{}
+	fs.Var(vars, "var", "configuration variable `KEY=VALUE`, taking precedence over -vars-from (repeatable)")
+	varsFrom := fs.String("vars-from", "", varsFromUsage)
+	fs.Var(inputs, "input", "workflow input `KEY=VALUE` (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		var err error
+		if path, err = defaultWorkflowFile(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	wf, err := workflow.ParseFile(path)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *eventName != "" || *eventFile != "" {
+		opts := dryrun.Options{EventName: *eventName, Vars: vars, Secrets: secretNames, Inputs: map[string]any{}}
+		if opts.Variables, err = loadVariables(*workspace, "", *varsFrom, *apiURL, *token); err != nil {
+			return fatalf("%v", err)
+		}
+		if opts.EventName == "" {
+			opts.EventName = "push"
+		}
+		if *eventFile != "" {
+			data, err := os.ReadFile(*eventFile)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			if err := json.Unmarshal(data, &opts.Event); err != nil {
+				return fatalf("failed to parse %s: %v", *eventFile, err)
+			}
+		}
+		if *activity != "" {
+			if opts.Event == nil {
+				opts.Event = map[string]any{}
+			}
+			opts.Event["action"] = *activity
+		}
+		for k, v := range inputs {
+			opts.Inputs[k] = v
+		}
+		if branch, err := gitLines(*workspace, "symbolic-ref", "-q", "--short", "HEAD"); err == nil && len(branch) > 0 {
+			opts.Ref = branch[0]
+		}
+		if strings.HasPrefix(opts.EventName, "pull_request") || opts.EventName == "merge_group" {
+			// The current branch is the head; -ref is the base.
+			opts.Head, opts.Ref = opts.Ref, "main"
+		}
+		if *ref != "" {
+			opts.Ref = *ref
+		}
+		if opts.Ref != "" && !strings.HasPrefix(opts.Ref, "refs/") {
+			opts.Ref = "refs/heads/" + opts.Ref
+		}
+		if sha, err := gitLines(*workspace, "rev-parse", "HEAD"); err == nil && len(sha) > 0 {
+			opts.SHA = sha[0]
+		}
+		opts.Actor = *actor
+		if opts.Actor == "" {
+			if name, err := gitLines(*workspace, "config", "user.name"); err == nil && len(name) > 0 {
+				opts.Actor = name[0]
+			}
+		}
+		if remote, err := gitLines(*workspace, "remote", "get-url", "origin"); err == nil && len(remote) > 0 {
+			_, opts.Repository = endpoints.ParseRemote(remote[0])
+		}
+		switch {
+		case len(files) > 0:
+			opts.Changed = files
+		case *base != "":
+			changed, err := gitLines(*workspace, "diff", "--name-only", *base+"...HEAD")
+			if err != nil {
+				return fatalf("failed to diff against %s: %v", *base, err)
+			}
+			opts.Changed = changed
+		}
+		if !*offline {
+			if *apiURL == "" {
+				*apiURL = instance(*workspace).API
+			}
+			hc, err := metadataClient()
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			opts.Resolver = &pin.GitHubResolver{APIURL: *apiURL, Token: *token, Client: hc}
+		}
+		plan, err := dryrun.New(context.Background(), wf, opts)
+		if err != nil {
+			return fatalf("%s: %v", path, err)
+		}
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(plan); err != nil {
+				return fatalf("%v", err)
+			}
+			return 0
+		}
+		printDryRun(plan, *showEnv)
+		return 0
+	}
+	fetcher, err := metadataFetcher(*workspace, *fetcherMode, *apiURL, *token)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	plan, err := resolve.Expand(context.Background(), fetcher, wf)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	rows := make([]planJob, 0, len(plan.Jobs))
+	for _, j := range plan.Jobs {
+		row := planJob{ID: j.ID, Needs: j.Needs, Via: j.Via}
+		if j.Call != nil {
+			row.Calls = j.Call.Uses.String()
+		}
+		rows = append(rows, row)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tNEEDS\tCALLS")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", row.ID, strings.Join(row.Needs, ", "), row.Calls)
+	}
+	tw.Flush()
+	return 0
+}
+
+// printDryRun prints a plan as a table of jobs, legs and steps.
+func printDryRun(plan *dryrun.Plan, showEnv bool) {
+	state := "is not triggered"
+	if plan.Triggered {
+		state = "is triggered"
+	}
+	fmt.Printf("Workflow %s %s by %s of %s: %s\n", plan.Workflow, state, plan.Event, plan.Ref, plan.Reason)
+	if plan.RunName != "" {
+		fmt.Printf("Run name: %s\n", plan.RunName)
+	}
+	if !plan.Triggered {
+		return
+	}
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSTEP\tDECISION\tDETAIL")
+	env := func(vars map[string]string) {
+		if !showEnv {
+			return
+		}
+		keys := make([]string, 0, len(vars))
+		for k := range vars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(tw, "\t\t\t%s=%s\n", k, vars[k])
+		}
+	}
+	for _, j := range plan.Jobs {
+		if len(j.Legs) == 0 {
+			detail := j.Reason
+			if j.Calls != "" {
+				detail = strings.TrimSpace("calls " + j.Calls + "; " + detail)
+			}
+			fmt.Fprintf(tw, "%s\t\t%s\t%s\n", j.ID, j.Decision, strings.TrimSuffix(detail, "; "))
+			continue
+		}
+		for _, leg := range j.Legs {
+			detail := j.Reason
+			switch {
+			case j.Calls != "":
+				detail = "calls " + j.Calls
+			case len(leg.RunsOn) > 0:
+				detail = "runs on " + strings.Join(leg.RunsOn, ", ")
+			}
+			if leg.Environment != "" {
+				detail = strings.TrimPrefix(detail+"; environment "+leg.Environment, "; ")
+			}
+			fmt.Fprintf(tw, "%s\t\t%s\t%s\n", leg.Name, j.Decision, detail)
+			for _, s := range leg.Steps {
+				detail := s.Reason
+				if detail == "" {
+					detail = s.Uses
+					if s.SHA != "" && !strings.HasSuffix(s.Uses, s.SHA) {
+						detail += " (" + s.SHA + ")"
+					}
+				}
+				if detail == "" {
+					detail, _, _ = strings.Cut(strings.TrimSpace(s.Run), "\n")
+				}
+				if s.Retry != "" && s.Decision != dryrun.Skip {
+					detail += "; " + s.Retry
+				}
+				fmt.Fprintf(tw, "\t%s\t%s\t%s\n", s.Name, s.Decision, detail)
+				if s.Decision != dryrun.Skip {
+					env(s.Env)
+				}
+			}
+		}
+	}
+	tw.Flush()
+}
+
+// planJob is one line of plan output.
+type planJob struct {
+	ID    string   `json:"id"`
+	Needs []string `json:"needs,omitempty"`
+	Calls string   `json:"calls,omitempty"`
+	Via   []string `json:"via,omitempty"`
+}
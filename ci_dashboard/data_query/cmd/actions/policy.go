@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/inventory"
+	"testingdashboard/m/v2/policy"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("policy", "Check workflows against an organization policy", policyCommand)
+}
+
+// defaultPolicyFile is where policy check looks for the policy.
+const defaultPolicyFile = ".github/actions-policy.yml"
+
+func policyCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintf(os.Stderr, "Usage: actions policy check [flags] [path ...]\n")
+		return 2
+	}
+	flags := flag.NewFlagSet("policy check", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: actions policy check [flags] [path ...]\n\n")
+		fmt.Fprintf(flags.Output(), "Paths are workflow files or repository directories; dir/... checks every\nrepository below dir. Exits 1 if any workflow violates the policy.\n\n")
+		flags.PrintDefaults()
+	}
+	policyFile := flags.String("policy", defaultPolicyFile, "policy `file`")
+	asJSON := flags.Bool("json", false, "print violations as JSON")
+	inventoryFile := flags.String("inventory", "", "check the workflows of an inventory `file` written by actions audit instead of paths")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *inventoryFile != "" && flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+	p, err := policy.Load(*policyFile)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	var wfs []*workflow.Workflow
+	if *inventoryFile != "" {
+		inv, err := inventory.Load(*inventoryFile)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		wfs = inv.Workflows()
+	} else {
+		paths := flags.Args()
+		if len(paths) == 0 {
+			paths = []string{"."}
+		}
+		files, err := expandWorkflowPaths(paths)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		for _, file := range files {
+			wf, err := workflow.ParseFile(file)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			wfs = append(wfs, wf)
+		}
+	}
+
+	var engine policy.Engine = p
+	violations := []policy.Violation{}
+	for _, wf := range wfs {
+		in, err := policy.NewInput(wf)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		v, err := engine.Evaluate(context.Background(), in)
+		if err != nil {
+			return fatalf("%s: %v", wf.Path, err)
+		}
+		violations = append(violations, v...)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(violations); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		for _, v := range violations {
+			fmt.Println(v)
+		}
+	}
+	if len(violations) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// expandWorkflowPaths turns workflow files, repository directories and
+// dir/... patterns into workflow files.
+func expandWorkflowPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		root, recursive := strings.CutSuffix(p, "...")
+		if !recursive {
+			info, err := os.Stat(p)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				files = append(files, p)
+				continue
+			}
+			found, err := workflowFiles(p)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, found...)
+			continue
+		}
+		if root == "" {
+			root = "."
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			switch d.Name() {
+			case ".git", "node_modules", "vendor":
+				return filepath.SkipDir
+			}
+			if filepath.Base(path) == "workflows" && filepath.Base(filepath.Dir(path)) == ".github" {
+				found, err := workflowFiles(filepath.Dir(filepath.Dir(path)))
+				files = append(files, found...)
+				if err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/permissions"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("preflight", "Check that the token has the permissions an operation needs", preflightCommand)
+}
+
+func preflightCommand(args []string) int {
+	fs := flag.NewFlagSet("preflight", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions preflight [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "The permissions are given with -need, or inferred for -job of -workflow.\n")
+		fmt.Fprintf(fs.Output(), "Exits 1 if the token lacks any, or with -strict if any cannot be verified.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	var needs listFlag
+	fs.Var(&needs, "need", "required `scope:level`, such as contents:write (repeatable)")
+	workflowFile := fs.String("workflow", "", "workflow `file` whose job's inferred needs are checked")
+	jobID := fs.String("job", os.Getenv("GITHUB_JOB"), "`job` of -workflow (default $GITHUB_JOB)")
+	strict := fs.Bool("strict", false, "also fail on permissions that cannot be verified")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || (len(needs) == 0 && *workflowFile == "") {
+		fs.Usage()
+		return 2
+	}
+
+	need := permissions.Set{}
+	for _, n := range needs {
+		scope, level, ok := strings.Cut(n, ":")
+		if !ok || !slices.Contains(permissions.Scopes, scope) || (level != workflow.PermissionRead && level != workflow.PermissionWrite) {
+			return fatalf("invalid -need %q: want a scope such as contents, then :read or :write", n)
+		}
+		need.Add(scope, level)
+	}
+	if *workflowFile != "" {
+		wf, err := workflow.ParseFile(*workflowFile)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		if wf.Jobs[*jobID] == nil {
+			return fatalf("%s has no job %q", *workflowFile, *jobID)
+		}
+		for _, jr := range permissions.Analyze(wf).Jobs {
+			if jr.ID == *jobID {
+				need.Merge(jr.Needs)
+			}
+		}
+	}
+
+	owner, repo, err := currentRepository(*workspace, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	r, err := newClient(*workspace, *apiURL, *token).Preflight(context.Background(), owner, repo, need)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *asJSON {
+		if code := encodeJSON(r); code != 0 {
+			return code
+		}
+	} else {
+		fmt.Printf("Token: %s", r.Kind)
+		if len(r.Scopes) > 0 {
+			fmt.Printf(" (scopes %s)", strings.Join(r.Scopes, ", "))
+		}
+		fmt.Println()
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SCOPE\tNEED\tRESULT\tREASON")
+		for _, result := range []struct {
+			name string
+			set  permissions.Set
+		}{{"missing", r.Missing}, {"unverified", r.Unverified}, {"granted", r.Granted}} {
+			for _, scope := range permissions.Scopes {
+				if _, ok := result.set[scope]; !ok {
+					continue
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", scope, result.set[scope], result.name, r.Reasons[scope])
+			}
+		}
+		tw.Flush()
+	}
+	if err := r.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "actions: %v\n", err)
+		return 1
+	}
+	if *strict && len(r.Unverified) > 0 {
+		fmt.Fprintf(os.Stderr, "actions: cannot verify %s\n", r.Unverified)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/core"
+	"testingdashboard/m/v2/glob"
+	"testingdashboard/m/v2/release"
+	"testingdashboard/m/v2/semver"
+)
+
+func init() {
+	register("release", "Compute the next version and changelog, and tag and publish the release", releaseCommand)
+}
+
+type releaseResult struct {
+	Released  bool                   `json:"released"`
+	Previous  string                 `json:"previous,omitempty"`
+	Version   string                 `json:"version,omitempty"`
+	Tag       string                 `json:"tag,omitempty"`
+	Level     string                 `json:"level"`
+	Changelog string                 `json:"changelog,omitempty"`
+	Commits   []release.Commit       `json:"commits"`
+	URL       string                 `json:"url,omitempty"`
+	Assets    []*client.ReleaseAsset `json:"assets,omitempty"`
+}
+
+func releaseCommand(args []string) int {
+	fs := flag.NewFlagSet("release", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions release [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "The next version follows from the conventional commits since the last\n")
+		fmt.Fprintf(fs.Output(), "version tag. Without -tag or -publish nothing is changed.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	prefix := fs.String("prefix", "v", "`prefix` of version tags")
+	pre := fs.String("prerelease", "", "release a prerelease with this `identifier`, such as rc")
+	preMajor := fs.Bool("pre-major", false, "bump the minor version for breaking changes while the major version is 0")
+	versionFlag := fs.String("version", "", "release this `version` instead of the computed one")
+	changelogFile := fs.String("changelog-file", "", "prepend the changelog to this `file`, such as CHANGELOG.md")
+	tag := fs.Bool("tag", false, "create the tag and push it")
+	remote := fs.String("remote", "origin", "`remote` the tag is pushed to; empty keeps it local")
+	publish := fs.Bool("publish", false, "create or update the GitHub release")
+	draft := fs.Bool("draft", false, "publish the release as a draft")
+	checksums := fs.String("checksums", "checksums.txt", "`name` of the asset with the SHA-256 of the others; empty for none")
+	concurrency := fs.Int("parallel", 4, "how `many` assets to upload at once")
+	overwrite := fs.Bool("overwrite", false, "replace assets the release already has")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	setOutputs := fs.Bool("output", false, "write the result to $GITHUB_OUTPUT")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	var assets listFlag
+	fs.Var(&assets, "asset", "`pattern` of files to attach to the release (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || (len(assets) > 0 && !*publish) {
+		fs.Usage()
+		return 2
+	}
+	ctx := context.Background()
+
+	last, current, err := release.LastTag(ctx, *workspace, *prefix, "HEAD", *pre != "")
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	commits, err := release.Log(ctx, *workspace, last, "HEAD")
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	level := release.Bump(commits)
+	res := releaseResult{Previous: last, Commits: commits, Level: level.String()}
+	var next semver.Version
+	switch {
+	case *versionFlag != "":
+		if next, err = semver.Parse(*versionFlag); err != nil {
+			return fatalf("%v", err)
+		}
+		res.Released = true
+	case level != release.None:
+		next = release.Next(current, level, release.BumpOptions{PreMajor: *preMajor, Prerelease: *pre})
+		res.Released = true
+	}
+	if res.Released {
+		res.Version, res.Tag = next.String(), *prefix+next.String()
+	}
+
+	owner, repo, repoErr := currentRepository(*workspace, *repoFlag)
+	if res.Released {
+		opts := release.ChangelogOptions{PreviousTag: last, Tag: res.Tag, Date: time.Now()}
+		if repoErr == nil {
+			opts.RepoURL = instance(*workspace).Server + "/" + owner + "/" + repo
+		}
+		res.Changelog = release.Changelog(res.Version, commits, opts)
+	}
+
+	if res.Released && *changelogFile != "" {
+		if err := prependChangelog(*changelogFile, res.Changelog); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	if res.Released && *tag {
+		if err := release.Tag(ctx, *workspace, res.Tag, "HEAD", res.Tag, *remote); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	if res.Released && *publish {
+		if repoErr != nil {
+			return fatalf("%v", repoErr)
+		}
+		head, err := gitLines(*workspace, "rev-parse", "HEAD")
+		if err != nil || len(head) == 0 {
+			return fatalf("cannot determine the commit to release")
+		}
+		c := newClient(*workspace, *apiURL, *token)
+		prerelease := next.Prerelease()
+		rel, err := release.Publish(ctx, c, owner, repo, &client.ReleaseRequest{
+			TagName:         res.Tag,
+			TargetCommitish: head[0],
+			Name:            res.Tag,
+			Body:            res.Changelog,
+			Draft:           draft,
+			Prerelease:      &prerelease,
+		})
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		res.URL = rel.HTMLURL
+		if len(assets) > 0 {
+			files, err := assetFiles(*workspace, assets)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			res.Assets, err = release.UploadAssets(ctx, c, owner, repo, rel, files, release.AssetOptions{Concurrency: *concurrency, Checksums: *checksums, Overwrite: *overwrite})
+			if err != nil {
+				return fatalf("%v", err)
+			}
+		}
+	}
+
+	if *setOutputs {
+		for _, o := range [][2]string{
+			{"released", fmt.Sprint(res.Released)},
+			{"version", res.Version},
+			{"tag", res.Tag},
+			{"level", res.Level},
+			{"changelog", res.Changelog},
+			{"url", res.URL},
+		} {
+			if err := core.SetOutput(o[0], o[1]); err != nil {
+				return fatalf("%v", err)
+			}
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	if !res.Released {
+		fmt.Printf("No release needed: no feature, fix or breaking change since %s\n", or(last, "the first commit"))
+		return 0
+	}
+	fmt.Printf("%s (%s bump from %s)\n\n%s", res.Tag, res.Level, or(last, "nothing"), res.Changelog)
+	if res.URL != "" {
+		fmt.Printf("\nPublished %s\n", res.URL)
+	}
+	for _, a := range res.Assets {
+		fmt.Printf("Uploaded %s\n", a.Name)
+	}
+	return 0
+}
+
+// assetFiles returns the files matching patterns relative to dir.
+func assetFiles(dir string, patterns []string) ([]string, error) {
+	opts := glob.DefaultOptions()
+	opts.Root = dir
+	opts.MatchDirectories = false
+	g, err := glob.New(strings.Join(patterns, "\n"), opts)
+	if err != nil {
+		return nil, err
+	}
+	files, err := g.Glob()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file matches %s", strings.Join(patterns, ", "))
+	}
+	return files, nil
+}
+
+// prependChangelog adds a release's changelog to the top of a changelog
+// file, below its title if it has one.
+func prependChangelog(name, entry string) error {
+	data, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	old := string(data)
+	head := ""
+	if strings.HasPrefix(old, "# ") {
+		title, rest, _ := strings.Cut(old, "\n")
+		head, old = title+"\n\n", strings.TrimLeft(rest, "\n")
+	}
+	content := head + entry
+	if old != "" {
+		content += "\n" + old
+	}
+	return os.WriteFile(name, []byte(content), 0o644)
+}
+
+func or(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/core"
+	"testingdashboard/m/v2/reports"
+	"testingdashboard/m/v2/summary"
+)
+
+func init() {
+	register("report", "Summarize JUnit XML and TAP test reports and annotate failures", reportCommand)
+}
+
+func reportCommand(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions report [flags] <pattern>...\n\n")
+		fmt.Fprintf(fs.Output(), "Patterns such as reports/**/*.xml select the report files. The exit\n")
+		fmt.Fprintf(fs.Output(), "status is 1 if a test failed unless -fail=false is given.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` patterns and test files are relative to")
+	title := fs.String("title", "Test results", "`title` of the summary and the check run")
+	asJSON := fs.Bool("json", false, "print the parsed reports as JSON")
+	writeSummary := fs.Bool("summary", false, "append the summary to $GITHUB_STEP_SUMMARY")
+	annotate := fs.Bool("annotate", false, "report failures as error workflow commands")
+	check := fs.String("check", "", "publish a check run `name`d so with the failures annotated")
+	repoFlag := fs.String("repo", "", "`owner/repo` of the check run (default $GITHUB_REPOSITORY or the origin remote)")
+	sha := fs.String("sha", "", "`commit` of the check run (default $GITHUB_SHA or HEAD)")
+	fail := fs.Bool("fail", true, "exit with status 1 if a test failed")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	root, err := filepath.Abs(*workspace)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	report, err := reports.ParseFiles(root, fs.Args())
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	if *writeSummary {
+		if err := report.Summary(*title).Write(summary.WriteOptions{}); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	if *annotate {
+		for _, a := range report.Annotations(root) {
+			core.Error(a.Message, core.Annotation{Title: a.Title, File: a.Path, StartLine: a.StartLine, EndLine: a.EndLine})
+		}
+	}
+	if *check != "" {
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		commit := *sha
+		if commit == "" {
+			commit = os.Getenv("GITHUB_SHA")
+		}
+		if commit == "" {
+			head, err := gitLines(*workspace, "rev-parse", "HEAD")
+			if err != nil || len(head) == 0 {
+				return fatalf("cannot determine the commit; pass -sha")
+			}
+			commit = head[0]
+		}
+		run, err := report.Publish(context.Background(), newClient(*workspace, *apiURL, *token), owner, repo, commit, *check, root)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Published check run %s\n", run.HTMLURL)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SUITE\tPASSED\tFAILED\tSKIPPED\tDURATION")
+		for _, s := range report.Suites {
+			t := s.Totals()
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", s.Name, t.Passed, t.Failed, t.Skipped, t.Duration)
+		}
+		tw.Flush()
+		for _, f := range report.Failures() {
+			loc := ""
+			if f.Case.File != "" {
+				loc = fmt.Sprintf(" (%s:%d)", f.Case.File, f.Case.Line)
+			}
+			fmt.Printf("\nFAIL %s / %s%s\n", f.Suite.Name, f.Case.Name, loc)
+			if f.Case.Message != "" {
+				fmt.Printf("    %s\n", f.Case.Message)
+			}
+		}
+	}
+	if *fail && report.Totals().Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/client"
+)
+
+func init() {
+	register("rerun", "Run a completed workflow run, its failed jobs or one job again, optionally with debug logging", rerunCommand)
+}
+
+func rerunCommand(args []string) int {
+	fs := flag.NewFlagSet("rerun", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions rerun [flags] <run-id>\n\n")
+		fmt.Fprintf(fs.Output(), "Starts a new attempt of the run. With -follow it then watches the attempt\n")
+		fmt.Fprintf(fs.Output(), "like actions watch and exits with the same status.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` of the run (default $GITHUB_REPOSITORY or the origin remote)")
+	failedOnly := fs.Bool("failed-only", false, "run only the failed jobs and the jobs that depend on them again")
+	jobFlag := fs.String("job", "", "run only the job with this `name` or ID and the jobs that depend on it again")
+	debug := fs.Bool("debug", false, "enable runner and step debug logging in the new attempt")
+	follow := fs.Bool("follow", false, "watch the new attempt until it completes")
+	interval := fs.Duration("interval", 5*time.Second, "how often to poll the run with -follow")
+	logs := fs.Bool("logs", true, "print job logs as they become available with -follow")
+	asJSON := fs.Bool("json", false, "with -follow, print one JSON object per line for each transition and log line")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || (*failedOnly && *jobFlag != "") {
+		fs.Usage()
+		return 2
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(fs.Arg(0), "#"), 10, 64)
+	if err != nil {
+		return fatalf("invalid run id %q", fs.Arg(0))
+	}
+	owner, repo, err := currentRepository(*workspace, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := newClient(*workspace, *apiURL, *token)
+	opts := client.RerunOptions{FailedOnly: *failedOnly, Debug: *debug}
+	if *jobFlag != "" {
+		if opts.Job, err = findJob(ctx, c, owner, repo, id, *jobFlag); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	run, err := c.Rerun(ctx, owner, repo, id, opts)
+	if err != nil {
+		return fatalf("failed to rerun run %d: %v", id, err)
+	}
+	fmt.Fprintf(os.Stderr, "Started attempt %d of run %d: %s\n", run.RunAttempt, run.ID, run.HTMLURL)
+	if !*follow {
+		return 0
+	}
+	return followRun(ctx, c, owner, repo, id, *interval, *logs, *asJSON)
+}
+
+// findJob returns the ID of the job of the run's latest attempt whose name
+// or ID is job.
+func findJob(ctx context.Context, c *client.Client, owner, repo string, run int64, job string) (int64, error) {
+	jobs, err := c.ListJobs(ctx, owner, repo, run, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list jobs of run %d: %w", run, err)
+	}
+	for _, j := range jobs {
+		if j.Name == job || strconv.FormatInt(j.ID, 10) == job {
+			return j.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("run %d has no job %q", run, job)
+}
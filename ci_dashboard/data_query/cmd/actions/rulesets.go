@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/rulesets"
+)
+
+func init() {
+	register("rulesets", "Show and verify the workflows and checks rulesets require, for a repository or an organization", rulesetsCommand)
+}
+
+func rulesetsCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "required" && args[0] != "verify" && args[0] != "audit") {
+		fmt.Fprintf(os.Stderr, "Usage: actions rulesets <required|verify|audit> [flags]\n")
+		return 2
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("rulesets "+sub, flag.ContinueOnError)
+	fs.Usage = func() {
+		switch sub {
+		case "required":
+			fmt.Fprintf(fs.Output(), "Usage: actions rulesets required [flags] [branch]\n\n")
+			fmt.Fprintf(fs.Output(), "Lists the workflows and status checks the active rulesets require of\n")
+			fmt.Fprintf(fs.Output(), "the branch, by default the repository's default branch.\n\n")
+		case "verify":
+			fmt.Fprintf(fs.Output(), "Usage: actions rulesets verify [flags] [branch]\n\n")
+			fmt.Fprintf(fs.Output(), "Checks that the required workflows exist, parse and last passed on the\n")
+			fmt.Fprintf(fs.Output(), "branch, and that the required checks succeeded on its head. The exit\n")
+			fmt.Fprintf(fs.Output(), "status is 1 if any requirement is not met.\n\n")
+		case "audit":
+			fmt.Fprintf(fs.Output(), "Usage: actions rulesets audit [flags] <org>\n\n")
+			fmt.Fprintf(fs.Output(), "Verifies the default branch of every repository of the organization and\n")
+			fmt.Fprintf(fs.Output(), "reports organization rulesets that select a repository but are not\n")
+			fmt.Fprintf(fs.Output(), "enforced on it. The exit status is 1 if anything is reported.\n\n")
+		}
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	var repoFlag, repos *string
+	var all *bool
+	var maxRuns *int
+	if sub != "required" {
+		maxRuns = fs.Int("max-runs", 50, "how many of a branch's latest runs to search for runs of required workflows")
+	}
+	if sub == "audit" {
+		repos = fs.String("repos", "", "comma-separated `patterns` of the repository names to audit")
+		all = fs.Bool("all", false, "also list the requirements that are met")
+	} else {
+		repoFlag = fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 || (sub == "audit" && fs.NArg() != 1) {
+		fs.Usage()
+		return 2
+	}
+	ctx := context.Background()
+	c := newClient(*workspace, *apiURL, *token)
+
+	var findings []*rulesets.Finding
+	if sub == "audit" {
+		opts := rulesets.AuditOptions{Verify: rulesets.VerifyOptions{MaxRuns: *maxRuns}}
+		if *repos != "" {
+			opts.Repos = strings.Split(*repos, ",")
+		}
+		var err error
+		if findings, err = rulesets.Audit(ctx, c, fs.Arg(0), opts); err != nil {
+			return fatalf("%v", err)
+		}
+		if !*all {
+			var problems []*rulesets.Finding
+			for _, f := range findings {
+				if !f.OK() {
+					problems = append(problems, f)
+				}
+			}
+			findings = problems
+		}
+	} else {
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		branch := fs.Arg(0)
+		if branch == "" {
+			r, err := c.GetRepository(ctx, owner, repo)
+			if err != nil {
+				return fatalf("failed to get %s/%s: %v", owner, repo, err)
+			}
+			branch = r.DefaultBranch
+		}
+		reqs, err := rulesets.Required(ctx, c, owner, repo, branch)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		if sub == "required" {
+			return printRequirements(reqs, *asJSON)
+		}
+		if findings, err = rulesets.Verify(ctx, c, owner, repo, branch, reqs, rulesets.VerifyOptions{MaxRuns: *maxRuns}); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if findings == nil {
+			findings = []*rulesets.Finding{}
+		}
+		if err := enc.Encode(findings); err != nil {
+			return fatalf("%v", err)
+		}
+	} else if len(findings) > 0 {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "REPOSITORY\tBRANCH\tREQUIREMENT\tSTATUS\tDETAIL")
+		for _, f := range findings {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", f.Repository, f.Branch, f.Subject(), f.Status, f.Detail)
+		}
+		tw.Flush()
+	}
+	for _, f := range findings {
+		if !f.OK() {
+			return 1
+		}
+	}
+	return 0
+}
+
+func printRequirements(reqs []*rulesets.Requirement, asJSON bool) int {
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if reqs == nil {
+			reqs = []*rulesets.Requirement{}
+		}
+		if err := enc.Encode(reqs); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	if len(reqs) == 0 {
+		fmt.Println("No ruleset requires workflows or status checks.")
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REQUIREMENT\tRULESET\tSOURCE")
+	for _, r := range reqs {
+		fmt.Fprintf(tw, "%s\t%d\t%s %s\n", r, r.RulesetID, r.SourceType, r.Source)
+	}
+	tw.Flush()
+	return 0
+}
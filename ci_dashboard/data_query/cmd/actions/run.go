@@ -0,0 +1,340 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/compliance"
+	"testingdashboard/m/v2/logging"
+	"testingdashboard/m/v2/metrics"
+	"testingdashboard/m/v2/netpolicy"
+	"testingdashboard/m/v2/provenance"
+	"testingdashboard/m/v2/runner"
+	"testingdashboard/m/v2/secrets"
+	"testingdashboard/m/v2/sign"
+	"testingdashboard/m/v2/tracing"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("run", "Run a workflow locally", runCommand)
+}
+
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions run [flags] [workflow.yml]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "workspace `directory` to run in")
+	eventName := fs.String("e", "push", "`name` of the triggering event")
+	eventFile := fs.String("event-file", "", "JSON `file` with the event payload")
+	var jobs listFlag
+	fs.Var(&jobs, "j", "run only this `job` and the jobs it needs (repeatable)")
+	secretValues, vars, env, inputs := keyValueFlag{}, keyValueFlag{}, keyValueFlag{}, keyValueFlag{}
+	fs.Var(secretValues, "s", "secret `KEY=VALUE`, taking precedence over -secrets-from (repeatable)")
+	var secretSources listFlag
+	fs.Var(&secretSources, "secrets-from", "load secrets from `source`: FILE, dotenv:FILE, age:FILE, env:NAMES, vault:PATH, aws:IDS or gcp:PROJECT/IDS (repeatable)")
+	fs.Var(vars, "var", "configuration variable `KEY=VALUE`, taking precedence over -vars-from (repeatable)")
+	varsFrom := fs.String("vars-from", "", varsFromUsage)
+	fs.Var(env, "env", "environment variable `KEY=VALUE` for every step (repeatable)")
+	fs.Var(inputs, "input", "workflow input `KEY=VALUE` (repeatable)")
+	metricsFile := fs.String("metrics-file", "", "write Prometheus metrics of the run to `file` when it ends, as for a textfile collector")
+	var sandbox runner.SandboxOptions
+	fs.Var((*limitsFlag)(&sandbox.Limits), "limits", "confine every step to `limits` such as cpus=2,memory=4G,pids=1024,network=false")
+	fs.Var((*limitsFlag)(&sandbox.ThirdParty), "third-party-limits", "further confine steps using docker:// images or actions of other owners to `limits`")
+	fs.StringVar(&sandbox.Cgroup, "cgroup", "", "cgroup v2 `directory` to confine steps in (default the runner's own)")
+	netPolicyFile := fs.String("net-policy", "", "send the network requests of steps through a proxy applying the netpolicy `file`")
+	netReport := fs.String("net-report", "", "write the requests made under -net-policy to `file` as JSON")
+	parallel := fs.Int("parallel", 0, "run at most `n` job legs at once (default the number of CPUs)")
+	isolate := fs.Bool("isolate", false, "run each job leg in its own copy of the workspace, as on GitHub")
+	artifactDir := fs.String("artifacts", "", "keep the artifacts jobs upload in `directory` (default a temporary one)")
+	logFormat := fs.String("log-format", logging.FormatText, "print the log as `format` text, or json with one record per line")
+	logFile := fs.String("log-file", "", "also write the log to `file` as JSON, one record per line")
+	auditLog := fs.String("audit-log", "", "append every step that runs to `file`, a hash-chained audit log with secrets redacted")
+	auditBundle := fs.String("audit-bundle", "", "write the run's audit log to `file` as a signed bundle")
+	auditKey := fs.String("audit-key", "", "sign the audit bundle with the PEM private key in `file`")
+	auditSigstore := fs.Bool("audit-sigstore", false, "sign the audit bundle keylessly with Sigstore, with the job's OIDC identity")
+	var redact listFlag
+	fs.Var(&redact, "redact", "also redact text matching `regexp` in the audit log (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *auditBundle != "" && (*auditKey == "") == !*auditSigstore {
+		fmt.Fprintln(os.Stderr, "actions: -audit-bundle needs one of -audit-key and -audit-sigstore")
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if path == "" {
+		var err error
+		if path, err = defaultWorkflowFile(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	wf, err := workflow.ParseFile(path)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	var event map[string]any
+	if *eventFile != "" {
+		data, err := os.ReadFile(*eventFile)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fatalf("failed to parse %s: %v", *eventFile, err)
+		}
+	}
+	var providers []secrets.Provider
+	for _, src := range secretSources {
+		p, err := secrets.Parse(src)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		providers = append(providers, p)
+	}
+	inputValues := map[string]any{}
+	for k, v := range inputs {
+		inputValues[k] = v
+	}
+
+	var netPolicy *netpolicy.Policy
+	if *netPolicyFile != "" {
+		if netPolicy, err = netpolicy.Load(*netPolicyFile); err != nil {
+			return fatalf("%v", err)
+		}
+	} else if *netReport != "" {
+		return fatalf("-net-report needs -net-policy")
+	}
+
+	summary := io.Writer(os.Stdout)
+	sink, err := logging.NewSink(*logFormat, os.Stdout)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *logFormat == logging.FormatJSON {
+		// Keep standard output to log records.
+		summary = os.Stderr
+	}
+	if *logFile != "" {
+		f, err := os.Create(*logFile)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		defer f.Close()
+		sink = logging.Multi(sink, logging.JSON(f))
+	}
+
+	varSet, err := loadVariables(*workspace, "", *varsFrom, "", "")
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	var audit *compliance.Log
+	var auditSigner provenance.Signer
+	if *auditLog != "" || *auditBundle != "" {
+		audit = &compliance.Log{Redactor: &compliance.Redactor{SensitiveInputs: compliance.DefaultSensitiveInputs}}
+		for _, pattern := range redact {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fatalf("invalid -redact: %v", err)
+			}
+			audit.Redactor.Patterns = append(audit.Redactor.Patterns, re)
+		}
+		if *auditLog != "" {
+			f, err := os.OpenFile(*auditLog, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			defer f.Close()
+			audit.W = f
+		}
+		switch {
+		case *auditKey != "":
+			if auditSigner, err = compliance.LoadSigner(*auditKey); err != nil {
+				return fatalf("%v", err)
+			}
+		case *auditSigstore:
+			auditSigner = &sign.Signer{}
+		}
+	}
+
+	var reg *metrics.Registry
+	var m *metrics.Runner
+	if *metricsFile != "" {
+		reg = &metrics.Registry{}
+		m = metrics.NewRunner(reg)
+	}
+
+	// Tracing is configured by the standard OTEL_ variables; a
+	// TRACEPARENT from a calling process makes the run part of its trace.
+	tracer, err := tracing.FromEnv("actions")
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if tp := os.Getenv("TRACEPARENT"); tp != "" && tracer != nil {
+		parent, err := tracing.ParseTraceParent(tp)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		ctx = tracing.WithRemoteParent(ctx, parent)
+	}
+	r := runner.New(runner.Options{
+		Workspace:       *workspace,
+		EventName:       *eventName,
+		Event:           event,
+		Inputs:          inputValues,
+		Env:             env,
+		Secrets:         secretValues,
+		SecretProviders: providers,
+		Vars:            vars,
+		Variables:       varSet,
+		Jobs:            jobs,
+		Metrics:         m,
+		Tracer:          tracer,
+		Sandbox:         sandbox,
+		NetPolicy:       netPolicy,
+		MaxParallel:     *parallel,
+		Isolate:         *isolate,
+		ArtifactDir:     *artifactDir,
+		Compliance:      audit,
+		Log:             sink,
+	})
+	result, err := r.Run(ctx, wf)
+	if flushErr := tracer.Flush(context.Background()); flushErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", flushErr)
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if reg != nil {
+		if err := writeMetricsFile(*metricsFile, reg); err != nil {
+			return fatalf("failed to write metrics: %v", err)
+		}
+	}
+	if *netReport != "" {
+		if err := writeNetReport(*netReport, result); err != nil {
+			return fatalf("failed to write the network report: %v", err)
+		}
+	}
+	if *auditBundle != "" {
+		if err := writeAuditBundle(ctx, *auditBundle, audit, auditSigner); err != nil {
+			return fatalf("failed to write the audit bundle: %v", err)
+		}
+	}
+
+	printRunSummary(summary, result)
+	if result.Conclusion != runner.ResultSuccess {
+		return 1
+	}
+	return 0
+}
+
+// writeAuditBundle signs the run's audit log and writes it to file.
+func writeAuditBundle(ctx context.Context, file string, audit *compliance.Log, signer provenance.Signer) error {
+	b, err := audit.Record().Sign(ctx, signer)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
+
+// writeNetReport writes the network requests of each job leg to file.
+func writeNetReport(file string, result *runner.Result) error {
+	type legRequests struct {
+		Job      string              `json:"job"`
+		Requests []netpolicy.Request `json:"requests"`
+	}
+	report := []legRequests{}
+	for _, job := range result.Jobs {
+		for _, leg := range job.Legs {
+			report = append(report, legRequests{Job: leg.Name, Requests: append([]netpolicy.Request{}, leg.Network...)})
+		}
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
+
+// limitsFlag is a runner.Limits set from its ParseLimits form.
+type limitsFlag runner.Limits
+
+func (f *limitsFlag) String() string {
+	if f == nil || runner.Limits(*f).IsZero() {
+		return ""
+	}
+	return runner.Limits(*f).String()
+}
+
+func (f *limitsFlag) Set(s string) error {
+	l, err := runner.ParseLimits(s)
+	if err != nil {
+		return err
+	}
+	*f = limitsFlag(l)
+	return nil
+}
+
+func printRunSummary(w io.Writer, result *runner.Result) {
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tSTEP\tRESULT\tEXIT CODE\tDURATION")
+	for _, job := range result.Jobs {
+		if len(job.Legs) == 0 {
+			fmt.Fprintf(tw, "%s\t\t%s\t\t\n", job.ID, job.Result)
+		}
+		for _, leg := range job.Legs {
+			fmt.Fprintf(tw, "%s\t\t%s\t\t%s\n", leg.Name, leg.Result, leg.Duration.Round(1e6))
+			printSteps(tw, leg.Steps, "")
+		}
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nWorkflow %s\n", result.Conclusion)
+}
+
+// printSteps lists steps, indenting the steps of composite actions under
+// the step that used them.
+func printSteps(tw io.Writer, steps []*runner.StepResult, indent string) {
+	for _, step := range steps {
+		conclusion := step.Conclusion
+		switch step.Limit {
+		case runner.LimitMemory:
+			conclusion += " (out of memory)"
+		case runner.LimitPIDs:
+			conclusion += " (process limit)"
+		}
+		fmt.Fprintf(tw, "\t%s%s\t%s\t%d\t%s\n", indent, step.Name, conclusion, step.ExitCode, step.Duration.Round(1e6))
+		printSteps(tw, step.Steps, indent+"  ")
+	}
+}
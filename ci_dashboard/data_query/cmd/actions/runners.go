@@ -0,0 +1,208 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/runson"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("runners", "Build a catalog of an organization's runners and resolve the runs-on of jobs against it", runnersCommand)
+}
+
+// defaultRunnersFile is where lint and runners resolve look for the runner
+// catalog.
+const defaultRunnersFile = ".github/runners.yml"
+
+// loadCatalog reads the runner catalog file, or the default one under dir
+// if it exists. It returns nil when there is none.
+func loadCatalog(dir, file string) (*runson.Catalog, error) {
+	if file != "" {
+		return runson.Load(file)
+	}
+	c, err := runson.Load(filepath.Join(dir, defaultRunnersFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// resolvedJob is the resolution of one job's runs-on.
+type resolvedJob struct {
+	Workflow string          `json:"workflow"`
+	Job      string          `json:"job"`
+	Line     int             `json:"line,omitempty"`
+	Matches  []*runson.Match `json:"matches,omitempty"`
+	// Dynamic is set when runs-on is only known while the workflow runs.
+	Dynamic bool `json:"dynamic,omitempty"`
+}
+
+func runnersCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "catalog" && args[0] != "resolve") {
+		fmt.Fprintf(os.Stderr, "Usage: actions runners <catalog|resolve> [flags]\n")
+		return 2
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("runners "+sub, flag.ContinueOnError)
+	fs.Usage = func() {
+		switch sub {
+		case "catalog":
+			fmt.Fprintf(fs.Output(), "Usage: actions runners catalog [flags] <org>\n\n")
+			fmt.Fprintf(fs.Output(), "Prints the organization's self-hosted runners and runner groups as a\n")
+			fmt.Fprintf(fs.Output(), "runner catalog, to save as %s for lint and runners resolve.\n\n", defaultRunnersFile)
+		case "resolve":
+			fmt.Fprintf(fs.Output(), "Usage: actions runners resolve [flags] [workflow.yml ...]\n\n")
+			fmt.Fprintf(fs.Output(), "Lists the runners that can take each job, one line per matrix combination\n")
+			fmt.Fprintf(fs.Output(), "with a different runs-on. Without -runners or -org only GitHub-hosted\n")
+			fmt.Fprintf(fs.Output(), "runners are known. The exit status is 1 if a job matches no runner.\n\n")
+		}
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	var runnersFile, org *string
+	var asJSON *bool
+	if sub == "resolve" {
+		runnersFile = fs.String("runners", "", "runner catalog `file` (default "+defaultRunnersFile+" if it exists)")
+		org = fs.String("org", "", "resolve against the current runners of this `organization` instead of a file")
+		asJSON = fs.Bool("json", false, "print the resolutions as JSON")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if (sub == "catalog" && fs.NArg() != 1) || (sub == "resolve" && *org != "" && *runnersFile != "") {
+		fs.Usage()
+		return 2
+	}
+	ctx := context.Background()
+
+	if sub == "catalog" {
+		c, err := runson.Fetch(ctx, newClient(*workspace, *apiURL, *token), fs.Arg(0))
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(c); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+
+	var catalog *runson.Catalog
+	var err error
+	if *org != "" {
+		catalog, err = runson.Fetch(ctx, newClient(*workspace, *apiURL, *token), *org)
+	} else {
+		catalog, err = loadCatalog(*workspace, *runnersFile)
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if catalog == nil {
+		catalog = &runson.Catalog{}
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	jobs := []resolvedJob{}
+	unmatched := false
+	for _, path := range paths {
+		wf, err := workflow.ParseFile(path)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		for _, id := range wf.JobIDs() {
+			job := wf.Jobs[id]
+			if job.RunsOn == nil {
+				continue
+			}
+			rj := resolvedJob{Workflow: path, Job: id, Line: job.Pos.Line}
+			targets, err := runson.Targets(job)
+			switch {
+			case errors.Is(err, runson.ErrDynamic):
+				rj.Dynamic = true
+			case err != nil:
+				return fatalf("%s: job %s: %v", path, id, err)
+			}
+			for _, t := range targets {
+				m := catalog.Resolve(t)
+				unmatched = unmatched || !m.OK()
+				rj.Matches = append(rj.Matches, m)
+			}
+			jobs = append(jobs, rj)
+		}
+	}
+
+	if *asJSON {
+		if code := encodeJSON(jobs); code != 0 {
+			return code
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "JOB\tRUNS-ON\tRUNNERS")
+		for _, rj := range jobs {
+			name := fmt.Sprintf("%s:%d %s", rj.Workflow, rj.Line, rj.Job)
+			if rj.Dynamic {
+				fmt.Fprintf(tw, "%s\t(dynamic)\t-\n", name)
+			}
+			for _, m := range rj.Matches {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", name, m.Target, runnerNames(m))
+			}
+		}
+		tw.Flush()
+	}
+	if unmatched {
+		return 1
+	}
+	return 0
+}
+
+// runnerNames summarizes the runners of a match, or why there are none.
+func runnerNames(m *runson.Match) string {
+	if !m.OK() {
+		return "none: " + m.Problem()
+	}
+	const maxNames = 5
+	var names []string
+	for i, r := range m.Runners {
+		if i == maxNames {
+			names = append(names, fmt.Sprintf("and %d more", len(m.Runners)-maxNames))
+			break
+		}
+		name := r.Name
+		if name == "" {
+			name = strings.Join(r.Labels, ",")
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
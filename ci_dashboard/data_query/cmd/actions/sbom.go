@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"testingdashboard/m/v2/inventory"
+	"testingdashboard/m/v2/pin"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/sbom"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("sbom", "Write a CycloneDX or SPDX SBOM of the actions workflows use", sbomCommand)
+}
+
+func sbomCommand(args []string) int {
+	fs := flag.NewFlagSet("sbom", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions sbom [flags] [workflow.yml ...]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are listed when no files are given")
+	repoFlag := fs.String("repo", "", "`owner/repo` the SBOM describes (default from $GITHUB_REPOSITORY or the origin remote)")
+	format := fs.String("format", "cyclonedx", "document `format`: cyclonedx or spdx")
+	output := fs.String("o", "", "write the document to `file` instead of stdout")
+	direct := fs.Bool("direct", false, "list only references written in the workflows, without fetching actions")
+	noResolve := fs.Bool("no-resolve", false, "do not resolve tags and branches to commit SHAs")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	inventoryFile := fs.String("inventory", "", "describe the workflows of an inventory `file` written by actions audit; implies -direct")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *inventoryFile != "" && fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	var write func(io.Writer, *sbom.Inventory, sbom.Subject) error
+	switch *format {
+	case "cyclonedx":
+		write = sbom.WriteCycloneDX
+	case "spdx":
+		write = sbom.WriteSPDX
+	default:
+		return fatalf("unknown format %q; want cyclonedx or spdx", *format)
+	}
+
+	var wfs []*workflow.Workflow
+	var crawled *inventory.Inventory
+	if *inventoryFile != "" {
+		var err error
+		if crawled, err = inventory.Load(*inventoryFile); err != nil {
+			return fatalf("%v", err)
+		}
+		wfs = crawled.Workflows()
+		// Local references are to the files of each repository, which are
+		// not at hand.
+		*direct = true
+	} else {
+		paths := fs.Args()
+		if len(paths) == 0 {
+			var err error
+			if paths, err = workflowFiles(*workspace); err != nil {
+				return fatalf("%v", err)
+			}
+		}
+		for _, path := range paths {
+			wf, err := workflow.ParseFile(path)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			wfs = append(wfs, wf)
+		}
+	}
+
+	inst := instance(*workspace)
+	if *apiURL == "" {
+		*apiURL = inst.API
+	}
+	opts := sbom.Options{ServerURL: inst.Server}
+	if !*direct {
+		opts.Fetcher = &resolve.GitFetcher{Workspace: *workspace, ServerURL: inst.Server}
+	}
+	if !*noResolve {
+		opts.Resolver = &pin.GitHubResolver{APIURL: *apiURL, Token: *token}
+	}
+	inv, err := sbom.Collect(context.Background(), wfs, opts)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	// The subject is informational, so an unknown repository is not an
+	// error.
+	subject := sbom.Subject{ServerURL: inst.Server}
+	switch owner, repo, err := currentRepository(*workspace, *repoFlag); {
+	case crawled != nil && *repoFlag == "":
+		// The inventory describes an organization as it was crawled.
+		subject.Name = crawled.Org
+		subject.Version = crawled.GeneratedAt.Format(time.RFC3339)
+	case err == nil:
+		subject.Name = owner + "/" + repo
+	case *repoFlag != "":
+		return fatalf("%v", err)
+	}
+	if head, err := gitLines(*workspace, "rev-parse", "HEAD"); crawled == nil && err == nil && len(head) > 0 {
+		subject.Version = head[0]
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := write(w, inv, subject); err != nil {
+		return fatalf("failed to write SBOM: %v", err)
+	}
+	return 0
+}
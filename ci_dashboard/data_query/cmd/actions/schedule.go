@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"testingdashboard/m/v2/cron"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("schedule", "Preview when scheduled workflows run", scheduleCommand)
+}
+
+type scheduleRow struct {
+	Workflow string      `json:"workflow"`
+	Cron     string      `json:"cron"`
+	Next     []time.Time `json:"next"`
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
+func scheduleCommand(args []string) int {
+	if len(args) == 0 || args[0] != "preview" {
+		fmt.Fprintf(os.Stderr, "Usage: actions schedule preview [flags] [workflow.yml ...]\n")
+		return 2
+	}
+	fs := flag.NewFlagSet("schedule preview", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions schedule preview [flags] [workflow.yml ...]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are previewed when no files are given")
+	n := fs.Int("n", 5, "`number` of upcoming runs to show per schedule")
+	from := fs.String("from", "", "RFC 3339 `time` to start from (default now)")
+	asJSON := fs.Bool("json", false, "print the schedules as JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	start := time.Now()
+	if *from != "" {
+		var err error
+		if start, err = time.Parse(time.RFC3339, *from); err != nil {
+			return fatalf("invalid -from: %v", err)
+		}
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	rows := []scheduleRow{}
+	failed := false
+	for _, path := range paths {
+		wf, err := workflow.ParseFile(path)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		ev := wf.On.Event("schedule")
+		if ev == nil {
+			continue
+		}
+		for _, entry := range ev.Schedules {
+			s, err := cron.Parse(entry.Cron)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				failed = true
+				continue
+			}
+			rows = append(rows, scheduleRow{Workflow: path, Cron: entry.Cron, Next: s.NextRuns(start, *n), Warnings: s.Warnings()})
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		for i, row := range rows {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s: %q\n", row.Workflow, row.Cron)
+			for _, w := range row.Warnings {
+				fmt.Printf("  warning: %s\n", w)
+			}
+			for _, t := range row.Next {
+				fmt.Printf("  %s\n", t.Format("Mon 2006-01-02 15:04 MST"))
+			}
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"testingdashboard/m/v2/schema"
+)
+
+func init() {
+	register("schema", "Print the JSON Schema of workflows or actions, or validate files against it", schemaCommand)
+}
+
+func schemaCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "workflow" && args[0] != "action" && args[0] != "validate") {
+		fmt.Fprintf(os.Stderr, "Usage: actions schema <workflow|action|validate> [flags]\n")
+		return 2
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("schema "+sub, flag.ContinueOnError)
+	fs.Usage = func() {
+		if sub != "validate" {
+			fmt.Fprintf(fs.Output(), "Usage: actions schema %s\n\n", sub)
+			fmt.Fprintf(fs.Output(), "Prints the JSON Schema of %s files.\n", map[string]string{"workflow": "workflow", "action": "action.yml"}[sub])
+			return
+		}
+		fmt.Fprintf(fs.Output(), "Usage: actions schema validate [flags] [file ...]\n\n")
+		fmt.Fprintf(fs.Output(), "Validates files against the schema, by default the workflows of the\n")
+		fmt.Fprintf(fs.Output(), "repository. Files named action.yml or action.yaml are validated as\n")
+		fmt.Fprintf(fs.Output(), "actions. The exit status is 1 if any file does not match.\n\n")
+		fs.PrintDefaults()
+	}
+	var workspace *string
+	var asJSON, asAction *bool
+	if sub == "validate" {
+		workspace = fs.String("C", ".", "repository `directory`")
+		asJSON = fs.Bool("json", false, "print the errors as JSON")
+		asAction = fs.Bool("action", false, "validate every file as an action")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if sub != "validate" {
+		if fs.NArg() != 0 {
+			fs.Usage()
+			return 2
+		}
+		s := schema.Workflow()
+		if sub == "action" {
+			s = schema.Action()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		var err error
+		if files, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+		if len(files) == 0 {
+			return fatalf("no workflows in %s", filepath.Join(*workspace, ".github", "workflows"))
+		}
+	}
+	type fileErrors struct {
+		File   string         `json:"file"`
+		Errors []schema.Error `json:"errors"`
+	}
+	var results []fileErrors
+	failed := false
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		s := schema.Workflow()
+		if base := filepath.Base(file); *asAction || base == "action.yml" || base == "action.yaml" {
+			s = schema.Action()
+		}
+		errs, err := schema.Validate(s, data)
+		if err != nil {
+			return fatalf("%s: %v", file, err)
+		}
+		if len(errs) > 0 {
+			failed = true
+		}
+		results = append(results, fileErrors{File: file, Errors: errs})
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		for _, r := range results {
+			for _, e := range r.Errors {
+				fmt.Printf("%s:%s\n", r.File, e)
+			}
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
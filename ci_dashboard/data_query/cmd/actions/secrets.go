@@ -0,0 +1,283 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/secrets"
+)
+
+func init() {
+	register("secrets", "List, set and delete repository, environment and organization secrets", func(args []string) int {
+		return storeCommand("secrets", secretStore{}, args)
+	})
+	register("vars", "List, set, delete and resolve repository, environment and organization variables", func(args []string) int {
+		if len(args) > 0 && args[0] == "resolve" {
+			return varsResolveCommand(args[1:])
+		}
+		return storeCommand("vars", varStore{}, args)
+	})
+}
+
+// store is the API of secrets or of variables, which the two commands
+// share their flags and subcommands over.
+type store interface {
+	noun() string
+	list(ctx context.Context, c *client.Client, s client.Scope, asJSON bool) error
+	set(ctx context.Context, c *client.Client, s client.Scope, name, value string, opts client.SecretOptions) error
+	remove(ctx context.Context, c *client.Client, s client.Scope, name string) error
+}
+
+func storeCommand(cmd string, st store, args []string) int {
+	if len(args) == 0 || !slices.Contains([]string{"list", "set", "delete"}, args[0]) {
+		subs := "list|set|delete"
+		if cmd == "vars" {
+			subs += "|resolve"
+		}
+		fmt.Fprintf(os.Stderr, "Usage: actions %s <%s> [flags] [NAME ...]\n", cmd, subs)
+		return 2
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet(cmd+" "+sub, flag.ContinueOnError)
+	fs.Usage = func() {
+		switch sub {
+		case "list":
+			fmt.Fprintf(fs.Output(), "Usage: actions %s list [flags]\n\n", cmd)
+		case "set":
+			fmt.Fprintf(fs.Output(), "Usage: actions %s set [flags] NAME [VALUE]\n       actions %s set [flags] -env-file FILE\n\n", cmd, cmd)
+			fmt.Fprintf(fs.Output(), "Without VALUE, the value is read from standard input.\n\n")
+		case "delete":
+			fmt.Fprintf(fs.Output(), "Usage: actions %s delete [flags] NAME ...\n\n", cmd)
+		}
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	org := fs.String("org", "", "manage the "+st.noun()+"s of `organization` instead of a repository")
+	environment := fs.String("env", "", "manage the "+st.noun()+"s of deployment `environment` of the repository")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	var asJSON *bool
+	var envFile, visibility, repos *string
+	switch sub {
+	case "list":
+		asJSON = fs.Bool("json", false, "print the "+st.noun()+"s as JSON")
+	case "set":
+		envFile = fs.String("env-file", "", "set every NAME=VALUE of dotenv `file`")
+		visibility = fs.String("visibility", "private", "which repositories of the organization can use it: all, private or `selected`")
+		repos = fs.String("repos", "", "comma-separated `names` of the repositories that can use it with -visibility selected")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	var scope client.Scope
+	switch {
+	case *org != "" && *environment != "":
+		return fatalf("-org and -env cannot be combined")
+	case *org != "":
+		scope = client.Org(*org)
+	default:
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		scope = client.Repo(owner, repo)
+		if *environment != "" {
+			scope = client.Env(owner, repo, *environment)
+		}
+	}
+	ctx := context.Background()
+	c := newClient(*workspace, *apiURL, *token)
+
+	switch sub {
+	case "list":
+		if fs.NArg() != 0 {
+			fs.Usage()
+			return 2
+		}
+		if err := st.list(ctx, c, scope, *asJSON); err != nil {
+			return fatalf("failed to list %ss of %s: %v", st.noun(), scope, err)
+		}
+		return 0
+	case "delete":
+		if fs.NArg() == 0 {
+			fs.Usage()
+			return 2
+		}
+		code := 0
+		for _, name := range fs.Args() {
+			if err := st.remove(ctx, c, scope, name); err != nil {
+				code = fatalf("failed to delete %s %s: %v", st.noun(), name, err)
+				continue
+			}
+			fmt.Printf("Deleted %s %s from %s\n", st.noun(), name, scope)
+		}
+		return code
+	}
+
+	values, err := setValues(fs, *envFile)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if values == nil {
+		fs.Usage()
+		return 2
+	}
+	opts := client.SecretOptions{Visibility: *visibility}
+	if *repos != "" {
+		if scope.Repo != "" || *visibility != "selected" {
+			return fatalf("-repos needs -org and -visibility selected")
+		}
+		for _, name := range strings.Split(*repos, ",") {
+			id, err := c.RepositoryID(ctx, scope.Owner, strings.TrimSpace(name))
+			if err != nil {
+				return fatalf("failed to look up repository %s: %v", name, err)
+			}
+			opts.Repositories = append(opts.Repositories, id)
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	code := 0
+	for _, name := range names {
+		if err := st.set(ctx, c, scope, name, values[name], opts); err != nil {
+			code = fatalf("failed to set %s %s: %v", st.noun(), name, err)
+			continue
+		}
+		fmt.Printf("Set %s %s for %s\n", st.noun(), name, scope)
+	}
+	return code
+}
+
+// setValues returns the names and values to set: those of envFile, or
+// NAME and VALUE from the arguments, or NAME with the value read from
+// standard input. It returns nil if the arguments name nothing.
+func setValues(fs *flag.FlagSet, envFile string) (map[string]string, error) {
+	if envFile != "" {
+		if fs.NArg() != 0 {
+			return nil, nil
+		}
+		data, err := os.ReadFile(envFile)
+		if err != nil {
+			return nil, err
+		}
+		values, err := secrets.ParseDotenv(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", envFile, err)
+		}
+		return values, nil
+	}
+	switch fs.NArg() {
+	case 1:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		// A single trailing newline comes from echo or the terminal.
+		value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+		return map[string]string{fs.Arg(0): value}, nil
+	case 2:
+		return map[string]string{fs.Arg(0): fs.Arg(1)}, nil
+	}
+	return nil, nil
+}
+
+type secretStore struct{}
+
+func (secretStore) noun() string { return "secret" }
+
+func (secretStore) list(ctx context.Context, c *client.Client, s client.Scope, asJSON bool) error {
+	list, err := c.ListSecrets(ctx, s)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(list)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tUPDATED\tVISIBILITY")
+	for _, sec := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", sec.Name, sec.UpdatedAt.Format(time.DateOnly), orDash(sec.Visibility))
+	}
+	return tw.Flush()
+}
+
+func (secretStore) set(ctx context.Context, c *client.Client, s client.Scope, name, value string, opts client.SecretOptions) error {
+	return c.SetSecret(ctx, s, name, []byte(value), opts)
+}
+
+func (secretStore) remove(ctx context.Context, c *client.Client, s client.Scope, name string) error {
+	return c.DeleteSecret(ctx, s, name)
+}
+
+type varStore struct{}
+
+func (varStore) noun() string { return "variable" }
+
+func (varStore) list(ctx context.Context, c *client.Client, s client.Scope, asJSON bool) error {
+	list, err := c.ListVariables(ctx, s)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(list)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVALUE\tUPDATED\tVISIBILITY")
+	for _, v := range list {
+		value, _, multiline := strings.Cut(v.Value, "\n")
+		if multiline {
+			value += " ..."
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", v.Name, value, v.UpdatedAt.Format(time.DateOnly), orDash(v.Visibility))
+	}
+	return tw.Flush()
+}
+
+func (varStore) set(ctx context.Context, c *client.Client, s client.Scope, name, value string, opts client.SecretOptions) error {
+	return c.SetVariable(ctx, s, name, value, opts)
+}
+
+func (varStore) remove(ctx context.Context, c *client.Client, s client.Scope, name string) error {
+	return c.DeleteVariable(ctx, s, name)
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/events"
+	"testingdashboard/m/v2/triggers"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("triggers", "Report which workflows an event would trigger", triggersCommand)
+}
+
+type triggerRow struct {
+	Workflow  string `json:"workflow"`
+	Triggered bool   `json:"triggered"`
+	Reason    string `json:"reason"`
+}
+
+func triggersCommand(args []string) int {
+	fs := flag.NewFlagSet("triggers", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions triggers [flags] [workflow.yml ...]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are checked when no files are given")
+	eventName := fs.String("e", "push", "`name` of the event")
+	eventFile := fs.String("event-file", "", "JSON `file` with the event payload")
+	ref := fs.String("ref", "", "`ref` the event is for (default the current branch)")
+	activity := fs.String("type", "", "activity `type` of the event, such as opened")
+	base := fs.String("base", "", "compute changed files as the diff from this `rev` to HEAD")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	var files listFlag
+	fs.Var(&files, "file", "changed `path` (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+
+	ev := &triggers.Event{Name: *eventName}
+	if *eventFile != "" {
+		data, err := os.ReadFile(*eventFile)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		payload, err := events.ParseEvent(*eventName, data)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		ev = triggers.FromPayload(payload)
+	}
+	if *ref != "" {
+		ev.Ref = *ref
+		if !strings.HasPrefix(ev.Ref, "refs/") {
+			ev.Ref = "refs/heads/" + ev.Ref
+		}
+	}
+	if ev.Ref == "" {
+		branch, err := gitLines(*workspace, "symbolic-ref", "-q", "--short", "HEAD")
+		if err != nil || len(branch) == 0 {
+			return fatalf("cannot determine the current branch; pass -ref")
+		}
+		ev.Ref = "refs/heads/" + branch[0]
+	}
+	if *activity != "" {
+		ev.Action = *activity
+	}
+	switch {
+	case len(files) > 0:
+		ev.Changed = files
+	case *base != "":
+		changed, err := gitLines(*workspace, "diff", "--name-only", *base+"...HEAD")
+		if err != nil {
+			return fatalf("failed to diff against %s: %v", *base, err)
+		}
+		ev.Changed = changed
+	}
+
+	var rows []triggerRow
+	for _, path := range paths {
+		wf, err := workflow.ParseFile(path)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		res, err := triggers.Matches(wf, ev)
+		if err != nil {
+			return fatalf("%s: %v", path, err)
+		}
+		rows = append(rows, triggerRow{Workflow: path, Triggered: res.Triggered, Reason: res.Reason})
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return fatalf("%v", err)
+		}
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "WORKFLOW\tTRIGGERED\tREASON")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%t\t%s\n", row.Workflow, row.Triggered, row.Reason)
+	}
+	tw.Flush()
+	return 0
+}
+
+// gitLines runs git in dir and returns the non-empty lines it prints.
+func gitLines(dir string, args ...string) ([]string, error) {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/runner"
+	"testingdashboard/m/v2/secrets"
+	"testingdashboard/m/v2/ui"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	register("ui", "Follow a GitHub or local run in an interactive terminal view", uiCommand)
+}
+
+func uiCommand(args []string) int {
+	fs := flag.NewFlagSet("ui", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions ui [flags] <run-id>\n       actions ui -local [flags] [workflow.yml]\n\n")
+		fmt.Fprintf(fs.Output(), "Shows the jobs and steps of the run as a tree with the log of the selected\n")
+		fmt.Fprintf(fs.Output(), "one. Keys: arrows or j/k to move, left/right to fold, PgUp/PgDn, Home and\n")
+		fmt.Fprintf(fs.Output(), "End to scroll the log, c to cancel the run, r to run the selected job or\n")
+		fmt.Fprintf(fs.Output(), "the whole run again and q to quit.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository or workspace `directory`")
+	local := fs.Bool("local", false, "run the workflow with the local runner instead of following a run on GitHub")
+	repoFlag := fs.String("repo", "", "`owner/repo` of the run (default $GITHUB_REPOSITORY or the origin remote)")
+	interval := fs.Duration("interval", 5*time.Second, "how often to poll the run")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	eventName := fs.String("e", "push", "`name` of the triggering event, with -local")
+	var jobs listFlag
+	fs.Var(&jobs, "j", "run only this `job` and the jobs it needs, with -local (repeatable)")
+	secretValues, vars, env, inputs := keyValueFlag{}, keyValueFlag{}, keyValueFlag{}, keyValueFlag{}
+	fs.Var(secretValues, "s", "secret `KEY=VALUE`, with -local (repeatable)")
+	var secretSources listFlag
+	fs.Var(&secretSources, "secrets-from", "load secrets from `source` as actions run does, with -local (repeatable)")
+	fs.Var(vars, "var", "configuration variable `KEY=VALUE`, with -local (repeatable)")
+	varsFrom := fs.String("vars-from", "", "resolve the vars context from `source` as actions run does, with -local")
+	fs.Var(env, "env", "environment variable `KEY=VALUE` for every step, with -local (repeatable)")
+	fs.Var(inputs, "input", "workflow input `KEY=VALUE`, with -local (repeatable)")
+	parallel := fs.Int("parallel", 0, "run at most `n` job legs at once, with -local (default the number of CPUs)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (*local && fs.NArg() > 1) || (!*local && fs.NArg() != 1) {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var src ui.Source
+	if *local {
+		path := fs.Arg(0)
+		if path == "" {
+			var err error
+			if path, err = defaultWorkflowFile(*workspace); err != nil {
+				return fatalf("%v", err)
+			}
+		}
+		wf, err := workflow.ParseFile(path)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		var providers []secrets.Provider
+		for _, s := range secretSources {
+			p, err := secrets.Parse(s)
+			if err != nil {
+				return fatalf("%v", err)
+			}
+			providers = append(providers, p)
+		}
+		varSet, err := loadVariables(*workspace, *repoFlag, *varsFrom, *apiURL, *token)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		inputValues := map[string]any{}
+		for k, v := range inputs {
+			inputValues[k] = v
+		}
+		l := &ui.Local{Workflow: wf, Options: runner.Options{
+			Workspace:       *workspace,
+			EventName:       *eventName,
+			Inputs:          inputValues,
+			Env:             env,
+			Secrets:         secretValues,
+			SecretProviders: providers,
+			Vars:            vars,
+			Variables:       varSet,
+			Jobs:            jobs,
+			MaxParallel:     *parallel,
+		}}
+		if err := l.Start(ctx); err != nil {
+			return fatalf("%v", err)
+		}
+		// Quitting cancels the run, which cleans up after itself.
+		defer l.Stop()
+		src = l
+	} else {
+		id, err := strconv.ParseInt(strings.TrimPrefix(fs.Arg(0), "#"), 10, 64)
+		if err != nil {
+			return fatalf("invalid run id %q", fs.Arg(0))
+		}
+		owner, repo, err := currentRepository(*workspace, *repoFlag)
+		if err != nil {
+			return fatalf("%v", err)
+		}
+		g := &ui.GitHub{Client: newClient(*workspace, *apiURL, *token), Owner: owner, Repo: repo, RunID: id, Interval: *interval}
+		if err := g.Start(ctx); err != nil {
+			return fatalf("%v", err)
+		}
+		src = g
+	}
+	if err := ui.Run(ctx, src, os.Stdin, os.Stdout); err != nil {
+		return fatalf("%v", err)
+	}
+	return 0
+}
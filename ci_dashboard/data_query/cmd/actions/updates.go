@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/updates"
+)
+
+func init() {
+	register("updates", "Report newer versions of the actions workflows use", updatesCommand)
+}
+
+type updateRow struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Uses    string `json:"uses"`
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+	To      string `json:"to"`
+	Major   bool   `json:"major"`
+}
+
+func updatesCommand(args []string) int {
+	fs := flag.NewFlagSet("updates", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions updates [flags] [file.yml ...]\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory` whose workflows are checked when no files are given")
+	asJSON := fs.Bool("json", false, "print the updates as JSON")
+	write := fs.Bool("w", false, "rewrite the files to use the newer versions")
+	sameMajor := fs.Bool("same-major", false, "only consider versions with the current major version")
+	prerelease := fs.Bool("prerelease", false, "consider prerelease versions")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		var err error
+		if paths, err = workflowFiles(*workspace); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	usages, err := updates.Inventory(paths)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *apiURL == "" {
+		*apiURL = instance(*workspace).API
+	}
+	src := &updates.GitHubSource{APIURL: *apiURL, Token: *token}
+	ups, err := updates.Check(context.Background(), src, usages, updates.Options{SameMajor: *sameMajor, Prerelease: *prerelease})
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	rows := []updateRow{}
+	for _, u := range ups {
+		rows = append(rows, updateRow{
+			Path:    u.Path,
+			Line:    u.Ref.Line,
+			Uses:    u.Ref.Uses.String(),
+			Current: u.Current.String(),
+			Latest:  u.Latest.String(),
+			To:      u.To,
+			Major:   u.Major(),
+		})
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return fatalf("%v", err)
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "LOCATION\tUSES\tCURRENT\tLATEST")
+		for _, r := range rows {
+			latest := r.Latest
+			if r.Major {
+				latest += " (major)"
+			}
+			fmt.Fprintf(tw, "%s:%d\t%s\t%s\t%s\n", r.Path, r.Line, r.Uses, r.Current, latest)
+		}
+		tw.Flush()
+	}
+	if *write {
+		if err := updates.Apply(ups); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"testingdashboard/m/v2/variables"
+)
+
+// varsResolveCommand prints the variables a job sees, with the level each
+// comes from.
+func varsResolveCommand(args []string) int {
+	fs := flag.NewFlagSet("vars resolve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions vars resolve [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Prints the vars context of a job in -env: the repository's variables over\n")
+		fmt.Fprintf(fs.Output(), "the organization's, under the environment's. With -save the variables of\n")
+		fmt.Fprintf(fs.Output(), "every level are written to a file for -vars-from of run, plan and lint.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` (default $GITHUB_REPOSITORY or the origin remote)")
+	environment := fs.String("env", "", "resolve for a job in deployment `environment`")
+	from := fs.String("from", "", "read the variables from `file` instead of the repository")
+	save := fs.String("save", "", "write the variables of every level to `file` as YAML")
+	asJSON := fs.Bool("json", false, "print the variables as JSON")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	var set *variables.Set
+	var err error
+	if *from != "" {
+		set, err = variables.Load(*from)
+	} else {
+		set, err = fetchVariables(*workspace, *repoFlag, *apiURL, *token)
+	}
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if *save != "" {
+		if err := set.Save(*save); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+	if *environment != "" && set.Environments != nil && set.Environments[*environment] == nil {
+		fmt.Fprintf(os.Stderr, "actions: the repository has no environment %s with variables\n", *environment)
+	}
+
+	resolved := set.Resolve(*environment)
+	if *asJSON {
+		return encodeJSON(resolved)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVALUE\tLEVEL\tOVERRIDES")
+	for _, v := range resolved {
+		value, _, multiline := strings.Cut(v.Value, "\n")
+		if multiline {
+			value += " ..."
+		}
+		overrides := make([]string, len(v.Overrides))
+		for i, l := range v.Overrides {
+			overrides[i] = string(l)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", v.Name, value, v.Level, orDash(strings.Join(overrides, ", ")))
+	}
+	tw.Flush()
+	return 0
+}
+
+// varsFromUsage documents -vars-from for the commands that take it.
+const varsFromUsage = "resolve the vars context from `source`: a file written by actions vars resolve -save, or github for the repository's variables"
+
+// loadVariables returns the variables of a -vars-from source, or nil
+// without one. Those of github are the repository's of repoFlag.
+func loadVariables(workspace, repoFlag, source, apiURL, token string) (*variables.Set, error) {
+	switch source {
+	case "":
+		return nil, nil
+	case "github":
+		return fetchVariables(workspace, repoFlag, apiURL, token)
+	}
+	return variables.Load(source)
+}
+
+func fetchVariables(workspace, repoFlag, apiURL, token string) (*variables.Set, error) {
+	owner, repo, err := currentRepository(workspace, repoFlag)
+	if err != nil {
+		return nil, err
+	}
+	return variables.Fetch(context.Background(), newClient(workspace, apiURL, token), owner, repo, variables.FetchOptions{})
+}
@@ -0,0 +1,297 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/metrics"
+)
+
+func init() {
+	register("watch", "Follow a workflow run, its jobs and logs until it completes", watchCommand)
+}
+
+// watchEvent is one status transition or log line, printed as a line of
+// JSON with -json.
+type watchEvent struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"` // run, job, step or log
+	Run        int64     `json:"run"`
+	Title      string    `json:"title,omitempty"`
+	Job        string    `json:"job,omitempty"`
+	Step       string    `json:"step,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Conclusion string    `json:"conclusion,omitempty"`
+	Duration   float64   `json:"duration_seconds,omitempty"`
+	Line       string    `json:"line,omitempty"`
+	URL        string    `json:"url,omitempty"`
+}
+
+// conclusionCodes maps run conclusions to exit codes; 2 is left for usage
+// errors.
+var conclusionCodes = map[string]int{
+	"success":         0,
+	"neutral":         0,
+	"skipped":         0,
+	"failure":         1,
+	"cancelled":       3,
+	"timed_out":       4,
+	"action_required": 5,
+	"startup_failure": 6,
+	"stale":           7,
+}
+
+func watchCommand(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: actions watch [flags] <run-id>\n\n")
+		fmt.Fprintf(fs.Output(), "Exits 0 if the run succeeds, 1 if it fails, 3 if it is cancelled,\n4 if it times out and 5 if it needs approval.\n\n")
+		fs.PrintDefaults()
+	}
+	workspace := fs.String("C", ".", "repository `directory`")
+	repoFlag := fs.String("repo", "", "`owner/repo` of the run (default $GITHUB_REPOSITORY or the origin remote)")
+	interval := fs.Duration("interval", 5*time.Second, "how often to poll the run")
+	logs := fs.Bool("logs", true, "print job logs as they become available")
+	asJSON := fs.Bool("json", false, "print one JSON object per line for each transition and log line")
+	metricsAddr := fs.String("metrics", "", "serve Prometheus metrics of the API client on `address`/metrics")
+	apiURL := fs.String("api-url", "", "GitHub API `url` (default $GITHUB_API_URL or that of the origin remote)")
+	token := fs.String("token", "", "GitHub `token` (default $GITHUB_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(fs.Arg(0), "#"), 10, 64)
+	if err != nil {
+		return fatalf("invalid run id %q", fs.Arg(0))
+	}
+	owner, repo, err := currentRepository(*workspace, *repoFlag)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+
+	c := newClient(*workspace, *apiURL, *token)
+	if *metricsAddr != "" {
+		reg := &metrics.Registry{}
+		c.Metrics = metrics.NewClient(reg)
+		if err := serveMetrics(*metricsAddr, reg); err != nil {
+			return fatalf("%v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return followRun(ctx, c, owner, repo, id, *interval, *logs, *asJSON)
+}
+
+// followRun prints the transitions of a run, and its logs if asked, until
+// it completes, and returns the exit code of its conclusion.
+func followRun(ctx context.Context, c *client.Client, owner, repo string, id int64, interval time.Duration, logs, asJSON bool) int {
+	w := &watcher{
+		c:     c,
+		owner: owner,
+		repo:  repo,
+		id:    id,
+		logs:  logs,
+		seen:  map[string]string{},
+		lines: map[int64]int{},
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		w.emit = func(e watchEvent) {
+			if e.Time.IsZero() {
+				e.Time = time.Now().UTC()
+			}
+			enc.Encode(e)
+		}
+	} else {
+		w.emit = printWatchEvent
+	}
+	run, err := w.watch(ctx, interval)
+	if err != nil {
+		return fatalf("%v", err)
+	}
+	if code, ok := conclusionCodes[run.Conclusion]; ok {
+		return code
+	}
+	return 1
+}
+
+type watcher struct {
+	c           *client.Client
+	owner, repo string
+	id          int64
+	logs        bool
+	emit        func(watchEvent)
+
+	// seen holds the last reported state of the run, each job and each
+	// step, keyed by kind and ID.
+	seen map[string]string
+	// lines counts the log lines already printed for each job.
+	lines map[int64]int
+}
+
+// watch polls until the run completes and returns its final state.
+func (w *watcher) watch(ctx context.Context, interval time.Duration) (*client.Run, error) {
+	for {
+		run, err := w.c.GetRun(ctx, w.owner, w.repo, w.id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get run %d: %w", w.id, err)
+		}
+		title := run.DisplayTitle
+		if title == "" {
+			title = run.Name
+		}
+		// Report completion after the final state of the jobs.
+		if !run.Completed() {
+			w.run(run, title)
+		}
+		jobs, err := w.c.ListJobs(ctx, w.owner, w.repo, w.id, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs of run %d: %w", w.id, err)
+		}
+		for _, job := range jobs {
+			if err := w.job(ctx, job); err != nil {
+				return nil, err
+			}
+		}
+		if run.Completed() {
+			w.run(run, title)
+			return run, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (w *watcher) run(run *client.Run, title string) {
+	if !w.changed(fmt.Sprintf("run/%d", run.RunAttempt), run.Status, run.Conclusion) {
+		return
+	}
+	e := watchEvent{Type: "run", Run: run.ID, Title: title, Status: run.Status, Conclusion: run.Conclusion, URL: run.HTMLURL}
+	if run.Completed() {
+		e.Duration = run.Duration().Seconds()
+	}
+	w.emit(e)
+}
+
+// job reports the transitions of a job and its steps, and any new log
+// lines once a step has finished.
+func (w *watcher) job(ctx context.Context, job *client.Job) error {
+	progressed := false
+	for _, step := range job.Steps {
+		if w.changed(fmt.Sprintf("step/%d/%d", job.ID, step.Number), step.Status, step.Conclusion) {
+			w.emit(watchEvent{Type: "step", Run: w.id, Job: job.Name, Step: step.Name, Status: step.Status, Conclusion: step.Conclusion, Duration: step.Duration().Seconds()})
+			progressed = progressed || step.Status == "completed"
+		}
+	}
+	if w.changed(fmt.Sprintf("job/%d", job.ID), job.Status, job.Conclusion) {
+		w.emit(watchEvent{Type: "job", Run: w.id, Job: job.Name, Status: job.Status, Conclusion: job.Conclusion, Duration: job.Duration().Seconds(), URL: job.HTMLURL})
+		progressed = progressed || job.Status == "completed"
+	}
+	if w.logs && progressed {
+		return w.tail(ctx, job)
+	}
+	return nil
+}
+
+// tail prints the lines of the job's log that have not been printed yet.
+// Logs of running jobs are often not served yet, which is not an error.
+func (w *watcher) tail(ctx context.Context, job *client.Job) error {
+	resp, err := w.c.DownloadJobLogs(ctx, w.owner, w.repo, job.ID)
+	if client.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		if job.Status != "completed" {
+			return nil
+		}
+		return fmt.Errorf("failed to download logs of job %s: %w", job.Name, err)
+	}
+	defer resp.Body.Close()
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 1<<20)
+	n := 0
+	for sc.Scan() {
+		n++
+		if n <= w.lines[job.ID] {
+			continue
+		}
+		e := watchEvent{Type: "log", Run: w.id, Job: job.Name, Line: sc.Text()}
+		// Each line starts with an RFC 3339 timestamp.
+		if ts, rest, ok := strings.Cut(e.Line, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, strings.TrimPrefix(ts, "\ufeff")); err == nil {
+				e.Time, e.Line = t, rest
+			}
+		}
+		w.emit(e)
+	}
+	w.lines[job.ID] = n
+	return sc.Err()
+}
+
+// changed records the state of key and reports whether it differs from the
+// last one seen.
+func (w *watcher) changed(key, status, conclusion string) bool {
+	state := status + "/" + conclusion
+	if w.seen[key] == state {
+		return false
+	}
+	w.seen[key] = state
+	return true
+}
+
+func printWatchEvent(e watchEvent) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	state := e.Status
+	if e.Conclusion != "" {
+		state = e.Conclusion
+	}
+	if e.Duration > 0 {
+		state += " in " + (time.Duration(e.Duration * float64(time.Second))).Round(time.Second).String()
+	}
+	stamp := e.Time.Local().Format("15:04:05")
+	switch e.Type {
+	case "run":
+		fmt.Printf("%s  run %d %s: %s\n", stamp, e.Run, e.Title, state)
+		if e.Status == "completed" && e.URL != "" {
+			fmt.Printf("%s  %s\n", stamp, e.URL)
+		}
+	case "job":
+		fmt.Printf("%s  %s: %s\n", stamp, e.Job, state)
+	case "step":
+		fmt.Printf("%s  %s / %s: %s\n", stamp, e.Job, e.Step, state)
+	case "log":
+		fmt.Printf("%s  %s | %s\n", stamp, e.Job, e.Line)
+	}
+}
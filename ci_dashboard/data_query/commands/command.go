@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package commands parses the workflow commands (lines starting with "::")
+// that steps print to talk to the runner.
+package commands
+
+import (
+	"strings"
+)
+
+// Command is a parsed "::name k=v,k=v::message" line.
+type Command struct {
+	Name    string
+	Props   map[string]string
+	Message string
+}
+
+// Parse parses a workflow command. Leading whitespace is allowed.
+func Parse(line string) (*Command, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(trimmed, "::") {
+		return nil, false
+	}
+	rest := trimmed[2:]
+	end := strings.Index(rest, "::")
+	if end < 0 {
+		return nil, false
+	}
+	head, msg := rest[:end], UnescapeData(rest[end+2:])
+	name, propStr, _ := strings.Cut(head, " ")
+	if name == "" {
+		return nil, false
+	}
+	props := map[string]string{}
+	for _, kv := range strings.Split(propStr, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(kv), "=")
+		if found && k != "" {
+			props[k] = UnescapeProperty(v)
+		}
+	}
+	return &Command{Name: name, Props: props, Message: msg}, true
+}
+
+// UnescapeData reverses the escaping of a command message.
+func UnescapeData(s string) string {
+	return strings.NewReplacer("%0D", "\r", "%0A", "\n", "%25", "%").Replace(s)
+}
+
+// UnescapeProperty reverses the escaping of a command property value.
+func UnescapeProperty(s string) string {
+	return strings.NewReplacer("%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",", "%25", "%").Replace(s)
+}
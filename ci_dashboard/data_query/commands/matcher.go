@@ -0,0 +1,325 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Matcher is a problem matcher: patterns that turn lines of output into
+// annotations, as registered with ::add-matcher::.
+type Matcher struct {
+	Owner string `json:"owner"`
+	// Severity is the level of problems whose patterns capture none:
+	// error, warning or notice. Empty means error.
+	Severity string    `json:"severity,omitempty"`
+	Pattern  []Pattern `json:"pattern"`
+
+	regexps []*regexp.Regexp
+	// state holds, for each pattern but the last, the captures of the
+	// run of lines matched so far, or nil.
+	state []map[string]string
+}
+
+// Pattern matches one line of a problem. The fields other than Regexp and
+// Loop are the numbers of the capture groups holding each value; zero
+// means the pattern does not capture it.
+type Pattern struct {
+	Regexp    string `json:"regexp"`
+	FromPath  int    `json:"fromPath,omitempty"`
+	File      int    `json:"file,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Severity  int    `json:"severity,omitempty"`
+	Code      int    `json:"code,omitempty"`
+	Message   int    `json:"message,omitempty"`
+	// Loop repeats the last pattern, reporting a problem for each line
+	// it matches after the ones before it.
+	Loop bool `json:"loop,omitempty"`
+}
+
+// ParseMatchers parses a problem matcher file.
+func ParseMatchers(data []byte) ([]*Matcher, error) {
+	var file struct {
+		ProblemMatcher []*Matcher `json:"problemMatcher"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for _, m := range file.ProblemMatcher {
+		if err := m.compile(); err != nil {
+			if m.Owner == "" {
+				return nil, err
+			}
+			return nil, fmt.Errorf("matcher %s: %w", m.Owner, err)
+		}
+	}
+	return file.ProblemMatcher, nil
+}
+
+func (m *Matcher) compile() error {
+	if m.Owner == "" {
+		return errors.New("owner is required")
+	}
+	switch strings.ToLower(m.Severity) {
+	case "", "error", "warning", "notice":
+	default:
+		return fmt.Errorf("unknown severity %q", m.Severity)
+	}
+	if len(m.Pattern) == 0 {
+		return errors.New("at least one pattern is required")
+	}
+	for i, p := range m.Pattern {
+		last := i == len(m.Pattern)-1
+		if p.Regexp == "" {
+			return fmt.Errorf("pattern %d: regexp is required", i+1)
+		}
+		re, err := regexp.Compile(p.Regexp)
+		if err != nil {
+			return fmt.Errorf("pattern %d: %w", i+1, err)
+		}
+		for _, group := range []int{p.FromPath, p.File, p.Line, p.EndLine, p.Column, p.EndColumn, p.Severity, p.Code, p.Message} {
+			if group < 0 || group > re.NumSubexp() {
+				return fmt.Errorf("pattern %d: the regexp has no group %d", i+1, group)
+			}
+		}
+		if p.Loop {
+			switch {
+			case !last || len(m.Pattern) == 1:
+				return fmt.Errorf("pattern %d: only the last of several patterns may loop", i+1)
+			case p.Message == 0:
+				return fmt.Errorf("pattern %d: a looping pattern must capture the message", i+1)
+			}
+		}
+		m.regexps = append(m.regexps, re)
+	}
+	if !slices.ContainsFunc(m.Pattern, func(p Pattern) bool { return p.Message != 0 }) {
+		return errors.New("no pattern captures the message")
+	}
+	m.state = make([]map[string]string, len(m.Pattern)-1)
+	return nil
+}
+
+// reset forgets any partly matched problem.
+func (m *Matcher) reset() {
+	clear(m.state)
+}
+
+// match feeds line to the matcher, returning the captures of a problem it
+// completes. A multi-line problem is matched one pattern per line; patterns
+// are tried last to first so one line never advances a problem twice.
+func (m *Matcher) match(line string) map[string]string {
+	if len(m.Pattern) == 1 {
+		groups := m.regexps[0].FindStringSubmatch(line)
+		if groups == nil {
+			return nil
+		}
+		return captures(nil, m.Pattern[0], groups)
+	}
+	for i := len(m.Pattern) - 1; i >= 0; i-- {
+		var running map[string]string
+		if i > 0 {
+			if running = m.state[i-1]; running == nil {
+				continue
+			}
+		}
+		last := i == len(m.Pattern)-1
+		groups := m.regexps[i].FindStringSubmatch(line)
+		switch {
+		case groups == nil && last:
+			m.state[i-1] = nil
+		case groups == nil:
+			m.state[i] = nil
+		case last:
+			m.reset()
+			if m.Pattern[i].Loop {
+				m.state[i-1] = running
+			}
+			return captures(running, m.Pattern[i], groups)
+		default:
+			m.state[i] = captures(running, m.Pattern[i], groups)
+		}
+	}
+	return nil
+}
+
+// captures adds what p captured in groups to a copy of running.
+func captures(running map[string]string, p Pattern, groups []string) map[string]string {
+	c := map[string]string{}
+	for k, v := range running {
+		c[k] = v
+	}
+	for k, group := range map[string]int{
+		"fromPath": p.FromPath, "file": p.File, "line": p.Line, "endLine": p.EndLine,
+		"col": p.Column, "endColumn": p.EndColumn, "severity": p.Severity, "code": p.Code, "message": p.Message,
+	} {
+		if group > 0 && groups[group] != "" {
+			c[k] = groups[group]
+		}
+	}
+	return c
+}
+
+// Matchers are the problem matchers registered in a job. It is safe for
+// concurrent use.
+type Matchers struct {
+	// Workspace is the workspace as steps see it. Relative matcher files
+	// and problem files are relative to it, and problem files outside it
+	// are dropped.
+	Workspace string
+	// HostPath translates a path steps see to the path on this machine,
+	// for steps in a container. Nil means the paths are the same.
+	HostPath func(string) string
+
+	mu sync.Mutex
+	// list is newest first, the order matchers are tried in.
+	list []*Matcher
+}
+
+// Load registers the matchers in the file name, replacing any with the
+// same owners.
+func (ms *Matchers) Load(name string) error {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(ms.Workspace, name)
+	}
+	data, err := os.ReadFile(ms.host(name))
+	if err != nil {
+		return err
+	}
+	list, err := ParseMatchers(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	ms.Add(list...)
+	return nil
+}
+
+// Add registers matchers, replacing any with the same owners.
+func (ms *Matchers) Add(list ...*Matcher) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, m := range list {
+		ms.remove(m.Owner)
+		m.reset()
+		ms.list = append([]*Matcher{m}, ms.list...)
+	}
+}
+
+// Remove unregisters the matcher owner.
+func (ms *Matchers) Remove(owner string) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.remove(owner)
+}
+
+func (ms *Matchers) remove(owner string) {
+	for i, m := range ms.list {
+		if strings.EqualFold(m.Owner, owner) {
+			ms.list = append(ms.list[:i], ms.list[i+1:]...)
+			return
+		}
+	}
+}
+
+// Match feeds a line of output to the matchers in turn. The first to
+// complete a problem wins and the others start over.
+func (ms *Matchers) Match(line string) (*Annotation, bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	for _, m := range ms.list {
+		c := m.match(line)
+		if c == nil {
+			continue
+		}
+		for _, other := range ms.list {
+			if other != m {
+				other.reset()
+			}
+		}
+		if a, ok := ms.annotation(m, c); ok {
+			return a, true
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// annotation turns captured values into an annotation, or reports false
+// when they do not make one.
+func (ms *Matchers) annotation(m *Matcher, c map[string]string) (*Annotation, bool) {
+	if strings.TrimSpace(c["message"]) == "" {
+		return nil, false
+	}
+	level := strings.ToLower(c["severity"])
+	if level == "" {
+		level = strings.ToLower(m.Severity)
+	}
+	switch level {
+	case "":
+		level = "error"
+	case "error", "warning", "notice":
+	default:
+		return nil, false
+	}
+	a := &Annotation{Level: level, Message: c["message"], Code: c["code"], File: ms.file(c["file"], c["fromPath"])}
+	a.Line, _ = strconv.Atoi(c["line"])
+	a.EndLine, _ = strconv.Atoi(c["endLine"])
+	a.Column, _ = strconv.Atoi(c["col"])
+	a.EndColumn, _ = strconv.Atoi(c["endColumn"])
+	return a, true
+}
+
+// file returns the path of a problem file relative to the workspace, or ""
+// if it does not exist there. Relative files are relative to the
+// directory of fromPath when given, then to the workspace.
+func (ms *Matchers) file(file, fromPath string) string {
+	if file == "" {
+		return ""
+	}
+	file = filepath.FromSlash(file)
+	if !filepath.IsAbs(file) && fromPath != "" {
+		file = filepath.Join(filepath.Dir(filepath.FromSlash(fromPath)), file)
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(ms.Workspace, file)
+	}
+	file = filepath.Clean(file)
+	if _, err := os.Stat(ms.host(file)); err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(ms.Workspace, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (ms *Matchers) host(name string) string {
+	if ms.HostPath == nil {
+		return name
+	}
+	return ms.HostPath(name)
+}
@@ -0,0 +1,212 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Annotation is an error, warning or notice reported by a step.
+type Annotation struct {
+	Level   string
+	Message string
+	Title   string
+	File    string
+	// Code is the rule or error code a problem matcher captured.
+	Code string
+	// Line, EndLine, Column and EndColumn are zero when not given.
+	Line, EndLine, Column, EndColumn int
+}
+
+// Line is one processed line of output.
+type Line struct {
+	// Command is the parsed command, or nil for plain output and for
+	// commands while processing is stopped.
+	Command *Command
+	// Text is the line as the log shows it, with secrets masked.
+	Text string
+	// Hidden is set for lines the log does not show, such as set-output.
+	Hidden bool
+}
+
+// Processor applies workflow commands one line at a time.
+type Processor struct {
+	// Masker receives add-mask values and masks every line.
+	Masker *Masker
+	// Outputs and State collect set-output and save-state values.
+	Outputs map[string]string
+	State   map[string]string
+	// Annotations collects error, warning and notice commands and the
+	// problems Matchers find.
+	Annotations []Annotation
+	// Matchers are the problem matchers applied to plain output.
+	Matchers *Matchers
+	// Debug shows debug messages.
+	Debug bool
+
+	// stopToken is set while commands are disabled by ::stop-commands::.
+	stopToken string
+}
+
+// NewProcessor returns a processor with its own masker and maps.
+func NewProcessor() *Processor {
+	return &Processor{Masker: &Masker{}, Matchers: &Matchers{}, Outputs: map[string]string{}, State: map[string]string{}}
+}
+
+// Process handles a line without its trailing newline.
+func (p *Processor) Process(line string) Line {
+	cmd, ok := Parse(line)
+	if !ok {
+		return p.output(line)
+	}
+	if p.stopToken != "" {
+		if cmd.Name == p.stopToken {
+			p.stopToken = ""
+			return Line{Command: cmd, Hidden: true}
+		}
+		return p.output(line)
+	}
+
+	msg := cmd.Message
+	switch cmd.Name {
+	case "set-output":
+		p.Outputs[cmd.Props["name"]] = msg
+	case "save-state":
+		p.State[cmd.Props["name"]] = msg
+	case "add-mask":
+		p.Masker.Add(msg)
+	case "stop-commands":
+		p.stopToken = msg
+	case "debug":
+		l := p.text(cmd, "##[debug]"+msg)
+		l.Hidden = !p.Debug
+		return l
+	case "notice", "warning", "error":
+		a := Annotation{Level: cmd.Name, Message: msg, Title: cmd.Props["title"], File: cmd.Props["file"]}
+		a.Line, _ = strconv.Atoi(cmd.Props["line"])
+		a.EndLine, _ = strconv.Atoi(cmd.Props["endLine"])
+		a.Column, _ = strconv.Atoi(cmd.Props["col"])
+		a.EndColumn, _ = strconv.Atoi(cmd.Props["endColumn"])
+		p.Annotations = append(p.Annotations, a)
+		return p.text(cmd, formatAnnotation(cmd.Name, cmd.Props, msg))
+	case "group":
+		return p.text(cmd, "##[group]"+msg)
+	case "endgroup":
+		return p.text(cmd, "##[endgroup]")
+	case "add-matcher":
+		if p.Matchers == nil {
+			break
+		}
+		if err := p.Matchers.Load(msg); err != nil {
+			return p.text(cmd, fmt.Sprintf("Error: failed to add problem matcher: %v", err))
+		}
+	case "remove-matcher":
+		if p.Matchers != nil {
+			p.Matchers.Remove(cmd.Props["owner"])
+		}
+	case "echo":
+		// Command echoing only affects the hosted log viewer.
+	case "set-env", "add-path":
+		return p.text(cmd, fmt.Sprintf("Error: the %s command is disabled; use GITHUB_ENV or GITHUB_PATH instead", cmd.Name))
+	default:
+		return p.output(line)
+	}
+	return Line{Command: cmd, Hidden: true}
+}
+
+// output handles a line that is not a command, which a problem matcher
+// may turn into an annotation.
+func (p *Processor) output(line string) Line {
+	if p.Matchers == nil {
+		return p.text(nil, line)
+	}
+	a, ok := p.Matchers.Match(line)
+	if !ok {
+		return p.text(nil, line)
+	}
+	a.Message = p.Masker.Mask(a.Message)
+	p.Annotations = append(p.Annotations, *a)
+	props := map[string]string{}
+	for k, v := range map[string]string{"code": a.Code, "file": a.File} {
+		if v != "" {
+			props[k] = v
+		}
+	}
+	for k, v := range map[string]int{"line": a.Line, "endLine": a.EndLine, "col": a.Column, "endColumn": a.EndColumn} {
+		if v != 0 {
+			props[k] = strconv.Itoa(v)
+		}
+	}
+	return p.text(nil, formatAnnotation(a.Level, props, a.Message))
+}
+
+func (p *Processor) text(cmd *Command, s string) Line {
+	return Line{Command: cmd, Text: p.Masker.Mask(s)}
+}
+
+// formatAnnotation renders an annotation the way the hosted log does.
+func formatAnnotation(level string, props map[string]string, msg string) string {
+	var loc []string
+	for _, k := range []string{"title", "code", "file", "line", "endLine", "col", "endColumn"} {
+		if v, ok := props[k]; ok {
+			loc = append(loc, k+"="+v)
+		}
+	}
+	if len(loc) == 0 {
+		return "##[" + level + "]" + msg
+	}
+	return "##[" + level + "]" + msg + " (" + strings.Join(loc, ", ") + ")"
+}
+
+// MaxLineSize is the longest line a Scanner accepts.
+const MaxLineSize = 16 << 20
+
+// Scanner reads an output stream line by line, processing commands as it
+// goes.
+type Scanner struct {
+	*Processor
+	sc   *bufio.Scanner
+	line Line
+}
+
+// NewScanner returns a scanner reading r with a new Processor.
+func NewScanner(r io.Reader) *Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), MaxLineSize)
+	return &Scanner{Processor: NewProcessor(), sc: sc}
+}
+
+// Scan advances to the next line, which is then available from Line.
+func (s *Scanner) Scan() bool {
+	if !s.sc.Scan() {
+		return false
+	}
+	s.line = s.Process(strings.TrimRight(s.sc.Text(), "\r"))
+	return true
+}
+
+// Line returns the most recent line.
+func (s *Scanner) Line() Line {
+	return s.line
+}
+
+// Err returns the first read error.
+func (s *Scanner) Err() error {
+	return s.sc.Err()
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compliance
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/provenance"
+)
+
+// PayloadType is the DSSE payload type of a Record.
+const PayloadType = "application/vnd.actions.compliance.v1+json"
+
+// Record is the payload of a bundle: a run's log and its head hash.
+type Record struct {
+	Workflow string    `json:"workflow,omitempty"`
+	RunID    string    `json:"run_id,omitempty"`
+	Created  time.Time `json:"created"`
+	Head     string    `json:"head"`
+	Entries  []Entry   `json:"entries"`
+}
+
+// Record returns the log as a Record of the run its first entry names.
+func (l *Log) Record() *Record {
+	entries := l.Entries()
+	r := &Record{Created: time.Now().UTC(), Entries: entries}
+	if len(entries) > 0 {
+		r.Workflow, r.RunID = entries[0].Workflow, entries[0].RunID
+		r.Head = entries[len(entries)-1].Hash
+	}
+	return r
+}
+
+// Sign wraps r in a DSSE envelope signed by signer, such as a
+// provenance.KeySigner or a sign.Signer for keyless Sigstore signing.
+func (r *Record) Sign(ctx context.Context, signer provenance.Signer) (*provenance.Bundle, error) {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	env := &provenance.Envelope{Payload: payload, PayloadType: PayloadType}
+	material, err := signer.SignEnvelope(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	return &provenance.Bundle{MediaType: provenance.BundleMediaType, VerificationMaterial: material, DSSEEnvelope: env}, nil
+}
+
+// Open returns the record in b after checking that its log is unbroken
+// and ends at the head it claims. Signatures are checked separately, with
+// VerifyKey or sign.Verify.
+func Open(b *provenance.Bundle) (*Record, error) {
+	if b.DSSEEnvelope == nil || b.DSSEEnvelope.PayloadType != PayloadType {
+		return nil, fmt.Errorf("the bundle does not hold an audit record")
+	}
+	var r Record
+	if err := json.Unmarshal(b.DSSEEnvelope.Payload, &r); err != nil {
+		return nil, fmt.Errorf("invalid audit record: %w", err)
+	}
+	if err := Verify(r.Entries); err != nil {
+		return nil, err
+	}
+	head := ""
+	if n := len(r.Entries); n > 0 {
+		head = r.Entries[n-1].Hash
+	}
+	if head != r.Head {
+		return nil, fmt.Errorf("the log ends at %s, not the recorded head %s", head, r.Head)
+	}
+	return &r, nil
+}
+
+// VerifyKey checks that b was signed by pub.
+func VerifyKey(b *provenance.Bundle, pub crypto.PublicKey) error {
+	if b.DSSEEnvelope == nil {
+		return fmt.Errorf("the bundle has no envelope")
+	}
+	return b.DSSEEnvelope.Verify(pub)
+}
+
+// LoadSigner returns a signer for the PEM private key in the file at
+// path: ECDSA, Ed25519 or RSA, in PKCS #8, SEC 1 or PKCS #1 form. Its hint
+// is KeyHint of the public key.
+func LoadSigner(path string) (*provenance.KeySigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: the key is not PEM encoded", path)
+	}
+	var key any
+	if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+			if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return nil, fmt.Errorf("%s: unsupported private key", path)
+			}
+		}
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: the key cannot sign", path)
+	}
+	hint, err := KeyHint(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &provenance.KeySigner{Key: signer, Hint: hint}, nil
+}
+
+// LoadPublicKey reads a PEM public key, or the public key of a PEM
+// certificate or private key.
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: the key is not PEM encoded", path)
+	}
+	if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		s, err := LoadSigner(path)
+		if err != nil {
+			return nil, err
+		}
+		return s.Key.Public(), nil
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return cert.PublicKey, nil
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return pub, nil
+}
+
+// KeyHint names a public key by the SHA-256 digest of its PKIX encoding.
+func KeyHint(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compliance keeps a tamper-evident record of the steps a run
+// executed: an append-only log in which each entry carries the hash of the
+// one before, with secrets redacted, exported per run as a signed bundle.
+package compliance
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry records one executed step.
+type Entry struct {
+	// Seq numbers the entries of a log from 1.
+	Seq      int       `json:"seq"`
+	Time     time.Time `json:"time"`
+	Workflow string    `json:"workflow,omitempty"`
+	RunID    string    `json:"run_id,omitempty"`
+	Job      string    `json:"job"`
+	// Leg is the display name of the job's matrix leg.
+	Leg    string `json:"leg,omitempty"`
+	Step   string `json:"step"`
+	StepID string `json:"step_id,omitempty"`
+	Uses   string `json:"uses,omitempty"`
+	// SHA is the commit the action resolved to.
+	SHA string `json:"sha,omitempty"`
+	// Run is the script the step ran.
+	Run        string            `json:"run,omitempty"`
+	Inputs     map[string]string `json:"inputs,omitempty"`
+	ExitCode   int               `json:"exit_code"`
+	Outcome    string            `json:"outcome"`
+	Conclusion string            `json:"conclusion"`
+	DurationMS int64             `json:"duration_ms"`
+	Attempts   int               `json:"attempts,omitempty"`
+	// Prev is the hash of the entry before, empty for the first, and Hash
+	// that of this entry with Hash empty.
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// digest returns the hash of e as Hash records it.
+func (e Entry) digest() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Redacted replaces what a Redactor hides.
+const Redacted = "***"
+
+// DefaultSensitiveInputs matches the names of inputs whose values are
+// redacted whatever they hold.
+var DefaultSensitiveInputs = regexp.MustCompile(`(?i)(token|password|passwd|secret|credential|private[-_]?key|api[-_]?key)`)
+
+// Redactor hides secrets in entries. The zero value redacts nothing.
+type Redactor struct {
+	// Values are secret values replaced wherever they appear.
+	Values []string
+	// Patterns match text to replace, such as tokens of a known form.
+	Patterns []*regexp.Regexp
+	// SensitiveInputs matches the names of inputs redacted whole.
+	SensitiveInputs *regexp.Regexp
+}
+
+// Redact returns s with the values and patterns replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	// Longer values first, so one that contains another is hidden whole.
+	values := append([]string(nil), r.Values...)
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			s = strings.ReplaceAll(s, v, Redacted)
+		}
+	}
+	for _, re := range r.Patterns {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+func (r *Redactor) entry(e *Entry) {
+	e.Step = r.Redact(e.Step)
+	e.Run = r.Redact(e.Run)
+	e.Uses = r.Redact(e.Uses)
+	if len(e.Inputs) == 0 {
+		return
+	}
+	inputs := make(map[string]string, len(e.Inputs))
+	for k, v := range e.Inputs {
+		if r != nil && r.SensitiveInputs != nil && r.SensitiveInputs.MatchString(k) && v != "" {
+			inputs[k] = Redacted
+			continue
+		}
+		inputs[k] = r.Redact(v)
+	}
+	e.Inputs = inputs
+}
+
+// Log is a hash-chained audit log. Entries can only be appended; each is
+// written to W as a JSON line once it is chained. Methods are safe for
+// concurrent use.
+type Log struct {
+	// Redactor hides secrets before entries are chained.
+	Redactor *Redactor
+	// W, if set, receives each entry.
+	W io.Writer
+
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Append redacts e, chains it to the log and writes it, returning the
+// entry as recorded.
+func (l *Log) Append(e Entry) (Entry, error) {
+	if l == nil {
+		return e, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Redactor.entry(&e)
+	e.Seq = len(l.entries) + 1
+	e.Time = e.Time.UTC()
+	e.Prev = ""
+	if n := len(l.entries); n > 0 {
+		e.Prev = l.entries[n-1].Hash
+	}
+	hash, err := e.digest()
+	if err != nil {
+		return e, err
+	}
+	e.Hash = hash
+	if l.W != nil {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return e, err
+		}
+		if _, err := l.W.Write(append(data, '\n')); err != nil {
+			return e, fmt.Errorf("failed to write audit entry: %w", err)
+		}
+	}
+	l.entries = append(l.entries, e)
+	return e, nil
+}
+
+// Entries returns the entries appended so far.
+func (l *Log) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// Head returns the hash of the last entry, which vouches for the whole
+// log, or "" if it is empty.
+func (l *Log) Head() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) == 0 {
+		return ""
+	}
+	return l.entries[len(l.entries)-1].Hash
+}
+
+// Read reads the entries a Log wrote, one JSON object per line.
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// Verify checks that entries form an unbroken chain from the first entry
+// of a log: numbered in order, each hashing to its Hash and pointing at
+// the one before.
+func Verify(entries []Entry) error {
+	prev := ""
+	for i, e := range entries {
+		if e.Seq != i+1 {
+			return fmt.Errorf("entry %d has sequence number %d", i+1, e.Seq)
+		}
+		if e.Prev != prev {
+			return fmt.Errorf("entry %d does not follow entry %d", e.Seq, i)
+		}
+		hash, err := e.digest()
+		if err != nil {
+			return err
+		}
+		if hash != e.Hash {
+			return fmt.Errorf("entry %d was modified: it hashes to %s, not %s", e.Seq, hash, e.Hash)
+		}
+		prev = e.Hash
+	}
+	return nil
+}
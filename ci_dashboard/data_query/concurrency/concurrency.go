@@ -0,0 +1,270 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package concurrency implements the semantics of the concurrency key: at
+// most one run or job per group is in progress and one is pending, a newer
+// pending one replaces the older, and cancel-in-progress cancels the one
+// in progress instead of waiting for it.
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+// States of a member of a group.
+const (
+	StateRunning   = "running"
+	StatePending   = "pending"
+	StateCancelled = "cancelled"
+)
+
+// Evaluate evaluates a concurrency key against the values its expressions
+// may use. An empty group means the key is absent or evaluates to "".
+func Evaluate(c *workflow.Concurrency, values map[string]any) (group string, cancelInProgress bool, err error) {
+	if c == nil {
+		return "", false, nil
+	}
+	ctx := &expr.Context{Values: values}
+	if group, err = expr.Interpolate(c.Group, ctx); err != nil {
+		return "", false, fmt.Errorf("failed to evaluate concurrency group: %w", err)
+	}
+	if cip := c.CancelInProgress; cip != nil {
+		cancelInProgress = cip.Value
+		if cip.Expression != "" {
+			v, err := expr.EvaluateValue(cip.Expression, ctx)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to evaluate cancel-in-progress: %w", err)
+			}
+			cancelInProgress = expr.Truthy(v)
+		}
+	}
+	return group, cancelInProgress, nil
+}
+
+// Key returns the identity of a group; group names are case-insensitive.
+func Key(group string) string {
+	return strings.ToLower(group)
+}
+
+// Change is a transition of a member of a group.
+type Change struct {
+	ID    string
+	Group string
+	State string
+	// By is the member whose arrival cancelled this one.
+	By string
+}
+
+// Groups is the state of every group. The zero value is ready to use; it
+// is not safe for concurrent use.
+type Groups struct {
+	groups map[string]*slot
+	// member maps the IDs in a group to its key.
+	member map[string]string
+}
+
+type slot struct {
+	group            string
+	running, pending string
+}
+
+// Enter adds id to group and returns the changes, including id's own
+// state. cancelInProgress is that of the entering member.
+func (g *Groups) Enter(id, group string, cancelInProgress bool) []Change {
+	if g.groups == nil {
+		g.groups, g.member = map[string]*slot{}, map[string]string{}
+	}
+	key := Key(group)
+	s := g.groups[key]
+	if s == nil {
+		s = &slot{group: group}
+		g.groups[key] = s
+	}
+	g.member[id] = key
+	var changes []Change
+	cancel := func(other string) {
+		delete(g.member, other)
+		changes = append(changes, Change{ID: other, Group: s.group, State: StateCancelled, By: id})
+	}
+	if s.pending != "" {
+		cancel(s.pending)
+		s.pending = ""
+	}
+	switch {
+	case s.running == "":
+		s.running = id
+	case cancelInProgress:
+		cancel(s.running)
+		s.running = id
+	default:
+		s.pending = id
+		return append(changes, Change{ID: id, Group: s.group, State: StatePending})
+	}
+	return append(changes, Change{ID: id, Group: s.group, State: StateRunning})
+}
+
+// Leave removes id, which finished or was cancelled, from its group and
+// returns the pending member that starts as a result, if any.
+func (g *Groups) Leave(id string) []Change {
+	key, ok := g.member[id]
+	if !ok {
+		return nil
+	}
+	delete(g.member, id)
+	s := g.groups[key]
+	switch id {
+	case s.pending:
+		s.pending = ""
+	case s.running:
+		s.running, s.pending = s.pending, ""
+		if s.running != "" {
+			return []Change{{ID: s.running, Group: s.group, State: StateRunning}}
+		}
+	}
+	if s.running == "" && s.pending == "" {
+		delete(g.groups, key)
+	}
+	return nil
+}
+
+// CancelledError reports a run or job cancelled by its concurrency group,
+// worded as GitHub words it.
+type CancelledError struct {
+	Group    string
+	Deadlock bool
+}
+
+func (e *CancelledError) Error() string {
+	if e.Deadlock {
+		return fmt.Sprintf("Canceling since a deadlock for concurrency group '%s' was detected between 'top level workflow' and 'job'", e.Group)
+	}
+	return fmt.Sprintf("Canceling since a higher priority waiting request for '%s' exists", e.Group)
+}
+
+// Manager enforces groups among runs and jobs executing in one process.
+// The zero value is ready to use.
+type Manager struct {
+	mu      sync.Mutex
+	groups  Groups
+	seq     int
+	tickets map[string]*Ticket
+}
+
+// Ticket is a place in a group.
+type Ticket struct {
+	m     *Manager
+	id    string
+	group string
+	ready chan struct{}
+	// err is set when the ticket is cancelled while pending.
+	err    error
+	cancel context.CancelCauseFunc
+	ctx    context.Context
+}
+
+// Enter joins group. The ticket is pending if another member holds the
+// group; call Wait, then Release when done. Enter with an empty group
+// returns a ticket that is ready at once.
+func (m *Manager) Enter(ctx context.Context, group string, cancelInProgress bool) *Ticket {
+	t := &Ticket{m: m, group: group, ready: make(chan struct{})}
+	t.ctx, t.cancel = context.WithCancelCause(ctx)
+	if group == "" {
+		close(t.ready)
+		return t
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tickets == nil {
+		m.tickets = map[string]*Ticket{}
+	}
+	m.seq++
+	t.id = fmt.Sprint(m.seq)
+	m.tickets[t.id] = t
+	m.apply(m.groups.Enter(t.id, group, cancelInProgress))
+	return t
+}
+
+// apply carries out changes with m.mu held.
+func (m *Manager) apply(changes []Change) {
+	for _, c := range changes {
+		t := m.tickets[c.ID]
+		if t == nil {
+			continue
+		}
+		switch c.State {
+		case StateRunning:
+			close(t.ready)
+		case StateCancelled:
+			err := &CancelledError{Group: c.Group}
+			select {
+			case <-t.ready:
+				// In progress: cancel its work; it releases when done.
+			default:
+				t.err = err
+				close(t.ready)
+			}
+			t.cancel(err)
+			delete(m.tickets, c.ID)
+		}
+	}
+}
+
+// Pending reports whether the ticket waits for the group.
+func (t *Ticket) Pending() bool {
+	select {
+	case <-t.ready:
+		return false
+	default:
+		return true
+	}
+}
+
+// Wait blocks until the ticket holds the group and returns a context that
+// is cancelled, with a *CancelledError cause, if a later member cancels
+// it. It fails if the ticket is replaced while pending or ctx ends.
+func (t *Ticket) Wait() (context.Context, error) {
+	select {
+	case <-t.ready:
+	case <-t.ctx.Done():
+		t.Release()
+		return nil, context.Cause(t.ctx)
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	return t.ctx, nil
+}
+
+// Release leaves the group, starting the pending member if any. It may be
+// called more than once.
+func (t *Ticket) Release() {
+	if t.id == "" {
+		t.cancel(nil)
+		return
+	}
+	m := t.m
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tickets[t.id] == t {
+		delete(m.tickets, t.id)
+		m.apply(m.groups.Leave(t.id))
+	}
+	t.cancel(nil)
+}
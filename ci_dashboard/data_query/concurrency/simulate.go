@@ -0,0 +1,398 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package concurrency
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/contexts"
+	"testingdashboard/m/v2/triggers"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Event is a hypothetical event, as listed in an events file.
+type Event struct {
+	Name   string `yaml:"event"`
+	Action string `yaml:"action,omitempty"`
+	// Ref is the pushed ref, or the base branch of a pull request. Branch
+	// names without refs/ are taken as branches.
+	Ref string `yaml:"ref,omitempty"`
+	// HeadRef and Number describe a pull request.
+	HeadRef string         `yaml:"head-ref,omitempty"`
+	Number  int            `yaml:"number,omitempty"`
+	SHA     string         `yaml:"sha,omitempty"`
+	Actor   string         `yaml:"actor,omitempty"`
+	Changed []string       `yaml:"changed,omitempty"`
+	Inputs  map[string]any `yaml:"inputs,omitempty"`
+	// Finished lists earlier events, by position from 1, whose runs
+	// complete before this event arrives. Runs are otherwise assumed to
+	// still be in progress.
+	Finished []int `yaml:"finished,omitempty"`
+}
+
+// LoadEvents reads a YAML or JSON list of events.
+func LoadEvents(path string) ([]*Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var evs []*Event
+	if err := yaml.Unmarshal(data, &evs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, ev := range evs {
+		if ev.Name == "" {
+			return nil, fmt.Errorf("%s: event %d has no event name", path, i+1)
+		}
+		for _, f := range ev.Finished {
+			if f < 1 || f > i {
+				return nil, fmt.Errorf("%s: event %d: finished must list earlier events", path, i+1)
+			}
+		}
+	}
+	return evs, nil
+}
+
+// SimulateOptions configure Simulate.
+type SimulateOptions struct {
+	// Repository is the owner/repo of github.repository.
+	Repository string
+	Vars       map[string]string
+}
+
+// Outcome is what happens to a run, or to one job leg of it, that has a
+// concurrency group.
+type Outcome struct {
+	// Event is the position from 1 of the triggering event.
+	Event    int    `json:"event"`
+	Workflow string `json:"workflow"`
+	// Job is empty for the workflow-level group.
+	Job   string `json:"job,omitempty"`
+	Group string `json:"group,omitempty"`
+	// State is running, pending, cancelled or completed, or unknown when
+	// the group depends on values only known while running, such as
+	// needs.
+	State string `json:"state"`
+	// CancelledBy is the event whose run cancelled this one.
+	CancelledBy int    `json:"cancelled_by,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// Simulated states besides those of Groups: unknown for members whose
+// group cannot be evaluated offline and completed for runs and jobs that
+// finished.
+const (
+	StateUnknown   = "unknown"
+	StateCompleted = "completed"
+)
+
+type simRun struct {
+	id       string
+	event    int
+	wf       *workflow.Workflow
+	name     string
+	github   map[string]any
+	inputs   map[string]any
+	group    string
+	outcome  *Outcome
+	jobs     []string
+	started  bool
+	finished bool
+}
+
+type simulator struct {
+	opts     SimulateOptions
+	groups   Groups
+	outcomes []*Outcome
+	// members maps group member IDs to their outcome and run.
+	members map[string]*Outcome
+	runOf   map[string]*simRun
+	byEvent map[int][]*simRun
+	seq     int
+}
+
+// Simulate plays events against workflows in order and reports the fate
+// of every run and job that has a concurrency group. A run's jobs join
+// their groups when the run starts.
+func Simulate(wfs []*workflow.Workflow, events []*Event, opts SimulateOptions) ([]*Outcome, error) {
+	s := &simulator{
+		opts:    opts,
+		members: map[string]*Outcome{},
+		runOf:   map[string]*simRun{},
+		byEvent: map[int][]*simRun{},
+	}
+	for i, ev := range events {
+		n := i + 1
+		for _, f := range ev.Finished {
+			for _, r := range s.byEvent[f] {
+				s.finish(r)
+			}
+		}
+		te := triggerEvent(ev)
+		for _, wf := range wfs {
+			res, err := triggers.Matches(wf, te)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", wf.Path, err)
+			}
+			if !res.Triggered {
+				continue
+			}
+			if err := s.start(n, ev, wf); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.outcomes, nil
+}
+
+func (s *simulator) start(n int, ev *Event, wf *workflow.Workflow) error {
+	s.seq++
+	r := &simRun{event: n, wf: wf, name: workflowName(wf), inputs: ev.Inputs}
+	r.github = s.githubContext(ev, r, s.seq)
+	s.byEvent[n] = append(s.byEvent[n], r)
+	id := fmt.Sprintf("run-%d", s.seq)
+	r.id = id
+
+	values := contexts.At("concurrency").Restrict(s.values(r, nil, nil))
+	group, cancel, err := Evaluate(wf.Concurrency, values)
+	if err != nil {
+		s.outcomes = append(s.outcomes, &Outcome{Event: n, Workflow: r.name, State: StateUnknown, Reason: err.Error()})
+		s.startJobs(r)
+		return nil
+	}
+	if group == "" {
+		s.startJobs(r)
+		return nil
+	}
+	r.group = group
+	r.outcome = &Outcome{Event: n, Workflow: r.name, Group: group}
+	s.outcomes = append(s.outcomes, r.outcome)
+	s.members[id] = r.outcome
+	s.runOf[id] = r
+	s.apply(s.groups.Enter(id, group, cancel))
+	return nil
+}
+
+// startJobs enters the groups of the run's jobs, one per matrix leg.
+func (s *simulator) startJobs(r *simRun) {
+	r.started = true
+	ids := make([]string, 0, len(r.wf.Jobs))
+	for id := range r.wf.Jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, jobID := range ids {
+		job := r.wf.Jobs[jobID]
+		if job.Concurrency == nil {
+			continue
+		}
+		for _, leg := range legs(job) {
+			name := jobID
+			if len(leg.Matrix) > 0 {
+				name = fmt.Sprintf("%s %v", jobID, leg.Matrix)
+			}
+			values := contexts.At("jobs", jobID, "concurrency").Restrict(s.values(r, leg.Matrix, leg.strategy))
+			group, cancel, err := Evaluate(job.Concurrency, values)
+			if err == nil && group == "" {
+				continue
+			}
+			o := &Outcome{Event: r.event, Workflow: r.name, Job: name, Group: group}
+			s.outcomes = append(s.outcomes, o)
+			if err != nil {
+				o.State, o.Reason = StateUnknown, err.Error()
+				continue
+			}
+			if r.group != "" && Key(group) == Key(r.group) {
+				o.State = StateCancelled
+				o.Reason = (&CancelledError{Group: group, Deadlock: true}).Error()
+				continue
+			}
+			s.seq++
+			id := fmt.Sprintf("job-%d", s.seq)
+			s.members[id] = o
+			s.runOf[id] = r
+			r.jobs = append(r.jobs, id)
+			s.apply(s.groups.Enter(id, group, cancel))
+		}
+	}
+}
+
+// finish completes a run that is in progress, leaving its groups.
+func (s *simulator) finish(r *simRun) {
+	if r.finished || !r.started {
+		return
+	}
+	r.finished = true
+	for _, id := range append(slices.Clone(r.jobs), r.id) {
+		if o := s.members[id]; o != nil && o.State == StateRunning {
+			o.State = StateCompleted
+		}
+		s.apply(s.groups.Leave(id))
+	}
+}
+
+func (s *simulator) apply(changes []Change) {
+	for _, c := range changes {
+		o := s.members[c.ID]
+		r := s.runOf[c.ID]
+		o.State = c.State
+		switch c.State {
+		case StateRunning:
+			if r.outcome == o && !r.started {
+				s.startJobs(r)
+			}
+		case StateCancelled:
+			o.CancelledBy = s.runOf[c.By].event
+			o.Reason = (&CancelledError{Group: c.Group}).Error()
+			if r.outcome == o {
+				// Cancelling a run cancels its jobs.
+				r.finished = true
+				for _, id := range r.jobs {
+					if j := s.members[id]; j.State != StateCancelled {
+						j.State, j.CancelledBy, j.Reason = StateCancelled, o.CancelledBy, "the run was cancelled"
+						s.apply(s.groups.Leave(id))
+					}
+				}
+			}
+		}
+	}
+}
+
+type leg struct {
+	Matrix   map[string]any
+	strategy map[string]any
+}
+
+// legs expands a job's matrix. Matrices built from expressions are
+// treated as a single leg whose matrix values are unknown.
+func legs(job *workflow.Job) []leg {
+	exp, err := job.Strategy.Expand()
+	if err != nil || len(exp.Configs) == 0 {
+		return []leg{{}}
+	}
+	out := make([]leg, len(exp.Configs))
+	for i, cfg := range exp.Configs {
+		out[i] = leg{Matrix: cfg.Matrix, strategy: map[string]any{
+			"fail-fast":    exp.FailFast,
+			"job-index":    cfg.Index,
+			"job-total":    cfg.Total,
+			"max-parallel": exp.MaxParallel,
+		}}
+	}
+	return out
+}
+
+func (s *simulator) values(r *simRun, matrix, strategy map[string]any) map[string]any {
+	vars := map[string]any{}
+	for k, v := range s.opts.Vars {
+		vars[k] = v
+	}
+	inputs := r.inputs
+	if inputs == nil {
+		inputs = map[string]any{}
+	}
+	if matrix == nil {
+		matrix = map[string]any{}
+	}
+	if strategy == nil {
+		strategy = map[string]any{}
+	}
+	return map[string]any{
+		"github":   r.github,
+		"inputs":   inputs,
+		"vars":     vars,
+		"matrix":   matrix,
+		"strategy": strategy,
+	}
+}
+
+// triggerEvent converts ev for trigger matching.
+func triggerEvent(ev *Event) *triggers.Event {
+	return &triggers.Event{Name: ev.Name, Action: ev.Action, Ref: fullRef(ev.Ref), Changed: ev.Changed}
+}
+
+func fullRef(ref string) string {
+	if ref != "" && !strings.HasPrefix(ref, "refs/") {
+		return "refs/heads/" + ref
+	}
+	return ref
+}
+
+// githubContext builds the github context of the run of an event.
+func (s *simulator) githubContext(ev *Event, r *simRun, runID int) map[string]any {
+	ref := fullRef(ev.Ref)
+	payload := map[string]any{}
+	if ev.Action != "" {
+		payload["action"] = ev.Action
+	}
+	g := map[string]any{
+		"event_name":  ev.Name,
+		"ref":         ref,
+		"sha":         ev.SHA,
+		"repository":  s.opts.Repository,
+		"actor":       ev.Actor,
+		"workflow":    r.name,
+		"run_id":      fmt.Sprint(runID),
+		"run_number":  fmt.Sprint(runID),
+		"run_attempt": "1",
+		"head_ref":    "",
+		"base_ref":    "",
+		"event":       payload,
+	}
+	owner, _, _ := strings.Cut(s.opts.Repository, "/")
+	g["repository_owner"] = owner
+	switch ev.Name {
+	case "pull_request", "pull_request_target":
+		g["head_ref"] = ev.HeadRef
+		g["base_ref"] = strings.TrimPrefix(ref, "refs/heads/")
+		payload["number"] = ev.Number
+		payload["pull_request"] = map[string]any{
+			"number": ev.Number,
+			"head":   map[string]any{"ref": ev.HeadRef, "sha": ev.SHA},
+			"base":   map[string]any{"ref": g["base_ref"]},
+		}
+		if ev.Name == "pull_request" {
+			// pull_request runs on the merge ref; pull_request_target runs
+			// on the base branch.
+			ref = fmt.Sprintf("refs/pull/%d/merge", ev.Number)
+			g["ref"] = ref
+		}
+	case "workflow_dispatch":
+		payload["inputs"] = ev.Inputs
+	}
+	g["ref_name"] = strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/tags/")
+	if strings.HasPrefix(ref, "refs/pull/") {
+		g["ref_name"] = strings.TrimPrefix(ref, "refs/pull/")
+	}
+	g["ref_type"] = "branch"
+	if strings.HasPrefix(ref, "refs/tags/") {
+		g["ref_type"] = "tag"
+	}
+	return g
+}
+
+func workflowName(wf *workflow.Workflow) string {
+	if wf.Name != "" {
+		return wf.Name
+	}
+	return filepath.Base(wf.Path)
+}
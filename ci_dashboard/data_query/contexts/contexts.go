@@ -0,0 +1,175 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contexts describes the expression contexts and where in a
+// workflow or action each of them, and each special function, may be used.
+package contexts
+
+import (
+	"slices"
+	"strings"
+)
+
+// Names lists every context.
+var Names = []string{"github", "env", "vars", "job", "jobs", "steps", "runner", "secrets", "strategy", "matrix", "needs", "inputs"}
+
+// properties lists the properties of the contexts with a fixed shape. The
+// others are keyed by user-defined names.
+var properties = map[string][]string{
+	"github": {
+		"action", "action_path", "action_ref", "action_repository", "action_status",
+		"actor", "actor_id", "api_url", "base_ref", "env", "event", "event_name",
+		"event_path", "graphql_url", "head_ref", "job", "output", "path", "ref",
+		"ref_name", "ref_protected", "ref_type", "repository", "repository_id",
+		"repository_owner", "repository_owner_id", "repositoryurl", "retention_days",
+		"run_attempt", "run_id", "run_number", "secret_source", "server_url", "sha",
+		"state", "step_summary", "token", "triggering_actor", "workflow",
+		"workflow_ref", "workflow_sha", "workspace",
+	},
+	"runner":   {"name", "os", "arch", "temp", "tool_cache", "debug", "environment"},
+	"job":      {"container", "services", "status", "check_run_id", "workflow_ref", "workflow_sha", "workflow_repository", "workflow_file_path"},
+	"strategy": {"fail-fast", "job-index", "job-total", "max-parallel"},
+}
+
+// Properties returns the properties of a context with a fixed shape, or nil
+// for contexts keyed by user-defined names such as env and steps.
+func Properties(context string) []string {
+	return properties[strings.ToLower(context)]
+}
+
+// Availability is what an expression at some location may use.
+type Availability struct {
+	Contexts []string
+	// Functions lists the special functions allowed in addition to the
+	// general ones: always, cancelled, success, failure and hashFiles.
+	Functions []string
+}
+
+// Allows reports whether the context name may be used.
+func (a Availability) Allows(name string) bool {
+	return containsFold(a.Contexts, name)
+}
+
+// AllowsFunction reports whether the function may be called. Functions other
+// than the special ones are always allowed.
+func (a Availability) AllowsFunction(name string) bool {
+	if !containsFold(special, name) {
+		return true
+	}
+	return containsFold(a.Functions, name)
+}
+
+// Restrict returns the values of the allowed contexts, so that evaluating a
+// reference to any other fails as it does on GitHub.
+func (a Availability) Restrict(values map[string]any) map[string]any {
+	out := make(map[string]any, len(a.Contexts))
+	for name, v := range values {
+		if a.Allows(name) {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+var (
+	special         = []string{"always", "cancelled", "success", "failure", "hashFiles"}
+	statusFunctions = []string{"always", "cancelled", "success", "failure"}
+	stepFunctions   = []string{"hashFiles"}
+)
+
+// StatusFunction reports whether name is one of the job status functions.
+func StatusFunction(name string) bool {
+	return containsFold(statusFunctions, name)
+}
+
+type rule struct {
+	// pattern is matched against the leading elements of a path; * matches
+	// any single element.
+	pattern   string
+	contexts  []string
+	functions []string
+}
+
+// rules follows the context availability table of the workflow syntax
+// reference. The first matching rule wins, so longer patterns come first.
+var rules = []rule{
+	{"run-name", []string{"github", "inputs", "vars"}, nil},
+	{"concurrency", []string{"github", "inputs", "vars"}, nil},
+	{"env", []string{"github", "secrets", "inputs", "vars"}, nil},
+	{"on.workflow_call.inputs.*.default", []string{"github", "inputs", "vars"}, nil},
+	{"on.workflow_call.outputs.*.value", []string{"github", "jobs", "vars", "inputs"}, nil},
+
+	{"jobs.*.if", []string{"github", "needs", "vars", "inputs"}, statusFunctions},
+	{"jobs.*.strategy", []string{"github", "needs", "vars", "inputs"}, nil},
+	{"jobs.*.container.credentials", []string{"github", "needs", "strategy", "matrix", "env", "vars", "secrets", "inputs"}, nil},
+	{"jobs.*.container.env", []string{"github", "needs", "strategy", "matrix", "job", "runner", "env", "vars", "secrets", "inputs"}, nil},
+	{"jobs.*.services.*.credentials", []string{"github", "needs", "strategy", "matrix", "env", "vars", "secrets", "inputs"}, nil},
+	{"jobs.*.services.*.env", []string{"github", "needs", "strategy", "matrix", "job", "runner", "env", "vars", "secrets", "inputs"}, nil},
+	{"jobs.*.defaults.run", []string{"github", "needs", "strategy", "matrix", "env", "vars", "inputs"}, nil},
+	{"jobs.*.env", []string{"github", "needs", "strategy", "matrix", "vars", "secrets", "inputs"}, nil},
+	{"jobs.*.environment.url", []string{"github", "needs", "strategy", "matrix", "job", "runner", "env", "vars", "steps", "inputs"}, nil},
+	{"jobs.*.outputs", []string{"github", "needs", "strategy", "matrix", "job", "runner", "env", "vars", "secrets", "steps", "inputs"}, nil},
+	{"jobs.*.secrets", []string{"github", "needs", "strategy", "matrix", "secrets", "inputs", "vars"}, nil},
+	{"jobs.*.steps.*.if", []string{"github", "needs", "strategy", "matrix", "job", "runner", "env", "vars", "steps", "inputs"}, append(statusFunctions, stepFunctions...)},
+	{"jobs.*.steps", []string{"github", "needs", "strategy", "matrix", "job", "runner", "env", "vars", "secrets", "steps", "inputs"}, stepFunctions},
+	// concurrency, container, continue-on-error, environment, name, runs-on,
+	// services, timeout-minutes and with.
+	{"jobs.*", []string{"github", "needs", "strategy", "matrix", "vars", "inputs"}, nil},
+
+	// Action metadata. Composite steps see the caller's job but not its
+	// secrets.
+	{"runs.steps.*.if", []string{"github", "inputs", "job", "runner", "env", "strategy", "matrix", "steps"}, append(statusFunctions, stepFunctions...)},
+	{"runs.steps", []string{"github", "inputs", "job", "runner", "env", "strategy", "matrix", "steps"}, stepFunctions},
+	{"runs", []string{"github", "inputs", "runner", "env"}, nil},
+	{"outputs.*.value", []string{"github", "inputs", "job", "runner", "env", "strategy", "matrix", "steps"}, nil},
+	{"inputs.*.default", []string{"github", "inputs", "job", "runner", "env", "strategy", "matrix", "steps"}, nil},
+}
+
+var fallback = rule{contexts: []string{"github", "inputs", "vars"}}
+
+// At returns what an expression may use at the location given by path, the
+// mapping keys and sequence indexes leading to it, such as
+// jobs build steps 0 run.
+func At(path ...string) Availability {
+	r := fallback
+	for _, candidate := range rules {
+		if hasPrefix(path, candidate.pattern) {
+			r = candidate
+			break
+		}
+	}
+	return Availability{Contexts: slices.Clone(r.contexts), Functions: slices.Clone(r.functions)}
+}
+
+func hasPrefix(path []string, pattern string) bool {
+	parts := strings.Split(pattern, ".")
+	if len(path) < len(parts) {
+		return false
+	}
+	for i, part := range parts {
+		if part != "*" && part != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
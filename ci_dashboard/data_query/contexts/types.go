@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contexts
+
+import (
+	"slices"
+
+	"testingdashboard/m/v2/expr"
+)
+
+// payloadCommon lists the properties of every webhook payload.
+var payloadCommon = []string{"action", "enterprise", "installation", "organization", "repository", "sender"}
+
+// payloads lists the top-level properties of the webhook payload of each
+// event, besides payloadCommon. workflow_call is missing: it receives the
+// payload of the caller's event.
+var payloads = map[string][]string{
+	"branch_protection_rule":      {"rule", "changes"},
+	"check_run":                   {"check_run", "requested_action"},
+	"check_suite":                 {"check_suite"},
+	"create":                      {"ref", "ref_type", "master_branch", "description", "pusher_type"},
+	"delete":                      {"ref", "ref_type", "pusher_type"},
+	"deployment":                  {"deployment", "workflow", "workflow_run"},
+	"deployment_status":           {"deployment", "deployment_status", "check_run", "workflow", "workflow_run"},
+	"discussion":                  {"discussion", "answer", "changes", "label"},
+	"discussion_comment":          {"comment", "discussion", "changes"},
+	"fork":                        {"forkee"},
+	"gollum":                      {"pages"},
+	"image_version":               {"image_version"},
+	"issue_comment":               {"comment", "issue", "changes"},
+	"issues":                      {"issue", "assignee", "changes", "label", "milestone"},
+	"label":                       {"label", "changes"},
+	"merge_group":                 {"merge_group"},
+	"milestone":                   {"milestone", "changes"},
+	"page_build":                  {"build", "id"},
+	"project":                     {"project", "changes"},
+	"project_card":                {"project_card", "changes"},
+	"project_column":              {"project_column", "changes"},
+	"public":                      {},
+	"pull_request":                {"number", "pull_request", "assignee", "label", "requested_reviewer", "requested_team", "changes", "before", "after", "reason"},
+	"pull_request_review":         {"review", "pull_request", "changes"},
+	"pull_request_review_comment": {"comment", "pull_request", "changes"},
+	"pull_request_target":         {"number", "pull_request", "assignee", "label", "requested_reviewer", "requested_team", "changes", "before", "after", "reason"},
+	"push":                        {"ref", "before", "after", "base_ref", "commits", "compare", "created", "deleted", "forced", "head_commit", "pusher"},
+	"registry_package":            {"registry_package"},
+	"release":                     {"release", "changes"},
+	"repository_dispatch":         {"branch", "client_payload"},
+	"schedule":                    {"schedule"},
+	"status":                      {"id", "sha", "name", "target_url", "context", "description", "state", "commit", "branches", "created_at", "updated_at", "avatar_url"},
+	"watch":                       {},
+	"workflow_dispatch":           {"inputs", "ref", "workflow"},
+	"workflow_run":                {"workflow", "workflow_run"},
+}
+
+// EventProperties returns the top-level properties of the payload of the
+// event, or nil if they are not known.
+func EventProperties(event string) []string {
+	props, ok := payloads[event]
+	if !ok {
+		return nil
+	}
+	return append(slices.Clone(payloadCommon), props...)
+}
+
+// Types returns the types of the contexts for expr.Check in a workflow
+// triggered by events. Contexts keyed by user-defined names are objects
+// with any properties; callers that know the names may refine them.
+func Types(events []string) map[string]*expr.Type {
+	stringProps := func(names []string) map[string]*expr.Type {
+		props := map[string]*expr.Type{}
+		for _, name := range names {
+			props[name] = expr.StringType
+		}
+		return props
+	}
+	outputs := expr.Map(expr.StringType)
+
+	github := stringProps(properties["github"])
+	github["event"] = eventType(events)
+	github["ref_protected"] = expr.BoolType
+	runner := stringProps(properties["runner"])
+	job := stringProps(properties["job"])
+	job["container"] = expr.AnyType
+	job["services"] = expr.AnyType
+	job["check_run_id"] = expr.NumberType
+	return map[string]*expr.Type{
+		"github":  expr.Object(github),
+		"runner":  expr.Object(runner),
+		"job":     expr.Object(job),
+		"env":     expr.Map(expr.StringType),
+		"vars":    expr.Map(expr.StringType),
+		"secrets": expr.Map(expr.StringType),
+		"strategy": expr.Object(map[string]*expr.Type{
+			"fail-fast": expr.BoolType, "job-index": expr.NumberType, "job-total": expr.NumberType, "max-parallel": expr.NumberType,
+		}),
+		"matrix": expr.AnyType,
+		"steps": expr.Map(expr.Object(map[string]*expr.Type{
+			"outputs": outputs, "outcome": expr.StringType, "conclusion": expr.StringType,
+		})),
+		"needs":  expr.Map(expr.Object(map[string]*expr.Type{"outputs": outputs, "result": expr.StringType})),
+		"jobs":   expr.Map(expr.Object(map[string]*expr.Type{"outputs": outputs, "result": expr.StringType})),
+		"inputs": expr.AnyType,
+	}
+}
+
+// eventType returns the type of github.event: the properties of the
+// payload of any of the events.
+func eventType(events []string) *expr.Type {
+	if len(events) == 0 {
+		return expr.AnyType
+	}
+	props := map[string]*expr.Type{}
+	for _, event := range events {
+		names := EventProperties(event)
+		if names == nil {
+			return expr.AnyType
+		}
+		for _, name := range names {
+			props[name] = expr.AnyType
+		}
+	}
+	return expr.Object(props)
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contracts checks every call of a reusable workflow in a set of
+// workflows, such as those of a repository or of an organization's
+// inventory, against the inputs and secrets the called workflow declares.
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Kinds of Violation besides those of resolve.CallError.
+const (
+	// KindUnresolved is a call of a workflow file that does not exist or
+	// does not parse.
+	KindUnresolved = "unresolved"
+	// KindInherit is secrets: inherit where it passes no secrets.
+	KindInherit = "inherit"
+)
+
+// Workflow is a workflow of the set.
+type Workflow struct {
+	// Repo is owner/repo and Path the file within it, as in
+	// .github/workflows/ci.yml.
+	Repo     string
+	Path     string
+	Workflow *workflow.Workflow
+}
+
+// Violation is a call that does not match what the called workflow
+// declares.
+type Violation struct {
+	Repo string `json:"repository"`
+	Path string `json:"path"`
+	Line int    `json:"line,omitempty"`
+	Job  string `json:"job"`
+	// Uses is the called workflow as the job references it.
+	Uses string `json:"uses"`
+	Kind string `json:"kind"`
+	// Name is the input or secret, if any.
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+func (v *Violation) String() string {
+	return fmt.Sprintf("%s:%d: job %s calls %s: %s", v.Path, v.Line, v.Job, v.Uses, v.Message)
+}
+
+// Report is the result of Check.
+type Report struct {
+	// Calls is how many calls were checked.
+	Calls      int          `json:"calls"`
+	Violations []*Violation `json:"violations"`
+	// External lists the called workflows outside the set that were not
+	// fetched, and so not checked.
+	External []string `json:"external,omitempty"`
+}
+
+// Check checks the calls of the workflows. A call of a workflow of the set
+// is checked against that file, whatever ref it names; a call of another
+// repository's workflow is checked against what fetcher returns, or left
+// unchecked if fetcher is nil.
+func Check(ctx context.Context, workflows []*Workflow, fetcher resolve.Fetcher) *Report {
+	byKey := map[string]*Workflow{}
+	repos := map[string]bool{}
+	for _, w := range workflows {
+		byKey[key(w.Repo, w.Path)] = w
+		repos[strings.ToLower(w.Repo)] = true
+	}
+	fetched := map[string]*workflow.Workflow{}
+	external := map[string]bool{}
+	report := &Report{Violations: []*Violation{}}
+	for _, w := range workflows {
+		for _, id := range w.Workflow.JobIDs() {
+			job := w.Workflow.Jobs[id]
+			if job.Uses == "" {
+				continue
+			}
+			report.Calls++
+			v := &Violation{Repo: w.Repo, Path: w.Path, Line: job.Pos.Line, Job: id, Uses: job.Uses}
+			add := func(kind, name, format string, args ...any) {
+				c := *v
+				c.Kind, c.Name, c.Message = kind, name, fmt.Sprintf(format, args...)
+				report.Violations = append(report.Violations, &c)
+			}
+			uses, err := workflow.ParseUses(job.Uses)
+			if err == nil && !uses.IsReusableWorkflow() {
+				err = fmt.Errorf("%s is not a workflow file under .github/workflows", uses)
+			}
+			if err != nil {
+				add(KindUnresolved, "", "%v", err)
+				continue
+			}
+			calleeRepo, calleePath := w.Repo, strings.TrimPrefix(uses.Path, "./")
+			if uses.Kind == workflow.UsesRepository {
+				calleeRepo = uses.Repository()
+			}
+			// GitHub passes inherited secrets only within an organization.
+			inherit := job.Secrets != nil && job.Secrets.Inherit
+			crossOrg := !strings.EqualFold(owner(w.Repo), owner(calleeRepo))
+			if inherit && crossOrg {
+				add(KindInherit, "", "secrets: inherit passes no secrets to workflows of another organization")
+			}
+
+			var called *workflow.Workflow
+			switch {
+			case byKey[key(calleeRepo, calleePath)] != nil:
+				called = byKey[key(calleeRepo, calleePath)].Workflow
+			case repos[strings.ToLower(calleeRepo)]:
+				add(KindUnresolved, "", "%s has no workflow %s that parses", calleeRepo, calleePath)
+				continue
+			case fetcher == nil:
+				external[uses.String()] = true
+				continue
+			default:
+				ref := uses.String()
+				if called = fetched[ref]; called == nil {
+					path, err := fetcher.Fetch(ctx, uses)
+					if err == nil {
+						called, err = workflow.ParseFile(path)
+					}
+					if err != nil {
+						add(KindUnresolved, "", "%v", err)
+						continue
+					}
+					fetched[ref] = called
+				}
+			}
+
+			for _, ce := range resolve.CallErrors(resolve.ValidateCall(job, called)) {
+				add(ce.Kind, ce.Name, "%v", ce.Err)
+			}
+			if inherit && crossOrg {
+				if decl := called.On.Event("workflow_call"); decl != nil {
+					for _, name := range sortedKeys(decl.Secrets) {
+						if s := decl.Secrets[name]; s != nil && s.Required {
+							add(resolve.CallMissingSecret, name, "required secret %s is not provided; pass it explicitly", name)
+						}
+					}
+				}
+			}
+		}
+	}
+	for ref := range external {
+		report.External = append(report.External, ref)
+	}
+	sort.Strings(report.External)
+	return report
+}
+
+// key identifies a workflow file; owner and repository names are not case
+// sensitive.
+func key(repo, path string) string {
+	return strings.ToLower(repo) + "/" + path
+}
+
+func owner(repo string) string {
+	o, _, _ := strings.Cut(repo, "/")
+	return o
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,635 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/build"
+	"testingdashboard/m/v2/workflow"
+)
+
+var circleVariables = variables{
+	known: map[string]string{
+		"CIRCLE_SHA1":              "${{ github.sha }}",
+		"CIRCLE_BRANCH":            "${{ github.head_ref || github.ref_name }}",
+		"CIRCLE_TAG":               "${{ github.ref_type == 'tag' && github.ref_name || '' }}",
+		"CIRCLE_BUILD_NUM":         "${{ github.run_number }}",
+		"CIRCLE_WORKFLOW_ID":       "${{ github.run_id }}",
+		"CIRCLE_JOB":               "${{ github.job }}",
+		"CIRCLE_PROJECT_REPONAME":  "${{ github.event.repository.name }}",
+		"CIRCLE_PROJECT_USERNAME":  "${{ github.repository_owner }}",
+		"CIRCLE_REPOSITORY_URL":    "${{ github.server_url }}/${{ github.repository }}",
+		"CIRCLE_PR_NUMBER":         "${{ github.event.pull_request.number }}",
+		"CIRCLE_PULL_REQUEST":      "${{ github.event.pull_request.html_url }}",
+		"CIRCLE_USERNAME":          "${{ github.actor }}",
+		"CIRCLE_WORKING_DIRECTORY": "${{ github.workspace }}",
+		"CIRCLE_NODE_INDEX":        "0",
+		"CIRCLE_NODE_TOTAL":        "1",
+	},
+	prefix: regexp.MustCompile(`^CIRCLE_`),
+}
+
+// circlePipeline maps pipeline values to expressions.
+var circlePipeline = map[string]string{
+	"pipeline.git.revision": "${{ github.sha }}",
+	"pipeline.git.branch":   "${{ github.head_ref || github.ref_name }}",
+	"pipeline.git.tag":      "${{ github.ref_type == 'tag' && github.ref_name || '' }}",
+	"pipeline.number":       "${{ github.run_number }}",
+	"pipeline.id":           "${{ github.run_id }}",
+}
+
+var (
+	circleParam = regexp.MustCompile(`<<\s*([A-Za-z0-9_.-]+)\s*>>`)
+	circleKey   = regexp.MustCompile(`\{\{\s*([^}]*?)\s*\}\}`)
+)
+
+type circleci struct {
+	res       *Result
+	root      map[string]any
+	notes     *notes
+	wf        *build.WorkflowBuilder
+	ids       ids
+	approvals map[string][]string
+}
+
+func convertCircleCI(doc *yaml.Node) (*Result, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{}
+	c := &circleci{res: res, root: root}
+	top := newNotes(res)
+	for _, orb := range sortedKeys(mapping(root["orbs"])) {
+		top.add(nil, "orbs."+orb, "orbs are not converted; find an equivalent action for %s", str(mapping(root["orbs"])[orb]))
+	}
+	if root["setup"] == true {
+		top.add(nil, "setup", "dynamic configuration is not converted")
+	}
+
+	workflows := mapping(root["workflows"])
+	var names []string
+	for _, name := range keysInOrder(circleNode(doc, "workflows")) {
+		if name != "version" && mapping(workflows[name]) != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		// Version 2.0 configuration runs the job named build.
+		if mapping(mapping(root["jobs"])["build"]) == nil {
+			return nil, fmt.Errorf("no workflows and no build job")
+		}
+		workflows = map[string]any{"build": map[string]any{"jobs": []any{"build"}}}
+		names = []string{"build"}
+	}
+	for _, name := range names {
+		c.workflow(name, mapping(workflows[name]), top)
+	}
+	return res, nil
+}
+
+// circleNode returns the value node under key of the mapping node n.
+func circleNode(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func (c *circleci) workflow(name string, spec map[string]any, top *notes) {
+	c.notes = newNotes(c.res)
+	c.notes.top = append(c.notes.top, top.top...)
+	c.ids = ids{}
+	c.approvals = map[string][]string{}
+	c.wf = newWorkflow(name)
+
+	entries := asList(spec["jobs"])
+	// Jobs run on tags only when a filter asks for them.
+	tags := false
+	for _, e := range entries {
+		if _, job := circleEntry(e); mapping(mapping(job["filters"])["tags"]) != nil {
+			tags = true
+		}
+	}
+	var triggers []any
+	for _, t := range asList(spec["triggers"]) {
+		sched := mapping(mapping(t)["schedule"])
+		if sched == nil {
+			continue
+		}
+		triggers = append(triggers, sched)
+		c.wf.OnSchedule(str(sched["cron"]))
+		if mapping(sched["filters"]) != nil {
+			c.notes.add(nil, "workflows."+name+".triggers.schedule.filters", "schedules run on the default branch only")
+		}
+	}
+	if len(triggers) == 0 {
+		if tags {
+			c.wf.OnPush()
+		} else {
+			c.wf.OnPush(build.Branches("**"))
+		}
+		c.wf.OnPullRequest()
+	}
+	for _, p := range sortedKeys(mapping(c.root["parameters"])) {
+		def := mapping(mapping(c.root["parameters"])[p])
+		typ := str(def["type"])
+		switch typ {
+		case "integer":
+			typ = "number"
+		case "enum":
+			typ = "choice"
+		case "boolean", "string":
+		default:
+			typ = "string"
+		}
+		c.wf.OnWorkflowDispatch(build.Input(p, workflow.Input{
+			Description: str(def["description"]),
+			Default:     def["default"],
+			Type:        typ,
+			Options:     list(def["enum"]),
+		}))
+	}
+	if spec["when"] != nil || spec["unless"] != nil {
+		c.notes.add(nil, "workflows."+name+".when", "workflow conditions are not converted")
+	}
+
+	for _, e := range entries {
+		jobName, opts := circleEntry(e)
+		id := str(opts["name"])
+		if id == "" {
+			id = jobName
+		}
+		if str(opts["type"]) == "approval" {
+			c.approvals[id] = list(opts["requires"])
+			continue
+		}
+		c.ids.of(id)
+	}
+	for _, e := range entries {
+		jobName, opts := circleEntry(e)
+		name := str(opts["name"])
+		if name == "" {
+			name = jobName
+		}
+		if str(opts["type"]) == "approval" {
+			continue
+		}
+		c.job(name, jobName, opts)
+	}
+	c.notes.apply(c.wf, ".circleci/config.yml")
+	c.res.Files = append(c.res.Files, &File{Name: c.ids.of(name) + ".yml", Workflow: c.wf})
+}
+
+// circleEntry splits a workflow job entry into the job it runs and its
+// options.
+func circleEntry(e any) (string, map[string]any) {
+	if m := mapping(e); len(m) == 1 {
+		for k, v := range m {
+			return k, mapping(v)
+		}
+	}
+	return str(e), nil
+}
+
+func (c *circleci) job(name, jobName string, opts map[string]any) {
+	id := c.ids.of(name)
+	j := c.wf.Job(id)
+	if id != name {
+		j.Name(name)
+	}
+	at := "jobs." + name
+	note := func(key, format string, args ...any) { c.notes.add(j, join(at, key), format, args...) }
+
+	spec := mapping(mapping(c.root["jobs"])[jobName])
+	if spec == nil {
+		note("", "job %s is not defined; it may come from an orb", jobName)
+		j.RunsOn("ubuntu-latest").Step(todo(jobName, "run "+jobName))
+		return
+	}
+
+	// Parameters are substituted from the entry, the matrix or their
+	// defaults.
+	params := map[string]string{}
+	for p, def := range mapping(spec["parameters"]) {
+		if d, ok := mapping(def)["default"]; ok {
+			params["parameters."+p] = str(d)
+		}
+	}
+	for k, v := range opts {
+		params["parameters."+k] = str(v)
+	}
+	if m := mapping(opts["matrix"]); m != nil {
+		for _, p := range sortedKeys(mapping(m["parameters"])) {
+			j.Matrix(p, asList(mapping(m["parameters"])[p])...)
+			params["parameters."+p] = "${{ matrix." + p + " }}"
+		}
+		for _, x := range asList(m["exclude"]) {
+			j.MatrixExclude(mapping(x))
+		}
+	}
+	for p := range mapping(c.root["parameters"]) {
+		params["pipeline.parameters."+p] = "${{ inputs." + p + " }}"
+	}
+	for k, v := range circlePipeline {
+		params[k] = v
+	}
+	spec = mapping(substitute(spec, func(ref string) string {
+		if v, ok := params[ref]; ok {
+			return v
+		}
+		note("", "cannot substitute << %s >>", ref)
+		return "<< " + ref + " >>"
+	}))
+
+	// Requirements on approvals become an environment.
+	for _, req := range list(opts["requires"]) {
+		if reqs, ok := c.approvals[req]; ok {
+			j.Environment(c.ids.of(req), "")
+			note("requires."+req, "add required reviewers to the %s environment to gate the job", c.ids.of(req))
+			for _, r := range reqs {
+				j.Needs(c.ids.of(r))
+			}
+			continue
+		}
+		j.Needs(c.ids.of(req))
+	}
+	if f := mapping(opts["filters"]); f != nil {
+		if cond, ok := circleFilters(f); ok {
+			j.If(cond)
+		} else {
+			note("filters", "regular expressions in filters are not converted")
+		}
+	}
+	if opts["context"] != nil {
+		note("context", "add the secrets of context %s as repository or environment secrets", strings.Join(list(opts["context"]), ", "))
+	}
+
+	c.executor(j, at, spec)
+	for k, v := range env(spec["environment"]) {
+		j.Env(k, v)
+	}
+	if sh := str(spec["shell"]); sh != "" {
+		j.DefaultShell(sh)
+	}
+	if wd := str(spec["working_directory"]); wd != "" && wd != "~/project" {
+		note("working_directory", "steps run in the checkout at $GITHUB_WORKSPACE rather than %s", wd)
+	}
+	if rc := str(spec["resource_class"]); rc != "" {
+		note("resource_class", "choose a larger runner for %s", rc)
+	}
+
+	steps := asList(spec["steps"])
+	var scripts []string
+	for i := 0; i < len(steps); i++ {
+		scripts = append(scripts, c.step(j, at, steps, i, id)...)
+	}
+	for k, v := range circleVariables.env(c.notes, j, join(at, "steps"), scripts...) {
+		j.Env(k, v)
+	}
+	if n, ok := spec["parallelism"].(int); ok && n > 1 {
+		values := make([]any, n)
+		for i := range values {
+			values[i] = i
+		}
+		j.Matrix("circle_node_index", values...).
+			Env("CIRCLE_NODE_INDEX", "${{ matrix.circle_node_index }}").
+			Env("CIRCLE_NODE_TOTAL", strconv.Itoa(n))
+		note("parallelism", "test splitting with circleci tests split is not converted")
+	}
+	c.notes.unsupported(j, at, spec,
+		"docker", "machine", "macos", "executor", "environment", "shell", "working_directory",
+		"resource_class", "steps", "parallelism", "parameters")
+}
+
+// substitute replaces << >> references in the strings of v.
+func substitute(v any, fn func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return circleParam.ReplaceAllStringFunc(v, func(ref string) string {
+			return fn(circleParam.FindStringSubmatch(ref)[1])
+		})
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = substitute(item, fn)
+		}
+		return out
+	case map[string]any:
+		out := map[string]any{}
+		for k, item := range v {
+			out[k] = substitute(item, fn)
+		}
+		return out
+	}
+	return v
+}
+
+// circleFilters converts the branch and tag filters of a job entry into a
+// condition.
+func circleFilters(f map[string]any) (string, bool) {
+	var conds []string
+	for _, kind := range []string{"branch", "tag"} {
+		m := mapping(f[kind+"es"])
+		if kind == "tag" {
+			m = mapping(f["tags"])
+		}
+		for _, k := range []string{"only", "ignore"} {
+			names := list(m[k])
+			if len(names) == 0 {
+				continue
+			}
+			cond, ok := circleRefs(kind, names)
+			if !ok {
+				return "", false
+			}
+			if k == "ignore" {
+				cond = "!(" + cond + ")"
+			}
+			conds = append(conds, cond)
+		}
+	}
+	if len(conds) == 0 {
+		return "", false
+	}
+	return "${{ " + strings.Join(conds, " && ") + " }}", true
+}
+
+// circleRefs returns a condition matching refs of kind, branch or tag,
+// named one of names.
+func circleRefs(kind string, names []string) (string, bool) {
+	var or []string
+	for _, n := range names {
+		if n == "/.*/" {
+			return "github.ref_type == '" + kind + "'", true
+		}
+		if strings.HasPrefix(n, "/") {
+			return "", false
+		}
+		or = append(or, "(github.head_ref || github.ref_name) == '"+strings.ReplaceAll(n, "'", "''")+"'")
+	}
+	return "github.ref_type == '" + kind + "' && (" + strings.Join(or, " || ") + ")", true
+}
+
+// executor sets where the job runs from its docker, machine or macos
+// settings or those of its executor.
+func (c *circleci) executor(j *build.JobBuilder, at string, spec map[string]any) {
+	note := func(key, format string, args ...any) { c.notes.add(j, join(at, key), format, args...) }
+	exec := spec
+	if e := spec["executor"]; e != nil {
+		name := str(e)
+		if m := mapping(e); m != nil {
+			name = str(m["name"])
+		}
+		def := mapping(mapping(c.root["executors"])[name])
+		if def == nil {
+			note("executor", "executor %s is not defined; it may come from an orb", name)
+			j.RunsOn("ubuntu-latest")
+			return
+		}
+		exec = merge(def, spec)
+	}
+	switch {
+	case exec["docker"] != nil:
+		j.RunsOn("ubuntu-latest")
+		for i, d := range asList(exec["docker"]) {
+			m := mapping(d)
+			ctr := workflow.Container{Image: str(m["image"]), Env: env(m["environment"])}
+			if auth := mapping(m["auth"]); auth != nil {
+				ctr.Credentials = &workflow.Credentials{Username: circleSecret(str(auth["username"])), Password: circleSecret(str(auth["password"]))}
+			}
+			if m["command"] != nil || m["entrypoint"] != nil || m["user"] != nil {
+				note("docker."+ctr.Image, "command, entrypoint and user are not converted")
+			}
+			if i == 0 {
+				j.Container(ctr)
+				continue
+			}
+			name := str(m["name"])
+			if name == "" {
+				name = serviceName(ctr.Image)
+			}
+			j.Service(name, ctr)
+			if i == 1 {
+				note("docker", "secondary containers are services reached by their name rather than localhost")
+			}
+		}
+	case exec["macos"] != nil:
+		j.RunsOn("macos-latest")
+		if x := str(mapping(exec["macos"])["xcode"]); x != "" {
+			note("macos.xcode", "select Xcode %s, for example with sudo xcode-select", x)
+		}
+	case exec["machine"] != nil:
+		j.RunsOn("ubuntu-latest")
+		if img := str(mapping(exec["machine"])["image"]); img != "" && !strings.HasPrefix(img, "ubuntu-") {
+			note("machine.image", "image %s is not converted", img)
+		}
+	default:
+		note("", "no executor; the job runs on ubuntu-latest")
+		j.RunsOn("ubuntu-latest")
+	}
+}
+
+// circleSecret turns a $VAR reference into a secret.
+func circleSecret(s string) string {
+	if name, ok := strings.CutPrefix(s, "$"); ok {
+		return "${{ secrets." + strings.Trim(name, "{}") + " }}"
+	}
+	return s
+}
+
+// step converts steps[i], returning the scripts it runs.
+func (c *circleci) step(j *build.JobBuilder, at string, steps []any, i int, id string) []string {
+	kind, arg := str(steps[i]), map[string]any(nil)
+	if m := mapping(steps[i]); len(m) == 1 {
+		for k, v := range m {
+			kind, arg = k, mapping(v)
+			if arg == nil {
+				// A run step may be just its command.
+				arg = map[string]any{"command": v}
+			}
+		}
+	}
+	at = join(at, "steps."+kind)
+	note := func(format string, args ...any) { c.notes.add(j, at, format, args...) }
+	switch kind {
+	case "checkout":
+		s := checkout()
+		if p := str(arg["path"]); p != "" && p != "." {
+			s.With("path", p)
+		}
+		j.Step(s)
+	case "run", "deploy":
+		cmd := str(arg["command"])
+		s := build.Run(cmd)
+		if n := str(arg["name"]); n != "" {
+			s.Name(n)
+		}
+		for k, v := range env(arg["environment"]) {
+			s.Env(k, v)
+		}
+		if wd := str(arg["working_directory"]); wd != "" {
+			s.WorkingDirectory(wd)
+		}
+		if sh := str(arg["shell"]); sh != "" {
+			s.Shell(sh)
+		}
+		switch str(arg["when"]) {
+		case "always":
+			s.If("${{ always() }}")
+		case "on_fail":
+			s.If("${{ failure() }}")
+		}
+		if arg["background"] == true {
+			note("background steps are not converted; start the process with & and wait for it")
+		}
+		if t := str(arg["no_output_timeout"]); t != "" {
+			if m, ok := minutes(t); ok {
+				s.TimeoutMinutes(m)
+			}
+		}
+		j.Step(s)
+		return []string{cmd}
+	case "restore_cache":
+		keys := list(arg["keys"])
+		if k := str(arg["key"]); k != "" {
+			keys = append([]string{k}, keys...)
+		}
+		// The paths are those of the save_cache step that saves one of
+		// the keys.
+		for _, next := range steps[i+1:] {
+			save := mapping(mapping(next)["save_cache"])
+			if save == nil || !slices.Contains(keys, str(save["key"])) {
+				continue
+			}
+			var restore []string
+			for _, k := range keys {
+				if k != str(save["key"]) {
+					restore = append(restore, circleCacheKey(k))
+				}
+			}
+			j.Step(cacheStep(list(save["paths"]), circleCacheKey(str(save["key"])), restore...))
+			return nil
+		}
+		note("no save_cache step saves these keys; the cache is not restored")
+	case "save_cache":
+		for _, prev := range steps[:i] {
+			if restore := mapping(mapping(prev)["restore_cache"]); restore != nil {
+				keys := append(list(restore["keys"]), str(restore["key"]))
+				if slices.Contains(keys, str(arg["key"])) {
+					// Saved by the actions/cache step made for
+					// restore_cache.
+					return nil
+				}
+			}
+		}
+		j.Step(build.Uses("actions/cache/save@v4").Name("Save cache").
+			With("path", strings.Join(list(arg["paths"]), "\n")).
+			With("key", circleCacheKey(str(arg["key"]))))
+	case "store_artifacts":
+		p := str(arg["path"])
+		name := str(arg["destination"])
+		if name == "" {
+			name = path.Base(p)
+		}
+		j.Step(uploadStep(name, []string{p}))
+	case "store_test_results":
+		j.Step(uploadStep("test-results", []string{str(arg["path"])}).If("${{ always() }}"))
+		note("test results are uploaded as an artifact; use a reporting action to show them")
+	case "persist_to_workspace":
+		root := str(arg["root"])
+		// upload-artifact stores paths relative to their common ancestor,
+		// which a marker in root keeps at root, so that attach_workspace
+		// restores them under its path as CircleCI does.
+		marker := path.Join(root, ".workspace-"+id)
+		paths := []string{marker}
+		for _, p := range list(arg["paths"]) {
+			paths = append(paths, path.Join(root, p))
+		}
+		j.Step(build.Run("touch " + marker).Name("Mark workspace root"))
+		j.Step(uploadStep("workspace-"+id, paths).With("include-hidden-files", "true"))
+	case "attach_workspace":
+		j.Step(build.Uses("actions/download-artifact@v4").Name("Attach workspace").
+			With("pattern", "workspace-*").
+			With("merge-multiple", "true").
+			With("path", str(arg["at"])))
+	case "setup_remote_docker":
+		// Docker is available on hosted runners.
+	case "add_ssh_keys":
+		note("add the keys as secrets and load them, for example with webfactory/ssh-agent")
+	case "when", "unless":
+		note("conditional steps are not converted")
+	default:
+		if cmd := mapping(mapping(c.root["commands"])[kind]); cmd != nil {
+			var scripts []string
+			params := map[string]string{}
+			for p, def := range mapping(cmd["parameters"]) {
+				params["parameters."+p] = str(mapping(def)["default"])
+			}
+			for k, v := range arg {
+				params["parameters."+k] = str(v)
+			}
+			body := asList(substitute(cmd["steps"], func(ref string) string {
+				if v, ok := params[ref]; ok {
+					return v
+				}
+				return "<< " + ref + " >>"
+			}))
+			for k := range body {
+				scripts = append(scripts, c.step(j, at, body, k, id)...)
+			}
+			return scripts
+		}
+		note("step %s is not converted; it may come from an orb", kind)
+		j.Step(todo(kind, kind))
+	}
+	return nil
+}
+
+// circleCacheKey translates the templates of a cache key into
+// expressions.
+func circleCacheKey(key string) string {
+	return circleKey.ReplaceAllStringFunc(key, func(t string) string {
+		t = circleKey.FindStringSubmatch(t)[1]
+		switch {
+		case strings.HasPrefix(t, "checksum "):
+			f := strings.Trim(strings.TrimSpace(strings.TrimPrefix(t, "checksum ")), `"`)
+			return "${{ hashFiles('" + strings.ReplaceAll(f, "'", "''") + "') }}"
+		case t == ".Branch":
+			return "${{ github.head_ref || github.ref_name }}"
+		case t == ".Revision":
+			return "${{ github.sha }}"
+		case t == ".BuildNum":
+			return "${{ github.run_number }}"
+		case t == "arch":
+			return "${{ runner.os }}-${{ runner.arch }}"
+		case t == "epoch":
+			return "${{ github.run_id }}"
+		case strings.HasPrefix(t, ".Environment."):
+			return "${{ env." + strings.TrimPrefix(t, ".Environment.") + " }}"
+		}
+		return t
+	})
+}
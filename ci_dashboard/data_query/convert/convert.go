@@ -0,0 +1,407 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package convert translates GitLab CI, CircleCI and Travis CI
+// configuration into Actions workflows. Scripts, images, services,
+// matrices, caches and artifacts are mapped to their Actions equivalents;
+// what has none is reported as a Note and left as a comment in the
+// workflow for someone to finish by hand.
+package convert
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/build"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Formats of configuration Convert accepts.
+const (
+	GitLab   = "gitlab"
+	CircleCI = "circleci"
+	Travis   = "travis"
+)
+
+// Note is a construct that was not converted, or not exactly.
+type Note struct {
+	// Path locates the construct in the source configuration, such as
+	// test.retry.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (n Note) String() string { return n.Path + ": " + n.Message }
+
+// File is a generated workflow.
+type File struct {
+	// Name is the file name under .github/workflows.
+	Name     string
+	Workflow *build.WorkflowBuilder
+}
+
+// Result is what a conversion produced.
+type Result struct {
+	Files []*File
+	Notes []Note
+}
+
+// Detect returns the format of the configuration file at path from its
+// name.
+func Detect(path string) (string, error) {
+	switch base := filepath.Base(path); {
+	case base == ".gitlab-ci.yml" || strings.HasSuffix(base, ".gitlab-ci.yml"):
+		return GitLab, nil
+	case base == ".travis.yml":
+		return Travis, nil
+	case filepath.Base(filepath.Dir(path)) == ".circleci":
+		return CircleCI, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s", path)
+}
+
+// Convert translates data, configuration in format, into workflows.
+func Convert(format string, data []byte) (*Result, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("configuration must be a mapping")
+	}
+	doc := root.Content[0]
+	switch format {
+	case GitLab:
+		return convertGitLab(doc)
+	case CircleCI:
+		return convertCircleCI(doc)
+	case Travis:
+		return convertTravis(doc)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// notes collects the notes of one workflow, keeping those about a job to
+// be written above it.
+type notes struct {
+	res  *Result
+	top  []string
+	jobs map[*build.JobBuilder][]string
+}
+
+func newNotes(res *Result) *notes {
+	return &notes{res: res, jobs: map[*build.JobBuilder][]string{}}
+}
+
+// add records a note about path, or about job when it is not nil.
+func (n *notes) add(job *build.JobBuilder, path, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	// Jobs shared by several workflows are noted once.
+	if note := (Note{Path: path, Message: msg}); !slices.Contains(n.res.Notes, note) {
+		n.res.Notes = append(n.res.Notes, note)
+	}
+	line := "TODO: " + path + ": " + msg
+	if job == nil {
+		n.top = append(n.top, line)
+	} else {
+		n.jobs[job] = append(n.jobs[job], line)
+	}
+}
+
+// unsupported notes the keys of m, a mapping at path, that are not in
+// handled.
+func (n *notes) unsupported(job *build.JobBuilder, path string, m map[string]any, handled ...string) {
+	for _, k := range sortedKeys(m) {
+		if !slices.Contains(handled, k) {
+			n.add(job, join(path, k), "not converted")
+		}
+	}
+}
+
+// apply writes the notes as comments of wf and its jobs.
+func (n *notes) apply(wf *build.WorkflowBuilder, source string) {
+	header := []string{"Converted from " + source + " by actions convert."}
+	wf.Comment(strings.Join(append(header, n.top...), "\n"))
+	for job, lines := range n.jobs {
+		job.Comment(strings.Join(lines, "\n"))
+	}
+}
+
+func join(path, key string) string {
+	switch {
+	case path == "":
+		return key
+	case key == "":
+		return path
+	}
+	return path + "." + key
+}
+
+// ids makes job IDs from names in other systems, which may hold spaces
+// and punctuation Actions does not allow.
+type ids map[string]string
+
+var invalidID = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// of returns the ID for name, allocating a unique one on first use.
+func (m ids) of(name string) string {
+	if id, ok := m[name]; ok {
+		return id
+	}
+	id := strings.Trim(invalidID.ReplaceAllString(name, "-"), "-")
+	if id == "" || !(id[0] == '_' || id[0] >= 'A' && id[0] <= 'Z' || id[0] >= 'a' && id[0] <= 'z') {
+		id = "job-" + id
+	}
+	taken := map[string]bool{}
+	for _, v := range m {
+		taken[v] = true
+	}
+	for i, base := 2, id; taken[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+	m[name] = id
+	return id
+}
+
+// variables maps the predefined variables of a CI system that scripts
+// commonly read to Actions expressions.
+type variables struct {
+	known  map[string]string
+	prefix *regexp.Regexp
+}
+
+var varRef = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// env returns environment variables giving scripts the predefined
+// variables they use, and notes those with no equivalent. The values go
+// through env rather than into the scripts so that they are not
+// interpreted as shell.
+func (v variables) env(n *notes, job *build.JobBuilder, path string, scripts ...string) map[string]string {
+	env := map[string]string{}
+	for _, script := range scripts {
+		for _, m := range varRef.FindAllStringSubmatch(script, -1) {
+			name := m[1]
+			if e, ok := v.known[name]; ok {
+				env[name] = e
+			} else if v.prefix.MatchString(name) && env[name] == "" {
+				env[name] = ""
+				n.add(job, path, "predefined variable %s has no equivalent", name)
+			}
+		}
+	}
+	for k, e := range env {
+		if e == "" {
+			delete(env, k)
+		}
+	}
+	return env
+}
+
+// newWorkflow starts a workflow with read access to the repository, which
+// is all the jobs of other systems get without further setup.
+func newWorkflow(name string) *build.WorkflowBuilder {
+	return build.NewWorkflow(name).Permission("contents", workflow.PermissionRead)
+}
+
+// checkout is the first step of every converted job, since other systems
+// check out the repository implicitly.
+func checkout() *build.StepBuilder { return build.Uses("actions/checkout@v4") }
+
+// cacheStep restores and saves paths under key.
+func cacheStep(paths []string, key string, restoreKeys ...string) *build.StepBuilder {
+	s := build.Uses("actions/cache@v4").Name("Cache").
+		With("path", strings.Join(paths, "\n")).
+		With("key", key)
+	if len(restoreKeys) > 0 {
+		s.With("restore-keys", strings.Join(restoreKeys, "\n"))
+	}
+	return s
+}
+
+// todo is a placeholder step for what was not converted.
+func todo(name, what string) *build.StepBuilder {
+	return build.Run(`echo "TODO: ` + strings.ReplaceAll(what, `"`, `'`) + `"`).Name(name)
+}
+
+// uploadStep uploads paths as the artifact name.
+func uploadStep(name string, paths []string) *build.StepBuilder {
+	return build.Uses("actions/upload-artifact@v4").Name("Upload "+name).
+		With("name", name).
+		With("path", strings.Join(paths, "\n"))
+}
+
+// artifactRoot returns the directory upload-artifact stores paths relative
+// to, their deepest common ancestor, which is where a download must put
+// them to restore them in place. Only paths ending in / and the part of a
+// pattern before its first wildcard are taken for directories; any other
+// path counts from its parent, as upload-artifact counts files.
+func artifactRoot(paths []string) string {
+	var root []string
+	for i, p := range paths {
+		p = strings.TrimPrefix(p, "./")
+		switch {
+		case strings.ContainsAny(p, "*?["):
+			p = path.Dir(p[:strings.IndexAny(p, "*?[")] + "x")
+		case !strings.HasSuffix(p, "/"):
+			p = path.Dir(p)
+		}
+		elems := strings.Split(path.Clean(p), "/")
+		if i == 0 {
+			root = elems
+			continue
+		}
+		n := 0
+		for n < len(root) && n < len(elems) && root[n] == elems[n] {
+			n++
+		}
+		root = root[:n]
+	}
+	if len(root) == 0 {
+		return "."
+	}
+	return path.Join(root...)
+}
+
+// serviceName derives the name of a service from its image.
+func serviceName(image string) string {
+	name := image
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name, _, _ = strings.Cut(name, ":")
+	name, _, _ = strings.Cut(name, "@")
+	return invalidID.ReplaceAllString(name, "-")
+}
+
+// script joins the lines of a script.
+func script(lines []string) string { return strings.Join(lines, "\n") }
+
+var (
+	durationRE    = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]+)`)
+	durationUnits = map[string]float64{
+		"h": 60, "hr": 60, "hour": 60, "hours": 60,
+		"m": 1, "min": 1, "mins": 1, "minute": 1, "minutes": 1,
+		"s": 1.0 / 60, "sec": 1.0 / 60, "seconds": 1.0 / 60,
+	}
+)
+
+// minutes parses durations such as 1h 30m, 90m or 45 minutes.
+func minutes(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, true
+	}
+	total := 0.0
+	rest := s
+	for _, m := range durationRE.FindAllStringSubmatch(s, -1) {
+		f, ok := durationUnits[m[2]]
+		if !ok {
+			return 0, false
+		}
+		n, _ := strconv.ParseFloat(m[1], 64)
+		total += n * f
+		rest = strings.Replace(rest, m[0], "", 1)
+	}
+	if total == 0 || strings.TrimSpace(rest) != "" {
+		return 0, false
+	}
+	return float64(int(total + 0.999)), true
+}
+
+// Helpers for configuration decoded into any.
+
+func str(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// list returns v as strings, flattening nested lists, which GitLab allows
+// in scripts built from anchors.
+func list(v any) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		var out []string
+		for _, item := range v {
+			out = append(out, list(item)...)
+		}
+		return out
+	}
+	return []string{str(v)}
+}
+
+func mapping(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+// env returns a mapping of variables as strings.
+func env(v any) map[string]string {
+	m := mapping(v)
+	if len(m) == 0 {
+		return nil
+	}
+	out := map[string]string{}
+	for k, value := range m {
+		if vm := mapping(value); vm != nil {
+			value = vm["value"]
+		}
+		out[k] = str(value)
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// keysInOrder returns the keys of the mapping node n in source order.
+func keysInOrder(n *yaml.Node) []string {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	var out []string
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		out = append(out, n.Content[i].Value)
+	}
+	return out
+}
+
+// decode decodes the mapping node n, applying merge keys.
+func decode(n *yaml.Node) (map[string]any, error) {
+	var m map[string]any
+	if err := n.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
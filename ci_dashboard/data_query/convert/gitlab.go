@@ -0,0 +1,645 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/build"
+	"testingdashboard/m/v2/workflow"
+)
+
+var gitlabVariables = variables{
+	known: map[string]string{
+		"CI_COMMIT_SHA":                       "${{ github.sha }}",
+		"CI_COMMIT_REF_NAME":                  "${{ github.head_ref || github.ref_name }}",
+		"CI_COMMIT_BRANCH":                    "${{ github.head_ref || github.ref_name }}",
+		"CI_COMMIT_TAG":                       "${{ github.ref_type == 'tag' && github.ref_name || '' }}",
+		"CI_DEFAULT_BRANCH":                   "${{ github.event.repository.default_branch }}",
+		"CI_PIPELINE_ID":                      "${{ github.run_id }}",
+		"CI_PIPELINE_IID":                     "${{ github.run_number }}",
+		"CI_PIPELINE_SOURCE":                  "${{ github.event_name }}",
+		"CI_JOB_NAME":                         "${{ github.job }}",
+		"CI_PROJECT_DIR":                      "${{ github.workspace }}",
+		"CI_PROJECT_PATH":                     "${{ github.repository }}",
+		"CI_PROJECT_NAME":                     "${{ github.event.repository.name }}",
+		"CI_PROJECT_NAMESPACE":                "${{ github.repository_owner }}",
+		"CI_PROJECT_URL":                      "${{ github.server_url }}/${{ github.repository }}",
+		"CI_SERVER_URL":                       "${{ github.server_url }}",
+		"CI_MERGE_REQUEST_IID":                "${{ github.event.pull_request.number }}",
+		"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "${{ github.head_ref }}",
+		"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "${{ github.base_ref }}",
+		"CI_JOB_TOKEN":                        "${{ github.token }}",
+		"GITLAB_USER_LOGIN":                   "${{ github.actor }}",
+		"CI_NODE_INDEX":                       "1",
+		"CI_NODE_TOTAL":                       "1",
+	},
+	prefix: regexp.MustCompile(`^(CI|GITLAB)_`),
+}
+
+// gitlabReserved are the top-level keys of .gitlab-ci.yml that are not
+// jobs.
+var gitlabReserved = []string{
+	"default", "image", "services", "stages", "types", "before_script", "after_script",
+	"variables", "cache", "include", "workflow",
+}
+
+type gitlabJob struct {
+	name  string
+	id    string
+	spec  map[string]any
+	stage string
+}
+
+type gitlab struct {
+	notes     *notes
+	wf        *build.WorkflowBuilder
+	ids       ids
+	jobs      []*gitlabJob
+	byName    map[string]*gitlabJob
+	templates map[string]map[string]any
+	defaults  map[string]any
+}
+
+func convertGitLab(doc *yaml.Node) (*Result, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{}
+	g := &gitlab{
+		notes:     newNotes(res),
+		ids:       ids{},
+		byName:    map[string]*gitlabJob{},
+		templates: map[string]map[string]any{},
+		defaults:  map[string]any{},
+	}
+	name := "GitLab CI"
+	if n := str(mapping(root["workflow"])["name"]); n != "" {
+		name = n
+	}
+	g.wf = newWorkflow(name).OnPush().OnPullRequest()
+	if w := mapping(root["workflow"]); w != nil {
+		if w["rules"] != nil {
+			g.notes.add(nil, "workflow.rules", "pipelines run on every push and pull request; narrow the triggers by hand")
+		}
+		g.notes.unsupported(nil, "workflow", w, "name", "rules")
+	}
+	if root["include"] != nil {
+		g.notes.add(nil, "include", "included configuration is not converted; convert each file and combine the jobs")
+	}
+	for _, k := range []string{"image", "services", "before_script", "after_script", "cache"} {
+		if v, ok := root[k]; ok {
+			g.defaults[k] = v
+		}
+	}
+	for k, v := range mapping(root["default"]) {
+		g.defaults[k] = v
+	}
+	for k, v := range env(root["variables"]) {
+		g.wf.Env(k, v)
+	}
+
+	stages := list(root["stages"])
+	if stages == nil {
+		stages = list(root["types"])
+	}
+	if stages == nil {
+		stages = []string{"build", "test", "deploy"}
+	}
+	stages = append(append([]string{".pre"}, stages...), ".post")
+
+	for _, key := range keysInOrder(doc) {
+		spec := mapping(root[key])
+		switch {
+		case slices.Contains(gitlabReserved, key):
+		case spec == nil:
+			g.notes.add(nil, key, "not a job; not converted")
+		case strings.HasPrefix(key, "."):
+			g.templates[key] = spec
+		default:
+			job := &gitlabJob{name: key, id: g.ids.of(key)}
+			g.jobs = append(g.jobs, job)
+			g.byName[key] = job
+		}
+	}
+	for _, job := range g.jobs {
+		job.spec = g.resolve(job.name, mapping(root[job.name]), 0)
+		job.stage = str(job.spec["stage"])
+		if job.stage == "" {
+			job.stage = "test"
+		}
+		if !slices.Contains(stages, job.stage) {
+			g.notes.add(nil, job.name+".stage", "unknown stage %s", job.stage)
+		}
+	}
+	// Jobs are written in stage order, as GitLab runs them.
+	slices.SortStableFunc(g.jobs, func(a, b *gitlabJob) int {
+		return slices.Index(stages, a.stage) - slices.Index(stages, b.stage)
+	})
+	for _, job := range g.jobs {
+		g.job(job, stages)
+	}
+	g.notes.apply(g.wf, ".gitlab-ci.yml")
+	res.Files = []*File{{Name: "gitlab-ci.yml", Workflow: g.wf}}
+	return res, nil
+}
+
+// resolve applies the templates spec extends and the defaults it inherits.
+func (g *gitlab) resolve(name string, spec map[string]any, depth int) map[string]any {
+	out := map[string]any{}
+	if depth == 0 {
+		inherit, limited := mapping(spec["inherit"])["default"]
+		for k, v := range g.defaults {
+			if limited && inherit != true && !slices.Contains(list(inherit), k) {
+				continue
+			}
+			out[k] = v
+		}
+	}
+	for _, base := range list(spec["extends"]) {
+		t, ok := g.templates[base]
+		if !ok || depth > 10 {
+			g.notes.add(nil, name+".extends", "unknown template %s", base)
+			continue
+		}
+		out = merge(out, g.resolve(base, t, depth+1))
+	}
+	return merge(out, spec)
+}
+
+// merge deep-merges mappings the way extends does; other values in over
+// replace those in base.
+func merge(base, over map[string]any) map[string]any {
+	out := map[string]any{}
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		if b, o := mapping(out[k]), mapping(v); b != nil && o != nil {
+			out[k] = merge(b, o)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func (g *gitlab) job(job *gitlabJob, stages []string) {
+	spec := job.spec
+	j := g.wf.Job(job.id)
+	if job.id != job.name {
+		j.Name(job.name)
+	}
+	note := func(key, format string, args ...any) { g.notes.add(j, join(job.name, key), format, args...) }
+
+	if tags := list(spec["tags"]); len(tags) > 0 {
+		j.RunsOn(append([]string{"self-hosted"}, tags...)...)
+	} else {
+		j.RunsOn("ubuntu-latest")
+	}
+	if image := g.image(job.name, spec["image"], j); image != "" {
+		j.Container(workflow.Container{Image: image})
+	}
+	for _, s := range asList(spec["services"]) {
+		g.service(j, job.name, s)
+	}
+	for k, v := range env(spec["variables"]) {
+		j.Env(k, v)
+	}
+
+	// Needs come from the job or, failing that, the previous stage.
+	var needs []string
+	if v, ok := spec["needs"]; ok {
+		for _, n := range asList(v) {
+			dep := str(n)
+			if m := mapping(n); m != nil {
+				dep = str(m["job"])
+				if m["pipeline"] != nil || m["project"] != nil {
+					note("needs", "needs on other pipelines are not converted")
+					continue
+				}
+			}
+			if g.byName[dep] == nil {
+				note("needs", "unknown job %s", dep)
+				continue
+			}
+			needs = append(needs, dep)
+		}
+	} else {
+		needs = g.previousStage(job, stages)
+	}
+	for _, dep := range needs {
+		j.Needs(g.byName[dep].id)
+	}
+
+	switch when := str(spec["when"]); when {
+	case "", "on_success":
+	case "always":
+		j.If("${{ always() }}")
+	case "on_failure":
+		j.If("${{ failure() }}")
+	case "manual":
+		note("when", "manual jobs have no equivalent; use an environment with required reviewers or a workflow_dispatch workflow")
+	default:
+		note("when", "when: %s is not converted", when)
+	}
+	if spec["rules"] != nil {
+		note("rules", "rules are not converted; the job always runs")
+	}
+	for _, k := range []string{"only", "except"} {
+		if v, ok := spec[k]; ok {
+			if cond, ok := gitlabRefs(v, k == "except"); ok {
+				j.If(cond)
+			} else {
+				note(k, "not converted; the job always runs")
+			}
+		}
+	}
+	switch af := spec["allow_failure"].(type) {
+	case bool:
+		if af {
+			j.ContinueOnError()
+		}
+	case map[string]any:
+		j.ContinueOnError()
+		note("allow_failure", "exit codes are not honored; any failure is allowed")
+	}
+	if t := str(spec["timeout"]); t != "" {
+		if m, ok := minutes(t); ok {
+			j.TimeoutMinutes(m)
+		} else {
+			note("timeout", "cannot parse %q", t)
+		}
+	}
+	switch e := spec["environment"].(type) {
+	case string:
+		j.Environment(e, "")
+	case map[string]any:
+		j.Environment(str(e["name"]), str(e["url"]))
+		if a := str(e["action"]); a != "" && a != "start" {
+			note("environment.action", "action %s is not converted", a)
+		}
+	}
+	if rg := str(spec["resource_group"]); rg != "" {
+		j.Concurrency(rg, false)
+	}
+
+	j.Step(checkout())
+	deps := needs
+	if v, ok := spec["dependencies"]; ok {
+		deps = list(v)
+	}
+	for _, dep := range deps {
+		if d := g.byName[dep]; d != nil && d.spec["artifacts"] != nil {
+			step := build.Uses("actions/download-artifact@v4").Name("Download "+d.id).With("name", d.id)
+			if root := artifactRoot(artifactPaths(mapping(d.spec["artifacts"]))); root != "." {
+				step.With("path", root)
+			}
+			j.Step(step)
+		}
+	}
+	for _, c := range asList(spec["cache"]) {
+		g.cache(j, job.name, mapping(c))
+	}
+	main := append(list(spec["before_script"]), list(spec["script"])...)
+	after := list(spec["after_script"])
+	if len(main) == 0 && spec["trigger"] == nil {
+		note("script", "job has no script")
+	}
+	scriptEnv := gitlabVariables.env(g.notes, j, join(job.name, "script"), append(main, after...)...)
+	for k, v := range scriptEnv {
+		j.Env(k, v)
+	}
+	// Parallel jobs number themselves over the defaults.
+	g.parallel(j, job.name, spec["parallel"])
+	if len(main) > 0 {
+		j.Step(build.Run(script(main)).Name("Script"))
+	}
+	if len(after) > 0 {
+		j.Step(build.Run(script(after)).Name("After script").If("${{ always() }}"))
+	}
+	if a := mapping(spec["artifacts"]); a != nil {
+		g.artifacts(j, job, a)
+	}
+
+	if spec["trigger"] != nil {
+		note("trigger", "downstream pipelines are not converted; call a reusable workflow or use repository_dispatch")
+		j.Step(todo("Trigger", "trigger the downstream pipeline"))
+	}
+	if spec["id_tokens"] != nil {
+		j.Permission("contents", workflow.PermissionRead).Permission("id-token", workflow.PermissionWrite)
+		note("id_tokens", "request an OIDC token with the core.getIDToken toolkit function or $ACTIONS_ID_TOKEN_REQUEST_URL")
+	}
+	if job.name == "pages" {
+		note("pages", "use actions/upload-pages-artifact and actions/deploy-pages to publish GitHub Pages")
+	}
+	for _, k := range []string{"retry", "interruptible", "coverage", "release", "secrets", "hooks", "dast_configuration", "inherit"} {
+		if spec[k] != nil {
+			note(k, "not converted")
+		}
+	}
+	g.notes.unsupported(j, job.name, spec,
+		"stage", "tags", "image", "services", "variables", "needs", "when", "rules", "only", "except",
+		"allow_failure", "timeout", "environment", "resource_group", "parallel", "dependencies", "cache",
+		"before_script", "script", "after_script", "artifacts", "trigger", "id_tokens", "extends",
+		"retry", "interruptible", "coverage", "release", "secrets", "hooks", "dast_configuration", "inherit")
+}
+
+// previousStage returns the jobs of the last stage before the job's that
+// has any.
+func (g *gitlab) previousStage(job *gitlabJob, stages []string) []string {
+	for i := slices.Index(stages, job.stage) - 1; i >= 0; i-- {
+		var names []string
+		for _, other := range g.jobs {
+			if other.stage == stages[i] {
+				names = append(names, other.name)
+			}
+		}
+		if len(names) > 0 {
+			return names
+		}
+	}
+	return nil
+}
+
+func (g *gitlab) image(job string, v any, j *build.JobBuilder) string {
+	if m := mapping(v); m != nil {
+		if m["entrypoint"] != nil {
+			g.notes.add(j, join(job, "image.entrypoint"), "not converted; Actions runs containers with their entrypoint replaced")
+		}
+		return str(m["name"])
+	}
+	return str(v)
+}
+
+func (g *gitlab) service(j *build.JobBuilder, job string, v any) {
+	image, alias := str(v), ""
+	var vars map[string]string
+	if m := mapping(v); m != nil {
+		image, alias = str(m["name"]), str(m["alias"])
+		vars = env(m["variables"])
+		if m["command"] != nil || m["entrypoint"] != nil {
+			g.notes.add(j, join(job, "services."+image), "command and entrypoint are not converted")
+		}
+	}
+	name := alias
+	if name == "" {
+		name = serviceName(image)
+	}
+	j.Service(name, workflow.Container{Image: image, Env: vars})
+}
+
+// gitlabRefs converts the simple refs form of only or except into a job
+// condition.
+func gitlabRefs(v any, except bool) (string, bool) {
+	refs := list(v)
+	if m := mapping(v); m != nil {
+		if len(m) != 1 || m["refs"] == nil {
+			return "", false
+		}
+		refs = list(m["refs"])
+	}
+	var conds []string
+	for _, ref := range refs {
+		switch ref {
+		case "branches":
+			conds = append(conds, "startsWith(github.ref, 'refs/heads/')")
+		case "tags":
+			conds = append(conds, "startsWith(github.ref, 'refs/tags/')")
+		case "merge_requests":
+			conds = append(conds, "github.event_name == 'pull_request'")
+		case "pushes":
+			conds = append(conds, "github.event_name == 'push'")
+		case "schedules":
+			conds = append(conds, "github.event_name == 'schedule'")
+		case "web", "api":
+			conds = append(conds, "github.event_name == 'workflow_dispatch'")
+		default:
+			if strings.HasPrefix(ref, "/") || strings.ContainsAny(ref, "*@") {
+				return "", false
+			}
+			conds = append(conds, "github.ref == 'refs/heads/"+strings.ReplaceAll(ref, "'", "''")+"'")
+		}
+	}
+	if len(conds) == 0 {
+		return "", false
+	}
+	cond := strings.Join(conds, " || ")
+	if except {
+		cond = "!(" + cond + ")"
+	}
+	return "${{ " + cond + " }}", true
+}
+
+// parallel converts parallel: n and parallel: matrix.
+func (g *gitlab) parallel(j *build.JobBuilder, job string, v any) {
+	switch v := v.(type) {
+	case nil:
+	case int:
+		values := make([]any, v)
+		for i := range values {
+			values[i] = i + 1
+		}
+		j.Matrix("ci_node_index", values...).
+			Env("CI_NODE_INDEX", "${{ matrix.ci_node_index }}").
+			Env("CI_NODE_TOTAL", strconv.Itoa(v))
+	case map[string]any:
+		entries := asList(v["matrix"])
+		names := map[string]bool{}
+		if len(entries) == 1 {
+			m := mapping(entries[0])
+			for _, name := range sortedKeys(m) {
+				j.Matrix(name, asList(m[name])...)
+				names[name] = true
+			}
+		} else {
+			for _, entry := range entries {
+				for _, combo := range expand(mapping(entry)) {
+					j.MatrixInclude(combo)
+					for name := range combo {
+						names[name] = true
+					}
+				}
+			}
+		}
+		for _, name := range sortedKeys(names) {
+			j.Env(name, "${{ matrix."+name+" }}")
+		}
+	default:
+		g.notes.add(j, join(job, "parallel"), "not converted")
+	}
+}
+
+// expand returns the combinations of one parallel: matrix entry.
+func expand(entry map[string]any) []map[string]any {
+	combos := []map[string]any{{}}
+	for _, name := range sortedKeys(entry) {
+		var next []map[string]any
+		for _, combo := range combos {
+			for _, value := range asList(entry[name]) {
+				c := map[string]any{name: value}
+				for k, v := range combo {
+					c[k] = v
+				}
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+var gitlabKeyVar = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+func (g *gitlab) cache(j *build.JobBuilder, job string, c map[string]any) {
+	if c == nil {
+		return
+	}
+	note := func(key, format string, args ...any) { g.notes.add(j, join(job, "cache."+key), format, args...) }
+	paths := list(c["paths"])
+	if len(paths) == 0 {
+		if c["untracked"] == true {
+			note("untracked", "caching untracked files is not converted")
+		}
+		return
+	}
+	key := "${{ runner.os }}-default"
+	if m := mapping(c["key"]); m != nil {
+		var files []string
+		for _, f := range list(m["files"]) {
+			files = append(files, "'"+strings.ReplaceAll(f, "'", "''")+"'")
+		}
+		key = "${{ runner.os }}-" + gitlabKey(str(m["prefix"])) + "${{ hashFiles(" + strings.Join(files, ", ") + ") }}"
+	} else if c["key"] != nil {
+		key = "${{ runner.os }}-" + gitlabKey(str(c["key"]))
+	}
+	fallback := list(c["fallback_keys"])
+	for i, k := range fallback {
+		fallback[i] = "${{ runner.os }}-" + gitlabKey(k)
+	}
+	step := cacheStep(paths, key, fallback...)
+	switch str(c["policy"]) {
+	case "pull":
+		step = build.Uses("actions/cache/restore@v4").Name("Restore cache").
+			With("path", strings.Join(paths, "\n")).
+			With("key", key)
+	case "push":
+		step = build.Uses("actions/cache/save@v4").Name("Save cache").
+			With("path", strings.Join(paths, "\n")).
+			With("key", key)
+		note("policy", "the cache is saved before the script runs; move the step to the end of the job")
+	}
+	if w := str(c["when"]); w != "" && w != "on_success" {
+		note("when", "actions/cache saves only when the job succeeds")
+	}
+	j.Step(step)
+}
+
+// gitlabKey rewrites the variables of a cache key as expressions.
+func gitlabKey(key string) string {
+	return gitlabKeyVar.ReplaceAllStringFunc(key, func(ref string) string {
+		name := gitlabKeyVar.FindStringSubmatch(ref)[1]
+		switch name {
+		case "CI_COMMIT_REF_SLUG", "CI_COMMIT_REF_NAME", "CI_COMMIT_BRANCH":
+			return "${{ github.head_ref || github.ref_name }}"
+		case "CI_JOB_NAME", "CI_JOB_NAME_SLUG":
+			return "${{ github.job }}"
+		case "CI_COMMIT_SHA":
+			return "${{ github.sha }}"
+		}
+		return "${{ env." + name + " }}"
+	})
+}
+
+// artifactPaths returns the paths and report files of artifacts a.
+func artifactPaths(a map[string]any) []string {
+	paths := list(a["paths"])
+	for _, kind := range sortedKeys(mapping(a["reports"])) {
+		paths = append(paths, list(mapping(a["reports"])[kind])...)
+	}
+	return paths
+}
+
+func (g *gitlab) artifacts(j *build.JobBuilder, job *gitlabJob, a map[string]any) {
+	note := func(key, format string, args ...any) {
+		g.notes.add(j, join(job.name, "artifacts."+key), format, args...)
+	}
+	paths := artifactPaths(a)
+	for _, kind := range sortedKeys(mapping(a["reports"])) {
+		note("reports."+kind, "reports are uploaded with the artifacts; use a reporting action to show them")
+	}
+	if len(paths) == 0 {
+		return
+	}
+	step := uploadStep(job.id, paths)
+	switch str(a["when"]) {
+	case "always":
+		step.If("${{ always() }}")
+	case "on_failure":
+		step.If("${{ failure() }}")
+	}
+	if exp := str(a["expire_in"]); exp != "" {
+		if d, ok := days(exp); ok {
+			step.With("retention-days", strconv.Itoa(d))
+		} else if m, ok := minutes(exp); ok {
+			step.With("retention-days", strconv.Itoa(max(1, int(m+1439)/1440)))
+		} else if exp != "never" {
+			note("expire_in", "cannot parse %q", exp)
+		}
+	}
+	for _, k := range []string{"exclude", "untracked", "expose_as"} {
+		if a[k] != nil {
+			note(k, "not converted")
+		}
+	}
+	j.Step(step)
+}
+
+var daysRE = regexp.MustCompile(`^(\d+)\s*(day|days|week|weeks|month|months|year|years|d|wk|mo|yr)$`)
+
+// days parses durations such as 1 week or 30 days.
+func days(s string) (int, bool) {
+	m := daysRE.FindStringSubmatch(strings.TrimSpace(strings.ToLower(s)))
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	switch m[2][0] {
+	case 'w':
+		n *= 7
+	case 'm':
+		n *= 30
+	case 'y':
+		n *= 365
+	}
+	return n, true
+}
+
+// asList returns v as a list, wrapping a single value.
+func asList(v any) []any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	}
+	return []any{v}
+}
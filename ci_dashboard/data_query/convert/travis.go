@@ -0,0 +1,544 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/build"
+	"testingdashboard/m/v2/workflow"
+)
+
+var travisVariables = variables{
+	known: map[string]string{
+		"TRAVIS_COMMIT":              "${{ github.sha }}",
+		"TRAVIS_BRANCH":              "${{ github.base_ref || github.ref_name }}",
+		"TRAVIS_PULL_REQUEST":        "${{ github.event.pull_request.number || 'false' }}",
+		"TRAVIS_PULL_REQUEST_BRANCH": "${{ github.head_ref }}",
+		"TRAVIS_PULL_REQUEST_SHA":    "${{ github.event.pull_request.head.sha }}",
+		"TRAVIS_TAG":                 "${{ github.ref_type == 'tag' && github.ref_name || '' }}",
+		"TRAVIS_BUILD_NUMBER":        "${{ github.run_number }}",
+		"TRAVIS_BUILD_ID":            "${{ github.run_id }}",
+		"TRAVIS_BUILD_DIR":           "${{ github.workspace }}",
+		"TRAVIS_REPO_SLUG":           "${{ github.repository }}",
+		"TRAVIS_EVENT_TYPE":          "${{ github.event_name }}",
+	},
+	prefix: regexp.MustCompile(`^TRAVIS_`),
+}
+
+// travisLanguage is how a Travis language is set up in Actions.
+type travisLanguage struct {
+	// versions is the key listing versions, which set up the language
+	// with input of action.
+	versions string
+	action   string
+	input    string
+	// variable holds the version in Travis.
+	variable string
+	install  string
+	script   string
+	// caches maps Travis cache names to inputs of action.
+	caches map[string][2]string
+}
+
+var travisLanguages = map[string]travisLanguage{
+	"node_js": {
+		versions: "node_js", action: "actions/setup-node@v4", input: "node-version", variable: "TRAVIS_NODE_VERSION",
+		install: "npm ci", script: "npm test",
+		caches: map[string][2]string{"npm": {"cache", "npm"}, "yarn": {"cache", "yarn"}},
+	},
+	"python": {
+		versions: "python", action: "actions/setup-python@v5", input: "python-version", variable: "TRAVIS_PYTHON_VERSION",
+		install: "pip install -r requirements.txt",
+		caches:  map[string][2]string{"pip": {"cache", "pip"}},
+	},
+	"go": {
+		versions: "go", action: "actions/setup-go@v5", input: "go-version", variable: "TRAVIS_GO_VERSION",
+		script: "go test -v ./...",
+	},
+	"ruby": {
+		versions: "rvm", action: "ruby/setup-ruby@v1", input: "ruby-version", variable: "TRAVIS_RUBY_VERSION",
+		install: "bundle install --jobs=3 --retry=3", script: "bundle exec rake",
+		caches: map[string][2]string{"bundler": {"bundler-cache", "true"}},
+	},
+	"java": {
+		versions: "jdk", action: "actions/setup-java@v4", input: "java-version", variable: "TRAVIS_JDK_VERSION",
+	},
+}
+
+// travisServices are the service containers for Travis services.
+var travisServices = map[string]workflow.Container{
+	"postgresql": {Image: "postgres", Env: map[string]string{"POSTGRES_HOST_AUTH_METHOD": "trust"}, Ports: []string{"5432:5432"}},
+	"mysql":      {Image: "mysql", Env: map[string]string{"MYSQL_ALLOW_EMPTY_PASSWORD": "yes"}, Ports: []string{"3306:3306"}},
+	"redis":      {Image: "redis", Ports: []string{"6379:6379"}},
+	"mongodb":    {Image: "mongo", Ports: []string{"27017:27017"}},
+	"memcached":  {Image: "memcached", Ports: []string{"11211:11211"}},
+	"rabbitmq":   {Image: "rabbitmq", Ports: []string{"5672:5672"}},
+}
+
+var travisRunners = map[string]string{"linux": "ubuntu-latest", "osx": "macos-latest", "windows": "windows-latest"}
+
+// travisPhases are the script phases in the order Travis runs them, with
+// their step names and conditions.
+var travisPhases = []struct{ key, name, cond string }{
+	{"before_install", "Before install", ""},
+	{"install", "Install", ""},
+	{"before_script", "Before script", ""},
+	{"script", "Script", ""},
+	{"after_success", "After success", "${{ success() }}"},
+	{"after_failure", "After failure", "${{ failure() }}"},
+	{"after_script", "After script", "${{ always() }}"},
+}
+
+// travisHandled are the keys a Travis job converts.
+var travisHandled = []string{
+	"language", "node_js", "python", "go", "rvm", "jdk", "os", "env", "services", "cache", "addons",
+	"git", "before_install", "install", "before_script", "script", "after_success", "after_failure",
+	"after_script", "name", "stage", "sudo", "dist",
+}
+
+type travis struct {
+	notes *notes
+	wf    *build.WorkflowBuilder
+	ids   ids
+}
+
+func convertTravis(doc *yaml.Node) (*Result, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{}
+	t := &travis{notes: newNotes(res), ids: ids{}}
+	t.wf = newWorkflow("Travis CI")
+
+	var opts []build.EventOption
+	if b := mapping(root["branches"]); b != nil {
+		for _, k := range []string{"only", "except"} {
+			var patterns []string
+			for _, p := range list(b[k]) {
+				if strings.HasPrefix(p, "/") {
+					t.notes.add(nil, "branches."+k, "regular expression %s is not converted", p)
+					continue
+				}
+				patterns = append(patterns, p)
+			}
+			if len(patterns) == 0 {
+				continue
+			}
+			if k == "only" {
+				opts = append(opts, build.Branches(patterns...))
+			} else {
+				opts = append(opts, build.BranchesIgnore(patterns...))
+			}
+		}
+	}
+	t.wf.OnPush(opts...).OnPullRequest(opts...)
+	for _, k := range []string{"if", "deploy", "before_deploy", "after_deploy", "notifications", "before_cache", "import"} {
+		if root[k] != nil {
+			t.notes.add(nil, k, "not converted")
+		}
+	}
+
+	matrix := mapping(root["jobs"])
+	if matrix == nil {
+		matrix = mapping(root["matrix"])
+	}
+	for _, k := range []string{"allow_failures", "fast_finish", "exclude"} {
+		if matrix[k] != nil {
+			t.notes.add(nil, "jobs."+k, "not converted")
+		}
+	}
+
+	// Each stage needs the jobs of the one before it.
+	var stages []string
+	for _, s := range asList(root["stages"]) {
+		name := str(s)
+		if m := mapping(s); m != nil {
+			name = str(m["name"])
+			if m["if"] != nil {
+				t.notes.add(nil, "stages."+name, "stage conditions are not converted")
+			}
+		}
+		if !slices.Contains(stages, strings.ToLower(name)) {
+			stages = append(stages, strings.ToLower(name))
+		}
+	}
+	type staged struct {
+		stage string
+		job   *build.JobBuilder
+		id    string
+	}
+	var jobs []staged
+	include := asList(matrix["include"])
+	rootCfg := filter(root, travisHandled)
+	if len(include) == 0 || t.expands(rootCfg) {
+		id := t.ids.of("test")
+		j := t.wf.Job(id)
+		t.job(j, "", rootCfg, rootCfg)
+		jobs = append(jobs, staged{"test", j, id})
+	}
+	stage := "test"
+	for i, entry := range include {
+		entry := mapping(entry)
+		if s := str(entry["stage"]); s != "" {
+			stage = strings.ToLower(s)
+		}
+		name := str(entry["name"])
+		if name == "" {
+			name = stage + "-" + strconv.Itoa(i+1)
+		}
+		id := t.ids.of(name)
+		j := t.wf.Job(id)
+		if id != name {
+			j.Name(name)
+		}
+		at := "jobs.include." + strconv.Itoa(i)
+		t.job(j, at, merge(t.first(rootCfg), entry), entry)
+		t.notes.unsupported(j, at, entry, travisHandled...)
+		jobs = append(jobs, staged{stage, j, id})
+	}
+	byStage := map[string][]string{}
+	for _, s := range jobs {
+		if !slices.Contains(stages, s.stage) {
+			stages = append(stages, s.stage)
+		}
+		byStage[s.stage] = append(byStage[s.stage], s.id)
+	}
+	for _, s := range jobs {
+		for i := slices.Index(stages, s.stage) - 1; i >= 0; i-- {
+			if prev := byStage[stages[i]]; len(prev) > 0 {
+				s.job.Needs(prev...)
+				break
+			}
+		}
+	}
+	t.notes.unsupported(nil, "", root, append(travisHandled,
+		"branches", "if", "deploy", "before_deploy", "after_deploy", "notifications", "before_cache", "import",
+		"jobs", "matrix", "stages", "group", "arch", "osx_image", "version")...)
+	for _, k := range []string{"arch", "osx_image", "group"} {
+		if root[k] != nil {
+			t.notes.add(nil, k, "not converted; jobs run on the latest hosted runners")
+		}
+	}
+	t.notes.apply(t.wf, ".travis.yml")
+	res.Files = []*File{{Name: "travis.yml", Workflow: t.wf}}
+	return res, nil
+}
+
+// filter returns the entries of m with keys in keys.
+func filter(m map[string]any, keys []string) map[string]any {
+	out := map[string]any{}
+	for k, v := range m {
+		if slices.Contains(keys, k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// expands reports whether cfg lists several values for a key Travis
+// expands into a matrix.
+func (t *travis) expands(cfg map[string]any) bool {
+	lang := travisLanguages[str(cfg["language"])]
+	for _, k := range []string{lang.versions, "os"} {
+		if k != "" && len(list(cfg[k])) > 1 {
+			return true
+		}
+	}
+	return len(t.envMatrix(cfg["env"])) > 0
+}
+
+// first returns cfg with the first value of each key Travis expands,
+// which is what jobs added with include inherit.
+func (t *travis) first(cfg map[string]any) map[string]any {
+	out := maps.Clone(cfg)
+	for _, k := range []string{travisLanguages[str(cfg["language"])].versions, "os"} {
+		if v, ok := cfg[k].([]any); ok && len(v) > 0 {
+			out[k] = v[0]
+		}
+	}
+	if envs := t.envMatrix(cfg["env"]); len(envs) > 0 {
+		global := cfg["env"]
+		if m := mapping(global); m != nil {
+			global = m["global"]
+		} else {
+			global = nil
+		}
+		first := asList(mapping(cfg["env"])["jobs"])
+		if first == nil {
+			first = asList(mapping(cfg["env"])["matrix"])
+		}
+		if first == nil {
+			first = asList(cfg["env"])
+		}
+		out["env"] = map[string]any{"global": append(slices.Clone(asList(global)), first[0])}
+	}
+	return out
+}
+
+// envMatrix returns the environments of env that each make a job.
+func (t *travis) envMatrix(v any) []any {
+	if m := mapping(v); m != nil {
+		v = m["jobs"]
+		if v == nil {
+			v = m["matrix"]
+		}
+	} else if _, ok := v.([]any); !ok {
+		return nil
+	}
+	var out []any
+	for _, e := range asList(v) {
+		if vars := travisEnv(str(e)); len(vars) > 0 {
+			out = append(out, anyMap(vars))
+		}
+	}
+	return out
+}
+
+func anyMap(m map[string]string) map[string]any {
+	out := map[string]any{}
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+var travisAssign = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)=("[^"]*"|'[^']*'|\S*)`)
+
+// travisEnv parses assignments such as A=1 B="x y".
+func travisEnv(s string) map[string]string {
+	out := map[string]string{}
+	for _, m := range travisAssign.FindAllStringSubmatch(s, -1) {
+		v := m[2]
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') {
+			v = v[1 : len(v)-1]
+		}
+		out[m[1]] = v
+	}
+	return out
+}
+
+// job converts cfg, noting what it cannot convert under at when it is
+// set by own rather than inherited from the root.
+func (t *travis) job(j *build.JobBuilder, at string, cfg, own map[string]any) {
+	note := func(key, format string, args ...any) {
+		path := key
+		if top, _, _ := strings.Cut(key, "."); own[top] != nil {
+			path = join(at, key)
+		}
+		t.notes.add(j, path, format, args...)
+	}
+	known := maps.Clone(travisVariables.known)
+
+	// Where the job runs.
+	switch oses := list(cfg["os"]); {
+	case len(oses) > 1:
+		j.Matrix("os", asList(cfg["os"])...)
+		j.RunsOn("${{ matrix.os == 'osx' && 'macos-latest' || matrix.os == 'windows' && 'windows-latest' || 'ubuntu-latest' }}")
+		known["TRAVIS_OS_NAME"] = "${{ matrix.os }}"
+	case len(oses) == 1 && travisRunners[oses[0]] == "":
+		note("os", "%s runners are not available; the job runs on ubuntu-latest", oses[0])
+		j.RunsOn("ubuntu-latest")
+	case len(oses) == 1:
+		j.RunsOn(travisRunners[oses[0]])
+		known["TRAVIS_OS_NAME"] = oses[0]
+	default:
+		j.RunsOn("ubuntu-latest")
+		known["TRAVIS_OS_NAME"] = "linux"
+	}
+	if d := str(cfg["dist"]); d != "" {
+		note("dist", "Ubuntu %s is not selected; the job runs on the latest runner image", d)
+	}
+
+	// Environment variables.
+	global := cfg["env"]
+	if m := mapping(global); m != nil {
+		global = m["global"]
+	} else if _, ok := global.([]any); ok {
+		global = nil
+	}
+	for _, e := range asList(global) {
+		if m := mapping(e); m != nil && m["secure"] != nil {
+			note("env", "encrypted variables are not converted; add them as secrets")
+			continue
+		}
+		for k, v := range travisEnv(str(e)) {
+			j.Env(k, v)
+		}
+	}
+	if envs := t.envMatrix(cfg["env"]); len(envs) > 0 {
+		j.Matrix("env", envs...)
+	}
+
+	// The language and its versions.
+	steps := []*build.StepBuilder{checkout()}
+	lang, langName := travisLanguages[str(cfg["language"])], str(cfg["language"])
+	var setup *build.StepBuilder
+	if lang.action != "" {
+		setup = build.Uses(lang.action)
+		var versions []any
+		for _, v := range list(cfg[lang.versions]) {
+			versions = append(versions, travisVersion(langName, v))
+		}
+		switch {
+		case len(versions) > 1:
+			j.Matrix(lang.versions, versions...)
+			setup.With(lang.input, "${{ matrix."+lang.versions+" }}")
+			known[lang.variable] = "${{ matrix." + lang.versions + " }}"
+		case len(versions) == 1:
+			setup.With(lang.input, str(versions[0]))
+			known[lang.variable] = str(versions[0])
+		}
+		if langName == "java" {
+			setup.With("distribution", "temurin")
+		}
+		steps = append(steps, setup)
+	} else if langName != "" && !slices.Contains([]string{"generic", "minimal", "shell", "c", "cpp", "bash", "sh"}, langName) {
+		note("language", "set up %s with an action", langName)
+	}
+
+	// Caches.
+	switch c := cfg["cache"].(type) {
+	case string:
+		t.cache(j, at, lang, setup, map[string]any{c: true}, &steps)
+	case []any:
+		m := map[string]any{}
+		for _, name := range list(c) {
+			m[name] = true
+		}
+		t.cache(j, at, lang, setup, m, &steps)
+	case map[string]any:
+		t.cache(j, at, lang, setup, c, &steps)
+	}
+
+	// Services and addons.
+	for _, s := range list(cfg["services"]) {
+		switch ctr, ok := travisServices[s]; {
+		case ok:
+			j.Service(s, ctr)
+		case s == "docker":
+		case s == "xvfb":
+			steps = append(steps, build.Run("sudo apt-get update && sudo apt-get install -y xvfb\nXvfb :99 &\necho DISPLAY=:99 >> \"$GITHUB_ENV\"").Name("Start Xvfb"))
+		default:
+			note("services."+s, "not converted")
+		}
+	}
+	if addons := mapping(cfg["addons"]); addons != nil {
+		for _, k := range sortedKeys(addons) {
+			switch k {
+			case "apt":
+				apt := mapping(addons[k])
+				pkgs := list(apt["packages"])
+				if apt == nil {
+					pkgs = list(addons[k])
+				}
+				if len(pkgs) > 0 {
+					steps = append(steps, build.Run("sudo apt-get update\nsudo apt-get install -y "+strings.Join(pkgs, " ")).Name("Install packages"))
+				}
+				if apt["sources"] != nil {
+					note("addons.apt.sources", "add the package sources before installing")
+				}
+			case "postgresql":
+				ctr := travisServices["postgresql"]
+				ctr.Image += ":" + str(addons[k])
+				j.Service("postgresql", ctr)
+			default:
+				note("addons."+k, "not converted")
+			}
+		}
+	}
+	if g := mapping(cfg["git"]); g != nil {
+		if d, ok := g["depth"]; ok {
+			if d == false {
+				steps[0].With("fetch-depth", "0")
+			} else {
+				steps[0].With("fetch-depth", str(d))
+			}
+		}
+		if g["submodules"] == true {
+			steps[0].With("submodules", "recursive")
+		}
+	}
+	if envs := t.envMatrix(cfg["env"]); len(envs) > 0 {
+		steps = append(steps, build.Run(`echo '${{ toJSON(matrix.env) }}' | jq -r 'to_entries[] | "\(.key)=\(.value)"' >> "$GITHUB_ENV"`).Name("Set matrix environment"))
+	}
+
+	// The phases of the build.
+	var scripts []string
+	for _, phase := range travisPhases {
+		lines, ok := list(cfg[phase.key]), cfg[phase.key] != nil
+		if !ok {
+			switch phase.key {
+			case "install":
+				if lang.install != "" {
+					lines = []string{lang.install}
+				}
+			case "script":
+				if lang.script != "" {
+					lines = []string{lang.script}
+				} else {
+					note("script", "no script and no default for %s", langName)
+				}
+			}
+		}
+		if len(lines) == 0 || len(lines) == 1 && lines[0] == "skip" {
+			continue
+		}
+		s := build.Run(script(lines)).Name(phase.name)
+		if phase.cond != "" {
+			s.If(phase.cond)
+		}
+		steps = append(steps, s)
+		scripts = append(scripts, lines...)
+	}
+	j.Step(steps...)
+	vars := variables{known: known, prefix: travisVariables.prefix}
+	for k, v := range vars.env(t.notes, j, "script", scripts...) {
+		j.Env(k, v)
+	}
+}
+
+// travisVersion converts a Travis version name, such as openjdk11, into
+// one for the setup action of lang.
+func travisVersion(lang, v string) any {
+	if lang == "java" {
+		return strings.TrimLeft(v, "abcdefghijklmnopqrstuvwxyz")
+	}
+	return v
+}
+
+// cache converts Travis caches, using the setup action's own caching for
+// those of package managers.
+func (t *travis) cache(j *build.JobBuilder, at string, lang travisLanguage, setup *build.StepBuilder, c map[string]any, steps *[]*build.StepBuilder) {
+	for _, name := range sortedKeys(c) {
+		switch {
+		case name == "directories":
+			key := "${{ runner.os }}-${{ github.job }}-"
+			*steps = append(*steps, cacheStep(list(c[name]), key+"${{ github.sha }}", key))
+		case c[name] == false:
+		case setup != nil && lang.caches[name] != [2]string{}:
+			setup.With(lang.caches[name][0], lang.caches[name][1])
+		default:
+			t.notes.add(j, join(at, "cache."+name), "not converted")
+		}
+	}
+}
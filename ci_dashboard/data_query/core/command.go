@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/envfile"
+)
+
+// FormatCommand formats a workflow command line such as
+// "::warning file=a.go,line=3::message", escaping the properties and message.
+func FormatCommand(name string, props map[string]string, msg string) string {
+	var b strings.Builder
+	b.WriteString("::")
+	b.WriteString(name)
+	keys := make([]string, 0, len(props))
+	for k, v := range props {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(k + "=" + EscapeProperty(props[k]))
+	}
+	b.WriteString("::")
+	b.WriteString(EscapeData(msg))
+	return b.String()
+}
+
+// EscapeData escapes a command message.
+func EscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// EscapeProperty escapes a command property value.
+func EscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// FormatFileCommand formats a name/value pair for a GITHUB_OUTPUT, GITHUB_ENV
+// or GITHUB_STATE file. A random delimiter keeps multiline values intact.
+func FormatFileCommand(name, value string) (string, error) {
+	return envfile.Format(name, value)
+}
+
+// appendFile appends a name/value pair to the file named by the env
+// variable.
+func appendFile(env, name, value string) error {
+	if err := envfile.Append(os.Getenv(env), name, value); err != nil {
+		return fmt.Errorf("failed to write %s: %w", env, err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package core is the Go counterpart of @actions/core for actions written in
+// Go: it reads inputs and talks to the runner through workflow commands and
+// environment files.
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"testingdashboard/m/v2/envfile"
+)
+
+// Output is where workflow commands are written. The runner reads them from
+// the step's stdout.
+var Output io.Writer = os.Stdout
+
+func issue(name string, props map[string]string, msg string) {
+	fmt.Fprintln(Output, FormatCommand(name, props, msg))
+}
+
+// GetInput returns the value of an action input, trimmed of whitespace.
+func GetInput(name string) string {
+	key := "INPUT_" + strings.ToUpper(strings.ReplaceAll(name, " ", "_"))
+	return strings.TrimSpace(os.Getenv(key))
+}
+
+// GetRequiredInput is like GetInput but fails if the input is empty.
+func GetRequiredInput(name string) (string, error) {
+	v := GetInput(name)
+	if v == "" {
+		return "", fmt.Errorf("input required and not supplied: %s", name)
+	}
+	return v, nil
+}
+
+// GetBooleanInput parses an input following the YAML 1.2 core schema, as
+// @actions/core does.
+func GetBooleanInput(name string) (bool, error) {
+	switch GetInput(name) {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	return false, fmt.Errorf("input %s does not meet YAML 1.2 \"Core Schema\" specification; support boolean input list: true | True | TRUE | false | False | FALSE", name)
+}
+
+// GetMultilineInput splits an input into its non-empty lines.
+func GetMultilineInput(name string) []string {
+	var lines []string
+	for _, l := range strings.Split(GetInput(name), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// GetState returns a value saved with SaveState by an earlier phase of the
+// action.
+func GetState(name string) string {
+	return os.Getenv("STATE_" + name)
+}
+
+// SetOutput sets a step output.
+func SetOutput(name, value string) error {
+	return fileCommand("GITHUB_OUTPUT", "set-output", name, value)
+}
+
+// SaveState saves a value for the pre or post phase of the action.
+func SaveState(name, value string) error {
+	return fileCommand("GITHUB_STATE", "save-state", name, value)
+}
+
+// ExportVariable sets an environment variable for this and later steps. It
+// refuses the variables the runner would not set, such as the GITHUB_
+// defaults.
+func ExportVariable(name, value string) error {
+	if err := envfile.CheckEnv(name); err != nil {
+		return err
+	}
+	os.Setenv(name, value)
+	if os.Getenv("GITHUB_ENV") == "" {
+		return fmt.Errorf("GITHUB_ENV is not set")
+	}
+	return appendFile("GITHUB_ENV", name, value)
+}
+
+// AddPath prepends dir to PATH for this and later steps.
+func AddPath(dir string) error {
+	os.Setenv("PATH", dir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+	if os.Getenv("GITHUB_PATH") == "" {
+		return fmt.Errorf("GITHUB_PATH is not set")
+	}
+	if err := envfile.AppendPath(os.Getenv("GITHUB_PATH"), dir); err != nil {
+		return fmt.Errorf("failed to write GITHUB_PATH: %w", err)
+	}
+	return nil
+}
+
+// fileCommand writes name=value to the file named by env, falling back to
+// the deprecated stdout command on runners that do not set it.
+func fileCommand(env, command, name, value string) error {
+	if os.Getenv(env) == "" {
+		issue(command, map[string]string{"name": name}, value)
+		return nil
+	}
+	return appendFile(env, name, value)
+}
+
+// SetSecret masks value in the rest of the job log.
+func SetSecret(value string) {
+	issue("add-mask", nil, value)
+}
+
+// IsDebug reports whether step debug logging is enabled.
+func IsDebug() bool {
+	return os.Getenv("RUNNER_DEBUG") == "1"
+}
+
+// Debug writes a message shown only when debug logging is enabled.
+func Debug(msg string) {
+	issue("debug", nil, msg)
+}
+
+// Info writes a plain log line.
+func Info(msg string) {
+	fmt.Fprintln(Output, msg)
+}
+
+// StartGroup begins a collapsible group in the log.
+func StartGroup(name string) {
+	issue("group", nil, name)
+}
+
+// EndGroup ends the current group.
+func EndGroup() {
+	issue("endgroup", nil, "")
+}
+
+// Group runs fn inside a log group.
+func Group(name string, fn func() error) error {
+	StartGroup(name)
+	defer EndGroup()
+	return fn()
+}
+
+// Annotation locates an annotation in a file. Zero fields are omitted.
+type Annotation struct {
+	Title                  string
+	File                   string
+	StartLine, EndLine     int
+	StartColumn, EndColumn int
+}
+
+func (a Annotation) props() map[string]string {
+	props := map[string]string{"title": a.Title, "file": a.File}
+	for k, v := range map[string]int{"line": a.StartLine, "endLine": a.EndLine, "col": a.StartColumn, "endColumn": a.EndColumn} {
+		if v > 0 {
+			props[k] = strconv.Itoa(v)
+		}
+	}
+	return props
+}
+
+// Error creates an error annotation.
+func Error(msg string, a Annotation) {
+	issue("error", a.props(), msg)
+}
+
+// Warning creates a warning annotation.
+func Warning(msg string, a Annotation) {
+	issue("warning", a.props(), msg)
+}
+
+// Notice creates a notice annotation.
+func Notice(msg string, a Annotation) {
+	issue("notice", a.props(), msg)
+}
+
+// SetFailed reports msg as an error and exits with status 1.
+func SetFailed(msg string) {
+	Error(msg, Annotation{})
+	os.Exit(1)
+}
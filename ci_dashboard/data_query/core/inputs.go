@@ -0,0 +1,183 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetBoolInput parses an input following the YAML 1.2 core schema, as
+// @actions/core does. It is GetBooleanInput with the name of the other
+// typed getters.
+func GetBoolInput(name string) (bool, error) {
+	return GetBooleanInput(name)
+}
+
+// GetIntInput parses an input as a decimal integer.
+func GetIntInput(name string) (int, error) {
+	v := GetInput(name)
+	if v == "" {
+		return 0, fmt.Errorf("input required and not supplied: %s", name)
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("input %s is not an integer: %q", name, v)
+	}
+	return n, nil
+}
+
+// GetDurationInput parses an input as a Go duration such as 90s or 1h30m.
+// A bare number is taken as seconds.
+func GetDurationInput(name string) (time.Duration, error) {
+	v := GetInput(name)
+	if v == "" {
+		return 0, fmt.Errorf("input required and not supplied: %s", name)
+	}
+	invalid := fmt.Errorf("input %s is not a duration such as 30s or 5m: %q", name, v)
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		// ParseFloat also accepts NaN, Inf and magnitudes no Duration holds.
+		ns := secs * float64(time.Second)
+		if math.IsNaN(ns) || math.Abs(ns) >= math.MaxInt64 {
+			return 0, invalid
+		}
+		return time.Duration(ns), nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, invalid
+	}
+	return d, nil
+}
+
+// GetEnumInput returns an input that must be one of allowed, ignoring
+// case. It returns the allowed spelling.
+func GetEnumInput(name string, allowed ...string) (string, error) {
+	v := GetInput(name)
+	if i := slices.IndexFunc(allowed, func(a string) bool { return strings.EqualFold(a, v) }); i >= 0 {
+		return allowed[i], nil
+	}
+	if v == "" {
+		return "", fmt.Errorf("input required and not supplied: %s", name)
+	}
+	return "", fmt.Errorf("input %s must be one of %s, not %q", name, strings.Join(allowed, ", "), v)
+}
+
+// Inputs reads typed inputs and collects their errors, so an action can
+// report every invalid input at once rather than the first. Getters return
+// the default when an input is empty and the zero value when it is
+// invalid.
+//
+//	var in core.Inputs
+//	dir := in.Required("directory")
+//	retries := in.Int("retries", 3)
+//	if err := in.Err(); err != nil {
+//		core.SetFailed(err.Error())
+//	}
+type Inputs struct {
+	errs []error
+}
+
+func (in *Inputs) fail(err error) {
+	in.errs = append(in.errs, err)
+}
+
+// String returns an input, or def if it is empty.
+func (in *Inputs) String(name, def string) string {
+	if v := GetInput(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// Required returns an input that must not be empty.
+func (in *Inputs) Required(name string) string {
+	v, err := GetRequiredInput(name)
+	if err != nil {
+		in.fail(err)
+	}
+	return v
+}
+
+// Bool returns a boolean input.
+func (in *Inputs) Bool(name string, def bool) bool {
+	if GetInput(name) == "" {
+		return def
+	}
+	v, err := GetBoolInput(name)
+	if err != nil {
+		in.fail(err)
+	}
+	return v
+}
+
+// Int returns an integer input.
+func (in *Inputs) Int(name string, def int) int {
+	if GetInput(name) == "" {
+		return def
+	}
+	v, err := GetIntInput(name)
+	if err != nil {
+		in.fail(err)
+	}
+	return v
+}
+
+// Duration returns a duration input.
+func (in *Inputs) Duration(name string, def time.Duration) time.Duration {
+	if GetInput(name) == "" {
+		return def
+	}
+	v, err := GetDurationInput(name)
+	if err != nil {
+		in.fail(err)
+	}
+	return v
+}
+
+// Enum returns an input that must be one of allowed.
+func (in *Inputs) Enum(name, def string, allowed ...string) string {
+	if GetInput(name) == "" {
+		return def
+	}
+	v, err := GetEnumInput(name, allowed...)
+	if err != nil {
+		in.fail(err)
+	}
+	return v
+}
+
+// Multiline returns the non-empty lines of an input.
+func (in *Inputs) Multiline(name string) []string {
+	return GetMultilineInput(name)
+}
+
+// Check records an error for the input name unless ok, for constraints
+// the getters do not cover, such as ranges.
+func (in *Inputs) Check(ok bool, name, format string, args ...any) {
+	if !ok {
+		in.fail(fmt.Errorf("input %s %s", name, fmt.Sprintf(format, args...)))
+	}
+}
+
+// Err returns the errors of all the inputs read, joined, or nil.
+func (in *Inputs) Err() error {
+	return errors.Join(in.errs...)
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coverage
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"testingdashboard/m/v2/client"
+)
+
+// BaselineOptions locate the baseline profile: an artifact that a workflow
+// uploads on the target branch.
+type BaselineOptions struct {
+	// Workflow is the file name or ID of the workflow.
+	Workflow string
+	Branch   string
+	Artifact string
+	// File is the profile's name in the artifact. Empty means the first
+	// file that is a coverage report.
+	File string
+	// MaxRuns is how many successful runs to look through for one that
+	// still has the artifact. Defaults to 20.
+	MaxRuns int
+}
+
+// maxProfileSize bounds how much of an artifact entry is read.
+const maxProfileSize = 256 << 20
+
+// Baseline downloads the profile of the newest successful run of the
+// workflow on the branch that has the artifact, and returns it normalized
+// to root with the run it came from.
+func Baseline(ctx context.Context, c *client.Client, owner, repo, root string, opts BaselineOptions) (*Profile, *client.Run, error) {
+	if opts.MaxRuns <= 0 {
+		opts.MaxRuns = 20
+	}
+	n := 0
+	for run, err := range c.ListRuns(ctx, owner, repo, client.RunsOptions{Workflow: opts.Workflow, Branch: opts.Branch, Status: "success"}) {
+		if err != nil {
+			return nil, nil, err
+		}
+		if n++; n > opts.MaxRuns {
+			break
+		}
+		artifacts, err := c.ListRunArtifacts(ctx, owner, repo, run.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, a := range artifacts {
+			if a.Name != opts.Artifact || a.Expired {
+				continue
+			}
+			p, err := download(ctx, c, owner, repo, a, opts.File)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read artifact %s of run %d: %w", a.Name, run.ID, err)
+			}
+			p.Normalize(root)
+			return p, run, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no recent successful run of %s on %s has an artifact named %s", opts.Workflow, opts.Branch, opts.Artifact)
+}
+
+func download(ctx context.Context, c *client.Client, owner, repo string, a *client.Artifact, file string) (*Profile, error) {
+	resp, err := c.DownloadArtifact(ctx, owner, repo, a.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxProfileSize))
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() || (file != "" && zf.Name != file && path.Base(zf.Name) != file) {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(io.LimitReader(r, maxProfileSize))
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := Detect(content); err != nil && file == "" {
+			continue
+		}
+		return Parse(content)
+	}
+	if file != "" {
+		return nil, fmt.Errorf("the artifact has no file %s", file)
+	}
+	return nil, fmt.Errorf("the artifact has no coverage report")
+}
@@ -0,0 +1,352 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package coverage reads Go, lcov and Cobertura coverage reports, compares
+// them with a baseline such as the target branch's, and gates and reports
+// the change.
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats of coverage reports.
+const (
+	Go        = "go"
+	LCOV      = "lcov"
+	Cobertura = "cobertura"
+)
+
+// Profile is the coverage of a set of files.
+type Profile struct {
+	// Format is the format the profile was read from. Go profiles count
+	// statements, the others lines.
+	Format string           `json:"format"`
+	Files  map[string]*File `json:"files"`
+}
+
+// File is the coverage of one file.
+type File struct {
+	Path    string `json:"path"`
+	Covered int    `json:"covered"`
+	Total   int    `json:"total"`
+	// Lines maps line numbers to how often they ran.
+	Lines map[int]int `json:"-"`
+}
+
+// Totals is covered out of total statements or lines.
+type Totals struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+}
+
+// Percent returns the covered share, or 100 when there is nothing to
+// cover.
+func (t Totals) Percent() float64 {
+	if t.Total == 0 {
+		return 100
+	}
+	return 100 * float64(t.Covered) / float64(t.Total)
+}
+
+// Totals returns the coverage of the file.
+func (f *File) Totals() Totals {
+	return Totals{Covered: f.Covered, Total: f.Total}
+}
+
+// Totals sums the coverage of every file.
+func (p *Profile) Totals() Totals {
+	var t Totals
+	for _, f := range p.Files {
+		t.Covered += f.Covered
+		t.Total += f.Total
+	}
+	return t
+}
+
+// Paths returns the file paths in order.
+func (p *Profile) Paths() []string {
+	paths := make([]string, 0, len(p.Files))
+	for path := range p.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Detect returns the format of a report from its content.
+func Detect(data []byte) (string, error) {
+	head := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(head, []byte("mode:")):
+		return Go, nil
+	case bytes.HasPrefix(head, []byte("<")):
+		return Cobertura, nil
+	case bytes.HasPrefix(head, []byte("TN:")) || bytes.HasPrefix(head, []byte("SF:")):
+		return LCOV, nil
+	}
+	return "", errors.New("unknown coverage format")
+}
+
+// Parse reads a report, detecting its format.
+func Parse(data []byte) (*Profile, error) {
+	format, err := Detect(data)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case Go:
+		return ParseGo(data)
+	case LCOV:
+		return ParseLCOV(data)
+	default:
+		return ParseCobertura(data)
+	}
+}
+
+// ParseFile reads the report in the file name and makes its paths
+// relative to root with Normalize.
+func ParseFile(name, root string) (*Profile, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	p.Normalize(root)
+	return p, nil
+}
+
+// ParseGo reads a profile written by go test -coverprofile. Blocks that
+// appear more than once, as when profiles of several packages are
+// concatenated, count once with their highest count.
+func ParseGo(data []byte) (*Profile, error) {
+	type block struct {
+		file          string
+		start, end    int
+		startCol, col int
+	}
+	type counts struct{ stmts, count int }
+	blocks := map[block]counts{}
+	var order []block
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// file.go:start.col,end.col statements count
+		i := strings.LastIndexByte(line, ':')
+		fields := strings.Fields(line[i+1:])
+		if i < 0 || len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: malformed block %q", n, line)
+		}
+		var b block
+		b.file = line[:i]
+		if _, err := fmt.Sscanf(fields[0], "%d.%d,%d.%d", &b.start, &b.startCol, &b.end, &b.col); err != nil {
+			return nil, fmt.Errorf("line %d: malformed range %q", n, fields[0])
+		}
+		stmts, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("line %d: malformed counts in %q", n, line)
+		}
+		prev, ok := blocks[b]
+		if !ok {
+			order = append(order, b)
+		}
+		blocks[b] = counts{stmts: stmts, count: max(count, prev.count)}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	p := &Profile{Format: Go, Files: map[string]*File{}}
+	for _, b := range order {
+		c := blocks[b]
+		f := p.file(b.file)
+		f.Total += c.stmts
+		if c.count > 0 {
+			f.Covered += c.stmts
+		}
+		for l := b.start; l <= b.end; l++ {
+			f.Lines[l] = max(f.Lines[l], c.count)
+		}
+	}
+	return p, nil
+}
+
+// ParseLCOV reads an lcov tracefile, counting the lines in DA records.
+func ParseLCOV(data []byte) (*Profile, error) {
+	p := &Profile{Format: LCOV, Files: map[string]*File{}}
+	var f *File
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		key, value, _ := strings.Cut(line, ":")
+		switch key {
+		case "SF":
+			f = p.file(value)
+		case "DA":
+			if f == nil {
+				return nil, fmt.Errorf("line %d: DA record outside a file", n)
+			}
+			parts := strings.Split(value, ",")
+			if len(parts) < 2 {
+				return nil, fmt.Errorf("line %d: malformed DA record %q", n, line)
+			}
+			num, err1 := strconv.Atoi(parts[0])
+			hits, err2 := strconv.ParseFloat(parts[1], 64)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("line %d: malformed DA record %q", n, line)
+			}
+			prev, seen := f.Lines[num]
+			f.Lines[num] = max(prev, int(hits))
+			if !seen {
+				f.Total++
+			}
+		case "end_of_record":
+			f = nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	p.count()
+	return p, nil
+}
+
+// ParseCobertura reads a Cobertura XML report. Relative file names are
+// joined with the first source directory.
+func ParseCobertura(data []byte) (*Profile, error) {
+	var report struct {
+		XMLName xml.Name `xml:"coverage"`
+		Sources []string `xml:"sources>source"`
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number int    `xml:"number,attr"`
+				Hits   string `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"packages>package>classes>class"`
+	}
+	if err := xml.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	source := ""
+	if len(report.Sources) > 0 {
+		source = strings.TrimSpace(report.Sources[0])
+	}
+	p := &Profile{Format: Cobertura, Files: map[string]*File{}}
+	for _, c := range report.Classes {
+		name := c.Filename
+		if source != "" && !filepath.IsAbs(name) && source != "." {
+			name = filepath.Join(source, name)
+		}
+		f := p.file(filepath.ToSlash(name))
+		for _, l := range c.Lines {
+			hits, _ := strconv.ParseFloat(l.Hits, 64)
+			f.Lines[l.Number] = max(f.Lines[l.Number], int(hits))
+		}
+	}
+	for _, f := range p.Files {
+		f.Total = len(f.Lines)
+	}
+	p.count()
+	return p, nil
+}
+
+func (p *Profile) file(path string) *File {
+	f, ok := p.Files[path]
+	if !ok {
+		f = &File{Path: path, Lines: map[int]int{}}
+		p.Files[path] = f
+	}
+	return f
+}
+
+// count sets the covered lines of line-based profiles.
+func (p *Profile) count() {
+	for _, f := range p.Files {
+		f.Covered = 0
+		for _, hits := range f.Lines {
+			if hits > 0 {
+				f.Covered++
+			}
+		}
+	}
+}
+
+// Normalize makes the paths of p relative to the repository at root so
+// profiles from different machines compare. Go profiles name files by
+// import path, so the module path of root's go.mod is stripped; absolute
+// paths under root become relative.
+func (p *Profile) Normalize(root string) {
+	module := ""
+	if p.Format == Go {
+		if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+			module = modulePath(data)
+		}
+	}
+	absRoot, _ := filepath.Abs(root)
+	files := map[string]*File{}
+	for path, f := range p.Files {
+		switch {
+		case module != "" && strings.HasPrefix(path, module+"/"):
+			path = strings.TrimPrefix(path, module+"/")
+		case filepath.IsAbs(path):
+			if rel, err := filepath.Rel(absRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = filepath.ToSlash(rel)
+			}
+		}
+		path = strings.TrimPrefix(path, "./")
+		if prev, ok := files[path]; ok {
+			for l, hits := range f.Lines {
+				prev.Lines[l] = max(prev.Lines[l], hits)
+			}
+			prev.Covered, prev.Total = prev.Covered+f.Covered, prev.Total+f.Total
+			continue
+		}
+		f.Path = path
+		files[path] = f
+	}
+	p.Files = files
+}
+
+// modulePath returns the module path a go.mod declares.
+func modulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			rest, _, _ = strings.Cut(rest, "//")
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,230 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coverage
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/checks"
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/summary"
+)
+
+// Diff compares a profile with its baseline.
+type Diff struct {
+	Head Totals `json:"head"`
+	// Base is nil without a baseline.
+	Base *Totals `json:"base,omitempty"`
+	// Files lists the files whose coverage changed, were added or were
+	// removed, by path.
+	Files []FileDiff `json:"files"`
+	// Violations are the gate's complaints; the change passes when there
+	// are none.
+	Violations []string `json:"violations,omitempty"`
+}
+
+// FileDiff is the change in coverage of one file. Base or Head is nil when
+// the file was added or removed.
+type FileDiff struct {
+	Path string  `json:"path"`
+	Base *Totals `json:"base,omitempty"`
+	Head *Totals `json:"head,omitempty"`
+}
+
+// Delta returns the change in percentage points of the overall coverage,
+// or zero without a baseline.
+func (d *Diff) Delta() float64 {
+	if d.Base == nil {
+		return 0
+	}
+	return d.Head.Percent() - d.Base.Percent()
+}
+
+// Delta returns the change in percentage points; an added file counts from
+// zero and a removed one to zero.
+func (f FileDiff) Delta() float64 {
+	var base, head float64
+	if f.Base != nil {
+		base = f.Base.Percent()
+	}
+	if f.Head != nil {
+		head = f.Head.Percent()
+	}
+	return head - base
+}
+
+// Change describes the change for display: added, removed or the signed
+// percentage points.
+func (f FileDiff) Change() string {
+	switch {
+	case f.Base == nil:
+		return "added"
+	case f.Head == nil:
+		return "removed"
+	}
+	return points(f.Delta())
+}
+
+// Compare compares head with base, which may be nil.
+func Compare(base, head *Profile) *Diff {
+	d := &Diff{Head: head.Totals()}
+	if base == nil {
+		return d
+	}
+	bt := base.Totals()
+	d.Base = &bt
+	for _, path := range head.Paths() {
+		h := head.Files[path].Totals()
+		b, ok := base.Files[path]
+		if !ok {
+			d.Files = append(d.Files, FileDiff{Path: path, Head: &h})
+			continue
+		}
+		if bt := b.Totals(); bt != h {
+			d.Files = append(d.Files, FileDiff{Path: path, Base: &bt, Head: &h})
+		}
+	}
+	for _, path := range base.Paths() {
+		if _, ok := head.Files[path]; !ok {
+			bt := base.Files[path].Totals()
+			d.Files = append(d.Files, FileDiff{Path: path, Base: &bt})
+		}
+	}
+	return d
+}
+
+// Gate is what a change must meet. Zero fields are not checked.
+type Gate struct {
+	// Min is the lowest overall coverage allowed, in percent.
+	Min float64
+	// MaxDrop is how many percentage points overall coverage may fall
+	// below the baseline.
+	MaxDrop float64
+	// MinFile is the lowest coverage allowed for files the change adds or
+	// whose coverage it lowers.
+	MinFile float64
+}
+
+// Check records in d.Violations where d falls short of g and reports
+// whether it passes.
+func (g Gate) Check(d *Diff) bool {
+	d.Violations = nil
+	if g.Min > 0 && below(d.Head.Percent(), g.Min) {
+		d.Violations = append(d.Violations, fmt.Sprintf("coverage is %.2f%%, below the minimum of %.2f%%", d.Head.Percent(), g.Min))
+	}
+	if g.MaxDrop > 0 && d.Base != nil && below(d.Delta(), -g.MaxDrop) {
+		d.Violations = append(d.Violations, fmt.Sprintf("coverage fell %.2f points from %.2f%%, more than the %.2f allowed", -d.Delta(), d.Base.Percent(), g.MaxDrop))
+	}
+	if g.MinFile > 0 {
+		for _, f := range d.Files {
+			if f.Head == nil || (f.Base != nil && f.Delta() >= 0) {
+				continue
+			}
+			if below(f.Head.Percent(), g.MinFile) {
+				d.Violations = append(d.Violations, fmt.Sprintf("%s: coverage is %.2f%%, below the minimum of %.2f%% for changed files", f.Path, f.Head.Percent(), g.MinFile))
+			}
+		}
+	}
+	return len(d.Violations) == 0
+}
+
+// below compares percentages to two decimals, as they are shown.
+func below(v, limit float64) bool {
+	return math.Round(v*100) < math.Round(limit*100)
+}
+
+// maxFiles is how many changed files the summary lists.
+const maxFiles = 100
+
+// Summary renders the diff for GITHUB_STEP_SUMMARY.
+func (d *Diff) Summary(title string) *summary.Builder {
+	b := summary.New().Heading(html.EscapeString(title), 2)
+	line := fmt.Sprintf("Coverage is <b>%.2f%%</b> (%d of %d)", d.Head.Percent(), d.Head.Covered, d.Head.Total)
+	if d.Base != nil {
+		line += fmt.Sprintf(", %s points from %.2f%% on the baseline", points(d.Delta()), d.Base.Percent())
+	}
+	b.Paragraph(line + ".")
+	if len(d.Violations) > 0 {
+		items := make([]string, len(d.Violations))
+		for i, v := range d.Violations {
+			items[i] = "❌ " + html.EscapeString(v)
+		}
+		b.List(items, false)
+	}
+	if d.Base == nil {
+		return b
+	}
+	if len(d.Files) == 0 {
+		return b.Paragraph("No file's coverage changed.")
+	}
+	var rows [][]string
+	for i, f := range d.Files {
+		if i == maxFiles {
+			rows = append(rows, []string{fmt.Sprintf("and %d more", len(d.Files)-maxFiles), "", "", ""})
+			break
+		}
+		rows = append(rows, []string{"<code>" + html.EscapeString(f.Path) + "</code>", percent(f.Base), percent(f.Head), f.Change()})
+	}
+	return b.SimpleTable([]string{"File", "Base", "Head", "Change"}, rows)
+}
+
+func percent(t *Totals) string {
+	if t == nil {
+		return "–"
+	}
+	return fmt.Sprintf("%.2f%%", t.Percent())
+}
+
+func points(delta float64) string {
+	switch r := math.Round(delta * 100); {
+	case r > 0:
+		return fmt.Sprintf("+%.2f", delta)
+	case r < 0:
+		return fmt.Sprintf("%.2f", delta)
+	}
+	return "±0.00"
+}
+
+// Publish creates a completed check run named name on the commit sha with
+// the summary as its output. It fails the check when the gate does.
+func (d *Diff) Publish(ctx context.Context, c *client.Client, owner, repo, sha, name string) (*checks.CheckRun, error) {
+	conclusion := checks.ConclusionSuccess
+	if len(d.Violations) > 0 {
+		conclusion = checks.ConclusionFailure
+	}
+	text, err := d.Summary(name).Truncate(checks.MaxText, summary.KeepHead)
+	if err != nil {
+		return nil, err
+	}
+	title := fmt.Sprintf("%.2f%% covered", d.Head.Percent())
+	if d.Base != nil {
+		title += fmt.Sprintf(" (%s)", points(d.Delta()))
+	}
+	if len(d.Violations) > 0 {
+		title += ": " + strings.Join(d.Violations, "; ")
+	}
+	return checks.Create(ctx, c, owner, repo, &checks.Run{
+		Name:        name,
+		HeadSHA:     sha,
+		Conclusion:  conclusion,
+		CompletedAt: time.Now(),
+		Output:      &checks.Output{Title: title, Summary: text},
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cron parses the cron schedules of on.schedule, which use the
+// five-field POSIX dialect evaluated in UTC, and computes when they run.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinInterval is the shortest interval GitHub runs scheduled workflows at.
+const MinInterval = 5 * time.Minute
+
+// field describes one of the five fields.
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 6, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	Source string
+	// sets holds the allowed values of each field as bit sets.
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record unrestricted day fields, which change how
+	// the two combine.
+	domStar, dowStar bool
+}
+
+// Parse parses a cron expression with minute, hour, day of month, month and
+// day of week fields. Fields accept *, values, ranges (1-5), lists (1,3)
+// and steps (*/15, 10-40/10); months and weekdays also accept three-letter
+// names.
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(parts))
+	}
+	s := &Schedule{Source: expr}
+	sets := []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		*sets[i] = set
+	}
+	s.domStar = strings.HasPrefix(parts[2], "*")
+	s.dowStar = strings.HasPrefix(parts[4], "*")
+	if _, ok := s.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)); !ok {
+		return nil, fmt.Errorf("cron expression %q never runs", expr)
+	}
+	return s, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(a, f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(b, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, rng)
+			}
+		default:
+			v, err := parseValue(rng, f)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, stepStr)
+			}
+			step = n
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", f.name, s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s %d is out of range %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
+
+// matchesDay applies cron's rule that when both day fields are restricted,
+// a day matching either one is enough.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	switch {
+	case s.domStar || s.dowStar:
+		return dom && dow
+	default:
+		return dom || dow
+	}
+}
+
+// Next returns the first time after t, in UTC, at which the schedule runs.
+// It reports false if there is none within five years, which only happens
+// for dates that do not exist such as February 30.
+func (s *Schedule) Next(t time.Time) (time.Time, bool) {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !has(s.hour, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NextRuns returns the next n times after from at which the schedule runs.
+func (s *Schedule) NextRuns(from time.Time, n int) []time.Time {
+	var runs []time.Time
+	for len(runs) < n {
+		next, ok := s.Next(from)
+		if !ok {
+			break
+		}
+		runs = append(runs, next)
+		from = next
+	}
+	return runs
+}
+
+// MinGap returns the shortest time between two consecutive runs among the
+// first runs of the schedule. Every field repeats at least yearly, and
+// short gaps come from the minute and hour fields, which repeat daily, so a
+// sample of a few days of runs finds them.
+func (s *Schedule) MinGap() time.Duration {
+	runs := s.NextRuns(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), 2000)
+	gap := time.Duration(0)
+	for i := 1; i < len(runs); i++ {
+		if d := runs[i].Sub(runs[i-1]); gap == 0 || d < gap {
+			gap = d
+		}
+	}
+	return gap
+}
+
+// Warnings describes ways the schedule will not behave as written on
+// GitHub.
+func (s *Schedule) Warnings() []string {
+	var out []string
+	if gap := s.MinGap(); gap > 0 && gap < MinInterval {
+		out = append(out, fmt.Sprintf("runs every %s, but GitHub runs scheduled workflows at most every %s", gap, MinInterval))
+	}
+	return out
+}
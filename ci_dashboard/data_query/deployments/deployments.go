@@ -0,0 +1,265 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deployments creates deployments and reports their statuses, which
+// the Environments of a repository show with a link to the environment and
+// to the log of the deploy.
+package deployments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/client"
+)
+
+// States of a deployment status.
+const (
+	StateQueued     = "queued"
+	StatePending    = "pending"
+	StateInProgress = "in_progress"
+	StateSuccess    = "success"
+	StateFailure    = "failure"
+	StateError      = "error"
+	StateInactive   = "inactive"
+)
+
+// Deployment is a request to deploy a commit to an environment.
+type Deployment struct {
+	ID          int64  `json:"id"`
+	Ref         string `json:"ref"`
+	SHA         string `json:"sha"`
+	Task        string `json:"task"`
+	Environment string `json:"environment"`
+	// OriginalEnvironment is the environment first deployed to, which
+	// differs from Environment once the deployment is redirected.
+	OriginalEnvironment   string          `json:"original_environment"`
+	Description           string          `json:"description"`
+	Payload               json.RawMessage `json:"payload,omitempty"`
+	TransientEnvironment  bool            `json:"transient_environment"`
+	ProductionEnvironment bool            `json:"production_environment"`
+	Creator               struct {
+		Login string `json:"login"`
+	} `json:"creator"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	URL       string    `json:"url"`
+}
+
+// Options describe a deployment to create.
+type Options struct {
+	// Ref is the branch, tag or SHA to deploy.
+	Ref         string
+	Environment string
+	// Task defaults to deploy.
+	Task        string
+	Description string
+	// Payload is extra information for the deployer, encoded as JSON.
+	Payload any
+	// RequiredContexts are the commit statuses and checks that must pass
+	// before the deployment is created. Nil requires none, since a
+	// deployment made from a run would otherwise wait on that run's own
+	// check.
+	RequiredContexts []string
+	// AutoMerge merges the default branch into Ref first when it is behind.
+	AutoMerge bool
+	// Transient marks an environment that goes away, such as a review app,
+	// whose earlier deployments are not kept active.
+	Transient bool
+	// Production marks a production environment. Nil leaves the default,
+	// which is true for an environment named production.
+	Production *bool
+}
+
+// Create creates a deployment. GitHub answers with 202 and no deployment
+// when AutoMerge merged the default branch first; Create reports that as
+// an error because the merge starts a new deployment of its own.
+func Create(ctx context.Context, c *client.Client, owner, repo string, opts Options) (*Deployment, error) {
+	switch {
+	case opts.Ref == "":
+		return nil, errors.New("a deployment needs a ref")
+	case opts.Environment == "":
+		return nil, errors.New("a deployment needs an environment")
+	}
+	body := map[string]any{
+		"ref":                    opts.Ref,
+		"environment":            opts.Environment,
+		"auto_merge":             opts.AutoMerge,
+		"required_contexts":      opts.RequiredContexts,
+		"transient_environment":  opts.Transient,
+		"production_environment": opts.Production,
+	}
+	if opts.RequiredContexts == nil {
+		body["required_contexts"] = []string{}
+	}
+	if opts.Production == nil {
+		delete(body, "production_environment")
+	}
+	for k, v := range map[string]string{"task": opts.Task, "description": opts.Description} {
+		if v != "" {
+			body[k] = v
+		}
+	}
+	if opts.Payload != nil {
+		body["payload"] = opts.Payload
+	}
+	var d Deployment
+	if err := c.Do(ctx, http.MethodPost, deploymentsPath(owner, repo), body, &d); err != nil {
+		return nil, fmt.Errorf("failed to create a deployment of %s to %s: %w", opts.Ref, opts.Environment, err)
+	}
+	if d.ID == 0 {
+		return nil, fmt.Errorf("the default branch was merged into %s instead of deploying it", opts.Ref)
+	}
+	return &d, nil
+}
+
+// Get returns a deployment.
+func Get(ctx context.Context, c *client.Client, owner, repo string, id int64) (*Deployment, error) {
+	var d Deployment
+	if err := c.Do(ctx, http.MethodGet, deploymentsPath(owner, repo, id), nil, &d); err != nil {
+		return nil, fmt.Errorf("failed to get deployment %d: %w", id, err)
+	}
+	return &d, nil
+}
+
+// Filter selects the deployments List returns. Zero fields match all.
+type Filter struct {
+	Environment string
+	Ref         string
+	SHA         string
+	Task        string
+}
+
+// List returns the newest deployments matching f, at most 100.
+func List(ctx context.Context, c *client.Client, owner, repo string, f Filter) ([]*Deployment, error) {
+	q := url.Values{"per_page": {"100"}}
+	for k, v := range map[string]string{"environment": f.Environment, "ref": f.Ref, "sha": f.SHA, "task": f.Task} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	var out []*Deployment
+	if err := c.Do(ctx, http.MethodGet, deploymentsPath(owner, repo)+"?"+q.Encode(), nil, &out); err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	return out, nil
+}
+
+// Delete deletes a deployment. Only inactive deployments, or the only
+// deployment of an environment, can be deleted; set an inactive status
+// first.
+func Delete(ctx context.Context, c *client.Client, owner, repo string, id int64) error {
+	if err := c.Do(ctx, http.MethodDelete, deploymentsPath(owner, repo, id), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete deployment %d: %w", id, err)
+	}
+	return nil
+}
+
+// Status is a state a deployment reached.
+type Status struct {
+	ID    int64  `json:"id"`
+	State string `json:"state"`
+	// Environment is the environment the deployment is in, which a status
+	// can change.
+	Environment    string    `json:"environment"`
+	Description    string    `json:"description"`
+	EnvironmentURL string    `json:"environment_url"`
+	LogURL         string    `json:"log_url"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// StatusOptions describe a deployment status.
+type StatusOptions struct {
+	// State is one of the State constants.
+	State string
+	// Description is cut to the 140 characters the API allows.
+	Description string
+	// EnvironmentURL links to the deployed environment, LogURL to the
+	// output of the deploy.
+	EnvironmentURL string
+	LogURL         string
+	// Environment redirects the deployment to another environment.
+	Environment string
+	// AutoInactive marks the environment's earlier deployments inactive on
+	// success. Nil leaves the default, which is true.
+	AutoInactive *bool
+}
+
+// maxDescription is how many characters a status description may have.
+const maxDescription = 140
+
+// SetStatus adds a status to a deployment.
+func SetStatus(ctx context.Context, c *client.Client, owner, repo string, id int64, opts StatusOptions) (*Status, error) {
+	if opts.State == "" {
+		return nil, errors.New("a deployment status needs a state")
+	}
+	body := map[string]any{"state": opts.State}
+	for k, v := range map[string]string{
+		"description":     truncate(opts.Description, maxDescription),
+		"environment_url": opts.EnvironmentURL,
+		"log_url":         opts.LogURL,
+		"environment":     opts.Environment,
+	} {
+		if v != "" {
+			body[k] = v
+		}
+	}
+	if opts.AutoInactive != nil {
+		body["auto_inactive"] = *opts.AutoInactive
+	}
+	var s Status
+	if err := c.Do(ctx, http.MethodPost, deploymentsPath(owner, repo, id, "statuses"), body, &s); err != nil {
+		return nil, fmt.Errorf("failed to set the status of deployment %d to %s: %w", id, opts.State, err)
+	}
+	return &s, nil
+}
+
+// Statuses returns the statuses of a deployment, newest first.
+func Statuses(ctx context.Context, c *client.Client, owner, repo string, id int64) ([]*Status, error) {
+	var out []*Status
+	if err := c.Do(ctx, http.MethodGet, deploymentsPath(owner, repo, id, "statuses")+"?per_page=100", nil, &out); err != nil {
+		return nil, fmt.Errorf("failed to list the statuses of deployment %d: %w", id, err)
+	}
+	return out, nil
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+func deploymentsPath(owner, repo string, elems ...any) string {
+	p := fmt.Sprintf("/repos/%s/%s/deployments", url.PathEscape(owner), url.PathEscape(repo))
+	for _, e := range elems {
+		p += "/" + url.PathEscape(fmt.Sprint(e))
+	}
+	return p
+}
+
+// splitRepository splits owner/repo.
+func splitRepository(full string) (owner, repo string, err error) {
+	owner, repo, ok := strings.Cut(full, "/")
+	if !ok || owner == "" || repo == "" {
+		return "", "", fmt.Errorf("invalid repository %q", full)
+	}
+	return owner, repo, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/endpoints"
+)
+
+// Run is the workflow run doing a deploy.
+type Run struct {
+	Owner, Repo string
+	SHA         string
+	Ref         string
+	// LogURL is the page of the run's attempt, which deployment statuses
+	// link to.
+	LogURL string
+}
+
+// CurrentRun returns the run from the GITHUB_ variables of a job.
+func CurrentRun() (*Run, error) {
+	owner, repo, err := splitRepository(os.Getenv("GITHUB_REPOSITORY"))
+	if err != nil {
+		return nil, fmt.Errorf("%w; is this running in a workflow?", err)
+	}
+	r := &Run{Owner: owner, Repo: repo, SHA: os.Getenv("GITHUB_SHA"), Ref: os.Getenv("GITHUB_REF")}
+	if id := os.Getenv("GITHUB_RUN_ID"); id != "" {
+		r.LogURL = endpoints.FromEnv().Repository(owner+"/"+repo) + "/actions/runs/" + id
+		if attempt := os.Getenv("GITHUB_RUN_ATTEMPT"); attempt != "" {
+			r.LogURL += "/attempts/" + attempt
+		}
+	}
+	return r, nil
+}
+
+// Tracker reports the progress of a deployment made by a run.
+type Tracker struct {
+	Client     *client.Client
+	Run        *Run
+	Deployment *Deployment
+	// EnvironmentURL is sent with every status once set, typically when
+	// the deploy knows where it went.
+	EnvironmentURL string
+}
+
+// Start creates a deployment of the run's commit, unless opts.Ref names
+// another, and marks it in progress with a link to the run.
+func Start(ctx context.Context, c *client.Client, run *Run, opts Options) (*Tracker, error) {
+	if opts.Ref == "" {
+		opts.Ref = run.SHA
+	}
+	d, err := Create(ctx, c, run.Owner, run.Repo, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tracker{Client: c, Run: run, Deployment: d}
+	if err := t.Update(ctx, StateInProgress, opts.Description); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Update adds a status in state to the deployment.
+func (t *Tracker) Update(ctx context.Context, state, description string) error {
+	_, err := SetStatus(ctx, t.Client, t.Run.Owner, t.Run.Repo, t.Deployment.ID, StatusOptions{
+		State:          state,
+		Description:    description,
+		EnvironmentURL: t.EnvironmentURL,
+		LogURL:         t.Run.LogURL,
+	})
+	return err
+}
+
+// Finish marks the deployment successful if err is nil, and failed with
+// err as the description otherwise. A cancelled context counts as an
+// error of the deploy rather than of its outcome.
+func (t *Tracker) Finish(ctx context.Context, err error) error {
+	switch {
+	case err == nil:
+		return t.Update(ctx, StateSuccess, "")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return t.Update(context.WithoutCancel(ctx), StateError, err.Error())
+	}
+	return t.Update(ctx, StateFailure, err.Error())
+}
@@ -0,0 +1,681 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff compares two versions of a workflow by meaning rather than
+// text: jobs and steps added or removed, actions and images changed,
+// permissions broadened and trigger filters narrowed.
+package diff
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/permissions"
+	"testingdashboard/m/v2/summary"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Kinds of changes.
+const (
+	KindAdded   = "added"
+	KindRemoved = "removed"
+	KindChanged = "changed"
+	// KindBroadened is reported for permissions that grant more and for
+	// triggers that match more events; KindNarrowed for the opposite.
+	KindBroadened = "broadened"
+	KindNarrowed  = "narrowed"
+)
+
+// Change is one difference between two versions of a workflow.
+type Change struct {
+	// Path locates the change, such as jobs.build.steps[2].uses. Step
+	// indexes are those of the new version unless the step was removed.
+	Path    string `json:"path"`
+	Job     string `json:"job,omitempty"`
+	Step    string `json:"step,omitempty"`
+	Kind    string `json:"kind"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+	Message string `json:"message"`
+}
+
+func (c Change) String() string { return c.Message }
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(c Change, format string, args ...any) {
+	c.Message = fmt.Sprintf(format, args...)
+	d.changes = append(d.changes, c)
+}
+
+// value reports a change of a single value, described by what.
+func (d *differ) value(c Change, what, old, new string) {
+	switch {
+	case old == new:
+		return
+	case old == "":
+		c.Kind, c.New = KindAdded, new
+		d.add(c, "%s set to %s", what, new)
+	case new == "":
+		c.Kind, c.Old = KindRemoved, old
+		d.add(c, "%s removed (was %s)", what, old)
+	default:
+		c.Kind, c.Old, c.New = KindChanged, old, new
+		d.add(c, "%s changed from %s to %s", what, old, new)
+	}
+}
+
+// text reports a change of a value too long to quote, such as a script.
+func (d *differ) text(c Change, what, old, new string) {
+	if old == new {
+		return
+	}
+	c.Kind, c.Old, c.New = KindChanged, old, new
+	switch {
+	case old == "":
+		c.Kind = KindAdded
+		d.add(c, "%s added", what)
+	case new == "":
+		c.Kind = KindRemoved
+		d.add(c, "%s removed", what)
+	default:
+		d.add(c, "%s changed", what)
+	}
+}
+
+// mapping reports the keys of a free-form mapping such as env that were
+// added, removed or changed. of says whose mapping it is.
+func (d *differ) mapping(c Change, what, of string, old, new map[string]string) {
+	path := c.Path
+	for _, k := range union(keys(old), keys(new)) {
+		c.Path = path + "." + k
+		d.value(c, what+" "+k+of, old[k], new[k])
+	}
+}
+
+// Compare returns the changes from old to new in the order of the new
+// workflow. Either may be nil for a workflow that was added or removed.
+func Compare(old, new *workflow.Workflow) []Change {
+	d := &differ{}
+	switch {
+	case old == nil && new == nil:
+		return nil
+	case old == nil:
+		d.add(Change{Kind: KindAdded}, "workflow %s added", describe(new))
+		return d.changes
+	case new == nil:
+		d.add(Change{Kind: KindRemoved}, "workflow %s removed", describe(old))
+		return d.changes
+	}
+	d.value(Change{Path: "name"}, "name", old.Name, new.Name)
+	d.value(Change{Path: "run-name"}, "run-name", old.RunName, new.RunName)
+	d.triggers(&old.On, &new.On)
+	d.permissions(Change{Path: "permissions"}, "workflow", old.Permissions, new.Permissions)
+	d.mapping(Change{Path: "env"}, "env", "", old.Env, new.Env)
+	d.defaults(Change{Path: "defaults"}, "", old.Defaults, new.Defaults)
+	d.value(Change{Path: "concurrency"}, "concurrency", concurrency(old.Concurrency), concurrency(new.Concurrency))
+
+	for _, id := range old.JobIDs() {
+		if new.Jobs[id] == nil {
+			d.add(Change{Path: "jobs." + id, Job: id, Kind: KindRemoved}, "job %s removed", id)
+		}
+	}
+	for _, id := range new.JobIDs() {
+		if old.Jobs[id] == nil {
+			d.add(Change{Path: "jobs." + id, Job: id, Kind: KindAdded}, "job %s added", id)
+			continue
+		}
+		d.job(old, new, id)
+	}
+	return d.changes
+}
+
+func describe(wf *workflow.Workflow) string {
+	if wf.Name != "" {
+		return strconv.Quote(wf.Name)
+	}
+	return wf.Path
+}
+
+// Positive filters match more events the more patterns they have; ignore
+// filters match fewer.
+var filters = []struct {
+	name   string
+	ignore bool
+	get    func(*workflow.Event) []string
+}{
+	{"types", false, func(ev *workflow.Event) []string { return ev.Types }},
+	{"branches", false, func(ev *workflow.Event) []string { return ev.Branches }},
+	{"branches-ignore", true, func(ev *workflow.Event) []string { return ev.BranchesIgnore }},
+	{"tags", false, func(ev *workflow.Event) []string { return ev.Tags }},
+	{"tags-ignore", true, func(ev *workflow.Event) []string { return ev.TagsIgnore }},
+	{"paths", false, func(ev *workflow.Event) []string { return ev.Paths }},
+	{"paths-ignore", true, func(ev *workflow.Event) []string { return ev.PathsIgnore }},
+	{"workflows", false, func(ev *workflow.Event) []string { return ev.Workflows }},
+}
+
+func (d *differ) triggers(old, new *workflow.Triggers) {
+	for _, name := range old.Names {
+		if !new.Has(name) {
+			d.add(Change{Path: "on." + name, Kind: KindRemoved}, "%s trigger removed", name)
+		}
+	}
+	for _, name := range new.Names {
+		if !old.Has(name) {
+			d.add(Change{Path: "on." + name, Kind: KindAdded}, "%s trigger added", name)
+			continue
+		}
+		a, b := old.Event(name), new.Event(name)
+		for _, f := range filters {
+			d.filter(Change{Path: "on." + name + "." + f.name}, name, f.name, f.ignore, f.get(a), f.get(b))
+		}
+		var oldCrons, newCrons []string
+		for _, s := range a.Schedules {
+			oldCrons = append(oldCrons, s.Cron)
+		}
+		for _, s := range b.Schedules {
+			newCrons = append(newCrons, s.Cron)
+		}
+		for _, cron := range newCrons {
+			if !slices.Contains(oldCrons, cron) {
+				d.add(Change{Path: "on.schedule", Kind: KindAdded, New: cron}, "schedule %q added", cron)
+			}
+		}
+		for _, cron := range oldCrons {
+			if !slices.Contains(newCrons, cron) {
+				d.add(Change{Path: "on.schedule", Kind: KindRemoved, Old: cron}, "schedule %q removed", cron)
+			}
+		}
+		d.inputs(name, a.Inputs, b.Inputs)
+		d.declared(Change{Path: "on." + name + ".secrets"}, name+" secret", keys(a.Secrets), keys(b.Secrets))
+		d.declared(Change{Path: "on." + name + ".outputs"}, name+" output", keys(a.Outputs), keys(b.Outputs))
+	}
+}
+
+// filter reports a change of the patterns of a trigger filter, and
+// whether the trigger now matches more or fewer events.
+func (d *differ) filter(c Change, event, name string, ignore bool, old, new []string) {
+	added, removed := minus(new, old), minus(old, new)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	// A filter with no patterns matches everything. Otherwise patterns
+	// added to a filter match more, unless it lists what to ignore.
+	more, fewer := KindBroadened, KindNarrowed
+	if ignore {
+		more, fewer = fewer, more
+	}
+	switch {
+	case len(old) == 0:
+		c.Kind = KindNarrowed
+	case len(new) == 0:
+		c.Kind = KindBroadened
+	case len(removed) == 0:
+		c.Kind = more
+	case len(added) == 0:
+		c.Kind = fewer
+	default:
+		c.Kind = KindChanged
+	}
+	c.Old, c.New = strings.Join(old, ", "), strings.Join(new, ", ")
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "added "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(removed, ", "))
+	}
+	d.add(c, "%s filter of %s trigger %s: %s", name, event, c.Kind, strings.Join(parts, "; "))
+}
+
+func (d *differ) inputs(event string, old, new map[string]*workflow.Input) {
+	for _, name := range union(keys(old), keys(new)) {
+		c := Change{Path: "on." + event + ".inputs." + name}
+		a, b := old[name], new[name]
+		switch {
+		case a == nil:
+			c.Kind = KindAdded
+			what := "optional"
+			if b.Required {
+				what = "required"
+			}
+			d.add(c, "%s %s input %s added", what, event, name)
+		case b == nil:
+			c.Kind = KindRemoved
+			d.add(c, "%s input %s removed", event, name)
+		default:
+			what := event + " input " + name
+			d.value(Change{Path: c.Path + ".type"}, "type of "+what, a.Type, b.Type)
+			d.value(Change{Path: c.Path + ".required"}, "required of "+what, strconv.FormatBool(a.Required), strconv.FormatBool(b.Required))
+			d.value(Change{Path: c.Path + ".default"}, "default of "+what, render(a.Default), render(b.Default))
+			d.value(Change{Path: c.Path + ".options"}, "options of "+what, strings.Join(a.Options, ", "), strings.Join(b.Options, ", "))
+		}
+	}
+}
+
+// declared reports names added to or removed from a set of declarations.
+func (d *differ) declared(c Change, what string, old, new []string) {
+	path := c.Path
+	for _, name := range minus(new, old) {
+		c.Path, c.Kind = path+"."+name, KindAdded
+		d.add(c, "%s %s added", what, name)
+	}
+	for _, name := range minus(old, new) {
+		c.Path, c.Kind = path+"."+name, KindRemoved
+		d.add(c, "%s %s removed", what, name)
+	}
+}
+
+// levels orders permission levels.
+var levels = map[string]int{workflow.PermissionNone: 0, workflow.PermissionRead: 1, workflow.PermissionWrite: 2}
+
+// permissions reports scopes granted more or less by the permissions of
+// owner, the workflow or a job. nil is the repository default.
+func (d *differ) permissions(c Change, owner string, old, new *workflow.Permissions) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		c.Kind, c.New = KindChanged, permissions.Declared(new).String()
+		d.add(c, "permissions of %s set to %s instead of the repository default", owner, c.New)
+		return
+	case new == nil:
+		c.Kind, c.Old = KindChanged, permissions.Declared(old).String()
+		d.add(c, "permissions of %s removed, leaving the repository default instead of %s", owner, c.Old)
+		return
+	}
+	a, b := permissions.Declared(old), permissions.Declared(new)
+	for _, scope := range permissions.Scopes {
+		from, to := a.Level(scope), b.Level(scope)
+		if from == to {
+			continue
+		}
+		sc := c
+		sc.Path += "." + scope
+		sc.Old, sc.New = from, to
+		sc.Kind = KindNarrowed
+		if levels[to] > levels[from] {
+			sc.Kind = KindBroadened
+		}
+		d.add(sc, "%s permission of %s %s from %s to %s", scope, owner, sc.Kind, from, to)
+	}
+}
+
+func (d *differ) defaults(c Change, owner string, old, new *workflow.Defaults) {
+	var a, b workflow.RunDefaults
+	if old != nil && old.Run != nil {
+		a = *old.Run
+	}
+	if new != nil && new.Run != nil {
+		b = *new.Run
+	}
+	d.value(Change{Path: c.Path + ".run.shell", Job: c.Job}, "default shell"+owner, a.Shell, b.Shell)
+	d.value(Change{Path: c.Path + ".run.working-directory", Job: c.Job}, "default working-directory"+owner, a.WorkingDirectory, b.WorkingDirectory)
+}
+
+func (d *differ) job(oldWF, newWF *workflow.Workflow, id string) {
+	old, new := oldWF.Jobs[id], newWF.Jobs[id]
+	of := " of job " + id
+	at := func(key string) Change { return Change{Path: "jobs." + id + "." + key, Job: id} }
+	d.value(at("name"), "name"+of, old.Name, new.Name)
+	d.value(at("needs"), "needs"+of, strings.Join(sorted(old.Needs), ", "), strings.Join(sorted(new.Needs), ", "))
+	d.value(at("if"), "condition"+of, old.If, new.If)
+	d.value(at("runs-on"), "runs-on"+of, runsOn(old.RunsOn), runsOn(new.RunsOn))
+	if old.Permissions != nil || new.Permissions != nil {
+		// A job without permissions has the workflow's.
+		a, b := old.Permissions, new.Permissions
+		if a == nil {
+			a = oldWF.Permissions
+		}
+		if b == nil {
+			b = newWF.Permissions
+		}
+		d.permissions(at("permissions"), "job "+id, a, b)
+	}
+	d.value(at("environment"), "environment"+of, environment(old.Environment), environment(new.Environment))
+	d.value(at("concurrency"), "concurrency"+of, concurrency(old.Concurrency), concurrency(new.Concurrency))
+	d.mapping(at("outputs"), "output", of, old.Outputs, new.Outputs)
+	d.mapping(at("env"), "env", of, old.Env, new.Env)
+	d.defaults(at("defaults"), of, old.Defaults, new.Defaults)
+	d.value(at("timeout-minutes"), "timeout-minutes"+of, number(old.TimeoutMinutes), number(new.TimeoutMinutes))
+	d.value(at("continue-on-error"), "continue-on-error"+of, boolean(old.ContinueOnError), boolean(new.ContinueOnError))
+	d.strategy(at("strategy"), of, old.Strategy, new.Strategy)
+	d.value(at("container.image"), "container image"+of, image(old.Container), image(new.Container))
+	for _, name := range union(keys(old.Services), keys(new.Services)) {
+		a, b := old.Services[name], new.Services[name]
+		c := at("services." + name)
+		switch {
+		case a == nil:
+			c.Kind, c.New = KindAdded, image(b)
+			d.add(c, "service %s%s added with image %s", name, of, c.New)
+		case b == nil:
+			c.Kind, c.Old = KindRemoved, image(a)
+			d.add(c, "service %s%s removed", name, of)
+		default:
+			d.value(at("services."+name+".image"), "image of service "+name+of, image(a), image(b))
+		}
+	}
+	d.value(at("uses"), "called workflow"+of, old.Uses, new.Uses)
+	d.value(at("with"), "inputs"+of, render(old.With), render(new.With))
+	d.value(at("secrets"), "secrets"+of, secrets(old.Secrets), secrets(new.Secrets))
+	d.steps(id, old.Steps, new.Steps)
+}
+
+func (d *differ) strategy(c Change, of string, old, new *workflow.Strategy) {
+	var a, b workflow.Strategy
+	if old != nil {
+		a = *old
+	}
+	if new != nil {
+		b = *new
+	}
+	d.value(Change{Path: c.Path + ".matrix", Job: c.Job}, "matrix"+of, render(a.Matrix), render(b.Matrix))
+	d.value(Change{Path: c.Path + ".fail-fast", Job: c.Job}, "fail-fast"+of, boolean(a.FailFast), boolean(b.FailFast))
+	d.value(Change{Path: c.Path + ".max-parallel", Job: c.Job}, "max-parallel"+of, number(a.MaxParallel), number(b.MaxParallel))
+}
+
+// steps pairs the steps of the two versions of a job by identity, keeping
+// their order, and compares each pair.
+func (d *differ) steps(job string, old, new []*workflow.Step) {
+	pairs := match(old, new)
+	for _, p := range pairs {
+		switch {
+		case p.new < 0:
+			s := old[p.old]
+			d.add(Change{Path: fmt.Sprintf("jobs.%s.steps[%d]", job, p.old), Job: job, Step: label(s), Kind: KindRemoved},
+				"step %s removed from job %s", label(s), job)
+		case p.old < 0:
+			s := new[p.new]
+			d.add(Change{Path: fmt.Sprintf("jobs.%s.steps[%d]", job, p.new), Job: job, Step: label(s), Kind: KindAdded},
+				"step %s added to job %s", label(s), job)
+		default:
+			d.step(job, p.new, old[p.old], new[p.new])
+		}
+	}
+}
+
+func (d *differ) step(job string, index int, old, new *workflow.Step) {
+	name := label(new)
+	of := " of step " + name + " in job " + job
+	at := func(key string) Change {
+		return Change{Path: fmt.Sprintf("jobs.%s.steps[%d].%s", job, index, key), Job: job, Step: name}
+	}
+	d.value(at("name"), "name"+of, old.Name, new.Name)
+	d.value(at("id"), "id"+of, old.ID, new.ID)
+	d.value(at("if"), "condition"+of, old.If, new.If)
+	what := "action"
+	if strings.HasPrefix(old.Uses, "docker://") || strings.HasPrefix(new.Uses, "docker://") {
+		what = "image"
+	}
+	d.value(at("uses"), what+of, old.Uses, new.Uses)
+	d.mapping(at("with"), "input", of, old.With, new.With)
+	d.text(at("run"), "script"+of, old.Run, new.Run)
+	d.value(at("shell"), "shell"+of, old.Shell, new.Shell)
+	d.value(at("working-directory"), "working-directory"+of, old.WorkingDirectory, new.WorkingDirectory)
+	d.mapping(at("env"), "env", of, old.Env, new.Env)
+	d.value(at("continue-on-error"), "continue-on-error"+of, boolean(old.ContinueOnError), boolean(new.ContinueOnError))
+	d.value(at("timeout-minutes"), "timeout-minutes"+of, number(old.TimeoutMinutes), number(new.TimeoutMinutes))
+}
+
+type pair struct{ old, new int }
+
+// match aligns old and new by the longest common subsequence of their
+// step keys. Unpaired steps have -1 on the other side.
+func match(old, new []*workflow.Step) []pair {
+	a, b := make([]string, len(old)), make([]string, len(new))
+	for i, s := range old {
+		a[i] = key(s)
+	}
+	for i, s := range new {
+		b[i] = key(s)
+	}
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out []pair
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, pair{i, j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, pair{i, -1})
+			i++
+		default:
+			out = append(out, pair{-1, j})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, pair{i, -1})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, pair{-1, j})
+	}
+	return out
+}
+
+// key identifies a step across versions: by id, else name, else the
+// action it runs without its version, else its script.
+func key(s *workflow.Step) string {
+	switch {
+	case s.ID != "":
+		return "id:" + s.ID
+	case s.Name != "":
+		return "name:" + s.Name
+	case s.Uses != "":
+		action, _, _ := strings.Cut(s.Uses, "@")
+		return "uses:" + action
+	}
+	return "run:" + s.Run
+}
+
+// label names a step for messages.
+func label(s *workflow.Step) string {
+	switch {
+	case s.Name != "":
+		return strconv.Quote(s.Name)
+	case s.ID != "":
+		return s.ID
+	case s.Uses != "":
+		action, _, _ := strings.Cut(s.Uses, "@")
+		return action
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(s.Run), "\n")
+	if len(line) > 40 {
+		line = line[:37] + "..."
+	}
+	return strconv.Quote(line)
+}
+
+func runsOn(r *workflow.RunsOn) string {
+	if r == nil {
+		return ""
+	}
+	s := strings.Join(r.Labels, ", ")
+	if r.Group != "" {
+		s = strings.TrimSuffix("group "+r.Group+", "+s, ", ")
+	}
+	return s
+}
+
+func environment(e *workflow.Environment) string {
+	if e == nil {
+		return ""
+	}
+	if e.URL != "" {
+		return e.Name + " (" + e.URL + ")"
+	}
+	return e.Name
+}
+
+func concurrency(c *workflow.Concurrency) string {
+	if c == nil {
+		return ""
+	}
+	if b := boolean(c.CancelInProgress); b != "" && b != "false" {
+		return c.Group + " (cancel-in-progress: " + b + ")"
+	}
+	return c.Group
+}
+
+func image(c *workflow.Container) string {
+	if c == nil {
+		return ""
+	}
+	return c.Image
+}
+
+func secrets(s *workflow.JobSecrets) string {
+	switch {
+	case s == nil:
+		return ""
+	case s.Inherit:
+		return "inherit"
+	}
+	return strings.Join(keys(s.Values), ", ")
+}
+
+func boolean(b *workflow.BoolExpr) string {
+	switch {
+	case b == nil:
+		return ""
+	case b.Expression != "":
+		return b.Expression
+	}
+	return strconv.FormatBool(b.Value)
+}
+
+func number(n *workflow.NumberExpr) string {
+	switch {
+	case n == nil:
+		return ""
+	case n.Expression != "":
+		return n.Expression
+	}
+	return strconv.FormatFloat(n.Value, 'f', -1, 64)
+}
+
+// render formats v as flow-style YAML on one line, or "" for nothing.
+func render(v any) string {
+	if v == nil {
+		return ""
+	}
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return ""
+	}
+	var style func(*yaml.Node)
+	style = func(n *yaml.Node) {
+		n.Style |= yaml.FlowStyle
+		for _, c := range n.Content {
+			style(c)
+		}
+	}
+	style(&node)
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func keys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sorted(l []string) []string {
+	out := slices.Clone(l)
+	sort.Strings(out)
+	return out
+}
+
+func union(a, b []string) []string {
+	out := slices.Clone(a)
+	for _, s := range b {
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// minus returns the elements of a not in b, in order.
+func minus(a, b []string) []string {
+	var out []string
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Summary renders changes as a job summary or PR comment, grouped into the
+// workflow's own changes and those of each job.
+func Summary(title string, changes []Change) *summary.Builder {
+	b := summary.New().Heading(html.EscapeString(title), 2)
+	if len(changes) == 0 {
+		return b.Paragraph("No changes.")
+	}
+	var groups []string
+	byGroup := map[string][]string{}
+	for _, c := range changes {
+		group := "Workflow"
+		if c.Job != "" {
+			group = "Job <code>" + html.EscapeString(c.Job) + "</code>"
+		}
+		if _, ok := byGroup[group]; !ok {
+			groups = append(groups, group)
+		}
+		item := html.EscapeString(c.Message)
+		if c.Kind == KindBroadened {
+			item = "<b>" + item + "</b>"
+		}
+		byGroup[group] = append(byGroup[group], item)
+	}
+	for _, group := range groups {
+		b.Heading(group, 3).List(byGroup[group], false)
+	}
+	return b
+}
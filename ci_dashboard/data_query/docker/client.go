@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package docker is a small client for the Docker Engine API, covering the
+// calls the local runner needs to run container steps.
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const apiVersion = "v1.41"
+
+// Client talks to a Docker daemon.
+type Client struct {
+	http *http.Client
+	base string
+}
+
+// NewClient returns a client for the daemon named by DOCKER_HOST, defaulting
+// to the local unix socket, or on macOS to Docker Desktop's socket in the
+// home directory when /var/run/docker.sock does not exist.
+func NewClient() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = defaultHost()
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCKER_HOST %q: %w", host, err)
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &Client{http: &http.Client{Transport: transport}, base: "http://docker/" + apiVersion}, nil
+	case "tcp", "http":
+		return &Client{http: &http.Client{}, base: "http://" + u.Host + "/" + apiVersion}, nil
+	case "npipe":
+		return nil, fmt.Errorf("docker named pipes are not supported: expose the daemon on tcp and set DOCKER_HOST")
+	}
+	return nil, fmt.Errorf("unsupported DOCKER_HOST scheme %q", u.Scheme)
+}
+
+func defaultHost() string {
+	const socket = "/var/run/docker.sock"
+	if runtime.GOOS == "darwin" {
+		if _, err := os.Stat(socket); err != nil {
+			if home, err := os.UserHomeDir(); err == nil {
+				desktop := filepath.Join(home, ".docker", "run", "docker.sock")
+				if _, err := os.Stat(desktop); err == nil {
+					return "unix://" + desktop
+				}
+			}
+		}
+	}
+	return "unix://" + socket
+}
+
+// Error is returned when the daemon responds with an error status.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("docker: %s (status %d)", e.Message, e.StatusCode)
+}
+
+// IsNotFound reports whether err is a 404 from the daemon.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &msg) != nil || msg.Message == "" {
+			msg.Message = strings.TrimSpace(string(data))
+		}
+		return nil, &Error{StatusCode: resp.StatusCode, Message: msg.Message}
+	}
+	return resp, nil
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	var body io.Reader
+	header := http.Header{}
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.do(ctx, method, path, query, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// streamMessages decodes the JSON progress stream returned by pull and
+// build, writing human-readable lines to w and returning any reported error.
+func streamMessages(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	for {
+		var msg struct {
+			Stream string `json:"stream"`
+			Status string `json:"status"`
+			ID     string `json:"id"`
+			Error  string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("docker: %s", msg.Error)
+		}
+		if w == nil {
+			continue
+		}
+		switch {
+		case msg.Stream != "":
+			io.WriteString(w, msg.Stream)
+		case msg.Status != "" && msg.ID != "":
+			fmt.Fprintf(w, "%s: %s\n", msg.ID, msg.Status)
+		case msg.Status != "":
+			fmt.Fprintln(w, msg.Status)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ContainerConfig is the subset of the container create request the runner
+// uses. Field names follow the Engine API.
+type ContainerConfig struct {
+	Image        string              `json:"Image"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Hostname     string              `json:"Hostname,omitempty"`
+	User         string              `json:"User,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Healthcheck  *Healthcheck        `json:"Healthcheck,omitempty"`
+	HostConfig   HostConfig          `json:"HostConfig"`
+	// NetworkingConfig sets the container's aliases on its networks.
+	NetworkingConfig *NetworkingConfig `json:"NetworkingConfig,omitempty"`
+}
+
+// HostConfig holds the host-side settings of a container.
+type HostConfig struct {
+	Binds       []string `json:"Binds,omitempty"`
+	NetworkMode string   `json:"NetworkMode,omitempty"`
+	AutoRemove  bool     `json:"AutoRemove,omitempty"`
+	// PortBindings maps container ports, such as 5432/tcp, to host ports.
+	// An empty HostPort picks a free one.
+	PortBindings map[string][]PortBinding `json:"PortBindings,omitempty"`
+	// ExtraHosts are host:address entries added to /etc/hosts; the
+	// address host-gateway stands for the host.
+	ExtraHosts []string `json:"ExtraHosts,omitempty"`
+	// Memory and MemorySwap cap memory, and memory plus swap, in bytes.
+	Memory     int64 `json:"Memory,omitempty"`
+	MemorySwap int64 `json:"MemorySwap,omitempty"`
+	// NanoCPUs caps CPU time in billionths of a CPU.
+	NanoCPUs  int64  `json:"NanoCpus,omitempty"`
+	PidsLimit *int64 `json:"PidsLimit,omitempty"`
+}
+
+// PortBinding is a host address a container port is published on.
+type PortBinding struct {
+	HostIP   string `json:"HostIp,omitempty"`
+	HostPort string `json:"HostPort"`
+}
+
+// Healthcheck is a container health check. Durations are in nanoseconds,
+// as the Engine API takes them.
+type Healthcheck struct {
+	Test        []string `json:"Test,omitempty"`
+	Interval    int64    `json:"Interval,omitempty"`
+	Timeout     int64    `json:"Timeout,omitempty"`
+	StartPeriod int64    `json:"StartPeriod,omitempty"`
+	Retries     int      `json:"Retries,omitempty"`
+}
+
+// NetworkingConfig holds the endpoint settings of each network a container
+// is created on.
+type NetworkingConfig struct {
+	EndpointsConfig map[string]*EndpointSettings `json:"EndpointsConfig"`
+}
+
+// EndpointSettings configures a container on one network.
+type EndpointSettings struct {
+	Aliases []string `json:"Aliases,omitempty"`
+}
+
+// ContainerInfo is the subset of the container inspect response the runner
+// uses.
+type ContainerInfo struct {
+	ID     string `json:"Id"`
+	Config struct {
+		// Env includes the variables set by the image, such as PATH.
+		Env []string `json:"Env"`
+	} `json:"Config"`
+	State struct {
+		Status   string `json:"Status"`
+		Running  bool   `json:"Running"`
+		ExitCode int    `json:"ExitCode"`
+		// OOMKilled reports that a process of the container ran out of
+		// memory.
+		OOMKilled bool `json:"OOMKilled"`
+		// Health is nil for containers without a health check.
+		Health *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	NetworkSettings struct {
+		Ports map[string][]PortBinding `json:"Ports"`
+	} `json:"NetworkSettings"`
+}
+
+// ContainerCreate creates a container and returns its ID.
+func (c *Client) ContainerCreate(ctx context.Context, name string, cfg *ContainerConfig) (string, error) {
+	q := url.Values{}
+	if name != "" {
+		q.Set("name", name)
+	}
+	var out struct {
+		ID string `json:"Id"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/create", q, cfg, &out); err != nil {
+		return "", err
+	}
+	return out.ID, nil
+}
+
+// ContainerStart starts a created container.
+func (c *Client) ContainerStart(ctx context.Context, id string) error {
+	return c.doJSON(ctx, http.MethodPost, "/containers/"+id+"/start", nil, nil, nil)
+}
+
+// ContainerInspect returns the state of a container.
+func (c *Client) ContainerInspect(ctx context.Context, id string) (*ContainerInfo, error) {
+	var info ContainerInfo
+	if err := c.doJSON(ctx, http.MethodGet, "/containers/"+id+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ContainerStop stops a container, killing it after timeout seconds.
+func (c *Client) ContainerStop(ctx context.Context, id string, timeout int) error {
+	return c.doJSON(ctx, http.MethodPost, "/containers/"+id+"/stop", url.Values{"t": {fmt.Sprint(timeout)}}, nil, nil)
+}
+
+// ContainerRemove removes a container and its anonymous volumes.
+func (c *Client) ContainerRemove(ctx context.Context, id string, force bool) error {
+	q := url.Values{"v": {"1"}}
+	if force {
+		q.Set("force", "1")
+	}
+	return c.doJSON(ctx, http.MethodDelete, "/containers/"+id, q, nil, nil)
+}
+
+// ContainerWait blocks until the container exits and returns its exit code.
+func (c *Client) ContainerWait(ctx context.Context, id string) (int, error) {
+	var out struct {
+		StatusCode int `json:"StatusCode"`
+		Error      *struct {
+			Message string `json:"Message"`
+		} `json:"Error"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/"+id+"/wait", nil, nil, &out); err != nil {
+		return -1, err
+	}
+	if out.Error != nil && out.Error.Message != "" {
+		return out.StatusCode, fmt.Errorf("docker: %s", out.Error.Message)
+	}
+	return out.StatusCode, nil
+}
+
+// ContainerLogsTail copies the last lines of a container's output without
+// following it.
+func (c *Client) ContainerLogsTail(ctx context.Context, id string, lines int, stdout, stderr io.Writer) error {
+	q := url.Values{"stdout": {"1"}, "stderr": {"1"}, "tail": {fmt.Sprint(lines)}}
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+id+"/logs", q, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return demux(resp.Body, stdout, stderr)
+}
+
+// ContainerLogs follows a container's output until it exits, copying stdout
+// and stderr to the given writers.
+func (c *Client) ContainerLogs(ctx context.Context, id string, stdout, stderr io.Writer) error {
+	q := url.Values{"follow": {"1"}, "stdout": {"1"}, "stderr": {"1"}}
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+id+"/logs", q, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return demux(resp.Body, stdout, stderr)
+}
+
+// demux splits the multiplexed stream used for containers without a TTY.
+func demux(r io.Reader, stdout, stderr io.Writer) error {
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		w := stdout
+		if header[0] == 2 {
+			w = stderr
+		}
+		if w == nil {
+			w = io.Discard
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// ErrOOMKilled is returned with the exit code by Run when the container ran
+// out of memory.
+var ErrOOMKilled = errors.New("the container ran out of memory")
+
+// Run creates and starts a container, streams its output and waits for it
+// to exit. The container is removed afterwards.
+func (c *Client) Run(ctx context.Context, name string, cfg *ContainerConfig, stdout, stderr io.Writer) (int, error) {
+	id, err := c.ContainerCreate(ctx, name, cfg)
+	if err != nil {
+		return -1, err
+	}
+	defer c.ContainerRemove(context.Background(), id, true)
+	if err := c.ContainerStart(ctx, id); err != nil {
+		return -1, err
+	}
+	if err := c.ContainerLogs(ctx, id, stdout, stderr); err != nil && ctx.Err() == nil {
+		return -1, err
+	}
+	if ctx.Err() != nil {
+		c.ContainerStop(context.Background(), id, 10)
+		return -1, ctx.Err()
+	}
+	code, err := c.ContainerWait(ctx, id)
+	if err != nil || code == 0 {
+		return code, err
+	}
+	if info, err := c.ContainerInspect(ctx, id); err == nil && info.State.OOMKilled {
+		return code, ErrOOMKilled
+	}
+	return code, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ExecConfig describes a command to run in a running container.
+type ExecConfig struct {
+	Cmd          []string `json:"Cmd"`
+	Env          []string `json:"Env,omitempty"`
+	WorkingDir   string   `json:"WorkingDir,omitempty"`
+	User         string   `json:"User,omitempty"`
+	AttachStdout bool     `json:"AttachStdout"`
+	AttachStderr bool     `json:"AttachStderr"`
+}
+
+// Exec runs a command in the running container id, copying its output to
+// stdout and stderr, and returns its exit code.
+func (c *Client) Exec(ctx context.Context, id string, cfg *ExecConfig, stdout, stderr io.Writer) (int, error) {
+	cfg.AttachStdout, cfg.AttachStderr = true, true
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/"+id+"/exec", nil, cfg, &created); err != nil {
+		return -1, err
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	resp, err := c.do(ctx, http.MethodPost, "/exec/"+created.ID+"/start", nil, header, strings.NewReader(`{"Detach":false,"Tty":false}`))
+	if err != nil {
+		return -1, err
+	}
+	err = demux(resp.Body, stdout, stderr)
+	resp.Body.Close()
+	if err != nil && ctx.Err() == nil {
+		return -1, err
+	}
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	var info struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/exec/"+created.ID+"/json", nil, nil, &info); err != nil {
+		return -1, err
+	}
+	return info.ExitCode, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AuthConfig holds registry credentials.
+type AuthConfig struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	ServerAddress string `json:"serveraddress,omitempty"`
+}
+
+func (a *AuthConfig) header() (http.Header, error) {
+	header := http.Header{}
+	if a == nil {
+		return header, nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	header.Set("X-Registry-Auth", base64.URLEncoding.EncodeToString(data))
+	return header, nil
+}
+
+// splitImage splits an image reference into repository and tag.
+func splitImage(ref string) (string, string) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	slash := strings.LastIndex(ref, "/")
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		return ref[:colon], ref[colon+1:]
+	}
+	return ref, "latest"
+}
+
+// ImagePull pulls ref, writing progress to w if it is non-nil.
+func (c *Client) ImagePull(ctx context.Context, ref string, auth *AuthConfig, w io.Writer) error {
+	repo, tag := splitImage(ref)
+	header, err := auth.header()
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {repo}, "tag": {tag}}, header, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return streamMessages(resp.Body, w)
+}
+
+// ImageExists reports whether ref is present locally.
+func (c *Client) ImageExists(ctx context.Context, ref string) (bool, error) {
+	err := c.doJSON(ctx, http.MethodGet, "/images/"+ref+"/json", nil, nil, nil)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ImageInfo describes a local image.
+type ImageInfo struct {
+	ID   string `json:"Id"`
+	Size int64  `json:"Size"`
+}
+
+// ImageInspect returns the local image ref.
+func (c *Client) ImageInspect(ctx context.Context, ref string) (*ImageInfo, error) {
+	var info ImageInfo
+	if err := c.doJSON(ctx, http.MethodGet, "/images/"+ref+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ImageRemove deletes a local image.
+func (c *Client) ImageRemove(ctx context.Context, ref string, force bool) error {
+	q := url.Values{}
+	if force {
+		q.Set("force", "1")
+	}
+	return c.doJSON(ctx, http.MethodDelete, "/images/"+ref, q, nil, nil)
+}
+
+// ImageBuild builds the directory contextDir into an image tagged tag.
+// dockerfile is relative to contextDir.
+func (c *Client) ImageBuild(ctx context.Context, contextDir, dockerfile, tag string, w io.Writer) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(tarDirectory(contextDir, pw))
+	}()
+	defer pr.Close()
+	q := url.Values{"t": {tag}, "dockerfile": {filepath.ToSlash(dockerfile)}, "rm": {"1"}}
+	header := http.Header{"Content-Type": {"application/x-tar"}}
+	resp, err := c.do(ctx, http.MethodPost, "/build", q, header, pr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return streamMessages(resp.Body, w)
+}
+
+// tarDirectory writes dir as an uncompressed tar stream.
+func tarDirectory(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
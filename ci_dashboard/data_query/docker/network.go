@@ -0,0 +1,42 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"context"
+	"net/http"
+)
+
+// NetworkCreate creates a bridge network and returns its ID.
+func (c *Client) NetworkCreate(ctx context.Context, name string, labels map[string]string) (string, error) {
+	in := struct {
+		Name           string            `json:"Name"`
+		Driver         string            `json:"Driver"`
+		CheckDuplicate bool              `json:"CheckDuplicate"`
+		Labels         map[string]string `json:"Labels,omitempty"`
+	}{name, "bridge", true, labels}
+	var out struct {
+		ID string `json:"Id"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/networks/create", nil, in, &out); err != nil {
+		return "", err
+	}
+	return out.ID, nil
+}
+
+// NetworkRemove removes a network. Its containers must be gone.
+func (c *Client) NetworkRemove(ctx context.Context, id string) error {
+	return c.doJSON(ctx, http.MethodDelete, "/networks/"+id, nil, nil, nil)
+}
@@ -0,0 +1,712 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dryrun works out what a run of a workflow would do for an event
+// without running anything: whether the event triggers it, the legs of
+// each job's matrix, which jobs and steps their if: conditions select, the
+// commit each action resolves to and the environment of every step.
+//
+// Values that exist only while the workflow runs, such as step outputs,
+// are left as the expressions that produce them, and conditions that
+// depend on them are reported as Unknown. Jobs and steps are assumed to
+// succeed.
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"testingdashboard/m/v2/contexts"
+	"testingdashboard/m/v2/events"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/pin"
+	"testingdashboard/m/v2/triggers"
+	"testingdashboard/m/v2/variables"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Decisions about jobs and steps.
+const (
+	Run  = "run"
+	Skip = "skip"
+	// Unknown is for conditions that depend on values known only while
+	// the workflow runs.
+	Unknown = "unknown"
+)
+
+// Options describe the simulated event.
+type Options struct {
+	EventName string
+	// Event is the event payload.
+	Event map[string]any
+	// Ref, SHA, Repository and Actor fill in the github context where the
+	// payload does not say.
+	Ref, SHA, Repository, Actor string
+	// Head is the branch of a pull request when the payload has none, in
+	// which case Ref is the branch it targets. For merge_group events
+	// without a payload Ref is the branch of the queue.
+	Head string
+	// Changed lists the changed files for path filters. Nil means unknown,
+	// in which case path filters pass.
+	Changed []string
+	Inputs  map[string]any
+	Vars    map[string]string
+	// Variables are resolved into the vars context of each job by its
+	// environment, under Vars, which take precedence.
+	Variables *variables.Set
+	// Secrets names the secrets that are set. Their values are never
+	// needed; they appear as ***. When nil, conditions on secrets are
+	// Unknown.
+	Secrets []string
+	// Resolver resolves action refs to commits. Nil leaves them unresolved.
+	Resolver pin.Resolver
+}
+
+// Plan is what a run would do.
+type Plan struct {
+	Workflow  string `json:"workflow"`
+	Event     string `json:"event"`
+	Ref       string `json:"ref"`
+	Triggered bool   `json:"triggered"`
+	// Reason explains whether the event triggers the workflow.
+	Reason  string            `json:"reason"`
+	RunName string            `json:"run_name,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	// Jobs are in source order. They are empty when the workflow is not
+	// triggered.
+	Jobs []*Job `json:"jobs,omitempty"`
+}
+
+// Job is a planned job.
+type Job struct {
+	ID       string   `json:"id"`
+	Needs    []string `json:"needs,omitempty"`
+	If       string   `json:"if,omitempty"`
+	Decision string   `json:"decision"`
+	Reason   string   `json:"reason,omitempty"`
+	// Calls is the reusable workflow the job calls.
+	Calls string `json:"calls,omitempty"`
+	Legs  []*Leg `json:"legs,omitempty"`
+}
+
+// Leg is one matrix combination of a job.
+type Leg struct {
+	Name   string         `json:"name"`
+	Matrix map[string]any `json:"matrix,omitempty"`
+	RunsOn []string       `json:"runs_on,omitempty"`
+	// Environment is the deployment environment of the leg.
+	Environment string            `json:"environment,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	Steps       []*Step           `json:"steps,omitempty"`
+}
+
+// Step is a planned step.
+type Step struct {
+	Name     string `json:"name"`
+	If       string `json:"if,omitempty"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+	Uses     string `json:"uses,omitempty"`
+	// SHA is the commit Uses resolves to.
+	SHA  string            `json:"sha,omitempty"`
+	Run  string            `json:"run,omitempty"`
+	With map[string]string `json:"with,omitempty"`
+	// Env is the step's environment, including that of its job and
+	// workflow.
+	Env map[string]string `json:"env,omitempty"`
+	// Retry describes the step's x-retry extension, if it has one.
+	Retry string `json:"retry,omitempty"`
+}
+
+type planner struct {
+	ctx    context.Context
+	opts   Options
+	wf     *workflow.Workflow
+	github map[string]any
+	jobs   map[string]*Job
+	shas   map[string]string
+}
+
+// New plans the run of wf for the event described by opts.
+func New(ctx context.Context, wf *workflow.Workflow, opts Options) (*Plan, error) {
+	if opts.EventName == "" {
+		opts.EventName = "push"
+	}
+	payload, github, err := githubContext(wf, opts)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Workflow: wf.Path, Event: opts.EventName, Ref: expr.ToString(github["ref"])}
+	te := triggers.FromPayload(payload)
+	if te.Ref == "" {
+		te.Ref = plan.Ref
+	}
+	te.Changed = opts.Changed
+	res, err := triggers.Matches(wf, te)
+	if err != nil {
+		return nil, err
+	}
+	plan.Triggered, plan.Reason = res.Triggered, res.Reason
+	if !plan.Triggered {
+		return plan, nil
+	}
+
+	p := &planner{ctx: ctx, opts: opts, wf: wf, github: github, jobs: map[string]*Job{}, shas: map[string]string{}}
+	values := p.values(nil, nil)
+	if wf.RunName != "" {
+		plan.RunName = p.interpolate(wf.RunName, contexts.At("run-name").Restrict(values))
+	}
+	plan.Env = p.env(wf.Env, contexts.At("env").Restrict(values))
+	for _, id := range wf.JobIDs() {
+		job, err := p.job(id, nil)
+		if err != nil {
+			return nil, err
+		}
+		plan.Jobs = append(plan.Jobs, job)
+	}
+	return plan, nil
+}
+
+// githubContext builds the github context of the run from the payload and
+// opts.
+func githubContext(wf *workflow.Workflow, opts Options) (events.Event, map[string]any, error) {
+	event := opts.Event
+	if event == nil {
+		event = map[string]any{}
+	}
+	if opts.EventName == "workflow_dispatch" && event["inputs"] == nil && opts.Inputs != nil {
+		event["inputs"] = opts.Inputs
+	}
+	if strings.HasPrefix(opts.EventName, "pull_request") && event["pull_request"] == nil {
+		event["number"] = 1
+		event["pull_request"] = map[string]any{
+			"number": 1,
+			"head":   map[string]any{"ref": opts.Head, "sha": opts.SHA},
+			"base":   map[string]any{"ref": strings.TrimPrefix(opts.Ref, "refs/heads/")},
+		}
+	}
+	if opts.EventName == "merge_group" && event["merge_group"] == nil {
+		// Ref is the branch the queue merges into, and the group holds
+		// a single pull request.
+		branch := strings.TrimPrefix(opts.Ref, "refs/heads/")
+		if event["action"] == nil {
+			event["action"] = "checks_requested"
+		}
+		event["merge_group"] = map[string]any{
+			"head_sha": opts.SHA,
+			"head_ref": events.MergeGroupRef(branch, 1, opts.SHA),
+			"base_ref": "refs/heads/" + branch,
+		}
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode event payload: %w", err)
+	}
+	payload, err := events.ParseEvent(opts.EventName, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	git := events.GitContext(payload)
+	ref, sha := git.Ref, git.SHA
+	if opts.Ref != "" && (ref == "" || opts.EventName == "push") {
+		ref = opts.Ref
+	}
+	if sha == "" {
+		sha = opts.SHA
+	}
+	repo := opts.Repository
+	if r := payload.Info().Repository; r != nil && r.FullName != "" {
+		repo = r.FullName
+	}
+	actor := opts.Actor
+	if s := payload.Info().Sender; s != nil && s.Login != "" {
+		actor = s.Login
+	}
+	owner, _, _ := strings.Cut(repo, "/")
+	refType, refName := "branch", strings.TrimPrefix(ref, "refs/heads/")
+	if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		refType, refName = "tag", name
+	} else if name, ok := strings.CutPrefix(ref, "refs/pull/"); ok {
+		refName = name
+	}
+	name := wf.Name
+	if name == "" {
+		name = filepath.Base(wf.Path)
+	}
+	return payload, map[string]any{
+		"event_name":       opts.EventName,
+		"event":            event,
+		"sha":              sha,
+		"ref":              ref,
+		"ref_name":         refName,
+		"ref_type":         refType,
+		"head_ref":         git.HeadRef,
+		"base_ref":         git.BaseRef,
+		"repository":       repo,
+		"repository_owner": owner,
+		"actor":            actor,
+		"triggering_actor": actor,
+		"workflow":         name,
+		"run_number":       "1",
+		"run_attempt":      "1",
+	}, nil
+}
+
+// job plans the job id, first planning the jobs it needs. seen guards
+// against cycles, which workflow validation rejects anyway.
+func (p *planner) job(id string, seen []string) (*Job, error) {
+	if j, ok := p.jobs[id]; ok {
+		return j, nil
+	}
+	wj := p.wf.Jobs[id]
+	j := &Job{ID: id, Needs: wj.Needs, If: wj.If, Calls: wj.Uses}
+	p.jobs[id] = j
+	if slices.Contains(seen, id) {
+		j.Decision, j.Reason = Unknown, "needs form a cycle"
+		return j, nil
+	}
+
+	needs := map[string]any{}
+	for _, n := range wj.Needs {
+		if p.wf.Jobs[n] == nil {
+			j.Decision, j.Reason = Unknown, "needs unknown job "+n
+			return j, nil
+		}
+		dep, err := p.job(n, append(seen, id))
+		if err != nil {
+			return nil, err
+		}
+		switch dep.Decision {
+		case Skip:
+			needs[n] = map[string]any{"result": "skipped"}
+		case Unknown:
+			if j.Decision == "" {
+				j.Decision, j.Reason = Unknown, "whether "+n+" runs is unknown"
+			}
+		default:
+			needs[n] = map[string]any{"result": "success"}
+		}
+	}
+	if j.Decision == Unknown {
+		return j, nil
+	}
+	values := p.values(needs, nil)
+	j.Decision, j.Reason = p.condition(wj.If, contexts.At("jobs", id, "if").Restrict(values), skippedNeed(wj, needs))
+	if j.Decision == Skip {
+		return j, nil
+	}
+
+	legs, reason, err := p.legs(wj, values)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", id, err)
+	}
+	if legs == nil {
+		j.Decision, j.Reason = Unknown, reason
+		return j, nil
+	}
+	for _, leg := range legs {
+		if err := p.leg(wj, leg, needs); err != nil {
+			return nil, fmt.Errorf("job %s: %w", id, err)
+		}
+		j.Legs = append(j.Legs, leg.Leg)
+	}
+	return j, nil
+}
+
+// skippedNeed returns a job the job needs that is skipped, if any.
+func skippedNeed(wj *workflow.Job, needs map[string]any) string {
+	for _, n := range wj.Needs {
+		if r, _ := needs[n].(map[string]any); r["result"] == "skipped" {
+			return n
+		}
+	}
+	return ""
+}
+
+type leg struct {
+	*Leg
+	strategy map[string]any
+}
+
+// legs expands the job's matrix, evaluating its expressions. It returns
+// nil and a reason when they depend on values known only while running.
+func (p *planner) legs(wj *workflow.Job, values map[string]any) ([]leg, string, error) {
+	s := wj.Strategy
+	if s != nil {
+		strategy := *s
+		ctx := contexts.At("jobs", wj.ID, "strategy").Restrict(values)
+		if m := s.Matrix; m != nil {
+			m := *m
+			if m.Expression != "" {
+				if dep := p.unknown(m.Expression, ctx); dep != "" {
+					return nil, "the matrix depends on " + dep, nil
+				}
+				v, err := expr.EvaluateValue(m.Expression, &expr.Context{Values: ctx})
+				if err != nil {
+					return nil, "", err
+				}
+				resolved, err := workflow.MatrixFromValue(v)
+				if err != nil {
+					return nil, "", err
+				}
+				m = *resolved
+			}
+			m.Dimensions = slices.Clone(m.Dimensions)
+			for i, dim := range m.Dimensions {
+				if dim.Expression == "" {
+					continue
+				}
+				if dep := p.unknown(dim.Expression, ctx); dep != "" {
+					return nil, "matrix " + dim.Name + " depends on " + dep, nil
+				}
+				v, err := expr.EvaluateValue(dim.Expression, &expr.Context{Values: ctx})
+				if err != nil {
+					return nil, "", err
+				}
+				list, ok := v.([]any)
+				if !ok {
+					return nil, "", fmt.Errorf("matrix %s must evaluate to an array", dim.Name)
+				}
+				m.Dimensions[i] = workflow.Dimension{Name: dim.Name, Values: list}
+			}
+			strategy.Matrix = &m
+		}
+		if ff := s.FailFast; ff != nil && ff.Expression != "" {
+			v, err := expr.EvaluateValue(ff.Expression, &expr.Context{Values: ctx})
+			if err != nil {
+				return nil, "", err
+			}
+			strategy.FailFast = &workflow.BoolExpr{Value: expr.Truthy(v)}
+		}
+		if mp := s.MaxParallel; mp != nil && mp.Expression != "" {
+			v, err := expr.EvaluateValue(mp.Expression, &expr.Context{Values: ctx})
+			if err != nil {
+				return nil, "", err
+			}
+			strategy.MaxParallel = &workflow.NumberExpr{Value: expr.ToNumber(v)}
+		}
+		s = &strategy
+	}
+	exp, err := s.Expand()
+	if err != nil {
+		return nil, "", err
+	}
+	var legs []leg
+	for _, cfg := range exp.Configs {
+		legs = append(legs, leg{
+			Leg: &Leg{Matrix: cfg.Matrix},
+			strategy: map[string]any{
+				"fail-fast":    exp.FailFast,
+				"job-index":    cfg.Index,
+				"job-total":    cfg.Total,
+				"max-parallel": exp.MaxParallel,
+			},
+		})
+	}
+	return legs, "", nil
+}
+
+func (p *planner) leg(wj *workflow.Job, l leg, needs map[string]any) error {
+	values := p.values(needs, l.Matrix)
+	values["strategy"] = l.strategy
+	at := func(path ...string) map[string]any {
+		return contexts.At(append([]string{"jobs", wj.ID}, path...)...).Restrict(values)
+	}
+	l.Name = workflow.LegName(wj, p.interpolate(wj.Name, at("name")), l.Matrix)
+	if wj.RunsOn != nil {
+		for _, label := range wj.RunsOn.Labels {
+			l.RunsOn = append(l.RunsOn, p.interpolate(label, at("runs-on")))
+		}
+		if wj.RunsOn.Group != "" {
+			l.RunsOn = append(l.RunsOn, "group "+p.interpolate(wj.RunsOn.Group, at("runs-on")))
+		}
+	}
+	if wj.Environment != nil {
+		l.Environment = p.interpolate(wj.Environment.Name, at("environment"))
+		values["vars"] = p.vars(l.Environment)
+	}
+	l.Env = merge(p.env(p.wf.Env, contexts.At("env").Restrict(values)), p.env(wj.Env, at("env")))
+	values["env"] = anyMap(l.Env)
+
+	for i, ws := range wj.Steps {
+		idx := fmt.Sprint(i)
+		stepAt := func(key string) map[string]any {
+			return contexts.At("jobs", wj.ID, "steps", idx, key).Restrict(values)
+		}
+		s := &Step{If: ws.If, Uses: ws.Uses}
+		s.Env = merge(l.Env, p.env(ws.Env, stepAt("env")))
+		// Steps see their own env in their other keys.
+		stepValues := values["env"]
+		values["env"] = anyMap(s.Env)
+		s.Decision, s.Reason = p.condition(ws.If, stepAt("if"), "")
+		s.Name = p.stepName(ws, stepAt("name"))
+		if ws.Run != "" {
+			s.Run = p.interpolate(ws.Run, stepAt("run"))
+		}
+		for k, v := range ws.With {
+			if s.With == nil {
+				s.With = map[string]string{}
+			}
+			s.With[k] = p.interpolate(v, stepAt("with"))
+		}
+		values["env"] = stepValues
+		s.Retry = describeRetry(ws.Retry)
+		if ws.Uses != "" && s.Decision != Skip {
+			sha, err := p.resolve(ws.Uses)
+			if err != nil {
+				return err
+			}
+			s.SHA = sha
+		}
+		l.Steps = append(l.Steps, s)
+	}
+	return nil
+}
+
+// describeRetry summarizes an x-retry extension, such as "up to 3 attempts
+// on exit code 75, 10s apart".
+func describeRetry(r *workflow.StepRetry) string {
+	if r == nil || r.MaxAttempts < 2 {
+		return ""
+	}
+	d := fmt.Sprintf("up to %d attempts", r.MaxAttempts)
+	var on []string
+	for _, code := range r.OnExitCodes {
+		on = append(on, fmt.Sprintf("exit code %d", code))
+	}
+	for _, pattern := range r.OnOutput {
+		on = append(on, fmt.Sprintf("output matching %q", pattern))
+	}
+	if len(on) > 0 {
+		d += " on " + strings.Join(on, " or ")
+	}
+	if r.Backoff != "" {
+		d += ", " + r.Backoff + " apart"
+	}
+	return d
+}
+
+func (p *planner) stepName(ws *workflow.Step, values map[string]any) string {
+	switch {
+	case ws.Name != "":
+		return p.interpolate(ws.Name, values)
+	case ws.Uses != "":
+		return p.interpolate("Run "+ws.Uses, values)
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(ws.Run), "\n")
+	return p.interpolate("Run "+first, values)
+}
+
+// resolve returns the commit of a step's action, or "" for local actions,
+// images and when there is no resolver.
+func (p *planner) resolve(uses string) (string, error) {
+	u, err := workflow.ParseUses(uses)
+	if err != nil || u.Kind != workflow.UsesRepository {
+		return "", nil
+	}
+	if pin.IsSHA(u.Ref) {
+		return u.Ref, nil
+	}
+	if p.opts.Resolver == nil {
+		return "", nil
+	}
+	key := u.Owner + "/" + u.Repo + "@" + u.Ref
+	if sha, ok := p.shas[key]; ok {
+		return sha, nil
+	}
+	sha, err := p.opts.Resolver.Resolve(p.ctx, u.Owner, u.Repo, u.Ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", key, err)
+	}
+	p.shas[key] = sha
+	return sha, nil
+}
+
+// values returns the contexts known before the run starts.
+func (p *planner) values(needs, matrix map[string]any) map[string]any {
+	inputs := p.opts.Inputs
+	if inputs == nil {
+		inputs = map[string]any{}
+	}
+	secrets := map[string]any{"GITHUB_TOKEN": "***"}
+	for _, name := range p.opts.Secrets {
+		secrets[name] = "***"
+	}
+	if needs == nil {
+		needs = map[string]any{}
+	}
+	if matrix == nil {
+		matrix = map[string]any{}
+	}
+	return map[string]any{
+		"github":   p.github,
+		"inputs":   inputs,
+		"vars":     p.vars(""),
+		"secrets":  secrets,
+		"needs":    needs,
+		"matrix":   matrix,
+		"strategy": map[string]any{},
+		"env":      map[string]any{},
+	}
+}
+
+// vars returns the vars context of a job in environment.
+func (p *planner) vars(environment string) map[string]any {
+	values := p.opts.Variables.Values(environment)
+	for k, v := range p.opts.Vars {
+		values[k] = v
+	}
+	return anyMap(values)
+}
+
+// condition decides an if: condition, assuming that everything before it
+// succeeded. A job that needs a skipped job is skipped unless its
+// condition calls a status function.
+func (p *planner) condition(cond string, values map[string]any, skipped string) (string, string) {
+	src := strings.TrimSpace(cond)
+	if s, ok := strings.CutPrefix(src, "${{"); ok && strings.HasSuffix(s, "}}") && strings.Count(src, "${{") == 1 {
+		src = strings.TrimSpace(strings.TrimSuffix(s, "}}"))
+	}
+	node, err := expr.Parse(src)
+	if src != "" && err != nil {
+		return Unknown, err.Error()
+	}
+	if skipped != "" && (src == "" || !expr.HasStatusFunction(node)) {
+		return Skip, "needs " + skipped + ", which is skipped"
+	}
+	if src == "" {
+		return Run, ""
+	}
+	if dep := p.unknown(src, values); dep != "" {
+		return Unknown, "depends on " + dep
+	}
+	ok, err := expr.EvaluateCondition(src, &expr.Context{Values: values})
+	if err != nil {
+		return Unknown, err.Error()
+	}
+	if !ok {
+		return Skip, "if: " + src + " is false"
+	}
+	return Run, ""
+}
+
+// runtime are the contexts whose values come from the run itself.
+var runtime = []string{"steps", "job", "runner", "jobs"}
+
+// unknown returns the first value the expression src, bare or in ${{ }},
+// uses that is known only while running, or "".
+func (p *planner) unknown(src string, values map[string]any) string {
+	if strings.Contains(src, "${{") {
+		embedded, _ := expr.Extract(src)
+		for _, e := range embedded {
+			if dep := p.unknown(e.Source, values); dep != "" {
+				return dep
+			}
+		}
+		return ""
+	}
+	node, err := expr.Parse(src)
+	if err != nil {
+		return ""
+	}
+	dep := ""
+	expr.Walk(node, func(n expr.Node) {
+		if dep != "" {
+			return
+		}
+		if call, ok := n.(*expr.Call); ok && strings.EqualFold(call.Name, "hashFiles") {
+			dep = "hashFiles()"
+			return
+		}
+		chain := expr.Chain(n)
+		if len(chain) == 0 {
+			return
+		}
+		root := strings.ToLower(chain[0])
+		switch {
+		case slices.Contains(runtime, root):
+			dep = strings.Join(chain, ".")
+		case root == "secrets" && p.opts.Secrets == nil && len(chain) > 1 && chain[1] != "GITHUB_TOKEN":
+			dep = strings.Join(chain, ".")
+		case root == "needs" && len(chain) > 2 && strings.EqualFold(chain[2], "outputs"):
+			dep = strings.Join(chain, ".")
+		}
+	})
+	return dep
+}
+
+// interpolate replaces the expressions in s that can be evaluated before
+// the run starts, leaving the others as they are.
+func (p *planner) interpolate(s string, values map[string]any) string {
+	embedded, err := expr.Extract(s)
+	if err != nil || len(embedded) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, e := range embedded {
+		b.WriteString(s[last:e.Start])
+		last = e.End
+		if p.unknown(e.Source, values) != "" {
+			b.WriteString(s[e.Start:e.End])
+			continue
+		}
+		v, err := expr.Evaluate(e.Source, &expr.Context{Values: values})
+		if err != nil {
+			b.WriteString(s[e.Start:e.End])
+			continue
+		}
+		b.WriteString(expr.ToString(v))
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (p *planner) env(env map[string]string, values map[string]any) map[string]string {
+	if len(env) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		out[k] = p.interpolate(v, values)
+	}
+	return out
+}
+
+// merge returns the variables of base overridden by those of over.
+func merge(base, over map[string]string) map[string]string {
+	if len(base) == 0 && len(over) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		out[k] = v
+	}
+	return out
+}
+
+func anyMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package endpoints locates the services of a GitHub instance: github.com,
+// a GHE.com data residency subdomain or a GitHub Enterprise Server.
+package endpoints
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DotCom is the server of github.com.
+const DotCom = "https://github.com"
+
+// Endpoints are the URLs of one GitHub instance, without trailing slashes.
+type Endpoints struct {
+	// Server is the web URL, as in GITHUB_SERVER_URL.
+	Server string
+	// API is the REST API, as in GITHUB_API_URL.
+	API string
+	// GraphQL is the GraphQL endpoint, as in GITHUB_GRAPHQL_URL.
+	GraphQL string
+	// Uploads is the endpoint release assets are uploaded to.
+	Uploads string
+	// OIDCIssuer is the issuer of the Actions OIDC tokens of the instance.
+	OIDCIssuer string
+	// Results is the artifact and cache service of a job, as in
+	// ACTIONS_RESULTS_URL. It is only known inside a job.
+	Results string
+	// IDTokenRequest is where a job requests OIDC tokens, as in
+	// ACTIONS_ID_TOKEN_REQUEST_URL. It is only known inside a job.
+	IDTokenRequest string
+}
+
+// ForServer derives the endpoints of the instance whose web URL is server.
+// An empty server means github.com.
+func ForServer(server string) Endpoints {
+	server = strings.TrimSuffix(server, "/")
+	if server == "" {
+		server = DotCom
+	}
+	u, err := url.Parse(server)
+	host := ""
+	if err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	switch {
+	case host == "github.com" || host == "www.github.com":
+		return Endpoints{
+			Server:     DotCom,
+			API:        "https://api.github.com",
+			GraphQL:    "https://api.github.com/graphql",
+			Uploads:    "https://uploads.github.com",
+			OIDCIssuer: "https://token.actions.githubusercontent.com",
+		}
+	case strings.HasSuffix(host, ".ghe.com"):
+		return Endpoints{
+			Server:     server,
+			API:        u.Scheme + "://api." + host,
+			GraphQL:    u.Scheme + "://api." + host + "/graphql",
+			Uploads:    u.Scheme + "://uploads." + host,
+			OIDCIssuer: u.Scheme + "://token.actions." + host,
+		}
+	}
+	return Endpoints{
+		Server:     server,
+		API:        server + "/api/v3",
+		GraphQL:    server + "/api/graphql",
+		Uploads:    server + "/api/uploads",
+		OIDCIssuer: server + "/_services/token",
+	}
+}
+
+// ForAPI derives the endpoints of the instance whose REST API is api.
+func ForAPI(api string) Endpoints {
+	api = strings.TrimSuffix(api, "/")
+	u, err := url.Parse(api)
+	if err != nil || u.Host == "" {
+		return ForServer("")
+	}
+	host := strings.ToLower(u.Host)
+	server := u.Scheme + "://" + u.Host
+	switch {
+	case host == "api.github.com":
+		server = DotCom
+	case strings.HasPrefix(host, "api.") && strings.HasSuffix(host, ".ghe.com"):
+		server = u.Scheme + "://" + strings.TrimPrefix(u.Host, "api.")
+	}
+	e := ForServer(server)
+	e.API = api
+	return e
+}
+
+// FromEnv returns the endpoints named by GITHUB_SERVER_URL, GITHUB_API_URL,
+// GITHUB_GRAPHQL_URL, ACTIONS_RESULTS_URL and ACTIONS_ID_TOKEN_REQUEST_URL,
+// deriving unset ones from the others and defaulting to github.com.
+func FromEnv() Endpoints {
+	var e Endpoints
+	if api := os.Getenv("GITHUB_API_URL"); api != "" && os.Getenv("GITHUB_SERVER_URL") == "" {
+		e = ForAPI(api)
+	} else {
+		e = ForServer(os.Getenv("GITHUB_SERVER_URL"))
+	}
+	if v := os.Getenv("GITHUB_API_URL"); v != "" {
+		e.API = strings.TrimSuffix(v, "/")
+	}
+	if v := os.Getenv("GITHUB_GRAPHQL_URL"); v != "" {
+		e.GraphQL = strings.TrimSuffix(v, "/")
+	}
+	e.Results = os.Getenv("ACTIONS_RESULTS_URL")
+	e.IDTokenRequest = os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	return e
+}
+
+// IsDotCom reports whether e is github.com.
+func (e Endpoints) IsDotCom() bool {
+	return e.Server == DotCom
+}
+
+// Repository returns the web URL of the repository owner/repo.
+func (e Endpoints) Repository(fullName string) string {
+	return e.Server + "/" + fullName
+}
+
+// ParseRemote splits a git remote URL of a repository, such as
+// git@github.com:owner/repo.git or https://ghes.example.com/owner/repo,
+// into the web URL of its server and owner/repo. It returns "" for both if
+// the remote is not a repository URL.
+func ParseRemote(remote string) (server, fullName string) {
+	remote = strings.TrimSuffix(strings.TrimSpace(remote), ".git")
+	if remote == "" {
+		return "", ""
+	}
+	var host, path string
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		switch u.Scheme {
+		case "https", "http", "ssh", "git":
+		default:
+			return "", ""
+		}
+		host, path = u.Hostname(), u.Path
+		if u.Scheme == "http" {
+			// Plain HTTP servers are served as they are reached.
+			server = "http://" + u.Host
+		}
+	} else if at, rest, ok := strings.Cut(remote, "@"); ok && !strings.Contains(at, "/") {
+		// scp-like syntax: user@host:owner/repo.
+		host, path, ok = strings.Cut(rest, ":")
+		if !ok {
+			return "", ""
+		}
+	} else {
+		return "", ""
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if host == "" || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", ""
+	}
+	if server == "" {
+		server = "https://" + host
+		if strings.EqualFold(host, "github.com") || strings.EqualFold(host, "ssh.github.com") {
+			server = DotCom
+		}
+	}
+	return server, parts[0] + "/" + parts[1]
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package envfile reads and writes the files through which steps set
+// environment variables, outputs and state (GITHUB_ENV, GITHUB_OUTPUT and
+// GITHUB_STATE) and add to PATH (GITHUB_PATH), with the semantics of the
+// hosted runner.
+package envfile
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Entry is a name and value set by a file.
+type Entry struct {
+	Name  string
+	Value string
+	// Line is where the entry starts, from 1.
+	Line int
+}
+
+// Parse parses the name=value lines and name<<DELIMITER blocks of a
+// GITHUB_ENV, GITHUB_OUTPUT or GITHUB_STATE file, in the order they were
+// written; a later entry for a name replaces an earlier one. As on the
+// hosted runner:
+//
+//   - Lines end in \n or \r\n; empty lines are skipped.
+//   - A line is name=value if its first = comes before any <<, and a block
+//     otherwise, so name=a<<b sets a<<b.
+//   - A block's value is the lines up to the one that is exactly the
+//     delimiter, joined with \n.
+func Parse(data []byte) ([]Entry, error) {
+	var entries []Entry
+	rest, n := string(data), 0
+	for rest != "" {
+		var line string
+		line, rest = cutLine(rest)
+		n++
+		if line == "" {
+			continue
+		}
+		eq, heredoc := strings.Index(line, "="), strings.Index(line, "<<")
+		if eq >= 0 && (heredoc < 0 || eq < heredoc) {
+			if eq == 0 {
+				return nil, fmt.Errorf("line %d: invalid format %q: the name must not be empty", n, line)
+			}
+			entries = append(entries, Entry{Name: line[:eq], Value: line[eq+1:], Line: n})
+			continue
+		}
+		if heredoc < 0 {
+			return nil, fmt.Errorf("line %d: invalid format %q", n, line)
+		}
+		name, delim := line[:heredoc], line[heredoc+2:]
+		if name == "" || delim == "" {
+			return nil, fmt.Errorf("line %d: invalid format %q: the name and delimiter must not be empty", n, line)
+		}
+		start := n
+		var value []string
+		closed := false
+		for rest != "" {
+			var l string
+			l, rest = cutLine(rest)
+			n++
+			if l == delim {
+				closed = true
+				break
+			}
+			value = append(value, l)
+		}
+		if !closed {
+			return nil, fmt.Errorf("line %d: no line matches the delimiter %q of %s", start, delim, name)
+		}
+		entries = append(entries, Entry{Name: name, Value: strings.Join(value, "\n"), Line: start})
+	}
+	return entries, nil
+}
+
+// cutLine splits off the first line of s, without its \n or \r\n.
+func cutLine(s string) (line, rest string) {
+	line, rest, _ = strings.Cut(s, "\n")
+	return strings.TrimSuffix(line, "\r"), rest
+}
+
+// Read parses a file with Parse. A missing file sets nothing.
+func Read(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Format formats a name and value as a block with a random delimiter,
+// which keeps any value intact.
+func Format(name, value string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("name must not be empty")
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	delim := "ghadelimiter_" + hex.EncodeToString(buf)
+	if strings.Contains(name, delim) || strings.Contains(value, delim) {
+		return "", fmt.Errorf("unexpected input: name and value must not contain the delimiter %q", delim)
+	}
+	return name + "<<" + delim + "\n" + value + "\n" + delim + "\n", nil
+}
+
+// Append appends name and value to the file at path.
+func Append(path, name, value string) error {
+	content, err := Format(name, value)
+	if err != nil {
+		return err
+	}
+	return appendFile(path, content)
+}
+
+// AppendPath appends a directory to the GITHUB_PATH file at path.
+func AppendPath(path, dir string) error {
+	if dir == "" || strings.ContainsAny(dir, "\r\n") {
+		return fmt.Errorf("invalid path %q", dir)
+	}
+	return appendFile(path, dir+"\n")
+}
+
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ParsePath returns the directories of a GITHUB_PATH file, one per line, in
+// the order they were added. Blank lines are skipped but others are kept as
+// written, spaces included.
+func ParsePath(data []byte) []string {
+	var dirs []string
+	for rest := string(data); rest != ""; {
+		var line string
+		line, rest = cutLine(rest)
+		if strings.TrimSpace(line) != "" {
+			dirs = append(dirs, line)
+		}
+	}
+	return dirs
+}
+
+// ReadPath parses a GITHUB_PATH file. A missing file adds nothing.
+func ReadPath(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParsePath(data), nil
+}
+
+// Prepend adds dirs to the front of path, which lists the directories
+// added earlier, the first taking precedence. Each directory added moves
+// ahead of those before it, and one added again moves to the front rather
+// than appearing twice.
+func Prepend(path, dirs []string) []string {
+	out := slices.Clone(path)
+	for _, d := range dirs {
+		out = slices.DeleteFunc(out, func(p string) bool { return p == d })
+		out = append([]string{d}, out...)
+	}
+	return out
+}
+
+// CheckEnv reports why the runner does not let GITHUB_ENV set a variable,
+// or returns nil. The GITHUB_ and RUNNER_ defaults are set for every step
+// and NODE_OPTIONS could inject code into every later JavaScript action.
+func CheckEnv(name string) error {
+	upper := strings.ToUpper(name)
+	switch {
+	case upper == "NODE_OPTIONS":
+		return fmt.Errorf("can't set NODE_OPTIONS with GITHUB_ENV")
+	case strings.HasPrefix(upper, "GITHUB_"), strings.HasPrefix(upper, "RUNNER_"):
+		return fmt.Errorf("can't overwrite the default environment variable %s with GITHUB_ENV", name)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Handler handles events.
+type Handler interface {
+	HandleEvent(ctx context.Context, e Event) error
+}
+
+// HandlerFunc adapts a function to a Handler.
+type HandlerFunc func(ctx context.Context, e Event) error
+
+// HandleEvent implements Handler.
+func (f HandlerFunc) HandleEvent(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// Dispatcher routes events to the handlers registered for their name.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	all      []Handler
+}
+
+// Handle registers h for events called name.
+func (d *Dispatcher) Handle(name string, h Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.handlers == nil {
+		d.handlers = map[string][]Handler{}
+	}
+	d.handlers[name] = append(d.handlers[name], h)
+}
+
+// HandleAll registers h for every event.
+func (d *Dispatcher) HandleAll(h Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.all = append(d.all, h)
+}
+
+// On registers fn for the events of type E, e.g.
+//
+//	events.On(d, func(ctx context.Context, e *events.PushEvent) error { ... })
+//
+// E can't be *UnknownEvent, whose name is only known per value; use Handle.
+func On[E Event](d *Dispatcher, fn func(ctx context.Context, e E) error) {
+	var zero E
+	d.Handle(zero.EventName(), HandlerFunc(func(ctx context.Context, e Event) error {
+		typed, ok := e.(E)
+		if !ok {
+			return nil
+		}
+		return fn(ctx, typed)
+	}))
+}
+
+// Dispatch calls every handler registered for e in registration order,
+// then the HandleAll handlers, and joins their errors.
+func (d *Dispatcher) Dispatch(ctx context.Context, e Event) error {
+	d.mu.RLock()
+	hs := append(append([]Handler(nil), d.handlers[e.EventName()]...), d.all...)
+	d.mu.RUnlock()
+	var errs []error
+	for _, h := range hs {
+		if err := h.HandleEvent(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DispatchPayload parses the payload of the named event and dispatches it.
+func (d *Dispatcher) DispatchPayload(ctx context.Context, name string, data []byte) error {
+	e, err := ParseEvent(name, data)
+	if err != nil {
+		return err
+	}
+	return d.Dispatch(ctx, e)
+}
+
+// MaxPayloadSize is the largest webhook payload GitHub delivers.
+const MaxPayloadSize = 25 << 20
+
+// WebhookHandler serves webhook deliveries to a Dispatcher.
+type WebhookHandler struct {
+	Dispatcher *Dispatcher
+	// Secret is the webhook secret. Deliveries without a valid
+	// X-Hub-Signature-256 are rejected when it is set.
+	Secret string
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.Header.Get("X-GitHub-Event")
+	if name == "" {
+		http.Error(w, "missing X-GitHub-Event header", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxPayloadSize+1))
+	if err != nil || len(body) > MaxPayloadSize {
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+	if h.Secret != "" {
+		if err := ValidateSignature(h.Secret, body, r.Header.Get("X-Hub-Signature-256")); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	e, err := ParseEvent(name, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Dispatcher.Dispatch(r.Context(), e); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ValidateSignature checks an X-Hub-Signature-256 header against body.
+func ValidateSignature(secret string, body []byte, signature string) error {
+	want, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return fmt.Errorf("missing or malformed signature")
+	}
+	got, err := hex.DecodeString(want)
+	if err != nil {
+		return fmt.Errorf("missing or malformed signature")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
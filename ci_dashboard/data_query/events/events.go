@@ -0,0 +1,427 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events defines the webhook payloads of the events that trigger
+// workflows, parses them and dispatches them to handlers.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Event is a parsed event payload.
+type Event interface {
+	// EventName is the name used in on: and X-GitHub-Event.
+	EventName() string
+	// Info returns the fields every payload shares.
+	Info() *Base
+}
+
+// Base holds the fields every payload shares. Organization and
+// Installation are only set when they apply.
+type Base struct {
+	Sender       *User         `json:"sender,omitempty"`
+	Repository   *Repository   `json:"repository,omitempty"`
+	Organization *Organization `json:"organization,omitempty"`
+	Installation *Installation `json:"installation,omitempty"`
+}
+
+// Info implements Event.
+func (b *Base) Info() *Base { return b }
+
+// BranchProtectionRuleEvent is sent when a branch protection rule changes.
+type BranchProtectionRuleEvent struct {
+	Base
+	Action string         `json:"action"`
+	Rule   map[string]any `json:"rule"`
+}
+
+// CheckRunEvent is sent when a check run changes.
+type CheckRunEvent struct {
+	Base
+	Action   string   `json:"action"`
+	CheckRun CheckRun `json:"check_run"`
+}
+
+// CheckSuiteEvent is sent when a check suite changes.
+type CheckSuiteEvent struct {
+	Base
+	Action     string     `json:"action"`
+	CheckSuite CheckSuite `json:"check_suite"`
+}
+
+// CreateEvent is sent when a branch or tag is created.
+type CreateEvent struct {
+	Base
+	Ref          string `json:"ref"`
+	RefType      string `json:"ref_type"`
+	MasterBranch string `json:"master_branch"`
+	Description  string `json:"description"`
+	PusherType   string `json:"pusher_type"`
+}
+
+// DeleteEvent is sent when a branch or tag is deleted.
+type DeleteEvent struct {
+	Base
+	Ref        string `json:"ref"`
+	RefType    string `json:"ref_type"`
+	PusherType string `json:"pusher_type"`
+}
+
+// DeploymentEvent is sent when a deployment is created.
+type DeploymentEvent struct {
+	Base
+	Action     string     `json:"action"`
+	Deployment Deployment `json:"deployment"`
+}
+
+// DeploymentStatusEvent is sent when a deployment's status changes.
+type DeploymentStatusEvent struct {
+	Base
+	Action           string           `json:"action"`
+	DeploymentStatus DeploymentStatus `json:"deployment_status"`
+	Deployment       Deployment       `json:"deployment"`
+}
+
+// DiscussionEvent is sent when a discussion changes.
+type DiscussionEvent struct {
+	Base
+	Action     string     `json:"action"`
+	Discussion Discussion `json:"discussion"`
+}
+
+// DiscussionCommentEvent is sent when a discussion comment changes.
+type DiscussionCommentEvent struct {
+	Base
+	Action     string     `json:"action"`
+	Discussion Discussion `json:"discussion"`
+	Comment    Comment    `json:"comment"`
+}
+
+// ForkEvent is sent when the repository is forked.
+type ForkEvent struct {
+	Base
+	Forkee Repository `json:"forkee"`
+}
+
+// GollumEvent is sent when wiki pages change.
+type GollumEvent struct {
+	Base
+	Pages []Page `json:"pages"`
+}
+
+// IssueCommentEvent is sent when an issue or pull request comment changes.
+type IssueCommentEvent struct {
+	Base
+	Action  string         `json:"action"`
+	Issue   Issue          `json:"issue"`
+	Comment Comment        `json:"comment"`
+	Changes map[string]any `json:"changes,omitempty"`
+}
+
+// IssuesEvent is sent when an issue changes.
+type IssuesEvent struct {
+	Base
+	Action   string         `json:"action"`
+	Issue    Issue          `json:"issue"`
+	Label    *Label         `json:"label,omitempty"`
+	Assignee *User          `json:"assignee,omitempty"`
+	Changes  map[string]any `json:"changes,omitempty"`
+}
+
+// LabelEvent is sent when a repository label changes.
+type LabelEvent struct {
+	Base
+	Action  string         `json:"action"`
+	Label   Label          `json:"label"`
+	Changes map[string]any `json:"changes,omitempty"`
+}
+
+// MergeGroupEvent is sent when a pull request is added to a merge queue,
+// with action checks_requested, and when the group is destroyed.
+type MergeGroupEvent struct {
+	Base
+	Action     string     `json:"action"`
+	MergeGroup MergeGroup `json:"merge_group"`
+	// Reason says why a group was destroyed: merged, invalidated or
+	// dequeued.
+	Reason string `json:"reason,omitempty"`
+}
+
+// MilestoneEvent is sent when a milestone changes.
+type MilestoneEvent struct {
+	Base
+	Action    string         `json:"action"`
+	Milestone Milestone      `json:"milestone"`
+	Changes   map[string]any `json:"changes,omitempty"`
+}
+
+// PageBuildEvent is sent when a GitHub Pages build finishes.
+type PageBuildEvent struct {
+	Base
+	ID    int64          `json:"id"`
+	Build map[string]any `json:"build"`
+}
+
+// PingEvent is sent when a webhook is created.
+type PingEvent struct {
+	Base
+	Zen    string `json:"zen"`
+	HookID int64  `json:"hook_id"`
+}
+
+// PublicEvent is sent when the repository is made public.
+type PublicEvent struct {
+	Base
+}
+
+// PullRequestEvent is sent when a pull request changes.
+type PullRequestEvent struct {
+	Base
+	Action            string         `json:"action"`
+	Number            int            `json:"number"`
+	PullRequest       PullRequest    `json:"pull_request"`
+	Label             *Label         `json:"label,omitempty"`
+	RequestedReviewer *User          `json:"requested_reviewer,omitempty"`
+	Before            string         `json:"before,omitempty"`
+	After             string         `json:"after,omitempty"`
+	Changes           map[string]any `json:"changes,omitempty"`
+}
+
+// PullRequestTargetEvent has the pull_request payload but runs in the
+// context of the base branch.
+type PullRequestTargetEvent struct {
+	PullRequestEvent
+}
+
+// PullRequestReviewEvent is sent when a review is submitted, edited or
+// dismissed.
+type PullRequestReviewEvent struct {
+	Base
+	Action      string      `json:"action"`
+	Review      Review      `json:"review"`
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+// PullRequestReviewCommentEvent is sent when a review comment changes.
+type PullRequestReviewCommentEvent struct {
+	Base
+	Action      string      `json:"action"`
+	Comment     Comment     `json:"comment"`
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+// PushEvent is sent when commits or tags are pushed.
+type PushEvent struct {
+	Base
+	Ref        string      `json:"ref"`
+	Before     string      `json:"before"`
+	After      string      `json:"after"`
+	BaseRef    string      `json:"base_ref"`
+	Created    bool        `json:"created"`
+	Deleted    bool        `json:"deleted"`
+	Forced     bool        `json:"forced"`
+	Compare    string      `json:"compare"`
+	Commits    []Commit    `json:"commits"`
+	HeadCommit *HeadCommit `json:"head_commit"`
+	Pusher     GitUser     `json:"pusher"`
+}
+
+// RegistryPackageEvent is sent when a package is published or updated.
+type RegistryPackageEvent struct {
+	Base
+	Action          string  `json:"action"`
+	RegistryPackage Package `json:"registry_package"`
+}
+
+// ReleaseEvent is sent when a release changes.
+type ReleaseEvent struct {
+	Base
+	Action  string  `json:"action"`
+	Release Release `json:"release"`
+}
+
+// RepositoryDispatchEvent is sent by the repository dispatch API.
+type RepositoryDispatchEvent struct {
+	Base
+	Action        string         `json:"action"`
+	Branch        string         `json:"branch"`
+	ClientPayload map[string]any `json:"client_payload"`
+}
+
+// ScheduleEvent triggers scheduled workflows.
+type ScheduleEvent struct {
+	Base
+	Schedule string `json:"schedule"`
+}
+
+// StatusEvent is sent when a commit status changes.
+type StatusEvent struct {
+	Base
+	ID          int64  `json:"id"`
+	SHA         string `json:"sha"`
+	State       string `json:"state"`
+	Context     string `json:"context"`
+	Description string `json:"description"`
+	TargetURL   string `json:"target_url"`
+	Branches    []struct {
+		Name   string `json:"name"`
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	} `json:"branches"`
+}
+
+// WatchEvent is sent when the repository is starred.
+type WatchEvent struct {
+	Base
+	Action string `json:"action"`
+}
+
+// WorkflowDispatchEvent is sent when a workflow is run manually.
+type WorkflowDispatchEvent struct {
+	Base
+	Inputs   map[string]any `json:"inputs"`
+	Ref      string         `json:"ref"`
+	Workflow string         `json:"workflow"`
+}
+
+// WorkflowJobEvent is sent when a job is queued, starts or completes.
+type WorkflowJobEvent struct {
+	Base
+	Action      string      `json:"action"`
+	WorkflowJob WorkflowJob `json:"workflow_job"`
+}
+
+// WorkflowRunEvent is sent when a workflow run is requested or completes.
+type WorkflowRunEvent struct {
+	Base
+	Action      string      `json:"action"`
+	Workflow    Workflow    `json:"workflow"`
+	WorkflowRun WorkflowRun `json:"workflow_run"`
+}
+
+// UnknownEvent is an event this package has no type for.
+type UnknownEvent struct {
+	Base
+	Name    string
+	Payload map[string]any
+}
+
+func (e *BranchProtectionRuleEvent) EventName() string     { return "branch_protection_rule" }
+func (e *CheckRunEvent) EventName() string                 { return "check_run" }
+func (e *CheckSuiteEvent) EventName() string               { return "check_suite" }
+func (e *CreateEvent) EventName() string                   { return "create" }
+func (e *DeleteEvent) EventName() string                   { return "delete" }
+func (e *DeploymentEvent) EventName() string               { return "deployment" }
+func (e *DeploymentStatusEvent) EventName() string         { return "deployment_status" }
+func (e *DiscussionEvent) EventName() string               { return "discussion" }
+func (e *DiscussionCommentEvent) EventName() string        { return "discussion_comment" }
+func (e *ForkEvent) EventName() string                     { return "fork" }
+func (e *GollumEvent) EventName() string                   { return "gollum" }
+func (e *IssueCommentEvent) EventName() string             { return "issue_comment" }
+func (e *IssuesEvent) EventName() string                   { return "issues" }
+func (e *LabelEvent) EventName() string                    { return "label" }
+func (e *MergeGroupEvent) EventName() string               { return "merge_group" }
+func (e *MilestoneEvent) EventName() string                { return "milestone" }
+func (e *PageBuildEvent) EventName() string                { return "page_build" }
+func (e *PingEvent) EventName() string                     { return "ping" }
+func (e *PublicEvent) EventName() string                   { return "public" }
+func (e *PullRequestEvent) EventName() string              { return "pull_request" }
+func (e *PullRequestTargetEvent) EventName() string        { return "pull_request_target" }
+func (e *PullRequestReviewEvent) EventName() string        { return "pull_request_review" }
+func (e *PullRequestReviewCommentEvent) EventName() string { return "pull_request_review_comment" }
+func (e *PushEvent) EventName() string                     { return "push" }
+func (e *RegistryPackageEvent) EventName() string          { return "registry_package" }
+func (e *ReleaseEvent) EventName() string                  { return "release" }
+func (e *RepositoryDispatchEvent) EventName() string       { return "repository_dispatch" }
+func (e *ScheduleEvent) EventName() string                 { return "schedule" }
+func (e *StatusEvent) EventName() string                   { return "status" }
+func (e *WatchEvent) EventName() string                    { return "watch" }
+func (e *WorkflowDispatchEvent) EventName() string         { return "workflow_dispatch" }
+func (e *WorkflowJobEvent) EventName() string              { return "workflow_job" }
+func (e *WorkflowRunEvent) EventName() string              { return "workflow_run" }
+func (e *UnknownEvent) EventName() string                  { return e.Name }
+
+var types = map[string]func() Event{
+	"branch_protection_rule":      func() Event { return &BranchProtectionRuleEvent{} },
+	"check_run":                   func() Event { return &CheckRunEvent{} },
+	"check_suite":                 func() Event { return &CheckSuiteEvent{} },
+	"create":                      func() Event { return &CreateEvent{} },
+	"delete":                      func() Event { return &DeleteEvent{} },
+	"deployment":                  func() Event { return &DeploymentEvent{} },
+	"deployment_status":           func() Event { return &DeploymentStatusEvent{} },
+	"discussion":                  func() Event { return &DiscussionEvent{} },
+	"discussion_comment":          func() Event { return &DiscussionCommentEvent{} },
+	"fork":                        func() Event { return &ForkEvent{} },
+	"gollum":                      func() Event { return &GollumEvent{} },
+	"issue_comment":               func() Event { return &IssueCommentEvent{} },
+	"issues":                      func() Event { return &IssuesEvent{} },
+	"label":                       func() Event { return &LabelEvent{} },
+	"merge_group":                 func() Event { return &MergeGroupEvent{} },
+	"milestone":                   func() Event { return &MilestoneEvent{} },
+	"page_build":                  func() Event { return &PageBuildEvent{} },
+	"ping":                        func() Event { return &PingEvent{} },
+	"public":                      func() Event { return &PublicEvent{} },
+	"pull_request":                func() Event { return &PullRequestEvent{} },
+	"pull_request_target":         func() Event { return &PullRequestTargetEvent{} },
+	"pull_request_review":         func() Event { return &PullRequestReviewEvent{} },
+	"pull_request_review_comment": func() Event { return &PullRequestReviewCommentEvent{} },
+	"push":                        func() Event { return &PushEvent{} },
+	"registry_package":            func() Event { return &RegistryPackageEvent{} },
+	"release":                     func() Event { return &ReleaseEvent{} },
+	"repository_dispatch":         func() Event { return &RepositoryDispatchEvent{} },
+	"schedule":                    func() Event { return &ScheduleEvent{} },
+	"status":                      func() Event { return &StatusEvent{} },
+	"watch":                       func() Event { return &WatchEvent{} },
+	"workflow_dispatch":           func() Event { return &WorkflowDispatchEvent{} },
+	"workflow_job":                func() Event { return &WorkflowJobEvent{} },
+	"workflow_run":                func() Event { return &WorkflowRunEvent{} },
+}
+
+// Names returns the event names ParseEvent has types for, sorted.
+func Names() []string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseEvent decodes the payload of the named event. Names without a type
+// decode to an *UnknownEvent holding the raw payload.
+func ParseEvent(name string, data []byte) (Event, error) {
+	if len(data) == 0 {
+		data = []byte("{}")
+	}
+	newEvent, ok := types[name]
+	if !ok {
+		e := &UnknownEvent{Name: name}
+		if err := json.Unmarshal(data, &e.Base); err != nil {
+			return nil, fmt.Errorf("failed to parse %s payload: %w", name, err)
+		}
+		if err := json.Unmarshal(data, &e.Payload); err != nil {
+			return nil, fmt.Errorf("failed to parse %s payload: %w", name, err)
+		}
+		return e, nil
+	}
+	e := newEvent()
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("failed to parse %s payload: %w", name, err)
+	}
+	return e, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Git is the git state GitHub gives a workflow run triggered by an event,
+// as in the github context. Fields the payload does not determine are
+// empty; in particular SHA is unknown for events that run on the latest
+// commit of the default branch.
+type Git struct {
+	Ref, SHA         string
+	RefName, RefType string
+	// HeadRef and BaseRef are set for pull request events.
+	HeadRef, BaseRef string
+}
+
+// GitContext returns the ref and commit a run triggered by e checks out.
+func GitContext(e Event) Git {
+	var g Git
+	switch e := e.(type) {
+	case *PushEvent:
+		g.Ref, g.SHA, g.BaseRef = e.Ref, e.After, e.BaseRef
+	case *PullRequestTargetEvent:
+		pr := &e.PullRequest
+		g.Ref, g.SHA = "refs/heads/"+pr.Base.Ref, pr.Base.SHA
+		g.HeadRef, g.BaseRef = pr.Head.Ref, pr.Base.Ref
+	case *PullRequestEvent:
+		g.pullRequest(&e.PullRequest)
+	case *PullRequestReviewEvent:
+		g.pullRequest(&e.PullRequest)
+	case *PullRequestReviewCommentEvent:
+		g.pullRequest(&e.PullRequest)
+	case *ReleaseEvent:
+		g.Ref = "refs/tags/" + e.Release.TagName
+	case *CreateEvent:
+		g.Ref = qualify(e.Ref, e.RefType)
+	case *WorkflowDispatchEvent:
+		g.Ref = e.Ref
+	case *MergeGroupEvent:
+		g.Ref, g.SHA = e.MergeGroup.HeadRef, e.MergeGroup.HeadSHA
+	case *DeploymentEvent:
+		g.Ref, g.SHA = e.Deployment.Ref, e.Deployment.SHA
+	case *DeploymentStatusEvent:
+		g.Ref, g.SHA = e.Deployment.Ref, e.Deployment.SHA
+	}
+	if g.Ref == "" {
+		if repo := e.Info().Repository; repo != nil && repo.DefaultBranch != "" {
+			g.Ref = "refs/heads/" + repo.DefaultBranch
+		}
+	}
+	switch {
+	case strings.HasPrefix(g.Ref, "refs/heads/"):
+		g.RefName, g.RefType = strings.TrimPrefix(g.Ref, "refs/heads/"), "branch"
+	case strings.HasPrefix(g.Ref, "refs/tags/"):
+		g.RefName, g.RefType = strings.TrimPrefix(g.Ref, "refs/tags/"), "tag"
+	case strings.HasPrefix(g.Ref, "refs/pull/"):
+		g.RefName, g.RefType = strings.TrimPrefix(g.Ref, "refs/pull/"), "branch"
+	}
+	return g
+}
+
+// pullRequest sets the merge ref GitHub creates for pull request events.
+func (g *Git) pullRequest(pr *PullRequest) {
+	g.Ref = fmt.Sprintf("refs/pull/%d/merge", pr.Number)
+	g.SHA = pr.MergeCommitSHA
+	g.HeadRef, g.BaseRef = pr.Head.Ref, pr.Base.Ref
+}
+
+// qualify turns a short branch or tag name into a full ref.
+func qualify(name, refType string) string {
+	if name == "" || strings.HasPrefix(name, "refs/") {
+		return name
+	}
+	if refType == "tag" {
+		return "refs/tags/" + name
+	}
+	return "refs/heads/" + name
+}
@@ -0,0 +1,431 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// User is a user, bot or organization account.
+type User struct {
+	Login     string `json:"login"`
+	ID        int64  `json:"id"`
+	NodeID    string `json:"node_id,omitempty"`
+	Type      string `json:"type,omitempty"`
+	SiteAdmin bool   `json:"site_admin,omitempty"`
+	HTMLURL   string `json:"html_url,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// Organization is the organization a repository belongs to.
+type Organization struct {
+	Login  string `json:"login"`
+	ID     int64  `json:"id"`
+	NodeID string `json:"node_id,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// Installation is the GitHub App installation a webhook was sent for.
+type Installation struct {
+	ID     int64  `json:"id"`
+	NodeID string `json:"node_id,omitempty"`
+}
+
+// App is a GitHub App, such as the one that created a check suite.
+type App struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name,omitempty"`
+}
+
+// ActionsApp is the slug of the app that creates the check suites and runs
+// of workflow runs.
+const ActionsApp = "github-actions"
+
+// Repository is a repository. Its timestamps are omitted because push
+// payloads encode them as Unix times and every other payload as strings.
+type Repository struct {
+	ID            int64  `json:"id"`
+	NodeID        string `json:"node_id,omitempty"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Owner         *User  `json:"owner,omitempty"`
+	Private       bool   `json:"private"`
+	Visibility    string `json:"visibility,omitempty"`
+	Fork          bool   `json:"fork"`
+	Archived      bool   `json:"archived,omitempty"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+	HTMLURL       string `json:"html_url,omitempty"`
+	CloneURL      string `json:"clone_url,omitempty"`
+	SSHURL        string `json:"ssh_url,omitempty"`
+	URL           string `json:"url,omitempty"`
+}
+
+// GitUser is the author or committer of a commit.
+type GitUser struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
+}
+
+// Commit is a commit in a push payload.
+type Commit struct {
+	ID        string    `json:"id"`
+	TreeID    string    `json:"tree_id,omitempty"`
+	Distinct  bool      `json:"distinct"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	URL       string    `json:"url,omitempty"`
+	Author    GitUser   `json:"author"`
+	Committer GitUser   `json:"committer"`
+	Added     []string  `json:"added,omitempty"`
+	Removed   []string  `json:"removed,omitempty"`
+	Modified  []string  `json:"modified,omitempty"`
+}
+
+// Label is an issue or pull request label.
+type Label struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+	Default     bool   `json:"default,omitempty"`
+}
+
+// Milestone is an issue or pull request milestone.
+type Milestone struct {
+	ID     int64  `json:"id"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+}
+
+// Issue is an issue, or a pull request seen through the issues API, in
+// which case PullRequest is set.
+type Issue struct {
+	ID                int64      `json:"id"`
+	NodeID            string     `json:"node_id,omitempty"`
+	Number            int        `json:"number"`
+	Title             string     `json:"title"`
+	Body              string     `json:"body"`
+	State             string     `json:"state"`
+	StateReason       string     `json:"state_reason,omitempty"`
+	Locked            bool       `json:"locked,omitempty"`
+	User              *User      `json:"user,omitempty"`
+	Labels            []Label    `json:"labels,omitempty"`
+	Assignees         []User     `json:"assignees,omitempty"`
+	Milestone         *Milestone `json:"milestone,omitempty"`
+	AuthorAssociation string     `json:"author_association,omitempty"`
+	HTMLURL           string     `json:"html_url,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	ClosedAt          *time.Time `json:"closed_at,omitempty"`
+	PullRequest       *struct {
+		URL     string `json:"url"`
+		HTMLURL string `json:"html_url,omitempty"`
+	} `json:"pull_request,omitempty"`
+}
+
+// Comment is an issue, commit, pull request review or discussion comment.
+type Comment struct {
+	ID                int64     `json:"id"`
+	NodeID            string    `json:"node_id,omitempty"`
+	Body              string    `json:"body"`
+	User              *User     `json:"user,omitempty"`
+	AuthorAssociation string    `json:"author_association,omitempty"`
+	HTMLURL           string    `json:"html_url,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	// Path, Line and CommitID are set on review comments.
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	CommitID string `json:"commit_id,omitempty"`
+}
+
+// Branch is the head or base of a pull request.
+type Branch struct {
+	Label string      `json:"label"`
+	Ref   string      `json:"ref"`
+	SHA   string      `json:"sha"`
+	User  *User       `json:"user,omitempty"`
+	Repo  *Repository `json:"repo,omitempty"`
+}
+
+// PullRequest is a pull request.
+type PullRequest struct {
+	ID                int64      `json:"id"`
+	NodeID            string     `json:"node_id,omitempty"`
+	Number            int        `json:"number"`
+	Title             string     `json:"title"`
+	Body              string     `json:"body"`
+	State             string     `json:"state"`
+	Draft             bool       `json:"draft"`
+	Locked            bool       `json:"locked,omitempty"`
+	Merged            bool       `json:"merged"`
+	MergeCommitSHA    string     `json:"merge_commit_sha,omitempty"`
+	Mergeable         *bool      `json:"mergeable,omitempty"`
+	User              *User      `json:"user,omitempty"`
+	Labels            []Label    `json:"labels,omitempty"`
+	Assignees         []User     `json:"assignees,omitempty"`
+	Milestone         *Milestone `json:"milestone,omitempty"`
+	Head              Branch     `json:"head"`
+	Base              Branch     `json:"base"`
+	AuthorAssociation string     `json:"author_association,omitempty"`
+	HTMLURL           string     `json:"html_url,omitempty"`
+	Commits           int        `json:"commits,omitempty"`
+	Additions         int        `json:"additions,omitempty"`
+	Deletions         int        `json:"deletions,omitempty"`
+	ChangedFiles      int        `json:"changed_files,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	ClosedAt          *time.Time `json:"closed_at,omitempty"`
+	MergedAt          *time.Time `json:"merged_at,omitempty"`
+}
+
+// Review is a pull request review.
+type Review struct {
+	ID                int64     `json:"id"`
+	NodeID            string    `json:"node_id,omitempty"`
+	Body              string    `json:"body"`
+	State             string    `json:"state"`
+	CommitID          string    `json:"commit_id"`
+	User              *User     `json:"user,omitempty"`
+	AuthorAssociation string    `json:"author_association,omitempty"`
+	HTMLURL           string    `json:"html_url,omitempty"`
+	SubmittedAt       time.Time `json:"submitted_at"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Label              string `json:"label,omitempty"`
+	ContentType        string `json:"content_type"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// Release is a GitHub release.
+type Release struct {
+	ID              int64      `json:"id"`
+	NodeID          string     `json:"node_id,omitempty"`
+	TagName         string     `json:"tag_name"`
+	TargetCommitish string     `json:"target_commitish"`
+	Name            string     `json:"name"`
+	Body            string     `json:"body"`
+	Draft           bool       `json:"draft"`
+	Prerelease      bool       `json:"prerelease"`
+	Author          *User      `json:"author,omitempty"`
+	Assets          []Asset    `json:"assets,omitempty"`
+	HTMLURL         string     `json:"html_url,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+}
+
+// HeadCommit is the commit a workflow run, check suite or merge group is
+// for.
+type HeadCommit struct {
+	ID        string    `json:"id"`
+	TreeID    string    `json:"tree_id,omitempty"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	Author    GitUser   `json:"author"`
+	Committer GitUser   `json:"committer"`
+}
+
+// Workflow is a workflow definition.
+type Workflow struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	State string `json:"state"`
+}
+
+// WorkflowRun is a run of a workflow.
+type WorkflowRun struct {
+	ID           int64         `json:"id"`
+	Name         string        `json:"name"`
+	DisplayTitle string        `json:"display_title,omitempty"`
+	Event        string        `json:"event"`
+	Status       string        `json:"status"`
+	Conclusion   string        `json:"conclusion"`
+	HeadBranch   string        `json:"head_branch"`
+	HeadSHA      string        `json:"head_sha"`
+	HeadCommit   *HeadCommit   `json:"head_commit,omitempty"`
+	Path         string        `json:"path"`
+	RunNumber    int           `json:"run_number"`
+	RunAttempt   int           `json:"run_attempt"`
+	WorkflowID   int64         `json:"workflow_id"`
+	Actor        *User         `json:"actor,omitempty"`
+	Repository   *Repository   `json:"repository,omitempty"`
+	PullRequests []PullRequest `json:"pull_requests,omitempty"`
+	HTMLURL      string        `json:"html_url,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// WorkflowJob is a job of a workflow run.
+type WorkflowJob struct {
+	ID          int64      `json:"id"`
+	RunID       int64      `json:"run_id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Conclusion  string     `json:"conclusion"`
+	HeadSHA     string     `json:"head_sha"`
+	HeadBranch  string     `json:"head_branch,omitempty"`
+	Labels      []string   `json:"labels"`
+	RunnerName  string     `json:"runner_name,omitempty"`
+	RunAttempt  int        `json:"run_attempt"`
+	HTMLURL     string     `json:"html_url,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// CheckSuite is a check suite: the check runs one app reports on a
+// commit. Each workflow run has a suite of its own, created by ActionsApp,
+// with a check run per job.
+type CheckSuite struct {
+	ID           int64         `json:"id"`
+	HeadBranch   string        `json:"head_branch"`
+	HeadSHA      string        `json:"head_sha"`
+	Before       string        `json:"before,omitempty"`
+	After        string        `json:"after,omitempty"`
+	Status       string        `json:"status"`
+	Conclusion   string        `json:"conclusion"`
+	App          *App          `json:"app,omitempty"`
+	PullRequests []PullRequest `json:"pull_requests,omitempty"`
+	// LatestCheckRunsCount is how many check runs the suite has.
+	LatestCheckRunsCount int `json:"latest_check_runs_count,omitempty"`
+}
+
+// CreatedByActions reports whether the suite belongs to a workflow run.
+func (s *CheckSuite) CreatedByActions() bool {
+	return s.App != nil && s.App.Slug == ActionsApp
+}
+
+// CheckRun is a check run.
+type CheckRun struct {
+	ID         int64       `json:"id"`
+	Name       string      `json:"name"`
+	HeadSHA    string      `json:"head_sha"`
+	Status     string      `json:"status"`
+	Conclusion string      `json:"conclusion"`
+	ExternalID string      `json:"external_id,omitempty"`
+	HTMLURL    string      `json:"html_url,omitempty"`
+	App        *App        `json:"app,omitempty"`
+	CheckSuite *CheckSuite `json:"check_suite,omitempty"`
+}
+
+// Deployment is a deployment to an environment.
+type Deployment struct {
+	ID          int64  `json:"id"`
+	SHA         string `json:"sha"`
+	Ref         string `json:"ref"`
+	Task        string `json:"task"`
+	Environment string `json:"environment"`
+	Description string `json:"description,omitempty"`
+	Creator     *User  `json:"creator,omitempty"`
+	Payload     any    `json:"payload,omitempty"`
+}
+
+// DeploymentStatus is a status update of a deployment.
+type DeploymentStatus struct {
+	ID             int64  `json:"id"`
+	State          string `json:"state"`
+	Description    string `json:"description,omitempty"`
+	Environment    string `json:"environment"`
+	EnvironmentURL string `json:"environment_url,omitempty"`
+	LogURL         string `json:"log_url,omitempty"`
+	Creator        *User  `json:"creator,omitempty"`
+}
+
+// Discussion is a repository discussion.
+type Discussion struct {
+	ID       int64  `json:"id"`
+	NodeID   string `json:"node_id,omitempty"`
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	State    string `json:"state"`
+	User     *User  `json:"user,omitempty"`
+	HTMLURL  string `json:"html_url,omitempty"`
+	Category struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	} `json:"category"`
+}
+
+// Page is a wiki page changed by a gollum event.
+type Page struct {
+	PageName string `json:"page_name"`
+	Title    string `json:"title"`
+	Action   string `json:"action"`
+	SHA      string `json:"sha"`
+	HTMLURL  string `json:"html_url,omitempty"`
+}
+
+// MergeGroup is a merge queue group.
+type MergeGroup struct {
+	HeadSHA    string      `json:"head_sha"`
+	HeadRef    string      `json:"head_ref"`
+	BaseSHA    string      `json:"base_sha"`
+	BaseRef    string      `json:"base_ref"`
+	HeadCommit *HeadCommit `json:"head_commit,omitempty"`
+}
+
+// mergeQueuePrefix starts the head refs of merge groups, which are
+// gh-readonly-queue/<base branch>/pr-<number>-<head sha>.
+const mergeQueuePrefix = "refs/heads/gh-readonly-queue/"
+
+// Branch returns the name of the branch the group merges into.
+func (g *MergeGroup) Branch() string {
+	return strings.TrimPrefix(g.BaseRef, "refs/heads/")
+}
+
+// PullRequestNumber returns the number of the newest pull request of the
+// group, as its head ref names it, or 0 if the ref is not of that form.
+func (g *MergeGroup) PullRequestNumber() int {
+	rest, ok := strings.CutPrefix(g.HeadRef, mergeQueuePrefix+g.Branch()+"/pr-")
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.SplitN(rest, "-", 2)[0])
+	return n
+}
+
+// MergeGroupRef returns the head ref of the merge group that adds pull
+// request number, whose head is sha, to the queue of branch.
+func MergeGroupRef(branch string, number int, sha string) string {
+	return mergeQueuePrefix + branch + "/pr-" + strconv.Itoa(number) + "-" + sha
+}
+
+// Package is a package published to GitHub Packages.
+type Package struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	Namespace      string `json:"namespace,omitempty"`
+	Ecosystem      string `json:"ecosystem,omitempty"`
+	PackageType    string `json:"package_type"`
+	HTMLURL        string `json:"html_url,omitempty"`
+	Owner          *User  `json:"owner,omitempty"`
+	PackageVersion *struct {
+		ID      int64  `json:"id"`
+		Version string `json:"version"`
+		Name    string `json:"name,omitempty"`
+	} `json:"package_version,omitempty"`
+}
@@ -0,0 +1,245 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const gcpMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// insertBatch is how many rows each streaming insert sends.
+const insertBatch = 500
+
+var bigqueryTypes = map[string]string{
+	TypeString:    "STRING",
+	TypeInteger:   "INTEGER",
+	TypeFloat:     "FLOAT",
+	TypeTimestamp: "TIMESTAMP",
+	TypeList:      "STRING",
+}
+
+// BigQuery streams records into a BigQuery table, creating it, partitioned
+// by day of created_at, if it does not exist.
+type BigQuery struct {
+	Project, Dataset, Table string
+	// Token is an OAuth access token. Defaults to
+	// $GOOGLE_OAUTH_ACCESS_TOKEN, then gcloud auth print-access-token,
+	// then the metadata server's service account.
+	Token string
+	// Endpoint overrides https://bigquery.googleapis.com.
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// ParseBigQueryTable parses a table as project.dataset.table.
+func ParseBigQueryTable(s string) (*BigQuery, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid BigQuery table %q: want project.dataset.table", s)
+	}
+	return &BigQuery{Project: parts[0], Dataset: parts[1], Table: parts[2]}, nil
+}
+
+func (b *BigQuery) String() string {
+	return b.Project + "." + b.Dataset + "." + b.Table
+}
+
+// Write creates the table if needed and inserts the records. Rows carry
+// their job ID as insert ID, so BigQuery drops ones retried shortly after.
+func (b *BigQuery) Write(ctx context.Context, records []*Record) error {
+	token, err := b.token(ctx)
+	if err != nil {
+		return err
+	}
+	tables := fmt.Sprintf("%s/bigquery/v2/projects/%s/datasets/%s/tables", b.endpoint(), url.PathEscape(b.Project), url.PathEscape(b.Dataset))
+	err = b.do(ctx, token, http.MethodGet, tables+"/"+url.PathEscape(b.Table), nil, nil)
+	var e *bigqueryError
+	if errors.As(err, &e) && e.status == http.StatusNotFound {
+		err = b.do(ctx, token, http.MethodPost, tables, b.tableResource(), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", b, err)
+	}
+	for start := 0; start < len(records); start += insertBatch {
+		batch := records[start:min(start+insertBatch, len(records))]
+		rows := make([]map[string]any, len(batch))
+		for i, r := range batch {
+			rows[i] = map[string]any{"insertId": strconv.FormatInt(r.JobID, 10), "json": bigqueryRow(r)}
+		}
+		var resp struct {
+			InsertErrors []struct {
+				Index  int `json:"index"`
+				Errors []struct {
+					Reason  string `json:"reason"`
+					Message string `json:"message"`
+				} `json:"errors"`
+			} `json:"insertErrors"`
+		}
+		if err := b.do(ctx, token, http.MethodPost, tables+"/"+url.PathEscape(b.Table)+"/insertAll", map[string]any{"rows": rows}, &resp); err != nil {
+			return fmt.Errorf("failed to insert into %s: %w", b, err)
+		}
+		if len(resp.InsertErrors) > 0 {
+			ie := resp.InsertErrors[0]
+			msg := "unknown error"
+			if len(ie.Errors) > 0 {
+				msg = ie.Errors[0].Message
+			}
+			return fmt.Errorf("failed to insert %d rows into %s; the first, job %d: %s", len(resp.InsertErrors), b, batch[ie.Index].JobID, msg)
+		}
+	}
+	return nil
+}
+
+func (b *BigQuery) tableResource() map[string]any {
+	fields := make([]map[string]string, len(Columns))
+	for i, c := range Columns {
+		mode := "NULLABLE"
+		if c.Type == TypeList {
+			mode = "REPEATED"
+		}
+		fields[i] = map[string]string{"name": c.Name, "type": bigqueryTypes[c.Type], "mode": mode}
+	}
+	return map[string]any{
+		"tableReference":   map[string]string{"projectId": b.Project, "datasetId": b.Dataset, "tableId": b.Table},
+		"schema":           map[string]any{"fields": fields},
+		"timePartitioning": map[string]string{"type": "DAY", "field": "created_at"},
+	}
+}
+
+func bigqueryRow(r *Record) map[string]any {
+	row := map[string]any{}
+	for i, v := range r.values() {
+		if t, ok := v.(time.Time); ok {
+			if t.IsZero() {
+				v = nil
+			} else {
+				v = t.UTC().Format(time.RFC3339)
+			}
+		}
+		row[Columns[i].Name] = v
+	}
+	return row
+}
+
+type bigqueryError struct {
+	status  int
+	message string
+}
+
+func (e *bigqueryError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.status, http.StatusText(e.status), e.message)
+}
+
+func (b *BigQuery) do(ctx context.Context, token, method, u string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &e)
+		if e.Error.Message == "" {
+			e.Error.Message = strings.TrimSpace(string(data))
+		}
+		return &bigqueryError{status: resp.StatusCode, message: e.Error.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (b *BigQuery) client() *http.Client {
+	if b.HTTPClient != nil {
+		return b.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (b *BigQuery) endpoint() string {
+	if b.Endpoint != "" {
+		return strings.TrimSuffix(b.Endpoint, "/")
+	}
+	return "https://bigquery.googleapis.com"
+}
+
+func (b *BigQuery) token(ctx context.Context) (string, error) {
+	if b.Token != "" {
+		return b.Token, nil
+	}
+	if t := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); t != "" {
+		return t, nil
+	}
+	if out, err := exec.CommandContext(ctx, "gcloud", "auth", "print-access-token").Output(); err == nil {
+		if t := strings.TrimSpace(string(out)); t != "" {
+			return t, nil
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataToken, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var tok struct {
+		AccessToken string `json:"access_token"`
+	}
+	resp, err := b.client().Do(req)
+	if err == nil {
+		err = json.NewDecoder(resp.Body).Decode(&tok)
+		resp.Body.Close()
+	}
+	if err != nil || tok.AccessToken == "" {
+		return "", errors.New("no Google credentials: set GOOGLE_OAUTH_ACCESS_TOKEN or run gcloud auth login")
+	}
+	return tok.AccessToken, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column types.
+const (
+	TypeString    = "string"
+	TypeInteger   = "integer"
+	TypeFloat     = "float"
+	TypeTimestamp = "timestamp"
+	// TypeList is a list of strings, such as the labels.
+	TypeList = "list"
+)
+
+// Column is a field of a record in the tabular formats.
+type Column struct {
+	Name, Type string
+}
+
+// Columns are the fields of a record in the order they are written.
+var Columns = []Column{
+	{"repository", TypeString},
+	{"run_id", TypeInteger},
+	{"run_number", TypeInteger},
+	{"run_attempt", TypeInteger},
+	{"workflow", TypeString},
+	{"workflow_path", TypeString},
+	{"event", TypeString},
+	{"branch", TypeString},
+	{"head_sha", TypeString},
+	{"job_id", TypeInteger},
+	{"name", TypeString},
+	{"job", TypeString},
+	{"leg", TypeString},
+	{"labels", TypeList},
+	{"runner_name", TypeString},
+	{"conclusion", TypeString},
+	{"created_at", TypeTimestamp},
+	{"started_at", TypeTimestamp},
+	{"completed_at", TypeTimestamp},
+	{"queue_seconds", TypeFloat},
+	{"duration_seconds", TypeFloat},
+}
+
+// values returns the record's fields in the order of Columns.
+func (r *Record) values() []any {
+	return []any{
+		r.Repository, r.RunID, int64(r.RunNumber), int64(r.RunAttempt),
+		r.Workflow, r.WorkflowPath, r.Event, r.Branch, r.HeadSHA,
+		r.JobID, r.Name, r.Job, r.Leg, r.Labels, r.RunnerName, r.Conclusion,
+		r.CreatedAt, r.StartedAt, r.CompletedAt,
+		r.QueueSeconds, r.DurationSeconds,
+	}
+}
+
+// text formats a field for CSV: times in RFC 3339 and UTC, empty when
+// zero, and lists comma-separated.
+func text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, ",")
+	}
+	panic("unexpected column value")
+}
+
+// WriteCSV writes the records as CSV with a header row.
+func WriteCSV(w io.Writer, records []*Record) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, len(Columns))
+	for i, c := range Columns {
+		header[i] = c.Name
+	}
+	cw.Write(header)
+	for _, r := range records {
+		values := r.values()
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = text(v)
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
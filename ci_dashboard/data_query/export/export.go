@@ -0,0 +1,245 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export pulls the timing of workflow runs and jobs from the API
+// and writes it as normalized records to CSV, SQLite or BigQuery, for
+// capacity planning of runner pools.
+package export
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/client"
+)
+
+// Record is the timing of one attempt of one job.
+type Record struct {
+	Repository string `json:"repository"`
+	RunID      int64  `json:"run_id"`
+	RunNumber  int    `json:"run_number"`
+	RunAttempt int    `json:"run_attempt"`
+	Workflow   string `json:"workflow"`
+	// WorkflowPath is the workflow file, such as .github/workflows/ci.yml.
+	WorkflowPath string `json:"workflow_path"`
+	Event        string `json:"event"`
+	Branch       string `json:"branch"`
+	HeadSHA      string `json:"head_sha"`
+	JobID        int64  `json:"job_id"`
+	// Name is the job's full name, Job the name without the matrix values
+	// and Leg the matrix values, such as "ubuntu, 1.22", identifying the
+	// matrix leg.
+	Name       string    `json:"name"`
+	Job        string    `json:"job"`
+	Leg        string    `json:"leg"`
+	Labels     []string  `json:"labels"`
+	RunnerName string    `json:"runner_name"`
+	Conclusion string    `json:"conclusion"`
+	CreatedAt  time.Time `json:"created_at"`
+	// StartedAt is zero for skipped jobs and jobs that never got a
+	// runner, such as ones cancelled while queued.
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	// QueueSeconds is the delay from creation to start, and
+	// DurationSeconds the time from start to completion.
+	QueueSeconds    float64 `json:"queue_seconds"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// Options select the runs to export.
+type Options struct {
+	// Workflow limits the runs to one workflow, by ID or file name.
+	Workflow string
+	// Since and Until bound the creation date of the runs. Zero values
+	// are unbounded.
+	Since, Until time.Time
+}
+
+// Collect returns the records of every job, including every attempt, of
+// the completed runs of owner/repo matching opts.
+func Collect(ctx context.Context, c *client.Client, owner, repo string, opts Options) iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		ro := client.RunsOptions{Workflow: opts.Workflow, Status: "completed", Created: createdRange(opts.Since, opts.Until)}
+		for run, err := range c.ListRuns(ctx, owner, repo, ro) {
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list runs of %s/%s: %w", owner, repo, err))
+				return
+			}
+			jobs, err := c.ListJobs(ctx, owner, repo, run.ID, true)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list jobs of run %d: %w", run.ID, err))
+				return
+			}
+			for _, job := range jobs {
+				if !yield(newRecord(owner+"/"+repo, run, job), nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// CollectOrg returns the records of every repository of org that is not
+// archived, as Collect does.
+func CollectOrg(ctx context.Context, c *client.Client, org string, opts Options) iter.Seq2[*Record, error] {
+	return func(yield func(*Record, error) bool) {
+		for repo, err := range c.ListOrgRepos(ctx, org) {
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list repositories of %s: %w", org, err))
+				return
+			}
+			if repo.Archived {
+				continue
+			}
+			for r, err := range Collect(ctx, c, org, repo.Name, opts) {
+				if !yield(r, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func newRecord(repo string, run *client.Run, job *client.Job) *Record {
+	base, leg := splitLeg(job.Name)
+	r := &Record{
+		Repository:   repo,
+		RunID:        run.ID,
+		RunNumber:    run.RunNumber,
+		RunAttempt:   job.RunAttempt,
+		Workflow:     run.Name,
+		WorkflowPath: run.Path,
+		Event:        run.Event,
+		Branch:       run.HeadBranch,
+		HeadSHA:      run.HeadSHA,
+		JobID:        job.ID,
+		Name:         job.Name,
+		Job:          base,
+		Leg:          leg,
+		Labels:       job.Labels,
+		RunnerName:   job.RunnerName,
+		Conclusion:   job.Conclusion,
+		CreatedAt:    job.CreatedAt,
+		StartedAt:    job.StartedAt,
+		CompletedAt:  job.CompletedAt,
+	}
+	if r.Labels == nil {
+		r.Labels = []string{}
+	}
+	switch {
+	case job.Conclusion == "skipped":
+		// Skipped jobs never queue.
+		r.StartedAt = time.Time{}
+		return r
+	case job.RunnerName == "":
+		// The job never got a runner, so it queued until it was
+		// cancelled.
+		r.StartedAt = time.Time{}
+		r.QueueSeconds = span(job.CreatedAt, job.CompletedAt)
+		return r
+	}
+	r.QueueSeconds = job.QueueTime().Seconds()
+	r.DurationSeconds = job.Duration().Seconds()
+	return r
+}
+
+func span(start, end time.Time) float64 {
+	if start.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Seconds()
+}
+
+// splitLeg splits the matrix values GitHub appends to the names of matrix
+// jobs from the job name.
+func splitLeg(name string) (job, leg string) {
+	if i := strings.LastIndex(name, " ("); i > 0 && strings.HasSuffix(name, ")") {
+		return name[:i], name[i+2 : len(name)-1]
+	}
+	return name, ""
+}
+
+// createdRange formats a created: search qualifier.
+func createdRange(since, until time.Time) string {
+	switch {
+	case since.IsZero() && until.IsZero():
+		return ""
+	case until.IsZero():
+		return ">=" + since.Format(time.DateOnly)
+	case since.IsZero():
+		return "<=" + until.Format(time.DateOnly)
+	}
+	return since.Format(time.DateOnly) + ".." + until.Format(time.DateOnly)
+}
+
+// LabelStats summarizes the jobs that asked for one runner label.
+type LabelStats struct {
+	Label string `json:"label"`
+	Jobs  int    `json:"jobs"`
+	// Queue percentiles and maximum, in seconds.
+	QueueP50 float64 `json:"queue_p50"`
+	QueueP90 float64 `json:"queue_p90"`
+	QueueP99 float64 `json:"queue_p99"`
+	QueueMax float64 `json:"queue_max"`
+	// Minutes is the total time the jobs ran.
+	Minutes float64 `json:"minutes"`
+}
+
+// ByLabel breaks records down by runner label, busiest first. A job
+// counts towards each of its labels.
+func ByLabel(records []*Record) []LabelStats {
+	queues := map[string][]float64{}
+	stats := map[string]*LabelStats{}
+	for _, r := range records {
+		if r.Conclusion == "skipped" {
+			continue
+		}
+		for _, l := range r.Labels {
+			s := stats[l]
+			if s == nil {
+				s = &LabelStats{Label: l}
+				stats[l] = s
+			}
+			s.Jobs++
+			s.Minutes += r.DurationSeconds / 60
+			queues[l] = append(queues[l], r.QueueSeconds)
+		}
+	}
+	out := make([]LabelStats, 0, len(stats))
+	for l, s := range stats {
+		q := queues[l]
+		sort.Float64s(q)
+		s.QueueP50, s.QueueP90, s.QueueP99 = percentile(q, 50), percentile(q, 90), percentile(q, 99)
+		s.QueueMax = q[len(q)-1]
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Minutes != out[j].Minutes {
+			return out[i].Minutes > out[j].Minutes
+		}
+		return out[i].Label < out[j].Label
+	})
+	return out
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTable is the table records are written to. The labels of each
+// job also go, one row per label, to the table with a _labels suffix.
+const DefaultTable = "jobs"
+
+var tableRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var sqliteTypes = map[string]string{
+	TypeString:    "TEXT",
+	TypeInteger:   "INTEGER",
+	TypeFloat:     "REAL",
+	TypeTimestamp: "TEXT",
+	TypeList:      "TEXT",
+}
+
+// WriteSQL writes a SQLite script that creates table, if it does not
+// exist, and inserts the records. Records are keyed by job ID, so
+// exporting overlapping windows replaces rather than duplicates them.
+func WriteSQL(w io.Writer, table string, records []*Record) error {
+	if !tableRE.MatchString(table) {
+		return fmt.Errorf("invalid table name %q", table)
+	}
+	var b bytes.Buffer
+	b.WriteString("BEGIN;\n")
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", table)
+	for _, c := range Columns {
+		fmt.Fprintf(&b, "  %s %s,\n", c.Name, sqliteTypes[c.Type])
+	}
+	b.WriteString("  PRIMARY KEY (job_id)\n);\n")
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s_labels (job_id INTEGER, label TEXT, PRIMARY KEY (job_id, label));\n", table)
+	for _, r := range records {
+		values := r.values()
+		literals := make([]string, len(values))
+		for i, v := range values {
+			literals[i] = sqlLiteral(v)
+		}
+		fmt.Fprintf(&b, "INSERT OR REPLACE INTO %s VALUES (%s);\n", table, strings.Join(literals, ", "))
+		fmt.Fprintf(&b, "DELETE FROM %s_labels WHERE job_id = %d;\n", table, r.JobID)
+		for _, l := range r.Labels {
+			fmt.Fprintf(&b, "INSERT OR IGNORE INTO %s_labels VALUES (%d, %s);\n", table, r.JobID, sqlLiteral(l))
+		}
+	}
+	b.WriteString("COMMIT;\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func sqlLiteral(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return "NULL"
+		}
+	}
+	return "'" + strings.ReplaceAll(text(v), "'", "''") + "'"
+}
+
+// SQLite writes records to a SQLite database with the sqlite3 command,
+// which must be on PATH.
+type SQLite struct {
+	Path string
+	// Table defaults to DefaultTable.
+	Table string
+}
+
+// Write inserts the records into the database, creating it if needed.
+func (s *SQLite) Write(ctx context.Context, records []*Record) error {
+	table := s.Table
+	if table == "" {
+		table = DefaultTable
+	}
+	var script bytes.Buffer
+	if err := WriteSQL(&script, table, records); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "sqlite3", "-bail", s.Path)
+	cmd.Stdin = &script
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("failed to write %s: %s", s.Path, msg)
+		}
+		return fmt.Errorf("failed to write %s: %w", s.Path, err)
+	}
+	return nil
+}
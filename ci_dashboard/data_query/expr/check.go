@@ -0,0 +1,340 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Kind is the kind of value an expression produces.
+type Kind int
+
+const (
+	// KindAny is a value whose kind is not known statically.
+	KindAny Kind = iota
+	KindNull
+	KindBool
+	KindNumber
+	KindString
+	KindObject
+	KindArray
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return "null"
+	case KindBool:
+		return "boolean"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindObject:
+		return "object"
+	case KindArray:
+		return "array"
+	}
+	return "any"
+}
+
+// article returns k with its indefinite article.
+func (k Kind) article() string {
+	if k == KindObject || k == KindArray || k == KindAny {
+		return "an " + k.String()
+	}
+	return "a " + k.String()
+}
+
+// Type is the static type of a value, as far as it is known.
+type Type struct {
+	Kind Kind
+	// Properties are the known properties of an object, by lower-case
+	// name.
+	Properties map[string]*Type
+	// Strict means an object has no properties but Properties.
+	Strict bool
+	// Elem is the type of the elements of an array, and of the properties
+	// of an object that is not strict other than Properties. Nil means
+	// any.
+	Elem *Type
+}
+
+// Types of scalars and of values not known statically.
+var (
+	AnyType    = &Type{}
+	NullType   = &Type{Kind: KindNull}
+	BoolType   = &Type{Kind: KindBool}
+	NumberType = &Type{Kind: KindNumber}
+	StringType = &Type{Kind: KindString}
+)
+
+// Object returns the type of objects with exactly the properties props,
+// keyed by lower-case name.
+func Object(props map[string]*Type) *Type {
+	return &Type{Kind: KindObject, Properties: props, Strict: true}
+}
+
+// Map returns the type of objects with any properties of type elem.
+func Map(elem *Type) *Type {
+	return &Type{Kind: KindObject, Elem: elem}
+}
+
+// ArrayOf returns the type of arrays of elem.
+func ArrayOf(elem *Type) *Type {
+	return &Type{Kind: KindArray, Elem: elem}
+}
+
+func (t *Type) kind() Kind {
+	if t == nil {
+		return KindAny
+	}
+	return t.Kind
+}
+
+// Problem is a type error found by Check.
+type Problem struct {
+	// Offset is the byte offset within the expression of the node at
+	// fault.
+	Offset  int
+	Message string
+}
+
+// Check infers the type of the parsed expression node where the contexts
+// have types, keyed by lower-case name. It reports references to unknown
+// contexts and functions, calls with the wrong number or kind of
+// arguments, properties that cannot exist and comparisons that coerce
+// their operands so that they are always true or false.
+func Check(node Node, types map[string]*Type) (*Type, []Problem) {
+	c := &checker{types: types}
+	t := c.check(node)
+	return t.t, c.problems
+}
+
+type checker struct {
+	types    map[string]*Type
+	problems []Problem
+}
+
+// typed is the type of a node; filtered is set for the arrays object
+// filters produce, whose properties are those of their elements.
+type typed struct {
+	t        *Type
+	filtered bool
+}
+
+func (c *checker) report(n Node, format string, args ...any) {
+	c.problems = append(c.problems, Problem{Offset: n.Pos(), Message: fmt.Sprintf(format, args...)})
+}
+
+// name describes n in messages by the reference it makes, if it makes one.
+func name(n Node) string {
+	if chain := Chain(n); chain != nil {
+		return strings.Join(chain, ".")
+	}
+	return "the value"
+}
+
+func (c *checker) check(n Node) typed {
+	switch n := n.(type) {
+	case *Literal:
+		switch n.Value.(type) {
+		case nil:
+			return typed{t: NullType}
+		case bool:
+			return typed{t: BoolType}
+		case float64:
+			return typed{t: NumberType}
+		case string:
+			return typed{t: StringType}
+		}
+	case *Ident:
+		t, ok := c.types[strings.ToLower(n.Name)]
+		if !ok {
+			c.report(n, "unknown context %q", n.Name)
+			return typed{t: AnyType}
+		}
+		return typed{t: t}
+	case *Property:
+		return c.member(c.check(n.Object), n.Object, n.Name)
+	case *Index:
+		obj := c.check(n.Object)
+		idx := n.Index
+		c.check(idx)
+		if lit, ok := idx.(*Literal); ok {
+			if s, ok := lit.Value.(string); ok && obj.t.kind() == KindObject {
+				return c.member(obj, n.Object, s)
+			}
+		}
+		if k := obj.t.kind(); k == KindArray || k == KindObject {
+			return typed{t: elem(obj.t)}
+		}
+	case *Filter:
+		obj := c.check(n.Object)
+		return typed{t: ArrayOf(elem(obj.t)), filtered: true}
+	case *Call:
+		return typed{t: c.call(n)}
+	case *Not:
+		c.check(n.Operand)
+		return typed{t: BoolType}
+	case *Binary:
+		l, r := c.check(n.Left), c.check(n.Right)
+		switch n.Op {
+		case "&&", "||":
+			// The result is one of the operands.
+			if l.t.kind() == r.t.kind() {
+				return typed{t: l.t}
+			}
+			return typed{t: AnyType}
+		}
+		c.compare(n, l.t, r.t)
+		return typed{t: BoolType}
+	}
+	return typed{t: AnyType}
+}
+
+// elem returns the type of the elements of t, or of its properties if it
+// is an object.
+func elem(t *Type) *Type {
+	if t.kind() == KindObject && t.Strict {
+		// The properties may have different types.
+		var common *Type
+		for _, p := range t.Properties {
+			if common != nil && common.kind() != p.kind() {
+				return AnyType
+			}
+			common = p
+		}
+		if common != nil {
+			return common
+		}
+	}
+	if t == nil || t.Elem == nil {
+		return AnyType
+	}
+	return t.Elem
+}
+
+// member returns the type of the property prop of obj, the value of the
+// node n.
+func (c *checker) member(obj typed, n Node, prop string) typed {
+	if obj.filtered {
+		// The property of each element.
+		m := c.member(typed{t: elem(obj.t)}, n, prop)
+		return typed{t: ArrayOf(m.t), filtered: true}
+	}
+	switch k := obj.t.kind(); k {
+	case KindAny, KindNull:
+		return typed{t: AnyType}
+	case KindObject:
+		if t, ok := obj.t.Properties[strings.ToLower(prop)]; ok {
+			return typed{t: t}
+		}
+		if obj.t.Strict {
+			c.report(n, "%s has no property %q", name(n), prop)
+			return typed{t: AnyType}
+		}
+		return typed{t: elem(obj.t)}
+	default:
+		c.report(n, "%s is %s and has no property %q", name(n), k.article(), prop)
+		return typed{t: AnyType}
+	}
+}
+
+func (c *checker) call(n *Call) *Type {
+	args := make([]*Type, len(n.Args))
+	for i, arg := range n.Args {
+		args[i] = c.check(arg).t
+	}
+	lower := strings.ToLower(n.Name)
+	fn, ok := Functions[lower]
+	if !ok {
+		c.report(n, "unknown function %s", n.Name)
+		return AnyType
+	}
+	if len(n.Args) < fn.MinArgs || fn.MaxArgs >= 0 && len(n.Args) > fn.MaxArgs {
+		c.report(n, "wrong number of arguments to %s", fn.Name)
+		return AnyType
+	}
+	switch lower {
+	case "contains":
+		if k := args[0].kind(); k == KindObject {
+			c.report(n.Args[0], "%s cannot search %s, which is %s; pass a string or an array", fn.Name, name(n.Args[0]), k.article())
+		}
+		return BoolType
+	case "startswith", "endswith":
+		return BoolType
+	case "format":
+		if k := args[0].kind(); k != KindString && k != KindAny {
+			c.report(n.Args[0], "format expects a format string, not %s", k.article())
+		} else if lit, ok := n.Args[0].(*Literal); ok {
+			if _, err := format(lit.Value.(string), make([]any, len(n.Args)-1)); err != nil {
+				c.report(n.Args[0], "%v", err)
+			}
+		}
+		return StringType
+	case "fromjson":
+		if k := args[0].kind(); k == KindObject || k == KindArray {
+			c.report(n.Args[0], "fromJSON expects a JSON string, not %s", k.article())
+		}
+		return AnyType
+	case "join", "tojson", "hashfiles":
+		return StringType
+	}
+	// The status functions.
+	return BoolType
+}
+
+// compare checks the operands of the comparison n.
+func (c *checker) compare(n *Binary, l, r *Type) {
+	lk, rk := l.kind(), r.kind()
+	if lk == KindAny || rk == KindAny || lk == rk {
+		return
+	}
+	equality := n.Op == "==" || n.Op == "!="
+	for _, side := range []struct {
+		node, other Node
+		kind        Kind
+		otherKind   Kind
+	}{{n.Left, n.Right, lk, rk}, {n.Right, n.Left, rk, lk}} {
+		if side.kind == KindObject || side.kind == KindArray {
+			// Objects and arrays are only equal to themselves and convert
+			// to NaN.
+			c.report(side.node, "%s is %s, so comparing it with %s is always %v", name(side.node), side.kind.article(), side.otherKind.article(), n.Op == "!=")
+			return
+		}
+	}
+	if !equality {
+		return
+	}
+	for _, side := range []struct {
+		node, lit Node
+		kind      Kind
+	}{{n.Left, n.Right, lk}, {n.Right, n.Left, rk}} {
+		lit, ok := side.lit.(*Literal)
+		if !ok {
+			continue
+		}
+		s, ok := lit.Value.(string)
+		if !ok || !math.IsNaN(ToNumber(s)) {
+			continue
+		}
+		// The string converts to a number, and NaN is equal to nothing.
+		c.report(side.node, "%s is %s, so comparing it with %q is always %v", name(side.node), side.kind.article(), s, n.Op == "!=")
+		return
+	}
+}
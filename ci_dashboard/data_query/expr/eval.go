@@ -0,0 +1,541 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package expr implements the GitHub Actions expression language used inside
+// ${{ }} and in if: conditions.
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Status is the job status consulted by success(), failure() and cancelled().
+type Status string
+
+const (
+	StatusSuccess   Status = "success"
+	StatusFailure   Status = "failure"
+	StatusCancelled Status = "cancelled"
+)
+
+// Context supplies everything an expression can observe.
+type Context struct {
+	// Values maps context names (github, env, matrix, ...) to their values.
+	// Values are plain Go data: maps, slices, strings, numbers and booleans.
+	Values map[string]any
+	// Status is the current job status. The zero value means success.
+	Status Status
+	// HashFiles implements hashFiles(). If nil, calling it is an error.
+	HashFiles func(patterns ...string) (string, error)
+}
+
+// Evaluate parses and evaluates an expression without the surrounding ${{ }}.
+func Evaluate(src string, ctx *Context) (any, error) {
+	node, err := Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return EvaluateNode(node, ctx)
+}
+
+// EvaluateNode evaluates a parsed expression.
+func EvaluateNode(node Node, ctx *Context) (any, error) {
+	if ctx == nil {
+		ctx = &Context{}
+	}
+	e := &evaluator{ctx: ctx}
+	v, err := e.eval(node)
+	if f, ok := v.(filtered); ok {
+		v = []any(f)
+	}
+	return v, err
+}
+
+// EvaluateCondition evaluates an if: condition. The ${{ }} wrapper is
+// optional, and conditions that do not call a status function are implicitly
+// combined with success(), as on GitHub.
+func EvaluateCondition(cond string, ctx *Context) (bool, error) {
+	cond = strings.TrimSpace(cond)
+	if strings.HasPrefix(cond, "${{") && strings.HasSuffix(cond, "}}") && strings.Count(cond, "${{") == 1 {
+		cond = strings.TrimSpace(cond[3 : len(cond)-2])
+	}
+	if cond == "" {
+		cond = "success()"
+	}
+	node, err := Parse(cond)
+	if err != nil {
+		return false, err
+	}
+	if !HasStatusFunction(node) {
+		node = &Binary{Op: "&&", Left: &Call{Name: "success"}, Right: node}
+	}
+	v, err := EvaluateNode(node, ctx)
+	if err != nil {
+		return false, err
+	}
+	return Truthy(v), nil
+}
+
+// HasStatusFunction reports whether node calls success(), failure(),
+// always() or cancelled().
+func HasStatusFunction(node Node) bool {
+	found := false
+	Walk(node, func(n Node) {
+		if call, ok := n.(*Call); ok {
+			switch strings.ToLower(call.Name) {
+			case "success", "failure", "always", "cancelled":
+				found = true
+			}
+		}
+	})
+	return found
+}
+
+// Walk calls fn for node and every node beneath it, parents first.
+func Walk(node Node, fn func(Node)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+	switch n := node.(type) {
+	case *Property:
+		Walk(n.Object, fn)
+	case *Index:
+		Walk(n.Object, fn)
+		Walk(n.Index, fn)
+	case *Filter:
+		Walk(n.Object, fn)
+	case *Call:
+		for _, arg := range n.Args {
+			Walk(arg, fn)
+		}
+	case *Not:
+		Walk(n.Operand, fn)
+	case *Binary:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	}
+}
+
+// Chain returns the path of a property dereference such as
+// steps.x.outputs, with * for filters and non-constant indexes, or nil if n
+// is not one.
+func Chain(n Node) []string {
+	switch n := n.(type) {
+	case *Ident:
+		return []string{n.Name}
+	case *Property:
+		if obj := Chain(n.Object); obj != nil {
+			return append(obj, n.Name)
+		}
+	case *Filter:
+		if obj := Chain(n.Object); obj != nil {
+			return append(obj, "*")
+		}
+	case *Index:
+		obj := Chain(n.Object)
+		if obj == nil {
+			return nil
+		}
+		if lit, ok := n.Index.(*Literal); ok {
+			if s, ok := lit.Value.(string); ok {
+				return append(obj, s)
+			}
+		}
+		return append(obj, "*")
+	}
+	return nil
+}
+
+type evaluator struct {
+	ctx *Context
+}
+
+func (e *evaluator) eval(node Node) (any, error) {
+	switch n := node.(type) {
+	case *Literal:
+		return n.Value, nil
+	case *Ident:
+		for name, v := range e.ctx.Values {
+			if strings.EqualFold(name, n.Name) {
+				return Normalize(v), nil
+			}
+		}
+		return nil, fmt.Errorf("unrecognized named-value: '%s'", n.Name)
+	case *Property:
+		obj, err := e.eval(n.Object)
+		if err != nil {
+			return nil, err
+		}
+		return property(obj, n.Name), nil
+	case *Index:
+		obj, err := e.eval(n.Object)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := e.eval(n.Index)
+		if err != nil {
+			return nil, err
+		}
+		return index(obj, idx), nil
+	case *Filter:
+		obj, err := e.eval(n.Object)
+		if err != nil {
+			return nil, err
+		}
+		return filter(obj), nil
+	case *Call:
+		return e.call(n)
+	case *Not:
+		v, err := e.eval(n.Operand)
+		if err != nil {
+			return nil, err
+		}
+		return !Truthy(v), nil
+	case *Binary:
+		left, err := e.eval(n.Left)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case "&&":
+			if !Truthy(left) {
+				return left, nil
+			}
+			return e.eval(n.Right)
+		case "||":
+			if Truthy(left) {
+				return left, nil
+			}
+			return e.eval(n.Right)
+		}
+		right, err := e.eval(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		return compare(n.Op, left, right), nil
+	}
+	return nil, fmt.Errorf("unsupported expression node %T", node)
+}
+
+// filtered marks arrays produced by an object filter, so that a following
+// property access applies to each element.
+type filtered []any
+
+func property(obj any, name string) any {
+	if _, ok := obj.(filtered); !ok {
+		obj = Normalize(obj)
+	}
+	switch o := obj.(type) {
+	case map[string]any:
+		if v, ok := o[name]; ok {
+			return v
+		}
+		for k, v := range o {
+			if strings.EqualFold(k, name) {
+				return v
+			}
+		}
+	case filtered:
+		var out filtered
+		for _, item := range o {
+			if v := property(item, name); v != nil {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func index(obj any, idx any) any {
+	if _, ok := obj.(filtered); !ok {
+		obj = Normalize(obj)
+	}
+	switch o := obj.(type) {
+	case []any:
+		f := ToNumber(idx)
+		if math.IsNaN(f) || f < 0 {
+			return nil
+		}
+		i := int(math.Floor(f))
+		if i >= len(o) {
+			return nil
+		}
+		return o[i]
+	case filtered:
+		var out filtered
+		for _, item := range o {
+			if v := index(item, idx); v != nil {
+				out = append(out, v)
+			}
+		}
+		return out
+	case map[string]any:
+		return property(o, ToString(idx))
+	}
+	return nil
+}
+
+func filter(obj any) any {
+	if _, ok := obj.(filtered); !ok {
+		obj = Normalize(obj)
+	}
+	switch o := obj.(type) {
+	case []any:
+		return filtered(o)
+	case filtered:
+		var out filtered
+		for _, item := range o {
+			if inner, ok := filter(item).(filtered); ok {
+				out = append(out, inner...)
+			}
+		}
+		return out
+	case map[string]any:
+		out := make(filtered, 0, len(o))
+		for _, k := range sortedKeys(o) {
+			out = append(out, o[k])
+		}
+		return out
+	}
+	return filtered{}
+}
+
+// Normalize converts Go values into the representation used by the
+// evaluator: nil, bool, float64, string, []any and map[string]any.
+func Normalize(v any) any {
+	switch x := v.(type) {
+	case nil, bool, float64, string, map[string]any:
+		return x
+	case filtered:
+		return []any(x)
+	case []any:
+		return x
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return x.String()
+		}
+		return f
+	case map[string]string:
+		out := make(map[string]any, len(x))
+		for k, s := range x {
+			out[k] = s
+		}
+		return out
+	case []string:
+		out := make([]any, len(x))
+		for i, s := range x {
+			out[i] = s
+		}
+		return out
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = Normalize(iter.Value().Interface())
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make([]any, rv.Len())
+		for i := range out {
+			out[i] = Normalize(rv.Index(i).Interface())
+		}
+		return out
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return ToNumber(fmt.Sprint(v))
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Pointer, reflect.Struct:
+		// Round-trip structs through JSON so their json tags define the
+		// property names, as they would for an event payload.
+		data, err := json.Marshal(v)
+		if err != nil {
+			break
+		}
+		var out any
+		if err := json.Unmarshal(data, &out); err == nil {
+			return out
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// Truthy reports whether v is truthy under the expression language rules.
+func Truthy(v any) bool {
+	switch x := Normalize(v).(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0 && !math.IsNaN(x)
+	case string:
+		return x != ""
+	}
+	return true
+}
+
+// ToNumber converts v to a number using the language's coercion rules.
+func ToNumber(v any) float64 {
+	switch x := Normalize(v).(type) {
+	case nil:
+		return 0
+	case bool:
+		if x {
+			return 1
+		}
+		return 0
+	case float64:
+		return x
+	case string:
+		s := strings.TrimSpace(x)
+		if s == "" {
+			return 0
+		}
+		f, err := parseNumber(s)
+		if err != nil {
+			return math.NaN()
+		}
+		return f
+	}
+	return math.NaN()
+}
+
+// ToString converts v to the string produced when interpolating it.
+func ToString(v any) string {
+	switch x := Normalize(v).(type) {
+	case nil:
+		return ""
+	case bool:
+		if x {
+			return "true"
+		}
+		return "false"
+	case float64:
+		return formatNumber(x)
+	case string:
+		return x
+	}
+	s, _ := toJSON(v)
+	return s
+}
+
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		return "0"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func compare(op string, left, right any) bool {
+	left, right = Normalize(left), Normalize(right)
+	switch op {
+	case "==":
+		return looseEqual(left, right)
+	case "!=":
+		return !looseEqual(left, right)
+	}
+	if ls, ok := left.(string); ok {
+		if rs, ok := right.(string); ok {
+			c := strings.Compare(strings.ToUpper(ls), strings.ToUpper(rs))
+			switch op {
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			case ">=":
+				return c >= 0
+			}
+		}
+	}
+	l, r := ToNumber(left), ToNumber(right)
+	switch op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	}
+	return false
+}
+
+func looseEqual(left, right any) bool {
+	switch l := left.(type) {
+	case nil:
+		if right == nil {
+			return true
+		}
+	case bool:
+		if r, ok := right.(bool); ok {
+			return l == r
+		}
+	case float64:
+		if r, ok := right.(float64); ok {
+			return l == r
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.EqualFold(l, r)
+		}
+	case map[string]any, []any:
+		// Objects and arrays are only equal to themselves.
+		if reflect.TypeOf(left) == reflect.TypeOf(right) {
+			return reflect.ValueOf(left).Pointer() == reflect.ValueOf(right).Pointer()
+		}
+		return false
+	}
+	switch right.(type) {
+	case map[string]any, []any:
+		return false
+	}
+	l, r := ToNumber(left), ToNumber(right)
+	return l == r
+}
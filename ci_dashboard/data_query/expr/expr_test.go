@@ -0,0 +1,253 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expr
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		src   string
+		kinds []tokenKind
+	}{
+		{"", nil},
+		{"github.ref", []tokenKind{tokenIdent, tokenDot, tokenIdent}},
+		{"a.*.b", []tokenKind{tokenIdent, tokenDot, tokenStar, tokenDot, tokenIdent}},
+		{"!a && b || c", []tokenKind{tokenNot, tokenIdent, tokenAnd, tokenIdent, tokenOr, tokenIdent}},
+		{"a == b != c", []tokenKind{tokenIdent, tokenEq, tokenIdent, tokenNe, tokenIdent}},
+		{"1 < 2 <= 3 > 4 >= 5", []tokenKind{tokenNumber, tokenLt, tokenNumber, tokenLe, tokenNumber, tokenGt, tokenNumber, tokenGe, tokenNumber}},
+		{"f('x', -1)", []tokenKind{tokenIdent, tokenLParen, tokenString, tokenComma, tokenNumber, tokenRParen}},
+		{"a[0]", []tokenKind{tokenIdent, tokenLBracket, tokenNumber, tokenRBracket}},
+		{"true false null", []tokenKind{tokenTrue, tokenFalse, tokenNull}},
+		{"a-b", []tokenKind{tokenIdent}},
+	}
+	for _, tt := range tests {
+		tokens, err := lex(tt.src)
+		if err != nil {
+			t.Errorf("lex(%q): %v", tt.src, err)
+			continue
+		}
+		var kinds []tokenKind
+		for _, tok := range tokens[:len(tokens)-1] {
+			kinds = append(kinds, tok.kind)
+		}
+		if !equalKinds(kinds, tt.kinds) {
+			t.Errorf("lex(%q) = %v, want %v", tt.src, kinds, tt.kinds)
+		}
+		if last := tokens[len(tokens)-1]; last.kind != tokenEOF || last.pos != len(tt.src) {
+			t.Errorf("lex(%q) ends with %+v, want EOF at %d", tt.src, last, len(tt.src))
+		}
+	}
+}
+
+func equalKinds(a, b []tokenKind) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLexLiterals(t *testing.T) {
+	tests := []struct {
+		src  string
+		text string
+		num  float64
+	}{
+		{"'it''s'", "it's", 0},
+		{"''", "", 0},
+		{"42", "42", 42},
+		{"-3.5", "-3.5", -3.5},
+		{"+7", "+7", 7},
+		{".5", ".5", 0.5},
+		{"1e3", "1e3", 1000},
+		{"2E-2", "2E-2", 0.02},
+		{"0xff", "0xff", 255},
+		{"0o17", "0o17", 15},
+		{"-0x10", "-0x10", -16},
+	}
+	for _, tt := range tests {
+		tokens, err := lex(tt.src)
+		if err != nil {
+			t.Errorf("lex(%q): %v", tt.src, err)
+			continue
+		}
+		if len(tokens) != 2 {
+			t.Errorf("lex(%q) = %d tokens, want 1", tt.src, len(tokens)-1)
+			continue
+		}
+		if tok := tokens[0]; tok.text != tt.text || tok.num != tt.num {
+			t.Errorf("lex(%q) = %q %v, want %q %v", tt.src, tok.text, tok.num, tt.text, tt.num)
+		}
+	}
+}
+
+func TestSyntaxErrorOffset(t *testing.T) {
+	tests := []struct {
+		src     string
+		offset  int
+		message string
+	}{
+		{"0.1 + 0", 4, "unexpected character '+'"},
+		{"a = b", 2, "unexpected '='"},
+		{"a & b", 2, "unexpected '&'"},
+		{"a | b", 2, "unexpected '|'"},
+		{"x == 'abc", 5, "unterminated string"},
+		{"1 == 1x", 5, `invalid number "1x"`},
+		{"a ==", 4, ""},
+		{"(a", 2, ""},
+		{"a b", 2, ""},
+		{"x[1]-1", 4, "unexpected character '-'"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) {
+			t.Errorf("Parse(%q) error = %v, want *SyntaxError", tt.src, err)
+			continue
+		}
+		if syntax.Offset != tt.offset {
+			t.Errorf("Parse(%q) offset = %d, want %d", tt.src, syntax.Offset, tt.offset)
+		}
+		if tt.message != "" && syntax.Message != tt.message {
+			t.Errorf("Parse(%q) message = %q, want %q", tt.src, syntax.Message, tt.message)
+		}
+		if n := strings.Count(err.Error(), "position"); n != 1 {
+			t.Errorf("Parse(%q) error %q names %d positions, want 1", tt.src, err, n)
+		}
+	}
+}
+
+func TestCoercion(t *testing.T) {
+	numbers := []struct {
+		v    any
+		want float64
+	}{
+		{nil, 0},
+		{true, 1},
+		{false, 0},
+		{3, 3},
+		{"", 0},
+		{"  12 ", 12},
+		{"0x1A", 26},
+		{"-1.5", -1.5},
+		{"abc", math.NaN()},
+		{[]any{}, math.NaN()},
+		{map[string]any{}, math.NaN()},
+	}
+	for _, tt := range numbers {
+		got := ToNumber(tt.v)
+		if got != tt.want && !(math.IsNaN(got) && math.IsNaN(tt.want)) {
+			t.Errorf("ToNumber(%#v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+
+	strs := []struct {
+		v    any
+		want string
+	}{
+		{nil, ""},
+		{true, "true"},
+		{false, "false"},
+		{1.0, "1"},
+		{0.5, "0.5"},
+		{-0.0, "0"},
+		{1e21, "1000000000000000000000"},
+		{math.NaN(), "NaN"},
+		{math.Inf(1), "Infinity"},
+		{math.Inf(-1), "-Infinity"},
+		{"x", "x"},
+	}
+	for _, tt := range strs {
+		if got := ToString(tt.v); got != tt.want {
+			t.Errorf("ToString(%#v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+
+	truthy := []struct {
+		v    any
+		want bool
+	}{
+		{nil, false},
+		{false, false},
+		{0, false},
+		{math.NaN(), false},
+		{"", false},
+		{true, true},
+		{-1, true},
+		{"false", true},
+		{[]any{}, true},
+		{map[string]any{}, true},
+	}
+	for _, tt := range truthy {
+		if got := Truthy(tt.v); got != tt.want {
+			t.Errorf("Truthy(%#v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	ctx := &Context{Values: map[string]any{
+		"env": map[string]any{"NAME": "Main", "COUNT": "3"},
+		"obj": map[string]any{},
+	}}
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"'abc' == 'ABC'", true},
+		{"'abc' != 'abd'", true},
+		{"1 == 1.0", true},
+		{"'1' == 1", true},
+		{"'' == 0", true},
+		{"true == 1", true},
+		{"null == 0", true},
+		{"null == null", true},
+		{"'a' == 0", false},
+		{"env.NAME == 'main'", true},
+		{"env.COUNT > 2", true},
+		{"env.MISSING == null", true},
+		{"'a' < 'B'", true},
+		{"'b' >= 'B'", true},
+		{"'10' < '9'", true},
+		{"10 < '9'", false},
+		{"2 <= 2", true},
+		{"'x' < 1", false},
+		{"'x' >= 1", false},
+		{"obj == obj", true},
+		{"obj == 0", false},
+		{"!0", true},
+		{"!''", true},
+		{"!'0'", false},
+	}
+	for _, tt := range tests {
+		v, err := Evaluate(tt.src, ctx)
+		if err != nil {
+			t.Errorf("Evaluate(%q): %v", tt.src, err)
+			continue
+		}
+		if v != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.src, v, tt.want)
+		}
+	}
+}
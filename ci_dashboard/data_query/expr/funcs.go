@@ -0,0 +1,217 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Function describes a built-in function's arity.
+type Function struct {
+	Name    string
+	MinArgs int
+	// MaxArgs is -1 for variadic functions.
+	MaxArgs int
+}
+
+// Functions lists the built-in functions, keyed by lower-case name.
+var Functions = map[string]Function{
+	"contains":   {Name: "contains", MinArgs: 2, MaxArgs: 2},
+	"startswith": {Name: "startsWith", MinArgs: 2, MaxArgs: 2},
+	"endswith":   {Name: "endsWith", MinArgs: 2, MaxArgs: 2},
+	"format":     {Name: "format", MinArgs: 1, MaxArgs: -1},
+	"join":       {Name: "join", MinArgs: 1, MaxArgs: 2},
+	"tojson":     {Name: "toJSON", MinArgs: 1, MaxArgs: 1},
+	"fromjson":   {Name: "fromJSON", MinArgs: 1, MaxArgs: 1},
+	"hashfiles":  {Name: "hashFiles", MinArgs: 1, MaxArgs: -1},
+	"success":    {Name: "success", MinArgs: 0, MaxArgs: 0},
+	"failure":    {Name: "failure", MinArgs: 0, MaxArgs: 0},
+	"always":     {Name: "always", MinArgs: 0, MaxArgs: 0},
+	"cancelled":  {Name: "cancelled", MinArgs: 0, MaxArgs: 0},
+}
+
+func (e *evaluator) call(n *Call) (any, error) {
+	name := strings.ToLower(n.Name)
+	fn, ok := Functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unrecognized function: '%s'", n.Name)
+	}
+	if len(n.Args) < fn.MinArgs || fn.MaxArgs >= 0 && len(n.Args) > fn.MaxArgs {
+		return nil, fmt.Errorf("wrong number of arguments to %s: got %d", fn.Name, len(n.Args))
+	}
+	args := make([]any, len(n.Args))
+	for i, arg := range n.Args {
+		v, err := e.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	switch name {
+	case "contains":
+		return contains(args[0], args[1]), nil
+	case "startswith":
+		return strings.HasPrefix(strings.ToUpper(ToString(args[0])), strings.ToUpper(ToString(args[1]))), nil
+	case "endswith":
+		return strings.HasSuffix(strings.ToUpper(ToString(args[0])), strings.ToUpper(ToString(args[1]))), nil
+	case "format":
+		return format(ToString(args[0]), args[1:])
+	case "join":
+		sep := ","
+		if len(args) > 1 {
+			sep = ToString(args[1])
+		}
+		return join(args[0], sep), nil
+	case "tojson":
+		return toJSON(args[0])
+	case "fromjson":
+		return fromJSON(ToString(args[0]))
+	case "hashfiles":
+		if e.ctx.HashFiles == nil {
+			return nil, fmt.Errorf("hashFiles is not available in this context")
+		}
+		patterns := make([]string, len(args))
+		for i, arg := range args {
+			patterns[i] = ToString(arg)
+		}
+		return e.ctx.HashFiles(patterns...)
+	case "success":
+		return e.ctx.Status == "" || e.ctx.Status == StatusSuccess, nil
+	case "failure":
+		return e.ctx.Status == StatusFailure, nil
+	case "always":
+		return true, nil
+	case "cancelled":
+		return e.ctx.Status == StatusCancelled, nil
+	}
+	return nil, fmt.Errorf("unrecognized function: '%s'", n.Name)
+}
+
+func contains(search, item any) bool {
+	switch s := Normalize(search).(type) {
+	case []any:
+		for _, v := range s {
+			if looseEqual(Normalize(v), Normalize(item)) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(strings.ToUpper(ToString(search)), strings.ToUpper(ToString(item)))
+}
+
+func join(v any, sep string) string {
+	items, ok := Normalize(v).([]any)
+	if !ok {
+		return ToString(v)
+	}
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = ToString(item)
+	}
+	return strings.Join(parts, sep)
+}
+
+func format(f string, args []any) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(f); i++ {
+		c := f[i]
+		switch {
+		case c == '{' && i+1 < len(f) && f[i+1] == '{':
+			sb.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(f) && f[i+1] == '}':
+			sb.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(f[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("invalid format string %q", f)
+			}
+			var n int
+			if _, err := fmt.Sscanf(f[i+1:i+end], "%d", &n); err != nil || fmt.Sprint(n) != f[i+1:i+end] {
+				return "", fmt.Errorf("invalid format string %q", f)
+			}
+			if n >= len(args) {
+				return "", fmt.Errorf("format string %q references argument %d but only %d were given", f, n, len(args))
+			}
+			sb.WriteString(ToString(args[n]))
+			i += end
+		case c == '}':
+			return "", fmt.Errorf("invalid format string %q", f)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+func toJSON(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(jsonSafe(Normalize(v))); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonSafe replaces values encoding/json rejects.
+func jsonSafe(v any) any {
+	switch x := v.(type) {
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return formatNumber(x)
+		}
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, item := range x {
+			out[k] = jsonSafe(Normalize(item))
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, item := range x {
+			out[i] = jsonSafe(Normalize(item))
+		}
+		return out
+	}
+	return v
+}
+
+func fromJSON(s string) (any, error) {
+	var v any
+	dec := json.NewDecoder(strings.NewReader(s))
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("error parsing fromJSON: %w", err)
+	}
+	return v, nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hashfiles implements the hashFiles() expression function the way
+// the hosted runner does, so cache keys computed locally match.
+package hashfiles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"testingdashboard/m/v2/glob"
+)
+
+// FollowSymlinksFlag, given as the first argument, makes hashFiles follow
+// symbolic links to directories.
+const FollowSymlinksFlag = "--follow-symbolic-links"
+
+// Hasher hashes files in a workspace.
+type Hasher struct {
+	// Workspace is the directory relative patterns are rooted at. Only
+	// files inside it are hashed.
+	Workspace string
+	// FollowSymlinks descends into symbolic links to directories.
+	FollowSymlinks bool
+}
+
+// Hash hashes the files matching patterns in GITHUB_WORKSPACE, or the
+// current directory if it is unset.
+func Hash(patterns ...string) (string, error) {
+	ws := os.Getenv("GITHUB_WORKSPACE")
+	if ws == "" {
+		var err error
+		if ws, err = os.Getwd(); err != nil {
+			return "", err
+		}
+	}
+	return (&Hasher{Workspace: ws}).Hash(patterns...)
+}
+
+// Hash returns the hex SHA-256 of the concatenated SHA-256 digests of every
+// matching file, in the order the runner visits them, or "" if nothing
+// matches. A leading FollowSymlinksFlag argument sets FollowSymlinks.
+func (h *Hasher) Hash(patterns ...string) (string, error) {
+	files, err := h.Files(patterns...)
+	if err != nil || len(files) == 0 {
+		return "", err
+	}
+	sum := sha256.New()
+	for _, file := range files {
+		digest, err := hashFile(file)
+		if err != nil {
+			return "", err
+		}
+		sum.Write(digest)
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// Files returns the files Hash would hash, in order.
+func (h *Hasher) Files(patterns ...string) ([]string, error) {
+	follow := h.FollowSymlinks
+	if len(patterns) > 0 && patterns[0] == FollowSymlinksFlag {
+		follow, patterns = true, patterns[1:]
+	}
+	ws, err := filepath.Abs(h.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	opts := glob.DefaultOptions()
+	opts.Root, opts.FollowSymlinks = ws, follow
+	g, err := glob.New(strings.Join(patterns, "\n"), opts)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for path, err := range g.All() {
+		if err != nil {
+			return nil, fmt.Errorf("hashFiles(%s) failed: %w", strings.Join(patterns, ", "), err)
+		}
+		if !strings.HasPrefix(path, ws+string(filepath.Separator)) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("hashFiles(%s) failed: %w", strings.Join(patterns, ", "), err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
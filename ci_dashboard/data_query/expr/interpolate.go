@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Embedded is a ${{ }} expression found inside a larger string.
+type Embedded struct {
+	// Source is the expression text between the delimiters, trimmed.
+	Source string
+	// Start and End are the byte offsets of "${{" and just past "}}".
+	Start, End int
+}
+
+// Extract finds every ${{ }} expression in s.
+func Extract(s string) ([]Embedded, error) {
+	var out []Embedded
+	for i := 0; i < len(s); {
+		start := strings.Index(s[i:], "${{")
+		if start < 0 {
+			break
+		}
+		start += i
+		end := -1
+		inString := false
+		for j := start + 3; j < len(s); j++ {
+			switch {
+			case s[j] == '\'':
+				inString = !inString
+			case !inString && strings.HasPrefix(s[j:], "}}"):
+				end = j
+			}
+			if end >= 0 {
+				break
+			}
+		}
+		if end < 0 {
+			return out, fmt.Errorf("unterminated expression starting at position %d", start)
+		}
+		out = append(out, Embedded{
+			Source: strings.TrimSpace(s[start+3 : end]),
+			Start:  start,
+			End:    end + 2,
+		})
+		i = end + 2
+	}
+	return out, nil
+}
+
+// Interpolate replaces every ${{ }} expression in s with its string value.
+func Interpolate(s string, ctx *Context) (string, error) {
+	exprs, err := Extract(s)
+	if err != nil || len(exprs) == 0 {
+		return s, err
+	}
+	var sb strings.Builder
+	last := 0
+	for _, e := range exprs {
+		v, err := Evaluate(e.Source, ctx)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(s[last:e.Start])
+		sb.WriteString(ToString(v))
+		last = e.End
+	}
+	sb.WriteString(s[last:])
+	return sb.String(), nil
+}
+
+// EvaluateValue evaluates s when it is a single ${{ }} expression, keeping the
+// result's type, and otherwise interpolates it as a string.
+func EvaluateValue(s string, ctx *Context) (any, error) {
+	exprs, err := Extract(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(exprs) == 1 && strings.TrimSpace(s[:exprs[0].Start]) == "" && strings.TrimSpace(s[exprs[0].End:]) == "" {
+		return Evaluate(exprs[0].Source, ctx)
+	}
+	return Interpolate(s, ctx)
+}
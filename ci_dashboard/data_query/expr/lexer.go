@@ -0,0 +1,226 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenTrue
+	tokenFalse
+	tokenNull
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenDot
+	tokenComma
+	tokenStar
+	tokenNot
+	tokenEq
+	tokenNe
+	tokenLt
+	tokenLe
+	tokenGt
+	tokenGe
+	tokenAnd
+	tokenOr
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+// endsOperand reports whether a token of this kind can end an operand, which
+// decides whether a following '-' starts a negative number literal.
+func (k tokenKind) endsOperand() bool {
+	switch k {
+	case tokenNumber, tokenString, tokenIdent, tokenTrue, tokenFalse, tokenNull, tokenRParen, tokenRBracket, tokenStar:
+		return true
+	}
+	return false
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c == '-' || c >= '0' && c <= '9'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// errorAt returns the SyntaxError of src at offset pos.
+func errorAt(src string, pos int, format string, args ...any) error {
+	return &SyntaxError{Source: src, Offset: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	prev := tokenEOF
+	for i := 0; i < len(src); {
+		c := src[i]
+		start := i
+		emit := func(kind tokenKind, n int) {
+			tokens = append(tokens, token{kind: kind, text: src[start : start+n], pos: start})
+			prev = kind
+			i += n
+		}
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			emit(tokenLParen, 1)
+		case c == ')':
+			emit(tokenRParen, 1)
+		case c == '[':
+			emit(tokenLBracket, 1)
+		case c == ']':
+			emit(tokenRBracket, 1)
+		case c == ',':
+			emit(tokenComma, 1)
+		case c == '*':
+			emit(tokenStar, 1)
+		case c == '.' && !(i+1 < len(src) && isDigit(src[i+1]) && !prev.endsOperand()):
+			emit(tokenDot, 1)
+		case c == '!':
+			if strings.HasPrefix(src[i:], "!=") {
+				emit(tokenNe, 2)
+			} else {
+				emit(tokenNot, 1)
+			}
+		case c == '=':
+			if !strings.HasPrefix(src[i:], "==") {
+				return nil, errorAt(src, i, "unexpected '='")
+			}
+			emit(tokenEq, 2)
+		case c == '<':
+			if strings.HasPrefix(src[i:], "<=") {
+				emit(tokenLe, 2)
+			} else {
+				emit(tokenLt, 1)
+			}
+		case c == '>':
+			if strings.HasPrefix(src[i:], ">=") {
+				emit(tokenGe, 2)
+			} else {
+				emit(tokenGt, 1)
+			}
+		case c == '&':
+			if !strings.HasPrefix(src[i:], "&&") {
+				return nil, errorAt(src, i, "unexpected '&'")
+			}
+			emit(tokenAnd, 2)
+		case c == '|':
+			if !strings.HasPrefix(src[i:], "||") {
+				return nil, errorAt(src, i, "unexpected '|'")
+			}
+			emit(tokenOr, 2)
+		case c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for {
+				if j >= len(src) {
+					return nil, errorAt(src, i, "unterminated string")
+				}
+				if src[j] == '\'' {
+					if j+1 < len(src) && src[j+1] == '\'' {
+						sb.WriteByte('\'')
+						j += 2
+						continue
+					}
+					break
+				}
+				sb.WriteByte(src[j])
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: i})
+			prev = tokenString
+			i = j + 1
+		case isDigit(c) || c == '.' || (c == '-' || c == '+') && !prev.endsOperand():
+			j := i
+			if c == '-' || c == '+' {
+				j++
+			}
+			for j < len(src) && (isIdentPart(src[j]) || src[j] == '.' ||
+				(src[j] == '-' || src[j] == '+') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			text := src[i:j]
+			num, err := parseNumber(text)
+			if err != nil {
+				return nil, errorAt(src, i, "invalid number %q", text)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, num: num, pos: i})
+			prev = tokenNumber
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(src) && isIdentPart(src[j]) {
+				j++
+			}
+			kind := tokenIdent
+			switch src[i:j] {
+			case "true":
+				kind = tokenTrue
+			case "false":
+				kind = tokenFalse
+			case "null":
+				kind = tokenNull
+			}
+			emit(kind, j-i)
+		default:
+			return nil, errorAt(src, i, "unexpected character %q", c)
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(src)})
+	return tokens, nil
+}
+
+func parseNumber(text string) (float64, error) {
+	sign := 1.0
+	body := text
+	if strings.HasPrefix(body, "-") {
+		sign, body = -1, body[1:]
+	} else if strings.HasPrefix(body, "+") {
+		body = body[1:]
+	}
+	lower := strings.ToLower(body)
+	switch {
+	case strings.HasPrefix(lower, "0x"):
+		n, err := strconv.ParseUint(lower[2:], 16, 64)
+		return sign * float64(n), err
+	case strings.HasPrefix(lower, "0o"):
+		n, err := strconv.ParseUint(lower[2:], 8, 64)
+		return sign * float64(n), err
+	}
+	n, err := strconv.ParseFloat(body, 64)
+	return sign * n, err
+}
@@ -0,0 +1,345 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expr
+
+import (
+	"fmt"
+)
+
+// Node is a node of a parsed expression.
+type Node interface {
+	// Pos is the byte offset of the node within the expression source.
+	Pos() int
+}
+
+// Literal is a null, boolean, number or string literal.
+type Literal struct {
+	Value  any
+	Offset int
+}
+
+// Ident is a reference to a named context such as github or matrix.
+type Ident struct {
+	Name   string
+	Offset int
+}
+
+// Property is a dereference of the form x.name.
+type Property struct {
+	Object Node
+	Name   string
+	Offset int
+}
+
+// Index is a dereference of the form x[index].
+type Index struct {
+	Object Node
+	Index  Node
+	Offset int
+}
+
+// Filter is an object filter of the form x.* or x[*].
+type Filter struct {
+	Object Node
+	Offset int
+}
+
+// Call is a function call.
+type Call struct {
+	Name   string
+	Args   []Node
+	Offset int
+}
+
+// Not is logical negation.
+type Not struct {
+	Operand Node
+	Offset  int
+}
+
+// Binary is a comparison or logical operator applied to two operands.
+type Binary struct {
+	Op          string
+	Left, Right Node
+	Offset      int
+}
+
+func (n *Literal) Pos() int  { return n.Offset }
+func (n *Ident) Pos() int    { return n.Offset }
+func (n *Property) Pos() int { return n.Offset }
+func (n *Index) Pos() int    { return n.Offset }
+func (n *Filter) Pos() int   { return n.Offset }
+func (n *Call) Pos() int     { return n.Offset }
+func (n *Not) Pos() int      { return n.Offset }
+func (n *Binary) Pos() int   { return n.Offset }
+
+// SyntaxError is returned for malformed expressions.
+type SyntaxError struct {
+	Source  string
+	Offset  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid expression %q: %s at position %d", e.Source, e.Message, e.Offset)
+}
+
+// maxDepth bounds nesting, mirroring the limit applied by the service.
+const maxDepth = 50
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// Parse parses an expression without the surrounding ${{ }}.
+func Parse(src string) (Node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return &SyntaxError{Source: p.src, Offset: tok.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		if tok.kind == tokenEOF {
+			return tok, p.errorf(tok, "expected %s", what)
+		}
+		return tok, p.errorf(tok, "expected %s, got %q", what, tok.text)
+	}
+	return tok, nil
+}
+
+func (p *parser) enter(tok token) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.errorf(tok, "exceeds maximum nesting depth of %d", maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		tok := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: "||", Left: left, Right: right, Offset: tok.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseEquality()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		tok := p.next()
+		right, err := p.parseEquality()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: "&&", Left: left, Right: right, Offset: tok.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseEquality() (Node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for k := p.peek().kind; k == tokenEq || k == tokenNe; k = p.peek().kind {
+		tok := p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: tok.text, Left: left, Right: right, Offset: tok.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for k := p.peek().kind; k == tokenLt || k == tokenLe || k == tokenGt || k == tokenGe; k = p.peek().kind {
+		tok := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: tok.text, Left: left, Right: right, Offset: tok.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().kind == tokenNot {
+		tok := p.next()
+		if err := p.enter(tok); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Operand: operand, Offset: tok.pos}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (Node, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch tok := p.peek(); tok.kind {
+		case tokenDot:
+			p.next()
+			name := p.next()
+			switch name.kind {
+			case tokenStar:
+				node = &Filter{Object: node, Offset: tok.pos}
+			case tokenIdent, tokenTrue, tokenFalse, tokenNull:
+				node = &Property{Object: node, Name: name.text, Offset: tok.pos}
+			default:
+				return nil, p.errorf(name, "expected property name after '.'")
+			}
+		case tokenLBracket:
+			p.next()
+			if p.peek().kind == tokenStar {
+				p.next()
+				if _, err := p.expect(tokenRBracket, "']'"); err != nil {
+					return nil, err
+				}
+				node = &Filter{Object: node, Offset: tok.pos}
+				continue
+			}
+			if err := p.enter(tok); err != nil {
+				return nil, err
+			}
+			index, err := p.parseOr()
+			p.depth--
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokenRBracket, "']'"); err != nil {
+				return nil, err
+			}
+			node = &Index{Object: node, Index: index, Offset: tok.pos}
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return &Literal{Value: tok.num, Offset: tok.pos}, nil
+	case tokenString:
+		return &Literal{Value: tok.text, Offset: tok.pos}, nil
+	case tokenTrue:
+		return &Literal{Value: true, Offset: tok.pos}, nil
+	case tokenFalse:
+		return &Literal{Value: false, Offset: tok.pos}, nil
+	case tokenNull:
+		return &Literal{Value: nil, Offset: tok.pos}, nil
+	case tokenLParen:
+		if err := p.enter(tok); err != nil {
+			return nil, err
+		}
+		node, err := p.parseOr()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case tokenIdent:
+		if p.peek().kind != tokenLParen {
+			return &Ident{Name: tok.text, Offset: tok.pos}, nil
+		}
+		p.next()
+		if err := p.enter(tok); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+		call := &Call{Name: tok.text, Offset: tok.pos}
+		if p.peek().kind == tokenRParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			sep := p.next()
+			if sep.kind == tokenRParen {
+				return call, nil
+			}
+			if sep.kind != tokenComma {
+				return nil, p.errorf(sep, "expected ',' or ')' in call to %s", tok.text)
+			}
+		}
+	case tokenEOF:
+		return nil, p.errorf(tok, "unexpected end of expression")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
@@ -0,0 +1,409 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package format rewrites workflow files in a canonical style: two-space
+// indentation, keys in the order of the workflow syntax reference, a bare
+// on key, ${{ expr }} with single spaces inside the braces, and a blank
+// line between top-level keys and between jobs. Comments, anchors and the
+// order of free-form mappings such as env are kept, and folded scalars are
+// wrapped to Width.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/expr"
+)
+
+// orders maps the paths of mappings with known keys, as in lint, to the
+// order their keys are written in. Keys not listed follow in source order.
+// Identity and conditions come first, then where the job runs, then what
+// it does.
+var orders = []struct {
+	pattern string
+	keys    []string
+}{
+	{"", []string{"name", "run-name", "on", "permissions", "env", "defaults", "concurrency", "jobs"}},
+	{"defaults", []string{"run"}},
+	{"defaults.run", []string{"shell", "working-directory"}},
+	{"concurrency", []string{"group", "cancel-in-progress"}},
+	{"on.*", []string{
+		"types", "branches", "branches-ignore", "tags", "tags-ignore", "paths",
+		"paths-ignore", "workflows", "inputs", "outputs", "secrets",
+	}},
+	{"on.*.inputs.*", []string{"description", "required", "type", "default", "options", "deprecationMessage"}},
+	{"on.*.outputs.*", []string{"description", "value"}},
+	{"on.*.secrets.*", []string{"description", "required"}},
+	{"jobs.*", []string{
+		"name", "needs", "if", "permissions", "environment", "concurrency",
+		"continue-on-error", "strategy", "runs-on", "timeout-minutes",
+		"container", "services", "outputs", "env", "defaults", "steps",
+		"uses", "with", "secrets",
+	}},
+	{"jobs.*.defaults", []string{"run"}},
+	{"jobs.*.defaults.run", []string{"shell", "working-directory"}},
+	{"jobs.*.concurrency", []string{"group", "cancel-in-progress"}},
+	{"jobs.*.environment", []string{"name", "url"}},
+	{"jobs.*.strategy", []string{"matrix", "fail-fast", "max-parallel"}},
+	{"jobs.*.container", []string{"image", "credentials", "env", "ports", "volumes", "options"}},
+	{"jobs.*.services.*", []string{"image", "credentials", "env", "ports", "volumes", "options"}},
+	// Scripts go last so the keys configuring them stay in view.
+	{"jobs.*.steps.*", []string{
+		"name", "id", "if", "uses", "with", "working-directory", "shell",
+		"env", "continue-on-error", "timeout-minutes", "run",
+	}},
+}
+
+// Source formats the workflow file data.
+func Source(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return Node(&doc)
+}
+
+// Node formats the document node doc, which it modifies.
+func Node(doc *yaml.Node) ([]byte, error) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("workflow must be a mapping")
+	}
+	normalize(doc.Content[0], nil)
+	var want any
+	if err := doc.Decode(&want); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	// Refolding and comment spacing are checked, and skipped if they
+	// would change a value, since they edit the text rather than the nodes.
+	out := spaceSections(spaceComments(refold(buf.Bytes()), lineComments(doc)))
+	if !decodesTo(out, want) {
+		out = spaceSections(buf.Bytes())
+		if !decodesTo(out, want) {
+			return nil, fmt.Errorf("formatting would change the workflow")
+		}
+	}
+	return out, nil
+}
+
+func decodesTo(data []byte, want any) bool {
+	var got any
+	return yaml.Unmarshal(data, &got) == nil && reflect.DeepEqual(got, want)
+}
+
+// lineComments returns the comments at the ends of lines in n.
+func lineComments(n *yaml.Node) map[string]bool {
+	found := map[string]bool{}
+	var walk func(*yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.LineComment != "" {
+			found[n.LineComment] = true
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(n)
+	return found
+}
+
+// spaceComments puts two spaces, rather than yaml.v3's one, before the
+// comments at the ends of lines.
+func spaceComments(data []byte, comments map[string]bool) []byte {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		at := strings.Index(line, " #")
+		for at > 0 {
+			if c := line[at+1:]; comments[c] && line[at-1] != ' ' {
+				lines[i] = line[:at] + "  " + c
+				break
+			}
+			next := strings.Index(line[at+2:], " #")
+			if next < 0 {
+				break
+			}
+			at += 2 + next
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// Width is the line length folded scalars are wrapped to.
+const Width = 80
+
+// foldedHeader matches a line ending in a folded block scalar indicator.
+var foldedHeader = regexp.MustCompile(`(^|:|-)\s*>[1-9]?[-+]?(\s+#.*)?$`)
+
+// refold wraps the lines of folded block scalars, which yaml.v3 writes on
+// one line each, to Width, and drops the blank line it writes after them.
+func refold(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		out = append(out, lines[i])
+		if !foldedHeader.MatchString(lines[i]) {
+			continue
+		}
+		keep := strings.Contains(lines[i], ">+")
+		parent, block := leading(lines[i]), -1
+		end := i + 1
+		for ; end < len(lines); end++ {
+			line := lines[end]
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if leading(line) <= parent {
+				break
+			}
+			if block < 0 {
+				block = leading(line)
+			}
+		}
+		body := lines[i+1 : end]
+		if !keep {
+			for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+				body = body[:len(body)-1]
+			}
+		}
+		for _, line := range body {
+			out = append(out, wrap(line, block)...)
+		}
+		i = end - 1
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// wrap splits a line of a folded scalar indented by indent at single
+// spaces. More-indented lines, which are not folded, are left alone.
+func wrap(line string, indent int) []string {
+	if len(line) <= Width || indent < 0 || leading(line) != indent {
+		return []string{line}
+	}
+	words := strings.Split(line[indent:], " ")
+	if slices.Contains(words, "") {
+		return []string{line}
+	}
+	var out []string
+	cur := line[:indent] + words[0]
+	for _, w := range words[1:] {
+		if len(cur)+1+len(w) > Width {
+			out = append(out, cur)
+			cur = line[:indent] + w
+			continue
+		}
+		cur += " " + w
+	}
+	return append(out, cur)
+}
+
+// normalize rewrites node, found at path, and everything below it.
+func normalize(node *yaml.Node, path []string) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		node.Value = spaceExpressions(node.Value)
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			normalize(item, append(path, "*"))
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			if len(path) == 0 && k.Value == "on" {
+				// yaml.v3 quotes on, which YAML 1.1 reads as a boolean
+				// but GitHub does not, unless the key has no tag.
+				k.Tag, k.Style = "", 0
+			}
+			normalize(node.Content[i+1], append(path, k.Value))
+		}
+		if keys := orderAt(path); keys != nil {
+			sortKeys(node, keys)
+		}
+	}
+}
+
+// orderAt returns the key order of the mapping at path, or nil.
+func orderAt(path []string) []string {
+	for _, o := range orders {
+		parts := strings.Split(o.pattern, ".")
+		if o.pattern == "" {
+			parts = nil
+		}
+		if len(parts) != len(path) {
+			continue
+		}
+		match := true
+		for i, p := range parts {
+			if p != "*" && p != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return o.keys
+		}
+	}
+	return nil
+}
+
+// spaceExpressions writes ${{ }} expressions in s with one space inside
+// each brace pair. s is returned unchanged if an expression is malformed.
+func spaceExpressions(s string) string {
+	found, err := expr.Extract(s)
+	if err != nil || len(found) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, e := range found {
+		b.WriteString(s[last:e.Start])
+		if e.Source == "" {
+			b.WriteString(s[e.Start:e.End])
+		} else {
+			b.WriteString("${{ " + e.Source + " }}")
+		}
+		last = e.End
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// sortKeys reorders the pairs of mapping m to follow order, leaving keys
+// not in order after the others. The order is kept if the new one would
+// put an alias before its anchor.
+func sortKeys(m *yaml.Node, order []string) {
+	type pair struct{ k, v *yaml.Node }
+	var pairs []pair
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		pairs = append(pairs, pair{m.Content[i], m.Content[i+1]})
+	}
+	rank := func(p pair) int {
+		if i := slices.Index(order, p.k.Value); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(pairs, func(a, b pair) int { return rank(a) - rank(b) })
+	sorted := make([]*yaml.Node, 0, len(m.Content))
+	for _, p := range pairs {
+		sorted = append(sorted, p.k, p.v)
+	}
+	if aliasesFollowAnchors(sorted) {
+		m.Content = sorted
+	}
+}
+
+// aliasesFollowAnchors reports whether every alias in nodes whose anchor
+// is also in nodes comes after it.
+func aliasesFollowAnchors(nodes []*yaml.Node) bool {
+	anchors := map[*yaml.Node]bool{}
+	var collect func(*yaml.Node)
+	collect = func(n *yaml.Node) {
+		if n.Anchor != "" {
+			anchors[n] = false
+		}
+		for _, c := range n.Content {
+			collect(c)
+		}
+	}
+	for _, n := range nodes {
+		collect(n)
+	}
+	ok := true
+	var walk func(*yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Anchor != "" {
+			anchors[n] = true
+		}
+		if n.Kind == yaml.AliasNode {
+			if seen, inside := anchors[n.Alias]; inside && !seen {
+				ok = false
+			}
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return ok
+}
+
+// spaceSections inserts a blank line before every top-level key, job and
+// step but the first of each, keeping comments with what they precede.
+func spaceSections(data []byte) []byte {
+	var out []string
+	inJobs, first := false, false
+	steps := -1 // indentation of the items of the current steps list
+	for _, line := range strings.Split(string(data), "\n") {
+		indent, trimmed := leading(line), strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			out = append(out, line)
+			continue
+		}
+		blank := false
+		switch {
+		case indent == 0:
+			blank = !onlyComments(out)
+			inJobs, first = strings.HasPrefix(line, "jobs:"), true
+		case indent == 2 && inJobs:
+			blank, first = !first, false
+		case indent == steps && strings.HasPrefix(trimmed, "-"):
+			blank, first = !first, false
+		}
+		if indent < steps {
+			steps = -1
+		}
+		if inJobs && indent == 4 && trimmed == "steps:" {
+			steps, first = indent+2, true
+		}
+		if blank {
+			at := len(out)
+			for at > 0 && strings.HasPrefix(strings.TrimSpace(out[at-1]), "#") && leading(out[at-1]) == indent {
+				at--
+			}
+			out = slices.Insert(out, at, "")
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+func onlyComments(lines []string) bool {
+	for _, line := range lines {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+func leading(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
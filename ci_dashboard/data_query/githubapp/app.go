@@ -0,0 +1,237 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package githubapp authenticates as a GitHub App: it signs app JWTs with
+// the app's private key and mints installation tokens from them, cached
+// and refreshed before they expire.
+package githubapp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/transport"
+)
+
+// App is a GitHub App and its private key.
+type App struct {
+	// ID is the app ID or its client ID; either is accepted as the issuer
+	// of app JWTs.
+	ID  string
+	Key *rsa.PrivateKey
+	// APIURL defaults to the API of endpoints.FromEnv.
+	APIURL string
+	// HTTPClient defaults to transport.DefaultClient.
+	HTTPClient *http.Client
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// ParsePrivateKey parses the PEM private key GitHub generates for an app,
+// in PKCS #1 or PKCS #8 form.
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// Load returns the app id with the private key in the file at keyPath.
+func Load(id, keyPath string) (*App, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyPath, err)
+	}
+	return &App{ID: id, Key: key}, nil
+}
+
+func (a *App) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// JWT returns a token authenticating as the app itself, valid for nine
+// minutes. It is backdated a minute to allow for clock drift.
+func (a *App) JWT() (string, error) {
+	if a.ID == "" || a.Key == nil {
+		return "", fmt.Errorf("app ID and private key are required")
+	}
+	now := a.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.Key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign app token: %w", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Installation is an installation of the app on an account.
+type Installation struct {
+	ID      int64 `json:"id"`
+	Account struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"account"`
+	RepositorySelection string            `json:"repository_selection"`
+	Permissions         map[string]string `json:"permissions"`
+}
+
+// Installation returns the installation that covers owner/repo, or the
+// organization or user owner when repo is empty.
+func (a *App) Installation(ctx context.Context, owner, repo string) (*Installation, error) {
+	paths := []string{"/orgs/" + url.PathEscape(owner) + "/installation", "/users/" + url.PathEscape(owner) + "/installation"}
+	if repo != "" {
+		paths = []string{"/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo) + "/installation"}
+	}
+	var err error
+	for _, path := range paths {
+		var inst Installation
+		if err = a.do(ctx, http.MethodGet, path, nil, &inst); err == nil {
+			return &inst, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find the installation for %s: %w", strings.TrimSuffix(owner+"/"+repo, "/"), err)
+}
+
+// TokenOptions scope an installation token down from what the
+// installation grants.
+type TokenOptions struct {
+	// Repositories are names of repositories of the installation's account
+	// the token is limited to.
+	Repositories  []string `json:"repositories,omitempty"`
+	RepositoryIDs []int64  `json:"repository_ids,omitempty"`
+	// Permissions map permissions such as contents or actions to read or
+	// write.
+	Permissions map[string]string `json:"permissions,omitempty"`
+}
+
+// Token is an installation access token.
+type Token struct {
+	Token               string            `json:"token"`
+	ExpiresAt           time.Time         `json:"expires_at"`
+	Permissions         map[string]string `json:"permissions"`
+	RepositorySelection string            `json:"repository_selection"`
+}
+
+// InstallationToken mints a token for installation id. Tokens last an
+// hour.
+func (a *App) InstallationToken(ctx context.Context, id int64, opts TokenOptions) (*Token, error) {
+	var tok Token
+	path := "/app/installations/" + strconv.FormatInt(id, 10) + "/access_tokens"
+	if err := a.do(ctx, http.MethodPost, path, opts, &tok); err != nil {
+		return nil, fmt.Errorf("failed to create installation token: %w", err)
+	}
+	return &tok, nil
+}
+
+// do sends an API request authenticated with the app JWT.
+func (a *App) do(ctx context.Context, method, path string, in, out any) error {
+	jwt, err := a.JWT()
+	if err != nil {
+		return err
+	}
+	base := a.APIURL
+	if base == "" {
+		base = endpoints.FromEnv().API
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(base, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := a.HTTPClient
+	if client == nil {
+		client = transport.DefaultClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &e) != nil || e.Message == "" {
+			e.Message = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("%s: %s", resp.Status, e.Message)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/transport"
+)
+
+// RefreshBefore is how long before it expires a cached installation token
+// is replaced.
+const RefreshBefore = 5 * time.Minute
+
+// TokenSource mints installation tokens as they are needed, reusing each
+// until shortly before it expires. It is safe for concurrent use.
+type TokenSource struct {
+	App *App
+	// InstallationID is the installation tokens are minted for. When 0
+	// it is looked up from Owner and Repo on first use.
+	InstallationID int64
+	Owner, Repo    string
+	// Options scope each token.
+	Options TokenOptions
+
+	mu  sync.Mutex
+	tok *Token
+}
+
+// Token returns a valid installation token.
+func (s *TokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok != nil && s.App.now().Add(RefreshBefore).Before(s.tok.ExpiresAt) {
+		return s.tok, nil
+	}
+	if s.InstallationID == 0 {
+		if s.Owner == "" {
+			return nil, fmt.Errorf("installation ID or owner is required")
+		}
+		inst, err := s.App.Installation(ctx, s.Owner, s.Repo)
+		if err != nil {
+			return nil, err
+		}
+		s.InstallationID = inst.ID
+	}
+	tok, err := s.App.InstallationToken(ctx, s.InstallationID, s.Options)
+	if err != nil {
+		return nil, err
+	}
+	s.tok = tok
+	return tok, nil
+}
+
+// invalidate drops the cached token if it is still tok.
+func (s *TokenSource) invalidate(tok *Token) {
+	s.mu.Lock()
+	if s.tok == tok {
+		s.tok = nil
+	}
+	s.mu.Unlock()
+}
+
+// Middleware authenticates requests to the app's API host that carry no
+// Authorization header with an installation token. A 401 drops the token,
+// in case it was revoked, and the request is retried once with a new one.
+func (s *TokenSource) Middleware(next http.RoundTripper) http.RoundTripper {
+	return transport.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Authorization") != "" || !s.covers(req.URL) {
+			return next.RoundTrip(req)
+		}
+		for attempt := 0; ; attempt++ {
+			tok, err := s.Token(req.Context())
+			if err != nil {
+				return nil, err
+			}
+			authed := req.Clone(req.Context())
+			if req.Body != nil && req.Body != http.NoBody && attempt > 0 {
+				if req.GetBody == nil {
+					return nil, fmt.Errorf("cannot resend request body after 401")
+				}
+				if authed.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			authed.Header.Set("Authorization", "Bearer "+tok.Token)
+			resp, err := next.RoundTrip(authed)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+				return resp, err
+			}
+			resp.Body.Close()
+			s.invalidate(tok)
+		}
+	})
+}
+
+// covers reports whether u is on the host of the app's API, so tokens are
+// not sent to the storage hosts API responses redirect to.
+func (s *TokenSource) covers(u *url.URL) bool {
+	base := s.App.APIURL
+	if base == "" {
+		base = endpoints.FromEnv().API
+	}
+	api, err := url.Parse(base)
+	return err == nil && strings.EqualFold(api.Host, u.Host)
+}
+
+// Client returns an http.Client authenticating as the installation.
+func (s *TokenSource) Client() *http.Client {
+	return transport.NewClient(s.Middleware)
+}
+
+// FromEnv returns a TokenSource configured from GITHUB_APP_ID,
+// GITHUB_APP_PRIVATE_KEY (the PEM key itself) or
+// GITHUB_APP_PRIVATE_KEY_PATH, and GITHUB_APP_INSTALLATION_ID, else the
+// installation covering GITHUB_REPOSITORY. It returns nil if GITHUB_APP_ID
+// is unset.
+func FromEnv() (*TokenSource, error) {
+	id := os.Getenv("GITHUB_APP_ID")
+	if id == "" {
+		return nil, nil
+	}
+	app := &App{ID: id}
+	switch {
+	case os.Getenv("GITHUB_APP_PRIVATE_KEY") != "":
+		key, err := ParsePrivateKey([]byte(os.Getenv("GITHUB_APP_PRIVATE_KEY")))
+		if err != nil {
+			return nil, fmt.Errorf("GITHUB_APP_PRIVATE_KEY: %w", err)
+		}
+		app.Key = key
+	case os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH") != "":
+		loaded, err := Load(id, os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"))
+		if err != nil {
+			return nil, err
+		}
+		app = loaded
+	default:
+		return nil, fmt.Errorf("GITHUB_APP_ID is set without GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_PATH")
+	}
+	s := &TokenSource{App: app}
+	if v := os.Getenv("GITHUB_APP_INSTALLATION_ID"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID %q", v)
+		}
+		s.InstallationID = n
+	} else {
+		s.Owner, s.Repo, _ = strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/")
+	}
+	return s, nil
+}
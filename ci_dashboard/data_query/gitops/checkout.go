@@ -0,0 +1,335 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Submodule modes of CheckoutOptions.Submodules.
+const (
+	SubmodulesNone      = ""
+	SubmodulesTop       = "true"
+	SubmodulesRecursive = "recursive"
+)
+
+// CheckoutOptions are the inputs of actions/checkout.
+type CheckoutOptions struct {
+	// Repository is owner/repo on Auth.ServerURL.
+	Repository string
+	// Ref is a branch, tag, SHA or full ref such as refs/pull/1/merge.
+	// Empty checks out Commit, or the default branch without one.
+	Ref string
+	// Commit is the commit Ref is expected at, as GITHUB_SHA is for the
+	// event's ref. It is fetched rather than wherever Ref points now.
+	Commit string
+	// Dir is the directory to check out into. Whatever it holds is
+	// removed unless it is already a clone of Repository.
+	Dir  string
+	Auth Auth
+	// Depth is how many commits of history to fetch; 0 fetches all of it
+	// including tags.
+	Depth int
+	// Tags fetches tags even with a Depth.
+	Tags bool
+	// Filter is a partial clone filter such as blob:none.
+	Filter string
+	// Sparse lists the patterns of a sparse checkout, in cone mode unless
+	// NoCone is set.
+	Sparse []string
+	NoCone bool
+	// Submodules is SubmodulesNone, SubmodulesTop or SubmodulesRecursive.
+	Submodules string
+	// LFS downloads Git LFS files.
+	LFS bool
+	// Clean runs git clean and git reset on an existing clone first.
+	Clean bool
+	// PersistCredentials leaves the token in the local git config, so
+	// later steps can push and fetch.
+	PersistCredentials bool
+	// Log receives git's output.
+	Log io.Writer
+}
+
+// Result is what was checked out.
+type Result struct {
+	// Ref is the ref checked out, or "" for a detached commit.
+	Ref    string
+	Commit string
+}
+
+// fetchAttempts is how many times a fetch is tried, as the network and
+// the server fail now and then.
+const fetchAttempts = 3
+
+// Checkout checks out opts.Ref at opts.Commit into opts.Dir as
+// actions/checkout does, and returns the repository and what it checked
+// out.
+func Checkout(ctx context.Context, opts CheckoutOptions) (*Repo, *Result, error) {
+	if opts.Repository == "" || opts.Dir == "" {
+		return nil, nil, fmt.Errorf("checkout needs a repository and a directory")
+	}
+	if opts.Auth.ServerURL == "" {
+		opts.Auth.ServerURL = "https://github.com"
+	}
+	url := strings.TrimSuffix(opts.Auth.ServerURL, "/") + "/" + opts.Repository
+	r := &Repo{Dir: opts.Dir, Auth: &opts.Auth, Log: opts.Log}
+	reused, err := r.prepare(ctx, url, opts.Clean)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !reused {
+		if err := r.Git(ctx, "init", "-q"); err != nil {
+			return nil, nil, err
+		}
+		if err := r.Git(ctx, "remote", "add", "origin", url); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := r.Git(ctx, "config", "--local", "gc.auto", "0"); err != nil {
+		return nil, nil, err
+	}
+	if opts.LFS {
+		if err := r.Git(ctx, "lfs", "install", "--local"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if opts.Ref == "" && opts.Commit == "" {
+		if opts.Ref, err = r.DefaultBranch(ctx, url); err != nil {
+			return nil, nil, fmt.Errorf("failed to determine the default branch: %w", err)
+		}
+	}
+	if err := r.fetch(ctx, opts); err != nil {
+		// Fetching a branch or tag pattern that matches nothing fails
+		// without saying so.
+		if _, terr := r.checkoutTarget(ctx, opts.Ref, opts.Commit); terr != nil && opts.Commit == "" && !strings.HasPrefix(opts.Ref, "refs/") && !IsSHA(opts.Ref) {
+			return nil, nil, terr
+		}
+		return nil, nil, err
+	}
+	t, err := r.checkoutTarget(ctx, opts.Ref, opts.Commit)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(opts.Sparse) > 0 {
+		args := []string{"sparse-checkout", "set"}
+		if opts.NoCone {
+			args = append(args, "--no-cone")
+		}
+		if err := r.Git(ctx, append(append(args, "--"), opts.Sparse...)...); err != nil {
+			return nil, nil, err
+		}
+	} else if reused {
+		// A clone left sparse by an earlier checkout is made whole.
+		r.Git(ctx, "sparse-checkout", "disable")
+	}
+	if opts.LFS {
+		ref := t.startPoint
+		if ref == "" {
+			ref = t.ref
+		}
+		if err := r.retry(ctx, "lfs", "fetch", "origin", ref); err != nil {
+			return nil, nil, err
+		}
+	}
+	args := []string{"-c", "advice.detachedHead=false", "checkout", "--force"}
+	if t.branch != "" {
+		args = append(args, "-B", t.branch, t.startPoint)
+	} else {
+		args = append(args, t.ref)
+	}
+	if err := r.Git(ctx, args...); err != nil {
+		return nil, nil, err
+	}
+	if err := r.submodules(ctx, opts); err != nil {
+		return nil, nil, err
+	}
+	if opts.PersistCredentials {
+		if err := r.persistCredentials(ctx, opts.Submodules); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res := &Result{}
+	if res.Commit, err = r.Head(ctx); err != nil {
+		return nil, nil, err
+	}
+	if opts.Commit != "" && !strings.EqualFold(res.Commit, opts.Commit) {
+		return nil, nil, fmt.Errorf("checked out %s, not the expected commit %s", res.Commit, opts.Commit)
+	}
+	switch {
+	case t.branch != "":
+		res.Ref = "refs/heads/" + t.branch
+	case strings.HasPrefix(t.ref, "refs/remotes/pull/"):
+		res.Ref = "refs/pull/" + strings.TrimPrefix(t.ref, "refs/remotes/pull/")
+	case strings.HasPrefix(t.ref, "refs/"):
+		res.Ref = t.ref
+	}
+	return r, res, nil
+}
+
+// prepare readies the directory, reporting whether it already holds a
+// clone of url to fetch into. Anything else in it is removed.
+func (r *Repo) prepare(ctx context.Context, url string, clean bool) (bool, error) {
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(filepath.Join(r.Dir, ".git")); err == nil {
+		if remote, err := r.Output(ctx, "config", "--local", "--get", "remote.origin.url"); err == nil && strings.TrimSuffix(remote, ".git") == url {
+			// A killed git leaves its lock behind.
+			os.Remove(filepath.Join(r.Dir, ".git", "index.lock"))
+			os.Remove(filepath.Join(r.Dir, ".git", "shallow.lock"))
+			if !clean {
+				return true, nil
+			}
+			if r.Git(ctx, "clean", "-ffdx") == nil && r.Git(ctx, "reset", "--hard", "HEAD") == nil {
+				return true, nil
+			}
+		}
+	}
+	entries, err := os.ReadDir(r.Dir)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(r.Dir, e.Name())); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// fetch fetches what opts check out, to opts.Depth.
+func (r *Repo) fetch(ctx context.Context, opts CheckoutOptions) error {
+	args := []string{"-c", "protocol.version=2", "fetch", "--prune", "--no-recurse-submodules"}
+	if opts.Depth > 0 && !opts.Tags {
+		args = append(args, "--no-tags")
+	}
+	filter := opts.Filter
+	if filter == "" && len(opts.Sparse) > 0 {
+		filter = "blob:none"
+	}
+	if filter != "" {
+		args = append(args, "--filter="+filter)
+	}
+	refspecs := RefSpecs(opts.Ref, opts.Commit)
+	switch {
+	case opts.Depth > 0:
+		args = append(args, fmt.Sprintf("--depth=%d", opts.Depth))
+	case r.Shallow(ctx):
+		args = append(args, "--unshallow")
+		fallthrough
+	default:
+		// The whole history has every branch and tag, but not the refs of
+		// pull requests or a commit no ref reaches.
+		refspecs = append(append([]string{}, allHistory...), refspecs...)
+	}
+	return r.retry(ctx, append(append(args, "origin"), refspecs...)...)
+}
+
+// retry runs git with args until it succeeds, up to fetchAttempts times.
+func (r *Repo) retry(ctx context.Context, args ...string) error {
+	var err error
+	for i := range fetchAttempts {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(time.Duration(i) * 5 * time.Second):
+			}
+		}
+		if err = r.Git(ctx, args...); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// submodules checks out the submodules as opts say, with the token.
+func (r *Repo) submodules(ctx context.Context, opts CheckoutOptions) error {
+	if opts.Submodules == SubmodulesNone || opts.Submodules == "false" {
+		return nil
+	}
+	recursive := opts.Submodules == SubmodulesRecursive
+	args := []string{"submodule", "sync"}
+	if recursive {
+		args = append(args, "--recursive")
+	}
+	if err := r.Git(ctx, args...); err != nil {
+		return err
+	}
+	args = []string{"-c", "protocol.version=2", "submodule", "update", "--init", "--force"}
+	if opts.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", opts.Depth))
+	}
+	if recursive {
+		args = append(args, "--recursive")
+	}
+	if err := r.retry(ctx, args...); err != nil {
+		return err
+	}
+	args = []string{"submodule", "foreach"}
+	if recursive {
+		args = append(args, "--recursive")
+	}
+	return r.Git(ctx, append(args, "git config --local gc.auto 0")...)
+}
+
+// persistCredentials writes the token to the local config of the
+// repository and its submodules.
+func (r *Repo) persistCredentials(ctx context.Context, submodules string) error {
+	key, value := r.Auth.header()
+	if key == "" {
+		return nil
+	}
+	dirs := []string{r.Dir}
+	if submodules != SubmodulesNone && submodules != "false" {
+		args := []string{"submodule", "foreach", "--quiet"}
+		if submodules == SubmodulesRecursive {
+			args = append(args, "--recursive")
+		}
+		out, err := r.Output(ctx, append(args, "pwd")...)
+		if err != nil {
+			return err
+		}
+		if out != "" {
+			dirs = append(dirs, strings.Split(out, "\n")...)
+		}
+	}
+	for _, dir := range dirs {
+		sub := &Repo{Dir: dir}
+		if err := sub.Git(ctx, "config", "--local", key, value); err != nil {
+			return err
+		}
+		if submodules == SubmodulesNone || submodules == "false" {
+			continue
+		}
+		if k, prefixes := r.Auth.insteadOf(); k != "" {
+			for _, p := range prefixes {
+				if err := sub.Git(ctx, "config", "--local", "--add", k, p); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
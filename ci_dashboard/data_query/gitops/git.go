@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gitops runs the git operations of actions/checkout: fetching a
+// ref or commit to a depth, checking it out as the runner would, and the
+// submodules, sparse checkout, LFS and credentials that come with it. The
+// git command does the work; the package owns the incantations.
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Auth authenticates git with a token over HTTPS, as actions/checkout does.
+type Auth struct {
+	// ServerURL is the git host, such as https://github.com.
+	ServerURL string
+	Token     string
+}
+
+// header returns the http.extraheader config key and value sending the
+// token, or "" without one.
+func (a *Auth) header() (key, value string) {
+	if a == nil || a.Token == "" {
+		return "", ""
+	}
+	cred := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + a.Token))
+	return "http." + strings.TrimSuffix(a.ServerURL, "/") + "/.extraheader", "AUTHORIZATION: basic " + cred
+}
+
+// insteadOf returns the url.insteadOf config key and the SSH prefixes it
+// rewrites to HTTPS, so submodules referenced as git@host:owner/repo use
+// the token too.
+func (a *Auth) insteadOf() (key string, prefixes []string) {
+	u, err := url.Parse(a.ServerURL)
+	if err != nil || u.Host == "" {
+		return "", nil
+	}
+	return "url." + strings.TrimSuffix(a.ServerURL, "/") + "/.insteadOf", []string{"git@" + u.Host + ":", "ssh://git@" + u.Host + "/"}
+}
+
+// URL returns the HTTPS URL of the repository owner/repo with the token
+// in it, for tools that take no config. Prefer Repo.Auth, which keeps the
+// token out of remotes and process lists.
+func (a *Auth) URL(repository string) string {
+	u, err := url.Parse(strings.TrimSuffix(a.ServerURL, "/") + "/" + repository)
+	if err != nil {
+		return ""
+	}
+	if a.Token != "" {
+		u.User = url.UserPassword("x-access-token", a.Token)
+	}
+	return u.String()
+}
+
+// Repo is a git repository in a directory.
+type Repo struct {
+	Dir string
+	// Auth, if set, authenticates fetches, including those of submodules.
+	// It is passed in the environment, not stored in the repository.
+	Auth *Auth
+	// Log receives git's progress and the output of commands run through
+	// Git. Defaults to discarding it.
+	Log io.Writer
+}
+
+// env returns the environment of git commands, with the auth config in
+// GIT_CONFIG_* variables that git and the commands it starts read.
+func (r *Repo) env() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	var config [][2]string
+	if k, v := r.Auth.header(); k != "" {
+		config = append(config, [2]string{k, v})
+		if k, prefixes := r.Auth.insteadOf(); k != "" {
+			for _, p := range prefixes {
+				config = append(config, [2]string{k, p})
+			}
+		}
+	}
+	if len(config) > 0 {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(config)))
+		for i, kv := range config {
+			env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]))
+		}
+	}
+	return env
+}
+
+// Output runs git with args in the repository and returns its standard
+// output.
+func (r *Repo) Output(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Dir
+	cmd.Env = r.env()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", gitError(args, err, stderr.String())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Git runs git with args in the repository, writing its output to Log.
+func (r *Repo) Git(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Dir
+	cmd.Env = r.env()
+	var stderr bytes.Buffer
+	log := r.Log
+	if log == nil {
+		log = io.Discard
+	}
+	cmd.Stdout = log
+	cmd.Stderr = io.MultiWriter(log, &stderr)
+	if err := cmd.Run(); err != nil {
+		return gitError(args, err, stderr.String())
+	}
+	return nil
+}
+
+func gitError(args []string, err error, stderr string) error {
+	name := ""
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") && !strings.Contains(a, "=") {
+			name = a
+			break
+		}
+	}
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		// Keep the last lines, which say what went wrong.
+		lines := strings.Split(msg, "\n")
+		return fmt.Errorf("git %s: %v: %s", name, err, strings.Join(lines[max(0, len(lines)-3):], "; "))
+	}
+	return fmt.Errorf("git %s: %v", name, err)
+}
+
+// Head returns the commit checked out.
+func (r *Repo) Head(ctx context.Context) (string, error) {
+	return r.Output(ctx, "rev-parse", "HEAD")
+}
+
+// Shallow reports whether the repository is a shallow clone.
+func (r *Repo) Shallow(ctx context.Context) bool {
+	out, err := r.Output(ctx, "rev-parse", "--is-shallow-repository")
+	return err == nil && out == "true"
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// IsSHA reports whether s is a full commit SHA.
+func IsSHA(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// RefSpecs returns what to fetch for ref at commit, either of which may be
+// empty. A commit is fetched by SHA into the ref's remote-tracking name, so
+// a branch that has moved on or a pull request's refs/pull/N/merge, which
+// GitHub recomputes, still checks out the commit the event names. A bare
+// name may be a branch or a tag.
+func RefSpecs(ref, commit string) []string {
+	switch {
+	case commit != "" && strings.HasPrefix(ref, "refs/heads/"):
+		return []string{"+" + commit + ":refs/remotes/origin/" + strings.TrimPrefix(ref, "refs/heads/")}
+	case commit != "" && strings.HasPrefix(ref, "refs/pull/"):
+		return []string{"+" + commit + ":refs/remotes/pull/" + strings.TrimPrefix(ref, "refs/pull/")}
+	case commit != "" && strings.HasPrefix(ref, "refs/tags/"):
+		return []string{"+" + commit + ":" + ref}
+	case commit != "":
+		return []string{commit}
+	case strings.HasPrefix(ref, "refs/heads/"):
+		return []string{"+" + ref + ":refs/remotes/origin/" + strings.TrimPrefix(ref, "refs/heads/")}
+	case strings.HasPrefix(ref, "refs/pull/"):
+		return []string{"+" + ref + ":refs/remotes/pull/" + strings.TrimPrefix(ref, "refs/pull/")}
+	case strings.HasPrefix(ref, "refs/"):
+		return []string{"+" + ref + ":" + ref}
+	}
+	return []string{"+refs/heads/" + ref + "*:refs/remotes/origin/" + ref + "*", "+refs/tags/" + ref + "*:refs/tags/" + ref + "*"}
+}
+
+// allHistory is what a fetch of the whole history fetches.
+var allHistory = []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"}
+
+// target is what to check out once ref or commit is fetched: a branch
+// created at startPoint, or a detached ref or commit.
+type target struct {
+	branch, startPoint string
+	ref                string
+}
+
+// checkoutTarget returns the target of ref or commit, looking up whether
+// a bare name is a branch or a tag among the fetched refs.
+func (r *Repo) checkoutTarget(ctx context.Context, ref, commit string) (target, error) {
+	switch {
+	case ref == "" && commit == "":
+		return target{}, fmt.Errorf("no ref or commit to check out")
+	case strings.HasPrefix(ref, "refs/heads/"):
+		branch := strings.TrimPrefix(ref, "refs/heads/")
+		return target{branch: branch, startPoint: "refs/remotes/origin/" + branch}, nil
+	case strings.HasPrefix(ref, "refs/pull/"):
+		return target{ref: "refs/remotes/pull/" + strings.TrimPrefix(ref, "refs/pull/")}, nil
+	case strings.HasPrefix(ref, "refs/"):
+		return target{ref: ref}, nil
+	case ref == "":
+		return target{ref: commit}, nil
+	case IsSHA(ref):
+		return target{ref: ref}, nil
+	}
+	if _, err := r.Output(ctx, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+ref); err == nil {
+		return target{branch: ref, startPoint: "refs/remotes/origin/" + ref}, nil
+	}
+	if _, err := r.Output(ctx, "rev-parse", "--verify", "--quiet", "refs/tags/"+ref); err == nil {
+		return target{ref: "refs/tags/" + ref}, nil
+	}
+	if commit != "" {
+		// Only the commit was fetched.
+		return target{ref: commit}, nil
+	}
+	return target{}, fmt.Errorf("a branch or tag with the name %q could not be found", ref)
+}
+
+// DefaultBranch returns the ref of the default branch of the remote at
+// url, such as refs/heads/main.
+func (r *Repo) DefaultBranch(ctx context.Context, url string) (string, error) {
+	out, err := r.Output(ctx, "ls-remote", "--quiet", "--exit-code", "--symref", url, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if rest, ok := strings.CutPrefix(line, "ref:"); ok {
+			ref, _, _ := strings.Cut(strings.TrimSpace(rest), "\t")
+			return strings.TrimSpace(ref), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no default branch", url)
+}
+
+// PullRequestRefs returns the ref and commit a pull_request event checks
+// out: the merge commit GitHub prepared, or with head the head commit
+// itself.
+func PullRequestRefs(number int, mergeSHA, headSHA string, head bool) (ref, commit string) {
+	if head {
+		return fmt.Sprintf("refs/pull/%d/head", number), headSHA
+	}
+	return fmt.Sprintf("refs/pull/%d/merge", number), mergeSHA
+}
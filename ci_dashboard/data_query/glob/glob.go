@@ -0,0 +1,270 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package glob matches files the way @actions/glob does, which differs from
+// filepath.Glob: ** spans directories, patterns can be negated, a matching
+// directory implies its contents, and results come in the runner's
+// traversal order.
+package glob
+
+import (
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options controls matching. The zero value is not the @actions/glob
+// default; start from DefaultOptions.
+type Options struct {
+	// Root is the directory relative patterns are rooted at. Defaults to
+	// the current directory.
+	Root string
+	// FollowSymlinks descends into symbolic links to directories.
+	FollowSymlinks bool
+	// ImplicitDescendants makes a pattern matching a directory also match
+	// everything below it.
+	ImplicitDescendants bool
+	// MatchDirectories includes matching directories in the results.
+	MatchDirectories bool
+	// OmitBrokenSymlinks skips broken symbolic links instead of failing
+	// when FollowSymlinks is set.
+	OmitBrokenSymlinks bool
+	// ExcludeHiddenFiles skips files and directories starting with a dot.
+	ExcludeHiddenFiles bool
+	// Braces expands {a,b} alternatives, which @actions/glob does not.
+	Braces bool
+}
+
+// DefaultOptions returns the @actions/glob defaults.
+func DefaultOptions() Options {
+	return Options{
+		FollowSymlinks:      true,
+		ImplicitDescendants: true,
+		MatchDirectories:    true,
+		OmitBrokenSymlinks:  true,
+	}
+}
+
+// Globber matches a set of patterns.
+type Globber struct {
+	patterns []Pattern
+	opts     Options
+}
+
+// New compiles patterns, given one per line. Empty lines and lines starting
+// with # are ignored.
+func New(patterns string, opts Options) (*Globber, error) {
+	if opts.Root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		opts.Root = wd
+	}
+	root, err := filepath.Abs(opts.Root)
+	if err != nil {
+		return nil, err
+	}
+	opts.Root = root
+	g := &Globber{opts: opts}
+	for _, line := range strings.Split(patterns, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines := []string{line}
+		if opts.Braces {
+			lines = ExpandBraces(line)
+		}
+		for _, l := range lines {
+			p, err := ParsePattern(l, root)
+			if err != nil {
+				return nil, err
+			}
+			g.patterns = append(g.patterns, p)
+			if opts.ImplicitDescendants {
+				g.patterns = append(g.patterns, p.descendants())
+			}
+		}
+	}
+	return g, nil
+}
+
+// Patterns returns the compiled patterns, including implied ones.
+func (g *Globber) Patterns() []Pattern {
+	return g.patterns
+}
+
+// SearchPaths returns the directories a search starts from, in pattern
+// order, leaving out those inside another.
+func (g *Globber) SearchPaths() []string {
+	candidates := map[string]bool{}
+	for _, p := range g.patterns {
+		if !p.Negate {
+			candidates[p.SearchPath()] = true
+		}
+	}
+	var roots []string
+	included := map[string]bool{}
+	for _, p := range g.patterns {
+		sp := p.SearchPath()
+		if p.Negate || included[sp] {
+			continue
+		}
+		ancestor := false
+		for dir := filepath.Dir(sp); dir != sp; sp, dir = dir, filepath.Dir(dir) {
+			if candidates[dir] {
+				ancestor = true
+				break
+			}
+		}
+		if !ancestor {
+			roots = append(roots, p.SearchPath())
+			included[p.SearchPath()] = true
+		}
+	}
+	return roots
+}
+
+// Match reports whether the last pattern matching path includes it. path is
+// relative to Root unless absolute. The file system is not consulted.
+func (g *Globber) Match(path string, isDir bool) bool {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(g.opts.Root, path)
+	}
+	matched := false
+	for _, p := range g.patterns {
+		if !p.Match(path) {
+			continue
+		}
+		switch {
+		case p.Negate:
+			matched = false
+		case !p.DirOnly || isDir:
+			matched = true
+		}
+	}
+	return matched
+}
+
+func (g *Globber) partialMatch(path string) bool {
+	for _, p := range g.patterns {
+		if !p.Negate && p.PartialMatch(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// All iterates over the matching paths depth first, visiting the entries of
+// each directory in name order, without reading the whole tree up front.
+// Iteration stops at the first error.
+func (g *Globber) All() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for _, root := range g.SearchPaths() {
+			if _, err := os.Lstat(root); os.IsNotExist(err) {
+				continue
+			}
+			if !g.visit(root, nil, yield) {
+				return
+			}
+		}
+	}
+}
+
+// Glob returns every matching path.
+func (g *Globber) Glob() ([]string, error) {
+	var out []string
+	for p, err := range g.All() {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// visit walks path, returning false once iteration should stop. chain holds
+// the real paths of the directories above path, to stop at symlink cycles.
+func (g *Globber) visit(path string, chain []string, yield func(string, error) bool) bool {
+	isMatch := g.Match(path, false) || g.Match(path, true)
+	if !isMatch && !g.partialMatch(path) {
+		return true
+	}
+	info, err := g.stat(path)
+	if err != nil {
+		return yield("", err)
+	}
+	if info == nil {
+		return true
+	}
+	if g.opts.ExcludeHiddenFiles && strings.HasPrefix(filepath.Base(path), ".") {
+		return true
+	}
+	if !info.IsDir() {
+		if g.Match(path, false) {
+			return yield(path, nil)
+		}
+		return true
+	}
+	if g.opts.FollowSymlinks {
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return yield("", err)
+		}
+		for _, c := range chain {
+			if c == real {
+				return true
+			}
+		}
+		chain = append(chain, real)
+	}
+	if g.opts.MatchDirectories && g.Match(path, true) {
+		if !yield(path, nil) {
+			return false
+		}
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return yield("", err)
+	}
+	for _, e := range entries {
+		if !g.visit(filepath.Join(path, e.Name()), chain, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// stat returns nil for broken symlinks that should be skipped.
+func (g *Globber) stat(path string) (os.FileInfo, error) {
+	if !g.opts.FollowSymlinks {
+		return os.Lstat(path)
+	}
+	info, err := os.Stat(path)
+	if err == nil {
+		return info, nil
+	}
+	if os.IsNotExist(err) {
+		if _, lerr := os.Lstat(path); lerr == nil {
+			if g.opts.OmitBrokenSymlinks {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("no information found for the path %q; this may indicate a broken symbolic link", path)
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Pattern is one compiled pattern line.
+type Pattern struct {
+	// Source is the line as written, without negation.
+	Source string
+	Negate bool
+	// DirOnly is set for patterns with a trailing slash, which only match
+	// directories.
+	DirOnly bool
+
+	// segs is the absolute, slash-separated pattern split into segments.
+	segs []string
+}
+
+// ParsePattern compiles a single pattern rooted at root. Leading ! negates
+// it and a leading ~ stands for the home directory.
+func ParsePattern(s, root string) (Pattern, error) {
+	p := Pattern{}
+	s = strings.TrimSpace(s)
+	for strings.HasPrefix(s, "!") {
+		p.Negate, s = !p.Negate, s[1:]
+	}
+	p.Source = s
+	if s == "" {
+		return Pattern{}, fmt.Errorf("empty pattern")
+	}
+	if s == "~" || strings.HasPrefix(s, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Pattern{}, fmt.Errorf("failed to expand %s: %w", s, err)
+		}
+		s = home + s[1:]
+	}
+	p.DirOnly = strings.HasSuffix(s, "/")
+	if !filepath.IsAbs(s) {
+		s = filepath.Join(root, s)
+	}
+	p.segs = strings.Split(filepath.ToSlash(filepath.Clean(s)), "/")
+	for _, seg := range p.segs {
+		if seg == "**" {
+			continue
+		}
+		if strings.Contains(seg, "**") {
+			return Pattern{}, fmt.Errorf("invalid pattern %q: ** must be a whole path segment", p.Source)
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return Pattern{}, fmt.Errorf("invalid pattern %q: %w", p.Source, err)
+		}
+	}
+	return p, nil
+}
+
+// descendants returns the pattern that matches everything below p.
+func (p Pattern) descendants() Pattern {
+	d := p
+	d.DirOnly = false
+	d.segs = append(p.segs[:len(p.segs):len(p.segs)], "**")
+	return d
+}
+
+// SearchPath returns the directory before the first wildcard segment, where
+// a search for the pattern starts.
+func (p Pattern) SearchPath() string {
+	i := 0
+	for i < len(p.segs) && !hasMeta(p.segs[i]) {
+		i++
+	}
+	if i == 1 && p.segs[0] == "" {
+		return "/"
+	}
+	return filepath.FromSlash(strings.Join(p.segs[:i], "/"))
+}
+
+func hasMeta(seg string) bool {
+	return strings.ContainsAny(seg, "*?[")
+}
+
+// Match reports whether the absolute path matches the whole pattern.
+func (p Pattern) Match(abs string) bool {
+	return matchSegs(p.segs, split(abs), false)
+}
+
+// PartialMatch reports whether abs could be an ancestor of a match.
+func (p Pattern) PartialMatch(abs string) bool {
+	return matchSegs(p.segs, split(abs), true)
+}
+
+func split(abs string) []string {
+	return strings.Split(filepath.ToSlash(filepath.Clean(abs)), "/")
+}
+
+func matchSegs(pat, segs []string, partial bool) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			// ** matches zero or more segments, including hidden ones.
+			for i := 0; i <= len(segs); i++ {
+				if matchSegs(pat[1:], segs[i:], partial) {
+					return true
+				}
+			}
+			return partial && len(segs) > 0
+		}
+		if len(segs) == 0 {
+			return partial
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// ExpandBraces expands {a,b} alternatives, including nested ones, into
+// separate patterns. A brace group without a comma is kept literally, and
+// \{ escapes a brace.
+func ExpandBraces(s string) []string {
+	depth, start := 0, -1
+	var commas []int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				start, commas = i, nil
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if len(commas) == 0 {
+				// Literal braces; expand the rest.
+				var out []string
+				for _, rest := range ExpandBraces(s[i+1:]) {
+					for _, inner := range ExpandBraces(s[start+1 : i]) {
+						out = append(out, s[:start]+"{"+inner+"}"+rest)
+					}
+				}
+				return out
+			}
+			var out []string
+			bounds := append(append([]int{start}, commas...), i)
+			for j := 0; j+1 < len(bounds); j++ {
+				alt := s[bounds[j]+1 : bounds[j+1]]
+				out = append(out, ExpandBraces(s[:start]+alt+s[i+1:])...)
+			}
+			return out
+		}
+	}
+	return []string{s}
+}
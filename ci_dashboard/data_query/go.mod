@@ -5,7 +5,10 @@ go 1.24
 require (
 	github.com/bradleyfalzon/ghinstallation/v2 v2.14.0
 	github.com/google/go-github/v52 v52.0.0
+	github.com/tetratelabs/wazero v1.10.1
+	golang.org/x/crypto v0.35.0
 	golang.org/x/oauth2 v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -14,6 +17,5 @@ require (
 	github.com/golang-jwt/jwt/v4 v4.5.2 // indirect
 	github.com/google/go-github/v69 v69.2.0 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
-	golang.org/x/crypto v0.35.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 )
@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExportOptions controls how a graph is rendered.
+type ExportOptions struct {
+	// Title names the graph.
+	Title string
+	// Legs draws one node per matrix combination instead of a single node
+	// with the number of combinations.
+	Legs bool
+}
+
+// children groups the jobs by the job that called their workflow.
+func (g *Graph) children() map[string][]string {
+	out := map[string][]string{}
+	for _, id := range g.ids {
+		parent := ""
+		if n := g.nodes[id]; n != nil {
+			parent = n.Parent
+		}
+		out[parent] = append(out[parent], id)
+	}
+	return out
+}
+
+func (g *Graph) node(id string) *Node {
+	if n := g.nodes[id]; n != nil {
+		return n
+	}
+	return &Node{ID: id, Label: id}
+}
+
+// summary is the node label used when legs are not drawn.
+func (n *Node) summary() string {
+	switch {
+	case n.MatrixExpression != "":
+		return fmt.Sprintf("%s\n(%s)", n.Label, n.MatrixExpression)
+	case len(n.Legs) == 1:
+		return fmt.Sprintf("%s\n(1 leg)", n.Label)
+	case len(n.Legs) > 1:
+		return fmt.Sprintf("%s\n(%d legs)", n.Label, len(n.Legs))
+	}
+	return n.Label
+}
+
+// ExportDOT writes g in Graphviz DOT format. Edges point from a job to the
+// jobs that need it, and the jobs of called workflows are drawn in a
+// cluster labeled with the call.
+func ExportDOT(w io.Writer, g *Graph, opts ExportOptions) error {
+	bw := bufio.NewWriter(w)
+	title := opts.Title
+	if title == "" {
+		title = "workflow"
+	}
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(title))
+	fmt.Fprintf(bw, "  rankdir=LR;\n  compound=true;\n  node [shape=box, style=rounded];\n")
+
+	children := g.children()
+	legged := func(n *Node) bool { return opts.Legs && len(n.Legs) > 1 }
+	var writeNodes func(parent, indent string)
+	writeNodes = func(parent, indent string) {
+		for _, id := range children[parent] {
+			n := g.node(id)
+			if kids := children[id]; len(kids) > 0 {
+				fmt.Fprintf(bw, "%ssubgraph %s {\n", indent, dotQuote("cluster_"+id))
+				fmt.Fprintf(bw, "%s  label=%s;\n%s  style=dashed;\n", indent, dotQuote(id+": "+n.Calls), indent)
+				writeNodes(id, indent+"  ")
+				fmt.Fprintf(bw, "%s}\n", indent)
+			}
+			switch {
+			case legged(n):
+				fmt.Fprintf(bw, "%ssubgraph %s {\n", indent, dotQuote("cluster_matrix_"+id))
+				fmt.Fprintf(bw, "%s  label=%s;\n", indent, dotQuote(n.Label))
+				for i, leg := range n.Legs {
+					fmt.Fprintf(bw, "%s  %s [label=%s];\n", indent, dotQuote(legID(id, i)), dotQuote(leg))
+				}
+				fmt.Fprintf(bw, "%s}\n", indent)
+			case n.Calls != "":
+				fmt.Fprintf(bw, "%s%s [label=%s, shape=component, style=\"\"];\n", indent, dotQuote(id), dotQuote(n.summary()+"\n"+n.Calls))
+			default:
+				fmt.Fprintf(bw, "%s%s [label=%s];\n", indent, dotQuote(id), dotQuote(n.summary()))
+			}
+		}
+	}
+	writeNodes("", "  ")
+
+	// endpoint returns the node an edge attaches to and, for jobs drawn as
+	// a cluster of legs, the attribute that clips the edge to the cluster.
+	endpoint := func(id, attr string) (string, string) {
+		if n := g.node(id); legged(n) {
+			return dotQuote(legID(id, 0)), fmt.Sprintf("%s=%s", attr, dotQuote("cluster_matrix_"+id))
+		}
+		return dotQuote(id), ""
+	}
+	for _, id := range g.ids {
+		for _, need := range g.needs[id] {
+			from, ltail := endpoint(need, "ltail")
+			to, lhead := endpoint(id, "lhead")
+			var attrs []string
+			for _, a := range []string{ltail, lhead} {
+				if a != "" {
+					attrs = append(attrs, a)
+				}
+			}
+			if len(attrs) > 0 {
+				fmt.Fprintf(bw, "  %s -> %s [%s];\n", from, to, strings.Join(attrs, ", "))
+			} else {
+				fmt.Fprintf(bw, "  %s -> %s;\n", from, to)
+			}
+		}
+	}
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
+
+// ExportMermaid writes g as a Mermaid flowchart, laid out like ExportDOT.
+func ExportMermaid(w io.Writer, g *Graph, opts ExportOptions) error {
+	bw := bufio.NewWriter(w)
+	if opts.Title != "" {
+		fmt.Fprintf(bw, "---\ntitle: %s\n---\n", opts.Title)
+	}
+	fmt.Fprintf(bw, "flowchart LR\n")
+
+	// Mermaid IDs are restricted, so jobs are numbered by source order.
+	ids := map[string]string{}
+	for i, id := range g.ids {
+		ids[id] = fmt.Sprintf("j%d", i)
+	}
+	children := g.children()
+	legged := func(n *Node) bool { return opts.Legs && len(n.Legs) > 1 }
+	var writeNodes func(parent, indent string)
+	writeNodes = func(parent, indent string) {
+		for _, id := range children[parent] {
+			n := g.node(id)
+			if kids := children[id]; len(kids) > 0 {
+				fmt.Fprintf(bw, "%ssubgraph %s_calls[%s]\n", indent, ids[id], mermaidLabel(id+": "+n.Calls))
+				writeNodes(id, indent+"  ")
+				fmt.Fprintf(bw, "%send\n", indent)
+			}
+			switch {
+			case legged(n):
+				fmt.Fprintf(bw, "%ssubgraph %s[%s]\n", indent, ids[id], mermaidLabel(n.Label))
+				for i, leg := range n.Legs {
+					fmt.Fprintf(bw, "%s  %s_%d[%s]\n", indent, ids[id], i, mermaidLabel(leg))
+				}
+				fmt.Fprintf(bw, "%send\n", indent)
+			case n.Calls != "":
+				fmt.Fprintf(bw, "%s%s[[%s]]\n", indent, ids[id], mermaidLabel(n.summary()+"\n"+n.Calls))
+			default:
+				fmt.Fprintf(bw, "%s%s(%s)\n", indent, ids[id], mermaidLabel(n.summary()))
+			}
+		}
+	}
+	writeNodes("", "  ")
+	for _, id := range g.ids {
+		for _, need := range g.needs[id] {
+			fmt.Fprintf(bw, "  %s --> %s\n", ids[need], ids[id])
+		}
+	}
+	return bw.Flush()
+}
+
+func legID(id string, i int) string {
+	return fmt.Sprintf("%s#%d", id, i)
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func mermaidLabel(s string) string {
+	r := strings.NewReplacer(`"`, "#quot;", "\n", "<br>")
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,331 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graph builds the dependency graph formed by the needs of the jobs
+// of a workflow.
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Graph is a validated, acyclic job dependency graph.
+type Graph struct {
+	// ids lists the jobs in source order.
+	ids        []string
+	jobs       map[string]*workflow.Job
+	nodes      map[string]*Node
+	needs      map[string][]string
+	dependents map[string][]string
+}
+
+// CycleError reports jobs that need each other.
+type CycleError struct {
+	// Cycle lists the jobs on the cycle, starting and ending with the same
+	// job.
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return "dependency cycle between jobs: " + strings.Join(e.Cycle, " -> ")
+}
+
+// UndefinedError reports a need that names a job that does not exist.
+type UndefinedError struct {
+	Job, Need string
+}
+
+func (e *UndefinedError) Error() string {
+	return fmt.Sprintf("job %s needs undefined job %s", e.Job, e.Need)
+}
+
+// New builds the graph of wf's jobs.
+func New(wf *workflow.Workflow) (*Graph, error) {
+	ids := wf.JobIDs()
+	needs := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		needs[id] = wf.Jobs[id].Needs
+	}
+	g, err := build(ids, needs)
+	if err != nil {
+		return nil, err
+	}
+	g.jobs = wf.Jobs
+	for _, id := range ids {
+		g.nodes[id] = newNode(id, wf.Jobs[id])
+	}
+	return g, nil
+}
+
+func build(ids []string, needs map[string][]string) (*Graph, error) {
+	g := &Graph{
+		ids:        slices.Clone(ids),
+		jobs:       map[string]*workflow.Job{},
+		nodes:      map[string]*Node{},
+		needs:      map[string][]string{},
+		dependents: map[string][]string{},
+	}
+	for _, id := range ids {
+		g.needs[id] = nil
+	}
+	for _, id := range ids {
+		for _, need := range needs[id] {
+			if _, ok := g.needs[need]; !ok {
+				return nil, &UndefinedError{Job: id, Need: need}
+			}
+			if slices.Contains(g.needs[id], need) {
+				continue
+			}
+			g.needs[id] = append(g.needs[id], need)
+			g.dependents[need] = append(g.dependents[need], id)
+		}
+	}
+	if cycle := g.findCycle(); cycle != nil {
+		return nil, &CycleError{Cycle: cycle}
+	}
+	return g, nil
+}
+
+// findCycle returns a cycle in the graph, or nil.
+func (g *Graph) findCycle() []string {
+	const (
+		unvisited = iota
+		active
+		finished
+	)
+	state := map[string]int{}
+	var stack []string
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = active
+		stack = append(stack, id)
+		for _, need := range g.needs[id] {
+			switch state[need] {
+			case active:
+				start := slices.Index(stack, need)
+				return append(slices.Clone(stack[start:]), need)
+			case unvisited:
+				if cycle := visit(need); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = finished
+		return nil
+	}
+	for _, id := range g.ids {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Jobs returns the job IDs in source order.
+func (g *Graph) Jobs() []string {
+	return slices.Clone(g.ids)
+}
+
+// Has reports whether id is a job of the graph.
+func (g *Graph) Has(id string) bool {
+	_, ok := g.needs[id]
+	return ok
+}
+
+// Job returns the workflow job for id.
+func (g *Graph) Job(id string) *workflow.Job {
+	return g.jobs[id]
+}
+
+// Node returns the display information for id.
+func (g *Graph) Node(id string) *Node {
+	return g.nodes[id]
+}
+
+// Needs returns the jobs id directly needs.
+func (g *Graph) Needs(id string) []string {
+	return slices.Clone(g.needs[id])
+}
+
+// Dependents returns the jobs that directly need id, in source order.
+func (g *Graph) Dependents(id string) []string {
+	return g.ordered(g.dependents[id])
+}
+
+// Ancestors returns every job id transitively needs, in source order.
+func (g *Graph) Ancestors(id string) []string {
+	return g.ordered(g.reach(id, g.needs))
+}
+
+// Descendants returns every job that transitively needs id, in source order.
+func (g *Graph) Descendants(id string) []string {
+	return g.ordered(g.reach(id, g.dependents))
+}
+
+func (g *Graph) reach(id string, edges map[string][]string) []string {
+	seen := map[string]bool{}
+	var out []string
+	var visit func(string)
+	visit = func(id string) {
+		for _, next := range edges[id] {
+			if !seen[next] {
+				seen[next] = true
+				out = append(out, next)
+				visit(next)
+			}
+		}
+	}
+	visit(id)
+	return out
+}
+
+// ordered returns the given jobs sorted by source order.
+func (g *Graph) ordered(ids []string) []string {
+	var out []string
+	for _, id := range g.ids {
+		if slices.Contains(ids, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// TopologicalOrder returns the jobs so that every job comes after the jobs
+// it needs. Ties are broken by source order, so the result is stable.
+func (g *Graph) TopologicalOrder() []string {
+	var order []string
+	for _, level := range g.Levels() {
+		order = append(order, level...)
+	}
+	return order
+}
+
+// Levels groups the jobs into stages: the first holds the jobs that need
+// nothing, and each later one the jobs whose needs are all in earlier
+// stages. Jobs in the same stage can run in parallel.
+func (g *Graph) Levels() [][]string {
+	depth := map[string]int{}
+	var levels [][]string
+	for len(depth) < len(g.ids) {
+		var level []string
+		for _, id := range g.ids {
+			if _, done := depth[id]; done {
+				continue
+			}
+			ready := true
+			for _, need := range g.needs[id] {
+				if _, ok := depth[need]; !ok {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, id)
+			}
+		}
+		for _, id := range level {
+			depth[id] = len(levels)
+		}
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// Select returns the subgraph made of ids and everything they need.
+func (g *Graph) Select(ids ...string) (*Graph, error) {
+	keep := map[string]bool{}
+	for _, id := range ids {
+		if !g.Has(id) {
+			return nil, fmt.Errorf("job %s is not defined", id)
+		}
+		keep[id] = true
+		for _, a := range g.reach(id, g.needs) {
+			keep[a] = true
+		}
+	}
+	sub := &Graph{
+		jobs:       g.jobs,
+		nodes:      g.nodes,
+		needs:      map[string][]string{},
+		dependents: map[string][]string{},
+	}
+	for _, id := range g.ids {
+		if !keep[id] {
+			continue
+		}
+		sub.ids = append(sub.ids, id)
+		sub.needs[id] = g.needs[id]
+		for _, need := range g.needs[id] {
+			sub.dependents[need] = append(sub.dependents[need], id)
+		}
+	}
+	return sub, nil
+}
+
+// FailureImpact describes what happens to the rest of a workflow when a
+// job fails.
+type FailureImpact struct {
+	// Skipped are the jobs downstream of the failure that will not run
+	// because their if: does not use a status function.
+	Skipped []string
+	// Conditional are the downstream jobs whose if: uses a status function
+	// such as always() or failure(), so whether they run depends on it.
+	Conditional []string
+	// Unaffected are the jobs that do not depend on the failed job.
+	Unaffected []string
+}
+
+// IfFails reports which jobs still run when id fails.
+func (g *Graph) IfFails(id string) (*FailureImpact, error) {
+	if !g.Has(id) {
+		return nil, fmt.Errorf("job %s is not defined", id)
+	}
+	downstream := g.reach(id, g.dependents)
+	impact := &FailureImpact{}
+	for _, other := range g.ids {
+		switch {
+		case other == id:
+		case !slices.Contains(downstream, other):
+			impact.Unaffected = append(impact.Unaffected, other)
+		case hasStatusFunction(g.jobs[other]):
+			impact.Conditional = append(impact.Conditional, other)
+		default:
+			impact.Skipped = append(impact.Skipped, other)
+		}
+	}
+	return impact, nil
+}
+
+func hasStatusFunction(job *workflow.Job) bool {
+	if job == nil {
+		return false
+	}
+	cond := strings.TrimSpace(job.If)
+	if strings.HasPrefix(cond, "${{") && strings.HasSuffix(cond, "}}") {
+		cond = cond[3 : len(cond)-2]
+	}
+	if cond == "" {
+		return false
+	}
+	node, err := expr.Parse(cond)
+	return err == nil && expr.HasStatusFunction(node)
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Node is what the graph knows about a job beyond its dependencies.
+type Node struct {
+	ID string
+	// Label is the job name, or the ID when the job has no name.
+	Label string
+	// Legs names the matrix combinations of the job as their check runs
+	// are named. It is empty when the job has no matrix or the matrix is
+	// computed by an expression.
+	Legs []string
+	// MatrixExpression is set when the matrix is only known at run time.
+	MatrixExpression string
+	// Calls is the reusable workflow the job calls, if any.
+	Calls string
+	// Parent is the ID of the job that called the workflow this job belongs
+	// to. It is empty for top-level jobs.
+	Parent string
+}
+
+func newNode(id string, job *workflow.Job) *Node {
+	n := &Node{ID: id, Label: job.Name, Calls: job.Uses}
+	if n.Label == "" {
+		n.Label = id[strings.LastIndex(id, "/")+1:]
+	}
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		n.Parent = id[:i]
+	}
+	if job.Strategy == nil || job.Strategy.Matrix == nil {
+		return n
+	}
+	exp, err := job.Strategy.Expand()
+	if err != nil {
+		n.MatrixExpression = matrixExpression(job.Strategy.Matrix)
+		return n
+	}
+	for _, cfg := range exp.Configs {
+		name := n.Label
+		if v, err := expr.Interpolate(job.Name, &expr.Context{Values: map[string]any{"matrix": cfg.Matrix}}); err == nil && job.Name != "" {
+			name = v
+		}
+		n.Legs = append(n.Legs, workflow.LegName(job, name, cfg.Matrix))
+	}
+	return n
+}
+
+// matrixExpression returns the first expression the matrix depends on.
+func matrixExpression(m *workflow.Matrix) string {
+	if m.Expression != "" {
+		return m.Expression
+	}
+	for _, dim := range m.Dimensions {
+		if dim.Expression != "" {
+			return fmt.Sprintf("%s: %s", dim.Name, dim.Expression)
+		}
+	}
+	return "matrix"
+}
+
+// FromPlan builds the graph of a plan, so that the jobs of called reusable
+// workflows appear as nodes of their own.
+func FromPlan(plan *resolve.Plan) (*Graph, error) {
+	ids := make([]string, 0, len(plan.Jobs))
+	needs := map[string][]string{}
+	for _, j := range plan.Jobs {
+		ids = append(ids, j.ID)
+		needs[j.ID] = j.Needs
+	}
+	g, err := build(ids, needs)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range plan.Jobs {
+		g.jobs[j.ID] = j.Job
+		g.nodes[j.ID] = newNode(j.ID, j.Job)
+	}
+	return g, nil
+}
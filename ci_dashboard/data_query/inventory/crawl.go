@@ -0,0 +1,239 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/client"
+)
+
+// CrawlOptions tune Crawl.
+type CrawlOptions struct {
+	// Server is recorded as the inventory's instance.
+	Server string
+	// Previous is an earlier inventory of the organization. Repositories
+	// not pushed to since are copied from it, and files it has are not
+	// downloaded again.
+	Previous *Inventory
+	// CacheDir keeps workflow files by blob SHA across crawls. Empty
+	// means no cache.
+	CacheDir string
+	// Repos limits the crawl to the repositories whose names match one of
+	// the patterns, in path.Match syntax.
+	Repos []string
+	// Archived includes archived repositories.
+	Archived bool
+	// Parallel is how many repositories are crawled at once. Defaults to
+	// 4.
+	Parallel int
+	// Progress, if set, is called as each repository is done; cached is
+	// set for those copied from Previous.
+	Progress func(r *Repository, cached bool)
+}
+
+// DefaultCacheDir returns the directory Crawl keeps workflow files in.
+func DefaultCacheDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "actions-runner", "inventory"), nil
+}
+
+// Crawl records the workflows on the default branch of every repository
+// of org. A repository whose workflows cannot be read has its Error set
+// rather than failing the crawl.
+func Crawl(ctx context.Context, c *client.Client, org string, opts CrawlOptions) (*Inventory, error) {
+	if opts.Parallel <= 0 {
+		opts.Parallel = 4
+	}
+	var repos []*client.Repository
+	for r, err := range c.ListOrgRepos(ctx, org) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the repositories of %s: %w", org, err)
+		}
+		if r.Disabled || r.Archived && !opts.Archived || !matchAny(opts.Repos, r.Name) {
+			continue
+		}
+		repos = append(repos, r)
+	}
+
+	cr := &crawler{c: c, opts: opts, blobs: map[string]string{}}
+	if opts.Previous != nil {
+		for _, r := range opts.Previous.Repos {
+			for _, w := range r.Workflows {
+				cr.blobs[w.SHA] = w.Content
+			}
+		}
+	}
+	inv := &Inventory{Org: org, Server: opts.Server, GeneratedAt: time.Now().UTC(), Repos: make([]*Repository, len(repos))}
+	var wg sync.WaitGroup
+	work := make(chan int)
+	for range opts.Parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				inv.Repos[i] = cr.repo(ctx, repos[i])
+			}
+		}()
+	}
+	for i := range repos {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+type crawler struct {
+	c    *client.Client
+	opts CrawlOptions
+
+	mu sync.Mutex
+	// blobs holds the files of the previous inventory by blob SHA.
+	blobs map[string]string
+}
+
+func (cr *crawler) repo(ctx context.Context, r *client.Repository) *Repository {
+	out := &Repository{Name: r.FullName, DefaultBranch: r.DefaultBranch, PushedAt: r.PushedAt, Archived: r.Archived, Workflows: []*Workflow{}}
+	if prev := cr.previous(r); prev != nil {
+		cr.progress(prev, true)
+		return prev
+	}
+	owner, name, _ := strings.Cut(r.FullName, "/")
+	entries, err := cr.c.ListContents(ctx, owner, name, ".github/workflows", r.DefaultBranch)
+	var apiErr *client.Error
+	switch {
+	case client.IsNotFound(err), errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict:
+		// No workflows, or an empty repository.
+		cr.progress(out, false)
+		return out
+	case err != nil:
+		out.Error = err.Error()
+		cr.progress(out, false)
+		return out
+	}
+	for _, e := range entries {
+		if e.Type != "file" || !strings.HasSuffix(e.Name, ".yml") && !strings.HasSuffix(e.Name, ".yaml") {
+			continue
+		}
+		content, err := cr.blob(ctx, owner, name, e.SHA)
+		if err != nil {
+			out.Error = fmt.Sprintf("failed to download %s: %v", e.Path, err)
+			break
+		}
+		w := &Workflow{Path: e.Path, SHA: e.SHA, Content: content}
+		analyze(w)
+		out.Workflows = append(out.Workflows, w)
+	}
+	cr.progress(out, false)
+	return out
+}
+
+// previous returns the repository from the previous inventory if nothing
+// was pushed to it since.
+func (cr *crawler) previous(r *client.Repository) *Repository {
+	if cr.opts.Previous == nil || r.PushedAt.IsZero() {
+		return nil
+	}
+	prev := cr.opts.Previous.Repo(r.FullName)
+	if prev == nil || prev.Error != "" || !prev.PushedAt.Equal(r.PushedAt) || prev.DefaultBranch != r.DefaultBranch {
+		return nil
+	}
+	prev.Archived = r.Archived
+	return prev
+}
+
+func (cr *crawler) progress(r *Repository, cached bool) {
+	if cr.opts.Progress != nil {
+		cr.mu.Lock()
+		defer cr.mu.Unlock()
+		cr.opts.Progress(r, cached)
+	}
+}
+
+// blob returns the content of a file by blob SHA, from the previous
+// inventory, the cache or the API.
+func (cr *crawler) blob(ctx context.Context, owner, repo, sha string) (string, error) {
+	cr.mu.Lock()
+	content, ok := cr.blobs[sha]
+	cr.mu.Unlock()
+	if ok {
+		return content, nil
+	}
+	var file string
+	if cr.opts.CacheDir != "" {
+		file = filepath.Join(cr.opts.CacheDir, "blobs", sha)
+		if data, err := os.ReadFile(file); err == nil {
+			return string(data), nil
+		}
+	}
+	data, err := cr.c.GetBlob(ctx, owner, repo, sha)
+	if err != nil {
+		return "", err
+	}
+	if file != "" {
+		// The cache only saves requests, so failing to write it is not an
+		// error.
+		writeCache(file, data)
+	}
+	cr.mu.Lock()
+	cr.blobs[sha] = string(data)
+	cr.mu.Unlock()
+	return string(data), nil
+}
+
+func writeCache(file string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".blob-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil || os.Rename(tmp.Name(), file) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,351 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inventory records the workflows of every repository of an
+// organization, with the actions, triggers, permissions and runners they
+// use, in a JSON database that other commands can query.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/pin"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Inventory is the workflows of the repositories of an organization.
+type Inventory struct {
+	Org string `json:"org"`
+	// Server is the web URL of the GitHub instance.
+	Server      string        `json:"server,omitempty"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Repos       []*Repository `json:"repositories"`
+}
+
+// Repository is the workflows of a repository's default branch.
+type Repository struct {
+	Name          string      `json:"name"`
+	DefaultBranch string      `json:"default_branch"`
+	PushedAt      time.Time   `json:"pushed_at"`
+	Archived      bool        `json:"archived,omitempty"`
+	Workflows     []*Workflow `json:"workflows"`
+	// Error is why the workflows could not be listed.
+	Error string `json:"error,omitempty"`
+}
+
+// Workflow is a workflow file and what it uses.
+type Workflow struct {
+	Path string `json:"path"`
+	// SHA is the blob SHA of the file.
+	SHA         string       `json:"sha"`
+	Name        string       `json:"name,omitempty"`
+	Triggers    []string     `json:"triggers,omitempty"`
+	Permissions *Permissions `json:"permissions,omitempty"`
+	Jobs        []*Job       `json:"jobs,omitempty"`
+	Uses        []*Use       `json:"uses,omitempty"`
+	// Error is why the file does not parse.
+	Error   string `json:"error,omitempty"`
+	Content string `json:"content"`
+}
+
+// Job is a job of a workflow.
+type Job struct {
+	ID string `json:"id"`
+	// RunsOn lists the runner labels, and group:name for a runner group.
+	RunsOn      []string     `json:"runs_on,omitempty"`
+	Permissions *Permissions `json:"permissions,omitempty"`
+	// Uses is the reusable workflow a job calls.
+	Uses string `json:"uses,omitempty"`
+}
+
+// Permissions are the permissions of a workflow or job's token.
+type Permissions struct {
+	// All is read-all or write-all.
+	All    string            `json:"all,omitempty"`
+	Scopes map[string]string `json:"scopes,omitempty"`
+}
+
+// Use is a reference to an action or reusable workflow.
+type Use struct {
+	// Ref is the reference as written.
+	Ref string `json:"ref"`
+	// Kind is action, workflow, docker or local.
+	Kind string `json:"kind"`
+	// Name is owner/repo[/path], the image or the local path.
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Pinned is set for references to a full commit SHA or an image
+	// digest.
+	Pinned bool   `json:"pinned,omitempty"`
+	Job    string `json:"job"`
+	// Step is the index of the step, or -1 for a job calling a workflow.
+	Step int `json:"step"`
+}
+
+// Kinds of Use.
+const (
+	UseAction   = "action"
+	UseWorkflow = "workflow"
+	UseDocker   = "docker"
+	UseLocal    = "local"
+)
+
+// Load reads an inventory written by Save.
+func Load(file string) (*Inventory, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var inv Inventory
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory %s: %w", file, err)
+	}
+	return &inv, nil
+}
+
+// Save writes the inventory to file.
+func (inv *Inventory) Save(file string) error {
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0o644)
+}
+
+// Repo returns the repository named owner/repo, or nil.
+func (inv *Inventory) Repo(name string) *Repository {
+	for _, r := range inv.Repos {
+		if strings.EqualFold(r.Name, name) {
+			return r
+		}
+	}
+	return nil
+}
+
+// Workflows parses the workflows that parse, with paths of the form
+// owner/repo/.github/workflows/ci.yml, for the policy and SBOM commands.
+func (inv *Inventory) Workflows() []*workflow.Workflow {
+	var out []*workflow.Workflow
+	for _, r := range inv.Repos {
+		for _, w := range r.Workflows {
+			if w.Error != "" {
+				continue
+			}
+			wf, err := workflow.Parse([]byte(w.Content))
+			if err != nil {
+				continue
+			}
+			wf.Path = r.Name + "/" + w.Path
+			out = append(out, wf)
+		}
+	}
+	return out
+}
+
+// analyze fills in what w uses from its content.
+func analyze(w *Workflow) {
+	wf, err := workflow.Parse([]byte(w.Content))
+	if err != nil {
+		w.Error = err.Error()
+		return
+	}
+	w.Name = wf.Name
+	w.Triggers = slices.Clone(wf.On.Names)
+	w.Permissions = permissions(wf.Permissions)
+	for _, id := range wf.JobIDs() {
+		job := wf.Jobs[id]
+		j := &Job{ID: id, Permissions: permissions(job.Permissions), Uses: job.Uses}
+		if job.RunsOn != nil {
+			j.RunsOn = slices.Clone(job.RunsOn.Labels)
+			if job.RunsOn.Group != "" {
+				j.RunsOn = append(j.RunsOn, "group:"+job.RunsOn.Group)
+			}
+		}
+		w.Jobs = append(w.Jobs, j)
+		if job.Uses != "" {
+			w.Uses = append(w.Uses, use(job.Uses, id, -1))
+		}
+		for i, step := range job.Steps {
+			if step.Uses != "" {
+				w.Uses = append(w.Uses, use(step.Uses, id, i))
+			}
+		}
+	}
+}
+
+func permissions(p *workflow.Permissions) *Permissions {
+	if p == nil {
+		return nil
+	}
+	return &Permissions{All: p.All, Scopes: p.Scopes}
+}
+
+func use(ref, job string, step int) *Use {
+	u := &Use{Ref: ref, Job: job, Step: step, Kind: UseAction, Name: ref}
+	parsed, err := workflow.ParseUses(ref)
+	if err != nil {
+		return u
+	}
+	switch parsed.Kind {
+	case workflow.UsesLocal:
+		u.Kind, u.Name = UseLocal, parsed.Path
+	case workflow.UsesDocker:
+		u.Kind, u.Name = UseDocker, parsed.Image
+		if name, digest, ok := strings.Cut(parsed.Image, "@"); ok {
+			u.Name, u.Version, u.Pinned = name, digest, true
+		} else if i := strings.LastIndex(parsed.Image, ":"); i > strings.LastIndex(parsed.Image, "/") {
+			u.Name, u.Version = parsed.Image[:i], parsed.Image[i+1:]
+		}
+	default:
+		if step < 0 {
+			u.Kind = UseWorkflow
+		}
+		u.Name = parsed.Owner + "/" + parsed.Repo
+		if parsed.Path != "" {
+			u.Name += "/" + parsed.Path
+		}
+		u.Version, u.Pinned = parsed.Ref, pin.IsSHA(parsed.Ref)
+	}
+	return u
+}
+
+// Usage is how widely a value, such as an action or a runner label, is
+// used.
+type Usage struct {
+	Value string `json:"value"`
+	// Versions maps the versions of an action or workflow to the
+	// repositories using them.
+	Versions     map[string][]string `json:"versions,omitempty"`
+	Repositories []string            `json:"repositories"`
+	Workflows    int                 `json:"workflows"`
+}
+
+// tally counts the values each workflow yields, with their versions if
+// any, most used first.
+func (inv *Inventory) tally(values func(w *Workflow) [][2]string) []*Usage {
+	byValue := map[string]*Usage{}
+	for _, r := range inv.Repos {
+		for _, w := range r.Workflows {
+			seen := map[string]bool{}
+			for _, v := range values(w) {
+				u := byValue[v[0]]
+				if u == nil {
+					u = &Usage{Value: v[0]}
+					byValue[v[0]] = u
+				}
+				if v[1] != "" {
+					if u.Versions == nil {
+						u.Versions = map[string][]string{}
+					}
+					if !slices.Contains(u.Versions[v[1]], r.Name) {
+						u.Versions[v[1]] = append(u.Versions[v[1]], r.Name)
+					}
+				}
+				if !slices.Contains(u.Repositories, r.Name) {
+					u.Repositories = append(u.Repositories, r.Name)
+				}
+				if !seen[v[0]] {
+					seen[v[0]] = true
+					u.Workflows++
+				}
+			}
+		}
+	}
+	out := make([]*Usage, 0, len(byValue))
+	for _, u := range byValue {
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Repositories) != len(out[j].Repositories) {
+			return len(out[i].Repositories) > len(out[j].Repositories)
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
+
+// Actions returns the actions, reusable workflows and images used, with
+// their versions. Local references are left out.
+func (inv *Inventory) Actions() []*Usage {
+	return inv.tally(func(w *Workflow) [][2]string {
+		var out [][2]string
+		for _, u := range w.Uses {
+			if u.Kind != UseLocal {
+				out = append(out, [2]string{u.Name, u.Version})
+			}
+		}
+		return out
+	})
+}
+
+// Triggers returns the events workflows run on.
+func (inv *Inventory) Triggers() []*Usage {
+	return inv.tally(func(w *Workflow) [][2]string {
+		var out [][2]string
+		for _, t := range w.Triggers {
+			out = append(out, [2]string{t, ""})
+		}
+		return out
+	})
+}
+
+// Runners returns the runner labels jobs ask for.
+func (inv *Inventory) Runners() []*Usage {
+	return inv.tally(func(w *Workflow) [][2]string {
+		var out [][2]string
+		for _, j := range w.Jobs {
+			for _, l := range j.RunsOn {
+				out = append(out, [2]string{l, ""})
+			}
+		}
+		return out
+	})
+}
+
+// Permissions returns the permissions workflows and jobs grant, as
+// scope: level pairs or read-all and write-all. Workflows that set none
+// anywhere count as default.
+func (inv *Inventory) Permissions() []*Usage {
+	return inv.tally(func(w *Workflow) [][2]string {
+		var out [][2]string
+		add := func(p *Permissions) {
+			if p == nil {
+				return
+			}
+			if p.All != "" {
+				out = append(out, [2]string{p.All, ""})
+			}
+			for scope, level := range p.Scopes {
+				out = append(out, [2]string{scope + ": " + level, ""})
+			}
+			if p.All == "" && len(p.Scopes) == 0 {
+				out = append(out, [2]string{"none", ""})
+			}
+		}
+		add(w.Permissions)
+		for _, j := range w.Jobs {
+			add(j.Permissions)
+		}
+		if len(out) == 0 && w.Error == "" {
+			out = append(out, [2]string{"default", ""})
+		}
+		return out
+	})
+}
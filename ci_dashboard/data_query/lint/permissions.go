@@ -0,0 +1,46 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"testingdashboard/m/v2/permissions"
+)
+
+func init() {
+	Register(tokenPermissions{})
+}
+
+// tokenPermissions reports jobs whose token permissions are broader or
+// narrower than their steps appear to need.
+type tokenPermissions struct{}
+
+func (tokenPermissions) Name() string { return "permissions" }
+
+func (tokenPermissions) Description() string {
+	return "default, write-all, excessive or missing GITHUB_TOKEN permissions"
+}
+
+func (tokenPermissions) Check(p *Pass) {
+	for _, f := range permissions.Analyze(p.Workflow).Findings {
+		if f.Kind == permissions.KindUnknown {
+			continue
+		}
+		sev := SeverityWarning
+		if f.Severity == "info" {
+			sev = SeverityInfo
+		}
+		p.ReportAt(f.Pos, sev, "%s", f.Message)
+	}
+}
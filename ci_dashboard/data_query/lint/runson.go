@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/runson"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	Register(&RunnerLabels{})
+}
+
+// HostedLabels are the labels of GitHub-hosted runners.
+var HostedLabels = runson.HostedLabels
+
+// SelfHostedLabels are the labels every self-hosted runner is given.
+var SelfHostedLabels = runson.SelfHostedLabels
+
+// RunnerLabels checks runs-on against the labels runners are known to have.
+// Labels of an organization's own runners can be added to Extra. With a
+// Catalog, runs-on must resolve to one of its runners instead.
+type RunnerLabels struct {
+	Extra   []string
+	Catalog *runson.Catalog
+}
+
+func (*RunnerLabels) Name() string { return "runner-label" }
+
+func (*RunnerLabels) Description() string {
+	return "runs-on labels that no GitHub-hosted or configured runner has"
+}
+
+func (r *RunnerLabels) Check(p *Pass) {
+	for _, id := range p.Workflow.JobIDs() {
+		node := workflow.MappingValue(workflow.MappingValue(p.Root, "jobs"), id)
+		runsOn := workflow.MappingValue(node, "runs-on")
+		if p.Workflow.Jobs[id].Uses != "" {
+			continue
+		}
+		if runsOn == nil {
+			p.Report(keyNode(workflow.MappingValue(p.Root, "jobs"), id), SeverityError, "job %s does not set runs-on", id)
+			continue
+		}
+		if r.Catalog != nil {
+			r.checkCatalog(p, p.Workflow.Jobs[id], runsOn)
+			continue
+		}
+		if runsOn.Kind == yaml.MappingNode {
+			if labels := workflow.MappingValue(runsOn, "labels"); labels != nil {
+				r.checkLabels(p, labels)
+			}
+			continue
+		}
+		r.checkLabels(p, runsOn)
+	}
+}
+
+func (r *RunnerLabels) checkLabels(p *Pass, node *yaml.Node) {
+	var labels []*yaml.Node
+	switch node.Kind {
+	case yaml.ScalarNode:
+		labels = []*yaml.Node{node}
+	case yaml.SequenceNode:
+		labels = node.Content
+	default:
+		p.Report(node, SeverityError, "runs-on must be a label, a list of labels or a mapping")
+		return
+	}
+	selfHosted := false
+	for _, l := range labels {
+		if strings.EqualFold(l.Value, "self-hosted") {
+			selfHosted = true
+		}
+	}
+	for _, l := range labels {
+		if workflow.IsExpression(l.Value) {
+			continue
+		}
+		value := strings.ToLower(l.Value)
+		switch {
+		case slices.Contains(r.Extra, l.Value):
+		case slices.Contains(HostedLabels, value):
+			if selfHosted {
+				p.Report(l, SeverityWarning, "GitHub-hosted label %q combined with self-hosted", l.Value)
+			}
+		case slices.Contains(SelfHostedLabels, value):
+			if !selfHosted {
+				p.Report(l, SeverityWarning, "label %q only matches self-hosted runners; add self-hosted or use a GitHub-hosted label", l.Value)
+			}
+		case !selfHosted:
+			p.Report(l, SeverityWarning, "unknown runner label %q", l.Value)
+		}
+	}
+}
+
+// checkCatalog reports labels and groups the catalog does not have at the
+// node naming them, then targets, one per matrix combination, that no
+// single runner satisfies.
+func (r *RunnerLabels) checkCatalog(p *Pass, job *workflow.Job, node *yaml.Node) {
+	var labels []*yaml.Node
+	switch node.Kind {
+	case yaml.ScalarNode:
+		labels = []*yaml.Node{node}
+	case yaml.SequenceNode:
+		labels = node.Content
+	case yaml.MappingNode:
+		if g := workflow.MappingValue(node, "group"); g != nil && !strings.Contains(g.Value, "${{") && !r.Catalog.HasGroup(g.Value) {
+			p.Report(g, SeverityWarning, "no runner group %q in the runner catalog", g.Value)
+			return
+		}
+		if l := workflow.MappingValue(node, "labels"); l != nil && l.Kind == yaml.SequenceNode {
+			labels = l.Content
+		} else if l != nil {
+			labels = []*yaml.Node{l}
+		}
+	default:
+		p.Report(node, SeverityError, "runs-on must be a label, a list of labels or a mapping")
+		return
+	}
+	unknown := false
+	for _, l := range labels {
+		if strings.Contains(l.Value, "${{") || slices.Contains(r.Extra, l.Value) {
+			continue
+		}
+		if !r.Catalog.HasLabel(l.Value) {
+			p.Report(l, SeverityWarning, "no runner in the runner catalog has the label %q", l.Value)
+			unknown = true
+		}
+	}
+	if unknown || job == nil {
+		return
+	}
+	targets, err := runson.Targets(job)
+	if err != nil {
+		return
+	}
+	for _, t := range targets {
+		if slices.ContainsFunc(t.Labels, func(l string) bool { return slices.Contains(r.Extra, l) }) {
+			continue
+		}
+		if m := r.Catalog.Resolve(t); !m.OK() {
+			p.Report(node, SeverityWarning, "runs-on %s matches no runner: %s", t, m.Problem())
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/cron"
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	Register(schedule{})
+}
+
+// schedule checks the cron expressions of on.schedule.
+type schedule struct{}
+
+func (schedule) Name() string { return "schedule" }
+
+func (schedule) Description() string {
+	return "invalid cron schedules and schedules more frequent than GitHub runs them"
+}
+
+func (schedule) Check(p *Pass) {
+	entries := workflow.MappingValue(workflow.MappingValue(p.Root, "on"), "schedule")
+	if entries == nil || entries.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, entry := range entries.Content {
+		node := workflow.MappingValue(entry, "cron")
+		if node == nil || node.Kind != yaml.ScalarNode {
+			continue
+		}
+		s, err := cron.Parse(node.Value)
+		if err != nil {
+			p.Report(node, SeverityError, "%v", err)
+			continue
+		}
+		for _, w := range s.Warnings() {
+			p.Report(node, SeverityWarning, "cron expression %q %s", node.Value, w)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+func init() {
+	Register(stepKind{})
+}
+
+// stepKind checks that every step either runs a script or uses an action.
+type stepKind struct{}
+
+func (stepKind) Name() string { return "step-kind" }
+
+func (stepKind) Description() string {
+	return "steps that set both uses and run, or neither"
+}
+
+func (stepKind) Check(p *Pass) {
+	Walk(p.Root, func(path Path, node *yaml.Node) {
+		if !path.Match("jobs.*.steps.*") || node.Kind != yaml.MappingNode {
+			return
+		}
+		uses, run := keyNode(node, "uses"), keyNode(node, "run")
+		switch {
+		case uses != nil && run != nil:
+			p.Report(run, SeverityError, "%s sets both uses and run", describe(path))
+		case uses == nil && run == nil:
+			p.Report(node, SeverityError, "%s must set uses or run", describe(path))
+		case run == nil:
+			for _, key := range []string{"shell", "working-directory"} {
+				if k := keyNode(node, key); k != nil {
+					p.Report(k, SeverityWarning, "%s is ignored for steps that use an action", key)
+				}
+			}
+		case uses == nil:
+			if k := keyNode(node, "with"); k != nil {
+				p.Report(k, SeverityWarning, "with is ignored for run steps")
+			}
+		}
+		if uses != nil {
+			if v := workflow.MappingValue(node, "uses"); v != nil && !workflow.IsExpression(v.Value) {
+				if _, err := workflow.ParseUses(v.Value); err != nil {
+					p.Report(v, SeverityError, "%v", err)
+				}
+			}
+		}
+	})
+}
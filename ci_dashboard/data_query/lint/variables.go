@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/variables"
+)
+
+func init() {
+	Register(&Variables{})
+}
+
+// Variables checks that the variables expressions read from the vars
+// context are defined at some level the job can see, since undefined ones
+// are silently empty. Without Defined it reports nothing.
+type Variables struct {
+	// Defined are the variables of the repository, its organization and
+	// its environments.
+	Defined *variables.Set
+}
+
+func (*Variables) Name() string { return "undefined-variable" }
+
+func (*Variables) Description() string {
+	return "references to configuration variables no level defines for the job"
+}
+
+func (v *Variables) Check(p *Pass) {
+	if v.Defined == nil {
+		return
+	}
+	for _, e := range p.Expressions() {
+		node, err := expr.Parse(e.Source)
+		if err != nil {
+			// The expression rule reports it.
+			continue
+		}
+		expr.Walk(node, func(n expr.Node) {
+			ref := expr.Chain(n)
+			if len(ref) != 2 || !strings.EqualFold(ref[0], "vars") || ref[1] == "*" {
+				return
+			}
+			if msg := v.check(p, e.Path, ref[1]); msg != "" {
+				p.ReportAt(e.Pos(), SeverityWarning, "%s", msg)
+			}
+		})
+	}
+}
+
+// check describes why the variable name is undefined for the expression at
+// path, or returns "" if it is defined.
+func (v *Variables) check(p *Pass, path Path, name string) string {
+	var job, environment string
+	if path.HasPrefix("jobs.*") {
+		job = path[1]
+		if wj := p.Workflow.Jobs[job]; wj != nil && wj.Environment != nil {
+			environment = wj.Environment.Name
+		}
+	}
+	if _, ok := v.Defined.Lookup(name, ""); ok {
+		return ""
+	}
+	envs := v.Defined.EnvironmentsDefining(name)
+	list := strings.Join(envs, ", ")
+	switch {
+	case len(envs) == 0:
+		return fmt.Sprintf("variable %s is not defined in the repository, its organization or any environment", name)
+	case strings.Contains(environment, "${{"), containsFold(envs, environment):
+		// A computed environment is only known at run time.
+		return ""
+	case job == "":
+		return fmt.Sprintf("variable %s is only defined in environment %s, which workflow-level keys cannot see", name, list)
+	case environment == "":
+		return fmt.Sprintf("variable %s is only defined in environment %s, which job %s does not deploy to", name, list, job)
+	}
+	return fmt.Sprintf("variable %s is only defined in environment %s, not in %s that job %s deploys to", name, list, environment, job)
+}
@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Path locates a node by the mapping keys and sequence indexes leading to
+// it, such as jobs build steps 0 run.
+type Path []string
+
+func (p Path) String() string {
+	return strings.Join(p, ".")
+}
+
+// Match reports whether p matches pattern, a dotted path in which *
+// matches any single element.
+func (p Path) Match(pattern string) bool {
+	parts := strings.Split(pattern, ".")
+	if pattern == "" {
+		parts = nil
+	}
+	if len(parts) != len(p) {
+		return false
+	}
+	for i, part := range parts {
+		if part != "*" && part != p[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPrefix reports whether the leading elements of p match pattern.
+func (p Path) HasPrefix(pattern string) bool {
+	n := strings.Count(pattern, ".") + 1
+	return len(p) >= n && p[:n].Match(pattern)
+}
+
+// Walk calls fn for every node under root, parents first. Mapping keys are
+// not visited themselves; they appear as the last element of the path of
+// their value.
+func Walk(root *yaml.Node, fn func(path Path, node *yaml.Node)) {
+	var walk func(path Path, node *yaml.Node)
+	walk = func(path Path, node *yaml.Node) {
+		if node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		fn(path, node)
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(append(path[:len(path):len(path)], node.Content[i].Value), node.Content[i+1])
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				walk(append(path[:len(path):len(path)], strconv.Itoa(i)), item)
+			}
+		}
+	}
+	if root != nil {
+		walk(nil, root)
+	}
+}
+
+// keyNode returns the key node for key in mapping, or nil.
+func keyNode(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i]
+		}
+	}
+	return nil
+}
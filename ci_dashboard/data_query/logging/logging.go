@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging carries the log of a workflow run as records, one per
+// line, to sinks that print it for people or for log pipelines.
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Level is the severity of a record.
+type Level string
+
+// Levels, matching the workflow commands that log at them.
+const (
+	LevelDebug   Level = "debug"
+	LevelInfo    Level = "info"
+	LevelNotice  Level = "notice"
+	LevelWarning Level = "warning"
+	LevelError   Level = "error"
+)
+
+// Record is one line of a run's log.
+type Record struct {
+	Time  time.Time `json:"time"`
+	Level Level     `json:"level"`
+	// RunID, JobID, Job and StepID locate the line in the run; Job is the
+	// display name of the job's leg, with its matrix values. Each is empty
+	// outside of what it identifies.
+	RunID   string `json:"run_id,omitempty"`
+	JobID   string `json:"job_id,omitempty"`
+	Job     string `json:"job,omitempty"`
+	StepID  string `json:"step_id,omitempty"`
+	Step    string `json:"step,omitempty"`
+	Message string `json:"message"`
+	// Annotation is set for lines that report an error, warning or notice
+	// with ::error and the like or through a problem matcher.
+	Annotation *Annotation `json:"annotation,omitempty"`
+}
+
+// Annotation is where an annotated line points.
+type Annotation struct {
+	Title string `json:"title,omitempty"`
+	File  string `json:"file,omitempty"`
+	// Code is the rule or error code a problem matcher captured.
+	Code      string `json:"code,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	EndColumn int    `json:"end_column,omitempty"`
+}
+
+// Sink receives the records of a log in order. Calls are not concurrent.
+type Sink interface {
+	Log(r *Record) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(r *Record) error
+
+// Log implements Sink.
+func (f SinkFunc) Log(r *Record) error { return f(r) }
+
+// Formats of NewSink.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// NewSink returns a Sink writing to w in format.
+func NewSink(format string, w io.Writer) (Sink, error) {
+	switch format {
+	case FormatText, "":
+		return Text(w), nil
+	case FormatJSON:
+		return JSON(w), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q: expected text or json", format)
+}
+
+// Text returns a Sink printing records for people, each message labelled
+// with its job as in "[build (linux)] message".
+func Text(w io.Writer) Sink {
+	return SinkFunc(func(r *Record) error {
+		label := r.Job
+		if label == "" {
+			label = r.JobID
+		}
+		var err error
+		if label == "" {
+			_, err = fmt.Fprintln(w, r.Message)
+		} else {
+			_, err = fmt.Fprintf(w, "[%s] %s\n", label, r.Message)
+		}
+		return err
+	})
+}
+
+// JSON returns a Sink writing each record as a line of JSON.
+func JSON(w io.Writer) Sink {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return SinkFunc(func(r *Record) error {
+		return enc.Encode(r)
+	})
+}
+
+// Multi returns a Sink passing every record to each of sinks.
+func Multi(sinks ...Sink) Sink {
+	return SinkFunc(func(r *Record) error {
+		var errs []error
+		for _, s := range sinks {
+			if err := s.Log(r); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"testingdashboard/m/v2/client"
+)
+
+// Archive is the log archive of a run. It holds a file per job, named
+// "<n>_<job>.txt", and usually a directory per job with a file per step,
+// named "<job>/<n>_<step>.txt".
+type Archive struct {
+	Jobs []*Job
+
+	closer io.Closer
+	temp   string
+}
+
+// Job is the log of one job.
+type Job struct {
+	Name  string
+	Steps []*Step
+
+	file *zip.File
+}
+
+// Step is the log of one step.
+type Step struct {
+	Number int
+	Name   string
+
+	file *zip.File
+}
+
+// Open opens the log archive at path.
+func Open(path string) (*Archive, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log archive: %w", err)
+	}
+	a := index(&zr.Reader)
+	a.closer = zr
+	return a, nil
+}
+
+// Read reads a log archive from r.
+func Read(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log archive: %w", err)
+	}
+	return index(zr), nil
+}
+
+// Download fetches the log archive of a run. Close removes the downloaded
+// file.
+func Download(ctx context.Context, c *client.Client, owner, repo string, runID int64) (*Archive, error) {
+	resp, err := c.DownloadRunLogs(ctx, owner, repo, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	f, err := os.CreateTemp("", "logs-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to download logs of run %d: %w", runID, err)
+	}
+	a, err := Open(f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	a.temp = f.Name()
+	return a, nil
+}
+
+// Close releases the archive.
+func (a *Archive) Close() error {
+	var err error
+	if a.closer != nil {
+		err = a.closer.Close()
+	}
+	if a.temp != "" {
+		os.Remove(a.temp)
+	}
+	return err
+}
+
+// numbered splits "<n>_<name>.txt".
+func numbered(name string) (int, string, bool) {
+	name, ok := strings.CutSuffix(name, ".txt")
+	if !ok {
+		return 0, "", false
+	}
+	num, rest, ok := strings.Cut(name, "_")
+	n, err := strconv.Atoi(num)
+	if !ok || err != nil {
+		return 0, "", false
+	}
+	return n, rest, true
+}
+
+func index(zr *zip.Reader) *Archive {
+	jobs := map[string]*Job{}
+	order := map[string]int{}
+	job := func(name string) *Job {
+		j, ok := jobs[name]
+		if !ok {
+			j = &Job{Name: name}
+			jobs[name] = j
+			order[name] = len(order) + 1<<20
+		}
+		return j
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		dir, base, nested := strings.Cut(f.Name, "/")
+		if !nested {
+			if n, name, ok := numbered(dir); ok {
+				job(name).file = f
+				order[name] = n
+			}
+			continue
+		}
+		if n, name, ok := numbered(base); ok {
+			j := job(dir)
+			j.Steps = append(j.Steps, &Step{Number: n, Name: name, file: f})
+		}
+	}
+	a := &Archive{}
+	for _, j := range jobs {
+		sort.Slice(j.Steps, func(x, y int) bool { return j.Steps[x].Number < j.Steps[y].Number })
+		a.Jobs = append(a.Jobs, j)
+	}
+	sort.Slice(a.Jobs, func(x, y int) bool { return order[a.Jobs[x].Name] < order[a.Jobs[y].Name] })
+	return a
+}
+
+// Job returns the named job, or nil.
+func (a *Archive) Job(name string) *Job {
+	for _, j := range a.Jobs {
+		if j.Name == name {
+			return j
+		}
+	}
+	return nil
+}
+
+// Entries returns the entries of every job in order.
+func (a *Archive) Entries(opts Options) iter.Seq2[*Entry, error] {
+	return func(yield func(*Entry, error) bool) {
+		for _, j := range a.Jobs {
+			for e, err := range j.Entries(opts) {
+				if !yield(e, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Entries returns the entries of the job, split by step when the archive
+// has step logs and from the whole job log otherwise.
+func (j *Job) Entries(opts Options) iter.Seq2[*Entry, error] {
+	return func(yield func(*Entry, error) bool) {
+		if len(j.Steps) == 0 {
+			if j.file != nil {
+				parseFile(j.file, j.Name, "", 0, opts, yield)
+			}
+			return
+		}
+		for _, s := range j.Steps {
+			if !parseFile(s.file, j.Name, s.Name, s.Number, opts, yield) {
+				return
+			}
+		}
+	}
+}
+
+// parseFile yields the entries of f and reports whether to continue.
+func parseFile(f *zip.File, job, step string, number int, opts Options, yield func(*Entry, error) bool) bool {
+	r, err := f.Open()
+	if err != nil {
+		yield(nil, err)
+		return false
+	}
+	defer r.Close()
+	more := true
+	err = Parse(r, job, step, number, opts, func(e *Entry) bool {
+		more = yield(e, nil)
+		return more
+	})
+	if err != nil {
+		yield(nil, fmt.Errorf("failed to read %s: %w", f.Name, err))
+		return false
+	}
+	return more
+}
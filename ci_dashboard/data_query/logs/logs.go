@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logs parses workflow run logs into structured entries.
+package logs
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/commands"
+)
+
+// Levels of log lines, from the ##[level] marker the runner writes.
+const (
+	LevelDebug    = "debug"
+	LevelNotice   = "notice"
+	LevelWarning  = "warning"
+	LevelError    = "error"
+	LevelGroup    = "group"
+	LevelEndGroup = "endgroup"
+	LevelCommand  = "command"
+	LevelSection  = "section"
+)
+
+// Entry is one line of a log.
+type Entry struct {
+	Job  string `json:"job"`
+	Step string `json:"step,omitempty"`
+	// StepNumber is the 1-based number of the step, or 0 if unknown.
+	StepNumber int `json:"step_number,omitempty"`
+	// Line is the 1-based line number within the step's log, or the job's
+	// if the step is unknown.
+	Line int       `json:"line"`
+	Time time.Time `json:"time,omitzero"`
+	// Level is one of the Level constants, or empty for plain output.
+	Level string `json:"level,omitempty"`
+	// Text is the line without its ##[level] marker, and without its
+	// timestamp and ANSI escapes if requested.
+	Text string `json:"text"`
+	// Annotation is set for error, warning and notice lines.
+	Annotation *commands.Annotation `json:"annotation,omitempty"`
+}
+
+// Options controls how lines are cleaned up.
+type Options struct {
+	// KeepTimestamps leaves the leading timestamp in Text. It is parsed
+	// into Time either way.
+	KeepTimestamps bool
+	// StripANSI removes terminal escape sequences such as colors.
+	StripANSI bool
+}
+
+// MaxLineSize is the longest line Parse accepts.
+const MaxLineSize = 16 << 20
+
+var (
+	ansiRE   = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+	markerRE = regexp.MustCompile(`^##\[([A-Za-z]+)\]`)
+	// locRE matches the location suffix of annotations written by the
+	// local runner, such as " (file=a.go, line=3)".
+	locRE = regexp.MustCompile(` \(((?:title|file|line|endLine|col|endColumn)=[^)]*)\)$`)
+)
+
+// StripANSI removes terminal escape sequences from s.
+func StripANSI(s string) string {
+	if !strings.Contains(s, "\x1b") {
+		return s
+	}
+	return ansiRE.ReplaceAllString(s, "")
+}
+
+// Parse reads the log of a step, or of a whole job if step is empty, and
+// calls fn for each line. Parsing stops early if fn returns false.
+func Parse(r io.Reader, job, step string, stepNumber int, opts Options, fn func(*Entry) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), MaxLineSize)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		e := ParseLine(line, opts)
+		e.Job, e.Step, e.StepNumber, e.Line = job, step, stepNumber, n
+		if !fn(e) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+// ParseLine parses one log line. Job, Step and Line are left unset.
+func ParseLine(line string, opts Options) *Entry {
+	e := &Entry{}
+	rest, stamp := line, ""
+	if ts, after, ok := strings.Cut(line, " "); ok && len(ts) >= 20 && ts[4] == '-' {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			e.Time, rest, stamp = t, after, ts+" "
+		}
+	}
+	if opts.StripANSI {
+		rest = StripANSI(rest)
+	}
+	// Markers may follow color codes even when they are kept.
+	if m := markerRE.FindStringSubmatch(StripANSI(rest)); m != nil {
+		e.Level = strings.ToLower(m[1])
+		rest = rest[strings.Index(rest, m[0])+len(m[0]):]
+	} else if cmd, ok := commands.Parse(StripANSI(rest)); ok {
+		// Commands that were echoed rather than processed.
+		switch cmd.Name {
+		case LevelDebug, LevelNotice, LevelWarning, LevelError, LevelGroup, LevelEndGroup:
+			e.Level = cmd.Name
+			e.Annotation = annotation(cmd.Name, cmd.Props, cmd.Message)
+			rest = cmd.Message
+		}
+	}
+	switch e.Level {
+	case LevelError, LevelWarning, LevelNotice:
+		if e.Annotation == nil {
+			msg, props := rest, map[string]string{}
+			if m := locRE.FindStringSubmatchIndex(rest); m != nil {
+				msg = rest[:m[0]]
+				for _, kv := range strings.Split(rest[m[2]:m[3]], ", ") {
+					if k, v, ok := strings.Cut(kv, "="); ok {
+						props[k] = v
+					}
+				}
+			}
+			e.Annotation = annotation(e.Level, props, msg)
+		}
+	case LevelDebug, LevelGroup, LevelEndGroup, LevelCommand, LevelSection:
+	default:
+		e.Level = ""
+	}
+	e.Text = rest
+	if opts.KeepTimestamps {
+		e.Text = stamp + rest
+	}
+	return e
+}
+
+func annotation(level string, props map[string]string, msg string) *commands.Annotation {
+	switch level {
+	case LevelError, LevelWarning, LevelNotice:
+	default:
+		return nil
+	}
+	a := &commands.Annotation{Level: level, Message: msg, Title: props["title"], File: props["file"]}
+	a.Line, _ = strconv.Atoi(props["line"])
+	a.EndLine, _ = strconv.Atoi(props["endLine"])
+	a.Column, _ = strconv.Atoi(props["col"])
+	a.EndColumn, _ = strconv.Atoi(props["endColumn"])
+	return a
+}
@@ -0,0 +1,414 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/contexts"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/lint"
+	"testingdashboard/m/v2/workflow"
+)
+
+// complete returns the completions at pos.
+func (s *Server) complete(ctx context.Context, d *document, pos Position) []CompletionItem {
+	loc := d.at(pos)
+	if e, ok := loc.expression(); ok {
+		return s.completeExpression(ctx, d, loc, e)
+	}
+	if loc.inKey {
+		return s.completeKey(ctx, d, loc)
+	}
+	return completeValue(d, loc)
+}
+
+// expression returns the text of the expression before the cursor if the
+// cursor is in one: inside ${{ or an if condition.
+func (l *location) expression() (string, bool) {
+	if l.inKey {
+		return "", false
+	}
+	if i := strings.LastIndex(l.prefix, "${{"); i >= 0 {
+		if !strings.Contains(l.prefix[i:], "}}") {
+			return l.prefix[i+3:], true
+		}
+		return "", false
+	}
+	p := l.path()
+	if p.Match("jobs.*.if") || p.Match("jobs.*.steps.*.if") {
+		return l.prefix, true
+	}
+	return "", false
+}
+
+// chainRE matches the property chain being typed, such as steps.build.ou.
+var chainRE = regexp.MustCompile(`[A-Za-z_][\w.\-*]*$`)
+
+func (s *Server) completeExpression(ctx context.Context, d *document, loc *location, e string) []CompletionItem {
+	parts := strings.Split(chainRE.FindString(e), ".")
+	avail := contexts.At(loc.path()...)
+	var items []CompletionItem
+	if len(parts) == 1 {
+		for _, name := range avail.Contexts {
+			items = append(items, CompletionItem{Label: name, Kind: kindVariable, Documentation: markdown(contextDocs[name])})
+		}
+		names := make([]string, 0, len(expr.Functions))
+		for lower, fn := range expr.Functions {
+			if avail.AllowsFunction(fn.Name) {
+				names = append(names, lower)
+			}
+		}
+		sort.Strings(names)
+		for _, lower := range names {
+			fn := expr.Functions[lower]
+			items = append(items, CompletionItem{Label: fn.Name, Kind: kindFunction, InsertText: fn.Name + "(", Documentation: markdown(functionDocs[lower])})
+		}
+		return items
+	}
+	name := strings.ToLower(parts[0])
+	if !avail.Allows(name) {
+		return nil
+	}
+	sc := d.scope(loc.path())
+	for _, m := range s.members(ctx, d, sc, name, parts[1:len(parts)-1]) {
+		items = append(items, CompletionItem{Label: m.name, Kind: kindProperty, Detail: m.detail, Documentation: markdown(m.doc)})
+	}
+	return items
+}
+
+// member is a property of a context.
+type member struct {
+	name, detail, doc string
+}
+
+// scope is the job and step a path is in.
+type scope struct {
+	wf  *workflow.Workflow
+	job *workflow.Job
+	// step is the index of the step, -1 outside steps.
+	step int
+}
+
+func (d *document) scope(path lint.Path) scope {
+	sc := scope{wf: d.wf, step: -1}
+	if d.wf == nil || !path.HasPrefix("jobs.*") {
+		return sc
+	}
+	sc.job = d.wf.Jobs[path[1]]
+	if path.HasPrefix("jobs.*.steps.*") {
+		sc.step, _ = strconv.Atoi(path[3])
+	}
+	return sc
+}
+
+// stepByID returns the step of the scope's job with id.
+func (sc scope) stepByID(id string) *workflow.Step {
+	if sc.job == nil {
+		return nil
+	}
+	for _, step := range sc.job.Steps {
+		if strings.EqualFold(step.ID, id) {
+			return step
+		}
+	}
+	return nil
+}
+
+// members returns the properties of ctx followed by the properties in
+// chain, such as the outputs of a step for steps build outputs.
+func (s *Server) members(c context.Context, d *document, sc scope, ctx string, chain []string) []member {
+	var out []member
+	add := func(names ...string) {
+		for _, n := range names {
+			out = append(out, member{name: n})
+		}
+	}
+	wf := sc.wf
+	if wf == nil {
+		return nil
+	}
+	switch len(chain) {
+	case 0:
+		if props := contexts.Properties(ctx); props != nil {
+			add(props...)
+			return out
+		}
+		switch ctx {
+		case "env":
+			envs := []map[string]string{wf.Env}
+			if sc.job != nil {
+				envs = append(envs, sc.job.Env)
+				if sc.step >= 0 && sc.step < len(sc.job.Steps) {
+					envs = append(envs, sc.job.Steps[sc.step].Env)
+				}
+			}
+			for _, env := range envs {
+				add(sortedKeys(env)...)
+			}
+		case "steps":
+			if sc.job != nil {
+				for i, step := range sc.job.Steps {
+					if step.ID != "" && (sc.step < 0 || i < sc.step) {
+						out = append(out, member{name: step.ID, detail: step.Name, doc: stepDoc(step)})
+					}
+				}
+			}
+		case "needs":
+			if sc.job != nil {
+				for _, id := range sc.job.Needs {
+					out = append(out, jobMember(wf, id))
+				}
+			}
+		case "jobs":
+			for _, id := range wf.JobIDs() {
+				out = append(out, jobMember(wf, id))
+			}
+		case "matrix":
+			if sc.job != nil && sc.job.Strategy != nil && sc.job.Strategy.Matrix != nil {
+				m := sc.job.Strategy.Matrix
+				for _, dim := range m.Dimensions {
+					add(dim.Name)
+				}
+				for _, inc := range m.Include {
+					for _, k := range sortedKeys(inc) {
+						if !slices.ContainsFunc(out, func(m member) bool { return m.name == k }) {
+							add(k)
+						}
+					}
+				}
+			}
+		case "inputs":
+			for _, event := range []string{"workflow_dispatch", "workflow_call"} {
+				if ev := wf.On.Event(event); ev != nil {
+					for _, name := range sortedKeys(ev.Inputs) {
+						in := ev.Inputs[name]
+						out = append(out, member{name: name, detail: in.Type, doc: in.Description})
+					}
+				}
+			}
+		case "secrets":
+			add("GITHUB_TOKEN")
+			if ev := wf.On.Event("workflow_call"); ev != nil {
+				for _, name := range sortedKeys(ev.Secrets) {
+					out = append(out, member{name: name, doc: ev.Secrets[name].Description})
+				}
+			}
+		}
+	case 1:
+		switch ctx {
+		case "steps":
+			add("outputs", "outcome", "conclusion")
+		case "needs", "jobs":
+			add("outputs", "result")
+		}
+	case 2:
+		if !strings.EqualFold(chain[1], "outputs") {
+			return nil
+		}
+		switch ctx {
+		case "steps":
+			step := sc.stepByID(chain[0])
+			if step == nil || step.Uses == "" {
+				return nil
+			}
+			if a, err := s.action(c, d, step.Uses); err == nil {
+				for _, name := range sortedKeys(a.Outputs) {
+					out = append(out, member{name: name, doc: a.Outputs[name].Description})
+				}
+			}
+		case "needs", "jobs":
+			if job := wf.Jobs[chain[0]]; job != nil {
+				add(sortedKeys(job.Outputs)...)
+			}
+		}
+	}
+	return out
+}
+
+func jobMember(wf *workflow.Workflow, id string) member {
+	m := member{name: id}
+	if job := wf.Jobs[id]; job != nil {
+		m.detail = job.Name
+		if len(job.Outputs) > 0 {
+			m.doc = "Outputs: " + strings.Join(sortedKeys(job.Outputs), ", ")
+		}
+	}
+	return m
+}
+
+func stepDoc(step *workflow.Step) string {
+	if step.Uses != "" {
+		return "Uses `" + step.Uses + "`"
+	}
+	return ""
+}
+
+func (s *Server) completeKey(ctx context.Context, d *document, loc *location) []CompletionItem {
+	var items []CompletionItem
+	existing := map[string]bool{}
+	if d.wf != nil {
+		if n := nodeAt(d.wf.Node, loc.parent); n != nil && n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				existing[n.Content[i].Value] = n.Content[i].Value != loc.key
+			}
+		}
+	}
+	add := func(name, detail, doc string) {
+		if !existing[name] {
+			items = append(items, CompletionItem{Label: name, Kind: kindField, Detail: detail, InsertText: name + ": ", Documentation: markdown(doc)})
+		}
+	}
+	sc := d.scope(loc.parent)
+	switch {
+	case loc.parent.Match("jobs.*.steps.*.with"):
+		if sc.job == nil || sc.step >= len(sc.job.Steps) || sc.job.Steps[sc.step].Uses == "" {
+			return nil
+		}
+		a, err := s.action(ctx, d, sc.job.Steps[sc.step].Uses)
+		if err != nil {
+			return nil
+		}
+		for _, name := range sortedKeys(a.Inputs) {
+			in := a.Inputs[name]
+			add(name, inputDetail(in.Required, in.Default), in.Description)
+		}
+	case loc.parent.Match("jobs.*.with"), loc.parent.Match("jobs.*.secrets"):
+		if sc.job == nil || sc.job.Uses == "" {
+			return nil
+		}
+		called, _, err := s.reusable(ctx, d, sc.job.Uses)
+		ev := (*workflow.Event)(nil)
+		if err == nil {
+			ev = called.On.Event("workflow_call")
+		}
+		if ev == nil {
+			return nil
+		}
+		if loc.parent[2] == "with" {
+			for _, name := range sortedKeys(ev.Inputs) {
+				in := ev.Inputs[name]
+				add(name, inputDetail(in.Required, fmt.Sprint(in.Default)), in.Description)
+			}
+		} else {
+			for _, name := range sortedKeys(ev.Secrets) {
+				add(name, inputDetail(ev.Secrets[name].Required, ""), ev.Secrets[name].Description)
+			}
+		}
+	default:
+		for _, key := range lint.Keys(loc.parent) {
+			add(key, "", keyDoc(append(loc.parent[:len(loc.parent):len(loc.parent)], key)))
+		}
+	}
+	return items
+}
+
+func inputDetail(required bool, def string) string {
+	switch {
+	case required:
+		return "required"
+	case def != "" && def != "<nil>":
+		return "default " + def
+	}
+	return ""
+}
+
+var (
+	shells     = []string{"bash", "pwsh", "python", "sh", "cmd", "powershell"}
+	inputTypes = []string{"boolean", "choice", "environment", "number", "string"}
+	booleans   = []string{"true", "false"}
+)
+
+// completeValue completes the value of a key or a sequence item.
+func completeValue(d *document, loc *location) []CompletionItem {
+	path := loc.path()
+	var values []string
+	switch {
+	case path.Match("on"), path.Match("on.*") && isIndex(path[1]):
+		values = lint.Events
+	case path.Match("jobs.*.runs-on"), path.Match("jobs.*.runs-on.*"), path.Match("jobs.*.runs-on.labels"):
+		values = append(slices.Clone(lint.HostedLabels), lint.SelfHostedLabels...)
+	case path.Match("jobs.*.needs"), path.Match("jobs.*.needs.*"):
+		if d.wf != nil {
+			for _, id := range d.wf.JobIDs() {
+				if id != path[1] {
+					values = append(values, id)
+				}
+			}
+		}
+	case path.Match("permissions"), path.Match("jobs.*.permissions"):
+		values = []string{"read-all", "write-all"}
+	case path.Match("permissions.*"), path.Match("jobs.*.permissions.*"):
+		values = []string{"read", "write", "none"}
+	case path.Match("jobs.*.secrets"):
+		values = []string{"inherit"}
+	case path.Match("on.*.inputs.*.type"):
+		values = inputTypes
+	case loc.key == "shell":
+		values = shells
+	case loc.key == "continue-on-error", loc.key == "cancel-in-progress", loc.key == "fail-fast", loc.key == "required":
+		values = booleans
+	}
+	items := make([]CompletionItem, len(values))
+	for i, v := range values {
+		items[i] = CompletionItem{Label: v, Kind: kindValue}
+	}
+	return items
+}
+
+// nodeAt returns the node at path under root.
+func nodeAt(root *yaml.Node, path lint.Path) *yaml.Node {
+	n := root
+	for _, p := range path {
+		if n == nil {
+			return nil
+		}
+		switch n.Kind {
+		case yaml.MappingNode:
+			n = workflow.MappingValue(n, p)
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(p)
+			if err != nil || i >= len(n.Content) {
+				return nil
+			}
+			n = n.Content[i]
+		default:
+			return nil
+		}
+	}
+	return n
+}
+
+func isIndex(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"context"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// definition returns where what is at pos is defined: the file of an
+// action or reusable workflow, or the job, step or input an expression or
+// needs refers to.
+func (s *Server) definition(ctx context.Context, d *document, pos Position) *Location {
+	loc := d.at(pos)
+	path := loc.path()
+	sc := d.scope(path)
+	switch {
+	case loc.inKey:
+		return nil
+	case loc.key == "uses":
+		uses := usesValue(d.lines[pos.Line])
+		if path.Match("jobs.*.uses") {
+			if _, file, err := s.reusable(ctx, d, uses); err == nil {
+				return &Location{URI: pathURI(file)}
+			}
+			return nil
+		}
+		if a, err := s.action(ctx, d, uses); err == nil {
+			return &Location{URI: pathURI(a.Path)}
+		}
+		return nil
+	case path.Match("jobs.*.needs"), path.Match("jobs.*.needs.*"):
+		word, _ := d.word(pos)
+		return d.jobLocation(word)
+	}
+	if _, ok := loc.expression(); !ok || sc.wf == nil {
+		return nil
+	}
+	word, _ := d.word(pos)
+	parts := strings.Split(word, ".")
+	if len(parts) < 2 {
+		return nil
+	}
+	switch strings.ToLower(parts[0]) {
+	case "steps":
+		if step := sc.stepByID(parts[1]); step != nil {
+			return d.location(step.Pos)
+		}
+	case "needs", "jobs":
+		return d.jobLocation(parts[1])
+	case "inputs":
+		for _, event := range []string{"workflow_dispatch", "workflow_call"} {
+			inputs := nodeAt(sc.wf.Node, []string{"on", event, "inputs"})
+			if key := keyOf(inputs, parts[1]); key != nil {
+				return d.location(workflow.Position{Line: key.Line, Column: key.Column})
+			}
+		}
+	}
+	return nil
+}
+
+// jobLocation returns where the job id is defined in d.
+func (d *document) jobLocation(id string) *Location {
+	if d.wf == nil || d.wf.Jobs[id] == nil {
+		return nil
+	}
+	return d.location(d.wf.Jobs[id].Pos)
+}
+
+// location returns the location of pos in d.
+func (d *document) location(pos workflow.Position) *Location {
+	r := d.findingRange(pos)
+	return &Location{URI: d.uri, Range: r}
+}
+
+// keyOf returns the key node of key in mapping, or nil.
+func keyOf(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i]
+		}
+	}
+	return nil
+}
+
+// usesValue returns the value of the uses: key on line.
+func usesValue(line string) string {
+	l, _ := parseLine(line)
+	v := l.value
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.Trim(strings.TrimSpace(v), `"'`)
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import "testingdashboard/m/v2/lint"
+
+// keyDocs documents workflow keys by path pattern, most specific first.
+var keyDocs = []struct {
+	pattern, doc string
+}{
+	{"name", "The name of the workflow, shown in the Actions tab."},
+	{"run-name", "The name of runs of the workflow. It may use the `github`, `inputs` and `vars` contexts."},
+	{"on", "The events that trigger the workflow: an event name, a list of them or a mapping of events to their filters."},
+	{"on.*.types", "The activity types of the event that trigger the workflow."},
+	{"on.*.branches", "Run only for these branches. Patterns may use `*`, `**` and `!` to exclude."},
+	{"on.*.branches-ignore", "Do not run for these branches."},
+	{"on.*.tags", "Run only for these tags."},
+	{"on.*.tags-ignore", "Do not run for these tags."},
+	{"on.*.paths", "Run only if a changed file matches one of these patterns."},
+	{"on.*.paths-ignore", "Do not run if every changed file matches one of these patterns."},
+	{"on.*.inputs", "The inputs of a manually dispatched or called workflow, available as `inputs.<name>`."},
+	{"on.*.inputs.*.type", "The type of the input: `boolean`, `number`, `string`, or for `workflow_dispatch` also `choice` and `environment`."},
+	{"on.*.inputs.*.required", "Whether the input must be given."},
+	{"on.*.inputs.*.default", "The value of the input when it is not given."},
+	{"on.workflow_call.outputs", "The outputs of a called workflow, mapped from job outputs."},
+	{"on.workflow_call.secrets", "The secrets a called workflow accepts."},
+	{"on.schedule", "Cron schedules in UTC that trigger the workflow on the default branch."},
+	{"on.workflow_run.workflows", "The workflows whose runs trigger this one."},
+	{"permissions", "The permissions of the `GITHUB_TOKEN`: `read-all`, `write-all`, or a mapping of scopes to `read`, `write` or `none`."},
+	{"env", "Environment variables for every step of every job."},
+	{"defaults", "Defaults for the `run` steps of every job."},
+	{"defaults.run.shell", "The shell `run` steps use by default."},
+	{"defaults.run.working-directory", "The directory `run` steps run in by default."},
+	{"concurrency", "Runs in the same concurrency group wait for each other; `cancel-in-progress` cancels the running one instead."},
+	{"jobs", "The jobs of the workflow, by ID. Jobs run in parallel unless they list others in `needs`."},
+	{"jobs.*", "A job, run on a fresh runner."},
+	{"jobs.*.name", "The name of the job, shown on GitHub."},
+	{"jobs.*.needs", "Jobs that must succeed before this one runs. Their outputs are available as `needs.<job>.outputs`."},
+	{"jobs.*.if", "Run the job only if the condition holds. It is an expression even without `${{ }}`."},
+	{"jobs.*.runs-on", "The runner the job runs on: a label, a list of labels all of which the runner must have, or a group."},
+	{"jobs.*.permissions", "The permissions of the `GITHUB_TOKEN` for this job, replacing those of the workflow."},
+	{"jobs.*.environment", "The deployment environment of the job, whose protection rules must pass before it runs."},
+	{"jobs.*.concurrency", "The concurrency group of the job."},
+	{"jobs.*.outputs", "Outputs of the job, usually from step outputs, for jobs that need it."},
+	{"jobs.*.env", "Environment variables for every step of the job."},
+	{"jobs.*.defaults", "Defaults for the `run` steps of the job."},
+	{"jobs.*.timeout-minutes", "How long the job may run before it is cancelled. Defaults to 360."},
+	{"jobs.*.continue-on-error", "Let the workflow succeed even if this job fails."},
+	{"jobs.*.strategy", "Run the job once for each combination of the matrix."},
+	{"jobs.*.strategy.matrix", "Keys with lists of values; the job runs for each combination, adjusted by `include` and `exclude`."},
+	{"jobs.*.strategy.fail-fast", "Cancel the other legs of the matrix when one fails. Defaults to true."},
+	{"jobs.*.strategy.max-parallel", "How many legs of the matrix may run at once."},
+	{"jobs.*.container", "A container the job's steps run in."},
+	{"jobs.*.services", "Service containers the job's steps can reach, by name."},
+	{"jobs.*.steps", "The steps of the job, run in order."},
+	{"jobs.*.uses", "The reusable workflow the job calls: `owner/repo/.github/workflows/file.yml@ref` or `./.github/workflows/file.yml`."},
+	{"jobs.*.with", "The inputs of the called workflow."},
+	{"jobs.*.secrets", "The secrets passed to the called workflow, or `inherit` to pass all of them."},
+	{"jobs.*.steps.*.id", "The ID of the step, by which later steps refer to it as `steps.<id>`."},
+	{"jobs.*.steps.*.if", "Run the step only if the condition holds. It is an expression even without `${{ }}`."},
+	{"jobs.*.steps.*.name", "The name of the step, shown in the log."},
+	{"jobs.*.steps.*.uses", "The action the step runs: `owner/repo[/path]@ref`, `./path` or `docker://image`."},
+	{"jobs.*.steps.*.run", "The commands the step runs in the shell."},
+	{"jobs.*.steps.*.shell", "The shell of the `run` commands: `bash`, `pwsh`, `python`, `sh`, `cmd`, `powershell` or a command with `{0}`."},
+	{"jobs.*.steps.*.working-directory", "The directory the `run` commands run in."},
+	{"jobs.*.steps.*.with", "The inputs of the action."},
+	{"jobs.*.steps.*.env", "Environment variables for the step."},
+	{"jobs.*.steps.*.continue-on-error", "Let the job go on if the step fails."},
+	{"jobs.*.steps.*.timeout-minutes", "How long the step may run before it is cancelled."},
+}
+
+// keyDoc returns the documentation of the key at path.
+func keyDoc(path lint.Path) string {
+	for _, d := range keyDocs {
+		if path.Match(d.pattern) {
+			return d.doc
+		}
+	}
+	return ""
+}
+
+// contextDocs documents the expression contexts.
+var contextDocs = map[string]string{
+	"github":   "Information about the run and the event that triggered it.",
+	"env":      "The environment variables set by the workflow, the job and the step.",
+	"vars":     "The configuration variables of the organization, repository and environment.",
+	"job":      "Information about the running job: its container, services and status.",
+	"jobs":     "The results and outputs of the jobs of a called workflow, for its outputs.",
+	"steps":    "The outcome, conclusion and outputs of the steps of the job that have run, by ID.",
+	"runner":   "Information about the runner: its name, OS, architecture and directories.",
+	"secrets":  "The secrets available to the run, and `GITHUB_TOKEN`.",
+	"strategy": "The matrix strategy of the job: `fail-fast`, `job-index`, `job-total` and `max-parallel`.",
+	"matrix":   "The values of the matrix for the running leg of the job.",
+	"needs":    "The results and outputs of the jobs the job needs.",
+	"inputs":   "The inputs of a dispatched or called workflow, or of an action.",
+}
+
+// functionDocs documents the expression functions by lower-case name.
+var functionDocs = map[string]string{
+	"contains":   "`contains(search, item)` reports whether the string or array `search` contains `item`, ignoring case.",
+	"startswith": "`startsWith(s, prefix)` reports whether `s` starts with `prefix`, ignoring case.",
+	"endswith":   "`endsWith(s, suffix)` reports whether `s` ends with `suffix`, ignoring case.",
+	"format":     "`format(s, v0, v1, ...)` replaces `{0}`, `{1}`, ... in `s` with the values.",
+	"join":       "`join(array, sep)` joins the elements of `array` with `sep`, a comma by default.",
+	"tojson":     "`toJSON(v)` returns `v` as pretty-printed JSON.",
+	"fromjson":   "`fromJSON(s)` parses the JSON `s`.",
+	"hashfiles":  "`hashFiles(pattern, ...)` returns the SHA-256 of the workspace files matching the patterns.",
+	"success":    "`success()` holds if no earlier step or needed job failed or was cancelled. It is the default condition.",
+	"failure":    "`failure()` holds if an earlier step or needed job failed.",
+	"always":     "`always()` always holds, even when the run is cancelled.",
+	"cancelled":  "`cancelled()` holds if the run was cancelled.",
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/workflow"
+)
+
+// hover returns the documentation of what is at pos, in Markdown.
+func (s *Server) hover(ctx context.Context, d *document, pos Position) string {
+	loc := d.at(pos)
+	path := loc.path()
+	sc := d.scope(path)
+	if loc.inKey {
+		if loc.key == "" {
+			return ""
+		}
+		switch {
+		case path.Match("jobs.*.steps.*.with.*"):
+			if sc.job == nil || sc.step >= len(sc.job.Steps) {
+				return ""
+			}
+			a, err := s.action(ctx, d, sc.job.Steps[sc.step].Uses)
+			if err != nil || a.Inputs[loc.key] == nil {
+				return ""
+			}
+			in := a.Inputs[loc.key]
+			return inputHover(loc.key, in.Description, in.Required, in.Default, in.DeprecationMessage)
+		case path.Match("jobs.*.with.*"):
+			if ev := s.calledEvent(ctx, d, sc.job); ev != nil && ev.Inputs[loc.key] != nil {
+				in := ev.Inputs[loc.key]
+				def := ""
+				if in.Default != nil {
+					def = fmt.Sprint(in.Default)
+				}
+				return inputHover(loc.key, in.Description, in.Required, def, in.DeprecationMessage)
+			}
+			return ""
+		}
+		return keyDoc(path)
+	}
+
+	if _, ok := loc.expression(); ok {
+		word, call := d.word(pos)
+		return s.hoverExpression(ctx, d, sc, word, call)
+	}
+	if loc.key == "uses" {
+		uses := usesValue(d.lines[pos.Line])
+		if path.Match("jobs.*.uses") {
+			called, _, err := s.reusable(ctx, d, uses)
+			if err != nil {
+				return fmt.Sprintf("Cannot resolve `%s`: %v", uses, err)
+			}
+			return workflowHover(called)
+		}
+		a, err := s.action(ctx, d, uses)
+		if err != nil {
+			return fmt.Sprintf("Cannot resolve `%s`: %v", uses, err)
+		}
+		return actionHover(a)
+	}
+	return ""
+}
+
+// hoverExpression documents word, the context, property or function under
+// the cursor in an expression.
+func (s *Server) hoverExpression(ctx context.Context, d *document, sc scope, word string, call bool) string {
+	if call {
+		return functionDocs[strings.ToLower(word)]
+	}
+	parts := strings.Split(word, ".")
+	name := strings.ToLower(parts[0])
+	if len(parts) == 1 {
+		return contextDocs[name]
+	}
+	if sc.wf == nil {
+		return ""
+	}
+	switch name {
+	case "steps":
+		if step := sc.stepByID(parts[1]); step != nil {
+			doc := fmt.Sprintf("Step `%s`", step.ID)
+			if step.Name != "" {
+				doc += ": " + step.Name
+			}
+			if step.Uses != "" {
+				doc += "\n\nUses `" + step.Uses + "`"
+				if a, err := s.action(ctx, d, step.Uses); err == nil && len(parts) == 4 && a.Outputs[parts[3]] != nil {
+					doc += "\n\n`" + parts[3] + "`: " + a.Outputs[parts[3]].Description
+				}
+			}
+			return doc
+		}
+	case "needs", "jobs":
+		if job := sc.wf.Jobs[parts[1]]; job != nil {
+			doc := fmt.Sprintf("Job `%s`", job.ID)
+			if job.Name != "" {
+				doc += ": " + job.Name
+			}
+			if len(job.Outputs) > 0 {
+				doc += "\n\nOutputs: " + strings.Join(sortedKeys(job.Outputs), ", ")
+			}
+			return doc
+		}
+	case "inputs":
+		for _, event := range []string{"workflow_dispatch", "workflow_call"} {
+			if ev := sc.wf.On.Event(event); ev != nil && ev.Inputs[parts[1]] != nil {
+				in := ev.Inputs[parts[1]]
+				def := ""
+				if in.Default != nil {
+					def = fmt.Sprint(in.Default)
+				}
+				return inputHover(parts[1], in.Description, in.Required, def, in.DeprecationMessage)
+			}
+		}
+	}
+	return contextDocs[name]
+}
+
+// word returns the property chain under pos and whether it is called as a
+// function.
+func (d *document) word(pos Position) (string, bool) {
+	if pos.Line >= len(d.lines) {
+		return "", false
+	}
+	line := d.lines[pos.Line]
+	col := byteOffset(line, pos.Character)
+	start, end := col, col
+	for start > 0 && isWordByte(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isWordByte(line[end]) {
+		end++
+	}
+	rest := strings.TrimLeft(line[end:], " ")
+	return line[start:end], strings.HasPrefix(rest, "(")
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '-' || b == '.' || b == '*' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// calledEvent returns the workflow_call trigger of the workflow job calls.
+func (s *Server) calledEvent(ctx context.Context, d *document, job *workflow.Job) *workflow.Event {
+	if job == nil || job.Uses == "" {
+		return nil
+	}
+	called, _, err := s.reusable(ctx, d, job.Uses)
+	if err != nil {
+		return nil
+	}
+	return called.On.Event("workflow_call")
+}
+
+func inputHover(name, description string, required bool, def, deprecation string) string {
+	doc := "Input `" + name + "`"
+	if required {
+		doc += " (required)"
+	}
+	if description != "" {
+		doc += "\n\n" + description
+	}
+	if def != "" {
+		doc += "\n\nDefault: `" + def + "`"
+	}
+	if deprecation != "" {
+		doc += "\n\nDeprecated: " + deprecation
+	}
+	return doc
+}
+
+func actionHover(a *metadata.Action) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**", a.Name)
+	if a.Description != "" {
+		fmt.Fprintf(&b, "\n\n%s", a.Description)
+	}
+	if len(a.Inputs) > 0 {
+		b.WriteString("\n\nInputs:\n")
+		for _, name := range sortedKeys(a.Inputs) {
+			in := a.Inputs[name]
+			fmt.Fprintf(&b, "\n- `%s`", name)
+			if in.Required {
+				b.WriteString(" (required)")
+			}
+			if in.Description != "" {
+				fmt.Fprintf(&b, ": %s", firstLine(in.Description))
+			}
+		}
+	}
+	if len(a.Outputs) > 0 {
+		b.WriteString("\n\nOutputs:\n")
+		for _, name := range sortedKeys(a.Outputs) {
+			fmt.Fprintf(&b, "\n- `%s`", name)
+			if desc := a.Outputs[name].Description; desc != "" {
+				fmt.Fprintf(&b, ": %s", firstLine(desc))
+			}
+		}
+	}
+	return b.String()
+}
+
+func workflowHover(wf *workflow.Workflow) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**", wf.Name)
+	ev := wf.On.Event("workflow_call")
+	if ev == nil {
+		b.WriteString("\n\nThe workflow is not triggered by workflow_call.")
+		return b.String()
+	}
+	if len(ev.Inputs) > 0 {
+		b.WriteString("\n\nInputs:\n")
+		for _, name := range sortedKeys(ev.Inputs) {
+			in := ev.Inputs[name]
+			fmt.Fprintf(&b, "\n- `%s` %s", name, in.Type)
+			if in.Required {
+				b.WriteString(" (required)")
+			}
+			if in.Description != "" {
+				fmt.Fprintf(&b, ": %s", firstLine(in.Description))
+			}
+		}
+	}
+	if len(ev.Secrets) > 0 {
+		b.WriteString("\n\nSecrets: `" + strings.Join(sortedKeys(ev.Secrets), "`, `") + "`")
+	}
+	if len(ev.Outputs) > 0 {
+		b.WriteString("\n\nOutputs: `" + strings.Join(sortedKeys(ev.Outputs), "`, `") + "`")
+	}
+	return b.String()
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return s
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lsp
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"testingdashboard/m/v2/lint"
+)
+
+// location is where the cursor is in a workflow. It is found from the
+// indentation of the lines above, so that it works on documents that do
+// not parse while they are being typed.
+type location struct {
+	// parent is the path of the mapping the cursor's line belongs to.
+	parent lint.Path
+	// key is the key of the line, which is empty where a key is being
+	// typed; inKey reports whether the cursor is on the key rather than in
+	// its value.
+	key   string
+	inKey bool
+	// prefix is the text of the key or value before the cursor, and line
+	// the text of the line before the cursor.
+	prefix string
+	line   string
+}
+
+// path returns the path of the key or value at the cursor.
+func (l *location) path() lint.Path {
+	if l.key == "" {
+		return l.parent
+	}
+	return append(l.parent[:len(l.parent):len(l.parent)], l.key)
+}
+
+// yamlLine is a line of a workflow split into its parts.
+type yamlLine struct {
+	// dash is the column of the - of a sequence item, or -1.
+	dash int
+	// col is the column of the line's content after any dash.
+	col   int
+	key   string
+	value string
+	// hasKey reports whether the content is key: value.
+	hasKey bool
+}
+
+var keyRE = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#'"{\[][^#]*?)\s*:(\s|$)`)
+
+func parseLine(s string) (yamlLine, bool) {
+	content := strings.TrimLeft(s, " ")
+	if content == "" || strings.HasPrefix(content, "#") {
+		return yamlLine{}, false
+	}
+	l := yamlLine{dash: -1, col: len(s) - len(content)}
+	if content == "-" || strings.HasPrefix(content, "- ") {
+		l.dash = l.col
+		rest := strings.TrimLeft(content[1:], " ")
+		l.col += len(content) - len(rest)
+		content = rest
+	}
+	if m := keyRE.FindStringSubmatchIndex(content); m != nil {
+		l.key, l.hasKey = strings.Trim(content[m[2]:m[3]], `"'`), true
+		l.value = strings.TrimSpace(content[m[1]:])
+	}
+	return l, true
+}
+
+// blockRE matches the indicator of a block scalar.
+var blockRE = regexp.MustCompile(`^[|>][-+0-9]*\s*(#.*)?$`)
+
+// locate finds the cursor at the byte offset col of line.
+func locate(lines []string, line, col int) *location {
+	text := ""
+	if line < len(lines) {
+		text = lines[line]
+	}
+	col = min(col, len(text))
+	loc := &location{line: text[:col]}
+	l, ok := parseLine(text)
+	switch {
+	case ok && l.hasKey && col >= l.col:
+		keyEnd := strings.Index(text[l.col:], ":") + l.col
+		loc.key = l.key
+		loc.inKey = col <= keyEnd
+		loc.parent = parents(lines, line, l.col, l.dash)
+		if loc.inKey {
+			loc.prefix = text[l.col:col]
+		} else {
+			loc.prefix = strings.TrimLeft(text[keyEnd+1:col], " ")
+		}
+	case !ok || strings.TrimSpace(text[:col]) == "" || (l.dash >= 0 && strings.TrimSpace(text[:col]) == "-"):
+		// A new key, at the cursor's column or after a dash.
+		loc.inKey = true
+		if ok && l.dash >= 0 {
+			loc.parent = parents(lines, line, l.col, l.dash)
+		} else {
+			loc.parent = parents(lines, line, col, -1)
+		}
+	case l.col > col:
+		loc.parent = parents(lines, line, col, -1)
+		loc.inKey = true
+	default:
+		// A key without its colon yet, or a sequence item.
+		loc.parent = parents(lines, line, l.col, l.dash)
+		loc.prefix = text[l.col:col]
+		loc.inKey = l.dash < 0 || lint.Keys(loc.parent) != nil
+	}
+	if n := len(loc.parent); n > 0 && strings.HasPrefix(loc.parent[n-1], "\x00") {
+		// A line of a block scalar.
+		loc.key = strings.TrimPrefix(loc.parent[n-1], "\x00")
+		loc.parent, loc.inKey, loc.prefix = loc.parent[:n-1], false, strings.TrimSpace(text[:col])
+	}
+	return loc
+}
+
+// parents returns the path of the mapping a line with content at column
+// col belongs to, or of the sequence item if the line has a dash at
+// column dash. A block scalar holding the line is returned as its last
+// element prefixed with a NUL.
+func parents(lines []string, line, col, dash int) lint.Path {
+	var rev []string
+	seq, count := dash, 0
+	if dash < 0 {
+		seq = -1
+	}
+	for i := line - 1; i >= 0; i-- {
+		l, ok := parseLine(lines[i])
+		if !ok {
+			continue
+		}
+		if seq >= 0 {
+			switch {
+			case l.dash == seq:
+				count++
+				continue
+			case l.col > seq || (l.dash > seq):
+				continue
+			}
+			// The key of the sequence, at or left of its dashes.
+			rev = append(rev, strconv.Itoa(count))
+			seq, col = -1, l.col+1
+		}
+		switch {
+		case l.dash >= 0 && l.col == col && l.dash < col:
+			// The first key of the item our mapping is.
+			seq, count = l.dash, 0
+		case l.col < col && l.hasKey:
+			if len(rev) == 0 && col > l.col && blockRE.MatchString(l.value) && dash < 0 {
+				rev = append(rev, "\x00"+l.key)
+			} else {
+				rev = append(rev, l.key)
+			}
+			col = l.col
+			if l.dash >= 0 {
+				seq, count = l.dash, 0
+			}
+		}
+	}
+	if seq >= 0 {
+		rev = append(rev, strconv.Itoa(count))
+	}
+	path := make(lint.Path, len(rev))
+	for i, p := range rev {
+		path[len(rev)-1-i] = p
+	}
+	return path
+}
+
+// byteOffset converts the UTF-16 offset char of line to a byte offset.
+func byteOffset(line string, char int) int {
+	n := 0
+	for i, r := range line {
+		if n >= char {
+			return i
+		}
+		n += utf16.RuneLen(r)
+	}
+	return len(line)
+}
+
+// utf16Offset converts the byte offset i of line to a UTF-16 offset.
+func utf16Offset(line string, i int) int {
+	n := 0
+	for _, r := range line[:min(i, len(line))] {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// runeOffset converts the zero-based rune column of line to a byte
+// offset.
+func runeOffset(line string, col int) int {
+	i := 0
+	for ; col > 0 && i < len(line); col-- {
+		_, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+	}
+	return i
+}
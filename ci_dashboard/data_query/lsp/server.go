@@ -0,0 +1,385 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lsp is a Language Server Protocol server for workflow files. It
+// completes keys, action inputs and expression contexts, shows
+// documentation on hover, goes to the definition of reusable workflows,
+// actions, jobs and steps, and reports the linter's findings.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"testingdashboard/m/v2/lint"
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Server serves one client.
+type Server struct {
+	// Fetcher fetches the actions and reusable workflows of other
+	// repositories; nil only resolves local ones.
+	Fetcher resolve.Fetcher
+	// Rules are the linter rules diagnostics come from. Nil uses
+	// lint.Rules().
+	Rules []lint.Rule
+	// Workspace is the directory ./ references are relative to when a
+	// document is not under .github/workflows. It defaults to the root the
+	// client opens.
+	Workspace string
+
+	conn *conn
+	docs map[string]*document
+	// actions and workflows cache what uses references resolved to by
+	// reference.
+	mu        sync.Mutex
+	actions   map[string]*fetched[*metadata.Action]
+	workflows map[string]*fetched[*workflow.Workflow]
+}
+
+// fetched is the outcome of resolving a reference.
+type fetched[T any] struct {
+	value T
+	path  string
+	err   error
+}
+
+// document is an open file.
+type document struct {
+	uri, path string
+	version   int
+	lines     []string
+	// wf is the last successful parse, which is used while the text does
+	// not parse.
+	wf *workflow.Workflow
+}
+
+func (d *document) text() string {
+	return strings.Join(d.lines, "\n")
+}
+
+// workspace returns the directory ./ references in d are relative to.
+func (s *Server) workspace(d *document) string {
+	dir := filepath.Dir(d.path)
+	if filepath.Base(dir) == "workflows" && filepath.Base(filepath.Dir(dir)) == ".github" {
+		return filepath.Dir(filepath.Dir(dir))
+	}
+	if s.Workspace != "" {
+		return s.Workspace
+	}
+	return dir
+}
+
+// Serve answers the requests read from r on w until the client exits or r
+// ends.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	s.docs = map[string]*document{}
+	s.actions = map[string]*fetched[*metadata.Action]{}
+	s.workflows = map[string]*fetched[*workflow.Workflow]{}
+	for {
+		m, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil && m == nil {
+			return err
+		}
+		if err != nil {
+			s.conn.write(&message{Error: &rpcError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+		if m.Method == "exit" {
+			return nil
+		}
+		result, rerr := s.handle(ctx, m)
+		if m.ID == nil {
+			continue
+		}
+		resp := &message{ID: m.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			// A null result must still be sent.
+			resp.Result = json.RawMessage("null")
+		}
+		if err := s.conn.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, m *message) (any, *rpcError) {
+	var pos positionParams
+	switch m.Method {
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		if err := json.Unmarshal(m.Params, &pos); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+	}
+	switch m.Method {
+	case "initialize":
+		var p initializeParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		if s.Workspace == "" && p.RootURI != "" {
+			s.Workspace = uriPath(p.RootURI)
+		}
+		return map[string]any{
+			"capabilities": map[string]any{
+				// Documents are synced in full.
+				"textDocumentSync":   1,
+				"completionProvider": map[string]any{"triggerCharacters": []string{".", " ", "{"}},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]any{"name": "actions"},
+		}, nil
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return nil, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, nil
+		}
+		d := &document{uri: p.TextDocument.URI, path: uriPath(p.TextDocument.URI)}
+		s.docs[d.uri] = d
+		s.update(d, p.TextDocument.Version, p.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(m.Params, &p); err != nil || len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		if d := s.docs[p.TextDocument.URI]; d != nil {
+			s.update(d, p.TextDocument.Version, p.ContentChanges[len(p.ContentChanges)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(m.Params, &p); err == nil {
+			delete(s.docs, p.TextDocument.URI)
+			s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		}
+		return nil, nil
+	case "textDocument/completion":
+		d := s.docs[pos.TextDocument.URI]
+		if d == nil {
+			return nil, nil
+		}
+		items := s.complete(ctx, d, pos.Position)
+		if items == nil {
+			items = []CompletionItem{}
+		}
+		return completionList{Items: items}, nil
+	case "textDocument/hover":
+		d := s.docs[pos.TextDocument.URI]
+		if d == nil {
+			return nil, nil
+		}
+		text := s.hover(ctx, d, pos.Position)
+		if text == "" {
+			return nil, nil
+		}
+		return Hover{Contents: *markdown(text)}, nil
+	case "textDocument/definition":
+		d := s.docs[pos.TextDocument.URI]
+		if d == nil {
+			return nil, nil
+		}
+		if loc := s.definition(ctx, d, pos.Position); loc != nil {
+			return loc, nil
+		}
+		return nil, nil
+	}
+	if m.ID == nil {
+		// Unknown notifications are ignored.
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %s is not supported", m.Method)}
+}
+
+// update replaces the text of d and publishes its diagnostics.
+func (s *Server) update(d *document, version int, text string) {
+	d.version = version
+	d.lines = strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if wf, err := workflow.Parse([]byte(text)); err == nil {
+		wf.Path = d.path
+		d.wf = wf
+	}
+	findings := lint.Lint(d.path, []byte(text), s.Rules)
+	diags := make([]Diagnostic, 0, len(findings))
+	for _, f := range findings {
+		diags = append(diags, Diagnostic{
+			Range:    d.findingRange(f.Pos),
+			Severity: severity(f.Severity),
+			Code:     f.Rule,
+			Source:   "actions",
+			Message:  f.Message,
+		})
+	}
+	s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: d.uri, Version: version, Diagnostics: diags})
+}
+
+func severity(s lint.Severity) int {
+	switch s {
+	case lint.SeverityError:
+		return severityError
+	case lint.SeverityWarning:
+		return severityWarning
+	}
+	return severityInformation
+}
+
+// findingRange returns the range of the token at pos: an expression, a key
+// or a word.
+func (d *document) findingRange(pos workflow.Position) Range {
+	line := max(pos.Line-1, 0)
+	if line >= len(d.lines) {
+		return Range{Start: Position{Line: line}, End: Position{Line: line}}
+	}
+	text := d.lines[line]
+	start := runeOffset(text, max(pos.Column-1, 0))
+	end := start
+	rest := text[start:]
+	switch {
+	case strings.HasPrefix(rest, "${{"):
+		if i := strings.Index(rest, "}}"); i >= 0 {
+			end += i + 2
+		} else {
+			end = len(text)
+		}
+	default:
+		if i := strings.IndexAny(rest, ": \t"); i > 0 {
+			end += i
+		} else {
+			end = len(text)
+		}
+	}
+	return d.rangeOf(line, start, end)
+}
+
+// rangeOf returns the range of bytes start to end of line.
+func (d *document) rangeOf(line, start, end int) Range {
+	text := ""
+	if line < len(d.lines) {
+		text = d.lines[line]
+	}
+	return Range{
+		Start: Position{Line: line, Character: utf16Offset(text, start)},
+		End:   Position{Line: line, Character: utf16Offset(text, end)},
+	}
+}
+
+// at locates the cursor at pos.
+func (d *document) at(pos Position) *location {
+	col := 0
+	if pos.Line < len(d.lines) {
+		col = byteOffset(d.lines[pos.Line], pos.Character)
+	}
+	return locate(d.lines, pos.Line, col)
+}
+
+// action returns the metadata of the action uses refers to from d.
+func (s *Server) action(ctx context.Context, d *document, uses string) (*metadata.Action, error) {
+	key := s.workspace(d) + "\x00" + uses
+	s.mu.Lock()
+	f := s.actions[key]
+	s.mu.Unlock()
+	if f != nil {
+		return f.value, f.err
+	}
+	f = &fetched[*metadata.Action]{}
+	dir, err := s.fetch(ctx, d, uses)
+	if err == nil {
+		f.value, err = metadata.Load(dir)
+	}
+	if f.err = err; err == nil {
+		f.path = f.value.Path
+	}
+	s.mu.Lock()
+	s.actions[key] = f
+	s.mu.Unlock()
+	return f.value, f.err
+}
+
+// reusable returns the workflow a job's uses refers to from d, and its
+// file.
+func (s *Server) reusable(ctx context.Context, d *document, uses string) (*workflow.Workflow, string, error) {
+	key := s.workspace(d) + "\x00" + uses
+	s.mu.Lock()
+	f := s.workflows[key]
+	s.mu.Unlock()
+	if f != nil {
+		return f.value, f.path, f.err
+	}
+	f = &fetched[*workflow.Workflow]{}
+	if f.path, f.err = s.fetch(ctx, d, uses); f.err == nil {
+		f.value, f.err = workflow.ParseFile(f.path)
+	}
+	s.mu.Lock()
+	s.workflows[key] = f
+	s.mu.Unlock()
+	return f.value, f.path, f.err
+}
+
+// fetch returns the local path of what uses refers to from d.
+func (s *Server) fetch(ctx context.Context, d *document, uses string) (string, error) {
+	u, err := workflow.ParseUses(uses)
+	if err != nil {
+		return "", err
+	}
+	switch u.Kind {
+	case workflow.UsesLocal:
+		path := filepath.Join(s.workspace(d), filepath.FromSlash(u.Path))
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	case workflow.UsesRepository:
+		if s.Fetcher != nil {
+			return s.Fetcher.Fetch(ctx, u)
+		}
+	}
+	return "", fmt.Errorf("cannot resolve %s", uses)
+}
+
+// uriPath returns the file path of a file URI.
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// pathURI returns the file URI of path.
+func pathURI(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+}
@@ -0,0 +1,173 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mask hides secrets in text and streams, along with the encodings
+// of them that commonly end up in logs: base64, URL encoding and JSON
+// string escaping.
+package mask
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Replacement is what masked values are replaced with.
+const Replacement = "***"
+
+// minDerived is the shortest derived value that is masked; shorter
+// fragments of base64 would hide unrelated text.
+const minDerived = 4
+
+// Masker hides registered secrets. The zero value masks nothing and it is
+// safe for concurrent use.
+type Masker struct {
+	mu sync.RWMutex
+	// values are the secrets and their derived forms, longest first so
+	// overlapping values are fully hidden.
+	values   []string
+	replacer *strings.Replacer
+}
+
+// Add registers a secret and the forms derived from it: each line of a
+// multi-line secret, its base64 encodings, including inside a longer
+// encoded string such as a basic auth header, and its URL-encoded and
+// JSON-escaped forms. Blank secrets are ignored.
+func (m *Masker) Add(secret string) {
+	if strings.TrimSpace(secret) == "" {
+		return
+	}
+	values := []string{secret}
+	// Logs are masked a line at a time.
+	if strings.Contains(secret, "\n") {
+		for _, line := range strings.Split(secret, "\n") {
+			if line = strings.TrimSuffix(line, "\r"); strings.TrimSpace(line) != "" {
+				values = append(values, line)
+			}
+		}
+	}
+	for _, v := range Derive(secret) {
+		if len(v) >= minDerived {
+			values = append(values, v)
+		}
+	}
+	m.AddValues(values...)
+}
+
+// AddValues registers values to be masked as they are, without derived
+// forms. Blank values are ignored.
+func (m *Masker) AddValues(values ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changed := false
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" || slices.Contains(m.values, v) {
+			continue
+		}
+		m.values = append(m.values, v)
+		changed = true
+	}
+	if changed {
+		sort.SliceStable(m.values, func(i, j int) bool { return len(m.values[i]) > len(m.values[j]) })
+		m.replacer = nil
+	}
+}
+
+// Mask replaces registered values in s with Replacement.
+func (m *Masker) Mask(s string) string {
+	m.mu.RLock()
+	r := m.replacer
+	m.mu.RUnlock()
+	if r == nil {
+		m.mu.Lock()
+		if m.replacer == nil {
+			pairs := make([]string, 0, 2*len(m.values))
+			for _, v := range m.values {
+				pairs = append(pairs, v, Replacement)
+			}
+			m.replacer = strings.NewReplacer(pairs...)
+		}
+		r = m.replacer
+		m.mu.Unlock()
+	}
+	return r.Replace(s)
+}
+
+// Longest returns the length of the longest registered value.
+func (m *Masker) Longest() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.values) == 0 {
+		return 0
+	}
+	return len(m.values[0])
+}
+
+// Derive returns the encodings of secret that differ from it, without
+// duplicates.
+func Derive(secret string) []string {
+	var out []string
+	add := func(v string) {
+		if v != "" && v != secret && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+		add(enc.EncodeToString([]byte(secret)))
+		for _, v := range base64Fragments(enc, secret) {
+			add(v)
+		}
+	}
+	add(url.QueryEscape(secret))
+	add(url.PathEscape(secret))
+	if b, err := json.Marshal(secret); err == nil {
+		add(string(b[1 : len(b)-1]))
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(secret) == nil {
+		quoted := strings.TrimSuffix(buf.String(), "\n")
+		add(quoted[1 : len(quoted)-1])
+	}
+	return out
+}
+
+// base64Fragments returns the characters of the base64 encoding of secret
+// that do not depend on the bytes around it, for each of the three
+// alignments it can have inside a longer encoded string.
+func base64Fragments(enc *base64.Encoding, secret string) []string {
+	var out []string
+	for offset := range 3 {
+		data := append(make([]byte, offset), secret...)
+		s := strings.TrimRight(enc.EncodeToString(data), "=")
+		// Characters that mix bits of the surrounding bytes are dropped:
+		// the first one or two after a prefix, and the last one unless
+		// the secret ends on a group boundary.
+		start := [3]int{0, 2, 3}[offset]
+		end := len(s)
+		if len(data)%3 != 0 {
+			end--
+		}
+		if end-start > 0 {
+			out = append(out, s[start:end])
+		}
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mask
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxHold is how much of a line without a newline Writer holds back before
+// passing it on anyway.
+const maxHold = 64 << 10
+
+// Writer masks what is written through it before passing it on. Output is
+// passed on a line at a time, so a secret split across writes is still
+// masked, except in lines longer than 64 KiB. It is safe for concurrent use.
+type Writer struct {
+	m   *Masker
+	out io.Writer
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// NewWriter returns a writer masking the values of m on their way to out.
+func NewWriter(out io.Writer, m *Masker) *Writer {
+	return &Writer{m: m, out: out}
+}
+
+func (w *Writer) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(b)
+	n := bytes.LastIndexByte(w.buf.Bytes(), '\n') + 1
+	if n == 0 && w.buf.Len() > maxHold {
+		// Keep enough to finish a secret that starts near the end.
+		n = w.buf.Len() - w.m.Longest()
+	}
+	if n <= 0 {
+		return len(b), nil
+	}
+	if _, err := io.WriteString(w.out, w.m.Mask(string(w.buf.Next(n)))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Flush passes on any trailing partial line.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w.out, w.m.Mask(w.buf.String()))
+	w.buf.Reset()
+	return err
+}
+
+// Close flushes the writer. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	return w.Flush()
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metadata parses action metadata files (action.yml).
+package metadata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// Action is a parsed action.yml.
+type Action struct {
+	Name        string             `yaml:"name"`
+	Author      string             `yaml:"author,omitempty"`
+	Description string             `yaml:"description"`
+	Inputs      map[string]*Input  `yaml:"inputs,omitempty"`
+	Outputs     map[string]*Output `yaml:"outputs,omitempty"`
+	Runs        Runs               `yaml:"runs"`
+	Branding    *Branding          `yaml:"branding,omitempty"`
+
+	// Path is the metadata file the action was read from, if any.
+	Path string `yaml:"-"`
+}
+
+// Input is an action input declaration.
+type Input struct {
+	Description        string `yaml:"description"`
+	Required           bool   `yaml:"required,omitempty"`
+	Default            string `yaml:"default,omitempty"`
+	DeprecationMessage string `yaml:"deprecationMessage,omitempty"`
+}
+
+// Output is an action output declaration. Value is only used by composite
+// actions.
+type Output struct {
+	Description string `yaml:"description"`
+	Value       string `yaml:"value,omitempty"`
+}
+
+// Branding is the icon and color shown for the action on the Marketplace.
+type Branding struct {
+	Icon  string `yaml:"icon,omitempty"`
+	Color string `yaml:"color,omitempty"`
+}
+
+// Runs describes how the action is executed.
+type Runs struct {
+	Using string `yaml:"using"`
+
+	// JavaScript actions.
+	Main   string `yaml:"main,omitempty"`
+	Pre    string `yaml:"pre,omitempty"`
+	PreIf  string `yaml:"pre-if,omitempty"`
+	Post   string `yaml:"post,omitempty"`
+	PostIf string `yaml:"post-if,omitempty"`
+
+	// Docker actions.
+	Image          string            `yaml:"image,omitempty"`
+	Entrypoint     string            `yaml:"entrypoint,omitempty"`
+	PreEntrypoint  string            `yaml:"pre-entrypoint,omitempty"`
+	PostEntrypoint string            `yaml:"post-entrypoint,omitempty"`
+	Args           []string          `yaml:"args,omitempty"`
+	Env            map[string]string `yaml:"env,omitempty"`
+
+	// Composite actions.
+	Steps []*workflow.Step `yaml:"steps,omitempty"`
+
+	// WASM actions run Main, a WASI module, with the access it lists:
+	// workspace, workspace:ro, temp and tool-cache. Only this runner
+	// supports them.
+	Access []string `yaml:"access,omitempty"`
+}
+
+// Values of runs.using.
+const (
+	UsingDocker    = "docker"
+	UsingComposite = "composite"
+	UsingNode12    = "node12"
+	UsingNode16    = "node16"
+	UsingNode20    = "node20"
+	UsingNode24    = "node24"
+	// UsingWASM is experimental and not supported by GitHub's runner.
+	UsingWASM = "wasm"
+)
+
+// Access a WASM action may ask for.
+const (
+	AccessWorkspace         = "workspace"
+	AccessWorkspaceReadOnly = "workspace:ro"
+	AccessTemp              = "temp"
+	AccessToolCache         = "tool-cache"
+)
+
+// IsNode reports whether using names a JavaScript runtime.
+func IsNode(using string) bool {
+	switch using {
+	case UsingNode12, UsingNode16, UsingNode20, UsingNode24:
+		return true
+	}
+	return false
+}
+
+// Parse parses an action metadata document.
+func Parse(data []byte) (*Action, error) {
+	var action Action
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&action); err != nil {
+		return nil, fmt.Errorf("failed to parse action metadata: %w", err)
+	}
+	return &action, nil
+}
+
+// ParseFile reads and parses the metadata file at path.
+func ParseFile(path string) (*Action, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	action, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	action.Path = path
+	return action, nil
+}
+
+// Load reads action.yml or action.yaml from dir.
+func Load(dir string) (*Action, error) {
+	for _, name := range []string{"action.yml", "action.yaml"} {
+		path := filepath.Join(dir, name)
+		action, err := ParseFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		return action, err
+	}
+	return nil, fmt.Errorf("no action.yml or action.yaml found in %s", dir)
+}
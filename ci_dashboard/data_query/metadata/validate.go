@@ -0,0 +1,237 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// Problem is a violation of the action metadata syntax.
+type Problem struct {
+	// Field is the dotted path of the offending key, such as runs.main.
+	Field   string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Field == "" {
+		return p.Message
+	}
+	return p.Field + ": " + p.Message
+}
+
+// Colors are the allowed values of branding.color.
+var Colors = []string{"white", "black", "yellow", "blue", "green", "orange", "red", "purple", "gray-dark"}
+
+// Icons are the allowed values of branding.icon: the Feather icons that
+// GitHub supports.
+var Icons = []string{
+	"activity", "airplay", "alert-circle", "alert-octagon", "alert-triangle",
+	"align-center", "align-justify", "align-left", "align-right", "anchor",
+	"aperture", "archive", "arrow-down-circle", "arrow-down-left",
+	"arrow-down-right", "arrow-down", "arrow-left-circle", "arrow-left",
+	"arrow-right-circle", "arrow-right", "arrow-up-circle", "arrow-up-left",
+	"arrow-up-right", "arrow-up", "at-sign", "award", "bar-chart-2", "bar-chart",
+	"battery-charging", "battery", "bell-off", "bell", "bluetooth", "bold",
+	"book-open", "book", "bookmark", "box", "briefcase", "calendar",
+	"camera-off", "camera", "cast", "check-circle", "check-square", "check",
+	"chevron-down", "chevron-left", "chevron-right", "chevron-up",
+	"chevrons-down", "chevrons-left", "chevrons-right", "chevrons-up", "circle",
+	"clipboard", "clock", "cloud-drizzle", "cloud-lightning", "cloud-off",
+	"cloud-rain", "cloud-snow", "cloud", "code", "command", "compass", "copy",
+	"corner-down-left", "corner-down-right", "corner-left-down",
+	"corner-left-up", "corner-right-down", "corner-right-up", "corner-up-left",
+	"corner-up-right", "cpu", "credit-card", "crop", "crosshair", "database",
+	"delete", "disc", "dollar-sign", "download-cloud", "download", "droplet",
+	"edit-2", "edit-3", "edit", "external-link", "eye-off", "eye", "fast-forward",
+	"feather", "file-minus", "file-plus", "file-text", "file", "film", "filter",
+	"flag", "folder-minus", "folder-plus", "folder", "gift", "git-branch",
+	"git-commit", "git-merge", "git-pull-request", "globe", "grid",
+	"hard-drive", "hash", "headphones", "heart", "help-circle", "home", "image",
+	"inbox", "info", "italic", "layers", "layout", "life-buoy", "link-2", "link",
+	"list", "loader", "lock", "log-in", "log-out", "mail", "map-pin", "map",
+	"maximize-2", "maximize", "menu", "message-circle", "message-square",
+	"mic-off", "mic", "minimize-2", "minimize", "minus-circle", "minus-square",
+	"minus", "monitor", "moon", "more-horizontal", "more-vertical", "move",
+	"music", "navigation-2", "navigation", "octagon", "package", "paperclip",
+	"pause-circle", "pause", "percent", "phone-call", "phone-forwarded",
+	"phone-incoming", "phone-missed", "phone-off", "phone-outgoing", "phone",
+	"pie-chart", "play-circle", "play", "plus-circle", "plus-square", "plus",
+	"pocket", "power", "printer", "radio", "refresh-ccw", "refresh-cw", "repeat",
+	"rewind", "rotate-ccw", "rotate-cw", "rss", "save", "scissors", "search",
+	"send", "server", "settings", "share-2", "share", "shield-off", "shield",
+	"shopping-bag", "shopping-cart", "shuffle", "sidebar", "skip-back",
+	"skip-forward", "slash", "sliders", "smartphone", "speaker", "square",
+	"star", "stop-circle", "sun", "sunrise", "sunset", "tablet", "tag", "target",
+	"terminal", "thermometer", "thumbs-down", "thumbs-up", "toggle-left",
+	"toggle-right", "trash-2", "trash", "trending-down", "trending-up",
+	"triangle", "truck", "tv", "type", "umbrella", "underline", "unlock",
+	"upload-cloud", "upload", "user-check", "user-minus", "user-plus", "user-x",
+	"user", "users", "video-off", "video", "voicemail", "volume-1", "volume-2",
+	"volume-x", "volume", "watch", "wifi-off", "wifi", "wind", "x-circle",
+	"x-square", "x", "zap-off", "zap", "zoom-in", "zoom-out",
+}
+
+var idPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Validate checks the action against the metadata syntax GitHub accepts and
+// returns every problem found, ordered by field.
+func (a *Action) Validate() []Problem {
+	var problems []Problem
+	add := func(field, format string, args ...any) {
+		problems = append(problems, Problem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(a.Name) == "" {
+		add("name", "required")
+	}
+	if strings.TrimSpace(a.Description) == "" {
+		add("description", "required")
+	}
+	for name, input := range a.Inputs {
+		if !idPattern.MatchString(name) {
+			add("inputs."+name, "input names must start with a letter or _ and contain only alphanumerics, - or _")
+		}
+		if input != nil && input.Required && input.DeprecationMessage != "" {
+			add("inputs."+name, "a required input should not be deprecated")
+		}
+	}
+	for name, output := range a.Outputs {
+		if !idPattern.MatchString(name) {
+			add("outputs."+name, "output names must start with a letter or _ and contain only alphanumerics, - or _")
+		}
+		value := output != nil && output.Value != ""
+		switch {
+		case a.Runs.Using == UsingComposite && !value:
+			add("outputs."+name+".value", "required for composite actions")
+		case a.Runs.Using != UsingComposite && value:
+			add("outputs."+name+".value", "only composite actions set output values")
+		}
+	}
+	a.validateRuns(add)
+	if b := a.Branding; b != nil {
+		if b.Icon != "" && !slices.Contains(Icons, b.Icon) {
+			add("branding.icon", "unknown icon %q", b.Icon)
+		}
+		if b.Color != "" && !slices.Contains(Colors, b.Color) {
+			add("branding.color", "unknown color %q, expected one of %s", b.Color, strings.Join(Colors, ", "))
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Field < problems[j].Field })
+	return problems
+}
+
+func (a *Action) validateRuns(add func(field, format string, args ...any)) {
+	r := &a.Runs
+	// unused reports keys that belong to another kind of action.
+	unused := func(kind string, fields map[string]bool) {
+		names := make([]string, 0, len(fields))
+		for name, set := range fields {
+			if set {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add("runs."+name, "not used by %s actions", kind)
+		}
+	}
+	node := map[string]bool{"main": r.Main != "", "pre": r.Pre != "", "post": r.Post != "", "pre-if": r.PreIf != "", "post-if": r.PostIf != ""}
+	docker := map[string]bool{"image": r.Image != "", "entrypoint": r.Entrypoint != "", "pre-entrypoint": r.PreEntrypoint != "", "post-entrypoint": r.PostEntrypoint != "", "args": len(r.Args) > 0, "env": len(r.Env) > 0}
+	composite := map[string]bool{"steps": len(r.Steps) > 0}
+	wasm := map[string]bool{"access": len(r.Access) > 0}
+
+	switch {
+	case r.Using == "":
+		add("runs.using", "required")
+	case IsNode(r.Using):
+		if r.Main == "" {
+			add("runs.main", "required for %s actions", r.Using)
+		}
+		if r.PreIf != "" && r.Pre == "" {
+			add("runs.pre-if", "set without runs.pre")
+		}
+		if r.PostIf != "" && r.Post == "" {
+			add("runs.post-if", "set without runs.post")
+		}
+		unused("JavaScript", docker)
+		unused("JavaScript", composite)
+		unused("JavaScript", wasm)
+	case r.Using == UsingDocker:
+		if r.Image == "" {
+			add("runs.image", "required for docker actions")
+		}
+		unused("docker", node)
+		unused("docker", composite)
+		unused("docker", wasm)
+	case r.Using == UsingComposite:
+		if len(r.Steps) == 0 {
+			add("runs.steps", "required for composite actions")
+		}
+		ids := map[string]bool{}
+		for i, step := range r.Steps {
+			validateStep(add, fmt.Sprintf("runs.steps[%d]", i), step, ids)
+		}
+		unused("composite", node)
+		unused("composite", docker)
+		unused("composite", wasm)
+	case r.Using == UsingWASM:
+		if r.Main == "" {
+			add("runs.main", "required for wasm actions")
+		}
+		for i, a := range r.Access {
+			switch a {
+			case AccessWorkspace, AccessWorkspaceReadOnly, AccessTemp, AccessToolCache:
+			default:
+				add(fmt.Sprintf("runs.access[%d]", i), "unknown value %q", a)
+			}
+		}
+		delete(node, "main")
+		unused("wasm", node)
+		unused("wasm", docker)
+		unused("wasm", composite)
+	default:
+		add("runs.using", "unknown value %q, expected one of %s", r.Using,
+			strings.Join([]string{UsingComposite, UsingDocker, UsingNode12, UsingNode16, UsingNode20, UsingNode24, UsingWASM}, ", "))
+	}
+}
+
+func validateStep(add func(field, format string, args ...any), field string, step *workflow.Step, ids map[string]bool) {
+	if step.ID != "" {
+		if ids[step.ID] {
+			add(field+".id", "duplicate step id %q", step.ID)
+		}
+		ids[step.ID] = true
+	}
+	switch {
+	case step.Run == "" && step.Uses == "":
+		add(field, "one of run or uses is required")
+	case step.Run != "" && step.Uses != "":
+		add(field, "run and uses cannot both be set")
+	case step.Run != "" && step.Shell == "":
+		add(field+".shell", "required for run steps in composite actions")
+	case step.Uses != "":
+		if _, err := workflow.ParseUses(step.Uses); err != nil {
+			add(field+".uses", "%v", err)
+		}
+	}
+}
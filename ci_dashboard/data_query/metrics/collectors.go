@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// Runner holds the metrics of the local workflow runner. Its methods do
+// nothing on a nil *Runner, so callers need not check.
+type Runner struct {
+	StepDuration *Histogram
+	JobDuration  *Histogram
+	// QueueWait is how long a job waited between its needs finishing, or
+	// the run starting, and starting itself.
+	QueueWait   *Histogram
+	ActionCache *Counter
+}
+
+// NewRunner returns the runner metrics, registered with reg.
+func NewRunner(reg *Registry) *Runner {
+	m := &Runner{
+		StepDuration: NewHistogram("actions_runner_step_duration_seconds", "Duration of workflow steps.", nil, "job", "step", "conclusion"),
+		JobDuration:  NewHistogram("actions_runner_job_duration_seconds", "Duration of jobs, per matrix leg.", nil, "job", "result"),
+		QueueWait:    NewHistogram("actions_runner_job_queue_wait_seconds", "Time jobs waited to start after becoming runnable.", nil, "job"),
+		ActionCache:  NewCounter("actions_runner_action_cache_requests_total", "Action fetches by whether the action cache had them.", "result"),
+	}
+	reg.Register(m.StepDuration, m.JobDuration, m.QueueWait, m.ActionCache, &GaugeFunc{
+		Name: "actions_runner_action_cache_hit_ratio",
+		Help: "Fraction of action fetches served from the action cache.",
+		Func: m.CacheHitRatio,
+	})
+	return m
+}
+
+// ObserveStep records a finished step.
+func (m *Runner) ObserveStep(job, step, conclusion string, d time.Duration) {
+	if m != nil {
+		m.StepDuration.Observe(d.Seconds(), job, step, conclusion)
+	}
+}
+
+// ObserveJob records a finished job leg.
+func (m *Runner) ObserveJob(job, result string, d time.Duration) {
+	if m != nil {
+		m.JobDuration.Observe(d.Seconds(), job, result)
+	}
+}
+
+// ObserveQueueWait records how long a job waited to start.
+func (m *Runner) ObserveQueueWait(job string, d time.Duration) {
+	if m != nil {
+		m.QueueWait.Observe(d.Seconds(), job)
+	}
+}
+
+// ObserveCache records whether an action fetch hit the cache.
+func (m *Runner) ObserveCache(hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.ActionCache.Inc("hit")
+	} else {
+		m.ActionCache.Inc("miss")
+	}
+}
+
+// CacheHitRatio returns the fraction of action fetches that hit the cache,
+// or 0 before the first fetch.
+func (m *Runner) CacheHitRatio() float64 {
+	if m == nil {
+		return 0
+	}
+	hits, misses := m.ActionCache.Value("hit"), m.ActionCache.Value("miss")
+	if hits+misses == 0 {
+		return 0
+	}
+	return hits / (hits + misses)
+}
+
+// Client holds the metrics of the API client. Its methods do nothing on a
+// nil *Client.
+type Client struct {
+	Requests        *Counter
+	RequestDuration *Histogram
+	Retries         *Counter
+	RateLimit       *Gauge
+	RateRemaining   *Gauge
+}
+
+// NewClient returns the API client metrics, registered with reg.
+func NewClient(reg *Registry) *Client {
+	m := &Client{
+		Requests:        NewCounter("actions_api_requests_total", "API requests by method and status code; code is error when no response arrived.", "method", "code"),
+		RequestDuration: NewHistogram("actions_api_request_duration_seconds", "Duration of API requests.", []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, "method"),
+		Retries:         NewCounter("actions_api_retries_total", "Retried API requests by reason: rate_limit, server_error or network.", "method", "reason"),
+		RateLimit:       NewGauge("actions_api_rate_limit", "Requests allowed per rate limit window, as last reported."),
+		RateRemaining:   NewGauge("actions_api_rate_limit_remaining", "Requests left in the rate limit window, as last reported."),
+	}
+	reg.Register(m.Requests, m.RequestDuration, m.Retries, m.RateLimit, m.RateRemaining)
+	return m
+}
+
+// ObserveRequest records one attempt of a request. code is 0 when the
+// request failed without a response.
+func (m *Client) ObserveRequest(method string, code int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	status := "error"
+	if code != 0 {
+		status = strconv.Itoa(code)
+	}
+	m.Requests.Inc(method, status)
+	m.RequestDuration.Observe(d.Seconds(), method)
+}
+
+// ObserveRetry records that a request is retried.
+func (m *Client) ObserveRetry(method, reason string) {
+	if m != nil {
+		m.Retries.Inc(method, reason)
+	}
+}
+
+// SetRateLimit records the rate limit state of a response.
+func (m *Client) SetRateLimit(limit, remaining int) {
+	if m != nil {
+		m.RateLimit.Set(float64(limit))
+		m.RateRemaining.Set(float64(remaining))
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides counters, gauges and histograms and serves them
+// in the Prometheus text exposition format, along with the collectors the
+// runner and the API client report to.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types, as written in # TYPE lines.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Family is a metric and its samples at one point in time.
+type Family struct {
+	Name, Help, Type string
+	Samples          []Sample
+}
+
+// Sample is one value of a family. Suffix is appended to the family name,
+// as in _bucket, _sum and _count for histograms.
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Label is a label name and value.
+type Label struct {
+	Name, Value string
+}
+
+// Collector produces a metric family when scraped.
+type Collector interface {
+	Collect() *Family
+}
+
+// Registry holds the collectors a scrape writes. The zero value is ready
+// to use.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// Register adds collectors. It panics if a name is registered twice, which
+// is a programming error.
+func (r *Registry) Register(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range cs {
+		name := c.Collect().Name
+		for _, have := range r.collectors {
+			if have.Collect().Name == name {
+				panic("metrics: " + name + " registered twice")
+			}
+		}
+		r.collectors = append(r.collectors, c)
+	}
+}
+
+// Gather returns the families of every collector, sorted by name.
+func (r *Registry) Gather() []*Family {
+	r.mu.Lock()
+	cs := slices.Clone(r.collectors)
+	r.mu.Unlock()
+	fams := make([]*Family, len(cs))
+	for i, c := range cs {
+		fams[i] = c.Collect()
+	}
+	slices.SortFunc(fams, func(a, b *Family) int { return strings.Compare(a.Name, b.Name) })
+	return fams
+}
+
+// WriteText writes every family in the text exposition format.
+func (r *Registry) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range r.Gather() {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range f.Samples {
+			bw.WriteString(f.Name + s.Suffix)
+			if len(s.Labels) > 0 {
+				bw.WriteByte('{')
+				for i, l := range s.Labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, `%s="%s"`, l.Name, escapeValue(l.Value))
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteString(" " + formatValue(s.Value) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves a scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteText(w)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// vec holds one value per combination of label values.
+type vec[V any] struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]*V
+	keys   map[string][]string
+}
+
+func newVec[V any](name, help string, labels []string) vec[V] {
+	return vec[V]{name: name, help: help, labels: labels, values: map[string]*V{}, keys: map[string][]string{}}
+}
+
+// get returns the value for label values, creating it with init. The lock
+// is held on return.
+func (v *vec[V]) get(values []string, init func() *V) *V {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	val, ok := v.values[key]
+	if !ok {
+		val = init()
+		v.values[key] = val
+		v.keys[key] = slices.Clone(values)
+	}
+	return val
+}
+
+// each calls fn for the values in a stable order, with the lock held.
+func (v *vec[V]) each(fn func(labels []Label, val *V)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		labels := make([]Label, len(v.labels))
+		for i, name := range v.labels {
+			labels[i] = Label{name, v.keys[k][i]}
+		}
+		fn(labels, v.values[k])
+	}
+}
+
+func newFloat() *float64 { return new(float64) }
+
+// Counter is a value that only goes up, per combination of label values.
+type Counter struct {
+	vec[float64]
+}
+
+// NewCounter returns a counter with the given label names.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{newVec[float64](name, help, labels)}
+}
+
+// Add adds v, which must not be negative, to the counter for label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter " + c.name + " decreased")
+	}
+	p := c.get(labelValues, newFloat)
+	*p += v
+	c.mu.Unlock()
+}
+
+// Inc adds one.
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Value returns the counter for label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p := c.values[strings.Join(labelValues, "\xff")]; p != nil {
+		return *p
+	}
+	return 0
+}
+
+// Collect implements Collector.
+func (c *Counter) Collect() *Family {
+	f := &Family{Name: c.name, Help: c.help, Type: TypeCounter}
+	c.each(func(labels []Label, v *float64) {
+		f.Samples = append(f.Samples, Sample{Labels: labels, Value: *v})
+	})
+	return f
+}
+
+// Gauge is a value that goes up and down, per combination of label values.
+type Gauge struct {
+	vec[float64]
+}
+
+// NewGauge returns a gauge with the given label names.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{newVec[float64](name, help, labels)}
+}
+
+// Set sets the gauge for label values.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	p := g.get(labelValues, newFloat)
+	*p = v
+	g.mu.Unlock()
+}
+
+// Add adds v, which may be negative.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	p := g.get(labelValues, newFloat)
+	*p += v
+	g.mu.Unlock()
+}
+
+// Collect implements Collector.
+func (g *Gauge) Collect() *Family {
+	f := &Family{Name: g.name, Help: g.help, Type: TypeGauge}
+	g.each(func(labels []Label, v *float64) {
+		f.Samples = append(f.Samples, Sample{Labels: labels, Value: *v})
+	})
+	return f
+}
+
+// GaugeFunc is an unlabeled gauge computed when scraped.
+type GaugeFunc struct {
+	Name, Help string
+	Func       func() float64
+}
+
+// Collect implements Collector.
+func (g *GaugeFunc) Collect() *Family {
+	return &Family{Name: g.Name, Help: g.Help, Type: TypeGauge, Samples: []Sample{{Value: g.Func()}}}
+}
+
+// DefaultBuckets suit durations in seconds from tens of milliseconds to
+// an hour.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+
+// Histogram counts observations in buckets, per combination of label
+// values.
+type Histogram struct {
+	vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram returns a histogram with the given upper bounds, which
+// default to DefaultBuckets, and label names.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Histogram{vec: newVec[histogram](name, help, labels), buckets: buckets}
+}
+
+// Observe records v for label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	p := h.get(labelValues, func() *histogram { return &histogram{counts: make([]uint64, len(h.buckets))} })
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		p.counts[i]++
+	}
+	p.count++
+	p.sum += v
+	h.mu.Unlock()
+}
+
+// Collect implements Collector.
+func (h *Histogram) Collect() *Family {
+	f := &Family{Name: h.name, Help: h.help, Type: TypeHistogram}
+	h.each(func(labels []Label, v *histogram) {
+		var cumulative uint64
+		for i, le := range h.buckets {
+			cumulative += v.counts[i]
+			f.Samples = append(f.Samples, Sample{Suffix: "_bucket", Labels: withLabel(labels, "le", formatValue(le)), Value: float64(cumulative)})
+		}
+		f.Samples = append(f.Samples,
+			Sample{Suffix: "_bucket", Labels: withLabel(labels, "le", "+Inf"), Value: float64(v.count)},
+			Sample{Suffix: "_sum", Labels: labels, Value: v.sum},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(v.count)})
+	})
+	return f
+}
+
+func withLabel(labels []Label, name, value string) []Label {
+	return append(slices.Clip(labels), Label{name, value})
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package netpolicy restricts where jobs may connect. Steps reach the
+// network through a proxy that checks each destination against an
+// allowlist of domains and addresses and records every request, which it
+// refuses in enforce mode unless the policy allows it. Connections that
+// bypass the proxy are neither seen nor stopped; pair the policy with a
+// network the job cannot leave otherwise for a hard guarantee.
+package netpolicy
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mode is what the proxy does with requests the policy does not allow.
+type Mode string
+
+const (
+	// ModeAudit records them and lets them through.
+	ModeAudit Mode = "audit"
+	// ModeEnforce refuses them and fails the job.
+	ModeEnforce Mode = "enforce"
+)
+
+// Policy is a netpolicy file:
+//
+//	mode: enforce
+//	allow:
+//	  - github.com
+//	  - "*.githubusercontent.com"
+//	  - registry.npmjs.org:443
+//	  - 10.0.0.0/8
+//
+// An entry is a host name, *. and a domain for its subdomains, * for any
+// host, or an IP address or CIDR range, optionally followed by :port.
+type Policy struct {
+	// Mode defaults to audit.
+	Mode  Mode     `yaml:"mode"`
+	Allow []string `yaml:"allow"`
+}
+
+type rule struct {
+	// host is a lowercased name, a *.domain suffix or *; prefix is set
+	// instead for addresses.
+	host   string
+	prefix netip.Prefix
+	// port is 0 for any port.
+	port int
+}
+
+// Parse parses a policy file.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Load reads a policy file.
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return p, nil
+}
+
+// Validate checks the mode and allow entries, defaulting the mode.
+func (p *Policy) Validate() error {
+	switch p.Mode {
+	case "":
+		p.Mode = ModeAudit
+	case ModeAudit, ModeEnforce:
+	default:
+		return fmt.Errorf("invalid mode %q: expected audit or enforce", p.Mode)
+	}
+	for _, entry := range p.Allow {
+		if _, err := parseRule(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseRule(entry string) (rule, error) {
+	s := strings.ToLower(strings.TrimSpace(entry))
+	var r rule
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		r.prefix = prefix.Masked()
+		return r, nil
+	}
+	host := s
+	if h, port, err := net.SplitHostPort(s); err == nil {
+		n, err := strconv.Atoi(port)
+		if err != nil || n <= 0 || n > 65535 {
+			return r, fmt.Errorf("invalid port in %q", entry)
+		}
+		host, r.port = h, n
+	}
+	if prefix, err := netip.ParsePrefix(host); err == nil {
+		r.prefix = prefix.Masked()
+		return r, nil
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		r.prefix = netip.PrefixFrom(addr, addr.BitLen())
+		return r, nil
+	}
+	name := strings.TrimPrefix(host, "*.")
+	if host != "*" && (name == "" || strings.ContainsAny(name, "*/ ") || strings.HasPrefix(name, ".")) {
+		return r, fmt.Errorf("invalid allow entry %q", entry)
+	}
+	r.host = strings.TrimSuffix(host, ".")
+	return r, nil
+}
+
+// Allows reports whether the policy allows connecting to host on port.
+// Invalid entries allow nothing.
+func (p *Policy) Allows(host string, port int) bool {
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	addr, addrErr := netip.ParseAddr(host)
+	for _, entry := range p.Allow {
+		r, err := parseRule(entry)
+		if err != nil || (r.port != 0 && r.port != port) {
+			continue
+		}
+		switch {
+		case r.prefix.IsValid():
+			if addrErr == nil && r.prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		case r.host == "*":
+			return true
+		case addrErr == nil:
+		case strings.HasPrefix(r.host, "*."):
+			if strings.HasSuffix(host, r.host[1:]) {
+				return true
+			}
+		case host == r.host:
+			return true
+		}
+	}
+	return false
+}
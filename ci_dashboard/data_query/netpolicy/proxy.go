@@ -0,0 +1,266 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netpolicy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// dialTimeout bounds how long the proxy takes to reach a destination.
+const dialTimeout = 30 * time.Second
+
+// Request is an outbound request a step made through the proxy.
+type Request struct {
+	Time time.Time `json:"time"`
+	Step string    `json:"step"`
+	// Method is CONNECT for tunnels, such as those of HTTPS requests,
+	// whose contents the proxy does not see.
+	Method string `json:"method"`
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+	// URL is set for plain HTTP requests.
+	URL     string `json:"url,omitempty"`
+	Allowed bool   `json:"allowed"`
+	// Blocked is set when the proxy refused the request: in enforce mode,
+	// one the policy does not allow.
+	Blocked bool `json:"blocked"`
+}
+
+// Proxy is an HTTP proxy that applies a policy. Steps identify themselves
+// with the credentials of the URL Register returns them; requests without
+// them are attributed to the step registered last, and are only accepted
+// from the loopback interface.
+type Proxy struct {
+	policy   *Policy
+	token    string
+	listener net.Listener
+	server   *http.Server
+
+	mu       sync.Mutex
+	steps    map[string]string
+	current  string
+	requests []Request
+}
+
+// Listen starts a proxy for policy on addr, such as 127.0.0.1:0.
+func Listen(policy *Policy, addr string) (*Proxy, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the proxy: %w", err)
+	}
+	p := &Proxy{policy: policy, token: hex.EncodeToString(secret), listener: l, steps: map[string]string{}}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: dialTimeout}
+	go p.server.Serve(l)
+	return p, nil
+}
+
+// Port returns the port the proxy listens on.
+func (p *Proxy) Port() int {
+	return p.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Token is the secret in the URLs of the proxy, to be masked in logs.
+func (p *Proxy) Token() string {
+	return p.token
+}
+
+// Register makes step the one requests are attributed to and returns the
+// user name that identifies it in the proxy's URL.
+func (p *Proxy) Register(step string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	user := "step" + strconv.Itoa(len(p.steps)+1)
+	p.steps[user] = step
+	p.current = step
+	return user
+}
+
+// URL returns the URL of the proxy at host, as a step reaches it, with the
+// credentials of user.
+func (p *Proxy) URL(host, user string) string {
+	u := url.URL{Scheme: "http", User: url.UserPassword(user, p.token), Host: net.JoinHostPort(host, strconv.Itoa(p.Port()))}
+	return u.String()
+}
+
+// Requests returns the requests made so far, in order.
+func (p *Proxy) Requests() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Request(nil), p.requests...)
+}
+
+// Close stops the proxy, cutting off open tunnels.
+func (p *Proxy) Close() error {
+	return p.server.Close()
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	step, ok := p.authenticate(r)
+	if !ok {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="netpolicy"`)
+		http.Error(w, "netpolicy: proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+	host, port := r.Host, ""
+	if r.Method != http.MethodConnect {
+		if !r.URL.IsAbs() {
+			http.Error(w, "netpolicy: this is a proxy; requests must have an absolute URL", http.StatusBadRequest)
+			return
+		}
+		host = r.URL.Host
+	}
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	} else if r.Method == http.MethodConnect {
+		http.Error(w, "netpolicy: CONNECT needs host:port", http.StatusBadRequest)
+		return
+	} else if r.URL.Scheme == "https" {
+		port = "443"
+	} else {
+		port = "80"
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		http.Error(w, "netpolicy: invalid port", http.StatusBadRequest)
+		return
+	}
+	req := Request{Time: time.Now(), Step: step, Method: r.Method, Host: host, Port: n, Allowed: p.policy.Allows(host, n)}
+	if r.Method != http.MethodConnect {
+		u := *r.URL
+		u.User = nil
+		req.URL = u.String()
+	}
+	req.Blocked = !req.Allowed && p.policy.Mode == ModeEnforce
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	p.mu.Unlock()
+	if req.Blocked {
+		http.Error(w, fmt.Sprintf("netpolicy: %s is not allowed", net.JoinHostPort(host, port)), http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, net.JoinHostPort(host, port))
+		return
+	}
+	p.forward(w, r)
+}
+
+// authenticate returns the step that made r, and false if r may not use
+// the proxy.
+func (p *Proxy) authenticate(r *http.Request) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if user, pass, ok := parseProxyAuth(r); ok && pass == p.token {
+		if step, ok := p.steps[user]; ok {
+			return step, true
+		}
+	}
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	ip := net.ParseIP(host)
+	return p.current, ip != nil && ip.IsLoopback()
+}
+
+func parseProxyAuth(r *http.Request) (user, pass string, ok bool) {
+	auth := r.Header.Get("Proxy-Authorization")
+	if auth == "" {
+		return "", "", false
+	}
+	// Reuse the parsing of Authorization.
+	fake := &http.Request{Header: http.Header{"Authorization": {auth}}}
+	return fake.BasicAuth()
+}
+
+// tunnel connects the client to addr for CONNECT.
+func (p *Proxy) tunnel(w http.ResponseWriter, addr string) {
+	upstream, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("netpolicy: %v", err), http.StatusBadGateway)
+		return
+	}
+	client, buf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		upstream.Close()
+		http.Error(w, fmt.Sprintf("netpolicy: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		upstream.Close()
+		client.Close()
+		return
+	}
+	go func() {
+		// Bytes the client sent after the request are buffered.
+		if n := buf.Reader.Buffered(); n > 0 {
+			data, _ := buf.Reader.Peek(n)
+			upstream.Write(data)
+		}
+		io.Copy(upstream, client)
+		upstream.Close()
+	}()
+	io.Copy(client, upstream)
+	client.Close()
+}
+
+// hopHeaders are the headers of one connection, which the proxy does
+// not pass on.
+var hopHeaders = []string{"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+var transport = &http.Transport{
+	Proxy:                 nil,
+	DialContext:           (&net.Dialer{Timeout: dialTimeout}).DialContext,
+	MaxIdleConns:          16,
+	IdleConnTimeout:       90 * time.Second,
+	ResponseHeaderTimeout: 5 * time.Minute,
+}
+
+// forward sends a plain HTTP request on and copies back the response.
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request) {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.URL.User = nil
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("netpolicy: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for _, h := range hopHeaders {
+		resp.Header.Del(h)
+	}
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netpolicy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Destination sums up the requests of one step to one host and port.
+type Destination struct {
+	Step     string `json:"step"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Requests int    `json:"requests"`
+	Allowed  bool   `json:"allowed"`
+	Blocked  bool   `json:"blocked"`
+}
+
+// Destinations groups requests by step and destination, in the order they
+// were first made.
+func Destinations(requests []Request) []Destination {
+	var out []Destination
+	index := map[Destination]int{}
+	for _, r := range requests {
+		key := Destination{Step: r.Step, Host: r.Host, Port: r.Port}
+		i, ok := index[key]
+		if !ok {
+			i = len(out)
+			index[key] = i
+			out = append(out, Destination{Step: r.Step, Host: r.Host, Port: r.Port, Allowed: r.Allowed})
+		}
+		out[i].Requests++
+		out[i].Blocked = out[i].Blocked || r.Blocked
+	}
+	return out
+}
+
+// Violations returns the requests the policy does not allow, whether or
+// not they were blocked.
+func Violations(requests []Request) []Request {
+	var out []Request
+	for _, r := range requests {
+		if !r.Allowed {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Report writes a table of the destinations of requests to w.
+func Report(w io.Writer, mode Mode, requests []Request) error {
+	dests := Destinations(requests)
+	violations := len(Violations(requests))
+	verb := "would be blocked"
+	if mode == ModeEnforce {
+		verb = "blocked"
+	}
+	fmt.Fprintf(w, "Network policy (%s): %d requests to %d destinations, %d %s\n", mode, len(requests), len(dests), violations, verb)
+	if len(dests) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tDESTINATION\tREQUESTS\tRESULT")
+	for _, d := range dests {
+		result := "allowed"
+		switch {
+		case d.Blocked:
+			result = "blocked"
+		case !d.Allowed:
+			result = "not allowed"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", d.Step, net.JoinHostPort(d.Host, strconv.Itoa(d.Port)), d.Requests, result)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy restricts which jobs a token may come from. Each field is a list of
+// patterns; a claim must match one of them. An empty list allows anything,
+// except Audience, which is always checked. Patterns are compared exactly
+// except that * matches any run of characters, including /, as in cloud
+// provider trust policies.
+type Policy struct {
+	// Audience must be one of the token's audiences. It is required so that
+	// tokens minted for another service are not accepted.
+	Audience string `yaml:"audience" json:"audience"`
+
+	Subject         []string `yaml:"sub,omitempty" json:"sub,omitempty"`
+	Repository      []string `yaml:"repository,omitempty" json:"repository,omitempty"`
+	RepositoryOwner []string `yaml:"repository_owner,omitempty" json:"repository_owner,omitempty"`
+	Ref             []string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	Workflow        []string `yaml:"workflow,omitempty" json:"workflow,omitempty"`
+	JobWorkflowRef  []string `yaml:"job_workflow_ref,omitempty" json:"job_workflow_ref,omitempty"`
+	Environment     []string `yaml:"environment,omitempty" json:"environment,omitempty"`
+	EventName       []string `yaml:"event_name,omitempty" json:"event_name,omitempty"`
+}
+
+// Check reports the first claim that the policy rejects.
+func (p *Policy) Check(c *Claims) error {
+	if p.Audience == "" {
+		return fmt.Errorf("policy has no audience")
+	}
+	if !c.Audience.Contains(p.Audience) {
+		return fmt.Errorf("token audience %q does not include %q", []string(c.Audience), p.Audience)
+	}
+	for _, f := range []struct {
+		name     string
+		value    string
+		patterns []string
+	}{
+		{"sub", c.Subject, p.Subject},
+		{"repository", c.Repository, p.Repository},
+		{"repository_owner", c.RepositoryOwner, p.RepositoryOwner},
+		{"ref", c.Ref, p.Ref},
+		{"workflow", c.Workflow, p.Workflow},
+		{"job_workflow_ref", c.JobWorkflowRef, p.JobWorkflowRef},
+		{"environment", c.Environment, p.Environment},
+		{"event_name", c.EventName, p.EventName},
+	} {
+		if len(f.patterns) == 0 {
+			continue
+		}
+		ok := false
+		for _, pat := range f.patterns {
+			if Match(pat, f.value) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return fmt.Errorf("%s claim %q is not allowed by the policy", f.name, f.value)
+		}
+	}
+	return nil
+}
+
+// Match reports whether s matches pattern, where * matches any run of
+// characters.
+func Match(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oidc requests the OIDC token GitHub issues to a job and verifies
+// such tokens on the receiving side.
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/transport"
+)
+
+// Issuer is the issuer of tokens for github.com.
+const Issuer = "https://token.actions.githubusercontent.com"
+
+// Claims are the claims of a GitHub Actions token that policies look at.
+type Claims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  Audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	IssuedAt  int64    `json:"iat"`
+
+	Repository        string `json:"repository"`
+	RepositoryID      string `json:"repository_id"`
+	RepositoryOwner   string `json:"repository_owner"`
+	RepositoryOwnerID string `json:"repository_owner_id"`
+	Visibility        string `json:"repository_visibility"`
+	Ref               string `json:"ref"`
+	RefType           string `json:"ref_type"`
+	RefProtected      string `json:"ref_protected"`
+	SHA               string `json:"sha"`
+	Workflow          string `json:"workflow"`
+	WorkflowRef       string `json:"workflow_ref"`
+	JobWorkflowRef    string `json:"job_workflow_ref"`
+	Environment       string `json:"environment"`
+	EventName         string `json:"event_name"`
+	Actor             string `json:"actor"`
+	RunID             string `json:"run_id"`
+	RunAttempt        string `json:"run_attempt"`
+	RunnerEnvironment string `json:"runner_environment"`
+}
+
+// Audience is the aud claim, which may be a string or a list.
+type Audience []string
+
+// UnmarshalJSON accepts both forms.
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return fmt.Errorf("invalid aud claim: %w", err)
+	}
+	*a = list
+	return nil
+}
+
+// Contains reports whether aud is one of the audiences.
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseUnverified decodes the claims of a token without checking its
+// signature. Use a Verifier for anything that grants access.
+func ParseUnverified(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token payload: %w", err)
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode token claims: %w", err)
+	}
+	return &c, nil
+}
+
+// Requester fetches tokens from inside a job. The job needs the
+// id-token: write permission.
+type Requester struct {
+	// URL and Token default to ACTIONS_ID_TOKEN_REQUEST_URL and
+	// ACTIONS_ID_TOKEN_REQUEST_TOKEN.
+	URL, Token string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// MaxRetries defaults to 3.
+	MaxRetries int
+}
+
+// GetToken returns a token for audience, or for the default audience (the
+// repository owner's URL) if it is empty.
+func (r *Requester) GetToken(ctx context.Context, audience string) (string, error) {
+	reqURL, token := r.URL, r.Token
+	if reqURL == "" {
+		reqURL = endpoints.FromEnv().IDTokenRequest
+	}
+	if token == "" {
+		token = os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	}
+	if reqURL == "" || token == "" {
+		return "", fmt.Errorf("ACTIONS_ID_TOKEN_REQUEST_URL is not set; does the job have the id-token: write permission?")
+	}
+	if audience != "" {
+		u, err := url.Parse(reqURL)
+		if err != nil {
+			return "", err
+		}
+		q := u.Query()
+		q.Set("audience", audience)
+		u.RawQuery = q.Encode()
+		reqURL = u.String()
+	}
+	value, err := r.fetch(ctx, reqURL, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ID token: %w", err)
+	}
+	return value, nil
+}
+
+func (r *Requester) fetch(ctx context.Context, reqURL, token string) (string, error) {
+	client := &http.Client{}
+	if r.HTTPClient != nil {
+		*client = *r.HTTPClient
+	}
+	client.Transport = transport.Chain(client.Transport,
+		transport.Retry(transport.RetryOptions{MaxRetries: r.MaxRetries}),
+		transport.SecondaryRateLimit(transport.RateLimitOptions{MaxRetries: r.MaxRetries}),
+	)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<12))
+		return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if body.Value == "" {
+		return "", fmt.Errorf("response has no token")
+	}
+	return body.Value, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/endpoints"
+)
+
+// Leeway is the clock skew allowed when checking exp, nbf and iat.
+const Leeway = time.Minute
+
+// Verifier checks token signatures against the issuer's published keys and
+// then applies a Policy.
+type Verifier struct {
+	// Issuer defaults to the issuer of endpoints.FromEnv, which is Issuer
+	// outside GHES and GHE.com jobs.
+	Issuer string
+	// Policy is applied to the claims of every verified token.
+	Policy Policy
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+// Verify checks the signature, issuer, audience and lifetime of token, then
+// the policy, and returns its claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT")
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token header: %w", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return nil, fmt.Errorf("failed to decode token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode token signature: %w", err)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("invalid token signature")
+	}
+
+	claims, err := ParseUnverified(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Issuer != v.issuer() {
+		return nil, fmt.Errorf("token issuer is %q, want %q", claims.Issuer, v.issuer())
+	}
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	t := now()
+	switch {
+	case claims.ExpiresAt == 0 || t.After(time.Unix(claims.ExpiresAt, 0).Add(Leeway)):
+		return nil, fmt.Errorf("token has expired")
+	case claims.NotBefore != 0 && t.Before(time.Unix(claims.NotBefore, 0).Add(-Leeway)):
+		return nil, fmt.Errorf("token is not valid yet")
+	case claims.IssuedAt != 0 && t.Before(time.Unix(claims.IssuedAt, 0).Add(-Leeway)):
+		return nil, fmt.Errorf("token was issued in the future")
+	}
+	if err := v.Policy.Check(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) issuer() string {
+	if v.Issuer != "" {
+		return strings.TrimSuffix(v.Issuer, "/")
+	}
+	return endpoints.FromEnv().OIDCIssuer
+}
+
+// key returns the signing key kid, refetching the key set when kid is
+// unknown. Refetches are limited so bogus kids can't hammer the issuer.
+func (v *Verifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if k, ok := v.keys[kid]; ok {
+		return k, nil
+	}
+	if v.keys != nil && time.Since(v.fetched) < time.Minute {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetched = keys, time.Now()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	var config struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, v.issuer()+"/.well-known/openid-configuration", &config); err != nil {
+		return nil, fmt.Errorf("failed to get OpenID configuration: %w", err)
+	}
+	if config.JWKSURI == "" {
+		return nil, fmt.Errorf("OpenID configuration has no jwks_uri")
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, config.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to get signing keys: %w", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("key %q: invalid exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+func (v *Verifier) getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
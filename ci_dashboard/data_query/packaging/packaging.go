@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package packaging distributes an action written in Go as precompiled
+// binaries: it builds the main package for every runner platform and
+// generates the composite action.yml that downloads and verifies the
+// binary of the runner's platform from the action's release, so the action
+// needs neither Docker nor Node.
+package packaging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Platform is a GOOS and GOARCH a binary is built for.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// DefaultPlatforms are the platforms of GitHub's hosted runners.
+var DefaultPlatforms = []Platform{
+	{"linux", "amd64"}, {"linux", "arm64"},
+	{"darwin", "amd64"}, {"darwin", "arm64"},
+	{"windows", "amd64"}, {"windows", "arm64"},
+}
+
+var (
+	runnerOS   = map[string]string{"linux": "Linux", "darwin": "macOS", "windows": "Windows"}
+	runnerArch = map[string]string{"amd64": "X64", "arm64": "ARM64", "386": "X86", "arm": "ARM"}
+)
+
+// ParsePlatform parses os/arch, as in linux/amd64.
+func ParsePlatform(s string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(s, "/")
+	p := Platform{goos, goarch}
+	if !ok || p.RunnerOS() == "" || p.RunnerArch() == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q; want os/arch with os linux, darwin or windows and arch amd64, arm64, 386 or arm", s)
+	}
+	return p, nil
+}
+
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// RunnerOS returns the runner.os of the platform, or "" if no runner has
+// it.
+func (p Platform) RunnerOS() string {
+	return runnerOS[p.OS]
+}
+
+// RunnerArch returns the runner.arch of the platform, or "".
+func (p Platform) RunnerArch() string {
+	return runnerArch[p.Arch]
+}
+
+// BinaryName names the binary of an action for a platform, as in
+// hello-linux-amd64 or hello-windows-arm64.exe.
+func BinaryName(name string, p Platform) string {
+	n := name + "-" + p.OS + "-" + p.Arch
+	if p.OS == "windows" {
+		n += ".exe"
+	}
+	return n
+}
+
+// BuildOptions configure Build.
+type BuildOptions struct {
+	// Dir is the directory of the main package.
+	Dir string
+	// Name is the base name of the binaries.
+	Name string
+	// OutDir is where the binaries are written.
+	OutDir string
+	// Platforms default to DefaultPlatforms.
+	Platforms []Platform
+	// LDFlags are passed to go build after -s -w.
+	LDFlags string
+	// Stderr receives the output of go build. Defaults to os.Stderr.
+	Stderr io.Writer
+}
+
+// Binary is a built binary.
+type Binary struct {
+	Platform Platform
+	Path     string
+	SHA256   string
+}
+
+// Build builds static binaries of the main package for every platform, in
+// order.
+func Build(ctx context.Context, opts BuildOptions) ([]*Binary, error) {
+	if len(opts.Platforms) == 0 {
+		opts.Platforms = DefaultPlatforms
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+	out, err := filepath.Abs(opts.OutDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return nil, err
+	}
+	var binaries []*Binary
+	for _, p := range opts.Platforms {
+		path := filepath.Join(out, BinaryName(opts.Name, p))
+		cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-ldflags", strings.TrimSpace("-s -w "+opts.LDFlags), "-o", path, ".")
+		cmd.Dir = opts.Dir
+		cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS="+p.OS, "GOARCH="+p.Arch)
+		cmd.Stdout, cmd.Stderr = opts.Stderr, opts.Stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to build for %s: %w", p, err)
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return nil, err
+		}
+		binaries = append(binaries, &Binary{Platform: p, Path: path, SHA256: sum})
+	}
+	return binaries, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packaging
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/workflow"
+)
+
+// ChecksumsAsset is the release asset listing the SHA-256 of the binaries
+// in the format of sha256sum, which the wrapper checks them against.
+const ChecksumsAsset = "checksums.txt"
+
+// wrapperHeader marks a generated action.yml.
+const wrapperHeader = "# Generated by actions package from the inputs and outputs below; runs is\n# rewritten on every run.\n"
+
+// Wrapper returns the metadata of a composite action with the name,
+// description, inputs, outputs and branding of meta that runs the binary
+// named name of the runner's platform. It downloads the binary and the
+// checksums from the release the action is used at, so the action must be
+// used at a release tag, and keeps it in RUNNER_TEMP for later steps.
+func Wrapper(meta *metadata.Action, name string, platforms []Platform) *metadata.Action {
+	if len(platforms) == 0 {
+		platforms = DefaultPlatforms
+	}
+	w := &metadata.Action{
+		Name:        meta.Name,
+		Author:      meta.Author,
+		Description: meta.Description,
+		Inputs:      meta.Inputs,
+		Branding:    meta.Branding,
+	}
+	env := map[string]string{
+		"GH_TOKEN":          "${{ github.token }}",
+		"ACTION_REPOSITORY": "${{ github.action_repository }}",
+		"ACTION_REF":        "${{ github.action_ref }}",
+	}
+	for input := range meta.Inputs {
+		// The runner sets INPUT_ variables only for Docker and JavaScript
+		// actions.
+		env["INPUT_"+strings.ToUpper(strings.ReplaceAll(input, " ", "_"))] = "${{ inputs." + input + " }}"
+	}
+	if len(meta.Outputs) > 0 {
+		w.Outputs = map[string]*metadata.Output{}
+		for n, o := range meta.Outputs {
+			w.Outputs[n] = &metadata.Output{Description: o.Description, Value: "${{ steps.run.outputs." + n + " }}"}
+		}
+	}
+	w.Runs = metadata.Runs{
+		Using: metadata.UsingComposite,
+		Steps: []*workflow.Step{{ID: "run", Shell: "bash", Run: script(name, platforms), Env: env}},
+	}
+	return w
+}
+
+func script(name string, platforms []Platform) string {
+	var cases []string
+	for _, p := range platforms {
+		cases = append(cases, fmt.Sprintf("  %s/%s) bin=%s ;;\n", p.RunnerOS(), p.RunnerArch(), BinaryName(name, p)))
+	}
+	sort.Strings(cases)
+	return `set -euo pipefail
+case "$RUNNER_OS/$RUNNER_ARCH" in
+` + strings.Join(cases, "") + `  *) echo "::error::` + name + ` has no binary for $RUNNER_OS/$RUNNER_ARCH"; exit 1 ;;
+esac
+dir="$RUNNER_TEMP/` + name + `-$ACTION_REF"
+if [ ! -x "$dir/$bin" ]; then
+  gh release download "$ACTION_REF" -R "$ACTION_REPOSITORY" -p "$bin" -p ` + ChecksumsAsset + ` -D "$dir" --clobber
+  if command -v sha256sum >/dev/null; then sum=sha256sum; else sum="shasum -a 256"; fi
+  (cd "$dir" && grep "  $bin\$" ` + ChecksumsAsset + ` | $sum -c -)
+  chmod +x "$dir/$bin"
+fi
+"$dir/$bin"
+`
+}
+
+// MarshalWrapper formats the metadata of Wrapper as action.yml.
+func MarshalWrapper(w *metadata.Action) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(wrapperHeader)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(w); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pathfilter decides which named path filters a set of changed
+// files matches, for monorepo workflows that run only the jobs whose code
+// changed. Filters are written as for dorny/paths-filter and matched as
+// GitHub matches on.<event>.paths.
+package pathfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/changes"
+	"testingdashboard/m/v2/glob"
+	"testingdashboard/m/v2/triggers"
+)
+
+// Change is a changed file.
+type Change = changes.File
+
+// Filter is a named list of rules.
+type Filter struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a path pattern, optionally limited to files with some statuses.
+type Rule struct {
+	Pattern string `json:"pattern"`
+	// Statuses limits the rule to files with these statuses; empty means
+	// any.
+	Statuses []string `json:"statuses,omitempty"`
+}
+
+// Parse reads filters in the dorny/paths-filter format: a mapping from
+// filter names to a pattern or a list of patterns. A list entry may be a
+// mapping from statuses joined by | to patterns, such as
+// "added|modified: src/**", and nested lists, which YAML anchors produce,
+// are flattened. {a,b} alternatives are expanded.
+func Parse(data []byte) ([]Filter, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: filters must be a mapping of names to patterns", root.Line)
+	}
+	var filters []Filter
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		f := Filter{Name: root.Content[i].Value}
+		if err := f.add(root.Content[i+1], nil); err != nil {
+			return nil, fmt.Errorf("filter %s: %w", f.Name, err)
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+func (f *Filter) add(n *yaml.Node, only []string) error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	switch n.Kind {
+	case yaml.ScalarNode:
+		for _, p := range glob.ExpandBraces(n.Value) {
+			if err := validate(p); err != nil {
+				return fmt.Errorf("line %d: %w", n.Line, err)
+			}
+			f.Rules = append(f.Rules, Rule{Pattern: p, Statuses: only})
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if err := f.add(item, only); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		if only != nil {
+			return fmt.Errorf("line %d: status mappings cannot be nested", n.Line)
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			var limit []string
+			for _, s := range strings.Split(key.Value, "|") {
+				s = strings.TrimSpace(s)
+				if !slices.Contains(changes.Statuses, s) {
+					return fmt.Errorf("line %d: unknown file status %q", key.Line, s)
+				}
+				limit = append(limit, s)
+			}
+			if err := f.add(n.Content[i+1], limit); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("line %d: expected patterns", n.Line)
+	}
+	return nil
+}
+
+func validate(pattern string) error {
+	_, err := triggers.CompilePattern(strings.TrimPrefix(pattern, "!"))
+	return err
+}
+
+// FromTrigger returns a filter named name that matches the files that
+// would trigger a workflow with the paths or paths-ignore filter of an
+// event; at most one of them may be set.
+func FromTrigger(name string, paths, pathsIgnore []string) (Filter, error) {
+	f := Filter{Name: name}
+	if len(paths) > 0 && len(pathsIgnore) > 0 {
+		return f, fmt.Errorf("paths and paths-ignore cannot be used together")
+	}
+	for _, p := range paths {
+		f.Rules = append(f.Rules, Rule{Pattern: p})
+	}
+	if len(pathsIgnore) > 0 {
+		// A file triggers the workflow unless paths-ignore selects it.
+		f.Rules = append(f.Rules, Rule{Pattern: "**"})
+		for _, p := range pathsIgnore {
+			if q, ok := strings.CutPrefix(p, "!"); ok {
+				f.Rules = append(f.Rules, Rule{Pattern: q})
+			} else {
+				f.Rules = append(f.Rules, Rule{Pattern: "!" + p})
+			}
+		}
+	}
+	for _, r := range f.Rules {
+		if err := validate(r.Pattern); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// Matches reports whether the filter selects c. Rules apply in order and
+// the last one that matches decides, so a pattern starting with ! excludes
+// what earlier rules selected, as in a paths filter. Rules limited to
+// other statuses are skipped.
+func (f *Filter) Matches(c Change) (bool, error) {
+	matched := false
+	for _, r := range f.Rules {
+		if len(r.Statuses) > 0 && !slices.Contains(r.Statuses, c.Status) {
+			continue
+		}
+		pattern, negate := strings.CutPrefix(r.Pattern, "!")
+		re, err := triggers.CompilePattern(pattern)
+		if err != nil {
+			return false, err
+		}
+		if re.MatchString(c.Path) {
+			matched = !negate
+		}
+	}
+	return matched, nil
+}
+
+// Result is which changed files each filter matched.
+type Result struct {
+	Filters []FilterResult `json:"filters"`
+}
+
+// FilterResult is the changed files one filter matched.
+type FilterResult struct {
+	Name  string   `json:"name"`
+	Files []Change `json:"files"`
+}
+
+// Matched reports whether the filter matched any file.
+func (r FilterResult) Matched() bool {
+	return len(r.Files) > 0
+}
+
+// Match applies every filter to the changes.
+func Match(filters []Filter, changed []Change) (*Result, error) {
+	res := &Result{}
+	for i := range filters {
+		fr := FilterResult{Name: filters[i].Name, Files: []Change{}}
+		for _, c := range changed {
+			ok, err := filters[i].Matches(c)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: %w", filters[i].Name, err)
+			}
+			if ok {
+				fr.Files = append(fr.Files, c)
+			}
+		}
+		res.Filters = append(res.Filters, fr)
+	}
+	return res, nil
+}
+
+// Changes returns the names of the filters that matched.
+func (r *Result) Changes() []string {
+	names := []string{}
+	for _, f := range r.Filters {
+		if f.Matched() {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// Formats of the file lists in outputs.
+const (
+	ListNone   = "none"
+	ListCSV    = "csv"
+	ListJSON   = "json"
+	ListShell  = "shell"
+	ListEscape = "escape"
+)
+
+// Output is a step output.
+type Output struct {
+	Name  string
+	Value string
+}
+
+// Outputs returns the step outputs dorny/paths-filter sets: for each
+// filter, <name> is true or false and <name>_count the number of files it
+// matched, and changes is a JSON list of the filters that matched. Unless
+// listFormat is none or empty, <name>_files lists the files.
+func (r *Result) Outputs(listFormat string) ([]Output, error) {
+	var outs []Output
+	for _, f := range r.Filters {
+		outs = append(outs,
+			Output{f.Name, fmt.Sprint(f.Matched())},
+			Output{f.Name + "_count", fmt.Sprint(len(f.Files))})
+		if listFormat == "" || listFormat == ListNone {
+			continue
+		}
+		files, err := formatList(f.Files, listFormat)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, Output{f.Name + "_files", files})
+	}
+	names, err := json.Marshal(r.Changes())
+	if err != nil {
+		return nil, err
+	}
+	return append(outs, Output{"changes", string(names)}), nil
+}
+
+func formatList(files []Change, format string) (string, error) {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	switch format {
+	case ListCSV:
+		for i, p := range paths {
+			if strings.ContainsAny(p, "\",\n") {
+				paths[i] = `"` + strings.ReplaceAll(p, `"`, `""`) + `"`
+			}
+		}
+		return strings.Join(paths, ","), nil
+	case ListJSON:
+		data, err := json.Marshal(paths)
+		return string(data), err
+	case ListShell:
+		for i, p := range paths {
+			paths[i] = "'" + strings.ReplaceAll(p, "'", `'\''`) + "'"
+		}
+		return strings.Join(paths, " "), nil
+	case ListEscape:
+		for i, p := range paths {
+			var b strings.Builder
+			for _, c := range p {
+				if !isSafe(c) {
+					b.WriteByte('\\')
+				}
+				b.WriteRune(c)
+			}
+			paths[i] = b.String()
+		}
+		return strings.Join(paths, " "), nil
+	}
+	return "", fmt.Errorf("unknown list format %q", format)
+}
+
+func isSafe(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./,:@%+=", c)
+}
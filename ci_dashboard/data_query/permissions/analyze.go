@@ -0,0 +1,239 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package permissions
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// Kinds of findings.
+const (
+	// KindDefaultToken is reported for workflows that leave jobs with the
+	// repository's default token permissions, which may be write access to
+	// every scope.
+	KindDefaultToken = "default-token"
+	KindWriteAll     = "write-all"
+	// KindExcessive is reported for scopes granted beyond what a job needs.
+	KindExcessive = "excessive"
+	// KindMissing is reported for scopes a job seems to need but lacks.
+	KindMissing = "missing"
+	// KindUnknown is reported for steps whose needs cannot be inferred.
+	KindUnknown = "unknown"
+)
+
+// Finding is one problem with a workflow's permissions.
+type Finding struct {
+	Path     string            `json:"path"`
+	Pos      workflow.Position `json:"position"`
+	Job      string            `json:"job,omitempty"`
+	Kind     string            `json:"kind"`
+	Severity string            `json:"severity"` // warning or info
+	Scope    string            `json:"scope,omitempty"`
+	Message  string            `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", f.Path, f.Pos.Line, f.Pos.Column, f.Severity, f.Message, f.Kind)
+}
+
+// JobReport is what Analyze found for one job.
+type JobReport struct {
+	ID string `json:"id"`
+	// Source says where the job's permissions come from: job, workflow or
+	// default. Declared is nil for default.
+	Source   string `json:"source"`
+	Declared Set    `json:"declared"`
+	// Needs is a lower bound when Unknown is not empty.
+	Needs Set `json:"needs"`
+	// Reasons lists, per scope, what needs it.
+	Reasons map[string][]string `json:"reasons"`
+	// Unknown lists the steps whose needs could not be inferred.
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// Report is the result of Analyze.
+type Report struct {
+	Path string       `json:"path"`
+	Jobs []*JobReport `json:"jobs"`
+	// Workflow and Jobs are the suggested permissions: Workflow for the
+	// top-level key, and a complete block for each job that needs more,
+	// since job permissions replace rather than extend the workflow's.
+	Workflow  Set            `json:"suggested_workflow"`
+	Suggested map[string]Set `json:"suggested_jobs"`
+	Findings  []Finding      `json:"findings"`
+}
+
+// Analyze infers the permissions each job of wf needs and compares them
+// with what it is given.
+func Analyze(wf *workflow.Workflow) *Report {
+	r := &Report{Path: wf.Path, Suggested: map[string]Set{}, Findings: []Finding{}}
+	report := func(pos workflow.Position, job, kind, sev, scope, format string, args ...any) {
+		r.Findings = append(r.Findings, Finding{Path: wf.Path, Pos: pos, Job: job, Kind: kind, Severity: sev, Scope: scope, Message: fmt.Sprintf(format, args...)})
+	}
+	wfPos := workflow.Position{Line: 1, Column: 1}
+	if n := workflow.MappingValue(wf.Node, "permissions"); n != nil {
+		wfPos = workflow.Position{Line: n.Line, Column: n.Column}
+	}
+	if wf.Permissions != nil && wf.Permissions.All == "write-all" {
+		report(wfPos, "", KindWriteAll, "warning", "", "workflow grants write-all permissions")
+	}
+	defaulted := false
+	for _, id := range wf.JobIDs() {
+		job := wf.Jobs[id]
+		jr := analyzeJob(job)
+		r.Jobs = append(r.Jobs, jr)
+		for _, u := range jr.Unknown {
+			report(job.Pos, id, KindUnknown, "info", "", "cannot infer the permissions %s needs", u)
+		}
+		switch {
+		case job.Permissions != nil:
+			jr.Source, jr.Declared = "job", Declared(job.Permissions)
+			if job.Permissions.All == "write-all" {
+				report(job.Pos, id, KindWriteAll, "warning", "", "job %s grants write-all permissions", id)
+				continue
+			}
+		case wf.Permissions != nil:
+			jr.Source, jr.Declared = "workflow", Declared(wf.Permissions)
+			if wf.Permissions.All == "write-all" {
+				continue
+			}
+		default:
+			jr.Source = "default"
+			defaulted = true
+			continue
+		}
+		if job.Uses != "" {
+			// The called workflow's jobs need checking on their own.
+			continue
+		}
+		for _, scope := range Scopes {
+			need, have := jr.Needs.Level(scope), jr.Declared.Level(scope)
+			switch {
+			case !Covers(have, need):
+				report(job.Pos, id, KindMissing, "warning", scope, "job %s needs %s: %s for %s but has %s", id, scope, need, strings.Join(jr.Reasons[scope], ", "), have)
+			case have != need:
+				sev := "warning"
+				if len(jr.Unknown) > 0 {
+					sev = "info"
+				}
+				report(job.Pos, id, KindExcessive, sev, scope, "job %s has %s: %s but only needs %s", id, scope, have, need)
+			}
+		}
+	}
+	if defaulted {
+		report(wfPos, "", KindDefaultToken, "warning", "", "workflow does not set permissions, so jobs without their own get the default token, which may have write access to every scope")
+	}
+	sort.SliceStable(r.Findings, func(i, j int) bool { return r.Findings[i].Pos.Line < r.Findings[j].Pos.Line })
+	r.suggest()
+	return r
+}
+
+// suggest fills in the suggested permissions: the levels every job
+// needs at the top, and full blocks for jobs that need more.
+func (r *Report) suggest() {
+	r.Workflow = Set{}
+	if len(r.Jobs) == 0 {
+		return
+	}
+	for scope, level := range r.Jobs[0].Needs {
+		common := level
+		for _, jr := range r.Jobs[1:] {
+			if l := jr.Needs.Level(scope); !Covers(l, common) {
+				common = l
+			}
+		}
+		if common != workflow.PermissionNone {
+			r.Workflow[scope] = common
+		}
+	}
+	for _, jr := range r.Jobs {
+		if jr.Needs.String() != r.Workflow.String() {
+			r.Suggested[jr.ID] = jr.Needs
+		}
+	}
+}
+
+func analyzeJob(job *workflow.Job) *JobReport {
+	jr := &JobReport{ID: job.ID, Needs: Set{}, Reasons: map[string][]string{}}
+	add := func(scope, level, why string) {
+		jr.Needs.Add(scope, level)
+		if !slices.Contains(jr.Reasons[scope], why) {
+			jr.Reasons[scope] = append(jr.Reasons[scope], why)
+		}
+	}
+	if job.Uses != "" {
+		jr.Unknown = append(jr.Unknown, "the called workflow "+job.Uses)
+		return jr
+	}
+	for i, step := range job.Steps {
+		name := fmt.Sprintf("step %d", i+1)
+		if step.Name != "" {
+			name = fmt.Sprintf("step %q", step.Name)
+		}
+		if step.Run != "" {
+			for _, u := range ScriptUses(step.Run) {
+				add(u.Scope, u.Level, u.What)
+			}
+			continue
+		}
+		uses, err := workflow.ParseUses(step.Uses)
+		if err != nil || uses.Kind != workflow.UsesRepository {
+			jr.Unknown = append(jr.Unknown, name)
+			continue
+		}
+		key := uses.Repository()
+		if uses.Path != "" {
+			key += "/" + uses.Path
+		}
+		if key == "actions/github-script" {
+			for _, u := range GitHubScriptUses(step.With["script"]) {
+				add(u.Scope, u.Level, u.What)
+			}
+			continue
+		}
+		action, ok := lookup(key)
+		if !ok {
+			jr.Unknown = append(jr.Unknown, name+" ("+key+")")
+			continue
+		}
+		for scope, level := range action.Needs {
+			add(scope, level, key)
+		}
+		if action.When != nil {
+			for scope, level := range action.When(step.With) {
+				add(scope, level, key)
+			}
+		}
+	}
+	return jr
+}
+
+// lookup finds an action in Catalog, ignoring case as GitHub does.
+func lookup(key string) (Action, bool) {
+	if a, ok := Catalog[key]; ok {
+		return a, true
+	}
+	for k, a := range Catalog {
+		if strings.EqualFold(k, key) {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
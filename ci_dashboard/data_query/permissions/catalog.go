@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package permissions
+
+import (
+	"strings"
+)
+
+// Action describes the token permissions an action needs.
+type Action struct {
+	// Needs are needed whenever the action is used.
+	Needs Set
+	// When adds permissions depending on the step's with: inputs.
+	When func(with map[string]string) Set
+}
+
+// Catalog maps action repositories, such as "actions/checkout", to what
+// they need. Actions that only use the runtime token, such as the cache and
+// artifact actions, need nothing. Callers may add their own actions.
+var Catalog = map[string]Action{
+	"actions/checkout":                 {Needs: Set{"contents": "read"}},
+	"actions/cache":                    {},
+	"actions/cache/restore":            {},
+	"actions/cache/save":               {},
+	"actions/upload-artifact":          {},
+	"actions/download-artifact":        {When: ifInput("run-id", Set{"actions": "read"})},
+	"actions/setup-go":                 {},
+	"actions/setup-node":               {},
+	"actions/setup-python":             {},
+	"actions/setup-java":               {},
+	"actions/setup-dotnet":             {},
+	"actions/upload-pages-artifact":    {},
+	"actions/deploy-pages":             {Needs: Set{"pages": "write", "id-token": "write"}},
+	"actions/labeler":                  {Needs: Set{"contents": "read", "pull-requests": "write"}},
+	"actions/stale":                    {Needs: Set{"issues": "write", "pull-requests": "write"}},
+	"actions/first-interaction":        {Needs: Set{"issues": "write", "pull-requests": "write"}},
+	"actions/dependency-review-action": {Needs: Set{"contents": "read"}, When: ifInput("comment-summary-in-pr", Set{"pull-requests": "write"})},
+	"actions/attest-build-provenance":  {Needs: Set{"id-token": "write", "attestations": "write", "contents": "read"}},
+	"actions/attest":                   {Needs: Set{"id-token": "write", "attestations": "write", "contents": "read"}},
+	"actions/create-release":           {Needs: Set{"contents": "write"}},
+	"actions/upload-release-asset":     {Needs: Set{"contents": "write"}},
+	"github/codeql-action/init":        {Needs: Set{"security-events": "write", "actions": "read", "contents": "read"}},
+	"github/codeql-action/autobuild":   {},
+	"github/codeql-action/analyze":     {Needs: Set{"security-events": "write", "actions": "read", "contents": "read"}},
+	"github/codeql-action/upload-sarif": {
+		Needs: Set{"security-events": "write", "actions": "read", "contents": "read"},
+	},
+	"softprops/action-gh-release":              {Needs: Set{"contents": "write"}},
+	"release-drafter/release-drafter":          {Needs: Set{"contents": "write", "pull-requests": "read"}},
+	"peter-evans/create-pull-request":          {Needs: Set{"contents": "write", "pull-requests": "write"}},
+	"peter-evans/create-or-update-comment":     {Needs: Set{"issues": "write", "pull-requests": "write"}},
+	"marocchino/sticky-pull-request-comment":   {Needs: Set{"pull-requests": "write"}},
+	"amannn/action-semantic-pull-request":      {Needs: Set{"pull-requests": "read"}},
+	"golangci/golangci-lint-action":            {Needs: Set{"contents": "read"}, When: ifInput("only-new-issues", Set{"pull-requests": "read"})},
+	"gradle/actions/dependency-submission":     {Needs: Set{"contents": "write"}},
+	"ossf/scorecard-action":                    {Needs: Set{"security-events": "write", "id-token": "write", "contents": "read", "actions": "read"}},
+	"codecov/codecov-action":                   {When: ifInput("use_oidc", Set{"id-token": "write"})},
+	"docker/setup-buildx-action":               {},
+	"docker/setup-qemu-action":                 {},
+	"docker/metadata-action":                   {},
+	"docker/build-push-action":                 {},
+	"docker/login-action":                      {When: ghcrLogin},
+	"aws-actions/configure-aws-credentials":    {When: ifInput("role-to-assume", Set{"id-token": "write"})},
+	"google-github-actions/auth":               {When: ifInput("workload_identity_provider", Set{"id-token": "write"})},
+	"azure/login":                              {When: ifInput("client-id", Set{"id-token": "write"})},
+	"sigstore/cosign-installer":                {},
+	"slsa-framework/slsa-github-generator":     {Needs: Set{"id-token": "write", "actions": "read", "contents": "write"}},
+	"pypa/gh-action-pypi-publish":              {Needs: Set{"id-token": "write"}},
+	"JamesIves/github-pages-deploy-action":     {Needs: Set{"contents": "write"}},
+	"EndBug/add-and-commit":                    {Needs: Set{"contents": "write"}},
+	"stefanzweifel/git-auto-commit-action":     {Needs: Set{"contents": "write"}},
+	"dorny/paths-filter":                       {Needs: Set{"pull-requests": "read"}},
+	"dorny/test-reporter":                      {Needs: Set{"checks": "write", "contents": "read"}},
+	"EnricoMi/publish-unit-test-result-action": {Needs: Set{"checks": "write", "pull-requests": "write"}},
+}
+
+// ifInput returns a When that grants s if the input is set and not false.
+func ifInput(name string, s Set) func(map[string]string) Set {
+	return func(with map[string]string) Set {
+		if v, ok := with[name]; ok && v != "" && v != "false" {
+			return s
+		}
+		return nil
+	}
+}
+
+// ghcrLogin needs packages: write when logging in to GitHub Packages with
+// the job token, which is almost always to push.
+func ghcrLogin(with map[string]string) Set {
+	token := strings.Contains(with["password"], "GITHUB_TOKEN") || strings.Contains(with["password"], "github.token")
+	if strings.Contains(with["registry"], "ghcr.io") && token {
+		return Set{"packages": "write"}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package permissions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// FromContext returns the permissions the running job declares for its
+// GITHUB_TOKEN, read from the workflow file named by GITHUB_WORKFLOW_REF
+// in GITHUB_WORKSPACE. It reports false outside Actions and when neither
+// the job nor the workflow sets permissions, since the default then
+// depends on repository settings.
+func FromContext() (Set, bool) {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil, false
+	}
+	// GITHUB_WORKFLOW_REF is owner/repo/.github/workflows/ci.yml@ref. A
+	// reusable workflow's jobs see their caller's ref, so their job is
+	// not found below.
+	ref, _, _ := strings.Cut(os.Getenv("GITHUB_WORKFLOW_REF"), "@")
+	parts := strings.SplitN(ref, "/", 3)
+	if len(parts) != 3 {
+		return nil, false
+	}
+	wf, err := workflow.ParseFile(filepath.Join(os.Getenv("GITHUB_WORKSPACE"), filepath.FromSlash(parts[2])))
+	if err != nil {
+		return nil, false
+	}
+	job := wf.Jobs[os.Getenv("GITHUB_JOB")]
+	switch {
+	case job == nil:
+		return nil, false
+	case job.Permissions != nil:
+		return Declared(job.Permissions), true
+	case wf.Permissions != nil:
+		return Declared(wf.Permissions), true
+	}
+	return nil, false
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package permissions
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Use is one use of the token found in a script.
+type Use struct {
+	Scope, Level string
+	// What is the command or call that needs it.
+	What string
+}
+
+// ghCommands maps "gh <group> <command>" to what it needs. Commands not
+// listed with their group are looked up as "<group> *", where read-only
+// commands are the exception.
+var ghCommands = map[string][2]string{
+	"pr view": {"pull-requests", "read"}, "pr list": {"pull-requests", "read"},
+	"pr diff": {"pull-requests", "read"}, "pr checks": {"pull-requests", "read"},
+	"pr status": {"pull-requests", "read"}, "pr checkout": {"contents", "read"},
+	"pr merge": {"contents", "write"},
+	"pr *":     {"pull-requests", "write"},
+
+	"issue view": {"issues", "read"}, "issue list": {"issues", "read"}, "issue status": {"issues", "read"},
+	"issue *":    {"issues", "write"},
+	"label list": {"issues", "read"},
+	"label *":    {"issues", "write"},
+
+	"release view": {"contents", "read"}, "release list": {"contents", "read"},
+	"release download": {"contents", "read"},
+	"release *":        {"contents", "write"},
+
+	"run view": {"actions", "read"}, "run list": {"actions", "read"},
+	"run download": {"actions", "read"}, "run watch": {"actions", "read"},
+	"run *":         {"actions", "write"},
+	"workflow view": {"actions", "read"}, "workflow list": {"actions", "read"},
+	"workflow *": {"actions", "write"},
+	"cache list": {"actions", "read"},
+	"cache *":    {"actions", "write"},
+
+	"repo view": {"contents", "read"}, "repo clone": {"contents", "read"},
+	"attestation verify": {"attestations", "read"},
+}
+
+var (
+	ghRE = regexp.MustCompile(`(?m)(?:^|[;&|(\s])gh\s+([a-z-]+)\s+([a-z-]+)`)
+	// ghAPIRE matches gh api calls and captures the rest of the line.
+	ghAPIRE = regexp.MustCompile(`(?m)(?:^|[;&|(\s])gh\s+api\s+(.*)$`)
+	// curlRE matches curl or wget lines that talk to the REST API.
+	curlRE   = regexp.MustCompile(`(?m)^.*\b(?:curl|wget)\b.*(?:api\.github\.com|GITHUB_API_URL|github\.api_url).*$`)
+	methodRE = regexp.MustCompile(`(?:-X|--method|--request)[\s=]*["']?([A-Za-z]+)`)
+	fieldRE  = regexp.MustCompile(`\s(?:-f|-F|--field|--raw-field|--input|-d|--data(?:-raw|-binary)?)[\s=]`)
+	repoRE   = regexp.MustCompile(`repos/[^/\s"']+/[^/\s"']+/([a-z-]+)(?:/[^/\s"'?]+/([a-z-]+))?`)
+	gitPush  = regexp.MustCompile(`(?m)(?:^|[;&|(\s])git\s+(?:-C\s+\S+\s+)?push\b`)
+	ghcrPush = regexp.MustCompile(`(?m)(?:docker|podman)\s+push\s+["']?ghcr\.io/|oras\s+push\s+ghcr\.io/`)
+	oidcRE   = regexp.MustCompile(`ACTIONS_ID_TOKEN_REQUEST_(?:URL|TOKEN)`)
+	// restRE matches Octokit calls such as github.rest.issues.createComment
+	// in actions/github-script.
+	restRE = regexp.MustCompile(`\.rest\.([a-zA-Z]+)\.([a-zA-Z]+)`)
+)
+
+// ScriptUses returns the token uses found in a run: script by heuristics:
+// gh commands, REST calls with curl, git push, pushes to GitHub Packages
+// and OIDC token requests.
+func ScriptUses(script string) []Use {
+	var uses []Use
+	for _, m := range ghRE.FindAllStringSubmatch(script, -1) {
+		what := "gh " + m[1] + " " + m[2]
+		if m[1] == "api" {
+			continue
+		}
+		need, ok := ghCommands[m[1]+" "+m[2]]
+		if !ok {
+			need, ok = ghCommands[m[1]+" *"]
+		}
+		if ok {
+			uses = append(uses, Use{Scope: need[0], Level: need[1], What: what})
+		}
+	}
+	for _, m := range ghAPIRE.FindAllStringSubmatch(script, -1) {
+		method := "GET"
+		if fieldRE.MatchString(" " + m[1]) {
+			method = "POST"
+		}
+		uses = append(uses, apiUses(m[1], method, "gh api")...)
+	}
+	for _, line := range curlRE.FindAllString(script, -1) {
+		method := "GET"
+		if fieldRE.MatchString(line) {
+			method = "POST"
+		}
+		uses = append(uses, apiUses(line, method, "curl")...)
+	}
+	if gitPush.MatchString(script) {
+		uses = append(uses, Use{Scope: "contents", Level: "write", What: "git push"})
+	}
+	if ghcrPush.MatchString(script) {
+		uses = append(uses, Use{Scope: "packages", Level: "write", What: "push to ghcr.io"})
+	}
+	if oidcRE.MatchString(script) {
+		uses = append(uses, Use{Scope: "id-token", Level: "write", What: "OIDC token request"})
+	}
+	return uses
+}
+
+// apiUses maps the REST path in a command line to the scope it needs.
+func apiUses(line, method, what string) []Use {
+	if m := methodRE.FindStringSubmatch(line); m != nil {
+		method = strings.ToUpper(m[1])
+	}
+	m := repoRE.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	scope := pathScopes[m[1]]
+	// Statuses of a commit are under commits.
+	if m[1] == "commits" && (m[2] == "statuses" || m[2] == "status") {
+		scope = "statuses"
+	}
+	if m[1] == "commits" && (m[2] == "check-runs" || m[2] == "check-suites") {
+		scope = "checks"
+	}
+	if scope == "" {
+		return nil
+	}
+	level := "read"
+	if method != "GET" && method != "HEAD" {
+		level = "write"
+	}
+	return []Use{{Scope: scope, Level: level, What: what + " " + method + " " + m[0]}}
+}
+
+// pathScopes maps the first path element under /repos/{owner}/{repo} to
+// its scope.
+var pathScopes = map[string]string{
+	"issues": "issues", "labels": "issues", "milestones": "issues",
+	"pulls":    "pull-requests",
+	"contents": "contents", "git": "contents", "releases": "contents", "commits": "contents",
+	"branches": "contents", "tags": "contents", "merges": "contents", "dispatches": "contents",
+	"compare": "contents", "tarball": "contents", "zipball": "contents",
+	"actions":    "actions",
+	"check-runs": "checks", "check-suites": "checks",
+	"statuses":    "statuses",
+	"deployments": "deployments", "environments": "deployments",
+	"pages":         "pages",
+	"code-scanning": "security-events", "secret-scanning": "security-events",
+	"attestations": "attestations",
+}
+
+// restScopes maps Octokit namespaces to scopes.
+var restScopes = map[string]string{
+	"issues": "issues", "reactions": "issues",
+	"pulls": "pull-requests",
+	"repos": "contents", "git": "contents",
+	"actions":      "actions",
+	"checks":       "checks",
+	"packages":     "packages",
+	"codeScanning": "security-events", "secretScanning": "security-events",
+}
+
+// readMethod reports whether an Octokit method only reads.
+func readMethod(name string) bool {
+	for _, prefix := range []string{"get", "list", "check", "compare", "download"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GitHubScriptUses returns the token uses of an actions/github-script
+// script, from the Octokit REST calls it makes.
+func GitHubScriptUses(script string) []Use {
+	var uses []Use
+	for _, m := range restRE.FindAllStringSubmatch(script, -1) {
+		scope := restScopes[m[1]]
+		switch {
+		case m[1] == "repos" && strings.Contains(m[2], "Status"):
+			scope = "statuses"
+		case m[1] == "repos" && strings.Contains(m[2], "Deployment"):
+			scope = "deployments"
+		case m[1] == "repos" && strings.Contains(m[2], "Pages"):
+			scope = "pages"
+		}
+		if scope == "" {
+			continue
+		}
+		level := "write"
+		if readMethod(m[2]) {
+			level = "read"
+		}
+		uses = append(uses, Use{Scope: scope, Level: level, What: "github.rest." + m[1] + "." + m[2]})
+	}
+	return uses
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package permissions works out the GITHUB_TOKEN permissions a workflow's
+// jobs need and compares them with the permissions they are given.
+package permissions
+
+import (
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// Scopes are the permission scopes of GITHUB_TOKEN.
+var Scopes = []string{
+	"actions", "attestations", "checks", "contents", "deployments", "discussions",
+	"id-token", "issues", "models", "packages", "pages", "pull-requests",
+	"security-events", "statuses",
+}
+
+var rank = map[string]int{workflow.PermissionNone: 0, workflow.PermissionRead: 1, workflow.PermissionWrite: 2}
+
+// Set maps scopes to levels. Missing scopes are none.
+type Set map[string]string
+
+// Level returns the level of scope.
+func (s Set) Level(scope string) string {
+	if l, ok := s[scope]; ok {
+		return l
+	}
+	return workflow.PermissionNone
+}
+
+// Add raises scope to at least level and reports whether it changed.
+func (s Set) Add(scope, level string) bool {
+	if rank[level] <= rank[s.Level(scope)] {
+		return false
+	}
+	s[scope] = level
+	return true
+}
+
+// Merge adds every scope of o.
+func (s Set) Merge(o Set) {
+	for scope, level := range o {
+		s.Add(scope, level)
+	}
+}
+
+// Covers reports whether level grants at least want.
+func Covers(level, want string) bool {
+	return rank[level] >= rank[want]
+}
+
+// String formats the set as "contents: read, issues: write".
+func (s Set) String() string {
+	parts := make([]string, 0, len(s))
+	for _, scope := range s.scopes() {
+		parts = append(parts, scope+": "+s[scope])
+	}
+	if len(parts) == 0 {
+		return "{}"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s Set) scopes() []string {
+	keys := make([]string, 0, len(s))
+	for k, l := range s {
+		if l != workflow.PermissionNone {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// YAML renders the set as a permissions: block indented by indent.
+func (s Set) YAML(indent string) string {
+	scopes := s.scopes()
+	if len(scopes) == 0 {
+		return indent + "permissions: {}\n"
+	}
+	var b strings.Builder
+	b.WriteString(indent + "permissions:\n")
+	for _, scope := range scopes {
+		b.WriteString(indent + "  " + scope + ": " + s[scope] + "\n")
+	}
+	return b.String()
+}
+
+// Declared returns the levels granted by a permissions key.
+func Declared(p *workflow.Permissions) Set {
+	s := Set{}
+	for _, scope := range Scopes {
+		if l := p.Level(scope); l != workflow.PermissionNone {
+			s[scope] = l
+		}
+	}
+	return s
+}
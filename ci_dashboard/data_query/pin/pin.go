@@ -0,0 +1,198 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pin rewrites uses: references to point at commit SHAs, keeping the
+// original tag or branch as a trailing comment.
+package pin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+var shaRE = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsSHA reports whether ref is a full commit SHA.
+func IsSHA(ref string) bool {
+	return shaRE.MatchString(ref)
+}
+
+// Ref is a uses: reference to a repository found in a file.
+type Ref struct {
+	Uses *workflow.Uses
+	// Line and Column locate the value, 1-based.
+	Line, Column int
+	// Comment is the trailing comment on the line, without the #.
+	Comment string
+
+	node *yaml.Node
+}
+
+// Pinned reports whether the reference already names a commit SHA.
+func (r *Ref) Pinned() bool {
+	return IsSHA(r.Uses.Ref)
+}
+
+// Find returns the repository references in a workflow or action metadata
+// file. Local and docker:// references are skipped.
+func Find(data []byte) ([]*Ref, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	var refs []*Ref
+	var visit func(n *yaml.Node)
+	visit = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				if key.Value == "uses" && value.Kind == yaml.ScalarNode && !workflow.IsExpression(value.Value) {
+					if uses, err := workflow.ParseUses(value.Value); err == nil && uses.Kind == workflow.UsesRepository {
+						refs = append(refs, &Ref{
+							Uses:    uses,
+							Line:    value.Line,
+							Column:  value.Column,
+							Comment: strings.TrimSpace(strings.TrimPrefix(value.LineComment, "#")),
+							node:    value,
+						})
+					}
+				}
+			}
+		}
+		for _, c := range n.Content {
+			visit(c)
+		}
+	}
+	visit(&doc)
+	return refs, nil
+}
+
+// Change is one rewritten reference.
+type Change struct {
+	Ref *Ref
+	// From is the ref that was replaced and SHA the commit it resolved to.
+	From, SHA string
+}
+
+// Options controls Pin.
+type Options struct {
+	// Repin re-resolves references that are already pinned, using the ref
+	// named in their trailing comment.
+	Repin bool
+}
+
+// Pin resolves every unpinned repository reference in data and returns the
+// rewritten file. Everything but the reference and its trailing comment is
+// left byte for byte unchanged.
+func Pin(ctx context.Context, data []byte, r Resolver, opts Options) ([]byte, []Change, error) {
+	refs, err := Find(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved := map[string]string{}
+	var changes []Change
+	var edits []Edit
+	for _, ref := range refs {
+		from := ref.Uses.Ref
+		if ref.Pinned() {
+			if !opts.Repin || ref.Comment == "" || strings.ContainsAny(ref.Comment, " \t") {
+				continue
+			}
+			from = ref.Comment
+		}
+		key := ref.Uses.Repository() + "@" + from
+		sha, ok := resolved[key]
+		if !ok {
+			if sha, err = r.Resolve(ctx, ref.Uses.Owner, ref.Uses.Repo, from); err != nil {
+				return nil, nil, fmt.Errorf("line %d: failed to resolve %s: %w", ref.Line, key, err)
+			}
+			resolved[key] = sha
+		}
+		if sha == ref.Uses.Ref {
+			continue
+		}
+		edits = append(edits, Edit{Ref: ref, To: sha, Comment: from})
+		changes = append(changes, Change{Ref: ref, From: from, SHA: sha})
+	}
+	out, err := Rewrite(data, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, changes, nil
+}
+
+// Edit replaces the ref of a reference found by Find.
+type Edit struct {
+	Ref *Ref
+	// To is the new ref.
+	To string
+	// Comment, if set, replaces the trailing comment of the line.
+	Comment string
+}
+
+// Rewrite applies edits to data, which must be the file the references were
+// found in.
+func Rewrite(data []byte, edits []Edit) ([]byte, error) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for _, e := range edits {
+		if err := rewriteLine(lines, e); err != nil {
+			return nil, err
+		}
+	}
+	return bytes.Join(lines, nil), nil
+}
+
+// rewriteLine replaces the value referenced by e and its trailing comment.
+func rewriteLine(lines [][]byte, e Edit) error {
+	ref := e.Ref
+	if ref.Line < 1 || ref.Line > len(lines) {
+		return fmt.Errorf("line %d: out of range", ref.Line)
+	}
+	line := lines[ref.Line-1]
+	body, eol := line, []byte(nil)
+	if i := bytes.IndexAny(line, "\r\n"); i >= 0 {
+		body, eol = line[:i], line[i:]
+	}
+	start := ref.Column - 1
+	if start > len(body) {
+		return fmt.Errorf("line %d: value not found", ref.Line)
+	}
+	// The value may be quoted; keep the quotes.
+	quote := ""
+	if start < len(body) && (body[start] == '"' || body[start] == '\'') {
+		quote = string(body[start])
+	}
+	rest := string(body[start:])
+	original := quote + ref.node.Value + quote
+	if !strings.HasPrefix(rest, original) {
+		return fmt.Errorf("line %d: unexpected formatting of %s", ref.Line, ref.node.Value)
+	}
+	uses := *ref.Uses
+	uses.Ref = e.To
+	out := string(body[:start]) + quote + uses.String() + quote
+	if e.Comment != "" {
+		out += " # " + e.Comment
+	} else {
+		out += rest[len(original):]
+	}
+	lines[ref.Line-1] = append([]byte(out), eol...)
+	return nil
+}
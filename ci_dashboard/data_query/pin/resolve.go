@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/transport"
+)
+
+// Resolver resolves a tag, branch or SHA of a repository to a commit SHA.
+type Resolver interface {
+	Resolve(ctx context.Context, owner, repo, ref string) (string, error)
+}
+
+// DefaultAPIURL is the REST API of github.com.
+const DefaultAPIURL = "https://api.github.com"
+
+// GitHubResolver resolves refs with the GitHub REST API.
+type GitHubResolver struct {
+	// APIURL defaults to the API of endpoints.FromEnv.
+	APIURL string
+	// Token defaults to GITHUB_TOKEN. Anonymous requests are heavily rate
+	// limited.
+	Token string
+	// Client defaults to transport.DefaultClient.
+	Client *http.Client
+}
+
+// Resolve implements Resolver. Annotated tags are peeled to the commit they
+// point at.
+func (r *GitHubResolver) Resolve(ctx context.Context, owner, repo, ref string) (string, error) {
+	base := r.APIURL
+	if base == "" {
+		base = endpoints.FromEnv().API
+	}
+	token := r.Token
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	client := r.Client
+	if client == nil {
+		client = transport.DefaultClient()
+	}
+
+	u := fmt.Sprintf("%s/repos/%s/%s/commits/%s", strings.TrimSuffix(base, "/"),
+		url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	// The sha media type returns just the commit SHA as plain text.
+	req.Header.Set("Accept", "application/vnd.github.sha")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusUnprocessableEntity:
+		return "", fmt.Errorf("%s/%s has no ref %q", owner, repo, ref)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	sha := strings.TrimSpace(string(body))
+	if !IsSHA(sha) {
+		return "", fmt.Errorf("unexpected response %q", sha)
+	}
+	return sha, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy checks workflows against organization rules. Rules come
+// from a declarative policy file; other rule languages can be plugged in
+// by implementing Engine.
+package policy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// Violation is a workflow breaking a rule.
+type Violation struct {
+	Path    string            `json:"path"`
+	Pos     workflow.Position `json:"position"`
+	Rule    string            `json:"rule"`
+	Message string            `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s [%s]", v.Path, v.Pos.Line, v.Pos.Column, v.Message, v.Rule)
+}
+
+// Input is what an engine evaluates.
+type Input struct {
+	Workflow *workflow.Workflow
+	// Document is the workflow decoded into plain maps and slices, for
+	// engines that take JSON-like input.
+	Document map[string]any
+}
+
+// NewInput prepares wf for evaluation.
+func NewInput(wf *workflow.Workflow) (*Input, error) {
+	in := &Input{Workflow: wf, Document: map[string]any{}}
+	if wf.Node != nil {
+		if err := wf.Node.Decode(&in.Document); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", wf.Path, err)
+		}
+	}
+	return in, nil
+}
+
+// Engine evaluates rules against a workflow.
+type Engine interface {
+	Evaluate(ctx context.Context, in *Input) ([]Violation, error)
+}
+
+// Policy is a declarative policy file.
+type Policy struct {
+	Actions  ActionRules  `yaml:"actions"`
+	Triggers TriggerRules `yaml:"triggers"`
+	Jobs     JobRules     `yaml:"jobs"`
+}
+
+// ActionRules restrict the actions and reusable workflows steps and jobs
+// use. Patterns are matched against owner/repo and, for actions in a
+// subdirectory, owner/repo/path, ignoring case; * matches within one path
+// element.
+type ActionRules struct {
+	// Allowed, if not empty, lists the only sources that may be used.
+	Allowed []string `yaml:"allowed"`
+	// Denied sources may not be used even if allowed.
+	Denied []string `yaml:"denied"`
+	// RequireSHA requires references to be pinned to a full commit SHA,
+	// except for sources matching PinExempt.
+	RequireSHA bool     `yaml:"require-sha-pinning"`
+	PinExempt  []string `yaml:"pinning-exempt"`
+	// AllowDocker permits docker:// steps.
+	AllowDocker *bool `yaml:"allow-docker"`
+}
+
+// TriggerRules restrict the events workflows run on.
+type TriggerRules struct {
+	Forbidden []string `yaml:"forbidden"`
+}
+
+// JobRules are required of every job.
+type JobRules struct {
+	// RequireTimeout requires timeout-minutes, and MaxTimeout caps it.
+	RequireTimeout bool    `yaml:"require-timeout-minutes"`
+	MaxTimeout     float64 `yaml:"max-timeout-minutes"`
+	// RequireConcurrency requires a concurrency group at workflow or job
+	// level.
+	RequireConcurrency bool `yaml:"require-concurrency"`
+}
+
+// Parse parses a policy file. Unknown keys are errors so typos do not
+// silently disable rules.
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	for _, pattern := range append(append(append([]string{}, p.Actions.Allowed...), p.Actions.Denied...), p.Actions.PinExempt...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return &p, nil
+}
+
+// Load reads a policy file.
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return p, nil
+}
+
+// matchAny reports whether source matches one of patterns.
+func matchAny(patterns []string, source string) bool {
+	source = strings.ToLower(source)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), source); ok {
+			return true
+		}
+		// owner/* also covers actions in subdirectories.
+		if rest, ok := strings.CutSuffix(strings.ToLower(p), "/*"); ok && strings.HasPrefix(source, rest+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/pin"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Rules violations of a Policy are reported under.
+const (
+	RuleAllowedActions = "allowed-actions"
+	RuleSHAPinning     = "sha-pinning"
+	RuleTrigger        = "forbidden-trigger"
+	RuleTimeout        = "timeout-minutes"
+	RuleConcurrency    = "concurrency"
+)
+
+// Evaluate implements Engine.
+func (p *Policy) Evaluate(_ context.Context, in *Input) ([]Violation, error) {
+	wf := in.Workflow
+	var out []Violation
+	report := func(pos workflow.Position, rule, format string, args ...any) {
+		out = append(out, Violation{Path: wf.Path, Pos: pos, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	on := workflow.Position{Line: 1, Column: 1}
+	if n := workflow.MappingValue(wf.Node, "on"); n != nil {
+		on = workflow.Position{Line: n.Line, Column: n.Column}
+	}
+	for _, name := range p.Triggers.Forbidden {
+		if wf.On.Has(name) {
+			report(on, RuleTrigger, "workflow is triggered by %s, which the policy forbids", name)
+		}
+	}
+
+	for _, id := range wf.JobIDs() {
+		job := wf.Jobs[id]
+		if job.Uses != "" {
+			p.checkUses(job.Uses, job.Pos, report)
+		} else {
+			for _, step := range job.Steps {
+				if step.Uses != "" {
+					p.checkUses(step.Uses, step.Pos, report)
+				}
+			}
+			switch {
+			case job.TimeoutMinutes == nil:
+				if p.Jobs.RequireTimeout {
+					report(job.Pos, RuleTimeout, "job %s does not set timeout-minutes", id)
+				}
+			case p.Jobs.MaxTimeout > 0 && job.TimeoutMinutes.Expression == "" && job.TimeoutMinutes.Value > p.Jobs.MaxTimeout:
+				report(job.Pos, RuleTimeout, "job %s sets timeout-minutes to %g, more than the allowed %g", id, job.TimeoutMinutes.Value, p.Jobs.MaxTimeout)
+			}
+		}
+		if p.Jobs.RequireConcurrency && wf.Concurrency == nil && job.Concurrency == nil {
+			report(job.Pos, RuleConcurrency, "job %s has no concurrency group, at job or workflow level", id)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Pos.Line < out[j].Pos.Line })
+	return out, nil
+}
+
+func (p *Policy) checkUses(s string, pos workflow.Position, report func(workflow.Position, string, string, ...any)) {
+	if workflow.IsExpression(s) {
+		return
+	}
+	uses, err := workflow.ParseUses(s)
+	if err != nil {
+		return
+	}
+	switch uses.Kind {
+	case workflow.UsesLocal:
+		return
+	case workflow.UsesDocker:
+		if p.Actions.AllowDocker != nil && !*p.Actions.AllowDocker {
+			report(pos, RuleAllowedActions, "%s: docker:// steps are not allowed", s)
+		}
+		return
+	}
+	source := uses.Repository()
+	if uses.Path != "" && !uses.IsReusableWorkflow() {
+		source += "/" + uses.Path
+	}
+	switch {
+	case matchAny(p.Actions.Denied, source):
+		report(pos, RuleAllowedActions, "%s is denied by the policy", source)
+	case len(p.Actions.Allowed) > 0 && !matchAny(p.Actions.Allowed, source):
+		report(pos, RuleAllowedActions, "%s is not an allowed source; allowed are %s", source, strings.Join(p.Actions.Allowed, ", "))
+	}
+	if p.Actions.RequireSHA && !pin.IsSHA(uses.Ref) && !matchAny(p.Actions.PinExempt, source) {
+		report(pos, RuleSHAPinning, "%s must be pinned to a full commit SHA", s)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provenance
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"testingdashboard/m/v2/client"
+)
+
+// PayloadType is the DSSE payload type of in-toto statements.
+const PayloadType = "application/vnd.in-toto+json"
+
+// BundleMediaType is the media type of the Sigstore bundles Sign returns.
+const BundleMediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+// Envelope is a DSSE envelope.
+type Envelope struct {
+	Payload     []byte      `json:"payload"`
+	PayloadType string      `json:"payloadType"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a signature of an envelope.
+type Signature struct {
+	Sig   []byte `json:"sig"`
+	KeyID string `json:"keyid,omitempty"`
+}
+
+// PAE returns the pre-authentication encoding of the envelope, which is
+// what signatures sign.
+func (e *Envelope) PAE() []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(e.PayloadType), e.PayloadType, len(e.Payload), e.Payload)
+}
+
+// Verify checks that one of the envelope's signatures was made by pub.
+func (e *Envelope) Verify(pub crypto.PublicKey) error {
+	pae := e.PAE()
+	digest := sha256.Sum256(pae)
+	for _, s := range e.Signatures {
+		var ok bool
+		switch k := pub.(type) {
+		case *ecdsa.PublicKey:
+			ok = ecdsa.VerifyASN1(k, digest[:], s.Sig)
+		case *rsa.PublicKey:
+			ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], s.Sig) == nil
+		case ed25519.PublicKey:
+			ok = ed25519.Verify(k, pae, s.Sig)
+		default:
+			return fmt.Errorf("unsupported key type %T", pub)
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("no valid signature")
+}
+
+// Bundle is a Sigstore bundle holding a signed envelope, or a signature of
+// an artifact digest, and what is needed to verify it.
+type Bundle struct {
+	MediaType            string                `json:"mediaType"`
+	VerificationMaterial *VerificationMaterial `json:"verificationMaterial"`
+	DSSEEnvelope         *Envelope             `json:"dsseEnvelope,omitempty"`
+	MessageSignature     *MessageSignature     `json:"messageSignature,omitempty"`
+}
+
+// MessageSignature is a signature made directly over an artifact digest.
+type MessageSignature struct {
+	MessageDigest MessageDigest `json:"messageDigest"`
+	Signature     []byte        `json:"signature"`
+}
+
+// MessageDigest is a digest with its algorithm, such as SHA2_256.
+type MessageDigest struct {
+	Algorithm string `json:"algorithm"`
+	Digest    []byte `json:"digest"`
+}
+
+// VerificationMaterial is either a signing certificate, with the
+// transparency log entries of the signature, or a hint naming a key the
+// verifier already has.
+type VerificationMaterial struct {
+	Certificate *Certificate `json:"certificate,omitempty"`
+	PublicKey   *KeyHint     `json:"publicKey,omitempty"`
+	// TlogEntries are kept as the log returned them.
+	TlogEntries []json.RawMessage `json:"tlogEntries,omitempty"`
+}
+
+// Certificate is a DER certificate.
+type Certificate struct {
+	RawBytes []byte `json:"rawBytes"`
+}
+
+// KeyHint names a public key.
+type KeyHint struct {
+	Hint string `json:"hint"`
+}
+
+// Signer adds a signature to an envelope and returns the material that
+// verifies it.
+type Signer interface {
+	SignEnvelope(ctx context.Context, e *Envelope) (*VerificationMaterial, error)
+}
+
+// KeySigner signs with a key the verifier knows by Hint. The attestations
+// API only accepts bundles signed through Sigstore, so KeySigner bundles
+// are for verification by other means.
+type KeySigner struct {
+	Key  crypto.Signer
+	Hint string
+}
+
+// SignEnvelope implements Signer.
+func (s *KeySigner) SignEnvelope(ctx context.Context, e *Envelope) (*VerificationMaterial, error) {
+	sig, err := SignPAE(s.Key, e)
+	if err != nil {
+		return nil, err
+	}
+	e.Signatures = append(e.Signatures, Signature{Sig: sig, KeyID: s.Hint})
+	return &VerificationMaterial{PublicKey: &KeyHint{Hint: s.Hint}}, nil
+}
+
+// SignPAE signs the pre-authentication encoding of e with key, hashing it
+// with SHA-256 unless the key is ed25519.
+func SignPAE(key crypto.Signer, e *Envelope) ([]byte, error) {
+	msg, opts := e.PAE(), crypto.Hash(0)
+	if _, ok := key.Public().(ed25519.PublicKey); !ok {
+		digest := sha256.Sum256(msg)
+		msg, opts = digest[:], crypto.SHA256
+	}
+	sig, err := key.Sign(rand.Reader, msg, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign envelope: %w", err)
+	}
+	return sig, nil
+}
+
+// Sign wraps the statement in an envelope signed by signer.
+func Sign(ctx context.Context, s *Statement, signer Signer) (*Bundle, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode statement: %w", err)
+	}
+	env := &Envelope{Payload: payload, PayloadType: PayloadType}
+	material, err := signer.SignEnvelope(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{MediaType: BundleMediaType, VerificationMaterial: material, DSSEEnvelope: env}, nil
+}
+
+// Upload stores the bundle with the attestations API of the repository and
+// returns the attestation ID.
+func Upload(ctx context.Context, c *client.Client, owner, repo string, b *Bundle) (int64, error) {
+	id, err := c.CreateAttestation(ctx, owner, repo, b)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload attestation: %w", err)
+	}
+	return id, nil
+}
+
+// Attest builds, signs and uploads provenance for subjects to the
+// repository of the run.
+func Attest(ctx context.Context, c *client.Client, run Context, subjects []ResourceDescriptor, opts Options, signer Signer) (*Bundle, int64, error) {
+	s, err := Build(run, subjects, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	b, err := Sign(ctx, s, signer)
+	if err != nil {
+		return nil, 0, err
+	}
+	owner, repo, _ := strings.Cut(run.Repository, "/")
+	id, err := Upload(ctx, c, owner, repo, b)
+	if err != nil {
+		return nil, 0, err
+	}
+	return b, id, nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package provenance builds SLSA v1 provenance for artifacts produced by a
+// workflow run, signs it as a DSSE envelope and stores it with the
+// attestations API, as the attest-build-provenance action does.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/oidc"
+	"testingdashboard/m/v2/sbom"
+)
+
+// Type identifiers used in statements.
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+	PredicateType = "https://slsa.dev/provenance/v1"
+	// BuildType is the build type GitHub uses for workflow runs.
+	BuildType = "https://actions.github.io/buildtypes/workflow/v1"
+)
+
+// Statement is an in-toto statement with a SLSA provenance predicate.
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     Provenance           `json:"predicate"`
+}
+
+// ResourceDescriptor identifies an artifact or dependency by name, URI and
+// digests keyed by algorithm.
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Provenance is the SLSA v1 provenance predicate.
+type Provenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition describes the inputs of the build.
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]any       `json:"externalParameters"`
+	InternalParameters   map[string]any       `json:"internalParameters,omitempty"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies,omitempty"`
+}
+
+// RunDetails describes the build that ran.
+type RunDetails struct {
+	Builder  Builder      `json:"builder"`
+	Metadata *RunMetadata `json:"metadata,omitempty"`
+}
+
+// Builder identifies the trusted entity that ran the build.
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// RunMetadata identifies the invocation.
+type RunMetadata struct {
+	InvocationID string     `json:"invocationId,omitempty"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+	FinishedOn   *time.Time `json:"finishedOn,omitempty"`
+}
+
+// Context is what the provenance records about the run.
+type Context struct {
+	ServerURL         string
+	Repository        string
+	RepositoryID      string
+	RepositoryOwnerID string
+	Ref               string
+	SHA               string
+	EventName         string
+	// WorkflowRef is the top-level workflow, as
+	// owner/repo/.github/workflows/build.yml@refs/heads/main.
+	WorkflowRef string
+	// JobWorkflowRef is the workflow that defines the job, which differs
+	// from WorkflowRef when the job runs in a reusable workflow. The
+	// builder ID is derived from it.
+	JobWorkflowRef    string
+	RunID             string
+	RunAttempt        string
+	RunnerEnvironment string
+}
+
+// ContextFromClaims takes the context from the job's OIDC token, which is
+// the only source of JobWorkflowRef.
+func ContextFromClaims(c *oidc.Claims, serverURL string) Context {
+	if serverURL == "" {
+		serverURL = endpoints.DotCom
+	}
+	return Context{
+		ServerURL:         serverURL,
+		Repository:        c.Repository,
+		RepositoryID:      c.RepositoryID,
+		RepositoryOwnerID: c.RepositoryOwnerID,
+		Ref:               c.Ref,
+		SHA:               c.SHA,
+		EventName:         c.EventName,
+		WorkflowRef:       c.WorkflowRef,
+		JobWorkflowRef:    c.JobWorkflowRef,
+		RunID:             c.RunID,
+		RunAttempt:        c.RunAttempt,
+		RunnerEnvironment: c.RunnerEnvironment,
+	}
+}
+
+// ContextFromEnv takes the context from the GITHUB_ variables of a job.
+// The environment does not name the workflow defining the job, so
+// JobWorkflowRef is WorkflowRef.
+func ContextFromEnv() Context {
+	c := Context{
+		ServerURL:         endpoints.FromEnv().Server,
+		Repository:        os.Getenv("GITHUB_REPOSITORY"),
+		RepositoryID:      os.Getenv("GITHUB_REPOSITORY_ID"),
+		RepositoryOwnerID: os.Getenv("GITHUB_REPOSITORY_OWNER_ID"),
+		Ref:               os.Getenv("GITHUB_REF"),
+		SHA:               os.Getenv("GITHUB_SHA"),
+		EventName:         os.Getenv("GITHUB_EVENT_NAME"),
+		WorkflowRef:       os.Getenv("GITHUB_WORKFLOW_REF"),
+		RunID:             os.Getenv("GITHUB_RUN_ID"),
+		RunAttempt:        os.Getenv("GITHUB_RUN_ATTEMPT"),
+		RunnerEnvironment: os.Getenv("RUNNER_ENVIRONMENT"),
+	}
+	c.JobWorkflowRef = c.WorkflowRef
+	return c
+}
+
+// Options add to what Build records.
+type Options struct {
+	// Dependencies are resolved dependencies besides the source commit,
+	// such as the actions the workflow uses.
+	Dependencies []ResourceDescriptor
+	// StartedOn and FinishedOn are recorded when set.
+	StartedOn, FinishedOn time.Time
+}
+
+// Build returns the provenance statement for subjects built by the run.
+func Build(c Context, subjects []ResourceDescriptor, opts Options) (*Statement, error) {
+	switch {
+	case len(subjects) == 0:
+		return nil, fmt.Errorf("no subjects to attest")
+	case c.Repository == "" || c.SHA == "" || c.RunID == "":
+		return nil, fmt.Errorf("repository, commit and run ID are required; is this running in a workflow?")
+	}
+	for _, s := range subjects {
+		if len(s.Digest) == 0 {
+			return nil, fmt.Errorf("subject %s has no digest", s.Name)
+		}
+	}
+	server := strings.TrimSuffix(c.ServerURL, "/")
+	// WorkflowRef is owner/repo/path@ref.
+	name, ref, _ := strings.Cut(c.WorkflowRef, "@")
+	path := strings.TrimPrefix(name, c.Repository+"/")
+	jobWorkflow := c.JobWorkflowRef
+	if jobWorkflow == "" {
+		jobWorkflow = c.WorkflowRef
+	}
+	if path == "" || jobWorkflow == "" {
+		return nil, fmt.Errorf("workflow ref is required")
+	}
+
+	p := Provenance{
+		BuildDefinition: BuildDefinition{
+			BuildType: BuildType,
+			ExternalParameters: map[string]any{
+				"workflow": map[string]string{
+					"ref":        ref,
+					"repository": server + "/" + c.Repository,
+					"path":       path,
+				},
+			},
+			InternalParameters: map[string]any{
+				"github": map[string]string{
+					"event_name":          c.EventName,
+					"repository_id":       c.RepositoryID,
+					"repository_owner_id": c.RepositoryOwnerID,
+					"runner_environment":  c.RunnerEnvironment,
+				},
+			},
+			ResolvedDependencies: append([]ResourceDescriptor{{
+				URI:    "git+" + server + "/" + c.Repository + "@" + c.Ref,
+				Digest: map[string]string{"gitCommit": c.SHA},
+			}}, opts.Dependencies...),
+		},
+		RunDetails: RunDetails{
+			Builder:  Builder{ID: server + "/" + jobWorkflow},
+			Metadata: &RunMetadata{InvocationID: server + "/" + c.Repository + "/actions/runs/" + c.RunID},
+		},
+	}
+	if c.RunAttempt != "" {
+		p.RunDetails.Metadata.InvocationID += "/attempts/" + c.RunAttempt
+	}
+	if !opts.StartedOn.IsZero() {
+		t := opts.StartedOn.UTC()
+		p.RunDetails.Metadata.StartedOn = &t
+	}
+	if !opts.FinishedOn.IsZero() {
+		t := opts.FinishedOn.UTC()
+		p.RunDetails.Metadata.FinishedOn = &t
+	}
+	return &Statement{Type: StatementType, Subject: subjects, PredicateType: PredicateType, Predicate: p}, nil
+}
+
+// FileSubject returns a subject for the file at path, named by its base
+// name.
+func FileSubject(path string) (ResourceDescriptor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ResourceDescriptor{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ResourceDescriptor{}, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return ResourceDescriptor{Name: filepath.Base(path), Digest: map[string]string{"sha256": hex.EncodeToString(h.Sum(nil))}}, nil
+}
+
+// ActionDependencies records the actions and reusable workflows in inv as
+// resolved dependencies. Components without a resolved commit are skipped,
+// since a dependency needs a digest.
+func ActionDependencies(inv *sbom.Inventory) []ResourceDescriptor {
+	var out []ResourceDescriptor
+	for _, c := range inv.Components {
+		if c.SHA == "" || c.Kind == sbom.KindImage {
+			continue
+		}
+		uri := "git+" + c.SourceURL() + "@" + c.Version
+		if c.Path != "" {
+			uri += "#" + c.Path
+		}
+		out = append(out, ResourceDescriptor{Name: c.Ref, URI: uri, Digest: map[string]string{"gitCommit": c.SHA}})
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Section is a changelog section listing the commits of a type.
+type Section struct {
+	Type, Title string
+}
+
+// DefaultSections are the sections changelogs have by default; commits of
+// other types are left out.
+var DefaultSections = []Section{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"revert", "Reverts"},
+}
+
+// ChangelogOptions configure Changelog.
+type ChangelogOptions struct {
+	// RepoURL, such as https://github.com/owner/repo, links commits and the
+	// comparison with the previous tag.
+	RepoURL     string
+	PreviousTag string
+	Tag         string
+	Date        time.Time
+	// Sections default to DefaultSections.
+	Sections []Section
+}
+
+// Changelog renders the Markdown changelog of a release of version made of
+// commits: breaking changes first, then a section per commit type.
+func Changelog(version string, commits []Commit, opts ChangelogOptions) string {
+	if opts.Sections == nil {
+		opts.Sections = DefaultSections
+	}
+	var b strings.Builder
+	title := version
+	if opts.RepoURL != "" && opts.PreviousTag != "" && opts.Tag != "" {
+		title = fmt.Sprintf("[%s](%s/compare/%s...%s)", version, opts.RepoURL, opts.PreviousTag, opts.Tag)
+	}
+	fmt.Fprintf(&b, "## %s", title)
+	if !opts.Date.IsZero() {
+		fmt.Fprintf(&b, " (%s)", opts.Date.Format(time.DateOnly))
+	}
+	b.WriteString("\n")
+
+	var breaking []string
+	for _, c := range commits {
+		if !c.Breaking {
+			continue
+		}
+		note := c.BreakingNote
+		if note == "" {
+			note = c.Description
+		}
+		breaking = append(breaking, entry(c, note, opts.RepoURL))
+	}
+	section(&b, "⚠ BREAKING CHANGES", breaking)
+	for _, s := range opts.Sections {
+		var items []string
+		for _, c := range commits {
+			if c.Type == s.Type {
+				items = append(items, entry(c, c.Description, opts.RepoURL))
+			}
+		}
+		section(&b, s.Title, items)
+	}
+	return b.String()
+}
+
+func section(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, item := range items {
+		b.WriteString("* " + item + "\n")
+	}
+}
+
+func entry(c Commit, text, repoURL string) string {
+	// Continuation lines stay in the list item.
+	s := strings.ReplaceAll(text, "\n", "\n  ")
+	if c.Scope != "" {
+		s = "**" + c.Scope + ":** " + s
+	}
+	short := c.SHA[:min(7, len(c.SHA))]
+	if repoURL != "" {
+		return fmt.Sprintf("%s ([%s](%s/commit/%s))", s, short, repoURL, c.SHA)
+	}
+	return fmt.Sprintf("%s (%s)", s, short)
+}
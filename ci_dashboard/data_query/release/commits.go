@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package release automates releases: it reads conventional commits since
+// the last tag, computes the next semantic version and the changelog, tags
+// the commit and publishes a GitHub release with its assets and their
+// checksums.
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"testingdashboard/m/v2/semver"
+)
+
+// Commit is a commit, parsed as a conventional commit when its subject
+// follows the format type(scope)!: description.
+type Commit struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+	// Type is empty for commits that are not conventional.
+	Type        string `json:"type,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Breaking is set by a ! after the type or scope or a BREAKING CHANGE
+	// footer, whose text is BreakingNote.
+	Breaking     bool   `json:"breaking,omitempty"`
+	BreakingNote string `json:"breaking_note,omitempty"`
+}
+
+var (
+	subjectRE  = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?: *(.+)$`)
+	breakingRE = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: *`)
+)
+
+// ParseCommit parses the message of the commit sha.
+func ParseCommit(sha, message string) Commit {
+	subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	c := Commit{SHA: sha, Subject: strings.TrimSpace(subject), Body: strings.TrimSpace(body)}
+	m := subjectRE.FindStringSubmatch(c.Subject)
+	if m == nil {
+		return c
+	}
+	c.Type, c.Scope, c.Breaking, c.Description = strings.ToLower(m[1]), m[2], m[3] == "!", m[4]
+	if loc := breakingRE.FindStringIndex(c.Body); loc != nil {
+		c.Breaking = true
+		// The note runs to the end of its paragraph.
+		note, _, _ := strings.Cut(c.Body[loc[1]:], "\n\n")
+		c.BreakingNote = strings.TrimSpace(note)
+	}
+	return c
+}
+
+// Log returns the commits reachable from to but not from from, newest
+// first, in the repository at dir. An empty from means all of history.
+// Merge commits are skipped.
+func Log(ctx context.Context, dir, from, to string) ([]Commit, error) {
+	rev := to
+	if from != "" {
+		rev = from + ".." + to
+	}
+	out, err := git(ctx, dir, "log", "--no-merges", "--format=%H%x1f%B%x1e", rev, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the commits of %s: %w", rev, err)
+	}
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		sha, message, ok := strings.Cut(strings.TrimLeft(record, "\n"), "\x1f")
+		if !ok {
+			continue
+		}
+		commits = append(commits, ParseCommit(sha, message))
+	}
+	return commits, nil
+}
+
+// LastTag returns the tag of the highest version reachable from rev among
+// the tags that are prefix followed by a semantic version, or "" if there
+// is none. Prereleases count only if pre is set.
+func LastTag(ctx context.Context, dir, prefix, rev string, pre bool) (string, semver.Version, error) {
+	out, err := git(ctx, dir, "tag", "--merged", rev, "--list", prefix+"*")
+	if err != nil {
+		return "", semver.Version{}, fmt.Errorf("failed to list tags: %w", err)
+	}
+	var best string
+	var bestV semver.Version
+	for _, tag := range strings.Fields(out) {
+		s, ok := strings.CutPrefix(tag, prefix)
+		if !ok || s == "" || s[0] < '0' || s[0] > '9' {
+			continue
+		}
+		v, err := semver.Parse(s)
+		if err != nil || (v.Prerelease() && !pre) {
+			continue
+		}
+		if best == "" || v.Compare(bestV) > 0 {
+			best, bestV = tag, v
+		}
+	}
+	return best, bestV, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
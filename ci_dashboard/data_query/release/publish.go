@@ -0,0 +1,238 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"testingdashboard/m/v2/client"
+)
+
+// botName and botEmail sign annotated tags in clones without a git
+// identity, as on hosted runners.
+const (
+	botName  = "github-actions[bot]"
+	botEmail = "41898282+github-actions[bot]@users.noreply.github.com"
+)
+
+// Tag creates the tag name on rev in the repository at dir, annotated with
+// message unless it is empty, and pushes it to remote unless that is
+// empty.
+func Tag(ctx context.Context, dir, name, rev, message, remote string) error {
+	args := []string{"tag", name, rev}
+	if message != "" {
+		args = []string{"tag", "-a", "-m", message, name, rev}
+		if out, _ := git(ctx, dir, "config", "user.email"); strings.TrimSpace(out) == "" {
+			args = append([]string{"-c", "user.name=" + botName, "-c", "user.email=" + botEmail}, args...)
+		}
+	}
+	if _, err := git(ctx, dir, args...); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	if remote == "" {
+		return nil
+	}
+	if _, err := git(ctx, dir, "push", remote, "refs/tags/"+name); err != nil {
+		return fmt.Errorf("failed to push tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// Publish creates the release of req.TagName, or updates it if it exists
+// already, as a draft or published release.
+func Publish(ctx context.Context, c *client.Client, owner, repo string, req *client.ReleaseRequest) (*client.Release, error) {
+	existing, err := find(ctx, c, owner, repo, req.TagName)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		r, err := c.CreateRelease(ctx, owner, repo, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create release %s: %w", req.TagName, err)
+		}
+		return r, nil
+	}
+	r, err := c.UpdateRelease(ctx, owner, repo, existing.ID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update release %s: %w", req.TagName, err)
+	}
+	return r, nil
+}
+
+// find returns the release of tag, including a draft, or nil.
+func find(ctx context.Context, c *client.Client, owner, repo, tag string) (*client.Release, error) {
+	r, err := c.GetReleaseByTag(ctx, owner, repo, tag)
+	if err == nil {
+		return r, nil
+	}
+	if !client.IsNotFound(err) {
+		return nil, err
+	}
+	// Drafts are only listed.
+	releases, err := c.ListReleases(ctx, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range releases {
+		if r.Draft && r.TagName == tag {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// AssetOptions configure UploadAssets.
+type AssetOptions struct {
+	// Concurrency is how many assets upload at once. Defaults to 4.
+	Concurrency int
+	// Checksums, if set, is the name of an asset listing the SHA-256 of
+	// every other asset in the format of sha256sum.
+	Checksums string
+	// Overwrite replaces assets of the same name; otherwise they are an
+	// error.
+	Overwrite bool
+}
+
+// UploadAssets uploads files to the release in parallel, named by their
+// base names, followed by the checksums asset. It returns the assets in
+// the order of files.
+func UploadAssets(ctx context.Context, c *client.Client, owner, repo string, rel *client.Release, files []string, opts AssetOptions) ([]*client.ReleaseAsset, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	names := map[string]bool{}
+	for _, f := range files {
+		name := filepath.Base(f)
+		if names[name] || name == opts.Checksums {
+			return nil, fmt.Errorf("more than one asset is named %s", name)
+		}
+		names[name] = true
+	}
+	if opts.Checksums != "" {
+		names[opts.Checksums] = true
+	}
+	existing := map[string]*client.ReleaseAsset{}
+	for _, a := range rel.Assets {
+		if names[a.Name] {
+			if !opts.Overwrite {
+				return nil, fmt.Errorf("release %s already has an asset named %s", rel.TagName, a.Name)
+			}
+			existing[a.Name] = a
+		}
+	}
+
+	assets := make([]*client.ReleaseAsset, len(files))
+	sums := make([]string, len(files))
+	err := parallel(ctx, len(files), opts.Concurrency, func(ctx context.Context, i int) error {
+		a, sum, err := uploadFile(ctx, c, owner, repo, rel, files[i], existing[filepath.Base(files[i])])
+		if err != nil {
+			return err
+		}
+		assets[i], sums[i] = a, sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Checksums == "" {
+		return assets, nil
+	}
+
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return filepath.Base(files[order[i]]) < filepath.Base(files[order[j]]) })
+	var b strings.Builder
+	for _, i := range order {
+		b.WriteString(sums[i] + "  " + filepath.Base(files[i]) + "\n")
+	}
+	content := b.String()
+	if a := existing[opts.Checksums]; a != nil {
+		if err := c.DeleteReleaseAsset(ctx, owner, repo, a.ID); err != nil {
+			return nil, fmt.Errorf("failed to replace %s: %w", a.Name, err)
+		}
+	}
+	a, err := c.UploadReleaseAsset(ctx, rel.UploadURL, opts.Checksums, "", "text/plain", strings.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	return append(assets, a), nil
+}
+
+// uploadFile uploads one file, replacing old, and returns its checksum.
+func uploadFile(ctx context.Context, c *client.Client, owner, repo string, rel *client.Release, file string, old *client.ReleaseAsset) (*client.ReleaseAsset, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if old != nil {
+		if err := c.DeleteReleaseAsset(ctx, owner, repo, old.ID); err != nil {
+			return nil, "", fmt.Errorf("failed to replace %s: %w", old.Name, err)
+		}
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	a, err := c.UploadReleaseAsset(ctx, rel.UploadURL, filepath.Base(file), "", contentType, f, info.Size())
+	if err != nil {
+		return nil, "", err
+	}
+	return a, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parallel runs fn for 0..n-1 on up to workers goroutines and returns the
+// errors joined.
+func parallel(ctx context.Context, n, workers int, fn func(ctx context.Context, i int) error) error {
+	jobs := make(chan int)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = fn(ctx, i)
+			}
+		}()
+	}
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package release
+
+import (
+	"strconv"
+
+	"testingdashboard/m/v2/semver"
+)
+
+// Level is how much a version is bumped.
+type Level int
+
+// Levels, in increasing order.
+const (
+	None Level = iota
+	Patch
+	Minor
+	Major
+)
+
+func (l Level) String() string {
+	return [...]string{"none", "patch", "minor", "major"}[l]
+}
+
+// BumpOptions configure Bump and Next.
+type BumpOptions struct {
+	// PreMajor makes breaking changes bump the minor version while the
+	// major version is 0, as the semver spec's initial development allows.
+	PreMajor bool
+	// Prerelease, such as rc, makes the next version a prerelease with that
+	// identifier and a counter.
+	Prerelease string
+}
+
+// Bump returns the level the commits call for: major for breaking
+// changes, minor for features and patch for fixes and performance
+// improvements.
+func Bump(commits []Commit) Level {
+	level := None
+	for _, c := range commits {
+		switch {
+		case c.Breaking:
+			return Major
+		case c.Type == "feat":
+			level = max(level, Minor)
+		case c.Type == "fix" || c.Type == "perf":
+			level = max(level, Patch)
+		}
+	}
+	return level
+}
+
+// Next returns the version after current for a change of level. A
+// prerelease current version already carries a bump, so it is only bumped
+// again if level calls for more: 1.3.0-rc.1 with a fix becomes 1.3.0-rc.2,
+// or 1.3.0 without a prerelease identifier, and with a breaking change
+// 2.0.0-rc.0.
+func Next(current semver.Version, level Level, opts BumpOptions) semver.Version {
+	if opts.PreMajor && current.Major == 0 && level == Major {
+		level = Minor
+	}
+	v := semver.Version{Major: current.Major, Minor: current.Minor, Patch: current.Patch}
+	if !current.Prerelease() || level > implied(current) {
+		v = apply(v, level)
+	}
+	if opts.Prerelease == "" {
+		return v
+	}
+	n := 0
+	if pre := current.Pre; len(pre) == 2 && pre[0] == opts.Prerelease && v.Compare(semver.Version{Major: current.Major, Minor: current.Minor, Patch: current.Patch}) == 0 {
+		if i, err := strconv.Atoi(pre[1]); err == nil {
+			n = i + 1
+		}
+	}
+	v.Pre = []string{opts.Prerelease, strconv.Itoa(n)}
+	return v
+}
+
+// implied returns the level a prerelease's version tuple was bumped by.
+func implied(v semver.Version) Level {
+	switch {
+	case v.Minor == 0 && v.Patch == 0:
+		return Major
+	case v.Patch == 0:
+		return Minor
+	}
+	return Patch
+}
+
+func apply(v semver.Version, level Level) semver.Version {
+	switch level {
+	case Major:
+		return semver.Version{Major: v.Major + 1}
+	case Minor:
+		return semver.Version{Major: v.Major, Minor: v.Minor + 1}
+	case Patch:
+		return semver.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+	return v
+}
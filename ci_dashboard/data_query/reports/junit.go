@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reports
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// junitSuite is a <testsuite>, which some tools nest.
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Time   string       `xml:"time,attr"`
+	File   string       `xml:"file,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	File      string         `xml:"file,attr"`
+	Line      string         `xml:"line,attr"`
+	Failures  []junitProblem `xml:"failure"`
+	Errors    []junitProblem `xml:"error"`
+	Skipped   *junitProblem  `xml:"skipped"`
+	SystemOut string         `xml:"system-out"`
+	SystemErr string         `xml:"system-err"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ParseJUnit reads a JUnit XML report, whose root is <testsuites> or a
+// single <testsuite>. Errors count as failures. Nested suites are
+// flattened, their names joined with dots.
+func ParseJUnit(name string, data []byte) ([]*Suite, error) {
+	var root struct {
+		XMLName xml.Name
+		junitSuite
+	}
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	var suites []junitSuite
+	switch root.XMLName.Local {
+	case "testsuites":
+		suites = root.Suites
+	case "testsuite":
+		suites = []junitSuite{root.junitSuite}
+	default:
+		return nil, errors.New("not a JUnit report: the root element is <" + root.XMLName.Local + ">")
+	}
+	var out []*Suite
+	for _, s := range suites {
+		out = flatten(out, name, "", s)
+	}
+	return out, nil
+}
+
+func flatten(out []*Suite, report, prefix string, js junitSuite) []*Suite {
+	name := js.Name
+	if prefix != "" {
+		name = prefix + "." + name
+	}
+	if len(js.Cases) > 0 || len(js.Suites) == 0 {
+		s := &Suite{Name: name, Report: report, Duration: seconds(js.Time)}
+		if s.Name == "" {
+			s.Name = report
+		}
+		var sum time.Duration
+		for _, jc := range js.Cases {
+			c := junitTest(jc)
+			if c.File == "" {
+				c.File = js.File
+			}
+			sum += c.Duration
+			s.Cases = append(s.Cases, c)
+		}
+		if s.Duration == 0 {
+			s.Duration = sum
+		}
+		out = append(out, s)
+	}
+	for _, child := range js.Suites {
+		out = flatten(out, report, name, child)
+	}
+	return out
+}
+
+func junitTest(jc junitCase) *Case {
+	c := &Case{Name: jc.Name, Class: jc.Classname, Outcome: Passed, Duration: seconds(jc.Time), File: jc.File}
+	c.Line, _ = strconv.Atoi(jc.Line)
+	problems := append(jc.Failures, jc.Errors...)
+	switch {
+	case len(problems) > 0:
+		c.Outcome = Failed
+		var details []string
+		for _, p := range problems {
+			if c.Message == "" {
+				c.Message = strings.TrimSpace(p.Message)
+			}
+			if text := strings.TrimSpace(p.Text); text != "" {
+				details = append(details, text)
+			}
+		}
+		if c.Message == "" && len(details) > 0 {
+			c.Message, _, _ = strings.Cut(details[0], "\n")
+		}
+		if out := strings.TrimSpace(jc.SystemErr); out != "" {
+			details = append(details, out)
+		}
+		c.Details = strings.Join(details, "\n\n")
+		if c.Line == 0 {
+			if file, line := locate(c.Details); file != "" && (c.File == "" || strings.HasSuffix(file, c.File) || strings.HasSuffix(c.File, file)) {
+				if c.File == "" {
+					c.File = file
+				}
+				c.Line = line
+			}
+		}
+	case jc.Skipped != nil:
+		c.Outcome = Skipped
+		c.Message = strings.TrimSpace(jc.Skipped.Message)
+		if c.Message == "" {
+			c.Message = strings.TrimSpace(jc.Skipped.Text)
+		}
+	}
+	return c
+}
+
+// seconds parses a JUnit time attribute, which is in seconds and may use
+// a thousands separator.
+func seconds(s string) time.Duration {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil || f < 0 {
+		return 0
+	}
+	return time.Duration(f * float64(time.Second))
+}
@@ -0,0 +1,335 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reports reads JUnit XML and TAP test reports, summarizes them for
+// the job summary and turns their failures into check run annotations.
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/checks"
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/glob"
+	"testingdashboard/m/v2/summary"
+)
+
+// Test outcomes.
+const (
+	Passed  = "passed"
+	Failed  = "failed"
+	Skipped = "skipped"
+)
+
+// Report is the combined result of one or more report files.
+type Report struct {
+	Suites []*Suite `json:"suites"`
+}
+
+// Suite is a group of tests, such as a JUnit testsuite or a TAP file.
+type Suite struct {
+	Name string `json:"name"`
+	// Report is the file the suite was read from.
+	Report   string        `json:"report"`
+	Duration time.Duration `json:"duration"`
+	Cases    []*Case       `json:"cases"`
+}
+
+// Case is one test.
+type Case struct {
+	Name string `json:"name"`
+	// Class is the JUnit classname, often the package or file of the test.
+	Class    string        `json:"class,omitempty"`
+	Outcome  string        `json:"outcome"`
+	Duration time.Duration `json:"duration"`
+	// File and Line locate the test or its failure; Line is zero when
+	// unknown.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+	// Message is the failure or skip reason and Details the rest of what
+	// the report says about it, such as a stack trace.
+	Message string `json:"message,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+// Totals counts tests by outcome.
+type Totals struct {
+	Tests, Passed, Failed, Skipped int
+	Duration                       time.Duration
+}
+
+// Parse reads a report file, telling JUnit XML from TAP by its content.
+// name is recorded as the suites' Report.
+func Parse(name string, data []byte) ([]*Suite, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return ParseJUnit(name, data)
+	}
+	return ParseTAP(name, data)
+}
+
+// ParseFiles reads the report files matching patterns, given one per line
+// as in actions/upload-artifact and relative to root.
+func ParseFiles(root string, patterns []string) (*Report, error) {
+	opts := glob.DefaultOptions()
+	opts.Root = root
+	opts.MatchDirectories = false
+	g, err := glob.New(strings.Join(patterns, "\n"), opts)
+	if err != nil {
+		return nil, err
+	}
+	files, err := g.Glob()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no report matches %s", strings.Join(patterns, ", "))
+	}
+	r := &Report{}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		name := f
+		if rel, err := filepath.Rel(root, f); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+		suites, err := Parse(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		r.Suites = append(r.Suites, suites...)
+	}
+	return r, nil
+}
+
+// Totals counts the tests of the suite.
+func (s *Suite) Totals() Totals {
+	t := Totals{Duration: s.Duration}
+	for _, c := range s.Cases {
+		t.Tests++
+		switch c.Outcome {
+		case Passed:
+			t.Passed++
+		case Failed:
+			t.Failed++
+		case Skipped:
+			t.Skipped++
+		}
+	}
+	return t
+}
+
+// Totals counts the tests of every suite.
+func (r *Report) Totals() Totals {
+	var t Totals
+	for _, s := range r.Suites {
+		st := s.Totals()
+		t.Tests += st.Tests
+		t.Passed += st.Passed
+		t.Failed += st.Failed
+		t.Skipped += st.Skipped
+		t.Duration += st.Duration
+	}
+	return t
+}
+
+// Failures returns the failed tests with their suites.
+func (r *Report) Failures() []Failure {
+	var out []Failure
+	for _, s := range r.Suites {
+		for _, c := range s.Cases {
+			if c.Outcome == Failed {
+				out = append(out, Failure{Suite: s, Case: c})
+			}
+		}
+	}
+	return out
+}
+
+// Failure is a failed test.
+type Failure struct {
+	Suite *Suite
+	Case  *Case
+}
+
+// maxFailures is how many failures the summary details.
+const maxFailures = 50
+
+// Summary renders the report for GITHUB_STEP_SUMMARY: totals per suite,
+// then the failures.
+func (r *Report) Summary(title string) *summary.Builder {
+	t := r.Totals()
+	icon := "✅"
+	if t.Failed > 0 {
+		icon = "❌"
+	}
+	b := summary.New().Heading(html.EscapeString(title), 2)
+	b.Paragraph(fmt.Sprintf("%s %d tests: %d passed, %d failed, %d skipped in %s.", icon, t.Tests, t.Passed, t.Failed, t.Skipped, round(t.Duration)))
+	if len(r.Suites) == 0 {
+		return b
+	}
+	var rows [][]string
+	for _, s := range r.Suites {
+		st := s.Totals()
+		rows = append(rows, []string{
+			html.EscapeString(s.Name),
+			strconv.Itoa(st.Passed),
+			strconv.Itoa(st.Failed),
+			strconv.Itoa(st.Skipped),
+			round(st.Duration).String(),
+		})
+	}
+	b.SimpleTable([]string{"Suite", "Passed", "Failed", "Skipped", "Duration"}, rows)
+
+	failures := r.Failures()
+	if len(failures) == 0 {
+		return b
+	}
+	b.Heading("Failures", 3)
+	for i, f := range failures {
+		if i == maxFailures {
+			b.Paragraph(fmt.Sprintf("And %d more.", len(failures)-maxFailures))
+			break
+		}
+		label := html.EscapeString(f.Suite.Name + " / " + f.Case.Name)
+		if f.Case.File != "" {
+			loc := f.Case.File
+			if f.Case.Line > 0 {
+				loc += ":" + strconv.Itoa(f.Case.Line)
+			}
+			label += " <code>" + html.EscapeString(loc) + "</code>"
+		}
+		text := strings.TrimSpace(f.Case.Message + "\n\n" + f.Case.Details)
+		if text == "" {
+			b.Paragraph(label)
+			continue
+		}
+		b.Details(label, "\n\n<pre><code>"+html.EscapeString(text)+"</code></pre>\n")
+	}
+	return b
+}
+
+// round shortens d for display.
+func round(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(10 * time.Millisecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// Annotations returns a failure annotation for each failed test with a
+// file. Paths are made relative to root, and absolute paths outside it are
+// left out.
+func (r *Report) Annotations(root string) []checks.Annotation {
+	var out []checks.Annotation
+	for _, f := range r.Failures() {
+		path := resolve(root, f.Case.File, f.Case.Class)
+		if path == "" {
+			continue
+		}
+		if filepath.IsAbs(path) {
+			rel, err := filepath.Rel(root, path)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			path = rel
+		}
+		msg := f.Case.Message
+		if msg == "" {
+			msg = "Test failed"
+		}
+		out = append(out, checks.Annotation{
+			Path:       filepath.ToSlash(path),
+			StartLine:  max(f.Case.Line, 1),
+			EndLine:    max(f.Case.Line, 1),
+			Level:      checks.Failure,
+			Title:      f.Suite.Name + " / " + f.Case.Name,
+			Message:    msg,
+			RawDetails: f.Case.Details,
+		})
+	}
+	return out
+}
+
+// Publish creates a completed check run named name on the commit sha with
+// the summary as its output and the failures annotated.
+func (r *Report) Publish(ctx context.Context, c *client.Client, owner, repo, sha, name, root string) (*checks.CheckRun, error) {
+	t := r.Totals()
+	conclusion := checks.ConclusionSuccess
+	if t.Failed > 0 {
+		conclusion = checks.ConclusionFailure
+	}
+	text, err := r.Summary(name).Truncate(checks.MaxText, summary.KeepHead)
+	if err != nil {
+		return nil, err
+	}
+	return checks.Create(ctx, c, owner, repo, &checks.Run{
+		Name:        name,
+		HeadSHA:     sha,
+		Conclusion:  conclusion,
+		CompletedAt: time.Now(),
+		Output: &checks.Output{
+			Title:       fmt.Sprintf("%d passed, %d failed, %d skipped", t.Passed, t.Failed, t.Skipped),
+			Summary:     text,
+			Annotations: r.Annotations(root),
+		},
+	})
+}
+
+// resolve finds a relative file that does not exist under root in the
+// directory its class names, as Go reports give test files relative to
+// their package: for class example.com/mod/pkg and file x_test.go it tries
+// mod/pkg/x_test.go and pkg/x_test.go. Otherwise file is returned as is.
+func resolve(root, file, class string) string {
+	if file == "" || filepath.IsAbs(file) || class == "" {
+		return file
+	}
+	if _, err := os.Stat(filepath.Join(root, file)); err == nil {
+		return file
+	}
+	parts := strings.Split(class, "/")
+	for i := range parts {
+		candidate := filepath.Join(append(parts[i:], file)...)
+		if _, err := os.Stat(filepath.Join(root, candidate)); err == nil {
+			return candidate
+		}
+	}
+	return file
+}
+
+// locationRE finds a file:line reference in a failure message or stack
+// trace.
+var locationRE = regexp.MustCompile(`([\w.\-/\\]*\w\.[A-Za-z]\w*):(\d+)`)
+
+// locate returns the first file:line reference in text.
+func locate(text string) (string, int) {
+	for _, m := range locationRE.FindAllStringSubmatch(text, -1) {
+		if strings.HasPrefix(m[1], "http") || strings.Contains(m[1], "node_modules") {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		return m[1], line
+	}
+	return "", 0
+}
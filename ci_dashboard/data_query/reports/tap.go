@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reports
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	tapTestRE  = regexp.MustCompile(`^(not )?ok\b\s*(\d+)?\s*(?:- )?(.*)$`)
+	tapPlanRE  = regexp.MustCompile(`^1\.\.(\d+)`)
+	tapDirRE   = regexp.MustCompile(`(?i)\s+#\s*(skip|todo)\b\s*(.*)$`)
+	tapIndent  = regexp.MustCompile(`^\s*`)
+	tapSubtest = regexp.MustCompile(`^#\s*Subtest:\s*(.*)$`)
+)
+
+// tapLevel collects the tests of one level of subtest nesting.
+type tapLevel struct {
+	indent int
+	name   string
+	cases  []*Case
+}
+
+// ParseTAP reads a Test Anything Protocol report as one suite named after
+// the file. Subtests, indented under the test that sums them up, are
+// reported instead of their parent, named parent / child. Skipped and TODO
+// tests count as skipped; a short plan or a bail out adds a failed test.
+func ParseTAP(name string, data []byte) ([]*Suite, error) {
+	s := &Suite{Name: name, Report: name}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	stack := []*tapLevel{{}}
+	planned, seen, bailed := -1, 0, false
+	var last *Case
+	for i := 0; i < len(lines); i++ {
+		raw := lines[i]
+		indent := len(tapIndent.FindString(raw))
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if line == "---" && last != nil {
+			var block []string
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "..."; i++ {
+				block = append(block, lines[i])
+			}
+			diagnose(last, strings.Join(block, "\n"))
+			continue
+		}
+		if m := tapSubtest.FindStringSubmatch(line); m != nil {
+			stack = append(stack, &tapLevel{indent: indent + 4, name: strings.TrimSpace(m[1])})
+			continue
+		}
+		if indent == 0 {
+			if m := tapPlanRE.FindStringSubmatch(line); m != nil {
+				planned, _ = strconv.Atoi(m[1])
+				continue
+			}
+			if reason, ok := strings.CutPrefix(line, "Bail out!"); ok {
+				bailed = true
+				s.Cases = append(s.Cases, &Case{Name: "Bail out!", Outcome: Failed, Message: strings.TrimSpace(reason)})
+				break
+			}
+		}
+		m := tapTestRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		// Close the subtests deeper than this test; they belong to it.
+		var children []*Case
+		for len(stack) > 1 && stack[len(stack)-1].indent > indent {
+			lvl := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			children = append(lvl.cases, children...)
+		}
+		c := &Case{Name: strings.TrimSpace(m[3]), Outcome: Passed}
+		if d := tapDirRE.FindStringSubmatch(c.Name); d != nil {
+			c.Name = strings.TrimSpace(c.Name[:len(c.Name)-len(d[0])])
+			c.Outcome, c.Message = Skipped, strings.TrimSpace(d[2])
+			if strings.EqualFold(d[1], "todo") {
+				c.Message = strings.TrimSpace("TODO " + c.Message)
+			}
+		} else if m[1] != "" {
+			c.Outcome = Failed
+		}
+		if c.Name == "" {
+			c.Name = "test " + m[2]
+		}
+		if indent > stack[len(stack)-1].indent {
+			stack = append(stack, &tapLevel{indent: indent})
+		}
+		lvl := stack[len(stack)-1]
+		if indent == 0 {
+			seen++
+		}
+		if len(children) == 0 {
+			lvl.cases = append(lvl.cases, c)
+			last = c
+			continue
+		}
+		for _, child := range children {
+			child.Name = c.Name + " / " + child.Name
+		}
+		lvl.cases = append(lvl.cases, children...)
+		// A diagnostic block after the parent describes the parent.
+		last = &Case{}
+	}
+	for len(stack) > 1 {
+		lvl := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if lvl.name != "" {
+			for _, c := range lvl.cases {
+				c.Name = lvl.name + " / " + c.Name
+			}
+		}
+		stack[len(stack)-1].cases = append(stack[len(stack)-1].cases, lvl.cases...)
+	}
+	s.Cases = append(stack[0].cases, s.Cases...)
+	if planned >= 0 && seen < planned && !bailed {
+		s.Cases = append(s.Cases, &Case{Name: "plan", Outcome: Failed, Message: fmt.Sprintf("planned %d tests but %d ran", planned, seen)})
+	}
+	for _, c := range s.Cases {
+		s.Duration += c.Duration
+	}
+	return []*Suite{s}, nil
+}
+
+// diagnose applies a YAML diagnostic block to c. node:test and tap write
+// duration_ms, error and location fields; others write message, severity
+// and at.
+func diagnose(c *Case, block string) {
+	var d map[string]any
+	if yaml.Unmarshal([]byte(block), &d) != nil {
+		c.Details = strings.TrimSpace(block)
+		return
+	}
+	if ms, ok := d["duration_ms"].(float64); ok {
+		c.Duration = time.Duration(ms * float64(time.Millisecond))
+	} else if ms, ok := d["duration_ms"].(int); ok {
+		c.Duration = time.Duration(ms) * time.Millisecond
+	}
+	if c.Outcome != Failed {
+		return
+	}
+	for _, k := range []string{"message", "error"} {
+		if s, ok := d[k].(string); ok && c.Message == "" {
+			c.Message = strings.TrimSpace(s)
+		}
+	}
+	var details []string
+	for _, k := range []string{"stack", "stackTrace", "data"} {
+		if s, ok := d[k].(string); ok {
+			details = append(details, strings.TrimSpace(s))
+		}
+	}
+	for _, k := range []string{"expected", "actual", "found", "wanted"} {
+		if v, ok := d[k]; ok {
+			details = append(details, fmt.Sprintf("%s: %v", k, v))
+		}
+	}
+	c.Details = strings.Join(details, "\n")
+	switch at := d["at"].(type) {
+	case map[string]any:
+		c.File, _ = at["file"].(string)
+		c.Line, _ = at["line"].(int)
+	case string:
+		c.File, c.Line = locate(at)
+	}
+	if loc, ok := d["location"].(string); ok && c.File == "" {
+		c.File, c.Line = locate(loc)
+		if c.File == "" {
+			c.File = loc
+		}
+	}
+	if c.File == "" {
+		c.File, c.Line = locate(c.Details)
+	}
+}
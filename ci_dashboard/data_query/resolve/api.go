@@ -0,0 +1,154 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/pin"
+	"testingdashboard/m/v2/transport"
+	"testingdashboard/m/v2/workflow"
+)
+
+// APIFetcher downloads just the metadata file of a remote action, or the
+// reusable workflow file, with the contents API rather than cloning the
+// repository, which suits code that reads metadata but runs nothing. Refs
+// are resolved to commits first, and the files of a commit are kept in the
+// Store, so only ref lookups repeat; send those through a
+// transport.DiskCache to share and bound them.
+type APIFetcher struct {
+	// Workspace is the directory ./ references are relative to.
+	Workspace string
+	// CacheDir is the directory of the Store. Defaults to DefaultCacheDir().
+	CacheDir string
+	// APIURL defaults to the API of endpoints.FromEnv.
+	APIURL string
+	// Token defaults to GITHUB_TOKEN.
+	Token string
+	// Client defaults to transport.DefaultClient.
+	Client *http.Client
+	// Resolver resolves refs to commits. Defaults to a pin.GitHubResolver
+	// with the fetcher's API, token and client.
+	Resolver pin.Resolver
+}
+
+// maxFileSize bounds the metadata and workflow files read.
+const maxFileSize = 4 << 20
+
+// Fetch implements Fetcher.
+func (f *APIFetcher) Fetch(ctx context.Context, uses *workflow.Uses) (string, error) {
+	switch uses.Kind {
+	case workflow.UsesLocal:
+		return filepath.Join(f.Workspace, uses.Path), nil
+	case workflow.UsesDocker:
+		return "", fmt.Errorf("%s is an image, not an action repository", uses)
+	}
+	root := f.CacheDir
+	if root == "" {
+		var err error
+		if root, err = DefaultCacheDir(); err != nil {
+			return "", err
+		}
+	}
+	sha := strings.ToLower(uses.Ref)
+	if !isSHA(sha) {
+		resolver := f.Resolver
+		if resolver == nil {
+			resolver = &pin.GitHubResolver{APIURL: f.APIURL, Token: f.Token, Client: f.Client}
+		}
+		var err error
+		if sha, err = resolver.Resolve(ctx, uses.Owner, uses.Repo, uses.Ref); err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", uses, err)
+		}
+	}
+	dir := (&Store{Dir: root}).metadataDir(uses.Owner, uses.Repo, sha)
+	local := filepath.Join(dir, filepath.FromSlash(uses.Path))
+	names := []string{"action.yml", "action.yaml"}
+	if uses.IsReusableWorkflow() {
+		names = []string{""}
+	}
+	// The files of a commit do not change, so a kept one is current.
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(local, name)); err == nil {
+			touch(dir)
+			return local, nil
+		}
+	}
+	for _, name := range names {
+		err := f.download(ctx, uses, sha, path.Join(uses.Path, name), filepath.Join(local, name))
+		switch {
+		case err == nil:
+			touch(dir)
+			return local, nil
+		case !os.IsNotExist(err) || uses.IsReusableWorkflow():
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("%s: no action.yml or action.yaml found", uses)
+}
+
+// download writes the file name of the commit sha to local. It returns an
+// error satisfying os.IsNotExist if the commit has no such file.
+func (f *APIFetcher) download(ctx context.Context, uses *workflow.Uses, sha, name, local string) error {
+	base := f.APIURL
+	if base == "" {
+		base = endpoints.FromEnv().API
+	}
+	token := f.Token
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	client := f.Client
+	if client == nil {
+		client = transport.DefaultClient()
+	}
+	u := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", strings.TrimSuffix(base, "/"),
+		url.PathEscape(uses.Owner), url.PathEscape(uses.Repo), (&url.URL{Path: name}).EscapedPath(), sha)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	// The raw media type returns the file itself rather than JSON.
+	req.Header.Set("Accept", "application/vnd.github.raw")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s/%s@%s: %w", uses.Repository(), name, uses.Ref, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize))
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return &os.PathError{Op: "fetch", Path: uses.Repository() + "/" + name, Err: os.ErrNotExist}
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("failed to fetch %s/%s@%s: %s: %s", uses.Repository(), name, uses.Ref, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return writeAtomic(local, data)
+}
@@ -0,0 +1,232 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resolve locates the actions and reusable workflows referenced by
+// workflows, expanding composite actions into the steps they run and
+// workflow calls into the jobs they run.
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Fetcher makes the files of a referenced action or reusable workflow
+// available locally and returns the local path of uses.Path: the directory
+// holding an action's metadata file, or the workflow file itself.
+type Fetcher interface {
+	Fetch(ctx context.Context, uses *workflow.Uses) (string, error)
+}
+
+// GitFetcher fetches remote actions with git into a Store and resolves
+// local actions against a workspace.
+type GitFetcher struct {
+	// Workspace is the directory ./ references are relative to.
+	Workspace string
+	// CacheDir is the directory of the Store. Defaults to DefaultCacheDir().
+	CacheDir string
+	// ServerURL is the git host. Defaults to https://github.com.
+	ServerURL string
+	// RefTTL is how long a branch or tag is trusted to point where it did
+	// before it is resolved again. Defaults to DefaultRefTTL; negative
+	// resolves it on every fetch.
+	RefTTL time.Duration
+	// CacheHit, if set, is called for each remote fetch with whether the
+	// cache already held the commit.
+	CacheHit func(hit bool)
+
+	mu sync.Mutex
+}
+
+// DefaultRefTTL is the default of GitFetcher.RefTTL.
+const DefaultRefTTL = time.Hour
+
+// DefaultCacheDir returns the directory fetched actions are kept in,
+// honoring ACTIONS_RUNNER_ACTION_CACHE.
+func DefaultCacheDir() (string, error) {
+	if dir := os.Getenv("ACTIONS_RUNNER_ACTION_CACHE"); dir != "" {
+		return dir, nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "actions-runner", "actions"), nil
+}
+
+// Fetch implements Fetcher. Refs are resolved to commits first, so a
+// commit already in the store is not fetched again.
+func (f *GitFetcher) Fetch(ctx context.Context, uses *workflow.Uses) (string, error) {
+	switch uses.Kind {
+	case workflow.UsesLocal:
+		return filepath.Join(f.Workspace, uses.Path), nil
+	case workflow.UsesDocker:
+		return "", fmt.Errorf("%s is an image, not an action repository", uses)
+	}
+	root := f.CacheDir
+	if root == "" {
+		var err error
+		if root, err = DefaultCacheDir(); err != nil {
+			return "", err
+		}
+	}
+	store := &Store{Dir: root}
+	server := f.ServerURL
+	if server == "" {
+		server = endpoints.FromEnv().Server
+	}
+	url := strings.TrimSuffix(server, "/") + "/" + uses.Repository()
+
+	// Serialize fetches so parallel jobs do not clone the same commit.
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if sha := f.resolveRef(ctx, store, uses, url); sha != "" {
+		dir := store.repoDir(uses.Owner, uses.Repo, sha)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			f.hit(true)
+			touch(dir)
+			return filepath.Join(dir, uses.Path), nil
+		}
+	}
+	f.hit(false)
+	dir, err := f.clone(ctx, store, uses, url)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, uses.Path), nil
+}
+
+func (f *GitFetcher) hit(hit bool) {
+	if f.CacheHit != nil {
+		f.CacheHit(hit)
+	}
+}
+
+// resolveRef returns the commit uses.Ref points to, or "" when that takes
+// a fetch, as for an abbreviated SHA.
+func (f *GitFetcher) resolveRef(ctx context.Context, store *Store, uses *workflow.Uses, url string) string {
+	if isSHA(uses.Ref) {
+		return strings.ToLower(uses.Ref)
+	}
+	sha, resolved, ok := store.lookupRef(uses.Owner, uses.Repo, uses.Ref)
+	ttl := f.RefTTL
+	if ttl == 0 {
+		ttl = DefaultRefTTL
+	}
+	if ok && time.Since(resolved) < ttl {
+		return sha
+	}
+	remote, err := lsRemote(ctx, url, uses.Ref)
+	switch {
+	case err != nil && ok:
+		// Offline, the commit the ref last pointed to will do.
+		return sha
+	case err != nil || remote == "":
+		return ""
+	}
+	store.saveRef(uses.Owner, uses.Repo, uses.Ref, remote)
+	return remote
+}
+
+// lsRemote returns the commit ref names on the remote, preferring tags
+// over branches as git does, or "" if it names none.
+func lsRemote(ctx context.Context, url, ref string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "ls-remote", url, ref).Output()
+	if err != nil {
+		return "", err
+	}
+	found := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if sha, name, ok := strings.Cut(strings.TrimSpace(line), "\t"); ok {
+			found[name] = sha
+		}
+	}
+	// An annotated tag is listed with the commit it points to after it.
+	for _, name := range []string{"refs/tags/" + ref + "^{}", "refs/tags/" + ref, "refs/heads/" + ref, ref} {
+		if sha := found[name]; sha != "" {
+			return sha, nil
+		}
+	}
+	return "", nil
+}
+
+// isSHA reports whether ref is a full commit SHA.
+func isSHA(ref string) bool {
+	if len(ref) != 40 && len(ref) != 64 {
+		return false
+	}
+	for _, r := range ref {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// clone fetches a single ref without history into the store and returns
+// the checkout. Fetching by ref rather than cloning a branch lets tags,
+// branches and commit SHAs all work.
+func (f *GitFetcher) clone(ctx context.Context, store *Store, uses *workflow.Uses, url string) (string, error) {
+	tmp := filepath.Join(store.Dir, "tmp")
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		return "", err
+	}
+	temp, err := os.MkdirTemp(tmp, uses.Repo+"-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(temp)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"fetch", "-q", "--depth", "1", url, uses.Ref},
+		{"checkout", "-q", "FETCH_HEAD"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = temp
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("failed to fetch %s@%s: git %s: %v: %s", uses.Repository(), uses.Ref, args[0], err, strings.TrimSpace(string(out)))
+		}
+	}
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = temp
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s@%s: git rev-parse: %v", uses.Repository(), uses.Ref, err)
+	}
+	sha := strings.TrimSpace(string(out))
+	if !isSHA(uses.Ref) {
+		store.saveRef(uses.Owner, uses.Repo, uses.Ref, sha)
+	}
+	dir := store.repoDir(uses.Owner, uses.Repo, sha)
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(temp, dir); err != nil {
+		// Another run may have stored the commit first.
+		if _, statErr := os.Stat(filepath.Join(dir, ".git")); statErr != nil {
+			return "", err
+		}
+		touch(dir)
+	}
+	return dir, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"fmt"
+
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/workflow"
+)
+
+// MaxCompositeDepth is how deeply composite actions may nest, matching the
+// limit enforced by GitHub.
+const MaxCompositeDepth = 10
+
+// Step is a step after composite actions have been inlined.
+type Step struct {
+	JobID string
+	Step  *workflow.Step
+	// Action is the metadata of the action the step uses, when it uses one
+	// that has metadata (docker:// references do not).
+	Action *metadata.Action
+	// Using is runs.using of the action, or "run" for script steps and
+	// "docker" for docker:// references.
+	Using string
+	// Via lists the composite actions the step was inlined from, outermost
+	// first. It is empty for steps written directly in the workflow.
+	Via []string
+}
+
+// Job returns the steps of job with every composite action replaced by the
+// steps it runs. Composite actions themselves are kept in the list, directly
+// before their steps, so the full call chain stays visible.
+func Job(ctx context.Context, f Fetcher, job *workflow.Job) ([]*Step, error) {
+	var out []*Step
+	for _, step := range job.Steps {
+		steps, err := expand(ctx, f, job.ID, step, nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, steps...)
+	}
+	return out, nil
+}
+
+// Workflow flattens every job of wf, keyed by job ID.
+func Workflow(ctx context.Context, f Fetcher, wf *workflow.Workflow) (map[string][]*Step, error) {
+	out := map[string][]*Step{}
+	for _, id := range wf.JobIDs() {
+		steps, err := Job(ctx, f, wf.Jobs[id])
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", id, err)
+		}
+		out[id] = steps
+	}
+	return out, nil
+}
+
+func expand(ctx context.Context, f Fetcher, jobID string, step *workflow.Step, via []string) ([]*Step, error) {
+	s := &Step{JobID: jobID, Step: step, Via: via}
+	if step.Uses == "" {
+		s.Using = "run"
+		return []*Step{s}, nil
+	}
+	uses, err := workflow.ParseUses(step.Uses)
+	if err != nil {
+		return nil, err
+	}
+	if uses.Kind == workflow.UsesDocker {
+		s.Using = metadata.UsingDocker
+		return []*Step{s}, nil
+	}
+	action, err := Action(ctx, f, uses)
+	if err != nil {
+		return nil, err
+	}
+	s.Action = action
+	s.Using = action.Runs.Using
+	out := []*Step{s}
+	if action.Runs.Using != metadata.UsingComposite {
+		return out, nil
+	}
+
+	chain := append(append([]string(nil), via...), uses.String())
+	if len(chain) > MaxCompositeDepth {
+		return nil, fmt.Errorf("composite actions nested more than %d levels deep: %v", MaxCompositeDepth, chain)
+	}
+	for _, prev := range via {
+		if prev == uses.String() {
+			return nil, fmt.Errorf("composite action %s uses itself: %v", uses, chain)
+		}
+	}
+	for _, inner := range action.Runs.Steps {
+		steps, err := expand(ctx, f, jobID, inner, chain)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, steps...)
+	}
+	return out, nil
+}
+
+// Action fetches and parses the metadata of a referenced action.
+func Action(ctx context.Context, f Fetcher, uses *workflow.Uses) (*metadata.Action, error) {
+	dir, err := f.Fetch(ctx, uses)
+	if err != nil {
+		return nil, err
+	}
+	action, err := metadata.Load(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", uses, err)
+	}
+	return action, nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// MaxWorkflowDepth is how many levels of workflows may be connected through
+// reusable workflow calls, counting the top-level caller.
+const MaxWorkflowDepth = 10
+
+// Plan is a workflow with every reusable workflow call expanded into the
+// jobs of the called workflow.
+type Plan struct {
+	// Jobs are in source order, the jobs of a called workflow directly
+	// before the job that calls it.
+	Jobs []*PlannedJob
+}
+
+// PlannedJob is a job of a Plan.
+type PlannedJob struct {
+	// ID is unique within the plan. Jobs of a called workflow are prefixed
+	// with the ID of the calling job, as in "deploy/build".
+	ID  string
+	Job *workflow.Job
+	// Workflow is the workflow that defines Job.
+	Workflow *workflow.Workflow
+	// Needs are the plan IDs this job waits for. Jobs of a called workflow
+	// that need nothing inherit the needs of the calling job, and the
+	// calling job needs every job of the called workflow.
+	Needs []string
+	// Call is set when the job calls a reusable workflow.
+	Call *Call
+	// Via lists the reusable workflows the job was expanded from, outermost
+	// first.
+	Via []string
+}
+
+// Call is a resolved reusable workflow reference.
+type Call struct {
+	Uses     *workflow.Uses
+	Path     string
+	Workflow *workflow.Workflow
+}
+
+// Job returns the planned job with the given ID, or nil.
+func (p *Plan) Job(id string) *PlannedJob {
+	for _, j := range p.Jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// Expand resolves every reusable workflow wf calls, directly or
+// indirectly, validates each call against the workflow_call declaration of
+// the called workflow and returns the combined plan.
+func Expand(ctx context.Context, f Fetcher, wf *workflow.Workflow) (*Plan, error) {
+	plan := &Plan{}
+	if err := plan.add(ctx, f, wf, "", nil, nil); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// add appends the jobs of wf with IDs prefixed by prefix. Jobs without
+// needs wait for rootNeeds.
+func (p *Plan) add(ctx context.Context, f Fetcher, wf *workflow.Workflow, prefix string, rootNeeds, via []string) error {
+	for _, id := range wf.JobIDs() {
+		job := wf.Jobs[id]
+		pj := &PlannedJob{ID: prefix + id, Job: job, Workflow: wf, Via: via}
+		for _, need := range job.Needs {
+			pj.Needs = append(pj.Needs, prefix+need)
+		}
+		if len(job.Needs) == 0 {
+			pj.Needs = append(pj.Needs, rootNeeds...)
+		}
+		if job.Uses == "" {
+			p.Jobs = append(p.Jobs, pj)
+			continue
+		}
+
+		call, err := ResolveCall(ctx, f, job)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", pj.ID, err)
+		}
+		pj.Call = call
+		chain := append(append([]string(nil), via...), call.Uses.String())
+		if len(chain) >= MaxWorkflowDepth {
+			return fmt.Errorf("job %s: reusable workflows nested more than %d levels deep: %v", pj.ID, MaxWorkflowDepth, chain)
+		}
+		if slices.Contains(via, call.Uses.String()) {
+			return fmt.Errorf("job %s: reusable workflow %s calls itself: %v", pj.ID, call.Uses, chain)
+		}
+		if err := p.add(ctx, f, call.Workflow, pj.ID+"/", pj.Needs, chain); err != nil {
+			return err
+		}
+		// The calling job completes when the whole called workflow has.
+		pj.Needs = nil
+		for _, inner := range call.Workflow.JobIDs() {
+			pj.Needs = append(pj.Needs, pj.ID+"/"+inner)
+		}
+		p.Jobs = append(p.Jobs, pj)
+	}
+	return nil
+}
+
+// ResolveCall fetches the workflow job calls and checks the call against it.
+func ResolveCall(ctx context.Context, f Fetcher, job *workflow.Job) (*Call, error) {
+	uses, err := workflow.ParseUses(job.Uses)
+	if err != nil {
+		return nil, err
+	}
+	if !uses.IsReusableWorkflow() {
+		return nil, fmt.Errorf("%s is not a workflow file under .github/workflows", uses)
+	}
+	path, err := f.Fetch(ctx, uses)
+	if err != nil {
+		return nil, err
+	}
+	called, err := workflow.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateCall(job, called); err != nil {
+		return nil, fmt.Errorf("%s: %w", uses, err)
+	}
+	return &Call{Uses: uses, Path: path, Workflow: called}, nil
+}
+
+// Kinds of CallError.
+const (
+	CallNotCallable   = "not-callable"
+	CallUnknownInput  = "unknown-input"
+	CallMissingInput  = "missing-input"
+	CallInputType     = "input-type"
+	CallUnknownSecret = "unknown-secret"
+	CallMissingSecret = "missing-secret"
+)
+
+// CallError is a way a call does not match the workflow_call trigger of the
+// called workflow.
+type CallError struct {
+	Kind string
+	// Name is the input or secret, if any.
+	Name string
+	Err  error
+}
+
+func (e *CallError) Error() string { return e.Err.Error() }
+
+func (e *CallError) Unwrap() error { return e.Err }
+
+func callError(kind, name, format string, args ...any) error {
+	return &CallError{Kind: kind, Name: name, Err: fmt.Errorf(format, args...)}
+}
+
+// CallErrors returns the CallErrors an error of ValidateCall joins.
+func CallErrors(err error) []*CallError {
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else if err != nil {
+		errs = []error{err}
+	}
+	var out []*CallError
+	for _, err := range errs {
+		var ce *CallError
+		if errors.As(err, &ce) {
+			out = append(out, ce)
+		}
+	}
+	return out
+}
+
+// ValidateCall checks the with and secrets of a calling job against the
+// workflow_call trigger of the called workflow. The error joins a
+// CallError for each mismatch.
+func ValidateCall(job *workflow.Job, called *workflow.Workflow) error {
+	if !called.On.Has("workflow_call") {
+		return callError(CallNotCallable, "", "workflow is not triggered by workflow_call")
+	}
+	decl := called.On.Event("workflow_call")
+	if decl == nil {
+		decl = &workflow.Event{}
+	}
+
+	var errs []error
+	for _, name := range sortedKeys(job.With) {
+		input, ok := decl.Inputs[name]
+		if !ok {
+			errs = append(errs, callError(CallUnknownInput, name, "input %s is not defined by the called workflow", name))
+			continue
+		}
+		if input != nil {
+			if err := checkInputType(name, input.Type, job.With[name]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, name := range sortedKeys(decl.Inputs) {
+		input := decl.Inputs[name]
+		if _, ok := job.With[name]; !ok && input != nil && input.Required && input.Default == nil {
+			errs = append(errs, callError(CallMissingInput, name, "required input %s is not provided", name))
+		}
+	}
+
+	inherit := job.Secrets != nil && job.Secrets.Inherit
+	var passed map[string]string
+	if job.Secrets != nil {
+		passed = job.Secrets.Values
+	}
+	for _, name := range sortedKeys(passed) {
+		if _, ok := decl.Secrets[name]; !ok {
+			errs = append(errs, callError(CallUnknownSecret, name, "secret %s is not defined by the called workflow", name))
+		}
+	}
+	if !inherit {
+		for _, name := range sortedKeys(decl.Secrets) {
+			secret := decl.Secrets[name]
+			if _, ok := passed[name]; !ok && secret != nil && secret.Required {
+				errs = append(errs, callError(CallMissingSecret, name, "required secret %s is not provided", name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkInputType checks literal input values. Expressions are only known
+// at run time and are not checked.
+func checkInputType(name, typ string, v any) error {
+	if s, ok := v.(string); ok && workflow.IsExpression(s) {
+		return nil
+	}
+	ok := true
+	switch typ {
+	case "boolean":
+		_, ok = v.(bool)
+	case "number":
+		switch v.(type) {
+		case int, int64, uint64, float64:
+		default:
+			ok = false
+		}
+	case "string", "":
+		switch v.(type) {
+		case map[string]any, []any, nil:
+			ok = false
+		}
+	default:
+		return callError(CallInputType, name, "input %s has unsupported type %q", name, typ)
+	}
+	if !ok {
+		return callError(CallInputType, name, "input %s must be a %s, got %v", name, typ, v)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
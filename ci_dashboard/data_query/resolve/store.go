@@ -0,0 +1,364 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Defaults of Store.MaxAge and Store.MaxSize.
+const (
+	DefaultMaxAge  = 30 * 24 * time.Hour
+	DefaultMaxSize = 10 << 30
+)
+
+// autoPruneInterval is how often AutoPrune prunes.
+const autoPruneInterval = 24 * time.Hour
+
+// Store is the local cache of fetched actions and of the images built or
+// pulled for them. Checkouts are kept by commit SHA, so a ref is cloned
+// once per commit it points to:
+//
+//	repos/<owner>/<repo>/<sha>     the checkout
+//	refs/<owner>/<repo>/<ref>      the SHA the ref resolved to
+//	images/<image>                 an image, as JSON
+//	metadata/<owner>/<repo>/<sha>  the files an APIFetcher downloaded
+//
+// The modification times of checkouts, metadata and images record their
+// last use, and those of refs when they were resolved.
+type Store struct {
+	Dir string
+	// MaxAge and MaxSize bound the cache for AutoPrune. Default to
+	// DefaultMaxAge and DefaultMaxSize.
+	MaxAge  time.Duration
+	MaxSize int64
+}
+
+// DefaultStore returns the Store in DefaultCacheDir().
+func DefaultStore() (*Store, error) {
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir}, nil
+}
+
+// Kinds of Entry.
+const (
+	KindAction   = "action"
+	KindImage    = "image"
+	KindMetadata = "metadata"
+)
+
+// Entry is a cached checkout or image.
+type Entry struct {
+	Kind string `json:"kind"`
+	// Name is owner/repo@sha for an action and its metadata and the
+	// reference of an image.
+	Name string    `json:"name"`
+	Size int64     `json:"size"`
+	Used time.Time `json:"used"`
+	// path is the entry's file or directory in the store.
+	path string
+}
+
+// image is the record of a cached image.
+type image struct {
+	Ref  string `json:"ref"`
+	ID   string `json:"id,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+func (s *Store) repoDir(owner, repo, sha string) string {
+	return filepath.Join(s.Dir, "repos", owner, repo, sha)
+}
+
+func (s *Store) metadataDir(owner, repo, sha string) string {
+	return filepath.Join(s.Dir, "metadata", owner, repo, sha)
+}
+
+func (s *Store) refFile(owner, repo, ref string) string {
+	return filepath.Join(s.Dir, "refs", owner, repo, url.PathEscape(ref))
+}
+
+func (s *Store) imageFile(ref string) string {
+	return filepath.Join(s.Dir, "images", url.PathEscape(ref))
+}
+
+// Holds reports whether path is inside a cached checkout, whose files do
+// not change.
+func (s *Store) Holds(path string) bool {
+	rel, err := filepath.Rel(filepath.Join(s.Dir, "repos"), path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..") && len(strings.Split(rel, string(filepath.Separator))) >= 3
+}
+
+// lookupRef returns the SHA ref of owner/repo last resolved to and when.
+func (s *Store) lookupRef(owner, repo, ref string) (string, time.Time, bool) {
+	name := s.refFile(owner, repo, ref)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return strings.TrimSpace(string(data)), info.ModTime(), true
+}
+
+func (s *Store) saveRef(owner, repo, ref, sha string) error {
+	return writeAtomic(s.refFile(owner, repo, ref), []byte(sha+"\n"))
+}
+
+// AddImage records that the image ref was built or pulled for the cache,
+// which makes it subject to pruning.
+func (s *Store) AddImage(ref, id string, size int64) error {
+	data, err := json.Marshal(image{Ref: ref, ID: id, Size: size})
+	if err != nil {
+		return err
+	}
+	return writeAtomic(s.imageFile(ref), data)
+}
+
+// UseImage marks the image ref as used now and reports whether the cache
+// holds it.
+func (s *Store) UseImage(ref string) bool {
+	return touch(s.imageFile(ref)) == nil
+}
+
+// Entries returns the cached checkouts and images, least recently used
+// first.
+func (s *Store) Entries() ([]Entry, error) {
+	var entries []Entry
+	repos := filepath.Join(s.Dir, "repos")
+	dirs, err := filepath.Glob(filepath.Join(repos, "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		rel, _ := filepath.Rel(repos, dir)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		entries = append(entries, Entry{Kind: KindAction, Name: parts[0] + "/" + parts[1] + "@" + parts[2], Size: dirSize(dir), Used: info.ModTime(), path: dir})
+	}
+	metadata := filepath.Join(s.Dir, "metadata")
+	if dirs, err = filepath.Glob(filepath.Join(metadata, "*", "*", "*")); err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		rel, _ := filepath.Rel(metadata, dir)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		entries = append(entries, Entry{Kind: KindMetadata, Name: parts[0] + "/" + parts[1] + "@" + parts[2], Size: dirSize(dir), Used: info.ModTime(), path: dir})
+	}
+	files, err := os.ReadDir(filepath.Join(s.Dir, "images"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(s.Dir, "images", f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var img image
+		info, err := f.Info()
+		if err != nil || json.Unmarshal(data, &img) != nil {
+			continue
+		}
+		entries = append(entries, Entry{Kind: KindImage, Name: img.Ref, Size: img.Size, Used: info.ModTime(), path: path})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Used.Before(entries[j].Used) })
+	return entries, nil
+}
+
+// PruneOptions select what Prune removes. With neither MaxAge nor MaxSize
+// it removes everything.
+type PruneOptions struct {
+	// MaxAge removes the entries not used for longer.
+	MaxAge time.Duration
+	// MaxSize then removes the least recently used entries until the rest
+	// fit.
+	MaxSize int64
+	// RemoveImage deletes an image from Docker. Images are kept when it is
+	// nil or fails.
+	RemoveImage func(ctx context.Context, ref string) error
+	// DryRun reports what would be removed without removing it.
+	DryRun bool
+}
+
+// Prune removes the entries opts select and returns them. Refs resolved to
+// removed checkouts, refs older than MaxAge and checkouts in the layout of
+// older versions, which kept one per ref, go too.
+func (s *Store) Prune(ctx context.Context, opts PruneOptions) ([]Entry, error) {
+	entries, err := s.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	all := opts.MaxAge <= 0 && opts.MaxSize <= 0
+	now := time.Now()
+	var removed []Entry
+	var errs []error
+	kept := map[string]bool{}
+	for _, e := range entries {
+		expired := opts.MaxAge > 0 && now.Sub(e.Used) > opts.MaxAge
+		oversize := opts.MaxSize > 0 && total > opts.MaxSize
+		if !all && !expired && !oversize {
+			if e.Kind == KindAction {
+				kept[e.Name] = true
+			}
+			continue
+		}
+		if !opts.DryRun {
+			if err := s.remove(ctx, e, opts.RemoveImage); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		total -= e.Size
+		removed = append(removed, e)
+	}
+	if !opts.DryRun {
+		s.pruneRefs(kept, opts.MaxAge, now)
+		s.pruneLegacy()
+	}
+	return removed, errors.Join(errs...)
+}
+
+func (s *Store) remove(ctx context.Context, e Entry, removeImage func(context.Context, string) error) error {
+	if e.Kind == KindImage {
+		if removeImage == nil {
+			return errors.New("cannot remove image " + e.Name + " without Docker")
+		}
+		if err := removeImage(ctx, e.Name); err != nil {
+			return err
+		}
+		return os.Remove(e.path)
+	}
+	return os.RemoveAll(e.path)
+}
+
+// pruneRefs removes the refs resolved to checkouts that are not kept or
+// resolved longer than maxAge ago.
+func (s *Store) pruneRefs(kept map[string]bool, maxAge time.Duration, now time.Time) {
+	root := filepath.Join(s.Dir, "refs")
+	files, _ := filepath.Glob(filepath.Join(root, "*", "*", "*"))
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		info, statErr := os.Stat(name)
+		if err != nil || statErr != nil {
+			continue
+		}
+		rel, _ := filepath.Rel(root, filepath.Dir(name))
+		repo := filepath.ToSlash(rel) + "@" + strings.TrimSpace(string(data))
+		if !kept[repo] || (maxAge > 0 && now.Sub(info.ModTime()) > maxAge) {
+			os.Remove(name)
+		}
+	}
+}
+
+// pruneLegacy removes the checkouts kept as <owner>/<repo>@<ref>.
+func (s *Store) pruneLegacy() {
+	dirs, _ := filepath.Glob(filepath.Join(s.Dir, "*", "*@*", ".git"))
+	for _, dir := range dirs {
+		os.RemoveAll(filepath.Dir(dir))
+	}
+}
+
+// AutoPrune prunes the store to MaxAge and MaxSize if it was not pruned
+// in the last day.
+func (s *Store) AutoPrune(ctx context.Context, removeImage func(ctx context.Context, ref string) error) ([]Entry, error) {
+	stamp := filepath.Join(s.Dir, ".pruned")
+	if info, err := os.Stat(stamp); err == nil && time.Since(info.ModTime()) < autoPruneInterval {
+		return nil, nil
+	}
+	if _, err := os.Stat(s.Dir); err != nil {
+		return nil, nil
+	}
+	if err := writeAtomic(stamp, nil); err != nil {
+		return nil, err
+	}
+	opts := PruneOptions{MaxAge: s.MaxAge, MaxSize: s.MaxSize, RemoveImage: removeImage}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = DefaultMaxAge
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxSize
+	}
+	return s.Prune(ctx, opts)
+}
+
+// writeAtomic writes a file through a rename, so concurrent runs never
+// read it half written.
+func writeAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func touch(name string) error {
+	now := time.Now()
+	return os.Chtimes(name, now, now)
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,245 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BlobOptions controls blob transfers.
+type BlobOptions struct {
+	// ChunkSize is the size of each block or range. Defaults to 8 MiB.
+	ChunkSize int64
+	// Concurrency is how many chunks transfer at once. Defaults to 4.
+	Concurrency int
+	// ContentType is set on uploaded blobs.
+	ContentType string
+}
+
+func (o BlobOptions) chunk() int64 {
+	if o.ChunkSize <= 0 {
+		return 8 << 20
+	}
+	return o.ChunkSize
+}
+
+func (o BlobOptions) workers() int {
+	if o.Concurrency <= 0 {
+		return 4
+	}
+	return o.Concurrency
+}
+
+// parallel runs fn for 0..n-1 on up to workers goroutines and returns the
+// first error.
+func parallel(ctx context.Context, n, workers int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan int)
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := fn(ctx, i); err != nil {
+					errs <- err
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := range n {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// UploadBlob uploads r to an Azure block blob SAS URL in parallel blocks,
+// then commits the block list.
+func (c *Client) UploadBlob(ctx context.Context, url string, r io.ReaderAt, size int64, opts BlobOptions) error {
+	chunk := opts.chunk()
+	var ids []string
+	for off := int64(0); off < size || len(ids) == 0; off += chunk {
+		ids = append(ids, base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%010d", len(ids)))))
+	}
+	err := parallel(ctx, len(ids), opts.workers(), func(ctx context.Context, i int) error {
+		off := int64(i) * chunk
+		n := min(chunk, size-off)
+		err := c.Retry(ctx, func() error {
+			return c.put(ctx, url+"&comp=block&blockid="+ids[i], io.NewSectionReader(r, off, n), n, nil)
+		})
+		if err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var list bytes.Buffer
+	list.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+	for _, id := range ids {
+		list.WriteString("<Latest>" + id + "</Latest>")
+	}
+	list.WriteString("</BlockList>")
+	header := map[string]string{}
+	if opts.ContentType != "" {
+		header["x-ms-blob-content-type"] = opts.ContentType
+	}
+	return c.Retry(ctx, func() error {
+		return c.put(ctx, url+"&comp=blocklist", bytes.NewReader(list.Bytes()), int64(list.Len()), header)
+	})
+}
+
+// put sends a PUT to blob storage.
+func (c *Client) put(ctx context.Context, url string, body io.Reader, size int64, header map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("x-ms-version", "2023-11-03")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+	return nil
+}
+
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<12))
+	return &StatusError{Code: resp.StatusCode, Msg: strings.TrimSpace(string(msg))}
+}
+
+// DownloadBlob downloads url into w. Servers that support range requests
+// are read in parallel segments; others in a single request. It returns
+// the number of bytes written.
+func (c *Client) DownloadBlob(ctx context.Context, url string, w io.WriterAt, opts BlobOptions) (int64, error) {
+	size, ranges, err := c.probe(ctx, url)
+	if err != nil {
+		return 0, err
+	}
+	if !ranges {
+		var n int64
+		err := c.Retry(ctx, func() error {
+			var err error
+			n, err = c.get(ctx, url, "", &offsetWriter{w: w})
+			return err
+		})
+		return n, err
+	}
+	chunk := opts.chunk()
+	segments := int((size + chunk - 1) / chunk)
+	err = parallel(ctx, segments, opts.workers(), func(ctx context.Context, i int) error {
+		off := int64(i) * chunk
+		end := min(off+chunk, size) - 1
+		return c.Retry(ctx, func() error {
+			n, err := c.get(ctx, url, fmt.Sprintf("bytes=%d-%d", off, end), &offsetWriter{w: w, off: off})
+			if err == nil && n != end-off+1 {
+				err = fmt.Errorf("segment %d: short read of %d bytes", i, n)
+			}
+			return err
+		})
+	})
+	return size, err
+}
+
+// probe returns the size of the blob and whether it supports ranges.
+func (c *Client) probe(ctx context.Context, url string) (int64, bool, error) {
+	var size int64
+	var ranges bool
+	err := c.Retry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Range", "bytes=0-0")
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+			_, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/")
+			if n, err := strconv.ParseInt(total, 10, 64); ok && err == nil {
+				size, ranges = n, true
+			}
+		case http.StatusOK:
+		case http.StatusRequestedRangeNotSatisfiable:
+			// An empty blob.
+			size, ranges = 0, true
+		default:
+			return statusError(resp)
+		}
+		return nil
+	})
+	return size, ranges, err
+}
+
+func (c *Client) get(ctx context.Context, url, rng string, w io.Writer) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if rng != "" {
+		req.Header.Set("Range", rng)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, statusError(resp)
+	}
+	return io.Copy(w, resp.Body)
+}
+
+// offsetWriter writes sequentially to a WriterAt starting at off.
+type offsetWriter struct {
+	w   io.WriterAt
+	off int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.off)
+	o.off += int64(n)
+	return n, err
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package results is a client for the Actions results service, the Twirp
+// API behind artifacts and caches, and for the blob storage URLs it signs.
+package results
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/endpoints"
+)
+
+// Client talks to the results service of the current workflow run.
+type Client struct {
+	// URL is the service URL, ACTIONS_RESULTS_URL.
+	URL string
+	// Token is the runtime token, ACTIONS_RUNTIME_TOKEN.
+	Token string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// MaxRetries is how often a failed request is retried. Defaults to 5.
+	MaxRetries int
+	// RetryDelay is the initial backoff, doubled per attempt. Defaults to
+	// 3 seconds.
+	RetryDelay time.Duration
+
+	runID, jobID string
+}
+
+// NewFromEnv returns a client configured from the runner environment.
+func NewFromEnv() (*Client, error) {
+	c := &Client{URL: endpoints.FromEnv().Results, Token: os.Getenv("ACTIONS_RUNTIME_TOKEN")}
+	if c.URL == "" {
+		return nil, fmt.Errorf("ACTIONS_RESULTS_URL is not set")
+	}
+	if c.Token == "" {
+		return nil, fmt.Errorf("ACTIONS_RUNTIME_TOKEN is not set")
+	}
+	return c, nil
+}
+
+// BackendIDs returns the run and job IDs the service knows this job by.
+// They are carried in the runtime token's scope claim as
+// "Actions.Results:<run>:<job>".
+func (c *Client) BackendIDs() (string, string, error) {
+	if c.runID != "" {
+		return c.runID, c.jobID, nil
+	}
+	parts := strings.Split(c.Token, ".")
+	if len(parts) != 3 {
+		return "", "", fmt.Errorf("runtime token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode runtime token: %w", err)
+	}
+	var claims struct {
+		Scope string `json:"scp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", "", fmt.Errorf("failed to decode runtime token: %w", err)
+	}
+	for _, scope := range strings.Fields(claims.Scope) {
+		if f := strings.Split(scope, ":"); len(f) == 3 && f[0] == "Actions.Results" {
+			c.runID, c.jobID = f[1], f[2]
+			return c.runID, c.jobID, nil
+		}
+	}
+	return "", "", fmt.Errorf("runtime token has no Actions.Results scope")
+}
+
+// StatusError is a failed HTTP response.
+type StatusError struct {
+	Code int
+	Msg  string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Code, http.StatusText(e.Code), e.Msg)
+}
+
+// IsNotFound reports whether err is a 404 response.
+func IsNotFound(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Code == http.StatusNotFound
+}
+
+// retryable reports whether err is worth retrying.
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusTooManyRequests || se.Code >= 500
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Retry calls fn until it succeeds, fails permanently or runs out of
+// attempts.
+func (c *Client) Retry(ctx context.Context, fn func() error) error {
+	attempts := c.MaxRetries
+	if attempts <= 0 {
+		attempts = 5
+	}
+	delay := c.RetryDelay
+	if delay <= 0 {
+		delay = 3 * time.Second
+	}
+	var err error
+	for i := 0; ; i++ {
+		if err = fn(); err == nil || !retryable(err) || i >= attempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay << i):
+		}
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Call invokes a method of a Twirp service such as "ArtifactService".
+func (c *Client) Call(ctx context.Context, service, method string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(c.URL, "/") + "/twirp/github.actions.results.api.v1." + service + "/" + method
+	return c.Retry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			var twirp struct {
+				Msg string `json:"msg"`
+			}
+			if json.Unmarshal(data, &twirp) != nil || twirp.Msg == "" {
+				twirp.Msg = strings.TrimSpace(string(data))
+			}
+			return &StatusError{Code: resp.StatusCode, Msg: twirp.Msg}
+		}
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", method, err)
+		}
+		return nil
+	})
+}
+
+// StringValue is the protobuf StringValue wrapper.
+type StringValue struct {
+	Value string `json:"value"`
+}
+
+// Int64Value is the protobuf Int64Value wrapper, which JSON encodes as a
+// string.
+type Int64Value struct {
+	Value int64 `json:"value,string"`
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulesets
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+
+	"testingdashboard/m/v2/client"
+)
+
+// AuditOptions tune Audit.
+type AuditOptions struct {
+	// Repos limits the audit to repositories whose names match one of the
+	// patterns, in path.Match syntax. Empty means every repository.
+	Repos []string
+	// Verify tunes the verification of each repository.
+	Verify VerifyOptions
+}
+
+// Audit verifies that the default branch of every active repository of
+// org satisfies its requirements, and reports the organization rulesets
+// requiring workflows or status checks whose conditions select a
+// repository that they are not enforced on.
+func Audit(ctx context.Context, c *client.Client, org string, opts AuditOptions) ([]*Finding, error) {
+	summaries, err := c.ListOrgRulesets(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the rulesets of %s: %w", org, err)
+	}
+	var rulesets []*client.Ruleset
+	for _, s := range summaries {
+		if s.Target != "" && s.Target != "branch" || s.Enforcement == "disabled" {
+			continue
+		}
+		rs, err := c.GetOrgRuleset(ctx, org, s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ruleset %s of %s: %w", s.Name, org, err)
+		}
+		if slices.ContainsFunc(rs.Rules, func(r client.Rule) bool {
+			return r.Type == client.RuleWorkflows || r.Type == client.RuleRequiredStatusChecks
+		}) {
+			rulesets = append(rulesets, rs)
+		}
+	}
+
+	var out []*Finding
+	for repo, err := range c.ListOrgRepos(ctx, org) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the repositories of %s: %w", org, err)
+		}
+		if repo.Archived || repo.Disabled || !matchAny(opts.Repos, repo.Name) {
+			continue
+		}
+		out = append(out, auditRepo(ctx, c, org, repo, rulesets, opts)...)
+	}
+	return out, nil
+}
+
+func auditRepo(ctx context.Context, c *client.Client, org string, repo *client.Repository, rulesets []*client.Ruleset, opts AuditOptions) []*Finding {
+	branch := repo.DefaultBranch
+	failed := func(err error) []*Finding {
+		return []*Finding{{Repository: repo.FullName, Branch: branch, Status: StatusError, Detail: err.Error()}}
+	}
+	reqs, err := Required(ctx, c, org, repo.Name, branch)
+	if err != nil {
+		return failed(err)
+	}
+	enforced := map[int64]bool{}
+	for _, req := range reqs {
+		enforced[req.RulesetID] = true
+	}
+	var out []*Finding
+	for _, rs := range rulesets {
+		if applies, known := appliesTo(rs, repo, branch); !applies || !known {
+			continue
+		}
+		f := &Finding{Repository: repo.FullName, Branch: branch, Ruleset: rs.Name, Status: StatusNotEnforced}
+		switch {
+		case rs.Enforcement != "active":
+			f.Detail = fmt.Sprintf("the ruleset is in %s mode", rs.Enforcement)
+		case !enforced[rs.ID]:
+			f.Detail = "the ruleset selects the repository but none of its rules apply; the repository may be exempt"
+		default:
+			continue
+		}
+		out = append(out, f)
+	}
+	findings, err := Verify(ctx, c, org, repo.Name, branch, reqs, opts.Verify)
+	if err != nil {
+		return append(out, failed(err)...)
+	}
+	return append(out, findings...)
+}
+
+// appliesTo reports whether the conditions of rs select branch of repo.
+// known is false for conditions that cannot be evaluated here, such as
+// those on custom properties.
+func appliesTo(rs *client.Ruleset, repo *client.Repository, branch string) (applies, known bool) {
+	cond := rs.Conditions
+	if cond == nil {
+		return true, true
+	}
+	if len(cond.RepositoryProperty) > 0 && string(cond.RepositoryProperty) != "null" {
+		return false, false
+	}
+	if n := cond.RepositoryName; n != nil && !selects(n, repo.Name, "") {
+		return false, true
+	}
+	if ids := cond.RepositoryID; ids != nil && !slices.Contains(ids.RepositoryIDs, repo.ID) {
+		return false, true
+	}
+	if n := cond.RefName; n != nil && !selects(n, "refs/heads/"+branch, branch) {
+		return false, true
+	}
+	return true, true
+}
+
+// selects reports whether the condition includes name and does not
+// exclude it. defaultBranch is the branch ~DEFAULT_BRANCH stands for.
+func selects(n *client.NameCondition, name, defaultBranch string) bool {
+	match := func(patterns []string) bool {
+		for _, p := range patterns {
+			switch p {
+			case "~ALL":
+				return true
+			case "~DEFAULT_BRANCH":
+				if defaultBranch != "" && name == "refs/heads/"+defaultBranch {
+					return true
+				}
+				continue
+			}
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return match(n.Include) && !match(n.Exclude)
+}
+
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
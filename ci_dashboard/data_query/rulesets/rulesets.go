@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rulesets reads the workflows and status checks that repository
+// and organization rulesets require, verifies that repositories satisfy
+// them and audits their enforcement across an organization.
+package rulesets
+
+import (
+	"context"
+	"fmt"
+
+	"testingdashboard/m/v2/client"
+)
+
+// Kinds of requirement.
+const (
+	KindWorkflow    = "workflow"
+	KindStatusCheck = "status-check"
+)
+
+// Requirement is a workflow that must pass, or a status check that must
+// succeed, before a branch can be updated.
+type Requirement struct {
+	Kind string `json:"kind"`
+	// RulesetID, Source and SourceType identify the ruleset requiring it.
+	RulesetID  int64  `json:"ruleset_id"`
+	Source     string `json:"source"`
+	SourceType string `json:"source_type"`
+
+	// Repository, Path, Ref and SHA locate a required workflow.
+	Repository string `json:"repository,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+	SHA        string `json:"sha,omitempty"`
+
+	// Check is the context of a required status check, and IntegrationID
+	// the app that must set it, if any.
+	Check         string `json:"check,omitempty"`
+	IntegrationID int64  `json:"integration_id,omitempty"`
+}
+
+func (r *Requirement) String() string {
+	if r.Kind == KindStatusCheck {
+		return "check " + r.Check
+	}
+	s := "workflow " + r.Repository + "/" + r.Path
+	if v := r.Version(); v != "" {
+		s += "@" + v
+	}
+	return s
+}
+
+// Version returns the commit or ref of a required workflow.
+func (r *Requirement) Version() string {
+	if r.SHA != "" {
+		return r.SHA
+	}
+	return r.Ref
+}
+
+// Required returns what the active rulesets require of updates to branch
+// of owner/repo.
+func Required(ctx context.Context, c *client.Client, owner, repo, branch string) ([]*Requirement, error) {
+	rules, err := c.ListBranchRules(ctx, owner, repo, branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the rules of %s/%s branch %s: %w", owner, repo, branch, err)
+	}
+	repos := map[int64]string{}
+	var reqs []*Requirement
+	for _, rule := range rules {
+		base := Requirement{RulesetID: rule.RulesetID, Source: rule.RulesetSource, SourceType: rule.RulesetSourceType}
+		switch rule.Type {
+		case client.RuleWorkflows:
+			p, err := rule.Workflows()
+			if err != nil {
+				return nil, err
+			}
+			for _, w := range p.Workflows {
+				name, ok := repos[w.RepositoryID]
+				if !ok {
+					r, err := c.GetRepositoryByID(ctx, w.RepositoryID)
+					if err != nil {
+						return nil, fmt.Errorf("failed to look up repository %d of required workflow %s: %w", w.RepositoryID, w.Path, err)
+					}
+					name = r.FullName
+					repos[w.RepositoryID] = name
+				}
+				req := base
+				req.Kind, req.Repository, req.Path, req.Ref, req.SHA = KindWorkflow, name, w.Path, w.Ref, w.SHA
+				reqs = append(reqs, &req)
+			}
+		case client.RuleRequiredStatusChecks:
+			p, err := rule.StatusChecks()
+			if err != nil {
+				return nil, err
+			}
+			for _, check := range p.RequiredStatusChecks {
+				req := base
+				req.Kind, req.Check, req.IntegrationID = KindStatusCheck, check.Context, check.IntegrationID
+				reqs = append(reqs, &req)
+			}
+		}
+	}
+	return reqs, nil
+}
+
+// MergeQueue reports whether an active ruleset sends pull requests to
+// branch of owner/repo through a merge queue.
+func MergeQueue(ctx context.Context, c *client.Client, owner, repo, branch string) (bool, error) {
+	rules, err := c.ListBranchRules(ctx, owner, repo, branch)
+	if err != nil {
+		return false, fmt.Errorf("failed to list the rules of %s/%s branch %s: %w", owner, repo, branch, err)
+	}
+	for _, rule := range rules {
+		if rule.Type == client.RuleMergeQueue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,226 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rulesets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"testingdashboard/m/v2/checks"
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Statuses of a finding.
+const (
+	StatusOK = "ok"
+	// StatusMissing is a required workflow file or status check that does
+	// not exist.
+	StatusMissing = "missing"
+	// StatusInvalid is a required workflow that does not parse.
+	StatusInvalid = "invalid"
+	StatusFailing = "failing"
+	StatusPending = "pending"
+	// StatusNoRuns is a required workflow that has not run on the branch
+	// recently.
+	StatusNoRuns = "no-runs"
+	// StatusNotEnforced is a ruleset that should apply to a repository
+	// but does not, or only evaluates.
+	StatusNotEnforced = "not-enforced"
+	// StatusError is a repository that could not be checked.
+	StatusError = "error"
+)
+
+// Finding is the state of a requirement in a repository, or a problem with
+// the enforcement of a ruleset.
+type Finding struct {
+	Repository  string       `json:"repository"`
+	Branch      string       `json:"branch,omitempty"`
+	Requirement *Requirement `json:"requirement,omitempty"`
+	// Ruleset names the ruleset of a StatusNotEnforced finding.
+	Ruleset string `json:"ruleset,omitempty"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+	// URL is the run or check that decided the status.
+	URL string `json:"url,omitempty"`
+}
+
+// OK reports whether the finding is not a problem.
+func (f *Finding) OK() bool {
+	return f.Status == StatusOK
+}
+
+// Subject describes what the finding is about.
+func (f *Finding) Subject() string {
+	switch {
+	case f.Requirement != nil:
+		return f.Requirement.String()
+	case f.Ruleset != "":
+		return "ruleset " + f.Ruleset
+	}
+	return "repository"
+}
+
+// VerifyOptions tune Verify.
+type VerifyOptions struct {
+	// MaxRuns is how many of the branch's latest completed runs are
+	// searched for runs of required workflows. Defaults to 50.
+	MaxRuns int
+}
+
+// Verify checks that owner/repo satisfies the requirements on branch:
+// required workflows exist, parse and last passed on the branch, and
+// required status checks succeeded on its head.
+func Verify(ctx context.Context, c *client.Client, owner, repo, branch string, reqs []*Requirement, opts VerifyOptions) ([]*Finding, error) {
+	if opts.MaxRuns <= 0 {
+		opts.MaxRuns = 50
+	}
+	v := &verifier{c: c, owner: owner, repo: repo, branch: branch, opts: opts}
+	var out []*Finding
+	for _, req := range reqs {
+		f := &Finding{Repository: owner + "/" + repo, Branch: branch, Requirement: req}
+		var err error
+		if req.Kind == KindStatusCheck {
+			err = v.check(ctx, f)
+		} else {
+			err = v.workflow(ctx, f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+type verifier struct {
+	c                   *client.Client
+	owner, repo, branch string
+	opts                VerifyOptions
+	runs                []*client.Run
+	listed              bool
+}
+
+// branchRuns returns the latest completed runs on the branch, listing them
+// once.
+func (v *verifier) branchRuns(ctx context.Context) ([]*client.Run, error) {
+	if v.listed {
+		return v.runs, nil
+	}
+	for run, err := range v.c.ListRuns(ctx, v.owner, v.repo, client.RunsOptions{Branch: v.branch, Status: "completed"}) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the runs of %s/%s: %w", v.owner, v.repo, err)
+		}
+		v.runs = append(v.runs, run)
+		if len(v.runs) == v.opts.MaxRuns {
+			break
+		}
+	}
+	v.listed = true
+	return v.runs, nil
+}
+
+func (v *verifier) workflow(ctx context.Context, f *Finding) error {
+	req := f.Requirement
+	owner, repo, _ := strings.Cut(req.Repository, "/")
+	data, err := v.c.GetContents(ctx, owner, repo, req.Path, req.Version())
+	if client.IsNotFound(err) {
+		f.Status, f.Detail = StatusMissing, fmt.Sprintf("%s does not exist in %s", req.Path, req.Repository)
+		if req.Version() != "" {
+			f.Detail += " at " + req.Version()
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read required workflow %s: %w", req, err)
+	}
+	if _, err := workflow.Parse(data); err != nil {
+		f.Status, f.Detail = StatusInvalid, err.Error()
+		return nil
+	}
+
+	runs, err := v.branchRuns(ctx)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if !runOf(run, req) {
+			continue
+		}
+		f.URL = run.HTMLURL
+		switch run.Conclusion {
+		case checks.ConclusionSuccess, checks.ConclusionNeutral, checks.ConclusionSkipped:
+			f.Status = StatusOK
+		default:
+			f.Status, f.Detail = StatusFailing, fmt.Sprintf("run #%d on %s concluded %s", run.RunNumber, run.HeadSHA[:min(7, len(run.HeadSHA))], run.Conclusion)
+		}
+		return nil
+	}
+	f.Status, f.Detail = StatusNoRuns, fmt.Sprintf("none of the latest %d completed runs on %s ran it", len(runs), v.branch)
+	return nil
+}
+
+// runOf reports whether run is of the required workflow. Runs of workflows
+// from other repositories have paths of the form
+// owner/repo/.github/workflows/ci.yml@ref.
+func runOf(run *client.Run, req *Requirement) bool {
+	path, _, _ := strings.Cut(run.Path, "@")
+	return path == req.Path || strings.EqualFold(path, req.Repository+"/"+req.Path)
+}
+
+func (v *verifier) check(ctx context.Context, f *Finding) error {
+	req := f.Requirement
+	runs, err := checks.ListForRef(ctx, v.c, v.owner, v.repo, v.branch, req.Check)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if req.IntegrationID != 0 && (run.App == nil || run.App.ID != req.IntegrationID) {
+			continue
+		}
+		f.URL = run.HTMLURL
+		switch {
+		case run.Status != checks.StatusCompleted:
+			f.Status, f.Detail = StatusPending, "the check run is "+run.Status
+		case run.Conclusion == checks.ConclusionSuccess || run.Conclusion == checks.ConclusionNeutral || run.Conclusion == checks.ConclusionSkipped:
+			f.Status = StatusOK
+		default:
+			f.Status, f.Detail = StatusFailing, "the check run concluded "+run.Conclusion
+		}
+		return nil
+	}
+	statuses, err := checks.Statuses(ctx, v.c, v.owner, v.repo, v.branch)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		if s.Context != req.Check {
+			continue
+		}
+		f.URL = s.TargetURL
+		switch s.State {
+		case "success":
+			f.Status = StatusOK
+		case "pending":
+			f.Status, f.Detail = StatusPending, "the status is pending"
+		default:
+			f.Status, f.Detail = StatusFailing, "the status is "+s.State
+		}
+		return nil
+	}
+	f.Status, f.Detail = StatusMissing, fmt.Sprintf("no check run or status named %q on the head of %s", req.Check, v.branch)
+	return nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/logging"
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/workflow"
+)
+
+// exitError reports a non-zero exit code from a step that did not run as a
+// host process.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e *exitError) ExitCode() int {
+	return e.code
+}
+
+// runAction runs a uses: step.
+func (jr *jobRun) runAction(ctx context.Context, step *workflow.Step, sr *StepResult, env map[string]string, files *stepFiles, ectx *expr.Context) error {
+	ref, err := expr.Interpolate(step.Uses, ectx)
+	if err != nil {
+		return err
+	}
+	uses, err := workflow.ParseUses(ref)
+	if err != nil {
+		return err
+	}
+	if jr.thirdParty(uses) {
+		// runStep restores the limits when the step ends.
+		jr.limits = jr.limits.tighten(jr.run.r.opts.Sandbox.ThirdParty)
+	}
+	with, err := interpolateMap(step.With, ectx)
+	if err != nil {
+		return err
+	}
+
+	if uses.Kind == workflow.UsesDocker {
+		args, err := splitArgs(with["args"])
+		if err != nil {
+			return err
+		}
+		return jr.runContainerAction(ctx, &containerAction{
+			image:      uses.Image,
+			entrypoint: with["entrypoint"],
+			args:       args,
+			inputs:     with,
+		}, env, files)
+	}
+
+	dir, err := jr.run.r.opts.Fetcher.Fetch(ctx, uses)
+	if err != nil {
+		return err
+	}
+	if uses.Kind == workflow.UsesRepository {
+		sr.SHA = gitOutput(dir, "rev-parse", "HEAD")
+	}
+	meta, err := metadata.Load(dir)
+	if err != nil {
+		return err
+	}
+	inputs, err := jr.actionInputs(meta, with, ectx)
+	if err != nil {
+		return err
+	}
+	if jr.container != nil && meta.Runs.Using != metadata.UsingDocker && meta.Runs.Using != metadata.UsingWASM {
+		// JavaScript and composite actions run in the job container, so
+		// it must see their files; wasm actions run on the host.
+		if dir, err = jr.container.visible(dir); err != nil {
+			return err
+		}
+	}
+
+	switch using := meta.Runs.Using; {
+	case using == metadata.UsingDocker:
+		return jr.runDockerAction(ctx, dir, meta, inputs, with, env, files, ectx)
+	case metadata.IsNode(using):
+		na, err := resolveNodeAction(dir, uses, meta)
+		if err != nil {
+			return err
+		}
+		if na.post != "" {
+			// The post entry point runs at the end of the job once main has
+			// started, whatever its outcome.
+			jr.posts = append(jr.posts, &hookStep{name: "Post " + sr.Name, step: step, stepID: sr.ID, action: na, inputs: inputs, env: env, composite: jr.composite, limits: jr.limits})
+		}
+		return jr.runNodeScript(ctx, na, na.main, inputs, env, jr.stepState(sr), files)
+	case using == metadata.UsingComposite:
+		return jr.runComposite(ctx, dir, meta, inputs, env, sr)
+	case using == metadata.UsingWASM:
+		return jr.runWASMAction(ctx, dir, uses, meta, inputs, env, sr, files)
+	}
+	return fmt.Errorf("unsupported action type %q in %s", meta.Runs.Using, uses)
+}
+
+// actionInputs combines the step's with: values and the action's defaults.
+func (jr *jobRun) actionInputs(meta *metadata.Action, with map[string]string, ectx *expr.Context) (map[string]string, error) {
+	inputs := map[string]string{}
+	for name, input := range meta.Inputs {
+		if v, ok := with[name]; ok {
+			inputs[name] = v
+			continue
+		}
+		if input == nil {
+			continue
+		}
+		value, err := expr.Interpolate(input.Default, ectx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate default of input %s: %w", name, err)
+		}
+		inputs[name] = value
+		if input.Required && input.Default == "" {
+			jr.logf(logging.LevelWarning, "Warning: input required and not supplied: %s", name)
+		}
+	}
+	var unexpected []string
+	for name, v := range with {
+		if _, ok := meta.Inputs[name]; !ok {
+			unexpected = append(unexpected, name)
+			inputs[name] = v
+		}
+	}
+	if len(unexpected) > 0 {
+		sort.Strings(unexpected)
+		jr.logf(logging.LevelWarning, "Warning: unexpected input(s) %s", strings.Join(unexpected, ", "))
+	}
+	return inputs, nil
+}
+
+// inputEnv returns the INPUT_* variables for inputs.
+func inputEnv(inputs map[string]string) map[string]string {
+	env := make(map[string]string, len(inputs))
+	for name, v := range inputs {
+		env["INPUT_"+strings.ToUpper(strings.ReplaceAll(name, " ", "_"))] = v
+	}
+	return env
+}
+
+func interpolateMap(m map[string]string, ectx *expr.Context) (map[string]string, error) {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		value, err := expr.Interpolate(v, ectx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate %s: %w", k, err)
+		}
+		out[k] = value
+	}
+	return out, nil
+}
+
+// splitArgs splits a command line on whitespace, honoring single and double
+// quotes, the way the runner splits the args input of docker steps.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/glob"
+)
+
+// uploadArtifact copies the files with matches into the run's artifact
+// of that name, relative to the directory the paths have in common, as
+// actions/upload-artifact does.
+func (jr *jobRun) uploadArtifact(ctx context.Context, s *Step) error {
+	name := s.With["name"]
+	if name == "" {
+		name = "artifact"
+	}
+	if strings.ContainsAny(name, "\":<>|*?\r\n\\/") {
+		return fmt.Errorf("the artifact name %q contains one of the characters \" : < > | * ? \\r \\n \\ /", name)
+	}
+	patterns := s.With["path"]
+	if strings.TrimSpace(patterns) == "" {
+		return fmt.Errorf("input required and not supplied: path")
+	}
+	opts := glob.DefaultOptions()
+	opts.Root = s.Workspace
+	opts.ExcludeHiddenFiles = !boolInput(s.With["include-hidden-files"])
+	g, err := glob.New(expandHome(patterns), opts)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for p, err := range g.All() {
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			files = append(files, p)
+		}
+	}
+	if len(files) == 0 {
+		msg := "No files were found with the provided path: " + strings.Join(strings.Fields(patterns), ", ") + ". No artifacts will be uploaded."
+		switch s.With["if-no-files-found"] {
+		case "error":
+			return fmt.Errorf("%s", msg)
+		case "ignore":
+			fmt.Fprintln(s.Log, msg)
+		default:
+			fmt.Fprintln(s.Log, "::warning::"+msg)
+		}
+		return nil
+	}
+	root := uploadRoot(g.SearchPaths(), files)
+
+	jr.run.files.Lock()
+	defer jr.run.files.Unlock()
+	dir := filepath.Join(jr.run.artifacts, name)
+	if _, err := os.Stat(dir); err == nil {
+		if !boolInput(s.With["overwrite"]) {
+			return fmt.Errorf("an artifact with the name %s already exists in this run; set overwrite: true to replace it", name)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			return err
+		}
+		resolved, err := filepath.EvalSymlinks(f)
+		if err != nil {
+			return err
+		}
+		if err := copyFile(resolved, filepath.Join(dir, rel)); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", f, err)
+		}
+	}
+	jr.run.artifactIDs++
+	s.Outputs["artifact-id"] = strconv.Itoa(jr.run.artifactIDs)
+	s.Outputs["artifact-url"] = "file://" + filepath.ToSlash(dir)
+	fmt.Fprintf(s.Log, "Uploaded %s to artifact %s\n", fileCount(len(files)), name)
+	return nil
+}
+
+// uploadRoot returns the directory artifact paths are relative to: that
+// of the only file a path names, or the one all search paths share.
+func uploadRoot(searchPaths, files []string) string {
+	if len(searchPaths) == 1 && len(files) == 1 && searchPaths[0] == files[0] {
+		return filepath.Dir(files[0])
+	}
+	root := searchPaths[0]
+	for _, p := range searchPaths[1:] {
+		for root != p && !strings.HasPrefix(p, root+string(filepath.Separator)) {
+			parent := filepath.Dir(root)
+			if parent == root {
+				break
+			}
+			root = parent
+		}
+	}
+	return root
+}
+
+// downloadArtifact copies the run's artifacts into the path input, as
+// actions/download-artifact does: the one named into the path itself,
+// the others into a directory each unless merge-multiple is set.
+func (jr *jobRun) downloadArtifact(ctx context.Context, s *Step) error {
+	if id := s.With["run-id"]; id != "" && id != expr.ToString(jr.run.github["run_id"]) {
+		return fmt.Errorf("artifacts of other runs cannot be downloaded locally")
+	}
+	dest := expandHome(s.With["path"])
+	if dest == "" {
+		dest = s.Workspace
+	} else if !filepath.IsAbs(dest) {
+		dest = filepath.Join(s.Workspace, dest)
+	}
+
+	jr.run.files.Lock()
+	defer jr.run.files.Unlock()
+	var names []string
+	if name := s.With["name"]; name != "" {
+		if _, err := os.Stat(filepath.Join(jr.run.artifacts, name)); err != nil {
+			return fmt.Errorf("unable to download artifact(s): artifact not found for name: %s", name)
+		}
+		names = []string{name}
+	} else {
+		entries, err := os.ReadDir(jr.run.artifacts)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, e := range entries {
+			if ok, _ := path.Match(s.With["pattern"], e.Name()); e.IsDir() && (s.With["pattern"] == "" || ok) {
+				names = append(names, e.Name())
+			}
+		}
+	}
+	for _, name := range names {
+		to := dest
+		if s.With["name"] == "" && !boolInput(s.With["merge-multiple"]) {
+			to = filepath.Join(dest, name)
+		}
+		n, err := mergeTree(filepath.Join(jr.run.artifacts, name), to)
+		if err != nil {
+			return fmt.Errorf("failed to download artifact %s: %w", name, err)
+		}
+		fmt.Fprintf(s.Log, "Downloaded %s of artifact %s to %s\n", fileCount(n), name, to)
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(s.Log, "No artifacts to download")
+	}
+	s.Outputs["download-path"] = dest
+	return nil
+}
+
+// boolInput reads a boolean action input as @actions/core getBooleanInput
+// does, treating anything but true as false.
+func boolInput(s string) bool {
+	switch strings.TrimSpace(s) {
+	case "true", "True", "TRUE":
+		return true
+	}
+	return false
+}
+
+// expandHome replaces a leading ~ with the home directory, as the
+// artifact actions do.
+func expandHome(p string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	lines := strings.Split(p, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "~" || strings.HasPrefix(trimmed, "~/") {
+			lines[i] = home + strings.TrimPrefix(trimmed, "~")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
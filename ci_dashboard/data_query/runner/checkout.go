@@ -0,0 +1,117 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/gitops"
+)
+
+// checkout stands in for actions/checkout. The workspace is already a
+// checkout of the workflow's repository, which is used as it is; other
+// repositories and paths are checked out with gitops.
+func (jr *jobRun) checkout(ctx context.Context, s *Step) error {
+	own := expr.ToString(jr.run.github["repository"])
+	repository := s.With["repository"]
+	if repository == "" {
+		repository = own
+	}
+	dir := s.Workspace
+	if p := s.With["path"]; p != "" {
+		dir = filepath.Join(s.Workspace, p)
+		if rel, err := filepath.Rel(s.Workspace, dir); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("repository path %q is not under %s", p, s.Workspace)
+		}
+	}
+	if strings.EqualFold(repository, own) && dir == s.Workspace {
+		return jr.checkoutWorkspace(ctx, s)
+	}
+
+	opts := gitops.CheckoutOptions{
+		Repository:         repository,
+		Ref:                s.With["ref"],
+		Dir:                dir,
+		Auth:               gitops.Auth{ServerURL: s.With["github-server-url"], Token: s.With["token"]},
+		Depth:              1,
+		Tags:               boolInput(s.With["fetch-tags"]),
+		Filter:             s.With["filter"],
+		NoCone:             s.With["sparse-checkout-cone-mode"] != "" && !boolInput(s.With["sparse-checkout-cone-mode"]),
+		LFS:                boolInput(s.With["lfs"]),
+		Clean:              s.With["clean"] == "" || boolInput(s.With["clean"]),
+		PersistCredentials: s.With["persist-credentials"] == "" || boolInput(s.With["persist-credentials"]),
+		Log:                s.Log,
+	}
+	if opts.Auth.ServerURL == "" {
+		opts.Auth.ServerURL = jr.run.r.opts.Endpoints.Server
+	}
+	if opts.Auth.Token == "" {
+		opts.Auth.Token = expr.ToString(jr.run.github["token"])
+	}
+	if d := s.With["fetch-depth"]; d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 0 {
+			return fmt.Errorf("fetch-depth must be a number of commits, not %q", d)
+		}
+		opts.Depth = n
+	}
+	for _, line := range strings.Split(s.With["sparse-checkout"], "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			opts.Sparse = append(opts.Sparse, line)
+		}
+	}
+	switch sub := s.With["submodules"]; sub {
+	case "", "false":
+	case "true", "recursive":
+		opts.Submodules = sub
+	default:
+		return fmt.Errorf("submodules must be true, false or recursive, not %q", sub)
+	}
+	_, res, err := gitops.Checkout(ctx, opts)
+	if err != nil {
+		return err
+	}
+	s.Outputs["ref"], s.Outputs["commit"] = res.Ref, res.Commit
+	fmt.Fprintf(s.Log, "Checked out %s at %s into %s\n", repository, res.Commit, dir)
+	return nil
+}
+
+// checkoutWorkspace reports the workspace's own checkout, warning when it
+// is not at the ref the step asks for.
+func (jr *jobRun) checkoutWorkspace(ctx context.Context, s *Step) error {
+	repo := &gitops.Repo{Dir: s.Workspace}
+	commit, err := repo.Head(ctx)
+	if err != nil {
+		return fmt.Errorf("the workspace %s is not a git checkout: %w", s.Workspace, err)
+	}
+	if ref := s.With["ref"]; ref != "" {
+		if at, err := repo.Output(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil || at != commit {
+			fmt.Fprintf(s.Log, "::warning::The workspace is at %s, not %s; steps run on the local checkout as it is\n", commit[:min(7, len(commit))], ref)
+		}
+	}
+	s.Outputs["ref"] = expr.ToString(jr.run.github["ref"])
+	s.Outputs["commit"] = commit
+	if repository := expr.ToString(jr.run.github["repository"]); repository != "" {
+		fmt.Fprintf(s.Log, "Using the local checkout of %s at %s\n", repository, commit)
+	} else {
+		fmt.Fprintf(s.Log, "Using the local checkout at %s\n", commit)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"testingdashboard/m/v2/commands"
+	"testingdashboard/m/v2/logging"
+)
+
+// commandWriter sits between a step's output and the job log. It interprets
+// workflow commands (lines starting with "::"), applies problem matchers
+// and masks registered secrets in everything else.
+type commandWriter struct {
+	mu  sync.Mutex
+	p   *commands.Processor
+	out io.Writer
+	buf bytes.Buffer
+}
+
+func (jr *jobRun) newCommandWriter(sr *StepResult) *commandWriter {
+	return &commandWriter{
+		p: &commands.Processor{
+			Masker:   jr.masks,
+			Matchers: jr.matchers,
+			Outputs:  sr.Outputs,
+			State:    jr.stepState(sr),
+			Debug:    jr.run.r.opts.Env["ACTIONS_STEP_DEBUG"] == "true",
+		},
+		out: jr.log,
+	}
+}
+
+func (w *commandWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(b)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.buf.Next(i+1)), "\r\n")
+		w.handleLine(line)
+	}
+	return len(b), nil
+}
+
+// Flush processes any trailing partial line.
+func (w *commandWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() > 0 {
+		line := w.buf.String()
+		w.buf.Reset()
+		w.handleLine(line)
+	}
+}
+
+func (w *commandWriter) handleLine(line string) {
+	n := len(w.p.Annotations)
+	l := w.p.Process(line)
+	if l.Hidden {
+		return
+	}
+	level, a := logging.LevelInfo, (*logging.Annotation)(nil)
+	switch {
+	case len(w.p.Annotations) > n:
+		pa := w.p.Annotations[len(w.p.Annotations)-1]
+		level = logging.Level(pa.Level)
+		a = &logging.Annotation{Title: w.p.Masker.Mask(pa.Title), File: pa.File, Code: pa.Code, Line: pa.Line, EndLine: pa.EndLine, Column: pa.Column, EndColumn: pa.EndColumn}
+	case l.Command != nil && l.Command.Name == "debug":
+		level = logging.LevelDebug
+	}
+	w.forward(level, l.Text, a)
+}
+
+// writeEntry passes on a line that needs no command processing, such as
+// one a nested step's processor already handled.
+func (w *commandWriter) writeEntry(level logging.Level, text string, a *logging.Annotation) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.forward(level, w.p.Masker.Mask(text), a)
+}
+
+func (w *commandWriter) forward(level logging.Level, text string, a *logging.Annotation) {
+	if ew, ok := w.out.(entryWriter); ok {
+		ew.writeEntry(level, text, a)
+		return
+	}
+	fmt.Fprintln(w.out, text)
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"testingdashboard/m/v2/compliance"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+// auditEntry starts the audit entry of a step about to run, or returns nil
+// without Options.Compliance. Values the step cannot evaluate are left out.
+func (jr *jobRun) auditEntry(step *workflow.Step, sr *StepResult, ectx *expr.Context, inputs map[string]string) *compliance.Entry {
+	if jr.run.r.opts.Compliance == nil {
+		return nil
+	}
+	e := &compliance.Entry{
+		Workflow: expr.ToString(jr.run.github["workflow"]),
+		RunID:    expr.ToString(jr.run.github["run_id"]),
+		Job:      jr.job.ID,
+		Leg:      jr.name,
+		StepID:   step.ID,
+	}
+	e.Uses, _ = expr.Interpolate(step.Uses, ectx)
+	e.Run, _ = expr.Interpolate(step.Run, ectx)
+	if inputs == nil {
+		inputs, _ = interpolateMap(step.With, ectx)
+	}
+	if len(inputs) > 0 {
+		e.Inputs = make(map[string]string, len(inputs))
+		for k, v := range inputs {
+			e.Inputs[k] = jr.masks.Mask(v)
+		}
+	}
+	e.Uses, e.Run = jr.masks.Mask(e.Uses), jr.masks.Mask(e.Run)
+	return e
+}
+
+// audit completes e with the step's result and appends it to the log.
+func (jr *jobRun) audit(e *compliance.Entry, sr *StepResult) {
+	if e == nil {
+		return
+	}
+	e.Time = time.Now()
+	e.Step = sr.Name
+	e.SHA = sr.SHA
+	e.ExitCode = sr.ExitCode
+	e.Outcome, e.Conclusion = sr.Outcome, sr.Conclusion
+	e.DurationMS = sr.Duration.Milliseconds()
+	e.Attempts = sr.Attempts
+	if _, err := jr.run.r.opts.Compliance.Append(*e); err != nil {
+		fmt.Fprintf(jr.legLog, "Error recording step %q in the audit log: %v\n", sr.Name, err)
+	}
+}
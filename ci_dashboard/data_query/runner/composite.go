@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/resolve"
+)
+
+// compositeScope is what changes for the steps of a composite action: they
+// see the action's inputs, its directory and the env of the step using it.
+type compositeScope struct {
+	dir    string
+	inputs map[string]string
+	env    map[string]string
+	depth  int
+	// prefix scopes the state of the action's steps to this use of it.
+	prefix string
+}
+
+// runComposite runs the steps of a composite action in their own steps
+// context and copies the action's outputs to sr.
+func (jr *jobRun) runComposite(ctx context.Context, dir string, meta *metadata.Action, inputs, env map[string]string, sr *StepResult) error {
+	parent := jr.composite
+	scope := &compositeScope{dir: dir, inputs: inputs, env: map[string]string{}, depth: 1, prefix: jr.stateKey(sr.ID) + "/"}
+	if parent != nil {
+		scope.depth = parent.depth + 1
+		for k, v := range parent.env {
+			scope.env[k] = v
+		}
+	}
+	if scope.depth > resolve.MaxCompositeDepth {
+		return fmt.Errorf("composite actions nested more than %d levels deep", resolve.MaxCompositeDepth)
+	}
+	for k, v := range env {
+		scope.env[k] = v
+	}
+
+	steps, status := jr.steps, jr.status
+	jr.composite, jr.steps, jr.status = scope, map[string]any{}, expr.StatusSuccess
+	defer func() {
+		jr.composite, jr.steps = parent, steps
+	}()
+
+	for i, step := range meta.Runs.Steps {
+		if ctx.Err() != nil {
+			jr.status = expr.StatusCancelled
+		}
+		sr.Steps = append(sr.Steps, jr.runStep(ctx, step, i))
+	}
+
+	outCtx := &expr.Context{Values: restrict(jr.values(nil), "outputs", "*", "value"), Status: jr.status}
+	for name, output := range meta.Outputs {
+		if output == nil {
+			continue
+		}
+		value, err := expr.Interpolate(output.Value, outCtx)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate output %s: %w", name, err)
+		}
+		sr.Outputs[name] = value
+	}
+
+	inner := jr.status
+	jr.status = status
+	switch inner {
+	case expr.StatusCancelled:
+		return ctx.Err()
+	case expr.StatusFailure:
+		return fmt.Errorf("composite action %s failed", meta.Name)
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/docker"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/metadata"
+)
+
+// Paths used inside action containers, matching the hosted runner.
+const (
+	containerWorkspace    = "/github/workspace"
+	containerHome         = "/github/home"
+	containerWorkflowDir  = "/github/workflow"
+	containerFileCommands = "/github/file_commands"
+)
+
+// containerAction is a container step ready to run.
+type containerAction struct {
+	image string
+	// build is the Dockerfile to build image from, relative to buildDir.
+	build      string
+	buildDir   string
+	entrypoint string
+	args       []string
+	env        map[string]string
+	inputs     map[string]string
+}
+
+// dockerClient returns the run's Docker client, connecting on first use.
+func (run *run) dockerClient() (*docker.Client, error) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.docker == nil {
+		c, err := docker.NewClient()
+		if err != nil {
+			return nil, err
+		}
+		run.docker = c
+	}
+	return run.docker, nil
+}
+
+// runDockerAction runs an action whose metadata declares runs.using: docker.
+func (jr *jobRun) runDockerAction(ctx context.Context, dir string, meta *metadata.Action, inputs, with, env map[string]string, files *stepFiles, ectx *expr.Context) error {
+	actx := &expr.Context{Values: withInputs(ectx.Values, inputs), Status: ectx.Status}
+	ca := &containerAction{inputs: inputs, entrypoint: meta.Runs.Entrypoint}
+	image := meta.Runs.Image
+	if strings.HasPrefix(image, "docker://") {
+		ca.image = strings.TrimPrefix(image, "docker://")
+	} else {
+		sum := sha256.Sum256([]byte(dir))
+		ca.image = "actions-local/" + hex.EncodeToString(sum[:6]) + ":latest"
+		ca.build = image
+		ca.buildDir = dir
+	}
+
+	if v, ok := with["args"]; ok {
+		args, err := splitArgs(v)
+		if err != nil {
+			return err
+		}
+		ca.args = args
+	} else {
+		for _, arg := range meta.Runs.Args {
+			value, err := expr.Interpolate(arg, actx)
+			if err != nil {
+				return fmt.Errorf("failed to evaluate args: %w", err)
+			}
+			ca.args = append(ca.args, value)
+		}
+	}
+	if v, ok := with["entrypoint"]; ok {
+		ca.entrypoint = v
+	}
+	actionEnv, err := interpolateMap(meta.Runs.Env, actx)
+	if err != nil {
+		return err
+	}
+	ca.env = actionEnv
+	return jr.runContainerAction(ctx, ca, env, files)
+}
+
+// withInputs returns a copy of values with the inputs context replaced.
+func withInputs(values map[string]any, inputs map[string]string) map[string]any {
+	out := make(map[string]any, len(values)+1)
+	for k, v := range values {
+		out[k] = v
+	}
+	out["inputs"] = stringMap(inputs)
+	return out
+}
+
+func (jr *jobRun) runContainerAction(ctx context.Context, ca *containerAction, stepEnv map[string]string, files *stepFiles) error {
+	client, err := jr.run.dockerClient()
+	if err != nil {
+		return err
+	}
+	switch {
+	case ca.build != "" && jr.run.cachedBuild(ctx, client, ca):
+		fmt.Fprintf(jr.log, "Using %s built from %s\n", ca.image, ca.build)
+	case ca.build != "":
+		fmt.Fprintf(jr.log, "Building %s from %s\n", ca.image, ca.build)
+		if err := client.ImageBuild(ctx, ca.buildDir, ca.build, ca.image, jr.log); err != nil {
+			return err
+		}
+		if cache := jr.run.r.opts.Cache; cache != nil && cache.Holds(ca.buildDir) {
+			jr.run.cacheImage(ctx, client, ca.image)
+		}
+	default:
+		if err := jr.ensureImage(ctx, client, ca.image, nil); err != nil {
+			return err
+		}
+	}
+
+	home := filepath.Join(jr.temp, "_github_home")
+	if err := os.MkdirAll(home, 0o755); err != nil {
+		return err
+	}
+	env := jr.containerEnv(stepEnv, files)
+	for k, v := range ca.env {
+		env[k] = v
+	}
+	for k, v := range inputEnv(ca.inputs) {
+		env[k] = v
+	}
+
+	cfg := &docker.ContainerConfig{
+		Image:      ca.image,
+		Cmd:        ca.args,
+		Env:        envList(env),
+		WorkingDir: containerWorkspace,
+		Labels:     map[string]string{"actions-local": "true"},
+		HostConfig: docker.HostConfig{
+			Binds: []string{
+				jr.workspace + ":" + containerWorkspace,
+				home + ":" + containerHome,
+				filepath.Dir(jr.run.eventPath) + ":" + containerWorkflowDir,
+				filepath.Dir(files.env) + ":" + containerFileCommands,
+			},
+		},
+	}
+	if ca.entrypoint != "" {
+		cfg.Entrypoint = []string{ca.entrypoint}
+	}
+	if jr.services != nil {
+		// Join the services' network so steps reach them by service ID.
+		cfg.HostConfig.NetworkMode = jr.services.network
+	}
+	cfg.HostConfig.ExtraHosts = jr.proxyHosts()
+	if !jr.limits.IsZero() {
+		fmt.Fprintf(jr.log, "Limiting the step to %s\n", jr.limits)
+		jr.limits.hostConfig(&cfg.HostConfig)
+	}
+	name := fmt.Sprintf("actions-%s-%d", sanitizeName(jr.job.ID), time.Now().UnixNano())
+	code, err := client.Run(ctx, name, cfg, jr.log, jr.log)
+	if errors.Is(err, docker.ErrOOMKilled) && cfg.HostConfig.Memory > 0 {
+		return &limitError{limit: LimitMemory, max: FormatMemory(cfg.HostConfig.Memory), code: code}
+	}
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &exitError{code: code}
+	}
+	return nil
+}
+
+// ensureImage pulls ref unless it is already present, recording the
+// images it pulls in the cache.
+func (jr *jobRun) ensureImage(ctx context.Context, client *docker.Client, ref string, auth *docker.AuthConfig) error {
+	cache := jr.run.r.opts.Cache
+	if ok, err := client.ImageExists(ctx, ref); err == nil && ok {
+		if cache != nil {
+			cache.UseImage(ref)
+		}
+		return nil
+	}
+	fmt.Fprintf(jr.log, "Pulling %s\n", ref)
+	if err := client.ImagePull(ctx, ref, auth, nil); err != nil {
+		return err
+	}
+	jr.run.cacheImage(ctx, client, ref)
+	return nil
+}
+
+// cachedBuild reports whether the image of ca was built before from an
+// action in the cache. Such images are tagged by the checkout, which
+// names the commit, so they need no rebuild.
+func (run *run) cachedBuild(ctx context.Context, client *docker.Client, ca *containerAction) bool {
+	cache := run.r.opts.Cache
+	if cache == nil || !cache.Holds(ca.buildDir) {
+		return false
+	}
+	if ok, err := client.ImageExists(ctx, ca.image); err != nil || !ok {
+		return false
+	}
+	return cache.UseImage(ca.image)
+}
+
+// cacheImage records the image ref in the cache, so that pruning it
+// removes the image.
+func (run *run) cacheImage(ctx context.Context, client *docker.Client, ref string) {
+	cache := run.r.opts.Cache
+	if cache == nil {
+		return
+	}
+	if info, err := client.ImageInspect(ctx, ref); err == nil {
+		cache.AddImage(ref, info.ID, info.Size)
+	}
+}
+
+// pruneCache evicts old and excess cache entries, at most once a day.
+func (run *run) pruneCache(ctx context.Context) {
+	cache := run.r.opts.Cache
+	if cache == nil {
+		return
+	}
+	cache.AutoPrune(context.WithoutCancel(ctx), func(ctx context.Context, ref string) error {
+		client, err := run.dockerClient()
+		if err != nil {
+			return err
+		}
+		if err := client.ImageRemove(ctx, ref, false); err != nil && !docker.IsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// containerEnv is the step environment with paths translated to their
+// locations inside the container.
+func (jr *jobRun) containerEnv(stepEnv map[string]string, files *stepFiles) map[string]string {
+	env := jr.stepEnv(stepEnv, files)
+	env["GITHUB_WORKSPACE"] = containerWorkspace
+	env["HOME"] = containerHome
+	env["GITHUB_EVENT_PATH"] = containerWorkflowDir + "/" + filepath.Base(jr.run.eventPath)
+	for k, f := range map[string]string{
+		"GITHUB_ENV":          files.env,
+		"GITHUB_OUTPUT":       files.output,
+		"GITHUB_PATH":         files.path,
+		"GITHUB_STEP_SUMMARY": files.summary,
+		"GITHUB_STATE":        files.state,
+	} {
+		env[k] = containerFileCommands + "/" + filepath.Base(f)
+	}
+	delete(env, "RUNNER_TEMP")
+	jr.containerProxyEnv(env)
+	return env
+}
+
+func envList(env map[string]string) []string {
+	out := make([]string, 0, len(env))
+	for k, v := range env {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// sanitizeName makes s safe for use in a container name.
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, s)
+}
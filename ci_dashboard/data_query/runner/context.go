@@ -0,0 +1,250 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/docker"
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/events"
+	"testingdashboard/m/v2/secrets"
+	"testingdashboard/m/v2/workflow"
+)
+
+// run holds the state shared by every job of a single workflow run.
+type run struct {
+	r      *Runner
+	wf     *workflow.Workflow
+	temp   string
+	github map[string]any
+	// secrets is the secrets context.
+	secrets map[string]string
+	// mu guards needs, finished and docker, which parallel jobs share.
+	mu sync.Mutex
+	// needs records finished jobs for the needs context.
+	needs map[string]map[string]any
+	// started is when the run started and finished when each job did, for
+	// queue wait metrics.
+	started  time.Time
+	finished map[string]time.Time
+	// log is the root section of the run's log.
+	log *logSection
+	// slots holds a token for each running leg, up to Options.MaxParallel.
+	slots chan struct{}
+	// group is the workflow's concurrency group, if any.
+	group string
+	// eventPath is the file holding the event payload.
+	eventPath string
+	docker    *docker.Client
+	// artifacts holds a directory per uploaded artifact and handoff one
+	// per job with x-persist; files guards both.
+	artifacts string
+	handoff   string
+	files     sync.Mutex
+	// artifactIDs numbers uploaded artifacts.
+	artifactIDs int
+}
+
+func (r *Runner) newRun(ctx context.Context, wf *workflow.Workflow) (*run, error) {
+	values, err := secrets.Load(ctx, r.opts.SecretProviders)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.opts.Secrets {
+		values[k] = v
+	}
+	temp, err := realTempDir("actions-run-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner temp directory: %w", err)
+	}
+	github, err := r.githubContext(wf, values)
+	if err != nil {
+		os.RemoveAll(temp)
+		return nil, err
+	}
+	if given, ok := r.opts.Contexts["github"].(map[string]any); ok {
+		for k, v := range given {
+			github[k] = v
+		}
+	}
+	run := &run{
+		r:        r,
+		wf:       wf,
+		temp:     temp,
+		github:   github,
+		secrets:  values,
+		needs:    map[string]map[string]any{},
+		started:  time.Now(),
+		finished: map[string]time.Time{},
+		slots:    make(chan struct{}, r.opts.MaxParallel),
+		log:      newOrderedLog(r.opts.Log),
+	}
+	run.artifacts = r.opts.ArtifactDir
+	if run.artifacts == "" {
+		run.artifacts = filepath.Join(temp, "_artifacts")
+	}
+	run.handoff = filepath.Join(temp, "_handoff")
+	run.eventPath = filepath.Join(temp, "_github_workflow", "event.json")
+	data, err := json.Marshal(run.github["event"])
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(run.eventPath), 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(run.eventPath, data, 0o644)
+	}
+	if err != nil {
+		os.RemoveAll(temp)
+		return nil, fmt.Errorf("failed to write event payload: %w", err)
+	}
+	return run, nil
+}
+
+// githubContext builds the github context from the event payload and,
+// where it says nothing, the git checkout in the workspace.
+func (r *Runner) githubContext(wf *workflow.Workflow, secrets map[string]string) (map[string]any, error) {
+	event := r.opts.Event
+	if event == nil {
+		event = map[string]any{}
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event payload: %w", err)
+	}
+	payload, err := events.ParseEvent(r.opts.EventName, data)
+	if err != nil {
+		return nil, err
+	}
+	git := events.GitContext(payload)
+
+	sha := gitOutput(r.opts.Workspace, "rev-parse", "HEAD")
+	branch := gitOutput(r.opts.Workspace, "symbolic-ref", "-q", "--short", "HEAD")
+	ref, refName, refType := "", branch, "branch"
+	if branch != "" {
+		ref = "refs/heads/" + branch
+	}
+	if git.Ref != "" {
+		ref, refName, refType = git.Ref, git.RefName, git.RefType
+	}
+	if git.SHA != "" {
+		sha = git.SHA
+	}
+	_, repo := endpoints.ParseRemote(gitOutput(r.opts.Workspace, "remote", "get-url", "origin"))
+	if p := payload.Info().Repository; p != nil && p.FullName != "" {
+		repo = p.FullName
+	}
+	if v := os.Getenv("GITHUB_REPOSITORY"); v != "" {
+		repo = v
+	}
+	owner, _, _ := strings.Cut(repo, "/")
+	actor := hostUser()
+	if s := payload.Info().Sender; s != nil && s.Login != "" {
+		actor = s.Login
+	}
+	workflowName := wf.Name
+	if workflowName == "" {
+		workflowName = wf.Path
+	}
+	now := time.Now()
+	return map[string]any{
+		"event_name":       r.opts.EventName,
+		"event":            event,
+		"sha":              sha,
+		"ref":              ref,
+		"ref_name":         refName,
+		"ref_type":         refType,
+		"repository":       repo,
+		"repository_owner": owner,
+		"actor":            actor,
+		"triggering_actor": actor,
+		"workspace":        r.opts.Workspace,
+		"workflow":         workflowName,
+		"run_id":           fmt.Sprint(now.Unix()),
+		"run_number":       "1",
+		"run_attempt":      "1",
+		"server_url":       r.opts.Endpoints.Server,
+		"api_url":          r.opts.Endpoints.API,
+		"graphql_url":      r.opts.Endpoints.GraphQL,
+		"head_ref":         git.HeadRef,
+		"base_ref":         git.BaseRef,
+		"token":            secrets["GITHUB_TOKEN"],
+	}, nil
+}
+
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// defaultEndpoints returns the instance named by the environment, else
+// the one hosting the origin remote of the workspace, else github.com.
+func defaultEndpoints(workspace string) endpoints.Endpoints {
+	if os.Getenv("GITHUB_SERVER_URL") != "" || os.Getenv("GITHUB_API_URL") != "" {
+		return endpoints.FromEnv()
+	}
+	server, _ := endpoints.ParseRemote(gitOutput(workspace, "remote", "get-url", "origin"))
+	return endpoints.ForServer(server)
+}
+
+// runnerContext describes the local machine.
+func (jr *jobRun) runnerContext() map[string]any {
+	return map[string]any{
+		"name":        "local",
+		"os":          runnerOS(),
+		"arch":        runnerArch(),
+		"temp":        jr.temp,
+		"tool_cache":  jr.run.toolCacheDir(),
+		"workspace":   jr.workspace,
+		"debug":       "",
+		"environment": "self-hosted",
+	}
+}
+
+func runnerOS() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Windows"
+	case "darwin":
+		return "macOS"
+	}
+	return "Linux"
+}
+
+func runnerArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "X64"
+	case "386":
+		return "X86"
+	case "arm64":
+		return "ARM64"
+	case "arm":
+		return "ARM"
+	}
+	return strings.ToUpper(runtime.GOARCH)
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// StepExecutor runs steps in place of the runner, for embedders that add
+// their own uses: schemes or change how some steps run.
+type StepExecutor interface {
+	// ExecuteStep runs s. A non-nil error fails the step; an error with an
+	// ExitCode method sets its exit code.
+	ExecuteStep(ctx context.Context, s *Step) error
+}
+
+// StepExecutorFunc adapts a function to StepExecutor.
+type StepExecutorFunc func(ctx context.Context, s *Step) error
+
+// ExecuteStep implements StepExecutor.
+func (f StepExecutorFunc) ExecuteStep(ctx context.Context, s *Step) error {
+	return f(ctx, s)
+}
+
+// Step is a step about to run, with its expressions evaluated.
+type Step struct {
+	// Step is the step as the workflow or composite action writes it.
+	Step *workflow.Step
+	// ID is the step's id, or the one the runner made up for it.
+	ID   string
+	Name string
+	// JobID is the ID of the job the step belongs to.
+	JobID string
+	// Uses and Run are the step's uses: or run: value; the other is empty.
+	Uses, Run string
+	With      map[string]string
+	// Env is the environment the step runs with, including the GITHUB_*
+	// variables and the files of GITHUB_OUTPUT, GITHUB_ENV and the rest,
+	// which are read after the step as for any other.
+	Env       map[string]string
+	Workspace string
+	// Log is the step's log. Workflow commands written to it take effect.
+	Log io.Writer
+	// Outputs are the step's outputs; executors may set them directly.
+	Outputs map[string]string
+	// Limits are those the step would run within; executors that start
+	// processes should enforce them.
+	Limits Limits
+}
+
+// Scheme returns the scheme of Uses, such as internal for
+// internal://deploy, or "" if it has none.
+func (s *Step) Scheme() string {
+	scheme, _, ok := strings.Cut(s.Uses, "://")
+	if !ok {
+		return ""
+	}
+	return scheme
+}
+
+// StepHook runs code around every step that runs, including the steps of
+// composite actions. Either function may be nil.
+type StepHook struct {
+	// Before runs before the step. An error fails the step instead of
+	// running it.
+	Before func(ctx context.Context, s *Step) error
+	// After runs once the step concluded, even if Before or the step
+	// failed. Steps skipped by their if: run no hooks.
+	After func(ctx context.Context, s *Step, sr *StepResult)
+}
+
+// Executors is a registry of step executors and hooks. The zero value is
+// empty and ready to use.
+type Executors struct {
+	mu        sync.RWMutex
+	schemes   map[string]StepExecutor
+	overrides []override
+	hooks     []StepHook
+}
+
+type override struct {
+	match func(*Step) bool
+	x     StepExecutor
+}
+
+// HandleScheme makes x run the steps whose uses: has scheme, as in
+// uses: scheme://name. The scheme may not be one workflows already use.
+func (e *Executors) HandleScheme(scheme string, x StepExecutor) {
+	switch scheme {
+	case "", "docker", "http", "https":
+		panic(fmt.Sprintf("runner: cannot handle the uses: scheme %q", scheme))
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.schemes == nil {
+		e.schemes = map[string]StepExecutor{}
+	}
+	if _, dup := e.schemes[scheme]; dup {
+		panic(fmt.Sprintf("runner: the uses: scheme %q is handled twice", scheme))
+	}
+	e.schemes[scheme] = x
+}
+
+// Handle makes x run the steps match selects, overriding how the runner
+// would run them. Overrides are tried in the order they were added, before
+// schemes.
+func (e *Executors) Handle(match func(*Step) bool, x StepExecutor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.overrides = append(e.overrides, override{match, x})
+}
+
+// AddHook adds a hook. Before hooks run in the order they were added and
+// After hooks in reverse.
+func (e *Executors) AddHook(h StepHook) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks = append(e.hooks, h)
+}
+
+// lookup returns the executor of s, or nil if the runner runs it. A scheme
+// nothing handles is an error.
+func (e *Executors) lookup(s *Step) (StepExecutor, error) {
+	if e == nil {
+		return nil, nil
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, o := range e.overrides {
+		if o.match(s) {
+			return o.x, nil
+		}
+	}
+	scheme := s.Scheme()
+	if scheme == "" {
+		return nil, nil
+	}
+	if x, ok := e.schemes[scheme]; ok {
+		return x, nil
+	}
+	return nil, fmt.Errorf("no executor handles uses: %s://", scheme)
+}
+
+func (e *Executors) stepHooks() []StepHook {
+	if e == nil {
+		return nil
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.hooks
+}
+
+// builtinExecutor returns the executor standing in for the action uses
+// names, or nil. actions/upload-artifact and actions/download-artifact
+// need GitHub's artifact storage, so the runner keeps artifacts itself,
+// and actions/checkout would replace the local checkout.
+func (jr *jobRun) builtinExecutor(uses string) StepExecutor {
+	u, err := workflow.ParseUses(uses)
+	if err != nil || u.Kind != workflow.UsesRepository || u.Owner != "actions" || u.Path != "" {
+		return nil
+	}
+	switch u.Repo {
+	case "checkout":
+		return StepExecutorFunc(jr.checkout)
+	case "upload-artifact":
+		return StepExecutorFunc(jr.uploadArtifact)
+	case "download-artifact":
+		return StepExecutorFunc(jr.downloadArtifact)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"testingdashboard/m/v2/glob"
+)
+
+// isolate gives the leg its own copy of the workspace.
+func (jr *jobRun) isolate() error {
+	dir := filepath.Join(jr.temp, "workspace")
+	if err := copyTree(jr.run.r.opts.Workspace, dir); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	jr.workspace = dir
+	return nil
+}
+
+// persist saves the workspace files matching the job's x-persist paths
+// for the jobs that need it. The legs of a matrix add to the same files.
+func (jr *jobRun) persist() error {
+	if len(jr.job.Persist) == 0 {
+		return nil
+	}
+	// Symlinks are kept as they are.
+	opts := glob.DefaultOptions()
+	opts.FollowSymlinks = false
+	files, err := workspaceFiles(jr.workspace, strings.Join(jr.job.Persist, "\n"), opts)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(jr.run.handoff, jr.job.ID)
+	jr.run.files.Lock()
+	defer jr.run.files.Unlock()
+	for _, rel := range files {
+		if err := copyFile(filepath.Join(jr.workspace, rel), filepath.Join(dir, rel)); err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(jr.log, "Warning: no files match the x-persist paths of job %s\n", jr.job.ID)
+		return nil
+	}
+	fmt.Fprintf(jr.log, "Persisted %s for the jobs that need %s\n", fileCount(len(files)), jr.job.ID)
+	return nil
+}
+
+// restore copies the files the jobs the leg needs persisted into its
+// workspace, in the order of needs.
+func (jr *jobRun) restore() error {
+	jr.run.files.Lock()
+	defer jr.run.files.Unlock()
+	for _, need := range jr.job.Needs {
+		dir := filepath.Join(jr.run.handoff, need)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		n, err := mergeTree(dir, jr.workspace)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(jr.log, "Restored %s persisted by %s\n", fileCount(n), need)
+	}
+	return nil
+}
+
+// workspaceFiles returns the files matching patterns under root, relative
+// to it, leaving out directories. Patterns may not reach outside root.
+func workspaceFiles(root, patterns string, opts glob.Options) ([]string, error) {
+	opts.Root = root
+	g, err := glob.New(patterns, opts)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for p, err := range g.All() {
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("%s is outside the workspace", p)
+		}
+		stat := os.Lstat
+		if opts.FollowSymlinks {
+			stat = os.Stat
+		}
+		if info, err := stat(p); err != nil || info.IsDir() {
+			continue
+		}
+		files = append(files, rel)
+	}
+	return files, nil
+}
+
+// mergeTree copies the files under src into dst, replacing those already
+// there, and returns how many it copied.
+func mergeTree(src, dst string) (int, error) {
+	n := 0
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		n++
+		return copyFile(p, filepath.Join(dst, rel))
+	})
+	return n, err
+}
+
+// copyFile copies the file or symlink src to dst, replacing dst and
+// creating its directory.
+func copyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, dst)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func fileCount(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"testingdashboard/m/v2/compliance"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/workflow"
+)
+
+// hookStep is the pre or post entry point of a JavaScript action, which
+// runs apart from the step using the action.
+type hookStep struct {
+	name string
+	step *workflow.Step
+	// stepID is the ID of the step using the action, under which its
+	// state is saved.
+	stepID      string
+	action      *nodeAction
+	inputs, env map[string]string
+	// composite is the scope of the step if it is inside a composite
+	// action.
+	composite *compositeScope
+	// limits confine the entry point as they do the step.
+	limits Limits
+}
+
+// stateKey identifies the state of the step id in the current scope, so
+// steps of composite actions do not share state with the job's steps.
+func (jr *jobRun) stateKey(id string) string {
+	if jr.composite != nil {
+		return jr.composite.prefix + id
+	}
+	return id
+}
+
+// stepState returns the state saved by the step of sr, through save-state
+// or GITHUB_STATE, which its later entry points receive.
+func (jr *jobRun) stepState(sr *StepResult) map[string]string {
+	key := jr.stateKey(sr.ID)
+	if jr.state[key] == nil {
+		jr.state[key] = map[string]string{}
+	}
+	return jr.state[key]
+}
+
+// runPre runs the pre entry points of the JavaScript actions the job's
+// steps use, in step order, before any step runs. Steps whose action cannot
+// be resolved yet are left for the main step to report.
+func (jr *jobRun) runPre(ctx context.Context) []*StepResult {
+	var results []*StepResult
+	for i, step := range jr.job.Steps {
+		if step.Uses == "" {
+			continue
+		}
+		h := jr.resolvePre(ctx, step, i)
+		if h == nil {
+			continue
+		}
+		results = append(results, jr.runHook(ctx, h, h.action.pre, h.action.preIf))
+	}
+	return results
+}
+
+func (jr *jobRun) resolvePre(ctx context.Context, step *workflow.Step, index int) *hookStep {
+	ectx := &expr.Context{Values: restrict(jr.values(nil), jr.stepPath(index, "with")...), Status: jr.status}
+	if execs := jr.run.r.opts.Executors; execs != nil {
+		// A step an executor runs has no pre entry point.
+		with, _ := interpolateMap(step.With, ectx)
+		ps := &Step{Step: step, ID: step.ID, Name: stepDisplayName(step, index), JobID: jr.job.ID, Uses: step.Uses, With: with}
+		if ps.ID == "" {
+			ps.ID = fmt.Sprintf("__step%d", index)
+		}
+		if x, err := execs.lookup(ps); x != nil || err != nil {
+			return nil
+		}
+	}
+	if jr.builtinExecutor(step.Uses) != nil {
+		return nil
+	}
+	uses, err := workflow.ParseUses(step.Uses)
+	if err != nil || uses.Kind == workflow.UsesDocker {
+		return nil
+	}
+	stepLimits, err := parseStepLimits(step.Limits)
+	if err != nil {
+		return nil
+	}
+	limits := jr.limits.tighten(stepLimits)
+	if jr.thirdParty(uses) {
+		limits = limits.tighten(jr.run.r.opts.Sandbox.ThirdParty)
+	}
+	dir, err := jr.run.r.opts.Fetcher.Fetch(ctx, uses)
+	if err != nil {
+		return nil
+	}
+	meta, err := metadata.Load(dir)
+	if err != nil || !metadata.IsNode(meta.Runs.Using) || meta.Runs.Pre == "" {
+		return nil
+	}
+	if jr.container != nil {
+		if dir, err = jr.container.visible(dir); err != nil {
+			return nil
+		}
+	}
+	na, err := resolveNodeAction(dir, uses, meta)
+	if err != nil {
+		return nil
+	}
+	env, err := interpolateMap(step.Env, ectx)
+	if err != nil {
+		return nil
+	}
+	with, err := interpolateMap(step.With, ectx)
+	if err != nil {
+		return nil
+	}
+	// The main step warns about its inputs.
+	log := jr.log
+	jr.log = io.Discard
+	inputs, err := jr.actionInputs(meta, with, ectx)
+	jr.log = log
+	if err != nil {
+		return nil
+	}
+	id := step.ID
+	if id == "" {
+		id = fmt.Sprintf("__step%d", index)
+	}
+	name := stepDisplayName(step, index)
+	if n, err := expr.Interpolate(name, ectx); err == nil {
+		name = jr.masks.Mask(n)
+	}
+	return &hookStep{name: "Pre " + name, step: step, stepID: id, action: na, inputs: inputs, env: env, limits: limits}
+}
+
+// runPosts runs the post entry points of the actions whose main entry
+// point started, in reverse order. They run even when the job failed or
+// was cancelled, unless their post-if says otherwise.
+func (jr *jobRun) runPosts(ctx context.Context) []*StepResult {
+	ctx = context.WithoutCancel(ctx)
+	var results []*StepResult
+	for i := len(jr.posts) - 1; i >= 0; i-- {
+		h := jr.posts[i]
+		results = append(results, jr.runHook(ctx, h, h.action.post, h.action.postIf))
+	}
+	jr.posts = nil
+	return results
+}
+
+// runHook runs script, an entry point of h's action, if cond holds. An
+// empty cond means always(), the default of pre-if and post-if.
+func (jr *jobRun) runHook(ctx context.Context, h *hookStep, script, cond string) *StepResult {
+	start := time.Now()
+	sr := &StepResult{ID: h.stepID, Name: h.name, Outputs: map[string]string{}}
+	parent, parentLimits := jr.composite, jr.limits
+	jr.composite, jr.limits = h.composite, h.limits
+	var entry *compliance.Entry
+	defer func() {
+		jr.composite, jr.limits = parent, parentLimits
+		sr.Duration = time.Since(start)
+		jr.run.r.opts.Metrics.ObserveStep(jr.job.ID, sr.Name, sr.Conclusion, sr.Duration)
+		jr.audit(entry, sr)
+	}()
+	defer jr.stepProgress(sr)()
+	defer jr.legLog.setStep(sr)()
+	ctx, endTrace := jr.traceStep(ctx, h.step, sr)
+	defer endTrace()
+
+	if cond == "" {
+		cond = "always()"
+	}
+	ok, err := expr.EvaluateCondition(cond, &expr.Context{Values: restrict(jr.values(nil), "runs"), Status: jr.status})
+	if err != nil {
+		fmt.Fprintf(jr.log, "Error evaluating condition of %q: %v\n", sr.Name, err)
+		jr.fail(sr, err)
+		return sr
+	}
+	if !ok {
+		fmt.Fprintf(jr.log, "Skipping %q: condition not met\n", sr.Name)
+		sr.Outcome, sr.Conclusion = ResultSkipped, ResultSkipped
+		return sr
+	}
+
+	if h.action.uses.Kind == workflow.UsesRepository {
+		sr.SHA = gitOutput(h.action.dir, "rev-parse", "HEAD")
+	}
+	entry = jr.auditEntry(h.step, sr, &expr.Context{Values: jr.values(h.env)}, h.inputs)
+
+	fmt.Fprintf(jr.log, "==> %s\n", sr.Name)
+	defer jr.registerProxyStep(sr.Name)()
+	cw := jr.newCommandWriter(sr)
+	log := jr.log
+	jr.log = cw
+	defer func() {
+		cw.Flush()
+		jr.log = log
+	}()
+	files, err := jr.newStepFiles()
+	if err != nil {
+		jr.fail(sr, err)
+		return sr
+	}
+	err = jr.runNodeScript(ctx, h.action, script, h.inputs, h.env, jr.stepState(sr), files)
+	if applyErr := jr.applyStepFiles(files, sr); applyErr != nil && err == nil {
+		err = applyErr
+	}
+	jr.conclude(ctx, sr, err)
+	return sr
+}
@@ -0,0 +1,660 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/commands"
+	"testingdashboard/m/v2/concurrency"
+	"testingdashboard/m/v2/contexts"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/netpolicy"
+	"testingdashboard/m/v2/tracing"
+	"testingdashboard/m/v2/workflow"
+)
+
+// jobRun is the state of one matrix leg of a job.
+type jobRun struct {
+	run    *run
+	job    *workflow.Job
+	name   string
+	matrix map[string]any
+	// strategy is the strategy context for this leg.
+	strategy map[string]any
+	temp     string
+	log      io.Writer
+	// workspace is the directory steps run in: Options.Workspace, or the
+	// leg's own copy of it with Options.Isolate.
+	workspace string
+	// environment is the deployment environment of the leg, whose
+	// variables its vars context holds once it is known.
+	environment string
+
+	env    map[string]string
+	path   []string
+	steps  map[string]any
+	status expr.Status
+	// masks are values hidden from the log.
+	masks *commands.Masker
+	// matchers are the problem matchers steps have registered.
+	matchers *commands.Matchers
+	// state holds values saved with save-state, keyed by step ID.
+	state map[string]map[string]string
+	// composite is set while the steps of a composite action run.
+	composite *compositeScope
+	// stepSeq numbers step directories, including nested steps.
+	stepSeq int
+	// traceparent is the span of the running step, passed to it in
+	// TRACEPARENT.
+	traceparent string
+	// services are the leg's running service containers, if any, and
+	// serviceContext describes them in job.services.
+	services       *jobServices
+	serviceContext map[string]any
+	// container is set when the leg runs in jobs.<id>.container.
+	container *jobContainer
+	// posts are the post entry points to run when the steps finish.
+	posts []*hookStep
+	// legLog is the leg's log, which log writes to through the command
+	// processors of running steps.
+	legLog *logWriter
+	// limits confine the running step.
+	limits Limits
+	// proxy applies the network policy, if any; proxyUser identifies the
+	// running step to it.
+	proxy     *netpolicy.Proxy
+	proxyUser string
+}
+
+func (run *run) runJob(ctx context.Context, job *workflow.Job, out *logSection) *JobResult {
+	result := &JobResult{ID: job.ID, Outputs: map[string]string{}}
+	defer func() {
+		run.mu.Lock()
+		defer run.mu.Unlock()
+		run.needs[job.ID] = map[string]any{
+			"result":  result.Result,
+			"outputs": result.Outputs,
+		}
+		run.finished[job.ID] = time.Now()
+		if len(result.Legs) == 0 {
+			run.progress(ProgressEvent{JobID: job.ID, Result: result.Result})
+		}
+	}()
+	log := newLogWriter(out, run.jobRecord(job, ""))
+	defer log.Flush()
+
+	ok, err := run.jobCondition(job)
+	if err != nil {
+		fmt.Fprintf(log, "Error evaluating job condition: %v\n", err)
+		result.Result = ResultFailure
+		return result
+	}
+	if !ok {
+		fmt.Fprintf(log, "Skipping job %s: condition not met\n", job.ID)
+		result.Result = ResultSkipped
+		return result
+	}
+	if job.Uses != "" {
+		fmt.Fprintf(log, "Skipping job %s: reusable workflows are not supported yet\n", job.ID)
+		result.Result = ResultSkipped
+		return result
+	}
+
+	exp, err := run.expandStrategy(job)
+	if err != nil {
+		fmt.Fprintf(log, "Error expanding matrix: %v\n", err)
+		result.Result = ResultFailure
+		return result
+	}
+
+	// The job became ready to run when the last job it needs finished.
+	ready := run.started
+	run.mu.Lock()
+	for _, need := range job.Needs {
+		if t := run.finished[need]; t.After(ready) {
+			ready = t
+		}
+	}
+	run.mu.Unlock()
+
+	result.Result = ResultSuccess
+	result.Legs = run.runLegs(ctx, job, exp, ready, out)
+	for _, leg := range result.Legs {
+		allowed := leg.Result == ResultFailure && leg.ContinueOnError
+		for k, v := range leg.Outputs {
+			result.Outputs[k] = v
+		}
+		switch {
+		case allowed:
+		case leg.Result == ResultFailure:
+			result.Result = ResultFailure
+		case leg.Result == ResultCancelled:
+			if result.Result != ResultFailure {
+				result.Result = ResultCancelled
+			}
+		}
+	}
+	return result
+}
+
+// defaultJobTimeout is the timeout-minutes of jobs that do not set it.
+const defaultJobTimeout = 360 * time.Minute
+
+// Causes of the contexts of jobs and steps that time out.
+var (
+	errJobTimeout  = errors.New("job timed out")
+	errStepTimeout = errors.New("step timed out")
+)
+
+// evalBool evaluates a boolean that may be an expression; unset is false.
+func evalBool(b *workflow.BoolExpr, ectx *expr.Context) (bool, error) {
+	if b == nil {
+		return false, nil
+	}
+	if b.Expression == "" {
+		return b.Value, nil
+	}
+	v, err := expr.EvaluateValue(b.Expression, ectx)
+	if err != nil {
+		return false, err
+	}
+	return expr.Truthy(v), nil
+}
+
+// evalMinutes evaluates a timeout-minutes value; unset is 0.
+func evalMinutes(n *workflow.NumberExpr, ectx *expr.Context) (time.Duration, error) {
+	if n == nil {
+		return 0, nil
+	}
+	minutes := n.Value
+	if n.Expression != "" {
+		v, err := expr.EvaluateValue(n.Expression, ectx)
+		if err != nil {
+			return 0, err
+		}
+		minutes = expr.ToNumber(v)
+	}
+	return time.Duration(minutes * float64(time.Minute)), nil
+}
+
+// jobCondition evaluates jobs.<id>.if against the results of needed jobs.
+func (run *run) jobCondition(job *workflow.Job) (bool, error) {
+	status := expr.StatusSuccess
+	depSkipped := false
+	run.mu.Lock()
+	for _, need := range job.Needs {
+		switch run.needs[need]["result"] {
+		case ResultFailure:
+			status = expr.StatusFailure
+		case ResultCancelled:
+			if status != expr.StatusFailure {
+				status = expr.StatusCancelled
+			}
+		case ResultSkipped:
+			depSkipped = true
+		}
+	}
+	run.mu.Unlock()
+	cond := strings.TrimSpace(job.If)
+	if depSkipped && !conditionHasStatusFunction(cond) {
+		return false, nil
+	}
+	return expr.EvaluateCondition(cond, &expr.Context{
+		Values: restrict(run.jobValues(job, nil), "jobs", job.ID, "if"),
+		Status: status,
+	})
+}
+
+func conditionHasStatusFunction(cond string) bool {
+	cond = strings.TrimSpace(cond)
+	if strings.HasPrefix(cond, "${{") && strings.HasSuffix(cond, "}}") {
+		cond = cond[3 : len(cond)-2]
+	}
+	node, err := expr.Parse(cond)
+	return err == nil && expr.HasStatusFunction(node)
+}
+
+// jobValues returns the contexts available at job level to job, whose
+// needs context holds the jobs it needs. job is nil at workflow level.
+func (run *run) jobValues(job *workflow.Job, matrix map[string]any) map[string]any {
+	needs := map[string]any{}
+	if job != nil {
+		run.mu.Lock()
+		for _, id := range job.Needs {
+			if v, ok := run.needs[id]; ok {
+				needs[id] = v
+			}
+		}
+		run.mu.Unlock()
+	}
+	inputs := run.r.opts.Inputs
+	if inputs == nil {
+		inputs = map[string]any{}
+	}
+	if matrix == nil {
+		matrix = map[string]any{}
+	}
+	values := map[string]any{
+		"github":  run.github,
+		"needs":   needs,
+		"inputs":  inputs,
+		"vars":    run.vars(""),
+		"secrets": stringMap(run.secrets),
+		"matrix":  matrix,
+		"env":     stringMap(run.wf.Env),
+	}
+	run.givenContexts(values)
+	return values
+}
+
+// givenContexts replaces the contexts in values that Options.Contexts
+// holds.
+func (run *run) givenContexts(values map[string]any) {
+	for _, name := range []string{"needs", "matrix", "strategy", "inputs", "vars"} {
+		if v, ok := run.r.opts.Contexts[name]; ok {
+			values[name] = v
+		}
+	}
+}
+
+// vars returns the vars context of a job in environment.
+func (run *run) vars(environment string) map[string]any {
+	values := run.r.opts.Variables.Values(environment)
+	for k, v := range run.r.opts.Vars {
+		values[k] = v
+	}
+	return stringMap(values)
+}
+
+func stringMap(m map[string]string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// expandStrategy evaluates any expressions in the job's strategy and
+// expands it into concrete legs.
+func (run *run) expandStrategy(job *workflow.Job) (*workflow.Expansion, error) {
+	if job.Strategy == nil {
+		return (*workflow.Strategy)(nil).Expand()
+	}
+	strategy := *job.Strategy
+	ctx := &expr.Context{Values: restrict(run.jobValues(job, nil), "jobs", job.ID, "strategy")}
+	if ff := strategy.FailFast; ff != nil && ff.Expression != "" {
+		v, err := expr.EvaluateValue(ff.Expression, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate fail-fast: %w", err)
+		}
+		strategy.FailFast = &workflow.BoolExpr{Value: expr.Truthy(v)}
+	}
+	if mp := strategy.MaxParallel; mp != nil && mp.Expression != "" {
+		v, err := expr.EvaluateValue(mp.Expression, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate max-parallel: %w", err)
+		}
+		strategy.MaxParallel = &workflow.NumberExpr{Value: expr.ToNumber(v)}
+	}
+	if strategy.Matrix == nil {
+		return strategy.Expand()
+	}
+
+	m := *strategy.Matrix
+	if m.Expression != "" {
+		v, err := expr.EvaluateValue(m.Expression, ctx)
+		if err != nil {
+			return nil, err
+		}
+		resolved, err := workflow.MatrixFromValue(v)
+		if err != nil {
+			return nil, err
+		}
+		m = *resolved
+	}
+	m.Dimensions = append([]workflow.Dimension(nil), m.Dimensions...)
+	for i, dim := range m.Dimensions {
+		if dim.Expression == "" {
+			continue
+		}
+		v, err := expr.EvaluateValue(dim.Expression, ctx)
+		if err != nil {
+			return nil, err
+		}
+		values, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("matrix %s must evaluate to an array", dim.Name)
+		}
+		m.Dimensions[i] = workflow.Dimension{Name: dim.Name, Values: values}
+	}
+	strategy.Matrix = &m
+	return strategy.Expand()
+}
+
+// legName returns the display name of a matrix leg.
+func legName(job *workflow.Job, matrix map[string]any, ctx *expr.Context) string {
+	name := job.Name
+	if n, err := expr.Interpolate(job.Name, ctx); err == nil {
+		name = n
+	}
+	return workflow.LegName(job, name, matrix)
+}
+
+// runLeg runs one leg of job, which became ready to run at ready, logging
+// to out.
+func (run *run) runLeg(ctx context.Context, job *workflow.Job, exp *workflow.Expansion, cfg workflow.JobConfig, ready time.Time, out *logSection) *LegResult {
+	start := time.Now()
+	matrix := cfg.Matrix
+	values := run.jobValues(job, matrix)
+	name := legName(job, matrix, &expr.Context{Values: restrict(values, "jobs", job.ID, "name")})
+	leg := &LegResult{Name: name, Matrix: matrix, Result: ResultSuccess, Outputs: map[string]string{}}
+	ctx, span := run.r.opts.Tracer.Start(ctx, "job "+name, tracing.KindInternal)
+	span.SetAttribute("cicd.pipeline.task.name", job.ID)
+	span.SetAttribute("github.job.name", name)
+	defer func() {
+		leg.Duration = time.Since(start)
+		run.r.opts.Metrics.ObserveJob(job.ID, leg.Result, leg.Duration)
+		run.progress(ProgressEvent{JobID: job.ID, Job: name, Result: leg.Result})
+		endSpan(span, leg.Result)
+	}()
+
+	log := newLogWriter(out, run.jobRecord(job, name))
+	defer log.Flush()
+
+	temp, err := os.MkdirTemp(run.temp, "job-")
+	if err != nil {
+		fmt.Fprintf(log, "Error creating job temp directory: %v\n", err)
+		leg.Result = ResultFailure
+		return leg
+	}
+	jr := &jobRun{
+		run:    run,
+		job:    job,
+		name:   name,
+		matrix: matrix,
+		strategy: map[string]any{
+			"fail-fast":    exp.FailFast,
+			"job-index":    cfg.Index,
+			"job-total":    cfg.Total,
+			"max-parallel": exp.MaxParallel,
+		},
+		temp:      temp,
+		workspace: run.r.opts.Workspace,
+		limits:    run.r.opts.Sandbox.Limits,
+		log:       log,
+		legLog:    log,
+		env:       map[string]string{},
+		steps:     map[string]any{},
+		status:    expr.StatusSuccess,
+		state:     map[string]map[string]string{},
+		masks:     &commands.Masker{},
+	}
+	if run.r.opts.Isolate {
+		if err := jr.isolate(); err != nil {
+			fmt.Fprintf(log, "Error copying the workspace: %v\n", err)
+			leg.Result = ResultFailure
+			return leg
+		}
+		defer os.RemoveAll(jr.workspace)
+	}
+	jr.matchers = &commands.Matchers{Workspace: jr.workspace}
+	for _, secret := range run.secrets {
+		jr.masks.Add(secret)
+	}
+	log.masker = jr.masks
+
+	// The environment's variables are visible to the rest of the job.
+	if job.Environment != nil {
+		if leg.Environment, err = expr.Interpolate(job.Environment.Name, &expr.Context{Values: restrict(jr.values(nil), "jobs", job.ID, "environment")}); err != nil {
+			fmt.Fprintf(log, "Error evaluating environment: %v\n", err)
+			leg.Result = ResultFailure
+			return leg
+		}
+		jr.environment = leg.Environment
+	}
+
+	jobEnvCtx := &expr.Context{Values: restrict(jr.values(nil), "jobs", job.ID, "env")}
+	for k, v := range run.wf.Env {
+		jr.env[k] = v
+	}
+	for k, v := range job.Env {
+		value, err := expr.Interpolate(v, jobEnvCtx)
+		if err != nil {
+			fmt.Fprintf(log, "Error evaluating env %s: %v\n", k, err)
+			leg.Result = ResultFailure
+			return leg
+		}
+		jr.env[k] = value
+	}
+
+	group, cancelInProgress, err := concurrency.Evaluate(job.Concurrency, restrict(jr.values(nil), "jobs", job.ID, "concurrency"))
+	if err != nil {
+		fmt.Fprintf(log, "Error: %v\n", err)
+		leg.Result = ResultFailure
+		return leg
+	}
+	if group != "" && run.group != "" && concurrency.Key(group) == concurrency.Key(run.group) {
+		fmt.Fprintln(log, &concurrency.CancelledError{Group: group, Deadlock: true})
+		leg.Result = ResultCancelled
+		return leg
+	}
+	// parent is cancelled when the workflow's own group cancels the run,
+	// which Run reports.
+	parent := ctx
+	ticket := run.r.opts.Concurrency.Enter(ctx, group, cancelInProgress)
+	defer ticket.Release()
+	if ticket.Pending() {
+		fmt.Fprintf(log, "Waiting for concurrency group %s\n", group)
+	}
+	if ctx, err = ticket.Wait(); err != nil {
+		fmt.Fprintln(log, err)
+		leg.Result = ResultCancelled
+		return leg
+	}
+	// The leg holds a slot even when cancelled, so that steps that run
+	// regardless stay within Options.MaxParallel.
+	run.slots <- struct{}{}
+	defer func() { <-run.slots }()
+	run.r.opts.Metrics.ObserveQueueWait(job.ID, time.Since(ready))
+
+	limits := &expr.Context{Values: restrict(jr.values(nil), "jobs", job.ID)}
+	if leg.ContinueOnError, err = evalBool(job.ContinueOnError, limits); err != nil {
+		fmt.Fprintf(log, "Error evaluating continue-on-error: %v\n", err)
+		leg.Result = ResultFailure
+		return leg
+	}
+	timeout, err := evalMinutes(job.TimeoutMinutes, limits)
+	if err != nil {
+		fmt.Fprintf(log, "Error evaluating timeout-minutes: %v\n", err)
+		leg.Result = ResultFailure
+		return leg
+	}
+	if timeout <= 0 {
+		timeout = defaultJobTimeout
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, errJobTimeout)
+	defer cancel()
+
+	run.progress(ProgressEvent{JobID: job.ID, Job: name})
+	fmt.Fprintf(log, "Starting job %s\n", name)
+	if len(matrix) > 0 {
+		fmt.Fprintf(log, "Matrix: %s\n", prettyJSON(matrix))
+	}
+	if leg.Environment != "" {
+		// Protection rules are enforced by GitHub; locally the job deploys
+		// straight away.
+		fmt.Fprintf(log, "Environment: %s\n", leg.Environment)
+	}
+	if err := jr.restore(); err != nil {
+		fmt.Fprintf(log, "Error restoring the files of needed jobs: %v\n", err)
+		leg.Result = ResultFailure
+		return leg
+	}
+	if err := jr.startProxy(); err != nil {
+		fmt.Fprintf(log, "Error starting the network policy proxy: %v\n", err)
+		leg.Result = ResultFailure
+		return leg
+	}
+	defer jr.stopContainers()
+	if err := jr.startServices(ctx); err != nil {
+		fmt.Fprintf(log, "Error starting services: %v\n", err)
+		leg.Result = ResultFailure
+		if ctx.Err() != nil {
+			leg.Result = ResultCancelled
+		}
+		return leg
+	}
+	if err := jr.startJobContainer(ctx); err != nil {
+		fmt.Fprintf(log, "Error starting job container: %v\n", err)
+		leg.Result = ResultFailure
+		if ctx.Err() != nil {
+			leg.Result = ResultCancelled
+		}
+		return leg
+	}
+	leg.Steps = append(leg.Steps, jr.runPre(ctx)...)
+	for i, step := range job.Steps {
+		if ctx.Err() != nil {
+			jr.status = expr.StatusCancelled
+		}
+		sr := jr.runStep(ctx, step, i)
+		leg.Steps = append(leg.Steps, sr)
+	}
+	if ctx.Err() != nil {
+		jr.status = expr.StatusCancelled
+	}
+	if context.Cause(ctx) == errJobTimeout && parent.Err() == nil {
+		fmt.Fprintf(log, "Error: the job exceeded the maximum execution time of %s\n", timeout)
+		jr.status = expr.StatusFailure
+	}
+	leg.Steps = append(leg.Steps, jr.runPosts(ctx)...)
+	jr.stopProxy(leg)
+	if err := jr.persist(); err != nil {
+		fmt.Fprintf(log, "Error persisting files: %v\n", err)
+		jr.status = expr.StatusFailure
+	}
+
+	switch jr.status {
+	case expr.StatusFailure:
+		leg.Result = ResultFailure
+	case expr.StatusCancelled:
+		leg.Result = ResultCancelled
+	}
+
+	outCtx := &expr.Context{Values: restrict(jr.values(nil), "jobs", job.ID, "outputs"), Status: jr.status}
+	for k, v := range job.Outputs {
+		value, err := expr.Interpolate(v, outCtx)
+		if err != nil {
+			fmt.Fprintf(log, "Error evaluating output %s: %v\n", k, err)
+			continue
+		}
+		leg.Outputs[k] = value
+	}
+	if job.Environment != nil && job.Environment.URL != "" {
+		urlCtx := &expr.Context{Values: restrict(jr.values(nil), "jobs", job.ID, "environment", "url"), Status: jr.status}
+		if leg.EnvironmentURL, err = expr.Interpolate(job.Environment.URL, urlCtx); err != nil {
+			fmt.Fprintf(log, "Error evaluating environment url: %v\n", err)
+		} else if leg.EnvironmentURL != "" {
+			fmt.Fprintf(log, "Environment URL: %s\n", leg.EnvironmentURL)
+		}
+	}
+	var cancelled *concurrency.CancelledError
+	var failFast *failFastError
+	switch cause := context.Cause(ctx); {
+	case parent.Err() == nil && errors.As(cause, &cancelled):
+		fmt.Fprintln(log, cancelled)
+	case errors.As(cause, &failFast):
+		fmt.Fprintln(log, failFast)
+	}
+	fmt.Fprintf(log, "Job %s finished: %s\n", name, leg.Result)
+	return leg
+}
+
+// values returns the contexts available to a step, with env overlaid by
+// the step's own env.
+func (jr *jobRun) values(env map[string]string) map[string]any {
+	values := jr.run.jobValues(jr.job, jr.matrix)
+	merged := stringMap(jr.env)
+	if jr.composite != nil {
+		for k, v := range jr.composite.env {
+			merged[k] = v
+		}
+	}
+	for k, v := range env {
+		merged[k] = v
+	}
+	values["env"] = merged
+	if jr.environment != "" {
+		values["vars"] = jr.run.vars(jr.environment)
+	}
+	values["steps"] = jr.steps
+	values["strategy"] = jr.strategy
+	jr.run.givenContexts(values)
+	values["runner"] = jr.runnerContext()
+	job := map[string]any{"status": string(jr.status)}
+	if jr.serviceContext != nil {
+		job["services"] = jr.serviceContext
+	}
+	if jc := jr.container; jc != nil {
+		job["container"] = map[string]any{"id": jc.id, "network": jr.services.network}
+	}
+	values["job"] = job
+	github := map[string]any{}
+	for k, v := range jr.run.github {
+		github[k] = v
+	}
+	github["job"] = jr.job.ID
+	github["workspace"] = jr.workspace
+	if jr.composite != nil {
+		github["action_path"] = jr.composite.dir
+		values["inputs"] = stringMap(jr.composite.inputs)
+	}
+	if jc := jr.container; jc != nil {
+		// Steps see the paths of the container.
+		github["workspace"] = jc.workspace
+		if p, ok := jc.containerPath(expr.ToString(github["action_path"])); ok && jr.composite != nil {
+			github["action_path"] = p
+		}
+		runner := values["runner"].(map[string]any)
+		runner["temp"] = containerTemp
+		runner["workspace"] = path.Dir(jc.workspace)
+	}
+	values["github"] = github
+	return values
+}
+
+// restrict keeps the values of the contexts available at the workflow
+// location path, so references to others fail as they do on GitHub.
+func restrict(values map[string]any, path ...string) map[string]any {
+	return contexts.At(path...).Restrict(values)
+}
+
+// prettyJSON is used to log structured values such as matrix legs.
+func prettyJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
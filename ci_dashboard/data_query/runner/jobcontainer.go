@@ -0,0 +1,279 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/docker"
+)
+
+// Paths inside job containers, matching the hosted runner, which mounts
+// its work directory at /__w.
+const (
+	containerWork    = "/__w"
+	containerTemp    = "/__w/_temp"
+	containerActions = "/__w/_actions"
+	// defaultContainerPath is used when the image does not set PATH.
+	defaultContainerPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// jobContainer is the container a job leg's steps run in.
+type jobContainer struct {
+	id        string
+	workspace string
+	// mounts map host directories to their paths in the container.
+	mounts []mount
+	// path is the image's PATH.
+	path string
+	// hasBash is set if the image has bash, the default shell.
+	hasBash bool
+	// actions is the host directory of actions copied for the container,
+	// by the directory they were fetched to.
+	actions string
+	copied  map[string]string
+	// memory is the container's memory limit in bytes, or 0.
+	memory int64
+}
+
+type mount struct {
+	host, container string
+}
+
+// startJobContainer starts jobs.<id>.container on the job's network with
+// the workspace, temp directory, home and event mounted where the hosted
+// runner mounts them. The command keeps it idle while steps run in it. An
+// image that evaluates to "" runs the job on the host.
+func (jr *jobRun) startJobContainer(ctx context.Context) error {
+	if jr.job.Container == nil {
+		return nil
+	}
+	cfg, auth, err := jr.containerConfig(jr.job.Container, "job container", "jobs", jr.job.ID, "container")
+	if err != nil || cfg == nil {
+		return err
+	}
+	client := jr.services.client
+
+	workspace := jr.workspace
+	// GitHub names the directory after the repository.
+	base := filepath.Base(jr.run.r.opts.Workspace)
+	jc := &jobContainer{workspace: path.Join(containerWork, base, base), copied: map[string]string{}}
+	if jc.actions, err = os.MkdirTemp(jr.run.temp, "actions-"); err != nil {
+		return err
+	}
+	home := filepath.Join(jr.temp, "_github_home")
+	if err := os.MkdirAll(home, 0o755); err != nil {
+		return err
+	}
+	jc.mounts = []mount{
+		{workspace, jc.workspace},
+		{jr.temp, containerTemp},
+		{jc.actions, containerActions},
+		{home, containerHome},
+		{filepath.Dir(jr.run.eventPath), containerWorkflowDir},
+	}
+	for _, m := range jc.mounts {
+		cfg.HostConfig.Binds = append(cfg.HostConfig.Binds, m.host+":"+m.container)
+	}
+	cfg.WorkingDir = jc.workspace
+	// Steps run in the one container, so the runner's limits are the
+	// container's.
+	if l := jr.run.r.opts.Sandbox.Limits; !l.IsZero() {
+		fmt.Fprintf(jr.log, "Limiting the job container to %s\n", l)
+		l.hostConfig(&cfg.HostConfig)
+		if l.NoNetwork {
+			cfg.NetworkingConfig = nil
+		}
+	}
+	jc.memory = cfg.HostConfig.Memory
+	cfg.HostConfig.ExtraHosts = append(cfg.HostConfig.ExtraHosts, jr.proxyHosts()...)
+	if cfg.Entrypoint == nil {
+		cfg.Entrypoint, cfg.Cmd = []string{"tail"}, []string{"-f", "/dev/null"}
+	}
+
+	fmt.Fprintf(jr.log, "Starting job container (%s)\n", cfg.Image)
+	if err := jr.ensureImage(ctx, client, cfg.Image, auth); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("actions-%s-%d", sanitizeName(jr.job.ID), time.Now().UnixNano())
+	if jc.id, err = client.ContainerCreate(ctx, name, cfg); err != nil {
+		return err
+	}
+	jr.container = jc
+	jr.matchers.Workspace, jr.matchers.HostPath = jc.workspace, jc.hostPath
+	if err := client.ContainerStart(ctx, jc.id); err != nil {
+		return err
+	}
+	info, err := client.ContainerInspect(ctx, jc.id)
+	if err != nil {
+		return err
+	}
+	if !info.State.Running {
+		return fmt.Errorf("container exited with code %d", info.State.ExitCode)
+	}
+	jc.path = defaultContainerPath
+	for _, kv := range info.Config.Env {
+		if v, ok := strings.CutPrefix(kv, "PATH="); ok {
+			jc.path = v
+		}
+	}
+	code, err := client.Exec(ctx, jc.id, &docker.ExecConfig{Cmd: []string{"sh", "-c", "command -v bash"}}, io.Discard, io.Discard)
+	jc.hasBash = err == nil && code == 0
+	return nil
+}
+
+// containerPath translates a host path under one of the container's mounts
+// to its path in the container.
+func (jc *jobContainer) containerPath(host string) (string, bool) {
+	best := -1
+	var out string
+	for _, m := range jc.mounts {
+		rel, err := filepath.Rel(m.host, host)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if len(m.host) > best {
+			best, out = len(m.host), path.Join(m.container, filepath.ToSlash(rel))
+		}
+	}
+	return out, best >= 0
+}
+
+// hostPath translates a path in the container under one of its mounts to
+// the host path. Other paths are returned unchanged.
+func (jc *jobContainer) hostPath(p string) string {
+	best := -1
+	out := p
+	for _, m := range jc.mounts {
+		if p != m.container && !strings.HasPrefix(p, m.container+"/") {
+			continue
+		}
+		if len(m.container) > best {
+			best, out = len(m.container), filepath.Join(m.host, filepath.FromSlash(strings.TrimPrefix(p, m.container)))
+		}
+	}
+	return out
+}
+
+// mustContainerPath is containerPath for the paths the runner creates
+// under the mounts, which always translate.
+func (jc *jobContainer) mustContainerPath(host string) string {
+	p, _ := jc.containerPath(host)
+	return p
+}
+
+// visible returns a host directory with the contents of dir that is
+// mounted in the container: dir itself if it is, or a copy under the
+// actions mount.
+func (jc *jobContainer) visible(dir string) (string, error) {
+	if _, ok := jc.containerPath(dir); ok {
+		return dir, nil
+	}
+	if copied, ok := jc.copied[dir]; ok {
+		return copied, nil
+	}
+	sum := sha256.Sum256([]byte(dir))
+	dst := filepath.Join(jc.actions, hex.EncodeToString(sum[:6]))
+	if err := copyTree(dir, dst); err != nil {
+		return "", fmt.Errorf("failed to copy action into the job container: %w", err)
+	}
+	jc.copied[dir] = dst
+	return dst, nil
+}
+
+// jobContainerEnv is the environment of a step in the job container: the
+// step environment with paths translated and PATH extended by GITHUB_PATH.
+func (jr *jobRun) jobContainerEnv(stepEnv map[string]string, files *stepFiles) map[string]string {
+	jc := jr.container
+	env := jr.stepEnv(stepEnv, files)
+	for _, k := range []string{"GITHUB_WORKSPACE", "GITHUB_EVENT_PATH", "GITHUB_ENV", "GITHUB_OUTPUT", "GITHUB_PATH", "GITHUB_STEP_SUMMARY", "GITHUB_STATE", "RUNNER_TEMP", "GITHUB_ACTION_PATH"} {
+		if p, ok := jc.containerPath(env[k]); ok && env[k] != "" {
+			env[k] = p
+		}
+	}
+	env["HOME"] = containerHome
+	env["PATH"] = strings.Join(append(append([]string(nil), jr.path...), jc.path), ":")
+	jr.containerProxyEnv(env)
+	return env
+}
+
+// execInContainer runs argv in the job container.
+func (jr *jobRun) execInContainer(ctx context.Context, argv []string, dir string, env map[string]string) error {
+	if jr.limits != jr.run.r.opts.Sandbox.Limits {
+		// Processes started with docker exec share the container's cgroup.
+		return fmt.Errorf("steps in a job container cannot have limits of their own; they run within the container's (%s)", jr.run.r.opts.Sandbox.Limits)
+	}
+	client := jr.services.client
+	code, err := client.Exec(ctx, jr.container.id, &docker.ExecConfig{
+		Cmd:        argv,
+		Env:        envList(env),
+		WorkingDir: dir,
+	}, jr.log, jr.log)
+	if err != nil {
+		return err
+	}
+	if code != 0 && jr.container.memory > 0 {
+		if info, err := client.ContainerInspect(ctx, jr.container.id); err == nil && info.State.OOMKilled {
+			return &limitError{limit: LimitMemory, max: FormatMemory(jr.container.memory), code: code}
+		}
+	}
+	if code != 0 {
+		return &exitError{code: code}
+	}
+	return nil
+}
+
+// copyTree copies the directory src to dst, keeping symlinks.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		out := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(out, info.Mode().Perm()|0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, out)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(out, data, info.Mode().Perm())
+	})
+}
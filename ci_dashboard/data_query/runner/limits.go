@@ -0,0 +1,291 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"testingdashboard/m/v2/docker"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Limits caps the resources of a step. Zero fields are not limited.
+//
+// On the host, steps are confined with cgroup v2 and namespaces, which
+// needs Linux; a limited step fails elsewhere rather than run unconfined.
+// Steps running in a container of their own get Docker's resource limits.
+// Wasm actions only honor Memory, and never have network access.
+type Limits struct {
+	// CPUs is how many CPUs' worth of time the step may use, such as 0.5.
+	CPUs float64
+	// Memory caps the step's memory, without swap, in bytes.
+	Memory int64
+	// PIDs caps how many processes and threads the step may run at once.
+	PIDs int64
+	// NoNetwork cuts the step off from the network. On the host the step
+	// gets a network namespace of its own, whose loopback device is down.
+	NoNetwork bool
+}
+
+// Names of the limits a StepResult reports the step exceeded.
+const (
+	LimitMemory = "memory"
+	LimitPIDs   = "pids"
+)
+
+// SandboxOptions confine the steps a runner runs.
+type SandboxOptions struct {
+	// Limits apply to every step.
+	Limits Limits
+	// ThirdParty apply on top of Limits to steps using docker:// images or
+	// actions whose owner is not the repository's, and to the steps of
+	// composite actions among them.
+	ThirdParty Limits
+	// Cgroup is the cgroup v2 directory the cgroups of limited steps are
+	// created in, such as one systemd delegates. It defaults to the
+	// runner's own cgroup; since a cgroup holding processes cannot limit
+	// its children, the runner then moves those processes into a child
+	// named runner.
+	Cgroup string
+}
+
+// IsZero reports whether l limits nothing.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// cgroup reports whether enforcing l on the host needs a cgroup.
+func (l Limits) cgroup() bool {
+	return l.CPUs > 0 || l.Memory > 0 || l.PIDs > 0
+}
+
+// tighten returns the stricter of l and o for each limit.
+func (l Limits) tighten(o Limits) Limits {
+	if o.CPUs > 0 && (l.CPUs == 0 || o.CPUs < l.CPUs) {
+		l.CPUs = o.CPUs
+	}
+	if o.Memory > 0 && (l.Memory == 0 || o.Memory < l.Memory) {
+		l.Memory = o.Memory
+	}
+	if o.PIDs > 0 && (l.PIDs == 0 || o.PIDs < l.PIDs) {
+		l.PIDs = o.PIDs
+	}
+	l.NoNetwork = l.NoNetwork || o.NoNetwork
+	return l
+}
+
+func (l Limits) String() string {
+	var parts []string
+	if l.CPUs > 0 {
+		parts = append(parts, strconv.FormatFloat(l.CPUs, 'f', -1, 64)+" CPUs")
+	}
+	if l.Memory > 0 {
+		parts = append(parts, FormatMemory(l.Memory)+" of memory")
+	}
+	if l.PIDs > 0 {
+		parts = append(parts, fmt.Sprintf("%d processes", l.PIDs))
+	}
+	if l.NoNetwork {
+		parts = append(parts, "no network")
+	}
+	if len(parts) == 0 {
+		return "no limits"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// hostConfig applies l to a container.
+func (l Limits) hostConfig(hc *docker.HostConfig) {
+	if l.Memory > 0 && (hc.Memory == 0 || l.Memory < hc.Memory) {
+		hc.Memory = l.Memory
+		hc.MemorySwap = l.Memory
+	}
+	if nano := int64(l.CPUs * 1e9); nano > 0 && (hc.NanoCPUs == 0 || nano < hc.NanoCPUs) {
+		hc.NanoCPUs = nano
+	}
+	if l.PIDs > 0 && (hc.PidsLimit == nil || l.PIDs < *hc.PidsLimit) {
+		hc.PidsLimit = &l.PIDs
+	}
+	if l.NoNetwork {
+		hc.NetworkMode = "none"
+	}
+}
+
+// memoryUnits are the suffixes ParseMemory accepts, in powers of 1024.
+var memoryUnits = map[string]int{"": 0, "b": 0, "k": 1, "m": 2, "g": 3, "t": 4}
+
+// ParseMemory parses a size such as 512M, 1.5g or 2GiB into bytes. Units
+// are binary whether or not they end in iB or B, as Docker reads them.
+func ParseMemory(s string) (int64, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	i := strings.IndexFunc(v, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	num, unit := v, ""
+	if i >= 0 {
+		num, unit = v[:i], strings.TrimSpace(v[i:])
+	}
+	if len(unit) > 1 {
+		unit = strings.TrimSuffix(strings.TrimSuffix(unit, "b"), "i")
+	}
+	exp, ok := memoryUnits[unit]
+	n, err := strconv.ParseFloat(num, 64)
+	if !ok || err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid memory size %q", s)
+	}
+	bytes := n * math.Pow(1024, float64(exp))
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("memory size %q is too large", s)
+	}
+	return int64(bytes), nil
+}
+
+// FormatMemory formats bytes in the largest binary unit that keeps the
+// value at or above 1, such as 512 MiB or 1.5 GiB.
+func FormatMemory(bytes int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	v, i := float64(bytes), 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64) + " " + units[i]
+}
+
+// ParseLimits parses limits written as comma-separated key=value pairs with
+// the keys of x-limits, such as cpus=2,memory=4G,pids=1024,network=false.
+func ParseLimits(s string) (Limits, error) {
+	var sl workflow.StepLimits
+	for _, kv := range strings.Split(s, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return Limits{}, fmt.Errorf("invalid limit %q: expected key=value", kv)
+		}
+		var err error
+		switch k {
+		case "cpus":
+			sl.CPUs, err = strconv.ParseFloat(v, 64)
+		case "memory":
+			sl.Memory = v
+		case "pids":
+			sl.PIDs, err = strconv.ParseInt(v, 10, 64)
+		case "network":
+			var network bool
+			network, err = strconv.ParseBool(v)
+			sl.Network = &network
+		default:
+			return Limits{}, fmt.Errorf("unknown limit %q", k)
+		}
+		if err != nil {
+			return Limits{}, fmt.Errorf("invalid limit %q", kv)
+		}
+	}
+	return parseStepLimits(&sl)
+}
+
+// parseStepLimits reads the x-limits extension of a step.
+func parseStepLimits(sl *workflow.StepLimits) (Limits, error) {
+	var l Limits
+	if sl == nil {
+		return l, nil
+	}
+	if sl.CPUs < 0 || sl.PIDs < 0 {
+		return l, fmt.Errorf("invalid x-limits: cpus and pids must not be negative")
+	}
+	l.CPUs, l.PIDs = sl.CPUs, sl.PIDs
+	if sl.Memory != "" {
+		var err error
+		if l.Memory, err = ParseMemory(sl.Memory); err != nil {
+			return l, fmt.Errorf("invalid x-limits: %w", err)
+		}
+	}
+	l.NoNetwork = sl.Network != nil && !*sl.Network
+	return l, nil
+}
+
+// thirdParty reports whether the action uses refers to is not the
+// repository's own: a docker:// image or an action of another owner.
+func (jr *jobRun) thirdParty(uses *workflow.Uses) bool {
+	switch uses.Kind {
+	case workflow.UsesDocker:
+		return true
+	case workflow.UsesRepository:
+		owner, _, _ := strings.Cut(expr.ToString(jr.run.github["repository"]), "/")
+		return !strings.EqualFold(uses.Owner, owner)
+	}
+	return false
+}
+
+// limitError reports that a step failed because it exceeded a limit.
+type limitError struct {
+	limit string
+	max   string
+	code  int
+}
+
+func (e *limitError) Error() string {
+	if e.limit == LimitMemory {
+		return fmt.Sprintf("the step ran out of memory (limit %s)", e.max)
+	}
+	return fmt.Sprintf("the step reached its limit of %s %s", e.max, e.limit)
+}
+
+func (e *limitError) ExitCode() int {
+	return e.code
+}
+
+// cgroupParent is the cgroup the runner creates the cgroups of limited
+// steps in, prepared on first use.
+type cgroupParent struct {
+	once sync.Once
+	dir  string
+	err  error
+}
+
+func (r *Runner) cgroupParent() (string, error) {
+	r.cgroup.once.Do(func() {
+		r.cgroup.dir, r.cgroup.err = prepareCgroup(r.opts.Sandbox.Cgroup)
+	})
+	return r.cgroup.dir, r.cgroup.err
+}
+
+// cgroupSeq numbers the cgroups of steps across runs and jobs.
+var cgroupSeq atomic.Int64
+
+// runProcess runs a step process on the host within the step's limits.
+func (jr *jobRun) runProcess(cmd *exec.Cmd) error {
+	l := jr.limits
+	if l.IsZero() {
+		return cmd.Run()
+	}
+	fmt.Fprintf(jr.log, "Limiting the step to %s\n", l)
+	var parent string
+	if l.cgroup() {
+		var err error
+		if parent, err = jr.run.r.cgroupParent(); err != nil {
+			return err
+		}
+	}
+	return runConfined(cmd, l, parent, fmt.Sprintf("step-%d-%d", os.Getpid(), cgroupSeq.Add(1)))
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// cgroupRoot is where cgroup v2 is mounted.
+	cgroupRoot = "/sys/fs/cgroup"
+	// cgroup2Magic is the file system type of cgroup v2.
+	cgroup2Magic = 0x63677270
+	// cpuPeriod is the period of cpu.max, in microseconds.
+	cpuPeriod = 100000
+)
+
+// prepareCgroup returns the cgroup to create step cgroups in, dir or the
+// runner's own, with the cpu, memory and pids controllers enabled for its
+// children.
+func prepareCgroup(dir string) (string, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(cgroupRoot, &st); err != nil || st.Type != cgroup2Magic {
+		return "", fmt.Errorf("step limits need cgroup v2 mounted at %s", cgroupRoot)
+	}
+	own := dir == ""
+	if own {
+		data, err := os.ReadFile("/proc/self/cgroup")
+		if err != nil {
+			return "", fmt.Errorf("failed to find the runner's cgroup: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if p, ok := strings.CutPrefix(line, "0::"); ok {
+				dir = filepath.Join(cgroupRoot, p)
+			}
+		}
+		if dir == "" {
+			return "", fmt.Errorf("the runner is not in a cgroup v2 hierarchy")
+		}
+	}
+	available, err := os.ReadFile(filepath.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the controllers of %s: %w", dir, err)
+	}
+	var enable []string
+	for _, c := range strings.Fields(string(available)) {
+		if c == "cpu" || c == "memory" || c == "pids" {
+			enable = append(enable, "+"+c)
+		}
+	}
+	err = writeCgroup(dir, "cgroup.subtree_control", strings.Join(enable, " "))
+	if errors.Is(err, syscall.EBUSY) && own {
+		// Only leaf cgroups hold processes once controllers are enabled
+		// for children.
+		if err = evacuateCgroup(dir, filepath.Join(dir, "runner")); err == nil {
+			err = writeCgroup(dir, "cgroup.subtree_control", strings.Join(enable, " "))
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to enable resource controllers in %s, which must be delegated to the runner (for example with systemd-run --scope -p Delegate=yes): %w", dir, err)
+	}
+	return dir, nil
+}
+
+// evacuateCgroup moves the processes of dir into its child leaf.
+func evacuateCgroup(dir, leaf string) error {
+	if err := os.Mkdir(leaf, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	procs, err := os.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+	for _, pid := range strings.Fields(string(procs)) {
+		if err := writeCgroup(leaf, "cgroup.procs", pid); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCgroup(dir, file, value string) error {
+	return os.WriteFile(filepath.Join(dir, file), []byte(value), 0o644)
+}
+
+// runConfined runs cmd within l: in a new cgroup named name under parent,
+// unless l needs none, and in a network namespace of its own if l denies
+// network access.
+func runConfined(cmd *exec.Cmd, l Limits, parent, name string) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	attr := cmd.SysProcAttr
+	if l.NoNetwork {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+		if uid, gid := os.Geteuid(), os.Getegid(); uid != 0 {
+			// Unprivileged, the network namespace needs a user namespace,
+			// in which the step keeps its IDs.
+			attr.Cloneflags |= syscall.CLONE_NEWUSER
+			attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+			attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+		}
+	}
+	var cg *stepCgroup
+	if parent != "" {
+		var err error
+		if cg, err = newStepCgroup(filepath.Join(parent, name), l); err != nil {
+			return err
+		}
+		defer cg.remove()
+		attr.UseCgroupFD = true
+		attr.CgroupFD = int(cg.fd.Fd())
+	}
+	if err := cmd.Start(); err != nil {
+		if l.NoNetwork && errors.Is(err, syscall.EPERM) {
+			return fmt.Errorf("failed to cut the step off from the network: %w", err)
+		}
+		return err
+	}
+	err := cmd.Wait()
+	if err != nil && cg != nil {
+		if exceeded := cg.exceeded(l, err); exceeded != nil {
+			return exceeded
+		}
+	}
+	return err
+}
+
+// stepCgroup is the cgroup of one step process and its children.
+type stepCgroup struct {
+	dir string
+	fd  *os.File
+}
+
+func newStepCgroup(dir string, l Limits) (*stepCgroup, error) {
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create the step's cgroup: %w", err)
+	}
+	cg := &stepCgroup{dir: dir}
+	var settings [][2]string
+	if l.Memory > 0 {
+		// Without swap the limit is the memory the step can use, and an
+		// out-of-memory kill takes the whole step down.
+		settings = append(settings, [2]string{"memory.max", strconv.FormatInt(l.Memory, 10)}, [2]string{"memory.swap.max", "0"}, [2]string{"memory.oom.group", "1"})
+	}
+	if l.CPUs > 0 {
+		settings = append(settings, [2]string{"cpu.max", fmt.Sprintf("%d %d", max(int64(l.CPUs*cpuPeriod), 1000), cpuPeriod)})
+	}
+	if l.PIDs > 0 {
+		settings = append(settings, [2]string{"pids.max", strconv.FormatInt(l.PIDs, 10)})
+	}
+	for _, s := range settings {
+		err := writeCgroup(dir, s[0], s[1])
+		if err != nil && !(errors.Is(err, os.ErrNotExist) && s[0] == "memory.swap.max") {
+			cg.remove()
+			return nil, fmt.Errorf("failed to set %s of the step's cgroup: %w", s[0], err)
+		}
+	}
+	var err error
+	if cg.fd, err = os.Open(dir); err != nil {
+		cg.remove()
+		return nil, err
+	}
+	return cg, nil
+}
+
+// exceeded returns the limit error of a step that failed with err, if a
+// limit caused it.
+func (cg *stepCgroup) exceeded(l Limits, err error) error {
+	code := 137
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		code = exitErr.ExitCode()
+	}
+	if l.Memory > 0 && cg.event("memory.events", "oom_kill") > 0 {
+		return &limitError{limit: LimitMemory, max: FormatMemory(l.Memory), code: code}
+	}
+	if l.PIDs > 0 && cg.event("pids.events", "max") > 0 {
+		return &limitError{limit: LimitPIDs, max: strconv.FormatInt(l.PIDs, 10), code: code}
+	}
+	return nil
+}
+
+// event returns the count of key in the flat-keyed file of the cgroup.
+func (cg *stepCgroup) event(file, key string) int64 {
+	data, err := os.ReadFile(filepath.Join(cg.dir, file))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if k, v, ok := strings.Cut(line, " "); ok && k == key {
+			n, _ := strconv.ParseInt(v, 10, 64)
+			return n
+		}
+	}
+	return 0
+}
+
+// remove kills what is left of the step and removes its cgroup.
+func (cg *stepCgroup) remove() {
+	if cg.fd != nil {
+		cg.fd.Close()
+	}
+	writeCgroup(cg.dir, "cgroup.kill", "1")
+	deadline := time.Now().Add(processWaitDelay)
+	for os.Remove(cg.dir) != nil && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+)
+
+var errNoConfinement = errors.New("step limits on the host need Linux")
+
+func prepareCgroup(string) (string, error) {
+	return "", errNoConfinement
+}
+
+func runConfined(*exec.Cmd, Limits, string, string) error {
+	return errNoConfinement
+}
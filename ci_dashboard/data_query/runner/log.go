@@ -0,0 +1,226 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/logging"
+	"testingdashboard/m/v2/mask"
+	"testingdashboard/m/v2/workflow"
+)
+
+// jobRecord returns a function locating records in the leg of job, or in
+// the job itself when leg is empty, or at workflow level when job is nil.
+func (run *run) jobRecord(job *workflow.Job, leg string) func(r *logging.Record) {
+	runID := expr.ToString(run.github["run_id"])
+	return func(r *logging.Record) {
+		r.RunID = runID
+		if job != nil {
+			r.JobID = job.ID
+		}
+		r.Job = leg
+	}
+}
+
+// entryWriter is a log writer that also takes lines with their level and
+// annotation. Plain writes log at info level.
+type entryWriter interface {
+	Write(b []byte) (int, error)
+	writeEntry(level logging.Level, text string, a *logging.Annotation)
+}
+
+// logWriter turns every complete line written to it into a record of the
+// section it writes to, located in the run by fill.
+type logWriter struct {
+	mu   sync.Mutex
+	out  *logSection
+	fill func(r *logging.Record)
+	buf  bytes.Buffer
+	// stepID and step locate the lines of the running step.
+	stepID, step string
+	// masker, if set, masks every line, including the runner's own
+	// messages and the output of services.
+	masker *mask.Masker
+}
+
+func newLogWriter(out *logSection, fill func(r *logging.Record)) *logWriter {
+	return &logWriter{out: out, fill: fill}
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(b)
+	for {
+		i := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(w.buf.Next(i+1)), "\n")
+		w.log(lineLevel(line), line, nil)
+	}
+	return len(b), nil
+}
+
+// lineLevel is the level of a line the runner logs, which it marks
+// in the text.
+func lineLevel(line string) logging.Level {
+	switch {
+	case strings.HasPrefix(line, "Error"):
+		return logging.LevelError
+	case strings.HasPrefix(line, "Warning:"):
+		return logging.LevelWarning
+	}
+	return logging.LevelInfo
+}
+
+func (w *logWriter) writeEntry(level logging.Level, text string, a *logging.Annotation) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.log(level, text, a)
+}
+
+func (w *logWriter) log(level logging.Level, text string, a *logging.Annotation) {
+	if w.masker != nil {
+		text = w.masker.Mask(text)
+	}
+	r := &logging.Record{Time: time.Now(), Level: level, StepID: w.stepID, Step: w.step, Message: text, Annotation: a}
+	if w.fill != nil {
+		w.fill(r)
+	}
+	w.out.add(r)
+}
+
+// setStep attributes the lines that follow to the step of sr and returns
+// a function attributing them back to the previous step.
+func (w *logWriter) setStep(sr *StepResult) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id, name := w.stepID, w.step
+	w.stepID, w.step = sr.ID, sr.Name
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.stepID, w.step = id, name
+	}
+}
+
+// currentStep returns the ID of the running step, if any.
+func (w *logWriter) currentStep() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stepID
+}
+
+// Flush logs any trailing partial line.
+func (w *logWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	line := w.buf.String()
+	w.buf.Reset()
+	w.log(lineLevel(line), line, nil)
+	return nil
+}
+
+// logSection is a part of the run's log. Sections print in the order they
+// were created whatever order they are written in: the first unfinished
+// one streams to the sink while later ones are buffered until every
+// section before them has finished, so parallel jobs and legs print as if
+// they ran one after another.
+type logSection struct {
+	log   *orderedLog
+	parts []logPart
+	// next is the first part not logged yet.
+	next int
+	done bool
+}
+
+// logPart is either a record or a nested section.
+type logPart struct {
+	record *logging.Record
+	child  *logSection
+}
+
+type orderedLog struct {
+	mu   sync.Mutex
+	sink logging.Sink
+	// err is the first error of the sink, after which it is not called.
+	err  error
+	root *logSection
+}
+
+// newOrderedLog returns the root section of a log written to sink.
+func newOrderedLog(sink logging.Sink) *logSection {
+	l := &orderedLog{sink: sink}
+	l.root = &logSection{log: l}
+	return l.root
+}
+
+// add appends r to s.
+func (s *logSection) add(r *logging.Record) {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	s.parts = append(s.parts, logPart{record: r})
+	s.log.flush(s.log.root)
+}
+
+// Section adds a section to s after what was written to it so far.
+func (s *logSection) Section() *logSection {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	child := &logSection{log: s.log}
+	s.parts = append(s.parts, logPart{child: child})
+	return child
+}
+
+// Close marks s as finished, letting the sections after it print.
+func (s *logSection) Close() error {
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	s.done = true
+	s.log.flush(s.log.root)
+	return s.log.err
+}
+
+// flush logs what s can print now, stopping at the first nested section
+// that has not finished.
+func (l *orderedLog) flush(s *logSection) {
+	for ; s.next < len(s.parts); s.next++ {
+		p := &s.parts[s.next]
+		if p.child == nil {
+			if l.err == nil {
+				l.err = l.sink.Log(p.record)
+			}
+			p.record = nil
+			continue
+		}
+		if l.flush(p.child); !p.child.finished() {
+			return
+		}
+	}
+}
+
+// finished reports whether s is closed and written out.
+func (s *logSection) finished() bool {
+	return s.done && s.next == len(s.parts)
+}
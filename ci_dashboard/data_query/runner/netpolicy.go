@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/netpolicy"
+)
+
+// dockerHost is the name containers reach the runner's host by.
+const dockerHost = "host.docker.internal"
+
+// startProxy starts the leg's egress proxy if the runner has a network
+// policy. It listens on every interface for containers, which must
+// present the credentials of its URL, as steps do.
+func (jr *jobRun) startProxy() error {
+	policy := jr.run.r.opts.NetPolicy
+	if policy == nil {
+		return nil
+	}
+	proxy, err := netpolicy.Listen(policy, ":0")
+	if err != nil {
+		return err
+	}
+	jr.proxy = proxy
+	jr.masks.Add(proxy.Token())
+	fmt.Fprintf(jr.log, "Network policy: %s mode, %d allowed destinations\n", policy.Mode, len(policy.Allow))
+	return nil
+}
+
+// stopProxy reports the leg's requests, failing it on violations in
+// enforce mode, and stops the proxy.
+func (jr *jobRun) stopProxy(leg *LegResult) {
+	if jr.proxy == nil {
+		return
+	}
+	mode := jr.run.r.opts.NetPolicy.Mode
+	leg.Network = jr.proxy.Requests()
+	jr.proxy.Close()
+	netpolicy.Report(jr.log, mode, leg.Network)
+	if n := len(netpolicy.Violations(leg.Network)); n > 0 && mode == netpolicy.ModeEnforce {
+		fmt.Fprintf(jr.log, "Error: the network policy blocked %d requests\n", n)
+		jr.status = expr.StatusFailure
+	}
+}
+
+// registerProxyStep attributes the requests made from now on to the step
+// named name and returns a function restoring the previous step.
+func (jr *jobRun) registerProxyStep(name string) func() {
+	if jr.proxy == nil {
+		return func() {}
+	}
+	parent := jr.proxyUser
+	jr.proxyUser = jr.proxy.Register(name)
+	return func() { jr.proxyUser = parent }
+}
+
+// setProxyEnv points the proxy variables of env at the leg's proxy on
+// host. Destinations in noProxy are reached directly.
+func (jr *jobRun) setProxyEnv(env map[string]string, host string, noProxy ...string) {
+	if jr.proxy == nil {
+		return
+	}
+	url := jr.proxy.URL(host, jr.proxyUser)
+	for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "http_proxy", "https_proxy"} {
+		env[k] = url
+	}
+	none := append([]string{"localhost", "127.0.0.1", "::1"}, noProxy...)
+	env["NO_PROXY"] = strings.Join(none, ",")
+	env["no_proxy"] = env["NO_PROXY"]
+}
+
+// containerProxyEnv points the proxy variables of env, for a container on
+// the leg's network, at the proxy on the host. The services are reached
+// directly.
+func (jr *jobRun) containerProxyEnv(env map[string]string) {
+	var services []string
+	if jr.services != nil {
+		for id := range jr.services.containers {
+			services = append(services, id)
+		}
+		sort.Strings(services)
+	}
+	jr.setProxyEnv(env, dockerHost, services...)
+}
+
+// proxyHosts returns the extra hosts entries containers need to reach the
+// proxy.
+func (jr *jobRun) proxyHosts() []string {
+	if jr.proxy == nil {
+		return nil
+	}
+	return []string{dockerHost + ":host-gateway"}
+}
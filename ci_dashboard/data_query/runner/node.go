@@ -0,0 +1,121 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/workflow"
+)
+
+// nodeAction is a JavaScript action with its entry points resolved to
+// absolute paths. Pre and post are empty when the action does not define them.
+type nodeAction struct {
+	dir             string
+	using           string
+	main, pre, post string
+	preIf, postIf   string
+	uses            *workflow.Uses
+}
+
+func resolveNodeAction(dir string, uses *workflow.Uses, meta *metadata.Action) (*nodeAction, error) {
+	na := &nodeAction{
+		dir:    dir,
+		using:  meta.Runs.Using,
+		preIf:  meta.Runs.PreIf,
+		postIf: meta.Runs.PostIf,
+		uses:   uses,
+	}
+	if meta.Runs.Main == "" {
+		return nil, fmt.Errorf("action %s does not define runs.main", uses)
+	}
+	for _, entry := range []struct {
+		rel string
+		dst *string
+	}{{meta.Runs.Main, &na.main}, {meta.Runs.Pre, &na.pre}, {meta.Runs.Post, &na.post}} {
+		if entry.rel == "" {
+			continue
+		}
+		path := filepath.Join(dir, entry.rel)
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("action %s: %w", uses, err)
+		}
+		*entry.dst = path
+	}
+	return na, nil
+}
+
+// nodeBinary finds the node executable for runs.using. A versioned binary
+// such as node20 is preferred when installed.
+func nodeBinary(using string) (string, error) {
+	if path, err := exec.LookPath(using); err == nil {
+		return path, nil
+	}
+	path, err := exec.LookPath("node")
+	if err != nil {
+		return "", fmt.Errorf("%s actions require node on PATH", using)
+	}
+	return path, nil
+}
+
+// runNodeScript runs one entry point of a JavaScript action, passing it
+// the state its earlier entry points saved as STATE_ variables.
+func (jr *jobRun) runNodeScript(ctx context.Context, na *nodeAction, script string, inputs, stepEnv, state map[string]string, files *stepFiles) error {
+	env := map[string]string{}
+	for k, v := range stepEnv {
+		env[k] = v
+	}
+	for k, v := range state {
+		env["STATE_"+k] = v
+	}
+	for k, v := range inputEnv(inputs) {
+		env[k] = v
+	}
+	env["GITHUB_ACTION_PATH"] = na.dir
+	if na.uses.Kind == workflow.UsesRepository {
+		env["GITHUB_ACTION_REPOSITORY"] = na.uses.Repository()
+		env["GITHUB_ACTION_REF"] = na.uses.Ref
+	}
+
+	if jc := jr.container; jc != nil {
+		// Unlike the hosted runner, which mounts its own node, this relies
+		// on the image providing node.
+		err := jr.execInContainer(ctx, []string{"node", jc.mustContainerPath(script)}, jc.workspace, jr.jobContainerEnv(env, files))
+		var exit *exitError
+		if errors.As(err, &exit) && (exit.code == 126 || exit.code == 127) {
+			fmt.Fprintf(jr.log, "%s actions in a job container require node in the image\n", na.using)
+		}
+		return err
+	}
+	node, err := nodeBinary(na.using)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, node, script)
+	cmd.Dir = jr.workspace
+	cmd.Env = jr.processEnv(env, files)
+	cmd.Stdout = jr.log
+	cmd.Stderr = jr.log
+	// Children of a killed step may hold its output open; stop waiting
+	// for them when the step is cancelled or times out.
+	cmd.WaitDelay = processWaitDelay
+	return jr.runProcess(cmd)
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// hostWindows reports whether steps on the host run on Windows.
+const hostWindows = runtime.GOOS == "windows"
+
+// hostEnviron returns the runner's own environment. On Windows, names are
+// case-insensitive and the per-drive "=C:" entries start with "=".
+func hostEnviron() map[string]string {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		if kv == "" {
+			continue
+		}
+		if k, v, ok := strings.Cut(kv[1:], "="); ok {
+			setEnv(env, kv[:1]+k, v)
+		}
+	}
+	return env
+}
+
+// setEnv sets the variable name in env. On Windows it replaces a variable
+// whose name differs only in case, keeping its spelling, so a step's PATH
+// overrides the inherited Path rather than sitting beside it.
+func setEnv(env map[string]string, name, value string) {
+	env[envName(env, name)] = value
+}
+
+// envName returns the spelling of name already used in env.
+func envName(env map[string]string, name string) string {
+	if !hostWindows {
+		return name
+	}
+	if _, ok := env[name]; ok {
+		return name
+	}
+	for k := range env {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return name
+}
+
+// hostUser returns the login name of the user running the runner.
+func hostUser() string {
+	for _, k := range []string{"USER", "USERNAME", "LOGNAME"} {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// toolCacheDir returns the hosted tool cache, which setup actions install
+// into: RUNNER_TOOL_CACHE or AGENT_TOOLSDIRECTORY if set, as on machines
+// provisioned like the hosted runner, else a directory of the run.
+func (run *run) toolCacheDir() string {
+	for _, k := range []string{"RUNNER_TOOL_CACHE", "AGENT_TOOLSDIRECTORY"} {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return filepath.Join(run.temp, "tool_cache")
+}
+
+// realTempDir creates a temporary directory and returns its path with
+// symlinks resolved. On macOS the temporary directory lives under the
+// /var symlink, which scripts see as /private/var once they cd into it.
+func realTempDir(pattern string) (string, error) {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = real
+	}
+	return dir, nil
+}
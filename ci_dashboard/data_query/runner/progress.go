@@ -0,0 +1,49 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import "time"
+
+// ProgressEvent is a job leg or step starting or finishing.
+type ProgressEvent struct {
+	Time  time.Time
+	JobID string
+	// Job is the display name of the leg. It is empty for jobs that
+	// finish without running, such as skipped ones.
+	Job string
+	// StepID and Step are set for steps. Parent is the step ID of the step
+	// whose composite action the step belongs to.
+	StepID, Step, Parent string
+	// Result is empty when the job or step starts.
+	Result string
+}
+
+// progress reports e to Options.Progress.
+func (run *run) progress(e ProgressEvent) {
+	if f := run.r.opts.Progress; f != nil {
+		e.Time = time.Now()
+		f(e)
+	}
+}
+
+// stepProgress reports that the step of sr starts and returns a function
+// reporting that it finished.
+func (jr *jobRun) stepProgress(sr *StepResult) func() {
+	parent := jr.legLog.currentStep()
+	jr.run.progress(ProgressEvent{JobID: jr.job.ID, Job: jr.name, StepID: sr.ID, Step: sr.Name, Parent: parent})
+	return func() {
+		jr.run.progress(ProgressEvent{JobID: jr.job.ID, Job: jr.name, StepID: sr.ID, Step: sr.Name, Parent: parent, Result: sr.Conclusion})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"time"
+
+	"testingdashboard/m/v2/logging"
+	"testingdashboard/m/v2/workflow"
+)
+
+// maxRetryOutput is how much of the end of an attempt's output OnOutput
+// patterns are matched against.
+const maxRetryOutput = 1 << 20
+
+// RetryPolicy says whether and when a failed step runs again.
+type RetryPolicy struct {
+	// MaxAttempts counts the first run; below 2 nothing is retried.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubling with each
+	// retry after it up to MaxBackoff, if set.
+	Backoff, MaxBackoff time.Duration
+	// ExitCodes and Output, if either is set, limit retries to failures
+	// with one of the exit codes or output matching one of the patterns.
+	ExitCodes []int
+	Output    []*regexp.Regexp
+}
+
+// ParseRetry reads the x-retry extension of a step, returning nil for
+// steps without one.
+func ParseRetry(r *workflow.StepRetry) (*RetryPolicy, error) {
+	if r == nil {
+		return nil, nil
+	}
+	if r.MaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid x-retry: max-attempts must be at least 1")
+	}
+	p := &RetryPolicy{MaxAttempts: r.MaxAttempts, ExitCodes: r.OnExitCodes}
+	for _, d := range []struct {
+		key   string
+		value string
+		dst   *time.Duration
+	}{{"backoff", r.Backoff, &p.Backoff}, {"max-backoff", r.MaxBackoff, &p.MaxBackoff}} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid x-retry: %s must be a duration such as 10s, not %q", d.key, d.value)
+		}
+		*d.dst = v
+	}
+	for _, pattern := range r.OnOutput {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-retry: on-output: %w", err)
+		}
+		p.Output = append(p.Output, re)
+	}
+	return p, nil
+}
+
+// Delay returns how long to wait before attempt, which is 2 for the first
+// retry.
+func (p *RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 2; i < attempt && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// Retryable reports whether a step that failed with err after printing
+// output is retried. Steps that exceeded their x-limits are not: they
+// would again.
+func (p *RetryPolicy) Retryable(err error, output []byte) bool {
+	var limitErr *limitError
+	if err == nil || errors.As(err, &limitErr) {
+		return false
+	}
+	if len(p.ExitCodes) == 0 && len(p.Output) == 0 {
+		return true
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) && slices.Contains(p.ExitCodes, exitErr.ExitCode()) {
+		return true
+	}
+	for _, re := range p.Output {
+		if re.Match(output) {
+			return true
+		}
+	}
+	return false
+}
+
+// Do calls attempt, numbered from 1, until it succeeds, the policy gives
+// up or ctx is done, and returns the last error. Each attempt writes to a
+// log that copies to log and is kept for the Output patterns. A nil
+// policy makes one attempt.
+func (p *RetryPolicy) Do(ctx context.Context, log io.Writer, attempt func(ctx context.Context, n int, log io.Writer) error) error {
+	for n := 1; ; n++ {
+		tee := &teeLog{out: log}
+		err := attempt(ctx, n, tee)
+		if p == nil || n >= p.MaxAttempts || ctx.Err() != nil || !p.Retryable(err, tee.tail) {
+			return err
+		}
+		d := p.Delay(n + 1)
+		tee.writeEntry(logging.LevelWarning, fmt.Sprintf("Warning: attempt %d of %d failed: %v; retrying in %s", n, p.MaxAttempts, err, d), nil)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+	}
+}
+
+// Retry wraps x, an embedder's executor, so steps it fails run again as p
+// says. Steps that set x-retry are already retried by the runner.
+func Retry(x StepExecutor, p *RetryPolicy) StepExecutor {
+	return StepExecutorFunc(func(ctx context.Context, s *Step) error {
+		log := s.Log
+		defer func() { s.Log = log }()
+		return p.Do(ctx, log, func(ctx context.Context, _ int, attemptLog io.Writer) error {
+			s.Log = attemptLog
+			return x.ExecuteStep(ctx, s)
+		})
+	})
+}
+
+// teeLog writes to out, keeping the last maxRetryOutput bytes written.
+type teeLog struct {
+	out  io.Writer
+	tail []byte
+}
+
+func (t *teeLog) keep(b []byte) {
+	t.tail = append(t.tail, b...)
+	if over := len(t.tail) - maxRetryOutput; over > 0 {
+		t.tail = t.tail[over:]
+	}
+}
+
+func (t *teeLog) Write(b []byte) (int, error) {
+	t.keep(b)
+	return t.out.Write(b)
+}
+
+func (t *teeLog) writeEntry(level logging.Level, text string, a *logging.Annotation) {
+	t.keep([]byte(text + "\n"))
+	if ew, ok := t.out.(entryWriter); ok {
+		ew.writeEntry(level, text, a)
+		return
+	}
+	fmt.Fprintln(t.out, text)
+}
@@ -0,0 +1,321 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runner executes workflows on the local machine.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"testingdashboard/m/v2/compliance"
+	"testingdashboard/m/v2/concurrency"
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/graph"
+	"testingdashboard/m/v2/logging"
+	"testingdashboard/m/v2/metrics"
+	"testingdashboard/m/v2/netpolicy"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/secrets"
+	"testingdashboard/m/v2/tracing"
+	"testingdashboard/m/v2/variables"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Job and step results, matching the values GitHub reports.
+const (
+	ResultSuccess   = "success"
+	ResultFailure   = "failure"
+	ResultCancelled = "cancelled"
+	ResultSkipped   = "skipped"
+)
+
+// Options configures a Runner.
+type Options struct {
+	// Workspace is the directory steps run in. Defaults to the current directory.
+	Workspace string
+	// EventName and Event describe the triggering event. EventName defaults
+	// to "push".
+	EventName string
+	Event     map[string]any
+	// Inputs populates the inputs context.
+	Inputs map[string]any
+	// Env is added to the environment of every step.
+	Env map[string]string
+	// Secrets and Vars populate the secrets and vars contexts.
+	Secrets map[string]string
+	Vars    map[string]string
+	// Variables are the configuration variables of the organization,
+	// repository and environments, resolved into the vars context of
+	// each job by its environment. Vars take precedence.
+	Variables *variables.Set
+	// Contexts, if set, are contexts evaluated elsewhere, as a job that an
+	// agent takes from GitHub arrives with them. The entries of github
+	// override those the run derives; needs, matrix, strategy, inputs and
+	// vars replace the derived contexts. Others are ignored.
+	Contexts map[string]any
+	// SecretProviders are loaded at the start of each run into the
+	// secrets context, under Secrets, which take precedence.
+	SecretProviders []secrets.Provider
+	// Jobs restricts the run to these job IDs and the jobs they need.
+	Jobs []string
+	// Stdout receives the log of every step. Defaults to os.Stdout.
+	Stdout io.Writer
+	// Log receives the log as records, one per line. Defaults to the
+	// human format on Stdout.
+	Log logging.Sink
+	// Fetcher locates the actions steps use. Defaults to a resolve.GitFetcher
+	// rooted at Workspace that keeps actions in Cache.
+	Fetcher resolve.Fetcher
+	// Cache records the images built and pulled for actions, which runs
+	// prune with it daily. Defaults to resolve.DefaultStore().
+	Cache *resolve.Store
+	// Metrics, if set, records step and job durations, queue waits and,
+	// for the default Fetcher, action cache hits.
+	Metrics *metrics.Runner
+	// Tracer, if set, records a span for the workflow, each job leg and
+	// each step, and passes steps their span in TRACEPARENT. Spans join
+	// the trace of a remote parent in the context given to Run.
+	Tracer *tracing.Tracer
+	// Concurrency enforces concurrency groups. Runners sharing a Manager
+	// hold groups against each other. Defaults to one per Runner.
+	Concurrency *concurrency.Manager
+	// Endpoints is the GitHub instance the run imitates: the server_url,
+	// api_url and graphql_url of the github context and where actions are
+	// fetched from. Defaults to the instance of GITHUB_SERVER_URL or
+	// GITHUB_API_URL, then that of the origin remote, then github.com.
+	Endpoints endpoints.Endpoints
+	// Executors, if set, run steps with custom uses: schemes or in place
+	// of the runner, and hook every step.
+	Executors *Executors
+	// WASM limits the experimental actions with runs.using: wasm.
+	WASM WASMOptions
+	// Sandbox caps the resources and network access of steps.
+	Sandbox SandboxOptions
+	// NetPolicy, if set, sends the requests of every job through a proxy
+	// that records them and applies the policy.
+	NetPolicy *netpolicy.Policy
+	// MaxParallel caps how many job legs run at once across the workflow,
+	// on top of each job's strategy.max-parallel. Defaults to the number
+	// of CPUs; 1 runs legs one at a time. Parallel jobs share the
+	// workspace unless Isolate is set.
+	MaxParallel int
+	// Isolate runs each job leg in its own copy of the workspace as it was
+	// when the run started, as GitHub runs each job on a fresh machine, so
+	// that jobs only see each other's files through artifacts and
+	// x-persist.
+	Isolate bool
+	// ArtifactDir keeps what actions/upload-artifact uploads during a run,
+	// one directory per artifact, for actions/download-artifact in later
+	// jobs. Defaults to a directory removed when the run ends.
+	ArtifactDir string
+	// Compliance, if set, records every step that runs in a hash-chained
+	// audit log, with secrets and masked values redacted.
+	Compliance *compliance.Log
+	// Progress, if set, is told when job legs and steps start and finish,
+	// for live views of the run. Parallel legs call it concurrently.
+	Progress func(e ProgressEvent)
+}
+
+// Runner runs workflows locally.
+type Runner struct {
+	opts   Options
+	cgroup cgroupParent
+}
+
+// New returns a Runner configured by opts.
+func New(opts Options) *Runner {
+	if opts.EventName == "" {
+		opts.EventName = "push"
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Log == nil {
+		opts.Log = logging.Text(opts.Stdout)
+	}
+	if opts.Workspace == "" {
+		opts.Workspace, _ = os.Getwd()
+	}
+	if abs, err := filepath.Abs(opts.Workspace); err == nil {
+		opts.Workspace = abs
+	}
+	if opts.MaxParallel <= 0 {
+		opts.MaxParallel = runtime.NumCPU()
+	}
+	if opts.Concurrency == nil {
+		opts.Concurrency = &concurrency.Manager{}
+	}
+	if opts.Endpoints.Server == "" {
+		opts.Endpoints = defaultEndpoints(opts.Workspace)
+	}
+	if opts.Cache == nil {
+		opts.Cache, _ = resolve.DefaultStore()
+	}
+	if opts.Fetcher == nil {
+		f := &resolve.GitFetcher{Workspace: opts.Workspace, ServerURL: opts.Endpoints.Server}
+		if opts.Cache != nil {
+			f.CacheDir = opts.Cache.Dir
+		}
+		if opts.Metrics != nil {
+			f.CacheHit = opts.Metrics.ObserveCache
+		}
+		opts.Fetcher = f
+	}
+	return &Runner{opts: opts}
+}
+
+// Result is the outcome of running a workflow.
+type Result struct {
+	Conclusion string
+	Jobs       []*JobResult
+}
+
+// JobResult is the outcome of one job, aggregated over its matrix legs.
+type JobResult struct {
+	ID      string
+	Result  string
+	Outputs map[string]string
+	Legs    []*LegResult
+}
+
+// LegResult is the outcome of a single matrix combination of a job.
+type LegResult struct {
+	Name     string
+	Matrix   map[string]any
+	Result   string
+	Steps    []*StepResult
+	Outputs  map[string]string
+	Duration time.Duration
+	// ContinueOnError is set when the leg may fail without failing the job
+	// or cancelling other legs.
+	ContinueOnError bool
+	// Environment is the deployment environment the leg ran in, and
+	// EnvironmentURL the URL it deployed to, evaluated after its steps.
+	Environment    string
+	EnvironmentURL string
+	// Network lists the requests the leg's steps made under NetPolicy.
+	Network []netpolicy.Request
+}
+
+// StepResult is the outcome of one step.
+type StepResult struct {
+	ID         string
+	Name       string
+	ExitCode   int
+	Outcome    string
+	Conclusion string
+	Outputs    map[string]string
+	Duration   time.Duration
+	Err        error
+	// Limit names the limit that made the step fail, LimitMemory or
+	// LimitPIDs, if one did.
+	Limit string
+	// Attempts is how many times the step ran; more than once if x-retry
+	// retried it.
+	Attempts int
+	// SHA is the commit the step's action resolved to, for actions in
+	// repositories.
+	SHA string
+	// Steps holds the results of the steps of a composite action.
+	Steps []*StepResult
+}
+
+// Run executes wf and returns the result of every job that was considered.
+func (r *Runner) Run(ctx context.Context, wf *workflow.Workflow) (*Result, error) {
+	order, err := jobOrder(wf, r.opts.Jobs)
+	if err != nil {
+		return nil, err
+	}
+	run, err := r.newRun(ctx, wf)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(run.temp)
+
+	name := wf.Name
+	if name == "" {
+		name = filepath.Base(wf.Path)
+	}
+	ctx, span := r.opts.Tracer.Start(ctx, "workflow "+name, tracing.KindInternal)
+	span.SetAttribute("cicd.pipeline.name", name)
+	span.SetAttribute("github.event_name", r.opts.EventName)
+	result := &Result{Conclusion: ResultSuccess}
+	defer func() {
+		endSpan(span, result.Conclusion)
+	}()
+
+	group, cancelInProgress, err := concurrency.Evaluate(wf.Concurrency, restrict(run.jobValues(nil, nil), "concurrency"))
+	if err != nil {
+		return nil, err
+	}
+	log := newLogWriter(run.log, run.jobRecord(nil, ""))
+	ticket := r.opts.Concurrency.Enter(ctx, group, cancelInProgress)
+	defer ticket.Release()
+	if ticket.Pending() {
+		fmt.Fprintf(log, "Waiting for concurrency group %s\n", group)
+	}
+	if ctx, err = ticket.Wait(); err != nil {
+		fmt.Fprintln(log, err)
+		result.Conclusion = ResultCancelled
+		return result, nil
+	}
+	run.group = group
+	defer run.pruneCache(ctx)
+
+	for _, jr := range run.schedule(ctx, order) {
+		result.Jobs = append(result.Jobs, jr)
+		if jr.Result == ResultFailure || jr.Result == ResultCancelled {
+			result.Conclusion = jr.Result
+		}
+	}
+	var cancelled *concurrency.CancelledError
+	if errors.As(context.Cause(ctx), &cancelled) {
+		fmt.Fprintln(log, cancelled)
+	}
+	return result, nil
+}
+
+// endSpan records a job or step conclusion on span and ends it.
+func endSpan(span *tracing.Span, conclusion string) {
+	span.SetAttribute("cicd.pipeline.result", conclusion)
+	switch conclusion {
+	case ResultFailure, ResultCancelled:
+		span.SetStatus(tracing.StatusError, conclusion)
+	default:
+		span.SetStatus(tracing.StatusOK, "")
+	}
+	span.End()
+}
+
+// jobOrder returns the jobs to run in dependency order, restricted to the
+// selected jobs and everything they need.
+func jobOrder(wf *workflow.Workflow, selected []string) ([]string, error) {
+	g, err := graph.New(wf)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) > 0 {
+		if g, err = g.Select(selected...); err != nil {
+			return nil, err
+		}
+	}
+	return g.TopologicalOrder(), nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+// failFastError cancels the legs of a job after one of them failed.
+type failFastError struct {
+	leg string
+}
+
+func (e *failFastError) Error() string {
+	return fmt.Sprintf("fail-fast is enabled and %s failed", e.leg)
+}
+
+// schedule runs the jobs in order, each as soon as the jobs it needs have
+// finished, and returns their results in order. Their logs print in order
+// too.
+func (run *run) schedule(ctx context.Context, order []string) []*JobResult {
+	results := make([]*JobResult, len(order))
+	done := map[string]chan struct{}{}
+	for _, id := range order {
+		done[id] = make(chan struct{})
+	}
+	var wg sync.WaitGroup
+	for i, id := range order {
+		out := run.log.Section()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[id])
+			defer out.Close()
+			job := run.wf.Jobs[id]
+			for _, need := range job.Needs {
+				if ch := done[need]; ch != nil {
+					<-ch
+				}
+			}
+			results[i] = run.runJob(ctx, job, out)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// runLegs runs the legs of job, at most exp.MaxParallel at once, and
+// returns their results in matrix order. With fail-fast, a failing leg
+// cancels the others.
+func (run *run) runLegs(ctx context.Context, job *workflow.Job, exp *workflow.Expansion, ready time.Time, out *logSection) []*LegResult {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	sections := make([]*logSection, len(exp.Configs))
+	for i := range exp.Configs {
+		sections[i] = out.Section()
+	}
+	n := exp.MaxParallel
+	if n <= 0 {
+		n = len(exp.Configs)
+	}
+	sem := make(chan struct{}, n)
+	legs := make([]*LegResult, len(exp.Configs))
+	var wg sync.WaitGroup
+	for i, cfg := range exp.Configs {
+		acquired := false
+		select {
+		case sem <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+		}
+		var ff *failFastError
+		if errors.As(context.Cause(ctx), &ff) {
+			if acquired {
+				<-sem
+			}
+			name := legName(job, cfg.Matrix, &expr.Context{Values: restrict(run.jobValues(job, cfg.Matrix), "jobs", job.ID, "name")})
+			log := newLogWriter(sections[i], run.jobRecord(job, name))
+			fmt.Fprintf(log, "Cancelling %s: %v\n", name, ff)
+			log.Flush()
+			sections[i].Close()
+			legs[i] = &LegResult{Name: name, Matrix: cfg.Matrix, Result: ResultCancelled}
+			run.progress(ProgressEvent{JobID: job.ID, Job: name, Result: ResultCancelled})
+			continue
+		}
+		if !acquired {
+			sem <- struct{}{}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer sections[i].Close()
+			leg := run.runLeg(ctx, job, exp, cfg, ready, sections[i])
+			legs[i] = leg
+			if exp.FailFast && leg.Result == ResultFailure && !leg.ContinueOnError {
+				cancel(&failFastError{leg: leg.Name})
+			}
+		}()
+	}
+	wg.Wait()
+	return legs
+}
@@ -0,0 +1,404 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/docker"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+// serviceHealthPoll is how often a starting service's health is checked.
+const serviceHealthPoll = time.Second
+
+// jobServices are the service containers of a job leg and the network
+// they share with the job container and container steps.
+type jobServices struct {
+	client  *docker.Client
+	network string
+	// containers are the IDs of the started services, by service ID.
+	containers map[string]string
+}
+
+// startServices creates the job's network and starts its services on it,
+// as the hosted runner does: the job container and container steps reach
+// them by service ID and steps on the host through their published ports
+// on localhost. It records them in the job.services context. The caller
+// stops them, even on error.
+func (jr *jobRun) startServices(ctx context.Context) error {
+	if len(jr.job.Services) == 0 && jr.job.Container == nil {
+		return nil
+	}
+	client, err := jr.run.dockerClient()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("actions-%s-%d", sanitizeName(jr.job.ID), time.Now().UnixNano())
+	network, err := client.NetworkCreate(ctx, name, map[string]string{"actions-local": "true"})
+	if err != nil {
+		return fmt.Errorf("failed to create network: %w", err)
+	}
+	jr.services = &jobServices{client: client, network: network, containers: map[string]string{}}
+	jr.serviceContext = map[string]any{}
+
+	ids := make([]string, 0, len(jr.job.Services))
+	for id := range jr.job.Services {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := jr.startService(ctx, id, jr.job.Services[id], name); err != nil {
+			return fmt.Errorf("service %s: %w", id, err)
+		}
+	}
+	for _, id := range ids {
+		if cid, ok := jr.services.containers[id]; ok {
+			if err := jr.waitHealthy(ctx, id, cid); err != nil {
+				return fmt.Errorf("service %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (jr *jobRun) startService(ctx context.Context, id string, svc *workflow.Container, network string) error {
+	cfg, auth, err := jr.containerConfig(svc, "service "+id, "jobs", jr.job.ID, "services", id)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		// An image that evaluates to "" skips the service, which lets a
+		// matrix select services.
+		fmt.Fprintf(jr.log, "Skipping service %s: image is empty\n", id)
+		return nil
+	}
+	cfg.NetworkingConfig = &docker.NetworkingConfig{EndpointsConfig: map[string]*docker.EndpointSettings{
+		jr.services.network: {Aliases: []string{id}},
+	}}
+
+	fmt.Fprintf(jr.log, "Starting service %s (%s)\n", id, cfg.Image)
+	if err := jr.ensureImage(ctx, jr.services.client, cfg.Image, auth); err != nil {
+		return err
+	}
+	cid, err := jr.services.client.ContainerCreate(ctx, network+"-"+sanitizeName(id), cfg)
+	if err != nil {
+		return err
+	}
+	jr.services.containers[id] = cid
+	return jr.services.client.ContainerStart(ctx, cid)
+}
+
+// containerConfig evaluates a job container or service, found at path in
+// the workflow, into a container on the job's network. It returns a nil
+// config if the image evaluates to "". what names it in warnings.
+func (jr *jobRun) containerConfig(c *workflow.Container, what string, path ...string) (*docker.ContainerConfig, *docker.AuthConfig, error) {
+	at := func(key string) *expr.Context {
+		return &expr.Context{Values: restrict(jr.values(nil), append(path[:len(path):len(path)], key)...)}
+	}
+	ectx := at("image")
+	image, err := expr.Interpolate(c.Image, ectx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to evaluate image: %w", err)
+	}
+	if image == "" {
+		return nil, nil, nil
+	}
+	env, err := interpolateMap(c.Env, at("env"))
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := &docker.ContainerConfig{
+		Image:        image,
+		Env:          envList(env),
+		Labels:       map[string]string{"actions-local": "true"},
+		ExposedPorts: map[string]struct{}{},
+		HostConfig: docker.HostConfig{
+			NetworkMode:  jr.services.network,
+			PortBindings: map[string][]docker.PortBinding{},
+		},
+	}
+	for _, p := range c.Ports {
+		p, err := expr.Interpolate(p, ectx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to evaluate ports: %w", err)
+		}
+		port, binding, err := parsePort(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.ExposedPorts[port] = struct{}{}
+		cfg.HostConfig.PortBindings[port] = append(cfg.HostConfig.PortBindings[port], binding)
+	}
+	for _, v := range c.Volumes {
+		v, err := expr.Interpolate(v, ectx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to evaluate volumes: %w", err)
+		}
+		cfg.HostConfig.Binds = append(cfg.HostConfig.Binds, v)
+	}
+	options, err := expr.Interpolate(c.Options, ectx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to evaluate options: %w", err)
+	}
+	ignored, err := applyContainerOptions(cfg, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, opt := range ignored {
+		fmt.Fprintf(jr.log, "Warning: %s: ignoring unsupported option %s\n", what, opt)
+	}
+
+	var auth *docker.AuthConfig
+	if cred := c.Credentials; cred != nil {
+		credCtx := at("credentials")
+		auth = &docker.AuthConfig{ServerAddress: registryHost(image)}
+		if auth.Username, err = expr.Interpolate(cred.Username, credCtx); err != nil {
+			return nil, nil, fmt.Errorf("failed to evaluate credentials: %w", err)
+		}
+		if auth.Password, err = expr.Interpolate(cred.Password, credCtx); err != nil {
+			return nil, nil, fmt.Errorf("failed to evaluate credentials: %w", err)
+		}
+		jr.masks.Add(auth.Password)
+	}
+	return cfg, auth, nil
+}
+
+// waitHealthy waits until the service is healthy, or running if it has no
+// health check, and then records its published ports.
+func (jr *jobRun) waitHealthy(ctx context.Context, id, cid string) error {
+	client := jr.services.client
+	for {
+		info, err := client.ContainerInspect(ctx, cid)
+		if err != nil {
+			return err
+		}
+		status := "running"
+		if h := info.State.Health; h != nil {
+			status = h.Status
+		}
+		switch {
+		case !info.State.Running:
+			jr.serviceLogs(id, cid)
+			return fmt.Errorf("container exited with code %d", info.State.ExitCode)
+		case status == "unhealthy":
+			jr.serviceLogs(id, cid)
+			return fmt.Errorf("container is unhealthy")
+		case status == "healthy" || status == "running":
+			ports := map[string]any{}
+			for port, bindings := range info.NetworkSettings.Ports {
+				if len(bindings) > 0 {
+					// job.services.<id>.ports is keyed by the bare port.
+					ports[strings.TrimSuffix(port, "/tcp")] = bindings[0].HostPort
+				}
+			}
+			jr.serviceContext[id] = map[string]any{
+				"id":      cid,
+				"network": jr.services.network,
+				"ports":   ports,
+			}
+			fmt.Fprintf(jr.log, "Service %s is %s\n", id, status)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(serviceHealthPoll):
+		}
+	}
+}
+
+// serviceLogs logs the end of a failed service's output.
+func (jr *jobRun) serviceLogs(id, cid string) {
+	fmt.Fprintf(jr.log, "Last output of service %s:\n", id)
+	jr.services.client.ContainerLogsTail(context.Background(), cid, 50, jr.log, jr.log)
+}
+
+// stopContainers removes the job container, the services and their
+// network.
+func (jr *jobRun) stopContainers() {
+	if jr.services == nil {
+		return
+	}
+	ctx := context.Background()
+	client := jr.services.client
+	if jr.container != nil {
+		if err := client.ContainerRemove(ctx, jr.container.id, true); err != nil && !docker.IsNotFound(err) {
+			fmt.Fprintf(jr.log, "Warning: failed to remove job container: %v\n", err)
+		}
+		jr.container = nil
+	}
+	for id, cid := range jr.services.containers {
+		if err := client.ContainerRemove(ctx, cid, true); err != nil && !docker.IsNotFound(err) {
+			fmt.Fprintf(jr.log, "Warning: failed to remove service %s: %v\n", id, err)
+		}
+	}
+	if err := client.NetworkRemove(ctx, jr.services.network); err != nil && !docker.IsNotFound(err) {
+		fmt.Fprintf(jr.log, "Warning: failed to remove network: %v\n", err)
+	}
+	jr.services = nil
+}
+
+// parsePort parses an entry of ports: CONTAINER, HOST:CONTAINER or
+// IP:HOST:CONTAINER, each with an optional /tcp or /udp. A bare container
+// port is published on a free host port.
+func parsePort(s string) (string, docker.PortBinding, error) {
+	var binding docker.PortBinding
+	spec, proto, _ := strings.Cut(strings.TrimSpace(s), "/")
+	if proto == "" {
+		proto = "tcp"
+	}
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+	case 2:
+		binding.HostPort = parts[0]
+	case 3:
+		binding.HostIP, binding.HostPort = parts[0], parts[1]
+	default:
+		return "", binding, fmt.Errorf("invalid port %q", s)
+	}
+	container := parts[len(parts)-1]
+	if _, err := strconv.Atoi(container); err != nil || (proto != "tcp" && proto != "udp") {
+		return "", binding, fmt.Errorf("invalid port %q", s)
+	}
+	return container + "/" + proto, binding, nil
+}
+
+// containerOptions are the docker create flags of options that matter for
+// services, and whether each takes a value.
+var containerOptions = map[string]bool{
+	"--health-cmd": true, "--health-interval": true, "--health-timeout": true,
+	"--health-start-period": true, "--health-retries": true, "--no-healthcheck": false,
+	"-e": true, "--env": true, "-h": true, "--hostname": true, "-u": true, "--user": true,
+	"--entrypoint": true, "-w": true, "--workdir": true,
+	"-m": true, "--memory": true, "--cpus": true, "--pids-limit": true,
+}
+
+// applyContainerOptions applies the options of a container to cfg and
+// returns the flags it ignores.
+func applyContainerOptions(cfg *docker.ContainerConfig, options string) ([]string, error) {
+	args, err := splitArgs(options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	var ignored []string
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		if !strings.HasPrefix(flag, "-") {
+			return nil, fmt.Errorf("invalid options: unexpected %q", args[i])
+		}
+		takesValue, known := containerOptions[flag]
+		if !known {
+			// Assume an unknown flag takes a value unless another flag
+			// follows it.
+			takesValue = i+1 < len(args) && !strings.HasPrefix(args[i+1], "-")
+			ignored = append(ignored, flag)
+		}
+		if takesValue && !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("invalid options: %s needs a value", flag)
+			}
+			i++
+			value = args[i]
+		}
+		if known {
+			if err := applyContainerOption(cfg, flag, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ignored, nil
+}
+
+func applyContainerOption(cfg *docker.ContainerConfig, flag, value string) error {
+	health := func() *docker.Healthcheck {
+		if cfg.Healthcheck == nil {
+			cfg.Healthcheck = &docker.Healthcheck{}
+		}
+		return cfg.Healthcheck
+	}
+	duration := func(dst *int64) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", flag, value)
+		}
+		*dst = int64(d)
+		return nil
+	}
+	switch flag {
+	case "--health-cmd":
+		health().Test = []string{"CMD-SHELL", value}
+	case "--health-interval":
+		return duration(&health().Interval)
+	case "--health-timeout":
+		return duration(&health().Timeout)
+	case "--health-start-period":
+		return duration(&health().StartPeriod)
+	case "--health-retries":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", flag, value)
+		}
+		health().Retries = n
+	case "--no-healthcheck":
+		cfg.Healthcheck = &docker.Healthcheck{Test: []string{"NONE"}}
+	case "-e", "--env":
+		cfg.Env = append(cfg.Env, value)
+	case "-h", "--hostname":
+		cfg.Hostname = value
+	case "-u", "--user":
+		cfg.User = value
+	case "--entrypoint":
+		cfg.Entrypoint = []string{value}
+	case "-w", "--workdir":
+		cfg.WorkingDir = value
+	case "-m", "--memory":
+		n, err := ParseMemory(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", flag, value)
+		}
+		cfg.HostConfig.Memory = n
+	case "--cpus":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid %s %q", flag, value)
+		}
+		cfg.HostConfig.NanoCPUs = int64(n * 1e9)
+	case "--pids-limit":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", flag, value)
+		}
+		cfg.HostConfig.PidsLimit = &n
+	}
+	return nil
+}
+
+// registryHost returns the registry of an image reference, or "" for
+// Docker Hub.
+func registryHost(image string) string {
+	host, _, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// shellSpec describes how to invoke a script file.
+type shellSpec struct {
+	// template is the command line with {0} standing for the script path.
+	// Quoted arguments are kept together.
+	template string
+	ext      string
+	// powershell scripts stop at the first error and exit with the code
+	// of the last native command, as on the hosted runner.
+	powershell bool
+	// bom writes the script with a byte order mark, which Windows
+	// PowerShell needs to read it as UTF-8.
+	bom bool
+	// crlf writes the script with Windows line endings, without which cmd
+	// misreads labels and multi-line blocks.
+	crlf bool
+}
+
+// command returns the argv that runs the script at scriptPath.
+func (s shellSpec) command(scriptPath string) ([]string, error) {
+	fields, err := splitArgs(s.template)
+	if err != nil || len(fields) == 0 {
+		return nil, fmt.Errorf("invalid shell %q", s.template)
+	}
+	for i, f := range fields {
+		fields[i] = strings.ReplaceAll(f, "{0}", scriptPath)
+	}
+	if fields[0] == "%ComSpec%" {
+		fields[0] = os.Getenv("ComSpec")
+		if fields[0] == "" {
+			fields[0] = "cmd.exe"
+		}
+	}
+	return fields, nil
+}
+
+// script returns the contents of the script file for a run: step.
+func (s shellSpec) script(run string) []byte {
+	if s.powershell {
+		run = "$ErrorActionPreference = 'stop'\n" + run + "\nif ((Test-Path -LiteralPath variable:\\LASTEXITCODE)) { exit $LASTEXITCODE }\n"
+	}
+	if s.crlf {
+		run = strings.ReplaceAll(strings.ReplaceAll(run, "\r\n", "\n"), "\n", "\r\n")
+	}
+	if s.bom {
+		return append([]byte("\ufeff"), run...)
+	}
+	return []byte(run)
+}
+
+// shells are the hosted runner's commands for the shell keywords.
+var shells = map[string]shellSpec{
+	"bash":       {template: "bash --noprofile --norc -eo pipefail {0}", ext: ".sh"},
+	"sh":         {template: "sh -e {0}", ext: ".sh"},
+	"python":     {template: "python {0}", ext: ".py"},
+	"pwsh":       {template: `pwsh -command ". '{0}'"`, ext: ".ps1", powershell: true},
+	"powershell": {template: `powershell -command ". '{0}'"`, ext: ".ps1", powershell: true, bom: true},
+	"cmd":        {template: `%ComSpec% /D /E:ON /V:OFF /S /C "CALL "{0}""`, ext: ".cmd", crlf: true},
+}
+
+// defaultBash is used for steps without a shell where bash is installed.
+// Unlike shell: bash it does not set pipefail.
+var defaultBash = shellSpec{template: "bash -e {0}", ext: ".sh"}
+
+// hostInstalled reports whether program is on this machine's PATH.
+func hostInstalled(program string) bool {
+	_, err := exec.LookPath(program)
+	return err == nil
+}
+
+// resolveShell maps a step's shell key to a command. A step without a shell
+// runs bash -e, or sh -e where bash is missing, and pwsh, or PowerShell
+// where pwsh is missing, on Windows. installed reports whether a program
+// is available where the step runs. A custom shell is a command line
+// containing {0}; its script is passed as is, with the extension of the
+// shell its program names, if any.
+func resolveShell(shell string, windows bool, installed func(string) bool) (shellSpec, error) {
+	switch {
+	case shell == "" && windows:
+		if installed("pwsh") {
+			return shells["pwsh"], nil
+		}
+		return shells["powershell"], nil
+	case shell == "":
+		if installed("bash") {
+			return defaultBash, nil
+		}
+		return shells["sh"], nil
+	}
+	if shell == "python" && !installed("python") && installed("python3") {
+		// macOS and many Linux distributions only install python3.
+		return shellSpec{template: "python3 {0}", ext: ".py"}, nil
+	}
+	if spec, ok := shells[shell]; ok {
+		return spec, nil
+	}
+	if !strings.Contains(shell, "{0}") {
+		return shellSpec{}, fmt.Errorf("unsupported shell %q: use one of bash, sh, pwsh, powershell, python and cmd, or a command containing {0}", shell)
+	}
+	spec := shellSpec{template: shell}
+	if fields, err := splitArgs(shell); err == nil && len(fields) > 0 {
+		program := strings.ToLower(strings.TrimSuffix(filepath.Base(fields[0]), ".exe"))
+		if known, ok := shells[program]; ok {
+			spec.ext = known.ext
+		}
+	}
+	return spec, nil
+}
@@ -0,0 +1,578 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/compliance"
+	"testingdashboard/m/v2/envfile"
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/expr/hashfiles"
+	"testingdashboard/m/v2/logging"
+	"testingdashboard/m/v2/tracing"
+	"testingdashboard/m/v2/workflow"
+)
+
+// processWaitDelay bounds how long a killed step process is waited for.
+const processWaitDelay = 5 * time.Second
+
+// stepFiles are the per-step files exposed through GITHUB_ENV and friends.
+type stepFiles struct {
+	env, output, path, summary, state string
+}
+
+func (jr *jobRun) newStepFiles() (*stepFiles, error) {
+	jr.stepSeq++
+	dir := filepath.Join(jr.temp, fmt.Sprintf("step-%d", jr.stepSeq))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	files := &stepFiles{
+		env:     filepath.Join(dir, "env"),
+		output:  filepath.Join(dir, "output"),
+		path:    filepath.Join(dir, "path"),
+		summary: filepath.Join(dir, "summary.md"),
+		state:   filepath.Join(dir, "state"),
+	}
+	for _, f := range []string{files.env, files.output, files.path, files.summary, files.state} {
+		if err := os.WriteFile(f, nil, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// reset empties the files for another attempt of the step.
+func (f *stepFiles) reset() error {
+	for _, name := range []string{f.env, f.output, f.path, f.summary, f.state} {
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stepDisplayName(step *workflow.Step, index int) string {
+	switch {
+	case step.Name != "":
+		return step.Name
+	case step.Uses != "":
+		return "Run " + step.Uses
+	case step.Run != "":
+		line, _, _ := strings.Cut(strings.TrimSpace(step.Run), "\n")
+		return "Run " + line
+	}
+	return fmt.Sprintf("step %d", index+1)
+}
+
+// stepPath locates a key of the step at index in the workflow or, inside a
+// composite action, in its metadata.
+func (jr *jobRun) stepPath(index int, key string) []string {
+	if jr.composite != nil {
+		return []string{"runs", "steps", strconv.Itoa(index), key}
+	}
+	return []string{"jobs", jr.job.ID, "steps", strconv.Itoa(index), key}
+}
+
+func (jr *jobRun) runStep(ctx context.Context, step *workflow.Step, index int) *StepResult {
+	start := time.Now()
+	sr := &StepResult{ID: step.ID, Outputs: map[string]string{}}
+	if sr.ID == "" {
+		sr.ID = fmt.Sprintf("__step%d", index)
+	}
+	var entry *compliance.Entry
+	defer func() {
+		sr.Duration = time.Since(start)
+		jr.run.r.opts.Metrics.ObserveStep(jr.job.ID, sr.Name, sr.Conclusion, sr.Duration)
+		jr.audit(entry, sr)
+		jr.steps[sr.ID] = map[string]any{
+			"outputs":    stringMap(sr.Outputs),
+			"outcome":    sr.Outcome,
+			"conclusion": sr.Conclusion,
+		}
+	}()
+
+	hasher := &hashfiles.Hasher{Workspace: jr.workspace}
+	ectx := &expr.Context{Values: restrict(jr.values(nil), jr.stepPath(index, "name")...), Status: jr.status, HashFiles: hasher.Hash}
+	sr.Name = stepDisplayName(step, index)
+	if name, err := expr.Interpolate(sr.Name, ectx); err == nil {
+		sr.Name, _, _ = strings.Cut(name, "\n")
+		sr.Name = jr.masks.Mask(sr.Name)
+	}
+	defer jr.stepProgress(sr)()
+	defer jr.legLog.setStep(sr)()
+	ctx, endTrace := jr.traceStep(ctx, step, sr)
+	defer endTrace()
+
+	cond := &expr.Context{Values: restrict(jr.values(nil), jr.stepPath(index, "if")...), Status: jr.status, HashFiles: hasher.Hash}
+	ok, err := expr.EvaluateCondition(step.If, cond)
+	if err != nil {
+		fmt.Fprintf(jr.log, "Error evaluating condition of %q: %v\n", sr.Name, err)
+		jr.fail(sr, err)
+		return sr
+	}
+	if !ok {
+		fmt.Fprintf(jr.log, "Skipping %q: condition not met\n", sr.Name)
+		sr.Outcome, sr.Conclusion = ResultSkipped, ResultSkipped
+		return sr
+	}
+
+	env := map[string]string{}
+	for k, v := range step.Env {
+		value, err := expr.Interpolate(v, ectx)
+		if err != nil {
+			fmt.Fprintf(jr.log, "Error evaluating env %s of %q: %v\n", k, sr.Name, err)
+			jr.fail(sr, err)
+			return sr
+		}
+		env[k] = value
+	}
+	limits := &expr.Context{Values: restrict(jr.values(env), jr.stepPath(index, "continue-on-error")...), Status: jr.status, HashFiles: hasher.Hash}
+	continueOnError, err := evalBool(step.ContinueOnError, limits)
+	if err != nil {
+		fmt.Fprintf(jr.log, "Error evaluating continue-on-error of %q: %v\n", sr.Name, err)
+		jr.fail(sr, err)
+		return sr
+	}
+	timeout, err := evalMinutes(step.TimeoutMinutes, limits)
+	if err != nil {
+		fmt.Fprintf(jr.log, "Error evaluating timeout-minutes of %q: %v\n", sr.Name, err)
+		jr.fail(sr, err)
+		return sr
+	}
+	stepLimits, err := parseStepLimits(step.Limits)
+	if err != nil {
+		fmt.Fprintf(jr.log, "Error in x-limits of %q: %v\n", sr.Name, err)
+		jr.fail(sr, err)
+		return sr
+	}
+	retry, err := ParseRetry(step.Retry)
+	if err != nil {
+		fmt.Fprintf(jr.log, "Error in x-retry of %q: %v\n", sr.Name, err)
+		jr.fail(sr, err)
+		return sr
+	}
+	// Steps of a composite action stay within the limits of the step
+	// using it.
+	parentLimits := jr.limits
+	jr.limits = jr.limits.tighten(stepLimits)
+	defer func() { jr.limits = parentLimits }()
+	ectx = &expr.Context{Values: restrict(jr.values(env), jr.stepPath(index, "run")...), Status: jr.status, HashFiles: hasher.Hash}
+	entry = jr.auditEntry(step, sr, ectx, nil)
+
+	fmt.Fprintf(jr.log, "==> %s\n", sr.Name)
+	defer jr.registerProxyStep(sr.Name)()
+	// Route everything the step prints through the command processor.
+	cw := jr.newCommandWriter(sr)
+	log := jr.log
+	jr.log = cw
+	defer func() {
+		cw.Flush()
+		jr.log = log
+	}()
+
+	files, err := jr.newStepFiles()
+	if err != nil {
+		jr.fail(sr, err)
+		return sr
+	}
+
+	status := jr.status
+	hooks := jr.run.r.opts.Executors.stepHooks()
+	ps, x, err := jr.executor(step, sr, env, files, ectx)
+	for _, h := range hooks {
+		if err == nil && h.Before != nil {
+			err = h.Before(ctx, ps)
+		}
+	}
+	if err == nil {
+		// timeout-minutes applies to each attempt.
+		err = retry.Do(ctx, cw, func(ctx context.Context, n int, log io.Writer) error {
+			sr.Attempts = n
+			if n > 1 {
+				if err := files.reset(); err != nil {
+					return err
+				}
+			}
+			jr.log = log
+			if ps != nil {
+				ps.Log = log
+			}
+			return jr.runStepAttempt(ctx, step, sr, env, files, ectx, x, ps, timeout)
+		})
+		jr.log = cw
+	}
+
+	if applyErr := jr.applyStepFiles(files, sr); applyErr != nil && err == nil {
+		err = applyErr
+	}
+	jr.conclude(ctx, sr, err)
+	if continueOnError && sr.Outcome == ResultFailure {
+		// The step failed but is allowed to: steps.<id>.outcome says so
+		// while its conclusion and the job status stay successful.
+		sr.Conclusion = ResultSuccess
+		jr.status = status
+	}
+	for i := len(hooks) - 1; i >= 0 && ps != nil; i-- {
+		if hooks[i].After != nil {
+			hooks[i].After(ctx, ps, sr)
+		}
+	}
+	return sr
+}
+
+// runStepAttempt runs a step once, with x if an executor runs it, within
+// timeout if it is not zero.
+func (jr *jobRun) runStepAttempt(ctx context.Context, step *workflow.Step, sr *StepResult, env map[string]string, files *stepFiles, ectx *expr.Context, x StepExecutor, ps *Step, timeout time.Duration) error {
+	stepCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeoutCause(ctx, timeout, errStepTimeout)
+		defer cancel()
+	}
+	var err error
+	switch {
+	case x != nil:
+		err = x.ExecuteStep(stepCtx, ps)
+	case step.Run != "":
+		err = jr.runScript(stepCtx, step, env, files, ectx)
+	case step.Uses != "":
+		err = jr.runAction(stepCtx, step, sr, env, files, ectx)
+	default:
+		err = fmt.Errorf("step must define run or uses")
+	}
+	if err != nil && context.Cause(stepCtx) == errStepTimeout && ctx.Err() == nil {
+		err = fmt.Errorf("the step timed out after %s", timeout)
+	}
+	return err
+}
+
+// executor returns the step as executors see it and the executor that
+// runs it, or nil if the runner does. Without executors it returns nils,
+// unless the runner stands in for the step's action.
+func (jr *jobRun) executor(step *workflow.Step, sr *StepResult, env map[string]string, files *stepFiles, ectx *expr.Context) (*Step, StepExecutor, error) {
+	execs := jr.run.r.opts.Executors
+	builtin := jr.builtinExecutor(step.Uses)
+	if execs == nil && builtin == nil {
+		return nil, nil, nil
+	}
+	ps := &Step{
+		Step:      step,
+		ID:        sr.ID,
+		Name:      sr.Name,
+		JobID:     jr.job.ID,
+		Env:       jr.stepEnv(env, files),
+		Workspace: jr.workspace,
+		Log:       jr.log,
+		Outputs:   sr.Outputs,
+		Limits:    jr.limits,
+	}
+	var err error
+	if ps.Uses, err = expr.Interpolate(step.Uses, ectx); err != nil {
+		return nil, nil, err
+	}
+	if ps.Run, err = expr.Interpolate(step.Run, ectx); err != nil {
+		return nil, nil, err
+	}
+	if ps.With, err = interpolateMap(step.With, ectx); err != nil {
+		return nil, nil, err
+	}
+	x, err := execs.lookup(ps)
+	if err != nil {
+		return nil, nil, err
+	}
+	if x == nil {
+		x = builtin
+	}
+	return ps, x, nil
+}
+
+// traceStep starts the span of a step, which becomes the parent of what
+// the step runs. Call the returned function when the step ends.
+func (jr *jobRun) traceStep(ctx context.Context, step *workflow.Step, sr *StepResult) (context.Context, func()) {
+	ctx, span := jr.run.r.opts.Tracer.Start(ctx, "step "+sr.Name, tracing.KindInternal)
+	span.SetAttribute("github.step.name", sr.Name)
+	if step.ID != "" {
+		span.SetAttribute("github.step.id", step.ID)
+	}
+	if step.Uses != "" {
+		span.SetAttribute("github.step.uses", step.Uses)
+	}
+	parent := jr.traceparent
+	if tp := span.TraceParent(); tp != "" {
+		jr.traceparent = tp
+	}
+	return ctx, func() {
+		jr.traceparent = parent
+		if sr.ExitCode != 0 {
+			span.SetAttribute("process.exit.code", sr.ExitCode)
+		}
+		endSpan(span, sr.Conclusion)
+	}
+}
+
+// conclude records the outcome of a step that ran and ended with err.
+func (jr *jobRun) conclude(ctx context.Context, sr *StepResult, err error) {
+	var exitErr interface{ ExitCode() int }
+	var limitErr *limitError
+	switch {
+	case err == nil:
+		sr.Outcome, sr.Conclusion = ResultSuccess, ResultSuccess
+	case ctx.Err() != nil:
+		sr.Err = ctx.Err()
+		sr.Outcome, sr.Conclusion = ResultCancelled, ResultCancelled
+		jr.status = expr.StatusCancelled
+	case errors.As(err, &limitErr):
+		sr.ExitCode, sr.Limit = limitErr.code, limitErr.limit
+		jr.logf(logging.LevelError, "Error: %v", err)
+		jr.fail(sr, err)
+	case errors.As(err, &exitErr):
+		sr.ExitCode = exitErr.ExitCode()
+		jr.logf(logging.LevelError, "Error: process completed with exit code %d", sr.ExitCode)
+		jr.fail(sr, err)
+	default:
+		jr.logf(logging.LevelError, "Error: %v", err)
+		jr.fail(sr, err)
+	}
+}
+
+// logf logs a line of the runner's own at level.
+func (jr *jobRun) logf(level logging.Level, format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	if ew, ok := jr.log.(entryWriter); ok {
+		ew.writeEntry(level, text, nil)
+		return
+	}
+	fmt.Fprintln(jr.log, text)
+}
+
+// fail records a failed step and marks the job as failing.
+func (jr *jobRun) fail(sr *StepResult, err error) {
+	sr.Err = err
+	if sr.ExitCode == 0 {
+		sr.ExitCode = 1
+	}
+	sr.Outcome, sr.Conclusion = ResultFailure, ResultFailure
+	jr.status = expr.StatusFailure
+}
+
+func (jr *jobRun) runScript(ctx context.Context, step *workflow.Step, env map[string]string, files *stepFiles, ectx *expr.Context) error {
+	script, err := expr.Interpolate(step.Run, ectx)
+	if err != nil {
+		return err
+	}
+	shell := step.Shell
+	workdir := step.WorkingDirectory
+	defaults := []*workflow.Defaults{jr.job.Defaults, jr.run.wf.Defaults}
+	if jr.composite != nil {
+		// Composite actions do not inherit defaults and must name a shell.
+		if shell == "" {
+			return fmt.Errorf("run steps in composite actions must set shell")
+		}
+		defaults = nil
+	}
+	for _, d := range defaults {
+		if d == nil || d.Run == nil {
+			continue
+		}
+		if shell == "" {
+			shell = d.Run.Shell
+		}
+		if workdir == "" {
+			workdir = d.Run.WorkingDirectory
+		}
+	}
+	if workdir, err = expr.Interpolate(workdir, ectx); err != nil {
+		return err
+	}
+	if shell, err = expr.Interpolate(shell, ectx); err != nil {
+		return err
+	}
+	windows, installed := hostWindows, hostInstalled
+	if jc := jr.container; jc != nil {
+		// Job containers run Linux images; bash was looked for at start.
+		windows = false
+		installed = func(name string) bool { return name != "bash" || jc.hasBash }
+	}
+	spec, err := resolveShell(shell, windows, installed)
+	if err != nil {
+		return err
+	}
+	scriptPath := filepath.Join(jr.temp, fmt.Sprintf("script-%d%s", time.Now().UnixNano(), spec.ext))
+	if err := os.WriteFile(scriptPath, spec.script(script), 0o755); err != nil {
+		return err
+	}
+
+	if jc := jr.container; jc != nil {
+		// The working directory is a path in the container.
+		dir := jc.workspace
+		if workdir != "" {
+			dir = path.Join(dir, workdir)
+			if path.IsAbs(workdir) {
+				dir = workdir
+			}
+		}
+		argv, err := spec.command(jc.mustContainerPath(scriptPath))
+		if err != nil {
+			return err
+		}
+		return jr.execInContainer(ctx, argv, dir, jr.jobContainerEnv(env, files))
+	}
+
+	dir := jr.workspace
+	if workdir != "" {
+		if filepath.IsAbs(workdir) {
+			dir = workdir
+		} else {
+			dir = filepath.Join(dir, workdir)
+		}
+	}
+
+	argv, err := spec.command(scriptPath)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = jr.processEnv(env, files)
+	cmd.Stdout = jr.log
+	cmd.Stderr = jr.log
+	// Children of a killed step may hold its output open; stop waiting
+	// for them when the step is cancelled or times out.
+	cmd.WaitDelay = processWaitDelay
+	return jr.runProcess(cmd)
+}
+
+// stepEnv returns the variables a step sees regardless of where it runs:
+// the runner defaults, then the run, job and step env in increasing order of
+// precedence.
+func (jr *jobRun) stepEnv(stepEnv map[string]string, files *stepFiles) map[string]string {
+	github := jr.run.github
+	env := map[string]string{
+		"CI":                  "true",
+		"GITHUB_ACTIONS":      "true",
+		"GITHUB_WORKSPACE":    jr.workspace,
+		"GITHUB_EVENT_NAME":   expr.ToString(github["event_name"]),
+		"GITHUB_EVENT_PATH":   jr.run.eventPath,
+		"GITHUB_SHA":          expr.ToString(github["sha"]),
+		"GITHUB_REF":          expr.ToString(github["ref"]),
+		"GITHUB_REF_NAME":     expr.ToString(github["ref_name"]),
+		"GITHUB_REF_TYPE":     expr.ToString(github["ref_type"]),
+		"GITHUB_HEAD_REF":     expr.ToString(github["head_ref"]),
+		"GITHUB_BASE_REF":     expr.ToString(github["base_ref"]),
+		"GITHUB_REPOSITORY":   expr.ToString(github["repository"]),
+		"GITHUB_ACTOR":        expr.ToString(github["actor"]),
+		"GITHUB_RUN_ID":       expr.ToString(github["run_id"]),
+		"GITHUB_RUN_NUMBER":   expr.ToString(github["run_number"]),
+		"GITHUB_RUN_ATTEMPT":  expr.ToString(github["run_attempt"]),
+		"GITHUB_WORKFLOW":     expr.ToString(github["workflow"]),
+		"GITHUB_SERVER_URL":   expr.ToString(github["server_url"]),
+		"GITHUB_API_URL":      expr.ToString(github["api_url"]),
+		"GITHUB_GRAPHQL_URL":  expr.ToString(github["graphql_url"]),
+		"GITHUB_JOB":          jr.job.ID,
+		"GITHUB_ENV":          files.env,
+		"GITHUB_OUTPUT":       files.output,
+		"GITHUB_PATH":         files.path,
+		"GITHUB_STEP_SUMMARY": files.summary,
+		"GITHUB_STATE":        files.state,
+		"RUNNER_OS":           runnerOS(),
+		"RUNNER_ARCH":         runnerArch(),
+		"RUNNER_TEMP":         jr.temp,
+		"RUNNER_TOOL_CACHE":   jr.run.toolCacheDir(),
+		"RUNNER_NAME":         "local",
+	}
+	if jr.traceparent != "" {
+		env["TRACEPARENT"] = jr.traceparent
+	}
+	for k, v := range jr.run.r.opts.Env {
+		env[k] = v
+	}
+	for k, v := range jr.env {
+		env[k] = v
+	}
+	if jr.composite != nil {
+		for k, v := range jr.composite.env {
+			env[k] = v
+		}
+		env["GITHUB_ACTION_PATH"] = jr.composite.dir
+	}
+	for k, v := range stepEnv {
+		env[k] = v
+	}
+	jr.setProxyEnv(env, "127.0.0.1")
+	return env
+}
+
+// processEnv builds the environment of a step process on the host.
+func (jr *jobRun) processEnv(stepEnv map[string]string, files *stepFiles) []string {
+	env := hostEnviron()
+	for k, v := range jr.stepEnv(stepEnv, files) {
+		setEnv(env, k, v)
+	}
+	if len(jr.path) > 0 {
+		path := envName(env, "PATH")
+		env[path] = strings.Join(jr.path, string(os.PathListSeparator)) + string(os.PathListSeparator) + env[path]
+	}
+	return envList(env)
+}
+
+// applyStepFiles reads the files a step may have written and applies them
+// to the job.
+func (jr *jobRun) applyStepFiles(files *stepFiles, sr *StepResult) error {
+	vars, err := envfile.Read(files.env)
+	if err != nil {
+		return fmt.Errorf("failed to read GITHUB_ENV: %w", err)
+	}
+	for _, e := range vars {
+		if err := envfile.CheckEnv(e.Name); err != nil {
+			jr.logf(logging.LevelWarning, "Warning: %v", err)
+			continue
+		}
+		jr.env[e.Name] = e.Value
+	}
+	outputs, err := envfile.Read(files.output)
+	if err != nil {
+		return fmt.Errorf("failed to read GITHUB_OUTPUT: %w", err)
+	}
+	for _, e := range outputs {
+		sr.Outputs[e.Name] = e.Value
+	}
+	state, err := envfile.Read(files.state)
+	if err != nil {
+		return fmt.Errorf("failed to read GITHUB_STATE: %w", err)
+	}
+	for _, e := range state {
+		jr.stepState(sr)[e.Name] = e.Value
+	}
+	added, err := envfile.ReadPath(files.path)
+	if err != nil {
+		return fmt.Errorf("failed to read GITHUB_PATH: %w", err)
+	}
+	// Later additions take precedence, as on the hosted runner.
+	jr.path = envfile.Prepend(jr.path, added)
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/wasm"
+	"testingdashboard/m/v2/workflow"
+)
+
+// WASMOptions limit actions with runs.using: wasm.
+type WASMOptions struct {
+	// Deny lists the access the runner refuses such actions, such as
+	// workspace; an action asking for it fails.
+	Deny []string
+	// MemoryLimit caps the memory of each module in bytes. Defaults to
+	// 256 MiB.
+	MemoryLimit int64
+}
+
+// runWASMAction runs the module of a wasm action. It sees its own files,
+// the event and step files, and the directories its runs.access lists, at
+// their host paths so the GITHUB_* variables hold.
+func (jr *jobRun) runWASMAction(ctx context.Context, dir string, uses *workflow.Uses, meta *metadata.Action, inputs, stepEnv map[string]string, sr *StepResult, files *stepFiles) error {
+	opts := jr.run.r.opts.WASM
+	module := filepath.Join(dir, filepath.FromSlash(meta.Runs.Main))
+	if rel, err := filepath.Rel(dir, module); err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("runs.main %s is outside the action", meta.Runs.Main)
+	}
+
+	env := map[string]string{}
+	for k, v := range jr.stepEnv(stepEnv, files) {
+		env[k] = v
+	}
+	for k, v := range jr.stepState(sr) {
+		env["STATE_"+k] = v
+	}
+	for k, v := range inputEnv(inputs) {
+		env[k] = v
+	}
+	env["GITHUB_ACTION_PATH"] = dir
+	if uses.Kind == workflow.UsesRepository {
+		env["GITHUB_ACTION_REPOSITORY"] = uses.Repository()
+		env["GITHUB_ACTION_REF"] = uses.Ref
+	}
+
+	mounts := []wasm.Mount{
+		{Host: dir, ReadOnly: true},
+		{Host: filepath.Dir(jr.run.eventPath), ReadOnly: true},
+		{Host: filepath.Dir(files.env)},
+	}
+	for _, access := range meta.Runs.Access {
+		if slices.Contains(opts.Deny, access) || (access == metadata.AccessWorkspaceReadOnly && slices.Contains(opts.Deny, metadata.AccessWorkspace)) {
+			return fmt.Errorf("the runner does not grant wasm actions %s access", access)
+		}
+		switch access {
+		case metadata.AccessWorkspace, metadata.AccessWorkspaceReadOnly:
+			mounts = append(mounts, wasm.Mount{Host: jr.workspace, ReadOnly: access == metadata.AccessWorkspaceReadOnly})
+			// WASI has no working directory; wasi-libc and Go read PWD.
+			env["PWD"] = jr.workspace
+		case metadata.AccessTemp:
+			mounts = append(mounts, wasm.Mount{Host: jr.temp})
+		case metadata.AccessToolCache:
+			if _, err := os.Stat(jr.run.toolCacheDir()); err == nil {
+				mounts = append(mounts, wasm.Mount{Host: jr.run.toolCacheDir(), ReadOnly: true})
+			}
+		}
+	}
+	for i := range mounts {
+		mounts[i].Guest = filepath.ToSlash(mounts[i].Host)
+	}
+	memory := cmp.Or(opts.MemoryLimit, wasm.DefaultMemoryLimit)
+	if jr.limits.Memory > 0 {
+		memory = min(memory, jr.limits.Memory)
+	}
+	return wasm.Run(ctx, wasm.Config{
+		Module:      module,
+		Env:         env,
+		Stdout:      jr.log,
+		Stderr:      jr.log,
+		Mounts:      mounts,
+		MemoryLimit: memory,
+	})
+}
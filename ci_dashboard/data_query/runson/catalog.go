@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runson resolves the runs-on of jobs against a catalog of the
+// runners that can take them.
+package runson
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/client"
+)
+
+// HostedLabels are the labels of GitHub-hosted runners.
+var HostedLabels = []string{
+	"ubuntu-latest", "ubuntu-24.04", "ubuntu-22.04", "ubuntu-20.04",
+	"ubuntu-24.04-arm", "ubuntu-22.04-arm",
+	"windows-latest", "windows-2025", "windows-2022", "windows-2019", "windows-11-arm",
+	"macos-latest", "macos-15", "macos-14", "macos-13",
+	"macos-latest-large", "macos-15-large", "macos-14-large", "macos-13-large",
+	"macos-latest-xlarge", "macos-15-xlarge", "macos-14-xlarge", "macos-13-xlarge",
+}
+
+// SelfHostedLabels are the labels every self-hosted runner is given.
+var SelfHostedLabels = []string{"self-hosted", "linux", "windows", "macos", "x64", "x86", "arm", "arm64"}
+
+// DefaultGroup is the runner group runners are in unless assigned another.
+const DefaultGroup = "Default"
+
+// Runner is a runner, or a set of identical runners, that jobs can target.
+type Runner struct {
+	Name   string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Labels []string `yaml:"labels" json:"labels"`
+	// Group is the runner group; empty means DefaultGroup.
+	Group string `yaml:"group,omitempty" json:"group,omitempty"`
+	// Hosted marks GitHub-hosted runners, which are in no group.
+	Hosted bool `yaml:"hosted,omitempty" json:"hosted,omitempty"`
+}
+
+// HasLabel reports whether the runner has the label, ignoring case.
+func (r *Runner) HasLabel(label string) bool {
+	return slices.ContainsFunc(r.Labels, func(l string) bool { return strings.EqualFold(l, label) })
+}
+
+// InGroup reports whether the runner is in the group, ignoring case.
+func (r *Runner) InGroup(group string) bool {
+	if r.Hosted {
+		return false
+	}
+	g := r.Group
+	if g == "" {
+		g = DefaultGroup
+	}
+	return strings.EqualFold(g, group)
+}
+
+// Catalog lists the runners available to an organization's jobs.
+type Catalog struct {
+	// Hosted includes the standard GitHub-hosted runners. It defaults to
+	// true.
+	Hosted *bool `yaml:"hosted,omitempty" json:"hosted,omitempty"`
+	// Groups lists runner groups, including any with no runners yet.
+	Groups  []string  `yaml:"groups,omitempty" json:"groups,omitempty"`
+	Runners []*Runner `yaml:"runners" json:"runners"`
+}
+
+// HostedRunners returns the standard GitHub-hosted runners, one per image
+// label.
+func HostedRunners() []*Runner {
+	runners := make([]*Runner, len(HostedLabels))
+	for i, l := range HostedLabels {
+		runners[i] = &Runner{Name: l, Labels: []string{l}, Hosted: true}
+	}
+	return runners
+}
+
+// All returns the runners of the catalog, with the GitHub-hosted ones
+// unless Hosted is false.
+func (c *Catalog) All() []*Runner {
+	if c.Hosted != nil && !*c.Hosted {
+		return c.Runners
+	}
+	return append(HostedRunners(), c.Runners...)
+}
+
+// HasLabel reports whether any runner has the label.
+func (c *Catalog) HasLabel(label string) bool {
+	return slices.ContainsFunc(c.All(), func(r *Runner) bool { return r.HasLabel(label) })
+}
+
+// HasGroup reports whether the group is listed or has runners.
+func (c *Catalog) HasGroup(group string) bool {
+	if strings.EqualFold(group, DefaultGroup) || slices.ContainsFunc(c.Groups, func(g string) bool { return strings.EqualFold(g, group) }) {
+		return true
+	}
+	return slices.ContainsFunc(c.Runners, func(r *Runner) bool { return r.InGroup(group) })
+}
+
+// Labels returns every label of the catalog's runners, sorted and without
+// duplicates.
+func (c *Catalog) Labels() []string {
+	seen := map[string]bool{}
+	var labels []string
+	for _, r := range c.All() {
+		for _, l := range r.Labels {
+			if key := strings.ToLower(l); !seen[key] {
+				seen[key] = true
+				labels = append(labels, l)
+			}
+		}
+	}
+	slices.Sort(labels)
+	return labels
+}
+
+// Load reads a catalog from a YAML or JSON file.
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Catalog
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, r := range c.Runners {
+		if len(r.Labels) == 0 {
+			return nil, fmt.Errorf("%s: runner %d has no labels", path, i+1)
+		}
+	}
+	return &c, nil
+}
+
+// Fetch builds the catalog of an organization's self-hosted runners and
+// runner groups. Runner groups are skipped when the organization's plan
+// has none.
+func Fetch(ctx context.Context, c *client.Client, org string) (*Catalog, error) {
+	runners, err := c.ListRunners(ctx, client.Org(org))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runners: %w", err)
+	}
+	groups, err := c.ListRunnerGroups(ctx, org)
+	if err != nil && !client.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to list runner groups: %w", err)
+	}
+	cat := &Catalog{}
+	names := map[int64]string{}
+	for _, g := range groups {
+		names[g.ID] = g.Name
+		if !g.Default {
+			cat.Groups = append(cat.Groups, g.Name)
+		}
+	}
+	for _, r := range runners {
+		cat.Runners = append(cat.Runners, &Runner{Name: r.Name, Labels: r.LabelNames(), Group: names[r.RunnerGroupID]})
+	}
+	return cat, nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runson
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+// ErrDynamic is returned when runs-on depends on values that are only known
+// while the workflow runs, such as the outputs of needed jobs.
+var ErrDynamic = errors.New("runs-on is only known while the workflow runs")
+
+// Target is what a job's runs-on asks for once its expressions are
+// evaluated: a runner in Group, if set, that has all of Labels.
+type Target struct {
+	Group  string   `json:"group,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+func (t Target) String() string {
+	s := strings.Join(t.Labels, ", ")
+	if t.Group != "" {
+		if s != "" {
+			return "group " + t.Group + " with " + s
+		}
+		return "group " + t.Group
+	}
+	return s
+}
+
+// Evaluate evaluates the expressions of runs-on in ctx. A label that is a
+// single expression may evaluate to a list of labels. Expressions reading a
+// context ctx lacks make it return ErrDynamic.
+func Evaluate(r *workflow.RunsOn, ctx *expr.Context) (Target, error) {
+	group, err := evaluate(r.Group, ctx)
+	if err != nil {
+		return Target{}, err
+	}
+	t := Target{}
+	if len(group) > 0 {
+		t.Group = group[0]
+	}
+	for _, l := range r.Labels {
+		labels, err := evaluate(l, ctx)
+		if err != nil {
+			return Target{}, err
+		}
+		t.Labels = append(t.Labels, labels...)
+	}
+	return t, nil
+}
+
+func evaluate(s string, ctx *expr.Context) ([]string, error) {
+	exprs, err := expr.Extract(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(exprs) == 0 {
+		if s == "" {
+			return nil, nil
+		}
+		return []string{s}, nil
+	}
+	for _, e := range exprs {
+		node, err := expr.Parse(e.Source)
+		if err != nil {
+			return nil, err
+		}
+		dynamic := false
+		expr.Walk(node, func(n expr.Node) {
+			if id, ok := n.(*expr.Ident); ok {
+				if ctx == nil || ctx.Values[strings.ToLower(id.Name)] == nil {
+					dynamic = true
+				}
+			}
+		})
+		if dynamic {
+			return nil, ErrDynamic
+		}
+	}
+	v, err := expr.EvaluateValue(s, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			labels = append(labels, expr.ToString(item))
+		}
+	case nil:
+	default:
+		labels = []string{expr.ToString(v)}
+	}
+	return slices.DeleteFunc(labels, func(l string) bool { return l == "" }), nil
+}
+
+// Targets returns the distinct targets of a job that sets runs-on, one per
+// matrix combination when runs-on reads the matrix. It returns ErrDynamic
+// when runs-on reads a matrix that is computed by an expression.
+func Targets(job *workflow.Job) ([]Target, error) {
+	if job.RunsOn == nil {
+		return nil, nil
+	}
+	combos := []map[string]any{nil}
+	if job.Strategy != nil && job.Strategy.Matrix != nil {
+		if m, err := job.Strategy.Matrix.Expand(); err == nil {
+			combos = m
+		}
+	}
+	var targets []Target
+	seen := map[string]bool{}
+	for _, combo := range combos {
+		ctx := &expr.Context{Values: map[string]any{}}
+		if combo != nil {
+			ctx.Values["matrix"] = combo
+		}
+		t, err := Evaluate(job.RunsOn, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if key := strings.ToLower(t.String()); !seen[key] {
+			seen[key] = true
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// Match is the result of resolving a target against a catalog.
+type Match struct {
+	Target
+	// Runners are the runners that can take the job.
+	Runners []*Runner `json:"runners,omitempty"`
+	// Unknown lists the labels that no runner has.
+	Unknown []string `json:"unknown,omitempty"`
+	// UnknownGroup is set when the catalog has no such group.
+	UnknownGroup bool `json:"unknown_group,omitempty"`
+}
+
+// OK reports whether some runner can take the job.
+func (m *Match) OK() bool { return len(m.Runners) > 0 }
+
+// Problem describes why no runner can take the job, or is empty if one can.
+func (m *Match) Problem() string {
+	switch {
+	case m.OK():
+		return ""
+	case len(m.Labels) == 0 && m.Group == "":
+		return "runs-on has no labels"
+	case m.UnknownGroup:
+		return fmt.Sprintf("there is no runner group %q", m.Group)
+	case len(m.Unknown) == 1:
+		return fmt.Sprintf("no runner has the label %q", m.Unknown[0])
+	case len(m.Unknown) > 1:
+		return fmt.Sprintf("no runner has the labels %s", quoted(m.Unknown))
+	case m.Group != "" && len(m.Labels) == 0:
+		return fmt.Sprintf("runner group %q has no runners", m.Group)
+	case m.Group != "":
+		return fmt.Sprintf("no runner in group %q has all of the labels %s", m.Group, quoted(m.Labels))
+	}
+	return fmt.Sprintf("no runner has all of the labels %s", quoted(m.Labels))
+}
+
+func quoted(labels []string) string {
+	q := make([]string, len(labels))
+	for i, l := range labels {
+		q[i] = fmt.Sprintf("%q", l)
+	}
+	return strings.Join(q, ", ")
+}
+
+// Resolve returns the runners of the catalog that can take a job
+// targeting t: those in t's group, if any, that have all of its labels,
+// ignoring case.
+func (c *Catalog) Resolve(t Target) *Match {
+	m := &Match{Target: t}
+	if t.Group != "" && !c.HasGroup(t.Group) {
+		m.UnknownGroup = true
+	}
+	for _, l := range t.Labels {
+		if !c.HasLabel(l) {
+			m.Unknown = append(m.Unknown, l)
+		}
+	}
+	if len(t.Labels) == 0 && t.Group == "" {
+		return m
+	}
+	for _, r := range c.All() {
+		if t.Group != "" && !r.InGroup(t.Group) {
+			continue
+		}
+		if !slices.ContainsFunc(t.Labels, func(l string) bool { return !r.HasLabel(l) }) {
+			m.Runners = append(m.Runners, r)
+		}
+	}
+	return m
+}
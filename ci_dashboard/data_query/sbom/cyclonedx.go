@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Subject describes the repository the SBOM is for.
+type Subject struct {
+	// Name is owner/repo.
+	Name string
+	// ServerURL is the GitHub instance hosting the repository. Defaults to
+	// github.com.
+	ServerURL string
+	// Version is the commit of the repository, if known.
+	Version string
+	// Created defaults to the current time.
+	Created time.Time
+}
+
+func (s Subject) created() string {
+	t := s.Created
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// toolName identifies this program in generated documents.
+const toolName = "actions"
+
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     cdxTools      `json:"tools"`
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type               string        `json:"type"`
+	BOMRef             string        `json:"bom-ref,omitempty"`
+	Group              string        `json:"group,omitempty"`
+	Name               string        `json:"name"`
+	Version            string        `json:"version,omitempty"`
+	PURL               string        `json:"purl,omitempty"`
+	ExternalReferences []cdxExtRef   `json:"externalReferences,omitempty"`
+	Pedigree           *cdxPedigree  `json:"pedigree,omitempty"`
+	Properties         []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxExtRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cdxPedigree struct {
+	Commits []cdxCommit `json:"commits"`
+}
+
+type cdxCommit struct {
+	UID string `json:"uid"`
+	URL string `json:"url,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// WriteCycloneDX writes inv as a CycloneDX 1.5 JSON document.
+func WriteCycloneDX(w io.Writer, inv *Inventory, subject Subject) error {
+	root := cdxComponent{Type: "application", BOMRef: "root", Name: subject.Name, Version: subject.Version}
+	if subject.Name != "" {
+		root.ExternalReferences = []cdxExtRef{{Type: "vcs", URL: serverURL(subject.ServerURL) + "/" + subject.Name}}
+	}
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: subject.created(),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: toolName}}},
+			Component: &root,
+		},
+		Components:   []cdxComponent{},
+		Dependencies: []cdxDependency{{Ref: root.BOMRef, DependsOn: nonNil(inv.Direct)}},
+	}
+	for _, c := range inv.Components {
+		comp := cdxComponent{
+			Type:       "application",
+			BOMRef:     c.Ref,
+			Name:       c.Name(),
+			Version:    c.Version,
+			PURL:       c.PURL(),
+			Properties: []cdxProperty{{Name: toolName + ":kind", Value: c.Kind}},
+		}
+		if c.Kind == KindImage {
+			comp.Type = "container"
+			_, comp.Version = splitImage(c.Image)
+		} else {
+			comp.Group = c.Owner
+			comp.Name = strings.TrimPrefix(c.Name(), c.Owner+"/")
+			comp.ExternalReferences = []cdxExtRef{{Type: "vcs", URL: c.SourceURL()}}
+		}
+		if c.SHA != "" {
+			comp.Pedigree = &cdxPedigree{Commits: []cdxCommit{{UID: c.SHA, URL: c.CommitURL()}}}
+		}
+		if c.Using != "" {
+			comp.Properties = append(comp.Properties, cdxProperty{Name: toolName + ":using", Value: c.Using})
+		}
+		bom.Components = append(bom.Components, comp)
+		bom.Dependencies = append(bom.Dependencies, cdxDependency{Ref: c.Ref, DependsOn: nonNil(c.DependsOn)})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bom)
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
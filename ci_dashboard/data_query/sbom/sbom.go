@@ -0,0 +1,370 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom lists the actions, reusable workflows and images a
+// repository's workflows depend on and writes them as a software bill of
+// materials.
+package sbom
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/pin"
+	"testingdashboard/m/v2/resolve"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Kinds of components.
+const (
+	KindAction   = "action"
+	KindWorkflow = "workflow"
+	KindImage    = "image"
+)
+
+// Component is a dependency of the workflows.
+type Component struct {
+	// Ref identifies the component within the SBOM. It is the reference as
+	// written in uses:.
+	Ref  string `json:"ref"`
+	Kind string `json:"kind"`
+	// Owner, Repo, Path and Version are set for actions and workflows.
+	Owner   string `json:"owner,omitempty"`
+	Repo    string `json:"repo,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Server is the web URL of the GitHub instance hosting the repository
+	// of an action or workflow. Empty means github.com.
+	Server string `json:"server,omitempty"`
+	// SHA is the commit Version resolves to. It is empty when resolution
+	// was skipped.
+	SHA string `json:"sha,omitempty"`
+	// Image is set for docker:// references.
+	Image string `json:"image,omitempty"`
+	// Using is runs.using of an action, when its metadata was fetched.
+	Using string `json:"using,omitempty"`
+	// DependsOn lists the Refs of the components a composite action or
+	// reusable workflow uses itself.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// Name returns owner/repo[/path] or the image name.
+func (c *Component) Name() string {
+	if c.Kind == KindImage {
+		name, _ := splitImage(c.Image)
+		return name
+	}
+	if c.Path != "" {
+		return c.Owner + "/" + c.Repo + "/" + c.Path
+	}
+	return c.Owner + "/" + c.Repo
+}
+
+// SourceURL returns the repository the component is fetched from.
+func (c *Component) SourceURL() string {
+	if c.Kind == KindImage {
+		return ""
+	}
+	return serverURL(c.Server) + "/" + c.Owner + "/" + c.Repo
+}
+
+func serverURL(server string) string {
+	if server == "" {
+		return endpoints.DotCom
+	}
+	return strings.TrimSuffix(server, "/")
+}
+
+// CommitURL returns the URL of the resolved commit, or "" if unresolved.
+func (c *Component) CommitURL() string {
+	if c.SHA == "" || c.Kind == KindImage {
+		return ""
+	}
+	return c.SourceURL() + "/commit/" + c.SHA
+}
+
+// PURL returns the package URL of the component.
+func (c *Component) PURL() string {
+	if c.Kind == KindImage {
+		name, version := splitImage(c.Image)
+		purl := "pkg:docker/" + name
+		if version != "" {
+			purl += "@" + url.PathEscape(version)
+		}
+		return purl
+	}
+	version := c.SHA
+	if version == "" {
+		version = c.Version
+	}
+	purl := "pkg:githubactions/" + strings.ToLower(c.Owner) + "/" + strings.ToLower(c.Repo) + "@" + url.PathEscape(version)
+	if c.Path != "" {
+		purl += "#" + c.Path
+	}
+	return purl
+}
+
+// splitImage splits an image reference into its name and tag or digest.
+func splitImage(image string) (name, version string) {
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		return name, digest
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, ""
+}
+
+// Options configure Collect.
+type Options struct {
+	// Fetcher fetches actions and reusable workflows to find what they use
+	// in turn. When nil only the references written in the workflows are
+	// listed.
+	Fetcher resolve.Fetcher
+	// Resolver resolves refs to commit SHAs. When nil only refs that are
+	// already SHAs are recorded.
+	Resolver pin.Resolver
+	// ServerURL is the GitHub instance actions and workflows are fetched
+	// from. Defaults to github.com.
+	ServerURL string
+}
+
+// Inventory is the result of Collect.
+type Inventory struct {
+	// Components are sorted by Ref.
+	Components []*Component
+	// Direct lists the Refs the workflows use themselves, sorted.
+	Direct []string
+}
+
+// Collect returns every component the workflows use, directly or through
+// composite actions and reusable workflows, including local ones.
+func Collect(ctx context.Context, wfs []*workflow.Workflow, opts Options) (*Inventory, error) {
+	c := &collector{opts: opts, components: map[string]*Component{}, shas: map[string]string{}, visited: map[string]bool{}}
+	var direct []string
+	for _, wf := range wfs {
+		refs, err := c.workflow(ctx, wf, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", wf.Path, err)
+		}
+		direct = append(direct, refs...)
+	}
+	inv := &Inventory{Direct: sortUnique(direct)}
+	for _, comp := range c.components {
+		comp.DependsOn = sortUnique(comp.DependsOn)
+		inv.Components = append(inv.Components, comp)
+	}
+	slices.SortFunc(inv.Components, func(a, b *Component) int { return strings.Compare(a.Ref, b.Ref) })
+	return inv, nil
+}
+
+type collector struct {
+	opts       Options
+	components map[string]*Component
+	// shas caches resolved refs by owner/repo@ref.
+	shas map[string]string
+	// visited records actions and workflows whose contents were walked.
+	visited map[string]bool
+}
+
+// workflow returns the Refs of the components wf uses. Components used from
+// local actions and workflows are attributed to wf, since local files are
+// part of the repository rather than dependencies of their own.
+func (c *collector) workflow(ctx context.Context, wf *workflow.Workflow, via []string) ([]string, error) {
+	var refs []string
+	for _, id := range wf.JobIDs() {
+		job := wf.Jobs[id]
+		if job.Uses != "" {
+			r, err := c.call(ctx, job, via)
+			if err != nil {
+				return nil, fmt.Errorf("job %s: %w", id, err)
+			}
+			refs = append(refs, r...)
+			continue
+		}
+		r, err := c.steps(ctx, job.Steps, via)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", id, err)
+		}
+		refs = append(refs, r...)
+	}
+	return refs, nil
+}
+
+func (c *collector) call(ctx context.Context, job *workflow.Job, via []string) ([]string, error) {
+	uses, err := workflow.ParseUses(job.Uses)
+	if err != nil {
+		return nil, err
+	}
+	comp, err := c.add(ctx, uses, KindWorkflow)
+	if err != nil {
+		return nil, err
+	}
+	if c.opts.Fetcher == nil {
+		return refsOf(comp), nil
+	}
+	if comp != nil && c.visited[comp.Ref] {
+		return refsOf(comp), nil
+	}
+	if slices.Contains(via, uses.String()) {
+		return nil, fmt.Errorf("reusable workflow %s calls itself: %v", uses, append(via, uses.String()))
+	}
+	if len(via) >= resolve.MaxWorkflowDepth+resolve.MaxCompositeDepth {
+		return nil, fmt.Errorf("references nested too deeply: %v", via)
+	}
+	path, err := c.opts.Fetcher.Fetch(ctx, uses)
+	if err != nil {
+		return nil, err
+	}
+	called, err := workflow.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := c.workflow(ctx, called, append(via, uses.String()))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", uses, err)
+	}
+	if comp == nil {
+		return inner, nil
+	}
+	c.visited[comp.Ref] = true
+	comp.DependsOn = append(comp.DependsOn, inner...)
+	return refsOf(comp), nil
+}
+
+func (c *collector) steps(ctx context.Context, steps []*workflow.Step, via []string) ([]string, error) {
+	var refs []string
+	for _, step := range steps {
+		if step.Uses == "" {
+			continue
+		}
+		uses, err := workflow.ParseUses(step.Uses)
+		if err != nil {
+			return nil, err
+		}
+		r, err := c.action(ctx, uses, via)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, r...)
+	}
+	return refs, nil
+}
+
+func (c *collector) action(ctx context.Context, uses *workflow.Uses, via []string) ([]string, error) {
+	comp, err := c.add(ctx, uses, KindAction)
+	if err != nil {
+		return nil, err
+	}
+	if uses.Kind == workflow.UsesDocker || c.opts.Fetcher == nil {
+		return refsOf(comp), nil
+	}
+	if comp != nil && c.visited[comp.Ref] {
+		return refsOf(comp), nil
+	}
+	if slices.Contains(via, uses.String()) {
+		return nil, fmt.Errorf("composite action %s uses itself: %v", uses, append(via, uses.String()))
+	}
+	if len(via) >= resolve.MaxWorkflowDepth+resolve.MaxCompositeDepth {
+		return nil, fmt.Errorf("references nested too deeply: %v", via)
+	}
+	action, err := resolve.Action(ctx, c.opts.Fetcher, uses)
+	if err != nil {
+		return nil, err
+	}
+	var inner []string
+	switch action.Runs.Using {
+	case metadata.UsingComposite:
+		if inner, err = c.steps(ctx, action.Runs.Steps, append(via, uses.String())); err != nil {
+			return nil, fmt.Errorf("%s: %w", uses, err)
+		}
+	case metadata.UsingDocker:
+		if image, ok := strings.CutPrefix(action.Runs.Image, "docker://"); ok {
+			if inner, err = c.action(ctx, &workflow.Uses{Kind: workflow.UsesDocker, Image: image}, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if comp == nil {
+		return inner, nil
+	}
+	c.visited[comp.Ref] = true
+	comp.Using = action.Runs.Using
+	comp.DependsOn = append(comp.DependsOn, inner...)
+	return refsOf(comp), nil
+}
+
+// add records the component uses refers to. It returns nil for local
+// references.
+func (c *collector) add(ctx context.Context, uses *workflow.Uses, kind string) (*Component, error) {
+	if uses.Kind == workflow.UsesLocal {
+		return nil, nil
+	}
+	ref := uses.String()
+	if comp, ok := c.components[ref]; ok {
+		return comp, nil
+	}
+	comp := &Component{Ref: ref, Kind: kind}
+	if uses.Kind == workflow.UsesDocker {
+		comp.Kind = KindImage
+		comp.Image = uses.Image
+		c.components[ref] = comp
+		return comp, nil
+	}
+	comp.Owner, comp.Repo, comp.Path, comp.Version = uses.Owner, uses.Repo, uses.Path, uses.Ref
+	comp.Server = c.opts.ServerURL
+	sha, err := c.resolve(ctx, uses)
+	if err != nil {
+		return nil, err
+	}
+	comp.SHA = sha
+	c.components[ref] = comp
+	return comp, nil
+}
+
+func (c *collector) resolve(ctx context.Context, uses *workflow.Uses) (string, error) {
+	if pin.IsSHA(uses.Ref) {
+		return uses.Ref, nil
+	}
+	if c.opts.Resolver == nil {
+		return "", nil
+	}
+	key := uses.Repository() + "@" + uses.Ref
+	if sha, ok := c.shas[key]; ok {
+		return sha, nil
+	}
+	sha, err := c.opts.Resolver.Resolve(ctx, uses.Owner, uses.Repo, uses.Ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", key, err)
+	}
+	c.shas[key] = sha
+	return sha, nil
+}
+
+func refsOf(comp *Component) []string {
+	if comp == nil {
+		return nil
+	}
+	return []string{comp.Ref}
+}
+
+func sortUnique(s []string) []string {
+	slices.Sort(s)
+	return slices.Compact(s)
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string       `json:"SPDXID"`
+	Name             string       `json:"name"`
+	VersionInfo      string       `json:"versionInfo,omitempty"`
+	Supplier         string       `json:"supplier,omitempty"`
+	DownloadLocation string       `json:"downloadLocation"`
+	FilesAnalyzed    bool         `json:"filesAnalyzed"`
+	LicenseConcluded string       `json:"licenseConcluded"`
+	LicenseDeclared  string       `json:"licenseDeclared"`
+	CopyrightText    string       `json:"copyrightText"`
+	Comment          string       `json:"comment,omitempty"`
+	ExternalRefs     []spdxExtRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExtRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+var spdxInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// WriteSPDX writes inv as an SPDX 2.3 JSON document.
+func WriteSPDX(w io.Writer, inv *Inventory, subject Subject) error {
+	name := subject.Name
+	if name == "" {
+		name = "workflows"
+	}
+	root := spdxPackage{
+		SPDXID:           "SPDXRef-Repository",
+		Name:             name,
+		VersionInfo:      subject.Version,
+		DownloadLocation: "NOASSERTION",
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "NOASSERTION",
+		CopyrightText:    "NOASSERTION",
+	}
+	if subject.Name != "" {
+		root.DownloadLocation = "git+" + serverURL(subject.ServerURL) + "/" + subject.Name
+		if subject.Version != "" {
+			root.DownloadLocation += "@" + subject.Version
+		}
+	}
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name + " workflow dependencies",
+		DocumentNamespace: "https://spdx.org/spdxdocs/" + spdxInvalid.ReplaceAllString(name, "-") + "-" + newUUID(),
+		CreationInfo:      spdxCreationInfo{Created: subject.created(), Creators: []string{"Tool: " + toolName}},
+		Packages:          []spdxPackage{root},
+		Relationships:     []spdxRelationship{{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: root.SPDXID}},
+	}
+
+	ids := map[string]string{}
+	for i, c := range inv.Components {
+		ids[c.Ref] = fmt.Sprintf("SPDXRef-%d-%s", i+1, spdxInvalid.ReplaceAllString(c.Name(), "-"))
+	}
+	for _, c := range inv.Components {
+		pkg := spdxPackage{
+			SPDXID:           ids[c.Ref],
+			Name:             c.Name(),
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+			Comment:          c.Kind + " " + c.Ref,
+			ExternalRefs:     []spdxExtRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: c.PURL()}},
+		}
+		if c.Kind == KindImage {
+			_, pkg.VersionInfo = splitImage(c.Image)
+		} else {
+			pkg.Supplier = "Organization: " + c.Owner
+			pkg.DownloadLocation = "git+" + c.SourceURL()
+			if c.SHA != "" {
+				pkg.DownloadLocation += "@" + c.SHA
+			}
+			if c.Path != "" {
+				pkg.DownloadLocation += "#" + c.Path
+			}
+		}
+		doc.Packages = append(doc.Packages, pkg)
+	}
+	for _, ref := range inv.Direct {
+		doc.Relationships = append(doc.Relationships, spdxRelationship{Element: root.SPDXID, Type: "DEPENDS_ON", Related: ids[ref]})
+	}
+	for _, c := range inv.Components {
+		for _, ref := range c.DependsOn {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{Element: ids[c.Ref], Type: "DEPENDS_ON", Related: ids[ref]})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
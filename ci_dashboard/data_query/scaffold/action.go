@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffold
+
+// CoreModule is the module of the core package generated actions use to
+// read inputs and set outputs.
+const CoreModule = "testingdashboard/m/v2"
+
+// Actions is the registry of the built-in templates of new actions. Their
+// parameters are those of GoAction.
+var Actions Registry = actionTemplates{}
+
+type actionTemplates struct{}
+
+// Templates implements Registry.
+func (actionTemplates) Templates() ([]*Template, error) {
+	return []*Template{GoAction()}, nil
+}
+
+// GoAction is the template of an action written in Go, packaged as a
+// Docker container action built from source or as a composite action that
+// runs the binary of its release for the runner.
+func GoAction() *Template {
+	return &Template{
+		Name:        "go-action",
+		Description: "A GitHub Action written in Go",
+		Params: []Param{
+			{Name: "Name", Description: "action name"},
+			{Name: "Description", Description: "what the action does", Default: "A GitHub Action written in Go"},
+			{Name: "Module", Description: "Go module path"},
+			{Name: "Inputs", Description: "comma-separated input names", Optional: true},
+			{Name: "Outputs", Description: "comma-separated output names", Optional: true},
+			{Name: "Packaging", Description: "how the action runs", Default: "docker", Options: []string{"docker", "binary"}},
+			{Name: "GoVersion", Description: "Go version", Default: "1.24"},
+		},
+		Files: map[string]string{
+			"action.yml": goActionMetadata,
+			"main.go":    goActionMain,
+			"go.mod":     "module [[ .Module ]]\n\ngo [[ .GoVersion ]]\n",
+			`[[ if eq .Packaging "docker" ]]Dockerfile[[ end ]]`: goActionDockerfile,
+			`[[ if eq .Packaging "binary" ]]run.sh[[ end ]]`:     goActionRun,
+			".github/workflows/release.yml":                      goActionRelease,
+		},
+	}
+}
+
+const goActionMetadata = `name: [[ .Name ]]
+description: [[ .Description ]]
+[[- if .Inputs ]]
+inputs:
+[[- range split .Inputs ]]
+  [[ . ]]:
+    description: TODO
+    required: false
+[[- end ]]
+[[- end ]]
+[[- if .Outputs ]]
+outputs:
+[[- range split .Outputs ]]
+  [[ . ]]:
+    description: TODO
+[[- if eq $.Packaging "binary" ]]
+    value: ${{ steps.run.outputs.[[ . ]] }}
+[[- end ]]
+[[- end ]]
+[[- end ]]
+runs:
+[[- if eq .Packaging "docker" ]]
+  using: docker
+  image: Dockerfile
+[[- else ]]
+  using: composite
+  steps:
+    - id: run
+      shell: bash
+      run: '"$GITHUB_ACTION_PATH/run.sh"'
+      env:
+        GH_TOKEN: ${{ github.token }}
+        ACTION_REPOSITORY: ${{ github.action_repository }}
+        ACTION_REF: ${{ github.action_ref }}
+[[- range split .Inputs ]]
+        [[ inputEnv . ]]: ${{ inputs.[[ . ]] }}
+[[- end ]]
+[[- end ]]
+`
+
+const goActionMain = `// Command [[ .Name ]] is a GitHub Action.
+package main
+
+import "` + CoreModule + `/core"
+
+func main() {
+	var in core.Inputs
+[[- range split .Inputs ]]
+	[[ camel . ]] := in.String("[[ . ]]", "")
+[[- end ]]
+	if err := in.Err(); err != nil {
+		core.SetFailed(err.Error())
+	}
+	if err := run([[ range $i, $n := split .Inputs ]][[ if $i ]], [[ end ]][[ camel $n ]][[ end ]]); err != nil {
+		core.SetFailed(err.Error())
+	}
+}
+
+// run does the work of the action.
+func run([[ range $i, $n := split .Inputs ]][[ if $i ]], [[ end ]][[ camel $n ]][[ end ]][[ if .Inputs ]] string[[ end ]]) error {
+	core.Info("Running [[ .Name ]]")
+[[- range split .Outputs ]]
+	if err := core.SetOutput("[[ . ]]", ""); err != nil {
+		return err
+	}
+[[- end ]]
+	return nil
+}
+`
+
+const goActionDockerfile = `FROM golang:[[ .GoVersion ]] AS build
+WORKDIR /src
+COPY go.mod go.sum ./
+RUN go mod download
+COPY . .
+RUN CGO_ENABLED=0 go build -trimpath -ldflags=-s -o /action .
+
+FROM gcr.io/distroless/static-debian12
+COPY --from=build /action /action
+ENTRYPOINT ["/action"]
+`
+
+const goActionRun = `#!/usr/bin/env bash
+# Runs the binary the release workflow attached to the release the action
+# is used at, downloading it once per job. The action must be used at a
+# release tag.
+set -euo pipefail
+
+os=$(echo "$RUNNER_OS" | tr A-Z a-z)
+case "$os" in
+  macos) os=darwin ;;
+esac
+case "$RUNNER_ARCH" in
+  X64) arch=amd64 ;;
+  ARM64) arch=arm64 ;;
+  *) echo "unsupported architecture $RUNNER_ARCH" >&2; exit 1 ;;
+esac
+ext=
+if [ "$os" = windows ]; then ext=.exe; fi
+name="[[ .Name ]]-$os-$arch$ext"
+dir="$RUNNER_TEMP/[[ .Name ]]-$ACTION_REF"
+if [ ! -x "$dir/$name" ]; then
+  gh release download "$ACTION_REF" -R "$ACTION_REPOSITORY" -p "$name" -D "$dir"
+  chmod +x "$dir/$name"
+fi
+exec "$dir/$name"
+`
+
+const goActionRelease = `name: Release
+
+on:
+  push:
+    tags: ["v*"]
+
+permissions:
+  contents: write
+
+jobs:
+  release:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: go.mod
+      - run: go vet ./...
+      - run: go test ./...
+[[- if eq .Packaging "binary" ]]
+      - name: Build
+        run: |
+          for target in linux/amd64 linux/arm64 darwin/amd64 darwin/arm64 windows/amd64 windows/arm64; do
+            os=${target%/*} arch=${target#*/} ext=
+            if [ "$os" = windows ]; then ext=.exe; fi
+            CGO_ENABLED=0 GOOS=$os GOARCH=$arch go build -trimpath -ldflags=-s -o "dist/[[ .Name ]]-$os-$arch$ext" .
+          done
+[[- end ]]
+      - name: Release
+        run: gh release create "$GITHUB_REF_NAME" --verify-tag --generate-notes[[ if eq .Packaging "binary" ]] dist/*[[ end ]]
+        env:
+          GH_TOKEN: ${{ github.token }}
+      - name: Move the major version tag
+        if: ${{ !contains(github.ref_name, '-') }}
+        run: |
+          major=${GITHUB_REF_NAME%%.*}
+          git tag -f "$major"
+          git push -f origin "refs/tags/$major"
+`
@@ -0,0 +1,217 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffold
+
+// Builtin is the registry of the built-in templates.
+var Builtin Registry = builtin{}
+
+type builtin struct{}
+
+// Templates implements Registry.
+func (builtin) Templates() ([]*Template, error) {
+	out := []*Template{goTemplate(), dockerTemplate(), releaseTemplate(), monorepoTemplate()}
+	sortTemplates(out)
+	return out, nil
+}
+
+var branchParam = Param{Name: "Branch", Description: "default branch", Default: "main"}
+
+func goTemplate() *Template {
+	return &Template{
+		Name:        "go",
+		Description: "Build, vet and test a Go module on pushes and pull requests",
+		Params: []Param{
+			branchParam,
+			{Name: "GoVersion", Description: "Go version, or go.mod to use the module's", Default: "go.mod"},
+			{Name: "OS", Description: "comma-separated runner labels to test on", Default: "ubuntu-latest"},
+		},
+		Files: map[string]string{".github/workflows/go.yml": `name: Go
+
+on:
+  push:
+    branches: ["[[ .Branch ]]"]
+  pull_request:
+
+permissions:
+  contents: read
+
+jobs:
+  test:
+    strategy:
+      matrix:
+        os:
+[[- range split .OS ]]
+          - [[ . ]]
+[[- end ]]
+    runs-on: ${{ matrix.os }}
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+[[- if eq .GoVersion "go.mod" ]]
+          go-version-file: go.mod
+[[- else ]]
+          go-version: "[[ .GoVersion ]]"
+[[- end ]]
+      - run: go build ./...
+      - run: go vet ./...
+      - run: go test -race ./...
+`},
+	}
+}
+
+func dockerTemplate() *Template {
+	return &Template{
+		Name:        "docker",
+		Description: "Build a container image on pull requests and push it on the default branch and tags",
+		Params: []Param{
+			branchParam,
+			{Name: "Registry", Description: "container registry", Default: "ghcr.io"},
+			{Name: "Image", Description: "image name in the registry", Default: "${{ github.repository }}"},
+			{Name: "Context", Description: "build context directory", Default: "."},
+			{Name: "Platforms", Description: "comma-separated platforms to build", Default: "linux/amd64,linux/arm64"},
+		},
+		Files: map[string]string{".github/workflows/docker.yml": `name: Docker
+
+on:
+  push:
+    branches: ["[[ .Branch ]]"]
+    tags: ["v*"]
+  pull_request:
+
+permissions:
+  contents: read
+[[- if eq .Registry "ghcr.io" ]]
+  packages: write
+[[- end ]]
+
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: docker/setup-qemu-action@v3
+      - uses: docker/setup-buildx-action@v3
+      - uses: docker/login-action@v3
+        if: github.event_name != 'pull_request'
+        with:
+          registry: [[ .Registry ]]
+          username: ${{ github.actor }}
+          password: ${{ secrets.GITHUB_TOKEN }}
+      - id: meta
+        uses: docker/metadata-action@v5
+        with:
+          images: [[ .Registry ]]/[[ .Image ]]
+      - uses: docker/build-push-action@v6
+        with:
+          context: [[ .Context ]]
+          platforms: [[ .Platforms ]]
+          push: ${{ github.event_name != 'pull_request' }}
+          tags: ${{ steps.meta.outputs.tags }}
+          labels: ${{ steps.meta.outputs.labels }}
+          cache-from: type=gha
+          cache-to: type=gha,mode=max
+`},
+	}
+}
+
+func releaseTemplate() *Template {
+	return &Template{
+		Name:        "release",
+		Description: "Publish a GitHub release with generated notes when a version tag is pushed",
+		Params: []Param{
+			{Name: "Tags", Description: "pattern of the tags to release", Default: "v*"},
+			{Name: "Build", Description: "command building the release assets, or none", Default: "none"},
+			{Name: "Assets", Description: "glob of the files to attach, with Build", Default: "dist/*"},
+		},
+		Files: map[string]string{".github/workflows/release.yml": `name: Release
+
+on:
+  push:
+    tags: ["[[ .Tags ]]"]
+
+permissions:
+  contents: write
+
+jobs:
+  release:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+[[- if ne .Build "none" ]]
+      - run: [[ .Build ]]
+[[- end ]]
+      - run: gh release create "$GITHUB_REF_NAME" --verify-tag --generate-notes[[ if ne .Build "none" ]] [[ .Assets ]][[ end ]]
+        env:
+          GH_TOKEN: ${{ github.token }}
+`},
+	}
+}
+
+func monorepoTemplate() *Template {
+	return &Template{
+		Name:        "monorepo",
+		Description: "Test only the packages of a monorepo that a change touches",
+		Params: []Param{
+			branchParam,
+			{Name: "Packages", Description: "comma-separated package directories"},
+			{Name: "Test", Description: "command testing a package, run in its directory", Default: "make test"},
+		},
+		Files: map[string]string{".github/workflows/monorepo.yml": `name: Monorepo
+
+on:
+  push:
+    branches: ["[[ .Branch ]]"]
+  pull_request:
+
+permissions:
+  contents: read
+
+jobs:
+  changes:
+    runs-on: ubuntu-latest
+    permissions:
+      contents: read
+      pull-requests: read
+    outputs:
+      packages: ${{ steps.filter.outputs.changes }}
+    steps:
+      - uses: actions/checkout@v4
+      - id: filter
+        uses: dorny/paths-filter@v3
+        with:
+          filters: |
+[[- range split .Packages ]]
+            [[ . ]]:
+              - '[[ . ]]/**'
+[[- end ]]
+
+  test:
+    needs: changes
+    if: needs.changes.outputs.packages != '[]'
+    strategy:
+      fail-fast: false
+      matrix:
+        package: ${{ fromJSON(needs.changes.outputs.packages) }}
+    runs-on: ubuntu-latest
+    defaults:
+      run:
+        working-directory: ${{ matrix.package }}
+    steps:
+      - uses: actions/checkout@v4
+      - run: [[ .Test ]]
+`},
+	}
+}
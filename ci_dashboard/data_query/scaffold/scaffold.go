@@ -0,0 +1,310 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scaffold generates workflows for a new repository from templates
+// with parameters, from the built-in set or a Registry of an organization's
+// own.
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Template is a set of files generated from parameters. File names and
+// contents are text/template templates delimited by [[ and ]], so as not
+// to clash with ${{ }} expressions, and see the parameters as
+// [[ .Name ]]. split turns a comma-separated value into a list, camel a
+// name such as dry-run into a Go identifier and inputEnv an action input's
+// name into its environment variable. A file whose name renders empty is
+// not generated.
+type Template struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	Params      []Param `yaml:"params,omitempty"`
+	// Files maps paths relative to the repository root to their contents.
+	Files map[string]string `yaml:"files"`
+}
+
+// Param is a parameter of a template.
+type Param struct {
+	// Name is a Go identifier, as in GoVersion.
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Default is used when no value is given. A parameter without one is
+	// required unless it is Optional.
+	Default  string `yaml:"default,omitempty"`
+	Optional bool   `yaml:"optional,omitempty"`
+	// Options, if set, are the values allowed.
+	Options []string `yaml:"options,omitempty"`
+}
+
+// Required reports whether the parameter must be given.
+func (p *Param) Required() bool {
+	return p.Default == "" && !p.Optional
+}
+
+// Check reports whether value is allowed.
+func (p *Param) Check(value string) error {
+	if len(p.Options) > 0 && !slices.Contains(p.Options, value) && (value != "" || !p.Optional) {
+		return fmt.Errorf("invalid %s %q; want one of %s", p.Name, value, strings.Join(p.Options, ", "))
+	}
+	return nil
+}
+
+// Param returns the named parameter, or nil.
+func (t *Template) Param(name string) *Param {
+	for i := range t.Params {
+		if t.Params[i].Name == name {
+			return &t.Params[i]
+		}
+	}
+	return nil
+}
+
+// File is a generated file.
+type File struct {
+	Path    string
+	Content []byte
+}
+
+var funcs = template.FuncMap{
+	"split": func(s string) []string {
+		var out []string
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+		return out
+	},
+	"camel": func(s string) string {
+		words := strings.FieldsFunc(s, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for i, w := range words {
+			if i > 0 {
+				words[i] = strings.ToUpper(w[:1]) + w[1:]
+			}
+		}
+		return strings.Join(words, "")
+	},
+	"inputEnv": func(s string) string {
+		return "INPUT_" + strings.ToUpper(strings.ReplaceAll(s, " ", "_"))
+	},
+}
+
+// Render generates the files of the template, sorted by path. Parameters
+// missing from values take their defaults. Generated workflows and action
+// metadata must parse.
+func (t *Template) Render(values map[string]string) ([]File, error) {
+	data := map[string]string{}
+	for _, p := range t.Params {
+		v, ok := values[p.Name]
+		if !ok || v == "" {
+			v = p.Default
+		}
+		if v == "" && !p.Optional {
+			return nil, fmt.Errorf("template %s: %s is required", t.Name, p.Name)
+		}
+		if err := p.Check(v); err != nil {
+			return nil, fmt.Errorf("template %s: %w", t.Name, err)
+		}
+		data[p.Name] = v
+	}
+	for name := range values {
+		if t.Param(name) == nil {
+			return nil, fmt.Errorf("template %s has no parameter %s", t.Name, name)
+		}
+	}
+	var files []File
+	for _, name := range sortedKeys(t.Files) {
+		file, err := execute(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", t.Name, err)
+		}
+		if file == "" {
+			continue
+		}
+		if file = path.Clean(file); path.IsAbs(file) || strings.HasPrefix(file, "../") || file == ".." {
+			return nil, fmt.Errorf("template %s: file %s is outside the repository", t.Name, file)
+		}
+		content, err := execute(t.Files[name], data)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %s: %w", t.Name, file, err)
+		}
+		if strings.HasPrefix(file, ".github/workflows/") {
+			_, err = workflow.Parse([]byte(content))
+		} else if b := path.Base(file); b == "action.yml" || b == "action.yaml" {
+			_, err = metadata.Parse([]byte(content))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("template %s: generated %s: %w", t.Name, file, err)
+		}
+		files = append(files, File{Path: file, Content: []byte(content)})
+	}
+	return files, nil
+}
+
+func execute(text string, data map[string]string) (string, error) {
+	tmpl, err := template.New("").Delims("[[", "]]").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Write writes files under root. It refuses to overwrite files unless
+// force is set, before writing any.
+func Write(root string, files []File, force bool) error {
+	if !force {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(root, f.Path)); err == nil {
+				return fmt.Errorf("%s already exists", f.Path)
+			}
+		}
+	}
+	for _, f := range files {
+		p := filepath.Join(root, f.Path)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, f.Content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Registry is a set of templates.
+type Registry interface {
+	// Templates returns the templates, sorted by name.
+	Templates() ([]*Template, error)
+}
+
+// Lookup returns the named template of r.
+func Lookup(r Registry, name string) (*Template, error) {
+	templates, err := r.Templates()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range templates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("no template %s", name)
+}
+
+// Dir is a directory of templates, one YAML file per Template.
+type Dir string
+
+// Templates implements Registry.
+func (d Dir) Templates() ([]*Template, error) {
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(string(d), pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	var out []*Template
+	for _, file := range files {
+		t, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	sortTemplates(out)
+	return out, nil
+}
+
+// Load reads a template from a YAML file. Its name defaults to the file
+// name without the extension.
+func Load(file string) (*Template, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var t Template
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	if len(t.Files) == 0 {
+		return nil, fmt.Errorf("%s: template %s has no files", file, t.Name)
+	}
+	return &t, nil
+}
+
+// Chain combines registries. A template of a later registry replaces one
+// of the same name of an earlier one, so an organization can override the
+// built-in templates.
+type Chain []Registry
+
+// Templates implements Registry.
+func (c Chain) Templates() ([]*Template, error) {
+	byName := map[string]*Template{}
+	for _, r := range c {
+		templates, err := r.Templates()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range templates {
+			byName[t.Name] = t
+		}
+	}
+	out := make([]*Template, 0, len(byName))
+	for _, t := range byName {
+		out = append(out, t)
+	}
+	sortTemplates(out)
+	return out, nil
+}
+
+func sortTemplates(templates []*Template) {
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"testingdashboard/m/v2/lint"
+	"testingdashboard/m/v2/metadata"
+	"testingdashboard/m/v2/workflow"
+)
+
+var (
+	workflowOnce, actionOnce     sync.Once
+	workflowSchema, actionSchema *Schema
+)
+
+// Workflow returns the schema of workflow files. It is shared and must not
+// be modified.
+func Workflow() *Schema {
+	workflowOnce.Do(func() {
+		workflowSchema = Generate(reflect.TypeFor[workflow.Workflow]())
+		workflowSchema.Title = "GitHub Actions workflow"
+		workflowSchema.Description = "A workflow file under .github/workflows."
+	})
+	return workflowSchema
+}
+
+// Action returns the schema of action metadata files. It is shared and
+// must not be modified.
+func Action() *Schema {
+	actionOnce.Do(func() {
+		actionSchema = Generate(reflect.TypeFor[metadata.Action]())
+		actionSchema.Title = "GitHub Actions action metadata"
+		actionSchema.Description = "The action.yml of an action."
+	})
+	return actionSchema
+}
+
+// Generate returns the schema of the YAML documents that decode into the
+// struct type t. Fields follow their yaml tags; those without omitempty
+// are required and unknown keys are not allowed. Types that decode several
+// forms, such as runs-on, have schemas of their own.
+func Generate(t reflect.Type) *Schema {
+	g := &generator{defs: map[string]*Schema{}, names: map[reflect.Type]string{}, taken: map[string]bool{}}
+	root := g.object(t)
+	root.Schema = Draft
+	root.Defs = g.defs
+	return root
+}
+
+type generator struct {
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+	taken map[string]bool
+}
+
+// expressionPattern matches a value computed by a ${{ }} expression.
+const expressionPattern = `^\s*\$\{\{[\s\S]*\}\}\s*$`
+
+// ref returns a reference to the definition name, adding it with build if
+// needed.
+func (g *generator) ref(name string, build func() *Schema) *Schema {
+	if _, ok := g.defs[name]; !ok {
+		// Reserve the name so that recursive types refer to it.
+		g.defs[name] = nil
+		g.defs[name] = build()
+	}
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+func (g *generator) expression() *Schema {
+	return g.ref("Expression", func() *Schema {
+		return &Schema{Type: "string", Pattern: expressionPattern, Description: "an expression such as ${{ inputs.name }}"}
+	})
+}
+
+func (g *generator) scalar() *Schema {
+	return g.ref("Scalar", func() *Schema {
+		return &Schema{Description: "a string, number or boolean", OneOf: []*Schema{{Type: "string"}, {Type: "number"}, {Type: "boolean"}}}
+	})
+}
+
+// name returns the definition name of t, qualified by its package if
+// another type has the same name.
+func (g *generator) name(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if g.taken[name] {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t], g.taken[name] = name, true
+	return name
+}
+
+// schema returns the schema of values of t.
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if build, ok := overrides[t]; ok {
+		return g.ref(g.name(t), func() *Schema { return build(g) })
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		values := g.schema(t.Elem())
+		if t.Elem().Kind() == reflect.String {
+			// Values such as env and with are strings once decoded, but
+			// numbers and booleans are written as they are.
+			values = g.scalar()
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}
+	case reflect.Struct:
+		return g.ref(g.name(t), func() *Schema { return g.object(t) })
+	}
+	// Interfaces hold any value.
+	return &Schema{}
+}
+
+// object returns the schema of the mapping the struct t decodes from.
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: False}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("yaml")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		s.Properties[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+func stringsSchema(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+// overrides build the schemas of types whose UnmarshalYAML accepts more
+// than their fields.
+var overrides map[reflect.Type]func(g *generator) *Schema
+
+func init() {
+	overrides = map[reflect.Type]func(g *generator) *Schema{
+		reflect.TypeFor[workflow.StringList](): func(g *generator) *Schema {
+			return &Schema{Description: "a string or a list of strings", OneOf: []*Schema{{Type: "string"}, {Type: "array", Items: &Schema{Type: "string"}}}}
+		},
+		reflect.TypeFor[workflow.BoolExpr](): func(g *generator) *Schema {
+			return &Schema{OneOf: []*Schema{{Type: "boolean"}, g.expression()}}
+		},
+		reflect.TypeFor[workflow.NumberExpr](): func(g *generator) *Schema {
+			return &Schema{OneOf: []*Schema{{Type: "number"}, g.expression()}}
+		},
+		reflect.TypeFor[workflow.RunsOn](): func(g *generator) *Schema {
+			labels := g.schema(reflect.TypeFor[workflow.StringList]())
+			return &Schema{
+				Description: "a runner label, a list of labels or a runner group",
+				OneOf: []*Schema{
+					{Type: "string"},
+					{Type: "array", Items: &Schema{Type: "string"}},
+					{Type: "object", Properties: map[string]*Schema{"group": {Type: "string"}, "labels": labels}, AdditionalProperties: False},
+				},
+			}
+		},
+		reflect.TypeFor[workflow.Permissions](): func(g *generator) *Schema {
+			scopes := map[string]*Schema{}
+			for _, scope := range lint.Scopes {
+				scopes[scope] = &Schema{Enum: stringsSchema([]string{workflow.PermissionRead, workflow.PermissionWrite, workflow.PermissionNone})}
+			}
+			return &Schema{OneOf: []*Schema{
+				{Enum: stringsSchema([]string{"read-all", "write-all"})},
+				{Type: "object", Properties: scopes, AdditionalProperties: False},
+			}}
+		},
+		reflect.TypeFor[workflow.Concurrency](): func(g *generator) *Schema {
+			return &Schema{OneOf: []*Schema{{Type: "string"}, g.object(reflect.TypeFor[workflow.Concurrency]())}}
+		},
+		reflect.TypeFor[workflow.Container](): func(g *generator) *Schema {
+			return &Schema{OneOf: []*Schema{{Type: "string"}, g.object(reflect.TypeFor[workflow.Container]())}}
+		},
+		reflect.TypeFor[workflow.Environment](): func(g *generator) *Schema {
+			return &Schema{OneOf: []*Schema{{Type: "string"}, g.object(reflect.TypeFor[workflow.Environment]())}}
+		},
+		reflect.TypeFor[workflow.JobSecrets](): func(g *generator) *Schema {
+			return &Schema{OneOf: []*Schema{{Enum: []any{"inherit"}}, {Type: "object", AdditionalProperties: g.scalar()}}}
+		},
+		reflect.TypeFor[workflow.Matrix](): func(g *generator) *Schema {
+			combos := &Schema{OneOf: []*Schema{{Type: "array", Items: &Schema{Type: "object"}}, g.expression()}}
+			return &Schema{OneOf: []*Schema{
+				g.expression(),
+				{
+					Type:                 "object",
+					Properties:           map[string]*Schema{"include": combos, "exclude": combos},
+					AdditionalProperties: &Schema{OneOf: []*Schema{{Type: "array"}, g.expression()}},
+				},
+			}}
+		},
+		reflect.TypeFor[workflow.Triggers](): func(g *generator) *Schema {
+			event := g.ref("EventName", func() *Schema { return &Schema{Enum: stringsSchema(lint.Events)} })
+			config := &Schema{OneOf: []*Schema{{Type: "null"}, g.schema(reflect.TypeFor[workflow.Event]())}}
+			events := map[string]*Schema{}
+			for _, name := range lint.Events {
+				events[name] = config
+			}
+			events["schedule"] = &Schema{Type: "array", Items: g.schema(reflect.TypeFor[workflow.Schedule]())}
+			return &Schema{
+				Description: "an event, a list of events or a mapping of events to their configuration",
+				OneOf: []*Schema{
+					event,
+					{Type: "array", Items: event},
+					{Type: "object", Properties: events, AdditionalProperties: False},
+				},
+			}
+		},
+		reflect.TypeFor[workflow.Job](): func(g *generator) *Schema {
+			s := g.object(reflect.TypeFor[workflow.Job]())
+			// A job runs steps on a runner or calls a reusable workflow.
+			s.AnyOf = []*Schema{{Required: []string{"runs-on"}}, {Required: []string{"uses"}}}
+			return s
+		},
+		reflect.TypeFor[workflow.Step](): func(g *generator) *Schema {
+			s := g.object(reflect.TypeFor[workflow.Step]())
+			s.AnyOf = []*Schema{{Required: []string{"run"}}, {Required: []string{"uses"}}}
+			return s
+		},
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/workflow"
+)
+
+// Error is a place where a document does not match its schema.
+type Error struct {
+	Pos workflow.Position `json:"pos"`
+	// Path is the dotted path of the value, empty for the document.
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e Error) String() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Pos.Line, e.Pos.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Pos.Line, e.Pos.Column, e.Path, e.Message)
+}
+
+// Validate parses the YAML document data and validates it against s. The
+// error is for data that is not YAML.
+func Validate(s *Schema, data []byte) ([]Error, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return []Error{{Pos: workflow.Position{Line: 1, Column: 1}, Message: "empty document"}}, nil
+	}
+	return s.ValidateNode(&doc), nil
+}
+
+// ValidateNode validates a parsed document or value against s, which must
+// be a root schema for its references to resolve. The errors are in source
+// order.
+func (s *Schema) ValidateNode(node *yaml.Node) []Error {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	v := &validator{root: s, patterns: map[string]*regexp.Regexp{}}
+	errs := v.validate(s, node, nil)
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Pos.Line != errs[j].Pos.Line {
+			return errs[i].Pos.Line < errs[j].Pos.Line
+		}
+		return errs[i].Pos.Column < errs[j].Pos.Column
+	})
+	return errs
+}
+
+type validator struct {
+	root     *Schema
+	patterns map[string]*regexp.Regexp
+}
+
+// resolve follows the $ref of s into the root's $defs.
+func (v *validator) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = v.root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+	}
+	return s
+}
+
+func errorAt(node *yaml.Node, path []string, format string, args ...any) Error {
+	return Error{Pos: workflow.Position{Line: node.Line, Column: node.Column}, Path: strings.Join(path, "."), Message: fmt.Sprintf(format, args...)}
+}
+
+// typeOf returns the JSON type of node.
+func typeOf(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+func hasType(want string, node *yaml.Node) bool {
+	got := typeOf(node)
+	return want == got || (want == "number" && got == "integer")
+}
+
+// accepts reports whether node has a type s allows, ignoring everything
+// else about it.
+func (v *validator) accepts(s *Schema, node *yaml.Node) bool {
+	s = v.resolve(s)
+	switch {
+	case s == nil:
+		return true
+	case s.never:
+		return false
+	case s.Type != "":
+		return hasType(s.Type, node)
+	case s.Enum != nil:
+		return node.Kind == yaml.ScalarNode
+	}
+	branches := append(s.OneOf, s.AnyOf...)
+	for _, b := range branches {
+		if v.accepts(b, node) {
+			return true
+		}
+	}
+	return len(branches) == 0
+}
+
+// describe names what s allows, for errors.
+func (v *validator) describe(s *Schema) string {
+	s = v.resolve(s)
+	switch {
+	case s.Description != "":
+		return s.Description
+	case s.Type != "":
+		return s.Type
+	case s.Enum != nil && len(s.Enum) <= maxEnum:
+		return "one of " + enumList(s.Enum)
+	case s.Enum != nil:
+		return "a known value"
+	}
+	var names []string
+	for _, b := range append(s.OneOf, s.AnyOf...) {
+		names = append(names, v.describe(b))
+	}
+	return strings.Join(names, " or ")
+}
+
+// maxEnum is how many allowed values errors list.
+const maxEnum = 8
+
+func enumList(values []any) string {
+	names := make([]string, len(values))
+	for i, e := range values {
+		names[i] = fmt.Sprint(e)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (v *validator) validate(s *Schema, node *yaml.Node, path []string) []Error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	s = v.resolve(s)
+	if s == nil {
+		return nil
+	}
+	if s.never {
+		return []Error{errorAt(node, path, "not allowed")}
+	}
+	if s.Type != "" && !hasType(s.Type, node) {
+		return []Error{errorAt(node, path, "expected %s, found %s", v.describe(s), typeOf(node))}
+	}
+	var errs []Error
+	if s.Enum != nil && !v.inEnum(s, node) {
+		if node.Kind != yaml.ScalarNode {
+			return []Error{errorAt(node, path, "expected %s, found %s", v.describe(s), typeOf(node))}
+		}
+		if len(s.Enum) <= maxEnum {
+			errs = append(errs, errorAt(node, path, "%q is not one of %s", node.Value, enumList(s.Enum)))
+		} else {
+			errs = append(errs, errorAt(node, path, "unknown value %q", node.Value))
+		}
+	}
+	if s.Pattern != "" && typeOf(node) == "string" {
+		if !v.regexp(s.Pattern).MatchString(node.Value) {
+			if s.Description != "" {
+				errs = append(errs, errorAt(node, path, "expected %s", s.Description))
+			} else {
+				errs = append(errs, errorAt(node, path, "%q does not match %s", node.Value, s.Pattern))
+			}
+		}
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		errs = append(errs, v.object(s, node, path)...)
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range node.Content {
+				errs = append(errs, v.validate(s.Items, item, append(path[:len(path):len(path)], strconv.Itoa(i)))...)
+			}
+		}
+	}
+	if s.OneOf != nil {
+		errs = append(errs, v.branches(s, s.OneOf, node, path)...)
+	}
+	if s.AnyOf != nil {
+		errs = append(errs, v.branches(s, s.AnyOf, node, path)...)
+	}
+	return errs
+}
+
+// regexp compiles pattern once per validation. Patterns of the generated
+// schemas are valid.
+func (v *validator) regexp(pattern string) *regexp.Regexp {
+	re, ok := v.patterns[pattern]
+	if !ok {
+		re = regexp.MustCompile(pattern)
+		v.patterns[pattern] = re
+	}
+	return re
+}
+
+func (v *validator) inEnum(s *Schema, node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	for _, e := range s.Enum {
+		if fmt.Sprint(e) == node.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) object(s *Schema, node *yaml.Node, path []string) []Error {
+	var errs []Error
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			// Merge keys bring in the keys of another mapping.
+			continue
+		}
+		seen[key.Value] = true
+		sub := s.Properties[key.Value]
+		if sub == nil {
+			for pattern, ps := range s.PatternProperties {
+				if v.regexp(pattern).MatchString(key.Value) {
+					sub = ps
+					break
+				}
+			}
+		}
+		if sub == nil {
+			sub = s.AdditionalProperties
+		}
+		if sub == False {
+			errs = append(errs, errorAt(key, path, "unknown key %q", key.Value))
+			continue
+		}
+		if sub != nil {
+			errs = append(errs, v.validate(sub, value, append(path[:len(path):len(path)], key.Value))...)
+		}
+	}
+	for _, name := range s.Required {
+		if !seen[name] {
+			errs = append(errs, errorAt(node, path, "missing key %q", name))
+		}
+	}
+	return errs
+}
+
+// branches validates node against the alternatives of s. Node matches if
+// it matches any of them, which for the generated schemas is at most one.
+// Otherwise the errors are those of the alternative of node's type.
+func (v *validator) branches(s *Schema, alternatives []*Schema, node *yaml.Node, path []string) []Error {
+	var candidates [][]Error
+	requiredOnly := true
+	var keys []string
+	for _, b := range alternatives {
+		errs := v.validate(b, node, path)
+		if len(errs) == 0 {
+			return nil
+		}
+		if r := v.resolve(b); len(r.Required) > 0 && r.Type == "" && r.Properties == nil {
+			keys = append(keys, r.Required...)
+		} else {
+			requiredOnly = false
+		}
+		if v.accepts(b, node) {
+			candidates = append(candidates, errs)
+		}
+	}
+	if requiredOnly {
+		quoted := make([]string, len(keys))
+		for i, k := range keys {
+			quoted[i] = strconv.Quote(k)
+		}
+		return []Error{errorAt(node, path, "missing one of the keys %s", strings.Join(quoted, ", "))}
+	}
+	if len(candidates) == 0 {
+		return []Error{errorAt(node, path, "expected %s, found %s", v.describe(s), typeOf(node))}
+	}
+	best := candidates[0]
+	for _, errs := range candidates[1:] {
+		if len(errs) < len(best) {
+			best = errs
+		}
+	}
+	return best
+}
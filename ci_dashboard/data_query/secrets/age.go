@@ -0,0 +1,361 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Age reads secrets from a dotenv file encrypted with age
+// (https://age-encryption.org), to X25519 recipients or a passphrase.
+type Age struct {
+	Path string
+	// Identities are files of AGE-SECRET-KEY-1 lines. Defaults to the
+	// list in $AGE_IDENTITY_FILE, separated like PATH.
+	Identities []string
+	// Passphrase decrypts passphrase-encrypted files. Defaults to
+	// $AGE_PASSPHRASE.
+	Passphrase string
+}
+
+func (a *Age) String() string { return a.Path }
+
+// Load decrypts and parses the file.
+func (a *Age) Load(context.Context) (map[string]string, error) {
+	data, err := os.ReadFile(expandHome(a.Path))
+	if err != nil {
+		return nil, err
+	}
+	identities := a.Identities
+	if len(identities) == 0 && os.Getenv("AGE_IDENTITY_FILE") != "" {
+		identities = filepath.SplitList(os.Getenv("AGE_IDENTITY_FILE"))
+	}
+	var keys [][]byte
+	for _, path := range identities {
+		k, err := readIdentities(expandHome(path))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k...)
+	}
+	passphrase := a.Passphrase
+	if passphrase == "" {
+		passphrase = os.Getenv("AGE_PASSPHRASE")
+	}
+	plain, err := ageDecrypt(data, keys, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDotenv(plain)
+}
+
+// readIdentities reads the X25519 secret keys in an age identity file.
+func readIdentities(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys [][]byte
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hrp, key, err := bech32Decode(line)
+		if err != nil || hrp != "age-secret-key-" || len(key) != curve25519.ScalarSize {
+			return nil, fmt.Errorf("%s:%d: not an age X25519 identity", path, i+1)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no identities found", path)
+	}
+	return keys, nil
+}
+
+const (
+	ageVersion   = "age-encryption.org/v1"
+	ageArmor     = "-----BEGIN AGE ENCRYPTED FILE-----"
+	ageArmorEnd  = "-----END AGE ENCRYPTED FILE-----"
+	ageChunkSize = 64 << 10
+)
+
+var ageBase64 = base64.RawStdEncoding.Strict()
+
+type ageStanza struct {
+	args []string
+	body []byte
+}
+
+// ageDecrypt decrypts an age file, binary or armored, with the first of
+// keys or the passphrase that unwraps its file key.
+func ageDecrypt(data []byte, keys [][]byte, passphrase string) ([]byte, error) {
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte(ageArmor)) {
+		body, ok := bytes.CutSuffix(bytes.TrimPrefix(trimmed, []byte(ageArmor)), []byte(ageArmorEnd))
+		if !ok {
+			return nil, errors.New("invalid armor: missing end line")
+		}
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(body), nil))); err != nil {
+			return nil, fmt.Errorf("invalid armor: %w", err)
+		}
+	}
+	stanzas, header, mac, payload, err := parseAgeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := unwrapFileKey(stanzas, keys, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, ageKey(fileKey, nil, "header"))
+	h.Write(header)
+	if !hmac.Equal(h.Sum(nil), mac) {
+		return nil, errors.New("invalid header MAC")
+	}
+	return agePayload(fileKey, payload)
+}
+
+// parseAgeHeader splits an age file into its recipient stanzas, the header
+// bytes its MAC covers, the MAC and the payload.
+func parseAgeHeader(data []byte) (stanzas []ageStanza, header, mac, payload []byte, err error) {
+	pos := 0
+	readLine := func() (string, error) {
+		i := bytes.IndexByte(data[pos:], '\n')
+		if i < 0 {
+			return "", errors.New("invalid header: unexpected end of file")
+		}
+		line := string(data[pos : pos+i])
+		pos += i + 1
+		return line, nil
+	}
+	if line, err := readLine(); err != nil || line != ageVersion {
+		return nil, nil, nil, nil, errors.New("not an age v1 file")
+	}
+	for {
+		start := pos
+		line, err := readLine()
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if rest, ok := strings.CutPrefix(line, "--- "); ok {
+			if mac, err = ageBase64.DecodeString(rest); err != nil {
+				return nil, nil, nil, nil, fmt.Errorf("invalid header MAC: %w", err)
+			}
+			return stanzas, data[:start+3], mac, data[pos:], nil
+		}
+		rest, ok := strings.CutPrefix(line, "-> ")
+		if !ok {
+			return nil, nil, nil, nil, fmt.Errorf("invalid header line %q", line)
+		}
+		st := ageStanza{args: strings.Split(rest, " ")}
+		for {
+			line, err := readLine()
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			b, err := ageBase64.DecodeString(line)
+			if err != nil || len(line) > 64 {
+				return nil, nil, nil, nil, errors.New("invalid stanza body")
+			}
+			st.body = append(st.body, b...)
+			if len(line) < 64 {
+				break
+			}
+		}
+		stanzas = append(stanzas, st)
+	}
+}
+
+func unwrapFileKey(stanzas []ageStanza, keys [][]byte, passphrase string) ([]byte, error) {
+	for _, st := range stanzas {
+		switch st.args[0] {
+		case "X25519":
+			if len(st.args) != 2 {
+				return nil, errors.New("invalid X25519 stanza")
+			}
+			share, err := ageBase64.DecodeString(st.args[1])
+			if err != nil || len(share) != curve25519.PointSize {
+				return nil, errors.New("invalid X25519 stanza")
+			}
+			for _, key := range keys {
+				shared, err := curve25519.X25519(key, share)
+				if err != nil {
+					continue
+				}
+				pub, _ := curve25519.X25519(key, curve25519.Basepoint)
+				wrap := ageKey(shared, append(append([]byte(nil), share...), pub...), "age-encryption.org/v1/X25519")
+				if fileKey, err := ageOpen(wrap, st.body); err == nil {
+					return fileKey, nil
+				}
+			}
+		case "scrypt":
+			if len(stanzas) != 1 || len(st.args) != 3 {
+				return nil, errors.New("invalid scrypt stanza")
+			}
+			if passphrase == "" {
+				return nil, errors.New("the file is encrypted with a passphrase: set AGE_PASSPHRASE")
+			}
+			salt, err := ageBase64.DecodeString(st.args[1])
+			logN, nerr := strconv.Atoi(st.args[2])
+			if err != nil || len(salt) != 16 || nerr != nil || logN <= 0 || logN > 30 {
+				return nil, errors.New("invalid scrypt stanza")
+			}
+			wrap, err := scrypt.Key([]byte(passphrase), append([]byte("age-encryption.org/v1/scrypt"), salt...), 1<<logN, 8, 1, chacha20poly1305.KeySize)
+			if err != nil {
+				return nil, err
+			}
+			fileKey, err := ageOpen(wrap, st.body)
+			if err != nil {
+				return nil, errors.New("incorrect passphrase")
+			}
+			return fileKey, nil
+		}
+	}
+	return nil, errors.New("no identity matches any of the file's recipients")
+}
+
+// ageOpen decrypts a wrapped 16-byte file key.
+func ageOpen(key, body []byte) ([]byte, error) {
+	if len(body) != 16+chacha20poly1305.Overhead {
+		return nil, errors.New("invalid wrapped key")
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), body, nil)
+}
+
+// ageKey derives a 32-byte key with HKDF-SHA256.
+func ageKey(secret, salt []byte, info string) []byte {
+	key := make([]byte, chacha20poly1305.KeySize)
+	io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key)
+	return key
+}
+
+// agePayload decrypts the STREAM-encrypted payload: a 16-byte nonce, then
+// 64 KiB chunks, the last one flagged in its nonce.
+func agePayload(fileKey, payload []byte) ([]byte, error) {
+	if len(payload) < 16 {
+		return nil, errors.New("invalid payload: missing nonce")
+	}
+	aead, err := chacha20poly1305.New(ageKey(fileKey, payload[:16], "payload"))
+	if err != nil {
+		return nil, err
+	}
+	rest := payload[16:]
+	var out []byte
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	for counter := uint64(0); ; counter++ {
+		n := min(len(rest), ageChunkSize+chacha20poly1305.Overhead)
+		last := n == len(rest)
+		binary.BigEndian.PutUint64(nonce[3:11], counter)
+		if last {
+			nonce[11] = 1
+		}
+		chunk, err := aead.Open(nil, nonce, rest[:n], nil)
+		if err != nil || (last && len(chunk) == 0 && counter > 0) {
+			return nil, errors.New("invalid payload: decryption failed")
+		}
+		out = append(out, chunk...)
+		if last {
+			return out, nil
+		}
+		rest = rest[n:]
+	}
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Decode decodes a BIP 173 string, the encoding of age keys.
+func bech32Decode(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("mixed case")
+	}
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, errors.New("invalid separator")
+	}
+	hrp := s[:sep]
+	var values []byte
+	for _, c := range hrp {
+		values = append(values, byte(c>>5))
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, byte(c&31))
+	}
+	n := len(values)
+	for i := sep + 1; i < len(s); i++ {
+		d := strings.IndexByte(bech32Charset, s[i])
+		if d < 0 {
+			return "", nil, fmt.Errorf("invalid character %q", s[i])
+		}
+		values = append(values, byte(d))
+	}
+	if bech32Polymod(values) != 1 {
+		return "", nil, errors.New("invalid checksum")
+	}
+	data := values[n : len(values)-6]
+	// Regroup the 5-bit values into bytes.
+	var out []byte
+	acc, bits := uint32(0), 0
+	for _, v := range data {
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	if bits >= 5 || acc&(1<<bits-1) != 0 {
+		return "", nil, errors.New("invalid padding")
+	}
+	return hrp, out, nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := range gen {
+			if b>>i&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
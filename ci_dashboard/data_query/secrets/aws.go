@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AWSSecretsManager reads secrets from AWS Secrets Manager.
+type AWSSecretsManager struct {
+	Secrets []Ref
+	// Region defaults to $AWS_REGION or $AWS_DEFAULT_REGION.
+	Region string
+	// Credentials default to $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY
+	// and $AWS_SESSION_TOKEN, then the $AWS_PROFILE or default profile of
+	// ~/.aws/credentials.
+	Credentials *AWSCredentials
+	// Endpoint overrides the regional endpoint.
+	Endpoint string
+	Client   *http.Client
+}
+
+// AWSCredentials are the keys requests are signed with.
+type AWSCredentials struct {
+	AccessKeyID, SecretAccessKey, SessionToken string
+}
+
+func (a *AWSSecretsManager) String() string {
+	var ids []string
+	for _, r := range a.Secrets {
+		ids = append(ids, r.ID)
+	}
+	return "aws:" + strings.Join(ids, ",")
+}
+
+// Load reads the current version of each secret.
+func (a *AWSSecretsManager) Load(ctx context.Context) (map[string]string, error) {
+	region := firstNonEmpty(a.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return nil, errors.New("no region: set AWS_REGION or add ?region=")
+	}
+	creds := a.Credentials
+	if creds == nil {
+		var err error
+		if creds, err = awsCredentials(); err != nil {
+			return nil, err
+		}
+	}
+	endpoint := firstNonEmpty(a.Endpoint, "https://secretsmanager."+region+".amazonaws.com")
+	out := map[string]string{}
+	for _, ref := range a.Secrets {
+		body, _ := json.Marshal(map[string]string{"SecretId": ref.ID})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+		signV4(req, body, creds, region, "secretsmanager", time.Now().UTC())
+		var resp struct {
+			SecretString string `json:"SecretString"`
+		}
+		if err := doJSON(a.Client, req, &resp); err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", ref.ID, err)
+		}
+		bind(out, ref, resp.SecretString)
+	}
+	return out, nil
+}
+
+func awsCredentials() (*AWSCredentials, error) {
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		return &AWSCredentials{AccessKeyID: id, SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"), SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	profile := firstNonEmpty(os.Getenv("AWS_PROFILE"), "default")
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("no AWS credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or configure ~/.aws/credentials")
+	}
+	defer f.Close()
+	creds := &AWSCredentials{}
+	section := ""
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok || section != profile {
+			continue
+		}
+		switch strings.TrimSpace(k) {
+		case "aws_access_key_id":
+			creds.AccessKeyID = strings.TrimSpace(v)
+		case "aws_secret_access_key":
+			creds.SecretAccessKey = strings.TrimSpace(v)
+		case "aws_session_token":
+			creds.SessionToken = strings.TrimSpace(v)
+		}
+	}
+	if creds.AccessKeyID == "" {
+		return nil, fmt.Errorf("no credentials for profile %q in %s", profile, path)
+	}
+	return creds, nil
+}
+
+// signV4 signs req with AWS Signature Version 4.
+func signV4(req *http.Request, body []byte, creds *AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	payload := sha256.Sum256(body)
+
+	headers := map[string]string{"host": req.URL.Host}
+	names := []string{"host"}
+	for k := range req.Header {
+		lk := strings.ToLower(k)
+		headers[lk] = strings.TrimSpace(req.Header.Get(k))
+		names = append(names, lk)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signed, hex.EncodeToString(payload[:])}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+", SignedHeaders="+signed+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
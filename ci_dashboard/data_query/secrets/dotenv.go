@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Dotenv reads secrets from a dotenv file.
+type Dotenv struct {
+	Path string
+}
+
+func (d *Dotenv) String() string { return d.Path }
+
+// Load parses the file.
+func (d *Dotenv) Load(context.Context) (map[string]string, error) {
+	data, err := os.ReadFile(expandHome(d.Path))
+	if err != nil {
+		return nil, err
+	}
+	return ParseDotenv(data)
+}
+
+// ParseDotenv parses NAME=VALUE lines, ignoring blank lines, # comments
+// and a leading "export". Values may be single-quoted, kept as is, or
+// double-quoted, where \n, \t, \" and \\ are unescaped; either may span
+// lines.
+func ParseDotenv(data []byte) (map[string]string, error) {
+	s := strings.ReplaceAll(string(data), "\r\n", "\n")
+	out := map[string]string{}
+	line := 1
+	for s != "" {
+		var l string
+		l, s, _ = strings.Cut(s, "\n")
+		start := line
+		line++
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
+		}
+		l = strings.TrimPrefix(l, "export ")
+		name, value, ok := strings.Cut(l, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected NAME=VALUE", start)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			out[name] = value
+			continue
+		}
+		quote := value[0]
+		value = value[1:]
+		// Take further lines until the closing quote.
+		for {
+			if end := closingQuote(value, quote); end >= 0 {
+				value = value[:end]
+				break
+			}
+			if s == "" {
+				return nil, fmt.Errorf("line %d: unterminated %c quote", start, quote)
+			}
+			var next string
+			next, s, _ = strings.Cut(s, "\n")
+			line++
+			value += "\n" + next
+		}
+		if quote == '"' {
+			value = unescape(value)
+		}
+		out[name] = value
+	}
+	return out, nil
+}
+
+// closingQuote returns the index of the quote ending s, skipping escaped
+// double quotes.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// Env passes through variables of the runner's own environment.
+type Env struct {
+	// Names are variable names, or prefixes ending in * whose matches are
+	// provided without the prefix, so SECRET_* turns SECRET_TOKEN into
+	// TOKEN.
+	Names []string
+}
+
+func (e *Env) String() string { return "env:" + strings.Join(e.Names, ",") }
+
+// Load reads the variables. Unset variables are left out.
+func (e *Env) Load(context.Context) (map[string]string, error) {
+	out := map[string]string{}
+	for _, name := range e.Names {
+		prefix, ok := strings.CutSuffix(name, "*")
+		if !ok {
+			if v, ok := os.LookupEnv(name); ok {
+				out[name] = v
+			}
+			continue
+		}
+		for _, kv := range os.Environ() {
+			k, v, _ := strings.Cut(kv, "=")
+			if rest, ok := strings.CutPrefix(k, prefix); ok && rest != "" {
+				out[rest] = v
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const gcpMetadataToken = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCPSecretManager reads secrets from Google Cloud Secret Manager.
+type GCPSecretManager struct {
+	Project string
+	// Secrets name secrets of Project, with an optional @VERSION; the
+	// latest version is read by default.
+	Secrets []Ref
+	// Token is an OAuth access token. Defaults to
+	// $GOOGLE_OAUTH_ACCESS_TOKEN, then gcloud auth print-access-token,
+	// then the metadata server's service account.
+	Token string
+	// Endpoint overrides https://secretmanager.googleapis.com.
+	Endpoint string
+	Client   *http.Client
+}
+
+func (g *GCPSecretManager) String() string {
+	var ids []string
+	for _, r := range g.Secrets {
+		ids = append(ids, r.ID)
+	}
+	return "gcp:" + g.Project + "/" + strings.Join(ids, ",")
+}
+
+// Load accesses each secret version.
+func (g *GCPSecretManager) Load(ctx context.Context) (map[string]string, error) {
+	token, err := g.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimSuffix(firstNonEmpty(g.Endpoint, "https://secretmanager.googleapis.com"), "/")
+	out := map[string]string{}
+	for _, ref := range g.Secrets {
+		name, version, ok := strings.Cut(ref.ID, "@")
+		if !ok {
+			version = "latest"
+		}
+		u := fmt.Sprintf("%s/v1/projects/%s/secrets/%s/versions/%s:access", endpoint, url.PathEscape(g.Project), url.PathEscape(name), url.PathEscape(version))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		var resp struct {
+			Payload struct {
+				Data string `json:"data"`
+			} `json:"payload"`
+		}
+		if err := doJSON(g.Client, req, &resp); err != nil {
+			return nil, fmt.Errorf("failed to access %s: %w", ref.ID, err)
+		}
+		value, err := base64.StdEncoding.DecodeString(resp.Payload.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", ref.ID, err)
+		}
+		bind(out, Ref{Name: ref.Name, ID: name}, string(value))
+	}
+	return out, nil
+}
+
+func (g *GCPSecretManager) token(ctx context.Context) (string, error) {
+	if t := firstNonEmpty(g.Token, os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")); t != "" {
+		return t, nil
+	}
+	if out, err := exec.CommandContext(ctx, "gcloud", "auth", "print-access-token").Output(); err == nil {
+		if t := strings.TrimSpace(string(out)); t != "" {
+			return t, nil
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpMetadataToken, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(g.Client, req, &resp); err != nil || resp.AccessToken == "" {
+		return "", errors.New("no Google credentials: set GOOGLE_OAUTH_ACCESS_TOKEN or run gcloud auth login")
+	}
+	return resp.AccessToken, nil
+}
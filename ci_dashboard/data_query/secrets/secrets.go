@@ -0,0 +1,202 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package secrets loads the secrets context of local runs from files,
+// the environment and secret managers, so they need not be kept in plain
+// text next to the workflow.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Provider supplies secrets by name.
+type Provider interface {
+	// Load returns every secret the provider holds.
+	Load(ctx context.Context) (map[string]string, error)
+	// String describes the provider in errors, without secret values.
+	String() string
+}
+
+// Load merges the secrets of providers, with later providers taking
+// precedence.
+func Load(ctx context.Context, providers []Provider) (map[string]string, error) {
+	out := map[string]string{}
+	for _, p := range providers {
+		values, err := p.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load secrets from %s: %w", p, err)
+		}
+		for k, v := range values {
+			out[k] = v
+		}
+	}
+	return out, nil
+}
+
+// Parse returns the provider described by spec, one of:
+//
+//	FILE                       a dotenv file, or an age file if FILE ends in .age
+//	dotenv:FILE                a dotenv file
+//	age:FILE[?identity=KEY]    an age-encrypted dotenv file
+//	env:NAME,PREFIX_*          variables of the runner's environment
+//	vault:MOUNT/PATH[?kv=1]    a HashiCorp Vault KV secret
+//	aws:ID,NAME=ID[?region=R]  AWS Secrets Manager secrets
+//	gcp:PROJECT/ID,NAME=ID     Google Cloud Secret Manager secrets
+//
+// Secret manager secrets holding a JSON object of strings provide each of
+// its keys unless NAME= binds the whole value to one name.
+func Parse(spec string) (Provider, error) {
+	kind, rest, ok := strings.Cut(spec, ":")
+	if !ok || len(kind) == 1 {
+		// No scheme, or a Windows drive letter.
+		if strings.HasSuffix(spec, ".age") {
+			return &Age{Path: spec}, nil
+		}
+		return &Dotenv{Path: spec}, nil
+	}
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets provider %q: %w", spec, err)
+	}
+	if rest == "" {
+		return nil, fmt.Errorf("invalid secrets provider %q: missing %s location", spec, kind)
+	}
+	switch kind {
+	case "dotenv":
+		return &Dotenv{Path: rest}, nil
+	case "age":
+		a := &Age{Path: rest}
+		if id := query.Get("identity"); id != "" {
+			a.Identities = []string{id}
+		}
+		return a, nil
+	case "env":
+		return &Env{Names: strings.Split(rest, ",")}, nil
+	case "vault":
+		v := &Vault{Path: rest, KVVersion: 2}
+		if query.Get("kv") == "1" {
+			v.KVVersion = 1
+		}
+		return v, nil
+	case "aws":
+		return &AWSSecretsManager{Region: query.Get("region"), Secrets: parseRefs(rest)}, nil
+	case "gcp":
+		project, names, ok := strings.Cut(rest, "/")
+		if !ok || project == "" || names == "" {
+			return nil, fmt.Errorf("invalid secrets provider %q: want gcp:PROJECT/SECRET", spec)
+		}
+		return &GCPSecretManager{Project: project, Secrets: parseRefs(names)}, nil
+	}
+	return nil, fmt.Errorf("unknown secrets provider %q: use dotenv, age, env, vault, aws or gcp", kind)
+}
+
+// Ref names a secret in a secret manager and, optionally, the name it is
+// provided under.
+type Ref struct {
+	Name string
+	ID   string
+}
+
+func parseRefs(s string) []Ref {
+	var refs []Ref
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		if name, id, ok := strings.Cut(f, "="); ok {
+			refs = append(refs, Ref{Name: name, ID: id})
+		} else {
+			refs = append(refs, Ref{ID: f})
+		}
+	}
+	return refs
+}
+
+var invalidName = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// bind adds the value of the secret manager secret ref to out: under
+// ref.Name if set, else each key of a JSON object of strings, else under
+// the last element of the secret's ID.
+func bind(out map[string]string, ref Ref, value string) {
+	if ref.Name != "" {
+		out[ref.Name] = value
+		return
+	}
+	var fields map[string]any
+	if json.Unmarshal([]byte(value), &fields) == nil {
+		for k, v := range fields {
+			if s, ok := v.(string); ok {
+				out[k] = s
+			} else {
+				data, _ := json.Marshal(v)
+				out[k] = string(data)
+			}
+		}
+		return
+	}
+	id := ref.ID
+	if i := strings.LastIndexAny(id, "/:"); i >= 0 {
+		id = id[i+1:]
+	}
+	out[invalidName.ReplaceAllString(id, "_")] = value
+}
+
+// expandHome replaces a leading ~ in path with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || rest[0] == '/' || rest[0] == filepath.Separator) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + rest
+		}
+	}
+	return path
+}
+
+// doJSON sends req with client, or http.DefaultClient if nil, and decodes
+// a successful JSON response into out.
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("%s: %s", resp.Status, msg)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
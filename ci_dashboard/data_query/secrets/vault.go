@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Vault reads the keys of a HashiCorp Vault KV secret.
+type Vault struct {
+	// Path is the secret's mount followed by its path, as in
+	// "secret/ci/deploy".
+	Path string
+	// KVVersion is the version of the secrets engine, 1 or 2.
+	KVVersion int
+	// Addr, Token and Namespace default to $VAULT_ADDR, $VAULT_TOKEN or
+	// ~/.vault-token, and $VAULT_NAMESPACE.
+	Addr      string
+	Token     string
+	Namespace string
+	Client    *http.Client
+}
+
+func (v *Vault) String() string { return "vault:" + v.Path }
+
+// Load reads the latest version of the secret.
+func (v *Vault) Load(ctx context.Context) (map[string]string, error) {
+	addr := firstNonEmpty(v.Addr, os.Getenv("VAULT_ADDR"))
+	if addr == "" {
+		return nil, errors.New("VAULT_ADDR is not set")
+	}
+	token := firstNonEmpty(v.Token, os.Getenv("VAULT_TOKEN"))
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			data, _ := os.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	if token == "" {
+		return nil, errors.New("no Vault token: set VAULT_TOKEN or run vault login")
+	}
+	path := strings.Trim(v.Path, "/")
+	if v.KVVersion != 1 {
+		mount, rest, ok := strings.Cut(path, "/")
+		if !ok {
+			return nil, errors.New("the path must name a secret under a mount, as in secret/NAME")
+		}
+		path = mount + "/data/" + rest
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := firstNonEmpty(v.Namespace, os.Getenv("VAULT_NAMESPACE")); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := doJSON(v.Client, req, &resp); err != nil {
+		return nil, err
+	}
+	data := resp.Data
+	if v.KVVersion != 1 {
+		var inner struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &inner); err != nil {
+			return nil, err
+		}
+		data = inner.Data
+	}
+	out := map[string]string{}
+	bind(out, Ref{}, string(data))
+	return out, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
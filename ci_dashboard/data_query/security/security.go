@@ -0,0 +1,316 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package security finds workflow patterns that let outside contributors
+// run code or read secrets: untrusted event data interpolated into
+// scripts, privileged workflows that check out pull request code, and
+// secrets handed to that code.
+package security
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/expr"
+	"testingdashboard/m/v2/workflow"
+)
+
+// Rules findings are reported under.
+const (
+	RuleScriptInjection   = "script-injection"
+	RuleUntrustedCheckout = "untrusted-checkout"
+	RuleSecretExposure    = "secret-exposure"
+)
+
+// Finding is one problem found by Check.
+type Finding struct {
+	Path     string            `json:"path"`
+	Pos      workflow.Position `json:"position"`
+	Rule     string            `json:"rule"`
+	Severity string            `json:"severity"` // error or warning
+	Message  string            `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", f.Path, f.Pos.Line, f.Pos.Column, f.Severity, f.Message, f.Rule)
+}
+
+// untrustedRE matches the context properties an outside contributor
+// controls, following GitHub's security hardening guide.
+var untrustedRE = regexp.MustCompile(`^github\.(` + strings.Join([]string{
+	`head_ref`,
+	`event\.(issue|pull_request|discussion)\.(title|body)`,
+	`event\.(comment|review|review_comment)\.body`,
+	`event\.pages\.\*\.page_name`,
+	`event\.(commits\.\*|head_commit)\.(message|(author|committer)\.(email|name))`,
+	`event\.pull_request\.head\.(ref|label|repo\.default_branch)`,
+	`event\.workflow_run\.(display_title|head_branch|head_commit\.(message|(author|committer)\.(email|name)))`,
+	`event\.workflow_run\.pull_requests\.\*\.head\.ref`,
+}, "|") + `)$`)
+
+// IsUntrusted reports whether a dotted context reference, such as
+// github.event.issue.title, is controlled by outside contributors.
+func IsUntrusted(ref string) bool {
+	return untrustedRE.MatchString(strings.ToLower(ref))
+}
+
+// headRE matches references to the head of a pull request, which is code
+// from its author.
+var headRE = regexp.MustCompile(`^github\.(head_ref|event\.(pull_request\.head\.(sha|ref|repo\.full_name)|workflow_run\.(head_sha|head_branch|head_repository\.full_name)|(number|pull_request\.number|issue\.number)))$`)
+
+// privileged are the triggers whose runs get secrets and a write token
+// even when a fork caused them.
+var privileged = []string{"pull_request_target", "workflow_run", "issue_comment"}
+
+// Check runs every check over wf.
+func Check(wf *workflow.Workflow) []Finding {
+	c := &checker{wf: wf, findings: []Finding{}}
+	for _, name := range privileged {
+		if wf.On.Has(name) {
+			c.trigger = name
+			break
+		}
+	}
+	jobs := workflow.MappingValue(wf.Node, "jobs")
+	wfEnv := taintedEnv(workflow.MappingValue(wf.Node, "env"), nil)
+	for _, id := range wf.JobIDs() {
+		c.job(id, workflow.MappingValue(jobs, id), wfEnv)
+	}
+	sort.SliceStable(c.findings, func(i, j int) bool {
+		a, b := c.findings[i].Pos, c.findings[j].Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.findings
+}
+
+type checker struct {
+	wf *workflow.Workflow
+	// trigger is the privileged trigger of the workflow, if any.
+	trigger  string
+	findings []Finding
+}
+
+func (c *checker) report(pos workflow.Position, rule, sev, format string, args ...any) {
+	c.findings = append(c.findings, Finding{Path: c.wf.Path, Pos: pos, Rule: rule, Severity: sev, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) job(id string, node *yaml.Node, wfEnv map[string]string) {
+	job := c.wf.Jobs[id]
+	if job == nil || node == nil {
+		return
+	}
+	if job.Uses != "" {
+		if c.trigger != "" && job.Secrets != nil && job.Secrets.Inherit {
+			c.report(job.Pos, RuleSecretExposure, "warning", "job %s passes every secret to %s in a %s workflow; pass only the secrets it needs", id, job.Uses, c.trigger)
+		}
+		return
+	}
+	jobEnv := taintedEnv(workflow.MappingValue(node, "env"), wfEnv)
+	steps := workflow.MappingValue(node, "steps")
+	if steps == nil || steps.Kind != yaml.SequenceNode {
+		return
+	}
+	checkout := -1
+	for i, stepNode := range steps.Content {
+		if i >= len(job.Steps) {
+			break
+		}
+		step := job.Steps[i]
+		env := taintedEnv(workflow.MappingValue(stepNode, "env"), jobEnv)
+		for _, key := range []string{"run", "with.script"} {
+			c.injection(stepNode, key, env)
+		}
+		if c.trigger == "" {
+			continue
+		}
+		if checkout < 0 && untrustedCheckout(step) {
+			checkout = i
+			c.checkout(id, job, i)
+			continue
+		}
+		if checkout >= 0 {
+			c.secrets(id, stepNode)
+		}
+	}
+	if checkout >= 0 {
+		c.secrets(id, workflow.MappingValue(node, "env"))
+	}
+}
+
+// injection reports untrusted data interpolated into the script at key.
+func (c *checker) injection(step *yaml.Node, key string, env map[string]string) {
+	node := step
+	for _, k := range strings.Split(key, ".") {
+		node = workflow.MappingValue(node, k)
+	}
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return
+	}
+	embedded, _ := expr.Extract(node.Value)
+	for _, e := range embedded {
+		reported := map[string]bool{}
+		for _, ref := range references(e.Source) {
+			if reported[ref] {
+				continue
+			}
+			reported[ref] = true
+			pos := workflow.ScalarPosition(node, e.Start)
+			name, isEnv := strings.CutPrefix(ref, "env.")
+			switch {
+			case IsUntrusted(ref):
+				c.report(pos, RuleScriptInjection, "warning", "%s is controlled by outside contributors and should be passed through env instead of interpolated into the script", ref)
+			case isEnv && env[strings.ToLower(name)] != "":
+				c.report(pos, RuleScriptInjection, "warning", "%s holds %s, which is controlled by outside contributors; use $%s in the script instead of interpolating it", ref, env[strings.ToLower(name)], name)
+			}
+		}
+	}
+}
+
+// checkout reports a privileged job checking out pull request code at
+// step i. Running anything from the checkout afterwards hands the job's
+// secrets and token to the pull request's author.
+func (c *checker) checkout(id string, job *workflow.Job, i int) {
+	step := job.Steps[i]
+	runs := false
+	for _, later := range job.Steps[i+1:] {
+		if later.Run != "" || strings.HasPrefix(later.Uses, "./") {
+			runs = true
+			break
+		}
+	}
+	if runs {
+		c.report(step.Pos, RuleUntrustedCheckout, "error", "job %s checks out pull request code in a %s workflow and then runs it with access to secrets and a privileged token", id, c.trigger)
+	} else {
+		c.report(step.Pos, RuleUntrustedCheckout, "warning", "job %s checks out pull request code in a %s workflow, which has access to secrets and a privileged token", id, c.trigger)
+	}
+	if strings.HasPrefix(strings.ToLower(step.Uses), "actions/checkout@") && step.With["persist-credentials"] != "false" {
+		c.report(step.Pos, RuleSecretExposure, "warning", "the checkout stores the token in .git/config, where the pull request's code can read it; set persist-credentials: false")
+	}
+}
+
+// secrets reports secrets referenced anywhere under node, in a job that
+// runs untrusted code.
+func (c *checker) secrets(id string, node *yaml.Node) {
+	walkScalars(node, func(n *yaml.Node) {
+		embedded, _ := expr.Extract(n.Value)
+		for _, e := range embedded {
+			for _, ref := range references(e.Source) {
+				if strings.HasPrefix(ref, "secrets.") {
+					c.report(workflow.ScalarPosition(n, e.Start), RuleSecretExposure, "error", "%s is exposed to pull request code checked out earlier in job %s", ref, id)
+				}
+			}
+		}
+	})
+}
+
+// untrustedCheckout reports whether step checks out the head of a pull
+// request.
+func untrustedCheckout(step *workflow.Step) bool {
+	if strings.HasPrefix(strings.ToLower(step.Uses), "actions/checkout@") {
+		for _, key := range []string{"ref", "repository"} {
+			v := step.With[key]
+			if strings.Contains(v, "refs/pull/") {
+				return true
+			}
+			if refersToHead(v) {
+				return true
+			}
+		}
+		return false
+	}
+	run := step.Run
+	if run == "" {
+		return false
+	}
+	if strings.Contains(run, "gh pr checkout") || strings.Contains(run, "git fetch") && strings.Contains(run, "pull/") {
+		return true
+	}
+	return strings.Contains(run, "git checkout") && refersToHead(run)
+}
+
+func refersToHead(s string) bool {
+	embedded, _ := expr.Extract(s)
+	for _, e := range embedded {
+		for _, ref := range references(e.Source) {
+			if headRE.MatchString(strings.ToLower(ref)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// taintedEnv returns parent extended with the variables of env whose values
+// interpolate untrusted data, mapped to that data. Names are lowercased
+// since the env context is case-insensitive.
+func taintedEnv(env *yaml.Node, parent map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range parent {
+		out[k] = v
+	}
+	if env == nil || env.Kind != yaml.MappingNode {
+		return out
+	}
+	for i := 0; i+1 < len(env.Content); i += 2 {
+		name := strings.ToLower(env.Content[i].Value)
+		delete(out, name)
+		embedded, _ := expr.Extract(env.Content[i+1].Value)
+		for _, e := range embedded {
+			for _, ref := range references(e.Source) {
+				if IsUntrusted(ref) {
+					out[name] = ref
+				} else if from, ok := strings.CutPrefix(ref, "env."); ok && parent[strings.ToLower(from)] != "" {
+					out[name] = parent[strings.ToLower(from)]
+				}
+			}
+		}
+	}
+	return out
+}
+
+// references returns the context references in an expression as dotted
+// strings.
+func references(src string) []string {
+	node, err := expr.Parse(src)
+	if err != nil {
+		return nil
+	}
+	var refs []string
+	expr.Walk(node, func(n expr.Node) {
+		if ref := expr.Chain(n); ref != nil {
+			refs = append(refs, strings.Join(ref, "."))
+		}
+	})
+	return refs
+}
+
+func walkScalars(node *yaml.Node, fn func(*yaml.Node)) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.ScalarNode {
+		fn(node)
+		return
+	}
+	for _, c := range node.Content {
+		walkScalars(c, fn)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package selfhosted keeps a desired set of self-hosted runners registered
+// with a repository or organization, launching just-in-time runners for
+// missing ones and removing the ones no longer wanted.
+package selfhosted
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/client"
+)
+
+// Spec is a runner the Manager keeps registered.
+type Spec struct {
+	Name string
+	// Labels are the custom labels of the runner; the runner adds
+	// self-hosted and its OS and architecture itself.
+	Labels []string
+	// Group is the runner group of an organization runner. Defaults to
+	// the default group.
+	Group string
+	// WorkFolder defaults to _work.
+	WorkFolder string
+}
+
+// Launcher starts and stops runner processes.
+type Launcher interface {
+	// Launch starts a runner with a just-in-time configuration and
+	// returns once it has started.
+	Launch(ctx context.Context, spec Spec, jitConfig string) error
+	// Stop stops the runner named name if the launcher still runs it.
+	Stop(ctx context.Context, name string) error
+}
+
+// DefaultStartTimeout is how long a launched runner may take to come
+// online.
+const DefaultStartTimeout = 5 * time.Minute
+
+// Manager reconciles the runners registered for a scope with a desired
+// set. It only touches runners whose names start with Prefix, so several
+// managers, and hand-registered runners, can share a scope.
+type Manager struct {
+	Client   *client.Client
+	Scope    client.Scope
+	Launcher Launcher
+	// Prefix marks the runners the manager owns. Every Spec name must
+	// start with it.
+	Prefix string
+	// StartTimeout defaults to DefaultStartTimeout. A runner still offline
+	// after that long is replaced.
+	StartTimeout time.Duration
+	// Logf reports each change. Defaults to discarding.
+	Logf func(format string, args ...any)
+
+	mu       sync.Mutex
+	launched map[string]time.Time
+	groups   map[string]int64
+}
+
+// Result lists the runners a reconciliation changed, by name.
+type Result struct {
+	Launched  []string
+	Replaced  []string
+	Removed   []string
+	Relabeled []string
+	Regrouped []string
+}
+
+// Reconcile makes one pass: runners that are missing are launched,
+// runners that are not desired are removed once idle, runners that did
+// not come online in time are replaced, and labels and groups are
+// corrected. Errors for individual runners do not stop the pass.
+func (m *Manager) Reconcile(ctx context.Context, desired []Spec) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.launched == nil {
+		m.launched = map[string]time.Time{}
+	}
+	specs := map[string]Spec{}
+	for _, s := range desired {
+		if !strings.HasPrefix(s.Name, m.Prefix) {
+			return nil, fmt.Errorf("runner %s does not start with the prefix %q", s.Name, m.Prefix)
+		}
+		if _, dup := specs[s.Name]; dup {
+			return nil, fmt.Errorf("runner %s is listed twice", s.Name)
+		}
+		specs[s.Name] = s
+	}
+	runners, err := m.Client.ListRunners(ctx, m.Scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runners: %w", err)
+	}
+	registered := map[string]*client.Runner{}
+	for _, r := range runners {
+		if strings.HasPrefix(r.Name, m.Prefix) {
+			registered[r.Name] = r
+		}
+	}
+
+	res := &Result{}
+	var errs []error
+	for _, name := range sortedNames(registered) {
+		r := registered[name]
+		if _, ok := specs[name]; ok || r.Busy {
+			continue
+		}
+		if err := m.remove(ctx, r); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		res.Removed = append(res.Removed, name)
+	}
+	for _, name := range sortedNames(specs) {
+		spec := specs[name]
+		r, ok := registered[name]
+		switch {
+		case !ok:
+			if err := m.launch(ctx, spec); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			res.Launched = append(res.Launched, name)
+			continue
+		case m.stuck(r):
+			if err := m.remove(ctx, r); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := m.launch(ctx, spec); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			res.Replaced = append(res.Replaced, name)
+			continue
+		}
+		if r.Online() {
+			delete(m.launched, name)
+		}
+		changed, err := m.relabel(ctx, r, spec)
+		if err != nil {
+			errs = append(errs, err)
+		} else if changed {
+			res.Relabeled = append(res.Relabeled, name)
+		}
+		changed, err = m.regroup(ctx, r, spec)
+		if err != nil {
+			errs = append(errs, err)
+		} else if changed {
+			res.Regrouped = append(res.Regrouped, name)
+		}
+	}
+	return res, errors.Join(errs...)
+}
+
+// Run reconciles every interval until ctx is done, calling desired for
+// the set to keep. Errors are logged and retried on the next pass.
+func (m *Manager) Run(ctx context.Context, interval time.Duration, desired func() []Spec) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if _, err := m.Reconcile(ctx, desired()); err != nil {
+			m.logf("reconcile: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// stuck reports whether r is offline and was not just launched. Runners
+// the manager did not launch itself count as launched when first seen.
+func (m *Manager) stuck(r *client.Runner) bool {
+	if r.Online() || r.Busy {
+		return false
+	}
+	at, ok := m.launched[r.Name]
+	if !ok {
+		m.launched[r.Name] = time.Now()
+		return false
+	}
+	timeout := m.StartTimeout
+	if timeout <= 0 {
+		timeout = DefaultStartTimeout
+	}
+	return time.Since(at) > timeout
+}
+
+func (m *Manager) launch(ctx context.Context, spec Spec) error {
+	group, err := m.groupID(ctx, spec.Group)
+	if err != nil {
+		return fmt.Errorf("runner %s: %w", spec.Name, err)
+	}
+	cfg, err := m.Client.GenerateJITConfig(ctx, m.Scope, client.JITConfigOptions{
+		Name:       spec.Name,
+		Labels:     spec.Labels,
+		GroupID:    group,
+		WorkFolder: spec.WorkFolder,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register runner %s: %w", spec.Name, err)
+	}
+	if err := m.Launcher.Launch(ctx, spec, cfg.Encoded); err != nil {
+		// Do not leave a registration behind that nothing will use.
+		if cfg.Runner != nil {
+			m.Client.DeleteRunner(ctx, m.Scope, cfg.Runner.ID)
+		}
+		return fmt.Errorf("failed to launch runner %s: %w", spec.Name, err)
+	}
+	m.launched[spec.Name] = time.Now()
+	m.logf("launched runner %s", spec.Name)
+	return nil
+}
+
+func (m *Manager) remove(ctx context.Context, r *client.Runner) error {
+	if err := m.Client.DeleteRunner(ctx, m.Scope, r.ID); err != nil && !client.IsNotFound(err) {
+		return fmt.Errorf("failed to remove runner %s: %w", r.Name, err)
+	}
+	if err := m.Launcher.Stop(ctx, r.Name); err != nil {
+		return fmt.Errorf("failed to stop runner %s: %w", r.Name, err)
+	}
+	delete(m.launched, r.Name)
+	m.logf("removed runner %s", r.Name)
+	return nil
+}
+
+// relabel makes the custom labels of r those of spec.
+func (m *Manager) relabel(ctx context.Context, r *client.Runner, spec Spec) (bool, error) {
+	var custom []string
+	for _, l := range r.Labels {
+		if l.Type != "read-only" {
+			custom = append(custom, l.Name)
+		}
+	}
+	if sameLabels(custom, spec.Labels) {
+		return false, nil
+	}
+	if _, err := m.Client.SetRunnerLabels(ctx, m.Scope, r.ID, spec.Labels); err != nil {
+		return false, fmt.Errorf("failed to set labels of runner %s: %w", r.Name, err)
+	}
+	m.logf("set labels of runner %s to %s", r.Name, strings.Join(spec.Labels, ", "))
+	return true, nil
+}
+
+// regroup moves an organization runner into the group of spec. The
+// listing only reports groups for some runners; others are left alone.
+func (m *Manager) regroup(ctx context.Context, r *client.Runner, spec Spec) (bool, error) {
+	if m.Scope.Repo != "" || r.RunnerGroupID == 0 {
+		return false, nil
+	}
+	group, err := m.groupID(ctx, spec.Group)
+	if err != nil {
+		return false, fmt.Errorf("runner %s: %w", r.Name, err)
+	}
+	if group == r.RunnerGroupID {
+		return false, nil
+	}
+	if err := m.Client.AddGroupRunner(ctx, m.Scope.Owner, group, r.ID); err != nil {
+		return false, fmt.Errorf("failed to move runner %s to group %s: %w", r.Name, spec.Group, err)
+	}
+	m.logf("moved runner %s to group %s", r.Name, spec.Group)
+	return true, nil
+}
+
+// groupID returns the ID of the named runner group, looking groups up
+// once. Repository runners and the empty name use the default group.
+func (m *Manager) groupID(ctx context.Context, name string) (int64, error) {
+	if name == "" || m.Scope.Repo != "" {
+		if name != "" {
+			return 0, fmt.Errorf("runner groups are only available to organization runners")
+		}
+		return 1, nil
+	}
+	if id, ok := m.groups[name]; ok {
+		return id, nil
+	}
+	groups, err := m.Client.ListRunnerGroups(ctx, m.Scope.Owner)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list runner groups: %w", err)
+	}
+	m.groups = map[string]int64{}
+	for _, g := range groups {
+		m.groups[g.Name] = g.ID
+	}
+	id, ok := m.groups[name]
+	if !ok {
+		return 0, fmt.Errorf("organization %s has no runner group %q", m.Scope.Owner, name)
+	}
+	return id, nil
+}
+
+// EnsureGroup returns the runner group of an organization with the given
+// name, creating it with opts if it does not exist. Existing groups are
+// not changed.
+func EnsureGroup(ctx context.Context, c *client.Client, org, name string, opts client.RunnerGroupOptions) (*client.RunnerGroup, error) {
+	groups, err := c.ListRunnerGroups(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runner groups: %w", err)
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			return g, nil
+		}
+	}
+	opts.Name = name
+	g, err := c.CreateRunnerGroup(ctx, org, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner group %s: %w", name, err)
+	}
+	return g, nil
+}
+
+func (m *Manager) logf(format string, args ...any) {
+	if m.Logf != nil {
+		m.Logf(format, args...)
+	}
+}
+
+func sameLabels(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	for i := range a {
+		a[i] = strings.ToLower(a[i])
+	}
+	for i := range b {
+		b[i] = strings.ToLower(b[i])
+	}
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selfhosted
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// ProcessLauncher runs each runner as a local process.
+type ProcessLauncher struct {
+	// Command returns the command that runs spec. The JIT configuration is
+	// passed in ACTIONS_RUNNER_INPUT_JITCONFIG, which the runner reads like
+	// its --jitconfig flag. Defaults to run.sh in Root/<name>, which must
+	// hold an extracted runner.
+	Command func(spec Spec) *exec.Cmd
+	Root    string
+
+	mu    sync.Mutex
+	procs map[string]*exec.Cmd
+}
+
+// Launch implements Launcher.
+func (l *ProcessLauncher) Launch(ctx context.Context, spec Spec, jitConfig string) error {
+	var cmd *exec.Cmd
+	if l.Command != nil {
+		cmd = l.Command(spec)
+	} else {
+		dir := filepath.Join(l.Root, spec.Name)
+		cmd = exec.Command(filepath.Join(dir, "run.sh"))
+		cmd.Dir = dir
+	}
+	cmd.Env = append(os.Environ(), "ACTIONS_RUNNER_INPUT_JITCONFIG="+jitConfig)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	if l.procs == nil {
+		l.procs = map[string]*exec.Cmd{}
+	}
+	l.procs[spec.Name] = cmd
+	l.mu.Unlock()
+	go func() {
+		cmd.Wait()
+		l.mu.Lock()
+		if l.procs[spec.Name] == cmd {
+			delete(l.procs, spec.Name)
+		}
+		l.mu.Unlock()
+	}()
+	return nil
+}
+
+// Stop implements Launcher.
+func (l *ProcessLauncher) Stop(ctx context.Context, name string) error {
+	l.mu.Lock()
+	cmd := l.procs[name]
+	l.mu.Unlock()
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	if err := cmd.Process.Kill(); err != nil && err != os.ErrProcessDone {
+		return err
+	}
+	return nil
+}
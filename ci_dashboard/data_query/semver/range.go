@@ -0,0 +1,255 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Range is a set of version constraints such as ">=1.2 <2 || ^3.1".
+type Range struct {
+	source string
+	// sets are alternatives; every comparator of one must hold.
+	sets [][]comparator
+}
+
+type comparator struct {
+	op string // "", "<", "<=", ">", ">="; "" is equality
+	v  Version
+}
+
+func (c comparator) test(v Version) bool {
+	n := v.Compare(c.v)
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	}
+	return n == 0
+}
+
+var (
+	hyphenRE   = regexp.MustCompile(`^\s*(\S+)\s+-\s+(\S+)\s*$`)
+	operatorRE = regexp.MustCompile(`(<=|>=|<|>|=|~|\^)\s+`)
+)
+
+// ParseRange parses a node-semver range: comparators (<, <=, >, >=, =),
+// x-ranges (1.x, 1.2.*, *), tilde (~1.2) and caret (^1.2) ranges, hyphen
+// ranges (1.2 - 2.3) and || alternatives. An empty range matches any
+// release.
+func ParseRange(s string) (*Range, error) {
+	r := &Range{source: s}
+	for _, alt := range strings.Split(s, "||") {
+		var set []comparator
+		if m := hyphenRE.FindStringSubmatch(alt); m != nil {
+			cs, err := hyphen(m[1], m[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %q: %w", s, err)
+			}
+			set = cs
+		} else {
+			fields := strings.Fields(joinOperators(alt))
+			if len(fields) == 0 {
+				fields = []string{"*"}
+			}
+			for _, f := range fields {
+				cs, err := parseComparator(f)
+				if err != nil {
+					return nil, fmt.Errorf("invalid range %q: %w", s, err)
+				}
+				set = append(set, cs...)
+			}
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+// joinOperators removes spaces between an operator and its version, so
+// ">= 1.2" parses like ">=1.2".
+func joinOperators(s string) string {
+	return operatorRE.ReplaceAllString(s, "$1")
+}
+
+// partial parses a possibly partial version after an operator.
+func partial(s string) (Version, int, error) {
+	return parsePartial(clean(s))
+}
+
+// bump returns the smallest version above every version matching the first
+// parts components of v, e.g. 1.3.0-0 for 1.2.
+func bump(v Version, parts int) Version {
+	switch parts {
+	case 0:
+		return Version{}
+	case 1:
+		return Version{Major: v.Major + 1, Pre: []string{"0"}}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1, Pre: []string{"0"}}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1, Pre: []string{"0"}}
+}
+
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, o := range []string{"<=", ">=", "<", ">", "=", "~>", "~", "^"} {
+		if rest, ok := strings.CutPrefix(s, o); ok {
+			op, s = o, rest
+			break
+		}
+	}
+	v, parts, err := partial(s)
+	if err != nil {
+		return nil, err
+	}
+	lower := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Pre: v.Pre}
+	switch op {
+	case "", "=":
+		if parts == 3 {
+			return []comparator{{"", lower}}, nil
+		}
+		if parts == 0 {
+			return []comparator{{">=", Version{}}}, nil
+		}
+		return []comparator{{">=", lower}, {"<", bump(v, parts)}}, nil
+	case "~", "~>":
+		upper := bump(v, min(max(parts, 1), 2))
+		if parts == 0 {
+			return []comparator{{">=", Version{}}}, nil
+		}
+		return []comparator{{">=", lower}, {"<", upper}}, nil
+	case "^":
+		if parts == 0 {
+			return []comparator{{">=", Version{}}}, nil
+		}
+		// The first non-zero component given may not change.
+		n := 1
+		switch {
+		case v.Major == 0 && parts >= 2 && v.Minor == 0 && parts == 3:
+			n = 3
+		case v.Major == 0 && parts >= 2:
+			n = 2
+		}
+		return []comparator{{">=", lower}, {"<", bump(v, n)}}, nil
+	case ">":
+		if parts == 0 {
+			return []comparator{{"<", Version{}}}, nil
+		}
+		if parts < 3 {
+			// >1.2 is >=1.3.0, without reaching into 1.3.0 prereleases.
+			next := bump(v, parts)
+			next.Pre = nil
+			return []comparator{{">=", next}}, nil
+		}
+		return []comparator{{">", lower}}, nil
+	case ">=":
+		return []comparator{{">=", lower}}, nil
+	case "<":
+		if parts == 0 {
+			return []comparator{{"<", Version{}}}, nil
+		}
+		if parts < 3 {
+			lower.Pre = []string{"0"}
+		}
+		return []comparator{{"<", lower}}, nil
+	case "<=":
+		if parts == 0 {
+			return []comparator{{">=", Version{}}}, nil
+		}
+		if parts < 3 {
+			return []comparator{{"<", bump(v, parts)}}, nil
+		}
+		return []comparator{{"<=", lower}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+func hyphen(from, to string) ([]comparator, error) {
+	lo, _, err := partial(from)
+	if err != nil {
+		return nil, err
+	}
+	hi, parts, err := partial(to)
+	if err != nil {
+		return nil, err
+	}
+	cs := []comparator{{">=", lo}}
+	switch {
+	case parts == 3:
+		cs = append(cs, comparator{"<=", hi})
+	case parts > 0:
+		cs = append(cs, comparator{"<", bump(hi, parts)})
+	}
+	return cs, nil
+}
+
+// String returns the range as written.
+func (r *Range) String() string {
+	return r.source
+}
+
+// Contains reports whether v satisfies the range. As in node-semver, a
+// prerelease only satisfies a range that names a prerelease of the same
+// major.minor.patch, so ^1.2.0 does not match 1.3.0-beta.
+func (r *Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		if r.testSet(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Range) testSet(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.test(v) {
+			return false
+		}
+	}
+	if !v.Prerelease() {
+		return true
+	}
+	for _, c := range set {
+		if c.v.Prerelease() && c.v.sameTuple(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxSatisfying returns the highest of versions that satisfies the range,
+// skipping strings that are not versions.
+func (r *Range) MaxSatisfying(versions []string) (string, bool) {
+	var best string
+	var bestV Version
+	found := false
+	for _, s := range versions {
+		v, err := Parse(s)
+		if err != nil || !r.Contains(v) {
+			continue
+		}
+		if !found || v.Compare(bestV) > 0 {
+			best, bestV, found = s, v, true
+		}
+	}
+	return best, found
+}
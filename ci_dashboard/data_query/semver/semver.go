@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package semver implements semantic versions and the range syntax of
+// node-semver, which setup-* actions accept for version inputs.
+package semver
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version.
+type Version struct {
+	Major, Minor, Patch int
+	// Pre holds the dot-separated prerelease identifiers.
+	Pre []string
+	// Build is the build metadata, which does not affect precedence.
+	Build string
+}
+
+// Parse parses a full version such as 1.2.3-rc.1. A leading v or = and
+// surrounding whitespace are allowed, as in node-semver's loose cleaning.
+func Parse(s string) (Version, error) {
+	v, parts, err := parsePartial(clean(s))
+	if err != nil {
+		return Version{}, err
+	}
+	if parts != 3 {
+		return Version{}, fmt.Errorf("invalid version %q", s)
+	}
+	return v, nil
+}
+
+// Clean returns the canonical form of s, or "" if it is not a version.
+func Clean(s string) string {
+	v, err := Parse(s)
+	if err != nil {
+		return ""
+	}
+	return v.String()
+}
+
+func clean(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "=")
+	s = strings.TrimPrefix(s, "v")
+	return s
+}
+
+// parsePartial parses a version that may lack minor and patch components,
+// returning how many numeric components were given. x, X and * count as
+// missing.
+func parsePartial(s string) (Version, int, error) {
+	var v Version
+	s, v.Build, _ = strings.Cut(s, "+")
+	s, pre, hasPre := strings.Cut(s, "-")
+	if hasPre {
+		v.Pre = strings.Split(pre, ".")
+		for _, id := range v.Pre {
+			if id == "" || strings.Trim(id, "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-") != "" {
+				return Version{}, 0, fmt.Errorf("invalid prerelease %q", pre)
+			}
+		}
+	}
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 || s == "" {
+		return Version{}, 0, fmt.Errorf("invalid version %q", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	parts := 0
+	for i, f := range fields {
+		if f == "x" || f == "X" || f == "*" {
+			break
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 || (len(f) > 1 && f[0] == '0') {
+			return Version{}, 0, fmt.Errorf("invalid version component %q", f)
+		}
+		*nums[i] = n
+		parts++
+	}
+	if parts < 3 && hasPre {
+		return Version{}, 0, fmt.Errorf("prerelease on partial version %q", s)
+	}
+	return v, parts, nil
+}
+
+// String formats v canonically.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare orders versions by semver precedence.
+func (v Version) Compare(w Version) int {
+	if c := cmp.Compare(v.Major, w.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, w.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, w.Patch); c != 0 {
+		return c
+	}
+	switch {
+	case len(v.Pre) == 0 && len(w.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(w.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(w.Pre); i++ {
+		a, b := v.Pre[i], w.Pre[i]
+		an, aErr := strconv.Atoi(a)
+		bn, bErr := strconv.Atoi(b)
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = cmp.Compare(an, bn)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(a, b)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(v.Pre), len(w.Pre))
+}
+
+// Prerelease reports whether v has prerelease identifiers.
+func (v Version) Prerelease() bool {
+	return len(v.Pre) > 0
+}
+
+// sameTuple reports whether v and w share major, minor and patch.
+func (v Version) sameTuple(w Version) bool {
+	return v.Major == w.Major && v.Minor == w.Minor && v.Patch == w.Patch
+}
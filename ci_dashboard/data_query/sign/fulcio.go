@@ -0,0 +1,187 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"testingdashboard/m/v2/oidc"
+)
+
+type fulcioChain struct {
+	Chain struct {
+		Certificates []string `json:"certificates"`
+	} `json:"chain"`
+}
+
+// requestCertificate asks Fulcio to certify key for the identity in token.
+// Fulcio requires proof that the caller holds the key: a signature of the
+// token's subject.
+func (s *Signer) requestCertificate(ctx context.Context, key *ecdsa.PrivateKey, token string) (*x509.Certificate, error) {
+	claims, err := oidc.ParseUnverified(token)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(claims.Subject))
+	proof, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	req := map[string]any{
+		"credentials": map[string]string{"oidcIdentityToken": token},
+		"publicKeyRequest": map[string]any{
+			"publicKey": map[string]string{
+				"algorithm": "ECDSA",
+				"content":   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})),
+			},
+			"proofOfPossession": proof,
+		},
+	}
+	var resp struct {
+		Embedded *fulcioChain `json:"signedCertificateEmbeddedSct"`
+		Detached *fulcioChain `json:"signedCertificateDetachedSct"`
+	}
+	url := baseURL(s.FulcioURL, DefaultFulcioURL) + "/api/v2/signingCert"
+	if err := s.post(ctx, url, http.Header{"Authorization": {"Bearer " + token}}, req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get signing certificate: %w", err)
+	}
+	chain := resp.Embedded
+	if chain == nil {
+		chain = resp.Detached
+	}
+	if chain == nil || len(chain.Chain.Certificates) == 0 {
+		return nil, fmt.Errorf("fulcio returned no certificate")
+	}
+	certs, err := parsePEM([]byte(chain.Chain.Certificates[0]))
+	if err != nil {
+		return nil, err
+	}
+	if !key.PublicKey.Equal(certs[0].PublicKey) {
+		return nil, fmt.Errorf("fulcio certified a different key")
+	}
+	return certs[0], nil
+}
+
+func certPEM(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func parsePEM(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
+
+// Roots are the certificates Fulcio certificates chain to.
+type Roots struct {
+	Roots, Intermediates *x509.CertPool
+}
+
+// LoadRoots reads PEM certificates from path. Self-signed certificates are
+// roots, the others intermediates.
+func LoadRoots(path string) (*Roots, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := parsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return newRoots(certs), nil
+}
+
+// FetchRoots downloads the trust bundle of a Fulcio instance. The response
+// is only as trustworthy as the connection to it; prefer LoadRoots with a
+// pinned bundle for verification that matters.
+func FetchRoots(ctx context.Context, fulcioURL string, client *http.Client) (*Roots, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL(fulcioURL, DefaultFulcioURL)+"/api/v2/trustBundle", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch trust bundle: %s", resp.Status)
+	}
+	var bundle struct {
+		Chains []struct {
+			Certificates []string `json:"certificates"`
+		} `json:"chains"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<22)).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode trust bundle: %w", err)
+	}
+	var certs []*x509.Certificate
+	for _, c := range bundle.Chains {
+		for _, p := range c.Certificates {
+			parsed, err := parsePEM([]byte(p))
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, parsed...)
+		}
+	}
+	return newRoots(certs), nil
+}
+
+func newRoots(certs []*x509.Certificate) *Roots {
+	r := &Roots{Roots: x509.NewCertPool(), Intermediates: x509.NewCertPool()}
+	for _, c := range certs {
+		if c.CheckSignatureFrom(c) == nil {
+			r.Roots.AddCert(c)
+		} else {
+			r.Intermediates.AddCert(c)
+		}
+	}
+	return r
+}
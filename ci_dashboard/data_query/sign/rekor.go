@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"testingdashboard/m/v2/provenance"
+)
+
+// rekorEntry is a log entry as the Rekor API returns it.
+type rekorEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+	Verification   struct {
+		InclusionProof *struct {
+			Checkpoint string   `json:"checkpoint"`
+			Hashes     []string `json:"hashes"`
+			LogIndex   int64    `json:"logIndex"`
+			RootHash   string   `json:"rootHash"`
+			TreeSize   int64    `json:"treeSize"`
+		} `json:"inclusionProof"`
+		SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+	} `json:"verification"`
+}
+
+// TlogEntry is a transparency log entry as a bundle holds it. Integers
+// are strings and hashes base64, following the protobuf JSON mapping.
+type TlogEntry struct {
+	LogIndex string `json:"logIndex"`
+	LogID    struct {
+		KeyID []byte `json:"keyId"`
+	} `json:"logId"`
+	KindVersion struct {
+		Kind    string `json:"kind"`
+		Version string `json:"version"`
+	} `json:"kindVersion"`
+	IntegratedTime    string            `json:"integratedTime"`
+	InclusionPromise  *InclusionPromise `json:"inclusionPromise,omitempty"`
+	InclusionProof    *InclusionProof   `json:"inclusionProof,omitempty"`
+	CanonicalizedBody []byte            `json:"canonicalizedBody"`
+}
+
+// InclusionPromise is the log's signed promise to include an entry.
+type InclusionPromise struct {
+	SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+}
+
+// InclusionProof proves an entry is in the log's Merkle tree.
+type InclusionProof struct {
+	LogIndex   string   `json:"logIndex"`
+	RootHash   []byte   `json:"rootHash"`
+	TreeSize   string   `json:"treeSize"`
+	Hashes     [][]byte `json:"hashes"`
+	Checkpoint struct {
+		Envelope string `json:"envelope"`
+	} `json:"checkpoint"`
+}
+
+// upload adds an entry to the log.
+func (s *Signer) upload(ctx context.Context, proposed map[string]any) (*TlogEntry, error) {
+	var resp map[string]rekorEntry
+	url := baseURL(s.RekorURL, DefaultRekorURL) + "/api/v1/log/entries"
+	if err := s.post(ctx, url, nil, proposed, &resp); err != nil {
+		return nil, fmt.Errorf("failed to add transparency log entry: %w", err)
+	}
+	for _, e := range resp {
+		return convertEntry(&e, proposed["kind"].(string), proposed["apiVersion"].(string))
+	}
+	return nil, fmt.Errorf("transparency log returned no entry")
+}
+
+func convertEntry(e *rekorEntry, kind, version string) (*TlogEntry, error) {
+	t := &TlogEntry{LogIndex: strconv.FormatInt(e.LogIndex, 10), IntegratedTime: strconv.FormatInt(e.IntegratedTime, 10)}
+	var err error
+	if t.LogID.KeyID, err = hex.DecodeString(e.LogID); err != nil {
+		return nil, fmt.Errorf("invalid log ID %q", e.LogID)
+	}
+	t.KindVersion.Kind, t.KindVersion.Version = kind, version
+	if t.CanonicalizedBody, err = base64.StdEncoding.DecodeString(e.Body); err != nil {
+		return nil, fmt.Errorf("invalid entry body: %w", err)
+	}
+	if set := e.Verification.SignedEntryTimestamp; set != nil {
+		t.InclusionPromise = &InclusionPromise{SignedEntryTimestamp: set}
+	}
+	if p := e.Verification.InclusionProof; p != nil {
+		proof := &InclusionProof{LogIndex: strconv.FormatInt(p.LogIndex, 10), TreeSize: strconv.FormatInt(p.TreeSize, 10)}
+		proof.Checkpoint.Envelope = p.Checkpoint
+		if proof.RootHash, err = hex.DecodeString(p.RootHash); err != nil {
+			return nil, fmt.Errorf("invalid root hash %q", p.RootHash)
+		}
+		for _, h := range p.Hashes {
+			b, err := hex.DecodeString(h)
+			if err != nil {
+				return nil, fmt.Errorf("invalid inclusion proof hash %q", h)
+			}
+			proof.Hashes = append(proof.Hashes, b)
+		}
+		t.InclusionProof = proof
+	}
+	return t, nil
+}
+
+// material returns the verification material of a signature by cert
+// recorded in entry.
+func material(cert *x509.Certificate, entry *TlogEntry) (*provenance.VerificationMaterial, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return &provenance.VerificationMaterial{
+		Certificate: &provenance.Certificate{RawBytes: cert.Raw},
+		TlogEntries: []json.RawMessage{data},
+	}, nil
+}
+
+// verifyPromise checks the signed entry timestamp of e against the log's
+// public key. The timestamp signs the canonical JSON of the entry, whose
+// keys are in this order.
+func verifyPromise(e *TlogEntry, key *ecdsa.PublicKey) error {
+	if e.InclusionPromise == nil {
+		return fmt.Errorf("log entry has no signed entry timestamp")
+	}
+	index, err := strconv.ParseInt(e.LogIndex, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid log index %q", e.LogIndex)
+	}
+	integrated, err := strconv.ParseInt(e.IntegratedTime, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integrated time %q", e.IntegratedTime)
+	}
+	payload, err := json.Marshal(struct {
+		Body           string `json:"body"`
+		IntegratedTime int64  `json:"integratedTime"`
+		LogID          string `json:"logID"`
+		LogIndex       int64  `json:"logIndex"`
+	}{base64.StdEncoding.EncodeToString(e.CanonicalizedBody), integrated, hex.EncodeToString(e.LogID.KeyID), index})
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(key, digest[:], e.InclusionPromise.SignedEntryTimestamp) {
+		return fmt.Errorf("invalid signed entry timestamp")
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sign signs artifacts and attestations keylessly with Sigstore:
+// an ephemeral key is certified by Fulcio for the job's OIDC identity and
+// each signature is recorded in the Rekor transparency log. Verify checks
+// such signatures and the workflow identity in their certificates.
+package sign
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/oidc"
+	"testingdashboard/m/v2/provenance"
+)
+
+// Public-good Sigstore instances.
+const (
+	DefaultFulcioURL = "https://fulcio.sigstore.dev"
+	DefaultRekorURL  = "https://rekor.sigstore.dev"
+)
+
+// Audience is the OIDC audience Fulcio accepts.
+const Audience = "sigstore"
+
+// Signer signs with a short-lived certificate for the job's identity. It
+// implements provenance.Signer. The job needs the id-token: write
+// permission.
+type Signer struct {
+	// FulcioURL and RekorURL default to the public-good instances.
+	FulcioURL, RekorURL string
+	// Token returns an OIDC token for Audience. Defaults to requesting
+	// one from the job.
+	Token func(ctx context.Context) (string, error)
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu   sync.Mutex
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+}
+
+// SignEnvelope implements provenance.Signer.
+func (s *Signer) SignEnvelope(ctx context.Context, e *provenance.Envelope) (*provenance.VerificationMaterial, error) {
+	key, cert, err := s.certificate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := provenance.SignPAE(key, e)
+	if err != nil {
+		return nil, err
+	}
+	e.Signatures = append(e.Signatures, provenance.Signature{Sig: sig})
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.upload(ctx, map[string]any{
+		"apiVersion": "0.0.1",
+		"kind":       "dsse",
+		"spec": map[string]any{
+			"proposedContent": map[string]any{
+				"envelope":  string(data),
+				"verifiers": [][]byte{certPEM(cert)},
+			},
+		},
+	})
+	if err != nil {
+		e.Signatures = e.Signatures[:len(e.Signatures)-1]
+		return nil, err
+	}
+	return material(cert, entry)
+}
+
+// SignBlob signs the SHA-256 digest of data.
+func (s *Signer) SignBlob(ctx context.Context, data []byte) (*provenance.Bundle, error) {
+	digest := sha256.Sum256(data)
+	return s.SignDigest(ctx, digest[:])
+}
+
+// SignDigest signs a SHA-256 digest, for artifacts too large to hold in
+// memory.
+func (s *Signer) SignDigest(ctx context.Context, digest []byte) (*provenance.Bundle, error) {
+	if len(digest) != sha256.Size {
+		return nil, fmt.Errorf("digest is %d bytes, want a SHA-256 digest", len(digest))
+	}
+	key, cert, err := s.certificate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := key.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign digest: %w", err)
+	}
+	entry, err := s.upload(ctx, map[string]any{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]any{
+			"data":      map[string]any{"hash": map[string]string{"algorithm": "sha256", "value": fmt.Sprintf("%x", digest)}},
+			"signature": map[string]any{"content": sig, "publicKey": map[string]any{"content": certPEM(cert)}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	m, err := material(cert, entry)
+	if err != nil {
+		return nil, err
+	}
+	return &provenance.Bundle{
+		MediaType:            provenance.BundleMediaType,
+		VerificationMaterial: m,
+		MessageSignature: &provenance.MessageSignature{
+			MessageDigest: provenance.MessageDigest{Algorithm: "SHA2_256", Digest: digest},
+			Signature:     sig,
+		},
+	}, nil
+}
+
+// certificate returns the signing key and its certificate, requesting a
+// new certificate when there is none or it expires within a minute.
+func (s *Signer) certificate(ctx context.Context) (*ecdsa.PrivateKey, *x509.Certificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cert != nil && time.Until(s.cert.NotAfter) > time.Minute {
+		return s.key, s.cert, nil
+	}
+	token, err := s.token(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get OIDC token: %w", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := s.requestCertificate(ctx, key, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.key, s.cert = key, cert
+	return key, cert, nil
+}
+
+func (s *Signer) token(ctx context.Context) (string, error) {
+	if s.Token != nil {
+		return s.Token(ctx)
+	}
+	return (&oidc.Requester{HTTPClient: s.HTTPClient}).GetToken(ctx, Audience)
+}
+
+func (s *Signer) httpClient() *http.Client {
+	if s.HTTPClient != nil {
+		return s.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// post sends in as JSON and decodes the response into out.
+func (s *Signer) post(ctx context.Context, url string, header http.Header, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<22))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func baseURL(url, def string) string {
+	if url == "" {
+		url = def
+	}
+	return strings.TrimSuffix(url, "/")
+}
@@ -0,0 +1,289 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/oidc"
+	"testingdashboard/m/v2/provenance"
+)
+
+// Identity is the workflow identity Fulcio records in a certificate.
+type Identity struct {
+	// Issuer is the OIDC issuer that vouched for the identity.
+	Issuer string
+	// SubjectAlternativeName is the URI of the workflow that signed, as
+	// https://github.com/owner/repo/.github/workflows/release.yml@refs/tags/v1.
+	SubjectAlternativeName string
+	// BuildSignerURI and BuildSignerDigest name the workflow that defines
+	// the job, which is a reusable workflow's when one was called.
+	BuildSignerURI    string
+	BuildSignerDigest string
+	RunnerEnvironment string
+	// SourceRepositoryURI, Digest and Ref are the repository and commit
+	// the run was for.
+	SourceRepositoryURI    string
+	SourceRepositoryDigest string
+	SourceRepositoryRef    string
+	// BuildConfigURI is the top-level workflow.
+	BuildConfigURI   string
+	BuildTrigger     string
+	RunInvocationURI string
+}
+
+// Fulcio certificate extensions, under 1.3.6.1.4.1.57264.1.
+var (
+	oidIssuerV1               = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidIssuer                 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	oidBuildSignerURI         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 9}
+	oidBuildSignerDigest      = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 10}
+	oidRunnerEnvironment      = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 11}
+	oidSourceRepositoryURI    = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+	oidSourceRepositoryDigest = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 13}
+	oidSourceRepositoryRef    = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 14}
+	oidBuildConfigURI         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 18}
+	oidBuildTrigger           = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 20}
+	oidRunInvocationURI       = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 21}
+)
+
+// CertificateIdentity reads the identity from a Fulcio certificate.
+func CertificateIdentity(cert *x509.Certificate) (*Identity, error) {
+	id := &Identity{}
+	if len(cert.URIs) > 0 {
+		id.SubjectAlternativeName = cert.URIs[0].String()
+	}
+	fields := map[string]*string{
+		oidIssuer.String():                 &id.Issuer,
+		oidBuildSignerURI.String():         &id.BuildSignerURI,
+		oidBuildSignerDigest.String():      &id.BuildSignerDigest,
+		oidRunnerEnvironment.String():      &id.RunnerEnvironment,
+		oidSourceRepositoryURI.String():    &id.SourceRepositoryURI,
+		oidSourceRepositoryDigest.String(): &id.SourceRepositoryDigest,
+		oidSourceRepositoryRef.String():    &id.SourceRepositoryRef,
+		oidBuildConfigURI.String():         &id.BuildConfigURI,
+		oidBuildTrigger.String():           &id.BuildTrigger,
+		oidRunInvocationURI.String():       &id.RunInvocationURI,
+	}
+	var issuerV1 string
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidIssuerV1) {
+			// The original issuer extension holds the raw string.
+			issuerV1 = string(ext.Value)
+			continue
+		}
+		field, ok := fields[ext.Id.String()]
+		if !ok {
+			continue
+		}
+		if _, err := asn1.Unmarshal(ext.Value, field); err != nil {
+			return nil, fmt.Errorf("invalid certificate extension %s: %w", ext.Id, err)
+		}
+	}
+	if id.Issuer == "" {
+		id.Issuer = issuerV1
+	}
+	if id.Issuer == "" {
+		return nil, fmt.Errorf("certificate has no OIDC issuer; was it issued by Fulcio?")
+	}
+	return id, nil
+}
+
+// IdentityPolicy restricts which workflows may have signed. Fields other
+// than Issuer are patterns in which * matches any run of characters, as
+// in oidc.Policy; empty fields allow anything.
+type IdentityPolicy struct {
+	// Issuer defaults to oidc.Issuer.
+	Issuer string
+	// Repository is the owner/repo the run was for.
+	Repository string
+	// Workflow is the owner/repo/path of the workflow that defines the
+	// signing job, such as org/builds/.github/workflows/release.yml for a
+	// job in a reusable workflow.
+	Workflow string
+	// Ref is the ref the run was for, such as refs/tags/v*.
+	Ref string
+	// ServerURL defaults to https://github.com.
+	ServerURL string
+}
+
+// Check reports the first part of id the policy rejects.
+func (p *IdentityPolicy) Check(id *Identity) error {
+	server := strings.TrimSuffix(p.ServerURL, "/")
+	if server == "" {
+		server = endpoints.FromEnv().Server
+	}
+	issuer := p.Issuer
+	if issuer == "" {
+		issuer = endpoints.ForServer(server).OIDCIssuer
+	}
+	if id.Issuer != issuer {
+		return fmt.Errorf("certificate issuer %q is not %q", id.Issuer, issuer)
+	}
+	repo, ok := strings.CutPrefix(id.SourceRepositoryURI, server+"/")
+	if p.Repository != "" && (!ok || !oidc.Match(p.Repository, repo)) {
+		return fmt.Errorf("certificate repository %q does not match %q", id.SourceRepositoryURI, p.Repository)
+	}
+	signer := id.BuildSignerURI
+	if signer == "" {
+		signer = id.SubjectAlternativeName
+	}
+	workflow, ok := strings.CutPrefix(signer, server+"/")
+	workflow, _, _ = strings.Cut(workflow, "@")
+	if p.Workflow != "" && (!ok || !oidc.Match(p.Workflow, workflow)) {
+		return fmt.Errorf("certificate workflow %q does not match %q", signer, p.Workflow)
+	}
+	if p.Ref != "" && !oidc.Match(p.Ref, id.SourceRepositoryRef) {
+		return fmt.Errorf("certificate ref %q does not match %q", id.SourceRepositoryRef, p.Ref)
+	}
+	return nil
+}
+
+// VerifyOptions configure Verify.
+type VerifyOptions struct {
+	// Roots the signing certificate must chain to.
+	Roots *Roots
+	// RekorKey verifies the log's signed entry timestamp. When nil the
+	// timestamp is only used as the signing time, not checked.
+	RekorKey *ecdsa.PublicKey
+	// Identity the certificate must match.
+	Identity IdentityPolicy
+	// Digest is the SHA-256 digest of the artifact, required for bundles
+	// holding a message signature.
+	Digest []byte
+}
+
+// Verify checks b: the certificate chains to the roots and was valid when
+// the log recorded the signature, the signature is by the certificate's
+// key over the envelope or Digest, and the certificate identity matches
+// the policy. It returns the identity.
+func Verify(b *provenance.Bundle, opts VerifyOptions) (*Identity, error) {
+	m := b.VerificationMaterial
+	if m == nil || m.Certificate == nil {
+		return nil, fmt.Errorf("bundle has no signing certificate")
+	}
+	if opts.Roots == nil {
+		return nil, fmt.Errorf("no trust roots given")
+	}
+	cert, err := x509.ParseCertificate(m.Certificate.RawBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing certificate: %w", err)
+	}
+	if len(m.TlogEntries) == 0 {
+		return nil, fmt.Errorf("bundle has no transparency log entry")
+	}
+	var entry TlogEntry
+	if err := json.Unmarshal(m.TlogEntries[0], &entry); err != nil {
+		return nil, fmt.Errorf("invalid transparency log entry: %w", err)
+	}
+	if opts.RekorKey != nil {
+		if err := verifyPromise(&entry, opts.RekorKey); err != nil {
+			return nil, err
+		}
+	}
+	integrated, err := strconv.ParseInt(entry.IntegratedTime, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integrated time %q", entry.IntegratedTime)
+	}
+	// Certificates live for minutes; what matters is that the signature
+	// was logged while the certificate was valid.
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         opts.Roots.Roots,
+		Intermediates: opts.Roots.Intermediates,
+		CurrentTime:   time.Unix(integrated, 0),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return nil, fmt.Errorf("invalid signing certificate: %w", err)
+	}
+
+	var sig []byte
+	switch {
+	case b.DSSEEnvelope != nil:
+		if err := b.DSSEEnvelope.Verify(cert.PublicKey); err != nil {
+			return nil, err
+		}
+		if len(b.DSSEEnvelope.Signatures) > 0 {
+			sig = b.DSSEEnvelope.Signatures[0].Sig
+		}
+	case b.MessageSignature != nil:
+		ms := b.MessageSignature
+		if opts.Digest == nil {
+			return nil, fmt.Errorf("artifact digest is required to verify a message signature")
+		}
+		if !bytes.Equal(ms.MessageDigest.Digest, opts.Digest) {
+			return nil, fmt.Errorf("signature is for another artifact")
+		}
+		key, ok := cert.PublicKey.(*ecdsa.PublicKey)
+		if !ok || len(opts.Digest) != sha256.Size || !ecdsa.VerifyASN1(key, opts.Digest, ms.Signature) {
+			return nil, fmt.Errorf("no valid signature")
+		}
+		sig = ms.Signature
+	default:
+		return nil, fmt.Errorf("bundle has no signature")
+	}
+	if err := checkBody(entry.CanonicalizedBody, sig); err != nil {
+		return nil, err
+	}
+
+	id, err := CertificateIdentity(cert)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.Identity.Check(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// checkBody checks that the log entry records sig, so an entry for another
+// signature cannot vouch for this one.
+func checkBody(body, sig []byte) error {
+	var entry struct {
+		Spec struct {
+			// hashedrekord
+			Signature struct {
+				Content []byte `json:"content"`
+			} `json:"signature"`
+			// dsse
+			Signatures []struct {
+				Signature string `json:"signature"`
+			} `json:"signatures"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return fmt.Errorf("invalid transparency log entry body: %w", err)
+	}
+	if bytes.Equal(entry.Spec.Signature.Content, sig) && sig != nil {
+		return nil
+	}
+	for _, s := range entry.Spec.Signatures {
+		// dsse entries hold the signature base64-encoded as in the envelope.
+		if decoded, err := base64.StdEncoding.DecodeString(s.Signature); err == nil && bytes.Equal(decoded, sig) {
+			return nil
+		}
+	}
+	return fmt.Errorf("transparency log entry is for another signature")
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package status publishes commit statuses, the older kind of check that
+// some branch protections still require by context name.
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"testingdashboard/m/v2/checks"
+	"testingdashboard/m/v2/client"
+	"testingdashboard/m/v2/endpoints"
+)
+
+// States of a commit status.
+const (
+	Pending = "pending"
+	Success = "success"
+	Failure = "failure"
+	Error   = "error"
+)
+
+// DefaultContext is the context of statuses that do not name one.
+const DefaultContext = "default"
+
+// maxDescription is how many characters a description may have.
+const maxDescription = 140
+
+// Status is the state of one context on a commit.
+type Status struct {
+	State string `json:"state"`
+	// Context names the status; a newer status of the same context
+	// replaces it.
+	Context     string `json:"context"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
+// Publisher sets statuses on a commit, skipping a status identical to the
+// latest one of its context. It is safe for concurrent use.
+type Publisher struct {
+	Client      *client.Client
+	Owner, Repo string
+	SHA         string
+	// TargetURL is given to statuses that have none, typically the page of
+	// the run.
+	TargetURL string
+
+	mu sync.Mutex
+	// latest holds the latest status of each context, loaded from the
+	// commit on first use.
+	latest map[string]Status
+}
+
+// NewFromEnv returns a publisher for the commit of the current run whose
+// statuses link to the run.
+func NewFromEnv(c *client.Client) (*Publisher, error) {
+	owner, repo, ok := strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/")
+	sha := os.Getenv("GITHUB_SHA")
+	if !ok || sha == "" {
+		return nil, errors.New("GITHUB_REPOSITORY and GITHUB_SHA are not set; is this running in a workflow?")
+	}
+	p := &Publisher{Client: c, Owner: owner, Repo: repo, SHA: sha}
+	if id := os.Getenv("GITHUB_RUN_ID"); id != "" {
+		p.TargetURL = endpoints.FromEnv().Repository(owner+"/"+repo) + "/actions/runs/" + id
+	}
+	return p, nil
+}
+
+// Set publishes s unless the latest status of its context is identical,
+// and reports whether it did.
+func (p *Publisher) Set(ctx context.Context, s Status) (bool, error) {
+	switch s.State {
+	case Pending, Success, Failure, Error:
+	default:
+		return false, fmt.Errorf("invalid status state %q", s.State)
+	}
+	if s.Context == "" {
+		s.Context = DefaultContext
+	}
+	if s.TargetURL == "" {
+		s.TargetURL = p.TargetURL
+	}
+	if r := []rune(s.Description); len(r) > maxDescription {
+		s.Description = string(r[:maxDescription-1]) + "…"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.latest == nil {
+		statuses, err := checks.Statuses(ctx, p.Client, p.Owner, p.Repo, p.SHA)
+		if err != nil {
+			return false, err
+		}
+		p.latest = map[string]Status{}
+		for _, st := range statuses {
+			p.latest[st.Context] = Status{State: st.State, Context: st.Context, Description: st.Description, TargetURL: st.TargetURL}
+		}
+	}
+	if last, ok := p.latest[s.Context]; ok && last == s {
+		return false, nil
+	}
+	path := fmt.Sprintf("/repos/%s/%s/statuses/%s", url.PathEscape(p.Owner), url.PathEscape(p.Repo), url.PathEscape(p.SHA))
+	if err := p.Client.Do(ctx, http.MethodPost, path, s, nil); err != nil {
+		return false, fmt.Errorf("failed to set status %s on %s: %w", s.Context, p.SHA, err)
+	}
+	p.latest[s.Context] = s
+	return true, nil
+}
+
+// Pending marks the context name pending.
+func (p *Publisher) Pending(ctx context.Context, name, description string) error {
+	_, err := p.Set(ctx, Status{State: Pending, Context: name, Description: description})
+	return err
+}
+
+// Succeed marks the context name successful.
+func (p *Publisher) Succeed(ctx context.Context, name, description string) error {
+	_, err := p.Set(ctx, Status{State: Success, Context: name, Description: description})
+	return err
+}
+
+// Fail marks the context name failed.
+func (p *Publisher) Fail(ctx context.Context, name, description string) error {
+	_, err := p.Set(ctx, Status{State: Failure, Context: name, Description: description})
+	return err
+}
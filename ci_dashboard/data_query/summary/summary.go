@@ -0,0 +1,321 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package summary builds job summaries, the markdown a step writes to
+// GITHUB_STEP_SUMMARY to show on the run's summary page.
+package summary
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxSize is the largest summary GitHub accepts for one step.
+const MaxSize = 1 << 20
+
+// Builder accumulates a summary. Its methods return the builder so calls can
+// be chained.
+type Builder struct {
+	blocks []string
+}
+
+// New returns an empty builder.
+func New() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) add(s string) *Builder {
+	b.blocks = append(b.blocks, s)
+	return b
+}
+
+// Raw adds text as is.
+func (b *Builder) Raw(text string) *Builder {
+	return b.add(text)
+}
+
+// EOL adds a line break in the markdown source.
+func (b *Builder) EOL() *Builder {
+	return b.add("\n")
+}
+
+// Heading adds a heading of the given level, 1 to 6.
+func (b *Builder) Heading(text string, level int) *Builder {
+	level = min(max(level, 1), 6)
+	return b.add(fmt.Sprintf("<h%d>%s</h%d>\n", level, text, level))
+}
+
+// Paragraph adds a paragraph.
+func (b *Builder) Paragraph(text string) *Builder {
+	return b.add("<p>" + text + "</p>\n")
+}
+
+// CodeBlock adds preformatted code, escaped. lang may be empty.
+func (b *Builder) CodeBlock(code, lang string) *Builder {
+	attr := ""
+	if lang != "" {
+		attr = ` lang="` + html.EscapeString(lang) + `"`
+	}
+	return b.add("<pre" + attr + "><code>" + html.EscapeString(code) + "</code></pre>\n")
+}
+
+// List adds a bulleted or numbered list.
+func (b *Builder) List(items []string, ordered bool) *Builder {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	var s strings.Builder
+	s.WriteString("<" + tag + ">")
+	for _, item := range items {
+		s.WriteString("<li>" + item + "</li>")
+	}
+	s.WriteString("</" + tag + ">\n")
+	return b.add(s.String())
+}
+
+// Cell is a table cell.
+type Cell struct {
+	Data   string
+	Header bool
+	// Colspan and Rowspan are omitted when zero.
+	Colspan, Rowspan int
+}
+
+// Table adds a table.
+func (b *Builder) Table(rows [][]Cell) *Builder {
+	var s strings.Builder
+	s.WriteString("<table>")
+	for _, row := range rows {
+		s.WriteString("<tr>")
+		for _, c := range row {
+			tag := "td"
+			if c.Header {
+				tag = "th"
+			}
+			s.WriteString("<" + tag)
+			if c.Colspan > 0 {
+				s.WriteString(` colspan="` + strconv.Itoa(c.Colspan) + `"`)
+			}
+			if c.Rowspan > 0 {
+				s.WriteString(` rowspan="` + strconv.Itoa(c.Rowspan) + `"`)
+			}
+			s.WriteString(">" + c.Data + "</" + tag + ">")
+		}
+		s.WriteString("</tr>")
+	}
+	s.WriteString("</table>\n")
+	return b.add(s.String())
+}
+
+// SimpleTable adds a table with a header row.
+func (b *Builder) SimpleTable(header []string, rows [][]string) *Builder {
+	cells := make([][]Cell, 0, len(rows)+1)
+	var head []Cell
+	for _, h := range header {
+		head = append(head, Cell{Data: h, Header: true})
+	}
+	cells = append(cells, head)
+	for _, row := range rows {
+		var r []Cell
+		for _, v := range row {
+			r = append(r, Cell{Data: v})
+		}
+		cells = append(cells, r)
+	}
+	return b.Table(cells)
+}
+
+// Details adds a collapsible section.
+func (b *Builder) Details(label, content string) *Builder {
+	return b.add("<details><summary>" + label + "</summary>" + content + "</details>\n")
+}
+
+// Image adds an image. width and height are omitted when empty.
+func (b *Builder) Image(src, alt, width, height string) *Builder {
+	attrs := ` src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `"`
+	if width != "" {
+		attrs += ` width="` + html.EscapeString(width) + `"`
+	}
+	if height != "" {
+		attrs += ` height="` + html.EscapeString(height) + `"`
+	}
+	return b.add("<img" + attrs + ">\n")
+}
+
+// Quote adds a block quote, optionally citing a URL.
+func (b *Builder) Quote(text, cite string) *Builder {
+	attr := ""
+	if cite != "" {
+		attr = ` cite="` + html.EscapeString(cite) + `"`
+	}
+	return b.add("<blockquote" + attr + ">" + text + "</blockquote>\n")
+}
+
+// Link adds a link.
+func (b *Builder) Link(text, href string) *Builder {
+	return b.add(`<a href="` + html.EscapeString(href) + `">` + text + "</a>\n")
+}
+
+// Separator adds a horizontal rule.
+func (b *Builder) Separator() *Builder {
+	return b.add("<hr>\n")
+}
+
+// Break adds a line break.
+func (b *Builder) Break() *Builder {
+	return b.add("<br>\n")
+}
+
+// Len returns the size of the summary in bytes.
+func (b *Builder) Len() int {
+	n := 0
+	for _, s := range b.blocks {
+		n += len(s)
+	}
+	return n
+}
+
+// String returns the summary.
+func (b *Builder) String() string {
+	return strings.Join(b.blocks, "")
+}
+
+// Empty reports whether nothing has been added.
+func (b *Builder) Empty() bool {
+	return len(b.blocks) == 0
+}
+
+// Clear discards everything added so far.
+func (b *Builder) Clear() *Builder {
+	b.blocks = nil
+	return b
+}
+
+// Strategy decides what happens when a summary exceeds the size limit.
+type Strategy int
+
+const (
+	// Fail returns an error and writes nothing.
+	Fail Strategy = iota
+	// KeepHead drops the blocks at the end and adds a truncation notice.
+	KeepHead
+	// KeepTail drops the blocks at the start and adds a truncation notice.
+	KeepTail
+)
+
+// TruncationNotice marks where a truncated summary was cut.
+const TruncationNotice = "\n<p><em>Summary truncated: it exceeded the 1MB limit.</em></p>\n"
+
+// Truncate returns the summary cut to at most limit bytes by strategy.
+// Whole blocks are dropped where possible; a block that does not fit on its
+// own is cut at a character boundary.
+func (b *Builder) Truncate(limit int, strategy Strategy) (string, error) {
+	if b.Len() <= limit {
+		return b.String(), nil
+	}
+	if strategy == Fail || limit < len(TruncationNotice) {
+		return "", fmt.Errorf("summary is %d bytes, over the %d byte limit", b.Len(), limit)
+	}
+	room := limit - len(TruncationNotice)
+	blocks := b.blocks
+	if strategy == KeepTail {
+		blocks = reversed(blocks)
+	}
+	var kept []string
+	for _, s := range blocks {
+		if len(s) > room {
+			if len(kept) == 0 {
+				kept = append(kept, cut(s, room, strategy == KeepTail))
+			}
+			break
+		}
+		kept = append(kept, s)
+		room -= len(s)
+	}
+	if strategy == KeepTail {
+		return TruncationNotice + strings.Join(reversed(kept), ""), nil
+	}
+	return strings.Join(kept, "") + TruncationNotice, nil
+}
+
+func reversed(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
+
+// cut shortens s to n bytes without splitting a character, keeping the end
+// of s if tail is set.
+func cut(s string, n int, tail bool) string {
+	if tail {
+		i := len(s) - n
+		for i < len(s) && !utf8.RuneStart(s[i]) {
+			i++
+		}
+		return s[i:]
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// WriteOptions controls Write.
+type WriteOptions struct {
+	// Overwrite replaces the file instead of appending to it.
+	Overwrite bool
+	// Strategy applies when the file would exceed MaxSize.
+	Strategy Strategy
+}
+
+// Write writes the summary to GITHUB_STEP_SUMMARY and clears the builder.
+// The size limit counts whatever the step already wrote to the file.
+func (b *Builder) Write(opts WriteOptions) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return fmt.Errorf("GITHUB_STEP_SUMMARY is not set")
+	}
+	limit := MaxSize
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if !opts.Overwrite {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if info, err := os.Stat(path); err == nil {
+			limit -= int(info.Size())
+		}
+	}
+	content, err := b.Truncate(limit, opts.Strategy)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open summary file: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	b.Clear()
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolcache
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"testingdashboard/m/v2/semver"
+)
+
+// Dir returns the tool cache root, RUNNER_TOOL_CACHE.
+func Dir() (string, error) {
+	dir := os.Getenv("RUNNER_TOOL_CACHE")
+	if dir == "" {
+		return "", fmt.Errorf("RUNNER_TOOL_CACHE is not set")
+	}
+	return dir, nil
+}
+
+// Arch returns the architecture name used in tool cache paths, which
+// follows Node's os.arch(): x64, arm64, ia32 or arm.
+func Arch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "ia32"
+	}
+	return runtime.GOARCH
+}
+
+// toolPath returns the cache directory of a tool version. Versions are
+// cleaned so v1.2.3 and 1.2.3 share an entry.
+func toolPath(tool, version, arch string) (string, error) {
+	root, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	if v := semver.Clean(version); v != "" {
+		version = v
+	}
+	if arch == "" {
+		arch = Arch()
+	}
+	return filepath.Join(root, tool, version, arch), nil
+}
+
+// create empties the cache directory of a tool version, removing its
+// completion marker until the new contents are in place.
+func create(tool, version, arch string) (string, error) {
+	dir, err := toolPath(tool, version, arch)
+	if err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.Remove(dir + ".complete"); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func complete(dir string) error {
+	return os.WriteFile(dir+".complete", nil, 0o644)
+}
+
+// CacheDir copies the contents of src into the tool cache as a version of
+// tool and returns the cached directory. An empty arch means Arch().
+func CacheDir(src, tool, version, arch string) (string, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", src)
+	}
+	dir, err := create(tool, version, arch)
+	if err != nil {
+		return "", err
+	}
+	if err := copyDir(src, dir); err != nil {
+		return "", fmt.Errorf("failed to cache %s: %w", tool, err)
+	}
+	return dir, complete(dir)
+}
+
+// CacheFile copies the file src into the tool cache as a version of tool,
+// named target, and returns the cached directory.
+func CacheFile(src, target, tool, version, arch string) (string, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", src)
+	}
+	dir, err := create(tool, version, arch)
+	if err != nil {
+		return "", err
+	}
+	if err := copyFile(src, filepath.Join(dir, target), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to cache %s: %w", tool, err)
+	}
+	return dir, complete(dir)
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		out := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(out, info.Mode().Perm()|0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, out)
+		}
+		return copyFile(path, out, info.Mode().Perm())
+	})
+}
+
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return writeFile(dst, in, mode)
+}
+
+// Find returns the cached directory of the highest version of tool that
+// satisfies spec, which may be an exact version or a semver range, or ""
+// if there is none. Only complete entries are considered.
+func Find(tool, spec, arch string) (string, error) {
+	if tool == "" {
+		return "", fmt.Errorf("tool name is required")
+	}
+	if spec == "" {
+		return "", fmt.Errorf("version spec is required")
+	}
+	version := spec
+	if semver.Clean(spec) == "" {
+		r, err := semver.ParseRange(spec)
+		if err != nil {
+			return "", err
+		}
+		versions, err := FindAllVersions(tool, arch)
+		if err != nil {
+			return "", err
+		}
+		match, ok := r.MaxSatisfying(versions)
+		if !ok {
+			return "", nil
+		}
+		version = match
+	}
+	dir, err := toolPath(tool, version, arch)
+	if err != nil {
+		return "", err
+	}
+	if !exists(dir) || !exists(dir+".complete") {
+		return "", nil
+	}
+	return dir, nil
+}
+
+// FindAllVersions returns the complete cached versions of tool for arch,
+// in ascending order.
+func FindAllVersions(tool, arch string) ([]string, error) {
+	root, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	if arch == "" {
+		arch = Arch()
+	}
+	entries, err := os.ReadDir(filepath.Join(root, tool))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, e := range entries {
+		dir := filepath.Join(root, tool, e.Name(), arch)
+		if _, err := semver.Parse(e.Name()); err == nil && exists(dir) && exists(dir+".complete") {
+			versions = append(versions, e.Name())
+		}
+	}
+	slices.SortFunc(versions, func(a, b string) int {
+		va, _ := semver.Parse(a)
+		vb, _ := semver.Parse(b)
+		return va.Compare(vb)
+	})
+	return versions, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
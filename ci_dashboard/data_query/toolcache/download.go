@@ -0,0 +1,155 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolcache downloads, extracts and caches tools in the runner's
+// tool cache, following @actions/tool-cache.
+package toolcache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HTTPError is a download that failed with an HTTP status.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected HTTP response from %s: %d", e.URL, e.StatusCode)
+}
+
+// DownloadOptions controls DownloadTool.
+type DownloadOptions struct {
+	// Dest is the file to write. It defaults to a random name in
+	// RUNNER_TEMP.
+	Dest string
+	// Auth, if set, is sent as the Authorization header, e.g. "token ...".
+	Auth string
+	// Header holds extra request headers.
+	Header http.Header
+	// MaxAttempts defaults to 3.
+	MaxAttempts int
+	// RetryDelay is the wait between attempts. Defaults to 10 seconds.
+	RetryDelay time.Duration
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// DownloadTool downloads url and returns the path of the file. Server
+// errors, 408 and 429 are retried; other client errors are not.
+func DownloadTool(ctx context.Context, url string, opts DownloadOptions) (string, error) {
+	dest := opts.Dest
+	if dest == "" {
+		dest = filepath.Join(tempDir(), randomName())
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return "", fmt.Errorf("destination file %s already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", err
+	}
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = 10 * time.Second
+	}
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = download(ctx, url, dest, opts); err == nil {
+			return dest, nil
+		}
+		os.Remove(dest)
+		var herr *HTTPError
+		if errors.As(err, &herr) && herr.StatusCode < 500 &&
+			herr.StatusCode != http.StatusRequestTimeout && herr.StatusCode != http.StatusTooManyRequests {
+			return "", err
+		}
+		if attempt >= attempts || ctx.Err() != nil {
+			return "", fmt.Errorf("failed to download %s: %w", url, err)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func download(ctx context.Context, url, dest string, opts DownloadOptions) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, vs := range opts.Header {
+		req.Header[k] = vs
+	}
+	if opts.Auth != "" {
+		req.Header.Set("Authorization", opts.Auth)
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{URL: url, StatusCode: resp.StatusCode}
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return fmt.Errorf("incomplete download: got %d of %d bytes", n, resp.ContentLength)
+	}
+	return nil
+}
+
+// tempDir returns RUNNER_TEMP, or the system temporary directory outside a
+// runner.
+func tempDir() string {
+	if dir := os.Getenv("RUNNER_TEMP"); dir != "" {
+		return dir
+	}
+	return os.TempDir()
+}
+
+func randomName() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
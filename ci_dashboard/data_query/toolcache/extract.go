@@ -0,0 +1,276 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolcache
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// destDir returns dest, or a new directory in RUNNER_TEMP if it is empty.
+func destDir(dest string) (string, error) {
+	if dest == "" {
+		dest = filepath.Join(tempDir(), randomName())
+	}
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(dest)
+}
+
+// target joins name to dir, refusing names that escape it, either directly
+// or through a symlink extracted earlier.
+func target(dir, name string) (string, error) {
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if !within(dir, p) {
+		return "", fmt.Errorf("archive entry %q escapes the target directory", name)
+	}
+	if p == dir {
+		return p, nil
+	}
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(p)); err == nil && !within(dir, parent) {
+		return "", fmt.Errorf("archive entry %q escapes the target directory", name)
+	}
+	return p, nil
+}
+
+func within(dir, p string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))
+}
+
+// ExtractTar extracts a tar archive into dest, or a new temporary directory
+// if dest is empty, and returns the directory. Gzip and bzip2 compression
+// are detected and handled natively; xz and zstd use the xz and zstd
+// binaries.
+func ExtractTar(ctx context.Context, file, dest string) (string, error) {
+	dir, err := destDir(dest)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(6)
+
+	var r io.Reader = br
+	var cmd *exec.Cmd
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		defer zr.Close()
+		r = zr
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		r = bzip2.NewReader(br)
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0}):
+		cmd = exec.CommandContext(ctx, "xz", "-dc")
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		cmd = exec.CommandContext(ctx, "zstd", "-dc")
+	}
+	if cmd != nil {
+		cmd.Stdin = br
+		cmd.Stderr = os.Stderr
+		out, err := cmd.StdoutPipe()
+		if err != nil {
+			return "", err
+		}
+		if err := cmd.Start(); err != nil {
+			return "", fmt.Errorf("failed to start %s: %w", cmd.Args[0], err)
+		}
+		r = out
+	}
+	err = extractTar(r, dir)
+	if cmd != nil {
+		// Drain so the decompressor can exit before waiting on it.
+		io.Copy(io.Discard, r)
+		if werr := cmd.Wait(); err == nil && werr != nil {
+			err = fmt.Errorf("%s failed: %w", cmd.Args[0], werr)
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", file, err)
+	}
+	return dir, nil
+}
+
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path, err := target(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, mode.Perm()|0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(path, tr, mode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			os.Remove(path)
+			if err := os.Symlink(hdr.Linkname, path); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			old, err := target(dir, hdr.Linkname)
+			if err != nil {
+				return err
+			}
+			os.Remove(path)
+			if err := os.Link(old, path); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if mode == 0 {
+		mode = 0o644
+	}
+	// Remove first so a symlink from an earlier entry is not followed.
+	os.Remove(path)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ExtractZip extracts a zip archive into dest, or a new temporary directory
+// if dest is empty, and returns the directory.
+func ExtractZip(file, dest string) (string, error) {
+	dir, err := destDir(dest)
+	if err != nil {
+		return "", err
+	}
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		path, err := target(dir, zf.Name)
+		if err != nil {
+			return "", err
+		}
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			err = os.MkdirAll(path, 0o755)
+		case mode&os.ModeSymlink != 0:
+			err = extractZipSymlink(zf, path)
+		default:
+			err = extractZipFile(zf, path)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to extract %s: %w", zf.Name, err)
+		}
+	}
+	return dir, nil
+}
+
+func extractZipFile(zf *zip.File, path string) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeFile(path, rc, zf.Mode().Perm())
+}
+
+func extractZipSymlink(zf *zip.File, path string) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	link, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	os.Remove(path)
+	return os.Symlink(string(link), path)
+}
+
+// Extract7z extracts a 7z archive into dest, or a new temporary directory
+// if dest is empty, with the first of 7z, 7za and 7zr found on PATH.
+func Extract7z(ctx context.Context, file, dest string) (string, error) {
+	dir, err := destDir(dest)
+	if err != nil {
+		return "", err
+	}
+	var bin string
+	for _, name := range []string{"7z", "7za", "7zr"} {
+		if p, err := exec.LookPath(name); err == nil {
+			bin = p
+			break
+		}
+	}
+	if bin == "" {
+		return "", fmt.Errorf("failed to extract %s: 7z is not installed", file)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, bin, "x", "-bb1", "-bd", "-sccUTF-8", "-y", "-o"+dir, abs)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", file, err)
+	}
+	return dir, nil
+}
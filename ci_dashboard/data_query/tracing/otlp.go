@@ -0,0 +1,257 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the OTLP/HTTP endpoint of a local collector.
+const DefaultEndpoint = "http://localhost:4318"
+
+// OTLPExporter sends spans to an OTLP/HTTP endpoint as JSON.
+type OTLPExporter struct {
+	// URL is the traces URL, such as http://localhost:4318/v1/traces.
+	URL     string
+	Headers map[string]string
+	// Resource holds attributes of the process, such as service.name.
+	Resource   map[string]any
+	HTTPClient *http.Client
+	// Timeout bounds each export. Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// Export implements Exporter.
+func (e *OTLPExporter) Export(ctx context.Context, spans []*SpanData) error {
+	body, err := json.Marshal(otlpRequest(e.Resource, spans))
+	if err != nil {
+		return err
+	}
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	hc := e.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s: %s", e.URL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// ConsoleExporter writes each batch of spans as an OTLP JSON line, for
+// debugging and for collectors that read files.
+type ConsoleExporter struct {
+	W        io.Writer
+	Resource map[string]any
+}
+
+// Export implements Exporter.
+func (e *ConsoleExporter) Export(ctx context.Context, spans []*SpanData) error {
+	return json.NewEncoder(e.W).Encode(otlpRequest(e.Resource, spans))
+}
+
+// otlpRequest builds an ExportTraceServiceRequest in the protobuf JSON
+// mapping: IDs in hex and 64-bit integers as strings.
+func otlpRequest(resource map[string]any, spans []*SpanData) map[string]any {
+	out := make([]map[string]any, len(spans))
+	for i, s := range spans {
+		span := map[string]any{
+			"traceId":           s.Context.TraceID.String(),
+			"spanId":            s.Context.SpanID.String(),
+			"name":              s.Name,
+			"kind":              s.Kind,
+			"startTimeUnixNano": strconv.FormatInt(s.Start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.End.UnixNano(), 10),
+			"attributes":        attributes(s.Attributes),
+			"status":            map[string]any{"code": s.StatusCode, "message": s.StatusMessage},
+		}
+		if s.Parent != (SpanID{}) {
+			span["parentSpanId"] = s.Parent.String()
+		}
+		out[i] = span
+	}
+	return map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{"attributes": attributes(resource)},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "testingdashboard/m/v2/tracing"},
+				"spans": out,
+			}},
+		}},
+	}
+}
+
+func attributes(m map[string]any) []any {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]any, 0, len(keys))
+	for _, k := range keys {
+		var v map[string]any
+		switch x := m[k].(type) {
+		case bool:
+			v = map[string]any{"boolValue": x}
+		case int:
+			v = map[string]any{"intValue": strconv.Itoa(x)}
+		case int64:
+			v = map[string]any{"intValue": strconv.FormatInt(x, 10)}
+		case float64:
+			v = map[string]any{"doubleValue": x}
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(x)}
+		}
+		out = append(out, map[string]any{"key": k, "value": v})
+	}
+	return out
+}
+
+// FromEnv returns a tracer configured by the standard OpenTelemetry
+// variables, or nil when tracing is not enabled. Tracing is enabled by
+// OTEL_TRACES_EXPORTER=otlp or console, or by setting an OTLP endpoint;
+// OTEL_SDK_DISABLED=true and OTEL_TRACES_EXPORTER=none disable it. Only
+// the http/json protocol is supported.
+func FromEnv(serviceName string) (*Tracer, error) {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil, nil
+	}
+	exporter := os.Getenv("OTEL_TRACES_EXPORTER")
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if exporter == "" && (endpoint != "" || base != "") {
+		exporter = "otlp"
+	}
+	resource, err := resourceFromEnv(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	switch exporter {
+	case "", "none":
+		return nil, nil
+	case "console":
+		return &Tracer{Exporter: &ConsoleExporter{W: os.Stderr, Resource: resource}}, nil
+	case "otlp":
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q", exporter)
+	}
+
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	if protocol != "" && protocol != "http/json" {
+		return nil, fmt.Errorf("unsupported OTLP protocol %q; only http/json is supported", protocol)
+	}
+	// The signal-specific endpoint is used as is; the general one gets
+	// the traces path appended.
+	if endpoint == "" {
+		if base == "" {
+			base = DefaultEndpoint
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	exp := &OTLPExporter{URL: endpoint, Resource: resource}
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		headers, err := parseList(os.Getenv(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if exp.Headers == nil && len(headers) > 0 {
+			exp.Headers = map[string]string{}
+		}
+		for k, v := range headers {
+			exp.Headers[k] = v
+		}
+	}
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_TIMEOUT", "OTEL_EXPORTER_OTLP_TRACES_TIMEOUT"} {
+		if s := os.Getenv(name); s != "" {
+			ms, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: want milliseconds", name, s)
+			}
+			exp.Timeout = time.Duration(ms) * time.Millisecond
+		}
+	}
+	return &Tracer{Exporter: exp}, nil
+}
+
+// resourceFromEnv reads OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME,
+// which takes precedence, defaulting service.name to serviceName.
+func resourceFromEnv(serviceName string) (map[string]any, error) {
+	attrs, err := parseList(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTEL_RESOURCE_ATTRIBUTES: %w", err)
+	}
+	resource := map[string]any{"service.name": serviceName, "telemetry.sdk.language": "go"}
+	for k, v := range attrs {
+		resource[k] = v
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		resource["service.name"] = name
+	}
+	return resource, nil
+}
+
+// parseList parses the comma-separated key=value lists of the OTEL
+// variables, whose values are percent-encoded.
+func parseList(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not key=value", item)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", item, err)
+		}
+		out[strings.TrimSpace(k)] = value
+	}
+	return out, nil
+}
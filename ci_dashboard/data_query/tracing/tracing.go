@@ -0,0 +1,287 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing records OpenTelemetry spans and exports them over OTLP,
+// propagating trace context in the W3C traceparent format.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceID and SpanID identify traces and spans.
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+
+// SpanContext is what a span's children need to know about it.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// Valid reports whether neither ID is zero.
+func (sc SpanContext) Valid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// TraceParent formats sc as a traceparent header.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceParent parses a traceparent header, as in the TRACEPARENT
+// variable a parent process sets.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	trace, err1 := hex.DecodeString(parts[1])
+	span, err2 := hex.DecodeString(parts[2])
+	flags, err3 := hex.DecodeString(parts[3])
+	if err1 != nil || err2 != nil || err3 != nil || len(trace) != 16 || len(span) != 8 || len(flags) != 1 {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	copy(sc.TraceID[:], trace)
+	copy(sc.SpanID[:], span)
+	sc.Sampled = flags[0]&1 == 1
+	if !sc.Valid() {
+		return sc, fmt.Errorf("invalid traceparent %q", s)
+	}
+	return sc, nil
+}
+
+// Span kinds, as numbered by OTLP.
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// Status codes, as numbered by OTLP.
+const (
+	StatusUnset = 0
+	StatusOK    = 1
+	StatusError = 2
+)
+
+// Span is an operation being timed. Its methods do nothing on a nil
+// *Span, which is what a nil *Tracer starts.
+type Span struct {
+	tracer *Tracer
+
+	mu   sync.Mutex
+	data SpanData
+	done bool
+}
+
+// SpanData is a finished span as exporters receive it.
+type SpanData struct {
+	Name          string
+	Kind          int
+	Context       SpanContext
+	Parent        SpanID
+	Start, End    time.Time
+	Attributes    map[string]any
+	StatusCode    int
+	StatusMessage string
+}
+
+// Context returns the span's context, or the zero SpanContext.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.Context
+}
+
+// TraceParent returns the traceparent header for the span's children, or
+// "" for a nil span.
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.Context.TraceParent()
+}
+
+// SetAttribute sets an attribute. Values are strings, bools, ints, int64s
+// or float64s.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = map[string]any{}
+	}
+	s.data.Attributes[key] = value
+}
+
+// SetStatus sets the span status; message is kept for errors only.
+func (s *Span) SetStatus(code int, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.StatusCode = code
+	if code == StatusError {
+		s.data.StatusMessage = message
+	}
+}
+
+// End finishes the span and hands it to the tracer's exporter. Later calls
+// do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
+	}
+	s.done = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.tracer.finish(&data)
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the span in ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+type remoteKey struct{}
+
+// WithRemoteParent returns a context whose spans are children of a span
+// in another process, such as the one TRACEPARENT names.
+func WithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Tracer starts spans and batches finished ones for its exporter. A nil
+// *Tracer starts nil spans, so tracing costs nothing when disabled.
+type Tracer struct {
+	Exporter Exporter
+	// MaxBatch is how many finished spans are buffered before they are
+	// exported. Defaults to 512.
+	MaxBatch int
+
+	mu      sync.Mutex
+	pending []*SpanData
+	// exporting tracks batches exported in the background.
+	exporting sync.WaitGroup
+}
+
+// Exporter sends finished spans somewhere.
+type Exporter interface {
+	Export(ctx context.Context, spans []*SpanData) error
+}
+
+// Start starts a span named name as a child of the span in ctx, or of the
+// remote parent, or as the root of a new trace. Call End on the span.
+func (t *Tracer) Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{tracer: t, data: SpanData{Name: name, Kind: kind, Start: time.Now()}}
+	sc := SpanContext{Sampled: true}
+	if parent := SpanFromContext(ctx); parent != nil {
+		sc.TraceID, s.data.Parent = parent.data.Context.TraceID, parent.data.Context.SpanID
+		sc.Sampled = parent.data.Context.Sampled
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		// Honor the parent's sampling decision so traces are either
+		// complete or absent.
+		sc.TraceID, s.data.Parent = remote.TraceID, remote.SpanID
+		sc.Sampled = remote.Sampled
+	} else {
+		rand.Read(sc.TraceID[:])
+	}
+	rand.Read(sc.SpanID[:])
+	s.data.Context = sc
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (t *Tracer) finish(data *SpanData) {
+	if !data.Context.Sampled {
+		return
+	}
+	t.mu.Lock()
+	t.pending = append(t.pending, data)
+	full := len(t.pending) >= t.maxBatch()
+	t.mu.Unlock()
+	if full {
+		// Spans end on the traced code path, so export without holding it
+		// up; Flush reports errors of the final batch.
+		t.exporting.Add(1)
+		go func() {
+			defer t.exporting.Done()
+			t.export(context.Background())
+		}()
+	}
+}
+
+func (t *Tracer) maxBatch() int {
+	if t.MaxBatch <= 0 {
+		return 512
+	}
+	return t.MaxBatch
+}
+
+// Flush exports the finished spans not yet exported and waits for
+// background exports. Call it before the program exits.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.exporting.Wait()
+	return t.export(ctx)
+}
+
+func (t *Tracer) export(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(spans) == 0 || t.Exporter == nil {
+		return nil
+	}
+	if err := t.Exporter.Export(ctx, spans); err != nil {
+		return fmt.Errorf("failed to export %d spans: %w", len(spans), err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// Modes of a Cassette.
+const (
+	// CassetteOnce replays the cassette if its file exists and records it
+	// otherwise.
+	CassetteOnce = "once"
+	// CassetteRecord sends every request and records it, replacing the
+	// file.
+	CassetteRecord = "record"
+	// CassetteReplay answers every request from the file and fails those
+	// it has no response for, so nothing reaches the network.
+	CassetteReplay = "replay"
+)
+
+// redactedHeaders are not recorded, nor the token of a response, so
+// cassettes hold no credentials.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// Cassette records the HTTP requests sent through its Middleware and their
+// responses to a file, and replays them from it, so code that talks to
+// APIs runs offline and deterministically. Requests to loopback addresses
+// pass through untouched. Use NewCassette or CassetteFromEnv.
+type Cassette struct {
+	path   string
+	replay bool
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	// used marks the interactions replayed; each is replayed once, in
+	// order, and the last one matching a request again after that.
+	used []bool
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request as a Cassette matches it: by method, URL
+// and body.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response. Body is set for UTF-8 bodies
+// and BodyBase64 for others.
+type RecordedResponse struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+type cassetteFile struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// NewCassette returns a cassette of the file at path in one of the
+// Cassette modes.
+func NewCassette(path, mode string) (*Cassette, error) {
+	c := &Cassette{path: path}
+	switch mode {
+	case CassetteRecord:
+		return c, nil
+	case CassetteOnce, "":
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+	case CassetteReplay:
+	default:
+		return nil, fmt.Errorf("invalid cassette mode %q; want %s, %s or %s", mode, CassetteOnce, CassetteRecord, CassetteReplay)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f cassetteFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	c.replay, c.interactions, c.used = true, f.Interactions, make([]bool, len(f.Interactions))
+	return c, nil
+}
+
+// CassetteFromEnv returns the cassette of the file $ACTIONS_CASSETTE in
+// the mode $ACTIONS_CASSETTE_MODE, once by default, or nil if
+// ACTIONS_CASSETTE is not set.
+func CassetteFromEnv() (*Cassette, error) {
+	path := os.Getenv("ACTIONS_CASSETTE")
+	if path == "" {
+		return nil, nil
+	}
+	return NewCassette(path, os.Getenv("ACTIONS_CASSETTE_MODE"))
+}
+
+// Replaying reports whether the cassette answers requests rather than
+// recording them.
+func (c *Cassette) Replaying() bool {
+	return c.replay
+}
+
+// Middleware is the Middleware of c. A nil next is http.DefaultTransport,
+// so a test can use c.Middleware(nil) as the transport of a client.
+func (c *Cassette) Middleware(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if isLoopback(req.URL.Hostname()) {
+			return next.RoundTrip(req)
+		}
+		body, err := requestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		rec := RecordedRequest{Method: req.Method, URL: req.URL.String(), Body: string(body)}
+		if c.replay {
+			return c.play(req, rec)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if err := c.record(rec, resp, data); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	})
+}
+
+// requestBody reads the body of req and leaves it readable again.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func (c *Cassette) play(req *http.Request, rec RecordedRequest) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := -1
+	for i, in := range c.interactions {
+		if in.Request != rec {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return in.Response.response(req)
+		}
+		last = i
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("cassette %s has no response to %s %s", c.path, rec.Method, rec.URL)
+	}
+	return c.interactions[last].Response.response(req)
+}
+
+func (r *RecordedResponse) response(req *http.Request) (*http.Response, error) {
+	body := []byte(r.Body)
+	if r.BodyBase64 != "" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(r.BodyBase64); err != nil {
+			return nil, fmt.Errorf("invalid recorded body of %s %s: %w", req.Method, req.URL, err)
+		}
+	}
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// record adds an interaction and rewrites the file, so the cassette is
+// complete however the process exits.
+func (c *Cassette) record(rec RecordedRequest, resp *http.Response, body []byte) error {
+	header := resp.Header.Clone()
+	for _, h := range redactedHeaders {
+		header.Del(h)
+	}
+	out := RecordedResponse{Status: resp.StatusCode, Header: header}
+	body = redactToken(body)
+	if utf8.Valid(body) {
+		out.Body = string(body)
+	} else {
+		out.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, &Interaction{Request: rec, Response: out})
+	data, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// redactToken replaces the token of a response such as that of an app
+// installation token or a runner registration token.
+func redactToken(body []byte) []byte {
+	var obj map[string]json.RawMessage
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) || json.Unmarshal(body, &obj) != nil {
+		return body
+	}
+	var token string
+	if json.Unmarshal(obj["token"], &token) != nil || token == "" {
+		return body
+	}
+	obj["token"] = json.RawMessage(`"REDACTED"`)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
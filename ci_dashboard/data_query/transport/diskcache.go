@@ -0,0 +1,276 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDiskCacheTTL is the TTL of DiskCacheFromEnv.
+const DefaultDiskCacheTTL = time.Hour
+
+// ErrOffline is the error of a request an offline DiskCache holds no
+// response for.
+var ErrOffline = errors.New("offline and not cached")
+
+// DiskCache keeps GET responses in a directory, so they outlive the
+// process and are shared by every client using the directory. A response
+// younger than TTL is served without a request; an older one is
+// revalidated with its ETag and served again on 304 Not Modified. When the
+// request fails, or the server errors or is rate limiting, the kept
+// response is served however old it is. Place it before Retry, so it falls
+// back only once retrying gave up.
+type DiskCache struct {
+	Dir string
+	// TTL is how long a response is served without revalidating it. Zero
+	// revalidates every time.
+	TTL time.Duration
+	// Offline sends no requests: kept responses are served and the others
+	// fail with ErrOffline.
+	Offline bool
+	// MaxBodySize is the largest body kept. Defaults to 1 MiB.
+	MaxBodySize int64
+	// OnHit, if set, is called when a kept response is served.
+	OnHit func(req *http.Request)
+}
+
+// diskEntry is the file of a kept response.
+type diskEntry struct {
+	URL    string      `json:"url"`
+	ETag   string      `json:"etag,omitempty"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	// Stored is when the response was received or last revalidated.
+	Stored time.Time `json:"stored"`
+}
+
+// DefaultDiskCacheDir returns the directory of DiskCacheFromEnv, honoring
+// ACTIONS_HTTP_CACHE.
+func DefaultDiskCacheDir() (string, error) {
+	if dir := os.Getenv("ACTIONS_HTTP_CACHE"); dir != "" {
+		return dir, nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "actions-runner", "http"), nil
+}
+
+// DiskCacheFromEnv returns the cache in DefaultDiskCacheDir with the TTL
+// $ACTIONS_HTTP_CACHE_TTL, DefaultDiskCacheTTL by default, that is offline
+// if ACTIONS_OFFLINE is true. It returns nil if ACTIONS_HTTP_CACHE is off.
+func DiskCacheFromEnv() (*DiskCache, error) {
+	if os.Getenv("ACTIONS_HTTP_CACHE") == "off" {
+		return nil, nil
+	}
+	dir, err := DefaultDiskCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	c := &DiskCache{Dir: dir, TTL: DefaultDiskCacheTTL}
+	if s := os.Getenv("ACTIONS_HTTP_CACHE_TTL"); s != "" {
+		if c.TTL, err = time.ParseDuration(s); err != nil {
+			return nil, fmt.Errorf("invalid ACTIONS_HTTP_CACHE_TTL: %w", err)
+		}
+	}
+	if s := os.Getenv("ACTIONS_OFFLINE"); s != "" {
+		if c.Offline, err = strconv.ParseBool(s); err != nil {
+			return nil, fmt.Errorf("invalid ACTIONS_OFFLINE: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Client returns a client that sends requests through c and then retries
+// server errors and waits out secondary rate limits. A nil c returns
+// DefaultClient.
+func (c *DiskCache) Client() *http.Client {
+	if c == nil {
+		return DefaultClient()
+	}
+	return NewClient(c.Middleware, Retry(RetryOptions{}), SecondaryRateLimit(RateLimitOptions{}))
+}
+
+// Middleware is the Middleware of c.
+func (c *DiskCache) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" || req.Header.Get("Range") != "" {
+			if c.Offline {
+				return nil, ErrOffline
+			}
+			return next.RoundTrip(req)
+		}
+		name := c.file(req)
+		prev := c.load(name)
+		switch {
+		case prev != nil && (c.Offline || time.Since(prev.Stored) < c.TTL):
+			return c.serve(req, nil, prev), nil
+		case c.Offline:
+			return nil, ErrOffline
+		}
+		if prev != nil && prev.ETag != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		resp, err := next.RoundTrip(req)
+		switch {
+		case err != nil && prev != nil:
+			return c.serve(req, nil, prev), nil
+		case err != nil:
+			return nil, err
+		case prev != nil && resp.StatusCode == http.StatusNotModified:
+			discard(resp)
+			prev.Stored = time.Now()
+			c.save(name, prev)
+			return c.serve(req, resp, prev), nil
+		case prev != nil && unavailable(resp):
+			discard(resp)
+			return c.serve(req, nil, prev), nil
+		case resp.StatusCode == http.StatusOK && !strings.Contains(resp.Header.Get("Cache-Control"), "no-store"):
+			c.store(name, req, resp)
+		}
+		return resp, nil
+	})
+}
+
+// unavailable reports whether resp is a server error or a rate limit, when
+// a kept response is better than none.
+func unavailable(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return resp.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return resp.StatusCode >= 500
+}
+
+// file is where the response to req is kept, named by the key of
+// ETagCache.
+func (c *DiskCache) file(req *http.Request) string {
+	sum := sha256.Sum256([]byte(etagKey(req)))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.Dir, key[:2], key[2:]+".json")
+}
+
+func (c *DiskCache) load(name string) *diskEntry {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil
+	}
+	var e diskEntry
+	if json.Unmarshal(data, &e) != nil {
+		return nil
+	}
+	return &e
+}
+
+// save writes e to name through a temporary file, so concurrent readers
+// never see half of it.
+func (c *DiskCache) save(name string, e *diskEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// store keeps resp if its body is small enough, leaving the body readable.
+// Failing to write the cache does not fail the request.
+func (c *DiskCache) store(name string, req *http.Request, resp *http.Response) {
+	limit := c.MaxBodySize
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil || int64(len(data)) > limit {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		return
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	c.save(name, &diskEntry{URL: req.URL.Redacted(), ETag: resp.Header.Get("ETag"), Header: resp.Header.Clone(), Body: data, Stored: time.Now()})
+}
+
+// serve answers req with e, taking the headers of notModified, if any.
+func (c *DiskCache) serve(req *http.Request, notModified *http.Response, e *diskEntry) *http.Response {
+	if c.OnHit != nil {
+		c.OnHit(req)
+	}
+	if notModified == nil {
+		notModified = &http.Response{Proto: "HTTP/1.1", ProtoMajor: 1, ProtoMinor: 1}
+	}
+	if e.Header == nil {
+		e.Header = http.Header{}
+	}
+	return cachedResponse(req, notModified, &etagEntry{etag: e.ETag, header: e.Header, body: e.Body})
+}
+
+// Prune removes the responses not received or revalidated within maxAge,
+// or all of them if maxAge is not positive, and returns how many it
+// removed.
+func (c *DiskCache) Prune(maxAge time.Duration) (int, error) {
+	files, err := filepath.Glob(filepath.Join(c.Dir, "*", "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	var errs []error
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil || (maxAge > 0 && time.Since(info.ModTime()) <= maxAge) {
+			continue
+		}
+		if err := os.Remove(name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
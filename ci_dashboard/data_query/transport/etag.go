@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ETagCache makes repeated GET requests conditional: responses with an
+// ETag are kept in memory and a 304 Not Modified to a later request is
+// answered with the kept response. GitHub does not count 304s against the
+// rate limit. Its zero value is ready to use.
+type ETagCache struct {
+	// MaxEntries bounds the responses kept. Defaults to 1000.
+	MaxEntries int
+	// MaxBodySize is the largest body kept. Defaults to 1 MiB.
+	MaxBodySize int64
+	// OnHit, if set, is called when a kept response is served.
+	OnHit func(req *http.Request)
+
+	mu      sync.Mutex
+	entries map[string]*etagEntry
+}
+
+type etagEntry struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// Middleware is the Middleware of c.
+func (c *ETagCache) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+			return next.RoundTrip(req)
+		}
+		key := etagKey(req)
+		c.mu.Lock()
+		prev := c.entries[key]
+		c.mu.Unlock()
+		if prev != nil {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", prev.etag)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case resp.StatusCode == http.StatusNotModified && prev != nil:
+			discard(resp)
+			if c.OnHit != nil {
+				c.OnHit(req)
+			}
+			return cachedResponse(req, resp, prev), nil
+		case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+			c.store(key, resp)
+		}
+		return resp, nil
+	})
+}
+
+// store keeps resp if its body is small enough, leaving the body readable.
+func (c *ETagCache) store(key string, resp *http.Response) {
+	limit := c.MaxBodySize
+	if limit <= 0 {
+		limit = 1 << 20
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil || int64(len(data)) > limit {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		return
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*etagEntry{}
+	}
+	for k := range c.entries {
+		if len(c.entries) < maxEntries {
+			break
+		}
+		delete(c.entries, k)
+	}
+	c.entries[key] = &etagEntry{etag: resp.Header.Get("ETag"), header: resp.Header.Clone(), body: data}
+}
+
+// cachedResponse answers req with e, taking the headers the 304 sent, such
+// as the rate limit, over those kept.
+func cachedResponse(req *http.Request, notModified *http.Response, e *etagEntry) *http.Response {
+	header := e.header.Clone()
+	for k, v := range notModified.Header {
+		header[k] = v
+	}
+	header.Set("Content-Length", strconv.Itoa(len(e.body)))
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// etagKey identifies a response by URL, representation and credentials,
+// since different tokens may see different data.
+func etagKey(req *http.Request) string {
+	auth := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + "\x00" + req.Header.Get("Accept") + "\x00" + hex.EncodeToString(auth[:8])
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitOptions configure SecondaryRateLimit.
+type RateLimitOptions struct {
+	// MaxRetries is how often a limited request is retried. Defaults to 3.
+	MaxRetries int
+	// MaxWait caps how long a request waits before a retry; responses
+	// asking for a longer wait are returned as they are. Defaults to a
+	// minute.
+	MaxWait time.Duration
+	// OnRetry, if set, is called before each retry.
+	OnRetry func(req *http.Request, wait time.Duration)
+}
+
+// SecondaryRateLimit retries requests rejected by a secondary rate limit:
+// a 429, or a 403 with Retry-After or that says so, without the primary
+// limit being exhausted. It waits for Retry-After, or a minute doubling
+// with each retry, as GitHub asks. Limited requests were not processed,
+// so every method is retried.
+func SecondaryRateLimit(opts RateLimitOptions) Middleware {
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = time.Minute
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			for attempt := 0; ; attempt++ {
+				try := rewind(req)
+				if try == nil {
+					return next.RoundTrip(req)
+				}
+				resp, err := next.RoundTrip(try)
+				if err != nil || attempt >= opts.MaxRetries || !secondaryLimited(resp) {
+					return resp, err
+				}
+				wait := time.Minute << attempt
+				if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+					wait = time.Duration(s) * time.Second
+				}
+				if wait > opts.MaxWait {
+					return resp, nil
+				}
+				discard(resp)
+				if opts.OnRetry != nil {
+					opts.OnRetry(req, wait)
+				}
+				if err := sleep(req.Context(), wait); err != nil {
+					return nil, err
+				}
+			}
+		})
+	}
+}
+
+func secondaryLimited(resp *http.Response) bool {
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		if resp.Header.Get("Retry-After") != "" {
+			return true
+		}
+		return bytes.Contains(bytes.ToLower(peek(resp, 1<<12)), []byte("secondary rate limit"))
+	}
+	return false
+}
+
+// Rate is the state of a primary rate limit.
+type Rate struct {
+	Limit, Remaining, Used int
+	Reset                  time.Time
+}
+
+// Budget tracks the primary rate limits of the responses passing through
+// it by resource, such as core, graphql or search. Its zero value is ready
+// to use.
+type Budget struct {
+	// Block holds requests back while the limit of their resource is
+	// exhausted, down to Reserve, until it resets. A request rejected
+	// because the limit ran out is retried once after the reset.
+	Block bool
+	// Reserve is how many requests Block leaves for other clients of the
+	// same token.
+	Reserve int
+	// MaxWait caps how long Block waits; requests that would wait longer
+	// are sent anyway. Defaults to a minute.
+	MaxWait time.Duration
+	// OnUpdate, if set, is called with each rate limit state seen.
+	OnUpdate func(resource string, r Rate)
+
+	mu    sync.Mutex
+	rates map[string]Rate
+}
+
+// Rate returns the last state seen of the limit of resource.
+func (b *Budget) Rate(resource string) Rate {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rates[resource]
+}
+
+// Middleware is the Middleware of b.
+func (b *Budget) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resource := requestResource(req)
+		if b.Block {
+			if wait := b.wait(resource); wait > 0 {
+				if err := sleep(req.Context(), wait); err != nil {
+					return nil, err
+				}
+			}
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		b.update(resource, resp.Header)
+		if !b.Block || !primaryLimited(resp) {
+			return resp, nil
+		}
+		wait := b.wait(resource)
+		if wait <= 0 {
+			return resp, nil
+		}
+		retry := rewind(req)
+		if retry == nil {
+			return resp, nil
+		}
+		discard(resp)
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		resp, err = next.RoundTrip(retry)
+		if err == nil {
+			b.update(resource, resp.Header)
+		}
+		return resp, err
+	})
+}
+
+// wait returns how long a request to resource should wait for the limit
+// to reset, or 0 if it should go ahead.
+func (b *Budget) wait(resource string) time.Duration {
+	b.mu.Lock()
+	r, ok := b.rates[resource]
+	b.mu.Unlock()
+	if !ok || r.Remaining > b.Reserve {
+		return 0
+	}
+	maxWait := b.MaxWait
+	if maxWait <= 0 {
+		maxWait = time.Minute
+	}
+	wait := time.Until(r.Reset) + time.Second
+	if wait <= 0 || wait > maxWait {
+		return 0
+	}
+	return wait
+}
+
+func (b *Budget) update(resource string, h http.Header) {
+	limit, err1 := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	remaining, err2 := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	reset, err3 := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return
+	}
+	used, _ := strconv.Atoi(h.Get("X-RateLimit-Used"))
+	if r := h.Get("X-RateLimit-Resource"); r != "" {
+		resource = r
+	}
+	r := Rate{Limit: limit, Remaining: remaining, Used: used, Reset: time.Unix(reset, 0)}
+	b.mu.Lock()
+	if b.rates == nil {
+		b.rates = map[string]Rate{}
+	}
+	b.rates[resource] = r
+	b.mu.Unlock()
+	if b.OnUpdate != nil {
+		b.OnUpdate(resource, r)
+	}
+}
+
+func primaryLimited(resp *http.Response) bool {
+	return (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// requestResource guesses the rate limit resource a request counts
+// against before its response names it.
+func requestResource(req *http.Request) string {
+	switch path := req.URL.Path; {
+	case strings.HasSuffix(path, "/graphql"):
+		return "graphql"
+	case strings.Contains(path, "/search/code"):
+		return "code_search"
+	case strings.Contains(path, "/search/"):
+		return "search"
+	}
+	return "core"
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Reasons a request is retried, as passed to OnRetry.
+const (
+	ReasonNetwork     = "network"
+	ReasonServerError = "server_error"
+	ReasonRateLimit   = "rate_limit"
+)
+
+// RetryOptions configure Retry.
+type RetryOptions struct {
+	// MaxRetries is how often a request is retried. Defaults to 3.
+	MaxRetries int
+	// BaseDelay is the backoff of the first retry, doubled for each
+	// following one. Defaults to a second.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff. Defaults to 30 seconds.
+	MaxDelay time.Duration
+	// Unsafe also retries POST and PATCH requests, for APIs where they are
+	// idempotent.
+	Unsafe bool
+	// OnRetry, if set, is called before each retry.
+	OnRetry func(req *http.Request, reason string)
+}
+
+// Retry retries requests that failed with a network error, a 5xx status or
+// a 429 without rate limit headers, waiting an exponentially growing,
+// fully jittered delay between attempts. Only idempotent methods are
+// retried unless Unsafe is set, and only requests whose body can be read
+// again.
+func Retry(opts RetryOptions) Middleware {
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = time.Second
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 30 * time.Second
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !opts.Unsafe && (req.Method == http.MethodPost || req.Method == http.MethodPatch) {
+				return next.RoundTrip(req)
+			}
+			for attempt := 0; ; attempt++ {
+				try := rewind(req)
+				if try == nil {
+					return next.RoundTrip(req)
+				}
+				resp, err := next.RoundTrip(try)
+				reason := retryReason(resp, err)
+				if reason == "" || attempt >= opts.MaxRetries || req.Context().Err() != nil {
+					return resp, err
+				}
+				if resp != nil {
+					discard(resp)
+				}
+				if opts.OnRetry != nil {
+					opts.OnRetry(req, reason)
+				}
+				if err := sleep(req.Context(), backoff(opts.BaseDelay, opts.MaxDelay, attempt)); err != nil {
+					return nil, err
+				}
+			}
+		})
+	}
+}
+
+func retryReason(resp *http.Response, err error) string {
+	switch {
+	case err != nil:
+		return ReasonNetwork
+	case resp.StatusCode >= 500:
+		return ReasonServerError
+	case resp.StatusCode == http.StatusTooManyRequests && resp.Header.Get("Retry-After") == "" && resp.Header.Get("X-RateLimit-Remaining") != "0":
+		return ReasonRateLimit
+	}
+	return ""
+}
+
+// backoff returns a random delay up to base doubled attempt times, capped
+// at limit.
+func backoff(base, limit time.Duration, attempt int) time.Duration {
+	d := base << min(attempt, 30)
+	if d <= 0 || d > limit {
+		d = limit
+	}
+	return rand.N(d) + 1
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transport is HTTP middleware for talking to the GitHub API:
+// retries with backoff for server errors, waiting out secondary rate
+// limits, tracking the primary rate limit budget, conditional requests
+// with ETags and keeping responses on disk. Each is an http.RoundTripper
+// wrapper, so they compose with each other and with any http.Client.
+package transport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a RoundTripper.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Chain wraps base, http.DefaultTransport if nil, in mws. The first
+// middleware is the outermost, seeing each request first.
+func Chain(base http.RoundTripper, mws ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		base = mws[i](base)
+	}
+	return base
+}
+
+// NewClient returns an http.Client sending requests through mws.
+func NewClient(mws ...Middleware) *http.Client {
+	return &http.Client{Transport: Chain(nil, mws...)}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *http.Client
+)
+
+// DefaultClient returns a shared client for GitHub APIs that retries
+// server errors, waits out secondary rate limits and caches GET responses
+// by ETag.
+func DefaultClient() *http.Client {
+	defaultOnce.Do(func() {
+		defaultClient = NewClient(
+			Retry(RetryOptions{}),
+			SecondaryRateLimit(RateLimitOptions{}),
+			(&ETagCache{}).Middleware,
+		)
+	})
+	return defaultClient
+}
+
+// rewind returns a copy of req with a fresh body for another attempt, or
+// nil if the body cannot be read again.
+func rewind(req *http.Request) *http.Request {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req
+	}
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	next := req.Clone(req.Context())
+	next.Body = body
+	return next
+}
+
+// discard drains and closes the body of a response that is being retried,
+// so its connection can be reused.
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+}
+
+// peek reads up to n bytes of the body of resp, leaving the body intact.
+func peek(resp *http.Response, n int64) []byte {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, n))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	return data
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package triggers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	compiledMu sync.Mutex
+	compiled   = map[string]*regexp.Regexp{}
+)
+
+// CompilePattern compiles a branch, tag or path filter pattern: * matches
+// any characters except /, ** any characters, ? and + make the preceding
+// character optional or repeatable, [] matches one character of a set and
+// \ escapes the next character.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	compiledMu.Lock()
+	defer compiledMu.Unlock()
+	if re, ok := compiled[pattern]; ok {
+		return re, nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?', '+':
+			if i == 0 {
+				return nil, fmt.Errorf("invalid pattern %q: %c must follow a character", pattern, c)
+			}
+			b.WriteByte(c)
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 2 {
+				return nil, fmt.Errorf("invalid pattern %q: unterminated [", pattern)
+			}
+			b.WriteString(pattern[i : i+end+1])
+			i += end
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, fmt.Errorf("invalid pattern %q: trailing \\", pattern)
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	compiled[pattern] = re
+	return re, nil
+}
+
+// MatchFilter reports whether s is selected by patterns. Patterns are
+// applied in order and the last one that matches decides, so a pattern
+// starting with ! excludes what earlier patterns selected and a later
+// positive pattern can select it again.
+func MatchFilter(patterns []string, s string) (bool, error) {
+	matched := false
+	for _, p := range patterns {
+		negate := strings.HasPrefix(p, "!")
+		re, err := CompilePattern(strings.TrimPrefix(p, "!"))
+		if err != nil {
+			return false, err
+		}
+		if re.MatchString(s) {
+			matched = !negate
+		}
+	}
+	return matched, nil
+}
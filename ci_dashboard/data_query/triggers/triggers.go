@@ -0,0 +1,261 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package triggers decides whether an event triggers a workflow, applying
+// the branches, tags, paths, types and workflows filters of its on: key.
+package triggers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"testingdashboard/m/v2/events"
+	"testingdashboard/m/v2/workflow"
+)
+
+// MaxChangedFiles is how many changed files GitHub considers for path
+// filters; changes beyond it never trigger a workflow.
+const MaxChangedFiles = 300
+
+// Event is the part of a triggering event that filters look at.
+type Event struct {
+	Name string
+	// Action is the activity type, such as opened or completed.
+	Action string
+	// Ref is the full ref the branch and tag filters apply to: the pushed
+	// ref for push, the base branch for pull requests and merge groups and
+	// the head branch of the run for workflow_run.
+	Ref string
+	// Workflow is the name of the workflow that ran, for workflow_run.
+	Workflow string
+	// App is the slug of the app that created the check suite, for
+	// check_suite.
+	App string
+	// Changed lists the changed files, relative to the repository root. Nil
+	// means unknown, in which case path filters are assumed to pass.
+	Changed []string
+}
+
+// FromPayload extracts the filtered fields from an event payload. Changed
+// files are not part of payloads and must be filled in by the caller.
+func FromPayload(p events.Event) *Event {
+	e := &Event{Name: p.EventName()}
+	switch p := p.(type) {
+	case *events.PushEvent:
+		e.Ref = p.Ref
+	case *events.PullRequestEvent:
+		e.Action, e.Ref = p.Action, "refs/heads/"+p.PullRequest.Base.Ref
+	case *events.PullRequestTargetEvent:
+		e.Action, e.Ref = p.Action, "refs/heads/"+p.PullRequest.Base.Ref
+	case *events.WorkflowRunEvent:
+		e.Action, e.Ref, e.Workflow = p.Action, "refs/heads/"+p.WorkflowRun.HeadBranch, p.Workflow.Name
+	case *events.MergeGroupEvent:
+		e.Action, e.Ref = p.Action, p.MergeGroup.BaseRef
+	case *events.CheckSuiteEvent:
+		e.Action = p.Action
+		if p.CheckSuite.App != nil {
+			e.App = p.CheckSuite.App.Slug
+		}
+	default:
+		e.Action = action(p)
+	}
+	return e
+}
+
+// action returns the activity type of payloads that have one.
+func action(p events.Event) string {
+	switch p := p.(type) {
+	case *events.BranchProtectionRuleEvent:
+		return p.Action
+	case *events.CheckRunEvent:
+		return p.Action
+	case *events.DiscussionEvent:
+		return p.Action
+	case *events.DiscussionCommentEvent:
+		return p.Action
+	case *events.IssueCommentEvent:
+		return p.Action
+	case *events.IssuesEvent:
+		return p.Action
+	case *events.LabelEvent:
+		return p.Action
+	case *events.MilestoneEvent:
+		return p.Action
+	case *events.PullRequestReviewEvent:
+		return p.Action
+	case *events.PullRequestReviewCommentEvent:
+		return p.Action
+	case *events.RegistryPackageEvent:
+		return p.Action
+	case *events.ReleaseEvent:
+		return p.Action
+	case *events.RepositoryDispatchEvent:
+		return p.Action
+	case *events.WatchEvent:
+		return p.Action
+	case *events.WorkflowRunEvent:
+		return p.Action
+	}
+	return ""
+}
+
+// defaultTypes lists the activity types that trigger a workflow when types
+// is not given, for the events where that is not every type.
+var defaultTypes = map[string][]string{
+	"pull_request":        {"opened", "synchronize", "reopened"},
+	"pull_request_target": {"opened", "synchronize", "reopened"},
+	"merge_group":         {"checks_requested"},
+}
+
+// Result is the outcome of Matches.
+type Result struct {
+	Triggered bool
+	// Reason explains the outcome, naming the filter that rejected the
+	// event if it was not triggered.
+	Reason string
+}
+
+func triggered(format string, args ...any) Result {
+	return Result{Triggered: true, Reason: fmt.Sprintf(format, args...)}
+}
+
+func skipped(format string, args ...any) Result {
+	return Result{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Matches reports whether the event triggers the workflow. Invalid filter
+// patterns are returned as errors.
+func Matches(wf *workflow.Workflow, e *Event) (Result, error) {
+	cfg := wf.On.Event(e.Name)
+	if cfg == nil {
+		return skipped("workflow is not triggered by %s", e.Name), nil
+	}
+	types := cfg.Types
+	if len(types) == 0 {
+		types = defaultTypes[e.Name]
+	}
+	if e.Action != "" && len(types) > 0 && !slices.Contains(types, e.Action) {
+		return skipped("activity type %s is not one of %s", e.Action, strings.Join(types, ", ")), nil
+	}
+
+	switch e.Name {
+	case "push":
+		return matchPush(cfg, e)
+	case "pull_request", "pull_request_target":
+		r, err := matchBranches(cfg, e.Ref)
+		if err != nil || !r.Triggered || !pathsApply(cfg, e.Changed) {
+			return r, err
+		}
+		return matchPaths(cfg, e.Changed)
+	case "workflow_run":
+		if len(cfg.Workflows) > 0 && !slices.Contains(cfg.Workflows, e.Workflow) {
+			return skipped("workflow %q is not one of the workflows filter", e.Workflow), nil
+		}
+		return matchBranches(cfg, e.Ref)
+	case "merge_group":
+		// Path filters do not apply to merge groups.
+		return matchBranches(cfg, e.Ref)
+	case "check_suite":
+		if e.App == events.ActionsApp {
+			// This keeps workflows from triggering each other endlessly.
+			return skipped("check suites of workflow runs do not trigger workflows"), nil
+		}
+	}
+	return triggered("triggered by %s", e.Name), nil
+}
+
+// InMergeQueue reports whether the workflow runs for the merge groups of
+// the queue of branch, which it must to report checks the queue requires.
+func InMergeQueue(wf *workflow.Workflow, branch string) (Result, error) {
+	return Matches(wf, &Event{Name: "merge_group", Action: "checks_requested", Ref: "refs/heads/" + branch})
+}
+
+func matchPush(cfg *workflow.Event, e *Event) (Result, error) {
+	hasBranches := len(cfg.Branches) > 0 || len(cfg.BranchesIgnore) > 0
+	hasTags := len(cfg.Tags) > 0 || len(cfg.TagsIgnore) > 0
+	if tag, ok := strings.CutPrefix(e.Ref, "refs/tags/"); ok {
+		if hasBranches && !hasTags {
+			return skipped("tag pushes are not selected: only branch filters are defined"), nil
+		}
+		// Path filters are not evaluated for tag pushes.
+		return matchRef("tag", "tags", tag, cfg.Tags, cfg.TagsIgnore)
+	}
+	branch := strings.TrimPrefix(e.Ref, "refs/heads/")
+	if hasTags && !hasBranches {
+		return skipped("branch pushes are not selected: only tag filters are defined"), nil
+	}
+	r, err := matchRef("branch", "branches", branch, cfg.Branches, cfg.BranchesIgnore)
+	if err != nil || !r.Triggered || !pathsApply(cfg, e.Changed) {
+		return r, err
+	}
+	return matchPaths(cfg, e.Changed)
+}
+
+func matchBranches(cfg *workflow.Event, ref string) (Result, error) {
+	return matchRef("branch", "branches", strings.TrimPrefix(ref, "refs/heads/"), cfg.Branches, cfg.BranchesIgnore)
+}
+
+// matchRef applies the include or ignore filter called filter to the
+// branch or tag name.
+func matchRef(kind, filter, name string, include, ignore []string) (Result, error) {
+	if len(include) > 0 {
+		ok, err := MatchFilter(include, name)
+		if err != nil || !ok {
+			return skipped("%s %s does not match the %s filter", kind, name, filter), err
+		}
+		return triggered("%s %s matches the %s filter", kind, name, filter), nil
+	}
+	if len(ignore) > 0 {
+		ok, err := MatchFilter(ignore, name)
+		if err != nil || ok {
+			return skipped("%s %s matches the %s-ignore filter", kind, name, filter), err
+		}
+	}
+	return triggered("%s %s is selected", kind, name), nil
+}
+
+// pathsApply reports whether there are path filters and changed files to
+// apply them to.
+func pathsApply(cfg *workflow.Event, changed []string) bool {
+	return changed != nil && (len(cfg.Paths) > 0 || len(cfg.PathsIgnore) > 0)
+}
+
+func matchPaths(cfg *workflow.Event, changed []string) (Result, error) {
+	if len(changed) > MaxChangedFiles {
+		changed = changed[:MaxChangedFiles]
+	}
+	if len(cfg.Paths) > 0 {
+		for _, f := range changed {
+			ok, err := MatchFilter(cfg.Paths, f)
+			if err != nil {
+				return Result{}, err
+			}
+			if ok {
+				return triggered("%s matches the paths filter", f), nil
+			}
+		}
+		return skipped("no changed file matches the paths filter"), nil
+	}
+	for _, f := range changed {
+		ok, err := MatchFilter(cfg.PathsIgnore, f)
+		if err != nil {
+			return Result{}, err
+		}
+		if !ok {
+			return triggered("%s is not ignored by the paths-ignore filter", f), nil
+		}
+	}
+	return skipped("every changed file matches the paths-ignore filter"), nil
+}
@@ -0,0 +1,412 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// redraw is how often the screen is drawn without input.
+const redraw = 200 * time.Millisecond
+
+// Keys other than printable characters.
+const (
+	keyUp = iota + utf8.MaxRune + 1
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEscape
+	keyCtrlC = 3
+)
+
+// app is the state of the screen.
+type app struct {
+	src  Source
+	root *Node
+	// rows are the nodes shown in the tree, parents the node containing
+	// each.
+	rows    []*Node
+	parents map[*Node]*Node
+	// cursor is the key of the selected node, collapsed those of the
+	// nodes whose children are hidden.
+	cursor    string
+	collapsed map[string]bool
+	// top is the first row shown; scroll is how many lines the log is
+	// scrolled up from its end, which it follows at zero.
+	top, scroll int
+	// confirm is the action awaiting y or n, message the last outcome.
+	confirm func()
+	prompt  string
+	message string
+	results chan string
+	width   int
+	height  int
+}
+
+// Run shows src on the terminal out, reading keys from in, until the user
+// quits or ctx is done.
+func Run(ctx context.Context, src Source, in, out *os.File) error {
+	restore, err := makeRaw(in)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer restore()
+	w := bufio.NewWriter(out)
+	// Use the alternate screen without a cursor.
+	fmt.Fprint(w, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(w, "\x1b[?25h\x1b[?1049l")
+		w.Flush()
+	}()
+
+	a := &app{src: src, cursor: rootKey, collapsed: map[string]bool{}, results: make(chan string, 1)}
+	keys := make(chan rune)
+	go readKeys(in, keys)
+	t := time.NewTicker(redraw)
+	defer t.Stop()
+	for {
+		if a.width, a.height, err = termSize(out); err != nil {
+			return err
+		}
+		if a.width == 0 || a.height == 0 {
+			a.width, a.height = 80, 24
+		}
+		a.update()
+		a.draw(w)
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case k, ok := <-keys:
+			if !ok || a.key(ctx, k) {
+				return nil
+			}
+		case m := <-a.results:
+			a.message = m
+		case <-t.C:
+		}
+	}
+}
+
+// readKeys sends the keys read from in until it fails.
+func readKeys(in *os.File, keys chan<- rune) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+		for b := buf[:n]; len(b) > 0; {
+			k, size := parseKey(b)
+			b = b[size:]
+			keys <- k
+		}
+	}
+}
+
+// escapes are the sequences terminals send for special keys.
+var escapes = map[string]rune{
+	"\x1b[A": keyUp, "\x1bOA": keyUp,
+	"\x1b[B": keyDown, "\x1bOB": keyDown,
+	"\x1b[C": keyRight, "\x1bOC": keyRight,
+	"\x1b[D": keyLeft, "\x1bOD": keyLeft,
+	"\x1b[5~": keyPageUp, "\x1b[6~": keyPageDown,
+	"\x1b[H": keyHome, "\x1b[1~": keyHome, "\x1bOH": keyHome,
+	"\x1b[F": keyEnd, "\x1b[4~": keyEnd, "\x1bOF": keyEnd,
+}
+
+// parseKey returns the first key in b and how many bytes it takes.
+func parseKey(b []byte) (rune, int) {
+	if b[0] == 0x1b {
+		for seq, k := range escapes {
+			if strings.HasPrefix(string(b), seq) {
+				return k, len(seq)
+			}
+		}
+		return keyEscape, 1
+	}
+	if b[0] == '\r' || b[0] == '\n' {
+		return keyEnter, 1
+	}
+	r, size := utf8.DecodeRune(b)
+	return r, size
+}
+
+// update takes a snapshot of the run and lays out the tree.
+func (a *app) update() {
+	a.root = a.src.Snapshot()
+	a.rows, a.parents = nil, map[*Node]*Node{}
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		a.rows = append(a.rows, n)
+		if a.collapsed[n.Key] {
+			return
+		}
+		for _, c := range n.Children {
+			a.parents[c] = n
+			walk(c)
+		}
+	}
+	walk(a.root)
+	if a.selected() == nil {
+		a.cursor = rootKey
+	}
+	a.src.Select(a.cursor)
+}
+
+// selected returns the row of the cursor.
+func (a *app) selected() *Node {
+	for _, n := range a.rows {
+		if n.Key == a.cursor {
+			return n
+		}
+	}
+	return nil
+}
+
+func (a *app) index() int {
+	for i, n := range a.rows {
+		if n.Key == a.cursor {
+			return i
+		}
+	}
+	return 0
+}
+
+// move moves the cursor by delta rows.
+func (a *app) move(delta int) {
+	i := min(max(a.index()+delta, 0), len(a.rows)-1)
+	if a.rows[i].Key != a.cursor {
+		a.cursor, a.scroll = a.rows[i].Key, 0
+	}
+}
+
+// key handles k and reports whether to quit.
+func (a *app) key(ctx context.Context, k rune) bool {
+	a.message = ""
+	if a.confirm != nil {
+		if k == 'y' || k == 'Y' {
+			go a.confirm()
+		}
+		a.confirm, a.prompt = nil, ""
+		return false
+	}
+	n := a.selected()
+	switch k {
+	case 'q', keyCtrlC:
+		return true
+	case keyUp, 'k':
+		a.move(-1)
+	case keyDown, 'j':
+		a.move(1)
+	case keyLeft, 'h':
+		switch {
+		case len(n.Children) > 0 && !a.collapsed[n.Key]:
+			a.collapsed[n.Key] = true
+		case a.parents[n] != nil:
+			a.cursor, a.scroll = a.parents[n].Key, 0
+		}
+	case keyRight, 'l', keyEnter:
+		delete(a.collapsed, n.Key)
+	case keyPageUp:
+		a.scroll += a.logHeight()
+	case keyPageDown:
+		a.scroll = max(a.scroll-a.logHeight(), 0)
+	case keyHome, 'g':
+		a.scroll = len(n.Log)
+	case keyEnd, 'G':
+		a.scroll = 0
+	case 'c':
+		a.ask("Cancel the run?", func() {
+			a.result("Cancelling the run", a.src.Cancel(ctx))
+		})
+	case 'r':
+		// A step is run again with its job.
+		for n.Kind == KindStep && a.parents[n] != nil {
+			n = a.parents[n]
+		}
+		key, name := n.Key, n.Name
+		a.ask(fmt.Sprintf("Run %s again?", name), func() {
+			a.result("Running "+name+" again", a.src.Rerun(ctx, key))
+		})
+	}
+	return false
+}
+
+func (a *app) ask(prompt string, action func()) {
+	a.prompt, a.confirm = prompt+" (y/n)", action
+}
+
+func (a *app) result(done string, err error) {
+	if err != nil {
+		done = "Error: " + err.Error()
+	}
+	a.results <- done
+}
+
+// treeHeight is how many rows of the tree are shown, up to half the
+// screen.
+func (a *app) treeHeight() int {
+	return max(min(len(a.rows), (a.height-2)/2), 1)
+}
+
+func (a *app) logHeight() int {
+	return max(a.height-a.treeHeight()-2, 1)
+}
+
+// draw draws the tree, the log of the selected node and the footer.
+func (a *app) draw(w *bufio.Writer) {
+	now := time.Now()
+	var lines []string
+	th := a.treeHeight()
+	i := a.index()
+	a.top = min(max(a.top, i-th+1), i)
+	for _, n := range a.rows[a.top:min(a.top+th, len(a.rows))] {
+		lines = append(lines, a.treeLine(n, n.Key == a.cursor, now))
+	}
+
+	n := a.selected()
+	lh := a.logHeight()
+	a.scroll = min(a.scroll, max(len(n.Log)-lh, 0))
+	title := fmt.Sprintf(" %s ", n.Name)
+	if a.scroll > 0 {
+		title += fmt.Sprintf("(%d lines below) ", a.scroll)
+	}
+	lines = append(lines, "\x1b[7m"+pad(title, a.width)+"\x1b[0m")
+	end := len(n.Log) - a.scroll
+	for _, l := range n.Log[max(end-lh, 0):end] {
+		lines = append(lines, fit(cleanLine(l), a.width))
+	}
+	for len(lines) < a.height-1 {
+		lines = append(lines, "")
+	}
+
+	footer := "↑↓ move  ←→ fold  PgUp/PgDn/Home/End log  c cancel  r re-run  q quit"
+	switch err := a.src.Err(); {
+	case a.prompt != "":
+		footer = "\x1b[1m" + fit(a.prompt, a.width) + "\x1b[0m"
+	case a.message != "":
+		footer = fit(a.message, a.width)
+	case err != nil:
+		footer = "\x1b[31m" + fit(err.Error(), a.width) + "\x1b[0m"
+	default:
+		footer = fit(footer, a.width)
+	}
+	lines = append(lines, footer)
+
+	fmt.Fprint(w, "\x1b[H")
+	for i, l := range lines {
+		if i > 0 {
+			fmt.Fprint(w, "\r\n")
+		}
+		fmt.Fprint(w, l, "\x1b[K")
+	}
+	fmt.Fprint(w, "\x1b[J")
+}
+
+// treeLine renders n as a row of the tree.
+func (a *app) treeLine(n *Node, selected bool, now time.Time) string {
+	depth := 0
+	for p := a.parents[n]; p != nil; p = a.parents[p] {
+		depth++
+	}
+	fold := "  "
+	switch {
+	case len(n.Children) > 0 && a.collapsed[n.Key]:
+		fold = "▸ "
+	case len(n.Children) > 0:
+		fold = "▾ "
+	}
+	symbol, color := status(n)
+	left := strings.Repeat("  ", depth) + fold + symbol + " " + n.Name
+	right := ""
+	if d := n.Duration(now); d > 0 {
+		right = " " + d.Round(time.Second).String()
+	}
+	width := a.width - utf8.RuneCountInString(right)
+	line := fit(left, width)
+	line += strings.Repeat(" ", max(width-utf8.RuneCountInString(line), 0)) + right
+	if selected {
+		return "\x1b[7m" + line + "\x1b[0m"
+	}
+	return color + line + "\x1b[0m"
+}
+
+// status returns the symbol and color of n's status.
+func status(n *Node) (string, string) {
+	switch {
+	case n.Status == StatusQueued:
+		return "○", "\x1b[2m"
+	case n.Status != StatusCompleted:
+		return "●", "\x1b[33m"
+	}
+	switch n.Conclusion {
+	case "success":
+		return "✓", "\x1b[32m"
+	case "failure":
+		return "✗", "\x1b[31m"
+	case "cancelled":
+		return "⊘", "\x1b[2m"
+	case "skipped":
+		return "-", "\x1b[2m"
+	}
+	return "?", ""
+}
+
+// ansi matches terminal escape sequences in log lines.
+var ansi = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
+// cleanLine removes escape sequences and control characters from a log
+// line.
+func cleanLine(s string) string {
+	s = ansi.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// fit cuts s to width runes.
+func fit(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:max(width, 0)])
+}
+
+// pad fits s to exactly width runes.
+func pad(s string, width int) string {
+	s = fit(s, width)
+	return s + strings.Repeat(" ", width-utf8.RuneCountInString(s))
+}
@@ -0,0 +1,258 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/client"
+)
+
+// GitHub follows a run on GitHub by polling the API.
+type GitHub struct {
+	Client      *client.Client
+	Owner, Repo string
+	RunID       int64
+	// Interval is how often the run is polled. Defaults to 5 seconds.
+	Interval time.Duration
+
+	mu       sync.Mutex
+	root     *Node
+	run      *client.Run
+	selected string
+	err      error
+	poke     chan struct{}
+	// jobs maps the keys of job nodes to the jobs, logs the IDs of jobs to
+	// their logs.
+	jobs map[string]*client.Job
+	logs map[int64]*jobLog
+}
+
+// jobLog is the log of a job, complete once the job completed.
+type jobLog struct {
+	lines    []logLine
+	complete bool
+}
+
+type logLine struct {
+	time time.Time
+	text string
+}
+
+// Start loads the run and polls it in the background until ctx is done.
+func (g *GitHub) Start(ctx context.Context) error {
+	g.poke = make(chan struct{}, 1)
+	g.logs = map[int64]*jobLog{}
+	if err := g.poll(ctx); err != nil {
+		return err
+	}
+	interval := g.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			case <-g.poke:
+			}
+			err := g.poll(ctx)
+			g.mu.Lock()
+			g.err = err
+			g.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// poll loads the run, its jobs and the log of the selected job.
+func (g *GitHub) poll(ctx context.Context) error {
+	run, err := g.Client.GetRun(ctx, g.Owner, g.Repo, g.RunID)
+	if err != nil {
+		return fmt.Errorf("failed to get run %d: %w", g.RunID, err)
+	}
+	jobs, err := g.Client.ListJobs(ctx, g.Owner, g.Repo, g.RunID, false)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs of run %d: %w", g.RunID, err)
+	}
+	g.mu.Lock()
+	selected := g.selected
+	g.mu.Unlock()
+	var logErr error
+	for _, job := range jobs {
+		if key := "job/" + job.Name; selected == key || strings.HasPrefix(selected, key+"/") {
+			logErr = g.loadLog(ctx, job)
+		}
+	}
+
+	title := run.DisplayTitle
+	if title == "" {
+		title = run.Name
+	}
+	root := &Node{Key: rootKey, Kind: KindRun, Name: fmt.Sprintf("#%d %s", run.RunNumber, title), Status: run.Status, Conclusion: run.Conclusion, Started: run.RunStartedAt}
+	if run.Completed() {
+		root.Finished = run.UpdatedAt
+	}
+	byKey := map[string]*client.Job{}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, job := range jobs {
+		n := &Node{Key: "job/" + job.Name, Kind: KindJob, Name: job.Name, Status: job.Status, Conclusion: job.Conclusion, Started: job.StartedAt, Finished: job.CompletedAt}
+		byKey[n.Key] = job
+		for _, step := range job.Steps {
+			n.Children = append(n.Children, &Node{Key: fmt.Sprintf("%s/%d", n.Key, step.Number), Kind: KindStep, Name: step.Name, Status: step.Status, Conclusion: step.Conclusion, Started: step.StartedAt, Finished: step.CompletedAt})
+		}
+		if l := g.logs[job.ID]; l != nil {
+			splitLog(n, job, l)
+		}
+		root.Children = append(root.Children, n)
+	}
+	g.root, g.run, g.jobs = root, run, byKey
+	return logErr
+}
+
+// loadLog downloads the log of job unless it is complete. Logs of running
+// jobs are often not served yet, which is not an error.
+func (g *GitHub) loadLog(ctx context.Context, job *client.Job) error {
+	g.mu.Lock()
+	l := g.logs[job.ID]
+	g.mu.Unlock()
+	if l != nil && l.complete {
+		return nil
+	}
+	resp, err := g.Client.DownloadJobLogs(ctx, g.Owner, g.Repo, job.ID)
+	if client.IsNotFound(err) || (err != nil && job.Status != StatusCompleted) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download logs of job %s: %w", job.Name, err)
+	}
+	defer resp.Body.Close()
+	l = &jobLog{complete: job.Status == StatusCompleted}
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := logLine{text: sc.Text()}
+		// Each line starts with an RFC 3339 timestamp.
+		if ts, rest, ok := strings.Cut(line.text, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, strings.TrimPrefix(ts, "\ufeff")); err == nil {
+				line.time, line.text = t, rest
+			}
+		}
+		l.lines = append(l.lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.logs[job.ID] = l
+	g.mu.Unlock()
+	return nil
+}
+
+// splitLog fills the logs of job's node and of its steps, each line going
+// to the last step started by then. Step times are whole seconds.
+func splitLog(n *Node, job *client.Job, l *jobLog) {
+	for _, line := range l.lines {
+		n.Log = append(n.Log, line.text)
+		var step *Node
+		for i, s := range job.Steps {
+			if !s.StartedAt.IsZero() && !line.time.Truncate(time.Second).Before(s.StartedAt) {
+				step = n.Children[i]
+			}
+		}
+		if step != nil {
+			step.Log = append(step.Log, line.text)
+		}
+	}
+}
+
+// Snapshot implements Source.
+func (g *GitHub) Snapshot() *Node {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.root.clone()
+}
+
+// Select implements Source, loading the log of the job shown.
+func (g *GitHub) Select(key string) {
+	g.mu.Lock()
+	changed := g.selected != key
+	g.selected = key
+	g.mu.Unlock()
+	if changed {
+		g.refresh()
+	}
+}
+
+// refresh polls the run now.
+func (g *GitHub) refresh() {
+	select {
+	case g.poke <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel implements Source.
+func (g *GitHub) Cancel(ctx context.Context) error {
+	if err := g.Client.CancelRun(ctx, g.Owner, g.Repo, g.RunID); err != nil {
+		return fmt.Errorf("failed to cancel run %d: %w", g.RunID, err)
+	}
+	g.refresh()
+	return nil
+}
+
+// Rerun implements Source. Run again, a failed run reruns its failed jobs
+// and others all of them.
+func (g *GitHub) Rerun(ctx context.Context, key string) error {
+	g.mu.Lock()
+	run, job := g.run, g.jobs[key]
+	g.mu.Unlock()
+	var err error
+	switch {
+	case key == rootKey && run.Conclusion == "failure":
+		err = g.Client.RerunFailedJobs(ctx, g.Owner, g.Repo, g.RunID, false)
+	case key == rootKey:
+		err = g.Client.RerunRun(ctx, g.Owner, g.Repo, g.RunID, false)
+	case job != nil:
+		err = g.Client.RerunJob(ctx, g.Owner, g.Repo, job.ID, false)
+	default:
+		return fmt.Errorf("select the run or a job to run again")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rerun: %w", err)
+	}
+	g.mu.Lock()
+	g.logs = map[int64]*jobLog{}
+	g.mu.Unlock()
+	g.refresh()
+	return nil
+}
+
+// Err implements Source.
+func (g *GitHub) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
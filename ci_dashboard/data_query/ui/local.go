@@ -0,0 +1,262 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ui
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"testingdashboard/m/v2/graph"
+	"testingdashboard/m/v2/logging"
+	"testingdashboard/m/v2/runner"
+	"testingdashboard/m/v2/workflow"
+)
+
+// rootKey is the key of the run's node.
+const rootKey = "run"
+
+// Local runs a workflow with the local runner and follows it.
+type Local struct {
+	Workflow *workflow.Workflow
+	// Options configure each run. Local sets Stdout, Log and Progress, and
+	// Jobs when a job is run again.
+	Options runner.Options
+
+	mu      sync.Mutex
+	root    *Node
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running bool
+	err     error
+	// jobs maps the keys of job nodes to their job IDs, parents steps to
+	// the steps containing them and steps the running step of each leg
+	// and step ID.
+	jobs    map[string]string
+	parents map[*Node]*Node
+	steps   map[string]*Node
+}
+
+// Start starts a run of the workflow.
+func (l *Local) Start(ctx context.Context) error {
+	return l.start(ctx, l.Options.Jobs)
+}
+
+func (l *Local) start(ctx context.Context, jobs []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running {
+		return errors.New("the run is still in progress; cancel it first")
+	}
+	g, err := graph.New(l.Workflow)
+	if err == nil && len(jobs) > 0 {
+		g, err = g.Select(jobs...)
+	}
+	if err != nil {
+		return err
+	}
+	name := l.Workflow.Name
+	if name == "" {
+		name = filepath.Base(l.Workflow.Path)
+	}
+	l.root = &Node{Key: rootKey, Kind: KindRun, Name: name, Status: StatusInProgress, Started: time.Now()}
+	l.jobs = map[string]string{}
+	l.parents = map[*Node]*Node{}
+	l.steps = map[string]*Node{}
+	for _, id := range g.TopologicalOrder() {
+		l.jobNode(id)
+	}
+	l.err = nil
+	l.running = true
+
+	opts := l.Options
+	opts.Jobs = jobs
+	opts.Stdout = io.Discard
+	opts.Log = logging.SinkFunc(l.log)
+	opts.Progress = l.progress
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.done = make(chan struct{})
+	go func() {
+		result, err := runner.New(opts).Run(ctx, l.Workflow)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		defer close(l.done)
+		l.running = false
+		l.root.Status, l.root.Finished = StatusCompleted, time.Now()
+		if err != nil {
+			l.err = err
+			l.root.Conclusion = runner.ResultFailure
+			return
+		}
+		l.root.Conclusion = result.Conclusion
+	}()
+	return nil
+}
+
+// jobNode returns the placeholder of the job id, which stands for it
+// until its first leg starts, adding it if needed. Once legs started it
+// returns the first.
+func (l *Local) jobNode(id string) *Node {
+	key := "job/" + id
+	for _, c := range l.root.Children {
+		if c.Key == key || l.jobs[c.Key] == id {
+			return c
+		}
+	}
+	n := &Node{Key: key, Kind: KindJob, Name: id, Status: StatusQueued}
+	l.root.Children = append(l.root.Children, n)
+	l.jobs[key] = id
+	return n
+}
+
+// legNode returns the node of the leg name of job id, which replaces the
+// job's placeholder or follows its other legs.
+func (l *Local) legNode(id, name string) *Node {
+	key := "leg/" + name
+	if n := l.root.find(key); n != nil {
+		return n
+	}
+	n := &Node{Key: key, Kind: KindJob, Name: name, Status: StatusQueued}
+	l.jobs[key] = id
+	placeholder, last := -1, -1
+	for i, c := range l.root.Children {
+		switch {
+		case c.Key == "job/"+id:
+			placeholder = i
+		case l.jobs[c.Key] == id:
+			last = i
+		}
+	}
+	switch {
+	case placeholder >= 0:
+		l.root.Children[placeholder] = n
+	case last >= 0:
+		l.root.Children = append(l.root.Children[:last+1], append([]*Node{n}, l.root.Children[last+1:]...)...)
+	default:
+		l.root.Children = append(l.root.Children, n)
+	}
+	return n
+}
+
+func (l *Local) progress(e runner.ProgressEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var n *Node
+	switch {
+	case e.Job == "":
+		n = l.jobNode(e.JobID)
+	case e.StepID == "":
+		n = l.legNode(e.JobID, e.Job)
+	case e.Result == "":
+		leg := l.legNode(e.JobID, e.Job)
+		parent := leg
+		if p := l.steps[e.Job+"/"+e.Parent]; e.Parent != "" && p != nil {
+			parent = p
+		}
+		n = &Node{Key: parent.Key + "/" + e.StepID + "#" + e.Step, Kind: KindStep, Name: e.Step}
+		parent.Children = append(parent.Children, n)
+		l.parents[n] = parent
+		l.steps[e.Job+"/"+e.StepID] = n
+	default:
+		if n = l.steps[e.Job+"/"+e.StepID]; n == nil {
+			return
+		}
+	}
+	if e.Result == "" {
+		n.Status, n.Started = StatusInProgress, e.Time
+		return
+	}
+	n.Status, n.Conclusion, n.Finished = StatusCompleted, e.Result, e.Time
+	if n.Started.IsZero() {
+		n.Started = e.Time
+	}
+}
+
+func (l *Local) log(r *logging.Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case r.Job != "":
+		leg := l.legNode(r.JobID, r.Job)
+		leg.Log = append(leg.Log, r.Message)
+		// A step's log includes that of the steps of its composite action.
+		for n := l.steps[r.Job+"/"+r.StepID]; r.StepID != "" && n != nil && n != leg; n = l.parents[n] {
+			n.Log = append(n.Log, r.Message)
+		}
+	case r.JobID != "":
+		n := l.jobNode(r.JobID)
+		n.Log = append(n.Log, r.Message)
+	default:
+		l.root.Log = append(l.root.Log, r.Message)
+	}
+	return nil
+}
+
+// Snapshot implements Source.
+func (l *Local) Snapshot() *Node {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.root.clone()
+}
+
+// Select implements Source. Local logs are always loaded.
+func (l *Local) Select(key string) {}
+
+// Cancel implements Source.
+func (l *Local) Cancel(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.running {
+		return errors.New("the run is not in progress")
+	}
+	l.cancel()
+	return nil
+}
+
+// Stop cancels the run if it is in progress and waits for it to end.
+func (l *Local) Stop() {
+	l.mu.Lock()
+	cancel, done := l.cancel, l.done
+	l.mu.Unlock()
+	if done != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Rerun implements Source, running the job of a leg with the jobs it
+// needs.
+func (l *Local) Rerun(ctx context.Context, key string) error {
+	l.mu.Lock()
+	id, ok := l.jobs[key]
+	l.mu.Unlock()
+	if key == rootKey {
+		return l.start(ctx, l.Options.Jobs)
+	}
+	if !ok {
+		return errors.New("select the run or a job to run again")
+	}
+	return l.start(ctx, []string{id})
+}
+
+// Err implements Source.
+func (l *Local) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
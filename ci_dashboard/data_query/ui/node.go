@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ui is a terminal interface following a workflow run, on GitHub
+// or on the local runner, as a live tree of its jobs and steps with their
+// logs.
+package ui
+
+import (
+	"context"
+	"time"
+)
+
+// Statuses of a Node, as GitHub reports them.
+const (
+	StatusQueued     = "queued"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+)
+
+// Kinds of Node.
+const (
+	KindRun  = "run"
+	KindJob  = "job"
+	KindStep = "step"
+)
+
+// Node is the run, one of its jobs or one of their steps.
+type Node struct {
+	// Key identifies the node across snapshots.
+	Key  string
+	Kind string
+	Name string
+	// Status is a Status constant; Conclusion is set once it completed.
+	Status, Conclusion string
+	Started, Finished  time.Time
+	Children           []*Node
+	// Log holds the lines of a step, or all the lines of a job.
+	Log []string
+}
+
+// Duration returns how long the node ran, so far if it still runs.
+func (n *Node) Duration(now time.Time) time.Duration {
+	switch {
+	case n.Started.IsZero():
+		return 0
+	case n.Finished.IsZero():
+		return now.Sub(n.Started)
+	}
+	return n.Finished.Sub(n.Started)
+}
+
+// clone returns a deep copy of n, sharing the log lines, which are only
+// ever appended to.
+func (n *Node) clone() *Node {
+	c := *n
+	c.Log = n.Log[:len(n.Log):len(n.Log)]
+	c.Children = make([]*Node, len(n.Children))
+	for i, child := range n.Children {
+		c.Children[i] = child.clone()
+	}
+	return &c
+}
+
+// find returns the node with key under n, if any.
+func (n *Node) find(key string) *Node {
+	if n.Key == key {
+		return n
+	}
+	for _, c := range n.Children {
+		if f := c.find(key); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// Source is a run the UI follows.
+type Source interface {
+	// Snapshot returns the run as it is now. The UI does not modify it.
+	Snapshot() *Node
+	// Select tells the source which node the UI shows, so that it can load
+	// its log.
+	Select(key string)
+	// Cancel cancels the run.
+	Cancel(ctx context.Context) error
+	// Rerun runs the job with key again, or the whole run for the run's
+	// key.
+	Rerun(ctx context.Context, key string) error
+	// Err returns the last error of following the run, if any.
+	Err() error
+}
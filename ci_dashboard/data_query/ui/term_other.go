@@ -0,0 +1,32 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package ui
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoTerminal = errors.New("the ui needs a Linux terminal")
+
+func makeRaw(*os.File) (func(), error) {
+	return nil, errNoTerminal
+}
+
+func termSize(*os.File) (int, int, error) {
+	return 0, 0, errNoTerminal
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"testingdashboard/m/v2/endpoints"
+	"testingdashboard/m/v2/transport"
+)
+
+// Tag is a tag of a repository and the commit it points at.
+type Tag struct {
+	Name, SHA string
+}
+
+// Source lists the tags of a repository.
+type Source interface {
+	Tags(ctx context.Context, owner, repo string) ([]Tag, error)
+}
+
+// MaxTagPages bounds how many pages of tags GitHubSource fetches.
+const MaxTagPages = 10
+
+// GitHubSource lists tags with the GitHub REST API.
+type GitHubSource struct {
+	// APIURL defaults to the API of endpoints.FromEnv.
+	APIURL string
+	// Token defaults to GITHUB_TOKEN.
+	Token string
+	// Client defaults to transport.DefaultClient.
+	Client *http.Client
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// Tags implements Source.
+func (s *GitHubSource) Tags(ctx context.Context, owner, repo string) ([]Tag, error) {
+	base := s.APIURL
+	if base == "" {
+		base = endpoints.FromEnv().API
+	}
+	token := s.Token
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	client := s.Client
+	if client == nil {
+		client = transport.DefaultClient()
+	}
+
+	var tags []Tag
+	next := fmt.Sprintf("%s/repos/%s/%s/tags?per_page=100", strings.TrimSuffix(base, "/"), url.PathEscape(owner), url.PathEscape(repo))
+	for page := 0; next != "" && page < MaxTagPages; page++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var body []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				SHA string `json:"sha"`
+			} `json:"commit"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<12))
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to list tags of %s/%s: %s: %s", owner, repo, resp.Status, strings.TrimSpace(string(msg)))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tags of %s/%s: %w", owner, repo, err)
+		}
+		for _, t := range body {
+			tags = append(tags, Tag{Name: t.Name, SHA: t.Commit.SHA})
+		}
+		next = ""
+		if m := nextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			next = m[1]
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package updates finds newer versions of the actions and reusable workflows
+// a repository uses and rewrites the references to them.
+package updates
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"testingdashboard/m/v2/pin"
+)
+
+// Usage is one uses: reference in a file.
+type Usage struct {
+	Path string
+	Ref  *pin.Ref
+}
+
+// Version returns the version the reference is on: its ref, or for pinned
+// references the version in the trailing comment.
+func (u *Usage) Version() (Version, bool) {
+	if u.Ref.Pinned() {
+		return ParseVersion(u.Ref.Comment)
+	}
+	return ParseVersion(u.Ref.Uses.Ref)
+}
+
+// Inventory returns the repository references in the given files.
+func Inventory(paths []string) ([]Usage, error) {
+	var usages []Usage
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := pin.Find(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, ref := range refs {
+			usages = append(usages, Usage{Path: path, Ref: ref})
+		}
+	}
+	return usages, nil
+}
+
+// Update is a newer version available for a usage.
+type Update struct {
+	Usage
+	Current, Latest Version
+	// To is the ref to switch to: a tag with the same precision as the
+	// current ref, or a commit SHA for pinned references.
+	To string
+	// Tag is the tag To was chosen from.
+	Tag string
+}
+
+// Major reports whether the update crosses a major version.
+func (u *Update) Major() bool {
+	return u.Latest.Major != u.Current.Major
+}
+
+// Options controls Check.
+type Options struct {
+	// SameMajor only considers versions with the current major version.
+	SameMajor bool
+	// Prerelease considers prerelease versions.
+	Prerelease bool
+}
+
+// Check looks up the tags of every referenced repository and returns the
+// usages that have a newer version. References to branches or to SHAs
+// without a version comment are skipped.
+func Check(ctx context.Context, src Source, usages []Usage, opts Options) ([]Update, error) {
+	tags := map[string][]Tag{}
+	var updates []Update
+	for _, u := range usages {
+		current, ok := u.Version()
+		if !ok {
+			continue
+		}
+		repo := u.Ref.Uses.Owner + "/" + u.Ref.Uses.Repo
+		list, ok := tags[repo]
+		if !ok {
+			var err error
+			if list, err = src.Tags(ctx, u.Ref.Uses.Owner, u.Ref.Uses.Repo); err != nil {
+				return nil, err
+			}
+			tags[repo] = list
+		}
+		if up, ok := newer(u, current, list, opts); ok {
+			updates = append(updates, up)
+		}
+	}
+	return updates, nil
+}
+
+// newer picks the update for a usage from the repository's tags.
+func newer(u Usage, current Version, tags []Tag, opts Options) (Update, bool) {
+	var latest Version
+	var found bool
+	versions := map[string]Tag{}
+	for _, t := range tags {
+		v, ok := ParseVersion(t.Name)
+		if !ok || v.Prefixed != current.Prefixed || v.Pre != "" && !opts.Prerelease {
+			continue
+		}
+		if opts.SameMajor && v.Major != current.Major {
+			continue
+		}
+		versions[v.String()] = t
+		if !found || v.Compare(latest) > 0 {
+			latest, found = v, true
+		}
+	}
+	if !found {
+		return Update{}, false
+	}
+	// A floating tag such as v4 already tracks every v4.x release, so only
+	// compare the components the current ref names, and prefer a tag that is
+	// as precise so v4 moves to v5 rather than v5.0.1.
+	short := latest.truncate(current.Parts)
+	target := latest
+	if _, ok := versions[short.String()]; ok && latest.Pre == "" {
+		target = short
+	}
+	switch c := short.Compare(current); {
+	case c < 0, c == 0 && !u.Ref.Pinned():
+		return Update{}, false
+	}
+	tag := versions[target.String()]
+	up := Update{Usage: u, Current: current, Latest: latest, To: tag.Name, Tag: tag.Name}
+	if u.Ref.Pinned() {
+		if tag.SHA == "" || tag.SHA == u.Ref.Uses.Ref {
+			return Update{}, false
+		}
+		up.To = tag.SHA
+	}
+	return up, true
+}
+
+// Apply rewrites the files of the updates in place.
+func Apply(updates []Update) error {
+	edits := map[string][]pin.Edit{}
+	var paths []string
+	for _, u := range updates {
+		if _, ok := edits[u.Path]; !ok {
+			paths = append(paths, u.Path)
+		}
+		e := pin.Edit{Ref: u.Ref, To: u.To}
+		if u.Ref.Pinned() {
+			e.Comment = u.Tag
+		}
+		edits[u.Path] = append(edits[u.Path], e)
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out, err := pin.Rewrite(data, edits[path])
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
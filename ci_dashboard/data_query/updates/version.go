@@ -0,0 +1,112 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updates
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version tag such as v4, v4.1 or v4.1.0-beta.1.
+type Version struct {
+	Major, Minor, Patch int
+	// Parts is the number of numeric components written in the tag.
+	Parts int
+	// Pre is the prerelease suffix without the dash.
+	Pre string
+	// Prefixed reports whether the tag starts with v.
+	Prefixed bool
+}
+
+// ParseVersion parses a version tag. Build metadata is ignored.
+func ParseVersion(tag string) (Version, bool) {
+	var v Version
+	s := tag
+	if rest, ok := strings.CutPrefix(s, "v"); ok {
+		s, v.Prefixed = rest, true
+	}
+	s, _, _ = strings.Cut(s, "+")
+	s, v.Pre, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, false
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || p != strconv.Itoa(n) {
+			return Version{}, false
+		}
+		*nums[i] = n
+	}
+	v.Parts = len(parts)
+	return v, true
+}
+
+// Compare orders versions by precedence. A missing component sorts as zero,
+// and a prerelease before the release it precedes.
+func (v Version) Compare(w Version) int {
+	if c := cmp.Compare(v.Major, w.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, w.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, w.Patch); c != 0 {
+		return c
+	}
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+	return strings.Compare(v.Pre, w.Pre)
+}
+
+// String formats v with the same number of components as it was written.
+func (v Version) String() string {
+	nums := []int{v.Major, v.Minor, v.Patch}
+	var parts []string
+	for _, n := range nums[:max(v.Parts, 1)] {
+		parts = append(parts, strconv.Itoa(n))
+	}
+	s := strings.Join(parts, ".")
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	if v.Prefixed {
+		s = "v" + s
+	}
+	return s
+}
+
+// truncate drops the components after the first parts.
+func (v Version) truncate(parts int) Version {
+	if parts >= v.Parts {
+		return v
+	}
+	v.Parts = parts
+	if parts < 3 {
+		v.Patch = 0
+	}
+	if parts < 2 {
+		v.Minor = 0
+	}
+	return v
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package variables
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"testingdashboard/m/v2/client"
+)
+
+// FetchOptions controls what Fetch reads.
+type FetchOptions struct {
+	// Environments are the environments whose variables to read. Nil
+	// means all of the repository's.
+	Environments []string
+}
+
+// Fetch reads the variables of the repository, of the organization that
+// it can use, and of its environments. Levels the token cannot read are
+// treated as empty, since their variables are no more visible to it than
+// absent ones.
+func Fetch(ctx context.Context, c *client.Client, owner, repo string, opts FetchOptions) (*Set, error) {
+	s := &Set{Environments: map[string]map[string]string{}}
+	var err error
+	if s.Repository, err = values(c.ListVariables(ctx, client.Repo(owner, repo))); err != nil {
+		return nil, fmt.Errorf("failed to list the variables of %s/%s: %w", owner, repo, err)
+	}
+	if s.Organization, err = values(c.ListRepoOrganizationVariables(ctx, owner, repo)); err != nil && !unreadable(err) {
+		return nil, fmt.Errorf("failed to list the organization variables of %s/%s: %w", owner, repo, err)
+	}
+	envs := opts.Environments
+	if envs == nil {
+		list, err := c.ListEnvironments(ctx, owner, repo)
+		if err != nil && !unreadable(err) {
+			return nil, fmt.Errorf("failed to list the environments of %s/%s: %w", owner, repo, err)
+		}
+		for _, e := range list {
+			envs = append(envs, e.Name)
+		}
+	}
+	for _, env := range envs {
+		values, err := values(c.ListVariables(ctx, client.Env(owner, repo, env)))
+		if err != nil && !unreadable(err) {
+			return nil, fmt.Errorf("failed to list the variables of environment %s: %w", env, err)
+		}
+		s.Environments[env] = values
+	}
+	return s, nil
+}
+
+func values(list []*client.Variable, err error) (map[string]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(list))
+	for _, v := range list {
+		out[v.Name] = v.Value
+	}
+	return upper(out), nil
+}
+
+// unreadable reports whether err means the level does not exist or the
+// token may not read it.
+func unreadable(err error) bool {
+	var e *client.Error
+	return client.IsNotFound(err) || (errors.As(err, &e) && e.StatusCode == http.StatusForbidden)
+}
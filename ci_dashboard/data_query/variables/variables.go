@@ -0,0 +1,178 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package variables resolves the configuration variables of the vars
+// context from the levels GitHub defines them at: an environment's override
+// its repository's, which override its organization's.
+package variables
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Level is where a variable is defined.
+type Level string
+
+const (
+	LevelOrganization Level = "organization"
+	LevelRepository   Level = "repository"
+	LevelEnvironment  Level = "environment"
+)
+
+// Set is the variables a repository's workflows can read, by level. Names
+// are case-insensitive, as in expressions, and kept upper-case, as GitHub
+// stores them.
+type Set struct {
+	Organization map[string]string `json:"organization,omitempty" yaml:"organization,omitempty"`
+	Repository   map[string]string `json:"repository,omitempty" yaml:"repository,omitempty"`
+	// Environments holds the variables of each deployment environment.
+	Environments map[string]map[string]string `json:"environments,omitempty" yaml:"environments,omitempty"`
+}
+
+// Variable is a variable as a job sees it.
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Level Level  `json:"level"`
+	// Environment is set for environment variables.
+	Environment string `json:"environment,omitempty"`
+	// Overrides lists the levels whose variable of the same name this one
+	// hides.
+	Overrides []Level `json:"overrides,omitempty"`
+}
+
+// Resolve returns the variables a job in environment sees, by name. An
+// empty environment means a job without one. It is safe on a nil Set.
+func (s *Set) Resolve(environment string) []Variable {
+	if s == nil {
+		return nil
+	}
+	byName := map[string]*Variable{}
+	add := func(level Level, env string, values map[string]string) {
+		for name, value := range values {
+			name = strings.ToUpper(name)
+			v := &Variable{Name: name, Value: value, Level: level, Environment: env}
+			if prev := byName[name]; prev != nil {
+				v.Overrides = append(prev.Overrides, prev.Level)
+			}
+			byName[name] = v
+		}
+	}
+	add(LevelOrganization, "", s.Organization)
+	add(LevelRepository, "", s.Repository)
+	if environment != "" {
+		add(LevelEnvironment, environment, s.environment(environment))
+	}
+	out := make([]Variable, 0, len(byName))
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		out = append(out, *byName[name])
+	}
+	return out
+}
+
+// Values returns the vars context of a job in environment. It is safe on
+// a nil Set.
+func (s *Set) Values(environment string) map[string]string {
+	values := map[string]string{}
+	for _, v := range s.Resolve(environment) {
+		values[v.Name] = v.Value
+	}
+	return values
+}
+
+// Lookup returns the variable name as a job in environment sees it.
+func (s *Set) Lookup(name, environment string) (Variable, bool) {
+	name = strings.ToUpper(name)
+	for _, v := range s.Resolve(environment) {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+// EnvironmentsDefining returns the environments that define name, sorted.
+func (s *Set) EnvironmentsDefining(name string) []string {
+	if s == nil {
+		return nil
+	}
+	var envs []string
+	for env, values := range s.Environments {
+		for k := range values {
+			if strings.EqualFold(k, name) {
+				envs = append(envs, env)
+				break
+			}
+		}
+	}
+	slices.Sort(envs)
+	return envs
+}
+
+// environment returns the variables of the environment name, which GitHub
+// matches case-insensitively.
+func (s *Set) environment(name string) map[string]string {
+	if values, ok := s.Environments[name]; ok {
+		return values
+	}
+	for env, values := range s.Environments {
+		if strings.EqualFold(env, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+// Load reads a Set from a YAML or JSON file, as written by Save.
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Set
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	s.Organization, s.Repository = upper(s.Organization), upper(s.Repository)
+	for env, values := range s.Environments {
+		s.Environments[env] = upper(values)
+	}
+	return &s, nil
+}
+
+// Save writes s to path as YAML.
+func (s *Set) Save(path string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func upper(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]string, len(values))
+	for k, v := range values {
+		out[strings.ToUpper(k)] = v
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wasm runs WebAssembly modules compiled for WASI preview 1 in an
+// embedded wazero runtime. A module sees only the directories it is given,
+// its arguments and environment, a clock and random numbers: WASI preview
+// 1 has no sockets, so it has no network access.
+//
+// This is an experimental backend for actions with runs.using: wasm, which
+// GitHub's runner does not support.
+package wasm
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// Mount makes a host directory visible to the module at Guest.
+type Mount struct {
+	Host, Guest string
+	ReadOnly    bool
+}
+
+// Config describes one run of a module.
+type Config struct {
+	// Module is the path of the .wasm file.
+	Module string
+	// Args follow the program name, which is the module's base name.
+	Args   []string
+	Env    map[string]string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Mounts []Mount
+	// MemoryLimit caps the module's memory in bytes, rounded down to 64 KiB
+	// pages. Defaults to DefaultMemoryLimit.
+	MemoryLimit int64
+}
+
+// ExitError is a module exiting with a non-zero code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the exit code.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// DefaultMemoryLimit is the memory a module may use unless configured.
+const DefaultMemoryLimit = 256 << 20
+
+// cache holds the compiled modules of every run in the process, so a
+// module used by several steps compiles once.
+var (
+	cacheOnce sync.Once
+	cache     wazero.CompilationCache
+)
+
+// Run runs the module's _start function to completion and returns an
+// *ExitError if it exits with a non-zero code. Cancelling ctx stops it.
+func Run(ctx context.Context, cfg Config) error {
+	code, err := os.ReadFile(cfg.Module)
+	if err != nil {
+		return err
+	}
+	cacheOnce.Do(func() { cache = wazero.NewCompilationCache() })
+	limit := cfg.MemoryLimit
+	if limit <= 0 {
+		limit = DefaultMemoryLimit
+	}
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCompilationCache(cache).
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(uint32(min(limit>>16, 1<<16))))
+	defer rt.Close(context.WithoutCancel(ctx))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		return err
+	}
+	compiled, err := rt.CompileModule(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to compile %s: %w", cfg.Module, err)
+	}
+
+	fs := wazero.NewFSConfig()
+	for _, m := range cfg.Mounts {
+		if m.ReadOnly {
+			fs = fs.WithReadOnlyDirMount(m.Host, m.Guest)
+		} else {
+			fs = fs.WithDirMount(m.Host, m.Guest)
+		}
+	}
+	mc := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(append([]string{filepath.Base(cfg.Module)}, cfg.Args...)...).
+		WithFSConfig(fs).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	if cfg.Stdin != nil {
+		mc = mc.WithStdin(cfg.Stdin)
+	}
+	if cfg.Stdout != nil {
+		mc = mc.WithStdout(cfg.Stdout)
+	}
+	if cfg.Stderr != nil {
+		mc = mc.WithStderr(cfg.Stderr)
+	}
+	keys := make([]string, 0, len(cfg.Env))
+	for k := range cfg.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		mc = mc.WithEnv(k, cfg.Env[k])
+	}
+
+	mod, err := rt.InstantiateModule(ctx, compiled, mc)
+	if mod != nil {
+		mod.Close(context.WithoutCancel(ctx))
+	}
+	var exit *sys.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case errors.As(err, &exit):
+		if exit.ExitCode() == 0 {
+			return nil
+		}
+		return &ExitError{Code: int(exit.ExitCode())}
+	}
+	return err
+}
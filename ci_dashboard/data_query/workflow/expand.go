@@ -0,0 +1,264 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// MaxMatrixJobs is the most jobs a single matrix may generate.
+const MaxMatrixJobs = 256
+
+// JobConfig is one concrete job generated by a strategy.
+type JobConfig struct {
+	Matrix map[string]any
+	// Index and Total are strategy.job-index and strategy.job-total.
+	Index, Total int
+}
+
+// Expansion is the result of expanding a strategy.
+type Expansion struct {
+	Configs     []JobConfig
+	FailFast    bool
+	MaxParallel int
+}
+
+// Expand expands the strategy's matrix and resolves fail-fast and
+// max-parallel to their effective values. A nil strategy yields a single
+// job. Expressions must have been evaluated beforehand.
+func (s *Strategy) Expand() (*Expansion, error) {
+	exp := &Expansion{FailFast: true}
+	var m *Matrix
+	if s != nil {
+		m = s.Matrix
+		if s.FailFast != nil {
+			if s.FailFast.Expression != "" {
+				return nil, fmt.Errorf("fail-fast expression %s must be evaluated before expansion", s.FailFast.Expression)
+			}
+			exp.FailFast = s.FailFast.Value
+		}
+	}
+	combos, err := m.Expand()
+	if err != nil {
+		return nil, err
+	}
+	for i, combo := range combos {
+		exp.Configs = append(exp.Configs, JobConfig{Matrix: combo, Index: i, Total: len(combos)})
+	}
+	exp.MaxParallel = len(combos)
+	if s != nil && s.MaxParallel != nil {
+		if s.MaxParallel.Expression != "" {
+			return nil, fmt.Errorf("max-parallel expression %s must be evaluated before expansion", s.MaxParallel.Expression)
+		}
+		n := int(s.MaxParallel.Value)
+		if n < 1 || float64(n) != s.MaxParallel.Value {
+			return nil, fmt.Errorf("max-parallel must be a positive integer, got %v", s.MaxParallel.Value)
+		}
+		exp.MaxParallel = min(n, len(combos))
+	}
+	return exp, nil
+}
+
+// Expand returns the combinations described by the matrix, following
+// GitHub's rules:
+//
+//   - The dimensions are multiplied out, the first dimension varying slowest.
+//   - Each exclude entry removes every combination it matches. An entry
+//     matches when all of its keys have equal values in the combination.
+//   - Each include entry is then merged into every combination whose
+//     original values it does not contradict. Keys added by an earlier
+//     include may be overwritten, original values may not. An entry that
+//     merges into no combination is appended as a new one.
+func (m *Matrix) Expand() ([]map[string]any, error) {
+	if m == nil {
+		return []map[string]any{{}}, nil
+	}
+	if m.Expression != "" {
+		return nil, fmt.Errorf("matrix expression %s must be evaluated before expansion", m.Expression)
+	}
+
+	var dims []string
+	var combos []map[string]any
+	if len(m.Dimensions) > 0 {
+		combos = []map[string]any{{}}
+	}
+	for _, dim := range m.Dimensions {
+		if dim.Expression != "" {
+			return nil, fmt.Errorf("matrix %s expression %s must be evaluated before expansion", dim.Name, dim.Expression)
+		}
+		if len(dim.Values) == 0 {
+			return nil, fmt.Errorf("matrix vector %s does not contain any values", dim.Name)
+		}
+		dims = append(dims, dim.Name)
+		next := make([]map[string]any, 0, len(combos)*len(dim.Values))
+		for _, combo := range combos {
+			for _, v := range dim.Values {
+				c := make(map[string]any, len(combo)+1)
+				for k, cv := range combo {
+					c[k] = cv
+				}
+				c[dim.Name] = v
+				next = append(next, c)
+			}
+		}
+		if len(next) > MaxMatrixJobs {
+			return nil, fmt.Errorf("matrix generates more than %d jobs", MaxMatrixJobs)
+		}
+		combos = next
+	}
+
+	for _, ex := range m.Exclude {
+		for k := range ex {
+			if !slices.Contains(dims, k) {
+				return nil, fmt.Errorf("matrix exclude key %s does not match any key within the matrix", k)
+			}
+		}
+		combos = slices.DeleteFunc(combos, func(combo map[string]any) bool {
+			return matchesAll(combo, ex)
+		})
+	}
+
+	var extra []map[string]any
+	for _, inc := range m.Include {
+		merged := false
+		for _, combo := range combos {
+			if !compatible(combo, inc, dims) {
+				continue
+			}
+			for k, v := range inc {
+				if !slices.Contains(dims, k) {
+					combo[k] = v
+				}
+			}
+			merged = true
+		}
+		if !merged {
+			c := make(map[string]any, len(inc))
+			for k, v := range inc {
+				c[k] = v
+			}
+			extra = append(extra, c)
+		}
+	}
+	combos = append(combos, extra...)
+
+	switch {
+	case len(combos) > MaxMatrixJobs:
+		return nil, fmt.Errorf("matrix generates more than %d jobs", MaxMatrixJobs)
+	case len(combos) == 0 && len(m.Dimensions) > 0:
+		return nil, fmt.Errorf("matrix excludes every combination")
+	case len(combos) == 0:
+		return []map[string]any{{}}, nil
+	}
+	return combos, nil
+}
+
+// compatible reports whether inc agrees with the original matrix values of
+// combo, the only values an include may not overwrite.
+func compatible(combo, inc map[string]any, dims []string) bool {
+	for k, v := range inc {
+		if slices.Contains(dims, k) && !valuesEqual(combo[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAll reports whether every key of partial has the same value in combo.
+func matchesAll(combo, partial map[string]any) bool {
+	for k, v := range partial {
+		cv, ok := combo[k]
+		if !ok || !valuesEqual(cv, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesEqual compares matrix values, treating numbers of different Go
+// types as equal when they have the same value.
+func valuesEqual(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// MatrixFromValue converts the value of a matrix expression, such as
+// fromJSON(needs.setup.outputs.matrix), into a Matrix.
+func MatrixFromValue(v any) (*Matrix, error) {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("matrix expression must evaluate to an object")
+	}
+	toEntries := func(v any) ([]map[string]any, error) {
+		items, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("matrix include and exclude must be arrays")
+		}
+		var out []map[string]any
+		for _, item := range items {
+			entry, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("matrix include and exclude entries must be objects")
+			}
+			out = append(out, entry)
+		}
+		return out, nil
+	}
+	m := &Matrix{}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var err error
+		switch k {
+		case "include":
+			m.Include, err = toEntries(obj[k])
+		case "exclude":
+			m.Exclude, err = toEntries(obj[k])
+		default:
+			values, ok := obj[k].([]any)
+			if !ok {
+				return nil, fmt.Errorf("matrix %s must be an array", k)
+			}
+			m.Dimensions = append(m.Dimensions, Dimension{Name: k, Values: values})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InputType returns the declared type of the input, defaulting to string.
+func (in *Input) InputType() string {
+	if in.Type == "" {
+		return "string"
+	}
+	return in.Type
+}
+
+// Convert checks value against the input's type and returns it as the
+// inputs context sees it: a bool for boolean inputs, a float64 for number
+// inputs and a string otherwise.
+func (in *Input) Convert(value string) (any, error) {
+	switch in.InputType() {
+	case "boolean":
+		switch value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a boolean; use true or false", value)
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return f, nil
+	case "choice":
+		if slices.Contains(in.Options, value) {
+			return value, nil
+		}
+		for _, opt := range in.Options {
+			if strings.EqualFold(opt, value) {
+				return nil, fmt.Errorf("%q is not one of the options; did you mean %q?", value, opt)
+			}
+		}
+		return nil, fmt.Errorf("%q is not one of the options %s", value, strings.Join(in.Options, ", "))
+	}
+	return value, nil
+}
+
+// ValidateInputs checks values against the declared inputs and returns the
+// complete inputs context: provided values converted to their types and
+// defaults filled in for the rest. Every problem is reported, not just the
+// first.
+func ValidateInputs(declared map[string]*Input, values map[string]string) (map[string]any, error) {
+	out := map[string]any{}
+	var errs []error
+	for _, name := range sortedKeys(values) {
+		in, key := lookupInput(declared, name)
+		if in == nil {
+			errs = append(errs, fmt.Errorf("unexpected input %q", name))
+			continue
+		}
+		v, err := in.Convert(values[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("input %s: %w", key, err))
+			continue
+		}
+		out[key] = v
+	}
+	for _, name := range sortedKeys(declared) {
+		if _, ok := out[name]; ok {
+			continue
+		}
+		if _, key := lookupInput(values, name); key != "" {
+			continue
+		}
+		in := declared[name]
+		switch {
+		case in.Default != nil:
+			v, err := in.Convert(fmt.Sprint(in.Default))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("default of input %s: %w", name, err))
+				continue
+			}
+			out[name] = v
+		case in.Required:
+			errs = append(errs, fmt.Errorf("input %s is required", name))
+		case in.InputType() == "boolean":
+			out[name] = false
+		default:
+			out[name] = ""
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// lookupInput finds name in m ignoring case, as input names are.
+func lookupInput[V any](m map[string]V, name string) (V, string) {
+	if v, ok := m[name]; ok {
+		return v, name
+	}
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, k
+		}
+	}
+	var zero V
+	return zero, ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
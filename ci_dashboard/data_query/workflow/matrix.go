@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"testingdashboard/m/v2/expr"
+)
+
+// Matrix is strategy.matrix. Dimensions keep their source order because it
+// determines the order of the expanded combinations.
+type Matrix struct {
+	Dimensions []Dimension
+	Include    []map[string]any
+	Exclude    []map[string]any
+
+	// Expression is set instead of the fields above when the whole matrix,
+	// or one of its parts, is computed by an expression such as fromJSON.
+	Expression string
+}
+
+// Dimension is a single matrix key and its candidate values.
+type Dimension struct {
+	Name   string
+	Values []any
+	// Expression is set when the values are computed by an expression.
+	Expression string
+}
+
+// UnmarshalYAML decodes the matrix while keeping key order.
+func (m *Matrix) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if !IsExpression(node.Value) {
+			return fmt.Errorf("line %d: matrix must be a mapping or an expression", node.Line)
+		}
+		m.Expression = node.Value
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: matrix must be a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		switch key {
+		case "include", "exclude":
+			var entries []map[string]any
+			if value.Kind == yaml.ScalarNode && IsExpression(value.Value) {
+				m.Expression = value.Value
+				continue
+			}
+			if err := value.Decode(&entries); err != nil {
+				return fmt.Errorf("line %d: invalid matrix %s: %w", value.Line, key, err)
+			}
+			if key == "include" {
+				m.Include = entries
+			} else {
+				m.Exclude = entries
+			}
+		default:
+			dim := Dimension{Name: key}
+			if value.Kind == yaml.ScalarNode && IsExpression(value.Value) {
+				dim.Expression = value.Value
+			} else if err := value.Decode(&dim.Values); err != nil {
+				return fmt.Errorf("line %d: matrix %s must be a list: %w", value.Line, key, err)
+			}
+			m.Dimensions = append(m.Dimensions, dim)
+		}
+	}
+	return nil
+}
+
+// MarshalYAML emits the matrix in its original key order.
+func (m Matrix) MarshalYAML() (any, error) {
+	if m.Expression != "" && len(m.Dimensions) == 0 && m.Include == nil && m.Exclude == nil {
+		return m.Expression, nil
+	}
+	out := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, v any) error {
+		value := &yaml.Node{}
+		if err := value.Encode(v); err != nil {
+			return err
+		}
+		out.Content = append(out.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+		return nil
+	}
+	for _, dim := range m.Dimensions {
+		var v any = dim.Values
+		if dim.Expression != "" {
+			v = dim.Expression
+		}
+		if err := add(dim.Name, v); err != nil {
+			return nil, err
+		}
+	}
+	if m.Include != nil {
+		if err := add("include", m.Include); err != nil {
+			return nil, err
+		}
+	}
+	if m.Exclude != nil {
+		if err := add("exclude", m.Exclude); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+var matrixExprRE = regexp.MustCompile(`\$\{\{.*?\bmatrix\b.*?\}\}`)
+
+// LegName returns the name GitHub gives the leg of job with matrix, and so
+// its check run: name, the evaluated name: of the job or else its ID,
+// followed by the matrix values in parentheses unless name: uses the
+// matrix.
+func LegName(job *Job, name string, matrix map[string]any) string {
+	if name == "" {
+		name = job.ID
+	}
+	if len(matrix) == 0 || matrixExprRE.MatchString(job.Name) {
+		return name
+	}
+	return name + " (" + MatrixLabel(job, matrix) + ")"
+}
+
+// MatrixLabel joins the values of a leg's matrix in the order job's matrix
+// defines its dimensions, followed by the keys only include adds, which
+// have no order of their own, alphabetically.
+func MatrixLabel(job *Job, matrix map[string]any) string {
+	var keys []string
+	if job.Strategy != nil && job.Strategy.Matrix != nil {
+		for _, dim := range job.Strategy.Matrix.Dimensions {
+			if _, ok := matrix[dim.Name]; ok {
+				keys = append(keys, dim.Name)
+			}
+		}
+	}
+	var extra []string
+	for k := range matrix {
+		if !slices.Contains(keys, k) {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	values := make([]string, 0, len(matrix))
+	for _, k := range append(keys, extra...) {
+		values = append(values, expr.ToString(matrix[k]))
+	}
+	return strings.Join(values, ", ")
+}
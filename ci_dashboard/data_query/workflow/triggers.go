@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Triggers is the on key. It is normalized to a set of events regardless of
+// whether the file used the string, list or mapping syntax.
+type Triggers struct {
+	// Names lists the events in source order.
+	Names  []string
+	Events map[string]*Event
+}
+
+// Event holds the configuration of a single trigger. Only the fields that
+// apply to the event are set.
+type Event struct {
+	Name string `yaml:"-"`
+
+	Types          StringList `yaml:"types,omitempty"`
+	Branches       StringList `yaml:"branches,omitempty"`
+	BranchesIgnore StringList `yaml:"branches-ignore,omitempty"`
+	Tags           StringList `yaml:"tags,omitempty"`
+	TagsIgnore     StringList `yaml:"tags-ignore,omitempty"`
+	Paths          StringList `yaml:"paths,omitempty"`
+	PathsIgnore    StringList `yaml:"paths-ignore,omitempty"`
+
+	// Workflows is set for workflow_run.
+	Workflows StringList `yaml:"workflows,omitempty"`
+
+	// Inputs is set for workflow_dispatch and workflow_call.
+	Inputs map[string]*Input `yaml:"inputs,omitempty"`
+	// Outputs and Secrets are set for workflow_call.
+	Outputs map[string]*Output `yaml:"outputs,omitempty"`
+	Secrets map[string]*Secret `yaml:"secrets,omitempty"`
+
+	// Schedules is set for schedule.
+	Schedules []Schedule `yaml:"-"`
+}
+
+// Input is a workflow_dispatch or workflow_call input declaration.
+type Input struct {
+	Description        string   `yaml:"description,omitempty"`
+	Required           bool     `yaml:"required,omitempty"`
+	Default            any      `yaml:"default,omitempty"`
+	Type               string   `yaml:"type,omitempty"`
+	Options            []string `yaml:"options,omitempty"`
+	DeprecationMessage string   `yaml:"deprecationMessage,omitempty"`
+}
+
+// Output is a workflow_call output declaration.
+type Output struct {
+	Description string `yaml:"description,omitempty"`
+	Value       string `yaml:"value"`
+}
+
+// Secret is a workflow_call secret declaration.
+type Secret struct {
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+}
+
+// Schedule is an entry under on.schedule.
+type Schedule struct {
+	Cron string `yaml:"cron"`
+}
+
+// Has reports whether the workflow is triggered by the named event.
+func (t *Triggers) Has(name string) bool {
+	_, ok := t.Events[name]
+	return ok
+}
+
+// Event returns the configuration for the named event, or nil.
+func (t *Triggers) Event(name string) *Event {
+	return t.Events[name]
+}
+
+func (t *Triggers) add(name string, ev *Event) {
+	if t.Events == nil {
+		t.Events = map[string]*Event{}
+	}
+	if _, ok := t.Events[name]; !ok {
+		t.Names = append(t.Names, name)
+	}
+	ev.Name = name
+	t.Events[name] = ev
+}
+
+// UnmarshalYAML accepts the string, list and mapping forms of on.
+func (t *Triggers) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		t.add(node.Value, &Event{})
+		return nil
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: event names must be strings", item.Line)
+			}
+			t.add(item.Value, &Event{})
+		}
+		return nil
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i].Value, node.Content[i+1]
+			ev := &Event{}
+			switch {
+			case value.Kind == yaml.ScalarNode && value.Tag == "!!null":
+			case name == "schedule":
+				if err := value.Decode(&ev.Schedules); err != nil {
+					return fmt.Errorf("line %d: invalid schedule: %w", value.Line, err)
+				}
+			default:
+				if err := value.Decode(ev); err != nil {
+					return fmt.Errorf("line %d: invalid %s trigger: %w", value.Line, name, err)
+				}
+			}
+			t.add(name, ev)
+		}
+		return nil
+	}
+	return fmt.Errorf("line %d: invalid on", node.Line)
+}
+
+// MarshalYAML emits the mapping form, using null for unconfigured events.
+func (t Triggers) MarshalYAML() (any, error) {
+	out := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range t.Names {
+		ev := t.Events[name]
+		value := &yaml.Node{}
+		var err error
+		if name == "schedule" {
+			err = value.Encode(ev.Schedules)
+		} else if ev.isEmpty() {
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: ""}
+		} else {
+			type plain Event
+			err = value.Encode((*plain)(ev))
+		}
+		if err != nil {
+			return nil, err
+		}
+		out.Content = append(out.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+	return out, nil
+}
+
+func (ev *Event) isEmpty() bool {
+	return len(ev.Types) == 0 && len(ev.Branches) == 0 && len(ev.BranchesIgnore) == 0 &&
+		len(ev.Tags) == 0 && len(ev.TagsIgnore) == 0 && len(ev.Paths) == 0 &&
+		len(ev.PathsIgnore) == 0 && len(ev.Workflows) == 0 && len(ev.Inputs) == 0 &&
+		len(ev.Outputs) == 0 && len(ev.Secrets) == 0 && len(ev.Schedules) == 0
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// UsesKind distinguishes the forms a uses: reference can take.
+type UsesKind int
+
+const (
+	// UsesRepository is owner/repo[/path]@ref.
+	UsesRepository UsesKind = iota
+	// UsesLocal is ./path within the workspace.
+	UsesLocal
+	// UsesDocker is docker://image.
+	UsesDocker
+)
+
+// Uses is a parsed uses: reference from a step or a job.
+type Uses struct {
+	Kind  UsesKind
+	Owner string
+	Repo  string
+	// Path is the directory of the action within the repository, or the
+	// workflow file for reusable workflows. It is the full path for local
+	// references.
+	Path string
+	Ref  string
+	// Image is the image reference for docker:// references.
+	Image string
+}
+
+// ParseUses parses a uses: value.
+func ParseUses(s string) (*Uses, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("empty uses reference")
+	case strings.HasPrefix(s, "docker://"):
+		image := strings.TrimPrefix(s, "docker://")
+		if image == "" {
+			return nil, fmt.Errorf("invalid uses %q: missing image", s)
+		}
+		return &Uses{Kind: UsesDocker, Image: image}, nil
+	case strings.HasPrefix(s, "./") || strings.HasPrefix(s, "../"):
+		return &Uses{Kind: UsesLocal, Path: s}, nil
+	}
+	name, ref, ok := strings.Cut(s, "@")
+	if !ok || ref == "" {
+		return nil, fmt.Errorf("invalid uses %q: missing @ref", s)
+	}
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid uses %q: expected owner/repo@ref", s)
+	}
+	u := &Uses{Kind: UsesRepository, Owner: parts[0], Repo: parts[1], Ref: ref}
+	if len(parts) == 3 {
+		u.Path = parts[2]
+	}
+	return u, nil
+}
+
+// Repository returns owner/repo.
+func (u *Uses) Repository() string {
+	return u.Owner + "/" + u.Repo
+}
+
+// IsReusableWorkflow reports whether the reference points at a workflow file
+// rather than an action.
+func (u *Uses) IsReusableWorkflow() bool {
+	p := u.Path
+	if u.Kind == UsesLocal {
+		p = strings.TrimPrefix(p, "./")
+	}
+	ext := path.Ext(p)
+	return strings.HasPrefix(p, ".github/workflows/") && (ext == ".yml" || ext == ".yaml")
+}
+
+// String formats the reference as it would appear in a workflow.
+func (u *Uses) String() string {
+	switch u.Kind {
+	case UsesDocker:
+		return "docker://" + u.Image
+	case UsesLocal:
+		return u.Path
+	}
+	s := u.Repository()
+	if u.Path != "" {
+		s += "/" + u.Path
+	}
+	return s + "@" + u.Ref
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsExpression reports whether s is entirely a single ${{ }} expression.
+func IsExpression(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "${{") && strings.HasSuffix(s, "}}") && strings.Count(s, "${{") == 1
+}
+
+// StringList is a list of strings that may also be written as one scalar.
+type StringList []string
+
+// UnmarshalYAML accepts a scalar or a sequence of scalars.
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			*l = nil
+			return nil
+		}
+		*l = StringList{node.Value}
+		return nil
+	case yaml.SequenceNode:
+		var items []string
+		if err := node.Decode(&items); err != nil {
+			return err
+		}
+		*l = items
+		return nil
+	}
+	return fmt.Errorf("line %d: expected a string or a list of strings", node.Line)
+}
+
+// BoolExpr is a boolean that may instead be given as an expression.
+type BoolExpr struct {
+	Value      bool
+	Expression string
+}
+
+// UnmarshalYAML accepts true, false or an expression.
+func (b *BoolExpr) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: expected a boolean", node.Line)
+	}
+	if IsExpression(node.Value) {
+		b.Expression = node.Value
+		return nil
+	}
+	v, err := strconv.ParseBool(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: expected a boolean, got %q", node.Line, node.Value)
+	}
+	b.Value = v
+	return nil
+}
+
+// MarshalYAML emits the literal or the expression.
+func (b BoolExpr) MarshalYAML() (any, error) {
+	if b.Expression != "" {
+		return b.Expression, nil
+	}
+	return b.Value, nil
+}
+
+// NumberExpr is a number that may instead be given as an expression.
+type NumberExpr struct {
+	Value      float64
+	Expression string
+}
+
+// UnmarshalYAML accepts a number or an expression.
+func (n *NumberExpr) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: expected a number", node.Line)
+	}
+	if IsExpression(node.Value) {
+		n.Expression = node.Value
+		return nil
+	}
+	v, err := strconv.ParseFloat(node.Value, 64)
+	if err != nil {
+		return fmt.Errorf("line %d: expected a number, got %q", node.Line, node.Value)
+	}
+	n.Value = v
+	return nil
+}
+
+// MarshalYAML emits the literal or the expression.
+func (n NumberExpr) MarshalYAML() (any, error) {
+	if n.Expression != "" {
+		return n.Expression, nil
+	}
+	return n.Value, nil
+}
+
+// RunsOn is jobs.<id>.runs-on in any of its forms.
+type RunsOn struct {
+	Labels []string `yaml:"labels,omitempty"`
+	Group  string   `yaml:"group,omitempty"`
+}
+
+// UnmarshalYAML accepts a label, a list of labels or a group mapping.
+func (r *RunsOn) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode, yaml.SequenceNode:
+		var labels StringList
+		if err := node.Decode(&labels); err != nil {
+			return err
+		}
+		r.Labels = labels
+		return nil
+	case yaml.MappingNode:
+		var raw struct {
+			Group  string     `yaml:"group"`
+			Labels StringList `yaml:"labels"`
+		}
+		if err := node.Decode(&raw); err != nil {
+			return err
+		}
+		r.Group, r.Labels = raw.Group, raw.Labels
+		return nil
+	}
+	return fmt.Errorf("line %d: invalid runs-on", node.Line)
+}
+
+// MarshalYAML emits the most compact equivalent form.
+func (r RunsOn) MarshalYAML() (any, error) {
+	if r.Group != "" {
+		type plain RunsOn
+		return plain(r), nil
+	}
+	if len(r.Labels) == 1 {
+		return r.Labels[0], nil
+	}
+	return r.Labels, nil
+}
+
+// Permission levels accepted for each scope.
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+	PermissionNone  = "none"
+)
+
+// Permissions is the permissions key at workflow or job level.
+type Permissions struct {
+	// All is "read-all" or "write-all" when the shorthand is used.
+	All string
+	// Scopes maps scope names such as "contents" to a permission level.
+	Scopes map[string]string
+}
+
+// UnmarshalYAML accepts read-all, write-all, {} or a scope mapping.
+func (p *Permissions) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value != "read-all" && node.Value != "write-all" {
+			return fmt.Errorf("line %d: permissions must be read-all, write-all or a mapping", node.Line)
+		}
+		p.All = node.Value
+		return nil
+	case yaml.MappingNode:
+		p.Scopes = map[string]string{}
+		return node.Decode(&p.Scopes)
+	}
+	return fmt.Errorf("line %d: invalid permissions", node.Line)
+}
+
+// MarshalYAML emits the shorthand or the scope mapping.
+func (p Permissions) MarshalYAML() (any, error) {
+	if p.All != "" {
+		return p.All, nil
+	}
+	if p.Scopes == nil {
+		return map[string]string{}, nil
+	}
+	return p.Scopes, nil
+}
+
+// Level returns the effective level for scope.
+func (p *Permissions) Level(scope string) string {
+	switch p.All {
+	case "read-all":
+		return PermissionRead
+	case "write-all":
+		return PermissionWrite
+	}
+	if level, ok := p.Scopes[scope]; ok {
+		return level
+	}
+	return PermissionNone
+}
+
+// Concurrency is the concurrency key at workflow or job level.
+type Concurrency struct {
+	Group            string    `yaml:"group"`
+	CancelInProgress *BoolExpr `yaml:"cancel-in-progress,omitempty"`
+}
+
+// UnmarshalYAML accepts a group name or the full mapping.
+func (c *Concurrency) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Group = node.Value
+		return nil
+	}
+	type plain Concurrency
+	return node.Decode((*plain)(c))
+}
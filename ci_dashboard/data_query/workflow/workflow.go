@@ -0,0 +1,348 @@
+// Copyright 2025 Google LLC
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+
+//     https://www.apache.org/licenses/LICENSE-2.0
+
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package workflow parses GitHub Actions workflow files into typed Go structs.
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a 1-based line and column in the source file.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func positionOf(node *yaml.Node) Position {
+	return Position{Line: node.Line, Column: node.Column}
+}
+
+// Workflow is a parsed workflow file.
+type Workflow struct {
+	Name        string            `yaml:"name,omitempty"`
+	RunName     string            `yaml:"run-name,omitempty"`
+	On          Triggers          `yaml:"on"`
+	Permissions *Permissions      `yaml:"permissions,omitempty"`
+	Env         map[string]string `yaml:"env,omitempty"`
+	Defaults    *Defaults         `yaml:"defaults,omitempty"`
+	Concurrency *Concurrency      `yaml:"concurrency,omitempty"`
+	Jobs        map[string]*Job   `yaml:"jobs"`
+
+	// Path is the file the workflow was read from, if any.
+	Path string `yaml:"-"`
+	// Node is the root mapping node of the document, kept for tools that
+	// need source positions or keys not modeled by the structs.
+	Node *yaml.Node `yaml:"-"`
+}
+
+// Job is a single entry under the jobs key.
+type Job struct {
+	ID              string                `yaml:"-"`
+	Name            string                `yaml:"name,omitempty"`
+	Needs           StringList            `yaml:"needs,omitempty"`
+	If              string                `yaml:"if,omitempty"`
+	RunsOn          *RunsOn               `yaml:"runs-on,omitempty"`
+	Permissions     *Permissions          `yaml:"permissions,omitempty"`
+	Environment     *Environment          `yaml:"environment,omitempty"`
+	Concurrency     *Concurrency          `yaml:"concurrency,omitempty"`
+	Outputs         map[string]string     `yaml:"outputs,omitempty"`
+	Env             map[string]string     `yaml:"env,omitempty"`
+	Defaults        *Defaults             `yaml:"defaults,omitempty"`
+	TimeoutMinutes  *NumberExpr           `yaml:"timeout-minutes,omitempty"`
+	ContinueOnError *BoolExpr             `yaml:"continue-on-error,omitempty"`
+	Strategy        *Strategy             `yaml:"strategy,omitempty"`
+	Container       *Container            `yaml:"container,omitempty"`
+	Services        map[string]*Container `yaml:"services,omitempty"`
+	Steps           []*Step               `yaml:"steps,omitempty"`
+	// Persist is the x-persist extension, which only the local runner
+	// reads: workspace paths handed to the jobs that need this one.
+	Persist StringList `yaml:"x-persist,omitempty"`
+
+	// Uses, With and Secrets are set when the job calls a reusable workflow.
+	Uses    string         `yaml:"uses,omitempty"`
+	With    map[string]any `yaml:"with,omitempty"`
+	Secrets *JobSecrets    `yaml:"secrets,omitempty"`
+
+	Pos Position `yaml:"-"`
+}
+
+// Step is a single entry under jobs.<id>.steps.
+type Step struct {
+	ID               string            `yaml:"id,omitempty"`
+	If               string            `yaml:"if,omitempty"`
+	Name             string            `yaml:"name,omitempty"`
+	Uses             string            `yaml:"uses,omitempty"`
+	Run              string            `yaml:"run,omitempty"`
+	Shell            string            `yaml:"shell,omitempty"`
+	WorkingDirectory string            `yaml:"working-directory,omitempty"`
+	With             map[string]string `yaml:"with,omitempty"`
+	Env              map[string]string `yaml:"env,omitempty"`
+	ContinueOnError  *BoolExpr         `yaml:"continue-on-error,omitempty"`
+	TimeoutMinutes   *NumberExpr       `yaml:"timeout-minutes,omitempty"`
+	// Limits is the x-limits extension, which only the local runner reads.
+	// GitHub rejects workflows that use it.
+	Limits *StepLimits `yaml:"x-limits,omitempty"`
+	// Retry is the x-retry extension, which only the local runner reads.
+	Retry *StepRetry `yaml:"x-retry,omitempty"`
+
+	Pos Position `yaml:"-"`
+}
+
+// StepLimits caps the resources of a step. Zero fields are not limited.
+type StepLimits struct {
+	// CPUs is how many CPUs' worth of time the step may use, such as 0.5.
+	CPUs float64 `yaml:"cpus,omitempty"`
+	// Memory is a size such as 512M or 2G.
+	Memory string `yaml:"memory,omitempty"`
+	// PIDs caps how many processes and threads the step may run at once.
+	PIDs int64 `yaml:"pids,omitempty"`
+	// Network set to false cuts the step off from the network.
+	Network *bool `yaml:"network,omitempty"`
+}
+
+// StepRetry runs a failed step again. Without OnExitCodes or OnOutput
+// every failure is retried; with them, only failures they match.
+type StepRetry struct {
+	// MaxAttempts counts the first run.
+	MaxAttempts int `yaml:"max-attempts"`
+	// Backoff is the delay before the second attempt, such as 10s. It
+	// doubles with each attempt after that, up to MaxBackoff.
+	Backoff    string `yaml:"backoff,omitempty"`
+	MaxBackoff string `yaml:"max-backoff,omitempty"`
+	// OnExitCodes are the exit codes that are retried.
+	OnExitCodes []int `yaml:"on-exit-codes,omitempty"`
+	// OnOutput are regular expressions, one of which the step's output
+	// must match for it to be retried.
+	OnOutput []string `yaml:"on-output,omitempty"`
+}
+
+// UnmarshalYAML accepts a number of attempts as well as the mapping.
+func (r *StepRetry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&r.MaxAttempts)
+	}
+	type plain StepRetry
+	return node.Decode((*plain)(r))
+}
+
+// Defaults holds the defaults key at workflow or job level.
+type Defaults struct {
+	Run *RunDefaults `yaml:"run,omitempty"`
+}
+
+// RunDefaults holds defaults.run.
+type RunDefaults struct {
+	Shell            string `yaml:"shell,omitempty"`
+	WorkingDirectory string `yaml:"working-directory,omitempty"`
+}
+
+// Container describes jobs.<id>.container or an entry under services.
+type Container struct {
+	Image       string            `yaml:"image"`
+	Credentials *Credentials      `yaml:"credentials,omitempty"`
+	Env         map[string]string `yaml:"env,omitempty"`
+	Ports       []string          `yaml:"ports,omitempty"`
+	Volumes     []string          `yaml:"volumes,omitempty"`
+	Options     string            `yaml:"options,omitempty"`
+}
+
+// Credentials are registry credentials for a container image.
+type Credentials struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// Environment is a job's deployment environment.
+type Environment struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url,omitempty"`
+}
+
+// JobSecrets is the secrets key of a job calling a reusable workflow.
+type JobSecrets struct {
+	Inherit bool
+	Values  map[string]string
+}
+
+// Strategy is jobs.<id>.strategy.
+type Strategy struct {
+	Matrix      *Matrix     `yaml:"matrix,omitempty"`
+	FailFast    *BoolExpr   `yaml:"fail-fast,omitempty"`
+	MaxParallel *NumberExpr `yaml:"max-parallel,omitempty"`
+}
+
+// Parse parses a workflow document.
+func Parse(data []byte) (*Workflow, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, fmt.Errorf("failed to parse workflow: empty document")
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: workflow must be a mapping", root.Line)
+	}
+
+	var wf Workflow
+	if err := root.Decode(&wf); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	wf.Node = root
+	if err := wf.positionJobs(root); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// ParseFile reads and parses the workflow at path.
+func ParseFile(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	wf, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	wf.Path = path
+	return wf, nil
+}
+
+// positionJobs fills in the ID and source position of every job.
+func (wf *Workflow) positionJobs(root *yaml.Node) error {
+	jobs := MappingValue(root, "jobs")
+	if jobs == nil {
+		return nil
+	}
+	if jobs.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: jobs must be a mapping", jobs.Line)
+	}
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		key := jobs.Content[i]
+		if job, ok := wf.Jobs[key.Value]; ok && job != nil {
+			job.ID = key.Value
+			job.Pos = positionOf(key)
+		}
+	}
+	return nil
+}
+
+// JobIDs returns the job IDs in the order they appear in the file.
+func (wf *Workflow) JobIDs() []string {
+	ids := make([]string, 0, len(wf.Jobs))
+	for id := range wf.Jobs {
+		ids = append(ids, id)
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		pi, pj := wf.Jobs[ids[i]].Pos, wf.Jobs[ids[j]].Pos
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// UnmarshalYAML records the step's position.
+func (s *Step) UnmarshalYAML(node *yaml.Node) error {
+	type plain Step
+	if err := node.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	s.Pos = positionOf(node)
+	return nil
+}
+
+// UnmarshalYAML accepts both the short image-only form and the full mapping.
+func (c *Container) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Image = node.Value
+		return nil
+	}
+	type plain Container
+	return node.Decode((*plain)(c))
+}
+
+// UnmarshalYAML accepts both the name-only form and the full mapping.
+func (e *Environment) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Name = node.Value
+		return nil
+	}
+	type plain Environment
+	return node.Decode((*plain)(e))
+}
+
+// UnmarshalYAML accepts either "inherit" or a mapping of secret values.
+func (s *JobSecrets) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.Value != "inherit" {
+			return fmt.Errorf("line %d: secrets must be \"inherit\" or a mapping", node.Line)
+		}
+		s.Inherit = true
+		return nil
+	}
+	return node.Decode(&s.Values)
+}
+
+// MarshalYAML emits the inherit keyword or the mapping of values.
+func (s JobSecrets) MarshalYAML() (any, error) {
+	if s.Inherit {
+		return "inherit", nil
+	}
+	return s.Values, nil
+}
+
+// ScalarPosition returns the source position of the byte at offset within
+// the value of a scalar node. Columns are exact for single-line scalars and
+// approximate for block scalars.
+func ScalarPosition(node *yaml.Node, offset int) Position {
+	pos := positionOf(node)
+	before := node.Value[:min(offset, len(node.Value))]
+	switch {
+	case node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		pos.Line += 1 + strings.Count(before, "\n")
+		if i := strings.LastIndex(before, "\n"); i >= 0 {
+			before = before[i+1:]
+		}
+		pos.Column = len(before) + 1
+	case node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0:
+		pos.Column += 1 + len(before)
+	default:
+		pos.Column += len(before)
+	}
+	return pos
+}
+
+// MappingValue returns the value node for key in a mapping node, or nil.
+func MappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}